@@ -217,6 +217,8 @@ def main() -> bool:
         "--add-data",
         f"{project_root / 'orlando_toolkit' / 'config'};orlando_toolkit/config",  # Include config data
         "--add-data",
+        f"{project_root / 'orlando_toolkit' / 'ui' / 'locales'};orlando_toolkit/ui/locales",  # Include interface translations
+        "--add-data",
         f"{project_root / 'orlando_toolkit' / 'core' / 'preview' / 'templates'};orlando_toolkit/core/preview/templates",  # Include XSLT templates (package resources)
         "--hidden-import",
         "tkinter",
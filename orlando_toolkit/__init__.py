@@ -2,11 +2,47 @@
 
 This package hosts the refactored, GUI-agnostic implementation.  Front-ends
 (e.g. Tk GUI, CLI) should only depend on the public API exposed here rather
-than importing internal modules directly.
+than importing internal modules directly.  Programs embedding the toolkit use
+:func:`convert` and :class:`Result` (see :mod:`orlando_toolkit.api`) with the
+option functions of :mod:`orlando_toolkit.options`; these names are covered
+by the semantic versioning of ``API_VERSION``.
 """
 
 from .core.models import DitaContext  # re-export for convenience
 
 __all__: list[str] = [
     "DitaContext",
-] 
\ No newline at end of file
+    "API_VERSION",
+    "ConversionError",
+    "Package",
+    "Result",
+    "StructureError",
+    "Toolkit",
+    "convert",
+    "ConversionOptions",
+    "build_options",
+    "with_metadata",
+    "with_output_profile",
+    "with_pipeline",
+    "with_stage",
+    "with_style_map",
+    "with_style_map_file",
+    "with_title",
+    "with_topic_depth",
+]
+
+_API = {"API_VERSION", "ConversionError", "Package", "Result", "StructureError", "Toolkit", "convert"}
+_OPTIONS = set(__all__) - _API - {"DitaContext"}
+
+
+def __getattr__(name: str):
+    # Loaded on first use so importing the package stays cheap for the GUI.
+    if name in _API:
+        from . import api
+
+        return getattr(api, name)
+    if name in _OPTIONS:
+        from . import options
+
+        return getattr(options, name)
+    raise AttributeError(f"module {__name__!r} has no attribute {name!r}")
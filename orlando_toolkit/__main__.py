@@ -0,0 +1,7 @@
+"""Run the command-line interface: ``python -m orlando_toolkit``."""
+
+import sys
+
+from orlando_toolkit.cli import main
+
+sys.exit(main())
@@ -0,0 +1,369 @@
+from __future__ import annotations
+
+"""Embeddable library API.
+
+A small, stable surface for programs that use the toolkit without its GUI::
+
+    from orlando_toolkit import convert
+
+    package = convert("manual.docx", "stable", with_title("Operator Manual"))
+    print(package.report.summary())
+    package.rename_topic(package.topics()[0]["id"], "Safety")
+    if package.validate().ok:
+        package.write_archive("manual.zip")
+
+Options are the functions of :mod:`orlando_toolkit.options` (``with_title``,
+``with_style_map``, ``with_output_profile``, …); a metadata mapping, or an
+output profile name, is accepted in their place.
+
+:func:`convert` runs the same pipeline as the application (handler plugin,
+processing stages, report) and returns a :class:`Result`; packaging happens
+only when :meth:`Result.write_archive` is called. Failures raise
+:class:`ConversionError` with the original exception as ``cause`` and its
+``code``, ``location`` and remediation ``hint``; cancellation raises
+``OperationCancelledError`` unchanged. :meth:`Result.stats` returns word
+counts, readability, image and table counts and reuse per topic and map for
+dashboards.
+
+The structure can be edited before packaging as in the GUI:
+:meth:`Result.topics` lists the map entries, :meth:`Result.rename_topic`,
+:meth:`Result.move_topic`, :meth:`Result.merge_topics`,
+:meth:`Result.split_topic`, :meth:`Result.delete_topics` and
+:meth:`Result.limit_depth` change it, raising :class:`StructureError` when
+an edit is refused. :meth:`Result.validate` checks the topics and map against
+the DITA grammar (``validation`` in ``pipeline.yml``). ``Package`` is another
+name for :class:`Result`.
+
+Plugins are discovered from the user's plugin directory and the ones left
+active in the application are activated (``plugins=True``). Pass a list of
+plugin ids to activate exactly those, or ``plugins=False`` for DITA-only mode.
+A :class:`Toolkit` keeps that setup for several conversions; the module-level
+:func:`convert` uses a fresh one per call.
+
+Stability: the names in ``__all__`` here and re-exported by the package, with
+their documented parameters, attributes and return shapes, follow semantic
+versioning through :data:`API_VERSION`. Minor versions only add (new
+functions, keyword arguments, dictionary keys); anything removed or changed
+is deprecated with a ``DeprecationWarning`` for at least one minor version
+and goes away in the next major one. Objects reached through them that come
+from ``orlando_toolkit.core`` (``result.context``, report entries) may be
+read, but everything under ``orlando_toolkit.core`` remains internal and may
+change between releases.
+"""
+
+import logging
+from pathlib import Path
+from typing import Any, Callable, Dict, List, Optional, Sequence, Union
+
+from orlando_toolkit.core.cancellation import CancellationToken, OperationCancelledError
+from orlando_toolkit.core.errors import ErrorInfo, ToolkitError, describe_error
+from orlando_toolkit.core.models import ConversionReport, DitaContext
+from orlando_toolkit.core.time_budget import TimeBudget
+from orlando_toolkit.options import OptionLike, build_options
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "API_VERSION",
+    "ConversionError",
+    "OperationCancelledError",
+    "Package",
+    "Result",
+    "StructureError",
+    "Toolkit",
+    "convert",
+]
+
+#: Semantic version of this API (not of the application).
+API_VERSION = "1.3.0"
+
+PluginSelection = Union[bool, Sequence[str]]
+
+
+class ConversionError(ToolkitError, RuntimeError):
+    """Raised when a conversion or packaging step fails.
+
+    ``cause`` holds the underlying exception (for example
+    ``UnsupportedFormatError``, ``ArchiveLimitError`` or
+    ``ActiveContentRefusedError``); ``code``, ``hint`` and ``location`` are
+    taken from it, and :attr:`info` describes the error as a whole.
+    """
+
+    def __init__(self, message: str, cause: Optional[BaseException] = None) -> None:
+        described = describe_error(cause) if cause is not None else None
+        super().__init__(message, cause=cause,
+                         code=described.code if described else None,
+                         hint=described.hint if described else None,
+                         location=described.location if described else None)
+
+    @property
+    def info(self) -> ErrorInfo:
+        return describe_error(self)
+
+
+class StructureError(ToolkitError, ValueError):
+    """Raised when a structure edit of :class:`Result` is refused; :attr:`details` says why."""
+
+    def __init__(self, message: str, details: Optional[Dict[str, Any]] = None) -> None:
+        super().__init__(message)
+        self.details = dict(details or {})
+
+
+class Result:
+    """Outcome of :func:`convert`: the converted document and its report."""
+
+    def __init__(self, context: DitaContext, source: Path, service: Any) -> None:
+        self.context = context
+        self.source = source
+        self._service = service
+        self._prepared = False
+        self._editor: Any = None
+
+    @property
+    def report(self) -> ConversionReport:
+        return self.context.report
+
+    @property
+    def partial(self) -> bool:
+        """True when a time budget cut the conversion short."""
+        return self.report.partial
+
+    @property
+    def metadata(self) -> Dict[str, Any]:
+        return self.context.metadata
+
+    def stats(self) -> Dict[str, Any]:
+        """Content statistics of the current structure, JSON-ready.
+
+        ``{"map": {...}, "topics": [...]}`` with word counts, readability
+        score, image and table counts and reuse percentage per topic and for
+        the whole map (see :mod:`orlando_toolkit.core.content_stats`).
+        """
+        from orlando_toolkit.core.content_stats import compute_content_stats
+
+        return compute_content_stats(self.context).to_dict()
+
+    def style_usage(self) -> List[Dict[str, Any]]:
+        """Source styles of the current structure, JSON-ready.
+
+        One entry per style with its kind, occurrence count, the DITA elements
+        it became and whether it is ``unmapped`` (see
+        :mod:`orlando_toolkit.core.style_usage`).
+        """
+        from orlando_toolkit.core.style_usage import compute_style_usage
+
+        return [usage.to_dict() for usage in compute_style_usage(self.context)]
+
+    # -- structure -------------------------------------------------------
+    def topics(self) -> List[Dict[str, Any]]:
+        """Map entries in document order, JSON-ready.
+
+        ``{"id", "title", "type", "level"}`` per topic; ``id`` is the topic
+        file name the editing methods take, ``level`` is 1 for top-level
+        entries. Section headings without a topic are not listed.
+        """
+        root = self.context.ditamap_root
+        if root is None:
+            return []
+        entries = []
+        for tref in root.iter("topicref"):
+            href = tref.get("href") or ""
+            if not href:
+                continue
+            name = href.split("/")[-1]
+            topic = self.context.topics.get(name)
+            level = sum(1 for a in tref.iterancestors() if a.tag in ("topicref", "topichead")) + 1
+            title = topic.findtext("title") if topic is not None else tref.findtext("topicmeta/navtitle")
+            entries.append({"id": name, "title": " ".join((title or "").split()),
+                            "type": topic.tag if topic is not None else None, "level": level})
+        return entries
+
+    def _edit(self, operation: str, *args: Any) -> Dict[str, Any]:
+        from orlando_toolkit.core.services.structure_editing_service import StructureEditingService
+
+        if self._editor is None:
+            self._editor = StructureEditingService()
+        outcome = getattr(self._editor, operation)(self.context, *args)
+        if not outcome.success:
+            raise StructureError(outcome.message, outcome.details)
+        return dict(outcome.details or {})
+
+    def rename_topic(self, topic_id: str, title: str) -> None:
+        self._edit("rename_topic", topic_id, title)
+
+    def rename_topics(self, template: str, topic_ids: Optional[Sequence[str]] = None, *, match: str = "",
+                      start: int = 1, step: int = 1, pad: int = 0) -> Dict[str, str]:
+        """Rename topics from a template (all topics when *topic_ids* is ``None``); returns id -> new title.
+
+        Placeholders are those of :mod:`orlando_toolkit.core.batch_rename`;
+        an invalid template raises ``ValueError``.
+        """
+        from orlando_toolkit.core.batch_rename import RenamePattern, propose_renames
+
+        pattern = RenamePattern(template=template, match=match, start=start, step=step, pad=pad)
+        error = pattern.validate()
+        if error:
+            raise ValueError(error)
+        ids = [t["id"] for t in self.topics()] if topic_ids is None else list(topic_ids)
+        renames = {p.filename: p.new for p in propose_renames(self.context, ids, pattern) if p.changed}
+        if renames:
+            self._edit("rename_topics", renames)
+        return renames
+
+    def move_topic(self, topic_id: str, direction: str) -> None:
+        """Move a topic one place ``"up"`` or ``"down"`` among its siblings."""
+        if direction not in ("up", "down"):
+            raise ValueError(f"direction must be 'up' or 'down', not {direction!r}")
+        self._edit("move_topic", topic_id, direction)
+
+    def merge_topics(self, topic_ids: Sequence[str], into: str) -> None:
+        """Append the content of *topic_ids* to *into* and remove them from the map."""
+        self._edit("merge_topics", list(topic_ids), into)
+
+    def split_topic(self, topic_id: str, point: int) -> str:
+        """Split a topic at its *point*-th inner heading; returns the new topic's id."""
+        return self._edit("split_topic", topic_id, point)["created"]
+
+    def delete_topics(self, topic_ids: Sequence[str]) -> int:
+        """Remove topics from the map; returns how many were removed."""
+        return self._edit("delete_topics", list(topic_ids))["deleted"]
+
+    def limit_depth(self, depth: int) -> None:
+        """Merge topics deeper than *depth* into their parents (reversible by a larger depth)."""
+        self._edit("apply_depth_limit", depth)
+
+    # -- validation and packaging ------------------------------------------
+    def validate(self) -> Any:
+        """Check the topics and map as they would be packaged.
+
+        Returns a report with ``ok``, ``grammar`` and ``issues`` (message,
+        topic, line, severity); see ``validation`` in ``pipeline.yml``.
+        """
+        from orlando_toolkit.core.services.validation_service import ValidationService
+
+        return ValidationService().validate(self.context, strip_hints=True)
+
+    def write_archive(self, path: str | Path, *, debug_copy_dir: Optional[str | Path] = None,
+                      cancel_token: Optional[CancellationToken] = None,
+                      progress: Optional[Callable[[str], None]] = None) -> Path:
+        """Package the document and write it as a DITA ZIP archive to *path*.
+
+        Missing parent directories are created. Topic and image renaming is
+        applied once, on the first call. *progress* receives the packaging
+        progress events (:mod:`orlando_toolkit.core.progress`).
+        """
+        path = Path(path)
+        try:
+            path.parent.mkdir(parents=True, exist_ok=True)
+            if not self._prepared:
+                self.context = self._service.prepare_package(self.context, cancel_token=cancel_token,
+                                                             progress_callback=progress)
+                self._prepared = True
+            self._service.write_package(self.context, path, debug_copy_dir=debug_copy_dir,
+                                        cancel_token=cancel_token, progress_callback=progress)
+        except OperationCancelledError:
+            raise
+        except Exception as exc:
+            raise ConversionError(f"Could not write {path.name}: {exc}", exc) from exc
+        return path
+
+    def write_tree(self, directory: str | Path, *, force: bool = False, prune: bool = True,
+                   cancel_token: Optional[CancellationToken] = None) -> Any:
+        """Write the package as a ``maps/``, ``topics/``, ``media/`` folder tree for git.
+
+        Files an earlier tree had but this package lacks are removed unless
+        *prune* is false; a non-empty folder not written by the toolkit needs
+        *force*. Returns the written, unchanged and removed file lists.
+        """
+        try:
+            if not self._prepared:
+                self.context = self._service.prepare_package(self.context, cancel_token=cancel_token)
+                self._prepared = True
+            return self._service.write_package_tree(self.context, directory, force=force, prune=prune,
+                                                    cancel_token=cancel_token)
+        except OperationCancelledError:
+            raise
+        except Exception as exc:
+            raise ConversionError(f"Could not write {Path(directory).name}: {exc}", exc) from exc
+
+
+class Toolkit:
+    """Headless toolkit session: plugin system and conversion service."""
+
+    def __init__(self, *, plugins: PluginSelection = True, dev_mode: bool = False) -> None:
+        from orlando_toolkit.core.services.conversion_service import ConversionService
+
+        self.plugin_manager = None
+        self.app_context = None
+        if plugins is False:
+            self.service = ConversionService()
+            return
+
+        from orlando_toolkit.core.context import AppContext, get_app_context, set_app_context
+        from orlando_toolkit.core.hooks import discover_hooks
+        from orlando_toolkit.core.id_strategies import discover_id_strategies
+        from orlando_toolkit.core.plugins.loader import PluginLoader
+        from orlando_toolkit.core.plugins.manager import PluginManager
+        from orlando_toolkit.core.plugins.registry import ServiceRegistry
+
+        registry = ServiceRegistry()
+        loader = PluginLoader(registry, dev_mode=dev_mode)
+        self.plugin_manager = PluginManager(loader)
+        self.app_context = AppContext(service_registry=registry, plugin_manager=self.plugin_manager)
+        loader.app_context = self.app_context
+        # An embedding application may already own the global context; keep it.
+        if get_app_context() is None:
+            set_app_context(self.app_context)
+        self.service = ConversionService(service_registry=registry)
+        self.app_context.update_services(conversion_service=self.service)
+
+        loader.discover_plugins()
+        if plugins is True:
+            self.plugin_manager.restore_plugin_states()
+        else:
+            for plugin_id in plugins:
+                if not loader.activate_plugin(plugin_id):
+                    logger.warning("Plugin %s could not be activated", plugin_id)
+        discover_hooks(registry)
+        discover_id_strategies(registry)
+
+    @property
+    def active_plugins(self) -> List[str]:
+        return self.plugin_manager.get_active_plugin_ids() if self.plugin_manager else []
+
+    def supported_extensions(self) -> List[str]:
+        return self.service.get_supported_extensions()
+
+    def convert(self, source: str | Path, *options: OptionLike,
+                progress: Optional[Callable[[str], None]] = None,
+                cancel_token: Optional[CancellationToken] = None,
+                time_budget: Optional[TimeBudget] = None) -> Result:
+        """Convert *source* and return a :class:`Result`.
+
+        *options* are applied in order (see :func:`build_options`); invalid
+        options raise ``ValueError`` before any work starts.
+        """
+        source = Path(source)
+        metadata = build_options(*options).to_metadata()
+        try:
+            context = self.service.convert(source, metadata, progress,
+                                           cancel_token=cancel_token, time_budget=time_budget)
+        except OperationCancelledError:
+            raise
+        except Exception as exc:
+            raise ConversionError(f"Could not convert {source.name}: {exc}", exc) from exc
+        return Result(context, source, self.service)
+
+
+#: The document returned by :func:`convert`, ready to edit, validate and package.
+Package = Result
+
+
+def convert(source: str | Path, *options: OptionLike,
+            plugins: PluginSelection = True, progress: Optional[Callable[[str], None]] = None,
+            cancel_token: Optional[CancellationToken] = None,
+            time_budget: Optional[TimeBudget] = None) -> Result:
+    """Convert *source* in a new :class:`Toolkit`; see the module docstring."""
+    toolkit = Toolkit(plugins=plugins)
+    return toolkit.convert(source, *options, progress=progress, cancel_token=cancel_token,
+                           time_budget=time_budget)
@@ -19,17 +19,36 @@ from urllib.error import URLError, HTTPError
 from copy import deepcopy
 
 import tkinter as tk
-from tkinter import ttk, filedialog, messagebox
+from tkinter import ttk, filedialog, messagebox, simpledialog
 import subprocess
 import sys
 
 from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.cancellation import CancellationToken, OperationCancelledError
+from orlando_toolkit.core.concurrency import PipelineSettings
+from orlando_toolkit.core.errors import describe_error
+from orlando_toolkit.core.history import get_history_store
+from orlando_toolkit.core.hooks import discover_hooks
+from orlando_toolkit.core.id_strategies import discover_id_strategies
+from orlando_toolkit.core.jobs import pending_workspaces
+from orlando_toolkit.core.progress import ProgressEvent, overall_fraction
+from orlando_toolkit.core.processing import resolve_conversion_options
+from orlando_toolkit.core.toc_check import check_toc
+from orlando_toolkit.core.templates import (
+    NO_TEMPLATE_PROFILE,
+    apply_template_profile,
+    detect_template,
+    match_template,
+    record_template_match,
+)
+from orlando_toolkit.core.project import PROJECT_EXTENSION, Project, load_project, save_project
 from orlando_toolkit.core.models.ui_config import (
     SplashLayoutConfig, ButtonConfig, SplashButtonConfig, IconConfig, 
     DEFAULT_SPLASH_LAYOUT, DEFAULT_ICONS
 )
 from orlando_toolkit.core.context import AppContext, set_app_context
 from orlando_toolkit.core.services import ConversionService, StructureEditingService, UndoService, PreviewService, ProgressService
+from orlando_toolkit.core.services.validation_service import PackageValidationError
 from orlando_toolkit.core.plugins.manager import PluginManager
 from orlando_toolkit.core.plugins.loader import PluginLoader
 from orlando_toolkit.core.plugins.registry import ServiceRegistry
@@ -40,18 +59,37 @@ from orlando_toolkit.ui.widgets.loading_spinner import LoadingSpinner
 from orlando_toolkit.ui.widgets.metadata_form import MetadataForm
 from orlando_toolkit.version import get_app_version
 from orlando_toolkit.ui.dialogs.about_dialog import show_about_dialog
+from orlando_toolkit.ui.dialogs.publish_dialog import PublishDialog
+from orlando_toolkit.ui.dialogs.reuse_dialog import ReuseDialog
+from orlando_toolkit.ui.dialogs.safety_notice_dialog import SafetyNoticeDialog
+from orlando_toolkit.ui.dialogs.style_usage_dialog import StyleUsagePanel
+from orlando_toolkit.ui.dialogs.find_replace_dialog import FindReplaceDialog
+from orlando_toolkit.ui.dialogs.validation_dialog import ValidationPanel
+from orlando_toolkit.ui.dialogs.diagnostics_dialog import ConversionLogPanel
+from orlando_toolkit.ui.dialogs.integrity_dialog import IntegrityPanel
+from orlando_toolkit.ui.dialogs.accessibility_dialog import AccessibilityPanel
+from orlando_toolkit.ui.dialogs.terminology_dialog import TerminologyPanel
+from orlando_toolkit.ui.dialogs.spelling_dialog import SpellingPanel
+from orlando_toolkit.ui.i18n import available_languages, current_language, language_name, set_language, tr
 
 logger = logging.getLogger(__name__)
 
 __all__ = ["OrlandoToolkit"]
 
 
+def _automatic_profile() -> str:
+    """Home screen profile choice applying no saved profile (template presets still apply)."""
+    return tr("Automatic")
+
+
 class OrlandoToolkit:
     """Main application widget wrapping all Tkinter UI components."""
 
     def __init__(self, root: tk.Tk):
         self.root = root
         self.dita_context: Optional[DitaContext] = None
+        # Project file the current session was opened from or last saved to
+        self.project_path: Optional[Path] = None
         
         # Store reference to this app instance in the root window for plugin dialog access
         self.root._orlando_toolkit_app = self
@@ -84,6 +122,8 @@ class OrlandoToolkit:
         
         # Plugin progress callback
         self._current_progress_callback = None
+        # Cancellation token for the background operation in flight (if any)
+        self._cancel_token: Optional[CancellationToken] = None
         
         # Update plugin loader to use the shared app_context
         self.plugin_loader.app_context = self.app_context
@@ -128,6 +168,9 @@ class OrlandoToolkit:
         self.version_area: Optional[ttk.Frame] = None
         self.update_button: Optional[ttk.Button] = None
         self._update_available_version: Optional[str] = None
+        # Conversion profile applied to the next document conversions
+        self.conversion_profile_var = tk.StringVar(value=_automatic_profile())
+        self.profile_combo: Optional[ttk.Combobox] = None
 
         # Initialize plugin system and load any previously activated plugins
         self._initialize_plugin_system()
@@ -144,6 +187,10 @@ class OrlandoToolkit:
             # Restore plugin activation states from previous session
             installed_plugins = self.plugin_manager.get_installed_plugins()
             self.plugin_manager.restore_plugin_states()
+
+            # Conversion hooks and id strategies published by installed packages (entry points)
+            discover_hooks(self.service_registry)
+            discover_id_strategies(self.service_registry)
                     
             logger.info("Plugin system initialized with %d available plugins", len(installed_plugins))
             
@@ -183,7 +230,7 @@ class OrlandoToolkit:
         
         ttk.Label(title_frame, text="Orlando Toolkit", 
                  font=("Segoe UI", 26, "bold"), foreground="#202124").pack()
-        ttk.Label(title_frame, text="DITA Reader and Structure Editor", 
+        ttk.Label(title_frame, text=tr("DITA Reader and Structure Editor"), 
                  font=("Segoe UI", 13), foreground="#5f6368").pack(pady=(8, 0))
         
         # Container for buttons that will be replaced during loading
@@ -196,6 +243,9 @@ class OrlandoToolkit:
         # Plugin buttons underneath - like Google's suggestion buttons
         self.create_plugin_buttons_google_style()
 
+        # Conversion profile applied to the next conversions
+        self.create_conversion_profile_selector()
+
         # Status and utility elements at bottom
         self.create_status_elements()
         
@@ -208,6 +258,11 @@ class OrlandoToolkit:
         # Auto-check for updates on splash load
         self.schedule_auto_update_check()
 
+        # Packagings interrupted by a crash can be finished from their workspace
+        if not getattr(self, "_resume_offered", False):
+            self._resume_offered = True
+            self.root.after(1200, self.offer_resume_packaging)
+
     def create_management_button_top_right(self) -> None:
         """Create plugin management button in top-right corner."""
         # Create custom style for management button - subtle but clickable
@@ -237,7 +292,7 @@ class OrlandoToolkit:
         mgmt_button.bind("<Return>", lambda e: self.show_plugin_management())
         mgmt_button.bind("<space>", lambda e: self.show_plugin_management())
         
-        self._add_tooltip(mgmt_button, "Manage Plugins")
+        self._add_tooltip(mgmt_button, tr("Manage Plugins"))
 
     def create_logo(self) -> None:
         """Create and display the application logo."""
@@ -269,13 +324,32 @@ class OrlandoToolkit:
         # Main button using the app's accent style for proper blue appearance
         main_button = ttk.Button(
             main_button_frame,
-            text="Open DITA Project",
+            text=tr("Open DITA Project"),
             command=self.open_dita_project,
             style="Accent.TButton"
         )
         main_button.pack(ipadx=20, ipady=15)  # Add padding for prominent appearance
         
-        self._add_tooltip(main_button, "Open existing DITA project archive")
+        self._add_tooltip(main_button, tr("Open an existing DITA package, map or saved project"))
+        self.create_recent_projects_links(main_button_frame)
+
+    def create_recent_projects_links(self, parent: ttk.Frame) -> None:
+        """List recently saved or opened project files under the main button."""
+        try:
+            recent = get_history_store().recent_projects(limit=3)
+        except Exception as exc:
+            logger.debug("Recent projects unavailable: %s", exc)
+            return
+        if not recent:
+            return
+        recent_frame = ttk.Frame(parent)
+        recent_frame.pack(pady=(10, 0))
+        ttk.Label(recent_frame, text=tr("Recent:"), foreground="#5f6368").pack(side="left", padx=(0, 6))
+        for project_file in recent:
+            link = ttk.Label(recent_frame, text=Path(project_file).stem, foreground="#1a73e8", cursor="hand2")
+            link.pack(side="left", padx=4)
+            link.bind("<Button-1>", lambda _e, f=project_file: self.open_project_file(f))
+            self._add_tooltip(link, project_file)
 
     def create_plugin_buttons_google_style(self) -> None:
         """Create plugin buttons underneath main button - Google suggestions style."""
@@ -337,7 +411,8 @@ class OrlandoToolkit:
                     # Add tooltip with proper fallback text
                     tooltip_text = plugin_config.get('tooltip', 
                                                    plugin_config.get('description', 
-                                                                   f'Import using {plugin_config.get("display_name", plugin_id)}'))
+                                                                   tr("Import using {name}",
+                                                                      name=plugin_config.get("display_name", plugin_id))))
                     self._add_tooltip(plugin_button, tooltip_text)
                     
                     col += 1
@@ -349,9 +424,109 @@ class OrlandoToolkit:
                     logger.warning("Failed to create plugin button for %s: %s", plugin_config, e)
                     continue
                     
+            combine_link = ttk.Label(plugin_frame, text=tr("Combine a folder of documents…"), foreground="#1a73e8",
+                                     cursor="hand2")
+            combine_link.pack(pady=(6, 0))
+            combine_link.bind("<Button-1>", lambda _e: self.combine_folder())
+            self._add_tooltip(combine_link, tr("Convert every document of a folder into one map, one chapter each"))
+
         except Exception as e:
             logger.error("Failed to create plugin buttons: %s", e)
 
+    @staticmethod
+    def _conversion_profile_names() -> list[str]:
+        try:
+            from orlando_toolkit.core.profiles import available_profiles
+
+            return [_automatic_profile()] + sorted(available_profiles())
+        except Exception as exc:
+            logger.debug("Conversion profiles unavailable: %s", exc)
+            return [_automatic_profile()]
+
+    def create_conversion_profile_selector(self) -> None:
+        """Combobox of the conversion profiles, with links to import and export profile files."""
+        names = self._conversion_profile_names()
+        if self.conversion_profile_var.get() not in names:
+            self.conversion_profile_var.set(_automatic_profile())
+
+        profile_frame = ttk.Frame(self.buttons_container)
+        profile_frame.pack(pady=(12, 0))
+        ttk.Label(profile_frame, text=tr("Conversion profile:"), foreground="#5f6368").pack(side="left", padx=(0, 6))
+        self.profile_combo = ttk.Combobox(profile_frame, textvariable=self.conversion_profile_var,
+                                          state="readonly", width=22, values=names)
+        self.profile_combo.pack(side="left")
+        self._add_tooltip(self.profile_combo,
+                          tr("Depth, style maps, image and metadata defaults applied to the next conversions "
+                             "({automatic}: presets matching the document's template)",
+                             automatic=_automatic_profile()))
+        for text, command in ((tr("Import…"), self.import_conversion_profile),
+                              (tr("Export…"), self.export_conversion_profile)):
+            link = ttk.Label(profile_frame, text=text, foreground="#1a73e8", cursor="hand2")
+            link.pack(side="left", padx=(8, 0))
+            link.bind("<Button-1>", lambda _e, c=command: c())
+
+    def import_conversion_profile(self) -> None:
+        from orlando_toolkit.core.profiles import import_profile
+
+        path = filedialog.askopenfilename(title=tr("Import conversion profile"),
+                                          filetypes=((tr("Profile files"), "*.yml *.yaml *.json"),
+                                                     (tr("All files"), "*.*")))
+        if not path:
+            return
+        try:
+            try:
+                name = import_profile(path)
+            except FileExistsError as exc:
+                if not messagebox.askyesno(tr("Import Profile"), tr("{problem}. Replace it?", problem=exc)):
+                    return
+                name = import_profile(path, overwrite=True)
+        except (OSError, ValueError) as exc:
+            messagebox.showerror(tr("Import Profile"), describe_error(exc).format())
+            return
+        if self.profile_combo is not None and self.profile_combo.winfo_exists():
+            self.profile_combo.configure(values=self._conversion_profile_names())
+        self.conversion_profile_var.set(name)
+        messagebox.showinfo(tr("Import Profile"), tr("Profile {name} imported and selected.", name=name))
+
+    def export_conversion_profile(self) -> None:
+        from orlando_toolkit.core.profiles import export_profile
+
+        name = self.conversion_profile_var.get()
+        if name == _automatic_profile():
+            messagebox.showinfo(tr("Export Profile"), tr("Select the conversion profile to export first."))
+            return
+        path = filedialog.asksaveasfilename(title=tr("Export conversion profile"), defaultextension=".yml",
+                                            initialfile=f"{name}.yml",
+                                            filetypes=(("YAML", "*.yml"), ("JSON", "*.json")))
+        if not path:
+            return
+        try:
+            written = export_profile(name, path)
+        except (OSError, ValueError) as exc:
+            messagebox.showerror(tr("Export Profile"), describe_error(exc).format())
+            return
+        messagebox.showinfo(tr("Export Profile"), tr("Profile {name} written to\n{path}", name=name, path=written))
+
+    def _with_conversion_profile(self, filepath: Optional[str], metadata: dict) -> Optional[dict]:
+        """*metadata* over the selected conversion profile; ``None`` when it cannot be loaded.
+
+        With no profile selected, the template presets of *filepath* apply as before.
+        """
+        name = self.conversion_profile_var.get()
+        if name == _automatic_profile():
+            template_profile = self._choose_template_profile(filepath) if filepath else None
+            if template_profile:
+                metadata["template_profile"] = template_profile
+            return metadata
+        from orlando_toolkit.options import build_options, with_options, with_output_profile
+
+        try:
+            return build_options(with_output_profile(name), with_options(metadata)).to_metadata()
+        except (OSError, ValueError) as exc:
+            messagebox.showerror(tr("Conversion Profile"), tr("Profile {name} cannot be used:\n\n{problem}",
+                                                              name=name, problem=exc))
+            return None
+
     def create_status_elements(self) -> None:
         """Create status label and progress bar."""
         # Status label for feedback
@@ -366,19 +541,45 @@ class OrlandoToolkit:
         # LoadingSpinner will be created on-demand
 
     def create_utility_links(self) -> None:
-        """Create About and Update links on the splash screen."""
+        """Create About and Update links and the interface language selector on the splash screen."""
         try:
             # Container anchored bottom-left
             util_frame = ttk.Frame(self.home_frame)
             util_frame.place(relx=0.0, rely=1.0, x=8, y=-6, anchor="sw")
 
             # About link
-            about_link = ttk.Label(util_frame, text="About", cursor="hand2", foreground="#888888")
+            about_link = ttk.Label(util_frame, text=tr("About"), cursor="hand2", foreground="#888888")
             about_link.pack(side="left")
             about_link.bind("<Button-1>", lambda e: show_about_dialog(self.root))
+
+            # Interface language
+            languages = available_languages()
+            if len(languages) > 1:
+                names = [language_name(code) for code in languages]
+                ttk.Label(util_frame, text=tr("Language:"), foreground="#888888").pack(side="left", padx=(16, 4))
+                language_combo = ttk.Combobox(util_frame, values=names, state="readonly", width=12)
+                language_combo.set(language_name(current_language()))
+                language_combo.pack(side="left")
+                language_combo.bind("<<ComboboxSelected>>",
+                                    lambda _e: self.change_language(languages[language_combo.current()]))
         except Exception:
             pass
 
+    def change_language(self, language: str) -> None:
+        """Show the interface in *language* from now on and rebuild the home screen in it."""
+        if language == current_language():
+            return
+        set_language(language)
+        try:
+            from orlando_toolkit.config import ConfigManager
+
+            ConfigManager().update_ui_config({"language": language})
+        except Exception as exc:
+            logger.warning("Could not save the interface language: %s", exc)
+        if self.home_frame:
+            self.home_frame.destroy()
+        self.create_home_screen()
+
     # ------------------------------------------------------------------
     # Updater integration (leverages external installer logic)
     # ------------------------------------------------------------------
@@ -393,7 +594,7 @@ class OrlandoToolkit:
         """
         try:
             # Show spinner and disable UI
-            self._show_loading_spinner(title="Checking for updates", subtitle="Contacting server…")
+            self._show_loading_spinner(title=tr("Checking for updates"), subtitle=tr("Contacting server…"))
             self._disable_all_ui_elements()
 
             def worker():
@@ -410,7 +611,7 @@ class OrlandoToolkit:
                     self.root.after(0, lambda: [
                         self._hide_loading_spinner(),
                         self._enable_all_ui_elements(),
-                        messagebox.showinfo("Update", "Could not determine latest version right now."),
+                        messagebox.showinfo(tr("Update"), tr("Could not determine latest version right now.")),
                     ])
                     return
 
@@ -418,7 +619,7 @@ class OrlandoToolkit:
                     self.root.after(0, lambda: [
                         self._hide_loading_spinner(),
                         self._enable_all_ui_elements(),
-                        messagebox.showinfo("Update", f"You are up to date (v{current})."),
+                        messagebox.showinfo(tr("Update"), tr("You are up to date (v{version}).", version=current)),
                     ])
                     return
 
@@ -427,8 +628,9 @@ class OrlandoToolkit:
                     self._hide_loading_spinner()
                     self._enable_all_ui_elements()
                     proceed = messagebox.askyesno(
-                        "Update Available",
-                        f"A new version is available (v{latest}).\n\nUpdate now? The app will close during the update.",
+                        tr("Update Available"),
+                        tr("A new version is available (v{version}).\n\nUpdate now? The app will close during the "
+                           "update.", version=latest),
                     )
                     if not proceed:
                         return
@@ -436,16 +638,16 @@ class OrlandoToolkit:
                     launched = self._launch_silent_updater()
                     if launched:
                         messagebox.showinfo(
-                            "Updating",
-                            "The updater is running. The application will now close.\n\n"
-                            "After the update completes, launch Orlando Toolkit from the desktop shortcut.",
+                            tr("Updating"),
+                            tr("The updater is running. The application will now close.\n\n"
+                               "After the update completes, launch Orlando Toolkit from the desktop shortcut."),
                         )
                         try:
                             self.root.after(200, self.root.destroy)
                         except Exception:
                             os._exit(0)
                     else:
-                        messagebox.showerror("Update", "Failed to start updater.")
+                        messagebox.showerror(tr("Update"), tr("Failed to start updater."))
 
                 self.root.after(0, prompt_and_update)
 
@@ -457,7 +659,7 @@ class OrlandoToolkit:
                 self._enable_all_ui_elements()
             except Exception:
                 pass
-            messagebox.showerror("Update", f"Update check failed:\n\n{e}")
+            messagebox.showerror(tr("Update"), tr("Update check failed:\n\n{problem}", problem=e))
 
     def _get_latest_version(self) -> Optional[str]:
         """Fetch latest version (X.Y.Z) from GitHub releases without API token."""
@@ -549,9 +751,10 @@ class OrlandoToolkit:
                         self._update_available_version = latest
                         if self.update_button and self.update_button.winfo_exists():
                             try:
-                                self.update_button.configure(text=f"Update available: v{latest}", style="Accent.TButton")
+                                self.update_button.configure(text=tr("Update available: v{version}", version=latest),
+                                                             style="Accent.TButton")
                             except Exception:
-                                self.update_button.configure(text=f"Update available: v{latest}")
+                                self.update_button.configure(text=tr("Update available: v{version}", version=latest))
                     self.root.after(0, apply_ui)
                 threading.Thread(target=worker, daemon=True).start()
             # Run shortly after splash is visible
@@ -563,6 +766,8 @@ class OrlandoToolkit:
         """Create progress callback that updates LoadingSpinner when visible, status_label otherwise."""
         def smart_progress_callback(message: str) -> None:
             """Update either LoadingSpinner subtitle or status label based on visibility."""
+            if isinstance(message, ProgressEvent):
+                message = f"{message} ({overall_fraction(message):.0%})"
             try:
                 # If LoadingSpinner is visible, update its subtitle
                 if hasattr(self, 'loading_spinner') and self.loading_spinner and self.loading_spinner.is_visible():
@@ -574,8 +779,14 @@ class OrlandoToolkit:
                 pass
         return smart_progress_callback
 
-    def _show_loading_spinner(self, title: str = "Loading", subtitle: str = "Please wait...") -> None:
-        """Show loading spinner with custom message, replacing buttons but keeping logo/title."""
+    def _show_loading_spinner(self, title: Optional[str] = None, subtitle: Optional[str] = None,
+                              cancel_token: Optional[CancellationToken] = None) -> None:
+        """Show loading spinner with custom message, replacing buttons but keeping logo/title.
+
+        When *cancel_token* is given, the spinner offers a Cancel button bound to it.
+        """
+        title = tr("Loading") if title is None else title
+        subtitle = tr("Please wait...") if subtitle is None else subtitle
         try:
             # Hide the buttons container
             if hasattr(self, 'buttons_container') and self.buttons_container:
@@ -608,9 +819,37 @@ class OrlandoToolkit:
             # Update and show
             self.loading_spinner.update_message(title, subtitle)
             self.loading_spinner.show()
+            self.loading_spinner.set_cancel_callback(
+                (lambda: self._request_cancel(cancel_token)) if cancel_token is not None else None
+            )
+        except Exception:
+            pass
+
+    def _begin_cancellable_operation(self) -> CancellationToken:
+        """Create and remember a fresh token for the next background operation."""
+        self._cancel_token = CancellationToken()
+        return self._cancel_token
+
+    def _request_cancel(self, token: CancellationToken) -> None:
+        """Cancel *token* and reflect the pending stop in the spinner."""
+        token.cancel("Cancelled by user")
+        try:
+            if self.loading_spinner and self.loading_spinner.is_visible():
+                self.loading_spinner.update_subtitle_only(tr("Cancelling…"))
         except Exception:
             pass
 
+    def on_operation_cancelled(self) -> None:
+        """Restore the idle UI after a background operation stopped on request."""
+        self._cancel_token = None
+        self._hide_loading_spinner()
+        if self.status_label:
+            try:
+                self.status_label.config(text=tr("Operation cancelled."))
+            except Exception:
+                pass
+        self._enable_all_ui_elements()
+
     def _hide_loading_spinner(self) -> None:
         """Hide loading spinner and restore buttons."""
         try:
@@ -649,7 +888,7 @@ class OrlandoToolkit:
 
                 # Update button (will be restyled/text-updated on auto-check)
                 self.update_button = ttk.Button(self.version_area,
-                                                text="Check for updates",
+                                                text=tr("Check for updates"),
                                                 command=self.check_for_app_update,
                                                 style="TButton")
                 self.update_button.pack(side="left")
@@ -676,6 +915,9 @@ class OrlandoToolkit:
                 # Skip labels with images (like logo) - they should remain visual-only
                 if isinstance(widget, ttk.Label) and hasattr(widget, 'image') and widget.image:
                     pass  # Don't disable logo labels
+                # Widgets that must stay usable while busy (e.g., spinner Cancel button)
+                elif getattr(widget, '_keep_enabled', False):
+                    pass
                 # Try to disable interactive widgets only
                 elif hasattr(widget, 'config'):
                     try:
@@ -764,13 +1006,13 @@ class OrlandoToolkit:
         
         # Reset status label
         if self.status_label:
-            self.status_label.config(text="Plugin processing failed. Please try again.")
+            self.status_label.config(text=tr("Plugin processing failed. Please try again."))
         
         # Re-enable all UI elements
         self._enable_all_ui_elements()
         
         # Show error dialog
-        messagebox.showerror("Plugin Processing Error", error_message)
+        messagebox.showerror(tr("Plugin Processing Error"), error_message)
     
     # ------------------------------------------------------------------
     # Plugin Integration and Workflow Management
@@ -814,9 +1056,9 @@ class OrlandoToolkit:
         
         # Fallback configuration
         return SplashButtonConfig(
-            text="Import",
+            text=tr("Import"),
             icon="default-plugin-icon.png",
-            tooltip=f"Import content using {plugin_id}",
+            tooltip=tr("Import content using {name}", name=plugin_id),
             plugin_id=plugin_id,
             command=lambda: self.launch_plugin_workflow(plugin_id)
         )
@@ -990,10 +1232,17 @@ class OrlandoToolkit:
         except Exception as e:
             self._logger.error("Failed to show plugin management dialog: %s", e)
             messagebox.showerror(
-                "Plugin Management Error",
-                f"Failed to open plugin management:\n\n{e}"
+                tr("Plugin Management Error"),
+                tr("Failed to open plugin management:\n\n{problem}", problem=e)
             )
     
+    def open_project_file(self, filepath: str) -> None:
+        """Reopen a saved project (.otkproj) in the background."""
+        cancel_token = self._begin_cancellable_operation()
+        self._show_loading_spinner(tr("Opening Project"), "", cancel_token=cancel_token)
+        self._disable_all_ui_elements()
+        threading.Thread(target=self.run_project_open_thread, args=(filepath,), daemon=True).start()
+
     def open_dita_project(self) -> None:
         """Open existing DITA project from ZIP archive."""
         # Get supported formats for DITA import
@@ -1006,37 +1255,43 @@ class OrlandoToolkit:
                 filetypes = []
                 for fmt in dita_formats:
                     filetypes.append((fmt.description, f"*{fmt.extension}"))
-                filetypes.append(("All files", "*.*"))
+                filetypes.append((tr("All files"), "*.*"))
             else:
                 # Fallback to ZIP only
-                filetypes = [("ZIP Archives", "*.zip"), ("All files", "*.*")]
+                filetypes = [(tr("ZIP Archives"), "*.zip"), (tr("All files"), "*.*")]
                 
         except Exception as e:
             logger.warning("Failed to get supported formats for DITA import: %s", e)
-            filetypes = [("ZIP Archives", "*.zip"), ("All files", "*.*")]
+            filetypes = [(tr("ZIP Archives"), "*.zip"), (tr("All files"), "*.*")]
+        filetypes.insert(0, (tr("Orlando Toolkit projects"), f"*{PROJECT_EXTENSION}"))
         
         filepath = filedialog.askopenfilename(
-            title="Select a DITA Project Archive", 
+            title=tr("Select a DITA Package, Map or Project"), 
             filetypes=filetypes
         )
         if not filepath:
             return
+
+        if Path(filepath).suffix.lower() == PROJECT_EXTENSION:
+            self.open_project_file(filepath)
+            return
         
         # Check if file is supported
         if not self.service.can_handle_file(filepath):
             messagebox.showerror(
-                "Unsupported File Type",
-                f"The selected file type is not supported:\n{Path(filepath).name}\n\n"
-                f"Supported formats:\n" + 
-                "\n".join(f"• {fmt.description} ({fmt.extension})" 
-                         for fmt in self.service.get_supported_formats())
+                tr("Unsupported File Type"),
+                tr("The selected file type is not supported:\n{name}\n\nSupported formats:\n{formats}",
+                   name=Path(filepath).name,
+                   formats="\n".join(f"• {fmt.description} ({fmt.extension})"
+                                     for fmt in self.service.get_supported_formats()))
             )
             return
         
         # Import DITA project using conversion service
         if self.status_label:
             self.status_label.config(text="")
-        self._show_loading_spinner("Opening DITA Project", "")
+        cancel_token = self._begin_cancellable_operation()
+        self._show_loading_spinner(tr("Opening DITA Project"), "", cancel_token=cancel_token)
 
         # Comprehensively disable all UI elements during processing
         self._disable_all_ui_elements()
@@ -1048,7 +1303,7 @@ class OrlandoToolkit:
             # No default revision_number so the package is treated as an edition
         }
 
-        threading.Thread(target=self.run_dita_import_thread, args=(filepath, initial_metadata), daemon=True).start()
+        threading.Thread(target=self.run_dita_import_thread, args=(filepath, initial_metadata, cancel_token), daemon=True).start()
     
     def launch_plugin_workflow(self, plugin_id: str) -> None:
         """Launch workflow for a specific plugin.
@@ -1073,8 +1328,8 @@ class OrlandoToolkit:
             plugin_metadata = self.plugin_manager.get_plugin_metadata(plugin_id)
             if not plugin_metadata:
                 messagebox.showerror(
-                    "Plugin Error", 
-                    f"Could not find metadata for plugin: {plugin_id}"
+                    tr("Plugin Error"),
+                    tr("Could not find metadata for plugin: {name}", name=plugin_id)
                 )
                 return
             
@@ -1084,23 +1339,27 @@ class OrlandoToolkit:
                 for fmt in plugin_metadata.supported_formats:
                     if isinstance(fmt, dict):
                         extension = fmt.get("extension", "")
-                        description = fmt.get("description", f"{extension.upper()} files")
+                        description = fmt.get("description", tr("{format} files", format=extension.upper()))
                         if extension:
                             filetypes.append((description, f"*{extension}"))
             
             if not filetypes:
                 # Fallback to all files if no formats specified
-                filetypes = [("All files", "*.*")]
+                filetypes = [(tr("All files"), "*.*")]
             else:
-                filetypes.append(("All files", "*.*"))  # Always add all files option
+                filetypes.append((tr("All files"), "*.*"))  # Always add all files option
             
             # Choose title based on plugin name
-            title = f"Select file for {plugin_metadata.display_name}"
+            title = tr("Select file(s) for {name}", name=plugin_metadata.display_name)
             
-            # Open file dialog with plugin-specific formats
-            filepath = filedialog.askopenfilename(title=title, filetypes=filetypes)
-            if not filepath:
+            # Open file dialog with plugin-specific formats; several files are combined into one map
+            filepaths = filedialog.askopenfilenames(title=title, filetypes=filetypes)
+            if not filepaths:
+                return
+            if len(filepaths) > 1:
+                self.start_combined_conversion(list(filepaths))
                 return
+            filepath = filepaths[0]
             
             # Get plugin's document handler from service registry
             document_handlers = self.service_registry.get_document_handlers()
@@ -1115,46 +1374,50 @@ class OrlandoToolkit:
             if plugin_handler:
                 # Use plugin's document handler to process the file
                 logger.info("Processing file %s with plugin %s", filepath, plugin_id)
-                
+                metadata = self._with_conversion_profile(filepath, {
+                    "manual_title": Path(filepath).stem,
+                    "revision_date": datetime.now().strftime("%Y-%m-%d"),
+                })
+                if metadata is None:
+                    return
+
                 # Show loading UI like regular DITA opening
                 if self.status_label:
                     self.status_label.config(text="")
-                self._show_loading_spinner("Converting Document", "")
+                cancel_token = self._begin_cancellable_operation()
+                self._show_loading_spinner(tr("Converting Document"), "", cancel_token=cancel_token)
                 
                 # Comprehensively disable all UI elements during processing
                 self._disable_all_ui_elements()
                 
                 # Process in background thread like regular DITA opening
-                metadata = {
-                    "manual_title": Path(filepath).stem,
-                    "revision_date": datetime.now().strftime("%Y-%m-%d"),
-                }
-                
                 threading.Thread(
                     target=self.run_plugin_processing_thread,
-                    args=(plugin_handler, filepath, metadata, plugin_id),
+                    args=(plugin_handler, filepath, metadata, plugin_id, cancel_token),
                     daemon=True
                 ).start()
             else:
                 # No document handler found - plugin may not be fully loaded
                 messagebox.showwarning(
-                    "Plugin Not Ready", 
-                    f"Plugin {plugin_metadata.display_name} does not have a document handler registered.\n\n"
-                    f"The plugin may need to be reactivated or may have loading issues."
+                    tr("Plugin Not Ready"),
+                    tr("Plugin {name} does not have a document handler registered.\n\n"
+                       "The plugin may need to be reactivated or may have loading issues.",
+                       name=plugin_metadata.display_name)
                 )
             
         except Exception as e:
             logger.error("Failed to launch plugin workflow for %s: %s", plugin_id, e)
             messagebox.showerror(
-                "Plugin Error", 
-                f"Failed to launch plugin {plugin_id}:\n\n{e}"
+                tr("Plugin Error"),
+                tr("Failed to launch plugin {name}:\n\n{problem}", name=plugin_id, problem=e)
             )
 
     def _create_progress_callback(self) -> callable:
         """Create thread-safe progress callback for all processing types."""
         return self._progress_callback
 
-    def run_plugin_processing_thread(self, plugin_handler, filepath: str, metadata: dict, plugin_id: str) -> None:
+    def run_plugin_processing_thread(self, plugin_handler, filepath: str, metadata: dict, plugin_id: str,
+                                     cancel_token: Optional[CancellationToken] = None) -> None:
         """Run plugin processing in background thread (like regular DITA opening).
         
         Args:
@@ -1162,6 +1425,7 @@ class OrlandoToolkit:
             filepath: Path to file to process
             metadata: Conversion metadata
             plugin_id: ID of the plugin
+            cancel_token: Optional token bound to the spinner's Cancel button
         """
         try:
             logger.info("Background plugin processing started for %s", plugin_id)
@@ -1171,12 +1435,19 @@ class OrlandoToolkit:
             
             # Call the plugin's document handler with progress callback
             logger.info("Calling convert_to_dita with progress callback")
-            result = plugin_handler.convert_to_dita(Path(filepath), metadata, progress_callback)
+            metadata, template_match = apply_template_profile(filepath, metadata)
+            time_budget = PipelineSettings.resolve(metadata).time_budget()
+            result = ConversionService._call_handler(
+                plugin_handler, Path(filepath), metadata, progress_callback, cancel_token,
+                time_budget,
+            )
+            if cancel_token is not None:
+                cancel_token.raise_if_cancelled()
             logger.info("Plugin returned result type: %s", type(result).__name__)
             
             if not result:
                 logger.error("Plugin processing returned no result")
-                self.root.after(0, lambda: self._handle_plugin_failure("Plugin failed to process the file", filepath))
+                self.root.after(0, lambda: self._handle_plugin_failure(tr("Plugin failed to process the file"), filepath))
                 return
                 
             logger.info("Finalizing DITA conversion")
@@ -1188,14 +1459,23 @@ class OrlandoToolkit:
             else:
                 logger.warning("Result has no plugin_data attribute to store source plugin ID")
             
+            if isinstance(result, DitaContext):
+                record_template_match(result.report, template_match)
+                check_toc(filepath, result, resolve_conversion_options(metadata).get("toc_check"))
+                result = self.service.finalize_conversion(result, metadata, cancel_token=cancel_token,
+                                                          time_budget=time_budget)
+            
             # Load the converted content into the app
             logger.info("Loading converted content")
             self._load_conversion_result(result, filepath)
             logger.info("Plugin conversion completed successfully")
                 
+        except OperationCancelledError:
+            logger.info("Plugin processing cancelled for %s", plugin_id)
+            self.root.after(0, self.on_operation_cancelled)
         except Exception as e:
             logger.error("Plugin processing failed: %s", e)
-            error_msg = f"Failed to process file:\n\n{e}"
+            error_msg = tr("Failed to process file:\n\n{problem}", problem=e)
             self.root.after(0, lambda msg=error_msg: self._handle_plugin_failure(msg, filepath))
 
     def _load_conversion_result(self, result, source_filepath: str) -> None:
@@ -1233,16 +1513,16 @@ class OrlandoToolkit:
                 # Generic result - show success message
                 logger.warning("Unhandled result type - showing generic success message")
                 messagebox.showinfo(
-                    "Conversion Complete",
-                    f"File processed successfully by plugin.\n\n"
-                    f"Source: {Path(source_filepath).name}\n"
-                    f"Result type: {type(result).__name__}"
+                    tr("Conversion Complete"),
+                    tr("File processed successfully by plugin.\n\nSource: {name}\nResult type: {kind}",
+                       name=Path(source_filepath).name, kind=type(result).__name__)
                 )
                 
         except Exception as e:
             logger.error("Failed to load conversion result: %s", e)
             # Handle failure properly with UI cleanup
-            self.root.after(0, lambda: self._handle_plugin_failure(f"Failed to load conversion result:\n\n{e}", source_filepath))
+            self.root.after(0, lambda: self._handle_plugin_failure(
+                tr("Failed to load conversion result:\n\n{problem}", problem=e), source_filepath))
 
     def _transition_to_post_conversion_state(self, source_filepath: str) -> None:
         """Transition application to post-conversion state with loaded DITA content."""
@@ -1272,7 +1552,7 @@ class OrlandoToolkit:
             
         except Exception as e:
             logger.error("Failed to transition to post-conversion state: %s", e)
-            messagebox.showerror("Interface Error", f"Failed to set up main interface:\n\n{e}")
+            messagebox.showerror(tr("Interface Error"), tr("Failed to set up main interface:\n\n{problem}", problem=e))
 
     # ------------------------------------------------------------------
     # Document conversion workflow
@@ -1287,19 +1567,19 @@ class OrlandoToolkit:
             filetypes = []
             for fmt in supported_formats:
                 filetypes.append((fmt.description, f"*{fmt.extension}"))
-            filetypes.append(("All files", "*.*"))
+            filetypes.append((tr("All files"), "*.*"))
             
             # Choose title based on available formats
             if any('DITA' in fmt.description for fmt in supported_formats):
-                title = "Select a Document or DITA Package"
+                title = tr("Select a Document or DITA Package")
             else:
-                title = "Select a Document"
+                title = tr("Select a Document")
                 
         except Exception as e:
             logger.warning("Failed to get supported formats: %s", e)
             # Fallback to all files only (plugin-agnostic)
-            filetypes = [("All files", "*.*")]
-            title = "Select a file"
+            filetypes = [(tr("All files"), "*.*")]
+            title = tr("Select a file")
         
         filepath = filedialog.askopenfilename(title=title, filetypes=filetypes)
         if not filepath:
@@ -1308,43 +1588,189 @@ class OrlandoToolkit:
         # Check if file is supported
         if not self.service.can_handle_file(filepath):
             messagebox.showerror(
-                "Unsupported File Type",
-                f"The selected file type is not supported:\n{Path(filepath).name}\n\n"
-                f"Supported formats:\n" + 
-                "\n".join(f"• {fmt.description} ({fmt.extension})" 
-                         for fmt in self.service.get_supported_formats())
+                tr("Unsupported File Type"),
+                tr("The selected file type is not supported:\n{name}\n\nSupported formats:\n{formats}",
+                   name=Path(filepath).name,
+                   formats="\n".join(f"• {fmt.description} ({fmt.extension})"
+                                     for fmt in self.service.get_supported_formats()))
             )
             return
 
+        initial_metadata = self._with_conversion_profile(filepath, {
+            "manual_title": Path(filepath).stem,
+            "revision_date": datetime.now().strftime("%Y-%m-%d"),
+            # No default revision_number so the generated package is treated as an edition.
+        })
+        if initial_metadata is None or not self._review_headings(filepath, initial_metadata) \
+                or not self._review_front_matter(filepath, initial_metadata):
+            return
+
         if self.status_label:
             self.status_label.config(text="")
-        self._show_loading_spinner("Converting Document", "")
+        cancel_token = self._begin_cancellable_operation()
+        self._show_loading_spinner(tr("Converting Document"), "", cancel_token=cancel_token)
 
         # Comprehensively disable all UI elements during processing
         self._disable_all_ui_elements()
 
-        initial_metadata = {
-            "manual_title": Path(filepath).stem,
+        # Use unified processing thread for both conversions and imports
+        threading.Thread(target=self.run_document_processing_thread, args=(filepath, initial_metadata, cancel_token), daemon=True).start()
+
+    def combine_folder(self) -> None:
+        """Combine the convertible documents of a folder (and its sub-folders) into one map."""
+        folder = filedialog.askdirectory(title=tr("Select the folder of documents to combine"))
+        if not folder:
+            return
+        from orlando_toolkit.core.combine import collect_sources
+
+        filepaths = [str(p) for p in collect_sources([folder], lambda p: self.service.can_handle_file(p))]
+        if not filepaths:
+            messagebox.showinfo(tr("Combine Documents"), tr("No supported documents found in:\n{folder}", folder=folder))
+            return
+        self.start_combined_conversion(filepaths, base_dir=folder, title=Path(folder).name)
+
+    def start_combined_conversion(self, filepaths: list[str], *, base_dir: Optional[str] = None,
+                                  title: Optional[str] = None) -> None:
+        """Convert *filepaths* in order and combine them into one map, one chapter per document."""
+        unsupported = [Path(p).name for p in filepaths if not self.service.can_handle_file(p)]
+        if unsupported:
+            messagebox.showerror(tr("Unsupported File Type"),
+                                 tr("These files cannot be converted:\n{names}", names="\n".join(unsupported[:20])))
+            return
+        metadata = self._with_conversion_profile(None, {
+            "manual_title": title or Path(filepaths[0]).parent.name or Path(filepaths[0]).stem,
             "revision_date": datetime.now().strftime("%Y-%m-%d"),
-            # No default revision_number so the generated package is treated as an edition.
-        }
+        })
+        if metadata is None:
+            return
+        if self.status_label:
+            self.status_label.config(text="")
+        cancel_token = self._begin_cancellable_operation()
+        self._show_loading_spinner(tr("Combining Documents"), "", cancel_token=cancel_token)
+        self._disable_all_ui_elements()
+        threading.Thread(target=self.run_combined_conversion_thread,
+                         args=(filepaths, metadata, base_dir, cancel_token), daemon=True).start()
 
-        # Use unified processing thread for both conversions and imports
-        threading.Thread(target=self.run_document_processing_thread, args=(filepath, initial_metadata), daemon=True).start()
+    def run_combined_conversion_thread(self, filepaths: list[str], metadata: dict, base_dir: Optional[str],
+                                       cancel_token: Optional[CancellationToken] = None) -> None:
+        try:
+            logger.info("Starting combined conversion of %d documents", len(filepaths))
+            ctx = self.service.convert_combined(filepaths, metadata, self._progress_callback,
+                                                cancel_token=cancel_token, base_dir=base_dir)
+            try:
+                if ctx.metadata.get("topic_depth") is None:
+                    from orlando_toolkit.core.services.heading_analysis_service import compute_max_depth
+                    ctx.metadata["topic_depth"] = max(1, int(compute_max_depth(ctx)))
+            except Exception:
+                # Best-effort only; UI can still adjust depth later
+                pass
+            self.root.after(0, self.on_conversion_success, ctx)
+        except OperationCancelledError:
+            logger.info("Combined conversion cancelled")
+            self.root.after(0, self.on_operation_cancelled)
+        except Exception as exc:
+            logger.error("Combined conversion failed", exc_info=True)
+            self.root.after(0, self.on_conversion_failure, exc)
+
+    def _review_headings(self, filepath: str, metadata: dict) -> bool:
+        """Show the detected headings when ``headings.review`` is on; False when the user cancels.
+
+        The reviewed levels go to ``metadata["heading_overrides"]``.
+        """
+        from orlando_toolkit.core.heading_review import read_heading_outline, review_enabled, review_overrides
+
+        if Path(filepath).suffix.lower() in (".zip", ".ditamap") or not review_enabled(metadata):
+            return True
+        outline = read_heading_outline(filepath, metadata)
+        if not outline:
+            return True
+        from orlando_toolkit.ui.dialogs.heading_review_dialog import HeadingReviewDialog
+
+        levels = HeadingReviewDialog(self.root, outline, Path(filepath).name).show_modal()
+        if levels is None:
+            return False
+        overrides = review_overrides(outline, levels)
+        if overrides:
+            metadata["heading_overrides"] = overrides
+        return True
 
-    def run_document_processing_thread(self, filepath: str, metadata: dict) -> None:
+    def _review_front_matter(self, filepath: str, metadata: dict) -> bool:
+        """Preview the front matter left out when ``front_matter.review`` is on; False when the user cancels.
+
+        Blocks the user keeps go to ``metadata["front_matter_keep"]``.
+        """
+        from orlando_toolkit.core.front_matter import KEEP_KEY, plan_front_matter, review_enabled
+        from orlando_toolkit.core.processing import resolve_conversion_options
+        from orlando_toolkit.core.style_map import heading_levels, resolve_style_rules
+
+        if Path(filepath).suffix.lower() != ".docx" or not review_enabled(metadata):
+            return True
+        try:
+            blocks = plan_front_matter(filepath, resolve_conversion_options(metadata).get("front_matter"),
+                                       heading_levels(resolve_style_rules(metadata)))
+        except Exception as exc:
+            logger.warning("Front matter preview failed for %s: %s", filepath, exc)
+            return True
+        if not blocks:
+            return True
+        from orlando_toolkit.ui.dialogs.front_matter_dialog import FrontMatterDialog
+
+        keep = FrontMatterDialog.ask(self.root, blocks, Path(filepath).name)
+        if keep is None:
+            return False
+        if keep:
+            metadata[KEEP_KEY] = keep
+        return True
+
+    def _choose_template_profile(self, filepath: str) -> Optional[str]:
+        """Ask which preset to use when the document's template matches several profiles.
+
+        Returns the chosen profile, ``"none"``, or ``None`` to let the
+        conversion select automatically (single match or no match).
+        """
+        try:
+            match = match_template(detect_template(filepath))
+        except Exception as exc:
+            logger.debug("Template detection failed for %s: %s", filepath, exc)
+            return None
+        if not match.ambiguous:
+            return None
+
+        dialog = tk.Toplevel(self.root)
+        dialog.title(tr("Choose Conversion Profile"))
+        dialog.transient(self.root)
+        dialog.resizable(False, False)
+        names = ", ".join(match.template.names) or tr("its styles")
+        ttk.Label(dialog, text=tr("{name} was made from {template},\n"
+                                  "which matches several conversion profiles. Which one should be used?",
+                                  name=Path(filepath).name, template=names),
+                  justify="left").pack(padx=16, pady=(16, 8), anchor="w")
+        choice = tk.StringVar(value=match.candidates[0])
+        for candidate in match.candidates:
+            ttk.Radiobutton(dialog, text=candidate, value=candidate, variable=choice).pack(padx=24, anchor="w")
+        ttk.Radiobutton(dialog, text=tr("No profile"), value=NO_TEMPLATE_PROFILE,
+                        variable=choice).pack(padx=24, anchor="w")
+        ttk.Button(dialog, text=tr("Convert"), style="Accent.TButton",
+                   command=dialog.destroy).pack(padx=16, pady=16, anchor="e")
+        dialog.protocol("WM_DELETE_WINDOW", lambda: (choice.set(NO_TEMPLATE_PROFILE), dialog.destroy()))
+        dialog.grab_set()
+        self.root.wait_window(dialog)
+        return choice.get()
+
+    def run_document_processing_thread(self, filepath: str, metadata: dict,
+                                       cancel_token: Optional[CancellationToken] = None) -> None:
         """Process document (conversion or import) in background thread."""
         try:
             # Determine operation type for logging
             file_path = Path(filepath)
-            if file_path.suffix.lower() == '.zip':
+            if file_path.suffix.lower() in ('.zip', '.ditamap'):
                 operation = "DITA import"
             else:
                 operation = "Document conversion"
             
             logger.info("Starting %s for: %s", operation, filepath)
             
-            ctx = self.service.convert(filepath, metadata, self._progress_callback)
+            ctx = self.service.convert(filepath, metadata, self._progress_callback, cancel_token=cancel_token)
             # Treat a None result as a failure
             if ctx is None:
                 logger.error("%s returned no result for file: %s", operation, filepath)
@@ -1370,17 +1796,46 @@ class OrlandoToolkit:
             
             logger.info("%s completed successfully", operation)
             self.root.after(0, self.on_conversion_success, ctx)
+        except OperationCancelledError:
+            logger.info("%s cancelled for %s", operation, filepath)
+            self.root.after(0, self.on_operation_cancelled)
         except Exception as exc:
             logger.error("%s failed for %s", operation, filepath, exc_info=True)
             self.root.after(0, self.on_conversion_failure, exc)
 
-    def run_conversion_thread(self, filepath: str, metadata: dict) -> None:
+    def run_conversion_thread(self, filepath: str, metadata: dict,
+                              cancel_token: Optional[CancellationToken] = None) -> None:
         """Legacy method - delegate to unified processing."""
-        self.run_document_processing_thread(filepath, metadata)
+        self.run_document_processing_thread(filepath, metadata, cancel_token)
 
-    def run_dita_import_thread(self, filepath: str, metadata: dict) -> None:
+    def run_dita_import_thread(self, filepath: str, metadata: dict,
+                               cancel_token: Optional[CancellationToken] = None) -> None:
         """Legacy method - delegate to unified processing."""
-        self.run_document_processing_thread(filepath, metadata)
+        self.run_document_processing_thread(filepath, metadata, cancel_token)
+
+    def run_project_open_thread(self, filepath: str) -> None:
+        try:
+            project = load_project(filepath)
+        except Exception as exc:
+            logger.error("Opening project failed for %s", filepath, exc_info=True)
+            self.root.after(0, self.on_conversion_failure, exc)
+            return
+        self.root.after(0, self.on_project_opened, project)
+
+    def on_project_opened(self, project: Project) -> None:
+        self.project_path = project.path
+        status = project.source_status
+        if status in ("modified", "missing"):
+            name = project.source.name or tr("the source document")
+            if status == "modified":
+                text = tr("{name} has changed since the project was saved.", name=name)
+            else:
+                text = tr("{name} cannot be found at the location recorded when the project was saved.", name=name)
+            messagebox.showwarning(
+                tr("Project source"),
+                text + "\n\n" + tr("The saved structure and edits are shown as they were."),
+            )
+        self.on_conversion_success(project.context)
 
     # ------------------------------------------------------------------
     # Conversion callbacks
@@ -1394,6 +1849,7 @@ class OrlandoToolkit:
         metadata form. A Continue button opens the full workspace.
         """
         self.dita_context = context
+        self._cancel_token = None
         
         # Update AppContext with current document context
         logger.info("Calling app_context._set_current_dita_context with source plugin: %s", 
@@ -1424,14 +1880,16 @@ class OrlandoToolkit:
         self.show_post_conversion_summary()
 
     def on_conversion_failure(self, error: Exception) -> None:
+        self._cancel_token = None
         self._hide_loading_spinner()
         if self.status_label:
-            self.status_label.config(text="Conversion failed. Please try again.")
+            self.status_label.config(text=tr("Conversion failed. Please try again."))
         
         # Re-enable all UI elements after processing failure
         self._enable_all_ui_elements()
         
-        messagebox.showerror("Conversion Error", f"Document processing failed:\n\n{error}")
+        messagebox.showerror(tr("Conversion Error"),
+                             tr("Document processing failed:\n\n{problem}", problem=describe_error(error).format()))
 
     # ------------------------------------------------------------------
     # Main UI after conversion
@@ -1447,7 +1905,7 @@ class OrlandoToolkit:
         # Place Structure first (leftmost) and select by default
         from orlando_toolkit.ui.structure_tab import StructureTab
         self.structure_tab = StructureTab(self.notebook)
-        self.notebook.add(self.structure_tab, text="Structure")
+        self.notebook.add(self.structure_tab, text=tr("Structure"))
         
         # Load the converted DITA context into the structure tab
         if self.dita_context:
@@ -1458,12 +1916,12 @@ class OrlandoToolkit:
 
         # Place Media second, Metadata third per updated UX
         self.media_tab = MediaTab(self.notebook)
-        self.notebook.add(self.media_tab, text="Media")
+        self.notebook.add(self.media_tab, text=tr("Media"))
         if self.dita_context:
             self.media_tab.load_context(self.dita_context)
 
         self.metadata_tab = MetadataTab(self.notebook)
-        self.notebook.add(self.metadata_tab, text="Metadata")
+        self.notebook.add(self.metadata_tab, text=tr("Metadata"))
         if self.dita_context:
             self.metadata_tab.load_context(self.dita_context)
 
@@ -1474,14 +1932,49 @@ class OrlandoToolkit:
 
         left_actions = ttk.Frame(self.main_actions_frame)
         left_actions.pack(side="left")
-        ttk.Button(left_actions, text="← Back to Home", command=self.back_to_home).pack(side="left")
-        about_link = ttk.Label(left_actions, text="About", cursor="hand2", foreground="#888888")
+        ttk.Button(left_actions, text=tr("← Back to Home"), command=self.back_to_home).pack(side="left")
+        about_link = ttk.Label(left_actions, text=tr("About"), cursor="hand2", foreground="#888888")
         about_link.pack(side="left", padx=(10, 0), pady=(3, 0))
         about_link.bind("<Button-1>", lambda e: show_about_dialog(self.root))
 
         right_actions = ttk.Frame(self.main_actions_frame)
         right_actions.pack(side="right")
-        ttk.Button(right_actions, text="Generate DITA Package", style="Accent.TButton", command=self.generate_package).pack(side="right")
+        ttk.Button(right_actions, text=tr("Generate DITA Package"), style="Accent.TButton", command=self.generate_package).pack(side="right")
+        ttk.Button(right_actions, text=tr("Publish PDF/HTML5"), command=self.publish_package).pack(side="right", padx=(0, 8))
+        ttk.Button(right_actions, text=tr("Import Translation…"),
+                   command=self.import_translation).pack(side="right", padx=(0, 8))
+        ttk.Button(right_actions, text=tr("Export XLIFF…"), command=self.export_xliff).pack(side="right", padx=(0, 8))
+        ttk.Button(right_actions, text=tr("Export to Confluence…"),
+                   command=self.export_confluence).pack(side="right", padx=(0, 8))
+        if self._s1000d_enabled():
+            ttk.Button(right_actions, text=tr("Export to S1000D…"),
+                       command=self.export_s1000d).pack(side="right", padx=(0, 8))
+        ttk.Button(right_actions, text=tr("Export Review Bundle…"),
+                   command=self.export_review_bundle).pack(side="right", padx=(0, 8))
+        ttk.Button(right_actions, text=tr("Compare with Package…"),
+                   command=self.compare_with_package).pack(side="right", padx=(0, 8))
+        ttk.Button(right_actions, text=tr("Fidelity Report…"),
+                   command=self.export_fidelity_report).pack(side="right", padx=(0, 8))
+        ttk.Button(right_actions, text=tr("Import Outline…"), command=self.import_outline).pack(side="right", padx=(0, 8))
+        ttk.Button(right_actions, text=tr("Export Outline…"), command=self.export_outline).pack(side="right", padx=(0, 8))
+        ttk.Button(right_actions, text=tr("Save Project"), command=self.save_project_file).pack(side="right", padx=(0, 8))
+        ttk.Button(right_actions, text=tr("Save Profile…"),
+                   command=self.save_conversion_profile).pack(side="right", padx=(0, 8))
+        ttk.Button(right_actions, text=tr("Reuse Content…"), command=self.review_reuse).pack(side="right", padx=(0, 8))
+        ttk.Button(right_actions, text=tr("Safety Notices…"),
+                   command=self.review_safety_notices).pack(side="right", padx=(0, 8))
+        ttk.Button(right_actions, text=tr("Find & Replace…"), command=self.find_replace).pack(side="right", padx=(0, 8))
+        ttk.Button(right_actions, text=tr("Terminology…"), command=self.check_terminology).pack(side="right", padx=(0, 8))
+        ttk.Button(right_actions, text=tr("Spelling…"), command=self.check_spelling).pack(side="right", padx=(0, 8))
+        ttk.Button(right_actions, text=tr("Validate"), command=self.validate_package).pack(side="right", padx=(0, 8))
+        ttk.Button(right_actions, text=tr("Conversion Log…"),
+                   command=self.show_conversion_log).pack(side="right", padx=(0, 8))
+        ttk.Button(right_actions, text=tr("Style Usage…"), command=self.show_style_usage).pack(side="right", padx=(0, 8))
+        ttk.Button(right_actions, text=tr("Check Links…"), command=self.check_links).pack(side="right", padx=(0, 8))
+        ttk.Button(right_actions, text=tr("Accessibility…"),
+                   command=self.check_accessibility).pack(side="right", padx=(0, 8))
+        ttk.Button(right_actions, text=tr("Update from Source…"),
+                   command=self.update_from_source).pack(side="right", padx=(0, 8))
 
         # Default to Structure view
         try:
@@ -1513,18 +2006,29 @@ class OrlandoToolkit:
         num_images = len(self.dita_context.images) if self.dita_context.images else 0
         # Line 1: topics
         if num_topics > 0:
-            ttk.Label(summary, text=f"✓ {num_topics} topics extracted", **ok_style).pack(anchor="center")
+            ttk.Label(summary, text=tr("✓ {count} topics extracted", count=num_topics), **ok_style).pack(anchor="center")
         else:
-            ttk.Label(summary, text="✗ No topics found", **err_style).pack(anchor="center")
+            ttk.Label(summary, text=tr("✗ No topics found"), **err_style).pack(anchor="center")
         # Line 2: images
         if num_images > 0:
-            ttk.Label(summary, text=f"✓ {num_images} images extracted", **ok_style).pack(anchor="center")
+            ttk.Label(summary, text=tr("✓ {count} images extracted", count=num_images), **ok_style).pack(anchor="center")
         else:
-            ttk.Label(summary, text="✗ No images found", **err_style).pack(anchor="center")
+            ttk.Label(summary, text=tr("✗ No images found"), **err_style).pack(anchor="center")
+        # Line 3: partial output (time budget exceeded, etc.)
+        report = getattr(self.dita_context, "report", None)
+        if report is not None and report.partial:
+            warn_style = {"foreground": "#ef6c00", "font": ("Arial", 11, "bold")}
+            ttk.Label(summary, text=tr("⚠ Partial output: {reasons}", reasons="; ".join(report.partial_reasons)),
+                      **warn_style).pack(anchor="center")
+        # Line 4: problems recorded while converting, listed in the Conversion Log
+        if report is not None and (report.count("error") or report.count("warning")):
+            ttk.Label(summary, text=tr("⚠ {errors} error(s), {warnings} warning(s) — see Conversion Log",
+                                       errors=report.count("error"), warnings=report.count("warning")), foreground="#ef6c00",
+                      font=("Arial", 10)).pack(anchor="center")
 
         # Inline metadata editor
         # Unified metadata form with compact styling
-        metadata_frame = ttk.LabelFrame(self.home_center, text="DITA Metadata", padding=8)
+        metadata_frame = ttk.LabelFrame(self.home_center, text=tr("DITA Metadata"), padding=8)
         metadata_frame.pack(fill="x", pady=(4, 14))
 
         # Use MetadataForm directly to avoid tab-specific decorations; reduced padding
@@ -1535,9 +2039,9 @@ class OrlandoToolkit:
 
         # Footer button: Continue if anything found; else Quit
         if num_topics > 0 or num_images > 0:
-            ttk.Button(self.home_center, text="Continue", style="Accent.TButton", command=self.open_main_ui_from_summary).pack(pady=16, ipadx=18, ipady=8)
+            ttk.Button(self.home_center, text=tr("Continue"), style="Accent.TButton", command=self.open_main_ui_from_summary).pack(pady=16, ipadx=18, ipady=8)
         else:
-            ttk.Button(self.home_center, text="Quit", command=self.on_close).pack(pady=16, ipadx=18, ipady=8)
+            ttk.Button(self.home_center, text=tr("Quit"), command=self.on_close).pack(pady=16, ipadx=18, ipady=8)
 
     def _commit_inline_metadata_to_context(self) -> None:
         """Ensure inline metadata edits are persisted to the context."""
@@ -1573,7 +2077,7 @@ class OrlandoToolkit:
         self.inline_metadata = None
 
         # Show an in-window full overlay with a large hourglass icon
-        self._show_loading_overlay("Loading structure…")
+        self._show_loading_overlay(tr("Loading structure…"))
 
         # Trigger fullscreen immediately for a stable visual
         try:
@@ -1614,6 +2118,7 @@ class OrlandoToolkit:
         for widget in self.root.winfo_children():
             widget.destroy()
         self.dita_context = None
+        self.project_path = None
         
         # Clear AppContext document context
         self.app_context._set_current_dita_context(None)
@@ -1628,7 +2133,7 @@ class OrlandoToolkit:
 
     def generate_package(self) -> None:
         if not self.dita_context:
-            messagebox.showerror("Error", "No DITA context is loaded.")
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
             return
 
         # Commit latest metadata from UI before reading values
@@ -1640,7 +2145,7 @@ class OrlandoToolkit:
 
         manual_code = (self.dita_context.metadata.get("manual_code") or "dita_project") if self.dita_context else "dita_project"
         save_path = filedialog.asksaveasfilename(
-            title="Save DITA archive",
+            title=tr("Save DITA archive"),
             defaultextension=".zip",
             filetypes=(("ZIP", "*.zip"),),
             initialfile=f"{manual_code}.zip",
@@ -1648,8 +2153,9 @@ class OrlandoToolkit:
         if not save_path:
             return
 
-        self._show_loading_spinner("Generating Package", "")
-        threading.Thread(target=self.run_generation_thread, args=(save_path,), daemon=True).start()
+        cancel_token = self._begin_cancellable_operation()
+        self._show_loading_spinner(tr("Generating Package"), "", cancel_token=cancel_token)
+        threading.Thread(target=self.run_generation_thread, args=(save_path, cancel_token), daemon=True).start()
 
 
     def _show_loading_overlay(self, message: str = "Loading…") -> None:
@@ -1662,7 +2168,7 @@ class OrlandoToolkit:
             try:
                 ttk.Label(center, text="⌛", font=("Arial", 72)).pack()
             except Exception:
-                ttk.Label(center, text="Loading", font=("Arial", 32, "bold")).pack()
+                ttk.Label(center, text=tr("Loading"), font=("Arial", 32, "bold")).pack()
             ttk.Label(center, text=message, font=("Arial", 14)).pack(pady=8)
             try:
                 overlay.lift()
@@ -1683,52 +2189,881 @@ class OrlandoToolkit:
             except Exception:
                 pass
 
-    def run_generation_thread(self, save_path: str):
-        try:
-            # Build an up-to-date context snapshot for export. We work on a
-            # background thread, so heavy deepcopy does not block the UI.
-            if self.structure_tab and getattr(self.structure_tab, "context", None):
-                ctx_export = deepcopy(self.structure_tab.context)
-                # Preserve latest metadata (may have been edited in other tabs)
-                if self.dita_context:
-                    # Keep Structure tab's chosen depth from being overwritten by base context
-                    # Prefer controller's max_depth if available; else metadata
+    def _working_context_snapshot(self) -> Optional[DitaContext]:
+        """Return a copy of the edited context with the latest metadata."""
+        # Called on background threads, so heavy deepcopy does not block the UI.
+        if self.structure_tab and getattr(self.structure_tab, "context", None):
+            ctx_export = deepcopy(self.structure_tab.context)
+            # Preserve latest metadata (may have been edited in other tabs)
+            if self.dita_context:
+                # Keep Structure tab's chosen depth from being overwritten by base context
+                # Prefer controller's max_depth if available; else metadata
+                depth_from_structure = None
+                try:
+                    depth_from_structure = getattr(self.structure_tab, "max_depth", None)
+                except Exception:
                     depth_from_structure = None
-                    try:
-                        depth_from_structure = getattr(self.structure_tab, "max_depth", None)
-                    except Exception:
-                        depth_from_structure = None
-                    if depth_from_structure is None:
-                        depth_from_structure = ctx_export.metadata.get("topic_depth")
-                    # Merge global metadata
-                    ctx_export.metadata.update(self.dita_context.metadata)
-                    # Restore the structure depth explicitly if known
-                    if depth_from_structure is not None:
-                        ctx_export.metadata["topic_depth"] = depth_from_structure
-            else:
-                ctx_export = deepcopy(self.dita_context)
+                if depth_from_structure is None:
+                    depth_from_structure = ctx_export.metadata.get("topic_depth")
+                # Merge global metadata
+                ctx_export.metadata.update(self.dita_context.metadata)
+                # Attachments are managed in the Images tab on the base context
+                ctx_export.attachments = dict(self.dita_context.attachments)
+                # Restore the structure depth explicitly if known
+                if depth_from_structure is not None:
+                    ctx_export.metadata["topic_depth"] = depth_from_structure
+        else:
+            ctx_export = deepcopy(self.dita_context)
+        return ctx_export
 
-            ctx = self.service.prepare_package(ctx_export)  # type: ignore[arg-type]
-            self.service.write_package(ctx, save_path)
+    def run_generation_thread(self, save_path: str, cancel_token: Optional[CancellationToken] = None):
+        try:
+            ctx_export = self._working_context_snapshot()
+            ctx = self.service.prepare_package(ctx_export, cancel_token=cancel_token,  # type: ignore[arg-type]
+                                               progress_callback=self._progress_callback)
+            self.service.write_package(ctx, save_path, cancel_token=cancel_token,
+                                       progress_callback=self._progress_callback)
             self.root.after(0, self.on_generation_success, save_path)
+        except OperationCancelledError:
+            logger.info("Package generation cancelled: %s", save_path)
+            self.root.after(0, self.on_operation_cancelled)
         except Exception as exc:
             logger.error("Package generation failed", exc_info=True)
             self.root.after(0, self.on_generation_failure, exc)
 
+    def offer_resume_packaging(self) -> None:
+        """Offer to finish the packagings a crash interrupted (``resume`` in pipeline.yml)."""
+        try:
+            workspaces = pending_workspaces()
+        except OSError:
+            return
+        if not workspaces:
+            return
+        names = "\n".join(f"• {w.output.name if w.output else w.id}" for w in workspaces[:10])
+        answer = messagebox.askyesnocancel(
+            tr("Resume Packaging"),
+            tr("{count} package(s) were interrupted before they were written:\n\n{names}\n\n"
+               "Finish them now? Choose No to discard them, Cancel to decide later.",
+               count=len(workspaces), names=names))
+        if answer is None:
+            return
+        if not answer:
+            for workspace in workspaces:
+                workspace.discard()
+            return
+        cancel_token = self._begin_cancellable_operation()
+        self._show_loading_spinner(tr("Resuming Packaging"), "", cancel_token=cancel_token)
+        threading.Thread(target=self.run_resume_thread, args=(workspaces, cancel_token), daemon=True).start()
+
+    def run_resume_thread(self, workspaces: list, cancel_token: CancellationToken) -> None:
+        written, errors = [], []
+        for workspace in workspaces:
+            try:
+                written.append(str(self.service.resume_package(workspace, cancel_token=cancel_token,
+                                                               progress_callback=self._progress_callback)))
+            except OperationCancelledError:
+                self.root.after(0, self.on_operation_cancelled)
+                return
+            except Exception as exc:
+                logger.error("Resuming packaging %s failed", workspace.id, exc_info=True)
+                errors.append(f"{workspace.id}: {describe_error(exc).message}")
+        self.root.after(0, self.on_resume_done, written, errors)
+
+    def on_resume_done(self, written: list, errors: list) -> None:
+        self._cancel_token = None
+        self._hide_loading_spinner()
+        lines = ([tr("Archive written to {path}", path=path) for path in written]
+                 + [tr("Failed: {problem}", problem=error) for error in errors])
+        (messagebox.showwarning if errors else messagebox.showinfo)(tr("Resume Packaging"), "\n".join(lines))
+
     def on_generation_success(self, save_path: str):
+        self._cancel_token = None
         self._hide_loading_spinner()
-        messagebox.showinfo("Success", f"Archive written to\n{save_path}")
+        messagebox.showinfo(tr("Success"), tr("Archive written to\n{path}", path=save_path))
 
     def on_generation_failure(self, error: Exception):
+        self._cancel_token = None
+        self._hide_loading_spinner()
+        messagebox.showerror(tr("Generation error"), describe_error(error).format())
+        if isinstance(error, PackageValidationError):
+            # The blocked package used final topic names; list the issues against the edited tree
+            self.validate_package()
+
+    # ------------------------------------------------------------------
+    # Publishing (DITA-OT)
+    # ------------------------------------------------------------------
+
+    def review_reuse(self) -> None:
+        """List blocks repeated across topics and replace the accepted ones with conrefs."""
+        ctx = getattr(self.structure_tab, "context", None) if self.structure_tab else None
+        if ctx is None:
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
+            return
+
+        from orlando_toolkit.core.processing import resolve_conversion_options
+        from orlando_toolkit.core.reuse import find_reuse_candidates
+
+        options = resolve_conversion_options(ctx.metadata).get("reuse") or {}
+        candidates = find_reuse_candidates(ctx, options)
+        if not candidates:
+            messagebox.showinfo(tr("Reuse Content"), tr("No block is repeated often enough to be shared."))
+            return
+        chosen = ReuseDialog.ask(self.root, candidates)
+        if not chosen:
+            return
+        result = self.structure_tab.apply_reuse(chosen, options)
+        if result is not None and not getattr(result, "success", False):
+            messagebox.showerror(tr("Reuse Content"), getattr(result, "message", "") or tr("Reuse failed."))
+
+    def review_safety_notices(self) -> None:
+        """Group the warnings, cautions and hazard statements by wording and single-source the accepted ones."""
+        ctx = getattr(self.structure_tab, "context", None) if self.structure_tab else None
+        if ctx is None:
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
+            return
+
+        from orlando_toolkit.core.processing import resolve_conversion_options
+        from orlando_toolkit.core.reuse import find_notice_groups
+
+        options = resolve_conversion_options(ctx.metadata).get("safety_notices") or {}
+        groups = find_notice_groups(ctx, options)
+        if not groups:
+            messagebox.showinfo(tr("Safety Notices"), tr("No warning, caution or hazard statement to single-source."))
+            return
+
+        def _title(name: str) -> str:
+            topic = ctx.topics.get(name)
+            return " ".join((topic.findtext("title") or "").split()) if topic is not None else name
+
+        chosen = SafetyNoticeDialog.ask(self.root, groups, title_for=_title)
+        if not chosen:
+            return
+        result = self.structure_tab.apply_notice_warehouse(chosen, options)
+        if result is not None and not getattr(result, "success", False):
+            messagebox.showerror(tr("Safety Notices"), getattr(result, "message", "") or tr("Single-sourcing failed."))
+
+    def find_replace(self) -> None:
+        """Replace a term across the text of all topics after previewing the affected topics."""
+        ctx = getattr(self.structure_tab, "context", None) if self.structure_tab else None
+        if ctx is None:
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
+            return
+        request = FindReplaceDialog.ask(self.root, self.structure_tab.find_matches)
+        if not request:
+            return
+        result = self.structure_tab.replace_text(
+            request["find"], request["replacement"], topics=request["topics"],
+            regex=request["regex"], case_sensitive=request["case_sensitive"],
+        )
+        if result is not None and not getattr(result, "success", False):
+            messagebox.showerror(tr("Find & Replace"), getattr(result, "message", "") or tr("Replace failed."))
+        elif result is not None:
+            messagebox.showinfo(tr("Find & Replace"), getattr(result, "message", ""))
+
+    def check_terminology(self) -> None:
+        """List the banned terms of the term lists used per topic, with replacement by the preferred term."""
+        ctx = getattr(self.structure_tab, "context", None) if self.structure_tab else None
+        if ctx is None:
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
+            return
+        panel = getattr(self, "_terminology_panel", None)
+        if panel is not None and panel.winfo_exists():
+            panel.refresh()
+            panel.lift()
+            return
+
+        from orlando_toolkit.core.terminology import TermSettings
+
+        settings = TermSettings.from_config()
+        self._terminology_panel = TerminologyPanel(
+            self.root, rules=settings.rules(), check=self.structure_tab.term_violations,
+            replace=self.structure_tab.replace_term, on_select=self.structure_tab.select_topic,
+            read_list=settings.read_list)
+
+    def check_spelling(self) -> None:
+        """List the suspected misspellings of all topics, with a project ignore list."""
+        ctx = getattr(self.structure_tab, "context", None) if self.structure_tab else None
+        if ctx is None:
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
+            return
+        panel = getattr(self, "_spelling_panel", None)
+        if panel is not None and panel.winfo_exists():
+            panel.refresh()
+            panel.lift()
+            return
+
+        from orlando_toolkit.core.spellcheck import IGNORE_KEY, issues_to_csv
+
+        def _ignore(words):
+            result = self.structure_tab.ignore_spelling(words)
+            # The session metadata is merged over the edited context when saving the project
+            if getattr(result, "success", False) and self.dita_context is not None:
+                self.dita_context.metadata[IGNORE_KEY] = list(result.details["ignored"])
+            return result
+
+        self._spelling_panel = SpellingPanel(
+            self.root, check=self.structure_tab.spelling_issues, ignore=_ignore, to_csv=issues_to_csv,
+            on_select=self.structure_tab.select_topic)
+
+    def validate_package(self) -> None:
+        """Validate the edited content against DITA 1.3 and list the problems in a panel."""
+        ctx = getattr(self.structure_tab, "context", None) if self.structure_tab else None
+        if ctx is None:
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
+            return
+
+        from orlando_toolkit.core.services import ValidationService
+
+        validator = ValidationService()
+
+        def _run():
+            return validator.validate(self.structure_tab.context, strip_hints=True)
+
+        panel = getattr(self, "_validation_panel", None)
+        if panel is not None and panel.winfo_exists():
+            panel.show(_run())
+            panel.lift()
+            return
+        self._validation_panel = ValidationPanel(self.root, _run(), on_select=self.structure_tab.select_topic,
+                                                 revalidate=_run)
+
+    def show_conversion_log(self) -> None:
+        """List the conversion report (stage entries and logged warnings) in a filterable console."""
+        ctx = getattr(self.structure_tab, "context", None) if self.structure_tab else None
+        report = getattr(ctx, "report", None)
+        if report is None:
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
+            return
+        panel = getattr(self, "_conversion_log_panel", None)
+        if panel is not None and panel.winfo_exists():
+            panel.show(report)
+            panel.lift()
+            return
+        self._conversion_log_panel = ConversionLogPanel(self.root, report, on_select=self.structure_tab.select_topic,
+                                                        source=ctx.metadata.get("source_file"))
+
+    def show_style_usage(self) -> None:
+        """List the source styles of the document, what they became and which ones are unmapped."""
+        ctx = getattr(self.structure_tab, "context", None) if self.structure_tab else None
+        if ctx is None:
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
+            return
+
+        from orlando_toolkit.core.style_usage import compute_style_usage
+
+        panel = getattr(self, "_style_usage_panel", None)
+        if panel is not None and panel.winfo_exists():
+            panel.refresh()
+            panel.lift()
+            return
+        self._style_usage_panel = StyleUsagePanel(self.root, lambda: compute_style_usage(self.structure_tab.context))
+
+    def update_from_source(self) -> None:
+        """Re-convert the changed source document and merge it into the edited structure."""
+        ctx = getattr(self.structure_tab, "context", None) if self.structure_tab else None
+        if ctx is None:
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
+            return
+        if ctx.metadata.get("source_documents"):
+            messagebox.showinfo(tr("Update from Source"),
+                                tr("This structure combines several documents; combine them again instead."))
+            return
+        if not ctx.metadata.get("source_outline"):
+            messagebox.showinfo(tr("Update from Source"),
+                                tr("This structure was not converted from a document by this version; "
+                                   "convert the document again instead."))
+            return
+        source = ctx.metadata.get("source_file") or ""
+        filepath = filedialog.askopenfilename(
+            title=tr("Select the changed document"),
+            initialdir=str(Path(source).parent) if source else None,
+            initialfile=Path(source).name if source else None,
+        )
+        if not filepath:
+            return
+
+        from orlando_toolkit.core.reconvert import reconversion_metadata
+
+        metadata = reconversion_metadata(ctx)
+        cancel_token = self._begin_cancellable_operation()
+        self._show_loading_spinner(tr("Updating from Source"), "", cancel_token=cancel_token)
+        threading.Thread(target=self.run_reconversion_thread, args=(filepath, metadata, cancel_token),
+                         daemon=True).start()
+
+    def run_reconversion_thread(self, filepath: str, metadata: dict, cancel_token: CancellationToken) -> None:
+        try:
+            fresh = self.service.convert(filepath, metadata, self._progress_callback, cancel_token=cancel_token)
+            self.root.after(0, self.on_reconversion_done, fresh)
+        except OperationCancelledError:
+            logger.info("Update from source cancelled: %s", filepath)
+            self.root.after(0, self.on_operation_cancelled)
+        except Exception as exc:
+            logger.error("Update from source failed for %s", filepath, exc_info=True)
+            self.root.after(0, self.on_reconversion_failure, exc)
+
+    def on_reconversion_done(self, fresh: DitaContext) -> None:
+        self._cancel_token = None
+        self._hide_loading_spinner()
+        result = self.structure_tab.update_from_source(fresh)
+        details = getattr(result, "details", None) or {}
+        outcome = details.get("result")
+        if outcome is not None and outcome.skipped:
+            messagebox.showwarning(
+                tr("Update from Source"),
+                f"{result.message}\n\n" + tr("Changes to these topics could not be placed because they were merged "
+                                             "or deleted in the editor:") + "\n" + "\n".join(outcome.skipped[:20]))
+        elif result is not None and getattr(result, "success", False):
+            messagebox.showinfo(tr("Update from Source"), result.message)
+        else:
+            messagebox.showinfo(tr("Update from Source"), getattr(result, "message", "") or tr("Nothing changed."))
+
+    def on_reconversion_failure(self, error: Exception) -> None:
+        self._cancel_token = None
+        self._hide_loading_spinner()
+        messagebox.showerror(tr("Update from Source"), describe_error(error).format())
+
+    def check_links(self) -> None:
+        """List broken links, orphaned topics and unused images with quick fixes."""
+        ctx = getattr(self.structure_tab, "context", None) if self.structure_tab else None
+        if ctx is None:
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
+            return
+        panel = getattr(self, "_integrity_panel", None)
+        if panel is not None and panel.winfo_exists():
+            panel.refresh()
+            panel.lift()
+            return
+
+        def _topics():
+            choices = []
+            for name, topic in sorted(self.structure_tab.context.topics.items()):
+                title = topic.find("title")
+                choices.append((name, " ".join("".join(title.itertext()).split()) if title is not None else ""))
+            return choices
+
+        self._integrity_panel = IntegrityPanel(self.root, check=self.structure_tab.integrity_issues,
+                                               fix=self.structure_tab.fix_integrity,
+                                               on_select=self.structure_tab.select_topic, topics=_topics)
+
+    def check_accessibility(self) -> None:
+        """List images without alt text, tables without headers, empty titles and low contrast."""
+        ctx = getattr(self.structure_tab, "context", None) if self.structure_tab else None
+        if ctx is None:
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
+            return
+        panel = getattr(self, "_accessibility_panel", None)
+        if panel is not None and panel.winfo_exists():
+            panel.refresh()
+            panel.lift()
+            return
+        self._accessibility_panel = AccessibilityPanel(self.root, check=self.structure_tab.accessibility_issues,
+                                                       fix=self.structure_tab.fix_accessibility,
+                                                       on_select=self.structure_tab.select_topic)
+
+    def publish_package(self) -> None:
+        """Generate the archive, then publish it with DITA-OT next to it."""
+        if not self.dita_context:
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
+            return
+        try:
+            if getattr(self, "metadata_tab", None):
+                self.metadata_tab.commit()
+        except Exception:
+            pass
+
+        from orlando_toolkit.core.services import PublishingService
+
+        publisher = PublishingService()
+        install = publisher.locate() is None
+        if install and not messagebox.askyesno(
+                tr("DITA-OT not found"),
+                tr("Publishing needs DITA-OT (and Java), which was not found.\n\n"
+                   "Download it now from\n{url}?", url=publisher.settings.download_url)):
+            return
+
+        manual_code = self.dita_context.metadata.get("manual_code") or "dita_project"
+        save_path = filedialog.asksaveasfilename(
+            title=tr("Save DITA archive to publish"),
+            defaultextension=".zip",
+            filetypes=(("ZIP", "*.zip"),),
+            initialfile=f"{manual_code}.zip",
+        )
+        if not save_path:
+            return
+
+        cancel_token = self._begin_cancellable_operation()
+        dialog = PublishDialog(self.root, cancel_token=cancel_token)
+        threading.Thread(target=self.run_publishing_thread,
+                         args=(publisher, save_path, install, dialog, cancel_token), daemon=True).start()
+
+    def run_publishing_thread(self, publisher, save_path: str, install: bool, dialog: PublishDialog,
+                              cancel_token: CancellationToken) -> None:
+        try:
+            ctx_export = self._working_context_snapshot()
+            ctx = self.service.prepare_package(ctx_export, cancel_token=cancel_token)  # type: ignore[arg-type]
+            self.service.write_package(ctx, save_path, cancel_token=cancel_token)
+            dialog.append(tr("Archive written to {path}", path=save_path))
+            if install:
+                dialog.status(tr("Installing DITA-OT…"))
+                publisher.install(on_output=dialog.append, cancel_token=cancel_token)
+            dialog.status(tr("Running DITA-OT…"))
+            outputs = publisher.publish(save_path, on_output=dialog.append, cancel_token=cancel_token)
+            dialog.finish(outputs)
+        except OperationCancelledError:
+            logger.info("Publishing cancelled: %s", save_path)
+            dialog.finish()
+        except Exception as exc:
+            logger.error("Publishing failed", exc_info=True)
+            dialog.finish(error=describe_error(exc).format())
+        finally:
+            self._cancel_token = None
+
+    def export_confluence(self) -> None:
+        """Write the edited content as Confluence pages (importable zip), then optionally push them."""
+        ctx = getattr(self.structure_tab, "context", None) if self.structure_tab else None
+        if ctx is None:
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
+            return
+        try:
+            if getattr(self, "metadata_tab", None):
+                self.metadata_tab.commit()
+        except Exception:
+            pass
+
+        from orlando_toolkit.core.confluence import ConfluenceSettings, export_confluence
+
+        manual_code = (self.dita_context.metadata.get("manual_code") if self.dita_context else None) or "dita_project"
+        save_path = filedialog.asksaveasfilename(
+            title=tr("Save Confluence pages"),
+            defaultextension=".zip",
+            filetypes=(("ZIP", "*.zip"),),
+            initialfile=f"{manual_code}_confluence.zip",
+        )
+        if not save_path:
+            return
+        settings = ConfluenceSettings.from_config()
+        try:
+            export = export_confluence(self._working_context_snapshot(), settings)
+            export.write_zip(save_path)
+        except Exception as exc:
+            logger.error("Confluence export failed", exc_info=True)
+            messagebox.showerror(tr("Export to Confluence"), describe_error(exc).format())
+            return
+        written = tr("{count} page(s) written to\n{path}", count=len(export.pages), path=save_path)
+        if not (settings.base_url and settings.space_key):
+            messagebox.showinfo(tr("Export to Confluence"), written)
+            return
+        if not messagebox.askyesno(tr("Export to Confluence"),
+                                   written + "\n\n" + tr("Also publish them to space {space} at {url} now?",
+                                                           space=settings.space_key, url=settings.base_url)):
+            return
+
+        cancel_token = self._begin_cancellable_operation()
+        dialog = PublishDialog(self.root, cancel_token=cancel_token)
+        threading.Thread(target=self.run_confluence_push_thread,
+                         args=(export, settings, save_path, dialog, cancel_token), daemon=True).start()
+
+    def run_confluence_push_thread(self, export, settings, save_path: str, dialog: PublishDialog,
+                                   cancel_token: CancellationToken) -> None:
+        from orlando_toolkit.core.confluence import push_confluence
+
+        try:
+            dialog.status(tr("Publishing to Confluence…"))
+            push_confluence(export, settings, on_progress=dialog.append, cancel_token=cancel_token)
+            dialog.finish({"confluence": Path(save_path)})
+        except OperationCancelledError:
+            logger.info("Confluence push cancelled")
+            dialog.finish()
+        except Exception as exc:
+            logger.error("Confluence push failed", exc_info=True)
+            dialog.finish(error=describe_error(exc).format())
+        finally:
+            self._cancel_token = None
+
+    @staticmethod
+    def _s1000d_enabled() -> bool:
+        from orlando_toolkit.core.s1000d import S1000DSettings
+
+        return S1000DSettings.from_config().enabled
+
+    def export_s1000d(self) -> None:
+        """Write the edited content as S1000D data modules with their DMRL (experimental)."""
+        ctx = getattr(self.structure_tab, "context", None) if self.structure_tab else None
+        if ctx is None:
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
+            return
+        try:
+            if getattr(self, "metadata_tab", None):
+                self.metadata_tab.commit()
+        except Exception:
+            pass
+
+        from orlando_toolkit.core.s1000d import S1000DSettings, export_s1000d
+
+        manual_code = (self.dita_context.metadata.get("manual_code") if self.dita_context else None) or "dita_project"
+        save_path = filedialog.asksaveasfilename(
+            title=tr("Save S1000D data modules"),
+            defaultextension=".zip",
+            filetypes=(("ZIP", "*.zip"),),
+            initialfile=f"{manual_code}_s1000d.zip",
+        )
+        if not save_path:
+            return
+        try:
+            export = export_s1000d(self._working_context_snapshot(), S1000DSettings.from_config())
+            export.write_zip(save_path)
+        except Exception as exc:
+            logger.error("S1000D export failed", exc_info=True)
+            messagebox.showerror(tr("Export to S1000D"), describe_error(exc).format())
+            return
+        message = tr("{count} data module(s) written to\n{path}", count=len(export.modules), path=save_path)
+        if export.changes:
+            message += "\n\n" + tr("Simplified for S1000D:") + "\n" + "\n".join(f"• {change}" for change in export.changes)
+        messagebox.showinfo(tr("Export to S1000D"), message)
+
+    def export_review_bundle(self) -> None:
+        """Write every topic as standalone HTML with a map sidebar, zipped for reviewers."""
+        ctx = getattr(self.structure_tab, "context", None) if self.structure_tab else None
+        if ctx is None:
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
+            return
+        try:
+            if getattr(self, "metadata_tab", None):
+                self.metadata_tab.commit()
+        except Exception:
+            pass
+
+        from orlando_toolkit.core.review_bundle import build_review_bundle
+
+        manual_code = (self.dita_context.metadata.get("manual_code") if self.dita_context else None) or "dita_project"
+        save_path = filedialog.asksaveasfilename(
+            title=tr("Save review bundle"),
+            defaultextension=".zip",
+            filetypes=(("ZIP", "*.zip"),),
+            initialfile=f"{manual_code}_review.zip",
+        )
+        if not save_path:
+            return
+        try:
+            bundle = build_review_bundle(self._working_context_snapshot())
+            bundle.write_zip(save_path)
+        except Exception as exc:
+            logger.error("Review bundle export failed", exc_info=True)
+            messagebox.showerror(tr("Export Review Bundle"), describe_error(exc).format())
+            return
+        message = tr("{count} topic page(s) written to\n{path}\n\nOpen index.html after unzipping.",
+                     count=len(bundle.pages), path=save_path)
+        if bundle.warnings:
+            message += "\n\n" + tr("Shown as XML:") + "\n" + "\n".join(bundle.warnings[:10])
+        messagebox.showinfo(tr("Export Review Bundle"), message)
+
+    def compare_with_package(self) -> None:
+        """Write a delivery note between a delivered package and the session as it would be packaged."""
+        ctx = self._working_context_snapshot() if self.structure_tab else None
+        if ctx is None:
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
+            return
+        package_path = filedialog.askopenfilename(
+            title=tr("Previously delivered package"),
+            filetypes=((tr("DITA package"), "*.zip *.ditamap"), (tr("All files"), "*.*")),
+        )
+        if not package_path:
+            return
+        manual_code = ctx.metadata.get("manual_code") or "dita_project"
+        save_path = filedialog.asksaveasfilename(
+            title=tr("Save delivery note"),
+            defaultextension=".html",
+            filetypes=(("HTML", "*.html"), ("CSV", "*.csv")),
+            initialfile=f"{manual_code}_changes.html",
+        )
+        if not save_path:
+            return
+
+        from orlando_toolkit.core.package_diff import compare_session
+
+        try:
+            diff = compare_session(package_path, ctx, service=self.service)
+            if save_path.lower().endswith(".csv"):
+                Path(save_path).write_text(diff.to_csv(), encoding="utf-8", newline="")
+            else:
+                Path(save_path).write_text(diff.to_html(), encoding="utf-8")
+        except Exception as exc:
+            logger.error("Package comparison failed", exc_info=True)
+            messagebox.showerror(tr("Compare with Package"), describe_error(exc).format())
+            return
+        messagebox.showinfo(tr("Compare with Package"),
+                            diff.summary() + "\n\n" + tr("Written to\n{path}", path=save_path))
+
+    def export_fidelity_report(self) -> None:
+        """Write what of the Word source reached the topics, for auditors."""
+        ctx = self._working_context_snapshot() if self.structure_tab else None
+        if ctx is None:
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
+            return
+        manual_code = ctx.metadata.get("manual_code") or "dita_project"
+        save_path = filedialog.asksaveasfilename(
+            title=tr("Save fidelity report"),
+            defaultextension=".html",
+            filetypes=(("HTML", "*.html"), ("CSV", "*.csv")),
+            initialfile=f"{manual_code}_fidelity.html",
+        )
+        if not save_path:
+            return
+
+        from orlando_toolkit.core.fidelity import FidelityUnavailable, fidelity_report
+
+        try:
+            report = fidelity_report(ctx)
+            if save_path.lower().endswith(".csv"):
+                Path(save_path).write_text(report.to_csv(), encoding="utf-8", newline="")
+            else:
+                Path(save_path).write_text(report.to_html(), encoding="utf-8")
+        except FidelityUnavailable as exc:
+            messagebox.showerror(tr("Fidelity Report"), str(exc))
+            return
+        except Exception as exc:
+            logger.error("Fidelity report failed", exc_info=True)
+            messagebox.showerror(tr("Fidelity Report"), describe_error(exc).format())
+            return
+        messagebox.showinfo(tr("Fidelity Report"),
+                            report.summary() + "\n\n" + tr("Written to\n{path}", path=save_path))
+
+    def export_outline(self) -> None:
+        """Write the numbered structure tree to CSV or Excel for review sign-off."""
+        ctx = getattr(self.structure_tab, "context", None) if self.structure_tab else None
+        if ctx is None:
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
+            return
+        manual_code = ctx.metadata.get("manual_code") or "dita_project"
+        save_path = filedialog.asksaveasfilename(
+            title=tr("Save outline"),
+            defaultextension=".xlsx",
+            filetypes=(("Excel workbook", "*.xlsx"), ("CSV", "*.csv")),
+            initialfile=f"{manual_code}_outline.xlsx",
+        )
+        if not save_path:
+            return
+
+        from orlando_toolkit.core.outline import outline_rows, write_outline
+
+        try:
+            rows = outline_rows(ctx)
+            write_outline(rows, save_path)
+        except Exception as exc:
+            logger.error("Outline export failed", exc_info=True)
+            messagebox.showerror(tr("Export Outline"), describe_error(exc).format())
+            return
+        messagebox.showinfo(tr("Export Outline"),
+                            tr("{count} entries written to\n{path}\n\n"
+                               "Reviewers may edit the Title and Level columns and reorder the "
+                               "rows, then use Import Outline… to apply the changes.", count=len(rows), path=save_path))
+
+    def import_outline(self) -> None:
+        """Apply the titles, order and levels of a reviewed outline sheet to the map."""
+        if self.structure_tab is None or getattr(self.structure_tab, "context", None) is None:
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
+            return
+        path = filedialog.askopenfilename(
+            title=tr("Open reviewed outline"),
+            filetypes=(("Outline", "*.xlsx *.csv"), ("Excel workbook", "*.xlsx"), ("CSV", "*.csv")),
+        )
+        if not path:
+            return
+
+        from orlando_toolkit.core.outline import read_outline
+
+        try:
+            rows = read_outline(path)
+        except Exception as exc:
+            logger.error("Outline import failed", exc_info=True)
+            messagebox.showerror(tr("Import Outline"), describe_error(exc).format())
+            return
+        result = self.structure_tab.apply_outline(rows)
+        if result is None:
+            return
+        problems = (getattr(result, "details", None) or {}).get("problems") or []
+        if problems:
+            shown = "\n".join(problems[:15]) + (
+                "\n" + tr("… {count} more", count=len(problems) - 15) if len(problems) > 15 else "")
+            messagebox.showerror(tr("Import Outline"),
+                                 f"{result.message} " + tr("Nothing was changed.") + f"\n\n{shown}")
+        elif result.success:
+            messagebox.showinfo(tr("Import Outline"), result.message)
+        else:
+            messagebox.showinfo(tr("Import Outline"), result.message or tr("The outline was not applied."))
+
+    # ------------------------------------------------------------------
+    # Translation (XLIFF)
+    # ------------------------------------------------------------------
+
+    def export_xliff(self) -> None:
+        """Write the translatable text of the edited content to an XLIFF 2.1 file."""
+        ctx = getattr(self.structure_tab, "context", None) if self.structure_tab else None
+        if ctx is None:
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
+            return
+        language = simpledialog.askstring(tr("Export XLIFF"), tr("Target language (for example de-DE):"),
+                                          parent=self.root)
+        if not language or not language.strip():
+            return
+        manual_code = (self.dita_context.metadata.get("manual_code") if self.dita_context else None) or "dita_project"
+        save_path = filedialog.asksaveasfilename(
+            title=tr("Save XLIFF file"),
+            defaultextension=".xlf",
+            filetypes=(("XLIFF", "*.xlf *.xliff"),),
+            initialfile=f"{manual_code}_{language.strip()}.xlf",
+        )
+        if not save_path:
+            return
+
+        from orlando_toolkit.core.xliff import export_xliff
+
+        try:
+            units = export_xliff(self._working_context_snapshot(), save_path, target_language=language)
+        except Exception as exc:
+            logger.error("XLIFF export failed", exc_info=True)
+            messagebox.showerror(tr("Export XLIFF"), describe_error(exc).format())
+            return
+        messagebox.showinfo(tr("Export XLIFF"), tr("{count} block(s) written to\n{path}", count=units, path=save_path))
+
+    def import_translation(self) -> None:
+        """Merge a translated XLIFF file into a copy of the content and write it as a package."""
+        ctx = getattr(self.structure_tab, "context", None) if self.structure_tab else None
+        if ctx is None:
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
+            return
+        xliff_path = filedialog.askopenfilename(title=tr("Select the translated XLIFF file"),
+                                                filetypes=(("XLIFF", "*.xlf *.xliff"), (tr("All files"), "*.*")))
+        if not xliff_path:
+            return
+        try:
+            if getattr(self, "metadata_tab", None):
+                self.metadata_tab.commit()
+        except Exception:
+            pass
+        manual_code = (self.dita_context.metadata.get("manual_code") if self.dita_context else None) or "dita_project"
+        save_path = filedialog.asksaveasfilename(
+            title=tr("Save translated DITA archive"),
+            defaultextension=".zip",
+            filetypes=(("ZIP", "*.zip"),),
+            initialfile=f"{Path(xliff_path).stem or manual_code}.zip",
+        )
+        if not save_path:
+            return
+
+        cancel_token = self._begin_cancellable_operation()
+        self._show_loading_spinner(tr("Importing Translation"), "", cancel_token=cancel_token)
+        threading.Thread(target=self.run_translation_thread, args=(xliff_path, save_path, cancel_token),
+                         daemon=True).start()
+
+    def run_translation_thread(self, xliff_path: str, save_path: str, cancel_token: CancellationToken) -> None:
+        from orlando_toolkit.core.xliff import import_xliff
+
+        try:
+            result = import_xliff(self._working_context_snapshot(), xliff_path)  # type: ignore[arg-type]
+            ctx = self.service.prepare_package(result.context, cancel_token=cancel_token)
+            self.service.write_package(ctx, save_path, cancel_token=cancel_token)
+            self.root.after(0, self.on_translation_done, result, save_path)
+        except OperationCancelledError:
+            logger.info("Translation import cancelled: %s", xliff_path)
+            self.root.after(0, self.on_operation_cancelled)
+        except Exception as exc:
+            logger.error("Translation import failed for %s", xliff_path, exc_info=True)
+            self.root.after(0, self.on_generation_failure, exc)
+
+    def on_translation_done(self, result, save_path: str) -> None:
+        self._cancel_token = None
+        self._hide_loading_spinner()
+        lines = [e.message for e in result.context.report.entries if e.category == "translation"]
+        messagebox.showinfo(tr("Import Translation"), "\n".join([*lines, "", tr("Archive written to\n{path}", path=save_path)]))
+
+    # ------------------------------------------------------------------
+    # Project files
+    # ------------------------------------------------------------------
+
+    def save_project_file(self) -> None:
+        if not self.dita_context:
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
+            return
+        try:
+            if getattr(self, "metadata_tab", None):
+                self.metadata_tab.commit()
+        except Exception:
+            pass
+
+        default_name = (self.project_path.name if self.project_path
+                        else f"{self.dita_context.metadata.get('manual_code') or 'dita_project'}{PROJECT_EXTENSION}")
+        save_path = filedialog.asksaveasfilename(
+            title=tr("Save project"),
+            defaultextension=PROJECT_EXTENSION,
+            filetypes=(("Orlando Toolkit project", f"*{PROJECT_EXTENSION}"),),
+            initialfile=default_name,
+        )
+        if not save_path:
+            return
+        self._show_loading_spinner(tr("Saving Project"), "")
+        threading.Thread(target=self.run_project_save_thread, args=(save_path,), daemon=True).start()
+
+    def run_project_save_thread(self, save_path: str) -> None:
+        try:
+            written = save_project(self._working_context_snapshot(), save_path)  # type: ignore[arg-type]
+            self.root.after(0, self.on_project_saved, written)
+        except Exception as exc:
+            logger.error("Saving project failed", exc_info=True)
+            self.root.after(0, self.on_generation_failure, exc)
+
+    def on_project_saved(self, path: Path) -> None:
+        self.project_path = path
         self._hide_loading_spinner()
-        messagebox.showerror("Generation error", str(error))
+        messagebox.showinfo(tr("Project saved"), tr("Project written to\n{path}", path=path))
+
+    def save_conversion_profile(self) -> None:
+        """Save the settings of this conversion as a named conversion profile."""
+        if not self.dita_context:
+            messagebox.showerror(tr("Error"), tr("No DITA context is loaded."))
+            return
+        from orlando_toolkit.core.profiles import profile_from_metadata, save_profile
+
+        try:
+            if getattr(self, "metadata_tab", None):
+                self.metadata_tab.commit()
+        except Exception:
+            pass
+        current = self.conversion_profile_var.get()
+        name = simpledialog.askstring(tr("Save Profile"), tr("Profile name:"), parent=self.root,
+                                      initialvalue="" if current == _automatic_profile() else current)
+        if not name:
+            return
+        metadata = dict(self.dita_context.metadata)
+        depth = getattr(self.structure_tab, "max_depth", None) if self.structure_tab else None
+        if depth:
+            metadata["topic_depth"] = depth
+        data = profile_from_metadata(metadata)
+        try:
+            try:
+                path = save_profile(name, data, overwrite=False)
+            except FileExistsError:
+                if not messagebox.askyesno(tr("Save Profile"), tr("Replace the saved profile {name}?", name=name)):
+                    return
+                path = save_profile(name, data)
+        except (OSError, ValueError) as exc:
+            messagebox.showerror(tr("Save Profile"), describe_error(exc).format())
+            return
+        self.conversion_profile_var.set(name.strip())
+        messagebox.showinfo(tr("Save Profile"),
+                            tr("Profile {name} saved to\n{path}\n\n"
+                               "Select it on the home screen or with --profile on the command line.",
+                               name=name.strip(), path=path))
 
     # ------------------------------------------------------------------
     # Exit handling
     # ------------------------------------------------------------------
 
     def on_close(self):
-        if messagebox.askokcancel("Quit", "Really quit?"):
+        if messagebox.askokcancel(tr("Quit"), tr("Really quit?")):
+            # Stop any in-flight background work so temp folders are released
+            if self._cancel_token is not None:
+                self._cancel_token.cancel("Application closing")
             # Cleanup session storage (preview/images edits)
             try:
                 from orlando_toolkit.core.session_storage import get_session_storage
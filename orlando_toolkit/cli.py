@@ -0,0 +1,689 @@
+from __future__ import annotations
+
+"""Command-line interface (``python -m orlando_toolkit``).
+
+Subcommands:
+
+- ``convert INPUT... [--out PATH] [--profile NAME] [--options FILE]
+  [--metadata KEY=VALUE]``: headless conversion to DITA archives, for build
+  servers. Inputs may be glob patterns (``docs/**/*.docx``); several inputs
+  are written as ``<name>.zip`` into the ``--out`` directory. The exit code
+  is 0 when every document converted, 1 when one failed, 2 for invalid
+  arguments (no matching input, unknown profile) and 3 when a report
+  reached ``--fail-on`` (warnings or a partial conversion). ``--publish
+  TRANSTYPE`` also runs DITA-OT on each archive (``pdf2``, ``html5``, …;
+  :mod:`orlando_toolkit.core.services.publishing_service`), a failed build
+  counting as a failed document. ``--tree`` writes each package as a folder
+  (``maps/``, ``topics/``, ``media/``) to commit to git instead of a ZIP,
+  removing files an earlier run wrote that are gone; ``--force`` allows a
+  non-empty folder the toolkit did not write
+  (:mod:`orlando_toolkit.core.package_tree`). ``--dialect`` writes DITA 2.0
+  or Lightweight DITA (``xdita``) instead of DITA 1.3
+  (:mod:`orlando_toolkit.core.dialects`). ``--progress`` prints the progress
+  of each phase; Ctrl+C cancels cleanly (exit code 130, no partial archive)
+  and a second Ctrl+C aborts at once. ``--perf`` prints the time and peak
+  memory of each phase (parse, analysis, topics, images, packaging) and
+  ``--perf-json`` writes them with the stage timings to
+  ``<archive>.perf.json`` (:mod:`orlando_toolkit.core.performance`);
+- ``resume [--list] [--discard ID]``: finishes packagings interrupted by a
+  crash from their workspace (:mod:`orlando_toolkit.core.jobs`);
+- ``history list [--kind KIND] [--source NAME] [--limit N]``: past
+  conversions, packages and projects, newest first;
+- ``history show ID [--json]``: one record (an id prefix is enough);
+- ``history compare OLD NEW``: what changed between two records (stats,
+  report entries per severity and category, settings);
+- ``profile list`` / ``profile export NAME FILE`` / ``profile import FILE
+  [--name NAME] [--force]`` / ``profile delete NAME``: conversion profiles
+  saved as portable YAML or JSON files (:mod:`orlando_toolkit.core.profiles`);
+  ``convert --profile`` takes a profile name or a profile file;
+- ``stats show`` / ``stats export FILE`` / ``stats reset``: the opt-in
+  anonymous usage statistics (:mod:`orlando_toolkit.core.usage_stats`);
+- ``compare OLD NEW [--html FILE] [--json FILE] [--csv FILE]``: what changes
+  at DITA level between two revisions of a document
+  (:mod:`orlando_toolkit.core.compare`); two packages (``.zip`` or
+  ``.ditamap``) are compared as delivered, with map structure and image
+  changes (:mod:`orlando_toolkit.core.package_diff`);
+- ``serve [--host HOST] [--port N] [--workers N]``: HTTP service taking
+  conversion jobs from other systems (:mod:`orlando_toolkit.server`);
+- ``confluence INPUT [--zip FILE] [--push]``: Confluence pages of a document,
+  one per topic, as an importable zip or pushed to ``confluence`` of
+  ``pipeline.yml`` (:mod:`orlando_toolkit.core.confluence`); an INPUT
+  written earlier with ``--zip`` is pushed as it is;
+- ``s1000d INPUT --zip FILE``: experimental S1000D data modules of a
+  document with their DMRL and publication module, coded from ``s1000d`` of
+  ``pipeline.yml`` (:mod:`orlando_toolkit.core.s1000d`);
+- ``watch [--folder DIR] [--profile NAME] [--out DIR] [--once]``: converts
+  documents dropped into the folders of ``watch`` in ``pipeline.yml``
+  (:mod:`orlando_toolkit.watch`), with retries, quarantine and a summary.
+"""
+
+import argparse
+import contextlib
+import glob
+import json
+import signal
+import sys
+import threading
+import zipfile
+from pathlib import Path
+from typing import Any, Dict, List, Optional
+
+from orlando_toolkit.core.cancellation import CancellationToken, OperationCancelledError
+from orlando_toolkit.core.compare import compare_documents
+from orlando_toolkit.core.dialects import DIALECTS
+from orlando_toolkit.core.errors import ToolkitError, describe_error
+from orlando_toolkit.core.history import KINDS, compare_entries, get_history_store
+from orlando_toolkit.core.jobs import ConversionJob, pending_workspaces
+from orlando_toolkit.core.package_diff import compare_packages
+from orlando_toolkit.core.performance import PerformanceRecorder, PerformanceSettings
+from orlando_toolkit.core.progress import as_event, overall_fraction
+from orlando_toolkit.core.usage_stats import get_usage_stats
+
+__all__ = ["EXIT_CANCELLED", "EXIT_FAILED", "EXIT_OK", "EXIT_REPORT", "EXIT_USAGE", "build_parser", "main"]
+
+EXIT_OK = 0
+EXIT_FAILED = 1
+EXIT_USAGE = 2
+EXIT_REPORT = 3
+EXIT_CANCELLED = 130
+_FAIL_ON = ("error", "warning", "never")
+
+
+def _history_list(args: argparse.Namespace) -> int:
+    entries = get_history_store().list(kind=args.kind, source=args.source, limit=args.limit)
+    if not entries:
+        print("No history recorded.")
+        return 0
+    for entry in entries:
+        print(f"{entry.id}  {entry.timestamp}  {entry.kind:<10}  {entry.source_name}  ({entry.summary()})")
+    return 0
+
+
+def _history_show(args: argparse.Namespace) -> int:
+    entry = get_history_store().get(args.id)
+    if entry is None:
+        print(f"No history record {args.id!r}", file=sys.stderr)
+        return 1
+    if args.json:
+        print(json.dumps(entry.to_dict(), ensure_ascii=False, indent=2))
+        return 0
+    print(f"Record:    {entry.id} ({entry.kind})")
+    print(f"Time:      {entry.timestamp}")
+    print(f"Source:    {entry.source.get('path') or entry.source_name}")
+    if entry.target:
+        print(f"Output:    {entry.target}")
+    if entry.plugin:
+        print(f"Plugin:    {entry.plugin}")
+    for key, value in sorted(entry.stats.items()):
+        print(f"{key + ':':<10} {value}")
+    for item in (entry.report or {}).get("entries", []):
+        print(f"  [{item.get('severity')}] {item.get('category')}: {item.get('message')}")
+    return 0
+
+
+def _history_compare(args: argparse.Namespace) -> int:
+    store = get_history_store()
+    old, new = store.get(args.old), store.get(args.new)
+    for wanted, entry in ((args.old, old), (args.new, new)):
+        if entry is None:
+            print(f"No history record {wanted!r}", file=sys.stderr)
+            return 1
+    diff = compare_entries(old, new)
+    if diff["source_changed"]:
+        print("Source document changed between the two records.")
+    for section in ("stats", "report", "settings"):
+        for key, change in diff[section].items():
+            print(f"{section}.{key}: {change['old']} -> {change['new']}")
+    if not any(diff[section] for section in ("stats", "report", "settings")):
+        print("No differences.")
+    return 0
+
+
+def _profile_list(args: argparse.Namespace) -> int:
+    from orlando_toolkit.core.profiles import available_profiles
+
+    profiles = available_profiles()
+    if not profiles:
+        print("No conversion profiles.")
+    for name, data in sorted(profiles.items()):
+        print(f"{name:<20} {data.get('description') or ''}".rstrip())
+    return 0
+
+
+def _profile_export(args: argparse.Namespace) -> int:
+    from orlando_toolkit.core.profiles import export_profile
+
+    try:
+        path = export_profile(args.name, args.file)
+    except (OSError, ValueError) as exc:
+        print(f"orlando profile: {exc}", file=sys.stderr)
+        return EXIT_USAGE
+    print(f"Profile {args.name} written to {path}")
+    return 0
+
+
+def _profile_import(args: argparse.Namespace) -> int:
+    from orlando_toolkit.core.profiles import import_profile
+
+    try:
+        name = import_profile(args.file, name=args.name, overwrite=args.force)
+    except (OSError, ValueError) as exc:
+        print(f"orlando profile: {exc}", file=sys.stderr)
+        return EXIT_USAGE
+    print(f"Profile {name} imported.")
+    return 0
+
+
+def _profile_delete(args: argparse.Namespace) -> int:
+    from orlando_toolkit.core.profiles import delete_profile
+
+    if not delete_profile(args.name):
+        print(f"No saved profile {args.name!r}", file=sys.stderr)
+        return 1
+    print(f"Profile {args.name} deleted.")
+    return 0
+
+
+def _stats_show(args: argparse.Namespace) -> int:
+    stats = get_usage_stats()
+    if not stats.enabled:
+        print("Usage statistics are off (set usage_stats.enabled in pipeline.yml to collect them).")
+    print(json.dumps(stats.snapshot(), ensure_ascii=False, indent=2, sort_keys=True))
+    return 0
+
+
+def _stats_export(args: argparse.Namespace) -> int:
+    print(f"Usage statistics written to {get_usage_stats().export(args.file)}")
+    return 0
+
+
+def _stats_reset(args: argparse.Namespace) -> int:
+    get_usage_stats().reset()
+    print("Usage statistics cleared.")
+    return 0
+
+
+_PACKAGE_SUFFIXES = (".zip", ".ditamap")
+
+
+def _compare(args: argparse.Namespace) -> int:
+    packages = all(Path(p).suffix.lower() in _PACKAGE_SUFFIXES for p in (args.old, args.new))
+    if args.csv and not packages:
+        print("orlando compare: --csv needs two packages (.zip or .ditamap)", file=sys.stderr)
+        return EXIT_USAGE
+    diff = compare_packages(args.old, args.new) if packages else None
+    report = diff.content if diff is not None else compare_documents(args.old, args.new)
+    result = diff if diff is not None else report
+    if args.html:
+        Path(args.html).write_text(result.to_html(), encoding="utf-8")
+    if args.json:
+        Path(args.json).write_text(result.to_json(), encoding="utf-8")
+    if args.csv:
+        Path(args.csv).write_text(diff.to_csv(), encoding="utf-8", newline="")
+    print(result.summary())
+    for change in diff.structure if diff is not None else ():
+        print(f"  [{change.status}] map: {change.path}" + (f" ({change.detail})" if change.detail else ""))
+    for topic in report.topics:
+        print(f"  [{topic.status}] {topic.path}" + (f" ({len(topic.blocks)} block(s))" if topic.blocks else ""))
+    for image in diff.images if diff is not None else ():
+        print(f"  [{image.status}] image: {image.name}" + (f" (was {image.old_name})" if image.old_name else ""))
+    return 0
+
+
+def _serve(args: argparse.Namespace) -> int:
+    from dataclasses import replace
+
+    from orlando_toolkit.server import ServerSettings, serve
+
+    settings = ServerSettings.from_config()
+    overrides = {k: v for k, v in (("host", args.host), ("port", args.port), ("workers", args.workers)) if v}
+    try:
+        serve(replace(settings, **overrides), plugins=args.plugin or not args.no_plugins)
+    except OSError as exc:
+        print(f"orlando serve: {exc}", file=sys.stderr)
+        return EXIT_USAGE
+    return 0
+
+
+def _confluence(args: argparse.Namespace) -> int:
+    from orlando_toolkit.core.confluence import ConfluenceExport, ConfluenceSettings, export_confluence, push_confluence
+
+    if not args.zip and not args.push:
+        print("orlando confluence: give --zip FILE, --push or both", file=sys.stderr)
+        return EXIT_USAGE
+    source = Path(args.input)
+    if not source.is_file():
+        print(f"orlando confluence: {source} does not exist", file=sys.stderr)
+        return EXIT_USAGE
+    settings = ConfluenceSettings.from_config()
+    try:
+        export = ConfluenceExport.read_zip(source)
+    except (KeyError, ValueError, OSError, zipfile.BadZipFile):
+        from orlando_toolkit.api import ConversionError, Toolkit
+
+        try:
+            result = Toolkit(plugins=args.plugin or not args.no_plugins).convert(source)
+        except ConversionError as exc:
+            print(f"FAILED {source}: [{exc.info.code}] {exc.info.message}", file=sys.stderr)
+            return EXIT_FAILED
+        export = export_confluence(result.context, settings)
+    if args.zip:
+        print(f"{source} -> {export.write_zip(args.zip)} ({len(export.pages)} page(s))")
+    if args.push:
+        try:
+            ids = push_confluence(export, settings, on_progress=print)
+        except ToolkitError as exc:
+            info = describe_error(exc)
+            print(f"FAILED {source}: [{info.code}] {info.message}", file=sys.stderr)
+            if info.hint:
+                print(f"       {info.hint}", file=sys.stderr)
+            return EXIT_FAILED
+        print(f"{len(ids)} page(s) published to space {settings.space_key}")
+    return 0
+
+
+def _s1000d(args: argparse.Namespace) -> int:
+    from orlando_toolkit.api import ConversionError, Toolkit
+    from orlando_toolkit.core.s1000d import S1000DSettings, export_s1000d
+
+    source = Path(args.input)
+    if not source.is_file():
+        print(f"orlando s1000d: {source} does not exist", file=sys.stderr)
+        return EXIT_USAGE
+    try:
+        result = Toolkit(plugins=args.plugin or not args.no_plugins).convert(source)
+    except ConversionError as exc:
+        print(f"FAILED {source}: [{exc.info.code}] {exc.info.message}", file=sys.stderr)
+        return EXIT_FAILED
+    try:
+        export = export_s1000d(result.context, S1000DSettings.from_config())
+    except ToolkitError as exc:
+        info = describe_error(exc)
+        print(f"FAILED {source}: [{info.code}] {info.message}", file=sys.stderr)
+        if info.hint:
+            print(f"       {info.hint}", file=sys.stderr)
+        return EXIT_FAILED
+    print(f"{source} -> {export.write_zip(args.zip)} ({len(export.modules)} data module(s))")
+    for change in export.changes:
+        print(f"  simplified: {change}")
+    return 0
+
+
+def _watch(args: argparse.Namespace) -> int:
+    from dataclasses import replace
+
+    from orlando_toolkit.watch import WatchFolder, WatchSettings, summary_text, watch
+
+    settings = WatchSettings.from_config()
+    if args.folder:
+        settings = replace(settings, folders=tuple(WatchFolder(f, args.profile, args.out) for f in args.folder))
+    if args.poll:
+        settings = replace(settings, poll_seconds=max(1.0, args.poll))
+    if not settings.folders:
+        print("orlando watch: give --folder DIR or set watch.folders in pipeline.yml", file=sys.stderr)
+        return EXIT_USAGE
+    missing = [f.path for f in settings.folders if not Path(f.path).is_dir()]
+    if missing:
+        print(f"orlando watch: {', '.join(missing)} is not a folder", file=sys.stderr)
+        return EXIT_USAGE
+    try:
+        results = watch(settings, plugins=args.plugin or not args.no_plugins, once=args.once)
+    except ValueError as exc:
+        print(f"orlando watch: {exc}", file=sys.stderr)
+        return EXIT_USAGE
+    if args.once:
+        print(summary_text(results) if results else "No document to convert")
+        return EXIT_FAILED if any(r.outcome != "converted" for r in results) else 0
+    return 0
+
+
+def _expand_inputs(patterns: List[str]) -> List[Path]:
+    paths: List[Path] = []
+    for pattern in patterns:
+        matches = sorted(glob.glob(pattern, recursive=True)) if glob.has_magic(pattern) else [pattern]
+        files = [Path(m) for m in matches if Path(m).is_file()]
+        if not files:
+            raise ValueError(f"No input file matches {pattern!r}")
+        paths.extend(f for f in files if f not in paths)
+    return paths
+
+
+def _parse_metadata(pairs: List[str]) -> Dict[str, Any]:
+    fields: Dict[str, Any] = {}
+    for pair in pairs:
+        key, sep, value = pair.partition("=")
+        if not sep or not key.strip():
+            raise ValueError(f"--metadata expects KEY=VALUE, got {pair!r}")
+        fields[key.strip()] = value
+    return fields
+
+
+def _output_paths(inputs: List[Path], out: Optional[str], *, tree: bool = False) -> List[Path]:
+    suffix = "" if tree else ".zip"
+    if len(inputs) == 1 and out and (tree or not Path(out).is_dir() and Path(out).suffix.lower() == ".zip"):
+        return [Path(out)]
+    folder = Path(out) if out else Path.cwd()
+    targets: List[Path] = []
+    for source in inputs:
+        target, n = folder / f"{source.stem}{suffix}", 2
+        while target in targets:
+            target, n = folder / f"{source.stem}_{n}{suffix}", n + 1
+        targets.append(target)
+    return targets
+
+
+def _reached(report: Any, fail_on: str) -> bool:
+    if fail_on == "never":
+        return False
+    if fail_on == "warning" and (report.count("warning") or report.partial):
+        return True
+    return bool(report.count("error"))
+
+
+def _convert(args: argparse.Namespace) -> int:
+    from orlando_toolkit.api import ConversionError, Toolkit
+    from orlando_toolkit.options import ConversionOptions, build_options, with_metadata, with_output_profile
+
+    try:
+        inputs = _expand_inputs(args.inputs)
+        options = build_options(
+            *(with_output_profile(name) for name in args.profile),
+            ConversionOptions.load(args.options) if args.options else None,
+            with_metadata(**_parse_metadata(args.metadata)),
+            with_metadata(dialect=args.dialect) if args.dialect else None,
+        )
+    except (OSError, ValueError) as exc:
+        print(f"orlando convert: {exc}", file=sys.stderr)
+        return EXIT_USAGE
+    if args.publish and args.tree:
+        print("orlando convert: --publish needs ZIP archives, not --tree", file=sys.stderr)
+        return EXIT_USAGE
+    targets = _output_paths(inputs, args.out, tree=args.tree)
+    toolkit = Toolkit(plugins=args.plugin or not args.no_plugins)
+    perf = PerformanceSettings.from_config()
+    profiling = args.perf or args.perf_json or perf.enabled or bool(perf.json_dir)
+    publisher = None
+    if args.publish:
+        from orlando_toolkit.core.services.publishing_service import PublishingService
+
+        publisher = PublishingService()
+
+    def _work(job: ConversionJob, source: Path, target: Path):
+        result = toolkit.convert(source, options, progress=job, cancel_token=job.token)
+        if args.tree:
+            return result, result.write_tree(target, force=args.force, cancel_token=job.token)
+        result.write_archive(target, cancel_token=job.token, progress=job)
+        return result, None
+
+    token = CancellationToken()
+    failed = flagged = 0
+    for source, target in zip(inputs, targets):
+        job = ConversionJob(str(source), token=token)
+        if args.progress:
+            job.subscribe(_print_progress)
+        recorder = PerformanceRecorder(memory=perf.memory) if profiling else None
+        try:
+            with _cancel_on_interrupt(token), recorder.active() if recorder else contextlib.nullcontext():
+                result, written = job.run(lambda job: _work(job, source, target))
+        except OperationCancelledError:
+            print(f"CANCELLED {source}: no archive written", file=sys.stderr)
+            return EXIT_CANCELLED
+        except ConversionError as exc:
+            failed += 1
+            info = exc.info
+            print(f"FAILED {source}: [{info.code}] {info.message}", file=sys.stderr)
+            if info.hint:
+                print(f"       {info.hint}", file=sys.stderr)
+            continue
+        report = result.report
+        if _reached(report, args.fail_on):
+            flagged += 1
+        if not args.quiet:
+            print(f"{source} -> {target} ({len(result.context.topics)} topic(s); {report.summary()})")
+            if args.tree:
+                print(f"  {written.summary()}")
+        if args.report:
+            report_path = target.parent / f"{target.name}.report.json" if args.tree else target.with_suffix(".report.json")
+            report_path.write_text(report.to_json(), encoding="utf-8")
+        if recorder is not None:
+            _report_performance(recorder, result, source, target, args, perf)
+        if publisher is not None:
+            try:
+                outputs = publisher.publish(target, args.publish)
+            except ToolkitError as exc:
+                failed += 1
+                info = describe_error(exc)
+                print(f"FAILED {target}: [{info.code}] {info.message}", file=sys.stderr)
+                if info.hint:
+                    print(f"       {info.hint}", file=sys.stderr)
+                continue
+            if not args.quiet:
+                for transtype, folder in outputs.items():
+                    print(f"  {transtype} -> {folder}")
+    if not args.quiet and len(inputs) > 1:
+        print(f"{len(inputs) - failed} of {len(inputs)} document(s) converted")
+    if failed:
+        return EXIT_FAILED
+    return EXIT_REPORT if flagged else EXIT_OK
+
+
+def _report_performance(recorder: PerformanceRecorder, result: Any, source: Path, target: Path,
+                        args: argparse.Namespace, settings: PerformanceSettings) -> None:
+    """Print the measurements of one document (``--perf``) and write them as JSON (``--perf-json``, ``json_dir``)."""
+    if args.perf or settings.enabled:
+        print(recorder.summary(result.report))
+    paths = []
+    if args.perf_json:
+        paths.append(target.parent / f"{target.name}.perf.json" if args.tree else target.with_suffix(".perf.json"))
+    if settings.json_dir:
+        paths.append(Path(settings.json_dir).expanduser() / f"{source.stem}.perf.json")
+    if not paths:
+        return
+    data = recorder.to_json(result.report, source=source, topics=len(result.context.topics),
+                            images=len(result.context.images))
+    for path in paths:
+        path.parent.mkdir(parents=True, exist_ok=True)
+        path.write_text(data, encoding="utf-8")
+
+
+@contextlib.contextmanager
+def _cancel_on_interrupt(token: CancellationToken):
+    """Make the first Ctrl+C cancel *token* cooperatively; a second one aborts at once."""
+    if threading.current_thread() is not threading.main_thread():
+        yield
+        return
+
+    def _interrupted(signum, frame) -> None:
+        if token.is_cancelled:
+            raise KeyboardInterrupt
+        print("Cancelling... (press Ctrl+C again to abort at once)", file=sys.stderr)
+        token.cancel("Interrupted")
+
+    previous = signal.signal(signal.SIGINT, _interrupted)
+    try:
+        yield
+    finally:
+        signal.signal(signal.SIGINT, previous)
+
+
+def _print_progress(event: str) -> None:
+    event = as_event(event)
+    print(f"[{overall_fraction(event):4.0%}] {event}", file=sys.stderr)
+
+
+def _resume(args: argparse.Namespace) -> int:
+    from orlando_toolkit.api import Toolkit
+
+    workspaces = pending_workspaces()
+    if args.discard:
+        matches = [w for w in workspaces if w.id.startswith(args.discard)]
+        if len(matches) != 1:
+            print(f"orlando resume: no single interrupted packaging matches {args.discard!r}", file=sys.stderr)
+            return EXIT_USAGE
+        matches[0].discard()
+        print(f"Discarded {matches[0].id}")
+        return EXIT_OK
+    if not workspaces:
+        print("No interrupted packaging to resume")
+        return EXIT_OK
+    if args.list:
+        for workspace in workspaces:
+            print(workspace.describe())
+        return EXIT_OK
+    service = Toolkit(plugins=args.plugin or not args.no_plugins).service
+    token = CancellationToken()
+    failed = 0
+    for workspace in workspaces:
+        job = ConversionJob(workspace.id, token=token)
+        if args.progress:
+            job.subscribe(_print_progress)
+        try:
+            with _cancel_on_interrupt(token):
+                output = job.run(lambda job: service.resume_package(workspace, cancel_token=job.token,
+                                                                    progress_callback=job))
+        except OperationCancelledError:
+            print(f"CANCELLED {workspace.id}", file=sys.stderr)
+            return EXIT_CANCELLED
+        except (ToolkitError, OSError) as exc:
+            failed += 1
+            info = describe_error(exc)
+            print(f"FAILED {workspace.id}: [{info.code}] {info.message}", file=sys.stderr)
+            continue
+        print(f"{workspace.id} -> {output}")
+    return EXIT_FAILED if failed else EXIT_OK
+
+
+def build_parser() -> argparse.ArgumentParser:
+    parser = argparse.ArgumentParser(prog="orlando", description="Orlando Toolkit command line")
+    commands = parser.add_subparsers(dest="command", required=True)
+
+    convert = commands.add_parser("convert", help="convert documents to DITA archives without the GUI")
+    convert.add_argument("inputs", nargs="+", metavar="INPUT", help="source file or glob pattern (quote it)")
+    convert.add_argument("--out", help="archive path for one input, otherwise the output directory")
+    convert.add_argument("--profile", action="append", default=[],
+                         help="output profile name (profiles.yml or saved profile) or profile file")
+    convert.add_argument("--options", help="YAML or JSON job file (metadata, conversion_options, ...)")
+    convert.add_argument("--metadata", action="append", default=[], metavar="KEY=VALUE",
+                         help="document field, e.g. manual_code=OM-12 or revision_date=2024-05-01")
+    convert.add_argument("--plugin", action="append", default=[], metavar="ID",
+                         help="activate exactly these plugins (default: those active in the application)")
+    convert.add_argument("--no-plugins", action="store_true", help="DITA, Markdown and AsciiDoc sources only")
+    convert.add_argument("--fail-on", choices=_FAIL_ON, default="error",
+                         help="report severity that makes the exit code 3 (default: error)")
+    convert.add_argument("--report", action="store_true", help="write <archive>.report.json next to each archive")
+    convert.add_argument("--tree", action="store_true",
+                         help="write each package as a maps/topics/media folder for version control instead of a ZIP")
+    convert.add_argument("--force", action="store_true",
+                         help="with --tree, replace the package folders of a non-empty folder not written by the toolkit")
+    convert.add_argument("--dialect", choices=DIALECTS,
+                         help="output dialect (default: output.dialect in conversion.yml, dita-1.3)")
+    convert.add_argument("--quiet", action="store_true", help="print failures only")
+    convert.add_argument("--perf", action="store_true",
+                         help="print the time and peak memory of each phase after each document")
+    convert.add_argument("--perf-json", action="store_true",
+                         help="write <archive>.perf.json (phase and stage timings, peak memory) next to each archive")
+    convert.add_argument("--progress", action="store_true", help="print the progress of each phase to stderr")
+    convert.add_argument("--publish", action="append", default=[], metavar="TRANSTYPE",
+                         help="also publish each archive with DITA-OT, e.g. pdf2 or html5 (repeatable)")
+    convert.set_defaults(func=_convert)
+
+    history = commands.add_parser("history", help="past conversions, packages and projects")
+    actions = history.add_subparsers(dest="action", required=True)
+    listing = actions.add_parser("list", help="list records, newest first")
+    listing.add_argument("--kind", choices=KINDS)
+    listing.add_argument("--source", help="only records whose source name contains this text")
+    listing.add_argument("--limit", type=int, default=20)
+    listing.set_defaults(func=_history_list)
+    show = actions.add_parser("show", help="show one record")
+    show.add_argument("id", help="record id or id prefix")
+    show.add_argument("--json", action="store_true", help="print the raw record")
+    show.set_defaults(func=_history_show)
+    compare = actions.add_parser("compare", help="compare two records")
+    compare.add_argument("old")
+    compare.add_argument("new")
+    compare.set_defaults(func=_history_compare)
+
+    profile = commands.add_parser("profile", help="saved conversion profiles")
+    actions = profile.add_subparsers(dest="action", required=True)
+    actions.add_parser("list", help="list the available profiles").set_defaults(func=_profile_list)
+    export = actions.add_parser("export", help="write a profile as a self-contained YAML or JSON file")
+    export.add_argument("name")
+    export.add_argument("file")
+    export.set_defaults(func=_profile_export)
+    imported = actions.add_parser("import", help="add a profile file to the saved profiles")
+    imported.add_argument("file")
+    imported.add_argument("--name", help="save under this name instead of the file's")
+    imported.add_argument("--force", action="store_true", help="replace a saved profile of the same name")
+    imported.set_defaults(func=_profile_import)
+    delete = actions.add_parser("delete", help="delete a saved profile")
+    delete.add_argument("name")
+    delete.set_defaults(func=_profile_delete)
+
+    stats = commands.add_parser("stats", help="opt-in anonymous usage statistics")
+    actions = stats.add_subparsers(dest="action", required=True)
+    actions.add_parser("show", help="print the collected counters").set_defaults(func=_stats_show)
+    export = actions.add_parser("export", help="write the counters to a JSON file")
+    export.add_argument("file")
+    export.set_defaults(func=_stats_export)
+    actions.add_parser("reset", help="delete the collected counters").set_defaults(func=_stats_reset)
+
+    compare = commands.add_parser("compare", help="change report between two revisions of a document or package")
+    compare.add_argument("old")
+    compare.add_argument("new")
+    compare.add_argument("--html", help="write the report as an HTML page")
+    compare.add_argument("--json", help="write the report as JSON")
+    compare.add_argument("--csv", help="write the changes of two packages as CSV rows")
+    compare.set_defaults(func=_compare)
+
+    server = commands.add_parser("serve", help="HTTP service for conversion jobs (pipeline.yml server section)")
+    server.add_argument("--host", help="address to listen on (default: server.host, 127.0.0.1)")
+    server.add_argument("--port", type=int, help="port to listen on (default: server.port, 8765)")
+    server.add_argument("--workers", type=int, help="conversions run at the same time (default: server.workers)")
+    server.add_argument("--plugin", action="append", default=[], metavar="ID",
+                        help="activate exactly these plugins (default: those active in the application)")
+    server.add_argument("--no-plugins", action="store_true", help="DITA, Markdown and AsciiDoc sources only")
+    server.set_defaults(func=_serve)
+
+    confluence = commands.add_parser("confluence", help="Confluence pages of a document (pipeline.yml confluence)")
+    confluence.add_argument("input", help="source document, DITA archive or a zip written by --zip")
+    confluence.add_argument("--zip", help="write the pages as an importable zip")
+    confluence.add_argument("--push", action="store_true", help="create or update the pages with the REST API")
+    confluence.add_argument("--plugin", action="append", default=[], metavar="ID",
+                            help="activate exactly these plugins (default: those active in the application)")
+    confluence.add_argument("--no-plugins", action="store_true", help="DITA, Markdown and AsciiDoc sources only")
+    confluence.set_defaults(func=_confluence)
+
+    s1000d = commands.add_parser("s1000d", help="S1000D data modules of a document (experimental, pipeline.yml s1000d)")
+    s1000d.add_argument("input", help="source document or DITA archive")
+    s1000d.add_argument("--zip", required=True, help="write the data modules, DMRL, publication module and ICNs")
+    s1000d.add_argument("--plugin", action="append", default=[], metavar="ID",
+                        help="activate exactly these plugins (default: those active in the application)")
+    s1000d.add_argument("--no-plugins", action="store_true", help="DITA, Markdown and AsciiDoc sources only")
+    s1000d.set_defaults(func=_s1000d)
+
+    resume = commands.add_parser("resume", help="finish packagings interrupted by a crash")
+    resume.add_argument("--list", action="store_true", help="list the interrupted packagings only")
+    resume.add_argument("--discard", metavar="ID", help="delete the workspace of an interrupted packaging")
+    resume.add_argument("--progress", action="store_true", help="print the progress to stderr")
+    resume.add_argument("--plugin", action="append", default=[], metavar="ID",
+                        help="activate exactly these plugins (default: those active in the application)")
+    resume.add_argument("--no-plugins", action="store_true", help="run no plugin package hooks")
+    resume.set_defaults(func=_resume)
+
+    watcher = commands.add_parser("watch", help="convert documents dropped into folders (pipeline.yml watch)")
+    watcher.add_argument("--folder", action="append", default=[], metavar="DIR",
+                         help="folder to watch instead of watch.folders (repeatable)")
+    watcher.add_argument("--profile", help="with --folder, output profile of its documents")
+    watcher.add_argument("--out", help="with --folder, where packages go (default: DIR/output)")
+    watcher.add_argument("--poll", type=float, help="seconds between scans (default: watch.poll_seconds)")
+    watcher.add_argument("--once", action="store_true", help="scan once and exit, e.g. from cron")
+    watcher.add_argument("--plugin", action="append", default=[], metavar="ID",
+                         help="activate exactly these plugins (default: those active in the application)")
+    watcher.add_argument("--no-plugins", action="store_true", help="DITA, Markdown and AsciiDoc sources only")
+    watcher.set_defaults(func=_watch)
+    return parser
+
+
+def main(argv: Optional[List[str]] = None) -> int:
+    args = build_parser().parse_args(argv)
+    return args.func(args)
@@ -13,12 +13,13 @@ The class is intentionally lightweight; missing PyYAML falls back to embedded
 Python dictionaries so existing behaviour is never broken.
 """
 
+import copy
 import importlib.resources as pkg_resources
 import logging
 import os
 import shutil
 from pathlib import Path
-from typing import Any, Dict
+from typing import Any, Dict, List, Optional
 
 logger = logging.getLogger(__name__)
 
@@ -84,6 +85,12 @@ class ConfigManager(metaclass=_Singleton):
         "preview_styles": "preview_styles.yml",
         "image_naming": "image_naming.yml",
         "logging": "logging.yml",
+        "pipeline": "pipeline.yml",
+        "conversion": "conversion.yml",
+        "messages": "messages.yml",
+        "security": "security.yml",
+        "profiles": "profiles.yml",
+        "ui": "ui.yml",
     }
 
     def __init__(self) -> None:
@@ -105,6 +112,24 @@ class ConfigManager(metaclass=_Singleton):
     def get_logging_config(self) -> Dict[str, Any]:
         return self._data.get("logging", {})
 
+    def get_pipeline_config(self) -> Dict[str, Any]:
+        return self._data.get("pipeline", {})
+
+    def get_conversion_config(self) -> Dict[str, Any]:
+        return self._data.get("conversion", {})
+
+    def get_messages_config(self) -> Dict[str, Any]:
+        return self._data.get("messages", {})
+
+    def get_security_config(self) -> Dict[str, Any]:
+        return self._data.get("security", {})
+
+    def get_profiles_config(self) -> Dict[str, Any]:
+        return self._data.get("profiles", {})
+
+    def get_ui_config(self) -> Dict[str, Any]:
+        return self._data.get("ui", {})
+
     def update_image_naming_config(self, updates: Dict[str, Any]) -> bool:
         """Update image naming configuration and persist to user config file.
         
@@ -155,6 +180,78 @@ class ConfigManager(metaclass=_Singleton):
             logger.error("Failed to persist image naming config: %s", e)
             return False
 
+    def update_ui_config(self, updates: Dict[str, Any]) -> bool:
+        """Update interface settings (``ui.yml``) and persist them to the user config file.
+
+        Returns:
+            bool: True if successfully written to disk, False otherwise
+        """
+        self._data.setdefault("ui", {}).update(updates)
+        try:
+            import yaml  # type: ignore
+        except ModuleNotFoundError:
+            logger.warning("PyYAML not available - cannot persist config changes")
+            return False
+
+        user_config_path = _get_user_config_dir() / "ui.yml"
+        try:
+            user_config: Dict[str, Any] = {}
+            if user_config_path.exists():
+                user_config = yaml.safe_load(user_config_path.read_text(encoding="utf-8")) or {}
+            user_config.update(updates)
+            user_config_path.parent.mkdir(parents=True, exist_ok=True)
+            with open(user_config_path, 'w', encoding='utf-8') as f:
+                yaml.safe_dump(user_config, f, default_flow_style=False, sort_keys=False, allow_unicode=True)
+            logger.info("Updated interface settings: %s", updates)
+            return True
+
+        except Exception as e:
+            logger.error("Failed to persist interface settings: %s", e)
+            return False
+
+    def update_hook_settings(self, order: List[str], disabled: List[str], profile: Optional[str] = None) -> bool:
+        """Persist the order and disabled conversion hooks, for all jobs or one output profile.
+
+        Without *profile* the ``hooks`` section of the user ``conversion.yml``
+        is updated; otherwise the profile's ``conversion_options.hooks`` in the
+        user ``profiles.yml`` (the packaged profile is copied there first).
+
+        Returns:
+            bool: True if successfully written to disk, False otherwise
+        """
+        try:
+            import yaml  # type: ignore
+        except ModuleNotFoundError:
+            logger.warning("PyYAML not available - cannot persist config changes")
+            return False
+
+        settings = {"order": list(order), "disabled": list(disabled)}
+        key, filename = ("conversion", "conversion.yml") if profile is None else ("profiles", "profiles.yml")
+        user_config_path = _get_user_config_dir() / filename
+        try:
+            user_config: Dict[str, Any] = {}
+            if user_config_path.exists():
+                user_config = yaml.safe_load(user_config_path.read_text(encoding="utf-8")) or {}
+            if profile is None:
+                targets = [user_config, self._data.setdefault(key, {})]
+            else:
+                packaged = copy.deepcopy(self._data.get(key, {}).get(profile) or {})
+                user_config.setdefault(profile, copy.deepcopy(packaged))
+                targets = [user_config[profile], self._data.setdefault(key, {}).setdefault(profile, packaged)]
+                targets = [t.setdefault("conversion_options", {}) for t in targets]
+            for target in targets:
+                target["hooks"] = {**(target.get("hooks") or {}), **settings}
+
+            user_config_path.parent.mkdir(parents=True, exist_ok=True)
+            with open(user_config_path, 'w', encoding='utf-8') as f:
+                yaml.safe_dump(user_config, f, default_flow_style=False, sort_keys=False, allow_unicode=True)
+            logger.info("Updated conversion hooks (%s): %s", profile or "all jobs", settings)
+            return True
+
+        except Exception as e:
+            logger.error("Failed to persist conversion hook settings: %s", e)
+            return False
+
 
     # ------------------------------------------------------------------
     # Internal loading logic
@@ -218,4 +315,10 @@ class ConfigManager(metaclass=_Singleton):
             "preview_styles": {},
             "image_naming": {},
             "logging": {},
+            "pipeline": {},
+            "conversion": {},
+            "messages": {},
+            "security": {},
+            "profiles": {},
+            "ui": {},
         } 
\ No newline at end of file
@@ -0,0 +1,270 @@
+from __future__ import annotations
+
+"""Accessibility audit of an edited context.
+
+HTML5 output is checked against WCAG downstream, where a failure sends the
+manual back for another round. :func:`check_accessibility` lists, in map
+order, what the content itself gets wrong:
+
+- ``missing_alt``: an ``image`` without ``<alt>`` (an empty ``<alt/>`` marks
+  a decorative image and is accepted);
+- ``table_header``: a ``table`` whose ``tgroup`` has no ``thead``, or a
+  ``simpletable`` without ``sthead``;
+- ``empty_title``: a topic, section, table or figure title with no text;
+- ``low_contrast``: inline formatting hints (``outputclass`` tokens
+  ``color-RRGGBB``, ``background-RRGGBB`` and ``highlight-<Word colour>``)
+  whose text reaches less than :data:`MIN_CONTRAST` against its background.
+
+Each :class:`AccessibilityIssue` can be repaired with :func:`apply_fix`:
+``add_alt`` (the given text, else the figure title or a description made of
+the file name), ``decorative`` (an empty ``<alt/>``), ``mark_header`` (the
+first body row becomes the header row), ``set_title`` (the given text, else
+the start of the first paragraph; map entries of the topic follow) and
+``remove_color`` (the colour tokens are dropped).
+"""
+
+import logging
+import posixpath
+import re
+from dataclasses import dataclass, field
+from typing import Dict, List, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.alt_text import _set_alt
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.placement import topic_order
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["FIXES", "MIN_CONTRAST", "AccessibilityIssue", "apply_fix", "check_accessibility", "contrast_ratio"]
+
+# kind -> quick-fix actions offered for it
+FIXES: Dict[str, Tuple[str, ...]] = {
+    "missing_alt": ("add_alt", "decorative"),
+    "table_header": ("mark_header",),
+    "empty_title": ("set_title",),
+    "low_contrast": ("remove_color",),
+}
+# WCAG 2 level AA for normal text
+MIN_CONTRAST = 4.5
+
+_TITLED = ("section", "table", "fig", "example")
+# Word highlight colours, as written by the converters in ``highlight-<name>``
+_HIGHLIGHTS = {
+    "black": "000000", "blue": "0000ff", "cyan": "00ffff", "green": "00ff00", "magenta": "ff00ff", "red": "ff0000",
+    "yellow": "ffff00", "white": "ffffff", "darkblue": "000080", "darkcyan": "008080", "darkgreen": "008000",
+    "darkmagenta": "800080", "darkred": "800000", "darkyellow": "808000", "darkgray": "808080",
+    "lightgray": "c0c0c0",
+}
+_COLOR_TOKEN = re.compile(r"^(colou?r|background|highlight)-([0-9a-z]+)$", re.IGNORECASE)
+
+
+@dataclass
+class AccessibilityIssue:
+    """One problem of :attr:`topic`; :attr:`target` names the image, table or title concerned."""
+
+    kind: str
+    topic: Optional[str]
+    target: str
+    message: str
+    element: Optional[ET.Element] = field(default=None, compare=False, repr=False)
+
+    @property
+    def fixes(self) -> Tuple[str, ...]:
+        return FIXES.get(self.kind, ())
+
+
+def _text(el: Optional[ET.Element]) -> str:
+    return " ".join("".join(el.itertext()).split()) if el is not None else ""
+
+
+def _luminance(rgb: str) -> float:
+    channels = []
+    for index in (0, 2, 4):
+        value = int(rgb[index:index + 2], 16) / 255
+        channels.append(value / 12.92 if value <= 0.03928 else ((value + 0.055) / 1.055) ** 2.4)
+    return 0.2126 * channels[0] + 0.7152 * channels[1] + 0.0722 * channels[2]
+
+
+def contrast_ratio(foreground: str, background: str) -> float:
+    """WCAG contrast ratio (1 to 21) of two ``RRGGBB`` colours."""
+    lighter, darker = sorted((_luminance(foreground), _luminance(background)), reverse=True)
+    return (lighter + 0.05) / (darker + 0.05)
+
+
+def _colours(el: ET.Element) -> Tuple[Optional[str], Optional[str]]:
+    """(text, background) ``RRGGBB`` hinted by the ``outputclass`` of *el*; None when not hinted."""
+    foreground = background = None
+    for token in (el.get("outputclass") or "").split():
+        match = _COLOR_TOKEN.match(token)
+        if not match:
+            continue
+        kind, value = match.group(1).lower(), match.group(2).lower()
+        value = _HIGHLIGHTS.get(value, value) if kind == "highlight" else value
+        if not re.fullmatch(r"[0-9a-f]{6}", value):
+            continue
+        if kind.startswith("colo"):
+            foreground = value
+        else:
+            background = value
+    return foreground, background
+
+
+def _effective_colours(el: ET.Element) -> Tuple[Optional[str], Optional[str]]:
+    """Colours of *el*, the missing one inherited from its ancestors."""
+    foreground = background = None
+    node = el
+    while node is not None and (foreground is None or background is None):
+        own_fg, own_bg = _colours(node)
+        foreground = foreground or own_fg
+        background = background or own_bg
+        node = node.getparent()
+    return foreground, background
+
+
+def _own_text(el: ET.Element) -> str:
+    """Text of *el* shown in its colours: descendants with colour hints of their own are left out."""
+    parts = [el.text or ""]
+    for child in el:
+        if isinstance(child.tag, str) and not any(_colours(child)):
+            parts.append(_own_text(child))
+        parts.append(child.tail or "")
+    return " ".join("".join(parts).split())
+
+
+def _table_label(table: ET.Element) -> str:
+    return _text(table.find("title")) or table.get("id") or table.tag
+
+
+def check_accessibility(context: DitaContext, min_contrast: float = MIN_CONTRAST) -> List[AccessibilityIssue]:
+    """Images without alt text, tables without header rows, empty titles and low-contrast text, in map order."""
+    issues: List[AccessibilityIssue] = []
+    for name in topic_order(context):
+        topic = context.topics[name]
+        title = topic.find("title")
+        if not _text(title):
+            issues.append(AccessibilityIssue("empty_title", name, name, "Topic has no title",
+                                             element=title if title is not None else topic))
+        for el in topic.iter():
+            if not isinstance(el.tag, str):
+                continue
+            if el.tag == "image" and el.find("alt") is None and not el.get("alt"):
+                image = posixpath.basename(el.get("href") or "") or "image"
+                issues.append(AccessibilityIssue("missing_alt", name, image, "Image has no alternative text",
+                                                 element=el))
+            elif el.tag == "table" and any(g.find("thead") is None for g in el.findall("tgroup")):
+                issues.append(AccessibilityIssue("table_header", name, _table_label(el),
+                                                 "Table has no header row", element=el))
+            elif el.tag == "simpletable" and el.find("sthead") is None and el.find("strow") is not None:
+                issues.append(AccessibilityIssue("table_header", name, _table_label(el),
+                                                 "Table has no header row", element=el))
+            elif el.tag == "title" and el.getparent() is not None and el.getparent().tag in _TITLED \
+                    and not _text(el):
+                issues.append(AccessibilityIssue("empty_title", name, el.getparent().tag,
+                                                 f"Empty {el.getparent().tag} title", element=el))
+            shown = _own_text(el) if any(_colours(el)) else ""
+            if not shown:
+                continue
+            foreground, background = _effective_colours(el)
+            ratio = contrast_ratio(foreground or "000000", background or "ffffff")
+            if ratio < min_contrast:
+                issues.append(AccessibilityIssue("low_contrast", name, shown[:40],
+                                                 f"Text contrast {ratio:.1f}:1 is below {min_contrast:g}:1",
+                                                 element=el))
+    logger.info("Accessibility: %d issue(s)", len(issues))
+    return issues
+
+
+def _suggested_alt(el: ET.Element) -> str:
+    parent = el.getparent()
+    while parent is not None and parent.tag != "fig":
+        parent = parent.getparent()
+    title = _text(parent.find("title")) if parent is not None else ""
+    if title:
+        return title
+    stem = posixpath.splitext(posixpath.basename(el.get("href") or ""))[0]
+    return " ".join(re.sub(r"[_\-.]+", " ", stem).split()).capitalize()
+
+
+def _mark_header(table: ET.Element) -> str:
+    single = "The table has a single row; add a row before marking a header"
+    if table.tag == "simpletable":
+        rows = table.findall("strow")
+        if len(rows) < 2:
+            raise ValueError(single)
+        rows[0].tag = "sthead"
+        return "First row marked as the header row"
+    for tgroup in table.findall("tgroup"):
+        tbody = tgroup.find("tbody")
+        if tgroup.find("thead") is not None or tbody is None:
+            continue
+        rows = tbody.findall("row")
+        if len(rows) < 2:
+            raise ValueError(single)
+        thead = ET.Element("thead")
+        tbody.remove(rows[0])
+        thead.append(rows[0])
+        tgroup.insert(list(tgroup).index(tbody), thead)
+    return "First row marked as the header row"
+
+
+def _set_title(context: DitaContext, issue: AccessibilityIssue, text: Optional[str]) -> str:
+    topic = context.topics.get(issue.topic or "")
+    title = issue.element
+    scope = title.getparent() if title is not None and title.tag == "title" else topic
+    text = " ".join((text or "").split())
+    if not text and scope is not None:
+        text = " ".join(_text(next(iter(scope.iter("p")), None)).split()[:8])
+    if not text:
+        raise ValueError("Type the title to use")
+    if title is None or title.tag != "title":
+        title = ET.Element("title")
+        topic.insert(0, title)
+    for child in list(title):
+        title.remove(child)
+    title.text = text
+    if title.getparent() is topic:
+        root = getattr(context, "ditamap_root", None)
+        for ref in (root.iter("topicref") if root is not None else ()):
+            if (ref.get("href") or "").split("#")[0].split("/")[-1] == issue.topic:
+                navtitle = ref.find("topicmeta/navtitle")
+                if navtitle is not None:
+                    navtitle.text = text
+    return f"Title set to “{text}”"
+
+
+def _remove_colour(el: ET.Element) -> str:
+    tokens = [t for t in (el.get("outputclass") or "").split() if not _COLOR_TOKEN.match(t)]
+    if tokens:
+        el.set("outputclass", " ".join(tokens))
+    elif el.get("outputclass") is not None:
+        del el.attrib["outputclass"]
+    return "Colour formatting removed"
+
+
+def apply_fix(context: DitaContext, issue: AccessibilityIssue, action: str, text: Optional[str] = None) -> str:
+    """Repair *issue* with *action* (one of :attr:`AccessibilityIssue.fixes`); returns what was done.
+
+    *text* is the alt text or title to use. Raises ValueError for an action
+    the issue does not offer or a fix that cannot be made.
+    """
+    if action not in issue.fixes:
+        raise ValueError(f"Cannot {action.replace('_', ' ')} for a {issue.kind.replace('_', ' ')}")
+    if action == "add_alt":
+        alt = " ".join((text or "").split()) or _suggested_alt(issue.element)
+        if not alt:
+            raise ValueError("Type the alternative text")
+        _set_alt(issue.element, alt)
+        message = f"Alternative text set to “{alt}”"
+    elif action == "decorative":
+        issue.element.insert(0, ET.Element("alt"))
+        message = "Image marked as decorative"
+    elif action == "mark_header":
+        message = _mark_header(issue.element)
+    elif action == "set_title":
+        message = _set_title(context, issue, text)
+    else:
+        message = _remove_colour(issue.element)
+    logger.info("Accessibility fix: %s", message)
+    return message
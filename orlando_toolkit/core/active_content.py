@@ -0,0 +1,239 @@
+from __future__ import annotations
+
+"""Macro and active-content policy for source documents.
+
+Office Open XML sources (``.docx``/``.docm`` and their Excel/PowerPoint
+siblings) can carry parts that execute code or host executable objects:
+
+- ``macros``: VBA projects (``vbaProject.bin``, ``vbaData.xml``), Excel 4
+  macro sheets, and the ``macroEnabled`` content types of ``.docm`` & co.;
+- ``activex``: ActiveX controls (``activeX/`` parts);
+- ``ole_objects``: embedded OLE objects (``embeddings/*.bin``).
+
+Before a plugin sees the file the conversion service inspects it and applies
+``security.yml`` (``active_content``): ``strip`` (default for macros and
+ActiveX) hands the plugin a sanitized copy without those parts, their
+relationships and content-type overrides; ``refuse`` aborts the conversion
+with :class:`ActiveContentRefusedError`; ``allow`` keeps them. Every decision
+is recorded in the conversion report under ``active_content``.
+
+Only the container is changed: document XML that referenced a removed part
+keeps a dangling relationship id, which converters treat as a missing object.
+"""
+
+import logging
+import posixpath
+import re
+import shutil
+import zipfile
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.errors import SourceLocation, ToolkitError
+from orlando_toolkit.core.xml_security import parse_bytes
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "ACTIVE_CONTENT_KINDS",
+    "ActiveContentPolicy",
+    "ActiveContentFindings",
+    "ActiveContentRefusedError",
+    "inspect_container",
+    "sanitize_container",
+    "record_decisions",
+]
+
+ACTIVE_CONTENT_KINDS = ("macros", "activex", "ole_objects")
+_ACTIONS = ("strip", "refuse", "allow")
+_DEFAULT_ACTIONS = {"macros": "strip", "activex": "strip", "ole_objects": "allow"}
+
+_MACRO_EXTENSIONS = {".docm": ".docx", ".dotm": ".dotx", ".xlsm": ".xlsx", ".xltm": ".xltx",
+                     ".pptm": ".pptx", ".potm": ".potx", ".ppsm": ".ppsx"}
+_PART_PATTERNS = {
+    "macros": re.compile(r"(^|/)(vbaProject\.bin|vbaData\.xml|vbaProjectSignature[^/]*\.bin)$|(^|/)macrosheets/", re.I),
+    "activex": re.compile(r"(^|/)activeX/", re.I),
+    "ole_objects": re.compile(r"(^|/)embeddings/[^/]*\.bin$", re.I),
+}
+_CONTENT_TYPES = "[Content_Types].xml"
+_PKG_CT = "http://schemas.openxmlformats.org/package/2006/content-types"
+_PKG_REL = "http://schemas.openxmlformats.org/package/2006/relationships"
+# macroEnabled main content types -> their macro-free equivalents
+_MACRO_FREE_TYPES = {
+    "application/vnd.ms-word.document.macroEnabled.main+xml":
+        "application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml",
+    "application/vnd.ms-word.template.macroEnabledTemplate.main+xml":
+        "application/vnd.openxmlformats-officedocument.wordprocessingml.template.main+xml",
+    "application/vnd.ms-excel.sheet.macroEnabled.main+xml":
+        "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml",
+    "application/vnd.ms-powerpoint.presentation.macroEnabled.main+xml":
+        "application/vnd.openxmlformats-officedocument.presentationml.presentation.main+xml",
+}
+
+
+class ActiveContentRefusedError(ToolkitError, ValueError):
+    """Raised when the policy refuses a source that carries active content."""
+
+    code = "OTK202"
+
+
+@dataclass(frozen=True)
+class ActiveContentPolicy:
+    macros: str = "strip"
+    activex: str = "strip"
+    ole_objects: str = "allow"
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "ActiveContentPolicy":
+        data = data or {}
+        values = {}
+        for kind in ACTIVE_CONTENT_KINDS:
+            action = str(data.get(kind, _DEFAULT_ACTIONS[kind]) or _DEFAULT_ACTIONS[kind]).strip().lower()
+            if action not in _ACTIONS:
+                logger.warning("Unknown active_content.%s action %r; using %s", kind, action, _DEFAULT_ACTIONS[kind])
+                action = _DEFAULT_ACTIONS[kind]
+            values[kind] = action
+        return cls(**values)
+
+    @classmethod
+    def from_config(cls) -> "ActiveContentPolicy":
+        try:
+            from orlando_toolkit.config import ConfigManager
+            return cls.from_mapping((ConfigManager().get_security_config() or {}).get("active_content"))
+        except Exception as exc:
+            logger.debug("Security config unavailable, using defaults: %s", exc)
+            return cls()
+
+    def action(self, kind: str) -> str:
+        return getattr(self, kind)
+
+
+@dataclass
+class ActiveContentFindings:
+    """Active parts found in a source container."""
+
+    source: str
+    macro_enabled_type: bool = False
+    parts: Dict[str, List[str]] = field(default_factory=lambda: {k: [] for k in ACTIVE_CONTENT_KINDS})
+
+    def kinds(self) -> List[str]:
+        found = [k for k in ACTIVE_CONTENT_KINDS if self.parts.get(k)]
+        if self.macro_enabled_type and "macros" not in found:
+            found.insert(0, "macros")
+        return found
+
+    def __bool__(self) -> bool:
+        return bool(self.kinds())
+
+
+def inspect_container(path: str | Path) -> ActiveContentFindings:
+    """Return the active content of the ZIP-based document at *path*."""
+    path = Path(path)
+    findings = ActiveContentFindings(source=path.name)
+    findings.macro_enabled_type = path.suffix.lower() in _MACRO_EXTENSIONS
+    if not zipfile.is_zipfile(path):
+        return findings
+    with zipfile.ZipFile(path) as archive:
+        names = archive.namelist()
+        for name in names:
+            for kind, pattern in _PART_PATTERNS.items():
+                if pattern.search(name):
+                    findings.parts[kind].append(name)
+                    break
+        if _CONTENT_TYPES in names:
+            try:
+                types = archive.read(_CONTENT_TYPES).decode("utf-8", errors="replace")
+                findings.macro_enabled_type = findings.macro_enabled_type or "macroEnabled" in types
+            except Exception as exc:
+                logger.debug("Could not read content types of %s: %s", path, exc)
+    return findings
+
+
+def _rels_source_dir(rels_name: str) -> str:
+    """``word/_rels/document.xml.rels`` -> ``word``; ``_rels/.rels`` -> ``""``."""
+    return posixpath.dirname(posixpath.dirname(rels_name))
+
+
+def _clean_rels(data: bytes, rels_name: str, removed: set) -> bytes:
+    root = parse_bytes(data, source=rels_name)
+    base = _rels_source_dir(rels_name)
+    changed = False
+    for rel in list(root):
+        if not isinstance(rel.tag, str) or (rel.get("TargetMode") or "") == "External":
+            continue
+        target = rel.get("Target") or ""
+        resolved = target.lstrip("/") if target.startswith("/") else posixpath.normpath(posixpath.join(base, target))
+        if resolved in removed:
+            root.remove(rel)
+            changed = True
+    return ET.tostring(root, xml_declaration=True, encoding="UTF-8", standalone=True) if changed else data
+
+
+def _clean_content_types(data: bytes, removed: set, drop_macro_type: bool) -> bytes:
+    root = parse_bytes(data, source=_CONTENT_TYPES)
+    for el in list(root):
+        if not isinstance(el.tag, str):
+            continue
+        if el.get("PartName", "").lstrip("/") in removed:
+            root.remove(el)
+        elif drop_macro_type and el.get("ContentType") in _MACRO_FREE_TYPES:
+            el.set("ContentType", _MACRO_FREE_TYPES[el.get("ContentType")])
+        elif drop_macro_type and el.get("Extension", "").lower() == "bin" and "vbaProject" in el.get("ContentType", ""):
+            root.remove(el)
+    return ET.tostring(root, xml_declaration=True, encoding="UTF-8", standalone=True)
+
+
+def sanitize_container(path: str | Path, findings: ActiveContentFindings, policy: ActiveContentPolicy,
+                       out_dir: str | Path) -> Path:
+    """Write a copy of *path* into *out_dir* without the parts *policy* strips.
+
+    Raises :class:`ActiveContentRefusedError` when a found kind is refused.
+    Returns *path* unchanged when nothing is stripped.
+    """
+    path = Path(path)
+    refused = [k for k in findings.kinds() if policy.action(k) == "refuse"]
+    if refused:
+        parts = [name for kind in refused for name in findings.parts.get(kind, [])]
+        raise ActiveContentRefusedError(f"{path.name} contains {', '.join(refused)} (refused by policy)",
+                                        location=SourceLocation(file=path.name, part=parts[0] if parts else None))
+    strip = [k for k in findings.kinds() if policy.action(k) == "strip"]
+    if not strip:
+        return path
+    removed = {name for kind in strip for name in findings.parts.get(kind, [])}
+    drop_macro_type = "macros" in strip
+    suffix = _MACRO_EXTENSIONS.get(path.suffix.lower(), path.suffix) if drop_macro_type else path.suffix
+    target = Path(out_dir) / f"{path.stem}{suffix}"
+    if not zipfile.is_zipfile(path):
+        shutil.copyfile(path, target)
+        return target
+    with zipfile.ZipFile(path) as src, zipfile.ZipFile(target, "w", zipfile.ZIP_DEFLATED) as dst:
+        for info in src.infolist():
+            if info.filename in removed:
+                continue
+            data = src.read(info.filename)
+            if info.filename == _CONTENT_TYPES:
+                data = _clean_content_types(data, removed, drop_macro_type)
+            elif info.filename.endswith(".rels"):
+                data = _clean_rels(data, info.filename, removed)
+            dst.writestr(info, data)
+    logger.info("Stripped %s from %s", ", ".join(strip), path.name)
+    return target
+
+
+def record_decisions(report: Any, findings: ActiveContentFindings, policy: ActiveContentPolicy) -> None:
+    """Add one ``active_content`` report entry per kind found in the source."""
+    if report is None:
+        return
+    for kind in findings.kinds():
+        action = policy.action(kind)
+        parts = list(findings.parts.get(kind, []))
+        label = kind.replace("_", " ")
+        if action == "strip":
+            report.warning("active_content", f"Removed {label} from {findings.source}", kind=kind,
+                           action=action, parts=parts, source=findings.source)
+        else:
+            report.info("active_content", f"Kept {label} in {findings.source} (policy: {action})", kind=kind,
+                        action=action, parts=parts, source=findings.source)
@@ -0,0 +1,194 @@
+from __future__ import annotations
+
+"""Word image descriptions as DITA ``<alt>``.
+
+Converters usually drop the alternative text Word keeps on each drawing
+(``wp:docPr/@descr``, the "Alt Text" pane, and ``@title``), which leaves
+every image without a description for accessibility checks. After the
+plugin handler returns, :func:`restore_word_alt_text` reads
+``word/document.xml`` of the source and gives each ``<image>`` of the
+topics the description of its drawing:
+
+- drawings are matched to ``context.images`` by the bytes of the embedded
+  media file; images the plugin re-encoded are paired with the remaining
+  drawings in document order when their numbers agree;
+- the description is used, else the title (``use_title``); drawings Word
+  marks as decorative get an empty ``<alt/>``;
+- an ``<alt>`` written by the plugin is kept.
+
+The Images tab edits the text afterwards (:func:`image_alt_text`,
+:func:`set_image_alt_text`); images still without one are reported under
+``alt_text`` (``report_missing``). The ``alt_text`` section of
+``conversion.yml`` controls the pass.
+"""
+
+import hashlib
+import logging
+import posixpath
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Dict, Iterator, List, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.placement import topic_order
+from orlando_toolkit.core.word_source import DOCUMENT, R_NS, WordSource
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["WordImage", "image_alt_text", "images_missing_alt", "read_word_alt_texts", "restore_word_alt_text",
+           "set_image_alt_text"]
+
+_WP = "http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing"
+_A = "http://schemas.openxmlformats.org/drawingml/2006/main"
+_DECORATIVE = "http://schemas.microsoft.com/office/drawing/2017/decorative"
+
+
+@dataclass
+class WordImage:
+    """One drawing of the document body, in document order."""
+
+    media: str = ""
+    description: str = ""
+    title: str = ""
+    decorative: bool = False
+    digest: str = ""
+
+
+def _digest(data: bytes) -> str:
+    return hashlib.sha1(data).hexdigest()
+
+
+def read_word_alt_texts(path: str | Path | WordSource) -> List[WordImage]:
+    """Drawings of the body of the ``.docx`` *path* with their alt text and media."""
+    docx = WordSource.of(path)
+    if docx is None or docx.document is None:
+        return []
+    images: List[WordImage] = []
+    targets = {rel: target for rel, (target, external) in docx.relationships(DOCUMENT).items() if not external}
+    for prop in docx.document.iter(f"{{{_WP}}}docPr"):
+        drawing = prop.getparent()
+        image = WordImage(description=(prop.get("descr") or "").strip(), title=(prop.get("title") or "").strip())
+        image.decorative = any(el.get("val") in ("1", "true") for el in prop.iter(f"{{{_DECORATIVE}}}decorative"))
+        blip = next(drawing.iter(f"{{{_A}}}blip"), None) if drawing is not None else None
+        image.media = targets.get(blip.get(f"{{{R_NS}}}embed") or "", "") if blip is not None else ""
+        if image.media:
+            try:
+                image.digest = _digest(docx.read(image.media))
+            except KeyError:
+                pass
+        images.append(image)
+    return images
+
+
+def _image_elements(context: Any, name: Optional[str] = None) -> Iterator[Tuple[str, Any]]:
+    """``(file name, <image>)`` of the topics in map order, for one file when *name* is given."""
+    for topic_name in topic_order(context):
+        for el in context.topics[topic_name].iter("image"):
+            base = posixpath.basename(el.get("href") or "")
+            if base and (name is None or base == name):
+                yield base, el
+
+
+def _set_alt(el: Any, text: str) -> None:
+    alt = el.find("alt")
+    if not text:
+        if alt is not None:
+            el.remove(alt)
+        return
+    if alt is None:
+        alt = ET.Element("alt")
+        el.insert(0, alt)
+    for child in list(alt):
+        alt.remove(child)
+    alt.text = text
+
+
+def image_alt_text(context: Any, name: str) -> str:
+    """Alt text of the first ``<image>`` showing the media file *name*."""
+    for _, el in _image_elements(context, name):
+        alt = el.find("alt")
+        if alt is not None:
+            return "".join(alt.itertext()).strip()
+    return ""
+
+
+def set_image_alt_text(context: Any, name: str, text: str) -> int:
+    """Give every ``<image>`` of *name* the alt *text* (removed when empty); returns the number updated."""
+    text = " ".join((text or "").split())
+    count = 0
+    for _, el in _image_elements(context, name):
+        _set_alt(el, text)
+        count += 1
+    return count
+
+
+def images_missing_alt(context: Any) -> List[str]:
+    """Media files shown by at least one ``<image>`` without ``<alt>``, in map order.
+
+    An empty ``<alt/>`` marks a decorative image and is not reported.
+    """
+    missing: List[str] = []
+    for name, el in _image_elements(context):
+        if el.find("alt") is None and name not in missing:
+            missing.append(name)
+    return missing
+
+
+def _alt(image: WordImage, use_title: bool) -> str:
+    return image.description or (image.title if use_title else "")
+
+
+def restore_word_alt_text(path: str | Path | WordSource, context: Any, options: Optional[Mapping[str, Any]] = None,
+                          report: Any = None) -> int:
+    """Add the Word alt text of the ``.docx`` *path* to the images of *context*; returns the number set."""
+    options = dict(options or {})
+    if not options.get("enabled", True):
+        return 0
+    report = report if report is not None else getattr(context, "report", None)
+    try:
+        drawings = read_word_alt_texts(path)
+    except Exception as exc:
+        logger.warning("Could not read the image descriptions of %s: %s", Path(path).name, exc)
+        drawings = []
+    use_title = bool(options.get("use_title", True))
+
+    digests = {name: _digest(data) for name, data in (getattr(context, "images", None) or {}).items()}
+    by_digest: Dict[str, List[WordImage]] = {}
+    for drawing in drawings:
+        if drawing.digest:
+            by_digest.setdefault(drawing.digest, []).append(drawing)
+    elements = list(_image_elements(context))
+    matched: Dict[int, WordImage] = {}
+    used: set = set()
+    for index, (name, _) in enumerate(elements):
+        queue = by_digest.get(digests.get(name, ""), [])
+        if queue:
+            drawing = queue.pop(0) if len(queue) > 1 else queue[0]
+            matched[index] = drawing
+            used.add(id(drawing))
+    rest = [d for d in drawings if id(d) not in used]
+    unmatched = [i for i in range(len(elements)) if i not in matched]
+    if rest and len(rest) == len(unmatched):
+        matched.update(zip(unmatched, rest))
+
+    count = 0
+    for index, drawing in matched.items():
+        el = elements[index][1]
+        if el.find("alt") is not None:
+            continue
+        if drawing.decorative:
+            el.insert(0, ET.Element("alt"))
+            continue
+        text = " ".join(_alt(drawing, use_title).split())
+        if text:
+            _set_alt(el, text)
+            count += 1
+    if report is not None:
+        if count:
+            report.info("alt_text", f"Alt text restored for {count} image(s)", images=count)
+        missing = images_missing_alt(context)
+        if missing and options.get("report_missing", True):
+            report.warning("alt_text", f"{len(missing)} image(s) have no alt text: {', '.join(missing)}",
+                           images=missing, hint="Fill the descriptions in the Images tab before export")
+    return count
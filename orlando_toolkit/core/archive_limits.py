@@ -0,0 +1,278 @@
+from __future__ import annotations
+
+"""Resource limits for source archives.
+
+ZIP-based sources (DITA packages, DOCX and other Office containers) are
+checked before anything is extracted or handed to a plugin, using the
+``security.yml`` (``archive_limits``) settings:
+
+- ``max_entries`` and ``max_uncompressed_bytes`` bound the archive as a
+  whole (nested archives included), ``max_entry_bytes`` a single member;
+- ``max_compression_ratio`` refuses members that inflate suspiciously
+  (classic zip bombs) once they exceed 1 MiB;
+- ``max_nesting`` bounds archives inside archives;
+- ``max_image_pixels`` and ``max_image_dimension`` refuse images whose
+  header declares dimensions that would exhaust memory when decoded.
+
+Sizes in ZIP headers can lie, so :func:`safe_extract` enforces the same
+limits on the bytes actually written. Every violation raises
+:class:`ArchiveLimitError` naming the entry, the measured value and the
+setting to raise.
+"""
+
+import io
+import logging
+import os
+import struct
+import zipfile
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, BinaryIO, Mapping, Optional, Tuple
+
+from orlando_toolkit.core.cancellation import CancellationToken, check_cancelled
+from orlando_toolkit.core.errors import SourceLocation, ToolkitError
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "ArchiveLimitError",
+    "ArchiveLimits",
+    "ArchiveStats",
+    "check_archive",
+    "safe_extract",
+    "image_size",
+]
+
+_IMAGE_EXTENSIONS = frozenset({".png", ".jpg", ".jpeg", ".gif", ".bmp", ".webp"})
+_HEADER_BYTES = 64 * 1024
+_RATIO_FLOOR = 1 << 20  # small members may compress extremely well innocently
+_CHUNK = 1 << 16
+
+
+class ArchiveLimitError(ToolkitError, ValueError):
+    """Raised when a source archive exceeds a configured resource limit."""
+
+    code = "OTK201"
+
+
+def _member(source: str, name: str) -> SourceLocation:
+    """Location of archive member *name*; nested archives as ``outer.zip!inner.docx``."""
+    file, _, nested = source.partition("!")
+    return SourceLocation(file=file, part=f"{nested}!{name}" if nested else name)
+
+
+@dataclass(frozen=True)
+class ArchiveLimits:
+    max_uncompressed_bytes: int = 1 << 30
+    max_entries: int = 10_000
+    max_entry_bytes: int = 256 << 20
+    max_compression_ratio: int = 100
+    max_nesting: int = 2
+    max_image_pixels: int = 100_000_000
+    max_image_dimension: int = 30_000
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "ArchiveLimits":
+        data = data or {}
+        defaults = cls()
+        try:
+            return cls(**{name: int(data.get(name, getattr(defaults, name)))
+                          for name in cls.__dataclass_fields__})
+        except (TypeError, ValueError) as exc:
+            logger.warning("Invalid archive_limits settings (%s); using defaults", exc)
+            return defaults
+
+    @classmethod
+    def from_config(cls) -> "ArchiveLimits":
+        try:
+            from orlando_toolkit.config import ConfigManager
+            return cls.from_mapping((ConfigManager().get_security_config() or {}).get("archive_limits"))
+        except Exception as exc:
+            logger.debug("Security config unavailable, using default archive limits: %s", exc)
+            return cls()
+
+
+@dataclass
+class ArchiveStats:
+    """Totals measured by :func:`check_archive`."""
+
+    entries: int = 0
+    uncompressed_bytes: int = 0
+    nesting: int = 0
+    images: int = 0
+
+
+def _mb(value: int) -> str:
+    return f"{value / (1 << 20):.1f} MB"
+
+
+def image_size(header: bytes) -> Optional[Tuple[int, int]]:
+    """Return ``(width, height)`` declared by a PNG/GIF/JPEG/BMP/WebP header."""
+    try:
+        if header[:8] == b"\x89PNG\r\n\x1a\n" and header[12:16] == b"IHDR":
+            return struct.unpack(">II", header[16:24])
+        if header[:6] in (b"GIF87a", b"GIF89a"):
+            return struct.unpack("<HH", header[6:10])
+        if header[:2] == b"BM":
+            width, height = struct.unpack("<ii", header[18:26])
+            return abs(width), abs(height)
+        if header[:4] == b"RIFF" and header[8:12] == b"WEBP":
+            chunk = header[12:16]
+            if chunk == b"VP8X":
+                return (int.from_bytes(header[24:27], "little") + 1, int.from_bytes(header[27:30], "little") + 1)
+            if chunk == b"VP8L":
+                bits = int.from_bytes(header[21:25], "little")
+                return (bits & 0x3FFF) + 1, ((bits >> 14) & 0x3FFF) + 1
+            if chunk == b"VP8 ":
+                width, height = struct.unpack("<HH", header[26:30])
+                return width & 0x3FFF, height & 0x3FFF
+        if header[:2] == b"\xff\xd8":
+            pos = 2
+            while pos + 9 < len(header):
+                if header[pos] != 0xFF:
+                    pos += 1
+                    continue
+                marker = header[pos + 1]
+                if marker in (0xD8, 0x01) or 0xD0 <= marker <= 0xD7 or marker == 0xFF:
+                    pos += 1 if marker == 0xFF else 2
+                    continue
+                length = struct.unpack(">H", header[pos + 2:pos + 4])[0]
+                if 0xC0 <= marker <= 0xCF and marker not in (0xC4, 0xC8, 0xCC):
+                    height, width = struct.unpack(">HH", header[pos + 5:pos + 9])
+                    return width, height
+                pos += 2 + length
+    except struct.error:
+        return None
+    return None
+
+
+def _check_image(name: str, header: bytes, limits: ArchiveLimits, source: str) -> bool:
+    size = image_size(header)
+    if size is None:
+        return False
+    width, height = size
+    if max(width, height) > limits.max_image_dimension:
+        raise ArchiveLimitError(
+            f"{source}: image {name} is {width}x{height} pixels; sides are limited to "
+            f"{limits.max_image_dimension} (archive_limits.max_image_dimension)",
+            location=_member(source, name),
+        )
+    if width * height > limits.max_image_pixels:
+        raise ArchiveLimitError(
+            f"{source}: image {name} has {width * height} pixels, more than "
+            f"{limits.max_image_pixels} (archive_limits.max_image_pixels)",
+            location=_member(source, name),
+        )
+    return True
+
+
+def _check_zip(archive: zipfile.ZipFile, limits: ArchiveLimits, stats: ArchiveStats, source: str,
+               depth: int) -> None:
+    stats.nesting = max(stats.nesting, depth)
+    for info in archive.infolist():
+        stats.entries += 1
+        if stats.entries > limits.max_entries:
+            raise ArchiveLimitError(
+                f"{source}: more than {limits.max_entries} entries (archive_limits.max_entries)"
+            )
+        if info.is_dir():
+            continue
+        name = info.filename
+        if info.file_size > limits.max_entry_bytes:
+            raise ArchiveLimitError(
+                f"{source}: {name} expands to {_mb(info.file_size)}, more than "
+                f"{_mb(limits.max_entry_bytes)} (archive_limits.max_entry_bytes)",
+                location=_member(source, name),
+            )
+        stats.uncompressed_bytes += info.file_size
+        if stats.uncompressed_bytes > limits.max_uncompressed_bytes:
+            raise ArchiveLimitError(
+                f"{source}: uncompressed content exceeds {_mb(limits.max_uncompressed_bytes)} "
+                f"(archive_limits.max_uncompressed_bytes)"
+            )
+        if info.file_size > _RATIO_FLOOR and info.file_size > limits.max_compression_ratio * max(info.compress_size, 1):
+            raise ArchiveLimitError(
+                f"{source}: {name} compresses {info.file_size // max(info.compress_size, 1)}:1, more than "
+                f"{limits.max_compression_ratio}:1 (archive_limits.max_compression_ratio)",
+                location=_member(source, name),
+            )
+        suffix = os.path.splitext(name)[1].lower()
+        with archive.open(info) as member:
+            head = member.read(_HEADER_BYTES)
+        if suffix in _IMAGE_EXTENSIONS and _check_image(name, head, limits, source):
+            stats.images += 1
+        elif head[:4] == b"PK\x03\x04":
+            if depth + 1 > limits.max_nesting:
+                raise ArchiveLimitError(
+                    f"{source}: {name} nests archives deeper than {limits.max_nesting} levels "
+                    f"(archive_limits.max_nesting)",
+                    location=_member(source, name),
+                )
+            try:
+                with zipfile.ZipFile(io.BytesIO(archive.read(info))) as nested:
+                    _check_zip(nested, limits, stats, f"{source}!{name}", depth + 1)
+            except zipfile.BadZipFile:
+                logger.debug("%s!%s looks like a ZIP but is not readable; treated as data", source, name)
+
+
+def check_archive(path: str | Path, limits: Optional[ArchiveLimits] = None) -> ArchiveStats:
+    """Check the ZIP archive at *path* against *limits* without extracting it.
+
+    Raises :class:`ArchiveLimitError` on the first limit exceeded and
+    ``zipfile.BadZipFile`` when *path* is not a readable archive.
+    """
+    path = Path(path)
+    limits = limits or ArchiveLimits.from_config()
+    stats = ArchiveStats()
+    with zipfile.ZipFile(path) as archive:
+        _check_zip(archive, limits, stats, path.name, 0)
+    return stats
+
+
+def _copy_limited(src: BinaryIO, dst: BinaryIO, budget: int, name: str, limits: ArchiveLimits,
+                  source: str) -> int:
+    written = 0
+    while True:
+        chunk = src.read(_CHUNK)
+        if not chunk:
+            return written
+        written += len(chunk)
+        if written > limits.max_entry_bytes or written > budget:
+            raise ArchiveLimitError(
+                f"{source}: {name} inflates beyond its limits while extracting "
+                f"(archive_limits.max_entry_bytes / max_uncompressed_bytes)",
+                location=_member(source, name),
+            )
+        dst.write(chunk)
+
+
+def safe_extract(path: str | Path, dest: str | Path, limits: Optional[ArchiveLimits] = None, *,
+                 cancel_token: Optional[CancellationToken] = None) -> ArchiveStats:
+    """Check and extract *path* into *dest*, enforcing *limits* on written bytes.
+
+    Entries with absolute paths or ``..`` components are refused.
+    """
+    path, dest = Path(path), Path(dest)
+    limits = limits or ArchiveLimits.from_config()
+    stats = check_archive(path, limits)
+    root = dest.resolve()
+    remaining = limits.max_uncompressed_bytes
+    with zipfile.ZipFile(path) as archive:
+        for info in archive.infolist():
+            check_cancelled(cancel_token)
+            name = info.filename
+            parts = name.replace("\\", "/").split("/")
+            if os.path.isabs(name) or name.startswith(("/", "\\")) or ".." in parts or ":" in parts[0]:
+                raise ArchiveLimitError(f"{path.name}: unsafe path in archive: {name}",
+                                        location=_member(path.name, name))
+            target = (root / name).resolve()
+            if root not in target.parents and target != root:
+                raise ArchiveLimitError(f"{path.name}: unsafe path in archive: {name}",
+                                        location=_member(path.name, name))
+            if info.is_dir():
+                target.mkdir(parents=True, exist_ok=True)
+                continue
+            target.parent.mkdir(parents=True, exist_ok=True)
+            with archive.open(info) as src, open(target, "wb") as dst:
+                remaining -= _copy_limited(src, dst, remaining, name, limits, path.name)
+    return stats
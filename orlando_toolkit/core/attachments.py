@@ -0,0 +1,153 @@
+from __future__ import annotations
+
+"""Downloadable files shipped with the package.
+
+PDFs, spreadsheets and other files a manual refers to are attached in the
+Images tab's Attachments section (or with :func:`attach_file`). They are
+kept in ``context.attachments`` (file name -> bytes) and their titles in
+``metadata["attachment_titles"]``; a project file saves both.
+
+When the package is prepared, :func:`apply_attachments` adds one
+resource-only entry per file at the end of the map::
+
+    <topicref href="resources/checklist.pdf" format="pdf" scope="local"
+              processing-role="resource-only">
+      <topicmeta><navtitle>Checklist</navtitle></topicmeta>
+    </topicref>
+
+so publishing tools copy the file without adding it to the navigation, and
+:func:`write_attachments` writes the files to ``DATA/resources/``. The
+format is the file extension; DITA files are refused, as are empty files.
+"""
+
+import logging
+import os
+import posixpath
+import re
+from pathlib import Path
+from typing import Any, Dict, List, Optional
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.spool import write_blob
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["ATTACHMENT_FOLDER", "TITLES_KEY", "apply_attachments", "attach_file", "attachment_entries",
+           "attachment_format", "attachment_title", "remove_attachment", "set_attachment_title",
+           "write_attachments"]
+
+ATTACHMENT_FOLDER = "resources"
+TITLES_KEY = "attachment_titles"
+_UNSAFE = re.compile(r'[<>:"/\\|?*#%\s\x00-\x1f]+')
+_REFUSED = (".dita", ".ditamap", ".ditaval")
+
+
+def attachment_format(name: str) -> str:
+    """``@format`` of an attachment: its extension in lower case (``pdf``)."""
+    return posixpath.splitext(name)[1][1:].lower()
+
+
+def _titles(context: Any) -> Dict[str, str]:
+    titles = context.metadata.get(TITLES_KEY)
+    if not isinstance(titles, dict):
+        titles = context.metadata[TITLES_KEY] = {}
+    return titles
+
+
+def attachment_title(context: Any, name: str) -> str:
+    """Title of attachment *name*; its file name without extension when none was set."""
+    title = (context.metadata.get(TITLES_KEY) or {}).get(name)
+    return str(title) if title else posixpath.splitext(name)[0].replace("_", " ")
+
+
+def attach_file(context: Any, path: str | Path, *, data: Optional[bytes] = None, title: str = "") -> str:
+    """Attach the file at *path* (or *data* under its name); returns the name it is stored under.
+
+    A name already attached is numbered (``checklist_2.pdf``).
+
+    Raises:
+        ValueError: The file is empty, has no extension or is a DITA file
+    """
+    original = Path(path).name
+    name = _UNSAFE.sub("_", original).strip("._") or "attachment"
+    stem, ext = posixpath.splitext(name)
+    if not ext:
+        raise ValueError(f"{original} has no file extension, so its format is unknown")
+    if ext.lower() in _REFUSED:
+        raise ValueError(f"{original} is a DITA file; import it as part of a package instead")
+    if data is None:
+        data = Path(path).read_bytes()
+    if not data:
+        raise ValueError(f"{original} is empty")
+    if getattr(context, "attachments", None) is None:
+        context.attachments = {}
+    candidate, n = name, 2
+    while candidate in context.attachments:
+        candidate, n = f"{stem}_{n}{ext}", n + 1
+    context.attachments[candidate] = data
+    if title.strip():
+        _titles(context)[candidate] = " ".join(title.split())
+    logger.info("Attached %s (%d bytes)", candidate, len(data))
+    return candidate
+
+
+def remove_attachment(context: Any, name: str) -> bool:
+    """Remove attachment *name*; False when it was not attached."""
+    if name not in (getattr(context, "attachments", None) or {}):
+        return False
+    del context.attachments[name]
+    _titles(context).pop(name, None)
+    return True
+
+
+def set_attachment_title(context: Any, name: str, title: str) -> None:
+    """Title shown for *name* in the map; empty goes back to the file name."""
+    title = " ".join(str(title or "").split())
+    if title:
+        _titles(context)[name] = title
+    else:
+        _titles(context).pop(name, None)
+
+
+def attachment_entries(context: Any) -> List[Any]:
+    """The resource-only map entries of the attachments, in name order."""
+    entries = []
+    for name in sorted(getattr(context, "attachments", None) or {}):
+        entry = ET.Element("topicref", {"href": f"{ATTACHMENT_FOLDER}/{name}", "format": attachment_format(name),
+                                        "scope": "local", "processing-role": "resource-only"})
+        ET.SubElement(ET.SubElement(entry, "topicmeta"), "navtitle").text = attachment_title(context, name)
+        entries.append(entry)
+    return entries
+
+
+def apply_attachments(context: Any) -> int:
+    """Add the attachments' entries to the end of the map, replacing earlier ones; returns their number."""
+    root = getattr(context, "ditamap_root", None)
+    if root is None:
+        return 0
+    for entry in root.findall("topicref"):
+        if (entry.get("href") or "").startswith(f"{ATTACHMENT_FOLDER}/"):
+            root.remove(entry)
+    entries = attachment_entries(context)
+    position = len(root)
+    for index, el in enumerate(root):
+        if isinstance(el.tag, str) and el.tag == "reltable":
+            position = index
+            break
+    for offset, entry in enumerate(entries):
+        root.insert(position + offset, entry)
+    return len(entries)
+
+
+def write_attachments(context: Any, data_dir: str | Path) -> List[str]:
+    """Write the attachments to ``resources/`` under *data_dir*; returns the names written."""
+    attachments = getattr(context, "attachments", None) or {}
+    if not attachments:
+        return []
+    folder = os.path.join(os.fspath(data_dir), ATTACHMENT_FOLDER)
+    os.makedirs(folder, exist_ok=True)
+    for name in attachments:
+        write_blob(attachments, name, os.path.join(folder, name))
+    logger.info("%d attachment(s) written to %s", len(attachments), folder)
+    return list(attachments)
@@ -0,0 +1,272 @@
+from __future__ import annotations
+
+"""Append-only audit log.
+
+Records who converted what, which structure edits were made and which
+packages were published, for regulated documentation environments. Off by
+default; ``security.yml`` (``audit_log``) enables it and selects the sinks:
+
+- ``jsonl``: one JSON object per line appended to ``path``. Each record
+  carries ``prev`` (the previous record's hash) and ``hash`` (SHA-256 over
+  ``prev`` and the record), so removed or edited lines are detected by
+  :func:`verify_chain`;
+- ``syslog``: the same JSON sent to a syslog daemon (``syslog.address``:
+  a socket path such as ``/dev/log`` or ``host:port``).
+
+Actions: ``convert`` (source file), ``edit.<operation>`` (structure editing
+service) and ``publish`` (written package). ``outcome`` is ``success``,
+``failure`` or ``cancelled``. The actor is, in order, the one set with
+:func:`acting_as` for the current thread (the service mode sets the requesting
+user, see :mod:`orlando_toolkit.server`), ``actor`` from
+the configuration, or the OS user.
+"""
+
+import contextlib
+import functools
+import getpass
+import hashlib
+import json
+import logging
+import logging.handlers
+import socket
+import threading
+from datetime import datetime, timezone
+from pathlib import Path
+from typing import Any, Callable, Dict, Iterator, List, Mapping, Optional, Tuple
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "AuditLog",
+    "JsonLinesSink",
+    "SyslogSink",
+    "get_audit_log",
+    "set_audit_log",
+    "acting_as",
+    "audited_edit",
+    "verify_chain",
+]
+
+_GENESIS = "0" * 64
+_local = threading.local()
+
+
+def _canonical(record: Mapping[str, Any]) -> str:
+    return json.dumps(record, sort_keys=True, ensure_ascii=False, separators=(",", ":"), default=str)
+
+
+def _chain_hash(prev: str, record: Mapping[str, Any]) -> str:
+    body = {k: v for k, v in record.items() if k != "hash"}
+    return hashlib.sha256((prev + _canonical(body)).encode("utf-8")).hexdigest()
+
+
+class JsonLinesSink:
+    """Appends hash-chained records to a JSON lines file."""
+
+    def __init__(self, path: str | Path) -> None:
+        self.path = Path(path).expanduser()
+        self._lock = threading.Lock()
+        self._last: Optional[str] = None
+
+    def _last_hash(self) -> str:
+        if self._last is None:
+            self._last = _GENESIS
+            if self.path.exists():
+                with open(self.path, "rb") as fh:
+                    lines = fh.read().splitlines()
+                for line in reversed(lines):
+                    if line.strip():
+                        self._last = json.loads(line).get("hash", _GENESIS)
+                        break
+        return self._last
+
+    def write(self, record: Dict[str, Any]) -> None:
+        with self._lock:
+            self.path.parent.mkdir(parents=True, exist_ok=True)
+            record = dict(record, prev=self._last_hash())
+            record["hash"] = _chain_hash(record["prev"], record)
+            with open(self.path, "a", encoding="utf-8") as fh:
+                fh.write(_canonical(record) + "\n")
+                fh.flush()
+            self._last = record["hash"]
+
+
+class SyslogSink:
+    """Sends records to syslog as ``orlando-toolkit: <json>``."""
+
+    def __init__(self, address: str = "/dev/log", facility: str = "user") -> None:
+        target: Any = address
+        if ":" in address and not address.startswith("/"):
+            host, port = address.rsplit(":", 1)
+            target = (host, int(port))
+        code = logging.handlers.SysLogHandler.facility_names.get(facility, logging.handlers.SysLogHandler.LOG_USER)
+        self._handler = logging.handlers.SysLogHandler(address=target, facility=code)
+        self._handler.setFormatter(logging.Formatter("orlando-toolkit: %(message)s"))
+
+    def write(self, record: Dict[str, Any]) -> None:
+        self._handler.emit(logging.LogRecord("orlando_toolkit.audit", logging.INFO, __file__, 0,
+                                             _canonical(record), None, None))
+
+
+class AuditLog:
+    """Fan-out of audit records to the configured sinks."""
+
+    def __init__(self, sinks: Optional[List[Any]] = None, *, actor: Optional[str] = None,
+                 fail_on_error: bool = False) -> None:
+        self.sinks = list(sinks or [])
+        self.actor = actor
+        self.fail_on_error = fail_on_error
+        self._host = socket.gethostname()
+
+    @property
+    def enabled(self) -> bool:
+        return bool(self.sinks)
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "AuditLog":
+        data = data or {}
+        if not data.get("enabled", False):
+            return cls()
+        sinks: List[Any] = []
+        for name in data.get("sinks") or ["jsonl"]:
+            try:
+                if name == "jsonl":
+                    sinks.append(JsonLinesSink(data.get("path") or "~/.orlando_toolkit/audit/audit.jsonl"))
+                elif name == "syslog":
+                    syslog = data.get("syslog") or {}
+                    sinks.append(SyslogSink(str(syslog.get("address", "/dev/log")), str(syslog.get("facility", "user"))))
+                else:
+                    logger.warning("Unknown audit sink %r", name)
+            except Exception as exc:
+                logger.error("Could not open audit sink %s: %s", name, exc)
+        return cls(sinks, actor=data.get("actor"), fail_on_error=bool(data.get("fail_on_error", False)))
+
+    @classmethod
+    def from_config(cls) -> "AuditLog":
+        try:
+            from orlando_toolkit.config import ConfigManager
+            return cls.from_mapping((ConfigManager().get_security_config() or {}).get("audit_log"))
+        except Exception as exc:
+            logger.debug("Security config unavailable, audit log disabled: %s", exc)
+            return cls()
+
+    def current_actor(self) -> str:
+        explicit = getattr(_local, "actor", None)
+        if explicit:
+            return explicit
+        if self.actor:
+            return str(self.actor)
+        try:
+            return getpass.getuser()
+        except Exception:
+            return "unknown"
+
+    def record(self, action: str, target: Optional[str] = None, *, outcome: str = "success",
+               detail: Optional[Mapping[str, Any]] = None) -> Optional[Dict[str, Any]]:
+        """Append one record to every sink and return it (``None`` when disabled).
+
+        Sink errors are logged; with ``fail_on_error`` they propagate so the
+        audited operation fails instead of going unrecorded.
+        """
+        if not self.sinks:
+            return None
+        record: Dict[str, Any] = {
+            "timestamp": datetime.now(timezone.utc).isoformat(timespec="milliseconds"),
+            "actor": self.current_actor(),
+            "host": self._host,
+            "action": action,
+            "target": target,
+            "outcome": outcome,
+            "detail": dict(detail or {}),
+        }
+        for sink in self.sinks:
+            try:
+                sink.write(record)
+            except Exception as exc:
+                logger.error("Audit sink %s failed: %s", type(sink).__name__, exc)
+                if self.fail_on_error:
+                    raise
+        return record
+
+
+_log: Optional[AuditLog] = None
+_log_lock = threading.Lock()
+
+
+def get_audit_log() -> AuditLog:
+    """Return the process-wide audit log, configured on first use."""
+    global _log
+    if _log is None:
+        with _log_lock:
+            if _log is None:
+                _log = AuditLog.from_config()
+    return _log
+
+
+def set_audit_log(log: Optional[AuditLog]) -> None:
+    """Replace the process-wide audit log (``None`` re-reads the configuration)."""
+    global _log
+    _log = log
+
+
+@contextlib.contextmanager
+def acting_as(actor: Optional[str]) -> Iterator[None]:
+    """Attribute records made by this thread inside the block to *actor* (``None``: the default actor)."""
+    previous = getattr(_local, "actor", None)
+    _local.actor = actor
+    try:
+        yield
+    finally:
+        _local.actor = previous
+
+
+def _document_of(context: Any) -> Optional[str]:
+    metadata = getattr(context, "metadata", None) or {}
+    return metadata.get("source_file") or metadata.get("manual_title")
+
+
+def audited_edit(operation: str) -> Callable:
+    """Decorate a structure-editing method so its outcome is audited.
+
+    Only the outermost audited call is recorded; edits composed of other
+    edits yield one record.
+    """
+    def decorate(method: Callable) -> Callable:
+        @functools.wraps(method)
+        def wrapper(self, context, *args, **kwargs):
+            depth = getattr(_local, "edit_depth", 0)
+            _local.edit_depth = depth + 1
+            try:
+                result = method(self, context, *args, **kwargs)
+            finally:
+                _local.edit_depth = depth
+            log = get_audit_log()
+            if depth == 0 and log.enabled:
+                detail = dict(getattr(result, "details", None) or {})
+                detail["message"] = getattr(result, "message", None)
+                log.record(f"edit.{operation}", _document_of(context),
+                           outcome="success" if getattr(result, "success", False) else "failure", detail=detail)
+            return result
+        return wrapper
+    return decorate
+
+
+def verify_chain(path: str | Path) -> Tuple[bool, Optional[int]]:
+    """Check the hash chain of a JSON lines audit file.
+
+    Returns ``(True, None)`` when intact, else ``(False, line_number)`` of
+    the first record that does not follow from the one before it.
+    """
+    prev = _GENESIS
+    with open(Path(path).expanduser(), encoding="utf-8") as fh:
+        for number, line in enumerate(fh, 1):
+            if not line.strip():
+                continue
+            try:
+                record = json.loads(line)
+            except ValueError:
+                return False, number
+            if record.get("prev") != prev or _chain_hash(prev, record) != record.get("hash"):
+                return False, number
+            prev = record["hash"]
+    return True, None
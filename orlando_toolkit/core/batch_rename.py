@@ -0,0 +1,140 @@
+from __future__ import annotations
+
+"""Topic titles built from a rename template.
+
+The Structure tab's *Batch rename* renames many topics at once, for instance
+to apply a numbering scheme. The template is a Python format string applied
+to each selected topic in map order, with the placeholders:
+
+- ``{title}`` - the current title;
+- ``{parent}`` - the title of the parent topic or section, empty at the top;
+- ``{section}`` - the section number of the topic (``2.1``), ``{level}``
+  its depth in the map;
+- ``{counter}`` - position of the topic in the selection, from ``start`` by
+  ``step`` and padded to ``pad`` digits unless a format is given
+  (``{counter:03}``);
+- ``{0}``, ``{1}``, … and ``{name}`` - the whole match and the groups of the
+  ``match`` regular expression, searched in the current title.
+
+With a ``match`` expression, topics whose title does not match keep it.
+:func:`propose_renames` computes the new titles for the preview; the
+renaming itself is one undoable edit
+(:meth:`StructureEditingService.rename_topics`).
+"""
+
+import logging
+import os
+import re
+import string
+from dataclasses import dataclass
+from typing import Any, Dict, List, Optional, Sequence, Tuple
+
+from orlando_toolkit.core.image_naming import _counter
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.utils import calculate_section_numbers
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["PLACEHOLDERS", "RenamePattern", "RenameProposal", "propose_renames"]
+
+PLACEHOLDERS: Tuple[str, ...] = ("title", "parent", "section", "level", "counter")
+
+
+@dataclass(frozen=True)
+class RenamePattern:
+    template: str = "{title}"
+    match: str = ""
+    start: int = 1
+    step: int = 1
+    pad: int = 0
+
+    def regex(self) -> Optional["re.Pattern[str]"]:
+        return re.compile(self.match) if self.match else None
+
+    def validate(self) -> Optional[str]:
+        """Return why the pattern cannot be used, or ``None`` when it is valid."""
+        if not self.template or not self.template.strip():
+            return "The template is empty"
+        try:
+            regex = self.regex()
+        except re.error as exc:
+            return f"Invalid regular expression: {exc}"
+        groups = regex.groups if regex is not None else -1
+        names = set(regex.groupindex) if regex is not None else set()
+        try:
+            fields = [f for _, f, _, _ in string.Formatter().parse(self.template) if f is not None]
+        except ValueError as exc:
+            return f"Invalid template: {exc}"
+        for name in fields:
+            if name == "" or (name.isdigit() and int(name) > groups):
+                return "{" + name + "} needs a group in the regular expression" if name else \
+                    "Empty placeholder {} (use {0} for the matched text)"
+            if not name.isdigit() and name not in PLACEHOLDERS and name not in names:
+                return f"Unknown placeholder {{{name}}}"
+        try:
+            self.template.format(*[""] * (groups + 1), **_sample_tokens(names))
+        except (ValueError, TypeError, IndexError, KeyError) as exc:
+            return f"Invalid template: {exc}"
+        return None
+
+
+@dataclass(frozen=True)
+class RenameProposal:
+    """New title of one topic; ``matched`` is False when the ``match`` expression did not apply."""
+
+    filename: str
+    old: str
+    new: str
+    matched: bool = True
+
+    @property
+    def changed(self) -> bool:
+        return self.matched and bool(self.new) and self.new != self.old
+
+
+def _sample_tokens(names: Any = ()) -> Dict[str, Any]:
+    return {"title": "Title", "parent": "Parent", "section": "1.2", "level": 2, "counter": _counter(1, 0),
+            **{name: "" for name in names}}
+
+
+def _title(context: DitaContext, ref: Any) -> str:
+    """Title of a topicref or topichead: the topic's title, else the navtitle."""
+    topic = context.topics.get(os.path.basename((ref.get("href") or "").split("#")[0]))
+    title = topic.find("title") if topic is not None else None
+    text = "".join(title.itertext()) if title is not None else ref.findtext("topicmeta/navtitle") or ""
+    return " ".join(text.split())
+
+
+def propose_renames(context: DitaContext, filenames: Sequence[str], pattern: RenamePattern) -> List[RenameProposal]:
+    """New title for each topic of *filenames*, in map order; empty when *pattern* is invalid."""
+    if pattern.validate() or context.ditamap_root is None:
+        return []
+    regex = pattern.regex()
+    wanted = set(filenames)
+    sections = calculate_section_numbers(context.ditamap_root)
+    proposals: List[RenameProposal] = []
+    seen = set()
+    counter = pattern.start
+    for ref in context.ditamap_root.iter("topicref"):
+        filename = os.path.basename((ref.get("href") or "").split("#")[0])
+        if filename not in wanted or filename in seen:
+            continue
+        seen.add(filename)
+        old = _title(context, ref)
+        match = regex.search(old) if regex is not None else None
+        if regex is not None and match is None:
+            proposals.append(RenameProposal(filename, old, old, matched=False))
+            continue
+        parent = ref.getparent()
+        section = sections.get(ref, "0")
+        tokens = {"title": old, "parent": _title(context, parent) if parent in sections else "",
+                  "section": section, "level": section.count(".") + 1,
+                  "counter": _counter(counter, max(0, pattern.pad))}
+        groups: Tuple[str, ...] = ()
+        if match is not None:
+            groups = (match.group(0),) + tuple(g or "" for g in match.groups())
+            tokens.update({k: v or "" for k, v in match.groupdict().items()})
+        new = " ".join(pattern.template.format(*groups, **tokens).split())
+        proposals.append(RenameProposal(filename, old, new))
+        counter += pattern.step
+    return proposals
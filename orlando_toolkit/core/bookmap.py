@@ -0,0 +1,232 @@
+from __future__ import annotations
+
+"""Bookmap serialization of the in-memory map.
+
+The toolkit always works on a plain ``<map>`` of ``topicref`` and
+``topichead`` elements, which is what the structure editor, the preview and
+the stages expect. When ``metadata["map_type"]`` is ``bookmap`` the packager
+writes :func:`to_bookmap` of it instead:
+
+- ``title`` becomes ``booktitle/mainbooktitle`` and ``topicmeta`` becomes
+  ``bookmeta``;
+- key definitions, resource-only entries (attachments) and the leading
+  entries kept out of the TOC (``toc="no"``, such as the cover's front
+  matter) go to ``frontmatter``, as do entries with
+  ``outputclass="preface"`` (as ``preface``);
+- entries with ``outputclass="appendix"`` become ``appendix``, a group with
+  ``outputclass="appendices"`` becomes ``appendices``; the other top-level
+  entries are ``chapter`` before the first appendix and ``backmatter``
+  after it.
+
+The roles are set by the ``appendices`` stage or per entry in the Structure
+tab (:data:`BOOK_ROLES`). Given the job metadata, :func:`to_bookmap` also
+fills the title page: ``book_subtitle`` becomes ``booktitlealt``,
+``book_author`` and ``book_publisher`` the ``author`` and
+``publisherinformation`` of ``bookmeta`` and ``manual_code`` (with
+``revision_number``) its ``bookid``; the tracked-changes summary of
+``change_history`` (:mod:`orlando_toolkit.core.track_changes`) becomes
+``bookchangehistory``, one ``edited`` entry per author and day. ``toc`` and
+``index`` add the generated ``booklists`` (table of contents in the front
+matter, index in the back matter).
+
+:func:`from_bookmap` turns an imported bookmap back into that plain map.
+"""
+
+from copy import deepcopy
+from typing import Any, List, Mapping, Optional
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.processing.text import is_element, local_name
+
+__all__ = ["BOOKMAP_DOCTYPE", "BOOK_ROLES", "book_role", "from_bookmap", "is_bookmap", "to_bookmap"]
+
+BOOKMAP_DOCTYPE = '<!DOCTYPE bookmap PUBLIC "-//OASIS//DTD DITA BookMap//EN" "./dtd/bookmap/dtd/bookmap.dtd">'
+#: Roles a top-level map entry can take in a bookmap (``chapter`` is the default)
+BOOK_ROLES = ("chapter", "preface", "appendix")
+_ENTRIES = ("topicref", "topichead")
+_ROLES = ("appendix", "appendices")
+_STRIPPED = _ROLES + ("preface",)
+_BOOK_ENTRIES = frozenset({"chapter", "part", "appendix", "appendices"})
+_FRONT_ENTRIES = frozenset({"preface", "notices", "colophon", "dedication"})
+
+
+def is_bookmap(metadata: Mapping[str, Any]) -> bool:
+    """True when the job asks for a bookmap (``metadata["map_type"]``)."""
+    return str(metadata.get("map_type") or "").strip().lower() == "bookmap"
+
+
+def book_role(entry) -> str:
+    """The :data:`BOOK_ROLES` entry of a top-level map *entry*."""
+    role = entry.get("outputclass")
+    return role if role in BOOK_ROLES else "chapter"
+
+
+def _retag(el, tag: str) -> Any:
+    el.tag = tag
+    if el.get("outputclass") in _STRIPPED:
+        del el.attrib["outputclass"]
+    return el
+
+
+def _text(metadata: Mapping[str, Any], key: str) -> str:
+    return " ".join(str(metadata.get(key) or "").split())
+
+
+def _fill_bookmeta(bookmeta, metadata: Mapping[str, Any]) -> None:
+    front: List[Any] = []
+    if _text(metadata, "book_author"):
+        author = ET.Element("author")
+        author.text = _text(metadata, "book_author")
+        front.append(author)
+    if _text(metadata, "book_publisher"):
+        info = ET.Element("publisherinformation")
+        ET.SubElement(info, "organization").text = _text(metadata, "book_publisher")
+        front.append(info)
+    for offset, el in enumerate(front):
+        bookmeta.insert(offset, el)
+    if _text(metadata, "manual_code") and bookmeta.find("bookid") is None:
+        bookid = ET.Element("bookid")
+        if _text(metadata, "revision_number"):
+            ET.SubElement(bookid, "edition").text = _text(metadata, "revision_number")
+        ET.SubElement(bookid, "booknumber").text = _text(metadata, "manual_code")
+        # bookid follows the generic metadata (othermeta, data) in bookmeta
+        bookmeta.append(bookid)
+    history = metadata.get("change_history") or []
+    if history and bookmeta.find("bookchangehistory") is None:
+        changes = ET.Element("bookchangehistory")
+        for entry in history:
+            changes.append(_edited(entry))
+        bookid = bookmeta.find("bookid")
+        bookmeta.insert(bookmeta.index(bookid) + 1 if bookid is not None else len(bookmeta), changes)
+
+
+def _edited(entry: Mapping[str, Any]) -> Any:
+    """One ``edited`` element of ``bookchangehistory`` for a change-history *entry*."""
+    edited = ET.Element("edited")
+    ET.SubElement(edited, "person").text = _text(entry, "author") or "Unknown"
+    if _text(entry, "rev"):
+        ET.SubElement(edited, "revisionid").text = _text(entry, "rev")
+    ET.SubElement(edited, "summary").text = (f"{int(entry.get('insertions') or 0)} insertion(s), "
+                                             f"{int(entry.get('deletions') or 0)} deletion(s)")
+    completed = ET.SubElement(edited, "completed")
+    year, month, day = (_text(entry, "date").split("-") + ["", "", ""])[:3]
+    for name, value in (("year", year), ("month", month), ("day", day)):
+        if value:
+            ET.SubElement(completed, name).text = value
+    return edited
+
+
+def _booklists(*names: str) -> Any:
+    lists = ET.Element("booklists")
+    for name in names:
+        ET.SubElement(lists, name)
+    return lists
+
+
+def to_bookmap(map_root, metadata: Optional[Mapping[str, Any]] = None, *,
+               toc: bool = False, index: bool = False) -> Any:
+    """A bookmap copy of *map_root*; see the module docstring."""
+    book = ET.Element("bookmap", dict(map_root.attrib))
+    head: List[Any] = []
+    front: List[Any] = []
+    body: List[Any] = []
+    back: List[Any] = []
+    tables: List[Any] = []
+    prefaces: List[Any] = []
+    for child in map_root:
+        if not is_element(child):
+            continue
+        el = deepcopy(child)
+        el.tail = None
+        name = local_name(el)
+        if name == "title":
+            booktitle = ET.Element("booktitle")
+            main = ET.SubElement(booktitle, "mainbooktitle")
+            main.text = el.text
+            for part in list(el):
+                main.append(part)
+            if metadata and _text(metadata, "book_subtitle"):
+                ET.SubElement(booktitle, "booktitlealt").text = _text(metadata, "book_subtitle")
+            head.append(booktitle)
+        elif name == "topicmeta":
+            if metadata:
+                _fill_bookmeta(el, metadata)
+            head.append(_retag(el, "bookmeta"))
+        elif name == "reltable":
+            tables.append(el)
+        elif name == "keydef" or (name in _ENTRIES and (el.get("processing-role") == "resource-only"
+                                                        or not body and el.get("toc") == "no")):
+            front.append(el)
+        elif name in _ENTRIES and el.get("outputclass") == "preface":
+            prefaces.append(_retag(el, "preface"))
+        elif name in _ENTRIES and el.get("outputclass") in _ROLES:
+            role = el.get("outputclass")
+            if role == "appendices":
+                for entry in el:
+                    if is_element(entry) and local_name(entry) in _ENTRIES and entry.get("outputclass") == "appendix":
+                        _retag(entry, "appendix")
+            body.append(_retag(el, role))
+        elif name in _ENTRIES and any(local_name(e) in _ROLES for e in body):
+            back.append(el)
+        elif name in _ENTRIES:
+            body.append(_retag(el, "chapter"))
+        else:
+            body.append(el)
+    if metadata and not any(local_name(el) == "bookmeta" for el in head):
+        bookmeta = ET.Element("bookmeta")
+        _fill_bookmeta(bookmeta, metadata)
+        head.extend([bookmeta] if len(bookmeta) else [])
+    front.extend([_booklists("toc")] if toc else [])
+    front.extend(prefaces)
+    if front:
+        matter = ET.Element("frontmatter")
+        matter.extend(front)
+        head.append(matter)
+    back.extend([_booklists("indexlist")] if index else [])
+    if back:
+        matter = ET.Element("backmatter")
+        matter.extend(back)
+        body.append(matter)
+    book.extend(head + body + tables)
+    return book
+
+
+def _as_entry(el, role: str = "") -> Any:
+    el.tag = "topicref" if el.get("href") or el.get("keyref") else "topichead"
+    if role:
+        el.set("outputclass", role)
+    return el
+
+
+def from_bookmap(book_root) -> Any:
+    """The plain map equivalent of an imported bookmap."""
+    root = ET.Element("map", dict(book_root.attrib))
+    for child in book_root:
+        if not is_element(child):
+            continue
+        el = deepcopy(child)
+        name = local_name(el)
+        if name == "booktitle":
+            title = ET.SubElement(root, "title")
+            main = el.find("mainbooktitle")
+            title.text = " ".join("".join((main if main is not None else el).itertext()).split())
+        elif name == "bookmeta":
+            el.tag = "topicmeta"
+            root.append(el)
+        elif name in ("frontmatter", "backmatter"):
+            for entry in list(el):
+                if not is_element(entry) or local_name(entry) == "booklists":
+                    continue
+                if local_name(entry) in _FRONT_ENTRIES:
+                    _as_entry(entry, "preface" if local_name(entry) == "preface" else "")
+                root.append(entry)
+        elif name in _BOOK_ENTRIES:
+            if name == "appendices":
+                for entry in el:
+                    if is_element(entry) and local_name(entry) == "appendix":
+                        _as_entry(entry, "appendix")
+            root.append(_as_entry(el, name if name in _ROLES else ""))
+        else:
+            root.append(el)
+    return root
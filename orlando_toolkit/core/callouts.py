@@ -0,0 +1,291 @@
+from __future__ import annotations
+
+"""Numbered callouts over illustrations, with their parts table.
+
+Exploded views and assembly drawings number their parts and list them in a
+table next to the picture. The Images tab places :class:`Callout` markers on
+an image (positions in pixels of the image) and :func:`set_image_callouts`
+writes them:
+
+- ``png``: a copy of the image with the numbered markers drawn in;
+- ``svg``: an SVG showing the image (embedded) with the markers as vector
+  shapes over it, so they stay sharp at any size.
+
+The annotated copy is added to the media as ``<name>_callouts.png`` (or
+``.svg``) and every ``<image>`` of the original points to it. After the first
+use of the image in each topic a callout table lists the numbers and part
+names::
+
+    <simpletable outputclass="callouts" id="callouts_pump">
+      <sthead><stentry>No.</stentry><stentry>Part</stentry></sthead>
+      <strow><stentry>1</stentry><stentry>Impeller</stentry></strow>
+    </simpletable>
+
+Headings come from ``callout_number``/``callout_part`` in ``messages.yml``
+in the document language. The original image and the markers
+(``metadata["image_callouts"]``) are kept, so the callouts can be edited
+again; writing no callout restores the plain image and removes the tables.
+"""
+
+import base64
+import io
+import logging
+import posixpath
+import re
+from dataclasses import dataclass
+from typing import Any, Dict, List, Mapping, Optional, Sequence, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.alt_text import _image_elements
+from orlando_toolkit.core.i18n import document_language, get_catalog
+from orlando_toolkit.core.processing.vector_images import is_metafile, is_svg
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["Callout", "MODES", "METADATA_KEY", "image_callouts", "image_size", "render_callouts_png",
+           "render_callouts_svg", "rename_callouts", "set_image_callouts"]
+
+MODES = ("png", "svg")
+METADATA_KEY = "image_callouts"
+_SVG = "http://www.w3.org/2000/svg"
+_XLINK = "http://www.w3.org/1999/xlink"
+_FILL = "#ffffff"
+_STROKE = "#c0392b"
+_MIME = {"png": "image/png", "jpg": "image/jpeg", "jpeg": "image/jpeg", "gif": "image/gif", "bmp": "image/bmp",
+         "webp": "image/webp", "tif": "image/tiff", "tiff": "image/tiff"}
+# Body elements a callout table can follow the picture in
+_CONTAINERS = frozenset({"conbody", "body", "refbody", "taskbody", "section", "example", "context", "result",
+                         "info", "stepxmp", "stepresult", "li", "dd", "bodydiv", "sectiondiv", "entry", "stentry"})
+
+
+@dataclass
+class Callout:
+    """Marker :attr:`number` at :attr:`x`, :attr:`y` (image pixels) naming the part :attr:`label`."""
+
+    number: int
+    x: int
+    y: int
+    label: str = ""
+
+    def validate(self, size: Optional[Tuple[int, int]] = None) -> Optional[str]:
+        """Why the callout cannot be written, or None."""
+        if self.number < 1:
+            return "Callout numbers start at 1"
+        if self.x < 0 or self.y < 0 or (size and (self.x > size[0] or self.y > size[1])):
+            return "The marker is outside the image"
+        return None
+
+
+def image_callouts(metadata: Mapping[str, Any], name: str) -> Tuple[List[Callout], str]:
+    """Callouts saved for the media file *name* and their mode (none: ``[]`` and ``png``)."""
+    saved = (metadata.get(METADATA_KEY) or {}).get(name) or {}
+    callouts: List[Callout] = []
+    for entry in saved.get("callouts") or []:
+        try:
+            number, x, y, label = entry
+            callouts.append(Callout(int(number), int(x), int(y), str(label or "")))
+        except (TypeError, ValueError):
+            logger.debug("Skipping an unreadable callout of %s: %r", name, entry)
+    mode = saved.get("mode")
+    return callouts, mode if mode in MODES else "png"
+
+
+def rename_callouts(metadata: Dict[str, Any], renames: Mapping[str, str]) -> None:
+    """Follow image renaming in the saved callouts (original and annotated file names)."""
+    saved = metadata.get(METADATA_KEY)
+    if not saved:
+        return
+    renamed: Dict[str, Any] = {}
+    for name, entry in saved.items():
+        entry = dict(entry)
+        if entry.get("output"):
+            entry["output"] = renames.get(entry["output"], entry["output"])
+        renamed[renames.get(name, name)] = entry
+    metadata[METADATA_KEY] = renamed
+
+
+# ----------------------------------------------------------------------
+# Rendering
+# ----------------------------------------------------------------------
+
+def _radius(size: Tuple[int, int]) -> int:
+    return max(9, min(size) // 40)
+
+
+def _font(size: int) -> Any:
+    from PIL import ImageFont  # type: ignore
+
+    for face in ("DejaVuSans-Bold.ttf", "arialbd.ttf", "Arial Bold.ttf"):
+        try:
+            return ImageFont.truetype(face, size)
+        except OSError:
+            continue
+    try:
+        return ImageFont.load_default(size=size)
+    except TypeError:
+        return ImageFont.load_default()
+
+
+def image_size(data: bytes) -> Tuple[int, int]:
+    """Pixel size of the raster image *data*."""
+    from PIL import Image  # type: ignore
+
+    with Image.open(io.BytesIO(data)) as image:
+        return image.size
+
+
+def render_callouts_png(data: bytes, callouts: Sequence[Callout]) -> bytes:
+    """PNG of the image *data* with the numbered markers drawn in."""
+    from PIL import Image, ImageDraw  # type: ignore
+
+    with Image.open(io.BytesIO(data)) as source:
+        image = source.convert("RGBA")
+    draw = ImageDraw.Draw(image)
+    radius = _radius(image.size)
+    font = _font(int(radius * 1.2))
+    for callout in callouts:
+        x, y = callout.x, callout.y
+        draw.ellipse((x - radius, y - radius, x + radius, y + radius), fill=_FILL, outline=_STROKE,
+                     width=max(2, radius // 5))
+        draw.text((x, y), str(callout.number), fill=_STROKE, font=font, anchor="mm")
+    buffer = io.BytesIO()
+    image.save(buffer, format="PNG", optimize=True)
+    return buffer.getvalue()
+
+
+def render_callouts_svg(name: str, data: bytes, size: Tuple[int, int], callouts: Sequence[Callout]) -> bytes:
+    """SVG showing the image *data* (embedded) with the numbered markers over it."""
+    width, height = size
+    ext = posixpath.splitext(name)[1].lstrip(".").lower()
+    svg = ET.Element(f"{{{_SVG}}}svg", nsmap={None: _SVG, "xlink": _XLINK}, width=str(width), height=str(height),
+                     viewBox=f"0 0 {width} {height}")
+    uri = f"data:{_MIME.get(ext, 'image/png')};base64," + base64.b64encode(data).decode("ascii")
+    ET.SubElement(svg, f"{{{_SVG}}}image", {"x": "0", "y": "0", "width": str(width), "height": str(height),
+                                             f"{{{_XLINK}}}href": uri})
+    radius = _radius(size)
+    for callout in callouts:
+        group = ET.SubElement(svg, f"{{{_SVG}}}g", {"class": "callout"})
+        ET.SubElement(group, f"{{{_SVG}}}circle", cx=str(callout.x), cy=str(callout.y), r=str(radius), fill=_FILL,
+                      stroke=_STROKE, **{"stroke-width": str(max(2, radius // 5))})
+        text = ET.SubElement(group, f"{{{_SVG}}}text", {
+            "x": str(callout.x), "y": str(callout.y), "fill": _STROKE, "font-size": str(int(radius * 1.2)),
+            "font-family": "Arial, Helvetica, sans-serif", "font-weight": "bold", "text-anchor": "middle",
+            "dominant-baseline": "central"})
+        text.text = str(callout.number)
+        if callout.label:
+            ET.SubElement(group, f"{{{_SVG}}}title").text = callout.label
+    return ET.tostring(svg, xml_declaration=True, encoding="UTF-8")
+
+
+# ----------------------------------------------------------------------
+# Writing
+# ----------------------------------------------------------------------
+
+def _table_id(name: str) -> str:
+    return "callouts_" + (re.sub(r"[^A-Za-z0-9_.-]+", "_", posixpath.splitext(name)[0]).strip("_") or "image")
+
+
+def _block(image: Any) -> Optional[Any]:
+    """The body block showing *image* (its ``p`` or ``fig``), or None outside a topic body."""
+    el = image
+    while el.getparent() is not None and el.getparent().tag not in _CONTAINERS:
+        el = el.getparent()
+    return el if el.getparent() is not None and el is not image else None
+
+
+def _table(callouts: Sequence[Callout], table_id: str, lang: str) -> Any:
+    catalog = get_catalog()
+    table = ET.Element("simpletable", outputclass="callouts", id=table_id, relcolwidth="1* 6*")
+    head = ET.SubElement(table, "sthead")
+    ET.SubElement(head, "stentry").text = catalog.get("callout_number", lang)
+    ET.SubElement(head, "stentry").text = catalog.get("callout_part", lang)
+    for callout in sorted(callouts, key=lambda c: c.number):
+        row = ET.SubElement(table, "strow")
+        ET.SubElement(row, "stentry").text = str(callout.number)
+        ET.SubElement(row, "stentry").text = " ".join(callout.label.split()) or None
+    return table
+
+
+def _remove_tables(topic: Any, table_id: str) -> None:
+    for table in topic.xpath(".//simpletable[@outputclass='callouts'][@id=$id]", id=table_id):
+        parent = table.getparent()
+        previous = table.getprevious()
+        if previous is not None:
+            previous.tail = (previous.tail or "") + (table.tail or "") or None
+        else:
+            parent.text = (parent.text or "") + (table.tail or "") or None
+        parent.remove(table)
+
+
+def set_image_callouts(context: Any, name: str, callouts: Sequence[Callout], mode: str = "png") -> int:
+    """Annotate the media file *name* with *callouts* (none: plain image); returns the images changed.
+
+    Raises ValueError naming the first invalid callout, a repeated number or
+    an unknown *mode*; SVG images cannot be annotated.
+    """
+    if mode not in MODES:
+        raise ValueError(f"Unknown callout output {mode!r} (expected png or svg)")
+    data = context.images.get(name)
+    if data is None:
+        raise ValueError(f"No image named {name}")
+    if callouts and (is_svg(name, data) or is_metafile(name, data)):
+        raise ValueError("Callouts need a raster image (PNG, JPEG, GIF)")
+    size = image_size(data) if callouts else None
+    seen: set = set()
+    for callout in callouts:
+        problem = callout.validate(size)
+        if problem:
+            raise ValueError(f"Callout {callout.number}: {problem}")
+        if callout.number in seen:
+            raise ValueError(f"Callout {callout.number} is used twice")
+        seen.add(callout.number)
+
+    saved: Dict[str, Any] = dict(context.metadata.get(METADATA_KEY) or {})
+    previous = (saved.get(name) or {}).get("output")
+    output = name
+    if callouts:
+        stem = posixpath.splitext(name)[0]
+        output = f"{stem}_callouts.{mode}"
+        if mode == "png":
+            context.images[output] = render_callouts_png(data, callouts)
+        else:
+            context.images[output] = render_callouts_svg(name, data, size, callouts)
+    if previous and previous not in (output, name):
+        context.images.pop(previous, None)
+
+    table_id = _table_id(name)
+    lang = document_language(context)
+    count = 0
+    placed: set = set()
+    shown = [name] + ([previous] if previous and previous != name else [])
+    for shown_name in shown:
+        for _, image in list(_image_elements(context, shown_name)):
+            image.set("href", posixpath.join(posixpath.dirname(image.get("href") or ""), output))
+            count += 1
+    for topic_name, topic in context.topics.items():
+        _remove_tables(topic, table_id)
+        if not callouts:
+            continue
+        for image in topic.iter("image"):
+            if posixpath.basename(image.get("href") or "") != output or topic_name in placed:
+                continue
+            block = _block(image)
+            if block is None:
+                continue
+            table = _table(callouts, table_id, lang)
+            table.tail, block.tail = block.tail, None
+            block.getparent().insert(block.getparent().index(block) + 1, table)
+            placed.add(topic_name)
+
+    if callouts:
+        saved[name] = {"mode": mode, "output": output,
+                       "callouts": [[c.number, c.x, c.y, c.label] for c in callouts]}
+    else:
+        saved.pop(name, None)
+    if saved:
+        context.metadata[METADATA_KEY] = saved
+    else:
+        context.metadata.pop(METADATA_KEY, None)
+    logger.info("Callouts of %s: %d marker(s) on %d image(s), %d table(s)", name, len(callouts), count, len(placed))
+    return count
@@ -0,0 +1,90 @@
+from __future__ import annotations
+
+"""Cooperative cancellation for long-running pipeline work.
+
+A :class:`CancellationToken` is created by the front-end (GUI cancel button,
+server job, CLI signal handler) and threaded through parsing, media
+processing and packaging. Workers call :meth:`CancellationToken.raise_if_cancelled`
+at safe checkpoints so that work stops promptly and temporary files are
+released by the surrounding ``with`` blocks.
+
+The token is thread-safe and has no GUI dependencies.
+"""
+
+import logging
+import threading
+from typing import Callable, List, Optional
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["CancellationToken", "OperationCancelledError", "check_cancelled"]
+
+
+class OperationCancelledError(Exception):
+    """Raised at a checkpoint when the associated token has been cancelled."""
+
+    def __init__(self, message: str = "Operation cancelled", reason: Optional[str] = None) -> None:
+        super().__init__(message)
+        self.reason = reason
+
+
+class CancellationToken:
+    """Thread-safe, one-shot cancellation flag.
+
+    Once cancelled, a token stays cancelled. Callbacks registered with
+    :meth:`add_callback` run exactly once, on the thread that calls
+    :meth:`cancel` (or immediately if the token is already cancelled).
+    """
+
+    def __init__(self) -> None:
+        self._event = threading.Event()
+        self._lock = threading.Lock()
+        self._reason: Optional[str] = None
+        self._callbacks: List[Callable[[], None]] = []
+
+    @property
+    def is_cancelled(self) -> bool:
+        return self._event.is_set()
+
+    @property
+    def reason(self) -> Optional[str]:
+        return self._reason
+
+    def cancel(self, reason: str = "Cancelled by user") -> None:
+        """Request cancellation; idempotent."""
+        with self._lock:
+            if self._event.is_set():
+                return
+            self._reason = reason
+            self._event.set()
+            callbacks = list(self._callbacks)
+            self._callbacks.clear()
+        logger.info("Cancellation requested: %s", reason)
+        for cb in callbacks:
+            try:
+                cb()
+            except Exception as exc:
+                logger.debug("Cancellation callback failed: %s", exc)
+
+    def add_callback(self, callback: Callable[[], None]) -> None:
+        """Register *callback* to run when the token is cancelled."""
+        with self._lock:
+            if not self._event.is_set():
+                self._callbacks.append(callback)
+                return
+        callback()
+
+    def raise_if_cancelled(self) -> None:
+        """Raise :class:`OperationCancelledError` if cancellation was requested."""
+        if self._event.is_set():
+            raise OperationCancelledError(self._reason or "Operation cancelled", self._reason)
+
+    def wait(self, timeout: Optional[float] = None) -> bool:
+        """Block until cancelled or *timeout* elapses; return the cancelled state."""
+        return self._event.wait(timeout)
+
+
+def check_cancelled(token: Optional[CancellationToken]) -> None:
+    """Checkpoint helper accepting an optional token."""
+    if token is not None:
+        token.raise_if_cancelled()
@@ -0,0 +1,608 @@
+from __future__ import annotations
+
+"""Word charts and SmartArt diagrams as figures.
+
+Converters skip drawings that are not pictures, so embedded Excel charts
+(``c:chart``) and SmartArt (``dgm:relIds``) vanish from the output. After
+the handler returns, :func:`restore_word_charts` reads ``word/document.xml``
+of the source and, for every chart or diagram:
+
+- renders it as SVG: charts from the values cached in the chart part (bar,
+  column, line, area, scatter, pie and doughnut), diagrams from the shapes
+  Word laid out in ``word/diagrams/drawing*.xml`` (or, without them, as a
+  list of the diagram's nodes);
+- otherwise uses the preview image Word stored next to it
+  (``mc:Fallback``); ``charts.prefer: preview`` tries it first.
+
+The image is added to ``context.images`` and referenced from a
+``<fig>`` placed after the paragraph holding the drawing (located by text
+like footnotes are, :mod:`orlando_toolkit.core.placement`), or at a
+converter's ``<ph data-chart="N"/>`` placeholder (``N`` counts the charts
+and diagrams of the document from 1). The figure title is the caption
+paragraph next to the drawing (style ``charts.caption_style``), which is
+removed from the text, else the chart's own title or the drawing's title.
+The images then go through the image stages like any other. Findings are
+reported under ``charts``.
+"""
+
+import logging
+import math
+import posixpath
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.placement import find_block, normalize, text_blocks, topic_order
+from orlando_toolkit.core.utils import topic_body
+from orlando_toolkit.core.word_source import DOCUMENT, R_NS, WordSource, w
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["CHART_HINT", "WordChart", "read_word_charts", "render_chart_svg", "render_diagram_svg",
+           "restore_word_charts"]
+
+CHART_HINT = "data-chart"
+_WP = "http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing"
+_A = "http://schemas.openxmlformats.org/drawingml/2006/main"
+_C = "http://schemas.openxmlformats.org/drawingml/2006/chart"
+_DGM = "http://schemas.openxmlformats.org/drawingml/2006/diagram"
+_DSP = "http://schemas.microsoft.com/office/drawing/2008/diagram"
+_MC = "http://schemas.openxmlformats.org/markup-compatibility/2006"
+_V = "urn:schemas-microsoft-com:vml"
+_SVG = "http://www.w3.org/2000/svg"
+
+_WIDTH, _HEIGHT = 640, 400
+_EMU_PER_PX = 9525
+_PALETTE = ("#4472c4", "#ed7d31", "#a5a5a5", "#ffc000", "#5b9bd5", "#70ad47", "#264478", "#9e480e")
+_PREFER = ("render", "preview")
+_CHART_TYPES = {"barChart": "bar", "bar3DChart": "bar", "lineChart": "line", "line3DChart": "line",
+                "areaChart": "area", "area3DChart": "area", "scatterChart": "line", "pieChart": "pie",
+                "pie3DChart": "pie", "doughnutChart": "pie", "ofPieChart": "pie"}
+
+
+def _local(el: Any) -> str:
+    return el.tag.split("}")[-1] if isinstance(el.tag, str) else ""
+
+
+@dataclass
+class WordChart:
+    number: int               # 1-based, charts and diagrams together, in document order
+    kind: str                 # chart | diagram
+    anchor: str               # text of the paragraph holding it, else of the last paragraph before it
+    inside: bool              # *anchor* is the drawing's own paragraph
+    title: str = ""
+    caption: str = ""         # caption paragraph next to the drawing
+    caption_before: bool = False
+    svg: Optional[bytes] = None
+    preview: Optional[Tuple[str, bytes]] = None   # (media file name, data)
+
+
+# ----------------------------------------------------------------------
+# Rendering
+# ----------------------------------------------------------------------
+
+def _svg(width: int = _WIDTH, height: int = _HEIGHT) -> Any:
+    return ET.Element(f"{{{_SVG}}}svg", nsmap={None: _SVG}, width=str(width), height=str(height),
+                      viewBox=f"0 0 {width} {height}")
+
+
+def _add(parent: Any, tag: str, text: Optional[str] = None, **attrib: Any) -> Any:
+    el = ET.SubElement(parent, f"{{{_SVG}}}{tag}", {k.replace("_", "-"): str(v) for k, v in attrib.items()})
+    if text:
+        el.text = text
+    return el
+
+
+def _label(parent: Any, x: float, y: float, text: str, size: int = 11, anchor: str = "middle",
+           **attrib: Any) -> Any:
+    return _add(parent, "text", text, x=f"{x:.1f}", y=f"{y:.1f}", font_size=size, text_anchor=anchor,
+                font_family="Arial, Helvetica, sans-serif", **attrib)
+
+
+def _text_of(el: Any) -> str:
+    return normalize("".join(t.text or "" for t in el.iter(f"{{{_A}}}t"))) if el is not None else ""
+
+
+def _cache(el: Any) -> List[str]:
+    """Values of a ``c:strCache``/``c:numCache`` below *el*, by ``idx``."""
+    if el is None:
+        return []
+    points: Dict[int, str] = {}
+    count = 0
+    caches = (f"{{{_C}}}strCache", f"{{{_C}}}numCache", f"{{{_C}}}strLit", f"{{{_C}}}numLit")
+    for cache in (c for c in el.iter() if c.tag in caches):
+        count_el = cache.find(f"{{{_C}}}ptCount")
+        if count_el is not None and (count_el.get("val") or "").isdigit():
+            count = max(count, int(count_el.get("val")))
+        for pt in cache.iter(f"{{{_C}}}pt"):
+            idx = pt.get("idx") or "0"
+            points[int(idx) if idx.isdigit() else len(points)] = pt.findtext(f"{{{_C}}}v") or ""
+        break
+    size = max(count, max(points) + 1 if points else 0)
+    return [points.get(i, "") for i in range(size)]
+
+
+def _number(value: str) -> float:
+    try:
+        return float(value)
+    except (TypeError, ValueError):
+        return 0.0
+
+
+def _format(value: float) -> str:
+    return f"{value:.0f}" if float(value).is_integer() else f"{value:.2f}".rstrip("0")
+
+
+def _first(el: Any, *names: str) -> Optional[Any]:
+    return next((c for c in (el.find(f"{{{_C}}}{n}") for n in names) if c is not None), None)
+
+
+def _chart_data(chart: Any) -> Tuple[str, str, List[str], List[Tuple[str, List[float]]]]:
+    """``(type, title, categories, [(series name, values)])`` of a chart part."""
+    plot = chart.find(f".//{{{_C}}}plotArea")
+    kind, group = "", None
+    for child in plot if plot is not None else ():
+        if _local(child) in _CHART_TYPES:
+            kind, group = _CHART_TYPES[_local(child)], child
+            if kind == "bar":
+                direction = child.find(f"{{{_C}}}barDir")
+                kind = "bar" if direction is not None and direction.get("val") == "bar" else "column"
+            break
+    title_el = chart.find(f".//{{{_C}}}chart/{{{_C}}}title")
+    title = _text_of(title_el)
+    categories: List[str] = []
+    series: List[Tuple[str, List[float]]] = []
+    for index, ser in enumerate(group.findall(f"{{{_C}}}ser") if group is not None else ()):
+        name = " ".join(v for v in _cache(ser.find(f"{{{_C}}}tx")) if v) or f"Series {index + 1}"
+        values = [_number(v) for v in _cache(_first(ser, "val", "yVal"))]
+        if not categories:
+            categories = _cache(_first(ser, "cat", "xVal"))
+        series.append((name, values))
+    if not title and len(series) == 1 and title_el is not None:
+        title = series[0][0]
+    return kind, title, categories, series
+
+
+def _legend(svg: Any, names: List[str], top: float) -> None:
+    x = 60.0
+    for index, name in enumerate(names):
+        _add(svg, "rect", x=f"{x:.1f}", y=f"{top:.1f}", width=10, height=10, fill=_PALETTE[index % len(_PALETTE)])
+        _label(svg, x + 14, top + 9, name, size=10, anchor="start")
+        x += 24 + 6.5 * len(name)
+
+
+def _pie(svg: Any, categories: List[str], values: List[float], top: float) -> None:
+    total = sum(v for v in values if v > 0)
+    cx, cy, radius = _WIDTH / 2, (top + _HEIGHT) / 2, min(_WIDTH, _HEIGHT - top) / 2 - 30
+    angle = -math.pi / 2
+    for index, value in enumerate(values):
+        if value <= 0 or total <= 0:
+            continue
+        sweep = 2 * math.pi * value / total
+        end = angle + sweep
+        x1, y1 = cx + radius * math.cos(angle), cy + radius * math.sin(angle)
+        x2, y2 = cx + radius * math.cos(end), cy + radius * math.sin(end)
+        color = _PALETTE[index % len(_PALETTE)]
+        if sweep >= 2 * math.pi - 1e-9:
+            _add(svg, "circle", cx=f"{cx:.1f}", cy=f"{cy:.1f}", r=f"{radius:.1f}", fill=color)
+        else:
+            large = 1 if sweep > math.pi else 0
+            _add(svg, "path", fill=color, stroke="#ffffff",
+                 d=f"M{cx:.1f},{cy:.1f} L{x1:.1f},{y1:.1f} A{radius:.1f},{radius:.1f} 0 {large} 1 "
+                   f"{x2:.1f},{y2:.1f} Z")
+        middle = angle + sweep / 2
+        name = categories[index] if index < len(categories) and categories[index] else str(index + 1)
+        _label(svg, cx + (radius + 16) * math.cos(middle), cy + (radius + 16) * math.sin(middle) + 4,
+               f"{name} ({value / total:.0%})", size=10,
+               anchor="start" if math.cos(middle) > 0.2 else "end" if math.cos(middle) < -0.2 else "middle")
+        angle = end
+
+
+def _axes(svg: Any, kind: str, categories: List[str], series: List[Tuple[str, List[float]]], top: float) -> None:
+    left, right, bottom = 60.0, _WIDTH - 20.0, _HEIGHT - 40.0
+    values = [v for _, vals in series for v in vals] or [0.0]
+    low, high = min(0.0, min(values)), max(0.0, max(values))
+    if high == low:
+        high = low + 1
+    count = max(len(categories), max((len(v) for _, v in series), default=0), 1)
+    horizontal = kind == "bar"
+    length = (bottom - top) if horizontal else (right - left)
+
+    def scale(value: float) -> float:
+        span = (right - left) if horizontal else (bottom - top)
+        fraction = (value - low) / (high - low)
+        return left + fraction * span if horizontal else bottom - fraction * span
+
+    for step in range(5):
+        value = low + (high - low) * step / 4
+        if horizontal:
+            x = scale(value)
+            _add(svg, "line", x1=f"{x:.1f}", y1=f"{top:.1f}", x2=f"{x:.1f}", y2=f"{bottom:.1f}", stroke="#d9d9d9")
+            _label(svg, x, bottom + 14, _format(value), size=9)
+        else:
+            y = scale(value)
+            _add(svg, "line", x1=f"{left:.1f}", y1=f"{y:.1f}", x2=f"{right:.1f}", y2=f"{y:.1f}", stroke="#d9d9d9")
+            _label(svg, left - 6, y + 3, _format(value), size=9, anchor="end")
+    slot = length / count
+    for index in range(count):
+        name = categories[index] if index < len(categories) else ""
+        if horizontal:
+            _label(svg, left - 6, top + slot * (index + 0.5) + 3, name, size=9, anchor="end")
+        else:
+            _label(svg, left + slot * (index + 0.5), bottom + 14, name, size=9)
+    zero = scale(0.0)
+    for number, (_name, vals) in enumerate(series):
+        color = _PALETTE[number % len(_PALETTE)]
+        if kind in ("bar", "column"):
+            width = slot * 0.8 / max(len(series), 1)
+            for index, value in enumerate(vals):
+                start = slot * index + slot * 0.1 + width * number
+                edge = scale(value)
+                if horizontal:
+                    _add(svg, "rect", x=f"{min(zero, edge):.1f}", y=f"{top + start:.1f}",
+                         width=f"{abs(edge - zero):.1f}", height=f"{width:.1f}", fill=color)
+                else:
+                    _add(svg, "rect", x=f"{left + start:.1f}", y=f"{min(zero, edge):.1f}", width=f"{width:.1f}",
+                         height=f"{abs(edge - zero):.1f}", fill=color)
+        else:
+            points = [(left + slot * (index + 0.5), scale(value)) for index, value in enumerate(vals)]
+            if kind == "area" and points:
+                outline = [(points[0][0], zero)] + points + [(points[-1][0], zero)]
+                _add(svg, "polygon", points=" ".join(f"{x:.1f},{y:.1f}" for x, y in outline), fill=color,
+                     fill_opacity="0.6")
+            else:
+                _add(svg, "polyline", points=" ".join(f"{x:.1f},{y:.1f}" for x, y in points), fill="none",
+                     stroke=color, stroke_width=2)
+    _add(svg, "line", x1=f"{left:.1f}", y1=f"{bottom:.1f}" if not horizontal else f"{top:.1f}",
+         x2=f"{right:.1f}" if not horizontal else f"{left:.1f}", y2=f"{bottom:.1f}", stroke="#595959")
+
+
+def render_chart_svg(chart: Any) -> Optional[bytes]:
+    """SVG of a chart part (``c:chartSpace``) from its cached values; None for unsupported charts."""
+    kind, title, categories, series = _chart_data(chart)
+    if not kind or not any(values for _, values in series):
+        return None
+    svg = _svg()
+    _add(svg, "rect", x=0, y=0, width=_WIDTH, height=_HEIGHT, fill="#ffffff")
+    top = 20.0
+    if title:
+        _label(svg, _WIDTH / 2, 24, title, size=14, font_weight="bold")
+        top = 40.0
+    if kind == "pie":
+        _pie(svg, categories, series[0][1], top)
+    else:
+        if len(series) > 1:
+            _legend(svg, [name for name, _ in series], top)
+            top += 20
+        _axes(svg, kind, categories, series, top + 10)
+    return ET.tostring(svg, xml_declaration=True, encoding="UTF-8")
+
+
+def _wrap_text(text: str, width: float, size: int) -> List[str]:
+    per_line = max(int(width / (size * 0.55)), 4)
+    lines: List[str] = []
+    for word in text.split():
+        if lines and len(lines[-1]) + 1 + len(word) <= per_line:
+            lines[-1] += " " + word
+        else:
+            lines.append(word)
+    return lines
+
+
+def _box(svg: Any, x: float, y: float, width: float, height: float, text: str, shape: str, fill: str) -> None:
+    if shape == "ellipse":
+        _add(svg, "ellipse", cx=f"{x + width / 2:.1f}", cy=f"{y + height / 2:.1f}", rx=f"{width / 2:.1f}",
+             ry=f"{height / 2:.1f}", fill=fill, stroke="#ffffff")
+    elif shape in ("rightArrow", "chevron", "homePlate"):
+        tip = min(width / 3, height / 2)
+        _add(svg, "polygon", fill=fill, points=f"{x:.1f},{y:.1f} {x + width - tip:.1f},{y:.1f} "
+                                               f"{x + width:.1f},{y + height / 2:.1f} {x + width - tip:.1f},"
+                                               f"{y + height:.1f} {x:.1f},{y + height:.1f}")
+    else:
+        radius = min(width, height) / 8 if shape == "roundRect" else 0
+        _add(svg, "rect", x=f"{x:.1f}", y=f"{y:.1f}", width=f"{width:.1f}", height=f"{height:.1f}", rx=f"{radius:.1f}",
+             fill=fill, stroke="#ffffff")
+    size = 12 if height >= 36 else 10
+    lines = _wrap_text(text, width - 8, size)
+    for index, line in enumerate(lines):
+        _label(svg, x + width / 2, y + height / 2 + (index - (len(lines) - 1) / 2) * (size + 2) + size / 3, line,
+               size=size, fill="#ffffff")
+
+
+def render_diagram_svg(drawing: Optional[Any], data: Optional[Any] = None) -> Optional[bytes]:
+    """SVG of a SmartArt diagram from its drawing part (``dsp:drawing``), else from its data model."""
+    shapes = []
+    for sp in drawing.iter(f"{{{_DSP}}}sp") if drawing is not None else ():
+        xfrm = sp.find(f"{{{_DSP}}}spPr/{{{_A}}}xfrm")
+        off = xfrm.find(f"{{{_A}}}off") if xfrm is not None else None
+        ext = xfrm.find(f"{{{_A}}}ext") if xfrm is not None else None
+        if off is None or ext is None:
+            continue
+        geometry = sp.find(f"{{{_DSP}}}spPr/{{{_A}}}prstGeom")
+        shapes.append(([int(off.get("x") or 0), int(off.get("y") or 0), int(ext.get("cx") or 0),
+                        int(ext.get("cy") or 0)], geometry.get("prst") if geometry is not None else "rect",
+                       _text_of(sp.find(f"{{{_DSP}}}txBody"))))
+    if shapes:
+        left = min(box[0] for box, _, _ in shapes)
+        top = min(box[1] for box, _, _ in shapes)
+        width = max(box[0] + box[2] for box, _, _ in shapes) - left
+        height = max(box[1] + box[3] for box, _, _ in shapes) - top
+        scale = min((_WIDTH - 20) / max(width, 1), 1 / _EMU_PER_PX * 2)
+        svg = _svg(_WIDTH, int(height * scale) + 20)
+        for index, (box, shape, text) in enumerate(shapes):
+            _box(svg, 10 + (box[0] - left) * scale, 10 + (box[1] - top) * scale, box[2] * scale, box[3] * scale,
+                 text, shape, _PALETTE[index % len(_PALETTE)])
+        return ET.tostring(svg, xml_declaration=True, encoding="UTF-8")
+    texts = [_text_of(pt.find(f"{{{_DGM}}}t")) for pt in data.iter(f"{{{_DGM}}}pt")
+             if pt.get("type") in (None, "node")] if data is not None else []
+    texts = [t for t in texts if t]
+    if not texts:
+        return None
+    svg = _svg(_WIDTH, 20 + 50 * len(texts))
+    for index, text in enumerate(texts):
+        _box(svg, 80, 10 + 50 * index, _WIDTH - 160, 40, text, "roundRect", _PALETTE[0])
+    return ET.tostring(svg, xml_declaration=True, encoding="UTF-8")
+
+
+# ----------------------------------------------------------------------
+# Reading
+# ----------------------------------------------------------------------
+
+def _targets(docx: WordSource, part: str) -> Dict[str, str]:
+    return {rel: target for rel, (target, external) in docx.relationships(part).items() if not external}
+
+
+def _read(docx: WordSource, part: Optional[str]) -> Optional[Any]:
+    return docx.part(part) if part else None
+
+
+def _paragraph_text(paragraph: Any) -> str:
+    parts = []
+    for el in paragraph.iter():
+        if el.tag == w("t"):
+            parts.append(el.text or "")
+        elif el.tag in (w("tab"), w("br")):
+            parts.append(" ")
+    return normalize("".join(parts))
+
+
+def _style_names(docx: WordSource) -> Dict[str, str]:
+    styles = docx.part("word/styles.xml")
+    names: Dict[str, str] = {}
+    for style in styles.iter(w("style")) if styles is not None else ():
+        name = style.find(w("name"))
+        names[style.get(w("styleId")) or ""] = name.get(w("val")) if name is not None else ""
+    return names
+
+
+def _is_caption(paragraph: Optional[Any], names: Dict[str, str], wanted: str) -> bool:
+    if paragraph is None or paragraph.tag != w("p"):
+        return False
+    style = paragraph.find(f"{w('pPr')}/{w('pStyle')}")
+    style_id = style.get(w("val")) if style is not None else ""
+    return wanted.casefold() in (style_id.casefold(), names.get(style_id, "").casefold())
+
+
+def _preview(graphic: Any, docx: WordSource, targets: Dict[str, str]) -> Optional[Tuple[str, bytes]]:
+    """The image stored in the ``mc:Fallback`` of the drawing's ``mc:AlternateContent``."""
+    alternate = next((a for a in graphic.iterancestors() if a.tag == f"{{{_MC}}}AlternateContent"), None)
+    fallback = alternate.find(f"{{{_MC}}}Fallback") if alternate is not None else None
+    if fallback is None:
+        return None
+    for el in (e for e in fallback.iter() if e.tag in (f"{{{_A}}}blip", f"{{{_V}}}imagedata")):
+        rel = el.get(f"{{{R_NS}}}embed") or el.get(f"{{{R_NS}}}id")
+        media = targets.get(rel or "")
+        if media:
+            try:
+                return posixpath.basename(media), docx.read(media)
+            except KeyError:
+                continue
+    return None
+
+
+def read_word_charts(path: str | Path | WordSource, options: Optional[Mapping[str, Any]] = None) -> List[WordChart]:
+    """Charts and SmartArt diagrams of the body of the ``.docx`` *path*, rendered."""
+    options = dict(options or {})
+    docx = WordSource.of(path)
+    if docx is None or docx.document is None:
+        return []
+    charts: List[WordChart] = []
+    targets = _targets(docx, DOCUMENT)
+    names = _style_names(docx)
+    caption_style = str(options.get("caption_style") or "Caption")
+    anchor = ""
+    for paragraph in docx.paragraphs:
+        if any(a.tag == w("p") for a in paragraph.iterancestors()):
+            continue
+        text = _paragraph_text(paragraph)
+        for graphic in paragraph.iter(f"{{{_A}}}graphicData"):
+            uri = graphic.get("uri") or ""
+            if uri.endswith("/chart"):
+                kind = "chart"
+            elif uri.endswith("/diagram"):
+                kind = "diagram"
+            else:
+                continue
+            if any(a.tag == f"{{{_MC}}}Fallback" for a in graphic.iterancestors()):
+                continue
+            chart = WordChart(len(charts) + 1, kind, text or anchor, inside=bool(text))
+            props = next((p for p in graphic.iterancestors() if _local(p) in ("inline", "anchor")), None)
+            doc_pr = props.find(f"{{{_WP}}}docPr") if props is not None else None
+            if doc_pr is not None:
+                chart.title = (doc_pr.get("title") or doc_pr.get("descr") or "").strip()
+            try:
+                if kind == "chart":
+                    ref = graphic.find(f"{{{_C}}}chart")
+                    part = _read(docx, targets.get(ref.get(f"{{{R_NS}}}id") or "") if ref is not None else None)
+                    if part is not None:
+                        chart.svg = render_chart_svg(part)
+                        chart.title = _chart_data(part)[1] or chart.title
+                else:
+                    ids = graphic.find(f"{{{_DGM}}}relIds")
+                    data_part = targets.get(ids.get(f"{{{R_NS}}}dm") or "") if ids is not None else None
+                    data = _read(docx, data_part)
+                    ext = next(data.iter(f"{{{_DSP}}}dataModelExt"), None) if data is not None else None
+                    drawing = _read(docx, targets.get(ext.get("relId") or "") if ext is not None else None)
+                    chart.svg = render_diagram_svg(drawing, data)
+            except Exception as exc:
+                logger.debug("%s %s could not be rendered: %s", kind, chart.number, exc)
+            chart.preview = _preview(graphic, docx, targets)
+            following, preceding = paragraph.getnext(), paragraph.getprevious()
+            if _is_caption(following, names, caption_style):
+                chart.caption = _paragraph_text(following)
+            elif _is_caption(preceding, names, caption_style):
+                chart.caption, chart.caption_before = _paragraph_text(preceding), True
+            charts.append(chart)
+        if text:
+            anchor = text
+    return charts
+
+
+# ----------------------------------------------------------------------
+# Placement
+# ----------------------------------------------------------------------
+
+def _unique(images: Any, name: str) -> str:
+    stem, ext = posixpath.splitext(name)
+    candidate, n = name, 2
+    while candidate in images:
+        candidate, n = f"{stem}_{n}{ext}", n + 1
+    return candidate
+
+
+def _figure(chart: WordChart, context: Any, prefer: str) -> Optional[Tuple[Any, str, bytes, bool]]:
+    """``(fig, image name, image data, from the preview)``; None when there is no image."""
+    sources = [(False, chart.svg), (True, chart.preview)]
+    if prefer == "preview":
+        sources.reverse()
+    for preview, value in sources:
+        if not value:
+            continue
+        if preview:
+            ext = posixpath.splitext(value[0])[1] or ".png"
+            name, data = _unique(context.images, f"{chart.kind}_{chart.number}{ext}"), value[1]
+        else:
+            name, data = _unique(context.images, f"{chart.kind}_{chart.number}.svg"), value
+        fig = ET.Element("fig", outputclass=chart.kind)
+        title = chart.caption or chart.title
+        if title:
+            ET.SubElement(fig, "title").text = title
+        image = ET.SubElement(fig, "image", href=f"../media/{name}", placement="break")
+        ET.SubElement(image, "alt").text = chart.title or title or ("Chart" if chart.kind == "chart" else "Diagram")
+        return fig, name, data, preview
+    return None
+
+
+def _after(block: Any, new: Any) -> None:
+    if block.tag in ("li", "entry", "stentry", "dd") or block.getparent() is None:
+        block.append(new)
+        return
+    parent = block.getparent()
+    new.tail = block.tail
+    block.tail = None
+    parent.insert(parent.index(block) + 1, new)
+
+
+def _replace(el: Any, new: Any) -> None:
+    parent = el.getparent()
+    new.tail = el.tail
+    parent.insert(parent.index(el), new)
+    parent.remove(el)
+
+
+def _drop(el: Any) -> None:
+    parent = el.getparent()
+    previous = el.getprevious()
+    if previous is not None:
+        previous.tail = (previous.tail or "") + (el.tail or "") or None
+    else:
+        parent.text = (parent.text or "") + (el.tail or "") or None
+    parent.remove(el)
+
+
+def _drop_caption(blocks: List[Tuple[str, Any, str]], chart: WordChart, cursor: int) -> None:
+    hit, _ = find_block(blocks, chart.caption, max(cursor - 2, 0))
+    el = blocks[hit][1] if hit is not None else None
+    if el is not None and el.tag == "p" and el.getparent() is not None:
+        _drop(el)
+
+
+def restore_word_charts(path: str | Path | WordSource, context: Any, options: Optional[Mapping[str, Any]] = None,
+                        report: Any = None) -> int:
+    """Add the charts and diagrams of the ``.docx`` *path* to *context* as figures; returns the number placed."""
+    options = dict(options or {})
+    if not options.get("enabled", True):
+        return 0
+    report = report if report is not None else getattr(context, "report", None)
+    try:
+        charts = read_word_charts(path, options)
+    except Exception as exc:
+        logger.warning("Could not read the charts of %s: %s", Path(path).name, exc)
+        return 0
+    if not charts:
+        return 0
+    prefer = str(options.get("prefer") or "render")
+    if prefer not in _PREFER:
+        logger.warning("Unknown charts.prefer %r (expected %s)", prefer, ", ".join(_PREFER))
+        prefer = "render"
+    placeholders = {el.get(CHART_HINT): el for name in topic_order(context)
+                    for el in context.topics[name].iter("ph") if el.get(CHART_HINT) is not None}
+    blocks = text_blocks(context)
+    cursor = placed = 0
+    failed: List[int] = []
+    unplaced: List[int] = []
+    previews = 0
+    for chart in charts:
+        figure = _figure(chart, context, prefer)
+        if figure is None:
+            failed.append(chart.number)
+            continue
+        fig, name, data, preview = figure
+        placeholder = placeholders.get(str(chart.number))
+        if placeholder is not None:
+            parent = placeholder.getparent()
+            target = parent if parent.tag == "p" and len(parent) == 1 and not normalize(
+                (parent.text or "") + (placeholder.tail or "")) else placeholder
+            _replace(target, fig)
+            context.images[name] = data
+            placed, previews = placed + 1, previews + preview
+            continue
+        hit, after = find_block(blocks, chart.anchor, cursor) if chart.anchor else (None, cursor)
+        if hit is not None:
+            block = blocks[hit][1]
+            cursor = after
+            if chart.caption and chart.caption_before and not chart.inside and chart.anchor == chart.caption:
+                _replace(block, fig)        # the caption above the drawing becomes its title
+            else:
+                _after(block, fig)
+                if chart.caption:
+                    _drop_caption(blocks, chart, cursor)
+        elif not chart.anchor and context.topics:
+            body = topic_body(context.topics[topic_order(context)[0]])
+            if body is None:
+                unplaced.append(chart.number)
+                continue
+            body.insert(0, fig)
+            if chart.caption:
+                _drop_caption(blocks, chart, 0)
+        else:
+            unplaced.append(chart.number)
+            continue
+        context.images[name] = data
+        placed, previews = placed + 1, previews + preview
+
+    if report is not None:
+        kinds = [c.kind for c in charts if c.number not in failed and c.number not in unplaced]
+        if placed:
+            report.info("charts", f"{kinds.count('chart')} chart(s) and {kinds.count('diagram')} SmartArt "
+                                  f"diagram(s) added as figures, {previews} from their preview image",
+                        charts=kinds.count("chart"), diagrams=kinds.count("diagram"), previews=previews)
+        if failed:
+            report.warning("charts", f"{len(failed)} chart(s) or diagram(s) could neither be rendered nor taken "
+                                     "from a preview image", numbers=failed)
+        if unplaced:
+            report.warning("charts", f"{len(unplaced)} chart(s) or diagram(s) could not be placed: the paragraph "
+                                     "before them was not found in the converted topics", numbers=unplaced)
+    return placed
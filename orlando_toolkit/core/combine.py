@@ -0,0 +1,326 @@
+from __future__ import annotations
+
+"""Several source documents combined into one DITA map.
+
+Manuals authored as one Word document per chapter are converted file by
+file (:meth:`ConversionService.convert_combined`) and stitched into a
+single :class:`DitaContext` by :func:`combine_documents`:
+
+- the ``combine`` section of ``pipeline.yml`` sets the chapter hierarchy:
+  ``chapters`` puts each document under a section titled after it,
+  ``folders`` additionally groups those sections by sub-folder of the
+  selected folder, and ``flat`` places the top-level topics of every
+  document directly in the map. Levels (``data-level``) follow the new
+  nesting;
+- topic, image and video file names are kept when free and otherwise
+  numbered (``topic_1_2.dita``); identical images share one file. Map
+  entries, links, conrefs and media references of the document are updated;
+- bookmarks already used by an earlier document are renamed
+  (``<document>_<bookmark>``) with the internal links pointing at them;
+- links to another member of the set (``Chapter2.docx#Install``) become
+  internal links, resolved with the others when the package is prepared.
+  A bookmark missing from the target links to its first topic and is
+  reported under ``combine``.
+
+Per-document session state (heading outline, depth merges, key scope) is not
+carried over; combined contexts cannot be updated incrementally from a
+single source (:mod:`orlando_toolkit.core.reconvert`).
+"""
+
+import logging
+import os
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Callable, Dict, Iterable, List, Mapping, Optional, Sequence, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.cross_links import _document_name, _fragment
+from orlando_toolkit.core.internal_links import BOOKMARK_HINT, topic_bookmarks
+from orlando_toolkit.core.models import ConversionReport, DitaContext
+from orlando_toolkit.core.spool import SpooledBlobs
+from orlando_toolkit.core.stable_ids import ANCHOR_HINT
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["CombineSettings", "HIERARCHIES", "collect_sources", "combine_documents"]
+
+HIERARCHIES = ("chapters", "folders", "flat")
+_STRUCTURAL = ("topicref", "topichead")
+_LINK_TAGS = ("xref", "link")
+_REF_ATTRS = ("href", "conref", "conrefend", "data")
+_MEDIA_PREFIX = "../media/"
+# Metadata describing one source document or one editing session, not the combined map
+_DOCUMENT_KEYS = ("source_outline", "source_file", "key_scope", "original_structure", "merged_depth",
+                  "merged_exclude_styles", "merged_exclude", "topic_depth", "depth_overrides")
+
+
+@dataclass
+class CombineSettings:
+    hierarchy: str = "chapters"
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "CombineSettings":
+        data = data or {}
+        hierarchy = str(data.get("hierarchy") or "chapters").lower()
+        if hierarchy not in HIERARCHIES:
+            logger.warning("Unknown combine hierarchy %r; using chapters", hierarchy)
+            hierarchy = "chapters"
+        return cls(hierarchy=hierarchy)
+
+    @classmethod
+    def from_config(cls) -> "CombineSettings":
+        try:
+            from orlando_toolkit.config import ConfigManager
+            return cls.from_mapping((ConfigManager().get_pipeline_config() or {}).get("combine"))
+        except Exception as exc:
+            logger.debug("Pipeline config unavailable, using default combine settings: %s", exc)
+            return cls()
+
+
+def collect_sources(paths: Iterable[str | Path], accept: Callable[[Path], bool]) -> List[Path]:
+    """Files to combine: *paths* in order, folders expanded to the files *accept* takes, sorted by path.
+
+    Hidden files and Word lock files (``~$name.docx``) are left out.
+    """
+    sources: List[Path] = []
+    for path in map(Path, paths):
+        if path.is_dir():
+            found = sorted((p for p in path.rglob("*") if p.is_file()), key=lambda p: str(p).lower())
+            candidates = [p for p in found
+                          if not any(part.startswith((".", "~$")) for part in p.relative_to(path).parts)]
+        else:
+            candidates = [path]
+        for candidate in candidates:
+            if candidate not in sources and (not path.is_dir() or accept(candidate)):
+                sources.append(candidate)
+    return sources
+
+
+def _free(name: str, taken: Mapping[str, Any]) -> str:
+    if name not in taken:
+        return name
+    stem, suffix = os.path.splitext(name)
+    number = 2
+    while f"{stem}_{number}{suffix}" in taken:
+        number += 1
+    return f"{stem}_{number}{suffix}"
+
+
+def _rename_media(target: Dict[str, bytes], media: Mapping[str, bytes]) -> Dict[str, str]:
+    """Add *media* to *target*; returns the renamed files (identical files are shared)."""
+    renames: Dict[str, str] = {}
+    for name in media:
+        data = media[name]
+        if target.get(name) == data:
+            continue
+        new_name = _free(name, target)
+        if isinstance(target, SpooledBlobs) and isinstance(media, SpooledBlobs):
+            target.add_file(new_name, media.path_of(name), link=True)
+        else:
+            target[new_name] = data
+        if new_name != name:
+            renames[name] = new_name
+    return renames
+
+
+def _retarget(value: str, topics: Mapping[str, str], media: Mapping[str, str]) -> Optional[str]:
+    """*value* with a renamed topic or media file replaced, or ``None`` when unchanged."""
+    path, sep, fragment = value.partition("#")
+    if path.startswith(_MEDIA_PREFIX):
+        name = path[len(_MEDIA_PREFIX):]
+        return f"{_MEDIA_PREFIX}{media[name]}{sep}{fragment}" if name in media else None
+    directory, _, name = path.rpartition("/")
+    if name in topics and directory in ("", "topics"):
+        return f"{directory + '/' if directory else ''}{topics[name]}{sep}{fragment}"
+    return None
+
+
+def _bookmark_holders(context: DitaContext) -> List[Tuple[str, ET._Element]]:
+    """``(topic file, element)`` of every element holding bookmarks."""
+    holders = []
+    for name, topic in context.topics.items():
+        for el in topic.iter():
+            if isinstance(el.tag, str) and (el.get(BOOKMARK_HINT) or (el is topic and el.get(ANCHOR_HINT))):
+                holders.append((name, el))
+    return holders
+
+
+def _rename_bookmarks(context: DitaContext, prefix: str, used: set) -> Dict[str, str]:
+    """Rename the bookmarks of *context* already in *used*; returns ``old -> new``."""
+    renames: Dict[str, str] = {}
+    for _, el in _bookmark_holders(context):
+        for name in topic_bookmarks(el):
+            if name in used and name not in renames:
+                new_name, number = f"{prefix}_{name}", 2
+                while new_name in used:
+                    new_name, number = f"{prefix}_{name}_{number}", number + 1
+                renames[name] = new_name
+    for _, el in _bookmark_holders(context):
+        if el.get(ANCHOR_HINT) in renames:
+            el.set(ANCHOR_HINT, renames[el.get(ANCHOR_HINT)])
+        if el.get(BOOKMARK_HINT):
+            el.set(BOOKMARK_HINT, " ".join(renames.get(n, n) for n in el.get(BOOKMARK_HINT).split()))
+    for topic in context.topics.values():
+        for xref in topic.iter("xref"):
+            href = xref.get("href") or ""
+            if href.startswith("#") and href[1:] in renames:
+                xref.set("href", f"#{renames[href[1:]]}")
+    used.update(name for _, el in _bookmark_holders(context) for name in topic_bookmarks(el))
+    return renames
+
+
+def _first_topic(context: DitaContext) -> Optional[str]:
+    root = context.ditamap_root
+    ref = root.find(".//topicref[@href]") if root is not None else None
+    return ref.get("href").split("#")[0].rsplit("/", 1)[-1] if ref is not None else None
+
+
+def _link_documents(members: Mapping[str, Tuple[DitaContext, Dict[str, str], Dict[str, str]]],
+                    report: ConversionReport) -> int:
+    """Turn links between *members* into internal links.
+
+    Members are keyed by lower-cased source file name and carry their topic
+    and bookmark renames.
+    """
+    count = 0
+    for context, _, _ in members.values():
+        for topic in context.topics.values():
+            for link in topic.iter():
+                if not isinstance(link.tag, str) or link.tag not in _LINK_TAGS:
+                    continue
+                href = link.get("href") or ""
+                member = members.get(_document_name(href) or "") if href and not href.startswith("#") else None
+                if member is None:
+                    continue
+                target, topics, bookmarks = member
+                bookmark = _fragment(href)
+                bookmark = bookmarks.get(bookmark, bookmark) if bookmark else None
+                holder = next((name for name, el in _bookmark_holders(target)
+                               if bookmark and bookmark in topic_bookmarks(el)), None)
+                if holder is not None and link.tag == "xref":
+                    new_href = f"#{bookmark}"
+                else:
+                    if bookmark and holder is None:
+                        report.warning("combine", f"Bookmark '{bookmark}' not found in the combined documents; "
+                                                  "linked to the first topic of its document", href=href)
+                    # Map entries already carry the new file names, the topics dictionary does not
+                    new_href = topics.get(holder, holder) if holder else _first_topic(target)
+                    if new_href is None:
+                        continue
+                for attr in ("scope", "format"):
+                    link.attrib.pop(attr, None)
+                link.set("href", new_href)
+                count += 1
+    return count
+
+
+def _shift_levels(node: ET._Element, delta: int) -> None:
+    for el in node.iter():
+        level = el.get("data-level") if el.tag in _STRUCTURAL else None
+        if delta and level and level.isdigit():
+            el.set("data-level", str(int(level) + delta))
+
+
+def _section(parent: ET._Element, title: str, level: int) -> ET._Element:
+    head = ET.SubElement(parent, "topichead")
+    ET.SubElement(ET.SubElement(head, "topicmeta"), "navtitle").text = title
+    head.set("data-level", str(level))
+    head.set("data-style", f"Heading {level}")
+    return head
+
+
+def _folder(root: ET._Element, folders: Dict[Tuple[str, ...], ET._Element], parts: Tuple[str, ...]) -> ET._Element:
+    """Section for the sub-folder *parts*, created with its parents on first use."""
+    if not parts:
+        return root
+    if parts not in folders:
+        parent = _folder(root, folders, parts[:-1])
+        folders[parts] = _section(parent, parts[-1], len(parts))
+    return folders[parts]
+
+
+def combine_documents(documents: Sequence[Tuple[str | Path, DitaContext]],
+                      metadata: Optional[Mapping[str, Any]] = None, *,
+                      settings: Optional[CombineSettings] = None,
+                      base_dir: Optional[str | Path] = None) -> DitaContext:
+    """One context holding *documents* (``(source path, context)`` pairs, in chapter order).
+
+    *metadata* (title, revision, ...) applies to the combined map; the
+    metadata of the first document fills in the rest. Sub-folders for the
+    ``folders`` hierarchy are relative to *base_dir*, by default the folder
+    common to all sources. The member contexts are modified.
+    """
+    if not documents:
+        raise ValueError("No documents to combine")
+    settings = settings or CombineSettings.from_config()
+    first = documents[0][1]
+    combined = DitaContext(ditamap_root=ET.Element("map"), metadata={
+        k: v for k, v in first.metadata.items() if k not in _DOCUMENT_KEYS})
+    combined.metadata.update(metadata or {})
+    combined.metadata["source_documents"] = [Path(source).name for source, _ in documents]
+    for key in ("images", "videos"):
+        # Spooled inputs keep the combined media on disk too
+        spools = [getattr(context, key) for _, context in documents if isinstance(getattr(context, key), SpooledBlobs)]
+        if spools:
+            setattr(combined, key, spools[0].empty_like())
+    root = combined.ditamap_root
+    if first.ditamap_root is not None:
+        root.attrib.update(first.ditamap_root.attrib)
+    if combined.metadata.get("manual_title"):
+        ET.SubElement(root, "title").text = str(combined.metadata["manual_title"])
+    if base_dir is None:
+        base_dir = os.path.commonpath([str(Path(source).resolve().parent) for source, _ in documents])
+
+    members: Dict[str, Tuple[DitaContext, Dict[str, str], Dict[str, str]]] = {}
+    report = combined.report
+    renamed_files = 0
+    used_bookmarks: set = set()
+    for source, context in documents:
+        stem = Path(source).stem
+        topics = {}
+        for name in context.topics:
+            topics[name] = _free(name, combined.topics)
+            combined.topics[topics[name]] = context.topics[name]
+        topics = {old: new for old, new in topics.items() if old != new}
+        media = _rename_media(combined.images, context.images)
+        media.update(_rename_media(combined.videos, context.videos))
+        renamed_files += len(topics) + len(media)
+        if topics or media:
+            for el in [*context.ditamap_root.iter(), *(e for t in context.topics.values() for e in t.iter())]:
+                for attr in _REF_ATTRS:
+                    value = el.get(attr) if isinstance(el.tag, str) else None
+                    new_value = _retarget(value, topics, media) if value else None
+                    if new_value is not None:
+                        el.set(attr, new_value)
+        members[Path(source).name.lower()] = (context, topics, _rename_bookmarks(context, stem, used_bookmarks))
+        for key, value in context.plugin_data.items():
+            combined.plugin_data.setdefault(key, value)
+        if context.report is not None:
+            report.extend(context.report)
+
+    report.info("combine", f"Combined {len(documents)} documents ({len(combined.topics)} topics); "
+                           f"{renamed_files} file(s) renamed to avoid conflicts", renamed=renamed_files)
+    linked = _link_documents(members, report)
+    if linked:
+        report.info("combine", f"{linked} link(s) between the combined documents made internal", links=linked)
+
+    folders: Dict[Tuple[str, ...], ET._Element] = {}
+    for source, context in documents:
+        entries = [el for el in context.ditamap_root if isinstance(el.tag, str) and el.tag in _STRUCTURAL]
+        if settings.hierarchy == "flat":
+            parent, depth = root, 0
+        else:
+            parts: Tuple[str, ...] = ()
+            if settings.hierarchy == "folders":
+                try:
+                    parts = Path(source).resolve().parent.relative_to(Path(base_dir).resolve()).parts
+                except ValueError:
+                    parts = ()
+            title = context.metadata.get("manual_title") or Path(source).stem
+            parent = _section(_folder(root, folders, parts), str(title), len(parts) + 1)
+            depth = len(parts) + 1
+        for entry in entries:
+            _shift_levels(entry, depth)
+            parent.append(entry)
+    return combined
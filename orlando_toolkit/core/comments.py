@@ -0,0 +1,263 @@
+from __future__ import annotations
+
+"""Keep Word review comments as DITA ``<draft-comment>``.
+
+Reviewers leave instructions in Word comments, which converters drop. With
+``comments.enabled`` (the "keep review comments" option of
+``conversion.yml``), :func:`restore_word_comments` reads
+``word/comments.xml`` of the source after the plugin handler returns and
+puts each comment back where it was anchored:
+
+- at a converter's empty ``<ph data-comment="ID"/>`` (``ID`` the Word
+  ``w:id``), which it replaces;
+- otherwise at the start of the commented text (``w:commentRangeStart``, or
+  the comment reference when the comment has no range), the Word paragraph
+  being located in the topics by its text like footnotes are
+  (:mod:`orlando_toolkit.core.placement`). A comment on a heading goes to the
+  start of the topic body.
+
+The element carries ``@author`` and ``@time`` (the comment date). Comments
+marked done in Word (``commentsExtended.xml``) get
+``disposition="resolved"``, or are left out with ``resolved: drop``.
+Draft comments are left out of published output unless the DITA-OT run sets
+``args.draft``. The number kept and the comments that could not be placed
+are reported under ``comments``.
+"""
+
+import logging
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.placement import find_block, innermost_block, insert_at, normalize, offset_of, \
+    text_blocks, topic_order
+from orlando_toolkit.core.utils import topic_body
+from orlando_toolkit.core.word_source import WordSource, w
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["COMMENT_HINT", "WordComment", "WordComments", "read_word_comments", "restore_word_comments"]
+
+_W14 = "http://schemas.microsoft.com/office/word/2010/wordml"
+_W15 = "http://schemas.microsoft.com/office/word/2012/wordml"
+COMMENT_HINT = "data-comment"
+_RESOLVED = ("keep", "drop")
+_TITLES = ("title", "navtitle")
+
+
+@dataclass
+class WordComment:
+    id: str
+    author: str = ""
+    date: str = ""
+    resolved: bool = False
+    paragraphs: List[List[Tuple[str, str]]] = field(default_factory=list)   # (text, "b"/"i"/"")
+
+
+@dataclass
+class WordComments:
+    comments: Dict[str, WordComment] = field(default_factory=dict)
+    paragraphs: List[Tuple[str, List[Tuple[str, int]]]] = field(default_factory=list)   # text, (id, offset)
+
+    def __bool__(self) -> bool:
+        return bool(self.comments)
+
+
+def _runs(paragraph: Any) -> List[Tuple[str, str]]:
+    runs: List[Tuple[str, str]] = []
+    for run in paragraph.iter(w("r")):
+        if run.find(w("annotationRef")) is not None:
+            continue
+        text = "".join(t.text or "" for t in run if t.tag == w("t")) + " " * len(run.findall(w("tab")))
+        if not text:
+            continue
+        props = run.find(w("rPr"))
+        style = ""
+        if props is not None and props.find(w("b")) is not None:
+            style = "b"
+        elif props is not None and props.find(w("i")) is not None:
+            style = "i"
+        runs.append((text, style))
+    return runs
+
+
+def _done_paragraphs(docx: WordSource) -> set:
+    root = docx.part("word/commentsExtended.xml")
+    if root is None:
+        return set()
+    return {el.get(f"{{{_W15}}}paraId") for el in root.iter(f"{{{_W15}}}commentEx")
+            if el.get(f"{{{_W15}}}done") in ("1", "true")}
+
+
+def _walk(node: Any, text: List[str], anchors: Dict[str, int], out: List[Tuple[str, List[Tuple[str, int]]]]) -> None:
+    """Collect the text of paragraph *node* and where its comments start."""
+    for child in node:
+        tag = child.tag
+        if tag == w("p"):
+            inner_text: List[str] = []
+            inner: Dict[str, int] = {}
+            _walk(child, inner_text, inner, out)
+            _close(inner_text, inner, out)
+            continue
+        if tag == w("t"):
+            text.append(child.text or "")
+        elif tag in (w("tab"), w("br")):
+            text.append(" ")
+        elif tag in (w("commentRangeStart"), w("commentReference")):
+            anchors.setdefault(child.get(w("id")) or "", offset_of("".join(text)))
+        elif tag in (w("delText"), w("instrText")):
+            continue
+        _walk(child, text, anchors, out)
+
+
+def _close(text: List[str], anchors: Dict[str, int], out: List[Tuple[str, List[Tuple[str, int]]]]) -> None:
+    if anchors:
+        out.append((normalize("".join(text)), sorted(anchors.items(), key=lambda item: item[1])))
+
+
+def read_word_comments(path: str | Path | WordSource) -> WordComments:
+    """Comments of a ``.docx`` and the body paragraphs they are anchored in."""
+    result = WordComments()
+    docx = WordSource.of(path)
+    root = docx.part("word/comments.xml") if docx is not None else None
+    if root is None or docx.document is None:
+        return result
+    done = _done_paragraphs(docx)
+    for element in root.findall(w("comment")):
+        comment = WordComment(element.get(w("id")) or "", author=element.get(w("author")) or "",
+                              date=element.get(w("date")) or "")
+        paragraphs = element.findall(w("p"))
+        comment.resolved = bool(paragraphs) and paragraphs[-1].get(f"{{{_W14}}}paraId") in done
+        for paragraph in paragraphs:
+            runs = _runs(paragraph)
+            if normalize("".join(t for t, _ in runs)):
+                comment.paragraphs.append(runs)
+        if comment.paragraphs:
+            result.comments[comment.id] = comment
+    body = docx.body
+    if body is not None and result.comments:
+        _walk(body, [], {}, result.paragraphs)
+    return result
+
+
+def _comment_element(comment: WordComment) -> Any:
+    draft = ET.Element("draft-comment")
+    if comment.author:
+        draft.set("author", comment.author)
+    if comment.date:
+        draft.set("time", comment.date)
+    if comment.resolved:
+        draft.set("disposition", "resolved")
+
+    def _fill(parent: Any, runs: List[Tuple[str, str]]) -> None:
+        last = None
+        for text, style in runs:
+            if style:
+                last = ET.SubElement(parent, style)
+                last.text = text
+            elif last is None:
+                parent.text = (parent.text or "") + text
+            else:
+                last.tail = (last.tail or "") + text
+        if parent.text:
+            parent.text = parent.text.lstrip()
+
+    if len(comment.paragraphs) == 1:
+        _fill(draft, comment.paragraphs[0])
+    else:
+        for runs in comment.paragraphs:
+            _fill(ET.SubElement(draft, "p"), runs)
+    return draft
+
+
+def _replace(el: Any, new: Optional[Any]) -> None:
+    parent = el.getparent()
+    if new is not None:
+        new.tail = el.tail
+        parent.insert(parent.index(el), new)
+    else:
+        previous = el.getprevious()
+        if previous is not None:
+            previous.tail = (previous.tail or "") + (el.tail or "")
+        else:
+            parent.text = (parent.text or "") + (el.tail or "")
+    parent.remove(el)
+
+
+def _placeholders(context: Any) -> List[Tuple[str, Any]]:
+    return [(el.get(COMMENT_HINT), el) for name in topic_order(context)
+            for el in list(context.topics[name].iter("ph")) if el.get(COMMENT_HINT) is not None]
+
+
+def _attach(name: str, context: Any, block: Any, offset: int, new: Any) -> None:
+    if block.tag in _TITLES:
+        # Titles cannot hold draft comments; the topic body can
+        body = topic_body(context.topics[name])
+        if body is None:
+            body = context.topics[name]
+        body.insert(0, new)
+    else:
+        insert_at(block, offset, new)
+
+
+def restore_word_comments(path: str | Path | WordSource, context: Any, options: Optional[Mapping[str, Any]] = None,
+                          report: Any = None) -> int:
+    """Put the review comments of the ``.docx`` *path* into *context*; returns the number kept."""
+    options = dict(options or {})
+    placeholders = _placeholders(context)
+    if not options.get("enabled", False):
+        for _, el in placeholders:
+            _replace(el, None)
+        return 0
+    report = report if report is not None else getattr(context, "report", None)
+    resolved = str(options.get("resolved", "keep"))
+    if resolved not in _RESOLVED:
+        if report is not None:
+            report.warning("comments", f"Unknown resolved mode {resolved!r} (expected {', '.join(_RESOLVED)})")
+        resolved = "keep"
+    try:
+        comments = read_word_comments(path)
+    except Exception as exc:
+        logger.warning("Could not read the comments of %s: %s", Path(path).name, exc)
+        comments = WordComments()
+
+    def _wanted(comment_id: str) -> Optional[WordComment]:
+        comment = comments.comments.get(comment_id)
+        if comment is None or (comment.resolved and resolved == "drop"):
+            return None
+        return comment
+
+    kept: set = set()
+    if placeholders:
+        for comment_id, el in placeholders:
+            comment = _wanted(comment_id) if comment_id not in kept else None
+            _replace(el, _comment_element(comment) if comment is not None else None)
+            if comment is not None:
+                kept.add(comment_id)
+    elif comments:
+        blocks = text_blocks(context)
+        located: List[Tuple[str, Any, int, WordComment]] = []
+        cursor = unplaced = 0
+        for text, anchors in comments.paragraphs:
+            wanted = [(c, offset) for c, offset in ((_wanted(i), o) for i, o in anchors) if c is not None]
+            if not wanted:
+                continue
+            hit, cursor = find_block(blocks, text, cursor)
+            if hit is None:
+                unplaced += len(wanted)
+                continue
+            name, block, _ = blocks[hit]
+            block = innermost_block(block, text)
+            located.extend((name, block, offset, comment) for comment, offset in wanted)
+        # Last offset first (and, at one offset, last comment first) so Word's order is kept
+        for name, block, offset, comment in sorted(reversed(located), key=lambda item: -item[2]):
+            _attach(name, context, block, offset, _comment_element(comment))
+            kept.add(comment.id)
+        if unplaced and report is not None:
+            report.warning("comments", f"{unplaced} review comment(s) could not be placed: their paragraph was "
+                                       "not found in the converted topics")
+    if report is not None and kept:
+        report.info("comments", f"{len(kept)} review comment(s) kept as <draft-comment>", comments=len(kept))
+    return len(kept)
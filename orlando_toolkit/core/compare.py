@@ -0,0 +1,226 @@
+from __future__ import annotations
+
+"""Change report between two revisions of a source document.
+
+:func:`compare_documents` converts both files and :func:`compare_contexts`
+compares the results at DITA level, so reviewers see what a republish will
+change before they run it:
+
+- topics are matched like stable ids (heading path, content fingerprint,
+  unique title) and reported as added, removed or changed;
+- within a changed topic the text blocks (paragraphs, list items, table
+  cells, …) are aligned and each changed block carries a word-level diff.
+
+The :class:`ChangeReport` renders as JSON (:meth:`~ChangeReport.to_json`) or
+as a self-contained HTML page (:meth:`~ChangeReport.to_html`) with deleted
+words struck through and inserted words highlighted.
+"""
+
+import difflib
+import html
+import json
+import logging
+import re
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Tuple
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.revisions import block_text, text_blocks
+from orlando_toolkit.core.stable_ids import PreviousConversion, fingerprint
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["BlockChange", "ChangeReport", "TopicChange", "compare_contexts", "compare_documents", "word_diff"]
+
+_TOKEN = re.compile(r"\s+|[^\s]+")
+
+
+def word_diff(old: str, new: str) -> List[Tuple[str, str]]:
+    """``(op, text)`` runs turning *old* into *new*; op is ``equal``, ``delete`` or ``insert``."""
+    a, b = _TOKEN.findall(old), _TOKEN.findall(new)
+    runs: List[Tuple[str, str]] = []
+    for tag, i1, i2, j1, j2 in difflib.SequenceMatcher(None, a, b, autojunk=False).get_opcodes():
+        if tag == "equal":
+            runs.append(("equal", "".join(a[i1:i2])))
+            continue
+        if i2 > i1:
+            runs.append(("delete", "".join(a[i1:i2])))
+        if j2 > j1:
+            runs.append(("insert", "".join(b[j1:j2])))
+    return runs
+
+
+@dataclass
+class BlockChange:
+    status: str                      # added | removed | changed
+    old: str = ""
+    new: str = ""
+    tag: str = "p"
+
+    @property
+    def words(self) -> List[Tuple[str, str]]:
+        if self.status == "added":
+            return [("insert", self.new)]
+        if self.status == "removed":
+            return [("delete", self.old)]
+        return word_diff(self.old, self.new)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {"status": self.status, "tag": self.tag, "old": self.old, "new": self.new,
+                "words": [list(run) for run in self.words]}
+
+
+@dataclass
+class TopicChange:
+    status: str                      # added | removed | changed
+    title: str
+    path: str
+    title_changed: Optional[Tuple[str, str]] = None
+    blocks: List[BlockChange] = field(default_factory=list)
+
+    def to_dict(self) -> Dict[str, Any]:
+        data: Dict[str, Any] = {"status": self.status, "title": self.title, "path": self.path,
+                                "blocks": [b.to_dict() for b in self.blocks]}
+        if self.title_changed:
+            data["title_changed"] = {"old": self.title_changed[0], "new": self.title_changed[1]}
+        return data
+
+
+@dataclass
+class ChangeReport:
+    old_source: str = ""
+    new_source: str = ""
+    unchanged_topics: int = 0
+    topics: List[TopicChange] = field(default_factory=list)
+
+    def count(self, status: str) -> int:
+        return sum(1 for t in self.topics if t.status == status)
+
+    @property
+    def changed_blocks(self) -> int:
+        return sum(len(t.blocks) for t in self.topics if t.status == "changed")
+
+    def summary(self) -> str:
+        return (f"{self.count('changed')} changed topic(s) ({self.changed_blocks} block(s)), "
+                f"{self.count('added')} added, {self.count('removed')} removed, {self.unchanged_topics} unchanged")
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {
+            "old": self.old_source,
+            "new": self.new_source,
+            "summary": {"changed": self.count("changed"), "added": self.count("added"),
+                        "removed": self.count("removed"), "unchanged": self.unchanged_topics,
+                        "changed_blocks": self.changed_blocks},
+            "topics": [t.to_dict() for t in self.topics],
+        }
+
+    def to_json(self, **kwargs: Any) -> str:
+        kwargs.setdefault("ensure_ascii", False)
+        kwargs.setdefault("indent", 2)
+        return json.dumps(self.to_dict(), **kwargs)
+
+    def to_html(self) -> str:
+        esc = html.escape
+        parts = [
+            "<!DOCTYPE html>",
+            "<html><head><meta charset='utf-8'><title>Change report</title><style>",
+            "body{font-family:sans-serif;margin:2em;max-width:60em}"
+            "del{background:#fdd;color:#900}ins{background:#dfd;color:#060;text-decoration:none}"
+            ".added{border-left:4px solid #2a2}.removed{border-left:4px solid #c22}"
+            ".changed{border-left:4px solid #e90}section{padding-left:1em;margin-bottom:1.5em}"
+            ".path{color:#666;font-size:90%}",
+            "</style></head><body>",
+            f"<h1>Change report</h1><p>{esc(self.old_source)} &#8594; {esc(self.new_source)}</p>",
+            f"<p>{esc(self.summary())}</p>",
+        ]
+        for topic in self.topics:
+            parts.append(f"<section class='{topic.status}'><h2>{esc(topic.title)} <small>({topic.status})</small></h2>"
+                         f"<p class='path'>{esc(topic.path)}</p>")
+            if topic.title_changed:
+                parts.append(f"<p>Title: <del>{esc(topic.title_changed[0])}</del> "
+                             f"<ins>{esc(topic.title_changed[1])}</ins></p>")
+            for block in topic.blocks:
+                words = "".join(esc(text) if op == "equal" else f"<{op[:3]}>{esc(text)}</{op[:3]}>"
+                                for op, text in block.words)
+                parts.append(f"<p class='{block.status}'>{words}</p>")
+            parts.append("</section>")
+        parts.append("</body></html>")
+        return "\n".join(parts)
+
+
+def _title(topic) -> str:
+    title = topic.find("title") if topic is not None else None
+    return block_text(title) if title is not None else ""
+
+
+def _block_changes(old_topic, new_topic) -> List[BlockChange]:
+    old_blocks, new_blocks = text_blocks(old_topic), text_blocks(new_topic)
+    old_text = [block_text(b) for b in old_blocks]
+    new_text = [block_text(b) for b in new_blocks]
+    changes: List[BlockChange] = []
+    for tag, i1, i2, j1, j2 in difflib.SequenceMatcher(None, old_text, new_text, autojunk=False).get_opcodes():
+        if tag == "equal":
+            continue
+        pairs = max(i2 - i1, j2 - j1)
+        for offset in range(pairs):
+            i, j = i1 + offset, j1 + offset
+            old, new = (old_text[i] if i < i2 else None), (new_text[j] if j < j2 else None)
+            if old is not None and new is not None:
+                changes.append(BlockChange("changed", old, new, new_blocks[j].tag))
+            elif new is not None:
+                changes.append(BlockChange("added", new=new, tag=new_blocks[j].tag))
+            else:
+                changes.append(BlockChange("removed", old=old, tag=old_blocks[i].tag))
+    return changes
+
+
+def _outline(context: DitaContext) -> List[Tuple[Any, Any, str]]:
+    """``(topicref, topic, filename)`` in map order."""
+    items = []
+    for tref in context.ditamap_root.iter("topicref") if context.ditamap_root is not None else ():
+        filename = (tref.get("href") or "").split("/")[-1]
+        if context.topics.get(filename) is not None:
+            items.append((tref, context.topics[filename], filename))
+    return items
+
+
+def compare_contexts(old: DitaContext, new: DitaContext, *, old_source: str = "",
+                     new_source: str = "") -> ChangeReport:
+    """Topic and block changes from *old* to *new*."""
+    report = ChangeReport(old_source=old_source, new_source=new_source)
+    previous = PreviousConversion.from_map(old.ditamap_root, lambda h: old.topics.get(h.split("/")[-1])) \
+        if old.ditamap_root is not None else PreviousConversion([])
+    used: set = set()
+    for tref, topic, _ in _outline(new):
+        fp = fingerprint(topic, tref, lambda h: new.topics.get(h.split("/")[-1]))
+        match = previous.match(fp, used)
+        old_topic = old.topics.get(match) if match else None
+        if old_topic is None:
+            blocks = [BlockChange("added", new=block_text(b), tag=b.tag) for b in text_blocks(topic)]
+            report.topics.append(TopicChange("added", _title(topic), fp.path, blocks=blocks))
+            continue
+        used.add(match)
+        blocks = _block_changes(old_topic, topic)
+        title_changed = (_title(old_topic), _title(topic)) if _title(old_topic) != _title(topic) else None
+        if blocks or title_changed:
+            report.topics.append(TopicChange("changed", _title(topic), fp.path, title_changed, blocks))
+        else:
+            report.unchanged_topics += 1
+    for tref, topic, filename in _outline(old):
+        if filename not in used:
+            fp = fingerprint(topic, tref, lambda h: old.topics.get(h.split("/")[-1]))
+            blocks = [BlockChange("removed", old=block_text(b), tag=b.tag) for b in text_blocks(topic)]
+            report.topics.append(TopicChange("removed", _title(topic), fp.path, blocks=blocks))
+    return report
+
+
+def compare_documents(old_path: str | Path, new_path: str | Path, metadata: Optional[Dict[str, Any]] = None, *,
+                      service: Any = None) -> ChangeReport:
+    """Convert both revisions with the same settings and compare them."""
+    if service is None:
+        from orlando_toolkit.core.services import ConversionService
+
+        service = ConversionService()
+    contexts = [service.convert(path, dict(metadata or {})) for path in (old_path, new_path)]
+    return compare_contexts(contexts[0], contexts[1], old_source=Path(old_path).name, new_source=Path(new_path).name)
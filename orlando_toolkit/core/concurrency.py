@@ -0,0 +1,296 @@
+from __future__ import annotations
+
+"""Worker pool and memory budget settings for the processing pipeline.
+
+The pipeline has four parallelisable stages:
+
+- ``parser``     – parsing topic/map XML during import
+- ``topics``     – building one topic per source section (markup importer,
+  plugin handlers)
+- ``media``      – reading and transforming media blobs (image extraction,
+  ``raster_images`` and ``vector_images`` conversion)
+- ``serializer`` – serialising topics when writing a package
+
+Each stage gets its own worker count, and all stages share a single memory
+budget so the toolkit behaves predictably on small containers as well as on
+large workstations. Settings come from ``pipeline.yml`` (see
+:class:`~orlando_toolkit.config.ConfigManager`) and can be overridden per job
+through ``metadata["pipeline"]``.
+
+Parallelism never changes output: :func:`ordered_map` returns results in
+input order (and reports progress in that order on the calling thread, so
+existing progress callbacks need no locking), inputs are iterated in a sorted or map-defined order, and
+archives are written with :func:`~orlando_toolkit.core.package_utils.write_zip_archive`,
+so serial and parallel runs produce byte-identical packages.
+"""
+
+import logging
+import os
+import threading
+from concurrent.futures import ThreadPoolExecutor
+from contextlib import contextmanager
+from dataclasses import dataclass, replace
+from typing import TYPE_CHECKING, Any, Callable, Dict, Iterable, Iterator, List, Mapping, Optional, TypeVar
+
+from orlando_toolkit.core.cancellation import CancellationToken, check_cancelled
+
+if TYPE_CHECKING:
+    from orlando_toolkit.core.time_budget import TimeBudget
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["PipelineSettings", "MemoryBudget", "ordered_map", "progress_steps"]
+
+T = TypeVar("T")
+R = TypeVar("R")
+
+_STAGES = ("parser", "topics", "media", "serializer")
+
+
+def _auto_workers() -> int:
+    """Default worker count: CPU count capped to keep memory use modest."""
+    return max(1, min(4, os.cpu_count() or 1))
+
+
+def _coerce_workers(value: Any) -> int:
+    if value is None or (isinstance(value, str) and value.strip().lower() == "auto"):
+        return _auto_workers()
+    try:
+        return max(1, int(value))
+    except (TypeError, ValueError):
+        logger.warning("Invalid worker count %r; using auto", value)
+        return _auto_workers()
+
+
+@dataclass(frozen=True)
+class PipelineSettings:
+    """Concurrency and memory knobs for one pipeline run.
+
+    Attributes
+    ----------
+    parser_workers, topics_workers, media_workers, serializer_workers
+        Thread count per stage; ``1`` runs the stage serially.
+    memory_budget_mb
+        Upper bound for bytes held in flight by parallel stages; ``0`` disables
+        the limit.
+    time_budget_s
+        Per-job wall-clock budget in seconds; ``0`` means unlimited.
+    low_memory
+        Hold media on disk (:mod:`orlando_toolkit.core.spool`): ``True``,
+        ``False``, or ``None`` for sources larger than
+        ``low_memory_threshold_mb``.
+    spool_dir
+        Folder for the spooled media; the system temp folder when ``None``.
+    """
+
+    parser_workers: int = 1
+    topics_workers: int = 1
+    media_workers: int = 1
+    serializer_workers: int = 1
+    memory_budget_mb: int = 0
+    time_budget_s: float = 0.0
+    low_memory: Optional[bool] = False
+    low_memory_threshold_mb: int = 200
+    spool_dir: Optional[str] = None
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "PipelineSettings":
+        """Build settings from a ``pipeline.yml``-shaped mapping."""
+        data = data or {}
+        workers = data.get("workers") or {}
+        if not isinstance(workers, Mapping):
+            # Scalar shorthand: same count for each stage
+            workers = {stage: workers for stage in _STAGES}
+        try:
+            budget = max(0, int(data.get("memory_budget_mb") or 0))
+        except (TypeError, ValueError):
+            logger.warning("Invalid memory_budget_mb %r; budget disabled", data.get("memory_budget_mb"))
+            budget = 0
+        try:
+            time_budget = max(0.0, float(data.get("time_budget_seconds") or 0))
+        except (TypeError, ValueError):
+            logger.warning("Invalid time_budget_seconds %r; budget disabled", data.get("time_budget_seconds"))
+            time_budget = 0.0
+        low_memory = data.get("low_memory") or {}
+        if not isinstance(low_memory, Mapping):
+            low_memory = {"enabled": low_memory}
+        enabled = low_memory.get("enabled", False)
+        if isinstance(enabled, str):
+            value = enabled.strip().lower()
+            enabled = None if value == "auto" else value in ("1", "true", "yes", "on")
+        try:
+            threshold = max(0, int(low_memory.get("threshold_mb", 200)))
+        except (TypeError, ValueError):
+            logger.warning("Invalid low_memory.threshold_mb %r; using 200", low_memory.get("threshold_mb"))
+            threshold = 200
+        return cls(
+            parser_workers=_coerce_workers(workers.get("parser", 1)),
+            topics_workers=_coerce_workers(workers.get("topics", 1)),
+            media_workers=_coerce_workers(workers.get("media", 1)),
+            serializer_workers=_coerce_workers(workers.get("serializer", 1)),
+            memory_budget_mb=budget,
+            time_budget_s=time_budget,
+            low_memory=None if enabled is None else bool(enabled),
+            low_memory_threshold_mb=threshold,
+            spool_dir=str(low_memory["spool_dir"]) if low_memory.get("spool_dir") else None,
+        )
+
+    @classmethod
+    def resolve(cls, metadata: Optional[Mapping[str, Any]] = None) -> "PipelineSettings":
+        """Return configured settings with per-job ``metadata["pipeline"]`` overrides."""
+        base: Dict[str, Any] = {}
+        try:
+            from orlando_toolkit.config import ConfigManager
+            base = dict(ConfigManager().get_pipeline_config() or {})
+        except Exception as exc:
+            logger.debug("Pipeline config unavailable, using defaults: %s", exc)
+        override = (metadata or {}).get("pipeline") if metadata else None
+        if isinstance(override, Mapping):
+            merged_workers = dict(base.get("workers") or {}) if isinstance(base.get("workers"), Mapping) else {}
+            if isinstance(override.get("workers"), Mapping):
+                merged_workers.update(override["workers"])
+            elif override.get("workers") is not None:
+                merged_workers = {stage: override["workers"] for stage in _STAGES}
+            low_memory = base.get("low_memory")
+            base.update({k: v for k, v in override.items() if k != "workers"})
+            if isinstance(low_memory, Mapping) and isinstance(override.get("low_memory"), Mapping):
+                base["low_memory"] = {**low_memory, **override["low_memory"]}
+            if merged_workers:
+                base["workers"] = merged_workers
+        return cls.from_mapping(base)
+
+    def serial(self) -> "PipelineSettings":
+        """Copy of these settings with every stage forced to one worker."""
+        return replace(self, parser_workers=1, topics_workers=1, media_workers=1, serializer_workers=1)
+
+    def workers_for(self, stage: str) -> int:
+        return int(getattr(self, f"{stage}_workers", 1))
+
+    def low_memory_for(self, source: Any = None) -> bool:
+        """Whether media of a conversion of *source* (a path, optional) are held on disk."""
+        if self.low_memory is not None:
+            return self.low_memory
+        try:
+            size = os.path.getsize(source) if source is not None else 0
+        except OSError:
+            size = 0
+        return size > self.low_memory_threshold_mb * 1024 * 1024
+
+    def budget(self) -> "MemoryBudget":
+        return MemoryBudget(self.memory_budget_mb * 1024 * 1024)
+
+    def time_budget(self) -> "TimeBudget":
+        """Start a :class:`TimeBudget` for one job."""
+        from orlando_toolkit.core.time_budget import TimeBudget
+        return TimeBudget(self.time_budget_s)
+
+
+class MemoryBudget:
+    """Counting semaphore over bytes shared by concurrent workers.
+
+    A reservation larger than the whole budget is admitted only when nothing
+    else is reserved, so oversized items degrade to serial processing instead
+    of deadlocking.
+    """
+
+    def __init__(self, limit_bytes: int = 0) -> None:
+        self._limit = max(0, int(limit_bytes))
+        self._in_use = 0
+        self._peak = 0
+        self._cond = threading.Condition()
+
+    @property
+    def limit_bytes(self) -> int:
+        return self._limit
+
+    @property
+    def in_use_bytes(self) -> int:
+        return self._in_use
+
+    @property
+    def peak_bytes(self) -> int:
+        return self._peak
+
+    @contextmanager
+    def reserve(self, nbytes: int, cancel_token: Optional[CancellationToken] = None) -> Iterator[None]:
+        """Hold *nbytes* of budget for the duration of the ``with`` block."""
+        nbytes = max(0, int(nbytes))
+        with self._cond:
+            if self._limit:
+                while self._in_use and self._in_use + nbytes > self._limit:
+                    check_cancelled(cancel_token)
+                    self._cond.wait(timeout=0.1)
+            self._in_use += nbytes
+            self._peak = max(self._peak, self._in_use)
+        try:
+            yield
+        finally:
+            with self._cond:
+                self._in_use -= nbytes
+                self._cond.notify_all()
+
+
+def ordered_map(
+    fn: Callable[[T], R],
+    items: Iterable[T],
+    *,
+    workers: int = 1,
+    cancel_token: Optional[CancellationToken] = None,
+    budget: Optional[MemoryBudget] = None,
+    size_of: Optional[Callable[[T], int]] = None,
+    progress: Optional[Callable[[int, int], None]] = None,
+) -> List[R]:
+    """Apply *fn* to *items* and return results in input order.
+
+    With ``workers <= 1`` the call runs serially on the current thread. When a
+    *budget* is given, each call holds ``size_of(item)`` bytes while it runs.
+    Cancellation is checked before each item; the first exception raised by
+    *fn* propagates after pending work is abandoned. *progress* is called
+    with ``(done, total)`` on the calling thread as results come in, in
+    input order.
+    """
+    seq = list(items)
+    total = len(seq)
+
+    def _done(index: int, result: R) -> R:
+        if progress is not None:
+            progress(index + 1, total)
+        return result
+
+    def _run(item: T) -> R:
+        check_cancelled(cancel_token)
+        if budget is not None and budget.limit_bytes and size_of is not None:
+            with budget.reserve(size_of(item), cancel_token):
+                return fn(item)
+        return fn(item)
+
+    if workers <= 1 or len(seq) <= 1:
+        return [_done(i, _run(item)) for i, item in enumerate(seq)]
+
+    with ThreadPoolExecutor(max_workers=min(workers, len(seq)), thread_name_prefix="otk") as pool:
+        futures = [pool.submit(_run, item) for item in seq]
+        try:
+            return [_done(i, f.result()) for i, f in enumerate(futures)]
+        except BaseException:
+            for f in futures:
+                f.cancel()
+            raise
+
+
+def progress_steps(callback: Optional[Callable[[str], None]], label: str,
+                   steps: int = 20, *, phase: str = "parse") -> Optional[Callable[[int, int], None]]:
+    """Adapt a message progress callback to the ``(done, total)`` one of :func:`ordered_map`.
+
+    About *steps* messages ``"<label> (done/total)..."`` are emitted however
+    many items there are, as :class:`~orlando_toolkit.core.progress.ProgressEvent`
+    objects of *phase*; returns ``None`` without *callback*.
+    """
+    if callback is None:
+        return None
+    from orlando_toolkit.core.progress import ProgressEvent
+
+    def _progress(done: int, total: int) -> None:
+        every = max(1, total // max(1, steps))
+        if done == total or done % every == 0:
+            callback(ProgressEvent(f"{label} ({done}/{total})...", phase, step=label, done=done, total=total))
+    return _progress
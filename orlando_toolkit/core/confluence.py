@@ -0,0 +1,510 @@
+from __future__ import annotations
+
+"""Confluence export: one page per topic in Confluence storage format.
+
+Teams reading documentation in Confluence rather than a DITA CCMS get the
+edited context as pages. :func:`export_confluence` walks the map and makes a
+:class:`ConfluencePage` per topic reference (title from the navtitle, else
+the topic title), nested like the map; topic heads become pages listing
+their children, resource-only entries are skipped. Titles are made unique
+(Confluence requires it within a space) and :attr:`ConfluenceSettings.title_prefix`
+is put in front of them.
+
+Topic bodies become storage-format XHTML: paragraphs, lists, steps,
+sections (``h2``), tables (spans kept), notes as info/tip/note/warning
+macros, code blocks as code macros, images as attachments of their page
+(``ac:image`` with the alt text), links to other topics as page links and
+footnotes as a numbered list at the end of the page. Conversion hints,
+comments, prologs and index terms are left out.
+
+The :class:`ConfluenceExport` is written as an importable zip
+(:meth:`ConfluenceExport.write_zip`: ``manifest.json`` with the page tree,
+``pages/<n>.xml`` bodies and ``attachments/``), or pushed with
+:func:`push_confluence` through the REST API (``/rest/api/content``): pages
+are created under ``parent_page_id`` of ``space_key``, or updated when a
+page of the same title exists (``update_existing``), and their images
+uploaded. The ``confluence`` section of ``pipeline.yml`` configures the
+push; the token is read from the ``token_env`` environment variable and
+sent as a bearer token, or with ``username`` (Confluence Cloud account
+e-mail) as basic authentication.
+"""
+
+import base64
+import html
+import json
+import logging
+import os
+import posixpath
+import urllib.error
+import urllib.parse
+import urllib.request
+import uuid
+import zipfile
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Callable, Dict, List, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.cancellation import CancellationToken, check_cancelled
+from orlando_toolkit.core.errors import ToolkitError
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.processing.text import is_element, local_name
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["ConfluenceError", "ConfluenceExport", "ConfluencePage", "ConfluenceSettings", "export_confluence",
+           "push_confluence", "topic_storage"]
+
+_MAP_ENTRIES = ("topicref", "topichead", "chapter", "part", "appendix", "appendices", "preface", "notices",
+                "frontmatter", "backmatter", "topicgroup", "mapref")
+_SKIPPED = frozenset({"prolog", "titlealts", "related-links", "indexterm", "draft-comment", "required-cleanup",
+                      "data", "data-about", "foreign", "unknown", "metadata", "alt", "navtitle", "desc"})
+_ENTRY_NOTES = {"tip": "tip", "fastpath": "tip", "important": "note", "attention": "note", "remember": "note",
+                "restriction": "note", "warning": "warning", "caution": "warning", "danger": "warning",
+                "trouble": "warning", "notice": "warning"}
+_INLINE = {"b": "strong", "i": "em", "u": "u", "sup": "sup", "sub": "sub", "line-through": "s",
+           "codeph": "code", "tt": "code", "filepath": "code", "cmdname": "code", "userinput": "code",
+           "systemoutput": "code", "varname": "code", "apiname": "code", "parmname": "code", "option": "code",
+           "uicontrol": "strong", "wintitle": "strong", "term": "em", "cite": "em"}
+_BLOCK_SAME = {"p": "p", "ul": "ul", "ol": "ol", "li": "li", "sl": "ul", "sli": "li", "steps": "ol",
+               "steps-unordered": "ul", "substeps": "ol", "step": "li", "substep": "li", "choices": "ul",
+               "choice": "li", "shortdesc": "p", "lq": "blockquote", "stepresult": "div", "info": "div"}
+_CODE = ("codeblock", "pre", "screen", "msgblock")
+_TOPICS = ("topic", "concept", "task", "reference", "glossentry", "troubleshooting")
+
+# Confluence storage format namespaces, declared on the page tree only while checking well-formedness
+_NS_DECL = ('xmlns:ac="http://atlassian.com/content" xmlns:ri="http://atlassian.com/resource/identifier"')
+
+
+class ConfluenceError(ToolkitError, RuntimeError):
+    """Raised when the Confluence settings are incomplete or the server refuses a request."""
+
+    code = "OTK504"
+
+
+@dataclass(frozen=True)
+class ConfluenceSettings:
+    base_url: Optional[str] = None
+    space_key: Optional[str] = None
+    parent_page_id: Optional[str] = None
+    username: Optional[str] = None
+    token_env: str = "CONFLUENCE_TOKEN"
+    title_prefix: str = ""
+    update_existing: bool = True
+    timeout_seconds: int = 60
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "ConfluenceSettings":
+        data = dict(data or {})
+
+        def _text(key: str) -> Optional[str]:
+            value = data.get(key)
+            return str(value).strip() or None if value is not None else None
+
+        return cls(base_url=(_text("base_url") or "").rstrip("/") or None, space_key=_text("space_key"),
+                   parent_page_id=_text("parent_page_id"), username=_text("username"),
+                   token_env=_text("token_env") or "CONFLUENCE_TOKEN",
+                   title_prefix=str(data.get("title_prefix") or ""),
+                   update_existing=bool(data.get("update_existing", True)),
+                   timeout_seconds=max(1, int(data.get("timeout_seconds") or 60)))
+
+    @classmethod
+    def from_config(cls) -> "ConfluenceSettings":
+        try:
+            from orlando_toolkit.config import ConfigManager
+            return cls.from_mapping((ConfigManager().get_pipeline_config() or {}).get("confluence"))
+        except Exception as exc:
+            logger.debug("Pipeline config unavailable, using default Confluence settings: %s", exc)
+            return cls()
+
+
+@dataclass
+class ConfluencePage:
+    """One page: :attr:`parent` is the :attr:`key` of its parent page, None at the top."""
+
+    key: str
+    title: str
+    body: str
+    parent: Optional[str] = None
+    topic: Optional[str] = None
+    attachments: List[str] = field(default_factory=list)
+
+
+@dataclass
+class ConfluenceExport:
+    """Pages in map order (parents before their children) and the images they attach."""
+
+    pages: List[ConfluencePage] = field(default_factory=list)
+    images: Dict[str, bytes] = field(default_factory=dict)
+
+    def write_zip(self, path: str | Path) -> Path:
+        """Write ``manifest.json``, ``pages/<key>.xml`` and ``attachments/<image>`` to the zip *path*."""
+        path = Path(path)
+        path.parent.mkdir(parents=True, exist_ok=True)
+        manifest = {"format": "confluence-storage", "version": 1, "pages": [
+            {"key": p.key, "title": p.title, "parent": p.parent, "topic": p.topic, "file": f"pages/{p.key}.xml",
+             "attachments": [f"attachments/{name}" for name in p.attachments]} for p in self.pages]}
+        with zipfile.ZipFile(path, "w", zipfile.ZIP_DEFLATED) as zf:
+            zf.writestr("manifest.json", json.dumps(manifest, ensure_ascii=False, indent=2))
+            for page in self.pages:
+                zf.writestr(f"pages/{page.key}.xml", page.body)
+            for name, data in self.images.items():
+                zf.writestr(f"attachments/{name}", data)
+        logger.info("Confluence export: %d page(s) written to %s", len(self.pages), path.name)
+        return path
+
+    @classmethod
+    def read_zip(cls, path: str | Path) -> "ConfluenceExport":
+        """The export written by :meth:`write_zip`, e.g. to push it later."""
+        with zipfile.ZipFile(path) as zf:
+            manifest = json.loads(zf.read("manifest.json").decode("utf-8"))
+            export = cls()
+            for item in manifest.get("pages", []):
+                names = [posixpath.basename(a) for a in item.get("attachments", [])]
+                export.pages.append(ConfluencePage(item["key"], item["title"], zf.read(item["file"]).decode("utf-8"),
+                                                   item.get("parent"), item.get("topic"), names))
+                for name in names:
+                    export.images.setdefault(name, zf.read(f"attachments/{name}"))
+        return export
+
+
+# -- storage format ---------------------------------------------------------
+
+def _esc(text: Optional[str]) -> str:
+    return html.escape(text or "", quote=False)
+
+
+def _attr(text: str) -> str:
+    return html.escape(text or "", quote=True)
+
+
+def _cdata(text: str) -> str:
+    return "<![CDATA[" + (text or "").replace("]]>", "]]]]><![CDATA[>") + "]]>"
+
+
+def _plain(el: Any) -> str:
+    return " ".join("".join(el.itertext()).split()) if el is not None else ""
+
+
+class _Renderer:
+    """Storage XHTML of one topic; collects the images it shows and its footnotes."""
+
+    def __init__(self, titles: Mapping[str, str], images: Mapping[str, bytes]) -> None:
+        self.titles, self.images = titles, images
+        self.attachments: List[str] = []
+        self.footnotes: List[str] = []
+
+    def children(self, el: Any) -> str:
+        parts = [_esc(el.text)]
+        for child in el:
+            parts.append(self.element(child) if is_element(child) else "")
+            parts.append(_esc(child.tail))
+        return "".join(parts)
+
+    def blocks(self, el: Any, skip: Tuple[str, ...] = ()) -> str:
+        """Children of a block container; loose text and inline runs are wrapped in paragraphs."""
+        parts: List[str] = []
+        run: List[str] = [_esc(el.text)]
+        for child in el:
+            if is_element(child) and local_name(child) not in skip:
+                rendered = self.element(child)
+                if self._is_block(child):
+                    if "".join(run).strip():
+                        parts.append(f"<p>{''.join(run).strip()}</p>")
+                    run = []
+                    parts.append(rendered)
+                else:
+                    run.append(rendered)
+            run.append(_esc(child.tail))
+        if "".join(run).strip():
+            parts.append(f"<p>{''.join(run).strip()}</p>")
+        return "".join(parts)
+
+    @staticmethod
+    def _is_block(el: Any) -> bool:
+        name = local_name(el)
+        if name == "image":
+            return el.get("placement") == "break"
+        return name not in _INLINE and name not in ("xref", "ph", "keyword", "q", "fn", "text", "menucascade",
+                                                    "tm", "abbreviated-form", "cmd")
+
+    def element(self, el: Any) -> str:
+        name = local_name(el)
+        if name in _SKIPPED:
+            return ""
+        if name in _INLINE:
+            tag = _INLINE[name]
+            return f"<{tag}>{self.children(el)}</{tag}>"
+        if name == "image":
+            return self.image(el)
+        if name == "xref":
+            return self.link(el)
+        if name == "q":
+            return f"“{self.children(el)}”"
+        if name == "fn":
+            self.footnotes.append(self.children(el))
+            return f"<sup>{len(self.footnotes)}</sup>"
+        if name == "menucascade":
+            return " &gt; ".join(self.element(c) for c in el if is_element(c))
+        if name in _CODE:
+            language = (el.get("outputclass") or "").replace("language-", "")
+            parameter = f'<ac:parameter ac:name="language">{_esc(language)}</ac:parameter>' if language else ""
+            return (f'<ac:structured-macro ac:name="code">{parameter}'
+                    f'<ac:plain-text-body>{_cdata("".join(el.itertext()))}</ac:plain-text-body></ac:structured-macro>')
+        if name == "lines":
+            return "<p>" + self.children(el).strip("\n").replace("\n", "<br/>") + "</p>"
+        if name == "note":
+            macro = _ENTRY_NOTES.get(el.get("type") or "", "info")
+            return (f'<ac:structured-macro ac:name="{macro}"><ac:rich-text-body>{self.blocks(el)}'
+                    "</ac:rich-text-body></ac:structured-macro>")
+        if name in ("section", "example", "refsyn", "sectiondiv") or name in _TOPICS:
+            title = el.find("title")
+            level = "h3" if name in _TOPICS or name == "sectiondiv" else "h2"
+            heading = f"<{level}>{self.children(title)}</{level}>" if _plain(title) else ""
+            return heading + self.blocks(el, skip=("title",))
+        if name == "fig":
+            title = el.find("title")
+            heading = f"<p><strong>{self.children(title)}</strong></p>" if _plain(title) else ""
+            return heading + self.blocks(el, skip=("title", "desc"))
+        if name == "dl":
+            return "".join(self.element(c) for c in el if is_element(c))
+        if name in ("dlentry", "dlhead"):
+            terms = "".join(f"<p><strong>{self.children(t)}</strong></p>" for t in el if local_name(t) in ("dt",
+                                                                                                         "dthd"))
+            return terms + "".join(self.blocks(d) for d in el if local_name(d) in ("dd", "ddhd"))
+        if name in ("table", "simpletable", "properties", "choicetable"):
+            return self.table(el)
+        if name in ("step", "substep", "li", "choice", "sli"):
+            return f"<li>{self.blocks(el)}</li>"
+        if name in _BLOCK_SAME:
+            tag = _BLOCK_SAME[name]
+            if tag == "div":
+                return self.blocks(el)
+            return f"<{tag}>{self.children(el) if tag == 'p' else self.blocks(el)}</{tag}>"
+        if name == "cmd":
+            return self.children(el)
+        if self._is_block(el) and any(is_element(c) and self._is_block(c) for c in el):
+            return self.blocks(el)
+        return self.children(el)
+
+    def image(self, el: Any) -> str:
+        name = posixpath.basename(el.get("href") or "")
+        if not name or name not in self.images:
+            return _esc(_plain(el.find("alt")))
+        if name not in self.attachments:
+            self.attachments.append(name)
+        alt = _plain(el.find("alt")) or el.get("alt") or ""
+        width = f' ac:width="{_attr(el.get("width"))}"' if (el.get("width") or "").isdigit() else ""
+        image = (f'<ac:image ac:alt="{_attr(alt)}"{width}><ri:attachment ri:filename="{_attr(name)}"/>'
+                 "</ac:image>")
+        return f"<p>{image}</p>" if el.get("placement") == "break" else image
+
+    def link(self, el: Any) -> str:
+        href = el.get("href") or ""
+        text = self.children(el)
+        filename = posixpath.basename(href.partition("#")[0])
+        if el.get("scope") == "external" or href.lower().startswith(("http:", "https:", "mailto:", "ftp:")):
+            return f'<a href="{_attr(href)}">{text or _esc(href)}</a>'
+        title = self.titles.get(filename)
+        if title is None:
+            return text
+        label = _plain(el) or title
+        return (f'<ac:link><ri:page ri:content-title="{_attr(title)}"/>'
+                f"<ac:plain-text-link-body>{_cdata(label)}</ac:plain-text-link-body></ac:link>")
+
+    def table(self, el: Any) -> str:
+        rows: List[str] = []
+        title = el.find("title")
+        caption = f"<p><strong>{self.children(title)}</strong></p>" if _plain(title) else ""
+        if local_name(el) != "table":
+            for row in el:
+                if not is_element(row) or local_name(row) in ("title", "desc"):
+                    continue
+                cell = "th" if local_name(row) in ("sthead", "prophead", "chhead") else "td"
+                rows.append("<tr>" + "".join(f"<{cell}>{self.blocks(c)}</{cell}>" for c in row if is_element(c))
+                            + "</tr>")
+            return caption + "<table><tbody>" + "".join(rows) + "</tbody></table>"
+        for tgroup in el.findall("tgroup"):
+            columns = [c.get("colname") for c in tgroup.findall("colspec")]
+            for part in tgroup:
+                if local_name(part) not in ("thead", "tbody"):
+                    continue
+                cell = "th" if local_name(part) == "thead" else "td"
+                for row in part.findall("row"):
+                    cells = []
+                    for entry in row.findall("entry"):
+                        spans = ""
+                        start, end = entry.get("namest"), entry.get("nameend")
+                        if start in columns and end in columns and columns.index(end) > columns.index(start):
+                            spans += f' colspan="{columns.index(end) - columns.index(start) + 1}"'
+                        if (entry.get("morerows") or "").isdigit() and int(entry.get("morerows")) > 0:
+                            spans += f' rowspan="{int(entry.get("morerows")) + 1}"'
+                        cells.append(f"<{cell}{spans}>{self.blocks(entry)}</{cell}>")
+                    rows.append("<tr>" + "".join(cells) + "</tr>")
+        return caption + "<table><tbody>" + "".join(rows) + "</tbody></table>"
+
+    def topic(self, topic: Any) -> str:
+        body = self.blocks(topic, skip=("title",))
+        if self.footnotes:
+            body += "<hr/><ol>" + "".join(f"<li>{note}</li>" for note in self.footnotes) + "</ol>"
+        return body
+
+
+def topic_storage(topic: Any, titles: Optional[Mapping[str, str]] = None,
+                  images: Optional[Mapping[str, bytes]] = None) -> Tuple[str, List[str]]:
+    """(storage XHTML, attached image names) of *topic*; *titles* maps topic file names to page titles."""
+    renderer = _Renderer(titles or {}, images or {})
+    body = renderer.topic(topic)
+    ET.fromstring(f"<page {_NS_DECL}>{body}</page>".encode("utf-8"))  # well-formed, or raises
+    return body, renderer.attachments
+
+
+# -- page tree --------------------------------------------------------------
+
+def _entry_title(entry: Any, topic: Any) -> str:
+    navtitle = entry.find("topicmeta/navtitle")
+    return _plain(navtitle) or entry.get("navtitle") or (_plain(topic.find("title")) if topic is not None else "")
+
+
+def export_confluence(context: DitaContext, settings: Optional[ConfluenceSettings] = None) -> ConfluenceExport:
+    """Pages of the map of *context*, nested like it, with the images they show."""
+    settings = settings or ConfluenceSettings()
+    export = ConfluenceExport()
+    used: Dict[str, int] = {}
+    titles: Dict[str, str] = {}
+
+    def _unique(title: str) -> str:
+        title = (settings.title_prefix + (title or "Untitled")).strip()
+        used[title.lower()] = used.get(title.lower(), 0) + 1
+        return title if used[title.lower()] == 1 else f"{title} ({used[title.lower()]})"
+
+    def _walk(parent_el: Any, parent_key: Optional[str]) -> None:
+        for entry in parent_el:
+            if not is_element(entry) or local_name(entry) not in _MAP_ENTRIES:
+                continue
+            if entry.get("processing-role") == "resource-only" or local_name(entry) == "mapref":
+                continue
+            name = posixpath.basename((entry.get("href") or "").partition("#")[0]) or None
+            topic = context.topics.get(name) if name else None
+            title = _entry_title(entry, topic)
+            if topic is None and not title:
+                _walk(entry, parent_key)  # topicgroup: its children belong to the parent
+                continue
+            page = ConfluencePage(f"p{len(export.pages) + 1}", _unique(title), "", parent_key,
+                                  name if topic is not None else None)
+            export.pages.append(page)
+            if page.topic and page.topic not in titles:
+                titles[page.topic] = page.title
+            _walk(entry, page.key)
+
+    root = getattr(context, "ditamap_root", None)
+    if root is not None:
+        _walk(root, None)
+    for page in export.pages:
+        if page.topic is None:
+            page.body = '<ac:structured-macro ac:name="children"/>'
+            continue
+        page.body, page.attachments = topic_storage(context.topics[page.topic], titles, context.images)
+        for image in page.attachments:
+            export.images.setdefault(image, context.images[image])
+    logger.info("Confluence export: %d page(s), %d image(s)", len(export.pages), len(export.images))
+    return export
+
+
+# -- REST push --------------------------------------------------------------
+
+class _Client:
+    def __init__(self, settings: ConfluenceSettings) -> None:
+        missing = [key for key in ("base_url", "space_key") if not getattr(settings, key)]
+        if missing:
+            raise ConfluenceError(f"Set confluence.{' and confluence.'.join(missing)} in pipeline.yml")
+        token = os.environ.get(settings.token_env or "")
+        if not token:
+            raise ConfluenceError(f"Set the {settings.token_env} environment variable to a Confluence API token")
+        if settings.username:
+            pair = f"{settings.username}:{token}".encode("utf-8")
+            self._auth = "Basic " + base64.b64encode(pair).decode("ascii")
+        else:
+            self._auth = f"Bearer {token}"
+        self.settings = settings
+
+    def call(self, method: str, path: str, payload: Any = None, *, query: Optional[Dict[str, str]] = None,
+             files: Optional[Tuple[str, bytes]] = None) -> Any:
+        url = f"{self.settings.base_url}/rest/api/{path}"
+        if query:
+            url += "?" + urllib.parse.urlencode(query)
+        headers = {"Authorization": self._auth, "Accept": "application/json"}
+        data = None
+        if files is not None:
+            boundary = uuid.uuid4().hex
+            name, content = files
+            data = (f"--{boundary}\r\nContent-Disposition: form-data; name=\"file\"; filename=\"{name}\"\r\n"
+                    "Content-Type: application/octet-stream\r\n\r\n").encode("utf-8") + content + \
+                f"\r\n--{boundary}--\r\n".encode("ascii")
+            headers.update({"Content-Type": f"multipart/form-data; boundary={boundary}",
+                            "X-Atlassian-Token": "no-check"})
+        elif payload is not None:
+            data = json.dumps(payload).encode("utf-8")
+            headers["Content-Type"] = "application/json"
+        request = urllib.request.Request(url, data=data, method=method, headers=headers)
+        try:
+            with urllib.request.urlopen(request, timeout=self.settings.timeout_seconds) as response:
+                body = response.read()
+        except urllib.error.HTTPError as exc:
+            detail = exc.read().decode("utf-8", "replace")
+            try:
+                detail = json.loads(detail).get("message") or detail
+            except (ValueError, AttributeError):
+                pass
+            raise ConfluenceError(f"Confluence refused {method} {path} ({exc.code}): {detail[:300]}") from exc
+        except (urllib.error.URLError, OSError) as exc:
+            raise ConfluenceError(f"Cannot reach Confluence at {self.settings.base_url}: {exc}") from exc
+        return json.loads(body.decode("utf-8")) if body else {}
+
+    def existing(self, title: str) -> Optional[Dict[str, Any]]:
+        found = self.call("GET", "content", query={"spaceKey": self.settings.space_key, "title": title,
+                                                   "expand": "version"})
+        results = found.get("results") or []
+        return results[0] if results else None
+
+    def save(self, page: ConfluencePage, parent_id: Optional[str]) -> str:
+        payload: Dict[str, Any] = {"type": "page", "title": page.title, "space": {"key": self.settings.space_key},
+                                   "body": {"storage": {"value": page.body, "representation": "storage"}}}
+        if parent_id:
+            payload["ancestors"] = [{"id": parent_id}]
+        current = self.existing(page.title) if self.settings.update_existing else None
+        if current is None:
+            return str(self.call("POST", "content", payload)["id"])
+        payload["version"] = {"number": int((current.get("version") or {}).get("number") or 1) + 1}
+        return str(self.call("PUT", f"content/{current['id']}", payload).get("id") or current["id"])
+
+    def attach(self, page_id: str, name: str, data: bytes) -> None:
+        found = self.call("GET", f"content/{page_id}/child/attachment", query={"filename": name})
+        results = found.get("results") or []
+        if results:
+            self.call("POST", f"content/{page_id}/child/attachment/{results[0]['id']}/data", files=(name, data))
+        else:
+            self.call("POST", f"content/{page_id}/child/attachment", files=(name, data))
+
+
+def push_confluence(export: ConfluenceExport, settings: Optional[ConfluenceSettings] = None, *,
+                    on_progress: Optional[Callable[[str], None]] = None,
+                    cancel_token: Optional[CancellationToken] = None) -> Dict[str, str]:
+    """Create or update the pages of *export* in Confluence; returns the page id per page key.
+
+    Raises :class:`ConfluenceError` for missing settings or a refused request;
+    pages pushed before a cancellation stay in Confluence.
+    """
+    client = _Client(settings or ConfluenceSettings.from_config())
+    ids: Dict[str, str] = {}
+    for page in export.pages:
+        check_cancelled(cancel_token)
+        parent_id = ids.get(page.parent) if page.parent else client.settings.parent_page_id
+        ids[page.key] = client.save(page, parent_id)
+        for name in page.attachments:
+            client.attach(ids[page.key], name, export.images[name])
+        if on_progress is not None:
+            on_progress(f"Published “{page.title}”")
+    logger.info("Confluence push: %d page(s) to space %s", len(ids), client.settings.space_key)
+    return ids
@@ -0,0 +1,195 @@
+from __future__ import annotations
+
+"""Per-topic and per-map content statistics.
+
+:func:`compute_content_stats` measures the converted document for
+dashboards and editorial reviews:
+
+- words and sentences of the topic body (titles excluded);
+- a readability score (Flesch reading ease, 0–100, higher is easier; the
+  formula is calibrated for English and only indicative elsewhere; ``None``
+  for scripts without word spacing);
+- images and tables (``table`` and ``simpletable``);
+- reuse: the share of words in blocks that are pulled in by ``conref`` or
+  that repeat, word for word, a block found elsewhere in the map (blocks
+  shorter than four words are not counted as repeats).
+
+:meth:`ConversionService.finalize_conversion` notes the map totals, with
+the per-topic figures as detail, under ``content_stats`` in the report;
+:meth:`orlando_toolkit.api.Result.stats` recomputes them on the current
+structure.
+"""
+
+import logging
+import re
+from dataclasses import asdict, dataclass, field
+from typing import Any, Dict, List, Optional
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.revisions import block_text, text_blocks
+from orlando_toolkit.core.utils import topic_body
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["ContentStats", "TopicStats", "compute_content_stats", "readability", "record_content_stats"]
+
+_MIN_REUSE_WORDS = 4
+_WORD = re.compile(r"[^\W\d_](?:[^\W_]|['’-][^\W\d_])*")
+_SENTENCE_END = re.compile(r"[.!?。！？]+(?=\s|$)")
+_VOWELS = re.compile(r"[aeiouyàâäéèêëîïôöùûüÿæœ]+", re.I)
+# Characters of scripts written without spaces between words
+_UNSPACED = re.compile(r"[぀-ヿ㐀-䶿一-鿿가-힯฀-๿]")
+
+
+def _syllables(word: str) -> int:
+    word = word.lower()
+    count = len(_VOWELS.findall(word))
+    if word.endswith("e") and not word.endswith(("le", "ee")) and count > 1:
+        count -= 1
+    return max(1, count)
+
+
+def readability(words: int, sentences: int, syllables: int) -> Optional[float]:
+    """Flesch reading ease, clamped to 0–100; ``None`` without words."""
+    if words <= 0:
+        return None
+    score = 206.835 - 1.015 * (words / max(1, sentences)) - 84.6 * (syllables / words)
+    return round(min(100.0, max(0.0, score)), 1)
+
+
+@dataclass
+class TopicStats:
+    filename: str
+    title: str = ""
+    words: int = 0
+    sentences: int = 0
+    syllables: int = 0
+    images: int = 0
+    tables: int = 0
+    reused_words: int = 0
+    conrefs: int = 0
+    unspaced: bool = False
+
+    @property
+    def readability(self) -> Optional[float]:
+        return None if self.unspaced else readability(self.words, self.sentences, self.syllables)
+
+    @property
+    def reuse(self) -> float:
+        """Percentage of the words that are reused."""
+        return round(100.0 * self.reused_words / self.words, 1) if self.words else 0.0
+
+    def to_dict(self) -> Dict[str, Any]:
+        data = asdict(self)
+        del data["syllables"], data["unspaced"]
+        data.update(readability=self.readability, reuse=self.reuse)
+        return data
+
+
+@dataclass
+class ContentStats:
+    topics: List[TopicStats] = field(default_factory=list)
+
+    def _total(self, name: str) -> int:
+        return sum(getattr(t, name) for t in self.topics)
+
+    @property
+    def words(self) -> int:
+        return self._total("words")
+
+    @property
+    def images(self) -> int:
+        return self._total("images")
+
+    @property
+    def tables(self) -> int:
+        return self._total("tables")
+
+    @property
+    def readability(self) -> Optional[float]:
+        spaced = [t for t in self.topics if not t.unspaced]
+        return readability(sum(t.words for t in spaced), sum(t.sentences for t in spaced),
+                           sum(t.syllables for t in spaced))
+
+    @property
+    def reuse(self) -> float:
+        words = self.words
+        return round(100.0 * self._total("reused_words") / words, 1) if words else 0.0
+
+    def topic(self, filename: str) -> Optional[TopicStats]:
+        return next((t for t in self.topics if t.filename == filename), None)
+
+    def totals(self) -> Dict[str, Any]:
+        return {"topics": len(self.topics), "words": self.words, "readability": self.readability,
+                "images": self.images, "tables": self.tables, "reuse": self.reuse,
+                "conrefs": self._total("conrefs")}
+
+    def summary(self) -> str:
+        score = "-" if self.readability is None else f"{self.readability:g}"
+        return (f"{len(self.topics)} topic(s), {self.words} words, readability {score}, "
+                f"{self.images} image(s), {self.tables} table(s), {self.reuse:g}% reused")
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {"map": self.totals(), "topics": [t.to_dict() for t in self.topics]}
+
+
+def _ordered_topics(context: DitaContext) -> List[str]:
+    """Topic filenames in map order, then any the map does not reference."""
+    names: List[str] = []
+    if context.ditamap_root is not None:
+        for ref in context.ditamap_root.iter("topicref"):
+            name = (ref.get("href") or "").split("#")[0].split("/")[-1]
+            if name in context.topics and name not in names:
+                names.append(name)
+    names.extend(n for n in context.topics if n not in names)
+    return names
+
+
+def compute_content_stats(context: DitaContext) -> ContentStats:
+    """Statistics of every topic of *context*, in map order."""
+    stats = ContentStats()
+    blocks_by_topic: Dict[str, List[str]] = {}
+    occurrences: Dict[str, int] = {}
+    for name in _ordered_topics(context):
+        texts = [block_text(b) for b in text_blocks(context.topics[name])]
+        blocks_by_topic[name] = texts
+        for text in texts:
+            if len(text.split()) >= _MIN_REUSE_WORDS:
+                occurrences[text] = occurrences.get(text, 0) + 1
+
+    for name, texts in blocks_by_topic.items():
+        root = context.topics[name]
+        title = root.find("title")
+        topic = TopicStats(filename=name, title=block_text(title) if title is not None else "")
+        body = topic_body(root)
+        if body is not None:
+            # Separate the text of adjacent blocks so sentences and words do not run together
+            text = " ".join(" ".join(body.itertext()).split())
+            words = _WORD.findall(text)
+            topic.words = len(words)
+            topic.sentences = len(_SENTENCE_END.findall(text)) or (1 if words else 0)
+            topic.syllables = sum(_syllables(w) for w in words)
+            topic.unspaced = len(_UNSPACED.findall(text)) > len(text) / 4
+            topic.images = sum(1 for _ in body.iter("image"))
+            topic.tables = sum(1 for _ in body.iter("table")) + sum(1 for _ in body.iter("simpletable"))
+            topic.conrefs = sum(1 for el in body.iter() if isinstance(el.tag, str) and el.get("conref"))
+            topic.reused_words = sum(len(_WORD.findall(t)) for t in texts if occurrences.get(t, 0) > 1)
+            topic.reused_words += sum(len(_WORD.findall(block_text(el))) for el in body.iter()
+                                      if isinstance(el.tag, str) and el.get("conref"))
+            topic.reused_words = min(topic.reused_words, topic.words)
+        stats.topics.append(topic)
+    return stats
+
+
+def record_content_stats(context: DitaContext) -> Optional[ContentStats]:
+    """Compute the statistics and note them under ``content_stats``; never raises."""
+    report = getattr(context, "report", None)
+    try:
+        stats = compute_content_stats(context)
+    except Exception as exc:
+        logger.warning("Content statistics unavailable: %s", exc)
+        return None
+    if report is not None:
+        report.info("content_stats", stats.summary(), **stats.totals(),
+                    per_topic=[t.to_dict() for t in stats.topics])
+    return stats
@@ -15,6 +15,7 @@ if TYPE_CHECKING:
     from .plugins.manager import PluginManager
     from .services import ConversionService, StructureEditingService, UndoService, PreviewService, ProgressService
     from .models import DitaContext
+    from .external_tools import ToolExecutor
 
 logger = logging.getLogger(__name__)
 
@@ -65,6 +66,7 @@ class AppContext:
         self._undo_service = undo_service
         self._preview_service = preview_service
         self._progress_service = progress_service
+        self._tool_executor: Optional[ToolExecutor] = None
         self.ui_registry = ui_registry
         self._app_instance = app_instance
         
@@ -147,6 +149,17 @@ class AppContext:
         """
         return self._progress_service
     
+    def get_tool_executor(self) -> ToolExecutor:
+        """Get the sandboxed executor for external tools (LibreOffice, DITA-OT, ...).
+        
+        Returns:
+            ToolExecutor configured from ``security.yml`` (created on first use)
+        """
+        if self._tool_executor is None:
+            from .external_tools import ToolExecutor
+            self._tool_executor = ToolExecutor()
+        return self._tool_executor
+    
     # -------------------------------------------------------------------------
     # Plugin Data Management
     # -------------------------------------------------------------------------
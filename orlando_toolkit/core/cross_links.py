@@ -0,0 +1,187 @@
+from __future__ import annotations
+
+"""Links between documents converted together.
+
+A set of Word documents often links across files (``Guide.docx#Install``).
+Converted one by one, such links stay external file links that are dead in
+the DITA output. When the documents are converted as a set
+(:meth:`ConversionService.convert_set`), :func:`resolve_cross_document_links`
+turns every link whose file is another member of the set into a key
+reference:
+
+- each document gets a key scope named after its source file
+  (``metadata["key_scope"]``);
+- the target topic gets a ``<keydef>`` in its own map, keyed by the bookmark
+  (the topic's ``data-anchor`` or an element ``id``);
+- the link becomes ``keyref="<scope>.<key>"`` (``<key>/<element>`` for a
+  bookmark inside a topic); links into the document itself use the key
+  without scope.
+
+The scoped keys resolve when the member maps are referenced from one root map
+with matching ``keyscope`` attributes; :func:`build_set_map` creates it.
+Bookmarks that cannot be found resolve to the target document's first topic
+and are reported under ``cross_links``.
+"""
+
+import logging
+import re
+from dataclasses import dataclass, field
+from pathlib import Path, PurePosixPath
+from typing import Any, Dict, List, Optional, Sequence, Tuple
+from urllib.parse import unquote, urlparse
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.stable_ids import ANCHOR_HINT
+from orlando_toolkit.core.utils import slugify
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["CrossLinkResult", "build_set_map", "resolve_cross_document_links"]
+
+_LINK_TAGS = ("xref", "link")
+
+
+@dataclass
+class CrossLinkResult:
+    resolved: int = 0
+    unresolved_bookmarks: List[Tuple[str, str]] = field(default_factory=list)
+    scopes: Dict[str, str] = field(default_factory=dict)   # source name -> key scope
+
+
+def _document_name(value: str) -> Optional[str]:
+    """Lower-cased file name a link points at (``file:///C:/Docs/Guide.docx#x`` -> ``guide.docx``)."""
+    parsed = urlparse(value)
+    # One-letter schemes are Windows drive letters
+    if parsed.scheme and parsed.scheme != "file" and len(parsed.scheme) > 1:
+        return None
+    path = unquote(parsed.path if parsed.scheme else value.partition("#")[0]).replace("\\", "/")
+    name = PurePosixPath(path).name
+    return name.lower() or None
+
+
+def _fragment(value: str) -> Optional[str]:
+    _, sep, fragment = value.partition("#")
+    return unquote(fragment) if sep and fragment else None
+
+
+@dataclass
+class _Member:
+    name: str
+    scope: str
+    context: Any
+    keys: Dict[str, str] = field(default_factory=dict)      # topic file -> key
+
+    def first_topic(self) -> Optional[str]:
+        root = getattr(self.context, "ditamap_root", None)
+        ref = root.find(".//topicref[@href]") if root is not None else None
+        return Path(ref.get("href")).name if ref is not None else None
+
+    def find(self, bookmark: str) -> Tuple[Optional[str], Optional[str]]:
+        """``(topic file, element id or None)`` holding *bookmark*."""
+        for filename, topic in self.context.topics.items():
+            if (topic.get(ANCHOR_HINT) or "") == bookmark:
+                return filename, None
+        for filename, topic in self.context.topics.items():
+            if topic.get("id") == bookmark:
+                return filename, None
+            for element in topic.iter():
+                if isinstance(element.tag, str) and element.get("id") == bookmark:
+                    return filename, bookmark
+        return None, None
+
+    def key_for(self, filename: str, hint: Optional[str]) -> str:
+        if filename in self.keys:
+            return self.keys[filename]
+        base = slugify(hint or PurePosixPath(filename).stem) or "topic"
+        key, number = base, 2
+        while key in self.keys.values():
+            key, number = f"{base}_{number}", number + 1
+        self.keys[filename] = key
+        root = self.context.ditamap_root
+        ref = root.find(f".//topicref[@href='topics/{filename}']") if root is not None else None
+        keydef = ET.Element("keydef", keys=key, href=(ref.get("href") if ref is not None else f"topics/{filename}"))
+        keydef.set("processing-role", "resource-only")
+        if root is not None:
+            root.insert(len(root.findall("title")) + len(root.findall("topicmeta")), keydef)
+        return key
+
+
+def _scopes(names: Sequence[str]) -> List[str]:
+    scopes: List[str] = []
+    for name in names:
+        base = re.sub(r"[^A-Za-z0-9_-]", "_", PurePosixPath(name).stem).strip("_") or "doc"
+        scope, number = base, 2
+        while scope in scopes:
+            scope, number = f"{base}_{number}", number + 1
+        scopes.append(scope)
+    return scopes
+
+
+def resolve_cross_document_links(documents: Sequence[Tuple[str | Path, Any]], *,
+                                 report: Any = None) -> CrossLinkResult:
+    """Rewrite links between the *documents* (``(source path, context)`` pairs) as keyrefs.
+
+    Each context's report receives the outcome unless *report* is given.
+    """
+    names = [Path(source).name for source, _ in documents]
+    members: Dict[str, _Member] = {}
+    for name, scope, (source, context) in zip(names, _scopes(names), documents):
+        members[name.lower()] = _Member(name=name, scope=scope, context=context)
+        context.metadata["key_scope"] = scope
+    result = CrossLinkResult(scopes={m.name: m.scope for m in members.values()})
+
+    for member in members.values():
+        target_report = report if report is not None else getattr(member.context, "report", None)
+        count = 0
+        for topic in member.context.topics.values():
+            for link in topic.iter():
+                if not isinstance(link.tag, str) or link.tag not in _LINK_TAGS:
+                    continue
+                href = link.get("href") or ""
+                target = members.get(_document_name(href) or "") if href else None
+                if target is None:
+                    continue
+                bookmark = _fragment(href)
+                filename, element_id = target.find(bookmark) if bookmark else (None, None)
+                # Topic keys are named after the bookmark that found them
+                hint = bookmark if filename is not None and element_id is None else None
+                if filename is None:
+                    if bookmark:
+                        result.unresolved_bookmarks.append((member.name, href))
+                        if target_report is not None:
+                            target_report.warning("cross_links", f"Bookmark '{bookmark}' not found in {target.name}; "
+                                                  "linked to its first topic", href=href)
+                    filename = target.first_topic()
+                    if filename is None:
+                        continue
+                key = target.key_for(filename, hint)
+                keyref = key if target is member else f"{target.scope}.{key}"
+                if element_id:
+                    keyref += f"/{element_id}"
+                for attr in ("href", "scope", "format"):
+                    link.attrib.pop(attr, None)
+                link.set("keyref", keyref)
+                count += 1
+        result.resolved += count
+        if count and target_report is not None:
+            target_report.info("cross_links", f"{count} link(s) to documents of the set converted to key references",
+                               links=count)
+    return result
+
+
+def build_set_map(documents: Sequence[Tuple[str, Any]], title: Optional[str] = None, *,
+                  map_names: Optional[Sequence[str]] = None) -> ET._Element:
+    """Root map referencing each member map under its key scope.
+
+    *map_names* are the member map file names as packaged (default
+    ``<scope>.ditamap``).
+    """
+    root = ET.Element("map")
+    if title:
+        ET.SubElement(root, "title").text = title
+    for index, (_, context) in enumerate(documents):
+        scope = context.metadata.get("key_scope") or f"doc{index + 1}"
+        href = map_names[index] if map_names else f"{scope}.ditamap"
+        ET.SubElement(root, "mapref", href=href, keyscope=scope, format="ditamap")
+    return root
@@ -0,0 +1,136 @@
+from __future__ import annotations
+
+"""Conversion diagnostics shown in the Conversion Log and exported for CI.
+
+The :class:`~orlando_toolkit.core.models.report.ConversionReport` of a
+conversion holds what the stages changed or could not do, with a severity,
+the topic concerned and details. Some problems are only logged (a plugin
+warning about an unknown style, a picture it could not read); while
+:meth:`ConversionService.convert` runs, :func:`capture_log_warnings`
+collects the warnings and errors logged by the toolkit and its plugins and
+:func:`merge_log_entries` adds them to the report under the ``log``
+category (logger name in the detail), skipping messages the report already
+holds.
+
+Entries about Word content locate it with ``paragraph`` or ``paragraphs``
+in their detail: the 1-based index of the body paragraph in
+``word/document.xml`` (:attr:`ReportEntry.paragraphs`,
+:func:`entry_location`). :func:`filter_entries` selects entries by severity,
+category and text for the console; :func:`export_diagnostics` writes them as
+JSON in the shape of ``orlando-toolkit convert --report``, so a CI job can
+gate on the ``counts`` (or convert with ``--fail-on``).
+"""
+
+import json
+import logging
+import threading
+from contextlib import contextmanager
+from pathlib import Path
+from typing import Any, Dict, Iterable, Iterator, List, Optional
+
+from orlando_toolkit.core.models.report import SEVERITIES, ConversionReport, ReportEntry
+
+__all__ = ["LOG_CATEGORY", "capture_log_warnings", "entry_location", "export_diagnostics", "filter_entries",
+           "merge_log_entries"]
+
+LOG_CATEGORY = "log"
+# Records repeated more often than this in one conversion are counted, not listed
+_MAX_REPEATS = 20
+
+
+class _ReportHandler(logging.Handler):
+    """Logging handler recording warnings and errors as report entries."""
+
+    def __init__(self, report: ConversionReport, level: int) -> None:
+        super().__init__(level)
+        self.report = report
+        self._seen: Dict[str, int] = {}
+        self._seen_lock = threading.Lock()
+
+    def emit(self, record: logging.LogRecord) -> None:
+        try:
+            message = record.getMessage()
+        except Exception:
+            message = str(record.msg)
+        with self._seen_lock:
+            self._seen[message] = self._seen.get(message, 0) + 1
+            if self._seen[message] > _MAX_REPEATS:
+                return
+        severity = "error" if record.levelno >= logging.ERROR else "warning"
+        self.report.add(severity, LOG_CATEGORY, message, logger=record.name)
+
+    @property
+    def repeated(self) -> Dict[str, int]:
+        return {message: n for message, n in self._seen.items() if n > _MAX_REPEATS}
+
+
+@contextmanager
+def capture_log_warnings(level: int = logging.WARNING) -> Iterator[ConversionReport]:
+    """Collect the records of *level* and above logged while the block runs, as report entries.
+
+    The handler sits on the root logger, so plugin loggers are included;
+    records logged by other threads during the block are collected too.
+    """
+    captured = ConversionReport()
+    handler = _ReportHandler(captured, level)
+    root = logging.getLogger()
+    root.addHandler(handler)
+    try:
+        yield captured
+    finally:
+        root.removeHandler(handler)
+        for message, n in handler.repeated.items():
+            captured.info(LOG_CATEGORY, f"Logged {n} times, listed {_MAX_REPEATS} times: {message}", repeats=n)
+
+
+def merge_log_entries(report: ConversionReport, captured: ConversionReport) -> int:
+    """Add the *captured* log entries the *report* does not already state; returns the number added."""
+    known = {entry.message for entry in report.entries}
+    added = 0
+    for entry in captured.entries:
+        if entry.message in known:
+            continue
+        known.add(entry.message)
+        report.add(entry.severity, entry.category, entry.message, topic=entry.topic, **entry.detail)
+        added += 1
+    return added
+
+
+def entry_location(entry: ReportEntry) -> str:
+    """Where the entry points in the Word source ("¶ 12", "¶ 3, 7"), or an empty string."""
+    paragraphs = entry.paragraphs
+    if not paragraphs:
+        return ""
+    shown = ", ".join(str(p) for p in paragraphs[:5])
+    return f"¶ {shown}" + ("…" if len(paragraphs) > 5 else "")
+
+
+def filter_entries(entries: Iterable[ReportEntry], severities: Optional[Iterable[str]] = None,
+                   category: Optional[str] = None, text: str = "") -> List[ReportEntry]:
+    """Entries of the given *severities* and *category* whose message, topic or category contain *text*."""
+    wanted = set(severities) if severities is not None else set(SEVERITIES)
+    needle = text.strip().casefold()
+    found: List[ReportEntry] = []
+    for entry in entries:
+        if entry.severity not in wanted or (category and entry.category != category):
+            continue
+        if needle and not any(needle in (value or "").casefold()
+                              for value in (entry.message, entry.topic, entry.category)):
+            continue
+        found.append(entry)
+    return found
+
+
+def export_diagnostics(report: ConversionReport, path: str | Path, *,
+                       entries: Optional[Iterable[ReportEntry]] = None, source: Optional[str] = None) -> Path:
+    """Write *report* as JSON to *path* (only *entries* when given, e.g. the filtered console rows)."""
+    data: Dict[str, Any] = report.to_dict()
+    if entries is not None:
+        chosen = list(entries)
+        data["entries"] = [entry.to_dict() for entry in chosen]
+        data["counts"] = {sev: sum(1 for e in chosen if e.severity == sev) for sev in SEVERITIES}
+    if source:
+        data = {"source": source, **data}
+    path = Path(path)
+    path.write_text(json.dumps(data, ensure_ascii=False, indent=2), encoding="utf-8")
+    return path
@@ -0,0 +1,601 @@
+from __future__ import annotations
+
+"""Output dialects: DITA 1.3, DITA 2.0 and Lightweight DITA (XDITA).
+
+Content is always edited as DITA 1.3; the dialect only changes what the
+packager writes (:func:`convert_map`, :func:`convert_topic` on copies) and
+what validation checks against (:func:`dialect_issues` and the grammars of
+the dialect). It is chosen with ``metadata["dialect"]`` (Metadata tab,
+``orlando convert --dialect``), else ``output.dialect`` in
+``conversion.yml``.
+
+``dita-2.0``
+    DITA 2.0 DOCTYPEs; ``titlealts`` become alternative titles in the
+    prolog, ``image/@alt`` an ``<alt>`` element, ``topicref/@navtitle`` a
+    ``<navtitle>``; removed elements (``boolean``, ``indextermref``,
+    ``anchor``, …) and attributes (``@print``, ``@lockmeta``, …) are dropped
+    or unwrapped; old ``@chunk`` values are renamed; video and audio
+    objects become ``<video>``/``<audio>``.
+
+``xdita``
+    LwDITA XDITA: every topic becomes a ``<topic>`` with a ``<body>``, task
+    steps become ordered lists, tables become ``simpletable``, code blocks
+    ``pre``; phrases XDITA does not know become ``<ph>`` keeping their name
+    in ``@outputclass``; loose text of list items, definitions and cells is
+    wrapped in ``<p>``; profiling attributes are merged into ``@props``;
+    index terms, related links and prolog entries other than ``othermeta``
+    are dropped. Maps lose headings, groups and relationship tables (their
+    topics are kept); bookmaps are written as maps.
+
+Every conversion returns the list of what it changed or dropped, so a
+package never loses content silently.
+"""
+
+import logging
+from copy import deepcopy
+from typing import Any, List, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.bookmap import BOOKMAP_DOCTYPE
+from orlando_toolkit.core.models import DitaContext
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "DEFAULT_DIALECT",
+    "DIALECTS",
+    "DIALECT_LABELS",
+    "convert_context",
+    "convert_map",
+    "convert_topic",
+    "dialect_issues",
+    "grammar_plugin",
+    "map_doctype",
+    "output_dialect",
+    "shell_name",
+    "topic_doctype",
+]
+
+DIALECTS = ("dita-1.3", "dita-2.0", "xdita")
+DEFAULT_DIALECT = "dita-1.3"
+DIALECT_LABELS = {"dita-1.3": "DITA 1.3", "dita-2.0": "DITA 2.0", "xdita": "XDITA"}
+_ALIASES = {"1.3": "dita-1.3", "dita13": "dita-1.3", "2.0": "dita-2.0", "dita2": "dita-2.0", "dita20": "dita-2.0",
+            "lwdita": "xdita", "lightweight": "xdita"}
+# DITA-OT plugins holding the grammars of each dialect
+_GRAMMAR_PLUGINS = {"dita-1.3": "org.oasis-open.dita.v1_3", "dita-2.0": "org.oasis-open.dita.v2_0",
+                    "xdita": "org.oasis-open.xdita.v0_2_2"}
+
+_MAP_DOCTYPES = {
+    "dita-1.3": '<!DOCTYPE map PUBLIC "-//OASIS//DTD DITA Map//EN" "./dtd/technicalContent/dtd/map.dtd">',
+    "dita-2.0": '<!DOCTYPE map PUBLIC "-//OASIS//DTD DITA 2.0 Map//EN" "map.dtd">',
+    "xdita": '<!DOCTYPE map PUBLIC "-//OASIS//DTD LIGHTWEIGHT DITA Map//EN" "lw-map.dtd">',
+}
+_BOOKMAP_DOCTYPE_20 = '<!DOCTYPE bookmap PUBLIC "-//OASIS//DTD DITA 2.0 BookMap//EN" "bookmap.dtd">'
+_TOPIC_NAMES = {"concept": "Concept", "task": "Task", "reference": "Reference", "topic": "Topic",
+                "glossentry": "Glossary Entry", "troubleshooting": "Troubleshooting"}
+_XDITA_TOPIC_DOCTYPE = '<!DOCTYPE topic PUBLIC "-//OASIS//DTD LIGHTWEIGHT DITA Topic//EN" "lw-topic.dtd">'
+_XML_LANG = "{http://www.w3.org/XML/1998/namespace}lang"
+
+# DITA 2.0 -------------------------------------------------------------------
+_REMOVED_20 = frozenset({"boolean", "indextermref", "anchor", "anchorref", "data-about", "itemgroup"})
+_UNWRAPPED_20 = frozenset({"data-about", "itemgroup"})
+_CHUNK_20 = {"to-content": "combine", "by-topic": "split", "by-document": "combine"}
+
+# XDITA ----------------------------------------------------------------------
+_XDITA_BLOCKS = frozenset({"p", "ul", "ol", "dl", "pre", "audio", "video", "example", "simpletable", "fig", "note",
+                           "section", "div", "fn"})
+_XDITA_INLINE = frozenset({"b", "i", "u", "sup", "sub", "ph", "xref", "image", "data", "fn"})
+_XDITA_STRUCTURE = frozenset({"topic", "title", "shortdesc", "prolog", "body", "li", "dlentry", "dt", "dd",
+                              "sthead", "strow", "stentry", "alt", "desc", "fallback", "video-poster",
+                              "media-source", "media-track", "map", "topicmeta", "navtitle", "topicref", "keydef"})
+_XDITA_ELEMENTS = _XDITA_BLOCKS | _XDITA_INLINE | _XDITA_STRUCTURE
+_XDITA_ATTRIBUTES = frozenset({"id", "conref", "props", "dir", _XML_LANG, "translate", "outputclass", "class",
+                               "href", "format", "scope", "keyref", "keys", "type", "height", "width", "name",
+                               "value", "processing-role", "autoplay", "controls", "loop", "muted"})
+_PROFILING = ("audience", "product", "platform", "otherprops", "deliveryTarget")
+# Containers whose loose text and phrases XDITA wants in <p>
+_XDITA_WRAPPED = frozenset({"body", "section", "li", "dd", "note", "stentry", "example", "div", "fn"})
+_XDITA_RENAMED = {
+    "conbody": "body", "taskbody": "body", "refbody": "body", "glossdef": "body", "troublebody": "body",
+    "glossterm": "title", "steps": "ol", "steps-unordered": "ul", "substeps": "ol", "choices": "ul",
+    "step": "li", "substep": "li", "choice": "li", "sl": "ul", "sli": "li", "cmd": "p",
+    "codeblock": "pre", "lines": "pre", "msgblock": "pre", "screen": "pre",
+    "context": "section", "prereq": "section", "result": "section", "postreq": "section",
+    "steps-informal": "section", "refsyn": "section", "tasktroubleshooting": "section",
+    "lq": "div", "sectiondiv": "div", "bodydiv": "div", "hazardstatement": "note",
+    "info": "div", "stepresult": "div", "stepxmp": "div", "tutorialinfo": "div", "itemgroup": "div",
+    "ddhd": "dt", "dthd": "dt", "em": "i", "strong": "b",
+}
+_XDITA_DROPPED = frozenset({"indexterm", "draft-comment", "required-cleanup", "titlealts", "related-links",
+                            "boolean", "indextermref", "anchor", "hazardsymbol", "state", "unknown", "foreign"})
+
+
+def output_dialect(metadata: Optional[Mapping[str, Any]] = None) -> str:
+    """Dialect of ``metadata["dialect"]``, else of ``output.dialect`` in the conversion options."""
+    value = (metadata or {}).get("dialect")
+    if not value:
+        try:
+            from orlando_toolkit.core.processing import resolve_conversion_options
+            value = (resolve_conversion_options(metadata).get("output") or {}).get("dialect")
+        except Exception as exc:
+            logger.debug("Conversion options unavailable, writing %s: %s", DEFAULT_DIALECT, exc)
+    name = str(value or DEFAULT_DIALECT).strip().lower()
+    name = _ALIASES.get(name, name)
+    if name not in DIALECTS:
+        logger.warning("Unknown output dialect %r; writing %s", value, DEFAULT_DIALECT)
+        return DEFAULT_DIALECT
+    return name
+
+
+def grammar_plugin(dialect: str) -> str:
+    """DITA-OT plugin holding the grammars of *dialect*."""
+    return _GRAMMAR_PLUGINS.get(dialect, _GRAMMAR_PLUGINS[DEFAULT_DIALECT])
+
+
+def shell_name(root_tag: str, dialect: str) -> str:
+    """Base name of the grammar shell validating *root_tag* (``lw-topic`` in XDITA)."""
+    return f"lw-{root_tag}" if dialect == "xdita" else root_tag
+
+
+def map_doctype(dialect: str, *, bookmap: bool = False) -> str:
+    """DOCTYPE of the map in *dialect*; XDITA has no bookmap."""
+    if bookmap and dialect == "dita-2.0":
+        return _BOOKMAP_DOCTYPE_20
+    if bookmap and dialect != "xdita":
+        return BOOKMAP_DOCTYPE
+    return _MAP_DOCTYPES.get(dialect, _MAP_DOCTYPES[DEFAULT_DIALECT])
+
+
+def topic_doctype(topic: Any, dialect: str) -> str:
+    """DOCTYPE of the converted *topic* in *dialect*."""
+    from orlando_toolkit.core.utils import topic_doctype as doctype_13
+
+    if dialect == "xdita":
+        return _XDITA_TOPIC_DOCTYPE
+    if dialect == "dita-2.0":
+        name = _TOPIC_NAMES.get(topic.tag, "Concept")
+        tag = topic.tag if topic.tag in _TOPIC_NAMES else "concept"
+        return f'<!DOCTYPE {tag} PUBLIC "-//OASIS//DTD DITA 2.0 {name}//EN" "{tag}.dtd">'
+    return doctype_13(topic)
+
+
+# ---------------------------------------------------------------------------
+# Conversion
+# ---------------------------------------------------------------------------
+
+def convert_topic(topic: Any, dialect: str, changes: Optional[List[str]] = None) -> Any:
+    """*topic* written in *dialect*: the topic itself for DITA 1.3, else a converted copy.
+
+    What was changed or dropped is appended to *changes*.
+    """
+    if dialect not in ("dita-2.0", "xdita"):
+        return topic
+    notes = _Notes(changes)
+    copy = deepcopy(topic)
+    if dialect == "dita-2.0":
+        _topic_20(copy, notes)
+    else:
+        _topic_xdita(copy, notes)
+    return copy
+
+
+def convert_map(root: Any, dialect: str, changes: Optional[List[str]] = None) -> Any:
+    """The map *root* written in *dialect* (a converted copy unless DITA 1.3)."""
+    if root is None or dialect not in ("dita-2.0", "xdita"):
+        return root
+    notes = _Notes(changes)
+    copy = deepcopy(root)
+    if dialect == "dita-2.0":
+        _map_20(copy, notes)
+    else:
+        _map_xdita(copy, notes)
+    return copy
+
+
+def convert_context(context: DitaContext, dialect: str, changes: Optional[List[str]] = None) -> DitaContext:
+    """Copy of *context* with its map and topics in *dialect* (for validation)."""
+    if dialect not in ("dita-2.0", "xdita"):
+        return context
+    converted = DitaContext(ditamap_root=convert_map(context.ditamap_root, dialect, changes),
+                            topics={name: convert_topic(topic, dialect, changes)
+                                    for name, topic in (context.topics or {}).items()},
+                            images=context.images, metadata=dict(context.metadata or {}))
+    return converted
+
+
+class _Notes:
+    """Records each kind of change once, so the list stays readable."""
+
+    def __init__(self, sink: Optional[List[str]]) -> None:
+        self.sink = sink
+
+    def add(self, message: str) -> None:
+        if self.sink is not None and message not in self.sink:
+            self.sink.append(message)
+
+
+def _elements(root: Any, *tags: str) -> List[Any]:
+    return [el for el in root.iter(*tags) if isinstance(el.tag, str)] if tags else \
+        [el for el in root.iter() if isinstance(el.tag, str)]
+
+
+def _remove(el: Any) -> None:
+    """Remove *el*, keeping its tail text."""
+    parent = el.getparent()
+    if parent is None:
+        return
+    if el.tail:
+        previous = el.getprevious()
+        if previous is not None:
+            previous.tail = (previous.tail or "") + el.tail
+        else:
+            parent.text = (parent.text or "") + el.tail
+    parent.remove(el)
+
+
+def _unwrap(el: Any) -> None:
+    """Replace *el* by its content."""
+    parent = el.getparent()
+    if parent is None:
+        return
+    index = parent.index(el)
+    previous = el.getprevious()
+    if el.text:
+        if previous is not None:
+            previous.tail = (previous.tail or "") + el.text
+        else:
+            parent.text = (parent.text or "") + el.text
+    children = list(el)
+    for offset, child in enumerate(children):
+        parent.insert(index + offset, child)
+    if el.tail:
+        last = children[-1] if children else el.getprevious()
+        if last is not None:
+            last.tail = (last.tail or "") + el.tail
+        else:
+            parent.text = (parent.text or "") + el.tail
+    parent.remove(el)
+
+
+def _alt_element(image: Any) -> None:
+    alt = image.attrib.pop("alt", None)
+    if alt and image.find("alt") is None:
+        ET.SubElement(image, "alt").text = alt
+
+
+def _media(obj: Any, dialect: str) -> Optional[Any]:
+    """``<video>``/``<audio>`` for a media object written by ``core.word_media``, else None."""
+    kind = (obj.get("outputclass") or obj.get("type") or "").split("/")[0]
+    if kind not in ("video", "audio") or not obj.get("data"):
+        return None
+    ref = "href" if dialect == "dita-2.0" else "value"
+    media = ET.Element(kind)
+    for name in ("id", _XML_LANG):
+        if obj.get(name):
+            media.set(name, obj.get(name))
+    desc = obj.find("desc")
+    if desc is not None:
+        media.append(deepcopy(desc))
+    poster = next((p for p in obj.iter("param") if p.get("name") == "poster" and p.get("value")), None)
+    if poster is not None and kind == "video":
+        ET.SubElement(media, "video-poster", {ref: poster.get("value")})
+    source = ET.SubElement(media, "media-source", {ref: obj.get("data")})
+    if obj.get("type") and dialect == "dita-2.0":
+        source.set("format", obj.get("type").split("/")[-1])
+    media.tail = obj.tail
+    return media
+
+
+def _replace(old: Any, new: Any) -> None:
+    parent = old.getparent()
+    if parent is not None:
+        parent.replace(old, new)
+
+
+def _topic_20(topic: Any, notes: _Notes) -> None:
+    for nested in [topic, *(t for t in topic.iter() if t is not topic and t.tag in _TOPIC_NAMES)]:
+        titlealts = nested.find("titlealts")
+        if titlealts is None:
+            continue
+        alternatives = [child for child in titlealts if isinstance(child.tag, str)]
+        nested.remove(titlealts)
+        if alternatives:
+            prolog = nested.find("prolog")
+            if prolog is None:
+                prolog = ET.Element("prolog")
+                anchor = max((nested.index(c) for c in nested if c.tag in ("title", "shortdesc", "abstract")),
+                             default=-1)
+                nested.insert(anchor + 1, prolog)
+            for offset, alt in enumerate(alternatives):
+                alt.tail = None
+                prolog.insert(offset, alt)
+        notes.add("titlealts moved to the prolog")
+    _common_20(topic, notes)
+
+
+def _map_20(root: Any, notes: _Notes) -> None:
+    for ref in _elements(root):
+        navtitle = ref.attrib.pop("navtitle", None) if ref.tag != "map" else None
+        if navtitle:
+            _navtitle(ref, navtitle)
+            notes.add("@navtitle moved to <navtitle>")
+        if ref.tag in ("topicset", "topicsetref"):
+            ref.tag = "topicref" if ref.get("href") else "topicgroup"
+            notes.add("topicset written as topicref")
+        chunk = ref.get("chunk")
+        if chunk:
+            values = [_CHUNK_20.get(v, v) for v in chunk.split() if v not in ("select-topic", "select-document",
+                                                                             "select-branch")]
+            if values:
+                ref.set("chunk", " ".join(dict.fromkeys(values)))
+            else:
+                del ref.attrib["chunk"]
+            notes.add("@chunk values renamed for DITA 2.0")
+    _common_20(root, notes)
+
+
+def _common_20(root: Any, notes: _Notes) -> None:
+    for image in _elements(root, "image"):
+        if image.get("alt"):
+            _alt_element(image)
+            notes.add("image/@alt written as <alt>")
+    for obj in _elements(root, "object"):
+        media = _media(obj, "dita-2.0")
+        if media is not None:
+            _replace(obj, media)
+            notes.add("video and audio objects written as <video>/<audio>")
+    for note in _elements(root, "note"):
+        other = note.attrib.pop("othertype", None)
+        if other and not note.get("outputclass"):
+            note.set("outputclass", other)
+    for el in _elements(root):
+        if el.tag in _REMOVED_20:
+            if el.tag in _UNWRAPPED_20:
+                _unwrap(el)
+            else:
+                _remove(el)
+            notes.add(f"<{el.tag}> removed (not in DITA 2.0)")
+            continue
+        for name in ("print", "lockmeta", "query"):
+            if name in el.attrib:
+                del el.attrib[name]
+                notes.add(f"@{name} removed (not in DITA 2.0)")
+
+
+def _topic_xdita(topic: Any, notes: _Notes) -> None:
+    nested = [t for t in topic if isinstance(t.tag, str) and t.tag in _TOPIC_NAMES]
+    for child in nested:
+        topic.remove(child)
+        notes.add("nested topics removed (XDITA topics cannot nest)")
+    if topic.tag != "topic":
+        notes.add(f"<{topic.tag}> written as <topic>")
+        topic.tag = "topic"
+    for abstract in _elements(topic, "abstract"):
+        short = abstract.find("shortdesc")
+        if short is not None:
+            abstract.addprevious(short)
+        _remove(abstract)
+        notes.add("abstract reduced to its short description")
+    _prolog_xdita(topic, notes)
+    for table in _elements(topic, "table"):
+        _replace(table, _simpletable(table, notes))
+    for obj in _elements(topic, "object"):
+        media = _media(obj, "xdita")
+        if media is not None:
+            _replace(obj, media)
+        else:
+            _remove(obj)
+            notes.add("<object> removed (not in XDITA)")
+    for el in _elements(topic):
+        if el.getparent() is None and el is not topic:
+            continue
+        if el.tag in _XDITA_DROPPED:
+            _remove(el)
+            notes.add(f"<{el.tag}> removed (not in XDITA)")
+        elif el.tag in _XDITA_RENAMED:
+            el.tag = _XDITA_RENAMED[el.tag]
+        elif el.tag == "image":
+            _alt_element(el)
+        elif el.tag not in _XDITA_ELEMENTS:
+            original = el.tag
+            if any(isinstance(c.tag, str) and (c.tag in _XDITA_BLOCKS or c.tag in _XDITA_RENAMED) for c in el):
+                el.tag = "div"
+            else:
+                if not el.get("outputclass"):
+                    el.set("outputclass", original)
+                el.tag = "ph"
+            notes.add(f"<{original}> written as <{el.tag}>")
+    for el in _elements(topic):
+        _attributes_xdita(el, notes)
+    for el in _elements(topic):
+        if el.tag in _XDITA_WRAPPED:
+            _wrap_blocks(el)
+    if topic.find("body") is None:
+        ET.SubElement(topic, "body")
+
+
+def _prolog_xdita(topic: Any, notes: _Notes) -> None:
+    prolog = topic.find("prolog")
+    if prolog is None:
+        return
+    kept = [ET.Element("data", name=m.get("name"), value=m.get("content") or "")
+            for m in prolog.iter("othermeta") if m.get("name")]
+    kept += [deepcopy(d) for d in prolog.iter("data")]
+    dropped = [c for c in prolog if isinstance(c.tag, str) and c.tag not in ("data",)]
+    for child in list(prolog):
+        prolog.remove(child)
+    for data in kept:
+        data.tail = None
+        prolog.append(data)
+    if dropped:
+        notes.add("prolog reduced to <data> entries")
+    if not len(prolog):
+        topic.remove(prolog)
+
+
+def _simpletable(table: Any, notes: _Notes) -> Any:
+    simple = ET.Element("simpletable")
+    for name in ("id", "outputclass", _XML_LANG):
+        if table.get(name):
+            simple.set(name, table.get(name))
+    title = table.find("title")
+    if title is not None:
+        copy = deepcopy(title)
+        copy.tail = None
+        simple.append(copy)
+    spans = False
+    for section, row_tag in (("thead", "sthead"), ("tbody", "strow")):
+        for row in table.iterfind(f"tgroup/{section}/row"):
+            new_row = ET.SubElement(simple, row_tag)
+            for entry in row.iterfind("entry"):
+                spans = spans or any(entry.get(a) for a in ("namest", "morerows", "spanname"))
+                cell = deepcopy(entry)
+                cell.tag, cell.tail = "stentry", None
+                for name in list(cell.attrib):
+                    if name not in ("id", "outputclass", _XML_LANG):
+                        del cell.attrib[name]
+                new_row.append(cell)
+    simple.tail = table.tail
+    notes.add("tables written as simpletable")
+    if spans:
+        notes.add("table cell spans dropped (simpletable has none)")
+    return simple
+
+
+def _attributes_xdita(el: Any, notes: _Notes) -> None:
+    props = [el.get("props")] if el.get("props") else []
+    for name in _PROFILING:
+        value = el.attrib.pop(name, None)
+        if value:
+            props.append(f"{name}({value})")
+    if el.get("alt") and el.tag == "image":
+        _alt_element(el)
+    if props:
+        el.set("props", " ".join(props))
+    for name in list(el.attrib):
+        if name not in _XDITA_ATTRIBUTES:
+            del el.attrib[name]
+            notes.add(f"@{name} removed (not in XDITA)")
+
+
+def _wrap_blocks(el: Any) -> None:
+    """Move loose text and phrases of *el* into ``<p>`` elements."""
+    items: List[Tuple[str, Any]] = []
+    if (el.text or "").strip():
+        items.append(("text", el.text))
+    for child in list(el):
+        if not isinstance(child.tag, str):
+            continue
+        if child.tag in _XDITA_BLOCKS - {"fn"} or child.tag in ("title", "dlentry", "sthead", "strow", "desc",
+                                                                  "alt", "media-source", "video-poster"):
+            items.append(("block", child))
+        else:
+            items.append(("inline", child))
+        if (child.tail or "").strip():
+            items.append(("text", child.tail))
+    if not any(kind != "block" for kind, _ in items):
+        return
+    el.text = None
+    for child in list(el):
+        el.remove(child)
+    paragraph: Optional[Any] = None
+    for kind, item in items:
+        if kind == "block":
+            item.tail = None
+            el.append(item)
+            paragraph = None
+            continue
+        if paragraph is None:
+            paragraph = ET.SubElement(el, "p")
+        if kind == "text":
+            if len(paragraph):
+                paragraph[-1].tail = (paragraph[-1].tail or "") + item
+            else:
+                paragraph.text = (paragraph.text or "") + item
+        else:
+            item.tail = None
+            paragraph.append(item)
+
+
+def _map_xdita(root: Any, notes: _Notes) -> None:
+    if root.tag != "map":
+        notes.add(f"<{root.tag}> written as <map>")
+        root.tag = "map"
+    title = root.find("title")
+    meta = root.find("topicmeta")
+    if title is not None:
+        if meta is None:
+            meta = ET.Element("topicmeta")
+            root.insert(0, meta)
+        if meta.find("navtitle") is None:
+            navtitle = ET.Element("navtitle")
+            navtitle.text, navtitle[:] = title.text, list(title)
+            meta.insert(0, navtitle)
+        root.remove(title)
+    for el in _elements(root):
+        if el.tag in ("reltable", "anchor", "navref", "ditavalref"):
+            _remove(el)
+            notes.add(f"<{el.tag}> removed (not in XDITA maps)")
+        elif el.tag in ("topicgroup", "frontmatter", "backmatter", "booklists", "bookmeta"):
+            _unwrap(el)
+        elif el.tag in ("mapref", "chapter", "part", "appendix", "appendices", "preface", "notices",
+                        "topichead", "topicset", "topicsetref"):
+            if el.tag == "mapref":
+                el.set("format", "ditamap")
+            navtitle = el.attrib.pop("navtitle", None)
+            el.tag = "topicref"
+            if navtitle:
+                _navtitle(el, navtitle)
+        elif el.tag == "topicref":
+            navtitle = el.attrib.pop("navtitle", None)
+            if navtitle:
+                _navtitle(el, navtitle)
+    for el in _elements(root):
+        if el.tag == "topicmeta":
+            for child in list(el):
+                if isinstance(child.tag, str) and child.tag == "othermeta" and child.get("name"):
+                    el.replace(child, ET.Element("data", name=child.get("name"), value=child.get("content") or ""))
+                elif isinstance(child.tag, str) and child.tag not in ("navtitle", "data"):
+                    el.remove(child)
+                    notes.add("map metadata reduced to navtitle and data")
+        _attributes_xdita(el, notes)
+
+
+def _navtitle(ref: Any, text: str) -> None:
+    meta = ref.find("topicmeta")
+    if meta is None:
+        meta = ET.Element("topicmeta")
+        ref.insert(0, meta)
+    if meta.find("navtitle") is None:
+        navtitle = ET.Element("navtitle")
+        navtitle.text = text
+        meta.insert(0, navtitle)
+
+
+# ---------------------------------------------------------------------------
+# Checks
+# ---------------------------------------------------------------------------
+
+def dialect_issues(root: Any, dialect: str) -> List[Tuple[str, Any]]:
+    """``(message, element)`` for markup the converted *root* may not contain in *dialect*."""
+    issues: List[Tuple[str, Any]] = []
+    if dialect == "dita-2.0":
+        for el in _elements(root):
+            if el.tag in _REMOVED_20 or el.tag == "titlealts":
+                issues.append((f"<{el.tag}> does not exist in DITA 2.0", el))
+            for name in ("print", "lockmeta", "query"):
+                if name in el.attrib:
+                    issues.append((f"@{name} does not exist in DITA 2.0", el))
+            if el.tag == "image" and el.get("alt"):
+                issues.append(("image/@alt does not exist in DITA 2.0 (use <alt>)", el))
+            if el.tag != "map" and el.get("navtitle"):
+                issues.append(("@navtitle does not exist in DITA 2.0 (use <navtitle>)", el))
+    elif dialect == "xdita":
+        for el in _elements(root):
+            if el.tag not in _XDITA_ELEMENTS:
+                issues.append((f"<{el.tag}> does not exist in XDITA", el))
+            for name in el.attrib:
+                if name not in _XDITA_ATTRIBUTES:
+                    issues.append((f"@{name} on <{el.tag}> does not exist in XDITA", el))
+    return issues
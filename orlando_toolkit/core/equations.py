@@ -0,0 +1,511 @@
+from __future__ import annotations
+
+"""Word equations (OMML) as MathML in the DITA equation domain.
+
+Converters that do not understand ``m:oMath`` emit the equation's runs as
+plain text ("E=mc2") or drop it. :func:`omml_to_mathml` converts one OMML
+element to a MathML ``<math>`` element (fractions, scripts, radicals,
+delimiters, n-ary operators, functions, accents, bars, limits, matrices
+and equation arrays; runs become ``mi``/``mn``/``mo``/``mtext``); plugins
+may call it directly. After the handler returns,
+:func:`restore_word_equations` reads ``word/document.xml`` of the source
+and puts every equation back:
+
+- at a converter's ``<ph data-equation="N"/>`` placeholder (``N`` counts
+  the equations of the document from 1, a display block being one);
+- otherwise in the paragraph holding it, located by its text (with the
+  equation's garbled text, which is replaced, or without it);
+- a display equation (``m:oMathPara``) whose paragraph was dropped goes
+  after the block of the previous paragraph.
+
+Inline equations become ``<equation-inline>``, display equations
+``<equation-block>``, each wrapping ``<mathml>``. With a ``fallback_tool``
+configured (run through :class:`~orlando_toolkit.core.external_tools.ToolExecutor`
+with the MathML file and the PNG to write), a rendering is added to
+``context.images`` and referenced next to the MathML for outputs without
+MathML support. Findings are reported under ``equations``; the
+``equations`` section of ``conversion.yml`` controls the pass.
+"""
+
+import logging
+import re
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Callable, Dict, List, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.placement import find_block, innermost_block, insert_at, normalize, offset_of, \
+    text_blocks, topic_order
+from orlando_toolkit.core.word_source import WordSource, w
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["EQUATION_HINT", "MATHML_NS", "WordEquation", "omml_to_mathml", "read_word_equations",
+           "restore_word_equations"]
+
+MATHML_NS = "http://www.w3.org/1998/Math/MathML"
+EQUATION_HINT = "data-equation"
+_M = "http://schemas.openxmlformats.org/officeDocument/2006/math"
+_TOKEN = re.compile(r"(?P<mn>\d+(?:[.,]\d+)*)|(?P<mi>[^\W\d_])|(?P<space>\s+)|(?P<mo>.)", re.S)
+# Upright runs ("sin", "max", units) are words, not products of variables
+_WORD_TOKEN = re.compile(r"(?P<mn>\d+(?:[.,]\d+)*)|(?P<mi>[^\W\d_]+)|(?P<space>\s+)|(?P<mo>.)", re.S)
+_NARY_UNDER_OVER = frozenset("∑∏∐⋀⋁⋂⋃⨀⨁⨂")
+
+
+def _m(tag: str) -> str:
+    return f"{{{_M}}}{tag}"
+
+
+def _mml(tag: str, *children: Any, text: Optional[str] = None, **attrib: str) -> Any:
+    el = ET.Element(f"{{{MATHML_NS}}}{tag}", attrib)
+    if text is not None:
+        el.text = text
+    for child in children:
+        if child is not None:
+            el.append(child)
+    return el
+
+
+def _local(el: Any) -> str:
+    return el.tag.split("}", 1)[-1] if isinstance(el.tag, str) else ""
+
+
+def _prop(el: Any, props: str, name: str, default: Optional[str] = None) -> Optional[str]:
+    """``m:val`` of ``<props><name/>`` (``""`` when present without a value)."""
+    container = el.find(_m(props))
+    node = container.find(_m(name)) if container is not None else None
+    if node is None:
+        return default
+    return node.get(_m("val"), "")
+
+
+def _flag(el: Any, props: str, name: str) -> bool:
+    return _prop(el, props, name) in ("", "1", "on", "true")
+
+
+def _row(items: List[Any]) -> Any:
+    items = [i for i in items if i is not None]
+    return items[0] if len(items) == 1 else _mml("mrow", *items)
+
+
+def _run(run: Any) -> List[Any]:
+    text = "".join(t.text or "" for t in run.iter() if _local(t) == "t")
+    if not text:
+        return []
+    if _prop(run, "rPr", "nor") is not None or run.tag == w("r"):
+        return [_mml("mtext", text=text)]
+    style = _prop(run, "rPr", "sty")
+    tokens: List[Any] = []
+    for match in (_WORD_TOKEN if style in ("p", "b") else _TOKEN).finditer(text):
+        kind = match.lastgroup
+        if kind == "space":
+            continue
+        token = _mml(kind, text=match.group())
+        if kind == "mi" and style in ("p", "b"):
+            token.set("mathvariant", "bold" if style == "b" else "normal")
+        elif kind == "mi" and style == "bi":
+            token.set("mathvariant", "bold-italic")
+        tokens.append(token)
+    return tokens
+
+
+def _children(el: Any) -> List[Any]:
+    out: List[Any] = []
+    for child in el:
+        out.extend(_convert(child))
+    return out
+
+
+def _part(el: Any, name: str) -> Any:
+    part = el.find(_m(name))
+    return _row(_children(part)) if part is not None and _children(part) else _mml("mrow")
+
+
+def _chr(el: Any, props: str, default: str) -> str:
+    value = _prop(el, props, "chr")
+    return default if value is None else value
+
+
+def _delimited(el: Any) -> List[Any]:
+    begin = _prop(el, "dPr", "begChr", "(")
+    end = _prop(el, "dPr", "endChr", ")")
+    separator = _prop(el, "dPr", "sepChr", "|")
+    items: List[Any] = [_mml("mo", text=begin, fence="true")] if begin else []
+    for index, part in enumerate(el.findall(_m("e"))):
+        if index and separator:
+            items.append(_mml("mo", text=separator, separator="true"))
+        items.append(_row(_children(part)))
+    if end:
+        items.append(_mml("mo", text=end, fence="true"))
+    return [_mml("mrow", *items)]
+
+
+def _nary(el: Any) -> List[Any]:
+    symbol = _chr(el, "naryPr", "∫")
+    operator = _mml("mo", text=symbol, largeop="true")
+    sub = None if _flag(el, "naryPr", "subHide") else _part(el, "sub")
+    sup = None if _flag(el, "naryPr", "supHide") else _part(el, "sup")
+    location = _prop(el, "naryPr", "limLoc", "undOvr" if symbol in _NARY_UNDER_OVER else "subSup")
+    under_over = location == "undOvr"
+    if sub is not None and sup is not None:
+        base = _mml("munderover" if under_over else "msubsup", operator, sub, sup)
+    elif sub is not None:
+        base = _mml("munder" if under_over else "msub", operator, sub)
+    elif sup is not None:
+        base = _mml("mover" if under_over else "msup", operator, sup)
+    else:
+        base = operator
+    return [_mml("mrow", base, _part(el, "e"))]
+
+
+def _fraction(el: Any) -> List[Any]:
+    kind = _prop(el, "fPr", "type", "bar")
+    num, den = _part(el, "num"), _part(el, "den")
+    if kind == "lin":
+        return [_mml("mrow", num, _mml("mo", text="/"), den)]
+    frac = _mml("mfrac", num, den)
+    if kind == "noBar":
+        frac.set("linethickness", "0")
+    elif kind == "skw":
+        frac.set("bevelled", "true")
+    return [frac]
+
+
+def _radical(el: Any) -> List[Any]:
+    degree = el.find(_m("deg"))
+    if _flag(el, "radPr", "degHide") or degree is None or not _children(degree):
+        return [_mml("msqrt", _part(el, "e"))]
+    return [_mml("mroot", _part(el, "e"), _part(el, "deg"))]
+
+
+def _matrix(el: Any) -> List[Any]:
+    table = _mml("mtable")
+    for row in el.findall(_m("mr")):
+        table.append(_mml("mtr", *(_mml("mtd", _row(_children(cell))) for cell in row.findall(_m("e")))))
+    return [table]
+
+
+def _equation_array(el: Any) -> List[Any]:
+    rows = (_mml("mtr", _mml("mtd", _row(_children(part)))) for part in el.findall(_m("e")))
+    return [_mml("mtable", *rows, columnalign="left")]
+
+
+def _pre_scripts(el: Any) -> List[Any]:
+    return [_mml("mmultiscripts", _part(el, "e"), _mml("mprescripts"), _part(el, "sub"), _part(el, "sup"))]
+
+
+def _accent(el: Any) -> List[Any]:
+    return [_mml("mover", _part(el, "e"), _mml("mo", text=_chr(el, "accPr", "̂")), accent="true")]
+
+
+def _bar(el: Any) -> List[Any]:
+    if _prop(el, "barPr", "pos", "bot") == "top":
+        return [_mml("mover", _part(el, "e"), _mml("mo", text="¯"), accent="true")]
+    return [_mml("munder", _part(el, "e"), _mml("mo", text="_"), accentunder="true")]
+
+
+def _group(el: Any) -> List[Any]:
+    symbol = _chr(el, "groupChrPr", "⏟")
+    if _prop(el, "groupChrPr", "pos", "bot") == "top":
+        return [_mml("mover", _part(el, "e"), _mml("mo", text=symbol))]
+    return [_mml("munder", _part(el, "e"), _mml("mo", text=symbol))]
+
+
+def _phantom(el: Any) -> List[Any]:
+    if _prop(el, "phantPr", "show") in ("0", "off", "false"):
+        return [_mml("mphantom", _part(el, "e"))]
+    return [_part(el, "e")]
+
+
+_CONVERTERS: Dict[str, Callable[[Any], List[Any]]] = {
+    "r": _run,
+    "f": _fraction,
+    "sSup": lambda el: [_mml("msup", _part(el, "e"), _part(el, "sup"))],
+    "sSub": lambda el: [_mml("msub", _part(el, "e"), _part(el, "sub"))],
+    "sSubSup": lambda el: [_mml("msubsup", _part(el, "e"), _part(el, "sub"), _part(el, "sup"))],
+    "sPre": _pre_scripts,
+    "rad": _radical,
+    "d": _delimited,
+    "nary": _nary,
+    "func": lambda el: [_mml("mrow", _part(el, "fName"), _mml("mo", text="⁡"), _part(el, "e"))],
+    "acc": _accent,
+    "bar": _bar,
+    "groupChr": _group,
+    "limLow": lambda el: [_mml("munder", _part(el, "e"), _part(el, "lim"))],
+    "limUpp": lambda el: [_mml("mover", _part(el, "e"), _part(el, "lim"))],
+    "m": _matrix,
+    "eqArr": _equation_array,
+    "box": lambda el: [_part(el, "e")],
+    "borderBox": lambda el: [_mml("menclose", _part(el, "e"), notation="box")],
+    "phant": _phantom,
+}
+
+
+def _convert(el: Any) -> List[Any]:
+    if not isinstance(el.tag, str):
+        return []
+    if el.tag == w("r"):
+        return _run(el)
+    if not el.tag.startswith(f"{{{_M}}}"):
+        return _children(el)             # w:ins, w:smartTag and other wrappers
+    name = _local(el)
+    if name.endswith("Pr"):
+        return []
+    converter = _CONVERTERS.get(name)
+    if converter is not None:
+        return converter(el)
+    return [_row(_children(el))] if _children(el) else []
+
+
+def omml_to_mathml(element: Any) -> Any:
+    """MathML ``<math>`` for an ``m:oMath`` or ``m:oMathPara`` element."""
+    if _local(element) == "oMathPara":
+        parts = [_row(_children(math) or [_mml("mrow")]) for math in element.findall(_m("oMath"))]
+        if len(parts) == 1:
+            return _wrap(parts[0], display="block")
+        return _wrap(_mml("mtable", *(_mml("mtr", _mml("mtd", part)) for part in parts)), display="block")
+    return _wrap(_row(_children(element) or [_mml("mrow")]))
+
+
+def _wrap(body: Any, display: str = "inline") -> Any:
+    math = ET.Element(f"{{{MATHML_NS}}}math", nsmap={None: MATHML_NS})
+    math.set("display", display)
+    math.append(body)
+    return math
+
+
+def _linear_text(element: Any) -> str:
+    return "".join(t.text or "" for t in element.iter() if _local(t) == "t")
+
+
+# ----------------------------------------------------------------------
+# Reading the source
+# ----------------------------------------------------------------------
+
+@dataclass
+class WordEquation:
+    number: int                 # 1-based, in document order
+    element: Any                # m:oMath or m:oMathPara
+    display: bool
+    text: str                   # the equation's runs, as a plain-text converter prints them
+    offset_with: int = 0        # offset in the paragraph text including equation runs
+    offset_without: int = 0     # offset when the converter dropped the equation
+    space_after: bool = False   # the equation is followed by whitespace
+
+
+def _paragraph(paragraph: Any, equations: List[WordEquation]) -> Tuple[str, str, List[WordEquation]]:
+    with_text, without_text = [], []
+    found: List[WordEquation] = []
+    positions: List[int] = []
+
+    def _walk(node: Any) -> None:
+        for child in node:
+            if not isinstance(child.tag, str):
+                continue
+            if child.tag in (_m("oMath"), _m("oMathPara")):
+                text = _linear_text(child)
+                equation = WordEquation(len(equations) + 1, child, child.tag == _m("oMathPara"), normalize(text),
+                                        offset_of("".join(with_text)), offset_of("".join(without_text)))
+                equations.append(equation)
+                found.append(equation)
+                with_text.append(text)
+                positions.append(len(with_text))
+                continue
+            if child.tag == w("t"):
+                with_text.append(child.text or "")
+                without_text.append(child.text or "")
+            elif child.tag in (w("tab"), w("br")):
+                with_text.append(" ")
+                without_text.append(" ")
+            elif child.tag not in (w("delText"), w("instrText"), w("p")):
+                _walk(child)
+
+    _walk(paragraph)
+    for equation, position in zip(found, positions):
+        equation.space_after = "".join(with_text[position:])[:1].isspace()
+    return normalize("".join(with_text)), normalize("".join(without_text)), found
+
+
+def read_word_equations(path: str | Path | WordSource) -> List[Tuple[str, str, List[WordEquation]]]:
+    """``(text with equations, text without, equations)`` of each paragraph holding equations."""
+    docx = WordSource.of(path)
+    document = docx.document if docx is not None else None
+    if document is None or next(document.iter(f"{{{_M}}}oMath"), None) is None:
+        return []
+    equations: List[WordEquation] = []
+    paragraphs = []
+    for paragraph in docx.paragraphs:
+        with_text, without_text, found = _paragraph(paragraph, equations)
+        if found:
+            paragraphs.append((with_text, without_text, found))
+    return paragraphs
+
+
+# ----------------------------------------------------------------------
+# Placement
+# ----------------------------------------------------------------------
+
+class _Renderer:
+    """Optional PNG renderings of the MathML through a sandboxed external tool."""
+
+    def __init__(self, context: Any, options: Mapping[str, Any], report: Any) -> None:
+        self.context = context
+        self.tool = options.get("fallback_tool")
+        self.args = [str(a) for a in options.get("fallback_args") or ("{input}", "{output}")]
+        self.report = report
+        self.failed = 0
+        self._executor = None
+
+    def render(self, math: Any, number: int) -> Optional[str]:
+        if not self.tool:
+            return None
+        from orlando_toolkit.core.external_tools import ToolExecutor
+
+        try:
+            if self._executor is None:
+                self._executor = ToolExecutor()
+            with self._executor.workspace() as workspace:
+                (workspace.path / "equation.mml").write_bytes(ET.tostring(math, encoding="utf-8"))
+                args = [a.format(input="equation.mml", output="equation.png") for a in self.args]
+                self._executor.run(self.tool, args, workspace=workspace, check=True)
+                data = (workspace.path / "equation.png").read_bytes()
+        except Exception as exc:
+            self.failed += 1
+            logger.debug("Equation %s could not be rendered: %s", number, exc)
+            return None
+        name, n = f"equation_{number}.png", 2
+        while name in self.context.images:
+            name, n = f"equation_{number}_{n}.png", n + 1
+        self.context.images[name] = data
+        return name
+
+
+def _element(equation: WordEquation, renderer: _Renderer) -> Any:
+    math = omml_to_mathml(equation.element)
+    wrapper = ET.Element("equation-block" if equation.display else "equation-inline")
+    ET.SubElement(wrapper, "mathml").append(math)
+    image = renderer.render(math, equation.number)
+    if image:
+        fallback = ET.SubElement(wrapper, "image", href=f"../media/{image}", outputclass="equation-fallback")
+        ET.SubElement(fallback, "alt").text = equation.text
+    return wrapper
+
+
+def _drop(el: Any) -> None:
+    parent = el.getparent()
+    previous = el.getprevious()
+    if previous is not None:
+        previous.tail = (previous.tail or "") + (el.tail or "") or None
+    else:
+        parent.text = (parent.text or "") + (el.tail or "") or None
+    parent.remove(el)
+
+
+def _replace(el: Any, new: Any) -> None:
+    parent = el.getparent()
+    new.tail = el.tail
+    parent.insert(parent.index(el), new)
+    parent.remove(el)
+
+
+def _attach_display(block: Any, new: Any) -> None:
+    """Place a display equation after *block* (inside it for list items and cells)."""
+    if block.tag in ("li", "entry", "stentry", "dd") or block.getparent() is None:
+        block.append(new)
+        return
+    parent = block.getparent()
+    new.tail = block.tail
+    block.tail = None
+    parent.insert(parent.index(block) + 1, new)
+
+
+def restore_word_equations(path: str | Path | WordSource, context: Any, options: Optional[Mapping[str, Any]] = None,
+                           report: Any = None) -> int:
+    """Put the equations of the ``.docx`` *path* back into *context*; returns the number placed."""
+    options = dict(options or {})
+    placeholders = [(name, el) for name in topic_order(context)
+                    for el in list(context.topics[name].iter("ph")) if el.get(EQUATION_HINT) is not None]
+    if not options.get("enabled", True):
+        for _, el in placeholders:
+            _drop(el)
+        return 0
+    report = report if report is not None else getattr(context, "report", None)
+    try:
+        paragraphs = read_word_equations(path)
+    except Exception as exc:
+        logger.warning("Could not read the equations of %s: %s", Path(path).name, exc)
+        paragraphs = []
+    if not paragraphs and not placeholders:
+        return 0
+    renderer = _Renderer(context, options, report)
+    by_number = {eq.number: eq for _, _, found in paragraphs for eq in found}
+    placed = unplaced = 0
+
+    if placeholders:
+        for _, el in placeholders:
+            try:
+                equation = by_number.get(int(el.get(EQUATION_HINT)))
+            except ValueError:
+                equation = None
+            if equation is None:
+                unplaced += 1
+                _drop(el)
+                continue
+            parent = el.getparent()
+            if equation.display and parent.tag == "p" and len(parent) == 1 and not normalize(
+                    (parent.text or "") + (el.tail or "")):
+                el = parent          # a paragraph holding only the display equation
+            _replace(el, _element(equation, renderer))
+            placed += 1
+    elif any(True for t in context.topics.values() for _ in t.iter("mathml")):
+        if report is not None:
+            report.info("equations", "Converter already produced <mathml>; Word equations left as converted")
+        return 0
+    else:
+        blocks = text_blocks(context)
+        cursor = 0
+        previous: Optional[Any] = None
+        for with_text, without_text, found in paragraphs:
+            garbled = bool(with_text) and with_text != without_text
+            hit, cursor_after = find_block(blocks, with_text, cursor) if garbled else (None, cursor)
+            if hit is None and without_text:
+                hit, cursor_after = find_block(blocks, without_text, cursor)
+                garbled = False
+            if hit is None:
+                if previous is not None and all(eq.display for eq in found):
+                    for equation in reversed(found):
+                        _attach_display(previous, _element(equation, renderer))
+                    placed += len(found)
+                else:
+                    unplaced += len(found)
+                continue
+            cursor = cursor_after
+            block = innermost_block(blocks[hit][1], with_text if garbled else without_text)
+            previous = block
+            if garbled and len(found) == 1 and found[0].display and with_text == found[0].text:
+                _replace(block, _element(found[0], renderer))
+                previous = None
+                placed += 1
+                continue
+            for equation in reversed(found):
+                offset = equation.offset_with if garbled else equation.offset_without
+                new = _element(equation, renderer)
+                removed = insert_at(block, offset, new, equation.text if garbled else "")
+                if not garbled and equation.space_after and new.tail and not new.tail[:1].isspace():
+                    new.tail = " " + new.tail
+                if garbled and not removed and report is not None:
+                    report.warning("equations", f"Equation {equation.number}: its plain-text rendering "
+                                                f"'{equation.text}' could not be removed")
+                placed += 1
+
+    if report is not None:
+        if placed:
+            report.info("equations", f"{placed} equation(s) converted to MathML", equations=placed)
+        if unplaced:
+            report.warning("equations", f"{unplaced} equation(s) could not be placed: their paragraph was not "
+                                        "found in the converted topics")
+        if renderer.failed:
+            report.warning("equations", f"{renderer.failed} equation(s) could not be rendered with "
+                                        f"{renderer.tool}; MathML only")
+    return placed
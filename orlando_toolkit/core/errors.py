@@ -0,0 +1,206 @@
+from __future__ import annotations
+
+"""Typed errors with codes, source locations and remediation hints.
+
+Errors raised by the pipeline carry three things besides their message:
+
+- ``code``: a stable identifier (``OTK201``) users can search for and that
+  front-ends and scripts can branch on;
+- ``location``: where in the source the problem is (:class:`SourceLocation`:
+  file, part inside a container, topic, element path, line), when known;
+- ``hint``: what the user can do about it, in one sentence.
+
+New errors derive from :class:`ToolkitError`; codes and default hints are
+listed in :data:`ERROR_CODES`. Plugins raise :class:`ContentError` (or record
+it with :func:`report_error`) for source content they cannot map, e.g.
+``ContentError("Heading 2 used inside a table", code="OTK401",
+hint="Move the heading out of the table", location=...)``.
+
+:func:`describe_error` turns any exception into an :class:`ErrorInfo` so the
+GUI, the conversion report and the library API present errors the same way;
+exceptions without a code are reported as ``OTK000``.
+"""
+
+from dataclasses import dataclass
+from typing import Any, Dict, Optional
+
+__all__ = [
+    "ERROR_CODES",
+    "ContentError",
+    "ErrorInfo",
+    "HandlerError",
+    "SourceLocation",
+    "ToolkitError",
+    "describe_error",
+    "report_error",
+]
+
+# code -> default remediation hint
+ERROR_CODES: Dict[str, str] = {
+    "OTK000": "See the log file for details and report the problem if it persists.",
+    # Sources
+    "OTK101": "Install and activate a plugin for this format, or open a DITA .zip archive.",
+    "OTK102": "Check that the archive contains a .ditamap and the topics it references.",
+    "OTK103": "The project file is damaged or not a project; reopen the source document instead.",
+    # Security policy
+    "OTK201": "The archive exceeds a limit in security.yml (archive_limits); raise it only for trusted files.",
+    "OTK202": "Save the document without macros (e.g. .docx instead of .docm) or change security.yml active_content.",
+    "OTK203": "Remove the DOCTYPE and entity declarations from the file, or relax security.yml xml_parsing.",
+    # Plugins
+    "OTK301": "Reinstall or deactivate the plugin; its log messages explain the failure.",
+    "OTK302": "Turn the hook off in the Plugin Manager (or conversion.yml hooks.disabled) and report it to its author.",
+    # Content and processing
+    "OTK400": "Fix the marked content in the source document and convert again.",
+    "OTK401": "Move the heading out of the table.",
+    "OTK410": "Disable the stage in conversion.yml to convert without it, and report the problem.",
+    "OTK420": "Fix the errors listed by Validate, or set validation.block_on_errors: false in pipeline.yml.",
+    "OTK430": "Fix the stylesheet named in the report, or set xslt.on_error: report to package without it.",
+    # External tools
+    "OTK501": "Check that the tool is installed and allowed in security.yml external_tools.",
+    "OTK502": "Raise external_tools.timeout_seconds in security.yml or simplify the input.",
+    "OTK503": "Install DITA-OT or set publishing.dita_ot_home in pipeline.yml; its log shows why a build failed.",
+    "OTK504": "Check confluence.base_url and space_key in pipeline.yml and the API token in its token_env variable.",
+    "OTK505": "Set s1000d.enabled: true in pipeline.yml and check its codes against your project's SNS and CAGE code.",
+    # Output
+    "OTK601": "Choose an empty folder, or pass --force to replace the maps, topics and media folders of this one.",
+}
+
+
+@dataclass(frozen=True)
+class SourceLocation:
+    """Where a problem is: any subset of file, container part, topic, element, line."""
+
+    file: Optional[str] = None
+    part: Optional[str] = None
+    topic: Optional[str] = None
+    element: Optional[str] = None
+    line: Optional[int] = None
+
+    def __str__(self) -> str:
+        pieces = []
+        if self.file:
+            pieces.append(self.file)
+        if self.part:
+            pieces.append(self.part + (f":{self.line}" if self.line else ""))
+        elif self.line:
+            pieces.append(f"line {self.line}")
+        if self.topic:
+            pieces.append(f"topic {self.topic}")
+        if self.element:
+            pieces.append(self.element)
+        return " › ".join(pieces)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {k: v for k, v in vars(self).items() if v is not None}
+
+
+class ToolkitError(Exception):
+    """Base for errors carrying a code, a location and a remediation hint.
+
+    ``code`` and ``hint`` default to class attributes so subclasses declare
+    them once; both can be overridden per instance.
+    """
+
+    code: str = "OTK000"
+    hint: Optional[str] = None
+
+    def __init__(self, message: str, *, code: Optional[str] = None, hint: Optional[str] = None,
+                 location: Optional[SourceLocation] = None, cause: Optional[BaseException] = None) -> None:
+        super().__init__(message)
+        if code is not None:
+            self.code = code
+        if hint is not None:
+            self.hint = hint
+        self.location = location
+        self.cause = cause
+
+
+class ContentError(ToolkitError):
+    """Source content the pipeline cannot map to DITA."""
+
+    code = "OTK400"
+
+
+class HandlerError(ToolkitError, RuntimeError):
+    """Raised when a plugin's document handler fails; ``cause`` is its exception."""
+
+    code = "OTK301"
+
+
+@dataclass(frozen=True)
+class ErrorInfo:
+    """Uniform description of an error for display and reports."""
+
+    code: str
+    message: str
+    hint: Optional[str] = None
+    location: Optional[SourceLocation] = None
+    type: str = "Exception"
+
+    def to_dict(self) -> Dict[str, Any]:
+        data: Dict[str, Any] = {"code": self.code, "message": self.message, "type": self.type}
+        if self.hint:
+            data["hint"] = self.hint
+        if self.location is not None:
+            data["location"] = self.location.to_dict()
+        return data
+
+    def format(self) -> str:
+        """Multi-line text for dialogs and the console."""
+        details = []
+        if self.location is not None and str(self.location):
+            details.append(f"Where: {self.location}")
+        if self.hint:
+            details.append(f"What to do: {self.hint}")
+        details.append(f"Error code: {self.code}")
+        return self.message + "\n\n" + "\n".join(details)
+
+
+def _cause_of(exc: BaseException) -> Optional[BaseException]:
+    cause = getattr(exc, "cause", None)
+    if isinstance(cause, BaseException):
+        return cause
+    return exc.__cause__
+
+
+def describe_error(exc: BaseException) -> ErrorInfo:
+    """Return the code, hint and location of *exc*.
+
+    The most specific error in the cause chain wins: ``ConversionError``
+    around ``DitaImportError`` around ``ArchiveLimitError`` reports the
+    archive limit. The message stays the outermost one.
+    """
+    chain = []
+    current: Optional[BaseException] = exc
+    while current is not None and all(current is not seen for seen in chain):
+        chain.append(current)
+        current = _cause_of(current)
+
+    code, hint = "OTK000", None
+    for error in reversed(chain):
+        candidate = getattr(error, "code", None)
+        if isinstance(candidate, str) and candidate != "OTK000":
+            code, hint = candidate, getattr(error, "hint", None)
+            break
+    location = next((loc for loc in (getattr(e, "location", None) for e in reversed(chain))
+                     if isinstance(loc, SourceLocation)), None)
+    return ErrorInfo(
+        code=code,
+        message=str(exc) or type(exc).__name__,
+        hint=hint or ERROR_CODES.get(code),
+        location=location,
+        type=type(exc).__name__,
+    )
+
+
+def report_error(report: Any, exc: BaseException, category: str, *, severity: str = "error",
+                 topic: Optional[str] = None, message: Optional[str] = None, **detail: Any):
+    """Record *exc* in a :class:`ConversionReport` with its code, hint and location."""
+    info = describe_error(exc)
+    detail.update(code=info.code)
+    if info.hint:
+        detail["hint"] = info.hint
+    if info.location is not None:
+        detail["location"] = info.location.to_dict()
+        topic = topic or info.location.topic
+    return report.add(severity, category, message or info.message, topic=topic, **detail)
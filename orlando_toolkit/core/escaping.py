@@ -0,0 +1,126 @@
+from __future__ import annotations
+
+"""Character escaping policy for serialized DITA.
+
+Downstream CCMS tools disagree on how non-ASCII characters should appear in
+XML files. The policy is applied to every map and topic file written by the
+packager:
+
+- ``utf-8`` (default): characters are written as raw UTF-8.
+- ``numeric``: every non-ASCII character becomes a numeric character
+  reference (``&#xE9;``, or ``&#233;`` with ``numeric_format: decimal``).
+- ``named``: characters listed in ``named_entities`` are written as named
+  entities (``&eacute;``); everything else non-ASCII falls back to a numeric
+  reference. XML only predefines ``amp``, ``lt``, ``gt``, ``quot`` and
+  ``apos``, so list other names only when the target DTD declares them.
+
+Characters listed in ``numeric_characters`` (``"U+00A0"`` entries) are always
+written as numeric references, whatever the mode; this keeps invisible
+characters such as NBSP visible in diffs.
+
+Only text and attribute values are escaped. Element and attribute names
+(ASCII in DITA), comments, processing instructions, CDATA sections and the
+document type declaration keep their characters: a character reference means
+nothing there.
+"""
+
+import html.entities
+import logging
+import re
+from dataclasses import dataclass, field
+from typing import Any, FrozenSet, Mapping, Optional
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["EscapingPolicy", "ESCAPING_MODES"]
+
+ESCAPING_MODES = ("utf-8", "numeric", "named")
+_NON_ASCII = re.compile(r"[^\x00-\x7f]")
+_CODEPOINT_RE = re.compile(r"^U\+([0-9A-Fa-f]{1,6})$")
+# Markup of a serialized document; what lies between two matches is text. The
+# serializers write ">" in text and attribute values as "&gt;".
+_MARKUP_RE = re.compile(r"<!--.*?-->|<\?.*?\?>|<!\[CDATA\[.*?\]\]>|<!DOCTYPE(?:[^\[>]|\[.*?\])*>|<[^>]*>", re.S)
+_VALUE_RE = re.compile(r"\"[^\"]*\"|'[^']*'")
+
+
+@dataclass(frozen=True)
+class EscapingPolicy:
+    mode: str = "utf-8"
+    numeric_format: str = "hex"
+    named_entities: FrozenSet[str] = field(default_factory=frozenset)
+    numeric_characters: FrozenSet[str] = field(default_factory=frozenset)
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "EscapingPolicy":
+        """Build from the ``serialization`` config section; invalid values fall back."""
+        data = data or {}
+        mode = str(data.get("characters", "utf-8") or "utf-8").strip().lower()
+        if mode in ("utf8", "raw"):
+            mode = "utf-8"
+        if mode not in ESCAPING_MODES:
+            logger.warning("Unknown character escaping mode %r; using utf-8", mode)
+            mode = "utf-8"
+        fmt = str(data.get("numeric_format", "hex") or "hex").strip().lower()
+        if fmt not in ("hex", "decimal"):
+            logger.warning("Unknown numeric_format %r; using hex", fmt)
+            fmt = "hex"
+        names = set()
+        for name in data.get("named_entities") or ():
+            name = str(name).strip().strip("&;")
+            if name in html.entities.name2codepoint:
+                names.add(name)
+            else:
+                logger.warning("Ignoring unknown entity name %r", name)
+        chars = set()
+        for item in data.get("numeric_characters") or ():
+            text = str(item).strip()
+            m = _CODEPOINT_RE.match(text)
+            if m:
+                chars.add(chr(int(m.group(1), 16)))
+            elif len(text) == 1:
+                chars.add(text)
+            else:
+                logger.warning("Ignoring numeric_characters entry %r", item)
+        return cls(mode=mode, numeric_format=fmt, named_entities=frozenset(names),
+                   numeric_characters=frozenset(chars))
+
+    @property
+    def is_raw(self) -> bool:
+        return self.mode == "utf-8" and not self.numeric_characters
+
+    def _reference(self, ch: str) -> str:
+        cp = ord(ch)
+        return f"&#x{cp:X};" if self.numeric_format == "hex" else f"&#{cp};"
+
+    def apply(self, xml_text: str) -> str:
+        """Return serialized *xml_text* with the characters of its text and attribute values escaped."""
+        if self.is_raw:
+            return xml_text
+        if self.mode == "utf-8":
+            pattern = re.compile("[" + "".join(re.escape(c) for c in sorted(self.numeric_characters)) + "]")
+        else:
+            pattern = _NON_ASCII
+        by_codepoint = {}
+        if self.mode == "named":
+            by_codepoint = {html.entities.name2codepoint[n]: n for n in sorted(self.named_entities)}
+
+        def _escape(match: "re.Match[str]") -> str:
+            ch = match.group(0)
+            name = by_codepoint.get(ord(ch))
+            if name and ch not in self.numeric_characters:
+                return f"&{name};"
+            return self._reference(ch)
+
+        def _values(match: "re.Match[str]") -> str:
+            return pattern.sub(_escape, match.group(0))
+
+        parts = []
+        position = 0
+        for markup in _MARKUP_RE.finditer(xml_text):
+            parts.append(pattern.sub(_escape, xml_text[position:markup.start()]))
+            tag = markup.group(0)
+            # Start tags and empty-element tags hold attribute values; "<!" and "<?" markup stays as written
+            parts.append(tag if tag[1] in "!?" else _VALUE_RE.sub(_values, tag))
+            position = markup.end()
+        parts.append(pattern.sub(_escape, xml_text[position:]))
+        return "".join(parts)
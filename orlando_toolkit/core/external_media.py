@@ -0,0 +1,182 @@
+from __future__ import annotations
+
+"""Media referenced on a shared asset server instead of embedded in the package.
+
+Large videos and high-resolution images are often served from an asset
+server. When ``external_media.base_url`` is set (``conversion.yml`` or a
+conversion profile), :func:`externalize_media` runs while the package is
+prepared, after images are renamed: every selected media file gets the URL
+of :attr:`ExternalMediaSettings.url_template` in the topics
+(``image/@href``, ``object/@data``, ``video/@href``, with
+``scope="external"``, and poster ``param/@value``) and is left out of
+``media/`` unless ``keep_in_package``. The project keeps its copy, so the preview, the Images
+tab and later exports still show it. Placeholders of the template:
+
+- ``{base_url}`` - ``base_url`` without its trailing ``/``;
+- ``{manual_code}`` - manual code from the metadata;
+- ``{kind}`` - ``images`` or ``videos``;
+- ``{name}`` - packaged file name (``{stem}`` without, ``{ext}`` its extension);
+- ``{hash}`` - first 8 hex digits of the SHA-1 of the file.
+
+A file is selected when the Images tab marked it (``metadata["external_media"]``,
+kept through image renaming), when its packaged name matches one of
+``files`` (names or glob patterns such as ``*.mp4``), or when it weighs at
+least ``min_size_kb``. The referenced files are listed in the conversion
+report under ``external_media``.
+"""
+
+import fnmatch
+import hashlib
+import logging
+import os
+import posixpath
+import string
+import urllib.parse
+from dataclasses import dataclass, field
+from typing import Any, Dict, Iterable, List, Mapping, Optional
+
+from orlando_toolkit.core.models import DitaContext
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["ExternalMediaSettings", "METADATA_KEY", "PLACEHOLDERS", "externalize_media", "is_marked_external",
+           "mark_external", "rename_marked"]
+
+METADATA_KEY = "external_media"
+PLACEHOLDERS = ("base_url", "manual_code", "kind", "name", "stem", "ext", "hash")
+_DEFAULT_TEMPLATE = "{base_url}/{name}"
+# (element, attribute) holding a media reference in the topics
+_REFERENCES = (("image", "href"), ("object", "data"), ("video", "href"), ("param", "value"))
+
+
+@dataclass
+class ExternalMediaSettings:
+    base_url: str = ""
+    url_template: str = _DEFAULT_TEMPLATE
+    files: List[str] = field(default_factory=list)
+    min_size_kb: Optional[float] = None
+    keep_in_package: bool = False
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "ExternalMediaSettings":
+        data = dict(data or {})
+        template = str(data.get("url_template") or _DEFAULT_TEMPLATE)
+        problem = cls.validate(template)
+        if problem:
+            logger.warning("Ignoring invalid external_media.url_template %r: %s", template, problem)
+            template = _DEFAULT_TEMPLATE
+        files = data.get("files") or []
+        try:
+            min_size = float(data["min_size_kb"]) if data.get("min_size_kb") not in (None, "") else None
+        except (TypeError, ValueError):
+            logger.warning("Ignoring invalid external_media.min_size_kb %r", data.get("min_size_kb"))
+            min_size = None
+        return cls(base_url=str(data.get("base_url") or "").strip(), url_template=template,
+                   files=[str(files)] if isinstance(files, str) else [str(f) for f in files],
+                   min_size_kb=min_size, keep_in_package=bool(data.get("keep_in_package", False)))
+
+    @classmethod
+    def resolve(cls, metadata: Optional[Mapping[str, Any]]) -> "ExternalMediaSettings":
+        """Settings of ``conversion.yml`` merged with the conversion options of *metadata*."""
+        from orlando_toolkit.core.processing import resolve_conversion_options
+        return cls.from_mapping(resolve_conversion_options(metadata).get("external_media"))
+
+    @property
+    def active(self) -> bool:
+        return bool(self.base_url)
+
+    @staticmethod
+    def validate(template: str) -> Optional[str]:
+        """Return why *template* cannot be used, or ``None`` when it is valid."""
+        try:
+            fields = [f for _, f, _, _ in string.Formatter().parse(template) if f is not None]
+        except ValueError as exc:
+            return f"Invalid template: {exc}"
+        unknown = [f for f in fields if f not in PLACEHOLDERS]
+        if unknown:
+            return f"Unknown placeholder {{{unknown[0]}}}"
+        if "name" not in fields and "stem" not in fields and "hash" not in fields:
+            return "The template must contain {name}, {stem} or {hash}"
+        return None
+
+    def url(self, name: str, data: bytes, *, kind: str = "images", manual_code: str = "") -> str:
+        stem, ext = os.path.splitext(name)
+        quote = urllib.parse.quote
+        return self.url_template.format(base_url=self.base_url.rstrip("/"), manual_code=quote(manual_code),
+                                        kind=kind, name=quote(name), stem=quote(stem), ext=ext,
+                                        hash=hashlib.sha1(data).hexdigest()[:8])
+
+    def selects(self, name: str, data: bytes, marked: Iterable[str] = ()) -> bool:
+        if name in set(marked):
+            return True
+        if any(fnmatch.fnmatch(name.lower(), pattern.lower()) for pattern in self.files):
+            return True
+        return self.min_size_kb is not None and len(data) >= self.min_size_kb * 1024
+
+
+def _marked(metadata: Mapping[str, Any]) -> List[str]:
+    value = metadata.get(METADATA_KEY)
+    return [str(name) for name in value] if isinstance(value, (list, tuple)) else []
+
+
+def is_marked_external(metadata: Mapping[str, Any], name: str) -> bool:
+    """Whether the Images tab marked the media file *name* for the asset server."""
+    return name in _marked(metadata)
+
+
+def mark_external(metadata: Dict[str, Any], name: str, external: bool = True) -> None:
+    """Mark (or unmark) the media file *name* to be referenced from the asset server."""
+    names = [n for n in _marked(metadata) if n != name]
+    if external:
+        names.append(name)
+    if names:
+        metadata[METADATA_KEY] = names
+    else:
+        metadata.pop(METADATA_KEY, None)
+
+
+def rename_marked(metadata: Dict[str, Any], renames: Mapping[str, str]) -> None:
+    """Follow image renaming in the marked file names."""
+    names = _marked(metadata)
+    if names:
+        metadata[METADATA_KEY] = [renames.get(name, name) for name in names]
+
+
+def externalize_media(context: DitaContext, settings: Optional[ExternalMediaSettings] = None) -> Dict[str, str]:
+    """Point the selected media of *context* at the asset server; returns packaged name -> URL."""
+    settings = settings or ExternalMediaSettings.resolve(context.metadata)
+    if not settings.active:
+        return {}
+    marked = _marked(context.metadata)
+    manual_code = str(context.metadata.get("manual_code") or "")
+    urls: Dict[str, str] = {}
+    stores = (("images", getattr(context, "images", None) or {}), ("videos", getattr(context, "videos", None) or {}))
+    for kind, store in stores:
+        for name, data in store.items():
+            if settings.selects(name, data, marked):
+                urls[name] = settings.url(name, data, kind=kind, manual_code=manual_code)
+    if not urls:
+        return {}
+
+    for topic in context.topics.values():
+        for tag, attribute in _REFERENCES:
+            for el in topic.iter(tag):
+                value = el.get(attribute) or ""
+                if "://" in value:
+                    continue
+                url = urls.get(posixpath.basename(value))
+                if url:
+                    el.set(attribute, url)
+                    if attribute == "href":
+                        el.set("scope", "external")
+    if not settings.keep_in_package:
+        for _kind, store in stores:
+            for name in urls:
+                store.pop(name, None)
+
+    report = getattr(context, "report", None)
+    if report is not None:
+        report.info("external_media", f"{len(urls)} media file(s) referenced from {settings.base_url}",
+                    files=sorted(urls))
+    logger.info("External media: %d file(s) referenced from %s", len(urls), settings.base_url)
+    return urls
@@ -0,0 +1,353 @@
+from __future__ import annotations
+
+"""Sandboxed execution of external tools.
+
+Stages and plugins that shell out (LibreOffice, EMF/WMF renderers, DITA-OT,
+…) go through :class:`ToolExecutor` instead of calling ``subprocess``:
+
+- every run happens inside a :class:`ToolWorkspace`, a private temporary
+  directory that is the working directory and ``HOME``/``TMPDIR`` of the
+  tool; inputs are copied in with :meth:`ToolWorkspace.add`, and an argument
+  (or the value of ``--opt=value``/``-opt:value``) that resolves outside the
+  workspace, absolute or relative (``../x``), is refused;
+- the environment is rebuilt from ``env_allowlist`` (``PATH``, locale, …)
+  plus explicit per-call variables, so credentials in the parent environment
+  never reach the tool;
+- each run has a timeout (``timeout_seconds``, overridable per call) and
+  honours a :class:`~orlando_toolkit.core.cancellation.CancellationToken`;
+  on expiry the whole process group is killed;
+- stdout/stderr are read while the tool runs and at most
+  ``max_output_bytes`` of each is kept (the start of stdout, the end of
+  stderr), so a chatty tool cannot fill the disk or memory; ``on_output``
+  receives their lines as they come (long builds such as DITA-OT);
+  ``max_memory_mb`` sets an address-space limit on POSIX.
+
+Settings come from ``security.yml`` (``external_tools``). ``tools`` maps a
+tool name to the executable to run; with ``allow_unlisted: false`` only
+listed tools can run at all.
+"""
+
+import logging
+import os
+import queue
+import shutil
+import subprocess
+import sys
+import tempfile
+import threading
+import time
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import IO, Any, Callable, Dict, Mapping, Optional, Sequence, Tuple
+
+from orlando_toolkit.core.cancellation import CancellationToken, check_cancelled
+from orlando_toolkit.core.errors import ToolkitError
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "ToolExecutionError",
+    "ToolNotFoundError",
+    "ToolTimeoutError",
+    "ToolPolicy",
+    "ToolResult",
+    "ToolWorkspace",
+    "ToolExecutor",
+]
+
+_DEFAULT_ENV = ("PATH", "LANG", "LC_ALL", "LC_CTYPE", "TZ", "SYSTEMROOT", "WINDIR", "PATHEXT", "COMSPEC")
+_POLL_SECONDS = 0.1
+_READ_CHUNK = 65536
+
+
+class ToolExecutionError(ToolkitError, RuntimeError):
+    """Raised when an external tool cannot run or (with ``check``) fails."""
+
+    code = "OTK501"
+
+
+class ToolNotFoundError(ToolExecutionError):
+    """Raised when a tool is not installed or not allowed by the policy."""
+
+
+class ToolTimeoutError(ToolExecutionError):
+    """Raised when a tool exceeds its timeout; the process group is killed."""
+
+    code = "OTK502"
+
+
+@dataclass(frozen=True)
+class ToolPolicy:
+    timeout_seconds: float = 120.0
+    env_allowlist: Tuple[str, ...] = _DEFAULT_ENV
+    tools: Mapping[str, str] = field(default_factory=dict)
+    allow_unlisted: bool = True
+    max_output_bytes: int = 1 << 20
+    max_memory_mb: int = 0
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "ToolPolicy":
+        data = data or {}
+        defaults = cls()
+        try:
+            return cls(
+                timeout_seconds=float(data.get("timeout_seconds", defaults.timeout_seconds)),
+                env_allowlist=tuple(str(v) for v in data.get("env_allowlist", defaults.env_allowlist) or ()),
+                tools={str(k): str(v) for k, v in (data.get("tools") or {}).items() if v},
+                allow_unlisted=bool(data.get("allow_unlisted", True)),
+                max_output_bytes=int(data.get("max_output_bytes", defaults.max_output_bytes)),
+                max_memory_mb=int(data.get("max_memory_mb", 0) or 0),
+            )
+        except (TypeError, ValueError) as exc:
+            logger.warning("Invalid external_tools settings (%s); using defaults", exc)
+            return defaults
+
+    @classmethod
+    def from_config(cls) -> "ToolPolicy":
+        try:
+            from orlando_toolkit.config import ConfigManager
+            return cls.from_mapping((ConfigManager().get_security_config() or {}).get("external_tools"))
+        except Exception as exc:
+            logger.debug("Security config unavailable, using default tool policy: %s", exc)
+            return cls()
+
+
+@dataclass(frozen=True)
+class ToolResult:
+    tool: str
+    returncode: int
+    stdout: str
+    stderr: str
+    duration: float
+    truncated: bool = False
+
+    @property
+    def ok(self) -> bool:
+        return self.returncode == 0
+
+
+class ToolWorkspace:
+    """Private working directory for one or more tool runs; removed on exit."""
+
+    def __init__(self, prefix: str = "otk_tool_") -> None:
+        self.path = Path(tempfile.mkdtemp(prefix=prefix)).resolve()
+        for name in ("home", "tmp"):
+            (self.path / name).mkdir()
+
+    def add(self, source: str | Path, name: Optional[str] = None) -> str:
+        """Copy *source* into the workspace and return its relative name."""
+        source = Path(source)
+        target = self.path / (name or source.name)
+        if self.path not in target.resolve().parents:
+            raise ToolExecutionError(f"Workspace name escapes the workspace: {name}")
+        target.parent.mkdir(parents=True, exist_ok=True)
+        if source.is_dir():
+            shutil.copytree(source, target)
+        else:
+            shutil.copyfile(source, target)
+        return target.relative_to(self.path).as_posix()
+
+    def contains(self, path: str | Path) -> bool:
+        """Whether *path*, relative paths taken from the workspace, lies inside it."""
+        try:
+            resolved = (self.path / path).resolve()
+        except (OSError, ValueError):
+            return False
+        return resolved == self.path or self.path in resolved.parents
+
+    def close(self) -> None:
+        shutil.rmtree(self.path, ignore_errors=True)
+
+    def __enter__(self) -> "ToolWorkspace":
+        return self
+
+    def __exit__(self, *exc: Any) -> None:
+        self.close()
+
+
+def _limit_memory(megabytes: int):
+    def _apply() -> None:  # pragma: no cover - runs in the child
+        import resource
+
+        limit = megabytes * 1024 * 1024
+        resource.setrlimit(resource.RLIMIT_AS, (limit, limit))
+    return _apply
+
+
+def _kill_group(proc: subprocess.Popen) -> None:
+    try:
+        if os.name == "posix":
+            os.killpg(proc.pid, 9)
+        else:
+            proc.kill()
+    except (ProcessLookupError, PermissionError, OSError):
+        pass
+    try:
+        proc.wait(timeout=5)
+    except subprocess.TimeoutExpired:
+        logger.warning("Tool process %s did not exit after kill", proc.pid)
+
+
+class _CappedReader(threading.Thread):
+    """Drains one output pipe of the tool, keeping at most *cap* bytes of it.
+
+    ``keep_tail`` keeps the last bytes instead of the first (stderr, where the
+    error is). Complete lines go to *lines* when given; a line longer than the
+    cap is passed on in pieces.
+    """
+
+    def __init__(self, pipe: IO[bytes], cap: int, keep_tail: bool,
+                 lines: Optional["queue.Queue[str]"]) -> None:
+        super().__init__(daemon=True)
+        self._pipe, self._cap, self._keep_tail, self._lines = pipe, cap, keep_tail, lines
+        self._partial = b""
+        self.data = bytearray()
+        self.truncated = False
+
+    def run(self) -> None:
+        try:
+            while True:
+                chunk = os.read(self._pipe.fileno(), _READ_CHUNK)
+                if not chunk:
+                    break
+                self._keep(chunk)
+                if self._lines is not None:
+                    self._split(chunk)
+        except OSError:
+            pass
+        finally:
+            if self._lines is not None and self._partial:
+                self._lines.put(self._partial.decode("utf-8", "replace").rstrip("\r"))
+            self._pipe.close()
+
+    def _keep(self, chunk: bytes) -> None:
+        if self._keep_tail:
+            self.data += chunk
+            if len(self.data) > self._cap:
+                del self.data[:len(self.data) - self._cap]
+                self.truncated = True
+        else:
+            room = self._cap - len(self.data)
+            self.data += chunk[:max(room, 0)]
+            self.truncated = self.truncated or len(chunk) > room
+
+    def _split(self, chunk: bytes) -> None:
+        *complete, self._partial = (self._partial + chunk).split(b"\n")
+        if len(self._partial) > self._cap:
+            complete.append(self._partial)
+            self._partial = b""
+        for line in complete:
+            self._lines.put(line.decode("utf-8", "replace").rstrip("\r"))
+
+
+def _drain(lines: "queue.Queue[str]", on_output: Callable[[str], None]) -> None:
+    while True:
+        try:
+            on_output(lines.get_nowait())
+        except queue.Empty:
+            return
+
+
+class ToolExecutor:
+    """Runs external tools under a :class:`ToolPolicy`."""
+
+    def __init__(self, policy: Optional[ToolPolicy] = None) -> None:
+        self.policy = policy or ToolPolicy.from_config()
+
+    def workspace(self) -> ToolWorkspace:
+        return ToolWorkspace()
+
+    def resolve(self, tool: str) -> str:
+        """Return the executable for *tool*; raises :class:`ToolNotFoundError`."""
+        configured = self.policy.tools.get(tool)
+        if configured is None and not self.policy.allow_unlisted:
+            raise ToolNotFoundError(f"Tool {tool!r} is not allowed (security.yml external_tools.tools)")
+        executable = shutil.which(configured or tool)
+        if executable is None:
+            raise ToolNotFoundError(f"Tool {tool!r} not found ({configured or 'on PATH'})")
+        return executable
+
+    def _environment(self, workspace: ToolWorkspace, extra: Optional[Mapping[str, str]]) -> Dict[str, str]:
+        env = {k: os.environ[k] for k in self.policy.env_allowlist if k in os.environ}
+        home, tmp = str(workspace.path / "home"), str(workspace.path / "tmp")
+        env.update({"HOME": home, "USERPROFILE": home, "TMPDIR": tmp, "TEMP": tmp, "TMP": tmp})
+        env.update({str(k): str(v) for k, v in (extra or {}).items()})
+        return env
+
+    def _check_arguments(self, workspace: ToolWorkspace, args: Sequence[str]) -> None:
+        # Any argument may be a path to the tool: each one, and the value of
+        # ``--opt=value`` or ``-opt:value``, must resolve inside the workspace.
+        # Plain words (``-v``, ``html5``) resolve inside it and pass.
+        for arg in args:
+            parts = {arg, arg.split("=", 1)[-1]}
+            if arg.startswith("-"):
+                parts.add(arg.split(":", 1)[-1])
+            if not all(workspace.contains(part) for part in parts if part):
+                raise ToolExecutionError(f"Argument outside the tool workspace: {arg}")
+
+    def run(self, tool: str, args: Sequence[str], *, workspace: ToolWorkspace,
+            timeout: Optional[float] = None, env: Optional[Mapping[str, str]] = None,
+            cancel_token: Optional[CancellationToken] = None, check: bool = False,
+            on_output: Optional[Callable[[str], None]] = None) -> ToolResult:
+        """Run *tool* with *args* inside *workspace* and return its result.
+
+        *on_output* is called with each stdout/stderr line as the tool writes it.
+
+        Raises :class:`ToolNotFoundError`, :class:`ToolTimeoutError`,
+        ``OperationCancelledError``, or :class:`ToolExecutionError` when
+        *check* is set and the tool exits non-zero.
+        """
+        executable = self.resolve(tool)
+        args = [str(a) for a in args]
+        self._check_arguments(workspace, args)
+        limit = self.policy.timeout_seconds if timeout is None else float(timeout)
+
+        kwargs: Dict[str, Any] = {}
+        if os.name == "posix":
+            kwargs["start_new_session"] = True
+            if self.policy.max_memory_mb > 0:
+                kwargs["preexec_fn"] = _limit_memory(self.policy.max_memory_mb)
+        elif sys.platform == "win32":
+            kwargs["creationflags"] = subprocess.CREATE_NEW_PROCESS_GROUP
+
+        started = time.monotonic()
+        try:
+            proc = subprocess.Popen([executable, *args], cwd=str(workspace.path), stdin=subprocess.DEVNULL,
+                                    stdout=subprocess.PIPE, stderr=subprocess.PIPE,
+                                    env=self._environment(workspace, env), shell=False, **kwargs)
+        except OSError as exc:
+            raise ToolExecutionError(f"Could not start {tool}: {exc}") from exc
+        cap = self.policy.max_output_bytes
+        lines: Optional["queue.Queue[str]"] = queue.Queue() if on_output is not None else None
+        readers = (_CappedReader(proc.stdout, cap, False, lines), _CappedReader(proc.stderr, cap, True, lines))
+        for reader in readers:
+            reader.start()
+        while proc.poll() is None:
+            if cancel_token is not None and cancel_token.is_cancelled:
+                _kill_group(proc)
+                check_cancelled(cancel_token)
+            if time.monotonic() - started > limit:
+                _kill_group(proc)
+                raise ToolTimeoutError(f"{tool} exceeded its timeout of {limit:g}s and was stopped")
+            if lines is not None:
+                _drain(lines, on_output)
+            if cancel_token is not None:
+                cancel_token.wait(_POLL_SECONDS)
+            else:
+                time.sleep(_POLL_SECONDS)
+        for reader in readers:
+            # A background child of the tool may still hold the pipe open.
+            reader.join(timeout=5)
+        if lines is not None:
+            _drain(lines, on_output)
+        duration = time.monotonic() - started
+
+        stdout, stderr = readers
+        result = ToolResult(tool, proc.returncode, bytes(stdout.data).decode("utf-8", "replace"),
+                            bytes(stderr.data).decode("utf-8", "replace"), duration,
+                            stdout.truncated or stderr.truncated)
+        logger.debug("%s exited with %s after %.2fs", tool, proc.returncode, duration)
+        if check and not result.ok:
+            tail = result.stderr.strip().splitlines()[-5:]
+            raise ToolExecutionError(f"{tool} exited with code {proc.returncode}: {' | '.join(tail)}")
+        return result
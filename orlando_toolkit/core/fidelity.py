@@ -0,0 +1,386 @@
+from __future__ import annotations
+
+"""Content coverage (fidelity) report: what of the Word source reached the topics.
+
+Auditors ask for proof that nothing was lost in conversion.
+:func:`fidelity_report` reads the source (``metadata["source_file"]``) again
+and counts, in the source and in the topics:
+
+- paragraphs: body and text box paragraphs with text outside tables; in
+  the output, the innermost text blocks outside tables, topic titles and
+  section titles of the map;
+- tables (``table``, ``simpletable``, ``properties``);
+- images (pictures and legacy VML images, not OLE object previews);
+- footnotes and endnotes (``fn``);
+- equations (``equation-inline``, ``equation-block``).
+
+Every source construct missing from the output is listed with its Word
+paragraph number (counted like the conversion report,
+:mod:`orlando_toolkit.core.diagnostics`) and the topic it belongs to:
+
+- a paragraph is ``dropped`` when its text (case and spacing ignored) is in
+  no topic or map title;
+- tables, images, notes and equations are attributed to the topic of the
+  nearest paragraph before them found in the output (by text, in map
+  order, :mod:`orlando_toolkit.core.placement`). When a topic has fewer of
+  a kind than its source section, the surplus is listed, items whose text
+  is missing from the output first: ``unconverted`` when the text is in the
+  output (a table flattened to paragraphs, an equation printed as text),
+  else ``dropped``;
+- content left out as front matter (``front_matter`` report entries) is
+  listed as ``excluded`` and not counted.
+
+The :class:`FidelityReport` renders as HTML (:meth:`~FidelityReport.to_html`)
+or as CSV rows (:meth:`~FidelityReport.to_csv`) for a spreadsheet.
+"""
+
+import bisect
+import csv
+import html
+import io
+import json
+import logging
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional, Set, Tuple
+
+from orlando_toolkit.core.footnotes import _read_notes
+from orlando_toolkit.core.front_matter import _paragraphs
+from orlando_toolkit.core.placement import BLOCK_TAGS, block_text, find_block, normalize, text_blocks, topic_order
+from orlando_toolkit.core.word_numbering import _owned, _paragraph_text
+from orlando_toolkit.core.word_source import DOCUMENT, WordSource, w
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["CSV_COLUMNS", "FidelityItem", "FidelityReport", "FidelityUnavailable", "KINDS", "fidelity_report"]
+
+KINDS = ("paragraph", "table", "image", "footnote", "equation")
+CSV_COLUMNS = ("kind", "status", "paragraph", "topic", "text")
+
+_M = "http://schemas.openxmlformats.org/officeDocument/2006/math"
+_PIC = "http://schemas.openxmlformats.org/drawingml/2006/picture"
+_WP = "http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing"
+_V = "urn:schemas-microsoft-com:vml"
+_MC = "http://schemas.openxmlformats.org/markup-compatibility/2006"
+_OUTPUT_TAGS = {"table": ("table", "simpletable", "properties"), "image": ("image",), "footnote": ("fn",),
+                "equation": ("equation-inline", "equation-block")}
+_TABLE_TAGS = frozenset(_OUTPUT_TAGS["table"])
+_PREVIEW_CHARS = 120
+
+
+class FidelityUnavailable(ValueError):
+    """The report cannot be made for this session; the message says why."""
+
+
+@dataclass(frozen=True)
+class FidelityItem:
+    """A source construct that did not reach the output as such."""
+
+    kind: str               # one of KINDS
+    paragraph: int          # 1-based Word paragraph number (first paragraph of a table)
+    status: str             # dropped | unconverted | excluded
+    text: str = ""
+    topic: str = ""         # topic file the construct belongs to, when known
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {"kind": self.kind, "paragraph": self.paragraph, "status": self.status, "text": self.text,
+                "topic": self.topic}
+
+
+@dataclass
+class FidelityReport:
+    source: str = ""
+    counts: Dict[str, Tuple[int, int]] = field(default_factory=dict)   # kind -> (source, output)
+    items: List[FidelityItem] = field(default_factory=list)
+
+    def missing(self) -> List[FidelityItem]:
+        return [i for i in self.items if i.status != "excluded"]
+
+    def summary(self) -> str:
+        counts = ", ".join(f"{kind}s {source} → {output}" for kind, (source, output) in self.counts.items())
+        statuses = [(s, sum(1 for i in self.items if i.status == s)) for s in ("dropped", "unconverted", "excluded")]
+        return f"{counts}; " + ", ".join(f"{n} {s}" for s, n in statuses)
+
+    def rows(self) -> List[Tuple[str, str, int, str, str]]:
+        """``(kind, status, paragraph, topic, text)`` per listed construct, in source order."""
+        return [(i.kind, i.status, i.paragraph, i.topic, i.text) for i in self.items]
+
+    def to_csv(self) -> str:
+        buffer = io.StringIO()
+        writer = csv.writer(buffer, lineterminator="\n")
+        writer.writerow(CSV_COLUMNS)
+        writer.writerows(self.rows())
+        return buffer.getvalue()
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {"source": self.source,
+                "counts": {kind: {"source": s, "output": o} for kind, (s, o) in self.counts.items()},
+                "items": [i.to_dict() for i in self.items]}
+
+    def to_json(self, **kwargs: Any) -> str:
+        kwargs.setdefault("ensure_ascii", False)
+        kwargs.setdefault("indent", 2)
+        return json.dumps(self.to_dict(), **kwargs)
+
+    def to_html(self) -> str:
+        esc = html.escape
+        parts = [
+            "<!DOCTYPE html>",
+            "<html><head><meta charset='utf-8'><title>Fidelity report</title><style>",
+            "body{font-family:sans-serif;margin:2em;max-width:60em}"
+            "table{border-collapse:collapse;margin-bottom:1.5em}"
+            "td,th{border:1px solid #ccc;padding:2px 8px;text-align:left;vertical-align:top}"
+            ".dropped{border-left:4px solid #c22}.unconverted{border-left:4px solid #e90}"
+            ".excluded{border-left:4px solid #999;color:#666}.short{color:#c22}",
+            "</style></head><body>",
+            f"<h1>Fidelity report</h1><p>{esc(self.source)}</p>",
+            f"<p>{esc(self.summary())}</p>",
+            "<h2>Counts</h2><table><tr><th>Construct</th><th>Source</th><th>Output</th></tr>",
+        ]
+        parts.extend(f"<tr{' class=short' if o < s else ''}><td>{esc(kind)}</td><td>{s}</td><td>{o}</td></tr>"
+                     for kind, (s, o) in self.counts.items())
+        parts.append("</table>")
+        if self.items:
+            parts.append("<h2>Constructs not converted</h2><table><tr><th>Paragraph</th><th>Construct</th>"
+                         "<th>Status</th><th>Topic</th><th>Text</th></tr>")
+            parts.extend(f"<tr class='{esc(i.status)}'><td>{i.paragraph}</td><td>{esc(i.kind)}</td>"
+                         f"<td>{esc(i.status)}</td><td>{esc(i.topic)}</td><td>{esc(i.text)}</td></tr>"
+                         for i in self.items)
+            parts.append("</table>")
+        else:
+            parts.append("<p>Every counted construct of the source is in the output.</p>")
+        parts.append("</body></html>")
+        return "\n".join(parts)
+
+
+# ----------------------------------------------------------------------
+# Source
+# ----------------------------------------------------------------------
+
+@dataclass
+class _Construct:
+    kind: str
+    paragraph: int
+    text: str = ""
+    locate: str = ""        # text of the body paragraph holding it, to find its topic
+    excluded: bool = False
+    key: str = ""           # text compared with the output (empty: not compared)
+
+
+def _key(text: str) -> str:
+    return "".join((text or "").split()).casefold()
+
+
+def _preview(text: str) -> str:
+    text = normalize(text)
+    return text if len(text) <= _PREVIEW_CHARS else text[:_PREVIEW_CHARS] + "…"
+
+
+def _in_fallback(el: Any, stop: Any) -> bool:
+    """Whether *el* is in an ``mc:Fallback`` (a copy of the ``mc:Choice`` content) below *stop*."""
+    node = el.getparent()
+    while node is not None and node is not stop:
+        if node.tag == f"{{{_MC}}}Fallback":
+            return True
+        node = node.getparent()
+    return False
+
+
+def _has_ancestor(el: Any, tag: str, stop: Any) -> bool:
+    node = el.getparent()
+    while node is not None and node is not stop:
+        if node.tag == tag:
+            return True
+        node = node.getparent()
+    return False
+
+
+def _math_text(el: Any) -> str:
+    return normalize("".join(t.text or "" for t in el.iter(f"{{{_M}}}t")))
+
+
+def _owned_constructs(paragraph: Any, number: int, locate: str, notes: Mapping[Tuple[str, str], Any]
+                      ) -> List[_Construct]:
+    """Images, notes and equations of *paragraph* itself, in document order."""
+    found: List[_Construct] = []
+    for el in paragraph.iter():
+        if not isinstance(el.tag, str) or not _owned(el, paragraph) or _in_fallback(el, paragraph):
+            continue
+        if el.tag == f"{{{_PIC}}}pic" or (el.tag == f"{{{_V}}}imagedata"
+                                           and not _has_ancestor(el, w("object"), paragraph)):
+            drawing = next((a for a in el.iterancestors(f"{{{_WP}}}inline", f"{{{_WP}}}anchor")), None)
+            doc_pr = drawing.find(f"{{{_WP}}}docPr") if drawing is not None else None
+            label = (doc_pr.get("descr") or doc_pr.get("name") or "") if doc_pr is not None else ""
+            found.append(_Construct("image", number, label, locate))
+        elif el.tag in (w("footnoteReference"), w("endnoteReference")):
+            kind = "footnote" if el.tag == w("footnoteReference") else "endnote"
+            note = notes.get((kind, el.get(w("id")) or ""))
+            text = normalize(" ".join(t for runs in note.paragraphs for t, _ in runs)) if note else ""
+            found.append(_Construct("footnote", number, text, locate, key=_key(text)))
+        elif el.tag == f"{{{_M}}}oMathPara" or (
+                el.tag == f"{{{_M}}}oMath" and not any(a.tag in (f"{{{_M}}}oMath", f"{{{_M}}}oMathPara")
+                                                       for a in el.iterancestors())):
+            text = _math_text(el)
+            found.append(_Construct("equation", number, text, locate, key=_key(text)))
+    return found
+
+
+def _source_constructs(document: Any, notes: Mapping[Tuple[str, str], Any], skipped: Set[int]) -> List[_Construct]:
+    body = document.find(w("body"))
+    constructs: List[_Construct] = []
+    number = 0
+    for block in list(body) if body is not None else ():
+        if block.tag not in (w("p"), w("tbl"), w("sdt")):
+            continue
+        paragraphs = _paragraphs(block)
+        first = number + 1
+        number += len(paragraphs)
+        start = len(constructs)
+        numbers = {id(p): first + offset for offset, p in enumerate(paragraphs)}
+        tables = [block] if block.tag == w("tbl") else [
+            t for t in block.iter(w("tbl")) if not _has_ancestor(t, w("tbl"), block)
+            and not _has_ancestor(t, w("p"), block)]
+        for table in tables:
+            cells = [p for p in paragraphs if p is table or table in p.iterancestors()]
+            text = " ".join(_paragraph_text(p) for p in cells)
+            constructs.append(_Construct("table", numbers[id(cells[0])] if cells else first, _preview(text),
+                                         key=_key(text)))
+        for paragraph in paragraphs:
+            paragraph_number = numbers[id(paragraph)]
+            locate = _paragraph_text(paragraph)
+            in_table = block.tag == w("tbl") or _has_ancestor(paragraph, w("tbl"), block)
+            if locate and not in_table:
+                constructs.append(_Construct("paragraph", paragraph_number, _preview(locate), locate,
+                                             key=_key(locate)))
+            constructs.extend(_owned_constructs(paragraph, paragraph_number, locate, notes))
+            for boxed in paragraph.iter(w("p")):
+                if boxed is paragraph or _in_fallback(boxed, paragraph):
+                    continue
+                text = _paragraph_text(boxed)
+                if text:
+                    constructs.append(_Construct("paragraph", paragraph_number, _preview(text), key=_key(text)))
+                constructs.extend(_owned_constructs(boxed, paragraph_number, locate, notes))
+        if first in skipped:
+            for construct in constructs[start:]:
+                construct.excluded = True
+    return constructs
+
+
+def _skipped_paragraphs(context: Any) -> Set[int]:
+    report = getattr(context, "report", None)
+    entries = getattr(report, "entries", None) or []
+    return {n for entry in entries if entry.category == "front_matter" for n in entry.paragraphs}
+
+
+# ----------------------------------------------------------------------
+# Output
+# ----------------------------------------------------------------------
+
+def _in_table(el: Any) -> bool:
+    return any(isinstance(a.tag, str) and a.tag in _TABLE_TAGS for a in el.iterancestors())
+
+
+def _output_counts(context: Any) -> Dict[str, Dict[str, int]]:
+    """Count of each kind per topic file (``""`` for the map's section titles)."""
+    counts: Dict[str, Dict[str, int]] = {kind: {} for kind in KINDS}
+    for name in topic_order(context):
+        for el in context.topics[name].iter():
+            if not isinstance(el.tag, str):
+                continue
+            for kind, tags in _OUTPUT_TAGS.items():
+                if el.tag in tags:
+                    counts[kind][name] = counts[kind].get(name, 0) + 1
+            if (el.tag in BLOCK_TAGS and block_text(el) and not _in_table(el)
+                    and not any(isinstance(c.tag, str) and c.tag in BLOCK_TAGS for c in el.iter() if c is not el)):
+                counts["paragraph"][name] = counts["paragraph"].get(name, 0) + 1
+    root = getattr(context, "ditamap_root", None)
+    if root is not None:
+        heads = sum(1 for head in root.iter("topichead") if normalize(head.findtext("topicmeta/navtitle") or ""))
+        if heads:
+            counts["paragraph"][""] = heads
+    return counts
+
+
+def _corpus(context: Any) -> str:
+    texts = [_key("".join(topic.itertext())) for topic in context.topics.values()]
+    root = getattr(context, "ditamap_root", None)
+    if root is not None:
+        texts.extend(_key(el.text or "") for el in root.iter("navtitle"))
+    return "\x00".join(texts)
+
+
+def _attribute(constructs: List[_Construct], context: Any) -> List[str]:
+    """Topic file of each construct: that of the nearest located body paragraph before it."""
+    blocks = text_blocks(context)
+    located: Dict[int, str] = {}
+    cursor = 0
+    for construct in constructs:
+        if construct.kind == "paragraph" and construct.locate and construct.paragraph not in located:
+            hit, cursor = find_block(blocks, construct.locate, cursor)
+            if hit is not None:
+                located[construct.paragraph] = blocks[hit][0]
+    numbers = sorted(located)
+    order = topic_order(context)
+    default = order[0] if order else ""
+    topics: List[str] = []
+    for construct in constructs:
+        position = bisect.bisect_right(numbers, construct.paragraph)
+        topics.append(located[numbers[position - 1]] if position else default)
+    return topics
+
+
+# ----------------------------------------------------------------------
+# Report
+# ----------------------------------------------------------------------
+
+def fidelity_report(context: Any, source: Optional[str | Path] = None) -> FidelityReport:
+    """Compare *context*'s topics with its Word source (default ``metadata["source_file"]``)."""
+    metadata = getattr(context, "metadata", None) or {}
+    source = source or metadata.get("source_file")
+    if not source:
+        raise FidelityUnavailable("The session has no Word source to compare with.")
+    path = Path(source)
+    if path.suffix.lower() != ".docx":
+        raise FidelityUnavailable(f"The fidelity report compares with a Word .docx source, not {path.name}.")
+    if not path.is_file():
+        raise FidelityUnavailable(f"The Word source {path} cannot be found.")
+    docx = WordSource.of(path)
+    if docx is None or DOCUMENT not in docx:
+        raise FidelityUnavailable(f"{path.name} cannot be read as a Word document.")
+    document = docx.document
+    notes = {**_read_notes(docx, "footnote", "footnotes"), **_read_notes(docx, "endnote", "endnotes")}
+
+    constructs = _source_constructs(document, notes, _skipped_paragraphs(context))
+    topics = _attribute(constructs, context)
+    output = _output_counts(context)
+    corpus = _corpus(context)
+
+    def _found(construct: _Construct) -> bool:
+        return bool(construct.key) and construct.key in corpus
+
+    statuses: Dict[int, str] = {}
+    grouped: Dict[Tuple[str, str], List[int]] = {}
+    for index, construct in enumerate(constructs):
+        if construct.excluded:
+            statuses[index] = "excluded"
+        elif construct.kind == "paragraph":
+            if not _found(construct):
+                statuses[index] = "dropped"
+        else:
+            grouped.setdefault((construct.kind, topics[index]), []).append(index)
+    for (kind, topic), indexes in grouped.items():
+        surplus = len(indexes) - output[kind].get(topic, 0)
+        if surplus <= 0:
+            continue
+        for index in sorted(indexes, key=lambda i: (_found(constructs[i]), -i))[:surplus]:
+            statuses[index] = "unconverted" if _found(constructs[index]) else "dropped"
+
+    report = FidelityReport(source=str(path))
+    for kind in KINDS:
+        counted = sum(1 for c in constructs if c.kind == kind and not c.excluded)
+        report.counts[kind] = (counted, sum(output[kind].values()))
+    report.items = [FidelityItem(constructs[i].kind, constructs[i].paragraph, statuses[i], constructs[i].text,
+                                 topics[i] if statuses[i] != "excluded" else "")
+                    for i in sorted(statuses)]
+    logger.info("Fidelity report for %s: %s", path.name, report.summary())
+    return report
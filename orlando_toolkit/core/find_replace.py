@@ -0,0 +1,168 @@
+from __future__ import annotations
+
+"""Project-wide find and replace in topic text.
+
+:func:`find_matches` lists the topics whose text contains the search term,
+with the number of matches and a few snippets for review;
+:func:`replace_text` replaces them. Both work on text nodes only (element
+text and tails): tags, attributes, ids and conversion hints are never
+touched, so a term split by inline markup (``Acme <b>Pro</b>``) is not
+found. The term is a literal string or, with ``regex``, a Python regular
+expression whose replacement may use ``\\1`` or ``\\g<name>`` groups;
+matching ignores case unless ``case_sensitive`` is set. The replacement
+may also be a function of the match (the terminology checker keeps the
+capitalisation of the term it replaces that way).
+
+Navigation titles of the map entries pointing to a changed topic are
+updated the same way so the tree follows renamed titles.
+"""
+
+import logging
+import re
+from dataclasses import dataclass, field
+from typing import Callable, Dict, Iterable, Iterator, List, Optional, Pattern, Tuple, Union
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.processing.text import is_element
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["ReplaceHit", "compile_pattern", "find_matches", "replace_text"]
+
+Replacement = Union[str, Callable[[re.Match], str]]
+
+_SNIPPET_CONTEXT = 30
+_MAX_SNIPPETS = 3
+
+
+@dataclass
+class ReplaceHit:
+    """Matches of the term in one topic: :attr:`count` and up to three :attr:`snippets`."""
+
+    topic: str
+    title: str
+    count: int = 0
+    snippets: List[str] = field(default_factory=list)
+
+
+def compile_pattern(find: str, *, regex: bool = False, case_sensitive: bool = False) -> Pattern[str]:
+    """Pattern for *find*; raises ValueError for an empty term or an invalid expression."""
+    if not find:
+        raise ValueError("Nothing to find.")
+    flags = 0 if case_sensitive else re.IGNORECASE
+    try:
+        return re.compile(find if regex else re.escape(find), flags)
+    except re.error as exc:
+        raise ValueError(f"Invalid pattern: {exc}") from exc
+
+
+def _text_slots(root: ET.Element) -> Iterator[Tuple[ET.Element, str]]:
+    """(node, "text" | "tail") for every text node below *root*; comments only contribute their tail."""
+    for node in root.iter():
+        if is_element(node) and node.text:
+            yield node, "text"
+        if node is not root and node.tail:
+            yield node, "tail"
+
+
+def _snippet(text: str, match: re.Match) -> str:
+    start, end = max(0, match.start() - _SNIPPET_CONTEXT), match.end() + _SNIPPET_CONTEXT
+    snippet = " ".join(text[start:end].split())
+    return ("…" if start else "") + snippet + ("…" if end < len(text) else "")
+
+
+def _topic_title(topic: ET.Element) -> str:
+    title = topic.find("title")
+    return " ".join("".join(title.itertext()).split()) if title is not None else ""
+
+
+def find_matches(
+    context: DitaContext, find: str, *, regex: bool = False, case_sensitive: bool = False
+) -> List[ReplaceHit]:
+    """Topics containing *find*, in map order (topics outside the map last)."""
+    pattern = compile_pattern(find, regex=regex, case_sensitive=case_sensitive)
+    hits: List[ReplaceHit] = []
+    for name in _topic_order(context):
+        topic = context.topics[name]
+        hit = ReplaceHit(topic=name, title=_topic_title(topic))
+        for node, slot in _text_slots(topic):
+            text = getattr(node, slot)
+            for match in pattern.finditer(text):
+                hit.count += 1
+                if len(hit.snippets) < _MAX_SNIPPETS:
+                    hit.snippets.append(_snippet(text, match))
+        if hit.count:
+            hits.append(hit)
+    return hits
+
+
+def _topic_order(context: DitaContext) -> List[str]:
+    names: List[str] = []
+    root = getattr(context, "ditamap_root", None)
+    for tref in (root.iter("topicref") if root is not None else ()):
+        name = (tref.get("href") or "").split("/")[-1]
+        if name in context.topics and name not in names:
+            names.append(name)
+    return names + [name for name in context.topics if name not in names]
+
+
+def _substitute(root: ET.Element, pattern: Pattern[str], replacement: Replacement, regex: bool) -> int:
+    count = 0
+    for node, slot in list(_text_slots(root)):
+        try:
+            if regex or callable(replacement):
+                text, n = pattern.subn(replacement, getattr(node, slot))
+            else:
+                text, n = pattern.subn(lambda _m: replacement, getattr(node, slot))
+        except (re.error, IndexError) as exc:
+            raise ValueError(f"Invalid replacement: {exc}") from exc
+        if n:
+            setattr(node, slot, text)
+            count += n
+    return count
+
+
+def _check_template(context: DitaContext, names: List[str], pattern: Pattern[str], replacement: str) -> None:
+    """Expand *replacement* on the first match so a bad group reference fails before anything changes."""
+    for name in names:
+        for node, slot in _text_slots(context.topics[name]):
+            match = pattern.search(getattr(node, slot))
+            if match is None:
+                continue
+            try:
+                match.expand(replacement)
+            except (re.error, IndexError) as exc:
+                raise ValueError(f"Invalid replacement: {exc}") from exc
+            return
+
+
+def replace_text(
+    context: DitaContext,
+    find: str,
+    replacement: Replacement,
+    *,
+    regex: bool = False,
+    case_sensitive: bool = False,
+    topics: Optional[Iterable[str]] = None,
+) -> Dict[str, int]:
+    """Replace *find* in the given topics (all by default); returns the replacements per changed topic."""
+    pattern = compile_pattern(find, regex=regex, case_sensitive=case_sensitive)
+    wanted = set(topics) if topics is not None else None
+    names = [name for name in _topic_order(context) if wanted is None or name in wanted]
+    if regex and not callable(replacement):
+        _check_template(context, names, pattern, replacement or "")
+    changed: Dict[str, int] = {}
+    for name in names:
+        count = _substitute(context.topics[name], pattern, replacement or "", regex)
+        if count:
+            changed[name] = count
+
+    root = getattr(context, "ditamap_root", None)
+    for tref in (root.iter("topicref") if root is not None and changed else ()):
+        navtitle = tref.find("topicmeta/navtitle")
+        if navtitle is not None and (tref.get("href") or "").split("/")[-1] in changed:
+            _substitute(navtitle, pattern, replacement or "", regex)
+    logger.info("Find/replace: %d replacement(s) in %d topic(s)", sum(changed.values()), len(changed))
+    return changed
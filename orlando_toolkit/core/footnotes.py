@@ -0,0 +1,356 @@
+from __future__ import annotations
+
+"""Restore Word footnotes and endnotes as DITA ``<fn>`` elements.
+
+Converters that drop Word's notes lose legal and safety annotations. After
+the plugin handler returns, :func:`restore_word_notes` reads
+``word/footnotes.xml`` and ``word/endnotes.xml`` of the source and puts each
+note back at its reference point:
+
+- a converter may mark the reference with an empty
+  ``<ph data-footnote="ID"/>`` (``data-endnote`` for endnotes, ``ID`` the
+  Word ``w:id``); the placeholder is replaced by the note;
+- otherwise the Word paragraph holding the reference is located in the
+  topics by its text (in map order, whitespace-insensitively) and the note
+  is inserted after the same number of characters;
+- a custom reference mark (``*``, ``†``) becomes ``@callout``;
+- a ``NOTEREF`` field (Word's cross-reference to a note) becomes
+  ``<xref type="fn">`` to the note's id, so the note is printed once and
+  cited everywhere it is referenced.
+
+Endnotes become ``<fn outputclass="endnote">`` so publishing can collect
+them (``endnotes: drop`` removes them). Notes that cannot be placed, and
+documents whose topics already contain ``<fn>``, are reported under
+``footnotes``; the ``footnotes`` section of ``conversion.yml`` controls the
+pass.
+"""
+
+import logging
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.placement import find_block, innermost_block, insert_at, normalize, offset_of, \
+    text_blocks, topic_order
+from orlando_toolkit.core.word_source import WordSource, w
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["NOTE_HINTS", "NoteReference", "WordNote", "WordNotes", "read_word_notes", "restore_word_notes"]
+
+NOTE_HINTS = {"footnote": "data-footnote", "endnote": "data-endnote"}
+_SEPARATORS = ("separator", "continuationSeparator", "continuationNotice")
+_ENDNOTES = ("fn", "drop")
+
+
+@dataclass
+class WordNote:
+    kind: str                                   # footnote | endnote
+    id: str
+    paragraphs: List[List[Tuple[str, str]]] = field(default_factory=list)   # (text, "b"/"i"/"")
+
+    @property
+    def key(self) -> Tuple[str, str]:
+        return self.kind, self.id
+
+
+@dataclass
+class NoteReference:
+    """A note cited in a body paragraph, *offset* characters into its normalized text."""
+
+    kind: str
+    id: str
+    offset: int
+    mark: str = ""              # custom reference mark typed after the reference
+    cross_reference: bool = False
+
+
+@dataclass
+class _Paragraph:
+    text: str = ""
+    references: List[NoteReference] = field(default_factory=list)
+    noterefs: List[Tuple[str, int, str]] = field(default_factory=list)   # (bookmark, offset, shown text)
+
+
+@dataclass
+class WordNotes:
+    notes: Dict[Tuple[str, str], WordNote] = field(default_factory=dict)
+    paragraphs: List[Tuple[str, List[NoteReference]]] = field(default_factory=list)
+
+    def __bool__(self) -> bool:
+        return bool(self.notes)
+
+
+def _read_notes(docx: WordSource, kind: str, name: str) -> Dict[Tuple[str, str], WordNote]:
+    root = docx.part(f"word/{name}.xml")
+    if root is None:
+        return {}
+    notes: Dict[Tuple[str, str], WordNote] = {}
+    for element in root.findall(w(kind)):
+        if element.get(w("type")) in _SEPARATORS:
+            continue
+        note = WordNote(kind, element.get(w("id")) or "")
+        for paragraph in element.iter(w("p")):
+            runs: List[Tuple[str, str]] = []
+            for run in paragraph.iter(w("r")):
+                if run.find(w("footnoteRef")) is not None or run.find(w("endnoteRef")) is not None:
+                    continue
+                text = "".join(t.text or "" for t in run if t.tag == w("t")) + \
+                    " " * len(run.findall(w("tab")))
+                if not text:
+                    continue
+                props = run.find(w("rPr"))
+                style = ""
+                if props is not None and props.find(w("b")) is not None:
+                    style = "b"
+                elif props is not None and props.find(w("i")) is not None:
+                    style = "i"
+                runs.append((text, style))
+            if normalize("".join(t for t, _ in runs)):
+                note.paragraphs.append(runs)
+        if note.paragraphs:
+            notes[note.key] = note
+    return notes
+
+
+def _walk(node: Any, paragraph: _Paragraph, state: Dict[str, Any], out: List[_Paragraph]) -> None:
+    tag = node.tag
+    if tag == w("p") and node is not state.get("current"):
+        inner, outer = _Paragraph(), state.get("current")
+        state["current"] = node
+        for child in node:
+            _walk(child, inner, state, out)
+        state["current"] = outer
+        out.append(inner)
+        return
+    if tag == w("t"):
+        paragraph.text += node.text or ""
+        if state.pop("custom", None) is not None and paragraph.references:
+            paragraph.references[-1].mark = normalize(node.text or "")
+    elif tag in (w("tab"), w("br")):
+        paragraph.text += " "
+    elif tag in (w("footnoteReference"), w("endnoteReference")):
+        kind = "footnote" if tag == w("footnoteReference") else "endnote"
+        ref = NoteReference(kind, node.get(w("id")) or "", offset_of(paragraph.text))
+        paragraph.references.append(ref)
+        if node.get(w("customMarkFollows")) in ("1", "true", "on"):
+            state["custom"] = True
+        for name in state["open_bookmarks"].values():
+            state["bookmarks"].setdefault(name, (kind, ref.id))
+    elif tag == w("bookmarkStart"):
+        state["open_bookmarks"][node.get(w("id"))] = node.get(w("name")) or ""
+    elif tag == w("bookmarkEnd"):
+        state["open_bookmarks"].pop(node.get(w("id")), None)
+    elif tag == w("instrText"):
+        if state.get("instr") is not None:
+            state["instr"] += node.text or ""
+    elif tag == w("fldChar"):
+        kind = node.get(w("fldCharType"))
+        if kind == "begin":
+            state["instr"] = ""
+        elif kind == "separate" and state.get("instr") is not None:
+            words = state.pop("instr").split()
+            state["noteref"] = (words[1], len(paragraph.text)) if words[:1] == ["NOTEREF"] and len(words) > 1 else None
+        elif kind == "end":
+            state["instr"] = None
+            pending = state.pop("noteref", None)
+            if pending:
+                _add_noteref(paragraph, *pending)
+    elif tag == w("fldSimple"):
+        words = (node.get(w("instr")) or "").split()
+        start = len(paragraph.text)
+        for child in node:
+            _walk(child, paragraph, state, out)
+        if words[:1] == ["NOTEREF"] and len(words) > 1:
+            _add_noteref(paragraph, words[1], start)
+        return
+    elif tag in (w("delText"), w("instrText")):
+        return
+    for child in node:
+        _walk(child, paragraph, state, out)
+
+
+def _add_noteref(paragraph: _Paragraph, bookmark: str, start: int) -> None:
+    shown = normalize(paragraph.text[start:])
+    offset = offset_of(paragraph.text[:start])
+    paragraph.noterefs.append((bookmark, offset, shown))
+
+
+def read_word_notes(path: str | Path | WordSource) -> WordNotes:
+    """Footnotes, endnotes and the body paragraphs citing them in a ``.docx``."""
+    result = WordNotes()
+    docx = WordSource.of(path)
+    if docx is None:
+        return result
+    result.notes.update(_read_notes(docx, "footnote", "footnotes"))
+    result.notes.update(_read_notes(docx, "endnote", "endnotes"))
+    if not result.notes:
+        return result
+    body = docx.body
+    paragraphs: List[_Paragraph] = []
+    state: Dict[str, Any] = {"open_bookmarks": {}, "bookmarks": {}}
+    if body is not None:
+        for child in body:
+            _walk(child, _Paragraph(), state, paragraphs)
+    for paragraph in paragraphs:
+        references = list(paragraph.references)
+        for bookmark, offset, shown in paragraph.noterefs:
+            target = state["bookmarks"].get(bookmark)
+            if target is not None:
+                references.append(NoteReference(target[0], target[1], offset, shown, cross_reference=True))
+        if references:
+            references.sort(key=lambda r: r.offset)
+            result.paragraphs.append((normalize(paragraph.text), references))
+    return result
+
+
+def _note_element(note: WordNote, fn_id: str, callout: str) -> Any:
+    fn = ET.Element("fn", id=fn_id)
+    if note.kind == "endnote":
+        fn.set("outputclass", "endnote")
+    if callout:
+        fn.set("callout", callout)
+
+    def _fill(parent: Any, runs: List[Tuple[str, str]]) -> None:
+        last = None
+        for text, style in runs:
+            if style:
+                last = ET.SubElement(parent, style)
+                last.text = text
+            elif last is None:
+                parent.text = (parent.text or "") + text
+            else:
+                last.tail = (last.tail or "") + text
+        if parent.text:
+            parent.text = parent.text.lstrip()
+
+    if len(note.paragraphs) == 1:
+        _fill(fn, note.paragraphs[0])
+    else:
+        for runs in note.paragraphs:
+            _fill(ET.SubElement(fn, "p"), runs)
+    return fn
+
+
+class _Placer:
+    """Decides where each note is printed and what every reference becomes."""
+
+    def __init__(self, context: Any, notes: WordNotes, endnotes: str) -> None:
+        self.context = context
+        self.notes = notes
+        self.endnotes = endnotes
+        self.placed: Dict[Tuple[str, str], Tuple[str, str]] = {}   # note -> (topic file, fn id)
+
+    def _note(self, reference: NoteReference) -> Optional[WordNote]:
+        note = self.notes.notes.get((reference.kind, reference.id))
+        if note is None or (note.kind == "endnote" and self.endnotes == "drop"):
+            return None
+        return note
+
+    def assign(self, reference: NoteReference, topic: str) -> bool:
+        """Print the note at *reference* unless it is a cross-reference or already placed."""
+        note = self._note(reference)
+        if note is None or reference.cross_reference or note.key in self.placed:
+            return False
+        self.placed[note.key] = (topic, f"fn_{note.kind[0]}{note.id}")
+        return True
+
+    def element(self, reference: NoteReference, topic: str, printed: bool) -> Optional[Any]:
+        note = self._note(reference)
+        if note is None or note.key not in self.placed:
+            return None
+        target, fn_id = self.placed[note.key]
+        if printed:
+            return _note_element(note, fn_id, reference.mark)
+        topic_id = self.context.topics[target].get("id") or ""
+        href = f"#{topic_id}/{fn_id}" if target == topic else f"{target}#{topic_id}/{fn_id}"
+        return ET.Element("xref", href=href, type="fn")
+
+
+def _placeholders(context: Any) -> List[Tuple[str, Any, NoteReference]]:
+    found = []
+    for name in topic_order(context):
+        for el in list(context.topics[name].iter("ph")):
+            for kind, hint in NOTE_HINTS.items():
+                if el.get(hint) is not None:
+                    found.append((name, el, NoteReference(kind, el.get(hint), 0, el.get("data-callout") or "")))
+    return found
+
+
+def _replace(el: Any, new: Optional[Any]) -> None:
+    parent = el.getparent()
+    tail = el.tail
+    if new is not None:
+        new.tail = tail
+        parent.insert(parent.index(el), new)
+        parent.remove(el)
+        return
+    previous = el.getprevious()
+    if previous is not None:
+        previous.tail = (previous.tail or "") + (tail or "")
+    else:
+        parent.text = (parent.text or "") + (tail or "")
+    parent.remove(el)
+
+
+def restore_word_notes(path: str | Path | WordSource, context: Any, options: Optional[Mapping[str, Any]] = None,
+                       report: Any = None) -> int:
+    """Put the notes of the ``.docx`` *path* back into *context*; returns the number placed."""
+    options = dict(options or {})
+    placeholders = _placeholders(context)
+    if not options.get("enabled", True):
+        for _, el, _ in placeholders:
+            _replace(el, None)
+        return 0
+    report = report if report is not None else getattr(context, "report", None)
+    endnotes = str(options.get("endnotes", "fn"))
+    if endnotes not in _ENDNOTES:
+        if report is not None:
+            report.warning("footnotes", f"Unknown endnotes mode {endnotes!r} (expected {', '.join(_ENDNOTES)})")
+        endnotes = "fn"
+    try:
+        notes = read_word_notes(path)
+    except Exception as exc:
+        logger.warning("Could not read the notes of %s: %s", Path(path).name, exc)
+        notes = WordNotes()
+    placer = _Placer(context, notes, endnotes)
+
+    if placeholders:
+        printed = [placer.assign(reference, name) for name, _, reference in placeholders]
+        for (name, el, reference), first in zip(placeholders, printed):
+            _replace(el, placer.element(reference, name, first))
+    elif notes and any(True for t in context.topics.values() for _ in t.iter("fn")):
+        if report is not None:
+            report.info("footnotes", "Converter already produced <fn> elements; Word notes left as converted")
+        return 0
+    elif notes:
+        blocks = text_blocks(context)
+        located: List[Tuple[str, Any, NoteReference, bool]] = []
+        cursor = unplaced = 0
+        for text, references in notes.paragraphs:
+            hit, cursor = find_block(blocks, text, cursor)
+            if hit is None:
+                unplaced += sum(1 for r in references if not r.cross_reference)
+                continue
+            name, block, _ = blocks[hit]
+            block = innermost_block(block, text)
+            located.extend((name, block, r, placer.assign(r, name)) for r in references)
+        # Last offset first, so earlier offsets still count the original text only
+        for name, block, reference, first in sorted(reversed(located), key=lambda item: -item[2].offset):
+            new = placer.element(reference, name, first)
+            if new is not None:
+                insert_at(block, reference.offset, new, reference.mark)
+        if unplaced and report is not None:
+            report.warning("footnotes", f"{unplaced} note(s) could not be placed: their paragraph was not "
+                                        "found in the converted topics")
+    if not notes:
+        return 0
+    placed = len(placer.placed)
+    if report is not None and placed:
+        kinds = [k for k, _ in placer.placed]
+        report.info("footnotes", f"{kinds.count('footnote')} footnote(s) and {kinds.count('endnote')} "
+                                 f"endnote(s) restored", footnotes=kinds.count("footnote"),
+                    endnotes=kinds.count("endnote"))
+    return placed
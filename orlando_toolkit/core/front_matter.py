@@ -0,0 +1,317 @@
+from __future__ import annotations
+
+"""Front matter removed from Word sources before they are split into topics.
+
+Manuals open with a cover page, a table of contents and revision tables that
+should never become topics. The ``front_matter`` section of
+``conversion.yml`` lists what to leave out; :func:`strip_front_matter`
+removes it from the ``.docx`` before the plugin converts the document
+(after tracked changes, fields and outline headings are resolved, so title
+rules see the headings the plugin will split at)::
+
+    front_matter:
+      styles: [Cover Title, Cover Subtitle]    # paragraphs (or tables) of these styles
+      titles: ["^Revision (history|record)$"]  # headings and everything up to the next one at their level
+      pages: "1-2"                              # body pages, "1-2,4"
+      toc: true                                 # Word tables of contents
+
+Pages are counted from Word's last layout (``w:lastRenderedPageBreak``)
+when the document has one, else from page breaks, "page break before"
+paragraphs and section breaks that start a new page. Title rules match
+headings of a ``heading N`` style or a style the style map makes a heading.
+
+Each removed paragraph leaves an empty paragraph behind, so the Word
+paragraph numbers of the conversion report (``paragraph``/``paragraphs``,
+:mod:`orlando_toolkit.core.diagnostics`) still count from the original.
+:func:`plan_front_matter` lists the blocks a configuration would remove,
+for the preview shown before converting when ``review`` is on; blocks the
+user keeps there are listed by first paragraph number in
+``metadata["front_matter_keep"]``. :func:`record_front_matter` reports what
+was removed under ``front_matter``.
+"""
+
+import logging
+import re
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Iterable, List, Mapping, Optional, Set, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.placement import normalize
+from orlando_toolkit.core.word_numbering import _paragraph_style, _paragraph_text, _read_parts, _val
+from orlando_toolkit.core.word_source import DOCUMENT, WordSource, w
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["FrontMatter", "KEEP_KEY", "SkippedBlock", "page_numbers", "plan_front_matter", "record_front_matter",
+           "review_enabled", "strip_front_matter"]
+
+KEEP_KEY = "front_matter_keep"
+_TOC_STYLE = re.compile(r"^toc(\s*\d|\s+heading)$", re.IGNORECASE)
+_TOC_FIELD = re.compile(r"^\s*TOC\b")
+_REASONS = ("style", "toc", "title", "page")
+_PREVIEW_CHARS = 120
+
+
+@dataclass(frozen=True)
+class SkippedBlock:
+    """A body paragraph, table or content control left out, with the rule that removed it."""
+
+    paragraph: int          # 1-based number of its first paragraph in word/document.xml
+    kind: str               # paragraph | table | toc
+    text: str
+    reason: str             # style | toc | title | page
+    page: int = 1
+
+
+@dataclass
+class FrontMatter:
+    """What :func:`strip_front_matter` did; ``path`` is the file to convert."""
+
+    path: Path
+    skipped: List[SkippedBlock] = field(default_factory=list)
+    kept: List[int] = field(default_factory=list)
+
+    def __bool__(self) -> bool:
+        return bool(self.skipped)
+
+
+def review_enabled(metadata: Optional[Mapping[str, Any]] = None) -> bool:
+    """Whether ``front_matter.review`` asks for the preview before converting."""
+    from orlando_toolkit.core.processing import resolve_conversion_options
+
+    section = resolve_conversion_options(metadata).get("front_matter") or {}
+    return isinstance(section, Mapping) and bool(section.get("review", False)) and _active(section)
+
+
+def _active(options: Mapping[str, Any]) -> bool:
+    return options.get("enabled", True) is not False and any(
+        options.get(key) for key in ("styles", "titles", "pages", "toc"))
+
+
+def page_numbers(spec: Any) -> Set[int]:
+    """Page numbers of a ``"1-3,5"`` range string or a list of numbers and ranges.
+
+    Raises ValueError for unreadable ranges.
+    """
+    parts: Iterable[Any] = spec if isinstance(spec, (list, tuple)) else str(spec or "").split(",")
+    pages: Set[int] = set()
+    for part in parts:
+        text = str(part).strip()
+        if not text:
+            continue
+        first, _, last = text.partition("-")
+        start, end = int(first), int(last or first)
+        if start < 1 or end < start:
+            raise ValueError(f"Invalid page range {text!r}")
+        pages.update(range(start, end + 1))
+    return pages
+
+
+def _text(block: Any) -> str:
+    text = normalize(" ".join(_paragraph_text(p) for p in _paragraphs(block)))
+    return text if len(text) <= _PREVIEW_CHARS else text[:_PREVIEW_CHARS] + "…"
+
+
+def _paragraphs(block: Any) -> List[Any]:
+    """Paragraphs of *block* counted like the report does (not those nested in a paragraph's text boxes)."""
+    found = [block] if block.tag == w("p") else []
+    found += [p for p in block.iter(w("p"))
+              if p is not block and not any(a.tag == w("p") for a in p.iterancestors())]
+    return found
+
+
+def _page_breaks(block: Any, rendered: bool) -> Tuple[int, int]:
+    """(breaks before the first text of *block*, breaks after it) in the chosen page counting."""
+    before = after = 0
+    seen_text = False
+    if not rendered and block.find(f"{w('pPr')}/{w('pageBreakBefore')}") is not None:
+        before += 1
+    for el in block.iter(w("t"), w("br"), w("lastRenderedPageBreak")):
+        if el.tag == w("t"):
+            seen_text = seen_text or bool((el.text or "").strip())
+            continue
+        if (el.tag == w("lastRenderedPageBreak")) != rendered:
+            continue
+        if el.tag == w("br") and el.get(w("type")) != "page":
+            continue
+        if seen_text:
+            after += 1
+        else:
+            before += 1
+    if not rendered:
+        sect = block.find(f"{w('pPr')}/{w('sectPr')}")
+        if sect is not None and _val(sect.find(w("type"))) not in ("continuous",):
+            after += 1
+    return before, after
+
+
+def _heading_level(block: Any, styles: Any, style_map: Mapping[str, Any]) -> Optional[int]:
+    if block.tag != w("p"):
+        return None
+    style_id = _paragraph_style(block)
+    level = styles.heading_level(style_id) or style_map.get(styles.names.get(style_id, style_id))
+    return int(level) if level else None
+
+
+def _style_name(block: Any, styles: Any) -> Tuple[str, str]:
+    if block.tag == w("tbl"):
+        style_id = _val(block.find(f"{w('tblPr')}/{w('tblStyle')}")) or ""
+    else:
+        style_id = _paragraph_style(block) if block.tag == w("p") else ""
+    return style_id, styles.names.get(style_id, style_id)
+
+
+def _is_toc_control(block: Any) -> bool:
+    gallery = block.find(f"{w('sdtPr')}/{w('docPartObj')}/{w('docPartGallery')}")
+    return block.tag == w("sdt") and (_val(gallery) or "").lower() == "table of contents"
+
+
+class _TocField:
+    """Follows a TOC field over the paragraphs it spans (its PAGEREF fields nest inside)."""
+
+    def __init__(self) -> None:
+        self.depth = 0
+
+    def covers(self, block: Any) -> bool:
+        inside = self.depth > 0
+        for el in block.iter(w("fldChar"), w("instrText"), w("fldSimple")):
+            if el.tag == w("fldSimple"):
+                inside = inside or bool(_TOC_FIELD.match(el.get(w("instr")) or ""))
+            elif el.tag == w("instrText"):
+                if not self.depth and _TOC_FIELD.match(el.text or ""):
+                    self.depth, inside = 1, True
+            elif self.depth:
+                kind = el.get(w("fldCharType"))
+                if kind == "begin":
+                    self.depth += 1
+                elif kind == "end":
+                    self.depth -= 1
+        return inside
+
+
+def _plan(document: Any, styles: Any, options: Mapping[str, Any], style_map: Mapping[str, Any]
+          ) -> List[Tuple[Any, SkippedBlock]]:
+    names = {str(s).casefold() for s in options.get("styles") or ()}
+    titles = []
+    for pattern in options.get("titles") or ():
+        try:
+            titles.append(re.compile(str(pattern), re.IGNORECASE))
+        except re.error as exc:
+            logger.warning("Ignoring front matter title pattern %r: %s", pattern, exc)
+    try:
+        pages = page_numbers(options.get("pages"))
+    except ValueError as exc:
+        logger.warning("Ignoring front matter pages: %s", exc)
+        pages = set()
+    toc = bool(options.get("toc", False))
+    body = document.find(w("body"))
+    rendered = document.find(f".//{w('lastRenderedPageBreak')}") is not None
+    toc_field = _TocField()
+    section_level: Optional[int] = None
+    page, number = 1, 0
+    planned: List[Tuple[Any, SkippedBlock]] = []
+    for block in list(body) if body is not None else ():
+        if block.tag not in (w("p"), w("tbl"), w("sdt")):
+            continue
+        before, after = _page_breaks(block, rendered)
+        page += before if number else 0
+        first = number + 1
+        number += len(_paragraphs(block))
+        level = _heading_level(block, styles, style_map)
+        if section_level is not None and level is not None and level <= section_level:
+            section_level = None
+        style_id, style_name = _style_name(block, styles)
+        reason = None
+        in_toc = toc_field.covers(block) if toc else False
+        if toc and (in_toc or _is_toc_control(block) or _TOC_STYLE.match(style_name.strip())):
+            reason = "toc"
+        elif names and (style_id.casefold() in names or style_name.casefold() in names):
+            reason = "style"
+        elif section_level is not None:
+            reason = "title"
+        elif level is not None and any(p.search(_text(block)) for p in titles):
+            section_level, reason = level, "title"
+        elif page in pages:
+            reason = "page"
+        if reason is not None:
+            kind = "table" if block.tag == w("tbl") else "toc" if block.tag == w("sdt") else "paragraph"
+            planned.append((block, SkippedBlock(first, kind, _text(block), reason, page)))
+        page += after
+    return planned
+
+
+def plan_front_matter(path: str | Path | WordSource, options: Optional[Mapping[str, Any]] = None,
+                      style_map: Optional[Mapping[str, Any]] = None) -> List[SkippedBlock]:
+    """The blocks of the ``.docx`` *path* the ``front_matter`` *options* leave out, in document order."""
+    options = dict(options or {})
+    docx = WordSource.of(path) if _active(options) else None
+    if docx is None or DOCUMENT not in docx:
+        return []
+    styles, _numbering = _read_parts(docx)
+    return [skipped for _, skipped in _plan(docx.document, styles, options, style_map or {})]
+
+
+def _placeholder(block: Any) -> Any:
+    """An empty paragraph standing for *block*, keeping its section break."""
+    empty = ET.Element(w("p"))
+    sect = block.find(f"{w('pPr')}/{w('sectPr')}") if block.tag == w("p") else None
+    if sect is not None:
+        ET.SubElement(empty, w("pPr")).append(sect)
+    return empty
+
+
+def strip_front_matter(path: str | Path | WordSource, options: Optional[Mapping[str, Any]],
+                       out_dir: Optional[str | Path] = None, style_map: Optional[Mapping[str, Any]] = None,
+                       keep: Optional[Iterable[int]] = None) -> FrontMatter:
+    """Remove the front matter *options* describe from the ``.docx`` *path*.
+
+    *keep* lists first paragraph numbers of blocks to leave in place (the
+    preview's unchecked rows). With *out_dir* a copy is written there and
+    becomes ``result.path``; without it the parts of a shared
+    :class:`WordSource` are edited for the caller to save. ``result.path``
+    is the source itself when no rule is set, the file is not a Word
+    package or nothing was removed.
+    """
+    options = dict(options or {})
+    docx = WordSource.of(path)
+    result = FrontMatter(path=docx.path if docx is not None else Path(path))
+    if not _active(options) or docx is None or DOCUMENT not in docx:
+        return result
+    styles, _numbering = _read_parts(docx)
+    kept = {int(n) for n in keep or ()}
+    for block, skipped in _plan(docx.document, styles, options, style_map or {}):
+        if skipped.paragraph in kept:
+            result.kept.append(skipped.paragraph)
+            continue
+        parent = block.getparent()
+        index = parent.index(block)
+        placeholders = [_placeholder(block)] + [ET.Element(w("p")) for _ in _paragraphs(block)[1:]]
+        parent.remove(block)
+        for offset, empty in enumerate(placeholders):
+            parent.insert(index + offset, empty)
+        result.skipped.append(skipped)
+    if not result.skipped:
+        return result
+    docx.touch(DOCUMENT)
+    logger.info("Left out %d front matter block(s) of %s", len(result.skipped), docx.path.name)
+    if out_dir is not None:
+        result.path = docx.save(Path(out_dir) / "front_matter")
+    return result
+
+
+def record_front_matter(context: Any, front_matter: Optional[FrontMatter], report: Any = None) -> int:
+    """Report the blocks :func:`strip_front_matter` removed; returns their number."""
+    if front_matter is None or not (front_matter.skipped or front_matter.kept):
+        return 0
+    report = report if report is not None else getattr(context, "report", None)
+    skipped = front_matter.skipped
+    if report is not None:
+        counts = {reason: sum(1 for s in skipped if s.reason == reason) for reason in _REASONS}
+        titles = [s.text for s in skipped if s.reason == "title" and s.kind == "paragraph"][:20]
+        report.info("front_matter", f"{len(skipped)} front matter block(s) left out before splitting into topics"
+                    + (f", {len(front_matter.kept)} kept in the preview" if front_matter.kept else ""),
+                    paragraphs=[s.paragraph for s in skipped], kept=len(front_matter.kept),
+                    **{k: v for k, v in counts.items() if v}, first_texts=titles)
+    return len(skipped)
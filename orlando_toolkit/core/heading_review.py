@@ -0,0 +1,92 @@
+from __future__ import annotations
+
+"""Heading review before conversion.
+
+Heading detection (heading styles, the style map, outline numbering, the
+``headings`` rules) decides how a document is split into topics, and a
+wrong guess only shows once the whole conversion has run. With
+``headings.review`` on, the application reads the heading outline first and
+lets the user promote, demote or exclude headings before any topic is made:
+
+- :func:`read_heading_outline` lists the headings of a Word or Markdown /
+  AsciiDoc source with their style, source level and the level the rules
+  give them, without converting;
+- :func:`review_overrides` turns the levels the user settled on into the
+  ``metadata["heading_overrides"]`` entries
+  :func:`~orlando_toolkit.core.heading_rules.apply_heading_rules` applies
+  after the rules, so every converter honours them.
+
+Headings are identified by title and occurrence, so the choices also apply
+when the same document is converted again (Update from Source, batch jobs
+with the saved metadata).
+"""
+
+import logging
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional, Sequence
+
+from orlando_toolkit.core.heading_rules import Heading, HeadingOverride, apply_heading_rules, heading_occurrences
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["OutlineHeading", "read_heading_outline", "review_enabled", "review_overrides"]
+
+
+@dataclass(frozen=True)
+class OutlineHeading:
+    """One heading of the outline; ``level`` is after the rules, ``None`` when it is the map title."""
+
+    title: str
+    style: Optional[str]
+    source_level: int
+    level: Optional[int]
+    occurrence: int = 1
+
+
+def review_enabled(metadata: Optional[Mapping[str, Any]] = None) -> bool:
+    """Whether ``headings.review`` asks for the review step."""
+    from orlando_toolkit.core.processing import resolve_conversion_options
+
+    section = resolve_conversion_options(metadata).get("headings") or {}
+    return isinstance(section, Mapping) and bool(section.get("review", False))
+
+
+def _source_headings(path: Path, metadata: Optional[Mapping[str, Any]]) -> List[Heading]:
+    from orlando_toolkit.core.importers.markup import MarkupDocumentImporter, _read_text
+    from orlando_toolkit.core.processing import resolve_conversion_options
+
+    parser = MarkupDocumentImporter().parser_for(path)
+    if parser is not None:
+        return [Heading(s.level, s.title, f"Heading {s.level}") for s in parser.parse(_read_text(path)).sections]
+    from orlando_toolkit.core.style_map import heading_levels, resolve_style_rules
+    from orlando_toolkit.core.word_numbering import read_word_headings
+
+    return read_word_headings(path, resolve_conversion_options(metadata).get("headings"),
+                              heading_levels(resolve_style_rules(metadata)))
+
+
+def read_heading_outline(file_path: str | Path, metadata: Optional[Mapping[str, Any]] = None) -> List[OutlineHeading]:
+    """The detected headings of *file_path*; empty when the format has no outline to review.
+
+    Levels are those of the ``headings`` rules, without earlier overrides.
+    """
+    path = Path(file_path)
+    try:
+        headings = _source_headings(path, metadata)
+    except Exception as exc:
+        logger.warning("Could not read the headings of %s: %s", path.name, exc)
+        return []
+    outline = apply_heading_rules(headings, metadata, overrides=())
+    return [OutlineHeading(title=h.title, style=h.style, source_level=h.level, level=level, occurrence=occurrence)
+            for h, level, occurrence in zip(headings, outline.levels, heading_occurrences(headings))]
+
+
+def review_overrides(outline: Sequence[OutlineHeading], levels: Sequence[Optional[int]]) -> List[Dict[str, Any]]:
+    """``heading_overrides`` entries for the headings whose reviewed level (``None``: excluded) differs."""
+    overrides = []
+    for heading, level in zip(outline, levels):
+        if level != heading.level:
+            overrides.append(HeadingOverride(title=" ".join(heading.title.split()), occurrence=heading.occurrence,
+                                             level=level).to_mapping())
+    return overrides
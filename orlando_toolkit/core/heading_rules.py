@@ -0,0 +1,267 @@
+from __future__ import annotations
+
+"""Heading promotion and demotion rules.
+
+Converters assign each source heading a level (from the style map) and then
+split the document into topics. In between, they pass the heading outline to
+:func:`apply_heading_rules`, which adjusts the levels according to the
+``headings`` section of ``conversion.yml`` (or the job's
+``metadata["conversion_options"]["headings"]``)::
+
+    headings:
+      rules:
+        - match: {level: 1}          # the first H1 becomes the map title,
+          action: map_title          # its H2 children the top-level topics
+        - match: {title: "^Appendix"}
+          action: promote            # promote | demote | map_title
+          by: 1
+          subtree: true              # also move the headings under it
+
+A rule matches a heading when every key of ``match`` holds: ``level`` (one
+level or a list), ``style`` (source style names) and ``title`` (regular
+expression, case-insensitive). Rules apply in order to the levels left by the
+previous rule. A subtree is the headings after the matched one with a deeper
+level, up to the next heading at the same level or above. Levels never go
+below 1. ``map_title`` applies to the first match only; the heading is taken
+out of the outline (its level becomes ``None``) and its subtree moves up one
+level. Styles with ``role: map_title`` in the style map
+(:mod:`orlando_toolkit.core.style_map`) add a ``map_title`` rule for those
+styles ahead of the configured ones.
+
+After the rules, the choices of the heading review
+(:mod:`orlando_toolkit.core.heading_review`), kept in
+``metadata["heading_overrides"]``, set the final level of single headings::
+
+    [{"title": "Notes", "occurrence": 2, "level": 3},
+     {"title": "Revision history", "exclude": true}]
+
+A heading is found by its title (whitespace-normalised) and which heading of
+that title it is (``occurrence``, from 1). An excluded heading leaves the
+outline like a map title, but its index is listed in
+``HeadingOutline.excluded`` so converters keep its content in the topic
+before it.
+"""
+
+import logging
+import re
+from dataclasses import dataclass, field
+from typing import Any, Dict, List, Mapping, Optional, Sequence, Tuple
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["Heading", "HeadingOutline", "HeadingOverride", "HeadingRule", "apply_heading_rules",
+           "heading_occurrences", "load_heading_overrides", "load_heading_rules"]
+
+_ACTIONS = ("promote", "demote", "map_title")
+
+
+@dataclass(frozen=True)
+class Heading:
+    """One source heading as the converter sees it before splitting."""
+
+    level: int
+    title: str = ""
+    style: Optional[str] = None
+
+
+@dataclass
+class HeadingOutline:
+    """Levels after the rules ran, index for index with the input headings."""
+
+    levels: List[Optional[int]]
+    map_title: Optional[str] = None
+    applied: List[str] = field(default_factory=list)
+    excluded: List[int] = field(default_factory=list)
+
+
+@dataclass(frozen=True)
+class HeadingRule:
+    action: str
+    levels: Tuple[int, ...] = ()
+    styles: Tuple[str, ...] = ()
+    title: Optional["re.Pattern[str]"] = None
+    by: int = 1
+    subtree: bool = True
+
+    @classmethod
+    def from_mapping(cls, data: Mapping[str, Any]) -> "HeadingRule":
+        action = str(data.get("action", "promote"))
+        if action not in _ACTIONS:
+            raise ValueError(f"Unknown heading rule action {action!r} (expected {', '.join(_ACTIONS)})")
+        match = data.get("match") or {}
+        levels = match.get("level")
+        levels = (levels,) if isinstance(levels, int) else tuple(int(v) for v in levels or ())
+        styles = match.get("style")
+        styles = (styles,) if isinstance(styles, str) else tuple(str(v) for v in styles or ())
+        title = re.compile(str(match["title"]), re.IGNORECASE) if match.get("title") else None
+        return cls(action=action, levels=levels, styles=styles, title=title,
+                   by=max(0, int(data.get("by", 1))), subtree=bool(data.get("subtree", True)))
+
+    def matches(self, heading: Heading, level: int) -> bool:
+        if self.levels and level not in self.levels:
+            return False
+        if self.styles and heading.style not in self.styles:
+            return False
+        if self.title is not None and not self.title.search(heading.title or ""):
+            return False
+        return True
+
+    def describe(self) -> str:
+        what = [f"level {'/'.join(map(str, self.levels))}"] if self.levels else []
+        what += [f"style {'/'.join(self.styles)}"] if self.styles else []
+        what += [f"title /{self.title.pattern}/"] if self.title is not None else []
+        amount = "" if self.action == "map_title" else f" by {self.by}"
+        return f"{self.action}{amount} ({', '.join(what) or 'all headings'})"
+
+
+def load_heading_rules(metadata: Optional[Mapping[str, Any]] = None) -> List[HeadingRule]:
+    """Rules from ``conversion.yml`` ``headings`` merged with the job's options.
+
+    Invalid rules are skipped with a warning.
+    """
+    from orlando_toolkit.core.processing.pipeline import resolve_conversion_options
+    from orlando_toolkit.core.style_map import resolve_style_rules
+
+    section = resolve_conversion_options(metadata).get("headings") or {}
+    rules: List[HeadingRule] = []
+    if not isinstance(section, Mapping) or section.get("enabled", True) is False:
+        return rules
+    titles = tuple(name for name, rule in resolve_style_rules(metadata).items() if rule.role == "map_title")
+    if titles:
+        rules.append(HeadingRule(action="map_title", styles=titles))
+    for number, data in enumerate(section.get("rules") or [], start=1):
+        try:
+            rules.append(HeadingRule.from_mapping(data))
+        except (TypeError, ValueError, re.error) as exc:
+            logger.warning("Ignoring heading rule %d: %s", number, exc)
+    return rules
+
+
+@dataclass(frozen=True)
+class HeadingOverride:
+    """Final level of one heading chosen in the heading review; ``level`` ``None`` excludes it."""
+
+    title: str
+    occurrence: int = 1
+    level: Optional[int] = None
+
+    @classmethod
+    def from_mapping(cls, data: Mapping[str, Any]) -> "HeadingOverride":
+        title = _title_key(str(data.get("title") or ""))
+        if not title:
+            raise ValueError("A heading override needs a title")
+        level = None if data.get("exclude") else int(data["level"])
+        return cls(title=title, occurrence=max(1, int(data.get("occurrence", 1))),
+                   level=None if level is None else max(1, level))
+
+    def to_mapping(self) -> Dict[str, Any]:
+        data: Dict[str, Any] = {"title": self.title, "occurrence": self.occurrence}
+        if self.level is None:
+            data["exclude"] = True
+        else:
+            data["level"] = self.level
+        return data
+
+
+def _title_key(title: str) -> str:
+    return " ".join(title.split())
+
+
+def heading_occurrences(headings: Sequence[Heading]) -> List[int]:
+    """For each heading, which heading of that title it is (1 for the first)."""
+    seen: Dict[str, int] = {}
+    occurrences = []
+    for heading in headings:
+        key = _title_key(heading.title)
+        seen[key] = seen.get(key, 0) + 1
+        occurrences.append(seen[key])
+    return occurrences
+
+
+def load_heading_overrides(metadata: Optional[Mapping[str, Any]] = None) -> List[HeadingOverride]:
+    """Overrides of ``metadata["heading_overrides"]``; invalid entries are skipped with a warning."""
+    overrides: List[HeadingOverride] = []
+    for number, data in enumerate((metadata or {}).get("heading_overrides") or [], start=1):
+        try:
+            overrides.append(HeadingOverride.from_mapping(data))
+        except (AttributeError, KeyError, TypeError, ValueError) as exc:
+            logger.warning("Ignoring heading override %d: %s", number, exc)
+    return overrides
+
+
+def _apply_overrides(headings: Sequence[Heading], outline: HeadingOutline,
+                     overrides: Sequence[HeadingOverride], report: Any) -> None:
+    wanted = {(o.title, o.occurrence): o for o in overrides}
+    counts = {"promoted": 0, "demoted": 0, "excluded": 0}
+    for index, (heading, occurrence) in enumerate(zip(headings, heading_occurrences(headings))):
+        override = wanted.pop((_title_key(heading.title), occurrence), None)
+        if override is None or override.level == outline.levels[index]:
+            continue
+        if override.level is None:
+            counts["excluded"] += 1
+            outline.excluded.append(index)
+        elif outline.levels[index] is None or override.level < outline.levels[index]:
+            counts["promoted"] += 1
+        else:
+            counts["demoted"] += 1
+        outline.levels[index] = override.level
+    changed = sum(counts.values())
+    if changed:
+        outline.applied.append(f"review ({changed} heading(s))")
+    if report is not None and (changed or wanted):
+        report.info("headings", f"Heading review changed {changed} heading(s)", **counts,
+                    not_found=[o.title for o in wanted.values()][:20])
+
+
+def _subtree_end(levels: Sequence[Optional[int]], start: int) -> int:
+    root = levels[start]
+    end = start + 1
+    while end < len(levels) and (levels[end] is None or levels[end] > root):
+        end += 1
+    return end
+
+
+def apply_heading_rules(headings: Sequence[Heading], metadata: Optional[Mapping[str, Any]] = None, *,
+                        rules: Optional[Sequence[HeadingRule]] = None,
+                        overrides: Optional[Sequence[HeadingOverride]] = None, report: Any = None) -> HeadingOutline:
+    """Return the adjusted outline of *headings*; *report* receives one entry per rule used.
+
+    *overrides* default to the heading review's choices in *metadata*.
+    """
+    if rules is None:
+        rules = load_heading_rules(metadata)
+    if overrides is None:
+        overrides = load_heading_overrides(metadata)
+    outline = HeadingOutline(levels=[max(1, int(h.level)) for h in headings])
+    levels = outline.levels
+    for rule in rules:
+        changed = 0
+        index = 0
+        while index < len(headings):
+            level = levels[index]
+            if level is None or not rule.matches(headings[index], level):
+                index += 1
+                continue
+            end = _subtree_end(levels, index) if rule.subtree or rule.action == "map_title" else index + 1
+            if rule.action == "map_title":
+                if outline.map_title is not None:
+                    index += 1
+                    continue
+                outline.map_title = headings[index].title
+                levels[index] = None
+                shift, first = -1, index + 1
+            else:
+                shift, first = (-rule.by if rule.action == "promote" else rule.by), index
+            for position in range(first, end):
+                if levels[position] is not None:
+                    levels[position] = max(1, levels[position] + shift)
+            changed += 1
+            index = end
+        if changed:
+            outline.applied.append(rule.describe())
+            if report is not None:
+                report.info("headings", f"Heading rule {rule.describe()} applied to {changed} heading(s)",
+                            matches=changed)
+    if overrides:
+        _apply_overrides(headings, outline, overrides, report)
+    return outline
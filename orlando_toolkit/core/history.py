@@ -0,0 +1,266 @@
+from __future__ import annotations
+
+"""Local conversion history and recent projects.
+
+Every conversion, package written and project saved or opened is recorded
+per user, with its source fingerprint, settings and conversion report, so
+results can be compared over time (``python -m orlando_toolkit history``).
+
+Records are JSON files in the history directory (``history`` next to the
+user configuration, or ``history.path`` in ``pipeline.yml``); the oldest are
+removed beyond ``history.max_entries``. Recording never fails the operation
+being recorded: errors are logged and ignored.
+"""
+
+import json
+import logging
+import threading
+from dataclasses import dataclass, field
+from datetime import datetime, timezone
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional, Union
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "HistoryEntry",
+    "HistoryStore",
+    "compare_entries",
+    "get_history_store",
+    "set_history_store",
+]
+
+KINDS = ("conversion", "package", "project")
+# Metadata kept out of the recorded settings (large or not settings at all)
+_SKIPPED_METADATA = {"original_structure", "source_outline"}
+
+
+def _default_dir() -> Path:
+    from orlando_toolkit.config.manager import _get_user_config_dir
+
+    base = _get_user_config_dir()
+    return (base.parent if base.name == "config" else base) / "history"
+
+
+def _json_safe(mapping: Mapping[str, Any]) -> Dict[str, Any]:
+    safe: Dict[str, Any] = {}
+    for key, value in mapping.items():
+        if key in _SKIPPED_METADATA:
+            continue
+        try:
+            json.dumps(value)
+        except (TypeError, ValueError):
+            continue
+        safe[key] = value
+    return safe
+
+
+@dataclass
+class HistoryEntry:
+    id: str
+    kind: str
+    timestamp: str
+    source: Dict[str, Any] = field(default_factory=dict)
+    target: Optional[str] = None
+    plugin: Optional[str] = None
+    settings: Dict[str, Any] = field(default_factory=dict)
+    stats: Dict[str, Any] = field(default_factory=dict)
+    report: Optional[Dict[str, Any]] = None
+
+    def to_dict(self) -> Dict[str, Any]:
+        return dict(vars(self))
+
+    @classmethod
+    def from_dict(cls, data: Mapping[str, Any]) -> "HistoryEntry":
+        known = {k: data[k] for k in cls.__dataclass_fields__ if k in data}
+        return cls(**known)
+
+    @property
+    def source_name(self) -> str:
+        return str(self.source.get("name") or Path(str(self.source.get("path") or "")).name or "-")
+
+    def summary(self) -> str:
+        counts = (self.report or {}).get("counts") or {}
+        parts = [f"{self.stats.get('topics', '-')} topics"]
+        if counts:
+            parts.append(f"{counts.get('error', 0)} error(s), {counts.get('warning', 0)} warning(s)")
+        if (self.report or {}).get("partial"):
+            parts.append("partial")
+        return ", ".join(parts)
+
+
+class HistoryStore:
+    """Directory of :class:`HistoryEntry` JSON files."""
+
+    def __init__(self, path: Optional[str | Path] = None, *, max_entries: int = 200, enabled: bool = True) -> None:
+        self.path = Path(path).expanduser() if path else _default_dir()
+        self.max_entries = max(1, int(max_entries))
+        self.enabled = enabled
+        self._lock = threading.Lock()
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "HistoryStore":
+        data = data or {}
+        try:
+            return cls(data.get("path") or None, max_entries=int(data.get("max_entries", 200)),
+                       enabled=bool(data.get("enabled", True)))
+        except (TypeError, ValueError) as exc:
+            logger.warning("Invalid history settings (%s); using defaults", exc)
+            return cls()
+
+    @classmethod
+    def from_config(cls) -> "HistoryStore":
+        try:
+            from orlando_toolkit.config import ConfigManager
+            return cls.from_mapping((ConfigManager().get_pipeline_config() or {}).get("history"))
+        except Exception as exc:
+            logger.debug("Pipeline config unavailable, using default history store: %s", exc)
+            return cls()
+
+    # ------------------------------------------------------------------
+    # Recording
+    # ------------------------------------------------------------------
+    def record(self, kind: str, *, source: Union[str, Path, Mapping[str, Any], None] = None,
+               target: Optional[str | Path] = None, context: Any = None,
+               stats: Optional[Mapping[str, Any]] = None) -> Optional[HistoryEntry]:
+        """Record one operation; *context* supplies settings, plugin and report.
+
+        *source* is a path (fingerprinted here) or an already computed
+        :class:`~orlando_toolkit.core.project.SourceInfo` mapping.
+        """
+        if not self.enabled:
+            return None
+        try:
+            if source is not None and not isinstance(source, Mapping):
+                from orlando_toolkit.core.project import SourceInfo
+
+                source = {k: v for k, v in vars(SourceInfo.of(source)).items() if v is not None}
+            now = datetime.now(timezone.utc)
+            entry = HistoryEntry(
+                id=now.strftime("%Y%m%d-%H%M%S-%f"),
+                kind=kind,
+                timestamp=now.isoformat(timespec="seconds"),
+                source=dict(source or {}),
+                target=str(target) if target is not None else None,
+                stats=dict(stats or {}),
+            )
+            if context is not None:
+                entry.settings = _json_safe(getattr(context, "metadata", None) or {})
+                entry.plugin = (getattr(context, "plugin_data", None) or {}).get("_source_plugin")
+                entry.stats.setdefault("topics", len(getattr(context, "topics", {}) or {}))
+                entry.stats.setdefault("images", len(getattr(context, "images", {}) or {}))
+                report = getattr(context, "report", None)
+                entry.report = report.to_dict() if report is not None else None
+            with self._lock:
+                self.path.mkdir(parents=True, exist_ok=True)
+                # Ids sort chronologically; bump the last digits on a collision
+                while (self.path / f"{entry.id}.json").exists():
+                    entry.id = f"{entry.id[:-6]}{int(entry.id[-6:]) + 1:06d}"
+                (self.path / f"{entry.id}.json").write_text(
+                    json.dumps(entry.to_dict(), ensure_ascii=False, indent=1), encoding="utf-8")
+                self._prune()
+            return entry
+        except Exception as exc:
+            logger.warning("Could not record %s in history: %s", kind, exc)
+            return None
+
+    def _prune(self) -> None:
+        files = sorted(self.path.glob("*.json"))
+        for old in files[:-self.max_entries]:
+            try:
+                old.unlink()
+            except OSError:
+                pass
+
+    # ------------------------------------------------------------------
+    # Queries
+    # ------------------------------------------------------------------
+    def list(self, *, kind: Optional[str] = None, source: Optional[str] = None,
+             limit: Optional[int] = 20) -> List[HistoryEntry]:
+        """Entries newest first, optionally filtered by kind and source name."""
+        entries: List[HistoryEntry] = []
+        if not self.path.is_dir():
+            return entries
+        for file in sorted(self.path.glob("*.json"), reverse=True):
+            try:
+                entry = HistoryEntry.from_dict(json.loads(file.read_text(encoding="utf-8")))
+            except Exception as exc:
+                logger.debug("Skipping unreadable history record %s: %s", file.name, exc)
+                continue
+            if kind and entry.kind != kind:
+                continue
+            if source and source.lower() not in entry.source_name.lower():
+                continue
+            entries.append(entry)
+            if limit and len(entries) >= limit:
+                break
+        return entries
+
+    def get(self, entry_id: str) -> Optional[HistoryEntry]:
+        """Entry whose id is or starts with *entry_id* (the most recent match)."""
+        if not self.path.is_dir():
+            return None
+        for file in sorted(self.path.glob(f"{entry_id}*.json"), reverse=True):
+            try:
+                return HistoryEntry.from_dict(json.loads(file.read_text(encoding="utf-8")))
+            except Exception as exc:
+                logger.debug("Unreadable history record %s: %s", file.name, exc)
+        return None
+
+    def recent_projects(self, limit: int = 10) -> List[str]:
+        """Project files saved or opened recently, most recent first, existing only."""
+        seen: List[str] = []
+        for entry in self.list(kind="project", limit=None):
+            if entry.target and entry.target not in seen and Path(entry.target).exists():
+                seen.append(entry.target)
+            if len(seen) >= limit:
+                break
+        return seen
+
+    def clear(self) -> int:
+        removed = 0
+        with self._lock:
+            for file in self.path.glob("*.json") if self.path.is_dir() else []:
+                file.unlink()
+                removed += 1
+        return removed
+
+
+def compare_entries(old: HistoryEntry, new: HistoryEntry) -> Dict[str, Any]:
+    """Differences between two entries: stats, report counts per category, settings."""
+    def _categories(entry: HistoryEntry) -> Dict[str, int]:
+        counts: Dict[str, int] = {}
+        for item in (entry.report or {}).get("entries", []):
+            key = f"{item.get('severity')}:{item.get('category')}"
+            counts[key] = counts.get(key, 0) + 1
+        return counts
+
+    def _delta(a: Mapping[str, Any], b: Mapping[str, Any]) -> Dict[str, Any]:
+        return {k: {"old": a.get(k), "new": b.get(k)} for k in sorted(set(a) | set(b)) if a.get(k) != b.get(k)}
+
+    return {
+        "stats": _delta(old.stats, new.stats),
+        "report": _delta(_categories(old), _categories(new)),
+        "settings": _delta(old.settings, new.settings),
+        "source_changed": old.source.get("sha256") != new.source.get("sha256"),
+    }
+
+
+_store: Optional[HistoryStore] = None
+_store_lock = threading.Lock()
+
+
+def get_history_store() -> HistoryStore:
+    """Return the process-wide history store, configured on first use."""
+    global _store
+    if _store is None:
+        with _store_lock:
+            if _store is None:
+                _store = HistoryStore.from_config()
+    return _store
+
+
+def set_history_store(store: Optional[HistoryStore]) -> None:
+    """Replace the process-wide store (``None`` re-reads the configuration)."""
+    global _store
+    _store = store
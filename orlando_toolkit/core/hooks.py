@@ -0,0 +1,141 @@
+from __future__ import annotations
+
+"""Conversion post-processing hooks.
+
+Changes a team used to patch into the generator are written as hooks
+instead: objects implementing any of the
+:class:`~orlando_toolkit.core.plugins.interfaces.ConversionHook` methods,
+called with the :class:`DitaContext` at four points:
+
+- ``parse``: the handler (or built-in parser) returned the topics, before
+  the processing stages;
+- ``topics``: the processing stages have run;
+- ``structure``: :meth:`ConversionService.prepare_package` has applied the
+  merges, renames and key references;
+- ``package``: the package files are written (``package_dir``), before they
+  are zipped.
+
+Hooks come from active plugins (``register_service("ConversionHook", ...)``)
+and from installed packages declaring an ``orlando_toolkit.hooks`` entry
+point, registered at startup by :func:`discover_hooks` under the entry
+point name. The ``hooks`` section of ``conversion.yml``, which output
+profiles may override, orders them (``order``; the others follow by name)
+and turns them off (``disabled``). A failing hook is recorded in the
+conversion report (``OTK302``) and never aborts the conversion.
+"""
+
+import logging
+import time
+from typing import Any, Dict, List, Mapping, Optional, Sequence, Tuple
+
+from orlando_toolkit.core.cancellation import OperationCancelledError
+from orlando_toolkit.core.errors import ToolkitError, report_error
+from orlando_toolkit.core.models import DitaContext
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["ENTRY_POINT_GROUP", "HOOK_POINTS", "HOOK_SERVICE", "discover_hooks", "hook_points", "resolve_hooks",
+           "run_hooks"]
+
+ENTRY_POINT_GROUP = "orlando_toolkit.hooks"
+HOOK_POINTS = ("parse", "topics", "structure", "package")
+HOOK_SERVICE = "ConversionHook"
+
+
+def _entry_points() -> Sequence[Any]:
+    from importlib import metadata
+
+    try:
+        return list(metadata.entry_points(group=ENTRY_POINT_GROUP))
+    except TypeError:  # Python < 3.10
+        return list(metadata.entry_points().get(ENTRY_POINT_GROUP, ()))
+
+
+def discover_hooks(registry: Any) -> List[str]:
+    """Register the hooks published under the ``orlando_toolkit.hooks`` entry point group.
+
+    An entry point names a hook object, a class or a factory called without
+    arguments. Returns the names registered; entry points failing to load
+    are logged and skipped.
+    """
+    if registry is None:
+        return []
+    names: List[str] = []
+    for entry in _entry_points():
+        if registry.get_service(HOOK_SERVICE, entry.name) is not None:
+            continue
+        try:
+            hook = entry.load()
+            if isinstance(hook, type) or (callable(hook) and not any(hook_points(hook).values())):
+                hook = hook()
+            registry.register_service(HOOK_SERVICE, hook, entry.name)
+        except Exception as exc:
+            logger.error("Conversion hook %s (%s) could not be loaded: %s", entry.name, entry.value, exc)
+            continue
+        names.append(entry.name)
+    if names:
+        logger.info("Conversion hooks from installed packages: %s", ", ".join(names))
+    return names
+
+
+def _registry(registry: Any) -> Any:
+    if registry is not None:
+        return registry
+    from orlando_toolkit.core.context import get_app_context
+
+    app = get_app_context()
+    return getattr(app, "service_registry", None) if app is not None else None
+
+
+def resolve_hooks(options: Optional[Mapping[str, Any]], registry: Any = None) -> List[Tuple[str, Any]]:
+    """``(name, hook)`` of the enabled hooks in the order they run."""
+    options = options or {}
+    registry = _registry(registry)
+    if registry is None or not options.get("enabled", True):
+        return []
+    hooks = registry.get_service_providers(HOOK_SERVICE)
+    disabled = {str(name) for name in options.get("disabled") or ()}
+    order = [str(name) for name in options.get("order") or () if str(name) in hooks]
+    names = list(dict.fromkeys(order + sorted(hooks)))
+    return [(name, hooks[name]) for name in names if name not in disabled]
+
+
+def run_hooks(point: str, context: DitaContext, *, registry: Any = None,
+              metadata: Optional[Mapping[str, Any]] = None, **kwargs: Any) -> DitaContext:
+    """Call ``on_<point>`` of each enabled hook with *context* (and *kwargs*).
+
+    A hook may return a replacement :class:`DitaContext`; other return
+    values are ignored.
+    """
+    if point not in HOOK_POINTS:
+        raise ValueError(f"Unknown hook point {point!r} (expected {', '.join(HOOK_POINTS)})")
+    from orlando_toolkit.core.processing import resolve_conversion_options
+
+    options = resolve_conversion_options(metadata if metadata is not None else context.metadata).get("hooks")
+    hooks = resolve_hooks(options if isinstance(options, Mapping) else {}, registry)
+    report = getattr(context, "report", None)
+    for name, hook in hooks:
+        method = getattr(hook, f"on_{point}", None)
+        if not callable(method):
+            continue
+        started = time.perf_counter()
+        try:
+            result = method(context, **kwargs)
+            if isinstance(result, DitaContext):
+                context = result
+        except OperationCancelledError:
+            raise
+        except Exception as exc:
+            logger.error("Conversion hook %s failed at %s: %s", name, point, exc, exc_info=True)
+            if report is not None:
+                report_error(report, ToolkitError(f"Hook {name} failed at {point}: {exc}", code="OTK302",
+                                                  cause=exc), "hooks", hook=name, point=point)
+        finally:
+            if report is not None:
+                report.record_timing(f"hook:{name}", time.perf_counter() - started)
+    return context
+
+
+def hook_points(hook: Any) -> Dict[str, bool]:
+    """Which hook points *hook* implements (for the Plugin Manager)."""
+    return {point: callable(getattr(hook, f"on_{point}", None)) for point in HOOK_POINTS}
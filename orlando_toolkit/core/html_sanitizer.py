@@ -0,0 +1,210 @@
+from __future__ import annotations
+
+"""Allow-list sanitizer for HTML entering the document model.
+
+Importers that accept HTML (an HTML importer, ``altChunk`` or clipboard HTML
+parts inside Office files, rich-text fields) must pass it through
+:func:`sanitize_html` before mapping it to DITA. The policy comes from
+``security.yml`` (``html``):
+
+- elements not in ``allowed_elements`` are unwrapped (their text is kept);
+  elements in ``drop_content`` (``script``, ``style``, ``iframe``, …) are
+  removed together with everything inside them;
+- attributes must be allowed globally (``"*"``) or for the element;
+  ``on*`` event handlers and ``style`` are never kept unless listed;
+- URL attributes (``href``, ``src``, …) keep relative URLs and absolute
+  URLs whose scheme is in ``url_schemes``; ``data:`` image sources only
+  with ``allow_data_images``.
+
+Sources whose markup is not HTML still check the absolute link and image
+targets they produce with :meth:`HtmlSanitizerPolicy.url_allowed` (the
+Markdown and AsciiDoc importers do).
+
+Comments, processing instructions and doctype declarations are dropped.
+Parsing uses the standard library's tolerant HTML tokenizer, so malformed
+markup still yields balanced output.
+"""
+
+import html
+import logging
+import re
+from dataclasses import dataclass, field
+from html.parser import HTMLParser
+from typing import Any, Dict, FrozenSet, List, Mapping, Optional, Tuple
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["HtmlSanitizerPolicy", "sanitize_html"]
+
+_DEFAULT_ELEMENTS = frozenset("""
+    p br b strong i em u s sub sup small mark span div a q cite abbr code kbd samp var pre blockquote hr
+    h1 h2 h3 h4 h5 h6 ul ol li dl dt dd table caption thead tbody tfoot tr th td colgroup col
+    img figure figcaption
+""".split())
+_DEFAULT_ATTRIBUTES = {
+    "*": frozenset({"id", "class", "title", "lang", "dir"}),
+    "a": frozenset({"href", "name"}),
+    "img": frozenset({"src", "alt", "width", "height"}),
+    "td": frozenset({"colspan", "rowspan", "headers"}),
+    "th": frozenset({"colspan", "rowspan", "headers", "scope"}),
+    "col": frozenset({"span"}),
+    "colgroup": frozenset({"span"}),
+    "ol": frozenset({"start", "type"}),
+    "blockquote": frozenset({"cite"}),
+    "q": frozenset({"cite"}),
+}
+_DEFAULT_DROP = frozenset({"script", "style", "iframe", "frame", "frameset", "object", "embed", "applet",
+                           "noscript", "template", "svg", "math", "form", "input", "button", "select",
+                           "textarea", "link", "meta", "base"})
+_URL_ATTRIBUTES = frozenset({"href", "src", "cite", "action", "formaction", "poster", "background",
+                             "longdesc", "usemap", "xlink:href"})
+_VOID = frozenset({"br", "hr", "img", "col", "wbr", "area", "source", "track"})
+_SCHEME_RE = re.compile(r"^([a-zA-Z][a-zA-Z0-9+.-]*):")
+_CONTROL_RE = re.compile(r"[\x00-\x20\x7f]+")
+
+
+@dataclass(frozen=True)
+class HtmlSanitizerPolicy:
+    allowed_elements: FrozenSet[str] = _DEFAULT_ELEMENTS
+    allowed_attributes: Mapping[str, FrozenSet[str]] = field(default_factory=lambda: dict(_DEFAULT_ATTRIBUTES))
+    drop_content: FrozenSet[str] = _DEFAULT_DROP
+    url_schemes: FrozenSet[str] = frozenset({"http", "https", "mailto"})
+    allow_data_images: bool = False
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "HtmlSanitizerPolicy":
+        """Build from the ``html`` section of ``security.yml``; missing keys keep defaults."""
+        data = data or {}
+        defaults = cls()
+
+        def _names(key: str, default: FrozenSet[str]) -> FrozenSet[str]:
+            value = data.get(key)
+            return frozenset(str(v).strip().lower() for v in value) if value is not None else default
+
+        attributes = dict(defaults.allowed_attributes)
+        if isinstance(data.get("allowed_attributes"), Mapping):
+            attributes = {str(k).strip().lower(): frozenset(str(a).strip().lower() for a in (v or ()))
+                          for k, v in data["allowed_attributes"].items()}
+        return cls(
+            allowed_elements=_names("allowed_elements", defaults.allowed_elements),
+            allowed_attributes=attributes,
+            drop_content=_names("drop_content", defaults.drop_content),
+            url_schemes=_names("url_schemes", defaults.url_schemes),
+            allow_data_images=bool(data.get("allow_data_images", False)),
+        )
+
+    @classmethod
+    def from_config(cls) -> "HtmlSanitizerPolicy":
+        try:
+            from orlando_toolkit.config import ConfigManager
+            return cls.from_mapping((ConfigManager().get_security_config() or {}).get("html"))
+        except Exception as exc:
+            logger.debug("Security config unavailable, using default HTML policy: %s", exc)
+            return cls()
+
+    def attribute_allowed(self, tag: str, name: str) -> bool:
+        return name in self.allowed_attributes.get("*", ()) or name in self.allowed_attributes.get(tag, ())
+
+    def url_allowed(self, tag: str, value: str) -> bool:
+        compact = _CONTROL_RE.sub("", html.unescape(value))
+        match = _SCHEME_RE.match(compact)
+        if match is None:
+            return True  # relative URL or fragment
+        scheme = match.group(1).lower()
+        if scheme == "data":
+            return self.allow_data_images and tag == "img" and compact[5:].lower().startswith("image/")
+        return scheme in self.url_schemes
+
+
+class _Sanitizer(HTMLParser):
+    def __init__(self, policy: HtmlSanitizerPolicy, stats: Dict[str, int]) -> None:
+        super().__init__(convert_charrefs=True)
+        self.policy = policy
+        self.stats = stats
+        self.out: List[str] = []
+        self.open: List[str] = []
+        self.skip_depth = 0
+        self.skip_tag: Optional[str] = None
+
+    def _count(self, key: str) -> None:
+        self.stats[key] = self.stats.get(key, 0) + 1
+
+    def _attributes(self, tag: str, attrs: List[Tuple[str, Optional[str]]]) -> str:
+        parts = []
+        for name, value in attrs:
+            name = name.lower()
+            if name.startswith("on") or not self.policy.attribute_allowed(tag, name):
+                self._count("attributes")
+                continue
+            value = value or ""
+            if name in _URL_ATTRIBUTES and not self.policy.url_allowed(tag, value):
+                self._count("urls")
+                continue
+            parts.append(f' {name}="{html.escape(value, quote=True)}"')
+        return "".join(parts)
+
+    def handle_starttag(self, tag: str, attrs) -> None:
+        if self.skip_depth:
+            if tag == self.skip_tag and tag not in _VOID:
+                self.skip_depth += 1
+            return
+        if tag in self.policy.drop_content:
+            self._count("dropped")
+            if tag not in _VOID:
+                self.skip_depth, self.skip_tag = 1, tag
+            return
+        if tag not in self.policy.allowed_elements:
+            self._count("unwrapped")
+            return
+        self.out.append(f"<{tag}{self._attributes(tag, attrs)}>")
+        if tag not in _VOID:
+            self.open.append(tag)
+
+    def handle_startendtag(self, tag: str, attrs) -> None:
+        if not self.skip_depth and tag in self.policy.drop_content:
+            self._count("dropped")
+            return
+        self.handle_starttag(tag, attrs)
+        if tag not in _VOID and not self.skip_depth and self.open and self.open[-1] == tag:
+            self.handle_endtag(tag)
+
+    def handle_endtag(self, tag: str) -> None:
+        if self.skip_depth:
+            if tag == self.skip_tag:
+                self.skip_depth -= 1
+            return
+        if tag not in self.open:
+            return
+        while self.open:
+            current = self.open.pop()
+            self.out.append(f"</{current}>")
+            if current == tag:
+                break
+
+    def handle_data(self, data: str) -> None:
+        if not self.skip_depth:
+            self.out.append(html.escape(data, quote=False))
+
+    def handle_comment(self, data: str) -> None:
+        self._count("comments")
+
+    def close(self) -> None:
+        super().close()
+        while self.open:
+            self.out.append(f"</{self.open.pop()}>")
+
+
+def sanitize_html(text: str, policy: Optional[HtmlSanitizerPolicy] = None,
+                  stats: Optional[Dict[str, int]] = None) -> str:
+    """Return *text* reduced to what *policy* allows.
+
+    *stats*, when given, receives counts of ``dropped`` and ``unwrapped``
+    elements, removed ``attributes`` and ``urls``, and ``comments``, so
+    importers can add them to the conversion report.
+    """
+    policy = policy or HtmlSanitizerPolicy.from_config()
+    counts: Dict[str, int] = stats if stats is not None else {}
+    parser = _Sanitizer(policy, counts)
+    parser.feed(text or "")
+    parser.close()
+    return "".join(parser.out)
@@ -0,0 +1,176 @@
+from __future__ import annotations
+
+"""Localizable message catalog for generated text.
+
+Any text the toolkit writes on its own (placeholder titles, note labels,
+table/figure captions, preview placeholders) is looked up here by key and by
+the document language instead of being hard-coded in English. Catalogs come
+from ``messages.yml`` (see :class:`orlando_toolkit.config.ConfigManager`);
+users can add languages or override wording in their config directory.
+
+Lookup falls back from the exact language tag (``pt-BR``) to its primary
+subtag (``pt``), then to the catalog's ``default_language``, then to the
+built-in English strings and finally to the key itself, so a missing
+translation never breaks a conversion.
+"""
+
+import logging
+from typing import Any, Dict, Mapping, Optional
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "DEFAULT_LANGUAGE",
+    "XML_LANG",
+    "canonical_language_tag",
+    "MessageCatalog",
+    "document_language",
+    "get_catalog",
+    "message",
+    "normalize_language",
+]
+
+DEFAULT_LANGUAGE = "en"
+XML_LANG = "{http://www.w3.org/XML/1998/namespace}lang"
+
+# Last-resort English strings, used when messages.yml is unavailable
+_BUILTIN: Dict[str, str] = {
+    "untitled": "Untitled",
+    "untitled_preview": "(untitled)",
+    "video_placeholder": "[Video: {name}]",
+    "table_caption": "Table {number}",
+    "figure_caption": "Figure {number}",
+    "note_label": "Note",
+    "note_heading": "{label}:",
+    "notices_title": "Legal notices",
+    "reuse_title": "Reusable content",
+    "safety_notices_title": "Safety notices",
+    "callout_number": "No.",
+    "callout_part": "Part",
+    "cover_document_number": "Document No. {value}",
+    "cover_issue": "Issue {value}",
+    "cover_date": "Date: {value}",
+}
+
+
+def normalize_language(tag: Any) -> Optional[str]:
+    """Return *tag* as a lowercase BCP 47 tag (``fr_FR`` → ``fr-fr``)."""
+    if not tag:
+        return None
+    text = str(tag).strip().replace("_", "-").lower()
+    return text or None
+
+
+def canonical_language_tag(tag: Any) -> Optional[str]:
+    """Return *tag* in conventional BCP 47 casing (``en_us`` → ``en-US``).
+
+    Used when writing ``xml:lang``; comparisons should use
+    :func:`normalize_language`.
+    """
+    norm = normalize_language(tag)
+    if not norm:
+        return None
+    parts = norm.split("-")
+    out = [parts[0]]
+    for part in parts[1:]:
+        if len(part) == 2 and part.isalpha():
+            out.append(part.upper())
+        elif len(part) == 4 and part.isalpha():
+            out.append(part.title())
+        else:
+            out.append(part)
+    return "-".join(out)
+
+
+class MessageCatalog:
+    """Messages keyed by language, then by message key."""
+
+    def __init__(self, catalogs: Optional[Mapping[str, Any]] = None,
+                 default_language: str = DEFAULT_LANGUAGE) -> None:
+        self._catalogs: Dict[str, Dict[str, str]] = {}
+        for lang, entries in (catalogs or {}).items():
+            norm = normalize_language(lang)
+            if norm and isinstance(entries, Mapping):
+                self._catalogs[norm] = {str(k): str(v) for k, v in entries.items() if v is not None}
+        self.default_language = normalize_language(default_language) or DEFAULT_LANGUAGE
+
+    @classmethod
+    def from_config(cls) -> "MessageCatalog":
+        """Build the catalog from the ``messages`` config section."""
+        data: Dict[str, Any] = {}
+        try:
+            from orlando_toolkit.config import ConfigManager
+            data = dict(ConfigManager().get_messages_config() or {})
+        except Exception as exc:
+            logger.debug("Message catalog unavailable, using built-in English: %s", exc)
+        default = data.pop("default_language", DEFAULT_LANGUAGE)
+        return cls(data, default_language=default)
+
+    def languages(self) -> list:
+        return sorted(self._catalogs)
+
+    def get(self, key: str, lang: Optional[str] = None, **params: Any) -> str:
+        """Return the message for *key* in *lang*, formatted with *params*."""
+        template = self._lookup(key, normalize_language(lang))
+        if not params:
+            return template
+        try:
+            return template.format(**params)
+        except (KeyError, IndexError, ValueError) as exc:
+            logger.warning("Cannot format message %r (%s): %s", key, lang, exc)
+            return template
+
+    def _lookup(self, key: str, lang: Optional[str]) -> str:
+        candidates = []
+        if lang:
+            candidates.append(lang)
+            primary = lang.split("-", 1)[0]
+            if primary != lang:
+                candidates.append(primary)
+        candidates.append(self.default_language)
+        for candidate in candidates:
+            value = self._catalogs.get(candidate, {}).get(key)
+            if value is not None:
+                return value
+        return _BUILTIN.get(key, key)
+
+
+def get_catalog() -> MessageCatalog:
+    """Return the configured catalog."""
+    return MessageCatalog.from_config()
+
+
+def message(key: str, lang: Optional[str] = None, **params: Any) -> str:
+    """Shorthand for ``get_catalog().get(key, lang, **params)``."""
+    return get_catalog().get(key, lang, **params)
+
+
+def document_language(context: Any) -> str:
+    """Return the language of *context*.
+
+    Resolution order: ``metadata["language"]``, ``xml:lang`` on the map root,
+    ``xml:lang`` on the first topic carrying one, then the catalog default.
+    """
+    if context is None:
+        return get_catalog().default_language
+    try:
+        lang = normalize_language((getattr(context, "metadata", None) or {}).get("language"))
+        if lang:
+            return lang
+    except Exception:
+        pass
+    roots = [getattr(context, "ditamap_root", None)]
+    try:
+        roots.extend((getattr(context, "topics", None) or {}).values())
+    except Exception:
+        pass
+    for root in roots:
+        if root is None:
+            continue
+        try:
+            lang = normalize_language(root.get(XML_LANG))
+        except Exception:
+            lang = None
+        if lang:
+            return lang
+    return get_catalog().default_language
@@ -0,0 +1,194 @@
+from __future__ import annotations
+
+"""Topic file names and ids produced by a naming strategy.
+
+:func:`~orlando_toolkit.core.package_utils.update_topic_references_and_names`
+names each referenced topic, in map order, with the strategy ``ids.naming``
+selects (``conversion.yml``, or the ``conversion_options`` of an output
+profile). The topic ``@id`` is the file name without ``.dita``.
+
+- ``slug`` (default) - ``topic_<section>_<title slug>``, shaped by
+  ``ids.include_section_number`` and ``ids.stable``;
+- ``sequential`` - ``ids.pattern`` rendered with ``{seq}`` (position of the
+  topic in the map, from ``ids.start``; ``{seq:04}`` pads it),
+  ``{manual_code}``, ``{section}`` (``3-2-1``) and ``{slug}``, e.g.
+  ``TP-{manual_code}-{seq:04}``;
+- ``hash`` - ``topic_`` and 12 hex digits of the SHA-1 of the heading anchor
+  or heading path, so the name follows the heading rather than its position;
+- ``guid`` - ``GUID-<uuid>``; random, or derived from the heading anchor or
+  path (UUID version 5) in stable or reproducible mode.
+
+Other strategies come from active plugins
+(``register_service("IdStrategy", strategy, plugin_id)``, named after the
+plugin) and from installed
+packages declaring an ``orlando_toolkit.id_strategies`` entry point,
+registered at startup by :func:`discover_id_strategies` under the entry
+point name. A strategy is an object with ``topic_name(info)`` returning
+the name for a :class:`TopicNameInfo`.
+
+Whatever the strategy returns is made a valid id: characters other than
+letters, digits, ``.``, ``-`` and ``_`` become ``_`` and a name not
+starting with a letter or ``_`` gets a ``topic_`` prefix. Names reused
+from ``ids.previous_map`` still win, and names given twice are told apart
+by ``ids.strategy`` (``suffix``, ``path`` or ``hash``).
+"""
+
+import logging
+import re
+import string
+import uuid
+from dataclasses import dataclass
+from typing import Any, List, Mapping, Optional, Sequence
+
+from orlando_toolkit.core.stable_ids import stable_hash
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["ENTRY_POINT_GROUP", "ID_STRATEGY_SERVICE", "NAMINGS", "PATTERN_FIELDS", "GuidNaming", "HashNaming",
+           "SequentialNaming", "TopicNameInfo", "available_namings", "discover_id_strategies", "resolve_naming",
+           "safe_name"]
+
+ENTRY_POINT_GROUP = "orlando_toolkit.id_strategies"
+ID_STRATEGY_SERVICE = "IdStrategy"
+#: Built-in strategies; ``slug`` is the packager's own naming
+NAMINGS = ("slug", "sequential", "hash", "guid")
+PATTERN_FIELDS = ("seq", "manual_code", "section", "slug")
+
+_DEFAULT_PATTERN = "topic_{seq:04}"
+_UNSAFE = re.compile(r"[^A-Za-z0-9._-]+")
+
+
+@dataclass(frozen=True)
+class TopicNameInfo:
+    """What a strategy knows about the topic it names."""
+
+    title: str
+    slug: str
+    #: Section number with dashes (``3-2-1``), ``0`` when the topic has none
+    section: str
+    #: Position of the topic among the named topics, from ``ids.start``
+    seq: int
+    manual_code: str
+    #: Ancestor titles and the title, joined with ``/``
+    path: str
+    #: Heading anchor or heading path the stable names derive from
+    key: str
+    stable: bool = False
+    topic: Any = None
+    topicref: Any = None
+
+
+def safe_name(name: str, max_length: int = 115) -> str:
+    """*name* as a file name stem usable as an XML id."""
+    name = _UNSAFE.sub("_", str(name or "")).strip("._-") or "topic"
+    if not (name[0].isalpha() or name[0] == "_"):
+        name = f"topic_{name}"
+    return name[:max_length]
+
+
+class SequentialNaming:
+    """Names rendered from a pattern around the topic's position in the map."""
+
+    def __init__(self, pattern: Optional[str] = None):
+        self.pattern = str(pattern or _DEFAULT_PATTERN)
+        try:
+            fields = [name for _text, name, _spec, _conv in string.Formatter().parse(self.pattern) if name is not None]
+        except ValueError as exc:
+            raise ValueError(f"Invalid ids.pattern {self.pattern!r}: {exc}") from exc
+        unknown = [name for name in fields if name not in PATTERN_FIELDS]
+        if unknown:
+            raise ValueError(f"Unknown ids.pattern placeholder(s) {', '.join(unknown)} "
+                             f"(expected {', '.join(PATTERN_FIELDS)})")
+        if "seq" not in fields:
+            raise ValueError(f"ids.pattern {self.pattern!r} needs {{seq}}")
+
+    def topic_name(self, info: TopicNameInfo) -> str:
+        return self.pattern.format(seq=info.seq, manual_code=info.manual_code, section=info.section,
+                                   slug=info.slug)
+
+
+class HashNaming:
+    """``topic_<hash>`` of the heading anchor or heading path."""
+
+    def topic_name(self, info: TopicNameInfo) -> str:
+        return f"topic_{stable_hash(info.key, 12)}"
+
+
+class GuidNaming:
+    """``GUID-<uuid>``, derived from the heading in stable mode."""
+
+    def topic_name(self, info: TopicNameInfo) -> str:
+        value = uuid.uuid5(uuid.NAMESPACE_URL, f"orlando-toolkit:{info.key}") if info.stable else uuid.uuid4()
+        return f"GUID-{str(value).upper()}"
+
+
+_BUILTINS = {"sequential": SequentialNaming, "hash": HashNaming, "guid": GuidNaming}
+
+
+def _registry(registry: Any) -> Any:
+    from orlando_toolkit.core.hooks import _registry as app_registry
+
+    return app_registry(registry)
+
+
+def available_namings(registry: Any = None) -> List[str]:
+    """Built-in strategy names followed by those of plugins and installed packages."""
+    registry = _registry(registry)
+    custom = sorted(registry.get_service_providers(ID_STRATEGY_SERVICE)) if registry is not None else []
+    return list(NAMINGS) + [name for name in custom if name not in NAMINGS]
+
+
+def resolve_naming(name: Optional[str], options: Optional[Mapping[str, Any]] = None, registry: Any = None) -> Any:
+    """The strategy called *name*, or None for ``slug``.
+
+    Raises:
+        ValueError: No strategy has that name, or its options are invalid
+    """
+    name = str(name or "slug").strip().lower()
+    if name == "slug":
+        return None
+    if name == "sequential":
+        return SequentialNaming((options or {}).get("pattern"))
+    if name in _BUILTINS:
+        return _BUILTINS[name]()
+    registry = _registry(registry)
+    strategy = registry.get_service(ID_STRATEGY_SERVICE, name) if registry is not None else None
+    if strategy is None or not callable(getattr(strategy, "topic_name", None)):
+        raise ValueError(f"Unknown id naming {name!r} (expected {', '.join(available_namings(registry))})")
+    return strategy
+
+
+def _entry_points() -> Sequence[Any]:
+    from importlib import metadata
+
+    try:
+        return list(metadata.entry_points(group=ENTRY_POINT_GROUP))
+    except TypeError:  # Python < 3.10
+        return list(metadata.entry_points().get(ENTRY_POINT_GROUP, ()))
+
+
+def discover_id_strategies(registry: Any) -> List[str]:
+    """Register the strategies published under the ``orlando_toolkit.id_strategies`` entry point group.
+
+    An entry point names a strategy object, a class or a factory called
+    without arguments. Returns the names registered; entry points failing
+    to load are logged and skipped.
+    """
+    if registry is None:
+        return []
+    names: List[str] = []
+    for entry in _entry_points():
+        if entry.name in NAMINGS or registry.get_service(ID_STRATEGY_SERVICE, entry.name) is not None:
+            continue
+        try:
+            strategy = entry.load()
+            if isinstance(strategy, type) or not callable(getattr(strategy, "topic_name", None)):
+                strategy = strategy()
+            registry.register_service(ID_STRATEGY_SERVICE, strategy, entry.name)
+        except Exception as exc:
+            logger.error("Id strategy %s (%s) could not be loaded: %s", entry.name, entry.value, exc)
+            continue
+        names.append(entry.name)
+    if names:
+        logger.info("Id strategies from installed packages: %s", ", ".join(names))
+    return names
@@ -0,0 +1,220 @@
+from __future__ import annotations
+
+"""Image file names built from a naming template.
+
+Images are renamed when the package is prepared
+(:func:`orlando_toolkit.core.package_utils.update_image_references_and_names`)
+and the Images tab shows the same proposals. The template is a Python format
+string read from ``image_naming.yml``; a conversion profile or the Images tab
+overrides it per document through ``metadata["image_naming"]`` (the older
+``metadata["prefix"]`` still sets the prefix); reproducible output
+(:mod:`orlando_toolkit.core.reproducible`) replaces the template with
+``reproducible.image_pattern``. Placeholders:
+
+- ``{prefix}`` - the configured prefix;
+- ``{manual_code}`` - manual code from the metadata;
+- ``{section}`` - section number of the topic holding the image (``2.1``),
+  ``0`` when the image is not referenced;
+- ``{topic}`` - slug of that topic's title;
+- ``{counter}`` - position of the image within its section, from
+  ``index_start`` and padded to ``index_zero_pad`` digits unless a format
+  is given (``{counter:03}``);
+- ``{number}`` - position of the image in the whole document, same rules;
+- ``{name}`` - original file name without extension;
+- ``{hash}`` - first 8 hex digits of the SHA-1 of the image bytes, so the
+  name follows the picture rather than its position;
+- ``{-index}`` - ``-<counter>``, only when the section holds several images;
+- ``{ext}`` - original extension, appended when the template leaves it out.
+
+Characters not allowed in file names become ``_`` and names produced twice
+are numbered (``name_2.png``).
+"""
+
+import hashlib
+import logging
+import os
+import re
+import string
+from dataclasses import dataclass, replace
+from typing import Any, Dict, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.utils import calculate_section_numbers, slugify
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["ImageNaming", "PLACEHOLDERS", "propose_names"]
+
+PLACEHOLDERS: Tuple[str, ...] = ("prefix", "manual_code", "section", "topic", "counter", "number", "name",
+                                 "hash", "-index", "ext")
+
+_DEFAULT_PATTERN = "{prefix}-{manual_code}-{section}{-index}{ext}"
+_UNSAFE = re.compile(r'[<>:"/\\|?*\s\x00-\x1f]+')
+
+
+class _Counter(int):
+    """Integer placeholder: zero-padded by default, any int format otherwise."""
+
+    pad = 0
+
+    def __format__(self, spec: str) -> str:
+        return format(int(self), spec) if spec else str(int(self)).zfill(self.pad)
+
+
+def _counter(value: int, pad: int) -> _Counter:
+    counter = _Counter(value)
+    counter.pad = pad
+    return counter
+
+
+@dataclass(frozen=True)
+class ImageNaming:
+    """Naming template and counter settings for one document."""
+
+    prefix: str = "IMG"
+    pattern: str = _DEFAULT_PATTERN
+    index_start: int = 1
+    index_zero_pad: int = 0
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]], base: Optional["ImageNaming"] = None) -> "ImageNaming":
+        """Settings from *data*, unset or invalid entries taken from *base*."""
+        naming = base or cls()
+        data = data or {}
+        updates: Dict[str, Any] = {}
+        for key in ("prefix", "pattern"):
+            if data.get(key) is not None:
+                updates[key] = str(data[key])
+        for key in ("index_start", "index_zero_pad"):
+            try:
+                if data.get(key) is not None:
+                    updates[key] = max(0, int(data[key]))
+            except (TypeError, ValueError):
+                logger.warning("Ignoring invalid image naming %s %r", key, data[key])
+        if "pattern" in updates and cls.validate(updates["pattern"]):
+            logger.warning("Ignoring invalid image naming pattern %r: %s", updates["pattern"],
+                           cls.validate(updates["pattern"]))
+            del updates["pattern"]
+        return replace(naming, **updates)
+
+    @classmethod
+    def from_config(cls) -> "ImageNaming":
+        try:
+            from orlando_toolkit.config import ConfigManager
+            return cls.from_mapping(ConfigManager().get_image_naming())
+        except Exception as exc:
+            logger.debug("Image naming config unavailable, using defaults: %s", exc)
+            return cls()
+
+    @classmethod
+    def resolve(cls, metadata: Optional[Mapping[str, Any]]) -> "ImageNaming":
+        """Configured settings overridden by ``image_naming`` and ``prefix`` in *metadata*."""
+        metadata = metadata or {}
+        naming = cls.from_config()
+        if isinstance(metadata.get("image_naming"), Mapping):
+            naming = cls.from_mapping(metadata["image_naming"], naming)
+        if metadata.get("prefix"):
+            naming = replace(naming, prefix=str(metadata["prefix"]))
+        from orlando_toolkit.core.reproducible import reproducible_options
+        reproducible = reproducible_options(metadata)
+        if reproducible.get("enabled") and reproducible.get("image_pattern"):
+            naming = cls.from_mapping({"pattern": reproducible["image_pattern"]}, naming)
+        return naming
+
+    @staticmethod
+    def validate(pattern: str) -> Optional[str]:
+        """Return why *pattern* cannot be used, or ``None`` when it is valid."""
+        if not pattern or not pattern.strip():
+            return "The pattern is empty"
+        try:
+            fields = [f for _, f, _, _ in string.Formatter().parse(pattern) if f is not None]
+        except ValueError as exc:
+            return f"Invalid pattern: {exc}"
+        unknown = [f for f in fields if f not in PLACEHOLDERS]
+        if unknown:
+            return f"Unknown placeholder {{{unknown[0]}}}"
+        try:
+            pattern.format(**_sample_tokens())
+        except (ValueError, TypeError) as exc:
+            return f"Invalid pattern: {exc}"
+        return None
+
+    def render(self, tokens: Mapping[str, Any]) -> str:
+        """File name for one image; *tokens* hold every placeholder but ``prefix``."""
+        name = self.pattern.format(prefix=self.prefix, **tokens)
+        ext = str(tokens.get("ext") or "")
+        stem = name[: -len(ext)] if ext and name.endswith(ext) else name
+        stem = _UNSAFE.sub("_", stem).strip("._") or "image"
+        return stem + ext
+
+
+def _sample_tokens() -> Dict[str, Any]:
+    return {"prefix": "IMG", "manual_code": "MAN", "section": "1.2", "topic": "intro", "counter": _counter(1, 0),
+            "number": _counter(1, 0), "name": "image1", "hash": "0a1b2c3d", "-index": "-1", "ext": ".png"}
+
+
+def _image_locations(context: DitaContext) -> Dict[str, Tuple[str, str]]:
+    """Image file name -> (section number, topic slug) of the first topic using it."""
+    root = context.ditamap_root
+    if root is None:
+        return {}
+    sections = calculate_section_numbers(root)
+    refs: Dict[str, ET._Element] = {}
+    for ref in root.iter("topicref"):
+        href = (ref.get("href") or "").split("#")[0]
+        if href:
+            refs.setdefault(os.path.basename(href), ref)
+    locations: Dict[str, Tuple[str, str]] = {}
+    for topic_name, topic in context.topics.items():
+        ref = refs.get(os.path.basename(topic_name))
+        if ref is None:
+            continue
+        title = ref.findtext("topicmeta/navtitle") or topic.findtext("title") or ""
+        place = (sections.get(ref, "0"), slugify(title))
+        for image in topic.iter("image"):
+            href = image.get("href") or ""
+            if href.startswith("../media/"):
+                locations.setdefault(os.path.basename(href), place)
+    return locations
+
+
+def propose_names(context: DitaContext, naming: Optional[ImageNaming] = None) -> Dict[str, str]:
+    """New file name for every image of *context*, in the order of ``context.images``."""
+    naming = naming or ImageNaming.resolve(context.metadata)
+    locations = _image_locations(context)
+    by_section: Dict[str, list[str]] = {}
+    for filename in context.images:
+        by_section.setdefault(locations.get(filename, ("0", ""))[0], []).append(filename)
+
+    manual_code = str(context.metadata.get("manual_code") or "manual")
+    pad = naming.index_zero_pad
+    hashed = "{hash" in naming.pattern
+    names: Dict[str, str] = {}
+    taken: set[str] = set()
+    for number, filename in enumerate(context.images, start=naming.index_start):
+        section, topic = locations.get(filename, ("0", ""))
+        siblings = by_section[section]
+        counter = siblings.index(filename) + naming.index_start
+        stem, ext = os.path.splitext(filename)
+        tokens = {
+            "manual_code": manual_code,
+            "section": section,
+            "topic": topic,
+            "counter": _counter(counter, pad),
+            "number": _counter(number, pad),
+            "name": stem,
+            "hash": hashlib.sha1(context.images[filename]).hexdigest()[:8] if hashed else "",
+            "-index": f"-{str(counter).zfill(pad)}" if len(siblings) > 1 else "",
+            "ext": ext,
+        }
+        new_name = naming.render(tokens)
+        new_stem, new_ext = os.path.splitext(new_name)
+        suffix = 2
+        while new_name.lower() in taken:
+            new_name = f"{new_stem}_{suffix}{new_ext}"
+            suffix += 1
+        taken.add(new_name.lower())
+        names[filename] = new_name
+    return names
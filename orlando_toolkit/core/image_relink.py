@@ -0,0 +1,176 @@
+from __future__ import annotations
+
+"""Replacing image files and re-linking image references.
+
+The Images tab's **Replace File…** swaps the bytes of an image for another
+file (:func:`replace_image`): the name is kept, or taken from the new file,
+and follows the format of the new file (a JPEG replacing ``fig_1.png``
+becomes ``fig_1.jpg``). **Relink…** points every reference to one image
+at another (:func:`relink_image`), for topics still showing an image that
+was deleted or that should show a better copy.
+
+References are the ``<image>`` elements of the topics and the poster
+``<param>`` of video and audio objects; their folder part is kept. All
+references are collected and checked before the first one changes, so a
+refused call leaves the topics as they were. The alt text lives on the
+``<image>`` and stays; an asset-server mark and the saved callouts follow
+the image to its new name, and callouts are drawn again on the new file.
+"""
+
+import logging
+import posixpath
+import re
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Tuple
+
+from orlando_toolkit.core.placement import topic_order
+from orlando_toolkit.core.processing.vector_images import is_metafile, is_svg
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["image_format", "image_usage", "missing_images", "relink_image", "replace_image"]
+
+_UNSAFE = re.compile(r'[<>:"/\\|?*#%\s\x00-\x1f]+')
+# Header signatures of the raster formats topics may show
+_SIGNATURES = ((b"\x89PNG\r\n\x1a\n", "png"), (b"\xff\xd8\xff", "jpg"), (b"GIF87a", "gif"), (b"GIF89a", "gif"),
+               (b"BM", "bmp"), (b"II*\x00", "tif"), (b"MM\x00*", "tif"))
+_SAME_FORMAT = {"jpeg": "jpg", "tiff": "tif", "svgz": "svg"}
+
+
+def image_format(data: bytes) -> str:
+    """Extension matching the content of *data* (``png``, ``jpg``, ``svg``…), ``""`` when not an image."""
+    for signature, ext in _SIGNATURES:
+        if data.startswith(signature):
+            return ext
+    if data[:4] == b"RIFF" and data[8:12] == b"WEBP":
+        return "webp"
+    if is_svg("", data):
+        return "svg"
+    if is_metafile("", data):
+        return "emf" if data[40:44] == b" EMF" else "wmf"
+    return ""
+
+
+def _references(context: Any) -> List[Tuple[str, Any, str]]:
+    """``(topic file, element, attribute)`` of every image reference, in map order."""
+    refs: List[Tuple[str, Any, str]] = []
+    for topic_name in topic_order(context):
+        topic = context.topics[topic_name]
+        for el in topic.iter("image"):
+            if el.get("href"):
+                refs.append((topic_name, el, "href"))
+        for param in topic.iter("param"):
+            if param.get("valuetype") == "ref" and param.get("value"):
+                refs.append((topic_name, param, "value"))
+    return refs
+
+
+def image_usage(context: Any) -> Dict[str, List[str]]:
+    """Image file name -> topic files referring to it, for every referenced image."""
+    usage: Dict[str, List[str]] = {}
+    for topic_name, el, attr in _references(context):
+        topics = usage.setdefault(posixpath.basename(el.get(attr)), [])
+        if topic_name not in topics:
+            topics.append(topic_name)
+    return usage
+
+
+def missing_images(context: Any) -> List[str]:
+    """Names referenced by the topics that are not among the images (deleted or never imported)."""
+    images = getattr(context, "images", None) or {}
+    videos = getattr(context, "videos", None) or {}
+    return [name for name in image_usage(context) if name not in images and name not in videos]
+
+
+def _follow(context: Any, old: str, new: str) -> None:
+    """Move the asset-server mark and saved callouts of *old* to *new*."""
+    from orlando_toolkit.core.callouts import rename_callouts
+    from orlando_toolkit.core.external_media import rename_marked
+
+    rename_marked(context.metadata, {old: new})
+    rename_callouts(context.metadata, {old: new})
+
+
+def relink_image(context: Any, old: str, new: str, *, remove_old: bool = False) -> int:
+    """Point every reference to image *old* at image *new*; returns the number of references changed.
+
+    With *remove_old*, *old* is also removed from the images and its
+    asset-server mark and callouts go to *new*.
+
+    Raises:
+        ValueError: *new* is not an image of *context*, or is *old*
+    """
+    if new not in (getattr(context, "images", None) or {}):
+        raise ValueError(f"No image named {new}")
+    if new == old:
+        raise ValueError(f"{old} cannot be relinked to itself")
+    changes = [(el, attr) for _topic, el, attr in _references(context)
+               if posixpath.basename(el.get(attr)) == old]
+    for el, attr in changes:
+        el.set(attr, posixpath.join(posixpath.dirname(el.get(attr)), new))
+    if remove_old and old in context.images:
+        del context.images[old]
+        _follow(context, old, new)
+    logger.info("Relinked %d reference(s) from %s to %s", len(changes), old, new)
+    return len(changes)
+
+
+def _new_name(context: Any, name: str, filename: Optional[str], keep_name: bool, ext: str) -> str:
+    stem, current = posixpath.splitext(name)
+    current = current[1:].lower()
+    if keep_name and _SAME_FORMAT.get(current, current) == ext:
+        return name
+    if not keep_name and filename:
+        stem = posixpath.splitext(_UNSAFE.sub("_", Path(filename).name).strip("._"))[0] or stem
+    candidate, n = f"{stem}.{ext}", 2
+    while candidate != name and candidate in context.images:
+        candidate, n = f"{stem}_{n}.{ext}", n + 1
+    return candidate
+
+
+def replace_image(context: Any, name: str, data: bytes, *, filename: Optional[str] = None,
+                  keep_name: bool = True) -> str:
+    """Replace image *name* with *data* and return the name it now has.
+
+    With *keep_name*, the name stays unless the extension must change for
+    the new format; otherwise the name comes from *filename* (the new
+    file), numbered when another image has it. References, the
+    asset-server mark and callouts follow a new name.
+
+    Raises:
+        ValueError: *name* is not an image, *data* is not an image, or the
+            saved callouts cannot be drawn on it
+    """
+    images = getattr(context, "images", None) or {}
+    if name not in images:
+        raise ValueError(f"No image named {name}")
+    ext = image_format(data or b"")
+    if not ext:
+        raise ValueError(f"{filename or 'The new file'} is not a PNG, JPEG, GIF, BMP, TIFF, WebP, SVG "
+                         f"or EMF/WMF image")
+    from orlando_toolkit.core.callouts import image_callouts, image_size, set_image_callouts
+
+    callouts, mode = image_callouts(context.metadata, name)
+    if callouts:
+        if ext in ("svg", "emf", "wmf"):
+            raise ValueError(f"{name} has callouts, which need a raster image (PNG, JPEG, GIF)")
+        size = image_size(data)
+        for callout in callouts:
+            problem = callout.validate(size)
+            if problem:
+                raise ValueError(f"Callout {callout.number} does not fit the new image: {problem}")
+
+    new = _new_name(context, name, filename, keep_name, ext)
+    if new == name:
+        context.images[name] = data
+    else:
+        from orlando_toolkit.core.spool import rename_blobs
+
+        context.images[name] = data
+        context.images = rename_blobs(context.images, {name: new})
+        relink_image(context, name, new)
+        _follow(context, name, new)
+    if callouts:
+        set_image_callouts(context, new, callouts, mode)
+    logger.info("Replaced image %s with %s (%d bytes)", name, new, len(data))
+    return new
@@ -0,0 +1,185 @@
+from __future__ import annotations
+
+"""Clickable image regions as DITA ``<imagemap>``.
+
+Wiring diagrams and exploded views link their parts to the topics that
+describe them. The Images tab draws :class:`MapArea` regions over an image
+(rectangles and polygons, in pixels of the image) and
+:func:`set_image_map` writes them around every ``<image>`` showing that
+file::
+
+    <imagemap>
+      <image href="../media/wiring.png"/>
+      <area><shape>rect</shape><coords>10,20,110,80</coords>
+        <xref href="fuse_box.dita">Fuse box</xref></area>
+    </imagemap>
+
+Area targets are topic file names, linked like other topic links
+(``fuse_box.dita``), or external URLs (``scope="external"``); the topics
+offered are those of the map (:func:`link_targets`). Writing no area turns
+the image map back into the plain image. :func:`image_map_areas` reads the
+regions back for editing; ``circle`` areas written by other tools are kept.
+"""
+
+import logging
+import posixpath
+from dataclasses import dataclass, field
+from typing import Any, List, Optional, Sequence, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.alt_text import _image_elements
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["MapArea", "SHAPES", "image_map_areas", "link_targets", "set_image_map"]
+
+SHAPES = ("rect", "poly", "circle")
+_EXTERNAL = ("http:", "https:", "mailto:", "ftp:")
+
+
+@dataclass
+class MapArea:
+    """A clickable region: :attr:`shape` with its :attr:`coords` in image pixels, linking to :attr:`target`."""
+
+    shape: str
+    coords: List[int] = field(default_factory=list)
+    target: str = ""
+    text: str = ""
+
+    @property
+    def external(self) -> bool:
+        return self.target.lower().startswith(_EXTERNAL)
+
+    def validate(self) -> Optional[str]:
+        """Why the area cannot be written, or None."""
+        count = len(self.coords)
+        if self.shape not in SHAPES:
+            return f"Unknown shape {self.shape!r}"
+        if self.shape == "rect" and count != 4:
+            return "A rectangle needs four coordinates (left, top, right, bottom)"
+        if self.shape == "poly" and (count < 6 or count % 2):
+            return "A polygon needs at least three points"
+        if self.shape == "circle" and count != 3:
+            return "A circle needs three coordinates (x, y, radius)"
+        if any(value < 0 for value in self.coords):
+            return "Coordinates cannot be negative"
+        if not self.target.strip():
+            return "The area has no link target"
+        return None
+
+
+def _text(el: Any) -> str:
+    return " ".join("".join(el.itertext()).split()) if el is not None else ""
+
+
+def _coords(text: str) -> List[int]:
+    values: List[int] = []
+    for part in (text or "").replace(";", ",").replace(" ", ",").split(","):
+        if part.strip():
+            values.append(int(round(float(part))))
+    return values
+
+
+def _is_map(el: Any) -> bool:
+    return el is not None and el.tag == "imagemap"
+
+
+def image_map_areas(context: Any, name: str) -> List[MapArea]:
+    """Areas of the first image map showing the media file *name* (empty when it has none)."""
+    for _, image in _image_elements(context, name):
+        parent = image.getparent()
+        if not _is_map(parent):
+            continue
+        areas: List[MapArea] = []
+        for area in parent.findall("area"):
+            xref = area.find("xref")
+            try:
+                coords = _coords(area.findtext("coords") or "")
+            except ValueError:
+                logger.debug("Skipping an area with unreadable coordinates in the image map of %s", name)
+                continue
+            href = (xref.get("href") or "") if xref is not None else ""
+            areas.append(MapArea((area.findtext("shape") or "rect").strip(), coords, href, _text(xref)))
+        return areas
+    return []
+
+
+def _area(area: MapArea) -> Any:
+    el = ET.Element("area")
+    ET.SubElement(el, "shape").text = area.shape
+    ET.SubElement(el, "coords").text = ",".join(str(value) for value in area.coords)
+    xref = ET.SubElement(el, "xref", href=area.target.strip())
+    if area.external:
+        xref.set("scope", "external")
+        xref.set("format", "html")
+    if area.text.strip():
+        xref.text = " ".join(area.text.split())
+    return el
+
+
+def set_image_map(context: Any, name: str, areas: Sequence[MapArea]) -> int:
+    """Give every ``<image>`` of *name* the image map *areas* (none: plain image); returns the images changed.
+
+    Raises ValueError naming the first invalid area.
+    """
+    for number, area in enumerate(areas, 1):
+        problem = area.validate()
+        if problem:
+            raise ValueError(f"Area {number}: {problem}")
+    count = 0
+    for _, image in list(_image_elements(context, name)):
+        parent = image.getparent()
+        if parent is None:
+            continue
+        if _is_map(parent):
+            imagemap = parent
+            for area in imagemap.findall("area"):
+                imagemap.remove(area)
+        elif not areas:
+            continue
+        else:
+            imagemap = ET.Element("imagemap")
+            imagemap.tail, image.tail = image.tail, None
+            parent.insert(parent.index(image), imagemap)
+            imagemap.append(image)
+        if not areas:
+            holder = imagemap.getparent()
+            image.tail = imagemap.tail
+            holder.insert(holder.index(imagemap), image)
+            holder.remove(imagemap)
+        else:
+            for area in areas:
+                imagemap.append(_area(area))
+        count += 1
+    logger.info("Image map of %s: %d area(s) on %d image(s)", name, len(areas), count)
+    return count
+
+
+def link_targets(context: Any) -> List[Tuple[str, str, int]]:
+    """``(topic file name, title, depth)`` of the topics of the map in map order, as area targets.
+
+    Depth counts the map levels above the topic (topic heads included) so a
+    picker can indent like the structure tree; resource-only topics are left out.
+    """
+    targets: List[Tuple[str, str, int]] = []
+    seen: set = set()
+
+    def _walk(parent: Any, depth: int) -> None:
+        for entry in parent:
+            if not isinstance(entry.tag, str) or entry.get("processing-role") == "resource-only":
+                continue
+            if entry.tag not in ("topicref", "topichead", "topicgroup", "chapter", "appendix", "part"):
+                continue
+            name = posixpath.basename((entry.get("href") or "").partition("#")[0])
+            topic = context.topics.get(name) if name else None
+            if topic is not None and name not in seen:
+                seen.add(name)
+                title = _text(entry.find("topicmeta/navtitle")) or _text(topic.find("title")) or name
+                targets.append((name, title, depth))
+            _walk(entry, depth if entry.tag == "topicgroup" else depth + 1)
+
+    root = getattr(context, "ditamap_root", None)
+    if root is not None:
+        _walk(root, 0)
+    return targets
@@ -6,12 +6,15 @@ This package contains importers for different document archive formats
 that Orlando Toolkit can work with natively, including:
 
 - DITA packages (zipped DITA archives)
-- Future importers for other structured formats
+- Markdown and AsciiDoc sources, through the DocumentParser layer
 
 Key components:
 - DitaPackageImporter: Handles zipped DITA archive import and validation
+- MarkupDocumentImporter: Builds a DitaContext from a DocumentParser's output
 """
 
 from .dita_importer import DitaPackageImporter
+from .markup import DocumentParser, MarkupDocumentImporter, ParsedDocument, Section, register_parser
 
-__all__ = ["DitaPackageImporter"]
\ No newline at end of file
+__all__ = ["DitaPackageImporter", "DocumentParser", "MarkupDocumentImporter", "ParsedDocument", "Section",
+           "register_parser"]
\ No newline at end of file
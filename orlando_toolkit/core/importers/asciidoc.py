@@ -0,0 +1,392 @@
+from __future__ import annotations
+
+"""AsciiDoc source parser (the common subset of the Asciidoctor syntax).
+
+Supported: the document title (``= Title``) and header attributes
+(``:lang:`` sets the language, ``{name}`` references are substituted),
+section titles (``==`` to ``======``; ``[[id]]`` or ``[#id]`` sets the
+anchor, otherwise ``_`` plus the title words), paragraphs, admonition
+paragraphs and blocks (``NOTE:``, ``[WARNING]`` + ``====``), bullet
+(``*``, ``-``) and numbered (``.``) lists with nesting by marker length,
+listing and literal blocks (``[source,python]`` gives
+``outputclass="language-python"``), tables (``|===``, header row from
+``options="header"`` or an implicit header line; ``2+|``, ``.3+|`` and
+``2.3+|`` cell spans give a CALS table), block and inline images
+(``.Title`` above a block image gives the figure title), links
+(``https://…[text]``, ``link:``), cross references (``<<id,text>>``,
+``xref:id[text]``), ``*strong*``, ``_emphasis_`` and ```monospace```.
+Comments are dropped.
+"""
+
+import logging
+import re
+from typing import Any, Dict, List, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.importers.markup import DocumentParser, ParsedDocument, Section
+from orlando_toolkit.core.tables import build_cals, place_cells
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["AsciiDocParser"]
+
+_TITLE = re.compile(r"^(=+)[ \t]+(.+?)[ \t]*$")
+_ATTRIBUTE = re.compile(r"^:([\w-]+)!?:[ \t]*(.*)$")
+_ANCHOR = re.compile(r"^\[\[([\w:.-]+)(?:,[^\]]*)?\]\]$|^\[#([\w:.-]+)[^\]]*\]$")
+_BLOCK_ATTRS = re.compile(r"^\[([^\[\]]*)\]$")
+_BLOCK_TITLE = re.compile(r"^\.([^.\s].*)$")
+_ADMONITION = re.compile(r"^(NOTE|TIP|IMPORTANT|WARNING|CAUTION):[ \t]+(.*)$")
+_BULLET = re.compile(r"^[ \t]*(\*{1,5}|-)[ \t]+(.*)$")
+_ORDERED = re.compile(r"^[ \t]*(\.{1,5})[ \t]+(.*)$")
+_BLOCK_IMAGE = re.compile(r"^image::([^\[\s]+)\[([^\]]*)\]$")
+_CELL_SPEC = re.compile(r"^(\d+)?(?:\.(\d+))?\+\|")
+_CELL_SEPARATOR = re.compile(r"(?:(?:^|(?<=\s))(?:\d+(?:\.\d+)?|\.\d+)\+)?\|")
+_DELIMITERS = {"----": "listing", "....": "literal", "====": "example", "****": "sidebar", "____": "quote"}
+
+_INLINE = re.compile(
+    r"(?P<mono>(?<![\w`])`(?P<mono_text>[^`]+)`(?![\w`]))"
+    r"|(?P<image>image:(?P<src>[^\[\s:][^\[\s]*)\[(?P<alt>[^\]]*)\])"
+    r"|(?P<xref><<(?P<xref_id>[\w:.-]+)(?:,\s*(?P<xref_text>[^>]+))?>>)"
+    r"|(?P<xref_macro>xref:(?P<macro_id>[\w:.#-]+)\[(?P<macro_text>[^\]]*)\])"
+    r"|(?P<link>(?:link:)?(?P<href>(?:https?|mailto|ftp):[^\s\[]+|(?<=link:)[^\s\[]+)\[(?P<link_text>[^\]]*)\])"
+    r"|(?P<url>(?<![\w\[<])(?:https?|ftp)://[^\s\[<>]+[^\s\[<>.,;:!?)])"
+    r"|(?P<strong>(?<![\w*])\*(?P<strong_text>\S(?:[^*]*?\S)?)\*(?![\w*]))"
+    r"|(?P<em>(?<![\w_])_(?P<em_text>\S(?:[^_]*?\S)?)_(?![\w_]))"
+)
+
+
+def _anchor(title: str) -> str:
+    """Asciidoctor's automatic section id: ``_`` and the lowercase title words."""
+    return "_" + re.sub(r"[^\w]+", "_", title.strip().lower()).strip("_")
+
+
+def _append_text(parent, text: str) -> None:
+    if not text:
+        return
+    if len(parent):
+        parent[-1].tail = (parent[-1].tail or "") + text
+    else:
+        parent.text = (parent.text or "") + text
+
+
+def inline(parent, text: str) -> None:
+    """Append the AsciiDoc inline content *text* to *parent*."""
+    position = 0
+    for match in _INLINE.finditer(text):
+        _append_text(parent, text[position:match.start()])
+        position = match.end()
+        kind = match.lastgroup
+        if kind == "mono":
+            ET.SubElement(parent, "codeph").text = match.group("mono_text")
+        elif kind == "image":
+            image = ET.SubElement(parent, "image", href=match.group("src"), placement="inline")
+            alt = match.group("alt").split(",")[0].strip()
+            if alt:
+                ET.SubElement(image, "alt").text = alt
+        elif kind == "xref":
+            xref = ET.SubElement(parent, "xref", href="#" + match.group("xref_id"))
+            if match.group("xref_text"):
+                inline(xref, match.group("xref_text").strip())
+        elif kind == "xref_macro":
+            target = match.group("macro_id")
+            xref = ET.SubElement(parent, "xref", href=target if "#" in target else "#" + target)
+            if match.group("macro_text"):
+                inline(xref, match.group("macro_text"))
+        elif kind == "link":
+            xref = ET.SubElement(parent, "xref", href=match.group("href"))
+            inline(xref, match.group("link_text") or match.group("href"))
+        elif kind == "url":
+            ET.SubElement(parent, "xref", href=match.group("url")).text = match.group("url")
+        elif kind == "strong":
+            inline(ET.SubElement(parent, "b"), match.group("strong_text"))
+        else:
+            inline(ET.SubElement(parent, "i"), match.group("em_text"))
+    _append_text(parent, text[position:])
+
+
+def _attributes(raw: str) -> Tuple[List[str], Dict[str, str]]:
+    """Positional and named attributes of a ``[...]`` block attribute line."""
+    positional: List[str] = []
+    named: Dict[str, str] = {}
+    for part in re.findall(r'(?:[^,"]|"[^"]*")+', raw):
+        part = part.strip()
+        if "=" in part:
+            key, value = part.split("=", 1)
+            named[key.strip()] = value.strip().strip('"')
+        elif part:
+            positional.append(part)
+    return positional, named
+
+
+def _cells(line: str) -> List[List[Any]]:
+    """Cells of a table line as ``[colspan, rowspan, text]`` (``2+|``, ``.3+|``, ``2.3+|`` specifiers)."""
+    cells: List[List[Any]] = []
+    matches = list(_CELL_SEPARATOR.finditer(line))
+    for index, match in enumerate(matches):
+        end = matches[index + 1].start() if index + 1 < len(matches) else len(line)
+        spec = _CELL_SPEC.match(match.group(0))
+        colspan = int(spec.group(1)) if spec and spec.group(1) else 1
+        rowspan = int(spec.group(2)) if spec and spec.group(2) else 1
+        cells.append([colspan, rowspan, line[match.end():end].strip()])
+    return cells
+
+
+def _codeblock(lines: List[str], tag: str, language: str = "") -> Any:
+    code = ET.Element(tag)
+    code.set("{http://www.w3.org/XML/1998/namespace}space", "preserve")
+    if language:
+        code.set("outputclass", f"language-{language}")
+    code.text = "\n".join(lines)
+    return code
+
+
+class AsciiDocParser(DocumentParser):
+    format_name = "asciidoc"
+    extensions = (".adoc", ".asciidoc", ".asc")
+    description = "AsciiDoc document"
+
+    def parse(self, text: str) -> ParsedDocument:
+        lines = text.replace("\r\n", "\n").replace("\r", "\n").split("\n")
+        document = ParsedDocument()
+        lines = self._strip_comments(lines)
+        lines = self._header(lines, document)
+        lines = [self._substitute(line, document.attributes) for line in lines]
+        current: List[Any] = document.preamble
+        for item in self._blocks(lines, headings=True):
+            if isinstance(item, Section):
+                document.sections.append(item)
+                current = item.blocks
+            else:
+                current.append(item)
+        return document
+
+    @staticmethod
+    def _strip_comments(lines: List[str]) -> List[str]:
+        kept: List[str] = []
+        in_comment = False
+        for line in lines:
+            if line.strip() == "////":
+                in_comment = not in_comment
+                continue
+            if in_comment or (line.startswith("//") and not line.startswith("///")):
+                continue
+            kept.append(line)
+        return kept
+
+    @staticmethod
+    def _header(lines: List[str], document: ParsedDocument) -> List[str]:
+        i = 0
+        while i < len(lines) and not lines[i].strip():
+            i += 1
+        title = _TITLE.match(lines[i]) if i < len(lines) else None
+        if title and len(title.group(1)) == 1:
+            document.title = title.group(2)
+            i += 1
+        start = i
+        while i < len(lines) and lines[i].strip():
+            attribute = _ATTRIBUTE.match(lines[i])
+            if attribute:
+                document.attributes[attribute.group(1)] = attribute.group(2).strip()
+            elif i > start or not document.title:
+                break
+            i += 1
+        if document.title or document.attributes:
+            return lines[i:]
+        return lines
+
+    @staticmethod
+    def _substitute(line: str, attributes: Dict[str, str]) -> str:
+        return re.sub(r"(?<!\\)\{([\w-]+)\}", lambda m: attributes.get(m.group(1), m.group(0)), line)
+
+    def _blocks(self, lines: List[str], headings: bool = False) -> List[Any]:
+        out: List[Any] = []
+        pending_anchor: Optional[str] = None
+        pending_attrs: Tuple[List[str], Dict[str, str]] = ([], {})
+        pending_title: Optional[str] = None
+        i = 0
+        while i < len(lines):
+            line = lines[i].rstrip()
+            if not line.strip():
+                i += 1
+                continue
+            anchor = _ANCHOR.match(line)
+            if anchor:
+                pending_anchor = anchor.group(1) or anchor.group(2)
+                i += 1
+                continue
+            attrs = _BLOCK_ATTRS.match(line)
+            if attrs and not line.startswith("[["):
+                pending_attrs = _attributes(attrs.group(1))
+                i += 1
+                continue
+            block_title = _BLOCK_TITLE.match(line)
+            if block_title and not _ORDERED.match(line):
+                pending_title = block_title.group(1)
+                i += 1
+                continue
+            positional, named = pending_attrs
+            style = positional[0] if positional else ""
+
+            title = _TITLE.match(line)
+            if headings and title and len(title.group(1)) >= 2:
+                text = ET.Element("title")
+                inline(text, title.group(2))
+                plain = "".join(text.itertext()).strip()
+                out.append(Section(level=len(title.group(1)) - 1, title=plain,
+                                   anchor=pending_anchor or _anchor(plain)))
+                i += 1
+            elif line.strip() in _DELIMITERS or line.strip() == "|===":
+                delimiter = line.strip()
+                body: List[str] = []
+                i += 1
+                while i < len(lines) and lines[i].rstrip() != delimiter:
+                    body.append(lines[i])
+                    i += 1
+                i += 1
+                out.append(self._delimited(delimiter, body, positional, named, pending_title))
+            elif _BLOCK_IMAGE.match(line):
+                match = _BLOCK_IMAGE.match(line)
+                fig = ET.Element("fig")
+                if pending_title:
+                    ET.SubElement(fig, "title").text = pending_title
+                image = ET.SubElement(fig, "image", href=match.group(1))
+                alt = match.group(2).split(",")[0].strip()
+                if alt:
+                    ET.SubElement(image, "alt").text = alt
+                out.append(fig)
+                i += 1
+            elif _BULLET.match(line) or _ORDERED.match(line):
+                block, i = self._list(lines, i)
+                out.append(block)
+            else:
+                raw: List[str] = []
+                while i < len(lines) and lines[i].strip() and (not raw or not self._starts_block(lines[i])):
+                    raw.append(lines[i].rstrip())
+                    i += 1
+                # A trailing " +" is a hard line break
+                text = " ".join(re.sub(r"\s\+$", "", r.strip()) for r in raw)
+                admonition = _ADMONITION.match(text)
+                if style.upper() in ("NOTE", "TIP", "IMPORTANT", "WARNING", "CAUTION"):
+                    note = ET.Element("note", type=style.lower())
+                    inline(ET.SubElement(note, "p"), text)
+                    out.append(note)
+                elif admonition:
+                    note = ET.Element("note", type=admonition.group(1).lower())
+                    inline(ET.SubElement(note, "p"), admonition.group(2))
+                    out.append(note)
+                elif style in ("source", "listing", "literal") or line.startswith(" "):
+                    tag = "pre" if style == "literal" or line.startswith(" ") else "codeblock"
+                    indent = min(len(r) - len(r.lstrip()) for r in raw)
+                    out.append(_codeblock([r[indent:] for r in raw], tag,
+                                          positional[1] if len(positional) > 1 else ""))
+                else:
+                    p = ET.Element("p")
+                    inline(p, text)
+                    out.append(p)
+            if pending_anchor and out and not isinstance(out[-1], Section):
+                out[-1].set("id", pending_anchor)
+            pending_anchor, pending_attrs, pending_title = None, ([], {}), None
+        return out
+
+    @staticmethod
+    def _starts_block(line: str) -> bool:
+        stripped = line.rstrip()
+        return bool(_TITLE.match(stripped) or stripped in _DELIMITERS or stripped == "|===" or
+                    _BLOCK_IMAGE.match(stripped) or _BULLET.match(stripped) or _ORDERED.match(stripped) or
+                    _ANCHOR.match(stripped) or
+                    (_BLOCK_ATTRS.match(stripped) and not stripped.startswith("[[")))
+
+    def _delimited(self, delimiter: str, body: List[str], positional: List[str], named: Dict[str, str],
+                   title: Optional[str]) -> Any:
+        kind = "table" if delimiter == "|===" else _DELIMITERS[delimiter]
+        style = positional[0] if positional else ""
+        if kind == "table":
+            return self._table(body, named)
+        if kind == "listing":
+            language = positional[1] if style == "source" and len(positional) > 1 else ""
+            return _codeblock(body, "codeblock", language)
+        if kind == "literal":
+            return _codeblock(body, "pre")
+        if style.upper() in ("NOTE", "TIP", "IMPORTANT", "WARNING", "CAUTION"):
+            container = ET.Element("note", type=style.lower())
+        elif kind == "quote":
+            container = ET.Element("lq")
+        else:
+            container = ET.Element("div" if kind == "sidebar" else "example")
+            if title and kind == "example":
+                ET.SubElement(container, "title").text = title
+        container.extend(self._blocks(body))
+        return container
+
+    @staticmethod
+    def _table(body: List[str], named: Dict[str, str]) -> Any:
+        cells: List[List[Any]] = []          # [colspan, rowspan, text]
+        first_row: Optional[int] = None
+        for line in body:
+            if not line.strip():
+                continue
+            if line.lstrip().startswith("|") or _CELL_SPEC.match(line.strip()):
+                row = _cells(line.strip())
+                first_row = sum(c[0] for c in row) if first_row is None else first_row
+                cells.extend(row)
+            elif cells:
+                cells[-1][2] = (cells[-1][2] + " " + line.strip()).strip()
+        cols = named.get("cols", "")
+        repeat = re.match(r"^(\d+)\*", cols)
+        if repeat:
+            columns = int(repeat.group(1))
+        elif cols:
+            columns = int(cols) if cols.isdigit() else len(cols.split(","))
+        else:
+            columns = first_row or 1
+        options = named.get("options", named.get("opts", ""))
+        # A first line of cells followed by a blank line is the header row
+        implicit = len(body) > 1 and body[0].strip() and not body[1].strip()
+        has_header = ("header" in options or bool(implicit)) and "noheader" not in options
+        if any(colspan > 1 or rowspan > 1 for colspan, rowspan, _ in cells):
+            # Spanned cells need CALS
+            flow = []
+            for colspan, rowspan, text in cells:
+                entry = ET.Element("entry")
+                inline(entry, text)
+                flow.append((colspan, rowspan, [entry]))
+            return build_cals(place_cells([flow], columns, header_rows=1 if has_header else 0))
+        table = ET.Element("simpletable")
+        texts = [text for _, _, text in cells]
+        for number, start in enumerate(range(0, len(texts), columns)):
+            row = (texts[start:start + columns] + [""] * columns)[:columns]
+            parent = ET.SubElement(table, "sthead" if number == 0 and has_header else "strow")
+            for cell in row:
+                inline(ET.SubElement(parent, "stentry"), cell)
+        return table
+
+    def _list(self, lines: List[str], start: int, parents: Tuple[str, ...] = ()) -> Tuple[Any, int]:
+        def _marker(line: str) -> Optional[Tuple[str, str]]:
+            match = _BULLET.match(line) or _ORDERED.match(line)
+            return (match.group(1), match.group(2)) if match else None
+
+        root_marker = _marker(lines[start])[0]
+        element = ET.Element("ol" if root_marker.startswith(".") else "ul")
+        i = start
+        li = None
+        while i < len(lines):
+            line = lines[i].rstrip()
+            marker = _marker(line)
+            if marker and marker[0] == root_marker:
+                li = ET.SubElement(element, "li")
+                inline(li, marker[1])
+                i += 1
+                continue
+            if marker and li is not None and marker[0] not in parents and (
+                    len(marker[0]) > len(root_marker) or marker[0][0] != root_marker[0]):
+                nested, i = self._list(lines, i, parents + (root_marker,))
+                li.append(nested)
+                continue
+            if li is not None and line.strip() and not marker and not self._starts_block(line):
+                # Continuation line of the item text
+                _append_text(li, " " + line.strip())
+                i += 1
+                continue
+            break
+        return element, i
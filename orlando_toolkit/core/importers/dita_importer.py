@@ -1,10 +1,20 @@
 from __future__ import annotations
 
-"""DITA package importer for zipped DITA archives.
+"""DITA package importer for zipped DITA archives and plain DITA maps.
 
-Handles importing zipped DITA packages (.zip files) and converting them
-into DitaContext objects that can be edited and manipulated by the
-Orlando Toolkit core functionality.
+Handles importing zipped DITA packages (.zip files) and ``.ditamap`` files
+with the topics and images next to them, and converting them into
+DitaContext objects that can be edited and manipulated by the Orlando
+Toolkit core functionality.
+
+Packages exported by the toolkit load as they were written. Other layouts
+are brought to the toolkit's flat one: topics referenced from the map (and
+from sub-maps, which are inlined) are resolved relative to the map that
+references them and stored as ``topics/<file>.dita``, clashing file names
+being numbered; images referenced from topics become ``../media/<file>``.
+Links and conrefs between topics follow the new names. Map entries without
+a navigation title get the topic title, and ``data-level`` is set from the
+nesting so the depth controls work.
 """
 
 import logging
@@ -13,24 +23,70 @@ import tempfile
 import zipfile
 from pathlib import Path
 from typing import Dict, Any, Optional, List, Callable
+from urllib.parse import unquote, urlparse
 from lxml import etree as ET
 
-from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.archive_limits import ArchiveLimitError, ArchiveLimits, safe_extract
+from orlando_toolkit.core.attachments import ATTACHMENT_FOLDER, TITLES_KEY
+from orlando_toolkit.core.bookmap import from_bookmap
+from orlando_toolkit.core.models import ConversionReport, DitaContext
 from orlando_toolkit.core.utils import slugify
+from orlando_toolkit.core.cancellation import CancellationToken, OperationCancelledError, check_cancelled
+from orlando_toolkit.core.concurrency import PipelineSettings, ordered_map
+from orlando_toolkit.core.errors import SourceLocation
+from orlando_toolkit.core.spool import SpooledBlobs
+from orlando_toolkit.core.time_budget import TimeBudget, is_expired
+from orlando_toolkit.core.xml_security import ParseAudit, XmlSecurityError, XmlSecurityPolicy, parse_file
 
 logger = logging.getLogger(__name__)
 
 __all__ = ["DitaPackageImporter"]
 
+# Marker returned by workers that skipped an item because the time budget ran out
+_SKIPPED = object()
+
+_FORMAT_DESCRIPTIONS = {
+    ".zip": "Zipped DITA Package Archive",
+    ".ditamap": "DITA Map with its topics",
+}
+_TOPIC_SUFFIXES = (".dita", ".xml")
+_IMAGE_EXTENSIONS = {'.png', '.jpg', '.jpeg', '.gif', '.bmp', '.svg', '.tiff', '.webp'}
+_STRUCTURAL = ("topicref", "topichead")
+# Folder of the map an entry came from, while sub-maps are inlined
+_BASE_HINT = "data-import-base"
+
+
+def _is_external(href: str) -> bool:
+    scheme = urlparse(href).scheme
+    # One-letter schemes are Windows drive letters
+    return bool(scheme) and len(scheme) > 1
+
+
+def _free_name(name: str, taken: Dict[str, Any]) -> str:
+    if name not in taken:
+        return name
+    stem, suffix = os.path.splitext(name)
+    number = 2
+    while f"{stem}_{number}{suffix}" in taken:
+        number += 1
+    return f"{stem}_{number}{suffix}"
+
 
 class DitaImportError(Exception):
     """Exception raised when DITA package import fails."""
+
+    code = "OTK102"
+    hint: Optional[str] = None
     
     def __init__(self, message: str, file_path: Optional[Path] = None, cause: Optional[Exception] = None):
         self.file_path = file_path
         self.cause = cause
         super().__init__(message)
 
+    @property
+    def location(self) -> Optional[SourceLocation]:
+        return SourceLocation(file=Path(self.file_path).name) if self.file_path else None
+
 
 class DitaPackageImporter:
     """Importer for zipped DITA archive packages.
@@ -47,6 +103,21 @@ class DitaPackageImporter:
     
     def __init__(self):
         self.logger = logging.getLogger(f"{__name__}.DitaPackageImporter")
+        # Token for the import currently in progress (set by import_package)
+        self._cancel_token: Optional[CancellationToken] = None
+        # Worker/memory settings for the import in progress
+        self._settings: PipelineSettings = PipelineSettings()
+        # Media go to disk (low-memory mode) for the import in progress
+        self._spool: bool = False
+        # Media bytes held in memory by the import in progress
+        self._retained_bytes = 0
+        self._time_budget: Optional[TimeBudget] = None
+        self._report: ConversionReport = ConversionReport()
+        # XML parsing policy and audit records for the import in progress
+        self._xml_policy: XmlSecurityPolicy = XmlSecurityPolicy()
+        self._parse_audit: List[ParseAudit] = []
+        # Folder referenced files must stay in (the extracted archive), or None
+        self._root_limit: Optional[Path] = None
     
     def can_import(self, file_path: Path) -> bool:
         """Check if this importer can handle the given file.
@@ -60,6 +131,10 @@ class DitaPackageImporter:
         if not file_path.exists() or not file_path.is_file():
             return False
         
+        # Plain maps are read in place, next to their topics
+        if file_path.suffix.lower() == '.ditamap':
+            return True
+
         # Check file extension
         if file_path.suffix.lower() != '.zip':
             return False
@@ -81,19 +156,25 @@ class DitaPackageImporter:
             return False
     
     def import_package(self, file_path: Path, metadata: Optional[Dict[str, Any]] = None,
-                       progress_callback: Optional[Callable[[str], None]] = None) -> DitaContext:
-        """Import a zipped DITA package into a DitaContext.
+                       progress_callback: Optional[Callable[[str], None]] = None,
+                       *, cancel_token: Optional[CancellationToken] = None,
+                       time_budget: Optional[TimeBudget] = None) -> DitaContext:
+        """Import a zipped DITA package or a ``.ditamap`` file into a DitaContext.
         
         Args:
-            file_path: Path to the ZIP file containing the DITA package
+            file_path: Path to the ZIP file containing the DITA package, or to a map
             metadata: Optional metadata to merge with imported data
             progress_callback: Optional callback for progress updates
+            cancel_token: Optional token checked between extraction and per-file parsing
+            time_budget: Optional budget; once exhausted, remaining topics and media
+                are skipped and the context report is marked partial
             
         Returns:
             DitaContext containing the imported DITA archive
             
         Raises:
             DitaImportError: If import fails for any reason
+            OperationCancelledError: If *cancel_token* was cancelled
         """
         if not self.can_import(file_path):
             raise DitaImportError(f"File is not a valid DITA package: {file_path}", file_path)
@@ -101,31 +182,53 @@ class DitaPackageImporter:
         if progress_callback:
             progress_callback(f"Importing DITA package: {file_path.name}")
         self.logger.debug("Importing DITA package: %s", file_path)
+        self._cancel_token = cancel_token
+        self._settings = PipelineSettings.resolve(metadata)
+        self._spool = self._settings.low_memory_for(file_path)
+        self._retained_bytes = 0
+        self._time_budget = time_budget if time_budget is not None else self._settings.time_budget()
+        self._report = ConversionReport()
+        self._xml_policy = XmlSecurityPolicy.from_config()
+        self._parse_audit = []
         
         try:
-            with tempfile.TemporaryDirectory(prefix="otk_dita_import_") as temp_dir:
-                # Extract ZIP archive
-                self._extract_zip(file_path, temp_dir)
-                
-                # Find and parse the DITA structure
-                context = self._parse_dita_structure(Path(temp_dir), metadata or {})
-                
-                # Set source information in metadata
-                context.metadata["source_file"] = str(file_path)
-                context.metadata["source_type"] = "dita_package"
-                context.metadata["import_timestamp"] = self._get_current_timestamp()
-                
-                if progress_callback:
-                    progress_callback(f"Successfully imported DITA package with {len(context.topics)} topics and {len(context.images)} images")
-                self.logger.debug("Successfully imported DITA package with %d topics and %d images",
-                               len(context.topics), len(context.images))
-                
-                return context
+            if file_path.suffix.lower() == '.ditamap':
+                # Referenced files may live outside the map's folder (shared topics)
+                context = self._parse_dita_structure(file_path.parent, metadata or {}, ditamap_path=file_path,
+                                                     confined=False)
+                source_type = "dita_map"
+            else:
+                with tempfile.TemporaryDirectory(prefix="otk_dita_import_") as temp_dir:
+                    # Extract ZIP archive
+                    self._extract_zip(file_path, temp_dir)
+                    check_cancelled(cancel_token)
+
+                    # Find and parse the DITA structure
+                    context = self._parse_dita_structure(Path(temp_dir), metadata or {})
+                source_type = "dita_package"
+
+            # Set source information in metadata
+            context.metadata["source_file"] = str(file_path)
+            context.metadata["source_type"] = source_type
+            context.metadata["import_timestamp"] = self._get_current_timestamp()
+
+            if progress_callback:
+                progress_callback(f"Successfully imported DITA package with {len(context.topics)} topics and {len(context.images)} images")
+            self.logger.debug("Successfully imported DITA package with %d topics and %d images",
+                           len(context.topics), len(context.images))
+
+            return context
                 
         except Exception as e:
-            if isinstance(e, DitaImportError):
+            if isinstance(e, (DitaImportError, OperationCancelledError)):
                 raise
             raise DitaImportError(f"Failed to import DITA package: {e}", file_path, e)
+        finally:
+            self._cancel_token = None
+            self._settings = PipelineSettings()
+            self._spool = False
+            self._retained_bytes = 0
+            self._time_budget = None
     
     def _extract_zip(self, zip_path: Path, extract_dir: str) -> None:
         """Extract ZIP archive to temporary directory.
@@ -138,26 +241,29 @@ class DitaPackageImporter:
             DitaImportError: If extraction fails
         """
         try:
-            with zipfile.ZipFile(zip_path, 'r') as zip_ref:
-                # Security check: validate paths before extraction
-                for member in zip_ref.namelist():
-                    if os.path.isabs(member) or ".." in member:
-                        raise DitaImportError(f"Unsafe path in ZIP: {member}", zip_path)
-                
-                zip_ref.extractall(extract_dir)
-                self.logger.debug("Extracted ZIP to: %s", extract_dir)
+            # Paths, sizes, entry count, nesting and image dimensions are checked
+            # before and while extracting (security.yml archive_limits)
+            stats = safe_extract(zip_path, extract_dir, ArchiveLimits.from_config(),
+                                 cancel_token=self._cancel_token)
+            self.logger.debug("Extracted ZIP to: %s (%d entries, %d bytes)", extract_dir,
+                              stats.entries, stats.uncompressed_bytes)
                 
+        except ArchiveLimitError as e:
+            raise DitaImportError(f"Archive refused: {e}", zip_path, e)
         except zipfile.BadZipFile as e:
             raise DitaImportError(f"Invalid ZIP file: {e}", zip_path, e)
         except OSError as e:
             raise DitaImportError(f"Failed to extract ZIP: {e}", zip_path, e)
     
-    def _parse_dita_structure(self, root_dir: Path, base_metadata: Dict[str, Any]) -> DitaContext:
+    def _parse_dita_structure(self, root_dir: Path, base_metadata: Dict[str, Any], *,
+                              ditamap_path: Optional[Path] = None, confined: bool = True) -> DitaContext:
         """Parse extracted DITA structure and build DitaContext.
         
         Args:
             root_dir: Root directory of extracted DITA package
             base_metadata: Base metadata to include in context
+            ditamap_path: Map to load; found in *root_dir* when omitted
+            confined: Ignore references to files outside *root_dir*
             
         Returns:
             DitaContext with parsed structure
@@ -165,8 +271,9 @@ class DitaPackageImporter:
         Raises:
             DitaImportError: If parsing fails
         """
+        self._root_limit = root_dir.resolve() if confined else None
         # Find ditamap file
-        ditamap_path = self._find_ditamap(root_dir)
+        ditamap_path = ditamap_path or self._find_ditamap(root_dir)
         if not ditamap_path:
             raise DitaImportError("No .ditamap file found in package", root_dir)
         
@@ -178,9 +285,21 @@ class DitaPackageImporter:
         except Exception as e:
             raise DitaImportError(f"Failed to parse ditamap: {e}", ditamap_path, e)
         
+        # Bookmaps are edited as plain maps and written back as bookmaps
+        is_book = isinstance(ditamap_root.tag, str) and ditamap_root.tag.split("}")[-1] == "bookmap"
+        if is_book:
+            ditamap_root = from_bookmap(ditamap_root)
+
         # Extract metadata from ditamap
         extracted_metadata = self._extract_metadata_from_ditamap(ditamap_root)
+        if is_book:
+            extracted_metadata["map_type"] = "bookmap"
         
+        # Attachments of a package written by the toolkit come back as attachments
+        attachments, titles = self._load_attachments(ditamap_root, ditamap_path, root_dir)
+        if titles:
+            extracted_metadata[TITLES_KEY] = titles
+
         # Merge metadata (base takes precedence)
         merged_metadata = {**extracted_metadata, **base_metadata}
         
@@ -188,20 +307,29 @@ class DitaPackageImporter:
         topics_dir = self._find_topics_directory(root_dir, ditamap_path)
         
         # Load all referenced topics
-        topics = self._load_topics(ditamap_root, topics_dir)
+        topic_files = self._collect_topic_files(ditamap_root, ditamap_path, topics_dir)
+        topics = self._load_topics(topic_files)
         
         # Find media directory and load images/videos
         media_dir = self._find_media_directory(root_dir, ditamap_path)
         images = self._load_images(media_dir)
         videos = self._load_videos(media_dir)
+
+        # References between topics and to images follow the flat layout
+        self._localize_references(topics, topic_files, images)
+        self._complete_map_entries(ditamap_root, topics)
         
+        self._record_parse_audit(root_dir)
+
         # Build and return DitaContext
         context = DitaContext(
             ditamap_root=ditamap_root,
             topics=topics,
             images=images,
             videos=videos,
-            metadata=merged_metadata
+            attachments=attachments,
+            metadata=merged_metadata,
+            report=self._report,
         )
         
         return context
@@ -226,7 +354,7 @@ class DitaPackageImporter:
         ]
         
         # Add subdirectories up to 2 levels deep
-        for path in list(root_dir.rglob("*/")):
+        for path in sorted(root_dir.rglob("*/")):
             relative_depth = len(path.relative_to(root_dir).parts)
             if relative_depth <= 2:
                 search_paths.append(path)
@@ -235,7 +363,7 @@ class DitaPackageImporter:
             if not search_dir.exists():
                 continue
             
-            ditamap_files = list(search_dir.glob("*.ditamap"))
+            ditamap_files = sorted(search_dir.glob("*.ditamap"))
             if ditamap_files:
                 # Return the first ditamap found
                 # Could be enhanced to choose based on naming patterns
@@ -298,7 +426,8 @@ class DitaPackageImporter:
             if candidate.exists() and candidate.is_dir():
                 # Check if it contains image or video files
                 image_extensions = {'.png', '.jpg', '.jpeg', '.gif', '.bmp', '.svg', '.tiff', '.webp'}
-                video_extensions = {'.mp4', '.mov', '.avi', '.mkv', '.webm', '.m4v', '.wmv'}
+                video_extensions = {'.mp4', '.mov', '.avi', '.mkv', '.webm', '.m4v', '.wmv',
+                                    '.mp3', '.m4a', '.wav', '.ogg'}
                 has_media = any(
                     f.is_file() and (f.suffix.lower() in image_extensions or f.suffix.lower() in video_extensions)
                     for f in candidate.iterdir()
@@ -322,9 +451,10 @@ class DitaPackageImporter:
             Exception: If parsing fails
         """
         try:
-            parser = ET.XMLParser(resolve_entities=False)  # Security: disable entity resolution
-            tree = ET.parse(str(xml_path), parser)
-            return tree.getroot()
+            return parse_file(xml_path, policy=self._xml_policy, audit=self._parse_audit)
+        except XmlSecurityError as e:
+            self._report.error("xml_security", f"Refused {xml_path.name}: {e}", topic=xml_path.name)
+            raise Exception(f"Refused {xml_path}: {e}")
         except ET.XMLSyntaxError as e:
             raise Exception(f"XML syntax error in {xml_path}: {e}")
         except OSError as e:
@@ -341,9 +471,13 @@ class DitaPackageImporter:
         """
         metadata = {}
         
-        # Extract title from topicmeta/navtitle or title attribute
+        # Extract title from the map title, topicmeta/navtitle or title attribute
+        map_title = "".join(ditamap_root.find("title").itertext()).strip() \
+            if ditamap_root.find("title") is not None else ""
         title_element = ditamap_root.find(".//topicmeta/navtitle")
-        if title_element is not None and title_element.text:
+        if map_title:
+            metadata["manual_title"] = map_title
+        elif title_element is not None and title_element.text:
             metadata["manual_title"] = title_element.text.strip()
         elif ditamap_root.get("title"):
             metadata["manual_title"] = ditamap_root.get("title").strip()
@@ -370,56 +504,205 @@ class DitaPackageImporter:
         
         return metadata
     
-    def _load_topics(self, ditamap_root: ET.Element, topics_dir: Path) -> Dict[str, ET.Element]:
-        """Load all topics referenced in the ditamap.
+    def _within_root(self, path: Path) -> bool:
+        if self._root_limit is None:
+            return True
+        try:
+            path.resolve().relative_to(self._root_limit)
+            return True
+        except ValueError:
+            return False
+
+    def _inline_submaps(self, node: ET.Element, base: Path, seen: set) -> None:
+        """Replace map references below *node* with the entries of the referenced maps."""
+        for ref in list(node):
+            if not isinstance(ref.tag, str):
+                continue
+            href = ref.get("href") or ""
+            is_map = ref.tag == "mapref" or ref.get("format") == "ditamap" or href.endswith(".ditamap")
+            if not is_map or not href or _is_external(href) or ref.get("scope") == "external":
+                if ref.get("href") and not ref.get(_BASE_HINT):
+                    ref.set(_BASE_HINT, str(base))
+                self._inline_submaps(ref, base, seen)
+                continue
+            submap_path = (base / unquote(href.partition("#")[0])).resolve()
+            entries: List[ET.Element] = []
+            if submap_path in seen:
+                self._report.warning("dita_import", f"Map {href} references itself; reference ignored")
+            elif not submap_path.is_file() or not self._within_root(submap_path):
+                self._report.warning("dita_import", f"Referenced map not found: {href}")
+            else:
+                try:
+                    submap = self._parse_xml_file(submap_path)
+                except Exception as e:
+                    self._report.warning("dita_import", f"Referenced map {href} could not be read: {e}")
+                else:
+                    self._inline_submaps(submap, submap_path.parent, seen | {submap_path})
+                    entries = [el for el in submap if isinstance(el.tag, str) and el.tag in _STRUCTURAL]
+            parent = ref.getparent()
+            position = parent.index(ref)
+            parent.remove(ref)
+            for offset, entry in enumerate(entries):
+                parent.insert(position + offset, entry)
+
+    def _find_topic_file(self, path_part: str, base: Path, topics_dir: Path) -> Optional[Path]:
+        """Topic file for *path_part*, relative to its map first, then in the usual package folders."""
+        filename = Path(unquote(path_part)).name
+        candidates = [
+            base / unquote(path_part),
+            topics_dir / filename,
+            topics_dir.parent / filename,
+            topics_dir.parent / "topics" / filename,
+        ]
+        for candidate in candidates:
+            if candidate.is_file() and self._within_root(candidate):
+                return candidate
+        return None
+
+    def _collect_topic_files(self, ditamap_root: ET.Element, ditamap_path: Path,
+                             topics_dir: Path) -> Dict[str, Path]:
+        """Topic files referenced by the map, by their name in the context.
+
+        Map entries are pointed at ``topics/<name>``; sub-maps are inlined first.
+        """
+        self._inline_submaps(ditamap_root, ditamap_path.parent, {ditamap_path.resolve()})
+        files: Dict[str, Path] = {}
+        names: Dict[Path, str] = {}
+        missing: List[str] = []
+        for ref in ditamap_root.iter():
+            check_cancelled(self._cancel_token)
+            if not isinstance(ref.tag, str):
+                continue
+            base = Path(ref.attrib.pop(_BASE_HINT, None) or ditamap_path.parent)
+            href = ref.get("href")
+            if not href or _is_external(href) or ref.get("scope") == "external":
+                continue
+            path_part, sep, fragment = href.partition("#")
+            if Path(path_part).suffix.lower() not in _TOPIC_SUFFIXES:
+                continue
+            topic_path = self._find_topic_file(path_part, base, topics_dir)
+            if topic_path is None:
+                self.logger.warning("Topic file not found: %s", href)
+                missing.append(href)
+                continue
+            key = topic_path.resolve()
+            if key not in names:
+                names[key] = _free_name(f"{Path(unquote(path_part)).stem}.dita", files)
+                files[names[key]] = topic_path
+            ref.set("href", f"topics/{names[key]}{sep}{fragment}")
+        if missing:
+            self._report.warning("dita_import", f"{len(missing)} topic file(s) referenced by the map were not "
+                                                f"found: {', '.join(missing[:20])}", missing=missing)
+        return files
+
+    def _load_topics(self, topic_files: Dict[str, Path]) -> Dict[str, ET.Element]:
+        """Parse the topic files found by :meth:`_collect_topic_files`.
         
         Args:
-            ditamap_root: Root element of ditamap
-            topics_dir: Directory containing topic files
+            topic_files: Topic files by their name in the context, in map order
             
         Returns:
             Dictionary mapping topic filenames to their root elements
         """
         topics = {}
+        topic_paths: Dict[str, Path] = dict(topic_files)
         
-        # Find all topicref elements with href attributes
-        for topicref in ditamap_root.xpath(".//topicref[@href]"):
-            href = topicref.get("href")
-            if not href or not href.endswith(".dita"):
-                continue
-            
-            # Extract filename from href (remove path components)
-            topic_filename = href.split("/")[-1]
-            
-            # Try to find the topic file
-            topic_path = topics_dir / topic_filename
-            if not topic_path.exists():
-                # Try alternative locations
-                alt_paths = [
-                    topics_dir.parent / topic_filename,
-                    topics_dir.parent / "topics" / topic_filename,
-                ]
-                
-                for alt_path in alt_paths:
-                    if alt_path.exists():
-                        topic_path = alt_path
-                        break
-                else:
-                    self.logger.warning("Topic file not found: %s (referenced from %s)", 
-                                      topic_filename, href)
-                    continue
-            
+        def _parse(item):
+            topic_filename, topic_path = item
+            if is_expired(self._time_budget):
+                return _SKIPPED
             try:
-                topic_element = self._parse_xml_file(topic_path)
-                topics[topic_filename] = topic_element
-                self.logger.debug("Loaded topic: %s", topic_filename)
-                
+                return self._parse_xml_file(topic_path)
             except Exception as e:
                 self.logger.error("Failed to parse topic %s: %s", topic_filename, e)
-                continue
+                return None
+        
+        # Parse in parallel; results come back in map order
+        parsed = ordered_map(_parse, topic_paths.items(),
+                             workers=self._settings.parser_workers,
+                             cancel_token=self._cancel_token)
+        skipped = 0
+        for (topic_filename, _), topic_element in zip(topic_paths.items(), parsed):
+            if topic_element is _SKIPPED:
+                skipped += 1
+            elif topic_element is not None:
+                topics[topic_filename] = topic_element
+                self.logger.debug("Loaded topic: %s", topic_filename)
+        self._record_skipped(skipped, "topic")
         
         return topics
     
+    def _localize_references(self, topics: Dict[str, ET.Element], topic_files: Dict[str, Path],
+                             images: Dict[str, bytes]) -> None:
+        """Point links and conrefs at the flat topic names and images at ``../media/``.
+
+        Images referenced from a topic but missing from the media folder are read into *images*.
+        """
+        names = {path.resolve(): name for name, path in topic_files.items()}
+        image_names: Dict[Path, str] = {}
+        for name, topic in topics.items():
+            topic_dir = topic_files[name].parent
+            for el in topic.iter():
+                if not isinstance(el.tag, str) or el.get("scope") == "external":
+                    continue
+                for attr in ("href", "conref", "conrefend"):
+                    value = el.get(attr)
+                    if not value or value.startswith("#") or _is_external(value):
+                        continue
+                    path_part, sep, fragment = value.partition("#")
+                    target = topic_dir / unquote(path_part)
+                    if el.tag == "image" and attr == "href":
+                        image = self._image_name(target, images, image_names)
+                        if image is not None:
+                            el.set("href", f"../media/{image}")
+                    elif target.resolve() in names:
+                        el.set(attr, f"{names[target.resolve()]}{sep}{fragment}")
+
+    def _image_name(self, path: Path, images: Dict[str, bytes], image_names: Dict[Path, str]) -> Optional[str]:
+        """Name of the image file *path* in *images*, reading it when needed."""
+        key = path.resolve()
+        if key in image_names:
+            return image_names[key]
+        if path.suffix.lower() not in _IMAGE_EXTENSIONS or not path.is_file() or not self._within_root(path):
+            return None
+        try:
+            data = path.read_bytes()
+        except OSError as e:
+            self.logger.error("Failed to read image %s: %s", path.name, e)
+            return None
+        name = path.name
+        if images.get(name) != data:
+            name = _free_name(name, images)
+            images[name] = data
+        image_names[key] = name
+        return name
+
+    def _complete_map_entries(self, ditamap_root: ET.Element, topics: Dict[str, ET.Element]) -> None:
+        """Give map entries the navigation title and ``data-level`` the Structure tab relies on."""
+        def _walk(node: ET.Element, level: int) -> None:
+            for entry in node:
+                if not isinstance(entry.tag, str) or entry.tag not in _STRUCTURAL:
+                    continue
+                if not entry.get("data-level"):
+                    entry.set("data-level", str(level))
+                if not (entry.findtext("topicmeta/navtitle") or "").strip():
+                    topic = topics.get((entry.get("href") or "").partition("#")[0].split("/")[-1])
+                    title = entry.get("navtitle") or (
+                        "".join(topic.find("title").itertext()).strip()
+                        if topic is not None and topic.find("title") is not None else "")
+                    if title:
+                        topicmeta = entry.find("topicmeta")
+                        if topicmeta is None:
+                            topicmeta = ET.Element("topicmeta")
+                            entry.insert(0, topicmeta)
+                        navtitle = topicmeta.find("navtitle")
+                        if navtitle is None:
+                            navtitle = ET.Element("navtitle")
+                            topicmeta.insert(0, navtitle)
+                        navtitle.text = title
+                _walk(entry, level + 1)
+        _walk(ditamap_root, 1)
+
     def _load_images(self, media_dir: Optional[Path]) -> Dict[str, bytes]:
         """Load all images from the media directory.
         
@@ -429,32 +712,9 @@ class DitaPackageImporter:
         Returns:
             Dictionary mapping image filenames to their binary content
         """
-        images = {}
-        
-        if not media_dir or not media_dir.exists():
-            return images
-        
         # Supported image extensions
         image_extensions = {'.png', '.jpg', '.jpeg', '.gif', '.bmp', '.svg', '.tiff', '.webp'}
-        
-        for image_path in media_dir.iterdir():
-            if not image_path.is_file():
-                continue
-            
-            if image_path.suffix.lower() not in image_extensions:
-                continue
-            
-            try:
-                with open(image_path, 'rb') as f:
-                    images[image_path.name] = f.read()
-                self.logger.debug("Loaded image: %s (%d bytes)", 
-                                image_path.name, len(images[image_path.name]))
-                
-            except OSError as e:
-                self.logger.error("Failed to read image %s: %s", image_path.name, e)
-                continue
-        
-        return images
+        return self._read_media_files(media_dir, image_extensions, "image")
 
     def _load_videos(self, media_dir: Optional[Path]) -> Dict[str, bytes]:
         """Load all videos from the media directory.
@@ -465,33 +725,140 @@ class DitaPackageImporter:
         Returns:
             Dictionary mapping video filenames to their binary content
         """
-        videos: Dict[str, bytes] = {}
+        # Supported video extensions (common set), and audio clips kept with the videos
+        video_extensions = {'.mp4', '.mov', '.avi', '.mkv', '.webm', '.m4v', '.wmv', '.mp3', '.m4a', '.wav', '.ogg'}
+        return self._read_media_files(media_dir, video_extensions, "video")
+
+    def _load_attachments(self, ditamap_root: ET.Element, ditamap_path: Path,
+                          root_dir: Path) -> tuple[Dict[str, bytes], Dict[str, str]]:
+        """Take the resource-only ``resources/`` entries out of the map and read their files.
+
+        Returns the attachments and the titles that differ from the file name.
+        """
+        attachments: Dict[str, bytes] = {}
+        titles: Dict[str, str] = {}
+        for ref in ditamap_root.findall("topicref"):
+            href = unquote(ref.get("href") or "")
+            if ref.get("processing-role") != "resource-only" or not href.startswith(f"{ATTACHMENT_FOLDER}/"):
+                continue
+            path = (ditamap_path.parent / href).resolve()
+            try:
+                path.relative_to(root_dir.resolve())
+                data = path.read_bytes()
+            except (ValueError, OSError) as exc:
+                self.logger.warning("Attachment %s not imported: %s", href, exc)
+                continue
+            ditamap_root.remove(ref)
+            attachments[path.name] = data
+            title = " ".join((ref.findtext("topicmeta/navtitle") or "").split())
+            if title and title != path.stem.replace("_", " "):
+                titles[path.name] = title
+        return attachments, titles
+
+    def _read_media_files(self, media_dir: Optional[Path], extensions: set, kind: str) -> Dict[str, bytes]:
+        """Read media files matching *extensions* using the media worker pool.
+        
+        The pipeline memory budget bounds both the concurrent reads and the
+        bytes kept: files that would take the media held in memory past the
+        budget are copied into a :class:`SpooledBlobs` instead of read, as
+        they always are in low-memory mode.
+        """
+        result: Dict[str, bytes] = {}
         
         if not media_dir or not media_dir.exists():
-            return videos
+            return result
         
-        # Supported video extensions (common set)
-        video_extensions = {'.mp4', '.mov', '.avi', '.mkv', '.webm', '.m4v', '.wmv'}
+        # Sorted so dict order (and thus output order) never depends on the filesystem
+        paths = []
+        for media_path in sorted(media_dir.iterdir()):
+            check_cancelled(self._cancel_token)
+            if media_path.is_file() and media_path.suffix.lower() in extensions:
+                paths.append(media_path)
         
-        for video_path in media_dir.iterdir():
-            if not video_path.is_file():
-                continue
-            
-            if video_path.suffix.lower() not in video_extensions:
-                continue
-            
+        def _size(path: Path) -> int:
             try:
-                with open(video_path, 'rb') as f:
-                    videos[video_path.name] = f.read()
-                self.logger.debug("Loaded video: %s (%d bytes)", 
-                                video_path.name, len(videos[video_path.name]))
-                
-            except OSError as e:
-                self.logger.error("Failed to read video %s: %s", video_path.name, e)
-                continue
+                return path.stat().st_size
+            except OSError:
+                return 0
         
-        return videos
+        def _read(path: Path) -> Optional[bytes]:
+            if is_expired(self._time_budget):
+                return _SKIPPED
+            try:
+                with open(path, 'rb') as f:
+                    return f.read()
+            except OSError as e:
+                self.logger.error("Failed to read %s %s: %s", kind, path.name, e)
+                return None
+        
+        budget = self._settings.budget()
+        total = sum(_size(path) for path in paths)
+        spool_files = self._spool
+        if not spool_files and budget.limit_bytes and self._retained_bytes + total > budget.limit_bytes:
+            spool_files = True
+            self.logger.info("%s files (%d bytes) exceed the memory budget; holding them on disk", kind, total)
+            self._report.info("low_memory", f"{len(paths)} {kind} file(s) held on disk: keeping them in memory "
+                                            f"would exceed memory_budget_mb", kind=kind, files=len(paths))
+
+        if spool_files:
+            spool = SpooledBlobs(directory=self._settings.spool_dir)
+            skipped = 0
+            for path in paths:
+                check_cancelled(self._cancel_token)
+                if is_expired(self._time_budget):
+                    skipped += 1
+                    continue
+                try:
+                    spool.add_file(path.name, path)
+                except OSError as e:
+                    self.logger.error("Failed to read %s %s: %s", kind, path.name, e)
+            self._record_skipped(skipped, kind)
+            return spool  # type: ignore[return-value]
+
+        blobs = ordered_map(_read, paths,
+                            workers=self._settings.media_workers,
+                            cancel_token=self._cancel_token,
+                            budget=budget,
+                            size_of=_size)
+        self._retained_bytes += total
+        skipped = 0
+        for path, data in zip(paths, blobs):
+            if data is _SKIPPED:
+                skipped += 1
+            elif data is not None:
+                result[path.name] = data
+                self.logger.debug("Loaded %s: %s (%d bytes)", kind, path.name, len(data))
+        self._record_skipped(skipped, kind)
+        
+        return result
+
+    def _record_skipped(self, count: int, kind: str) -> None:
+        """Note items left out because the time budget ran out."""
+        if not count or self._time_budget is None:
+            return
+        reason = self._time_budget.describe()
+        self.logger.warning("Time budget exceeded: %d %s file(s) not imported", count, kind)
+        self._report.warning("time_budget", f"{count} {kind} file(s) not imported: {reason}",
+                             kind=kind, skipped=count)
+        self._report.mark_partial(reason)
     
+    def _record_parse_audit(self, root_dir: Path) -> None:
+        """Copy DOCTYPE/entity findings of this import into the report."""
+        for record in sorted(self._parse_audit, key=lambda r: r.source):
+            try:
+                name = str(Path(record.source).relative_to(root_dir))
+            except ValueError:
+                name = Path(record.source).name
+            detail = record.as_detail()
+            detail["source"] = name
+            if record.refused:
+                message = f"External entities not resolved: {', '.join(record.refused)}"
+            elif record.resolved:
+                message = f"External entities resolved (opt-in): {', '.join(record.resolved)}"
+            else:
+                message = "Document declares a DOCTYPE or internal entities"
+            self._report.info("xml_security", message, topic=Path(name).name, **detail)
+
     def _get_current_timestamp(self) -> str:
         """Get current timestamp in ISO format.
         
@@ -507,12 +874,12 @@ class DitaPackageImporter:
         Returns:
             List of supported extensions
         """
-        return ['.zip']
+        return list(_FORMAT_DESCRIPTIONS)
     
-    def get_format_description(self) -> str:
+    def get_format_description(self, extension: str = ".zip") -> str:
         """Get human-readable description of supported format.
         
         Returns:
             Format description string
         """
-        return "Zipped DITA Package Archives"
\ No newline at end of file
+        return _FORMAT_DESCRIPTIONS.get(extension.lower(), "Zipped DITA Package Archives")
\ No newline at end of file
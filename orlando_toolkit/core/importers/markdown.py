@@ -0,0 +1,322 @@
+from __future__ import annotations
+
+"""Markdown source parser (CommonMark with GitHub tables and alerts).
+
+Supported: ATX and setext headings (``{#id}`` sets the anchor, otherwise
+the GitHub slug of the title), paragraphs, bullet and numbered lists with
+nesting, fenced and indented code (the info string becomes
+``outputclass="language-…"``), block quotes (``> [!NOTE]`` alerts become
+notes), pipe tables, images (alone in a paragraph they become figures),
+links, emphasis, strong emphasis and code spans. YAML front matter sets the
+title (``title``) and language (``lang``). Raw HTML is kept as text, never
+interpreted.
+"""
+
+import logging
+import re
+from typing import Any, List, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.importers.markup import DocumentParser, ParsedDocument, Section
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["MarkdownParser", "github_slug"]
+
+_ATX = re.compile(r"^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$")
+_HEADING_ID = re.compile(r"\s*\{#([\w:.-]+)\}\s*$")
+_SETEXT = re.compile(r"^ {0,3}(=+|-+)[ \t]*$")
+_FENCE = re.compile(r"^( {0,3})(`{3,}|~{3,})[ \t]*([^`\s]*)")
+_RULE = re.compile(r"^ {0,3}([-*_])(?:[ \t]*\1){2,}[ \t]*$")
+_BULLET = re.compile(r"^( *)([-*+])[ \t]+(.*)$")
+_ORDERED = re.compile(r"^( *)(\d{1,9})[.)][ \t]+(.*)$")
+_TABLE_RULE = re.compile(r"^\s*\|?\s*:?-{1,}:?\s*(\|\s*:?-{1,}:?\s*)*\|?\s*$")
+_ALERT = re.compile(r"^\[!(NOTE|TIP|IMPORTANT|WARNING|CAUTION)\]\s*$", re.I)
+_COMMENT = re.compile(r"^\s*<!--.*?-->\s*$")
+
+_INLINE = re.compile(
+    r"(?P<code>(`+)(?P<code_text>.+?)\2(?!`))"
+    r"|(?P<image>!\[(?P<alt>[^\]]*)\]\((?P<src><[^>]*>|[^)\s]+)(?:\s+\"(?P<img_title>[^\"]*)\")?\))"
+    r"|(?P<link>\[(?P<link_text>(?:[^\[\]]|\[[^\]]*\])*)\]\((?P<href><[^>]*>|[^)\s]*)(?:\s+\"[^\"]*\")?\))"
+    r"|(?P<auto><(?P<auto_href>(?:https?|mailto):[^>\s]+)>)"
+    r"|(?P<strong>(?P<strong_mark>\*\*|__)(?P<strong_text>\S(?:.*?\S)?)(?P=strong_mark))"
+    r"|(?P<em>(?<![\w*])(?P<em_mark>\*|_)(?P<em_text>\S(?:.*?\S)?)(?P=em_mark)(?![\w*]))"
+    r"|(?P<escape>\\(?P<escaped>[\\`*_{}\[\]()#+\-.!|>~]))"
+)
+
+
+def github_slug(title: str) -> str:
+    """The anchor GitHub generates for a heading."""
+    slug = re.sub(r"[^\w\- ]", "", title.strip().lower())
+    return slug.replace(" ", "-")
+
+
+def _append_text(parent, text: str) -> None:
+    if not text:
+        return
+    if len(parent):
+        parent[-1].tail = (parent[-1].tail or "") + text
+    else:
+        parent.text = (parent.text or "") + text
+
+
+def inline(parent, text: str) -> None:
+    """Append the Markdown inline content *text* to *parent*."""
+    position = 0
+    for match in _INLINE.finditer(text):
+        _append_text(parent, text[position:match.start()])
+        position = match.end()
+        kind = match.lastgroup
+        if kind == "code":
+            ET.SubElement(parent, "codeph").text = match.group("code_text").strip(" ") or match.group("code_text")
+        elif kind == "image":
+            image = ET.SubElement(parent, "image", href=match.group("src").strip("<>"), placement="inline")
+            if match.group("alt"):
+                ET.SubElement(image, "alt").text = match.group("alt")
+        elif kind == "link":
+            xref = ET.SubElement(parent, "xref", href=match.group("href").strip("<>"))
+            inline(xref, match.group("link_text"))
+        elif kind == "auto":
+            ET.SubElement(parent, "xref", href=match.group("auto_href")).text = match.group("auto_href")
+        elif kind == "strong":
+            inline(ET.SubElement(parent, "b"), match.group("strong_text"))
+        elif kind == "em":
+            inline(ET.SubElement(parent, "i"), match.group("em_text"))
+        else:
+            _append_text(parent, match.group("escaped"))
+    _append_text(parent, text[position:])
+
+
+def _paragraph(lines: List[str]) -> Any:
+    text = " ".join(line.strip() for line in lines)
+    image = _INLINE.fullmatch(text)
+    if image is not None and image.group("image"):
+        fig = ET.Element("fig")
+        if image.group("img_title"):
+            ET.SubElement(fig, "title").text = image.group("img_title")
+        img = ET.SubElement(fig, "image", href=image.group("src").strip("<>"))
+        if image.group("alt"):
+            ET.SubElement(img, "alt").text = image.group("alt")
+        return fig
+    para = ET.Element("p")
+    inline(para, text)
+    return para
+
+
+def _cells(line: str) -> List[str]:
+    line = line.strip()
+    if line.startswith("|"):
+        line = line[1:]
+    if line.endswith("|") and not line.endswith("\\|"):
+        line = line[:-1]
+    return [c.strip().replace("\\|", "|") for c in re.split(r"(?<!\\)\|", line)]
+
+
+def _table(header: str, rows: List[str]) -> Any:
+    table = ET.Element("simpletable")
+    head = ET.SubElement(table, "sthead")
+    columns = _cells(header)
+    for cell in columns:
+        inline(ET.SubElement(head, "stentry"), cell)
+    for row in rows:
+        strow = ET.SubElement(table, "strow")
+        cells = (_cells(row) + [""] * len(columns))[:len(columns)]
+        for cell in cells:
+            inline(ET.SubElement(strow, "stentry"), cell)
+    return table
+
+
+def _codeblock(lines: List[str], language: str = "") -> Any:
+    code = ET.Element("codeblock")
+    code.set("{http://www.w3.org/XML/1998/namespace}space", "preserve")
+    if language:
+        code.set("outputclass", f"language-{language}")
+    code.text = "\n".join(lines)
+    return code
+
+
+def _indent(line: str) -> int:
+    return len(line) - len(line.lstrip(" "))
+
+
+def _blank(line: str) -> bool:
+    return not line.strip()
+
+
+class MarkdownParser(DocumentParser):
+    format_name = "markdown"
+    extensions = (".md", ".markdown", ".mdown")
+    description = "Markdown document"
+
+    def parse(self, text: str) -> ParsedDocument:
+        lines = text.replace("\r\n", "\n").replace("\r", "\n").replace("\t", "    ").split("\n")
+        document = ParsedDocument()
+        lines = self._front_matter(lines, document)
+        current: List[Any] = document.preamble
+        for item in self._blocks(lines, headings=True):
+            if isinstance(item, Section):
+                document.sections.append(item)
+                current = item.blocks
+            else:
+                current.append(item)
+        return document
+
+    @staticmethod
+    def _front_matter(lines: List[str], document: ParsedDocument) -> List[str]:
+        if not lines or lines[0].strip() != "---":
+            return lines
+        end = next((i for i in range(1, len(lines)) if lines[i].strip() in ("---", "...")), None)
+        if end is None:
+            return lines
+        try:
+            import yaml
+
+            data = yaml.safe_load("\n".join(lines[1:end])) or {}
+        except Exception as exc:
+            logger.warning("Ignoring unreadable front matter: %s", exc)
+            data = {}
+        if isinstance(data, dict):
+            document.attributes.update({str(k): str(v) for k, v in data.items() if not isinstance(v, (dict, list))})
+            if document.attributes.get("title"):
+                document.title = document.attributes["title"]
+        return lines[end + 1:]
+
+    def _blocks(self, lines: List[str], headings: bool = False) -> List[Any]:
+        """Blocks (and, at top level, :class:`Section` starts) of *lines*."""
+        out: List[Any] = []
+        i = 0
+        while i < len(lines):
+            line = lines[i]
+            if _blank(line) or _COMMENT.match(line):
+                i += 1
+                continue
+            atx = _ATX.match(line)
+            if headings and atx:
+                out.append(self._section(len(atx.group(1)), atx.group(2) or ""))
+                i += 1
+                continue
+            fence = _FENCE.match(line)
+            if fence:
+                marker, body = fence.group(2), []
+                i += 1
+                while i < len(lines) and not lines[i].strip().startswith(marker):
+                    body.append(lines[i][min(len(fence.group(1)), _indent(lines[i])):])
+                    i += 1
+                out.append(_codeblock(body, fence.group(3)))
+                i += 1
+                continue
+            if _indent(line) >= 4:
+                body = []
+                while i < len(lines) and (_indent(lines[i]) >= 4 or _blank(lines[i])):
+                    body.append(lines[i][4:])
+                    i += 1
+                while body and not body[-1].strip():
+                    body.pop()
+                out.append(_codeblock(body))
+                continue
+            if _RULE.match(line):
+                i += 1
+                continue
+            if line.lstrip().startswith(">"):
+                quoted = []
+                while i < len(lines) and lines[i].lstrip().startswith(">"):
+                    quoted.append(re.sub(r"^\s*> ?", "", lines[i]))
+                    i += 1
+                out.append(self._quote(quoted))
+                continue
+            if _BULLET.match(line) or _ORDERED.match(line):
+                block, i = self._list(lines, i)
+                out.append(block)
+                continue
+            if "|" in line and i + 1 < len(lines) and _TABLE_RULE.match(lines[i + 1]) and "-" in lines[i + 1]:
+                rows = []
+                j = i + 2
+                while j < len(lines) and "|" in lines[j] and not _blank(lines[j]):
+                    rows.append(lines[j])
+                    j += 1
+                out.append(_table(line, rows))
+                i = j
+                continue
+            para = [line]
+            i += 1
+            while i < len(lines) and not _blank(lines[i]):
+                nxt = lines[i]
+                if headings and _SETEXT.match(nxt):
+                    break
+                if _ATX.match(nxt) or _FENCE.match(nxt) or nxt.lstrip().startswith(">") or \
+                        _BULLET.match(nxt) or (_ORDERED.match(nxt) and _ORDERED.match(nxt).group(2) == "1"):
+                    break
+                para.append(nxt)
+                i += 1
+            if headings and i < len(lines) and _SETEXT.match(lines[i]):
+                out.append(self._section(1 if lines[i].strip().startswith("=") else 2,
+                                         " ".join(p.strip() for p in para)))
+                i += 1
+                continue
+            out.append(_paragraph(para))
+        return out
+
+    @staticmethod
+    def _section(level: int, raw: str) -> Section:
+        anchor_match = _HEADING_ID.search(raw)
+        title = _HEADING_ID.sub("", raw).strip() if anchor_match else raw.strip()
+        plain = ET.Element("title")
+        inline(plain, title)
+        text = "".join(plain.itertext()).strip()
+        return Section(level=level, title=text, anchor=anchor_match.group(1) if anchor_match else github_slug(text))
+
+    def _quote(self, lines: List[str]) -> Any:
+        alert = _ALERT.match(lines[0].strip()) if lines else None
+        if alert:
+            note = ET.Element("note", type=alert.group(1).lower())
+            note.extend(self._blocks(lines[1:]))
+            return note
+        quote = ET.Element("lq")
+        quote.extend(self._blocks(lines))
+        return quote
+
+    def _list(self, lines: List[str], start: int) -> Tuple[Any, int]:
+        first = _BULLET.match(lines[start]) or _ORDERED.match(lines[start])
+        ordered = _ORDERED.match(lines[start]) is not None
+        base = len(first.group(1))
+        element = ET.Element("ol" if ordered else "ul")
+        items: List[List[str]] = []
+        loose = False
+        i = start
+        while i < len(lines):
+            line = lines[i]
+            marker = _ORDERED.match(line) if ordered else _BULLET.match(line)
+            if marker and len(marker.group(1)) == base:
+                items.append([marker.group(3)])
+                content_indent = len(line) - len(marker.group(3))
+                i += 1
+                continue
+            if _blank(line):
+                nxt = lines[i + 1] if i + 1 < len(lines) else ""
+                next_marker = _ORDERED.match(nxt) if ordered else _BULLET.match(nxt)
+                if _indent(nxt) > base and not _blank(nxt) or (next_marker and len(next_marker.group(1)) == base):
+                    loose = loose or bool(next_marker)
+                    items[-1].append("")
+                    i += 1
+                    continue
+                break
+            if _indent(line) > base:
+                items[-1].append(line[min(content_indent, _indent(line)):])
+                i += 1
+                continue
+            other = _BULLET.match(line) or _ORDERED.match(line)
+            if other or _ATX.match(line) or _FENCE.match(line):
+                break
+            items[-1].append(line.strip())  # lazy continuation
+            i += 1
+        for item in items:
+            li = ET.SubElement(element, "li")
+            blocks = self._blocks(item)
+            if len(blocks) >= 1 and blocks[0].tag == "p" and not loose:
+                para = blocks.pop(0)
+                li.text = para.text
+                for child in list(para):
+                    li.append(child)
+            li.extend(blocks)
+        return element, i
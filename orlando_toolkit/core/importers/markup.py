@@ -0,0 +1,390 @@
+from __future__ import annotations
+
+"""Built-in intake of lightweight markup sources (Markdown, AsciiDoc).
+
+Plugins convert rich formats such as DOCX. Plain-text markup needs no
+plugin: a :class:`DocumentParser` turns the source text into a
+:class:`ParsedDocument` (document title, attributes, content before the
+first heading, and one :class:`Section` of DITA block elements per heading)
+and :class:`MarkupDocumentImporter` builds the :class:`DitaContext` from it
+the way a handler would:
+
+- the heading outline goes through
+  :func:`~orlando_toolkit.core.heading_rules.apply_heading_rules`, so the
+  ``headings`` rules of ``conversion.yml`` apply; with
+  ``markup.title_from_single_h1`` a lone leading level-1 heading becomes
+  the map title; a heading excluded in the heading review becomes a bold
+  ``p outputclass="excluded-heading"`` in the topic before it;
+- every heading becomes a concept and a topicref carrying ``data-level``
+  and ``data-style="Heading N"``; the topic depth is applied when the package
+  is prepared, as for any other source;
+- links to heading anchors (``#install``) point to the matching topic and
+  local images are read into ``context.images`` (``image_root``, by default
+  only files below the source folder);
+- absolute link and image URLs must pass the ``html`` policy of
+  ``security.yml`` (:meth:`HtmlSanitizerPolicy.url_allowed`): a link to
+  ``javascript:…`` keeps only its text and such an image is dropped.
+
+Topics are built with the ``topics`` workers and images read with the
+``media`` workers of :class:`~orlando_toolkit.core.concurrency.PipelineSettings`;
+both are assembled in document order.
+
+:meth:`ConversionService.convert` uses the importer for the registered
+extensions when no plugin handler claims the file, then runs the same
+processing stages. :func:`register_parser` adds formats.
+"""
+
+import logging
+import re
+from abc import ABC, abstractmethod
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Callable, Dict, List, Optional, Sequence, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.cancellation import CancellationToken, check_cancelled
+from orlando_toolkit.core.concurrency import PipelineSettings, ordered_map, progress_steps
+from orlando_toolkit.core.heading_rules import Heading, apply_heading_rules
+from orlando_toolkit.core.html_sanitizer import HtmlSanitizerPolicy
+from orlando_toolkit.core.i18n import XML_LANG
+from orlando_toolkit.core.models import ConversionReport, DitaContext
+from orlando_toolkit.core.spool import SpooledBlobs, media_store
+from orlando_toolkit.core.stable_ids import ANCHOR_HINT
+from orlando_toolkit.core.time_budget import TimeBudget, is_expired
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "DocumentParser",
+    "MarkupDocumentImporter",
+    "ParsedDocument",
+    "Section",
+    "get_parsers",
+    "register_parser",
+]
+
+_EXTERNAL = re.compile(r"^[a-z][a-z0-9+.-]*:", re.I)
+
+
+@dataclass
+class Section:
+    """One heading and the DITA block elements up to the next heading."""
+
+    level: int
+    title: str
+    anchor: Optional[str] = None
+    blocks: List[Any] = field(default_factory=list)
+
+
+@dataclass
+class ParsedDocument:
+    """Result of :meth:`DocumentParser.parse`."""
+
+    title: Optional[str] = None
+    attributes: Dict[str, str] = field(default_factory=dict)
+    preamble: List[Any] = field(default_factory=list)
+    sections: List[Section] = field(default_factory=list)
+
+
+class DocumentParser(ABC):
+    """Source-format layer for text sources.
+
+    Subclasses declare their ``extensions`` (lowercase, with the dot) and a
+    ``format_name`` and turn the source text into DITA blocks. Images keep
+    the source path in ``href``; links to headings are ``xref href="#anchor"``
+    with the anchors given in :attr:`Section.anchor`.
+    """
+
+    format_name: str = ""
+    extensions: Tuple[str, ...] = ()
+    description: str = ""
+
+    def can_parse(self, file_path: Path) -> bool:
+        return Path(file_path).suffix.lower() in self.extensions
+
+    @abstractmethod
+    def parse(self, text: str) -> ParsedDocument:
+        """Return the structure of *text*."""
+
+
+_PARSERS: List[DocumentParser] = []
+
+
+def register_parser(parser: DocumentParser) -> None:
+    """Make *parser* available to :class:`MarkupDocumentImporter` (replacing one for the same format)."""
+    _PARSERS[:] = [p for p in _PARSERS if p.format_name != parser.format_name]
+    _PARSERS.append(parser)
+
+
+def get_parsers() -> List[DocumentParser]:
+    """Registered parsers, the built-in Markdown and AsciiDoc ones first."""
+    if not any(p.format_name == "markdown" for p in _PARSERS):
+        from orlando_toolkit.core.importers.markdown import MarkdownParser
+
+        _PARSERS.insert(0, MarkdownParser())
+    if not any(p.format_name == "asciidoc" for p in _PARSERS):
+        from orlando_toolkit.core.importers.asciidoc import AsciiDocParser
+
+        _PARSERS.insert(1, AsciiDocParser())
+    return list(_PARSERS)
+
+
+def _excluded_blocks(section: Section) -> List[Any]:
+    """The blocks of a heading excluded in the heading review, its title kept as a bold paragraph."""
+    title = ET.Element("p", outputclass="excluded-heading")
+    ET.SubElement(title, "b").text = section.title
+    return [title] + list(section.blocks)
+
+
+def _unwrap(el: Any) -> None:
+    """Replace *el* by its text and children."""
+    parent, previous = el.getparent(), el.getprevious()
+    if el.text:
+        if previous is not None:
+            previous.tail = (previous.tail or "") + el.text
+        else:
+            parent.text = (parent.text or "") + el.text
+    index = parent.index(el)
+    for offset, child in enumerate(list(el)):
+        parent.insert(index + offset, child)
+    _drop(el)
+
+
+def _drop(el: Any) -> None:
+    """Remove *el*, keeping its tail."""
+    parent, previous = el.getparent(), el.getprevious()
+    if el.tail:
+        if previous is not None:
+            previous.tail = (previous.tail or "") + el.tail
+        else:
+            parent.text = (parent.text or "") + el.tail
+    parent.remove(el)
+
+
+def _read_text(path: Path) -> str:
+    data = path.read_bytes()
+    for encoding in ("utf-8-sig", "cp1252"):
+        try:
+            return data.decode(encoding)
+        except UnicodeDecodeError:
+            continue
+    return data.decode("latin-1")
+
+
+class MarkupDocumentImporter:
+    """Build a :class:`DitaContext` from a source a :class:`DocumentParser` understands."""
+
+    def __init__(self, parsers: Optional[Sequence[DocumentParser]] = None) -> None:
+        self._parsers = list(parsers) if parsers is not None else None
+
+    @property
+    def parsers(self) -> List[DocumentParser]:
+        return self._parsers if self._parsers is not None else get_parsers()
+
+    def parser_for(self, file_path: Path) -> Optional[DocumentParser]:
+        return next((p for p in self.parsers if p.can_parse(Path(file_path))), None)
+
+    def can_import(self, file_path: Path) -> bool:
+        return self.parser_for(file_path) is not None
+
+    def get_supported_extensions(self) -> List[str]:
+        return [ext for p in self.parsers for ext in p.extensions]
+
+    def import_document(self, file_path: Path, metadata: Optional[Dict[str, Any]] = None,
+                        progress_callback: Optional[Callable[[str], None]] = None, *,
+                        cancel_token: Optional[CancellationToken] = None,
+                        time_budget: Optional[TimeBudget] = None) -> DitaContext:
+        """Parse *file_path* and return its context (processing stages not yet run)."""
+        from orlando_toolkit.core.processing import resolve_conversion_options
+
+        file_path = Path(file_path)
+        parser = self.parser_for(file_path)
+        if parser is None:
+            raise ValueError(f"No parser for {file_path.suffix or file_path.name}")
+        metadata = dict(metadata or {})
+        options = resolve_conversion_options(metadata).get("markup") or {}
+        report = ConversionReport()
+        if progress_callback:
+            progress_callback(f"Parsing {parser.format_name} document...")
+        parsed = parser.parse(_read_text(file_path))
+        check_cancelled(cancel_token)
+
+        headings = [Heading(s.level, s.title, f"Heading {s.level}") for s in parsed.sections]
+        outline = apply_heading_rules(headings, metadata, report=report)
+        levels = list(outline.levels)
+        title = parsed.title or outline.map_title
+        if not title and options.get("title_from_single_h1", True) and levels and levels[0] == 1 \
+                and levels.count(1) == 1:
+            title, levels[0] = parsed.sections[0].title, None
+            levels = [None if lv is None else max(1, lv - 1) for lv in levels]
+        if not str(metadata.get("manual_title") or "").strip():
+            metadata["manual_title"] = title or file_path.stem
+        language = parsed.attributes.get("lang") or parsed.attributes.get("language")
+        if language and not metadata.get("language"):
+            metadata["language"] = language
+
+        context = DitaContext(metadata=metadata, report=report)
+        context.images = media_store(metadata, file_path)
+        context.ditamap_root = ET.Element("map")
+        if language:
+            context.ditamap_root.set(XML_LANG, language)
+        ET.SubElement(context.ditamap_root, "title").text = metadata["manual_title"]
+
+        settings = PipelineSettings.resolve(metadata)
+        preamble = list(parsed.preamble)
+        kept: List[Tuple[Section, int]] = []
+        merged: Dict[int, List[Section]] = {}       # excluded headings, by the kept section before them
+        for index, (section, level) in enumerate(zip(parsed.sections, levels)):
+            if level is not None:
+                kept.append((section, level))
+            elif index in outline.excluded and kept:
+                merged.setdefault(len(kept) - 1, []).append(section)
+            else:
+                preamble.extend(_excluded_blocks(section) if index in outline.excluded else section.blocks)
+        jobs = [(f"topic_{n}.dita", s.title,
+                 s.blocks + [b for m in merged.get(n - 1, ()) for b in _excluded_blocks(m)], s.anchor)
+                for n, (s, _) in enumerate(kept, start=1)]
+        topics = ordered_map(lambda job: self._build_topic(*job), jobs, workers=settings.workers_for("topics"),
+                             cancel_token=cancel_token, progress=progress_steps(progress_callback, "Building topics"))
+
+        stack: List[Tuple[int, Any]] = [(0, context.ditamap_root)]
+        anchors: Dict[str, str] = {}
+        for number, ((section, level), topic) in enumerate(zip(kept, topics)):
+            name = f"{topic.get('id')}.dita"
+            context.topics[name] = topic
+            for anchor in [section.anchor] + [m.anchor for m in merged.get(number, ())]:
+                if anchor:
+                    anchors.setdefault(anchor, name)
+            while stack[-1][0] >= level:
+                stack.pop()
+            tref = ET.SubElement(stack[-1][1], "topicref", href=f"topics/{name}")
+            tref.set("data-level", str(level))
+            tref.set("data-style", f"Heading {level}")
+            ET.SubElement(ET.SubElement(tref, "topicmeta"), "navtitle").text = section.title
+            stack.append((level, tref))
+        if preamble:
+            name = self._add_topic(context, metadata["manual_title"], preamble, None, first=True)
+            tref = ET.Element("topicref", href=f"topics/{name}")
+            tref.set("data-level", "1")
+            ET.SubElement(ET.SubElement(tref, "topicmeta"), "navtitle").text = metadata["manual_title"]
+            context.ditamap_root.insert(1, tref)
+
+        if progress_callback:
+            progress_callback("Resolving links and images...")
+        policy = HtmlSanitizerPolicy.from_config()
+        self._resolve_links(context, anchors, policy)
+        self._load_images(context, file_path, options, time_budget, settings, cancel_token, policy)
+        context.plugin_data = {"_source_plugin": f"built-in:{parser.format_name}"}
+        report.info("markup", f"{parser.format_name} source parsed: {len(context.topics)} topic(s), "
+                              f"{len(context.images)} image(s)", format=parser.format_name)
+        return context
+
+    @staticmethod
+    def _build_topic(name: str, title: str, blocks: List[Any], anchor: Optional[str]) -> Any:
+        topic = ET.Element("concept", id=name[:-5])
+        if anchor:
+            topic.set(ANCHOR_HINT, anchor)
+        ET.SubElement(topic, "title").text = title
+        body = ET.SubElement(topic, "conbody")
+        body.extend(blocks)
+        return topic
+
+    @classmethod
+    def _add_topic(cls, context: DitaContext, title: str, blocks: List[Any], anchor: Optional[str],
+                   first: bool = False) -> str:
+        number = len(context.topics) + 1
+        name = f"topic_{number}.dita"
+        while name in context.topics:
+            number += 1
+            name = f"topic_{number}.dita"
+        topic = cls._build_topic(name, title, blocks, anchor)
+        if first:
+            context.topics = {name: topic, **context.topics}
+        else:
+            context.topics[name] = topic
+        return name
+
+    @staticmethod
+    def _resolve_links(context: DitaContext, anchors: Dict[str, str],
+                       policy: Optional[HtmlSanitizerPolicy] = None) -> None:
+        policy = policy or HtmlSanitizerPolicy()
+        element_ids: Dict[str, Tuple[str, str]] = {}
+        for name, topic in context.topics.items():
+            for el in topic.iter():
+                if isinstance(el.tag, str) and el.get("id") and el is not topic:
+                    element_ids.setdefault(el.get("id"), (name, topic.get("id")))
+        for name, topic in context.topics.items():
+            for xref in list(topic.iter("xref")):
+                href = xref.get("href") or ""
+                if _EXTERNAL.match(href) and not policy.url_allowed("a", href):
+                    context.report.warning("markup", f"Link to a disallowed URL removed: {href[:80]}", topic=name)
+                    _unwrap(xref)
+                    continue
+                if _EXTERNAL.match(href):
+                    xref.set("scope", "external")
+                    xref.set("format", "html")
+                    continue
+                if not href.startswith("#"):
+                    continue
+                anchor = href[1:]
+                if anchor in anchors:
+                    xref.set("href", anchors[anchor])
+                elif anchor in element_ids:
+                    target, topic_id = element_ids[anchor]
+                    xref.set("href", f"{target}#{topic_id}/{anchor}")
+                else:
+                    context.report.warning("markup", f"Link to unknown anchor #{anchor}", topic=name)
+
+    @staticmethod
+    def _load_images(context: DitaContext, source: Path, options: Dict[str, Any],
+                     time_budget: Optional[TimeBudget], settings: Optional[PipelineSettings] = None,
+                     cancel_token: Optional[CancellationToken] = None,
+                     policy: Optional[HtmlSanitizerPolicy] = None) -> None:
+        policy = policy or HtmlSanitizerPolicy()
+        base = source.parent.resolve()
+        root = Path(options["image_root"]).expanduser().resolve() if options.get("image_root") else base
+        uses: Dict[Path, List[Any]] = {}
+        for name, topic in context.topics.items():
+            for image in list(topic.iter("image")):
+                href = image.get("href") or ""
+                if _EXTERNAL.match(href) and not policy.url_allowed("img", href):
+                    context.report.warning("markup", f"Image with a disallowed URL removed: {href[:80]}", topic=name)
+                    parent = image.getparent()
+                    _drop(parent if parent.tag == "fig" else image)
+                    continue
+                if not href or _EXTERNAL.match(href):
+                    if href:
+                        image.set("scope", "external")
+                    continue
+                path = (base / href.split("#")[0].split("?")[0]).resolve()
+                if path not in uses and (root not in path.parents or not path.is_file()):
+                    context.report.warning("markup", f"Image not found or outside {root}: {href}", topic=name)
+                    continue
+                uses.setdefault(path, []).append(image)
+
+        spool = context.images if isinstance(context.images, SpooledBlobs) else None
+
+        def _read(path: Path) -> Any:
+            if is_expired(time_budget):
+                return None
+            # Spooled images are linked from the source folder, not read
+            return path if spool is not None else path.read_bytes()
+
+        settings = settings or PipelineSettings()
+        paths = list(uses)
+        blobs = ordered_map(_read, paths, workers=settings.workers_for("media"), cancel_token=cancel_token,
+                            budget=settings.budget(), size_of=lambda p: p.stat().st_size)
+        for path, data in zip(paths, blobs):
+            if data is None:
+                context.report.mark_partial("time budget exhausted while reading images")
+                return
+            filename, n = path.name, 2
+            while filename in context.images:
+                filename, n = f"{path.stem}_{n}{path.suffix}", n + 1
+            if spool is not None:
+                spool.add_file(filename, data)
+            else:
+                context.images[filename] = data
+            for image in uses[path]:
+                image.set("href", f"../media/{filename}")
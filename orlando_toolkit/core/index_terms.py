@@ -0,0 +1,337 @@
+from __future__ import annotations
+
+"""Word index entries (``XE`` fields) as DITA ``<indexterm>``.
+
+Back-of-book indexes are built from ``XE`` fields, which converters drop.
+After the plugin handler returns, :func:`restore_word_index_terms` reads the
+``XE`` fields of ``word/document.xml`` and puts each entry back:
+
+- ``XE "Pump:Seals:Replacing"`` becomes nested ``<indexterm>`` elements, one
+  level per ``:``-separated term (``\\:`` is a literal colon);
+- ``\\t "See Gaskets"`` becomes ``<index-see>`` (``See also`` gives
+  ``<index-see-also>``);
+- ``\\r Bookmark`` (a page range) sets ``start`` on the entry and adds an
+  ``<indexterm end>`` where the bookmark ends;
+- with ``placement: inline`` the entry goes where the field was: at a
+  converter's ``<ph data-indexterm="N"/>`` (``N`` counts the ``XE`` fields
+  of the document from 1), or in the paragraph holding it, located by its
+  text like footnotes are (:mod:`orlando_toolkit.core.placement`). Entries in
+  headings go to the topic's prolog;
+- with ``placement: prolog`` the entries of each topic are gathered, without
+  duplicates or ranges, in ``prolog/metadata/keywords``.
+
+Documents whose topics already contain ``<indexterm>`` are left as
+converted. Counts are reported under ``index``; the ``index_terms`` section
+of ``conversion.yml`` controls the pass.
+"""
+
+import logging
+import re
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.placement import find_block, innermost_block, insert_at, normalize, offset_of, \
+    text_blocks, topic_order
+from orlando_toolkit.core.word_source import WordSource, w
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "INDEX_HINT",
+    "IndexEntry",
+    "WordIndex",
+    "indexterm_element",
+    "parse_xe_field",
+    "prolog_keywords",
+    "read_word_index",
+    "restore_word_index_terms",
+]
+
+INDEX_HINT = "data-indexterm"
+_PLACEMENTS = ("inline", "prolog")
+_TITLES = ("title", "navtitle")
+_QUOTED = r'"((?:[^"\\]|\\.)*)"'
+_XE = re.compile(r'^\s*XE\s+' + _QUOTED + r'(.*)$', re.S)
+_SWITCH = re.compile(r'\\([a-z])(?:\s+(?:' + _QUOTED + r'|(\S+)))?', re.I)
+# Elements a topic's prolog follows
+_BEFORE_PROLOG = ("title", "titlealts", "shortdesc", "abstract")
+
+
+@dataclass
+class IndexEntry:
+    terms: List[str] = field(default_factory=list)     # outermost first
+    see: List[str] = field(default_factory=list)
+    see_also: List[str] = field(default_factory=list)
+    range: str = ""                                      # bookmark of a page range
+    end: bool = False                                    # the range's end marker
+    number: int = 0                                      # XE field number, from 1
+
+    @property
+    def key(self) -> Tuple[Tuple[str, ...], Tuple[str, ...], Tuple[str, ...]]:
+        return tuple(self.terms), tuple(self.see), tuple(self.see_also)
+
+
+@dataclass
+class WordIndex:
+    entries: List[IndexEntry] = field(default_factory=list)
+    paragraphs: List[Tuple[str, List[Tuple[int, IndexEntry]]]] = field(default_factory=list)
+
+    def __bool__(self) -> bool:
+        return bool(self.entries)
+
+
+def _levels(text: str) -> List[str]:
+    parts = re.split(r"(?<!\\):", text)
+    return [normalize(p.replace("\\:", ":").replace('\\"', '"')) for p in parts if normalize(p)]
+
+
+def parse_xe_field(instr: str) -> Optional[IndexEntry]:
+    """Entry of an ``XE`` field instruction, ``None`` for other fields."""
+    match = _XE.match(instr or "")
+    if not match:
+        return None
+    entry = IndexEntry(terms=_levels(match.group(1)))
+    if not entry.terms:
+        return None
+    for switch in _SWITCH.finditer(match.group(2)):
+        name, value = switch.group(1).lower(), switch.group(2) if switch.group(2) is not None else switch.group(3)
+        if name == "t" and value:
+            # Word keeps the cross-reference wording: "See Gaskets", "See also Seals"
+            text = normalize(value.replace('\\"', '"'))
+            also = re.match(r"^see also\s+(.*)$", text, re.I)
+            plain = re.match(r"^see\s+(.*)$", text, re.I)
+            if also:
+                entry.see_also = _levels(also.group(1))
+            else:
+                entry.see = _levels(plain.group(1) if plain else text)
+        elif name == "r" and value:
+            entry.range = value
+    return entry
+
+
+@dataclass
+class _Paragraph:
+    text: str = ""
+    items: List[Tuple[int, IndexEntry]] = field(default_factory=list)
+
+
+def _field(paragraph: _Paragraph, instr: str, state: Dict[str, Any]) -> None:
+    entry = parse_xe_field(instr)
+    if entry is None:
+        return
+    state["count"] += 1
+    entry.number = state["count"]
+    state["entries"].append(entry)
+    paragraph.items.append((offset_of(paragraph.text), entry))
+
+
+def _walk(node: Any, paragraph: Optional[_Paragraph], state: Dict[str, Any], out: List[_Paragraph]) -> None:
+    tag = node.tag
+    if tag == w("p"):
+        inner = _Paragraph()
+        for child in node:
+            _walk(child, inner, state, out)
+        out.append(inner)
+        return
+    if tag == w("t") and paragraph is not None:
+        if not state["fields"] or state["fields"][-1][1]:      # field results are shown, instructions are not
+            paragraph.text += node.text or ""
+    elif tag in (w("tab"), w("br")) and paragraph is not None:
+        paragraph.text += " "
+    elif tag == w("bookmarkStart"):
+        state["bookmarks"][node.get(w("id"))] = node.get(w("name")) or ""
+    elif tag == w("bookmarkEnd") and paragraph is not None:
+        name = state["bookmarks"].pop(node.get(w("id")), None)
+        if name:
+            paragraph.items.append((offset_of(paragraph.text), IndexEntry(range=name, end=True)))
+    elif tag == w("instrText"):
+        if state["fields"]:
+            state["fields"][-1][0] += node.text or ""
+        return
+    elif tag == w("fldChar"):
+        kind = node.get(w("fldCharType"))
+        if kind == "begin":
+            state["fields"].append(["", False])
+        elif kind == "separate" and state["fields"]:
+            state["fields"][-1][1] = True
+        elif kind == "end" and state["fields"]:
+            instr, _ = state["fields"].pop()
+            if paragraph is not None:
+                _field(paragraph, instr, state)
+    elif tag == w("fldSimple") and paragraph is not None:
+        for child in node:
+            _walk(child, paragraph, state, out)
+        _field(paragraph, node.get(w("instr")) or "", state)
+        return
+    elif tag == w("delText"):
+        return
+    for child in node:
+        _walk(child, paragraph, state, out)
+
+
+def read_word_index(path: str | Path | WordSource) -> WordIndex:
+    """``XE`` entries of a ``.docx`` and the body paragraphs holding them."""
+    result = WordIndex()
+    docx = WordSource.of(path)
+    body = docx.body if docx is not None else None
+    paragraphs: List[_Paragraph] = []
+    state: Dict[str, Any] = {"fields": [], "bookmarks": {}, "entries": [], "count": 0}
+    if body is not None:
+        for child in body:
+            _walk(child, None, state, paragraphs)
+    result.entries = state["entries"]
+    ranges = {e.range for e in result.entries if e.range}
+    for paragraph in paragraphs:
+        items = [(o, e) for o, e in paragraph.items if not e.end or e.range in ranges]
+        if items:
+            result.paragraphs.append((normalize(paragraph.text), items))
+    return result
+
+
+def indexterm_element(entry: IndexEntry, ranges: bool = True) -> Any:
+    """Nested ``<indexterm>`` for *entry* (``<indexterm end>`` for a range end)."""
+    if entry.end:
+        return ET.Element("indexterm", end=entry.range)
+
+    def _nest(parent: Optional[Any], tag: str, terms: List[str]) -> Tuple[Any, Any]:
+        """*terms* nested under a *tag* element (in *parent*); returns the outer and inner elements."""
+        top = ET.Element(tag) if parent is None else ET.SubElement(parent, tag)
+        top.text = terms[0]
+        leaf = top
+        for term in terms[1:]:
+            leaf = ET.SubElement(leaf, "indexterm")
+            leaf.text = term
+        return top, leaf
+
+    root, leaf = _nest(None, "indexterm", entry.terms)
+    for tag, terms in (("index-see", entry.see), ("index-see-also", entry.see_also)):
+        if terms:
+            _nest(leaf, tag, terms)
+    if ranges and entry.range:
+        leaf.set("start", entry.range)
+    return root
+
+
+def prolog_keywords(topic: Any) -> Any:
+    """The topic's ``prolog/metadata/keywords``, created where DITA expects it."""
+    prolog = topic.find("prolog")
+    if prolog is None:
+        position = 0
+        for index, child in enumerate(topic):
+            if child.tag in _BEFORE_PROLOG:
+                position = index + 1
+        prolog = ET.Element("prolog")
+        topic.insert(position, prolog)
+    metadata = prolog.find("metadata")
+    if metadata is None:
+        metadata = ET.SubElement(prolog, "metadata")
+    keywords = metadata.find("keywords")
+    if keywords is None:
+        keywords = ET.SubElement(metadata, "keywords")
+    return keywords
+
+
+def _replace(el: Any, new: Optional[Any]) -> None:
+    parent = el.getparent()
+    if new is not None:
+        new.tail = el.tail
+        parent.insert(parent.index(el), new)
+    else:
+        previous = el.getprevious()
+        if previous is not None:
+            previous.tail = (previous.tail or "") + (el.tail or "")
+        else:
+            parent.text = (parent.text or "") + (el.tail or "")
+    parent.remove(el)
+
+
+def _placeholders(context: Any) -> List[Tuple[str, str, Any]]:
+    return [(name, el.get(INDEX_HINT), el) for name in topic_order(context)
+            for el in list(context.topics[name].iter("ph")) if el.get(INDEX_HINT) is not None]
+
+
+class _Prolog:
+    """Entries gathered per topic for its prolog, without duplicates."""
+
+    def __init__(self, context: Any) -> None:
+        self.context = context
+        self.seen: Dict[str, set] = {}
+
+    def add(self, name: str, entry: IndexEntry) -> bool:
+        if entry.end or entry.key in self.seen.setdefault(name, set()):
+            return False
+        self.seen[name].add(entry.key)
+        prolog_keywords(self.context.topics[name]).append(indexterm_element(entry, ranges=False))
+        return True
+
+
+def restore_word_index_terms(path: str | Path | WordSource, context: Any, options: Optional[Mapping[str, Any]] = None,
+                             report: Any = None) -> int:
+    """Put the ``XE`` entries of the ``.docx`` *path* into *context*; returns the number of entries placed."""
+    options = dict(options or {})
+    placeholders = _placeholders(context)
+    if not options.get("enabled", True):
+        for _, _, el in placeholders:
+            _replace(el, None)
+        return 0
+    report = report if report is not None else getattr(context, "report", None)
+    placement = str(options.get("placement", "inline"))
+    if placement not in _PLACEMENTS:
+        if report is not None:
+            report.warning("index", f"Unknown index placement {placement!r} (expected {', '.join(_PLACEMENTS)})")
+        placement = "inline"
+    try:
+        index = read_word_index(path)
+    except Exception as exc:
+        logger.warning("Could not read the index entries of %s: %s", Path(path).name, exc)
+        index = WordIndex()
+    prolog = _Prolog(context)
+    placed = unplaced = 0
+    if placeholders:
+        numbered = {str(e.number): e for e in index.entries}
+        for name, number, el in placeholders:
+            entry = numbered.get(number)
+            if entry is not None and placement == "prolog":
+                placed += prolog.add(name, entry)
+                entry = None
+            elif entry is not None:
+                placed += 1
+            _replace(el, indexterm_element(entry) if entry is not None else None)
+    elif index and any(True for t in context.topics.values() for _ in t.iter("indexterm")):
+        if report is not None:
+            report.info("index", "Converter already produced <indexterm> elements; Word index entries left as "
+                                 "converted")
+        return 0
+    elif index:
+        blocks = text_blocks(context)
+        located: List[Tuple[str, Any, int, IndexEntry]] = []
+        cursor = 0
+        for text, items in index.paragraphs:
+            hit, cursor = find_block(blocks, text, cursor)
+            if hit is None:
+                unplaced += sum(1 for _, e in items if not e.end)
+                continue
+            name, block, _ = blocks[hit]
+            block = innermost_block(block, text)
+            located.extend((name, block, offset, entry) for offset, entry in items)
+        inline: List[Tuple[Any, int, IndexEntry]] = []
+        for name, block, offset, entry in located:
+            if placement == "prolog" or block.tag in _TITLES:
+                placed += prolog.add(name, entry)
+            else:
+                inline.append((block, offset, entry))
+                placed += not entry.end
+        # Last offset first (and, at one offset, last entry first) so Word's order is kept
+        for block, offset, entry in sorted(reversed(inline), key=lambda item: -item[1]):
+            insert_at(block, offset, indexterm_element(entry))
+    if report is not None:
+        if placed:
+            report.info("index", f"{placed} index entr{'y' if placed == 1 else 'ies'} restored as <indexterm>",
+                        entries=placed, placement=placement)
+        if unplaced:
+            report.warning("index", f"{unplaced} index entr{'y' if unplaced == 1 else 'ies'} could not be placed: "
+                                    "their paragraph was not found in the converted topics")
+    return placed
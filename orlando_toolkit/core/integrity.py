@@ -0,0 +1,203 @@
+from __future__ import annotations
+
+"""Link and reference integrity of an edited context.
+
+Heavy structure editing can leave links to topics that were deleted or
+merged away, and topics the map no longer references (packaging drops them).
+:func:`check_integrity` lists:
+
+- ``dangling_link``: an ``xref`` or ``link`` whose ``href`` names a topic,
+  topic id or element that does not exist;
+- ``dangling_conref``: a ``conref``/``conrefend`` to a missing topic or element;
+- ``orphan_topic``: a topic no ``topicref`` of the map points to;
+- ``unused_image``: an image of ``context.images`` no ``image`` references.
+
+Each :class:`IntegrityIssue` can be repaired with :func:`apply_fix`:
+``retarget`` (links and conrefs, to another topic keeping the element part
+when that topic has it), ``remove`` (links are unwrapped to their text,
+conref elements deleted), ``delete`` (orphan topics, unused images) and
+``reattach`` (an orphan topic appended to the end of the map). External
+links, keyrefs and ``#Bookmark`` links still to be resolved at packaging are
+not checked.
+"""
+
+import logging
+from dataclasses import dataclass, field
+from typing import Dict, List, Optional, Set, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.internal_links import topic_bookmarks
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.placement import topic_order
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["FIXES", "IntegrityIssue", "apply_fix", "check_integrity"]
+
+# kind -> quick-fix actions offered for it
+FIXES: Dict[str, Tuple[str, ...]] = {
+    "dangling_link": ("retarget", "remove"),
+    "dangling_conref": ("retarget", "remove"),
+    "orphan_topic": ("reattach", "delete"),
+    "unused_image": ("delete",),
+}
+_LINK_TAGS = ("xref", "link")
+_CONREF_ATTRS = ("conref", "conrefend")
+_EXTERNAL = ("http:", "https:", "mailto:", "ftp:", "file:")
+
+
+@dataclass
+class IntegrityIssue:
+    """One problem: :attr:`topic` holds the link (or is the orphan), :attr:`target` is what is missing."""
+
+    kind: str
+    topic: Optional[str]
+    target: str
+    message: str
+    attribute: Optional[str] = None
+    element: Optional[ET.Element] = field(default=None, compare=False, repr=False)
+
+    @property
+    def fixes(self) -> Tuple[str, ...]:
+        return FIXES.get(self.kind, ())
+
+
+def _element_ids(topic: ET.Element) -> Set[str]:
+    return {el.get("id") for el in topic.iter() if isinstance(el.tag, str) and el.get("id")}
+
+
+def _split(reference: str) -> Tuple[str, str, str]:
+    """(file name, topic id, element id) of ``file.dita#topic/element``; missing parts are empty."""
+    path, _, fragment = reference.partition("#")
+    topic_id, _, element_id = fragment.partition("/")
+    return path.split("/")[-1], topic_id, element_id
+
+
+def _missing(context: DitaContext, holder: str, reference: str, bookmarks: Set[str]) -> Optional[str]:
+    """Why *reference* (from topic *holder*) does not resolve, or None when it does."""
+    filename, topic_id, element_id = _split(reference)
+    if not filename and not element_id and topic_id in bookmarks:
+        return None
+    target = context.topics.get(filename or holder)
+    if target is None:
+        return f"topic {filename} does not exist"
+    if topic_id and topic_id != target.get("id") and (element_id or topic_id not in _element_ids(target)):
+        return f"{filename or holder} has no topic id '{topic_id}'"
+    if element_id and element_id not in _element_ids(target):
+        return f"{filename or holder} has no element '{element_id}'"
+    return None
+
+
+def _skipped(el: ET.Element, reference: str) -> bool:
+    path = reference.partition("#")[0]
+    return (not reference or el.get("scope") in ("external", "peer") or el.get("keyref") is not None
+            or el.get("format", "dita") != "dita" or reference.lower().startswith(_EXTERNAL)
+            or bool(path) and not path.endswith(".dita"))
+
+
+def check_integrity(context: DitaContext) -> List[IntegrityIssue]:
+    """Dangling links and conrefs, orphaned topics and unused images, in map order."""
+    issues: List[IntegrityIssue] = []
+    bookmarks: Set[str] = set()
+    for topic in context.topics.values():
+        for el in topic.iter():
+            if isinstance(el.tag, str):
+                bookmarks.update(topic_bookmarks(el))
+
+    used_images: Set[str] = set()
+    for name in topic_order(context):
+        for el in context.topics[name].iter():
+            if not isinstance(el.tag, str):
+                continue
+            if el.tag == "image" and el.get("href"):
+                used_images.add(el.get("href").split("/")[-1])
+            elif el.tag == "param" and el.get("valuetype") == "ref" and el.get("value"):
+                used_images.add(el.get("value").split("/")[-1])
+            if el.tag in _LINK_TAGS and not _skipped(el, el.get("href") or ""):
+                why = _missing(context, name, el.get("href"), bookmarks)
+                if why:
+                    issues.append(IntegrityIssue("dangling_link", name, el.get("href"),
+                                                 f"Link target missing: {why}", attribute="href", element=el))
+            for attr in _CONREF_ATTRS:
+                reference = el.get(attr) or ""
+                why = _missing(context, name, reference, set()) if reference else None
+                if why:
+                    issues.append(IntegrityIssue("dangling_conref", name, reference,
+                                                 f"Reused content missing: {why}", attribute=attr, element=el))
+
+    root = getattr(context, "ditamap_root", None)
+    referenced = {(ref.get("href") or "").split("#")[0].split("/")[-1]
+                  for ref in (root.iter("topicref") if root is not None else ())}
+    for name in context.topics:
+        if name not in referenced:
+            issues.append(IntegrityIssue("orphan_topic", name, name, "Topic is not referenced by the map"))
+    for name in context.images:
+        if name not in used_images:
+            issues.append(IntegrityIssue("unused_image", None, name, "Image is not used by any topic"))
+    logger.info("Integrity: %d issue(s)", len(issues))
+    return issues
+
+
+def _unwrap(el: ET.Element) -> None:
+    parent = el.getparent()
+    text = ("".join(el.itertext()) if el.tag == "xref" else "") + (el.tail or "")
+    previous = el.getprevious()
+    if previous is not None:
+        previous.tail = (previous.tail or "") + text
+    else:
+        parent.text = (parent.text or "") + text
+    parent.remove(el)
+
+
+def _retarget(context: DitaContext, issue: IntegrityIssue, target: Optional[str]) -> str:
+    topic = context.topics.get(target or "")
+    if topic is None:
+        raise ValueError(f"Unknown topic: {target}")
+    path, _topic_id, element_id = _split(issue.target)
+    folder = issue.target.partition("#")[0][:-len(path)] if path else ""
+    if element_id and element_id in _element_ids(topic):
+        reference = f"{folder}{target}#{topic.get('id')}/{element_id}"
+    elif issue.kind == "dangling_conref":
+        raise ValueError(f"{target} has no element '{element_id}' to reuse")
+    else:
+        reference = folder + target
+    issue.element.set(issue.attribute or "href", reference)
+    return f"Link now points to {target}"
+
+
+def _reattach(context: DitaContext, name: str) -> str:
+    root = context.ditamap_root
+    existing = next((ref.get("href") for ref in root.iter("topicref") if ref.get("href")), "topics/x")
+    folder = existing.rsplit("/", 1)[0] + "/" if "/" in existing else ""
+    tref = ET.SubElement(root, "topicref", href=folder + name)
+    title = context.topics[name].find("title")
+    if title is not None:
+        navtitle = ET.SubElement(ET.SubElement(tref, "topicmeta"), "navtitle")
+        navtitle.text = " ".join("".join(title.itertext()).split())
+    return f"Re-attached {name} at the end of the map"
+
+
+def apply_fix(context: DitaContext, issue: IntegrityIssue, action: str, target: Optional[str] = None) -> str:
+    """Repair *issue* with *action* (one of :attr:`IntegrityIssue.fixes`); returns what was done.
+
+    *target* is the topic file name for ``retarget``. Raises ValueError for
+    an action the issue does not offer or a target that cannot be used.
+    """
+    if action not in issue.fixes:
+        raise ValueError(f"Cannot {action} a {issue.kind.replace('_', ' ')}")
+    if action == "retarget":
+        message = _retarget(context, issue, target)
+    elif action == "remove":
+        _unwrap(issue.element)
+        message = "Link removed" if issue.kind == "dangling_link" else "Reusing element removed"
+    elif action == "reattach":
+        message = _reattach(context, issue.target)
+    elif issue.kind == "orphan_topic":
+        context.topics.pop(issue.target, None)
+        message = f"Deleted topic {issue.target}"
+    else:
+        context.images.pop(issue.target, None)
+        message = f"Deleted image {issue.target}"
+    logger.info("Integrity fix: %s", message)
+    return message
@@ -0,0 +1,345 @@
+from __future__ import annotations
+
+"""Word cross-references resolved to ``<xref>`` links between the generated topics.
+
+Word cross-references to headings (``REF`` fields, *Place in This Document*
+hyperlinks) point at bookmarks, which mean nothing once the document is
+split into topics; converters leave them as dead text. Two passes turn them
+into working links:
+
+- after the plugin handler returns, :func:`mark_word_bookmarks` reads
+  ``word/document.xml``. Each linked bookmark becomes a ``data-bookmarks``
+  hint on the topic of the heading holding it (on the paragraph, for a
+  bookmark in body text), and each link the converter kept as plain text
+  becomes ``<xref href="#Bookmark">`` around the shown text, the Word
+  paragraph being located by its text like footnotes are
+  (:mod:`orlando_toolkit.core.placement`). Converters may also emit
+  ``<xref href="#Bookmark">`` and ``data-anchor``/``data-bookmarks``
+  themselves;
+- :func:`resolve_bookmark_links` runs when the package is prepared, after
+  depth merges and Structure tab edits and before topics are renamed: every
+  ``xref href="#Bookmark"`` points to the topic (or element) holding the
+  bookmark at that time. A merged topic hands its bookmarks to its merged
+  title paragraph, so links follow the content. Links whose target was
+  deleted are reported under ``links`` and left as text.
+
+Links inside the Word TOC field are ignored (the TOC is regenerated from the
+map); the ``links`` section of ``conversion.yml`` controls both passes.
+"""
+
+import logging
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.placement import RESTORED_TAGS, block_text, find_block, innermost_block, insert_at, \
+    normalize, offset_of, text_blocks, topic_order
+from orlando_toolkit.core.stable_ids import ANCHOR_HINT
+from orlando_toolkit.core.utils import generate_dita_id
+from orlando_toolkit.core.word_source import WordSource, w
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "BOOKMARK_HINT",
+    "WordLink",
+    "WordLinks",
+    "add_bookmarks",
+    "mark_word_bookmarks",
+    "read_word_links",
+    "resolve_bookmark_links",
+    "topic_bookmarks",
+]
+
+BOOKMARK_HINT = "data-bookmarks"
+_TITLES = ("title", "navtitle")
+
+
+@dataclass
+class WordLink:
+    """A link to *bookmark* shown as *text*, *offset* characters into its paragraph."""
+
+    bookmark: str
+    offset: int
+    text: str
+    kind: str = "hyperlink"                 # hyperlink | ref
+
+
+@dataclass
+class _Paragraph:
+    text: str = ""
+    bookmarks: List[str] = field(default_factory=list)
+    links: List[WordLink] = field(default_factory=list)
+
+
+@dataclass
+class WordLinks:
+    paragraphs: List[Tuple[str, List[str], List[WordLink]]] = field(default_factory=list)   # text, bookmarks, links
+
+    @property
+    def links(self) -> List[WordLink]:
+        return [link for _, _, links in self.paragraphs for link in links]
+
+    def __bool__(self) -> bool:
+        return bool(self.links)
+
+
+def _in_toc(state: Dict[str, Any]) -> bool:
+    return any(instr.split()[:1] == ["TOC"] for instr in state["fields"])
+
+
+def _add_link(paragraph: _Paragraph, bookmark: str, start: int, kind: str, state: Dict[str, Any]) -> None:
+    shown = normalize(paragraph.text[start:])
+    if bookmark and shown and not _in_toc(state):
+        paragraph.links.append(WordLink(bookmark, offset_of(paragraph.text[:start]), shown, kind))
+
+
+def _walk(node: Any, paragraph: Optional[_Paragraph], state: Dict[str, Any], out: List[_Paragraph]) -> None:
+    tag = node.tag
+    if tag == w("p"):
+        inner = _Paragraph(bookmarks=state.pop("pending", []))
+        for child in node:
+            _walk(child, inner, state, out)
+        out.append(inner)
+        return
+    if tag == w("t") and paragraph is not None:
+        paragraph.text += node.text or ""
+    elif tag in (w("tab"), w("br")) and paragraph is not None:
+        paragraph.text += " "
+    elif tag == w("bookmarkStart"):
+        name = node.get(w("name")) or ""
+        if paragraph is None:
+            state.setdefault("pending", []).append(name)      # between paragraphs: starts the next one
+        else:
+            paragraph.bookmarks.append(name)
+    elif tag == w("hyperlink") and node.get(w("anchor")) and paragraph is not None:
+        start = len(paragraph.text)
+        for child in node:
+            _walk(child, paragraph, state, out)
+        _add_link(paragraph, node.get(w("anchor")), start, "hyperlink", state)
+        return
+    elif tag == w("instrText"):
+        if state["fields"]:
+            state["fields"][-1] += node.text or ""
+        return
+    elif tag == w("fldChar"):
+        kind = node.get(w("fldCharType"))
+        if kind == "begin":
+            state["fields"].append("")
+        elif kind == "separate" and state["fields"] and paragraph is not None:
+            state["starts"].append((state["fields"][-1], len(paragraph.text)))
+        elif kind == "end" and state["fields"]:
+            instr = state["fields"].pop()
+            start = state["starts"].pop() if state["starts"] and state["starts"][-1][0] == instr else None
+            words = instr.split()
+            if words[:1] == ["REF"] and len(words) > 1 and start is not None and paragraph is not None:
+                _add_link(paragraph, words[1], start[1], "ref", state)
+    elif tag == w("fldSimple") and paragraph is not None:
+        words = (node.get(w("instr")) or "").split()
+        start = len(paragraph.text)
+        for child in node:
+            _walk(child, paragraph, state, out)
+        if words[:1] == ["REF"] and len(words) > 1:
+            _add_link(paragraph, words[1], start, "ref", state)
+        return
+    elif tag == w("delText"):
+        return
+    for child in node:
+        _walk(child, paragraph, state, out)
+
+
+def read_word_links(path: str | Path | WordSource) -> WordLinks:
+    """Internal links of a ``.docx`` and the bookmarks they target, by body paragraph."""
+    result = WordLinks()
+    docx = WordSource.of(path)
+    body = docx.body if docx is not None else None
+    paragraphs: List[_Paragraph] = []
+    if body is not None:
+        state: Dict[str, Any] = {"fields": [], "starts": []}
+        for child in body:
+            _walk(child, None, state, paragraphs)
+    linked = {link.bookmark for p in paragraphs for link in p.links}
+    for paragraph in paragraphs:
+        bookmarks = [name for name in paragraph.bookmarks if name in linked]
+        if bookmarks or paragraph.links:
+            result.paragraphs.append((normalize(paragraph.text), bookmarks, paragraph.links))
+    return result
+
+
+def topic_bookmarks(el: Any) -> List[str]:
+    """Bookmarks held by *el*: its ``data-bookmarks`` and, on a topic, its ``data-anchor``."""
+    names = (el.get(BOOKMARK_HINT) or "").split()
+    anchor = el.get(ANCHOR_HINT)
+    if anchor and anchor not in names:
+        names.insert(0, anchor)
+    return names
+
+
+def add_bookmarks(el: Any, names: List[str]) -> None:
+    current = (el.get(BOOKMARK_HINT) or "").split()
+    current.extend(n for n in names if n and n not in current)
+    if current:
+        el.set(BOOKMARK_HINT, " ".join(current))
+
+
+def _text_before(block: Any, target: Any) -> str:
+    parts: List[str] = []
+
+    def _collect(node: Any) -> bool:
+        if node is target:
+            return True
+        parts.append(node.text or "")
+        for child in node:
+            if child.tag not in RESTORED_TAGS and _collect(child):
+                return True
+            parts.append(child.tail or "")
+        return False
+
+    _collect(block)
+    return "".join(parts)
+
+
+def _link_element(block: Any, link: WordLink) -> Optional[Any]:
+    """A new ``xref`` for *link*, or ``None`` when converted inline content was linked in place.
+
+    A converted ``xref`` showing the link text is pointed at the bookmark; an
+    inline element (``b``, ``ph``) holding exactly the link text is wrapped.
+    """
+    for el in block.iter():
+        if el is block or not isinstance(el.tag, str) or block_text(el) != link.text:
+            continue
+        href = el.get("href") or ""
+        if el.tag == "xref" and (not href or href.startswith("#")):
+            el.set("href", f"#{link.bookmark}")
+            return None
+        if el.tag != "xref" and offset_of(_text_before(block, el)) == link.offset:
+            parent = el.getparent()
+            xref = ET.Element("xref", href=f"#{link.bookmark}")
+            parent.insert(parent.index(el), xref)
+            xref.tail, el.tail = el.tail, None
+            parent.remove(el)
+            xref.append(el)
+            return None
+    xref = ET.Element("xref", href=f"#{link.bookmark}")
+    xref.text = link.text
+    return xref
+
+
+def mark_word_bookmarks(path: str | Path | WordSource, context: Any, options: Optional[Mapping[str, Any]] = None,
+                        report: Any = None) -> int:
+    """Mark the linked bookmarks and links of the ``.docx`` *path* in *context*; returns the links marked."""
+    options = dict(options or {})
+    if not options.get("enabled", True):
+        return 0
+    report = report if report is not None else getattr(context, "report", None)
+    try:
+        links = read_word_links(path)
+    except Exception as exc:
+        logger.warning("Could not read the links of %s: %s", Path(path).name, exc)
+        return 0
+    if not links:
+        return 0
+    blocks = text_blocks(context)
+    located: List[Tuple[Any, WordLink]] = []
+    cursor = unplaced = 0
+    for text, bookmarks, paragraph_links in links.paragraphs:
+        hit, cursor = find_block(blocks, text, cursor)
+        if hit is None:
+            unplaced += len(paragraph_links)
+            continue
+        name, block, _ = blocks[hit]
+        block = innermost_block(block, text)
+        if bookmarks:
+            topic = context.topics[name]
+            # A heading's bookmarks belong to its topic
+            holder = topic if block.tag in _TITLES and block.getparent() is topic else block
+            add_bookmarks(holder, bookmarks)
+        located.extend((block, link) for link in paragraph_links)
+    marked = 0
+    # Last offset first, so earlier offsets still count the original text only
+    for block, link in sorted(reversed(located), key=lambda item: -item[1].offset):
+        new = _link_element(block, link)
+        if new is None or insert_at(block, link.offset, new, link.text):
+            marked += 1
+        else:
+            # The shown text is not where Word had it: drop the link rather than duplicate the text
+            parent = new.getparent()
+            previous = new.getprevious()
+            if previous is not None:
+                previous.tail = (previous.tail or "") + (new.tail or "") or None
+            else:
+                parent.text = (parent.text or "") + (new.tail or "") or None
+            parent.remove(new)
+            unplaced += 1
+    if report is not None:
+        if marked:
+            report.info("links", f"{marked} internal link(s) to bookmarks found", links=marked)
+        if unplaced:
+            report.warning("links", f"{unplaced} internal link(s) could not be placed: their text was not found "
+                                    "in the converted topics")
+    return marked
+
+
+def _unwrap(xref: Any) -> None:
+    parent = xref.getparent()
+    text = "".join(xref.itertext()) + (xref.tail or "")
+    previous = xref.getprevious()
+    if previous is not None:
+        previous.tail = (previous.tail or "") + text
+    else:
+        parent.text = (parent.text or "") + text
+    parent.remove(xref)
+
+
+def resolve_bookmark_links(context: Any, options: Optional[Mapping[str, Any]] = None,
+                           report: Any = None) -> int:
+    """Point ``xref href="#Bookmark"`` at the topics holding the bookmarks; returns the links resolved."""
+    options = dict(options or {})
+    report = report if report is not None else getattr(context, "report", None)
+    targets: Dict[str, Tuple[str, Optional[Any]]] = {}
+    for name in topic_order(context):
+        topic = context.topics[name]
+        for bookmark in topic_bookmarks(topic):
+            targets.setdefault(bookmark, (name, None))
+        for el in topic.iter():
+            if el is not topic and isinstance(el.tag, str) and el.get(BOOKMARK_HINT):
+                for bookmark in topic_bookmarks(el):
+                    targets.setdefault(bookmark, (name, el))
+    resolved, unresolved = 0, []
+    if options.get("enabled", True):
+        for name in topic_order(context):
+            topic = context.topics[name]
+            ids = {el.get("id") for el in topic.iter() if isinstance(el.tag, str) and el.get("id")}
+            for xref in list(topic.iter("xref")):
+                href = xref.get("href") or ""
+                bookmark = href[1:]
+                # "#topic" and "#topic/element" are DITA references within the topic
+                if not href.startswith("#") or not bookmark or "/" in bookmark or bookmark in ids:
+                    continue
+                target = targets.get(bookmark)
+                if target is None:
+                    unresolved.append(bookmark)
+                    _unwrap(xref)
+                    continue
+                filename, el = target
+                if el is None:
+                    xref.set("href", filename)
+                else:
+                    if not el.get("id"):
+                        el.set("id", generate_dita_id())
+                    xref.set("href", f"{filename}#{context.topics[filename].get('id')}/{el.get('id')}")
+                resolved += 1
+    for topic in context.topics.values():
+        for el in topic.iter():
+            if isinstance(el.tag, str):
+                el.attrib.pop(BOOKMARK_HINT, None)
+    if report is not None:
+        if resolved:
+            report.info("links", f"{resolved} internal link(s) resolved to topics", links=resolved)
+        if unresolved:
+            listed = ", ".join(sorted(set(unresolved))[:20])
+            report.warning("links", f"{len(unresolved)} internal link(s) point to bookmarks no longer in the "
+                                    f"publication and were left as text: {listed}", bookmarks=sorted(set(unresolved)))
+    return resolved
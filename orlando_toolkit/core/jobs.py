@@ -0,0 +1,254 @@
+from __future__ import annotations
+
+"""Conversion jobs: progress, cancellation and resumable packaging.
+
+A :class:`ConversionJob` is what a front-end holds while a long conversion
+runs. It owns the :class:`~orlando_toolkit.core.cancellation.CancellationToken`
+passed through parsing, the processing stages and packaging, and it is the
+progress callback of those calls: every message becomes a
+:class:`~orlando_toolkit.core.progress.ProgressEvent`, is kept in
+:attr:`ConversionJob.events` and is passed on to the subscribed listeners.
+
+Packaging writes into a :class:`PackagingWorkspace` instead of a throw-away
+temporary folder when ``resume.enabled`` is set in ``pipeline.yml``. The
+workspace holds a snapshot of the prepared context (a project file, see
+:mod:`orlando_toolkit.core.project`), the package folder and a small
+``job.json`` journal. It is removed once the archive is written or the job is
+cancelled; a workspace left behind by a crash is listed by
+:func:`pending_workspaces` and finished with
+:meth:`~orlando_toolkit.core.services.conversion_service.ConversionService.resume_package`:
+a package folder already written completely is only zipped again, otherwise
+the package is rewritten from the snapshot.
+"""
+
+import json
+import logging
+import os
+import shutil
+import tempfile
+import threading
+import uuid
+from collections import deque
+from dataclasses import dataclass
+from datetime import datetime, timezone
+from pathlib import Path
+from typing import Any, Callable, Deque, Dict, List, Mapping, Optional, TypeVar
+
+from orlando_toolkit.core.cancellation import CancellationToken, OperationCancelledError
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.progress import ProgressEvent, as_event, overall_fraction
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "ConversionJob",
+    "JOB_STATES",
+    "PackagingWorkspace",
+    "ResumeSettings",
+    "pending_workspaces",
+]
+
+T = TypeVar("T")
+
+#: Life cycle of a :class:`ConversionJob`.
+JOB_STATES = ("pending", "running", "done", "cancelled", "failed")
+
+_JOURNAL = "job.json"
+_SNAPSHOT = "context.otkproj"
+_PACKAGE = "package"
+
+
+@dataclass(frozen=True)
+class ResumeSettings:
+    """The ``resume`` section of ``pipeline.yml``."""
+
+    enabled: bool = True
+    workspace_dir: Optional[str] = None
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "ResumeSettings":
+        data = data or {}
+        return cls(enabled=bool(data.get("enabled", True)), workspace_dir=data.get("workspace_dir") or None)
+
+    @classmethod
+    def from_config(cls) -> "ResumeSettings":
+        try:
+            from orlando_toolkit.config import ConfigManager
+            return cls.from_mapping((ConfigManager().get_pipeline_config() or {}).get("resume"))
+        except Exception as exc:
+            logger.debug("Pipeline config unavailable, using default resume settings: %s", exc)
+            return cls()
+
+    @property
+    def root(self) -> Path:
+        """Folder holding the packaging workspaces."""
+        if self.workspace_dir:
+            return Path(self.workspace_dir).expanduser()
+        from orlando_toolkit.config.manager import _get_user_config_dir
+
+        base = _get_user_config_dir()
+        return (base.parent if base.name == "config" else base) / "jobs"
+
+
+class PackagingWorkspace:
+    """Folder a package is written in, journaled so packaging survives a crash.
+
+    States in ``job.json``: ``prepared`` once the context snapshot is saved,
+    ``written`` once the package folder is complete (only the archive is
+    missing), ``failed`` after an error other than cancellation.
+    """
+
+    def __init__(self, directory: str | Path) -> None:
+        self.directory = Path(directory)
+
+    @classmethod
+    def create(cls, root: Optional[str | Path] = None) -> "PackagingWorkspace":
+        root = Path(root) if root else ResumeSettings.from_config().root
+        directory = root / uuid.uuid4().hex[:12]
+        directory.mkdir(parents=True)
+        return cls(directory)
+
+    @property
+    def id(self) -> str:
+        return self.directory.name
+
+    @property
+    def package_dir(self) -> Path:
+        return self.directory / _PACKAGE
+
+    @property
+    def snapshot_path(self) -> Path:
+        return self.directory / _SNAPSHOT
+
+    def read(self) -> Dict[str, Any]:
+        """Return the journal, or an empty dict when there is none yet."""
+        try:
+            return json.loads((self.directory / _JOURNAL).read_text(encoding="utf-8"))
+        except (OSError, ValueError):
+            return {}
+
+    @property
+    def state(self) -> Optional[str]:
+        return self.read().get("state")
+
+    @property
+    def output(self) -> Optional[Path]:
+        output = self.read().get("output")
+        return Path(output) if output else None
+
+    def begin(self, context: DitaContext, output: str | Path) -> None:
+        """Snapshot the prepared *context* and journal the target archive *output*."""
+        from orlando_toolkit.core.project import save_project
+
+        save_project(context, self.snapshot_path, record=False)
+        self.package_dir.mkdir(parents=True, exist_ok=True)
+        self._write({
+            "state": "prepared",
+            "output": str(Path(output).resolve()),
+            "source": context.metadata.get("source_file"),
+            "title": context.metadata.get("manual_title"),
+            "topics": len(context.topics),
+            "started_at": datetime.now(timezone.utc).isoformat(timespec="seconds"),
+        })
+
+    def mark(self, state: str, **details: Any) -> None:
+        journal = self.read()
+        journal.update(details, state=state, updated_at=datetime.now(timezone.utc).isoformat(timespec="seconds"))
+        self._write(journal)
+
+    def reset_package(self) -> None:
+        """Empty the package folder before it is (re)written."""
+        shutil.rmtree(self.package_dir, ignore_errors=True)
+        self.package_dir.mkdir(parents=True, exist_ok=True)
+
+    def load_context(self) -> DitaContext:
+        """Reopen the snapshot taken by :meth:`begin`."""
+        from orlando_toolkit.core.project import load_project
+
+        return load_project(self.snapshot_path, record=False).context
+
+    def discard(self) -> None:
+        shutil.rmtree(self.directory, ignore_errors=True)
+
+    def describe(self) -> str:
+        journal = self.read()
+        name = journal.get("title") or Path(journal.get("source") or self.id).name
+        return f"{self.id}: {name} -> {journal.get('output')} ({journal.get('state')})"
+
+    def _write(self, journal: Mapping[str, Any]) -> None:
+        # Atomic, so a crash never leaves a half-written journal
+        fd, tmp_name = tempfile.mkstemp(prefix=".job_", dir=str(self.directory))
+        with os.fdopen(fd, "w", encoding="utf-8") as handle:
+            json.dump(journal, handle, ensure_ascii=False, indent=2)
+        os.replace(tmp_name, self.directory / _JOURNAL)
+
+
+def pending_workspaces(root: Optional[str | Path] = None) -> List[PackagingWorkspace]:
+    """Return the workspaces of interrupted packagings, oldest first."""
+    root = Path(root) if root else ResumeSettings.from_config().root
+    if not root.is_dir():
+        return []
+    found = [PackagingWorkspace(path) for path in root.iterdir() if (path / _JOURNAL).is_file()]
+    return sorted(found, key=lambda workspace: workspace.read().get("started_at") or "")
+
+
+class ConversionJob:
+    """One long-running conversion: progress events, cancellation and state.
+
+    Pass :attr:`token` as ``cancel_token`` and the job itself as the progress
+    callback of the conversion and packaging calls, from inside :meth:`run`.
+    Listeners are called on the worker thread. Jobs of a batch may share one
+    *token*, so that cancelling stops the whole batch.
+    """
+
+    def __init__(self, name: str = "", *, token: Optional[CancellationToken] = None,
+                 max_events: int = 500) -> None:
+        self.name = name
+        self.token = token or CancellationToken()
+        self.state = "pending"
+        self.error: Optional[BaseException] = None
+        self.events: Deque[ProgressEvent] = deque(maxlen=max_events)
+        self.fraction = 0.0
+        self._listeners: List[Callable[[ProgressEvent], None]] = []
+        self._lock = threading.Lock()
+
+    def subscribe(self, listener: Callable[[ProgressEvent], None]) -> None:
+        with self._lock:
+            self._listeners.append(listener)
+
+    def __call__(self, message: str) -> None:
+        """Progress callback: record *message* and pass it on to the listeners."""
+        with self._lock:
+            # Plain messages belong to the phase of the event before them
+            phase = self.events[-1].phase if self.events else "parse"
+            event = as_event(message, phase)
+            self.events.append(event)
+            self.fraction = max(self.fraction, overall_fraction(event))
+            listeners = list(self._listeners)
+        for listener in listeners:
+            try:
+                listener(event)
+            except Exception as exc:
+                logger.debug("Progress listener failed: %s", exc)
+
+    @property
+    def last(self) -> Optional[ProgressEvent]:
+        return self.events[-1] if self.events else None
+
+    def cancel(self, reason: str = "Cancelled by user") -> None:
+        self.token.cancel(reason)
+
+    def run(self, work: Callable[["ConversionJob"], T]) -> T:
+        """Call ``work(job)`` and track the outcome in :attr:`state`."""
+        self.state = "running"
+        try:
+            result = work(self)
+        except OperationCancelledError as exc:
+            self.state, self.error = "cancelled", exc
+            raise
+        except BaseException as exc:
+            self.state, self.error = "failed", exc
+            raise
+        self.fraction = 1.0
+        self.state = "done"
+        return result
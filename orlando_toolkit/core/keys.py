@@ -0,0 +1,152 @@
+from __future__ import annotations
+
+"""Key table of a manual and its key definition map.
+
+Product names, model numbers and revision strings are kept in one table of
+keys (``ProductName: Orlando X200``) instead of being typed in every topic:
+
+- the table is the ``variables`` section of ``conversion.yml`` (``source``
+  file and ``values``) overridden by ``metadata["variables"]``, which the
+  Metadata tab edits; ``None`` drops a configured key for this document;
+- :func:`apply_key_table` replaces each value typed in the topics by
+  ``<keyword keyref="ProductName"/>`` (``variables.match_values``); the
+  package step runs it, so keys added after the conversion apply too;
+- :func:`write_keydef_map` gathers the ``<keydef>`` elements of the map and
+  one per key of the table into ``DATA/keydefs.ditamap``
+  (``variables.keydef_map``), referenced from the main map as a
+  resource-only ``<mapref>``. Bookmaps (and ``keydef_map: null``) get
+  the keydefs of the table inline.
+
+Changing a value in the table then changes every occurrence at publishing.
+"""
+
+import logging
+import os
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.bookmap import is_bookmap
+from orlando_toolkit.core.dialects import convert_map, map_doctype
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.processing.variables import VariablesStage, load_variables, phrase_pattern, \
+    replace_phrases
+from orlando_toolkit.core.utils import save_xml_file
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["DEFAULT_KEYDEF_MAP", "apply_key_table", "build_keydef_map", "key_table", "key_usage",
+           "write_keydef_map"]
+
+DEFAULT_KEYDEF_MAP = "keydefs.ditamap"
+
+
+def _options(metadata: Optional[Mapping[str, Any]]) -> Dict[str, Any]:
+    try:
+        from orlando_toolkit.core.processing import resolve_conversion_options
+        return dict(resolve_conversion_options(metadata or {}).get("variables") or {})
+    except Exception as exc:
+        logger.debug("Variables options unavailable: %s", exc)
+        return {}
+
+
+def key_table(metadata: Optional[Mapping[str, Any]] = None,
+              options: Optional[Mapping[str, Any]] = None) -> Dict[str, str]:
+    """Keys and values of the configuration (or *options*) overridden by ``metadata["variables"]``."""
+    metadata = metadata or {}
+    options = _options(metadata) if options is None else options
+    own = metadata.get("variables")
+    own = own if isinstance(own, Mapping) else {}
+    values = {**(options.get("values") or {}), **{k: v for k, v in own.items() if v is not None}}
+    table = load_variables(options.get("source"), values)
+    for name, value in own.items():
+        if value is None:
+            table.pop(str(name), None)
+    return table
+
+
+def key_usage(context: DitaContext, table: Optional[Mapping[str, str]] = None) -> Dict[str, int]:
+    """Key references plus replaceable occurrences of each key's value in the topics."""
+    table = key_table(context.metadata) if table is None else table
+    usage = {name: 0 for name in table}
+    compiled = phrase_pattern(table)
+    for root in context.topics.values():
+        for el in root.iter("keyword"):
+            if el.get("keyref") in usage:
+                usage[el.get("keyref")] += 1
+        if compiled is not None:
+            pattern, names = compiled
+            for match in pattern.finditer(" ".join(" ".join(root.itertext()).split())):
+                name = names.get(match.group(1))
+                if name in usage:
+                    usage[name] += 1
+    return usage
+
+
+def apply_key_table(context: DitaContext, table: Optional[Mapping[str, str]] = None) -> Dict[str, int]:
+    """Replace the values of the key table typed in the topics by key references."""
+    if not _options(context.metadata).get("match_values", True):
+        return {}
+    table = key_table(context.metadata) if table is None else table
+    used: Dict[str, int] = {}
+    for filename, root in context.topics.items():
+        before = sum(used.values())
+        replace_phrases(root, table, used)
+        replaced = sum(used.values()) - before
+        if replaced:
+            context.report.info("variables", f"{replaced} key value(s) replaced by key references",
+                                topic=filename, replaced=replaced)
+    return used
+
+
+def _keydef(name: str, value: str) -> ET._Element:
+    keydef = ET.Element("keydef", keys=name)
+    keywords = ET.SubElement(ET.SubElement(keydef, "topicmeta"), "keywords")
+    ET.SubElement(keywords, "keyword").text = value
+    return keydef
+
+
+def build_keydef_map(values: Mapping[str, str], title: str = "Key definitions") -> ET._Element:
+    """A map holding one text ``<keydef>`` per key of *values*."""
+    root = ET.Element("map")
+    ET.SubElement(root, "title").text = title
+    for name, value in values.items():
+        root.append(_keydef(name, value))
+    return root
+
+
+def write_keydef_map(context: DitaContext, data_dir: str | Path, *, escaping: Any = None,
+                     dialect: str = "dita-1.3") -> Optional[Path]:
+    """Move the keydefs of the map and the key table into a key definition map next to it.
+
+    The key map is written in the output *dialect* (``core.dialects``).
+    """
+    name = _options(context.metadata).get("keydef_map", DEFAULT_KEYDEF_MAP)
+    root = context.ditamap_root
+    if root is None:
+        return None
+    table = key_table(context.metadata)
+    keydefs: List[ET._Element] = [el for el in root if el.tag == "keydef" and not el.get("href")]
+    defined = {k for el in root.iter("keydef") for k in (el.get("keys") or "").split()}
+    if not name or is_bookmap(context.metadata):
+        # Inline keydefs (bookmaps move them to the front matter)
+        VariablesStage._define_keys(context, {k: v for k, v in table.items() if k not in defined})
+        return None
+    if not keydefs and not set(table) - defined:
+        return None
+
+    keymap = build_keydef_map({})
+    for el in keydefs:
+        root.remove(el)
+        el.tail = None
+        keymap.append(el)
+    for key, value in table.items():
+        if key not in defined:
+            keymap.append(_keydef(key, value))
+    position = len(root.findall("title")) + len(root.findall("topicmeta"))
+    root.insert(position, ET.Element("mapref", {"href": str(name), "format": "ditamap",
+                                                "processing-role": "resource-only"}))
+    path = Path(data_dir) / str(name)
+    save_xml_file(convert_map(keymap, dialect), os.fspath(path), map_doctype(dialect), escaping=escaping)
+    return path
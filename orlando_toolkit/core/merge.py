@@ -7,37 +7,74 @@ It must not perform any file I/O so that it can be reused by CLI, GUI and tests.
 """
 
 from copy import deepcopy
-from typing import Set, Optional, Tuple
+from typing import Mapping, Set, Optional, Tuple
 from lxml import etree as ET  # type: ignore
 
+from orlando_toolkit.core.i18n import document_language, message
+from orlando_toolkit.core.index_terms import prolog_keywords
+from orlando_toolkit.core.internal_links import add_bookmarks, topic_bookmarks
 from orlando_toolkit.core.models import DitaContext  # noqa: F401
 from orlando_toolkit.core.utils import generate_dita_id
 
 __all__ = [
+    "branch_key",
     "merge_topics_by_titles", 
     "merge_topics_unified",
     "convert_section_to_local_topic",
     "merge_topicref_into",
+    "merge_topicref_into_neighbor",
+    "split_points",
+    "split_topic_at",
 ]
 
 
 # BLOCK_LEVEL_TAGS removed - we now copy ALL content to preserve completeness
 
 
-def _copy_content(src_topic: ET.Element, dest_topic: ET.Element) -> None:
+def _as_concept(topic_el: ET.Element) -> None:
+    """Rewrite tasks and glossary entries in place as concepts."""
+    if topic_el.tag == "task":
+        from orlando_toolkit.core.processing.procedures import task_to_concept
+
+        task_to_concept(topic_el)
+    elif topic_el.tag == "glossentry":
+        from orlando_toolkit.core.processing.definitions import glossentry_to_concept
+
+        glossentry_to_concept(topic_el)
+
+
+def _conbody(topic_el: ET.Element) -> ET.Element:
+    """Return *topic_el*'s conbody, creating it; tasks and glossary entries become concepts first."""
+    _as_concept(topic_el)
+    body = topic_el.find("conbody")
+    if body is None:
+        body = ET.SubElement(topic_el, "conbody")
+    return body
+
+
+def _copy_content(src_topic: ET.Element, dest_topic: ET.Element) -> dict[str, str]:
     """Copy all content children from src_topic conbody into dest_topic conbody.
     
     Preserves complete content hierarchy and de-duplicates @id values.
+    Returns the old -> new mapping of the ids given to the copies.
     """
 
-    dest_body = dest_topic.find("conbody")
-    if dest_body is None:
-        dest_body = ET.SubElement(dest_topic, "conbody")
-
+    dest_body = _conbody(dest_topic)
+
+    if src_topic.tag in ("task", "glossentry"):
+        src_topic = deepcopy(src_topic)
+        _as_concept(src_topic)
+    # Index entries gathered in the prolog follow the content
+    src_terms = src_topic.findall("prolog/metadata/keywords/indexterm")
+    if src_terms:
+        dest_keywords = prolog_keywords(dest_topic)
+        for term in src_terms:
+            dest_keywords.append(deepcopy(term))
     src_body = src_topic.find("conbody")
     if src_body is None:
-        return
+        return {}
 
+    renamed: dict[str, str] = {}
     for child in list(src_body):
         new_child = deepcopy(child)
 
@@ -64,7 +101,9 @@ def _copy_content(src_topic: ET.Element, dest_topic: ET.Element) -> None:
                     ref = val[1:]
                     if ref in id_map:
                         el.set(attr, f"#{id_map[ref]}")
+        renamed.update(id_map)
         dest_body.append(new_child)
+    return renamed
 
 
 
@@ -150,13 +189,19 @@ def merge_topics_by_titles(ctx: "DitaContext", exclude_titles: set[str]) -> None
 # Helper utilities (internal) - DRY refactoring
 # ---------------------------------------------------------------------------
 
-def _add_title_paragraph(target_el: ET.Element, title_text: str) -> None:
+def _add_title_paragraph(
+    target_el: ET.Element, title_text: str, bookmarks: list[str] | None = None
+) -> ET.Element | None:
     """Add a title as paragraph to target element's conbody.
     
     DRY helper to avoid repetition of conbody creation and paragraph addition.
+    *bookmarks* of the merged topic move to the paragraph so links to it still resolve.
+    Returns the title paragraph (an existing one when de-duplicated), or None without a title.
     """
     if not title_text:
-        return
+        if bookmarks:
+            add_bookmarks(target_el, bookmarks)
+        return None
     
     clean_title = " ".join(title_text.split())
     head_p = ET.Element("p", id=generate_dita_id())
@@ -173,9 +218,7 @@ def _add_title_paragraph(target_el: ET.Element, title_text: str) -> None:
     head_p.set("outputclass", "merged-title")
     # Keep a class for downstream consumers (preview/styling)
 
-    parent_body = target_el.find("conbody")
-    if parent_body is None:
-        parent_body = ET.SubElement(target_el, "conbody")
+    parent_body = _conbody(target_el)
     # De-duplicate: if the closest previous merged-title has the same text, skip
     try:
         # Compute new visible text using the canonical cleaned title
@@ -196,12 +239,17 @@ def _add_title_paragraph(target_el: ET.Element, title_text: str) -> None:
                 except Exception:
                     prev_text = ""
             if prev_text.strip().upper() == new_text:
-                return
+                if bookmarks:
+                    add_bookmarks(el, bookmarks)
+                return el
             # Different merged-title encountered → do not dedup further
             break
     except Exception:
         pass
+    if bookmarks:
+        add_bookmarks(head_p, bookmarks)
     parent_body.append(head_p)
+    return head_p
 
 
 def _copy_section_attrs_to_child_preserving_style(section_tref: ET.Element, child_ref: ET.Element) -> None:
@@ -281,7 +329,10 @@ def _ensure_content_module(ctx: "DitaContext", section_tref: ET.Element) -> ET.E
 
     # Build topic element
     section_title_el = section_tref.find("topicmeta/navtitle")
-    title_txt = section_title_el.text if section_title_el is not None and section_title_el.text else "Untitled"
+    if section_title_el is not None and section_title_el.text:
+        title_txt = section_title_el.text
+    else:
+        title_txt = message("untitled", document_language(ctx))
     topic_el = _new_topic_with_title(title_txt)
 
     # Register in topics map
@@ -338,7 +389,7 @@ def merge_topicref_into(ctx: "DitaContext", target_topic_el: ET.Element, src_tre
             return None
         # Title paragraph then body content
         title_text = _extract_title_text(src_topic, is_topichead=False)
-        _add_title_paragraph(target_topic_el, title_text)
+        _add_title_paragraph(target_topic_el, title_text, topic_bookmarks(src_topic))
         _copy_content(src_topic, target_topic_el)
         # Remove topicref from the map
         parent = src_tref.getparent()
@@ -351,6 +402,187 @@ def merge_topicref_into(ctx: "DitaContext", target_topic_el: ET.Element, src_tre
     except Exception:
         return None
 
+_STRUCTURAL_TAGS = ("topicref", "topichead")
+_LINK_ATTRS = ("href", "conref", "conrefend")
+
+
+def _href_filename(tref: ET.Element) -> str:
+    return (tref.get("href") or "").split("#")[0].rsplit("/", 1)[-1]
+
+
+def _shift_levels(node: ET.Element, delta: int) -> None:
+    """Add *delta* to the ``data-level`` of the map entries in *node*'s subtree."""
+    if not delta:
+        return
+    for el in node.iter():
+        level = el.get("data-level") if el.tag in _STRUCTURAL_TAGS else None
+        if level and level.isdigit():
+            el.set("data-level", str(max(1, int(level) + delta)))
+
+
+def _retarget_links(ctx: "DitaContext", moves: dict, scopes: dict | None = None) -> int:
+    """Point ``href``/``conref``/``conrefend`` values at the new home of moved topics and elements.
+
+    *moves* maps ``(filename, element id)`` – ``None`` or the topic id for the whole
+    topic – to ``(filename, topic id, element id or None)``. *scopes* maps moved
+    subtrees to the file their same-file references (``#topic/element``) were
+    written for. Returns the number of values changed.
+    """
+    scopes = scopes or {}
+    changed = 0
+
+    def _rewrite(el: ET.Element, own: str | None, origin: str | None) -> None:
+        nonlocal changed
+        if (el.get("scope") or "") == "external":
+            return
+        for attr in _LINK_ATTRS:
+            value = el.get(attr)
+            if not value or "://" in value or value.startswith("mailto:"):
+                continue
+            path, hashmark, fragment = value.partition("#")
+            directory, slash, base = path.rpartition("/")
+            source = base or origin
+            head, sep, rest = fragment.partition("/")
+            target = moves.get((source, (rest if sep else head) or None))
+            if target is None:
+                # "#Bookmark" placeholders have no slash and are resolved at packaging
+                if not base and sep and origin != own and origin in ctx.topics:
+                    # Moved content pointing back at what stayed in its topic of origin
+                    el.set(attr, f"{origin}#{fragment}")
+                    changed += 1
+                continue
+            new_file, new_topic, new_element = target
+            new_fragment = f"{new_topic}/{new_element}" if new_element else (new_topic if hashmark else "")
+            new_path = "" if not base and new_file == own else directory + slash + new_file
+            new_value = new_path + (f"#{new_fragment}" if new_fragment else "")
+            if new_value != value:
+                el.set(attr, new_value)
+                changed += 1
+
+    def _walk(el: ET.Element, own: str | None, origin: str | None) -> None:
+        origin = scopes.get(el, origin)
+        if isinstance(el.tag, str):
+            _rewrite(el, own, origin)
+        for child in el:
+            _walk(child, own, origin)
+
+    for name, topic_el in ctx.topics.items():
+        _walk(topic_el, name, name)
+    if ctx.ditamap_root is not None:
+        _walk(ctx.ditamap_root, None, None)
+    return changed
+
+
+def merge_topicref_into_neighbor(ctx: "DitaContext", src_tref: ET.Element, target_tref: ET.Element) -> str | None:
+    """Merge the topic of *src_tref* into the topic of *target_tref*, its previous sibling or its parent.
+
+    Unlike :func:`merge_topicref_into`, the entries below *src_tref* are kept: they
+    follow the target's own children, or take the merged entry's place under its
+    parent. Links to the merged topic and its elements are pointed at the title
+    paragraph and the copies in the target. Returns the filename of the merged
+    topic (removed from ``ctx.topics``), or None if either topic is missing.
+    """
+    src_name, target_name = _href_filename(src_tref), _href_filename(target_tref)
+    src_topic, target_topic = ctx.topics.get(src_name), ctx.topics.get(target_name)
+    parent = src_tref.getparent()
+    if src_topic is None or target_topic is None or src_name == target_name or parent is None:
+        return None
+
+    children = [c for c in src_tref if getattr(c, "tag", None) in _STRUCTURAL_TAGS]
+    if parent is target_tref:
+        index = list(parent).index(src_tref)
+        for offset, child in enumerate(children):
+            _shift_levels(child, -1)
+            parent.insert(index + offset, child)
+    else:
+        target_tref.extend(children)
+
+    target_type = target_topic.tag
+    title_p = _add_title_paragraph(target_topic, _extract_title_text(src_topic), topic_bookmarks(src_topic))
+    body = _conbody(target_topic)
+    first_copy = len(body)
+    renamed = _copy_content(src_topic, target_topic)
+    parent.remove(src_tref)
+    del ctx.topics[src_name]
+    if target_topic.tag != target_type:
+        from orlando_toolkit.core.processing.procedures import retype_topicrefs
+
+        retype_topicrefs(ctx.ditamap_root, target_name, target_topic.tag)
+
+    target_id = target_topic.get("id")
+    whole = (target_name, target_id, title_p.get("id") if title_p is not None else None)
+    moves = {(src_name, None): whole, (src_name, src_topic.get("id")): whole}
+    moves.update({(src_name, old): (target_name, target_id, new) for old, new in renamed.items()})
+    _retarget_links(ctx, moves, {el: src_name for el in body[first_copy:]})
+    return src_name
+
+
+def _split_heads(topic_el: ET.Element) -> list[ET.Element]:
+    body = topic_el.find("conbody") if topic_el is not None else None
+    if body is None:
+        return []
+    return [el for el in body
+            if (el.tag == "p" and "merged-title" in (el.get("outputclass") or ""))
+            or (el.tag == "section" and el.find("title") is not None)]
+
+
+def _heading_text(head: ET.Element) -> str:
+    text = " ".join("".join((head.find("title") if head.tag == "section" else head).itertext()).split())
+    # Merged-title paragraphs spell the merged topic's title in capitals
+    return text.capitalize() if head.tag == "p" and text.isupper() else text
+
+
+def split_points(topic_el: ET.Element) -> list[str]:
+    """Headings *topic_el* can be split at: merged-title paragraphs and titled sections of its body."""
+    return [_heading_text(head) for head in _split_heads(topic_el)]
+
+
+def split_topic_at(ctx: "DitaContext", tref: ET.Element, point: int) -> str | None:
+    """Move the body of *tref*'s topic from split point *point* on to a new topic.
+
+    The heading becomes the new topic's title: a merged-title paragraph is
+    dropped, a section is unwrapped. The new topic follows *tref* at the same
+    level and takes over its child entries, so the reading order is unchanged
+    and the depth limit does not merge it back. Moved elements keep their ids
+    and links to them are retargeted. Returns the new filename, or None if
+    *point* is not one of :func:`split_points`.
+    """
+    name = _href_filename(tref)
+    topic_el = ctx.topics.get(name)
+    heads = _split_heads(topic_el)
+    if not 0 <= point < len(heads):
+        return None
+    head = heads[point]
+    body = head.getparent()
+    new_topic = _new_topic_with_title(_heading_text(head) or message("untitled", document_language(ctx)))
+    new_body = ET.SubElement(new_topic, "conbody")
+    moved = [c for c in head if head.tag == "section" and getattr(c, "tag", None) != "title"]
+    moved.extend(list(body)[list(body).index(head) + 1:])
+    new_body.extend(moved)
+    body.remove(head)
+    add_bookmarks(new_topic, topic_bookmarks(head))
+
+    new_name = f"topic_{new_topic.get('id')}.dita"
+    ctx.topics[new_name] = new_topic
+    directory = (tref.get("href") or "").split("#")[0].rpartition("/")[0]
+    new_ref = ET.Element("topicref", href=f"{directory}/{new_name}" if directory else new_name)
+    for attr in ("data-level", "data-style"):
+        if tref.get(attr):
+            new_ref.set(attr, tref.get(attr))
+    ET.SubElement(ET.SubElement(new_ref, "topicmeta"), "navtitle").text = new_topic.findtext("title")
+    new_ref.extend([c for c in tref if getattr(c, "tag", None) in _STRUCTURAL_TAGS])
+    parent = tref.getparent()
+    parent.insert(list(parent).index(tref) + 1, new_ref)
+
+    new_id = new_topic.get("id")
+    moves = {(name, el.get("id")): (new_name, new_id, el.get("id")) for el in new_body.iter()
+             if isinstance(el.tag, str) and el.get("id")}
+    if head.get("id"):
+        moves[(name, head.get("id"))] = (new_name, new_id, None)
+    _retarget_links(ctx, moves, {new_body: name})
+    return new_name
+
+
 def convert_section_to_local_topic(ctx: "DitaContext", section_tref: ET.Element) -> tuple[ET.Element | None, list[str]]:
     """Convert a section (topichead) into a content-bearing topic under itself.
 
@@ -410,7 +642,7 @@ def convert_section_to_local_topic(ctx: "DitaContext", section_tref: ET.Element)
                 if target_topic_el is not None and s_topic is not None:
                     t_el = s_topic.find("title")
                     t_txt = t_el.text if t_el is not None and t_el.text else ""
-                    _add_title_paragraph(target_topic_el, t_txt)
+                    _add_title_paragraph(target_topic_el, t_txt, topic_bookmarks(s_topic))
                     _copy_content(s_topic, target_topic_el)
                 _merge_descendants(sub)
                 try:
@@ -484,11 +716,44 @@ def convert_section_to_local_topic(ctx: "DitaContext", section_tref: ET.Element)
 
     return (target_topic_el, removed_files)
 
-def merge_topics_unified(ctx: "DitaContext", depth_limit: int, exclude_style_map: dict[int, set[str]] = None) -> None:
+def branch_key(node: ET.Element) -> str:
+    """Return the title path ("Appendices / Wiring") that names *node* in ``depth_overrides``.
+
+    Titles survive merges, splits of sections into local topics and project round-trips,
+    whereas generated file names and index paths do not.
+    """
+    parts = []
+    current = node
+    while current is not None and current.tag in ("topicref", "topichead"):
+        parts.append(_extract_title_text(current, is_topichead=True) or _href_filename(current))
+        current = current.getparent()
+    return " / ".join(reversed(parts))
+
+
+def _effective_limit(node: ET.Element, depth_limit: int, overrides: Mapping[str, int] | None) -> int:
+    """Depth limit for *node*: the override of its nearest overridden branch, else the global limit."""
+    current = node
+    while overrides and current is not None and current.tag in ("topicref", "topichead"):
+        key = branch_key(current)
+        if key in overrides:
+            return int(overrides[key])
+        current = current.getparent()
+    return depth_limit
+
+
+def merge_topics_unified(
+    ctx: "DitaContext",
+    depth_limit: int,
+    exclude_style_map: dict[int, set[str]] = None,
+    depth_overrides: Mapping[str, int] | None = None,
+    branch: ET.Element | None = None,
+) -> None:
     """Unified merge that handles both depth limits and style exclusions in a single pass.
     
     This replaces the problematic sequential approach that caused content loss.
     Merges topics if they exceed depth_limit OR if their (level, style) is in exclude_style_map.
+    A branch listed in depth_overrides (keyed by :func:`branch_key`) uses its own limit for
+    itself and its whole subtree.
     
     Parameters
     ----------
@@ -498,12 +763,17 @@ def merge_topics_unified(ctx: "DitaContext", depth_limit: int, exclude_style_map
         Maximum allowed depth (topics deeper than this get merged)
     exclude_style_map : dict[int, set[str]], optional
         Map of level -> set of style names to exclude/merge
+    depth_overrides : Mapping[str, int], optional
+        Map of branch title path -> depth limit for that branch
+    branch : ET.Element, optional
+        Only recompute this branch of the map (its siblings are left as they are)
     """
     
     if ctx.ditamap_root is None:
         return
         
     exclude_style_map = exclude_style_map or {}
+    depth_overrides = dict(depth_overrides or {})
     removed_topics: Set[str] = set()
 
     def _find_prev_content_target(current_tref: ET.Element) -> Tuple[Optional[ET.Element], Optional[ET.Element]]:
@@ -535,7 +805,7 @@ def merge_topics_unified(ctx: "DitaContext", depth_limit: int, exclude_style_map
         except Exception:
             return (None, None)
     
-    def _should_merge(tref: ET.Element) -> bool:
+    def _should_merge(tref: ET.Element, limit: int) -> bool:
         """Determine if a topicref should be merged based on depth OR style."""
         t_level = int(tref.get("data-level", 1))  # Use actual data-level attribute
         style_name = tref.get("data-style", "")
@@ -544,7 +814,7 @@ def merge_topics_unified(ctx: "DitaContext", depth_limit: int, exclude_style_map
         # They need to be converted to content-bearing topics when merged
         if tref.tag == "topichead":
             # Merge topichead if beyond depth limit (needs conversion to topic)
-            if t_level > depth_limit:
+            if t_level > limit:
                 return True
             # Merge topichead if style is excluded for this level
             if t_level in exclude_style_map and style_name in exclude_style_map[t_level]:
@@ -553,7 +823,7 @@ def merge_topics_unified(ctx: "DitaContext", depth_limit: int, exclude_style_map
             
         # For topicref (content-bearing): same logic applies
         # Merge if beyond depth limit
-        if t_level > depth_limit:
+        if t_level > limit:
             return True
             
         # Merge if style is excluded for this level
@@ -562,19 +832,27 @@ def merge_topics_unified(ctx: "DitaContext", depth_limit: int, exclude_style_map
             
         return False
     
-    def _recurse(node: ET.Element, level: int, ancestor_topic_el: ET.Element | None, ancestor_tref: ET.Element | None = None):
-        """Single-pass traversal that applies both depth and style criteria."""
+    def _recurse(node: ET.Element, level: int, ancestor_topic_el: ET.Element | None, ancestor_tref: ET.Element | None = None,
+                 limit: int = depth_limit, only: ET.Element | None = None):
+        """Single-pass traversal that applies both depth and style criteria.
+
+        *limit* is the depth limit inherited from the parent branch; a branch with an
+        override passes its own limit down to its children.
+        """
         
         for tref in list(node):
             if tref.tag not in ("topicref", "topichead"):
                 continue
+            if only is not None and tref is not only:
+                continue
 
             t_level = int(tref.get("data-level", level))
+            own = depth_overrides.get(branch_key(tref), limit) if depth_overrides else limit
 
             # Boundary anchor: if this is a section exactly at the depth limit,
             # convert it to a local content topic NOW, then recurse with the new
             # topic as ancestor so deeper merges land here rather than elsewhere.
-            if tref.tag == "topichead" and t_level == depth_limit:
+            if tref.tag == "topichead" and t_level == own:
                 parent = tref.getparent()
                 try:
                     insert_pos = list(parent).index(tref) if parent is not None else None
@@ -602,7 +880,7 @@ def merge_topics_unified(ctx: "DitaContext", depth_limit: int, exclude_style_map
                     nfname = nhref.split("/")[-1] if nhref else ""
                     ntopic = ctx.topics.get(nfname)
                     # Recurse with the new topic as ancestor so >depth children merge here
-                    _recurse(new_ref, t_level + 1, ntopic, new_ref)
+                    _recurse(new_ref, t_level + 1, ntopic, new_ref, own)
                     # No immediate removal; keep the boundary anchor as visible topic
                 # Continue with siblings after establishing anchor
                 continue
@@ -616,7 +894,7 @@ def merge_topics_unified(ctx: "DitaContext", depth_limit: int, exclude_style_map
                 topic_el = ctx.topics.get(fname)
 
             # Check if this topic should be merged (unified decision)
-            if _should_merge(tref):
+            if _should_merge(tref, limit):
                 # If this is a section: convert it to a local content topic like manual merge does
                 if tref.tag == "topichead":
                     parent = tref.getparent()
@@ -641,7 +919,7 @@ def merge_topics_unified(ctx: "DitaContext", depth_limit: int, exclude_style_map
                     except Exception:
                         new_ref = None
 
-                    if new_ref is not None and _should_merge(new_ref):
+                    if new_ref is not None and _should_merge(new_ref, limit):
                         # Resolve its topic element
                         nhref = new_ref.get("href") or ""
                         nfname = nhref.split("/")[-1] if nhref else ""
@@ -650,7 +928,7 @@ def merge_topics_unified(ctx: "DitaContext", depth_limit: int, exclude_style_map
                         if ancestor_topic_el is not None and ntopic is not None:
                             # Merge into current ancestor
                             removed_name = merge_topicref_into(ctx, ancestor_topic_el, new_ref)
-                            _recurse(new_ref, t_level + 1, ancestor_topic_el, ancestor_tref)
+                            _recurse(new_ref, t_level + 1, ancestor_topic_el, ancestor_tref, limit)
                             if removed_name:
                                 removed_topics.add(removed_name)
                             else:
@@ -669,7 +947,7 @@ def merge_topics_unified(ctx: "DitaContext", depth_limit: int, exclude_style_map
 
                         if target_topic is not None and ntopic is not None:
                             removed_name = merge_topicref_into(ctx, target_topic, new_ref)
-                            _recurse(new_ref, t_level + 1, target_topic, target_tref)
+                            _recurse(new_ref, t_level + 1, target_topic, target_tref, limit)
                             if removed_name:
                                 removed_topics.add(removed_name)
                             else:
@@ -682,7 +960,7 @@ def merge_topics_unified(ctx: "DitaContext", depth_limit: int, exclude_style_map
                             continue
 
                         # Fallback: just traverse deeper with whatever ancestor we have
-                        _recurse(new_ref, t_level + 1, ancestor_topic_el, ancestor_tref)
+                        _recurse(new_ref, t_level + 1, ancestor_topic_el, ancestor_tref, limit)
                         continue
 
                     # Otherwise, continue processing siblings/children in subsequent iterations
@@ -692,7 +970,7 @@ def merge_topics_unified(ctx: "DitaContext", depth_limit: int, exclude_style_map
                 if ancestor_topic_el is not None and topic_el is not None:
                     # Topic with ancestor: merge via helper and recurse
                     removed_name = merge_topicref_into(ctx, ancestor_topic_el, tref)
-                    _recurse(tref, t_level + 1, ancestor_topic_el, ancestor_tref)
+                    _recurse(tref, t_level + 1, ancestor_topic_el, ancestor_tref, limit)
                     if removed_name:
                         removed_topics.add(removed_name)
                     else:
@@ -724,11 +1002,11 @@ def merge_topics_unified(ctx: "DitaContext", depth_limit: int, exclude_style_map
                                     tref.set("data-style", f"Heading {section_level}")
                             except Exception:
                                 pass
-                            _recurse(tref, t_level + 1, topic_el, tref)
+                            _recurse(tref, t_level + 1, topic_el, tref, limit)
                             continue
                         # General case: merge into target and remove self
                         removed_name = merge_topicref_into(ctx, target_topic, tref)
-                        _recurse(tref, t_level + 1, target_topic, target_tref)
+                        _recurse(tref, t_level + 1, target_topic, target_tref, limit)
                         if removed_name:
                             removed_topics.add(removed_name)
                         else:
@@ -740,11 +1018,11 @@ def merge_topics_unified(ctx: "DitaContext", depth_limit: int, exclude_style_map
                                 removed_topics.add(fname)
                         continue
                     # Fallback: traverse deeper without removing
-                    _recurse(tref, t_level + 1, ancestor_topic_el, ancestor_tref)
+                    _recurse(tref, t_level + 1, ancestor_topic_el, ancestor_tref, limit)
                     continue
 
                 # No content to merge - just traverse deeper
-                _recurse(tref, t_level + 1, ancestor_topic_el, ancestor_tref)
+                _recurse(tref, t_level + 1, ancestor_topic_el, ancestor_tref, limit)
                 continue
             else:
                 # Not merging - traverse deeper with updated ancestor
@@ -758,10 +1036,20 @@ def merge_topics_unified(ctx: "DitaContext", depth_limit: int, exclude_style_map
                     next_ancestor_topic = ancestor_topic_el
                     next_ancestor_tref = ancestor_tref
                     
-                _recurse(tref, t_level + 1, next_ancestor_topic, next_ancestor_tref)
-
-    # Start the unified traversal
-    _recurse(ctx.ditamap_root, 1, None, None)
+                _recurse(tref, t_level + 1, next_ancestor_topic, next_ancestor_tref, own)
+
+    # Start the unified traversal (or resume it at a single branch)
+    if branch is None:
+        _recurse(ctx.ditamap_root, 1, None, None)
+    elif branch.getparent() is not None:
+        anchor = branch.getparent()
+        ancestor_tref = anchor
+        while ancestor_tref is not None and not (ancestor_tref.tag == "topicref" and
+                                                 ctx.topics.get(_href_filename(ancestor_tref)) is not None):
+            ancestor_tref = ancestor_tref.getparent()
+        ancestor_topic = ctx.topics.get(_href_filename(ancestor_tref)) if ancestor_tref is not None else None
+        _recurse(anchor, int(branch.get("data-level", 1)), ancestor_topic, ancestor_tref,
+                 _effective_limit(anchor, depth_limit, depth_overrides), only=branch)
 
     # Clean up merged topics
     for fname in removed_topics:
@@ -774,10 +1062,14 @@ def merge_topics_unified(ctx: "DitaContext", depth_limit: int, exclude_style_map
     # boundary items are previewable and not visually empty in the UI when a
     # max depth limit is active. This preserves all content by creating/reusing
     # a module and merging descendants, then replacing the section in place.
-    _promote_sections_at_depth_limit(ctx, depth_limit)
+    _promote_sections_at_depth_limit(ctx, depth_limit, depth_overrides)
 
     # Note: No final cleanup needed since topichead elements don't have conbody
 
+    # A single-branch pass leaves the global merge state untouched
+    if branch is not None:
+        return
+
     # Set metadata to indicate both operations completed
     ctx.metadata["merged_depth"] = depth_limit
     if exclude_style_map:
@@ -886,10 +1178,12 @@ def _collapse_redundant_sections(ctx: "DitaContext") -> None:
             parent.insert(parent_index, content_child) 
 
 
-def _promote_sections_at_depth_limit(ctx: "DitaContext", depth_limit: int) -> None:
+def _promote_sections_at_depth_limit(ctx: "DitaContext", depth_limit: int,
+                                     depth_overrides: Mapping[str, int] | None = None) -> None:
     """Ensure sections at the depth limit become content-bearing topics.
 
-    For any topichead with data-level == depth_limit, replace it with its content
+    For any topichead with data-level == depth_limit (or the override of its branch),
+    replace it with its content
     module (creating one if needed) and transfer metadata. This guarantees that at
     the boundary, a navigable topic exists rather than a structural topichead.
     """
@@ -904,7 +1198,7 @@ def _promote_sections_at_depth_limit(ctx: "DitaContext", depth_limit: int) -> No
                 lvl = int(th.get("data-level", 1))
             except Exception:
                 lvl = 1
-            if lvl == depth_limit:
+            if lvl == _effective_limit(th, depth_limit, depth_overrides):
                 candidates.append(th)
     except Exception:
         candidates = []
@@ -12,7 +12,9 @@ from typing import Any, Dict, Optional, List
 
 from lxml import etree as ET
 
-__all__ = ["DitaContext", "HeadingNode"]
+from .report import ConversionReport, ReportEntry
+
+__all__ = ["DitaContext", "HeadingNode", "ConversionReport", "ReportEntry"]
 
 
 @dataclass
@@ -72,19 +74,27 @@ class DitaContext:
         Mapping of image file names to raw bytes extracted during document conversion.
     videos
         Mapping of video file names to raw bytes extracted during document conversion.
+    attachments
+        Mapping of attached file names (PDFs, spreadsheets…) to raw bytes, shipped
+        under ``resources/`` (see :mod:`orlando_toolkit.core.attachments`).
     metadata
         Arbitrary key/value pairs captured from GUI or config (title, code…).
+    report
+        Warnings, skipped content and the partial-output flag for this conversion.
     """
 
     ditamap_root: Optional[ET.Element] = None
     topics: Dict[str, ET.Element] = field(default_factory=dict)
     images: Dict[str, bytes] = field(default_factory=dict)
     videos: Dict[str, bytes] = field(default_factory=dict)
+    attachments: Dict[str, bytes] = field(default_factory=dict)
     metadata: Dict[str, Any] = field(default_factory=dict)
     
     # Plugin data storage (namespaced by plugin ID) - Required by design Section 7.1
     plugin_data: Dict[str, Dict[str, Any]] = field(default_factory=dict)
 
+    report: ConversionReport = field(default_factory=ConversionReport, compare=False)
+
     def save_original_structure(self) -> None:
         """Save the original structure before any depth merging operations.
         
@@ -105,7 +115,8 @@ class DitaContext:
                 "images": deepcopy(self.images),
                 "videos": deepcopy(self.videos),
                 "metadata_snapshot": {k: v for k, v in self.metadata.items() 
-                                    if k not in ["original_structure", "merged_depth", "merged_exclude_styles"]},
+                                    if k not in ["original_structure", "merged_depth", "merged_exclude_styles",
+                                                 "depth_overrides"]},
             }
 
     def restore_from_original(self) -> None:
@@ -0,0 +1,195 @@
+from __future__ import annotations
+
+"""Conversion report collected while a document travels through the pipeline.
+
+The report is a plain, UI-agnostic record of notable events (warnings, skipped
+content, applied fixes) plus a ``partial`` flag that is raised whenever the
+output is knowingly incomplete, for example when a time budget ran out.
+
+Entries keep insertion order; pipeline stages append from the coordinating
+thread so the sequence is identical between serial and parallel runs.
+"""
+
+import json
+import threading
+from dataclasses import dataclass, field
+from typing import Any, Dict, List, Optional
+
+__all__ = ["ConversionReport", "ReportEntry", "SEVERITIES"]
+
+SEVERITIES = ("info", "warning", "error")
+
+
+@dataclass(frozen=True)
+class ReportEntry:
+    """Single report line."""
+
+    severity: str
+    category: str
+    message: str
+    topic: Optional[str] = None
+    detail: Dict[str, Any] = field(default_factory=dict)
+
+    @property
+    def code(self) -> Optional[str]:
+        """Error code recorded by ``report_error`` (``orlando_toolkit.core.errors``)."""
+        return self.detail.get("code")
+
+    @property
+    def hint(self) -> Optional[str]:
+        return self.detail.get("hint")
+
+    @property
+    def paragraphs(self) -> List[int]:
+        """Word body paragraphs (1-based) the entry is about, from ``paragraph``/``paragraphs`` in the detail."""
+        found = self.detail.get("paragraphs") or ([self.detail["paragraph"]] if "paragraph" in self.detail else [])
+        return [int(p) for p in found if isinstance(p, int) or str(p).isdigit()]
+
+    def to_dict(self) -> Dict[str, Any]:
+        data: Dict[str, Any] = {
+            "severity": self.severity,
+            "category": self.category,
+            "message": self.message,
+        }
+        if self.topic is not None:
+            data["topic"] = self.topic
+        if self.detail:
+            data["detail"] = dict(self.detail)
+        return data
+
+
+class ConversionReport:
+    """Ordered collection of :class:`ReportEntry` with a partial-output flag."""
+
+    def __init__(self) -> None:
+        self._entries: List[ReportEntry] = []
+        self._partial_reasons: List[str] = []
+        self._timings: Dict[str, float] = {}
+        self._lock = threading.Lock()
+
+    # ------------------------------------------------------------------
+    # Recording
+    # ------------------------------------------------------------------
+    def add(self, severity: str, category: str, message: str, *,
+            topic: Optional[str] = None, **detail: Any) -> ReportEntry:
+        if severity not in SEVERITIES:
+            severity = "info"
+        entry = ReportEntry(severity, category, message, topic, detail)
+        with self._lock:
+            self._entries.append(entry)
+        return entry
+
+    def info(self, category: str, message: str, **kwargs: Any) -> ReportEntry:
+        return self.add("info", category, message, **kwargs)
+
+    def warning(self, category: str, message: str, **kwargs: Any) -> ReportEntry:
+        return self.add("warning", category, message, **kwargs)
+
+    def error(self, category: str, message: str, **kwargs: Any) -> ReportEntry:
+        return self.add("error", category, message, **kwargs)
+
+    def mark_partial(self, reason: str) -> None:
+        """Flag the output as incomplete; repeated reasons are recorded once."""
+        with self._lock:
+            if reason not in self._partial_reasons:
+                self._partial_reasons.append(reason)
+
+    def record_timing(self, name: str, seconds: float) -> None:
+        """Add *seconds* to the wall-clock time spent in stage *name*."""
+        with self._lock:
+            self._timings[name] = self._timings.get(name, 0.0) + float(seconds)
+
+    def extend(self, other: "ConversionReport") -> None:
+        """Append entries, partial reasons and timings from *other*."""
+        for entry in other.entries:
+            with self._lock:
+                self._entries.append(entry)
+        for reason in other.partial_reasons:
+            self.mark_partial(reason)
+        for name, seconds in other.timings.items():
+            self.record_timing(name, seconds)
+
+    # ------------------------------------------------------------------
+    # Queries
+    # ------------------------------------------------------------------
+    @property
+    def entries(self) -> List[ReportEntry]:
+        with self._lock:
+            return list(self._entries)
+
+    @property
+    def partial(self) -> bool:
+        return bool(self._partial_reasons)
+
+    @property
+    def partial_reasons(self) -> List[str]:
+        return list(self._partial_reasons)
+
+    @property
+    def timings(self) -> Dict[str, float]:
+        with self._lock:
+            return dict(self._timings)
+
+    def count(self, severity: Optional[str] = None, category: Optional[str] = None) -> int:
+        return sum(
+            1 for e in self.entries
+            if (severity is None or e.severity == severity)
+            and (category is None or e.category == category)
+        )
+
+    def __len__(self) -> int:
+        return len(self._entries)
+
+    def __bool__(self) -> bool:
+        # A report is always truthy so ``context.report or ...`` idioms stay safe
+        return True
+
+    # Contexts are deep-copied for export; locks cannot be copied or pickled
+    def __getstate__(self) -> Dict[str, Any]:
+        return {"entries": self.entries, "partial_reasons": self.partial_reasons, "timings": self.timings}
+
+    def __setstate__(self, state: Dict[str, Any]) -> None:
+        self._entries = list(state.get("entries", []))
+        self._partial_reasons = list(state.get("partial_reasons", []))
+        self._timings = dict(state.get("timings", {}))
+        self._lock = threading.Lock()
+
+    # ------------------------------------------------------------------
+    # Serialization
+    # ------------------------------------------------------------------
+    def to_dict(self) -> Dict[str, Any]:
+        data: Dict[str, Any] = {
+            "partial": self.partial,
+            "partial_reasons": self.partial_reasons,
+            "counts": {sev: self.count(sev) for sev in SEVERITIES},
+            "entries": [e.to_dict() for e in self.entries],
+        }
+        timings = self.timings
+        if timings:
+            data["timings"] = {name: round(seconds, 4) for name, seconds in timings.items()}
+        return data
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any]) -> "ConversionReport":
+        """Rebuild a report from :meth:`to_dict` output."""
+        report = cls()
+        for item in (data or {}).get("entries", []):
+            report.add(item.get("severity", "info"), item.get("category", ""), item.get("message", ""),
+                       topic=item.get("topic"), **(item.get("detail") or {}))
+        for reason in (data or {}).get("partial_reasons", []):
+            report.mark_partial(reason)
+        for name, seconds in ((data or {}).get("timings") or {}).items():
+            report.record_timing(name, seconds)
+        return report
+
+    def to_json(self, **kwargs: Any) -> str:
+        kwargs.setdefault("ensure_ascii", False)
+        kwargs.setdefault("indent", 2)
+        return json.dumps(self.to_dict(), **kwargs)
+
+    def summary(self) -> str:
+        """One-line human readable summary."""
+        parts = [f"{self.count('error')} error(s)", f"{self.count('warning')} warning(s)"]
+        if self.partial:
+            parts.append("PARTIAL: " + "; ".join(self._partial_reasons))
+        return ", ".join(parts)
@@ -0,0 +1,483 @@
+from __future__ import annotations
+
+"""Word embedded OLE objects (Excel, Visio, ...) as DITA ``<object>``.
+
+Converters keep at most the preview picture of a spreadsheet, diagram or
+document embedded in Word, and drop the object itself. After the plugin
+handler returns, :func:`restore_word_objects` reads ``word/document.xml`` of
+the source and finds every ``w:object`` whose ``o:OLEObject`` is not a video
+or audio clip (:mod:`orlando_toolkit.core.word_media`) nor an equation
+(``Equation.*``, ``MathType``; their picture is kept). The object type comes
+from its ProgID, else from the extension of the embedded part (see
+:data:`OBJECT_TYPES`), and ``ole_objects.types`` says what each type becomes:
+
+- ``object`` (default): a figure with the preview as fallback image and the
+  native file attached to the package::
+
+      <fig outputclass="excel">
+        <image href="../media/excel_1_preview.emf" placement="break"><alt>Budget</alt></image>
+        <object data="../media/excel_1.xlsx" type="application/vnd.openxmlformats-..." outputclass="excel">
+          <desc>Budget</desc>
+        </object>
+      </fig>
+
+- ``link``: the preview with a link to the attached native file;
+- ``image``: the preview only;
+- ``ignore``: the output stays as the converter wrote it.
+
+The preview is the picture Word stored for the object (``v:imagedata``,
+usually a metafile converted by the ``vector_images`` stage). An Excel
+workbook without one gets its first sheet drawn as an SVG table when
+``render`` is on. When the converter already turned the preview into an
+``<image>`` (matched by the bytes of the file), the figure takes that image's
+place; otherwise it is placed after the paragraph holding the object,
+located by text (:mod:`orlando_toolkit.core.placement`). Native files go to
+``context.videos`` (the package's non-image media) as ``<type>_N.<ext>``;
+legacy ``oleObjectN.bin`` parts get the extension of their type.
+Macro-enabled files (``.xlsm``, ``.docm``, ``.pptm``, ``.vsdm``) are not
+attached unless ``allow_macros``. Findings are reported under
+``ole_objects``.
+"""
+
+import hashlib
+import io
+import logging
+import posixpath
+import zipfile
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.placement import find_block, normalize, text_blocks, topic_order
+from orlando_toolkit.core.utils import topic_body
+from orlando_toolkit.core.word_media import MEDIA_TYPES
+from orlando_toolkit.core.word_source import DOCUMENT, PKG_REL_NS, R_NS, WordSource, w
+from orlando_toolkit.core.xml_security import parse_bytes
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["MODES", "OBJECT_MIME_TYPES", "OBJECT_TYPES", "WordObject", "object_type", "read_word_objects",
+           "render_sheet_svg", "restore_word_objects"]
+
+#: Object types and how they are named in reports.
+OBJECT_TYPES = {"excel": "Excel spreadsheet", "visio": "Visio diagram", "word": "Word document",
+                "powerpoint": "PowerPoint presentation", "pdf": "PDF document", "other": "embedded object"}
+#: What an object type becomes (``ole_objects.types``).
+MODES = ("object", "link", "image", "ignore")
+OBJECT_MIME_TYPES = {
+    ".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+    ".xlsm": "application/vnd.ms-excel.sheet.macroEnabled.12",
+    ".xlsb": "application/vnd.ms-excel.sheet.binary.macroEnabled.12",
+    ".xls": "application/vnd.ms-excel",
+    ".vsdx": "application/vnd.ms-visio.drawing",
+    ".vsdm": "application/vnd.ms-visio.drawing.macroEnabled.12",
+    ".vsd": "application/vnd.visio",
+    ".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+    ".docm": "application/vnd.ms-word.document.macroEnabled.12",
+    ".doc": "application/msword",
+    ".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+    ".pptm": "application/vnd.ms-powerpoint.presentation.macroEnabled.12",
+    ".ppt": "application/vnd.ms-powerpoint",
+    ".pdf": "application/pdf",
+    ".bin": "application/octet-stream",
+}
+_MC = "http://schemas.openxmlformats.org/markup-compatibility/2006"
+_V = "urn:schemas-microsoft-com:vml"
+_O = "urn:schemas-microsoft-com:office:office"
+_SML = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+_SVG = "http://www.w3.org/2000/svg"
+_PROG_IDS = (("Excel.", "excel"), ("Visio.", "visio"), ("Word.", "word"), ("PowerPoint.", "powerpoint"),
+             ("AcroExch.", "pdf"), ("Acrobat.", "pdf"))
+_EXTENSION_TYPES = {".xlsx": "excel", ".xlsm": "excel", ".xlsb": "excel", ".xls": "excel", ".vsdx": "visio",
+                    ".vsdm": "visio", ".vsd": "visio", ".docx": "word", ".docm": "word", ".doc": "word",
+                    ".pptx": "powerpoint", ".pptm": "powerpoint", ".ppt": "powerpoint", ".pdf": "pdf"}
+# A legacy oleObjectN.bin holds the compound file these applications open
+_LEGACY_EXTENSIONS = {"excel": ".xls", "visio": ".vsd", "word": ".doc", "powerpoint": ".ppt"}
+_MACRO_EXTENSIONS = {".xlsm", ".xlsb", ".docm", ".pptm", ".vsdm"}
+_SKIPPED_PROG_IDS = ("Equation.", "MathType")
+
+_CELL_WIDTH, _CELL_HEIGHT = 110, 22
+_MAX_ROWS, _MAX_COLUMNS, _MAX_CHARS = 20, 8, 16
+
+
+@dataclass
+class WordObject:
+    number: int                     # 1-based, in document order
+    kind: str                       # key of OBJECT_TYPES
+    prog_id: str
+    anchor: str                     # text of the paragraph holding the object (or the one before it)
+    ext: str = ""                   # extension of the native file, ".xlsx"
+    data: Optional[bytes] = None    # embedded native file
+    url: str = ""                   # linked file, when not embedded
+    title: str = ""
+    preview: Optional[Tuple[str, bytes]] = None   # (part name, bytes) of the picture Word shows
+    rendered: Optional[bytes] = None              # SVG drawn from the native file
+    paragraph: int = 0              # 1-based index of its body paragraph in word/document.xml
+
+    @property
+    def media_type(self) -> str:
+        return OBJECT_MIME_TYPES.get(self.ext, "")
+
+    @property
+    def label(self) -> str:
+        return self.title or OBJECT_TYPES[self.kind][0].upper() + OBJECT_TYPES[self.kind][1:]
+
+
+def object_type(prog_id: str, ext: str = "") -> str:
+    """Key of :data:`OBJECT_TYPES` for an object with ProgID *prog_id* stored as *ext*."""
+    for prefix, kind in _PROG_IDS:
+        if prog_id.startswith(prefix):
+            return kind
+    return _EXTENSION_TYPES.get(ext.lower(), "other")
+
+
+# ----------------------------------------------------------------------
+# Sheet rendering
+# ----------------------------------------------------------------------
+
+def _column(ref: str) -> int:
+    number = 0
+    for char in ref:
+        if not char.isalpha():
+            break
+        number = number * 26 + ord(char.upper()) - 64
+    return number - 1
+
+
+def _sheet_rows(data: bytes) -> List[List[str]]:
+    """Cell texts of the first worksheet of the ``.xlsx`` *data*, top-left block only."""
+    with zipfile.ZipFile(io.BytesIO(data)) as book:
+        workbook = parse_bytes(book.read("xl/workbook.xml"), source="xl/workbook.xml")
+        sheet = next(workbook.iter(f"{{{_SML}}}sheet"), None)
+        if sheet is None:
+            return []
+        rels = parse_bytes(book.read("xl/_rels/workbook.xml.rels"), source="xl/_rels/workbook.xml.rels")
+        target = next((r.get("Target") for r in rels.iter(f"{{{PKG_REL_NS}}}Relationship")
+                       if r.get("Id") == sheet.get(f"{{{R_NS}}}id")), None)
+        if not target:
+            return []
+        part = target.lstrip("/") if target.startswith("/") else posixpath.normpath(posixpath.join("xl", target))
+        strings: List[str] = []
+        if "xl/sharedStrings.xml" in book.namelist():
+            shared = parse_bytes(book.read("xl/sharedStrings.xml"), source="xl/sharedStrings.xml")
+            strings = ["".join(t.text or "" for t in si.iter(f"{{{_SML}}}t")) for si in shared.iter(f"{{{_SML}}}si")]
+        worksheet = parse_bytes(book.read(part), source=part)
+    rows: List[List[str]] = []
+    for row in worksheet.iter(f"{{{_SML}}}row"):
+        index = int(row.get("r") or len(rows) + 1) - 1
+        if index >= _MAX_ROWS:
+            break
+        while len(rows) <= index:
+            rows.append([""] * _MAX_COLUMNS)
+        for cell in row.iter(f"{{{_SML}}}c"):
+            column = _column(cell.get("r") or "")
+            if not 0 <= column < _MAX_COLUMNS:
+                continue
+            kind = cell.get("t")
+            if kind == "inlineStr":
+                text = "".join(t.text or "" for t in cell.iter(f"{{{_SML}}}t"))
+            else:
+                text = cell.findtext(f"{{{_SML}}}v") or ""
+                if kind == "s" and text.isdigit() and int(text) < len(strings):
+                    text = strings[int(text)]
+            rows[index][column] = normalize(text)
+    used = max((i + 1 for row in rows for i, text in enumerate(row) if text), default=0)
+    return [row[:used] for row in rows] if used else []
+
+
+def render_sheet_svg(data: bytes) -> Optional[bytes]:
+    """The top-left cells of the first sheet of the ``.xlsx`` *data* as an SVG table, or ``None``."""
+    try:
+        rows = _sheet_rows(data)
+    except (KeyError, ValueError, zipfile.BadZipFile, ET.XMLSyntaxError) as exc:
+        logger.debug("Workbook could not be read: %s", exc)
+        return None
+    if not rows:
+        return None
+    columns = len(rows[0])
+    width, height = columns * _CELL_WIDTH + 1, len(rows) * _CELL_HEIGHT + 1
+    svg = ET.Element(f"{{{_SVG}}}svg", nsmap={None: _SVG}, width=str(width), height=str(height),
+                     viewBox=f"0 0 {width} {height}")
+    for r, row in enumerate(rows):
+        for c, text in enumerate(row):
+            x, y = c * _CELL_WIDTH + 0.5, r * _CELL_HEIGHT + 0.5
+            ET.SubElement(svg, f"{{{_SVG}}}rect", x=str(x), y=str(y), width=str(_CELL_WIDTH),
+                          height=str(_CELL_HEIGHT), fill="#f2f2f2" if r == 0 else "#ffffff", stroke="#bfbfbf")
+            if not text:
+                continue
+            label = ET.SubElement(svg, f"{{{_SVG}}}text", {
+                "x": f"{x + 4:.1f}", "y": f"{y + 15:.1f}", "font-size": "11",
+                "font-family": "Arial, Helvetica, sans-serif", "font-weight": "bold" if r == 0 else "normal"})
+            label.text = text if len(text) <= _MAX_CHARS else text[:_MAX_CHARS - 1] + "…"
+    return ET.tostring(svg, xml_declaration=True, encoding="UTF-8")
+
+
+# ----------------------------------------------------------------------
+# Reading
+# ----------------------------------------------------------------------
+
+def _paragraph_text(paragraph: Any) -> str:
+    parts = []
+    for el in paragraph.iter():
+        if el.tag == w("t"):
+            parts.append(el.text or "")
+        elif el.tag in (w("tab"), w("br")):
+            parts.append(" ")
+    return normalize("".join(parts))
+
+
+def _part(docx: WordSource, targets: Dict[str, Tuple[str, bool]],
+          rel: Optional[str]) -> Optional[Tuple[str, bytes]]:
+    target, external = targets.get(rel or "", ("", True))
+    if not target or external:
+        return None
+    try:
+        return target, docx.read(target)
+    except KeyError:
+        return None
+
+
+def _word_object(obj: Any, docx: WordSource, targets: Dict[str, Tuple[str, bool]],
+                 number: int, anchor: str, render: bool) -> Optional[WordObject]:
+    ole = obj.find(f"{{{_O}}}OLEObject")
+    if ole is None:
+        return None
+    prog_id = (ole.get("ProgID") or "").strip()
+    if prog_id.startswith(_SKIPPED_PROG_IDS):
+        return None
+    rel = ole.get(f"{{{R_NS}}}id")
+    target, external = targets.get(rel or "", ("", True))
+    embedded = None if external or ole.get("Type") == "Link" else _part(docx, targets, rel)
+    name = embedded[0] if embedded is not None else target
+    ext = posixpath.splitext(name.replace("\\", "/"))[1].lower()
+    if ext in MEDIA_TYPES:
+        return None                         # video or audio: core.word_media
+    kind = object_type(prog_id, ext)
+    found = WordObject(number, kind, prog_id, anchor)
+    if embedded is not None:
+        found.data = embedded[1]
+    elif target:
+        found.url = target
+    found.ext = _LEGACY_EXTENSIONS.get(kind, ".bin") if ext in ("", ".bin") else ext
+    shape = obj.find(f"{{{_V}}}shape")
+    imagedata = shape.find(f"{{{_V}}}imagedata") if shape is not None else None
+    if imagedata is not None:
+        found.preview = _part(docx, targets, imagedata.get(f"{{{R_NS}}}id"))
+    if shape is not None:
+        found.title = (shape.get("alt") or "").strip()
+    if render and found.preview is None and found.data and found.ext in (".xlsx", ".xlsm"):
+        found.rendered = render_sheet_svg(found.data)
+    return found
+
+
+def read_word_objects(path: str | Path | WordSource, options: Optional[Mapping[str, Any]] = None) -> List[WordObject]:
+    """Embedded and linked OLE objects of the body of the ``.docx`` *path*, in document order."""
+    options = dict(options or {})
+    docx = WordSource.of(path)
+    if docx is None or docx.document is None:
+        return []
+    render = bool(options.get("render", True))
+    objects: List[WordObject] = []
+    targets = docx.relationships(DOCUMENT)
+    anchor = ""
+    index = 0
+    for paragraph in docx.paragraphs:
+        if any(a.tag == w("p") for a in paragraph.iterancestors()):
+            continue
+        index += 1
+        text = _paragraph_text(paragraph)
+        for obj in paragraph.iter(w("object")):
+            if any(a.tag == f"{{{_MC}}}Fallback" for a in obj.iterancestors()):
+                continue
+            found = _word_object(obj, docx, targets, len(objects) + 1, text or anchor, render)
+            if found is not None:
+                found.paragraph = index
+                objects.append(found)
+        if text:
+            anchor = text
+    return objects
+
+
+# ----------------------------------------------------------------------
+# Placement
+# ----------------------------------------------------------------------
+
+def _unique(store: Any, name: str) -> str:
+    stem, ext = posixpath.splitext(name)
+    candidate, n = name, 2
+    while candidate in store:
+        candidate, n = f"{stem}_{n}{ext}", n + 1
+    return candidate
+
+
+def _digest(data: bytes) -> str:
+    return hashlib.sha1(data).hexdigest()
+
+
+def _modes(options: Mapping[str, Any]) -> Dict[str, str]:
+    types = options.get("types") or {}
+    modes: Dict[str, str] = {}
+    for kind in OBJECT_TYPES:
+        mode = str(types.get(kind) or "object") if isinstance(types, Mapping) else "object"
+        if mode not in MODES:
+            logger.warning("Unknown ole_objects mode %r for %s (expected %s)", mode, kind, ", ".join(MODES))
+            mode = "object"
+        modes[kind] = mode
+    return modes
+
+
+def _figure(found: WordObject, mode: str, image: str, native: str) -> Optional[Any]:
+    """The markup of *found*; *image* and *native* are the file names in the package (may be empty)."""
+    data = f"../media/{native}" if native else found.url
+    fig = ET.Element("fig", outputclass=found.kind)
+    if image:
+        el = ET.SubElement(fig, "image", href=f"../media/{image}", placement="break")
+        ET.SubElement(el, "alt").text = found.label
+    if mode == "object" and data:
+        obj = ET.SubElement(fig, "object", data=data, outputclass=found.kind)
+        if found.media_type:
+            obj.set("type", found.media_type)
+        ET.SubElement(obj, "desc").text = found.label
+    elif mode == "link" and data:
+        p = ET.SubElement(fig, "p")
+        xref = ET.SubElement(p, "xref", href=data, format=found.ext.lstrip(".") or "html",
+                             scope="local" if native else "external")
+        xref.text = f"Open the {OBJECT_TYPES[found.kind]}"
+    return fig if len(fig) else None
+
+
+def _after(block: Any, new: Any) -> None:
+    if block.tag in ("li", "entry", "stentry", "dd") or block.getparent() is None:
+        block.append(new)
+        return
+    parent = block.getparent()
+    new.tail = block.tail
+    block.tail = None
+    parent.insert(parent.index(block) + 1, new)
+
+
+def _replace(el: Any, new: Any) -> None:
+    parent = el.getparent()
+    new.tail = el.tail
+    parent.insert(parent.index(el), new)
+    parent.remove(el)
+
+
+def restore_word_objects(path: str | Path | WordSource, context: Any, options: Optional[Mapping[str, Any]] = None,
+                         report: Any = None) -> int:
+    """Add the OLE objects of the ``.docx`` *path* to *context*; returns the number placed."""
+    options = dict(options or {})
+    if not options.get("enabled", True):
+        return 0
+    report = report if report is not None else getattr(context, "report", None)
+    try:
+        objects = read_word_objects(path, options)
+    except Exception as exc:
+        logger.warning("Could not read the embedded objects of %s: %s", Path(path).name, exc)
+        return 0
+    if not objects:
+        return 0
+    if getattr(context, "videos", None) is None:
+        context.videos = {}
+    modes = _modes(options)
+    allow_macros = bool(options.get("allow_macros", False))
+    images: Dict[str, List[Any]] = {}
+    for topic_name in topic_order(context):
+        for el in context.topics[topic_name].iter("image"):
+            images.setdefault(posixpath.basename(el.get("href") or ""), []).append(el)
+    by_digest = {_digest(data): name for name, data in (context.images or {}).items() if name in images}
+
+    blocks = text_blocks(context)
+    cursor = placed = 0
+    last: Dict[int, Any] = {}
+    counts: Dict[str, int] = {}
+    attached = 0
+    failed: List[int] = []
+    unplaced: List[int] = []
+    macros: List[int] = []
+    linked: List[int] = []
+    for found in objects:
+        mode = modes[found.kind]
+        if mode == "ignore":
+            continue
+        native = ""
+        if found.data is not None and mode in ("object", "link"):
+            if found.ext in _MACRO_EXTENSIONS and not allow_macros:
+                macros.append(found.number)
+            else:
+                native = _unique(context.videos, f"{found.kind}_{found.number}{found.ext}")
+        elif found.url and mode in ("object", "link"):
+            linked.append(found.number)
+        existing = by_digest.get(_digest(found.preview[1])) if found.preview else None
+        target = images[existing].pop(0) if existing and images.get(existing) else None
+        if target is not None:
+            image, preview = existing, None
+            if not images[existing]:
+                by_digest.pop(_digest(found.preview[1]), None)
+        elif found.preview:
+            ext = posixpath.splitext(found.preview[0])[1] or ".png"
+            image = _unique(context.images, f"{found.kind}_{found.number}_preview{ext}")
+            preview = found.preview[1]
+        elif found.rendered:
+            image, preview = _unique(context.images, f"{found.kind}_{found.number}_preview.svg"), found.rendered
+        else:
+            image, preview = "", None
+        if mode == "image" and target is not None:
+            placed += 1                     # the converter's picture already is the output
+            counts[found.kind] = counts.get(found.kind, 0) + 1
+            continue
+        fig = _figure(found, mode, image, native)
+        if fig is None:
+            failed.append(found.number)
+            continue
+        if target is not None:
+            parent = target.getparent()
+            only = parent.tag == "p" and len(parent) == 1 and not normalize((parent.text or "") + (target.tail or ""))
+            _replace(parent if only else target, fig)
+        else:
+            hit, after = find_block(blocks, found.anchor, cursor) if found.anchor else (None, cursor)
+            if hit is not None:
+                cursor = after
+                block = blocks[hit][1]
+                _after(last.get(id(block), block), fig)     # objects after the same paragraph keep their order
+                last[id(block)] = fig
+            elif not found.anchor and context.topics:
+                body = topic_body(context.topics[topic_order(context)[0]])
+                if body is None:
+                    unplaced.append(found.number)
+                    continue
+                body.insert(0, fig)
+            else:
+                unplaced.append(found.number)
+                continue
+            if preview is not None:
+                context.images[image] = preview
+        if native:
+            context.videos[native] = found.data
+            attached += 1
+        placed += 1
+        counts[found.kind] = counts.get(found.kind, 0) + 1
+
+    if report is not None:
+        if placed:
+            kinds = ", ".join(f"{n} {OBJECT_TYPES[kind]}(s)" for kind, n in sorted(counts.items()))
+            report.info("ole_objects", f"{placed} embedded object(s) kept ({kinds}), {attached} attached as "
+                                       "native files", attached=attached, **counts)
+        if macros:
+            report.warning("ole_objects", f"{len(macros)} macro-enabled object(s) were not attached, only their "
+                                          "preview is kept", numbers=macros,
+                           hint="Set ole_objects.allow_macros in conversion.yml to attach them anyway")
+        if linked:
+            report.warning("ole_objects", f"{len(linked)} linked object(s) stay links to the original files",
+                           numbers=linked, hint="Embed the objects in Word to attach them to the package")
+        if failed:
+            report.warning("ole_objects", f"{len(failed)} object(s) had neither a preview image nor a file to "
+                                          "attach", numbers=failed)
+        if unplaced:
+            report.warning("ole_objects", f"{len(unplaced)} object(s) could not be placed: the paragraph before "
+                                          "them was not found in the converted topics", numbers=unplaced,
+                           paragraphs=[o.paragraph for o in objects if o.number in unplaced])
+    logger.info("Word OLE objects: %d object(s) placed", placed)
+    return placed
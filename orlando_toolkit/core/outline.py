@@ -0,0 +1,390 @@
+from __future__ import annotations
+
+"""Outline of the structure for review sign-off, as CSV or Excel, and back.
+
+:func:`outline_rows` lists every map entry in map order with its section
+number (as in the structure tree), level, title, type (``topic`` or
+``section``), topic file, and the words and images of its topic
+(:mod:`orlando_toolkit.core.content_stats`). :func:`write_outline` saves
+them as ``.csv`` (UTF-8 with BOM) or ``.xlsx`` (header row frozen, titles
+indented by level); no spreadsheet library is needed.
+
+A reviewer may edit the Title and Level columns and reorder the rows.
+:func:`read_outline` reads the sheet back and :func:`check_outline`
+matches its rows to the entries of the current map: by topic file for
+topics (a file used once), by number for sections. Every entry must appear
+exactly once, the first row must be at level 1 and a row at most one level
+deeper than the row above it. The structure editing service's
+``apply_outline`` then renames and rebuilds the map from the rows; Words,
+Images and Type are informational and ignored on import.
+"""
+
+import csv
+import io
+import logging
+import posixpath
+import zipfile
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Sequence, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.content_stats import compute_content_stats
+from orlando_toolkit.core.utils import calculate_section_numbers
+from orlando_toolkit.core.xml_security import parse_bytes
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["COLUMNS", "OutlineRow", "check_outline", "entry_title", "outline_csv", "outline_entries", "outline_rows",
+           "outline_xlsx", "read_outline", "write_outline"]
+
+COLUMNS = ("Number", "Level", "Title", "Type", "Topic file", "Words", "Images")
+_FIELDS = {"number": "number", "level": "level", "title": "title", "type": "kind", "topic file": "filename",
+           "words": "words", "images": "images"}
+_MAIN = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+_REL = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+_PKG_REL = "http://schemas.openxmlformats.org/package/2006/relationships"
+_MAX_INDENT = 8
+
+
+@dataclass
+class OutlineRow:
+    """One map entry; :attr:`line` is the row of the sheet it was read from (header is 1)."""
+
+    number: str
+    level: int
+    title: str
+    kind: str = "topic"
+    filename: str = ""
+    words: int = 0
+    images: int = 0
+    line: int = 0
+
+    def values(self) -> List[Any]:
+        return [self.number, self.level, self.title, self.kind, self.filename, self.words, self.images]
+
+
+def entry_title(node: Any, context: Any) -> str:
+    """Title of a map entry: its navtitle, else the title of its topic."""
+    navtitle = node.find("topicmeta/navtitle")
+    if navtitle is not None and "".join(navtitle.itertext()).strip():
+        return " ".join("".join(navtitle.itertext()).split())
+    topic = context.topics.get(_filename(node))
+    title = topic.find("title") if topic is not None else None
+    return " ".join("".join(title.itertext()).split()) if title is not None else ""
+
+
+def _filename(node: Any) -> str:
+    return posixpath.basename((node.get("href") or "").split("#")[0])
+
+
+def outline_entries(root: Any) -> List[Tuple[str, Any]]:
+    """``(section number, topicref or topichead)`` of the map, in map order."""
+    numbers = calculate_section_numbers(root)
+    return [(numbers[el], el) for el in root.iter() if el in numbers]
+
+
+def outline_rows(context: Any) -> List[OutlineRow]:
+    """The rows of the outline of *context*'s current structure."""
+    root = getattr(context, "ditamap_root", None)
+    if root is None:
+        return []
+    stats = compute_content_stats(context)
+    rows = []
+    for number, node in outline_entries(root):
+        filename = _filename(node) if node.tag == "topicref" else ""
+        topic = stats.topic(filename) if filename in context.topics else None
+        rows.append(OutlineRow(number=number, level=number.count(".") + 1, title=entry_title(node, context),
+                               kind="topic" if filename else "section", filename=filename,
+                               words=topic.words if topic else 0, images=topic.images if topic else 0))
+    return rows
+
+
+# ----------------------------------------------------------------------
+# Writing
+# ----------------------------------------------------------------------
+def outline_csv(rows: Sequence[OutlineRow]) -> str:
+    buffer = io.StringIO()
+    writer = csv.writer(buffer, lineterminator="\n")
+    writer.writerow(COLUMNS)
+    for row in rows:
+        writer.writerow(row.values())
+    return buffer.getvalue()
+
+
+def _column(index: int) -> str:
+    letters = ""
+    index += 1
+    while index:
+        index, rest = divmod(index - 1, 26)
+        letters = chr(65 + rest) + letters
+    return letters
+
+
+def _cell(row_el: Any, ref: str, value: Any, style: int = 0) -> None:
+    cell = ET.SubElement(row_el, f"{{{_MAIN}}}c", r=ref)
+    if style:
+        cell.set("s", str(style))
+    if isinstance(value, int):
+        ET.SubElement(cell, f"{{{_MAIN}}}v").text = str(value)
+        return
+    cell.set("t", "inlineStr")
+    text = ET.SubElement(ET.SubElement(cell, f"{{{_MAIN}}}is"), f"{{{_MAIN}}}t")
+    text.text = str(value)
+    if text.text != text.text.strip():
+        text.set("{http://www.w3.org/XML/1998/namespace}space", "preserve")
+
+
+def _sheet(rows: Sequence[OutlineRow]) -> bytes:
+    sheet = ET.Element(f"{{{_MAIN}}}worksheet", nsmap={None: _MAIN})
+    view = ET.SubElement(ET.SubElement(sheet, f"{{{_MAIN}}}sheetViews"), f"{{{_MAIN}}}sheetView", workbookViewId="0")
+    ET.SubElement(view, f"{{{_MAIN}}}pane", ySplit="1", topLeftCell="A2", activePane="bottomLeft", state="frozen")
+    cols = ET.SubElement(sheet, f"{{{_MAIN}}}cols")
+    for index, width in enumerate((10, 7, 60, 9, 40, 9, 9), start=1):
+        ET.SubElement(cols, f"{{{_MAIN}}}col", min=str(index), max=str(index), width=str(width), customWidth="1")
+    data = ET.SubElement(sheet, f"{{{_MAIN}}}sheetData")
+    header = ET.SubElement(data, f"{{{_MAIN}}}row", r="1")
+    for index, name in enumerate(COLUMNS):
+        _cell(header, f"{_column(index)}1", name, style=1)
+    for number, row in enumerate(rows, start=2):
+        row_el = ET.SubElement(data, f"{{{_MAIN}}}row", r=str(number))
+        for index, value in enumerate(row.values()):
+            style = 2 + min(row.level - 1, _MAX_INDENT) if COLUMNS[index] == "Title" else 0
+            _cell(row_el, f"{_column(index)}{number}", value, style)
+    return ET.tostring(sheet, xml_declaration=True, encoding="UTF-8", standalone=True)
+
+
+def _styles() -> bytes:
+    indents = "".join(f'<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0" applyAlignment="1">'
+                      f'<alignment indent="{level}"/></xf>' for level in range(_MAX_INDENT + 1))
+    return (f'<?xml version="1.0" encoding="UTF-8" standalone="yes"?>'
+            f'<styleSheet xmlns="{_MAIN}">'
+            f'<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font>'
+            f'<font><b/><sz val="11"/><name val="Calibri"/></font></fonts>'
+            f'<fills count="2"><fill><patternFill patternType="none"/></fill>'
+            f'<fill><patternFill patternType="gray125"/></fill></fills>'
+            f'<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>'
+            f'<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>'
+            f'<cellXfs count="{_MAX_INDENT + 3}"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>'
+            f'<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>{indents}</cellXfs>'
+            f'</styleSheet>').encode("utf-8")
+
+
+def outline_xlsx(rows: Sequence[OutlineRow]) -> bytes:
+    """An Excel workbook with one ``Outline`` sheet holding *rows*."""
+    parts = {
+        "[Content_Types].xml": (
+            '<?xml version="1.0" encoding="UTF-8" standalone="yes"?>'
+            '<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">'
+            '<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>'
+            '<Default Extension="xml" ContentType="application/xml"/>'
+            '<Override PartName="/xl/workbook.xml" '
+            'ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>'
+            '<Override PartName="/xl/worksheets/sheet1.xml" '
+            'ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>'
+            '<Override PartName="/xl/styles.xml" '
+            'ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>'),
+        "_rels/.rels": (
+            f'<?xml version="1.0" encoding="UTF-8" standalone="yes"?><Relationships xmlns="{_PKG_REL}">'
+            f'<Relationship Id="rId1" Type="{_REL}/officeDocument" Target="xl/workbook.xml"/></Relationships>'),
+        "xl/workbook.xml": (
+            f'<?xml version="1.0" encoding="UTF-8" standalone="yes"?>'
+            f'<workbook xmlns="{_MAIN}" xmlns:r="{_REL}"><sheets>'
+            f'<sheet name="Outline" sheetId="1" r:id="rId1"/></sheets></workbook>'),
+        "xl/_rels/workbook.xml.rels": (
+            f'<?xml version="1.0" encoding="UTF-8" standalone="yes"?><Relationships xmlns="{_PKG_REL}">'
+            f'<Relationship Id="rId1" Type="{_REL}/worksheet" Target="worksheets/sheet1.xml"/>'
+            f'<Relationship Id="rId2" Type="{_REL}/styles" Target="styles.xml"/></Relationships>'),
+    }
+    buffer = io.BytesIO()
+    with zipfile.ZipFile(buffer, "w", zipfile.ZIP_DEFLATED) as archive:
+        for name, text in parts.items():
+            archive.writestr(name, text.encode("utf-8"))
+        archive.writestr("xl/styles.xml", _styles())
+        archive.writestr("xl/worksheets/sheet1.xml", _sheet(rows))
+    return buffer.getvalue()
+
+
+def write_outline(rows: Sequence[OutlineRow], path: str | Path) -> Path:
+    """Write *rows* to *path*, as Excel when it ends with ``.xlsx``, else as CSV."""
+    path = Path(path)
+    if path.suffix.lower() == ".xlsx":
+        path.write_bytes(outline_xlsx(rows))
+    else:
+        path.write_text(outline_csv(rows), encoding="utf-8-sig")
+    return path
+
+
+# ----------------------------------------------------------------------
+# Reading
+# ----------------------------------------------------------------------
+def _part(archive: zipfile.ZipFile, name: str) -> Any:
+    return parse_bytes(archive.read(name), source=name)
+
+
+def _targets(archive: zipfile.ZipFile, rels_name: str, base: str) -> Dict[str, Tuple[str, str]]:
+    """Relationship id -> (type, part name) of the rels part *rels_name*."""
+    try:
+        rels = _part(archive, rels_name)
+    except KeyError:
+        return {}
+    targets = {}
+    for rel in rels.iter("{*}Relationship"):
+        target = rel.get("Target") or ""
+        target = target.lstrip("/") if target.startswith("/") else posixpath.normpath(posixpath.join(base, target))
+        targets[rel.get("Id") or ""] = (rel.get("Type") or "", target)
+    return targets
+
+
+def _xlsx_table(data: bytes) -> List[List[str]]:
+    """Cell texts of the first sheet of an Excel workbook, row by row."""
+    try:
+        archive = zipfile.ZipFile(io.BytesIO(data))
+    except zipfile.BadZipFile as exc:
+        raise ValueError(f"Not an Excel workbook: {exc}") from exc
+    with archive:
+        targets = _targets(archive, "xl/_rels/workbook.xml.rels", "xl")
+        workbook = _part(archive, "xl/workbook.xml")
+        sheet = next(workbook.iter("{*}sheet"), None)
+        if sheet is None:
+            raise ValueError("The workbook has no sheet")
+        rel_id = next((value for key, value in sheet.attrib.items() if key.endswith("}id")), "")
+        sheet_part = targets.get(rel_id, ("", "xl/worksheets/sheet1.xml"))[1]
+        shared: List[str] = []
+        for kind, target in targets.values():
+            if kind.endswith("/sharedStrings"):
+                shared = ["".join(t.text or "" for t in item.iter("{*}t"))
+                          for item in _part(archive, target).iter("{*}si")]
+        table: List[List[str]] = []
+        for row in _part(archive, sheet_part).iter("{*}row"):
+            values: Dict[int, str] = {}
+            for position, cell in enumerate(row.iter("{*}c")):
+                ref = "".join(ch for ch in cell.get("r") or "" if ch.isalpha())
+                column = position
+                if ref:
+                    column = 0
+                    for ch in ref.upper():
+                        column = column * 26 + ord(ch) - 64
+                    column -= 1
+                kind = cell.get("t")
+                if kind == "inlineStr":
+                    text = "".join(t.text or "" for t in cell.iter("{*}t"))
+                else:
+                    text = cell.findtext("{*}v") or ""
+                    if kind == "s" and text.strip().isdigit() and int(text) < len(shared):
+                        text = shared[int(text)]
+                values[column] = text
+            line = int(row.get("r") or len(table) + 1)
+            while len(table) < line - 1:
+                table.append([])
+            table.append([values.get(i, "") for i in range(max(values) + 1)] if values else [])
+        return table
+
+
+def _csv_table(data: bytes) -> List[List[str]]:
+    try:
+        text = data.decode("utf-8-sig")
+    except UnicodeDecodeError:
+        text = data.decode("cp1252", errors="replace")
+    try:
+        dialect = csv.Sniffer().sniff(text[:4096], delimiters=",;\t")
+    except csv.Error:
+        dialect = csv.excel
+    return [list(row) for row in csv.reader(io.StringIO(text), dialect)]
+
+
+def _int(value: str) -> int:
+    return int(float(value.strip()))
+
+
+def read_outline(path: str | Path) -> List[OutlineRow]:
+    """Rows of an outline sheet (``.xlsx`` or CSV) edited by a reviewer.
+
+    Raises:
+        ValueError: The file is not a readable outline (no Number or Title
+            column, a level that is not a number)
+    """
+    path = Path(path)
+    data = path.read_bytes()
+    table = _xlsx_table(data) if path.suffix.lower() == ".xlsx" or data[:2] == b"PK" else _csv_table(data)
+    header_line = next((i for i, cells in enumerate(table) if any(c.strip() for c in cells)), None)
+    if header_line is None:
+        raise ValueError(f"{path.name} is empty")
+    fields = {_FIELDS.get(" ".join(name.split()).lower()): index for index, name in enumerate(table[header_line])}
+    missing = [name for name in ("number", "title") if name not in fields]
+    if missing:
+        raise ValueError(f"{path.name} has no {' or '.join(n.capitalize() for n in missing)} column; "
+                         f"expected the columns {', '.join(COLUMNS)}")
+
+    def _get(cells: List[str], name: str) -> str:
+        index = fields.get(name)
+        return " ".join(str(cells[index]).split()) if index is not None and index < len(cells) else ""
+
+    rows: List[OutlineRow] = []
+    for line, cells in enumerate(table[header_line + 1:], start=header_line + 2):
+        if not any(str(c).strip() for c in cells):
+            continue
+        number = _get(cells, "number")
+        level = _get(cells, "level")
+        try:
+            row = OutlineRow(number=number, level=_int(level) if level else number.count(".") + 1,
+                             title=_get(cells, "title"), kind=_get(cells, "kind") or "topic",
+                             filename=_get(cells, "filename"), line=line)
+        except ValueError:
+            raise ValueError(f"Row {line}: level {level!r} is not a number") from None
+        rows.append(row)
+    return rows
+
+
+def check_outline(context: Any, rows: Sequence[OutlineRow]) -> Tuple[List[Tuple[OutlineRow, Any]], List[str]]:
+    """Pair *rows* with the map entries of *context*; returns the pairs and the problems found.
+
+    Nothing may be applied when problems are returned.
+    """
+    root = getattr(context, "ditamap_root", None)
+    if root is None:
+        return [], ["No map is loaded"]
+    entries = outline_entries(root)
+    by_number = {number: node for number, node in entries}
+    files: Dict[str, List[Any]] = {}
+    for _number, node in entries:
+        if node.tag == "topicref" and _filename(node):
+            files.setdefault(_filename(node), []).append(node)
+
+    pairs: List[Tuple[OutlineRow, Any]] = []
+    problems: List[str] = []
+    seen: Dict[int, int] = {}
+    previous: Optional[int] = None
+    for row in rows:
+        node: Optional[Any] = None
+        if row.filename and len(files.get(row.filename, ())) == 1:
+            node = files[row.filename][0]
+        elif row.number in by_number:
+            node = by_number[row.number]
+            if row.filename and _filename(node) != row.filename:
+                problems.append(f"Row {row.line}: {row.number} is {_filename(node) or 'a section'} in the map, "
+                                f"not {row.filename}")
+                node = None
+        else:
+            problems.append(f"Row {row.line}: no entry {row.filename or row.number} in the map")
+        if node is not None:
+            if id(node) in seen:
+                problems.append(f"Row {row.line}: {row.filename or row.number} is listed twice "
+                                f"(row {seen[id(node)]})")
+            else:
+                seen[id(node)] = row.line
+                pairs.append((row, node))
+        if not row.title:
+            problems.append(f"Row {row.line}: the title is empty")
+        limit = 1 if previous is None else previous + 1
+        if row.level < 1 or row.level > limit:
+            problems.append(f"Row {row.line}: level {row.level} must be between 1 and {limit}")
+        previous = row.level
+    left_out = [number for number, node in entries if id(node) not in seen]
+    if left_out:
+        shown = ", ".join(left_out[:10]) + (f", … {len(left_out) - 10} more" if len(left_out) > 10 else "")
+        problems.append(f"{len(left_out)} entr{'y is' if len(left_out) == 1 else 'ies are'} missing from the "
+                        f"sheet: {shown}. Rows cannot be deleted; export the outline again if the "
+                        f"structure changed")
+    return pairs, problems
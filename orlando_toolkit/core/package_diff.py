@@ -0,0 +1,271 @@
+from __future__ import annotations
+
+"""Delivery note between two generated packages.
+
+:mod:`orlando_toolkit.core.compare` compares topic content;
+:func:`compare_package_contexts` adds what a re-delivery must also document:
+
+- map structure: entries (topics and topic heads, by title path) added,
+  removed, moved under another parent or reordered among their siblings;
+- images: files added, removed, changed (same name, other bytes) or renamed
+  (same bytes, other name).
+
+:func:`compare_packages` imports two archives (or ``.ditamap`` files) and
+:func:`compare_session` compares the current session, prepared as it would
+be packaged, with an archive delivered earlier. The :class:`PackageDiff`
+renders as HTML (:meth:`~PackageDiff.to_html`) or as CSV rows
+(:meth:`~PackageDiff.to_csv`) for a spreadsheet.
+"""
+
+import csv
+import hashlib
+import html
+import io
+import json
+import logging
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional, Tuple
+
+from orlando_toolkit.core.compare import ChangeReport, compare_contexts
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.revisions import block_text
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["ImageChange", "PackageDiff", "StructureChange", "compare_package_contexts", "compare_packages",
+           "compare_session"]
+
+CSV_COLUMNS = ("area", "status", "item", "detail")
+
+
+@dataclass
+class StructureChange:
+    status: str                      # added | removed | moved | reordered
+    path: str
+    detail: str = ""
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {"status": self.status, "path": self.path, "detail": self.detail}
+
+
+@dataclass
+class ImageChange:
+    status: str                      # added | removed | changed | renamed
+    name: str
+    old_name: str = ""
+
+    def to_dict(self) -> Dict[str, Any]:
+        data = {"status": self.status, "name": self.name}
+        if self.old_name:
+            data["old_name"] = self.old_name
+        return data
+
+
+@dataclass
+class PackageDiff:
+    content: ChangeReport = field(default_factory=ChangeReport)
+    structure: List[StructureChange] = field(default_factory=list)
+    images: List[ImageChange] = field(default_factory=list)
+
+    @property
+    def old_source(self) -> str:
+        return self.content.old_source
+
+    @property
+    def new_source(self) -> str:
+        return self.content.new_source
+
+    def is_empty(self) -> bool:
+        return not (self.content.topics or self.structure or self.images)
+
+    def summary(self) -> str:
+        return (f"{self.content.summary()}; {len(self.structure)} structure change(s), "
+                f"{len(self.images)} image change(s)")
+
+    def rows(self) -> List[Tuple[str, str, str, str]]:
+        """``(area, status, item, detail)`` per change, structure first."""
+        rows: List[Tuple[str, str, str, str]] = []
+        for change in self.structure:
+            rows.append(("structure", change.status, change.path, change.detail))
+        for topic in self.content.topics:
+            detail = f"{len(topic.blocks)} block(s)" if topic.status == "changed" else ""
+            if topic.title_changed:
+                detail = f"title: {topic.title_changed[0]} -> {topic.title_changed[1]}" + (
+                    f"; {detail}" if topic.blocks else "")
+            rows.append(("topic", topic.status, topic.path, detail))
+        for image in self.images:
+            rows.append(("image", image.status, image.name, f"was {image.old_name}" if image.old_name else ""))
+        return rows
+
+    def to_csv(self) -> str:
+        buffer = io.StringIO()
+        writer = csv.writer(buffer, lineterminator="\n")
+        writer.writerow(CSV_COLUMNS)
+        writer.writerows(self.rows())
+        return buffer.getvalue()
+
+    def to_dict(self) -> Dict[str, Any]:
+        data = self.content.to_dict()
+        data["structure"] = [c.to_dict() for c in self.structure]
+        data["images"] = [c.to_dict() for c in self.images]
+        return data
+
+    def to_json(self, **kwargs: Any) -> str:
+        kwargs.setdefault("ensure_ascii", False)
+        kwargs.setdefault("indent", 2)
+        return json.dumps(self.to_dict(), **kwargs)
+
+    def to_html(self) -> str:
+        """The content report page with structure and image tables before the topics."""
+        esc = html.escape
+        page = self.content.to_html().replace("<title>Change report</title>", "<title>Delivery note</title>", 1)
+        page = page.replace("<h1>Change report</h1>", "<h1>Delivery note</h1>", 1)
+        parts = ["<style>table{border-collapse:collapse;margin-bottom:1.5em}"
+                 "td,th{border:1px solid #ccc;padding:2px 8px;text-align:left}</style>",
+                 f"<p>{esc(self.summary())}</p>"]
+        for heading, items in (("Structure", [(c.status, c.path, c.detail) for c in self.structure]),
+                               ("Images", [(c.status, c.name, c.old_name and f"was {c.old_name}")
+                                           for c in self.images])):
+            if not items:
+                continue
+            parts.append(f"<h2>{heading}</h2><table><tr><th>Change</th><th>Item</th><th>Detail</th></tr>")
+            parts.extend(f"<tr class='{esc(status)}'><td>{esc(status)}</td><td>{esc(item)}</td>"
+                         f"<td>{esc(detail or '')}</td></tr>" for status, item, detail in items)
+            parts.append("</table>")
+        if self.content.topics:
+            parts.append("<h2>Topics</h2>")
+        marker = f"<p>{esc(self.content.summary())}</p>"
+        return page.replace(marker, "\n".join(parts), 1)
+
+
+def _entry_title(context: DitaContext, ref) -> str:
+    href = ref.get("href")
+    topic = context.topics.get(href.split("/")[-1]) if href else None
+    title = topic.find("title") if topic is not None else None
+    if title is not None:
+        return block_text(title)
+    nav = ref.find("topicmeta/navtitle")
+    return (block_text(nav) if nav is not None else "") or ref.get("navtitle") or (href or "")
+
+
+def _map_entries(context: DitaContext) -> Dict[str, Tuple[str, str, int]]:
+    """``path -> (title, parent path, sibling index)`` for each map entry."""
+    entries: Dict[str, Tuple[str, str, int]] = {}
+    if context.ditamap_root is None:
+        return entries
+
+    def _walk(parent, parent_path: str) -> None:
+        index = 0
+        for ref in parent:
+            if not isinstance(ref.tag, str) or ref.tag not in ("topicref", "topichead"):
+                continue
+            title = _entry_title(context, ref)
+            path = f"{parent_path}/{title}" if parent_path else title
+            # Same title twice under one parent: keep both, told apart by position
+            key = path if path not in entries else f"{path} #{index + 1}"
+            entries[key] = (title, parent_path, index)
+            index += 1
+            _walk(ref, key)
+
+    _walk(context.ditamap_root, "")
+    return entries
+
+
+def compare_structure(old: DitaContext, new: DitaContext) -> List[StructureChange]:
+    """Map entries added, removed, moved or reordered from *old* to *new*."""
+    old_entries, new_entries = _map_entries(old), _map_entries(new)
+    old_by_title: Dict[str, List[str]] = {}
+    for path, (title, _, _) in old_entries.items():
+        if path not in new_entries:
+            old_by_title.setdefault(title, []).append(path)
+    changes: List[StructureChange] = []
+    moved_from: set = set()
+    for path, (title, parent, index) in new_entries.items():
+        if path in old_entries:
+            if index != old_entries[path][2]:
+                changes.append(StructureChange("reordered", path,
+                                               f"position {old_entries[path][2] + 1} -> {index + 1}"))
+            continue
+        candidates = [p for p in old_by_title.get(title, ()) if p not in moved_from]
+        if len(candidates) == 1:
+            moved_from.add(candidates[0])
+            old_parent = old_entries[candidates[0]][1]
+            changes.append(StructureChange("moved", path, f"from {old_parent or '(map root)'}"))
+        else:
+            changes.append(StructureChange("added", path))
+    for path in old_entries:
+        if path not in new_entries and path not in moved_from:
+            changes.append(StructureChange("removed", path))
+    # Entries below a moved or removed parent follow it and are not listed twice
+    prefixes = [c.path + "/" for c in changes if c.status in ("added", "moved")]
+    removed = [c.path + "/" for c in changes if c.status == "removed"]
+    return [c for c in changes
+            if not (c.status in ("added", "moved") and any(c.path.startswith(p) for p in prefixes))
+            and not (c.status == "removed" and any(c.path.startswith(p) for p in removed))]
+
+
+def _digests(images: Mapping[str, bytes]) -> Dict[str, str]:
+    return {name: hashlib.sha256(images[name]).hexdigest() for name in images}
+
+
+def compare_images(old: Mapping[str, bytes], new: Mapping[str, bytes]) -> List[ImageChange]:
+    """Image files added, removed, changed or renamed from *old* to *new*."""
+    old_digests, new_digests = _digests(old), _digests(new)
+    changes: List[ImageChange] = []
+    removed = [n for n in old_digests if n not in new_digests]
+    by_digest: Dict[str, List[str]] = {}
+    for name in removed:
+        by_digest.setdefault(old_digests[name], []).append(name)
+    for name in sorted(new_digests):
+        if name in old_digests:
+            if old_digests[name] != new_digests[name]:
+                changes.append(ImageChange("changed", name))
+            continue
+        former = by_digest.get(new_digests[name])
+        if former:
+            changes.append(ImageChange("renamed", name, former.pop(0)))
+        else:
+            changes.append(ImageChange("added", name))
+    renamed = {c.old_name for c in changes if c.status == "renamed"}
+    changes.extend(ImageChange("removed", name) for name in sorted(removed) if name not in renamed)
+    return changes
+
+
+def compare_package_contexts(old: DitaContext, new: DitaContext, *, old_source: str = "",
+                             new_source: str = "") -> PackageDiff:
+    """Content, structure and image changes from *old* to *new*."""
+    return PackageDiff(
+        content=compare_contexts(old, new, old_source=old_source, new_source=new_source),
+        structure=compare_structure(old, new),
+        images=compare_images(old.images, new.images),
+    )
+
+
+def _import(path: str | Path, service: Any) -> DitaContext:
+    if service is None:
+        from orlando_toolkit.core.importers import DitaPackageImporter
+
+        return DitaPackageImporter().import_package(Path(path))
+    return service.convert(path, {})
+
+
+def compare_packages(old_path: str | Path, new_path: str | Path, *, service: Any = None) -> PackageDiff:
+    """Import two packages (``.zip`` or ``.ditamap``) and compare them."""
+    old, new = _import(old_path, service), _import(new_path, service)
+    return compare_package_contexts(old, new, old_source=Path(old_path).name, new_source=Path(new_path).name)
+
+
+def compare_session(package_path: str | Path, context: DitaContext, *, service: Any = None,
+                    session_name: str = "current session") -> PackageDiff:
+    """Compare a delivered package with *context* as it would be packaged now.
+
+    *context* should be a copy: it is renamed in place like an export.
+    """
+    if service is None:
+        from orlando_toolkit.core.services import ConversionService
+
+        service = ConversionService()
+    prepared = service.prepare_package(context)
+    old = _import(package_path, None)
+    return compare_package_contexts(old, prepared, old_source=Path(package_path).name, new_source=session_name)
@@ -0,0 +1,149 @@
+from __future__ import annotations
+
+"""Exploded package output for version control.
+
+Besides the ZIP, a package can be written as a plain directory tree meant
+to be committed to git and diffed between releases:
+
+- ``maps/`` holds the main map, the key definition map and the DITAVAL
+  filters; map references are rewritten to ``../topics/``, ``../media/``
+  and ``../resources/``;
+- ``topics/`` holds the topics, indented one element per line (the archive
+  writes them minified) with attributes in name order; mixed content
+  (a ``<p>`` with inline markup) and ``codeblock``/``pre`` are written as
+  they are, so whitespace that renders is never added or removed;
+- ``media/`` holds images and videos byte for byte, ``resources/`` the
+  attachments.
+
+Files are only rewritten when their content changes, so unchanged topics
+keep their timestamps. Files under these folders that the new package
+no longer contains (a topic that was merged, an image that was replaced)
+are removed, as are folders left empty; anything else in the target folder
+(``.git``, a README) is left alone.
+
+A folder written by :func:`write_package_tree` carries a ``.orlando-tree``
+marker and is updated in place. Any other non-empty folder is refused with
+:class:`PackageTreeError` unless *force* is given, in which case these
+folders are replaced as above.
+"""
+
+import logging
+import os
+import shutil
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import List, Set
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.errors import ToolkitError
+from orlando_toolkit.core.reproducible import normalize_attributes
+from orlando_toolkit.core.topic_source import indent_element
+from orlando_toolkit.core.xml_security import parse_file
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["MARKER", "PackageTreeError", "TreeWriteResult", "TREE_FOLDERS", "write_package_tree"]
+
+MARKER = ".orlando-tree"
+TREE_FOLDERS = ("maps", "topics", "media", "resources")
+_MAP_SUFFIXES = (".ditamap", ".ditaval")
+
+
+class PackageTreeError(ToolkitError):
+    """Target folder refused (not an earlier package tree and not forced)."""
+
+    code = "OTK601"
+
+
+@dataclass
+class TreeWriteResult:
+    target: Path
+    written: List[str] = field(default_factory=list)
+    unchanged: List[str] = field(default_factory=list)
+    removed: List[str] = field(default_factory=list)
+
+    def summary(self) -> str:
+        return (f"{len(self.written)} file(s) written, {len(self.unchanged)} unchanged, "
+                f"{len(self.removed)} removed")
+
+
+def _format(path: Path, *, map_file: bool) -> bytes:
+    root = parse_file(path)
+    if map_file:
+        for el in root.iter():
+            if not isinstance(el.tag, str):
+                continue
+            href = el.get("href")
+            if (href and el.get("scope") != "external" and "://" not in href and not href.startswith(("/", "#", "../"))
+                    and not href.split("#")[0].lower().endswith(_MAP_SUFFIXES)):
+                el.set("href", "../" + href)
+    normalize_attributes(root)
+    indent_element(root)
+    doctype = root.getroottree().docinfo.doctype or None
+    data = ET.tostring(root, xml_declaration=True, encoding="UTF-8", doctype=doctype) + b"\n"
+    return data.replace(b"\r\n", b"\n")
+
+
+def _layout(package_dir: Path) -> dict:
+    """``relative target path -> (source file, kind)`` for a saved package folder."""
+    data_dir = package_dir / "DATA" if (package_dir / "DATA").is_dir() else package_dir
+    files = {}
+    for path in sorted(p for p in data_dir.rglob("*") if p.is_file()):
+        rel = path.relative_to(data_dir)
+        if rel.parts[0] in ("topics", "media", "resources"):
+            kind = "topic" if path.suffix.lower() == ".dita" else "copy"
+            files[rel.as_posix()] = (path, kind)
+        else:
+            files[f"maps/{rel.as_posix()}"] = (path, "map" if path.suffix.lower() == ".ditamap" else "copy")
+    return files
+
+
+def _existing(target: Path) -> Set[str]:
+    found: Set[str] = set()
+    for folder in TREE_FOLDERS:
+        base = target / folder
+        if base.is_dir():
+            found.update(p.relative_to(target).as_posix() for p in base.rglob("*") if p.is_file())
+    return found
+
+
+def write_package_tree(package_dir: str | Path, target: str | Path, *, force: bool = False,
+                       prune: bool = True) -> TreeWriteResult:
+    """Lay out the package folder *package_dir* (as written by ``save_dita_package``) under *target*.
+
+    With *prune*, files of an earlier tree missing from this package are deleted.
+    """
+    package_dir, target = Path(package_dir), Path(target)
+    if target.exists() and not target.is_dir():
+        raise PackageTreeError(f"{target} is a file, not a folder", hint="Choose a folder for the package tree.")
+    if target.is_dir() and any(target.iterdir()) and not (target / MARKER).exists():
+        if not force:
+            raise PackageTreeError(f"{target} is not empty and was not written by Orlando Toolkit")
+        logger.warning("Replacing package folders in %s (forced)", target)
+
+    target.mkdir(parents=True, exist_ok=True)
+    result = TreeWriteResult(target=target)
+    layout = _layout(package_dir)
+    for rel, (source, kind) in layout.items():
+        data = _format(source, map_file=kind == "map") if kind in ("map", "topic") else source.read_bytes()
+        dest = target / rel
+        if dest.is_file() and dest.read_bytes() == data:
+            result.unchanged.append(rel)
+            continue
+        dest.parent.mkdir(parents=True, exist_ok=True)
+        dest.write_bytes(data)
+        result.written.append(rel)
+
+    if prune:
+        for rel in sorted(_existing(target) - set(layout)):
+            (target / rel).unlink()
+            result.removed.append(rel)
+        for folder in TREE_FOLDERS:
+            for dirpath, _dirnames, _files in sorted(os.walk(target / folder), reverse=True):
+                if Path(dirpath) != target / folder and not os.listdir(dirpath):
+                    shutil.rmtree(dirpath)
+    (target / MARKER).write_text("Written by Orlando Toolkit; maps/, topics/, media/ and resources/ are regenerated.\n",
+                                 encoding="utf-8")
+    logger.info("Package tree %s: %s", target, result.summary())
+    return result
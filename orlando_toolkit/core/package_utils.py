@@ -13,14 +13,24 @@ the DOCX-specific converter module but are needed for all plugin conversions.
 from __future__ import annotations
 
 import os
+import shutil
 import uuid
 import logging
+import zipfile
 from pathlib import Path
-from typing import Dict, Any
+from typing import Callable, Dict, Any, List, Optional
 
 from orlando_toolkit.core.models import DitaContext
-from orlando_toolkit.core.utils import save_xml_file, save_minified_xml_file, slugify
-from orlando_toolkit.config import ConfigManager
+from orlando_toolkit.core.bookmap import is_bookmap, to_bookmap
+from orlando_toolkit.core.cancellation import CancellationToken, check_cancelled
+from orlando_toolkit.core.concurrency import PipelineSettings, ordered_map, progress_steps
+from orlando_toolkit.core import dialects, performance
+from orlando_toolkit.core.escaping import EscapingPolicy
+from orlando_toolkit.core.i18n import XML_LANG, canonical_language_tag
+from orlando_toolkit.core.reproducible import normalize_attributes, package_date, reproducible_options
+from orlando_toolkit.core.spool import rename_blobs, write_blob
+from orlando_toolkit.core.stable_ids import ANCHOR_HINT, PreviousConversion, fingerprint, stable_hash
+from orlando_toolkit.core.utils import save_xml_file, save_minified_xml_file, slugify, topic_body
 from lxml import etree as ET
 from datetime import datetime, timezone
 
@@ -28,9 +38,12 @@ logger = logging.getLogger(__name__)
 
 __all__ = [
     "save_dita_package",
+    "write_zip_archive",
     "update_image_references_and_names", 
     "update_topic_references_and_names",
+    "deduplicate_element_ids",
     "prune_empty_topics",
+    "ID_STRATEGIES",
 ]
 
 
@@ -52,9 +65,17 @@ def _ensure_map_metadata(context: DitaContext) -> None:
     # Resolve title and short name; ensure metadata has values for downstream consumers
     # Fallback to unique title when missing to avoid SaaS import rejection
     from datetime import datetime
+    reproducible = reproducible_options(context.metadata)
+    if reproducible.get("enabled"):
+        today = package_date(reproducible)
+    else:
+        today = datetime.now(timezone.utc).strftime("%Y-%m-%d")
     title_text = (context.metadata.get("manual_title") or context.metadata.get("title") or "")
     if not str(title_text).strip():
-        title_text = f"MANUAL_{datetime.utcnow().strftime('%Y%m%d-%H%M%S')}"
+        if reproducible.get("enabled"):
+            title_text = f"MANUAL_{today.replace('-', '')}"
+        else:
+            title_text = f"MANUAL_{datetime.utcnow().strftime('%Y%m%d-%H%M%S')}"
     short_name = None
     try:
         raw_short = context.metadata.get("manual_code")
@@ -67,10 +88,10 @@ def _ensure_map_metadata(context: DitaContext) -> None:
     except Exception:
         pass
 
-    # Ensure xml:lang
+    # Ensure xml:lang (normally already set by the language processing stage)
     try:
-        if not root.get("xml:lang"):
-            root.set("xml:lang", "en-US")
+        if not root.get(XML_LANG):
+            root.set(XML_LANG, canonical_language_tag(context.metadata.get("language")) or "en-US")
     except Exception:
         pass
 
@@ -141,12 +162,12 @@ def _ensure_map_metadata(context: DitaContext) -> None:
             if created is None:
                 created = ET.SubElement(crit, "created")
             if not created.get("date"):
-                created.set("date", datetime.now(timezone.utc).strftime("%Y-%m-%d"))
+                created.set("date", today)
             revised = crit.find("revised")
             if revised is None:
                 revised = ET.SubElement(crit, "revised")
             if not revised.get("modified"):
-                revised.set("modified", context.metadata.get("revision_date") or datetime.now(timezone.utc).strftime("%Y-%m-%d"))
+                revised.set("modified", context.metadata.get("revision_date") or today)
             # Reposition critdates to index 0
             try:
                 if crit.getparent() is not None:
@@ -210,17 +231,25 @@ def _ensure_map_metadata(context: DitaContext) -> None:
             if created is None:
                 created = ET.SubElement(crit, "created")
             if not created.get("date"):
-                created.set("date", datetime.now(timezone.utc).strftime("%Y-%m-%d"))
+                created.set("date", today)
             # revised@modified
             revised = crit.find("revised")
             if revised is None:
                 revised = ET.SubElement(crit, "revised")
             if not revised.get("modified"):
-                revised.set("modified", context.metadata.get("revision_date") or datetime.now(timezone.utc).strftime("%Y-%m-%d"))
+                revised.set("modified", context.metadata.get("revision_date") or today)
         except Exception:
             pass
 
-def save_dita_package(context: DitaContext, output_dir: str) -> None:
+# Rough in-memory cost of serialising one element, used for budget accounting
+_SERIALIZED_BYTES_PER_ELEMENT = 256
+
+
+def save_dita_package(context: DitaContext, output_dir: str, *,
+                      cancel_token: Optional[CancellationToken] = None,
+                      settings: Optional[PipelineSettings] = None,
+                      escaping: Optional[EscapingPolicy] = None,
+                      progress_callback: Optional[Callable[[str], None]] = None) -> None:
     """Write the DITA package folder structure to *output_dir*.
 
     Creates the standard DITA package structure:
@@ -231,7 +260,22 @@ def save_dita_package(context: DitaContext, output_dir: str) -> None:
     Args:
         context: DitaContext containing the DITA content to save
         output_dir: Directory path where the package should be written
+        cancel_token: Optional token checked before each file is written;
+            callers own *output_dir* cleanup on cancellation
+        settings: Worker/memory settings; resolved from configuration and
+            ``context.metadata["pipeline"]`` when omitted
+        escaping: Character escaping policy; resolved from the ``serialization``
+            section of ``conversion.yml`` and ``context.metadata["conversion_options"]``
+            when omitted
+        progress_callback: Optional callback receiving ``write`` phase
+            progress events while topics and media are written
     """
+    if settings is None:
+        settings = PipelineSettings.resolve(context.metadata)
+    if escaping is None:
+        from orlando_toolkit.core.processing import resolve_conversion_options
+        escaping = EscapingPolicy.from_mapping(resolve_conversion_options(context.metadata).get("serialization"))
+    budget = settings.budget()
     output_dir = str(output_dir)
     data_dir = os.path.join(output_dir, "DATA")
     topics_dir = os.path.join(data_dir, "topics")
@@ -253,35 +297,123 @@ def save_dita_package(context: DitaContext, output_dir: str) -> None:
 
     manual_code = context.metadata.get("manual_code")
     ditamap_path = os.path.join(data_dir, f"{manual_code}.ditamap")
+
+    # DITA 1.3, 2.0 or XDITA (core.dialects); the context itself stays DITA 1.3
+    dialect = dialects.output_dialect(context.metadata)
+    changes: List[str] = []
+
+    # Key definitions in a map of their own, referenced from the main map (core.keys)
+    from orlando_toolkit.core.keys import write_keydef_map
+    write_keydef_map(context, data_dir, escaping=escaping, dialect=dialect)
     
     # Save ditamap with SaaS-compatible DOCTYPE path (matches reference)
-    doctype_str = '<!DOCTYPE map PUBLIC "-//OASIS//DTD DITA Map//EN" "./dtd/technicalContent/dtd/map.dtd">'
-    save_xml_file(context.ditamap_root, ditamap_path, doctype_str)
-
-    # Save topics with proper DOCTYPE
-    doctype_concept = '<!DOCTYPE concept PUBLIC "-//OASIS//DTD DITA Concept//EN" "concept.dtd">'
-    for filename, topic_el in context.topics.items():
-        save_minified_xml_file(topic_el, os.path.join(topics_dir, filename), doctype_concept)
+    map_root = context.ditamap_root
+    bookmap = is_bookmap(context.metadata) and dialect != "xdita"
+    if bookmap:
+        from orlando_toolkit.core.processing import resolve_conversion_options
+        book = resolve_conversion_options(context.metadata).get("bookmap") or {}
+        index = book.get("index", "auto")
+        if index == "auto":
+            index = any(True for topic in context.topics.values() for _ in topic.iter("indexterm"))
+        map_root = to_bookmap(map_root, context.metadata, toc=bool(book.get("toc", True)), index=bool(index))
+    doctype_str = dialects.map_doctype(dialect, bookmap=bookmap)
+    map_root = dialects.convert_map(map_root, dialect, changes)
+    topics = {name: dialects.convert_topic(topic, dialect, changes) for name, topic in context.topics.items()}
+    if changes:
+        logger.info("Package written as %s: %s", dialects.DIALECT_LABELS[dialect], "; ".join(changes))
+    # Reproducible output: attribute order must not depend on how the tree was built
+    if reproducible_options(context.metadata).get("enabled"):
+        for root in [map_root, *topics.values()]:
+            normalize_attributes(root)
+    save_xml_file(map_root, ditamap_path, doctype_str, escaping=escaping)
+
+    # Save topics with the DOCTYPE of their topic type
+    ordered_map(
+        lambda item: save_minified_xml_file(item[1], os.path.join(topics_dir, item[0]),
+                                            dialects.topic_doctype(item[1], dialect), escaping=escaping),
+        topics.items(),
+        workers=settings.serializer_workers,
+        cancel_token=cancel_token,
+        budget=budget,
+        size_of=lambda item: sum(1 for _ in item[1].iter()) * _SERIALIZED_BYTES_PER_ELEMENT,
+        progress=progress_steps(progress_callback, "Writing topics", phase="write"),
+    )
 
     # Save images
-    for filename, blob in context.images.items():
-        Path(os.path.join(media_dir, filename)).write_bytes(blob)
+    with performance.phase("images"):
+        ordered_map(
+            lambda name: write_blob(context.images, name, os.path.join(media_dir, name)),
+            list(context.images),
+            workers=settings.media_workers,
+            cancel_token=cancel_token,
+            progress=progress_steps(progress_callback, "Writing images", phase="write"),
+        )
+
+        # Save videos (ensure video media are included in the package)
+        videos = getattr(context, 'videos', {})
+
+        def _write_video(filename: str) -> None:
+            try:
+                write_blob(videos, filename, os.path.join(media_dir, filename))
+            except Exception as exc:
+                logger.error("Failed to write video media %s: %s", filename, exc)
 
-    # Save videos (ensure video media are included in the package)
-    try:
-        for filename, blob in getattr(context, 'videos', {}).items():
-            Path(os.path.join(media_dir, filename)).write_bytes(blob)
-    except Exception as exc:
-        logger.error("Failed to write video media: %s", exc)
+        ordered_map(_write_video, list(videos),
+                    workers=settings.media_workers, cancel_token=cancel_token)
+
+    # Attached PDFs, spreadsheets… referenced by resource-only entries (core.attachments)
+    from orlando_toolkit.core.attachments import write_attachments
+    write_attachments(context, data_dir)
+
+    # Change-bar filter for content marked by core.revisions
+    from orlando_toolkit.core.revisions import write_revision_ditaval
+    write_revision_ditaval(context, data_dir)
+    # One filter per configuration of a profiled manual (core.profiling)
+    from orlando_toolkit.core.profiling import write_profile_ditavals
+    write_profile_ditavals(context, data_dir)
 
     logger.info("DITA package saved to %s", output_dir)
 
 
+# Fixed entry timestamp so archives do not depend on when (or in which order)
+# files were written; 1980-01-01 is the earliest date ZIP can represent.
+_ZIP_EPOCH = (1980, 1, 1, 0, 0, 0)
+_ZIP_CHUNK = 1024 * 1024
+
+
+def write_zip_archive(source_dir: str | Path, output_zip: str | Path) -> None:
+    """Zip *source_dir* into *output_zip* reproducibly.
+
+    Entries are added in sorted path order with fixed timestamps and
+    permissions, so the same package content always yields a byte-identical
+    archive regardless of worker counts or filesystem iteration order.
+    Files are streamed into the archive rather than read whole.
+    """
+    source_dir = Path(source_dir)
+    with zipfile.ZipFile(output_zip, "w", compression=zipfile.ZIP_DEFLATED) as zf:
+        for dirpath, dirnames, filenames in os.walk(source_dir):
+            dirnames.sort()
+            rel_dir = Path(dirpath).relative_to(source_dir)
+            if rel_dir.parts:
+                info = zipfile.ZipInfo(rel_dir.as_posix() + "/", date_time=_ZIP_EPOCH)
+                info.external_attr = (0o40755 << 16) | 0x10
+                zf.writestr(info, b"")
+            for name in sorted(filenames):
+                info = zipfile.ZipInfo((rel_dir / name).as_posix(), date_time=_ZIP_EPOCH)
+                info.external_attr = 0o100644 << 16
+                info.compress_type = zipfile.ZIP_DEFLATED
+                path = Path(dirpath, name)
+                # Known size up front: same entry header as ZipFile.writestr
+                info.file_size = path.stat().st_size
+                with open(path, "rb") as src, zf.open(info, "w") as dest:
+                    shutil.copyfileobj(src, dest, _ZIP_CHUNK)
+
+
 def update_image_references_and_names(context: DitaContext) -> DitaContext:
     """Rename image files and update hrefs inside all topic XML trees.
     
-    Uses the image naming configuration to generate consistent filenames
-    based on section numbers and configured patterns.
+    Uses the image naming template (:mod:`orlando_toolkit.core.image_naming`)
+    from the configuration, the conversion profile or the Images tab.
 
     Args:
         context: DitaContext to update
@@ -291,76 +423,14 @@ def update_image_references_and_names(context: DitaContext) -> DitaContext:
     """
     logger.info("Updating image names and references...")
 
-    # Get image naming configuration
-    image_naming_config = {}
-    try:
-        image_naming_config = ConfigManager().get_image_naming() or {}
-    except Exception as exc:
-        logger.error("Failed to read image naming config: %s", exc)
-
-    required_keys = {"prefix", "pattern", "index_start", "index_zero_pad"}
-    if not required_keys.issubset(image_naming_config.keys()):
-        logger.warning("Image naming config missing keys %s; skipping image renaming", 
-                       sorted(list(required_keys - set(image_naming_config.keys()))))
-        return context
+    from orlando_toolkit.core.image_naming import propose_names
 
-    manual_code = context.metadata.get("manual_code", "manual")
-    prefix = image_naming_config.get("prefix")
-    pattern = image_naming_config.get("pattern")
-    index_start = image_naming_config.get("index_start")
-    index_zero_pad = image_naming_config.get("index_zero_pad")
-
-    # Validate basic types
     try:
-        index_start = int(index_start)
-        index_zero_pad = int(index_zero_pad)
-    except Exception:
-        logger.warning("Image naming config has invalid integer fields; skipping image renaming")
+        rename_map = propose_names(context)
+    except Exception as exc:
+        logger.error("Image naming pattern error: %s; skipping image renaming", exc)
         return context
 
-    # Create per-section image naming
-    from orlando_toolkit.core.utils import find_topicref_for_image, get_section_number_for_topicref
-
-    # Group images by section
-    section_images: Dict[str, list[str]] = {}
-    for image_filename in list(context.images.keys()):
-        topicref = find_topicref_for_image(image_filename, context)
-        if topicref is not None and context.ditamap_root is not None:
-            section_number = get_section_number_for_topicref(topicref, context.ditamap_root)
-        else:
-            section_number = "0"
-
-        section_images.setdefault(section_number, []).append(image_filename)
-
-    # Generate new filenames with per-section numbering
-    rename_map: dict[str, str] = {}
-    for section_number, images_in_section in section_images.items():
-        for i, image_filename in enumerate(images_in_section):
-            extension = os.path.splitext(image_filename)[1]
-
-            # Prepare tokens for pattern substitution
-            tokens = {
-                "prefix": prefix,
-                "manual_code": manual_code,
-                "section": section_number,
-                "ext": extension,
-                "-index": "",
-            }
-
-            # Add index only if there are multiple images in this section
-            if len(images_in_section) > 1:
-                img_num = i + index_start
-                index_str = str(img_num).zfill(index_zero_pad) if index_zero_pad > 0 else str(img_num)
-                tokens["-index"] = f"-{index_str}"
-
-            try:
-                new_filename = pattern.format(**tokens)
-            except Exception as exc:
-                logger.error("Image naming pattern error: %s; skipping image renaming", exc)
-                return context
-
-            rename_map[image_filename] = new_filename
-
     # Update href references in topic XML
     for topic_el in context.topics.values():
         for img_el in topic_el.iter("image"):
@@ -369,27 +439,178 @@ def update_image_references_and_names(context: DitaContext) -> DitaContext:
                 basename = os.path.basename(href)
                 if basename in rename_map:
                     img_el.set("href", f"../media/{rename_map[basename]}")
+        # Poster images of video and audio objects
+        for param in topic_el.iter("param"):
+            basename = os.path.basename(param.get("value") or "")
+            if param.get("valuetype") == "ref" and basename in rename_map:
+                param.set("value", f"../media/{rename_map[basename]}")
 
     # Rebuild images dictionary with new names
-    new_images: dict[str, bytes] = {}
-    for old_name, data in context.images.items():
-        new_images[rename_map.get(old_name, old_name)] = data
-
-    context.images = new_images
+    context.images = rename_blobs(context.images, rename_map)
+    # Images marked for the asset server keep their mark under the new name
+    from orlando_toolkit.core.external_media import rename_marked
+    rename_marked(context.metadata, rename_map)
+    from orlando_toolkit.core.callouts import rename_callouts
+    rename_callouts(context.metadata, rename_map)
     return context
 
 
-def update_topic_references_and_names(context: DitaContext) -> DitaContext:
+ID_STRATEGIES = ("suffix", "path", "hash")
+
+
+def _resolve_id_options(context: DitaContext) -> Dict[str, Any]:
+    try:
+        from orlando_toolkit.core.processing import resolve_conversion_options
+        return dict(resolve_conversion_options(context.metadata).get("ids") or {})
+    except Exception as exc:
+        logger.debug("Id options unavailable, using defaults: %s", exc)
+        return {}
+
+
+def _short_hash(text: str, length: int = 8) -> str:
+    import hashlib  # local import to avoid increasing module import time
+    return hashlib.md5(text.encode("utf-8")).hexdigest()[:length]
+
+
+def _disambiguate(candidate: str, used: set[str], strategy: str, ancestors: list[str],
+                  seed: str, *, prefix: str = "", ext: str = "") -> str:
+    """Return *candidate* or a variant of it not in *used*.
+
+    ``suffix`` appends ``-2``, ``-3``…; ``path`` prefixes the nearest ancestor
+    slugs after *prefix* (``topic_parent_title``) one at a time; ``hash`` appends a short hash
+    of *seed* (ancestor titles + title) so the name does not depend on
+    document order. ``path`` and ``hash`` fall back to numbering when they
+    still collide.
+    """
+    if candidate not in used:
+        return candidate
+    stem = candidate[:-len(ext)] if ext else candidate
+    if strategy == "path":
+        body = stem[len(prefix):] if prefix and stem.startswith(prefix) else stem
+        for i in range(1, len(ancestors) + 1):
+            attempt = prefix + "_".join(ancestors[-i:] + [body]) + ext
+            if attempt not in used:
+                return attempt
+    elif strategy == "hash":
+        attempt = f"{stem}-{_short_hash(seed)}{ext}"
+        if attempt not in used:
+            return attempt
+        stem = attempt[:-len(ext)] if ext else attempt
+    suffix = 2
+    while f"{stem}-{suffix}{ext}" in used:
+        suffix += 1
+    return f"{stem}-{suffix}{ext}"
+
+
+def _split_reference(value: str) -> tuple[str, str, Optional[str]]:
+    """Split ``dir/file.dita#topic/element`` into ``(dir/, file.dita, fragment)``."""
+    path, sep, fragment = value.partition("#")
+    directory, _, basename = path.rpartition("/")
+    return (directory + "/" if directory else ""), basename, (fragment if sep else None)
+
+
+def _rewrite_references(root, rename_map: Dict[str, str], topic_ids: Dict[str, tuple[str, str]],
+                        own_file: Optional[str]) -> int:
+    """Point ``href``/``conref``/``conrefend`` values in *root* at renamed topics and ids.
+
+    *topic_ids* maps new filenames to ``(old_id, new_id)``; same-file
+    fragments (``#topic/element``) use *own_file*.
+    """
+    changed = 0
+    for el in root.iter():
+        if not isinstance(el.tag, str) or (el.get("scope") or "") == "external":
+            continue
+        for attr in ("href", "conref", "conrefend"):
+            value = el.get(attr)
+            if not value or "://" in value or value.startswith("mailto:"):
+                continue
+            directory, basename, fragment = _split_reference(value)
+            target = rename_map.get(basename, basename) if basename else own_file
+            new_basename = rename_map.get(basename, basename)
+            if fragment and target in topic_ids:
+                old_id, new_id = topic_ids[target]
+                head, slash, rest = fragment.partition("/")
+                if head == old_id:
+                    fragment = new_id + slash + rest
+            new_value = directory + new_basename + (f"#{fragment}" if fragment is not None else "")
+            if new_value != value:
+                el.set(attr, new_value)
+                changed += 1
+    return changed
+
+
+def deduplicate_element_ids(topic_el, strategy: str = "suffix") -> int:
+    """Give repeated ``@id`` values inside *topic_el* unique variants.
+
+    The first occurrence keeps its id, so existing ``#topic/element``
+    references keep resolving to the element they resolved to before.
+    Returns the number of ids changed.
+    """
+    used: set[str] = set()
+    changed = 0
+    root_id = topic_el.get("id")
+    for el in topic_el.iter():
+        if not isinstance(el.tag, str) or el is topic_el:
+            continue
+        value = el.get("id")
+        if not value:
+            continue
+        if value in used or value == root_id:
+            ancestors = []
+            parent = el.getparent()
+            while parent is not None and parent is not topic_el:
+                if parent.get("id"):
+                    ancestors.insert(0, parent.get("id"))
+                parent = parent.getparent()
+            title = el.find("title")
+            seed = "/".join(ancestors + [value, "".join(title.itertext()) if title is not None else ""])
+            new = _disambiguate(value, used | {root_id or ""}, strategy, ancestors, seed)
+            el.set("id", new)
+            value = new
+            changed += 1
+        used.add(value)
+    return changed
+
+
+def update_topic_references_and_names(context: DitaContext, *, strategy: Optional[str] = None,
+                                      include_section_number: Optional[bool] = None,
+                                      stable: Optional[bool] = None,
+                                      previous: Optional[PreviousConversion] = None,
+                                      naming: Optional[str] = None, registry: Any = None) -> DitaContext:
     """Generate human-readable, stable filenames for topics and update hrefs.
 
     Strategy:
     - Base name on section number + slugified title/navtitle: "topic_<num>_<slug>.dita"
-    - Ensure uniqueness by suffixing "-2", "-3", ... when needed
-    - Update both the ditamap @href and the topics dict keys
+      (``include_section_number: false`` drops the number: "topic_<slug>.dita")
+    - Another naming strategy (``ids.naming``: sequential, hash, guid or one
+      of a plugin, see :mod:`orlando_toolkit.core.id_strategies`) names the
+      topics instead
+    - Resolve collisions (headings sharing the same text) with *strategy*:
+      ``suffix`` ("-2", "-3", ...), ``path`` (ancestor heading slugs prefixed)
+      or ``hash`` (short hash of the heading path)
+    - Stable mode ("topic_<slug>-<hash>.dita", see :mod:`orlando_toolkit.core.stable_ids`)
+      derives names from heading anchors or heading paths, and reuses the
+      names of a previous conversion wherever a topic matches it
+    - ``reproducible.enabled`` implies stable mode
+    - Topic @id follows the filename; duplicate element ids inside a topic are
+      made unique with the same strategy
+    - Rewrite the ditamap @href, the topics dict keys and every inbound
+      @href/@conref (``file.dita#topic/element``) so links stay correct
 
     Args:
         context: DitaContext to update
-        
+        strategy: Collision strategy; defaults to the ``ids`` section of
+            ``conversion.yml`` (``suffix``)
+        include_section_number: Prefix names with the section number;
+            defaults to the ``ids`` section (``true``)
+        stable: Derive names from anchors/heading paths; defaults to
+            ``ids.stable`` (``false``), or true with reproducible output
+        previous: Previous conversion to keep names from; loaded from
+            ``ids.previous_map`` when omitted
+        naming: Naming strategy; defaults to ``ids.naming`` (``slug``)
+        registry: Service registry providing plugin strategies; defaults to
+            the application's
+
     Returns:
         Updated DitaContext with renamed topics and updated references
     """
@@ -398,6 +619,35 @@ def update_topic_references_and_names(context: DitaContext) -> DitaContext:
     if context.ditamap_root is None:
         return context
 
+    options = _resolve_id_options(context)
+    if strategy is None:
+        strategy = str(options.get("strategy", "suffix") or "suffix").strip().lower()
+    if strategy not in ID_STRATEGIES:
+        logger.warning("Unknown id strategy %r; using suffix", strategy)
+        strategy = "suffix"
+    if include_section_number is None:
+        include_section_number = bool(options.get("include_section_number", True))
+    if stable is None:
+        stable = bool(options.get("stable", False)) or bool(reproducible_options(context.metadata).get("enabled"))
+    if previous is None and options.get("previous_map"):
+        previous = PreviousConversion.load(options["previous_map"])
+    if naming is None:
+        naming = str(options.get("naming") or "slug")
+    from orlando_toolkit.core.id_strategies import TopicNameInfo, resolve_naming, safe_name
+
+    try:
+        namer = resolve_naming(naming, options, registry)
+    except ValueError as exc:
+        logger.warning("%s; using slug names", exc)
+        context.report.warning("ids", f"{exc}; topics keep slug names", naming=naming,
+                               hint="Check ids.naming and ids.pattern in conversion.yml or the output profile")
+        namer = None
+    try:
+        seq = int(options.get("start", 1))
+    except (TypeError, ValueError):
+        seq = 1
+    manual_code = str((context.metadata or {}).get("manual_code") or "").strip()
+
     try:
         from orlando_toolkit.core.utils import calculate_section_numbers, slugify
     except Exception:
@@ -419,7 +669,6 @@ def update_topic_references_and_names(context: DitaContext) -> DitaContext:
     # First pass: discover referenced topics and propose deterministic names
     rename_map: dict[str, str] = {}
     used_names: set[str] = set()
-    import hashlib  # local import to avoid increasing module import time
     MAX_FILENAME_LEN = 120  # conservative cap for Windows path constraints
 
     def _pick_title_for(topic_el, tref_el) -> str:
@@ -438,11 +687,20 @@ def update_topic_references_and_names(context: DitaContext) -> DitaContext:
             pass
         return "topic"
 
+    def _ancestor_titles(tref_el) -> list[str]:
+        titles: list[str] = []
+        parent = tref_el.getparent()
+        while parent is not None and parent.tag in ("topicref", "topichead"):
+            href = (parent.get("href") or "").split("/")[-1]
+            titles.insert(0, _pick_title_for(context.topics.get(href), parent))
+            parent = parent.getparent()
+        return titles
+
     for tref in list(context.ditamap_root.xpath(".//topicref[@href]")):
         href = tref.get("href") or ""
         old_filename = href.split("/")[-1]
         topic_el = context.topics.get(old_filename)
-        if topic_el is None:
+        if topic_el is None or old_filename in rename_map:
             continue
 
         # Section number (e.g., 3.2.1) → filename-safe variant
@@ -450,35 +708,62 @@ def update_topic_references_and_names(context: DitaContext) -> DitaContext:
         safe_number = number.replace(".", "-") if isinstance(number, str) else "0"
         title = _pick_title_for(topic_el, tref)
         base_slug = slugify(title) or "topic"
-        prefix = f"topic_{safe_number}_"
+        prefix = f"topic_{safe_number}_" if include_section_number else "topic_"
         ext = ".dita"
         allowed = MAX_FILENAME_LEN - len(prefix) - len(ext)
         if allowed < 8:
             allowed = 8  # ensure minimal space for slug
         if len(base_slug) > allowed:
             # Truncate and add deterministic suffix for stability
-            h = hashlib.md5((title or "").encode("utf-8")).hexdigest()[:8]
+            h = _short_hash(title or "")
             allowed2 = max(1, allowed - 9)  # account for '-' + 8-char hash
             trimmed = base_slug[:allowed2]
             candidate = f"{prefix}{trimmed}-{h}{ext}"
         else:
             candidate = f"{prefix}{base_slug}{ext}"
 
-        unique_name = candidate
-        suffix = 2
-        while unique_name in used_names:
-            base_no_ext = unique_name[:-len(ext)]
-            unique_name = f"{base_no_ext}-{suffix}{ext}"
-            suffix += 1
-
+        fp = None
+        if stable or previous is not None or namer is not None:
+            fp = fingerprint(topic_el, tref, lambda h: context.topics.get(h.split("/")[-1]))
+            reused = previous.match(fp, used_names) if previous is not None else None
+            if reused:
+                used_names.add(reused)
+                rename_map[old_filename] = reused
+                continue
+            if stable and namer is None:
+                slug = base_slug[:max(1, MAX_FILENAME_LEN - len("topic_") - len(ext) - 9)]
+                prefix = "topic_"
+                candidate = f"{prefix}{slug}-{stable_hash(fp.key)}{ext}"
+
+        ancestors = _ancestor_titles(tref)
+        if namer is not None:
+            info = TopicNameInfo(title=title, slug=base_slug, section=safe_number, seq=seq,
+                                 manual_code=manual_code, path="/".join(ancestors + [title]), key=fp.key,
+                                 stable=bool(stable), topic=topic_el, topicref=tref)
+            seq += 1
+            try:
+                stem = namer.topic_name(info)
+            except Exception as exc:
+                logger.error("Id strategy %s failed for %s: %s", naming, title, exc)
+                context.report.warning("ids", f"Id strategy {naming} failed: {exc}; slug name kept",
+                                       topic=candidate, naming=naming)
+            else:
+                prefix = ""
+                candidate = safe_name(stem, MAX_FILENAME_LEN - len(ext)) + ext
+        unique_name = _disambiguate(
+            candidate, used_names, strategy,
+            [slugify(t) or "topic" for t in ancestors], "/".join(ancestors + [title]),
+            prefix=prefix, ext=ext,
+        )
         used_names.add(unique_name)
         rename_map[old_filename] = unique_name
 
     if not rename_map:
         return context
 
-    # Second pass: apply renames in topics dict and update map hrefs
+    # Second pass: apply renames in topics dict and record id changes
     new_topics: dict[str, Any] = {}
+    topic_ids: dict[str, tuple[str, str]] = {}
     for old_filename, topic_el in list(context.topics.items()):
         new_filename = rename_map.get(old_filename)
         if not new_filename:
@@ -486,22 +771,25 @@ def update_topic_references_and_names(context: DitaContext) -> DitaContext:
             new_topics[old_filename] = topic_el
             continue
         try:
+            old_id = topic_el.get("id") or ""
+            topic_el.attrib.pop(ANCHOR_HINT, None)
             topic_el.set("id", new_filename[:-5])
+            topic_ids[new_filename] = (old_id, new_filename[:-5])
         except Exception:
             pass
         new_topics[new_filename] = topic_el
 
-    # Update hrefs in the map
-    for tref in list(context.ditamap_root.xpath(".//topicref[@href]")):
-        href = tref.get("href") or ""
-        old_filename = href.split("/")[-1]
-        new_filename = rename_map.get(old_filename)
-        if new_filename:
-            tref.set("href", f"topics/{new_filename}")
+    # Rewrite references in the map (topicref @href included) and in topics
+    changed = _rewrite_references(context.ditamap_root, rename_map, topic_ids, None)
+    deduplicated = 0
+    for filename, topic_el in new_topics.items():
+        deduplicated += deduplicate_element_ids(topic_el, strategy)
+        changed += _rewrite_references(topic_el, rename_map, topic_ids, filename)
 
     context.topics = new_topics
     if logger.isEnabledFor(logging.DEBUG):
-        logger.debug("Renamed %d topics", len(rename_map))
+        logger.debug("Renamed %d topics (%s), rewrote %d references, deduplicated %d ids",
+                     len(rename_map), strategy, changed, deduplicated)
     return context
 
 
@@ -525,13 +813,13 @@ def prune_empty_topics(context: DitaContext) -> DitaContext:
 
     # Detect empty content modules
     for fname, topic_el in context.topics.items():
-        conbody = topic_el.find("conbody")
-        if conbody is None:
+        body = topic_body(topic_el)
+        if body is None:
             empty_filenames.append(fname)
             continue
 
-        has_children = len(list(conbody)) > 0
-        has_text = (conbody.text or "").strip() != ""
+        has_children = len(list(body)) > 0
+        has_text = (body.text or "").strip() != ""
 
         if not has_children and not has_text:
             empty_filenames.append(fname)
@@ -0,0 +1,233 @@
+from __future__ import annotations
+
+"""Time and peak-memory profiling of conversions (``convert --perf``).
+
+Instrumentation is off unless a :class:`PerformanceRecorder` is active
+around the work to measure::
+
+    recorder = PerformanceRecorder()
+    with recorder.active():
+        context = service.convert(source, metadata)
+        service.write_package(service.prepare_package(context), target)
+    print(recorder.summary(context.report))
+
+The conversion service marks five phases with :func:`phase`:
+
+- ``parse`` - reading the source and building its topics (importer, plugin
+  handler, Word restoration steps);
+- ``analysis`` - the processing stages and ``topics`` hooks;
+- ``topics`` - preparing the package: merges, names, keys, prolog, hooks;
+- ``images`` - image stages, image renaming and writing images and videos;
+- ``packaging`` - validation, writing the topics and the map, zipping.
+
+Phases nest (images are written while packaging); each phase counts its
+own time only, so the phases add up to the measured total. Peak memory is
+the highest memory traced by :mod:`tracemalloc` while the phase ran, for
+the whole process, so it includes what earlier phases still hold; tracing
+slows Python code down and can be left out with ``memory=False``. Without
+an active recorder :func:`phase` does nothing.
+
+:meth:`PerformanceRecorder.to_dict` adds the time of each processing stage
+and conversion hook (``context.report.timings``) and the toolkit and
+Python versions, so the JSON files of successive releases can be compared.
+"""
+
+import json
+import logging
+import platform
+import time
+import tracemalloc
+from contextlib import contextmanager
+from contextvars import ContextVar
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Dict, Iterator, List, Mapping, Optional
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["FORMAT", "IMAGE_STAGES", "PHASES", "PerformanceRecorder", "PerformanceSettings", "current_recorder",
+           "phase"]
+
+FORMAT = "orlando-performance"
+PHASES = ("parse", "analysis", "topics", "images", "packaging")
+#: Processing stages counted in the ``images`` phase rather than ``analysis``
+IMAGE_STAGES = ("raster_images", "vector_images")
+
+_current: ContextVar[Optional["PerformanceRecorder"]] = ContextVar("orlando_performance", default=None)
+
+
+def current_recorder() -> Optional["PerformanceRecorder"]:
+    """The recorder active in this thread, if any."""
+    return _current.get()
+
+
+@contextmanager
+def phase(name: str) -> Iterator[None]:
+    """Count the enclosed work in phase *name* of the active recorder; does nothing without one."""
+    recorder = _current.get()
+    if recorder is None:
+        yield
+        return
+    recorder._enter(name)
+    try:
+        yield
+    finally:
+        recorder._exit()
+
+
+@dataclass
+class PerformanceSettings:
+    """The ``performance`` section of ``pipeline.yml``."""
+
+    enabled: bool = False
+    memory: bool = True
+    json_dir: Optional[str] = None
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "PerformanceSettings":
+        data = data or {}
+        return cls(enabled=bool(data.get("enabled", False)), memory=bool(data.get("memory", True)),
+                   json_dir=data.get("json_dir") or None)
+
+    @classmethod
+    def from_config(cls) -> "PerformanceSettings":
+        try:
+            from orlando_toolkit.config import ConfigManager
+            return cls.from_mapping((ConfigManager().get_pipeline_config() or {}).get("performance"))
+        except Exception as exc:
+            logger.debug("Pipeline config unavailable, using default performance settings: %s", exc)
+            return cls()
+
+
+class _Frame:
+    __slots__ = ("name", "started", "children", "peak")
+
+    def __init__(self, name: str, started: float, peak: int) -> None:
+        self.name = name
+        self.started = started
+        self.children = 0.0
+        self.peak = peak
+
+
+def _traced() -> Optional[tuple]:
+    """(current, peak since the last reset) when tracing, and resets the peak."""
+    if not tracemalloc.is_tracing():
+        return None
+    current, peak = tracemalloc.get_traced_memory()
+    if hasattr(tracemalloc, "reset_peak"):  # Python 3.9+
+        tracemalloc.reset_peak()
+    return current, peak
+
+
+class PerformanceRecorder:
+    """Time and peak memory per phase, over one or more :meth:`active` blocks."""
+
+    def __init__(self, *, memory: bool = True) -> None:
+        self.memory = memory
+        self.seconds: Dict[str, float] = {}
+        self.peaks: Dict[str, int] = {}
+        self.calls: Dict[str, int] = {}
+        self.total_seconds = 0.0
+        self.peak_bytes = 0
+        self._stack: List[_Frame] = []
+
+    @contextmanager
+    def active(self) -> Iterator["PerformanceRecorder"]:
+        """Record the phases entered by the enclosed work in this thread."""
+        started_tracing = self.memory and not tracemalloc.is_tracing()
+        if started_tracing:
+            tracemalloc.start()
+        token = _current.set(self)
+        started = time.perf_counter()
+        try:
+            yield self
+        finally:
+            self.total_seconds += time.perf_counter() - started
+            _current.reset(token)
+            traced = _traced()
+            if traced is not None:
+                self.peak_bytes = max(self.peak_bytes, traced[1])
+            if started_tracing:
+                tracemalloc.stop()
+
+    def _enter(self, name: str) -> None:
+        traced = _traced()
+        if traced is not None:
+            if self._stack:
+                self._stack[-1].peak = max(self._stack[-1].peak, traced[1])
+            self.peak_bytes = max(self.peak_bytes, traced[1])
+        self._stack.append(_Frame(name, time.perf_counter(), traced[0] if traced else 0))
+
+    def _exit(self) -> None:
+        frame = self._stack.pop()
+        elapsed = time.perf_counter() - frame.started
+        traced = _traced()
+        if traced is not None:
+            frame.peak = max(frame.peak, traced[1])
+            self.peak_bytes = max(self.peak_bytes, traced[1])
+            self.peaks[frame.name] = max(self.peaks.get(frame.name, 0), frame.peak)
+        if self._stack:
+            self._stack[-1].children += elapsed
+        self.seconds[frame.name] = self.seconds.get(frame.name, 0.0) + elapsed - frame.children
+        self.calls[frame.name] = self.calls.get(frame.name, 0) + 1
+
+    # ------------------------------------------------------------------
+    # Results
+    # ------------------------------------------------------------------
+    def phase_names(self) -> List[str]:
+        return [name for name in PHASES if name in self.calls] + sorted(set(self.calls) - set(PHASES))
+
+    def to_dict(self, report: Any = None, *, source: Optional[str | Path] = None, topics: Optional[int] = None,
+                images: Optional[int] = None) -> Dict[str, Any]:
+        """JSON-ready results; stage timings come from *report* (a conversion report)."""
+        from orlando_toolkit.version import get_app_version
+
+        data: Dict[str, Any] = {
+            "format": FORMAT,
+            "toolkit_version": get_app_version(),
+            "python": platform.python_version(),
+            "platform": platform.platform(terse=True),
+            "total_seconds": round(self.total_seconds, 4),
+            "memory_traced": self.memory,
+            "peak_memory_bytes": self.peak_bytes if self.memory else None,
+            "phases": {name: {"seconds": round(self.seconds.get(name, 0.0), 4), "calls": self.calls[name],
+                              "peak_memory_bytes": self.peaks.get(name) if self.memory else None}
+                       for name in self.phase_names()},
+        }
+        if source is not None:
+            data["source"] = Path(source).name
+        if topics is not None:
+            data["topics"] = topics
+        if images is not None:
+            data["images"] = images
+        timings = getattr(report, "timings", None) or {}
+        if timings:
+            data["stages"] = {name: round(seconds, 4) for name, seconds in
+                              sorted(timings.items(), key=lambda item: -item[1])}
+        return data
+
+    def to_json(self, report: Any = None, **kwargs: Any) -> str:
+        dump = {key: kwargs.pop(key) for key in ("indent", "ensure_ascii") if key in kwargs}
+        dump.setdefault("indent", 2)
+        dump.setdefault("ensure_ascii", False)
+        return json.dumps(self.to_dict(report, **kwargs), **dump)
+
+    def summary(self, report: Any = None, *, slowest: int = 5) -> str:
+        """Phases with their time and peak memory, then the *slowest* stages of *report*."""
+        peak = f", peak {_megabytes(self.peak_bytes)}" if self.memory else ""
+        lines = [f"Performance: {self.total_seconds:.2f} s{peak}"]
+        for name in self.phase_names():
+            seconds = self.seconds.get(name, 0.0)
+            share = 100 * seconds / self.total_seconds if self.total_seconds else 0.0
+            line = f"  {name:<10} {seconds:8.2f} s {share:5.1f}%"
+            if self.memory and name in self.peaks:
+                line += f"  peak {_megabytes(self.peaks[name])}"
+            lines.append(line)
+        timings = sorted((getattr(report, "timings", None) or {}).items(), key=lambda item: -item[1])[:slowest]
+        if timings:
+            lines.append("  slowest stages: " + ", ".join(f"{name} {seconds:.2f} s" for name, seconds in timings))
+        return "\n".join(lines)
+
+
+def _megabytes(size: int) -> str:
+    return f"{size / (1024 * 1024):.1f} MB"
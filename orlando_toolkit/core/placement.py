@@ -0,0 +1,221 @@
+from __future__ import annotations
+
+"""Put content recovered from a source file back into converted topics.
+
+Passes that re-read the source after a plugin converted it (Word notes,
+equations) need to find where a source paragraph ended up. The plugin's
+output keeps the paragraph text, so a paragraph is located by its text
+(whitespace-insensitive, in map order) and content is inserted a given
+number of characters into it:
+
+- :func:`text_blocks` lists the block elements of all topics in map order
+  with their normalized text; :func:`find_block` searches it from a cursor
+  so repeated paragraphs are matched in order;
+- :func:`insert_at` inserts an element at a character offset of a block,
+  optionally removing text that follows (a garbled rendering of what is
+  being restored).
+
+Content restored by an earlier pass (notes, comments, index entries,
+equations; :data:`RESTORED_TAGS`) is not part of the paragraph text, so the
+passes can run in any order.
+"""
+
+from pathlib import Path
+from typing import Any, Iterator, List, Optional, Tuple
+
+__all__ = ["BLOCK_TAGS", "RESTORED_TAGS", "block_text", "find_block", "innermost_block", "insert_at", "normalize",
+           "offset_of", "text_blocks", "topic_order"]
+
+BLOCK_TAGS = frozenset({"p", "li", "sli", "entry", "stentry", "dt", "dd", "title", "shortdesc", "cmd",
+                        "info", "stepresult", "note", "lq", "div", "pre", "codeblock"})
+RESTORED_TAGS = frozenset({"fn", "draft-comment", "indexterm", "equation-inline", "equation-block"})
+
+
+def normalize(text: str) -> str:
+    return " ".join((text or "").split())
+
+
+def offset_of(prefix: str) -> int:
+    """Offset in the normalized paragraph text of the point after *prefix*."""
+    return len(normalize(prefix + "x")) - 1
+
+
+def block_text(el: Any) -> str:
+    """Normalized text of *el* without restored content (:data:`RESTORED_TAGS`)."""
+    parts: List[str] = []
+
+    def _collect(node: Any) -> None:
+        parts.append(node.text or "")
+        for child in node:
+            if isinstance(child.tag, str) and child.tag not in RESTORED_TAGS:
+                _collect(child)
+            parts.append(child.tail or "")
+
+    _collect(el)
+    return normalize("".join(parts))
+
+
+def topic_order(context: Any) -> List[str]:
+    """Topic file names in map order, then the topics the map does not reference."""
+    names: List[str] = []
+    root = getattr(context, "ditamap_root", None)
+    if root is not None:
+        for ref in root.iter("topicref"):
+            name = Path((ref.get("href") or "").split("#")[0]).name
+            if name in context.topics and name not in names:
+                names.append(name)
+    names.extend(n for n in context.topics if n not in names)
+    return names
+
+
+def text_blocks(context: Any) -> List[Tuple[str, Any, str]]:
+    """``(topic file, block element, normalized text)`` for every block, in map order."""
+    blocks: List[Tuple[str, Any, str]] = []
+    for name in topic_order(context):
+        for el in context.topics[name].iter():
+            if isinstance(el.tag, str) and el.tag in BLOCK_TAGS:
+                blocks.append((name, el, block_text(el)))
+    return blocks
+
+
+def find_block(blocks: List[Tuple[str, Any, str]], text: str, cursor: int = 0) -> Tuple[Optional[int], int]:
+    """Index of the block whose text is *text* (from *cursor*, then anywhere) and the next cursor."""
+    hit = next((i for i in range(cursor, len(blocks)) if blocks[i][2] == text), None)
+    if hit is None:
+        hit = next((i for i in range(len(blocks)) if blocks[i][2] == text), None)
+    if hit is None:
+        return None, cursor
+    block = blocks[hit][1]
+    following = hit + 1
+    while following < len(blocks) and block in blocks[following][1].iterancestors():
+        following += 1
+    return hit, following
+
+
+def innermost_block(block: Any, text: str) -> Any:
+    """The deepest block inside *block* holding all of *text* (``li`` -> its ``p``)."""
+    while True:
+        inner = next((c for c in block if isinstance(c.tag, str) and c.tag in BLOCK_TAGS
+                      and block_text(c) == text), None)
+        if inner is None:
+            return block
+        block = inner
+
+
+def _pieces(block: Any) -> Iterator[Tuple[Any, str]]:
+    """Text slots of *block* in document order: (owner, "text"|"tail")."""
+    yield block, "text"
+    for child in block:
+        if isinstance(child.tag, str) and child.tag not in RESTORED_TAGS:
+            yield from _pieces(child)
+        yield child, "tail"
+
+
+def _following(new: Any, block: Any) -> Iterator[Tuple[Any, str]]:
+    """Text slots after *new* up to the end of *block*."""
+    yield new, "tail"
+    node = new
+    while node is not block:
+        for sibling in node.itersiblings():
+            if isinstance(sibling.tag, str) and sibling.tag not in RESTORED_TAGS:
+                yield from _pieces(sibling)
+            yield sibling, "tail"
+        node = node.getparent()
+        if node is None or node is block:
+            return
+        yield node, "tail"
+
+
+def _get(owner: Any, slot: str) -> str:
+    return (owner.text if slot == "text" else owner.tail) or ""
+
+
+def _set(owner: Any, slot: str, value: str) -> None:
+    if slot == "text":
+        owner.text = value or None
+    else:
+        owner.tail = value or None
+
+
+def _remove_following(new: Any, block: Any, remove: str) -> bool:
+    """Delete the characters of *remove* (whitespace ignored) right after *new*."""
+    wanted = "".join(remove.split())
+    edits: List[Tuple[Any, str, str]] = []
+    emptied: List[Any] = []
+    for owner, slot in _following(new, block):
+        if not wanted:
+            break
+        raw = _get(owner, slot)
+        kept = []
+        for index, char in enumerate(raw):
+            if not wanted:
+                kept.append(raw[index:])
+                break
+            if char.isspace():
+                continue
+            if char != wanted[0]:
+                return False
+            wanted = wanted[1:]
+        edits.append((owner, slot, "".join(kept)))
+        if slot == "text" and owner is not new:
+            emptied.append(owner)
+    if wanted:
+        return False
+    for owner, slot, value in edits:
+        _set(owner, slot, value)
+    for el in emptied:
+        parent = el.getparent()
+        if parent is not None and not el.text and not len(el):
+            previous = el.getprevious()
+            if previous is not None:
+                previous.tail = (previous.tail or "") + (el.tail or "") or None
+            else:
+                parent.text = (parent.text or "") + (el.tail or "") or None
+            parent.remove(el)
+    return True
+
+
+def insert_at(block: Any, offset: int, new: Any, remove: str = "") -> bool:
+    """Insert *new* after *offset* normalized characters of *block*.
+
+    With *remove*, the text right after the insertion point is deleted when
+    it matches (across inline elements, whitespace ignored). Returns whether
+    *remove* was found; *new* is inserted either way.
+    """
+    count, pending, started = 0, False, False
+    for owner, slot in _pieces(block):
+        raw = _get(owner, slot)
+        position = 0 if offset == 0 else None
+        for index, char in enumerate(raw if position is None else ""):
+            if char.isspace():
+                pending = started
+                continue
+            if pending:
+                count, pending = count + 1, False
+                if count == offset:
+                    position = index
+                    break
+            count, started = count + 1, True
+            if count == offset:
+                position = index + 1
+                break
+        if position is None:
+            continue
+        head, rest = raw[:position], raw[position:]
+        if slot == "text" and owner is not block and not head.strip():
+            # At the start of an inline element: insert before it, not inside
+            parent = owner.getparent()
+            parent.insert(parent.index(owner), new)
+            new.tail = None
+            return _remove_following(new, block, remove) if remove else True
+        if slot == "text":
+            owner.text = head or None
+            owner.insert(0, new)
+        else:
+            owner.tail = head or None
+            parent = owner.getparent()
+            parent.insert(parent.index(owner) + 1, new)
+        new.tail = rest or None
+        return _remove_following(new, block, remove) if remove else True
+    block.append(new)
+    return not remove
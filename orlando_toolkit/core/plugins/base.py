@@ -359,6 +359,12 @@ class BasePlugin(ABC):
     # Utility Methods
     # -------------------------------------------------------------------------
     
+    def tr(self, text: str, **params: Any) -> str:
+        """Interface text in the selected language (from the plugin's ``locales/`` catalogs)."""
+        from orlando_toolkit.ui.i18n import tr
+
+        return tr(text, **params)
+
     def log_info(self, message: str, *args: Any) -> None:
         """Log info message with plugin context."""
         self._logger.info(f"[{self.plugin_id}] {message}", *args)
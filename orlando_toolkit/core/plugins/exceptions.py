@@ -15,8 +15,12 @@ class PluginError(Exception):
     """Base exception for all plugin-related errors.
     
     All plugin exceptions inherit from this base class to enable
-    comprehensive error handling and logging.
+    comprehensive error handling and logging. ``code`` and ``hint`` follow
+    ``orlando_toolkit.core.errors``.
     """
+
+    code = "OTK301"
+    hint: Optional[str] = None
     
     def __init__(self, message: str, plugin_id: Optional[str] = None, 
                  cause: Optional[Exception] = None) -> None:
@@ -59,6 +63,8 @@ class UnsupportedFormatError(PluginError):
     This occurs when trying to convert a file type that no
     registered plugin supports.
     """
+
+    code = "OTK101"
     
     def __init__(self, file_path: str, available_formats: Optional[list[str]] = None) -> None:
         self.file_path = file_path
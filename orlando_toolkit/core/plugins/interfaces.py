@@ -9,7 +9,7 @@ between plugins and the core application.
 
 from abc import ABC, abstractmethod
 from pathlib import Path
-from typing import Dict, List, Any, Protocol, runtime_checkable, Callable, Optional
+from typing import Dict, Iterable, List, Any, Protocol, Sequence, runtime_checkable, Callable, Optional
 
 from orlando_toolkit.core.models import DitaContext
 
@@ -74,6 +74,17 @@ class DocumentHandler(Protocol):
             If progress_callback is provided, plugins should call it with descriptive
             status messages during conversion (e.g., "Loading DOCX file...", 
             "Extracting images...", "Analyzing document structure...").
+
+            Handlers may additionally accept a keyword-only ``cancel_token``
+            (:class:`~orlando_toolkit.core.cancellation.CancellationToken`). The
+            host passes it only when the signature declares it; handlers should
+            call ``cancel_token.raise_if_cancelled()`` between expensive steps.
+
+            A keyword-only ``time_budget``
+            (:class:`~orlando_toolkit.core.time_budget.TimeBudget`) is passed the
+            same way. When ``time_budget.expired`` becomes true, stop optional
+            work, return what was converted, and call
+            ``context.report.mark_partial(...)``.
         """
         ...
     
@@ -303,3 +314,60 @@ class WorkflowLauncher(Protocol):
             app_ui: OrlandoToolkit main UI widget to interop with UI helpers
         """
         ...
+
+
+@runtime_checkable
+class SpellChecker(Protocol):
+    """Optional plugin-provided spell checker.
+
+    Registered with ``register_service("SpellChecker", checker, plugin_id)``
+    and used by the ``spelling`` processing stage when its backend is
+    ``plugin``.
+    """
+
+    def check(self, words: Sequence[str], language: str) -> Iterable[str]:
+        """Return the subset of *words* suspected to be misspelled in *language*."""
+        ...
+
+
+@runtime_checkable
+class ConversionHook(Protocol):
+    """Optional plugin-provided conversion post-processing hook.
+
+    Registered with ``register_service("ConversionHook", hook, plugin_id)``
+    or published by an installed package under the ``orlando_toolkit.hooks``
+    entry point group (see :mod:`orlando_toolkit.core.hooks`). A hook
+    implements any of the methods below; missing ones are skipped. Each
+    receives the :class:`DitaContext` and changes it in place.
+    """
+
+    def on_parse(self, context: DitaContext) -> None:
+        """The source document was parsed into topics; processing stages have not run."""
+        ...
+
+    def on_topics(self, context: DitaContext) -> None:
+        """The processing stages have built the topics."""
+        ...
+
+    def on_structure(self, context: DitaContext) -> None:
+        """The structure of the package is final (merges, renames, key references applied)."""
+        ...
+
+    def on_package(self, context: DitaContext, package_dir: Path) -> None:
+        """The package files are written to *package_dir*, which is zipped next."""
+        ...
+
+
+@runtime_checkable
+class IdStrategy(Protocol):
+    """Optional plugin-provided naming strategy for topic files and ids.
+
+    Registered with ``register_service("IdStrategy", strategy, plugin_id)``
+    or published by an installed package under the
+    ``orlando_toolkit.id_strategies`` entry point group, and selected by
+    ``ids.naming`` (see :mod:`orlando_toolkit.core.id_strategies`).
+    """
+
+    def topic_name(self, info: Any) -> str:
+        """File name stem (and id) of the topic described by *info*, a ``TopicNameInfo``."""
+        ...
@@ -9,16 +9,18 @@ and logging for plugin loading operations.
 """
 
 import importlib
+import importlib.machinery
 import importlib.util
 import logging
 import sys
 from pathlib import Path
-from typing import Dict, List, Optional, Type, Any
+from typing import Dict, List, Optional, Set, Type, Any
 import os
 
 from .base import BasePlugin, PluginState, AppContext
 from .metadata import PluginMetadata, validate_plugin_metadata
 from .registry import ServiceRegistry
+from .signing import SignaturePolicy, SignatureResult, verify_plugin
 from .exceptions import (
     PluginError,
     PluginLoadError,
@@ -29,6 +31,51 @@ from .exceptions import (
 
 logger = logging.getLogger(__name__)
 
+# Folders of verified plugins, whose modules are compiled from source only
+_SOURCE_ONLY_DIRS: Set[Path] = set()
+
+
+class _SourceOnlyLoader(importlib.machinery.SourceFileLoader):
+    """Compiles a module from its source, never reading or writing ``__pycache__``.
+
+    Bytecode is not covered by ``plugin.sig``: a cached ``.pyc`` could run
+    instead of the signed source, and a written one fails the next check.
+    """
+
+    def get_code(self, fullname: str) -> Any:
+        path = self.get_filename(fullname)
+        return self.source_to_code(self.get_data(path), path)
+
+
+def _source_only_hook(path: str) -> Any:
+    """``sys.path_hooks`` entry claiming the folders of verified plugins."""
+    try:
+        folder = Path(path).resolve()
+    except (OSError, TypeError, ValueError):
+        raise ImportError(path)
+    if not any(folder == root or root in folder.parents for root in _SOURCE_ONLY_DIRS):
+        raise ImportError(path)
+    return importlib.machinery.FileFinder(
+        path,
+        (importlib.machinery.ExtensionFileLoader, importlib.machinery.EXTENSION_SUFFIXES),
+        (_SourceOnlyLoader, importlib.machinery.SOURCE_SUFFIXES),
+    )
+
+
+def _import_from_source_only(plugin_dir: Path) -> None:
+    """Route every later import from *plugin_dir*, submodules included, through :class:`_SourceOnlyLoader`."""
+    root = Path(plugin_dir).resolve()
+    _SOURCE_ONLY_DIRS.add(root)
+    if _source_only_hook not in sys.path_hooks:
+        sys.path_hooks.insert(0, _source_only_hook)
+    for entry in list(sys.path_importer_cache):
+        try:
+            folder = Path(entry).resolve()
+        except (OSError, TypeError, ValueError):
+            continue
+        if folder == root or root in folder.parents:
+            del sys.path_importer_cache[entry]
+
 
 def get_user_plugins_dir() -> Path:
     """Get the user's plugin directory path.
@@ -63,6 +110,7 @@ class PluginInfo:
         self.state = PluginState.DISCOVERED
         self.instance: Optional[BasePlugin] = None
         self.load_error: Optional[Exception] = None
+        self.signature: Optional[SignatureResult] = None
     
     @property
     def plugin_id(self) -> str:
@@ -103,6 +151,8 @@ class PluginLoader:
         
         self._plugins: Dict[str, PluginInfo] = {}
         self._logger = logging.getLogger(f"{__name__}.PluginLoader")
+        # Signature checks before any plugin code is imported (security.yml plugin_signatures)
+        self._signature_policy = SignaturePolicy.from_config()
         
         if self._dev_mode:
             # In dev mode, look for plugins in ../plugins/ directory
@@ -301,6 +351,9 @@ class PluginLoader:
             # Set loading state
             plugin_info.state = PluginState.LOADING
             
+            # Verify the signature before any plugin code runs
+            self._check_signature(plugin_info)
+            
             # Import plugin module
             plugin_module = self._import_plugin_module(plugin_info)
             
@@ -345,6 +398,29 @@ class PluginLoader:
                 cause=e
             )
     
+    def _check_signature(self, plugin_info: PluginInfo) -> None:
+        """Verify *plugin_info*'s signature according to the signature policy.
+        
+        Raises:
+            PluginLoadError: If the policy refuses unverified plugins and the
+                signature is missing, untrusted or invalid
+        """
+        policy = self._signature_policy
+        if not policy.enabled:
+            return
+        result = verify_plugin(plugin_info.plugin_dir, policy)
+        plugin_info.signature = result
+        if result.ok:
+            self._logger.info("Plugin signature verified: %s (%s)", plugin_info.plugin_id, result.message)
+            return
+        if policy.mode == "refuse":
+            raise PluginLoadError(
+                f"Plugin signature check failed ({result.status}): {result.message}",
+                plugin_id=plugin_info.plugin_id
+            )
+        self._logger.warning("Loading plugin %s without a valid signature (%s): %s",
+                             plugin_info.plugin_id, result.status, result.message)
+    
     def _import_plugin_module(self, plugin_info: PluginInfo) -> Any:
         """Import plugin module.
         
@@ -361,6 +437,11 @@ class PluginLoader:
         entry_point = plugin_info.metadata.entry_point
         plugin_dir = plugin_info.plugin_dir
         
+        if plugin_info.signature is not None:
+            # A checked plugin must not get __pycache__ folders, which would be refused on the
+            # next start; its modules, including those imported lazily, are compiled from source
+            _import_from_source_only(Path(plugin_dir))
+
         try:
             # Add plugin directory to Python path temporarily
             original_path = sys.path.copy()
@@ -489,6 +570,14 @@ class PluginLoader:
             except Exception:
                 pass
             
+            # Interface translations shipped in the plugin's locales/ folder
+            try:
+                if hasattr(self.app_context, 'ui_registry') and self.app_context.ui_registry:
+                    self.app_context.ui_registry.load_translation_files(
+                        plugin_id, plugin_info.instance.plugin_dir / "locales")
+            except Exception as e:
+                self._logger.warning("Could not load translations of plugin %s: %s", plugin_id, e)
+
             # Call activation lifecycle hook
             plugin_info.instance.on_activate()
             plugin_info.instance._set_state(PluginState.ACTIVE)
@@ -630,7 +719,8 @@ class PluginLoader:
             'loaded_plugins': len([p for p in self._plugins.values() if p.is_loaded()]),
             'active_plugins': len([p for p in self._plugins.values() if p.is_active()]),
             'error_plugins': len([p for p in self._plugins.values() if p.state == PluginState.ERROR]),
-            'plugins_dir': str(self._plugins_dir)
+            'plugins_dir': str(self._plugins_dir),
+            'signature_mode': self._signature_policy.mode
         }
         
         return stats
\ No newline at end of file
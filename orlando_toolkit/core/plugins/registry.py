@@ -372,8 +372,22 @@ class ServiceRegistry:
                 for s_type, service_instance in plugin_services.items():
                     if s_type == type_name or isinstance(service_instance, service_type):
                         services.append(service_instance)
-            
+
             return services
+
+    def get_service_providers(self, service_type: str) -> Dict[str, Any]:
+        """Get the instances of a service type keyed by the plugin providing them.
+
+        Args:
+            service_type: Type of service to retrieve
+
+        Returns:
+            Plugin ID -> service instance, in registration order
+        """
+        with self._lock:
+            return {plugin_id: services[service_type]
+                    for plugin_id, services in self._plugin_services.items()
+                    if service_type in services}
     
     # -------------------------------------------------------------------------
     # Plugin Management
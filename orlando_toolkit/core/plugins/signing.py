@@ -0,0 +1,231 @@
+from __future__ import annotations
+
+"""Plugin signature verification.
+
+A signed plugin ships a ``plugin.sig`` file next to ``plugin.json``:
+
+    {"format": 1, "algorithm": "ed25519", "key_id": "acme-2026",
+     "signature": "<base64>"}
+
+The signature covers the plugin's manifest digest: one ``<sha256>  <path>``
+line per file (POSIX relative paths, sorted; ``plugin.sig`` and VCS folders
+excluded). Any added, removed or modified file therefore invalidates it.
+A plugin folder holding compiled bytecode (``__pycache__``, ``*.pyc``) is
+refused outright: Python would run a matching ``.pyc`` instead of the signed
+source. The loader compiles the modules of checked plugins from source,
+without reading or writing ``__pycache__``, so they stay verifiable.
+
+``security.yml`` (``plugin_signatures``) selects the mode: ``off`` (default),
+``warn`` (load anyway, log and record the result) or ``refuse`` (the loader
+raises :class:`~orlando_toolkit.core.plugins.exceptions.PluginLoadError`).
+Trusted public keys are raw 32-byte Ed25519 keys, base64-encoded, listed under
+``trusted_keys`` (``key_id: key``) or stored as ``<key_id>.pub`` files in
+``trusted_keys_dir``.
+
+Signing and verification need the ``cryptography`` package; without it no
+plugin verifies (status ``unavailable``). :func:`sign_plugin` is meant for
+release tooling.
+"""
+
+import base64
+import hashlib
+import json
+import logging
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "SIGNATURE_FILE",
+    "SignaturePolicy",
+    "SignatureResult",
+    "bytecode_files",
+    "manifest_digest",
+    "verify_plugin",
+    "sign_plugin",
+    "public_key_for",
+]
+
+SIGNATURE_FILE = "plugin.sig"
+_MODES = ("off", "warn", "refuse")
+_EXCLUDED_DIRS = frozenset({".git", ".hg", ".svn"})
+_BYTECODE_SUFFIXES = frozenset({".pyc", ".pyo"})
+
+
+# ---------------------------------------------------------------------------
+# Ed25519 (``cryptography``)
+# ---------------------------------------------------------------------------
+
+def _ed25519():
+    """The ``cryptography`` Ed25519 module, or None when the package is missing."""
+    try:
+        from cryptography.hazmat.primitives.asymmetric import ed25519  # type: ignore
+    except ImportError:
+        return None
+    return ed25519
+
+
+def _require_ed25519():
+    ed25519 = _ed25519()
+    if ed25519 is None:
+        raise RuntimeError("Plugin signing needs the 'cryptography' package")
+    return ed25519
+
+
+def public_key_for(secret: bytes) -> bytes:
+    """Return the 32-byte public key of an Ed25519 *secret* seed."""
+    from cryptography.hazmat.primitives.serialization import Encoding, PublicFormat  # type: ignore
+
+    private = _require_ed25519().Ed25519PrivateKey.from_private_bytes(secret)
+    return private.public_key().public_bytes(Encoding.Raw, PublicFormat.Raw)
+
+
+def _sign(secret: bytes, message: bytes) -> bytes:
+    return _require_ed25519().Ed25519PrivateKey.from_private_bytes(secret).sign(message)
+
+
+def _verify(ed25519, public: bytes, message: bytes, signature: bytes) -> bool:
+    if len(signature) != 64:
+        return False
+    try:
+        ed25519.Ed25519PublicKey.from_public_bytes(public).verify(signature, message)
+        return True
+    except Exception:
+        return False
+
+
+# ---------------------------------------------------------------------------
+# Policy and verification
+# ---------------------------------------------------------------------------
+
+def _decode_key(value: Any) -> Optional[bytes]:
+    try:
+        key = base64.b64decode(str(value).strip(), validate=True)
+    except (ValueError, TypeError):
+        return None
+    return key if len(key) == 32 else None
+
+
+@dataclass(frozen=True)
+class SignaturePolicy:
+    mode: str = "off"
+    trusted_keys: Mapping[str, bytes] = field(default_factory=dict)
+
+    @property
+    def enabled(self) -> bool:
+        return self.mode != "off"
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "SignaturePolicy":
+        data = data or {}
+        mode = str(data.get("mode", "off") or "off").strip().lower()
+        if mode not in _MODES:
+            logger.warning("Unknown plugin_signatures.mode %r; using refuse", mode)
+            mode = "refuse"  # a typo must not silently disable verification
+        keys: Dict[str, bytes] = {}
+        for key_id, value in (data.get("trusted_keys") or {}).items():
+            key = _decode_key(value)
+            if key is None:
+                logger.warning("Ignoring malformed trusted plugin key %r", key_id)
+            else:
+                keys[str(key_id)] = key
+        keys_dir = data.get("trusted_keys_dir")
+        if keys_dir:
+            directory = Path(str(keys_dir)).expanduser()
+            for pub in sorted(directory.glob("*.pub")) if directory.is_dir() else ():
+                key = _decode_key(pub.read_text(encoding="utf-8"))
+                if key is None:
+                    logger.warning("Ignoring malformed trusted plugin key file %s", pub)
+                else:
+                    keys.setdefault(pub.stem, key)
+        return cls(mode=mode, trusted_keys=keys)
+
+    @classmethod
+    def from_config(cls) -> "SignaturePolicy":
+        try:
+            from orlando_toolkit.config import ConfigManager
+            return cls.from_mapping((ConfigManager().get_security_config() or {}).get("plugin_signatures"))
+        except Exception as exc:
+            logger.debug("Security config unavailable, plugin signatures not checked: %s", exc)
+            return cls()
+
+
+@dataclass(frozen=True)
+class SignatureResult:
+    """Outcome of :func:`verify_plugin`: ``valid``, ``unsigned``, ``untrusted``, ``invalid`` or ``unavailable``."""
+
+    status: str
+    message: str
+    key_id: Optional[str] = None
+
+    @property
+    def ok(self) -> bool:
+        return self.status == "valid"
+
+
+def manifest_digest(plugin_dir: str | Path) -> bytes:
+    """Return the canonical file listing a plugin signature covers."""
+    root = Path(plugin_dir)
+    lines = []
+    for path in sorted(root.rglob("*")):
+        rel = path.relative_to(root)
+        if not path.is_file() or _EXCLUDED_DIRS.intersection(rel.parts[:-1]):
+            continue
+        if rel.as_posix() == SIGNATURE_FILE:
+            continue
+        lines.append(f"{hashlib.sha256(path.read_bytes()).hexdigest()}  {rel.as_posix()}\n")
+    return "".join(lines).encode("utf-8")
+
+
+def bytecode_files(plugin_dir: str | Path) -> List[str]:
+    """Relative paths of the compiled bytecode (``__pycache__``, ``*.pyc``) under *plugin_dir*."""
+    root = Path(plugin_dir)
+    found = []
+    for path in sorted(root.rglob("*")):
+        rel = path.relative_to(root)
+        if _EXCLUDED_DIRS.intersection(rel.parts[:-1]):
+            continue
+        if path.suffix in _BYTECODE_SUFFIXES or (path.is_dir() and path.name == "__pycache__"):
+            found.append(rel.as_posix())
+    return found
+
+
+def verify_plugin(plugin_dir: str | Path, policy: SignaturePolicy) -> SignatureResult:
+    """Check the ``plugin.sig`` of *plugin_dir* against *policy*'s trusted keys."""
+    sig_path = Path(plugin_dir) / SIGNATURE_FILE
+    if not sig_path.is_file():
+        return SignatureResult("unsigned", f"no {SIGNATURE_FILE}")
+    bytecode = bytecode_files(plugin_dir)
+    if bytecode:
+        return SignatureResult("invalid", f"compiled bytecode not covered by the signature: {', '.join(bytecode[:5])}"
+                                          "; delete it and load the plugin again")
+    ed25519 = _ed25519()
+    if ed25519 is None:
+        return SignatureResult("unavailable", "the 'cryptography' package is not installed")
+    try:
+        data = json.loads(sig_path.read_text(encoding="utf-8"))
+        key_id = str(data["key_id"])
+        algorithm = str(data.get("algorithm", "ed25519")).lower()
+        signature = base64.b64decode(str(data["signature"]), validate=True)
+    except (OSError, ValueError, KeyError, TypeError) as exc:
+        return SignatureResult("invalid", f"unreadable {SIGNATURE_FILE}: {exc}")
+    if algorithm != "ed25519":
+        return SignatureResult("invalid", f"unsupported algorithm {algorithm!r}", key_id)
+    key = policy.trusted_keys.get(key_id)
+    if key is None:
+        return SignatureResult("untrusted", f"signed with untrusted key {key_id!r}", key_id)
+    if not _verify(ed25519, key, manifest_digest(plugin_dir), signature):
+        return SignatureResult("invalid", f"signature by {key_id!r} does not match the plugin files", key_id)
+    return SignatureResult("valid", f"signed by {key_id!r}", key_id)
+
+
+def sign_plugin(plugin_dir: str | Path, secret: bytes, key_id: str) -> Path:
+    """Write ``plugin.sig`` for *plugin_dir* with the Ed25519 *secret* seed."""
+    signature = _sign(secret, manifest_digest(plugin_dir))
+    path = Path(plugin_dir) / SIGNATURE_FILE
+    payload = {"format": 1, "algorithm": "ed25519", "key_id": key_id,
+               "signature": base64.b64encode(signature).decode("ascii")}
+    path.write_text(json.dumps(payload, indent=2) + "\n", encoding="utf-8")
+    return path
@@ -69,6 +69,7 @@ class UIRegistry:
     The registry supports:
     - Panel factory registration for right panel extensions
     - Marker provider registration for scrollbar marker extensions
+    - Interface translations shipped by plugins
     - Component lifecycle management and cleanup
     - Plugin isolation to prevent UI failures from affecting core app
     """
@@ -318,6 +319,52 @@ class UIRegistry:
         """Get workflow launcher for a plugin, if any."""
         return self._workflow_launchers.get(plugin_id)
     
+    # Interface Translations
+
+    def register_translations(self, plugin_id: str, language: str, entries: Dict[str, str]) -> None:
+        """Register interface translations of a plugin (English text -> translation).
+
+        A plugin's ``locales/<language>.yml`` catalogs are registered when it
+        is activated; this adds strings built at run time.
+
+        Args:
+            plugin_id: Plugin identifier
+            language: Language code (e.g., "fr")
+            entries: Translations keyed by the English text passed to ``tr()``
+        """
+        from orlando_toolkit.ui.i18n import register_translations
+
+        register_translations(plugin_id, language, entries)
+        if plugin_id not in self._plugin_components:
+            self._plugin_components[plugin_id] = {}
+        languages = self._plugin_components[plugin_id].setdefault('translations', [])
+        if language not in languages:
+            languages.append(language)
+        logger.info(f"Registered {len(entries)} '{language}' translation(s) for plugin '{plugin_id}'")
+
+    def load_translation_files(self, plugin_id: str, directory: Any) -> List[str]:
+        """Register the ``<language>.yml`` catalogs of a plugin folder; returns their languages."""
+        from orlando_toolkit.ui.i18n import load_translation_files
+
+        languages = load_translation_files(plugin_id, directory)
+        if languages:
+            if plugin_id not in self._plugin_components:
+                self._plugin_components[plugin_id] = {}
+            self._plugin_components[plugin_id].setdefault('translations', []).extend(
+                lang for lang in languages if lang not in self._plugin_components[plugin_id]['translations'])
+            logger.info(f"Loaded translations {languages} for plugin '{plugin_id}'")
+        return languages
+
+    def unregister_translations(self, plugin_id: str) -> None:
+        """Forget the interface translations of a plugin."""
+        from orlando_toolkit.ui.i18n import unregister_translations
+
+        unregister_translations(plugin_id)
+        if plugin_id in self._plugin_components:
+            self._plugin_components[plugin_id].pop('translations', None)
+            if not self._plugin_components[plugin_id]:
+                del self._plugin_components[plugin_id]
+
     # Plugin Capability Management
     
     def register_plugin_capability(self, plugin_id: str, capability: str) -> None:
@@ -423,6 +470,10 @@ class UIRegistry:
                 if 'markers' in components:
                     for marker_type in list(components['markers'].keys()):
                         self.unregister_marker_provider(plugin_id, marker_type)
+
+                # Clean up interface translations
+                if 'translations' in components:
+                    self.unregister_translations(plugin_id)
                 
                 # Remove plugin from tracking
                 if plugin_id in self._plugin_components:
@@ -0,0 +1,271 @@
+from __future__ import annotations
+
+"""HTML rendering of the Word section a topic was converted from.
+
+The side-by-side preview shows the selected topic next to the part of its
+source document it came from, so fidelity can be checked without opening
+Word. The source is ``metadata["source_file"]`` (recorded by
+:meth:`~orlando_toolkit.core.services.conversion_service.ConversionService.convert`).
+
+Topics are matched to the headings of ``word/document.xml`` by title, in
+map order, compared like the TOC check does (case, spacing and leading
+section numbers ignored). A section runs until the next heading matched to
+another topic, so content merged into a topic (depth limit, manual merges)
+shows under it.
+
+Only what matters for a visual comparison is rendered: headings,
+paragraphs with bold/italic/underline runs, list paragraphs, tables and
+images (written to the session folder, as in the topic preview). The
+document is parsed once per file and modification time.
+"""
+
+import hashlib
+import html
+import logging
+import posixpath
+import re
+import zipfile
+from dataclasses import dataclass
+from functools import lru_cache
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.toc_check import _normalize, map_outline
+from orlando_toolkit.core.word_source import DOCUMENT, R_NS, WordSource, w
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["SourcePreviewUnavailable", "SourceBlock", "read_source_blocks", "render_source_section"]
+
+_A = "http://schemas.openxmlformats.org/drawingml/2006/main"
+_HEADING_NAME = re.compile(r"^heading\s*(\d)$", re.I)
+_IMAGE_TYPES = {".png": "png", ".jpg": "jpg", ".jpeg": "jpg", ".gif": "gif", ".bmp": "bmp"}
+_STYLE = ("body{font-family:Segoe UI,Arial,sans-serif;font-size:10pt;margin:8px;}"
+          "table{border-collapse:collapse;margin:6px 0;}td{border:1px solid #999;padding:3px 5px;vertical-align:top;}"
+          "img{max-width:100%;}.li{margin:2px 0;}")
+
+
+class SourcePreviewUnavailable(ValueError):
+    """The source section of a topic cannot be shown; the message says why."""
+
+
+@dataclass(frozen=True)
+class SourceBlock:
+    """One body element of the document: a heading (``level``) or other content."""
+
+    html: str
+    level: Optional[int] = None
+    title: str = ""
+
+
+def _on(props: Optional[ET._Element], tag: str) -> bool:
+    el = props.find(w(tag)) if props is not None else None
+    return el is not None and (el.get(w("val")) or "true") not in ("0", "false", "none")
+
+
+def _style_levels(docx: WordSource, mapped: Mapping[str, int]) -> Dict[str, int]:
+    """Heading level of each paragraph style id (style map by style name, built-in headings, outline levels)."""
+    root = docx.part("word/styles.xml")
+    if root is None:
+        return {}
+    levels: Dict[str, int] = {}
+    based_on: Dict[str, str] = {}
+    for style in root.iter(w("style")):
+        style_id = style.get(w("styleId")) or ""
+        name_el = style.find(w("name"))
+        name = (name_el.get(w("val")) if name_el is not None else None) or style_id
+        parent = style.find(w("basedOn"))
+        if parent is not None:
+            based_on[style_id] = parent.get(w("val")) or ""
+        outline = style.find(f"{w('pPr')}/{w('outlineLvl')}")
+        match = _HEADING_NAME.match(name)
+        if name.casefold() in mapped:
+            levels[style_id] = mapped[name.casefold()]
+        elif match:
+            levels[style_id] = int(match.group(1))
+        elif outline is not None and (outline.get(w("val")) or "9").isdigit() and int(outline.get(w("val"))) < 9:
+            levels[style_id] = int(outline.get(w("val"))) + 1
+    for style_id in based_on:
+        seen, current = set(), style_id
+        while current not in levels and current in based_on and current not in seen:
+            seen.add(current)
+            current = based_on[current]
+        if current in levels and style_id not in levels:
+            levels[style_id] = levels[current]
+    return levels
+
+
+class _Renderer:
+    def __init__(self, docx: WordSource, levels: Dict[str, int]) -> None:
+        self.docx = docx
+        self.levels = levels
+        self.targets = {rel: target for rel, (target, _external) in docx.relationships(DOCUMENT).items()}
+
+    def level(self, paragraph: ET._Element) -> Optional[int]:
+        props = paragraph.find(w("pPr"))
+        if props is None:
+            return None
+        outline = props.find(w("outlineLvl"))
+        if outline is not None and (outline.get(w("val")) or "9").isdigit() and int(outline.get(w("val"))) < 9:
+            return int(outline.get(w("val"))) + 1
+        style = props.find(w("pStyle"))
+        return self.levels.get(style.get(w("val")) or "") if style is not None else None
+
+    def image(self, rel_id: str) -> str:
+        name = self.targets.get(rel_id, "")
+        kind = _IMAGE_TYPES.get(posixpath.splitext(name)[1].lower())
+        if not kind:
+            return f"<i>[image {html.escape(posixpath.basename(name) or rel_id)}]</i>"
+        try:
+            from orlando_toolkit.core.session_storage import get_session_storage
+
+            blob = self.docx.read(name)
+            path = get_session_storage().ensure_image_written(
+                f"src_{hashlib.md5(blob).hexdigest()[:12]}.{kind}", blob)
+            return f'<img src="{path.as_uri()}"/>'
+        except Exception as exc:
+            logger.debug("Source image %s unavailable: %s", name, exc)
+            return f"<i>[image {html.escape(posixpath.basename(name))}]</i>"
+
+    def inline(self, parent: ET._Element) -> str:
+        parts: List[str] = []
+        for node in parent:
+            tag = node.tag if isinstance(node.tag, str) else ""
+            if tag == w("r"):
+                parts.append(self.run(node))
+            elif tag in (w("hyperlink"), w("ins"), w("smartTag"), w("fldSimple"), w("sdt"),
+                         w("sdtContent"), w("customXml")):
+                parts.append(self.inline(node))
+        return "".join(parts)
+
+    def run(self, run: ET._Element) -> str:
+        text: List[str] = []
+        for node in run.iter():
+            if node.tag == w("t"):
+                text.append(html.escape(node.text or ""))
+            elif node.tag == w("tab"):
+                text.append(" ")
+            elif node.tag in (w("br"), w("cr")):
+                text.append("<br/>")
+            elif node.tag == f"{{{_A}}}blip":
+                text.append(self.image(node.get(f"{{{R_NS}}}embed") or ""))
+        content = "".join(text)
+        props = run.find(w("rPr"))
+        for tag, element in (("b", "b"), ("i", "i"), ("u", "u"), ("strike", "s")):
+            if content and _on(props, tag):
+                content = f"<{element}>{content}</{element}>"
+        return content
+
+    def paragraph(self, paragraph: ET._Element) -> SourceBlock:
+        content = self.inline(paragraph)
+        level = self.level(paragraph)
+        if level is not None:
+            title = " ".join("".join(t.text or "" for t in paragraph.iter(w("t"))).split())
+            return SourceBlock(f"<h{min(level, 6)}>{content}</h{min(level, 6)}>", level=level, title=title)
+        numbering = paragraph.find(f"{w('pPr')}/{w('numPr')}")
+        if numbering is not None:
+            depth = numbering.find(w("ilvl"))
+            indent = 1 + 1.5 * int((depth.get(w("val")) if depth is not None else "0") or 0)
+            return SourceBlock(f'<p class="li" style="margin-left:{indent}em">&#8226; {content}</p>')
+        return SourceBlock(f"<p>{content or '&#160;'}</p>")
+
+    def table(self, table: ET._Element) -> SourceBlock:
+        rows: List[str] = []
+        for row in table.findall(w("tr")):
+            cells: List[str] = []
+            for cell in row.findall(w("tc")):
+                span = cell.find(f"{w('tcPr')}/{w('gridSpan')}")
+                colspan = f' colspan="{span.get(w("val"))}"' if span is not None else ""
+                cells.append(f"<td{colspan}>{''.join(b.html for b in self.blocks(cell))}</td>")
+            rows.append(f"<tr>{''.join(cells)}</tr>")
+        return SourceBlock(f"<table>{''.join(rows)}</table>")
+
+    def blocks(self, parent: ET._Element) -> List[SourceBlock]:
+        result: List[SourceBlock] = []
+        for node in parent:
+            if node.tag == w("p"):
+                result.append(self.paragraph(node))
+            elif node.tag == w("tbl"):
+                result.append(self.table(node))
+            elif node.tag in (w("sdt"), w("sdtContent"), w("customXml")):
+                result.extend(self.blocks(node))
+        return result
+
+
+@lru_cache(maxsize=4)
+def _cached_blocks(path: str, mtime: float, headings: Tuple[Tuple[str, int], ...]) -> Tuple[SourceBlock, ...]:
+    docx = WordSource(path)
+    if DOCUMENT not in docx:
+        raise KeyError(f"There is no item named {DOCUMENT!r} in the archive")
+    body = docx.body
+    renderer = _Renderer(docx, _style_levels(docx, dict(headings)))
+    return tuple(renderer.blocks(body)) if body is not None else ()
+
+
+def read_source_blocks(path: str | Path, style_map: Optional[Mapping[str, Any]] = None) -> List[SourceBlock]:
+    """Body blocks of a ``.docx`` in document order; *style_map* adds custom heading styles."""
+    from orlando_toolkit.core.style_map import parse_style_rule
+
+    path = Path(path)
+    headings: Dict[str, int] = {}
+    for style, value in (style_map or {}).items():
+        try:
+            rule = parse_style_rule(str(style), value)
+        except ValueError:
+            continue
+        if rule.heading is not None:
+            headings[str(style).casefold()] = rule.heading
+    return list(_cached_blocks(str(path), path.stat().st_mtime, tuple(sorted(headings.items()))))
+
+
+def _match_headings(outline: List[Any], blocks: List[SourceBlock]) -> Dict[str, int]:
+    """Block index of the heading of each topic (by href), matched by title in map order."""
+    headings = [i for i, block in enumerate(blocks) if block.level is not None]
+    keys = {i: _normalize(blocks[i].title) for i in headings}
+    matched: Dict[str, int] = {}
+    used: set = set()
+    position = -1
+    for entry in outline:
+        if not entry.anchor:
+            continue
+        key = _normalize(entry.title)
+        candidates = [i for i in headings if i > position and i not in used and keys[i] == key]
+        candidates = candidates or [i for i in headings if i not in used and keys[i] == key]
+        if candidates:
+            matched[entry.anchor.split("#")[0].split("/")[-1]] = candidates[0]
+            used.add(candidates[0])
+            position = candidates[0]
+    return matched
+
+
+def render_source_section(context: Any, node: ET._Element) -> str:
+    """HTML of the source section of the topic referenced by *node* (a ``topicref``).
+
+    Raises :class:`SourcePreviewUnavailable` when the context has no Word
+    source, the file is gone, or no heading matches the topic.
+    """
+    metadata = getattr(context, "metadata", None) or {}
+    source = metadata.get("source_file")
+    if not source:
+        raise SourcePreviewUnavailable("No source document is recorded for this structure.")
+    path = Path(str(source))
+    if not path.is_file():
+        raise SourcePreviewUnavailable(f"The source document was not found: {path}")
+    if path.suffix.lower() not in (".docx", ".docm") or not zipfile.is_zipfile(path):
+        raise SourcePreviewUnavailable(f"The source preview supports Word documents only ({path.name}).")
+    name = (node.get("href") or "").split("#")[0].split("/")[-1] if node is not None and node.get("href") else ""
+    if not name:
+        raise SourcePreviewUnavailable("Select a topic to compare it with its source section.")
+    try:
+        blocks = read_source_blocks(path, metadata.get("style_map"))
+    except (OSError, KeyError, zipfile.BadZipFile, ET.XMLSyntaxError) as exc:
+        raise SourcePreviewUnavailable(f"The source document could not be read: {exc}") from exc
+    matched = _match_headings(map_outline(context), blocks)
+    if name not in matched:
+        raise SourcePreviewUnavailable("No heading of the source document matches this topic.")
+    start = matched[name]
+    end = min((i for i in matched.values() if i > start), default=len(blocks))
+    body = "".join(block.html for block in blocks[start:end])
+    return f"<html><head><style>{_STYLE}</style></head><body>{body}</body></html>"
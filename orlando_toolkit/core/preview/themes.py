@@ -0,0 +1,132 @@
+from __future__ import annotations
+
+"""Preview themes: the stylesheet and scale of the HTML topic preview.
+
+The preview compiler emits an HTML fragment with inline layout styles. A
+theme is the CSS the fragment is shown with, so the preview can approximate
+a corporate stylesheet or follow a dark desktop. Themes come from the
+``themes`` section of ``preview_styles.yml``; each has ``label``, ``css``
+(inline rules), ``css_file`` (a stylesheet relative to the user
+configuration folder, appended after ``css``), ``dark`` and ``extends`` (a
+theme whose CSS comes first).
+
+The theme of a conversion is ``metadata["preview_theme"]`` (set by an
+output profile), else ``preview.theme``, else ``light``. An unknown name
+falls back to ``light``. :func:`preview_scale` turns the screen density
+into the zoom factor of the preview widget (``preview.scale``: ``auto`` or
+a number).
+"""
+
+import html
+import logging
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["PreviewTheme", "available_themes", "get_theme", "theme_name", "preview_scale", "themed_document"]
+
+DEFAULT_THEME = "light"
+METADATA_KEY = "preview_theme"
+
+
+@dataclass
+class PreviewTheme:
+    name: str
+    label: str = ""
+    css: str = ""
+    dark: bool = False
+
+
+def _preview_styles() -> Mapping[str, Any]:
+    from orlando_toolkit.config import ConfigManager
+
+    return ConfigManager().get_preview_styles() or {}
+
+
+def _read_css_file(name: str) -> str:
+    from orlando_toolkit.config.manager import _get_user_config_dir
+
+    path = Path(name).expanduser()
+    if not path.is_absolute():
+        path = _get_user_config_dir() / path
+    try:
+        return path.read_text(encoding="utf-8")
+    except OSError as exc:
+        logger.warning("Preview theme stylesheet %s could not be read: %s", path, exc)
+        return ""
+
+
+def available_themes(config: Optional[Mapping[str, Any]] = None) -> Dict[str, PreviewTheme]:
+    """Themes by name, ``extends`` and ``css_file`` resolved (from *config*, else ``preview_styles.yml``)."""
+    specs = dict((config if config is not None else _preview_styles()).get("themes") or {})
+    specs.setdefault(DEFAULT_THEME, {})
+    themes: Dict[str, PreviewTheme] = {}
+
+    def _resolve(name: str, seen: List[str]) -> PreviewTheme:
+        if name in themes:
+            return themes[name]
+        spec = specs.get(name) or {}
+        parent = spec.get("extends")
+        base = None
+        seen = seen + [name]
+        if parent and parent in specs and parent not in seen:
+            base = _resolve(parent, seen)
+        elif parent:
+            logger.warning("Preview theme %r extends unknown or circular theme %r", name, parent)
+        css = [base.css] if base is not None and base.css else []
+        if spec.get("css"):
+            css.append(str(spec["css"]).strip())
+        if spec.get("css_file"):
+            css.append(_read_css_file(str(spec["css_file"])).strip())
+        dark = spec.get("dark")
+        themes[name] = PreviewTheme(name=name, label=str(spec.get("label") or name.replace("_", " ").title()),
+                                    css="\n".join(part for part in css if part),
+                                    dark=bool(base.dark if dark is None and base is not None else dark))
+        return themes[name]
+
+    for theme in specs:
+        _resolve(str(theme), [])
+    return themes
+
+
+def theme_name(metadata: Optional[Mapping[str, Any]] = None, config: Optional[Mapping[str, Any]] = None) -> str:
+    """Name of the preview theme for a conversion with *metadata*."""
+    chosen = (metadata or {}).get(METADATA_KEY)
+    if not chosen:
+        settings = (config if config is not None else _preview_styles()).get("preview") or {}
+        chosen = settings.get("theme")
+    return str(chosen or DEFAULT_THEME)
+
+
+def get_theme(name: Optional[str] = None, config: Optional[Mapping[str, Any]] = None) -> PreviewTheme:
+    """Theme *name*, or the default theme when it is not defined."""
+    themes = available_themes(config)
+    if name and name not in themes:
+        logger.warning("Unknown preview theme %r; using %r", name, DEFAULT_THEME)
+    return themes.get(name or DEFAULT_THEME) or themes[DEFAULT_THEME]
+
+
+def preview_scale(dpi: Optional[float] = None, config: Optional[Mapping[str, Any]] = None) -> float:
+    """Zoom factor of the preview: ``preview.scale``, or *dpi* / 96 when it is ``auto``.
+
+    Auto never goes below 1; a missing or unreadable *dpi* gives 1.
+    """
+    setting = ((config if config is not None else _preview_styles()).get("preview") or {}).get("scale", "auto")
+    if str(setting).strip().lower() != "auto":
+        try:
+            return max(float(setting), 0.5)
+        except (TypeError, ValueError):
+            logger.warning("Invalid preview scale %r; using auto", setting)
+    try:
+        return max(1.0, round(float(dpi) / 96.0, 2)) if dpi else 1.0
+    except (TypeError, ValueError):
+        return 1.0
+
+
+def themed_document(fragment: str, theme: PreviewTheme) -> str:
+    """Full HTML document showing the compiler's *fragment* with *theme*."""
+    classes = f"theme-{theme.name}" + (" dark" if theme.dark else "")
+    return (f"<html><head><meta charset=\"utf-8\"><style>{theme.css}</style></head>"
+            f"<body class=\"{html.escape(classes)}\">{fragment}</body></html>")
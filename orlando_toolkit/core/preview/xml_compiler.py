@@ -7,11 +7,15 @@ This module is **read-only** and has *no* GUI dependencies.  It relies only on
 that it can be reused in tests, CLI tools or future features.
 """
 
-from typing import Optional, TYPE_CHECKING
+from typing import Callable, Iterable, List, Optional, Tuple, TYPE_CHECKING
 from lxml import etree as ET  # type: ignore
 import os
+import re
 import importlib.resources as pkg_resources
 from orlando_toolkit.config import ConfigManager
+from orlando_toolkit.core.i18n import document_language, get_catalog
+from orlando_toolkit.core.preview import themes
+from orlando_toolkit.core.xml_security import parse_bytes, safe_xslt
 
 if TYPE_CHECKING:  # pragma: no cover - for type checkers only
     from orlando_toolkit.core.models import DitaContext  # noqa: F401
@@ -90,29 +94,120 @@ def get_raw_topic_xml(ctx: "DitaContext", tref: ET.Element, *, pretty: bool = Tr
 
     # Structural heading only – return minimal representation
     title_el = tref.find("topicmeta/navtitle")
-    title_txt = title_el.text if title_el is not None else "(untitled)"
+    title_txt = title_el.text if title_el is not None else get_catalog().get("untitled_preview", document_language(ctx))
     temp = ET.Element("topichead")
     ET.SubElement(temp, "title").text = title_txt
     return ET.tostring(temp, pretty_print=pretty, encoding="unicode")
 
 
+# Revision bars: elements with @rev are wrapped in a styled span/div (preview only)
+_REVISED_STYLE = "border-left:3px solid #d9822b;padding-left:4px;background-color:#fff4e5;"
+_REVISED_INLINE = frozenset({"ph", "b", "i", "u", "sup", "sub", "xref", "codeph", "term", "keyword", "uicontrol",
+                             "q", "tm", "cite"})
+_REVISED_CONTAINERS = frozenset({"li", "step", "entry", "stentry", "dt", "dd", "row", "strow"})
+
+
+def _highlight_revisions(tree: ET.Element) -> int:
+    """Mark the elements of *tree* that carry ``@rev`` with a revision bar; returns how many."""
+    revised = [el for el in tree.iter() if isinstance(el.tag, str) and el.get("rev")]
+    for el in revised:
+        attrs = {"style": _REVISED_STYLE, "title": f"rev {el.get('rev')}"}
+        inside = el.tag in _REVISED_CONTAINERS
+        if el is tree:
+            # A new topic: bar along its whole body
+            el = next((c for c in tree if isinstance(c.tag, str) and c.tag.endswith("body")), None)
+            inside = True
+            if el is None:
+                continue
+        if inside:
+            wrapper = ET.Element("div", attrs)
+            wrapper.text, el.text = el.text, None
+            for child in list(el):
+                wrapper.append(child)
+            el.append(wrapper)
+            continue
+        parent = el.getparent()
+        if parent is None:
+            continue
+        wrapper = ET.Element("span" if el.tag in _REVISED_INLINE else "div", attrs)
+        wrapper.tail, el.tail = el.tail, None
+        parent.replace(el, wrapper)
+        wrapper.append(el)
+    return len(revised)
+
+
+# Spelling: suspected words are wrapped in an underlined span (preview only)
+_MISSPELLED_STYLE = "border-bottom:2px dotted #d32f2f;"
+_NO_SPELLING = frozenset({"codeblock", "pre", "codeph", "screen", "msgblock", "filepath", "cmdname", "varname",
+                          "userinput", "systemoutput"})
+
+
+def _highlight_misspellings(tree: ET.Element, words: Iterable[str]) -> int:
+    """Underline the whole-word uses of *words* in the text of *tree*; returns how many."""
+    words = sorted({w for w in words if w}, key=len, reverse=True)
+    if not words:
+        return 0
+    pattern = re.compile(r"(?<!\w)(" + "|".join(re.escape(w) for w in words) + r")(?!\w)")
+    count = 0
+
+    def _spans(text: str) -> Tuple[str, List[ET.Element]]:
+        nonlocal count
+        parts = pattern.split(text)
+        spans: List[ET.Element] = []
+        for index in range(1, len(parts), 2):
+            span = ET.Element("span", {"style": _MISSPELLED_STYLE, "title": "Spelling?"})
+            span.text, span.tail = parts[index], parts[index + 1]
+            spans.append(span)
+            count += 1
+        return parts[0], spans
+
+    for el in list(tree.iter()):
+        if not isinstance(el.tag, str) or any(a.tag in _NO_SPELLING for a in el.iterancestors()) \
+                or el.tag in _NO_SPELLING:
+            continue
+        if el.text:
+            el.text, spans = _spans(el.text)
+            for offset, span in enumerate(spans):
+                el.insert(offset, span)
+        for child in list(el):
+            if child.tail:
+                child.tail, spans = _spans(child.tail)
+                for offset, span in enumerate(spans, start=el.index(child) + 1):
+                    el.insert(offset, span)
+    return count
+
+
 # ---------------------------------------------------------------------------
 # Raw topic + HTML renderer
 # ---------------------------------------------------------------------------
 
-def render_html_preview(ctx: "DitaContext", tref: ET.Element, *, pretty: bool = True) -> str:  # noqa: D401
+def render_html_preview(ctx: "DitaContext", tref: ET.Element, *, pretty: bool = True,
+                        highlight_revisions: bool = False,
+                        misspelled: Optional[Iterable[str]] = None,
+                        image_href: Optional[Callable[[str], Optional[str]]] = None,
+                        link_href: Optional[Callable[[str], Optional[str]]] = None,
+                        theme: Optional[str] = None) -> str:  # noqa: D401
     """Return simple HTML preview for the selected heading/topic.
 
     Uses an internal minimal XSLT transform so we avoid external
-    dependencies and keep the codebase self-contained.
+    dependencies and keep the codebase self-contained. With
+    *highlight_revisions* the elements marked with ``@rev`` get a revision bar
+    and the words of *misspelled* are underlined.
+    *image_href* (image file name -> src) and *link_href* (xref href -> href)
+    point images and links elsewhere than the session files, for pages
+    written out of the application; None keeps the default.
+    With a *theme* name the fragment is returned as a full document styled
+    by that preview theme (see :mod:`orlando_toolkit.core.preview.themes`).
     """
 
     xml_str = get_raw_topic_xml(ctx, tref, pretty=False)
+    catalog = get_catalog()
+    lang = document_language(ctx)
 
     # Ensure images resolve in HTML preview by materializing them to temp files
     # and updating hrefs to file URIs (works reliably with tkinterweb).
     try:
-        tree = ET.fromstring(xml_str.encode())
+        tree = parse_bytes(xml_str, source="preview")
 
         import mimetypes
         import hashlib
@@ -144,6 +239,11 @@ def render_html_preview(ctx: "DitaContext", tref: ET.Element, *, pretty: bool =
         for img in tree.findall('.//image'):
             href = img.get('href', '')
             fname = Path(href).name
+            if image_href is not None and fname in getattr(ctx, 'images', {}):
+                target = image_href(fname)
+                if target:
+                    img.set('href', target)
+                    continue
             if fname in getattr(ctx, 'images', {}):
                 blob = ctx.images[fname]  # type: ignore[attr-defined]
                 mime, _ = mimetypes.guess_type(fname)
@@ -157,6 +257,12 @@ def render_html_preview(ctx: "DitaContext", tref: ET.Element, *, pretty: bool =
                 out_path = storage.ensure_image_written(f"img_{h}.{ext}", blob)
                 img.set('href', out_path.as_uri())
 
+        if link_href is not None:
+            for xref in tree.iter('xref'):
+                target = link_href(xref.get('href') or '')
+                if target is not None:
+                    xref.set('href', target)
+
         # 3) Sanitize embedded media (video/object) for preview stability
         #    Replace <object>/<video> with a lightweight placeholder so the HTML engine
         #    does not attempt media playback inside the preview pane.
@@ -167,7 +273,7 @@ def render_html_preview(ctx: "DitaContext", tref: ET.Element, *, pretty: bool =
                 # Try to show filename if available
                 data = obj.get('data') or obj.get('href') or ''
                 name = os.path.basename(str(data)) if data else 'media'
-                ph.text = f"[Video: {name}]"
+                ph.text = catalog.get("video_placeholder", lang, name=name)
                 parent = obj.getparent()
                 if parent is not None:
                     parent.replace(obj, ph)
@@ -176,13 +282,41 @@ def render_html_preview(ctx: "DitaContext", tref: ET.Element, *, pretty: bool =
                 ph = ET.Element('p')
                 href = vid.get('href') or ''
                 name = os.path.basename(str(href)) if href else 'media'
-                ph.text = f"[Video: {name}]"
+                ph.text = catalog.get("video_placeholder", lang, name=name)
                 parent = vid.getparent()
                 if parent is not None:
                     parent.replace(vid, ph)
         except Exception:
             pass
 
+        # 4) Localized note labels (preview only; exported DITA is unchanged)
+        try:
+            for note in tree.iter('note'):
+                note_type = (note.get('type') or 'note').strip().lower()
+                if note_type == 'other' and note.get('othertype'):
+                    label = note.get('othertype')
+                elif note_type == 'note':
+                    label = catalog.get("note_label", lang)
+                else:
+                    label = catalog.get(f"note_{note_type}", lang)
+                    if label == f"note_{note_type}":
+                        label = catalog.get("note_label", lang)
+                note.set('data-note-label', catalog.get("note_heading", lang, label=label))
+        except Exception:
+            pass
+
+        # 5) Revision bars (preview only; exported DITA is unchanged)
+        if highlight_revisions:
+            try:
+                _highlight_revisions(tree)
+            except Exception:
+                pass
+        if misspelled:
+            try:
+                _highlight_misspellings(tree, misspelled)
+            except Exception:
+                pass
+
         xml_str = ET.tostring(tree, encoding='unicode')
     except Exception:
         # Fallback: leave hrefs untouched
@@ -191,9 +325,11 @@ def render_html_preview(ctx: "DitaContext", tref: ET.Element, *, pretty: bool =
     # Load and prepare XSLT with dynamic color mappings
     xslt_content = _load_xslt_template_with_colors()
 
-    xslt_root = ET.XML(xslt_content.encode())  # type: ignore[arg-type]
-    transform = ET.XSLT(xslt_root)  # type: ignore[call-arg]
-    src = ET.fromstring(xml_str.encode())
+    xslt_root = parse_bytes(xslt_content, source="dita_to_html.xslt")
+    transform = safe_xslt(xslt_root)
+    src = parse_bytes(xml_str, source="preview")
     res = transform(src)
     html_content = str(res)
+    if theme is not None:
+        html_content = themes.themed_document(html_content, themes.get_theme(theme))
     return html_content
@@ -0,0 +1,24 @@
+from __future__ import annotations
+
+"""Format-agnostic post-conversion processing stages.
+
+Run by :meth:`ConversionService.finalize_conversion` after a plugin handler has
+produced a :class:`~orlando_toolkit.core.models.DitaContext`. Stage options come
+from ``conversion.yml`` and per-job ``metadata["conversion_options"]``.
+"""
+
+from .base import ProcessingStage
+from .pipeline import (
+    default_stages,
+    resolve_conversion_options,
+    run_processing_stages,
+    strip_stage_hints,
+)
+
+__all__ = [
+    "ProcessingStage",
+    "default_stages",
+    "resolve_conversion_options",
+    "run_processing_stages",
+    "strip_stage_hints",
+]
@@ -0,0 +1,248 @@
+from __future__ import annotations
+
+"""First-use acronym expansion audit.
+
+Style guides ask for every acronym to be spelled out the first time it is
+used in a chapter. The stage walks the topics of each chapter (a top-level
+entry of the map; ``scope: topic`` or ``document`` changes the unit) in map
+order and checks the first body occurrence of every acronym (two or more
+capitals, digits allowed, at most ``max_length`` characters: "API" and
+"MP3" are acronyms, "SaaS" and "CHAPTER" are not). The first use
+counts as expanded when it reads "Application Programming Interface (API)"
+or "API (Application Programming Interface)", the initials of the spelled
+out words matching the acronym, or when the chapter defines it in a ``<dl>``
+or glossary entry. Titles, code and preformatted content are ignored.
+
+Acronyms not expanded at first use are reported under ``acronyms`` with the
+topic of their first use, so they show up with the other quality findings
+of the report. With ``fix: true`` a first use whose expansion is known is
+rewritten "Expansion (ACR)"; expansions come from ``glossary`` (acronym ->
+expansion), ``glossary_file`` (CSV or YAML, read like variable files) and
+the glossary entries and definition lists of the document itself.
+"""
+
+import logging
+import re
+from typing import Any, Dict, Iterable, List, Mapping, Optional, Tuple
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import (
+    get_slot,
+    is_element,
+    is_preformatted,
+    iter_text_slots,
+    local_name,
+    set_slot,
+)
+from orlando_toolkit.core.utils import topic_body
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["AcronymStage", "find_acronyms", "is_expansion"]
+
+_ACRONYM = re.compile(r"(?<![\w&-])([A-Z][A-Z0-9&]*[A-Z0-9])s?(?![\w&])")
+# Section numbers such as "II" or "XIV"; "CLI" or "MD" are acronyms
+_ROMAN = re.compile(r"^X{0,3}(IX|IV|V?I{0,3})$")
+_SCOPES = ("chapter", "topic", "document")
+_SKIP_TAGS = frozenset({
+    "title", "navtitle", "shortdesc", "codeph", "codeblock", "filepath", "cmdname", "varname", "apiname",
+    "userinput", "systemoutput", "option", "parmname", "keyword", "abbreviated-form",
+})
+# Words that may be left out of the initials ("Department of Defense" -> DoD, DOD)
+_MINOR_WORDS = frozenset({"a", "an", "and", "by", "for", "in", "of", "on", "or", "the", "to", "with",
+                          "de", "des", "du", "et", "la", "le", "les", "der", "die", "und", "von"})
+_WORDS = re.compile(r"[^\W_]+(?:['’][^\W_]+)*")
+
+
+def find_acronyms(text: str, *, min_length: int = 2, max_length: int = 6) -> List[Tuple[str, int, int]]:
+    """``(acronym, start, end)`` for the acronyms of *text* (plural "s" excluded).
+
+    Longer capitalised words ("CHAPTER", "WARNING") are emphasis, not acronyms.
+    """
+    found = []
+    for match in _ACRONYM.finditer(text):
+        acronym = match.group(1)
+        capitals = sum(1 for c in acronym if c.isupper())
+        if not min_length <= len(acronym) <= max_length or capitals < 2 or _ROMAN.match(acronym):
+            continue
+        found.append((acronym, match.start(1), match.end(1)))
+    return found
+
+
+def is_expansion(words: Iterable[str], acronym: str) -> bool:
+    """True when the initials of *words* (minor words optional) spell *acronym*."""
+    letters = "".join(c for c in acronym if c.isalnum()).upper()
+    parts: List[str] = []
+    for word in words:
+        parts.extend(p for p in re.split(r"[-/]", word) if p)
+    if not parts or not letters:
+        return False
+    for variant in (parts, [p for p in parts if p.lower() not in _MINOR_WORDS]):
+        # Initials, or initials plus inner capitals ("JavaScript Object Notation" -> JSON)
+        initials = "".join(p[0] for p in variant).upper()
+        capitals = "".join(p[0] + "".join(c for c in p[1:] if c.isupper()) for p in variant).upper()
+        if letters in (initials, capitals):
+            return True
+    return False
+
+
+def _expanded_before(before: str, acronym: str) -> bool:
+    """"Spelled Out Words (ACR" ending *before* (the opening parenthesis included)."""
+    if not before.rstrip().endswith("("):
+        return False
+    words = _WORDS.findall(before.rstrip()[:-1])[-(len(acronym) + 4):]
+    return any(is_expansion(words[-n:], acronym) for n in range(1, len(words) + 1))
+
+
+def _expanded_after(after: str, acronym: str) -> bool:
+    """"ACR (Spelled Out Words)" starting *after*."""
+    match = re.match(r"^\s*\(([^)]{3,120})\)", after)
+    return bool(match) and is_expansion(_WORDS.findall(match.group(1)), acronym)
+
+
+def _skipped(el) -> bool:
+    node = el
+    while node is not None:
+        if is_element(node) and local_name(node) in _SKIP_TAGS:
+            return True
+        node = node.getparent()
+    return is_preformatted(el)
+
+
+def _text(el) -> str:
+    return " ".join("".join(el.itertext()).split())
+
+
+def _defined_terms(topic) -> Dict[str, str]:
+    """Acronyms defined by a glossary entry or definition list entry of *topic*."""
+    terms: Dict[str, str] = {}
+    pairs = [(topic.find("glossterm"), topic.find("glossdef"))] if local_name(topic) == "glossentry" else []
+    pairs += [(entry.find("dt"), entry.find("dd")) for entry in topic.iter("dlentry")]
+    for term, definition in pairs:
+        if term is None or definition is None:
+            continue
+        name = _text(term)
+        if [a for a, _, _ in find_acronyms(name)] == [name]:
+            expansion = _text(definition).rstrip(".")
+            terms[name] = expansion if 0 < len(expansion.split()) <= 10 else ""
+    return terms
+
+
+class AcronymStage(ProcessingStage):
+    name = "acronyms"
+
+    def is_enabled(self, options: Dict[str, Any]) -> bool:
+        return bool(options.get("enabled", False))
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        scope = str(options.get("scope", "chapter"))
+        if scope not in _SCOPES:
+            report.warning(self.name, f"Unknown acronym scope {scope!r} (expected {', '.join(_SCOPES)})")
+            return
+        ignore = {str(a) for a in options.get("ignore") or ()}
+        lengths = (max(2, int(options.get("min_length", 2))), int(options.get("max_length", 6)))
+        fix = bool(options.get("fix", False))
+        glossary = self._glossary(context, options)
+
+        missing_total = fixed_total = 0
+        for chapter, filenames in self._chapters(context, scope):
+            defined: Dict[str, str] = {}
+            for filename in filenames:
+                defined.update(_defined_terms(context.topics[filename]))
+            seen: set = set(ignore) | set(defined)
+            for filename in filenames:
+                missing, fixed = self._audit_topic(context.topics[filename], seen, glossary, lengths, fix)
+                if fixed:
+                    fixed_total += len(fixed)
+                    report.info(self.name, f"Expanded at first use: {', '.join(fixed)}", topic=filename,
+                                chapter=chapter, acronyms=fixed)
+                if missing:
+                    missing_total += len(missing)
+                    where = f" in chapter '{chapter}'" if chapter else ""
+                    report.warning(self.name, f"Acronyms not expanded at first use{where}: {', '.join(missing)}",
+                                   topic=filename, chapter=chapter, acronyms=missing)
+        if missing_total or fixed_total:
+            logger.debug("%d acronym(s) not expanded at first use, %d fixed", missing_total, fixed_total)
+
+    @staticmethod
+    def _glossary(context: DitaContext, options: Mapping[str, Any]) -> Dict[str, str]:
+        from orlando_toolkit.core.processing.variables import load_variables
+
+        glossary: Dict[str, str] = {}
+        for topic in context.topics.values():
+            glossary.update({k: v for k, v in _defined_terms(topic).items() if v})
+        if options.get("glossary_file") or options.get("glossary"):
+            glossary.update(load_variables(options.get("glossary_file"), options.get("glossary")))
+        return {k: " ".join(v.split()) for k, v in glossary.items() if v and v.strip()}
+
+    @staticmethod
+    def _chapters(context: DitaContext, scope: str) -> List[Tuple[str, List[str]]]:
+        """``(chapter title, topic filenames in map order)``."""
+        def _name(ref) -> Optional[str]:
+            name = (ref.get("href") or "").split("#")[0].split("/")[-1]
+            return name if name in context.topics else None
+
+        root = context.ditamap_root
+        ordered: List[str] = []
+        chapters: List[Tuple[str, List[str]]] = []
+        if root is not None:
+            for entry in root:
+                if not is_element(entry) or local_name(entry) not in ("topicref", "topichead"):
+                    continue
+                refs = [r for r in entry.iter() if is_element(r) and local_name(r) == "topicref"]
+                names = list(dict.fromkeys(n for n in map(_name, refs) if n and n not in ordered))
+                ordered.extend(names)
+                navtitle = entry.find("topicmeta/navtitle")
+                title = _text(navtitle) if navtitle is not None else ""
+                if not title and names and context.topics[names[0]].find("title") is not None:
+                    title = _text(context.topics[names[0]].find("title"))
+                chapters.append((title, names))
+        chapters.extend(("", [n]) for n in context.topics if n not in ordered)
+        if scope == "document":
+            return [("", [n for _, names in chapters for n in names])]
+        if scope == "topic":
+            return [("", [n]) for _, names in chapters for n in names]
+        return [c for c in chapters if c[1]]
+
+    @staticmethod
+    def _audit_topic(topic, seen: set, glossary: Mapping[str, str], lengths: Tuple[int, int],
+                     fix: bool) -> Tuple[List[str], List[str]]:
+        body = topic_body(topic)
+        missing: List[str] = []
+        fixed: List[str] = []
+        if body is None:
+            return missing, fixed
+        history = ""
+        for el, slot in iter_text_slots(body):
+            text = get_slot(el, slot)
+            if not text:
+                continue
+            owner = el if slot == "text" else el.getparent()
+            if owner is None or _skipped(owner):
+                history += " "
+                continue
+            # Uppercase blocks ("WARNING: DO NOT OPEN") are not acronym uses
+            letters = [c for c in text if c.isalpha()]
+            if len(letters) > 12 and sum(1 for c in letters if c.isupper()) > 0.7 * len(letters):
+                history += " " + text
+                continue
+            replacements: List[Tuple[int, int, str]] = []
+            for acronym, start, end in find_acronyms(text, min_length=lengths[0], max_length=lengths[1]):
+                if acronym in seen:
+                    continue
+                seen.add(acronym)
+                if _expanded_before(history + text[:start], acronym) or _expanded_after(text[end:], acronym):
+                    continue
+                if fix and acronym in glossary:
+                    replacements.append((start, end, f"{glossary[acronym]} ({acronym})"))
+                    fixed.append(acronym)
+                else:
+                    missing.append(acronym)
+            for start, end, value in reversed(replacements):
+                text = text[:start] + value + text[end:]
+            if replacements:
+                set_slot(el, slot, text)
+            history = (history + text)[-300:]
+        return missing, fixed
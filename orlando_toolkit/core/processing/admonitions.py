@@ -0,0 +1,182 @@
+from __future__ import annotations
+
+"""Notes, warnings and cautions from paragraph conventions.
+
+Source documents mark admonitions in three ways; each becomes a DITA
+``<note type="…">`` (or a ``<hazardstatement>``):
+
+- a paragraph style (``data-style``) listed under ``styles`` (style name ->
+  note type); consecutive paragraphs of one style form one note;
+- a leading label such as ``WARNING:`` or ``Note:``, also bold, in English
+  or the document language (the ``note_*`` labels of ``messages.yml``) when
+  ``detect_prefixes`` is on; the label is removed because the note type
+  renders it;
+- a bordered or shaded box (``data-border`` / ``data-shading`` hints) when
+  ``boxed`` names a type.
+
+Types listed under ``hazard_types`` become ``<hazardstatement>`` (DITA 1.3)
+with the first sentence as ``<typeofhazard>`` and the rest as
+``<howtoavoid>``. Existing notes and content inside tables, lists and notes
+are left alone.
+"""
+
+import logging
+import re
+from typing import Any, Dict, List, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.i18n import document_language, get_catalog
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import is_element, local_name
+from orlando_toolkit.core.style_usage import PARAGRAPH_HINT
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["AdmonitionStage", "NOTE_TYPES", "build_admonition"]
+
+NOTE_TYPES = ("note", "tip", "fastpath", "restriction", "important", "remember", "attention", "caution",
+              "notice", "danger", "warning", "trouble")
+_DEFAULT_STYLES = {"Note": "note", "Tip": "tip", "Important": "important", "Caution": "caution",
+                   "Warning": "warning", "Danger": "danger"}
+# Body elements whose paragraphs may become notes (not table cells, list items or notes)
+_CONTAINERS = frozenset({"conbody", "section", "body", "refbody", "context", "result", "example", "info"})
+_NO_SHADING = frozenset({"", "auto", "none", "clear", "ffffff", "#ffffff", "white"})
+_SENTENCE_END = re.compile(r"(?<=[.!?])\s+")
+
+
+def _labels(lang: str) -> List[Tuple[str, str]]:
+    """``(label, note type)`` pairs, longest label first."""
+    catalog = get_catalog()
+    pairs: Dict[str, str] = {}
+    for language in dict.fromkeys((lang, "en")):
+        for note_type in NOTE_TYPES:
+            key = "note_label" if note_type == "note" else f"note_{note_type}"
+            label = catalog.get(key, language)
+            if label and label != key:
+                pairs.setdefault(label.casefold(), note_type)
+    return sorted(pairs.items(), key=lambda item: -len(item[0]))
+
+
+def _prefix_pattern(labels: List[Tuple[str, str]]) -> "re.Pattern[str]":
+    names = "|".join(re.escape(label) for label, _ in labels)
+    return re.compile(rf"^\s*({names})\s*[:：!]\s*", re.IGNORECASE)
+
+
+def _take_prefix(para, pattern, labels: Mapping[str, str]) -> Optional[str]:
+    """Remove a leading admonition label from *para*; return its note type."""
+    text = para.text or ""
+    match = pattern.match(text)
+    if match:
+        para.text = text[match.end():] or None
+        return labels[match.group(1).casefold()]
+    first = para[0] if len(para) else None
+    if text.strip() or first is None or local_name(first) not in ("b", "strong", "u", "ph"):
+        return None
+    inner = "".join(first.itertext())
+    match = pattern.match(inner)
+    if not match and first.tail:
+        match = pattern.match(inner + first.tail[:1])
+    if not match or len(inner.strip().rstrip(":：!").strip()) != len(match.group(1)):
+        return None
+    tail = (first.tail or "").lstrip()
+    tail = tail[1:].lstrip() if tail[:1] in (":", "：", "!") else tail
+    para.text = ((para.text or "") + tail) or None
+    para.remove(first)
+    return labels[match.group(1).casefold()]
+
+
+def build_admonition(paragraphs: List[Any], note_type: str, hazard: bool) -> Any:
+    """``<note>``/``<hazardstatement>`` holding *paragraphs* (moved, not copied)."""
+    if not hazard:
+        note = ET.Element("note")
+        if note_type != "note":
+            note.set("type", note_type)
+        for para in paragraphs:
+            para.tail = None
+            note.append(para)
+        return note
+    statement = ET.Element("hazardstatement", type=note_type)
+    panel = ET.SubElement(statement, "messagepanel")
+    first = paragraphs[0]
+    if first.get(PARAGRAPH_HINT):
+        statement.set(PARAGRAPH_HINT, first.get(PARAGRAPH_HINT))
+    text = first.text or ""
+    parts = _SENTENCE_END.split(text.strip(), maxsplit=1) if not len(first) else [text]
+    hazard_el = ET.SubElement(panel, "typeofhazard")
+    avoid = ET.SubElement(panel, "howtoavoid")
+    hazard_el.text = parts[0]
+    for child in list(first):
+        hazard_el.append(child)
+    rest = [parts[1]] if len(parts) > 1 else []
+    rest += [" ".join("".join(p.itertext()).split()) for p in paragraphs[1:]]
+    avoid.text = " ".join(r for r in rest if r) or None
+    return statement
+
+
+class AdmonitionStage(ProcessingStage):
+    name = "admonitions"
+    hint_attributes = ("data-style", "data-border", "data-shading")
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        styles = options.get("styles")
+        styles = _DEFAULT_STYLES if styles is None else styles
+        style_types: Dict[str, str] = {}
+        for style, note_type in (styles or {}).items():
+            if str(note_type) in NOTE_TYPES:
+                style_types[str(style).casefold()] = str(note_type)
+            else:
+                logger.warning("Unknown note type %r for style %s", note_type, style)
+        boxed = options.get("boxed")
+        boxed = str(boxed) if boxed in NOTE_TYPES else None
+        hazards = {str(t) for t in options.get("hazard_types") or ()}
+        labels = _labels(document_language(context)) if options.get("detect_prefixes", True) else []
+        pattern = _prefix_pattern(labels) if labels else None
+        label_types = dict(labels)
+
+        for filename, root in context.topics.items():
+            counts: Dict[str, int] = {}
+            containers = [el for el in root.iter() if is_element(el) and local_name(el) in _CONTAINERS]
+            for container in containers:
+                groups: List[Tuple[str, List[Any]]] = []
+                previous_style = None
+                for child in list(container):
+                    if not is_element(child) or local_name(child) != "p":
+                        previous_style = None
+                        continue
+                    style = (child.get("data-style") or "").casefold()
+                    note_type = style_types.get(style)
+                    prefixed = _take_prefix(child, pattern, label_types) if pattern is not None else None
+                    if note_type is None:
+                        note_type = prefixed
+                    if note_type is None and boxed and self._boxed(child):
+                        note_type = boxed
+                    if note_type is None:
+                        previous_style = None
+                        continue
+                    if style and style == previous_style and groups and groups[-1][0] == note_type \
+                            and prefixed is None:
+                        groups[-1][1].append(child)
+                    else:
+                        groups.append((note_type, [child]))
+                    previous_style = style or None
+                for note_type, paragraphs in groups:
+                    index = list(container).index(paragraphs[0])
+                    tail = paragraphs[-1].tail
+                    for para in paragraphs:
+                        container.remove(para)
+                    element = build_admonition(paragraphs, note_type, note_type in hazards)
+                    element.tail = tail
+                    container.insert(index, element)
+                    counts[note_type] = counts.get(note_type, 0) + 1
+            if counts:
+                summary = ", ".join(f"{count} {kind}" for kind, count in sorted(counts.items()))
+                report.info(self.name, f"Admonitions detected: {summary}", topic=filename, **counts)
+
+    @staticmethod
+    def _boxed(para) -> bool:
+        border = (para.get("data-border") or "").strip().lower()
+        shading = (para.get("data-shading") or "").strip().lower()
+        return border not in ("", "none", "nil", "false") or shading not in _NO_SHADING
@@ -0,0 +1,170 @@
+from __future__ import annotations
+
+"""Appendices and annexes as bookmap back matter.
+
+A top-level map entry whose title matches one of ``patterns`` ("Appendix
+B", "Annex 2 – Wiring", "Anhang C") is an appendix; a group titled like
+``group_patterns`` ("Appendices", "Annexes") holds appendices. Each one is
+marked ``outputclass="appendix"`` (the group ``"appendices"``) and gets its
+letter as ``<data name="appendix-number">`` in its topicmeta: the letter of
+the heading, a number converted to a letter (2 -> B), or the letter after
+the previous appendix. Entries whose heading style has ``role: appendix``
+in the style map (:mod:`orlando_toolkit.core.style_map`) are appendices
+whatever their title, and enable the stage.
+
+With ``output: bookmap`` the job's ``map_type`` becomes ``bookmap``, so the
+packager writes the map as a bookmap with ``<appendix>`` entries
+(:mod:`orlando_toolkit.core.bookmap`) and publishing numbers them as
+appendices; ``output: map`` keeps a plain map with the marks only. With
+``strip_label: true`` the "Appendix B" label is removed from the titles, the
+publishing engine generating its own.
+"""
+
+import logging
+import re
+from typing import Any, Dict, List, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import is_element, local_name
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["AppendixStage", "DEFAULT_GROUP_PATTERNS", "DEFAULT_PATTERNS", "appendix_letter"]
+
+_OUTPUTS = ("bookmap", "map")
+_ENTRIES = ("topicref", "topichead")
+_LABELS = r"appendix|appendice|annex|annexe|anhang|anexo|apéndice"
+DEFAULT_PATTERNS = (
+    r"(?i)^(?:" + _LABELS + r")(?:\s+([A-Z]|\d{1,2})(?:\s*[.:–—-]\s*|\s+|$)|\s*(?:[.:–—-]\s*|$))",
+)
+DEFAULT_GROUP_PATTERNS = (r"(?i)^(?:appendices|annexes|anhänge|anexos|apéndices)$",)
+
+
+def appendix_letter(index: int) -> str:
+    """0 -> A, 25 -> Z, 26 -> AA."""
+    letters = ""
+    index += 1
+    while index:
+        index, rest = divmod(index - 1, 26)
+        letters = chr(ord("A") + rest) + letters
+    return letters
+
+
+def _letter_index(letter: str) -> int:
+    index = 0
+    for char in letter:
+        index = index * 26 + ord(char) - ord("A") + 1
+    return index - 1
+
+
+def _compile(patterns, fallback) -> List["re.Pattern[str]"]:
+    compiled = []
+    for pattern in fallback if patterns is None else patterns:
+        try:
+            compiled.append(re.compile(str(pattern)))
+        except re.error as exc:
+            logger.warning("Invalid appendix pattern %r: %s", pattern, exc)
+    return compiled
+
+
+def _appendix_styles(options: Dict[str, Any]) -> frozenset:
+    rules = options.get("style_map") or {}
+    return frozenset(name.casefold() for name, rule in rules.items() if rule.role == "appendix")
+
+
+def _text(el) -> str:
+    return " ".join("".join(el.itertext()).split())
+
+
+class AppendixStage(ProcessingStage):
+    name = "appendices"
+    uses_style_map = True
+
+    def is_enabled(self, options: Dict[str, Any]) -> bool:
+        return bool(options.get("enabled", False)) or bool(_appendix_styles(options))
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        output = str(options.get("output", "bookmap"))
+        if output not in _OUTPUTS:
+            report.warning(self.name, f"Unknown appendices output {output!r} (expected {', '.join(_OUTPUTS)})")
+            return
+        root = context.ditamap_root
+        if root is None:
+            return
+        patterns = _compile(options.get("patterns"), DEFAULT_PATTERNS)
+        groups = _compile(options.get("group_patterns"), DEFAULT_GROUP_PATTERNS)
+        styles = _appendix_styles(options)
+
+        found: List[Tuple[Any, Optional["re.Match[str]"], str]] = []
+        for entry in (c for c in root if is_element(c) and local_name(c) in _ENTRIES):
+            title = self._title(context, entry)
+            if any(g.search(title) for g in groups):
+                entry.set("outputclass", "appendices")
+                for child in (c for c in entry if is_element(c) and local_name(c) in _ENTRIES):
+                    child_title = self._title(context, child)
+                    found.append((child, self._match(patterns, child_title), child_title))
+                continue
+            match = self._match(patterns, title)
+            if match is not None or (entry.get("data-style") or "").casefold() in styles:
+                found.append((entry, match, title))
+        if not found:
+            return
+
+        strip = bool(options.get("strip_label", False))
+        letters: List[str] = []
+        for entry, match, title in found:
+            letter = self._identifier(match)
+            if not letter:
+                letter = appendix_letter(_letter_index(letters[-1]) + 1 if letters else 0)
+            letters.append(letter)
+            entry.set("outputclass", "appendix")
+            meta = entry.find("topicmeta")
+            if meta is None:
+                meta = ET.Element("topicmeta")
+                entry.insert(0, meta)
+            data = next((d for d in meta.findall("data") if d.get("name") == "appendix-number"), None)
+            if data is None:
+                data = ET.SubElement(meta, "data", name="appendix-number")
+            data.set("value", letter)
+            if strip and match is not None:
+                self._strip(context, entry, match.group(0))
+        if output == "bookmap":
+            context.metadata["map_type"] = "bookmap"
+        names = ", ".join(f"{letter} {title}" for letter, (_, _, title) in zip(letters, found))
+        report.info(self.name, f"{len(found)} appendix(es) mapped: {names}", appendices=letters, output=output)
+
+    @staticmethod
+    def _title(context: DitaContext, entry) -> str:
+        navtitle = entry.find("topicmeta/navtitle")
+        if navtitle is not None and _text(navtitle):
+            return _text(navtitle)
+        topic = context.topics.get((entry.get("href") or "").split("#")[0].split("/")[-1])
+        title = topic.find("title") if topic is not None else None
+        return _text(title) if title is not None else ""
+
+    @staticmethod
+    def _match(patterns, title: str) -> Optional["re.Match[str]"]:
+        return next((m for m in (p.search(title) for p in patterns) if m), None) if title else None
+
+    @staticmethod
+    def _identifier(match: Optional["re.Match[str]"]) -> str:
+        value = ((match.group(1) if match is not None and match.groups() else None) or "").strip().upper()
+        if value.isdigit():
+            return appendix_letter(int(value) - 1) if int(value) > 0 else ""
+        return value if value.isalpha() else ""
+
+    @staticmethod
+    def _strip(context: DitaContext, entry, label: str) -> None:
+        """Remove the leading *label* from the entry's navtitle and topic title."""
+        topic = context.topics.get((entry.get("href") or "").split("#")[0].split("/")[-1])
+        targets = [entry.find("topicmeta/navtitle"), topic.find("title") if topic is not None else None]
+        for el in targets:
+            if el is None or not el.text:
+                continue
+            stripped = el.text.lstrip()
+            if stripped.startswith(label) and (stripped[len(label):].strip() or len(el)):
+                el.text = stripped[len(label):].lstrip()
@@ -0,0 +1,39 @@
+from __future__ import annotations
+
+"""Base class for post-conversion processing stages.
+
+A stage receives the fully converted :class:`DitaContext` after the plugin
+handler returns and rewrites topic XML in place (text normalisation, language
+attributes, direction, …). Stages are format-agnostic: plugins pass
+source-specific facts to them through ``data-*`` hint attributes, which the
+stage consumes and :func:`~orlando_toolkit.core.processing.strip_stage_hints`
+removes before packaging.
+"""
+
+from typing import Any, Dict, Tuple
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+
+__all__ = ["ProcessingStage"]
+
+
+class ProcessingStage:
+    """Override :attr:`name` and :meth:`process`.
+
+    ``options`` is the stage's section of ``conversion.yml`` merged with
+    per-job ``metadata["conversion_options"][name]``.
+    """
+
+    #: Config section and report category
+    name: str = ""
+    #: ``data-*`` attributes plugins may set for this stage
+    hint_attributes: Tuple[str, ...] = ()
+    #: Receive the job's style map (``StyleRule`` by style name) as ``options["style_map"]``
+    uses_style_map: bool = False
+
+    def is_enabled(self, options: Dict[str, Any]) -> bool:
+        return bool(options.get("enabled", True))
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        raise NotImplementedError
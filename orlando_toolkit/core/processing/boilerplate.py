@@ -0,0 +1,149 @@
+from __future__ import annotations
+
+"""Repeated legal and proprietary notices.
+
+Word documents often repeat a copyright line or a "proprietary and
+confidential" paragraph at the start or end of every chapter, or in the page
+footer; converted naively every topic carries a copy. A paragraph is
+boilerplate when the same text (whitespace and case ignored, at least
+``min_words`` words) is among the first or last ``edge_blocks`` paragraphs
+of the body, or comes from a header or footer (``data-origin`` hint), in at
+least ``min_topics`` topics.
+
+With ``target: topic`` the notices are collected once in a shared topic
+(titled with the ``notices_title`` message) placed first in the map, and
+every copy becomes ``<p conref="notices.dita#notices/notice_N"/>`` (or is
+removed with ``keep_references: false``, the front-matter topic showing them
+once). With ``target: bookmeta`` the copies are removed and each notice is
+kept in the map's ``topicmeta`` as ``<othermeta name="notice">``.
+"""
+
+import logging
+import unicodedata
+from copy import deepcopy
+from typing import Any, Dict, List, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.i18n import document_language, get_catalog
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import is_element, local_name
+from orlando_toolkit.core.utils import topic_body
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["BoilerplateStage", "NOTICES_ID"]
+
+NOTICES_ID = "notices"
+_TARGETS = ("topic", "bookmeta")
+_ORIGINS = frozenset({"header", "footer"})
+
+
+def _key(el) -> str:
+    text = " ".join("".join(el.itertext()).split())
+    return unicodedata.normalize("NFC", text).casefold()
+
+
+def _candidates(body, edge: int) -> List[Any]:
+    """Paragraphs at the edges of *body*, plus header and footer paragraphs anywhere."""
+    children = [c for c in body if is_element(c)]
+    edges = children[:edge] + children[-edge:] if edge else []
+    found = [c for c in dict.fromkeys(edges) if local_name(c) == "p"]
+    found += [p for p in body.iter("p") if p.get("data-origin") in _ORIGINS and p not in found]
+    return found
+
+
+class BoilerplateStage(ProcessingStage):
+    name = "boilerplate"
+    hint_attributes = ("data-origin",)
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        target = str(options.get("target", "topic"))
+        if target not in _TARGETS:
+            report.warning(self.name, f"Unknown boilerplate target {target!r} (expected {', '.join(_TARGETS)})")
+            return
+        min_topics = max(2, int(options.get("min_topics", 3)))
+        min_words = max(1, int(options.get("min_words", 5)))
+        edge = max(0, int(options.get("edge_blocks", 2)))
+
+        occurrences: Dict[str, List[Tuple[str, Any]]] = {}
+        for filename, root in context.topics.items():
+            body = topic_body(root)
+            if body is None:
+                continue
+            for para in _candidates(body, edge):
+                key = _key(para)
+                if len(key.split()) >= min_words:
+                    occurrences.setdefault(key, []).append((filename, para))
+        notices = [(key, found) for key, found in occurrences.items()
+                   if len({name for name, _ in found}) >= min_topics]
+        if not notices:
+            return
+
+        if target == "bookmeta" and context.ditamap_root is not None:
+            self._to_bookmeta(context, notices)
+            where = "the map metadata"
+        else:
+            where = self._to_topic(context, notices, bool(options.get("keep_references", True)))
+        topics = len({name for _, found in notices for name, _ in found})
+        report.info(self.name, f"{len(notices)} repeated notice(s) found in {topics} topic(s) moved to {where}",
+                    notices=len(notices), topics=topics, target=target)
+
+    @staticmethod
+    def _remove(para) -> None:
+        parent = para.getparent()
+        if para.tail and para.tail.strip():
+            previous = para.getprevious()
+            if previous is not None:
+                previous.tail = (previous.tail or "") + para.tail
+            else:
+                parent.text = (parent.text or "") + para.tail
+        parent.remove(para)
+
+    def _to_bookmeta(self, context: DitaContext, notices) -> None:
+        root = context.ditamap_root
+        meta = root.find("topicmeta")
+        if meta is None:
+            meta = ET.Element("topicmeta")
+            root.insert(1 if root.find("title") is not None else 0, meta)
+        for _, found in notices:
+            text = " ".join("".join(found[0][1].itertext()).split())
+            ET.SubElement(meta, "othermeta", name="notice", content=text)
+            for _, para in found:
+                self._remove(para)
+
+    def _to_topic(self, context: DitaContext, notices, keep_references: bool) -> str:
+        filename, n = f"{NOTICES_ID}.dita", 2
+        while filename in context.topics:
+            filename, n = f"{NOTICES_ID}_{n}.dita", n + 1
+        topic_id = filename[:-5]
+        topic = ET.Element("concept", id=topic_id)
+        ET.SubElement(topic, "title").text = get_catalog().get("notices_title", document_language(context))
+        body = ET.SubElement(topic, "conbody")
+        for index, (_, found) in enumerate(notices, start=1):
+            shared = deepcopy(found[0][1])
+            shared.tail = None
+            shared.attrib.clear()
+            shared.set("id", f"notice_{index}")
+            body.append(shared)
+            for _, para in found:
+                if not keep_references:
+                    self._remove(para)
+                    continue
+                ref = ET.Element("p", conref=f"{filename}#{topic_id}/notice_{index}")
+                ref.tail = para.tail
+                parent = para.getparent()
+                parent.insert(list(parent).index(para), ref)
+                parent.remove(para)
+        context.topics[filename] = topic
+
+        root = context.ditamap_root
+        if root is not None:
+            first = next(root.iter("topicref"), None)
+            href = first.get("href") if first is not None else ""
+            folder = href.rsplit("/", 1)[0] + "/" if href and "/" in href else "topics/"
+            position = sum(1 for c in root if is_element(c) and local_name(c) in ("title", "topicmeta"))
+            root.insert(position, ET.Element("topicref", href=folder + filename))
+        return filename
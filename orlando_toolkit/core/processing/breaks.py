@@ -0,0 +1,129 @@
+from __future__ import annotations
+
+"""Soft hyphens and manual line breaks.
+
+Word-processor artefacts that otherwise leak into topic text:
+
+- Soft hyphens (U+00AD) are optional hyphenation points that some renderers
+  print literally. ``soft_hyphens: strip`` (default) removes them everywhere.
+- Manual line breaks arrive as U+2028 LINE SEPARATOR or as empty elements
+  carrying ``data-break="line"``. ``line_breaks`` selects the policy:
+  ``break`` (default) writes the DITA-OT ``<?linebreak?>`` processing
+  instruction, ``space`` joins the lines with a single space, ``preserve``
+  keeps (or writes) U+2028. Inside preformatted blocks breaks always become
+  ``\\n`` unless the policy is ``preserve``.
+
+Counts per topic go to the conversion report.
+"""
+
+import logging
+from typing import Any, Dict, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import (
+    get_slot,
+    is_element,
+    is_preformatted,
+    iter_text_slots,
+    set_slot,
+)
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["LineBreakStage", "LINEBREAK_PI"]
+
+LINEBREAK_PI = "linebreak"
+_SOFT_HYPHEN = "\u00ad"
+_LINE_SEPARATOR = "\u2028"
+_POLICIES = ("break", "space", "preserve")
+
+
+def _join_space(left: str, right: str) -> str:
+    if not left or not right or left[-1].isspace() or right[0].isspace():
+        return left + right
+    return left + " " + right
+
+
+class LineBreakStage(ProcessingStage):
+    name = "breaks"
+    hint_attributes = ("data-break",)
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        strip_soft = str(options.get("soft_hyphens", "strip")).strip().lower() != "preserve"
+        policy = str(options.get("line_breaks", "break") or "break").strip().lower()
+        if policy not in _POLICIES:
+            logger.warning("Unknown line_breaks policy %r; using 'break'", policy)
+            policy = "break"
+        for filename, root in context.topics.items():
+            hinted = self._replace_hint_elements(root)
+            soft, breaks = self._fix_text(root, strip_soft, policy)
+            if soft:
+                report.info(self.name, f"Removed {soft} soft hyphen(s)", topic=filename, soft_hyphens=soft)
+            if breaks or hinted:
+                report.info(self.name, f"Converted {breaks} manual line break(s) ({policy})",
+                            topic=filename, line_breaks=breaks, policy=policy)
+
+    def _replace_hint_elements(self, root) -> int:
+        """Turn ``data-break="line"`` placeholders into U+2028 for the text pass."""
+        count = 0
+        for el in list(root.iter()):
+            if not is_element(el) or (el.get("data-break") or "").strip().lower() != "line":
+                continue
+            parent = el.getparent()
+            if parent is None or len(el) or (el.text or "").strip():
+                el.attrib.pop("data-break", None)
+                continue
+            replacement = _LINE_SEPARATOR + (el.tail or "")
+            prev = el.getprevious()
+            if prev is not None:
+                prev.tail = (prev.tail or "") + replacement
+            else:
+                parent.text = (parent.text or "") + replacement
+            parent.remove(el)
+            count += 1
+        return count
+
+    def _fix_text(self, root, strip_soft: bool, policy: str) -> Tuple[int, int]:
+        soft = breaks = 0
+        for el, slot in list(iter_text_slots(root)):
+            text = get_slot(el, slot)
+            if not text:
+                continue
+            if strip_soft and _SOFT_HYPHEN in text:
+                soft += text.count(_SOFT_HYPHEN)
+                text = text.replace(_SOFT_HYPHEN, "")
+                set_slot(el, slot, text)
+            if _LINE_SEPARATOR not in text or policy == "preserve":
+                continue
+            breaks += text.count(_LINE_SEPARATOR)
+            owner = el if slot == "text" else (el.getparent() if is_element(el) else None)
+            if owner is not None and is_preformatted(owner):
+                set_slot(el, slot, text.replace(_LINE_SEPARATOR, "\n"))
+            elif policy == "space":
+                parts = text.split(_LINE_SEPARATOR)
+                joined = parts[0]
+                for part in parts[1:]:
+                    joined = _join_space(joined, part)
+                set_slot(el, slot, joined)
+            else:
+                self._split_with_pi(el, slot, text.split(_LINE_SEPARATOR))
+        return soft, breaks
+
+    def _split_with_pi(self, el, slot: str, parts) -> None:
+        """Keep ``parts[0]`` in the slot; insert a linebreak PI before each other part."""
+        set_slot(el, slot, parts[0] or None)
+        if slot == "text":
+            parent, index = el, 0
+        else:
+            parent = el.getparent()
+            if parent is None:
+                return
+            index = list(parent).index(el) + 1
+        for offset, part in enumerate(parts[1:]):
+            pi = ET.ProcessingInstruction(LINEBREAK_PI)
+            pi.tail = part or None
+            parent.insert(index + offset, pi)
@@ -0,0 +1,264 @@
+from __future__ import annotations
+
+"""Figure and table captions as ``<fig>``/``<table>`` titles.
+
+Converters keep a Word caption ("Figure 12 – Hydraulic schematic") as a
+paragraph next to the picture or table. A paragraph is a caption when:
+
+- it holds a Word ``SEQ`` field (:data:`~orlando_toolkit.core.word_fields.CAPTION_HINT`,
+  the sequence name, set by
+  :func:`~orlando_toolkit.core.word_fields.record_word_fields`);
+- its style (``data-style``) is listed under ``styles``;
+- ``detect_labels`` is on and it starts with a figure or table label, a
+  number and a separator ("Table 3: …", "Abbildung 2 – …"): the labels of
+  ``figure_caption``/``table_caption`` in ``messages.yml`` for English and
+  the document language, ``Fig.``, ``Tab.`` and those listed under
+  ``labels``.
+
+A caption is bound to the element right before or after it, figure captions
+looking above first and table captions below first: a ``<table>`` or
+``<fig>`` without a title, or a paragraph holding only a picture, which is
+wrapped in a ``<fig>``. A caption paragraph inside an untitled ``<fig>``
+becomes that figure's title. With ``strip_numbers`` the label and number
+("Figure 12 – ") are removed from the title so DITA-OT numbers figures and
+tables again on publish. The caption's id and Word bookmarks move to the
+figure or table, so cross-references to it keep working. Captions with
+nothing to bind to stay paragraphs and are reported under ``captions``.
+"""
+
+import logging
+import re
+from typing import Any, Dict, Iterable, List, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.i18n import document_language, get_catalog
+from orlando_toolkit.core.internal_links import BOOKMARK_HINT, add_bookmarks
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import is_element, local_name
+from orlando_toolkit.core.word_fields import CAPTION_HINT
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["CaptionStage", "caption_labels"]
+
+_DEFAULT_STYLES = ("Caption", "Figure Caption", "Table Caption")
+_BUILTIN_LABELS = {"fig.": "figure", "tab.": "table"}
+_KINDS = ("figure", "table")
+# Body elements whose paragraphs may be captions
+_CONTAINERS = frozenset({"conbody", "section", "body", "refbody", "example", "context", "result", "info",
+                         "stepxmp", "li", "bodydiv", "sectiondiv", "fig"})
+_NUMBER = r"(?:\d+(?:[.\-–‑]\d+)*[a-z]?|(?-i:[IVXLC]+))"
+_SEPARATORS = ":.–—-"
+
+
+def caption_labels(lang: str, extra: Optional[Mapping[str, Any]] = None) -> Dict[str, str]:
+    """Caption label (casefolded) -> ``figure``/``table`` for *lang* and English."""
+    catalog = get_catalog()
+    labels = dict(_BUILTIN_LABELS)
+    for language in dict.fromkeys((lang, "en")):
+        for kind in _KINDS:
+            label = catalog.get(f"{kind}_caption", language).split("{number}")[0].strip()
+            if label and "{" not in label:
+                labels.setdefault(label.casefold(), kind)
+    for label, kind in (extra or {}).items():
+        if str(kind) in _KINDS:
+            labels[str(label).casefold()] = str(kind)
+        else:
+            logger.warning("Unknown caption kind %r for label %s (expected figure or table)", kind, label)
+    return labels
+
+
+def _pattern(labels: Iterable[str], strict: bool) -> "re.Pattern[str]":
+    names = "|".join(re.escape(label) for label in sorted(labels, key=len, reverse=True))
+    # A label alone in the text needs a separator ("Table 3 shows ..." is no caption)
+    separator = rf"\s*(?:[{_SEPARATORS}](?!\d)\s*|$)" if strict else rf"\s*(?:[{_SEPARATORS}](?!\d))?\s*"
+    return re.compile(rf"^\s*({names})\s*{_NUMBER}\b{separator}", re.IGNORECASE)
+
+
+def _raw_text(el: Any) -> str:
+    parts = [el.text or ""]
+    for child in el:
+        if is_element(child):
+            parts.append(_raw_text(child))
+        parts.append(child.tail or "")
+    return "".join(parts)
+
+
+def _drop_chars(el: Any, count: int) -> None:
+    """Remove the first *count* characters of the text of *el*, keeping its markup."""
+    emptied: List[Any] = []
+
+    def _cut(text: Optional[str]) -> Optional[str]:
+        nonlocal count
+        taken = min(count, len(text or ""))
+        count -= taken
+        return (text or "")[taken:] or None
+
+    def _walk(node: Any) -> None:
+        if node.text:
+            node.text = _cut(node.text)
+            if node is not el and not node.text and not len(node):
+                emptied.append(node)
+        for child in list(node):
+            if count and is_element(child):
+                _walk(child)
+            if count:
+                child.tail = _cut(child.tail)
+
+    _walk(el)
+    for node in emptied:
+        parent = node.getparent()
+        previous = node.getprevious()
+        if previous is not None:
+            previous.tail = (previous.tail or "") + (node.tail or "") or None
+        else:
+            parent.text = (parent.text or "") + (node.tail or "") or None
+        parent.remove(node)
+
+
+def _only_image(el: Any) -> Optional[Any]:
+    """The picture of a paragraph holding nothing else."""
+    children = [c for c in el if is_element(c)]
+    if len(children) != 1 or local_name(children[0]) != "image":
+        return None
+    if (el.text or "").strip() or (children[0].tail or "").strip():
+        return None
+    return children[0]
+
+
+def _accepts(el: Optional[Any], kind: Optional[str]) -> bool:
+    if el is None or not is_element(el):
+        return False
+    name = local_name(el)
+    if name == "table":
+        return kind != "figure" and el.find("title") is None
+    if name == "fig":
+        return kind != "table" and el.find("title") is None
+    if kind == "table":
+        return False
+    if name == "image":
+        return el.get("placement") == "break"
+    return name == "p" and el.get(CAPTION_HINT) is None and _only_image(el) is not None
+
+
+def _neighbour(caption: Any, kind: Optional[str]) -> Optional[Any]:
+    parent = caption.getparent()
+    if local_name(parent) == "fig":
+        return parent if _accepts(parent, "figure") and kind != "table" else None
+    previous, following = caption.getprevious(), caption.getnext()
+    # Text between the caption and its neighbour means they are not adjacent
+    if previous is not None and (previous.tail or "").strip():
+        previous = None
+    if following is not None and (caption.tail or "").strip():
+        following = None
+    order = (following, previous) if kind == "table" else (previous, following)
+    return next((el for el in order if _accepts(el, kind)), None)
+
+
+def _as_figure(el: Any) -> Any:
+    """*el* itself when it is a ``fig`` or ``table``, else a new ``fig`` in its place."""
+    if local_name(el) in ("fig", "table"):
+        return el
+    image = el if local_name(el) == "image" else _only_image(el)
+    fig = ET.Element("fig")
+    if el.get("id"):
+        fig.set("id", el.get("id"))
+    parent = el.getparent()
+    parent.insert(parent.index(el), fig)
+    fig.tail = el.tail
+    image.tail = None
+    image.set("placement", "break")
+    fig.append(image)
+    if el is not image:
+        parent.remove(el)
+    return fig
+
+
+def _title(caption: Any, prefix: int) -> Any:
+    title = ET.Element("title")
+    title.text = caption.text
+    for child in list(caption):
+        title.append(child)
+    if prefix:
+        stripped = ET.fromstring(ET.tostring(title))
+        _drop_chars(stripped, prefix)
+        if _raw_text(stripped).strip():
+            title = stripped
+    title.text = (title.text or "").lstrip() or None
+    title.tail = None
+    return title
+
+
+def _remove(el: Any) -> None:
+    parent = el.getparent()
+    previous = el.getprevious()
+    tail = (el.tail or "") if (el.tail or "").strip() else ""
+    if previous is not None:
+        previous.tail = (previous.tail or "") + tail or previous.tail
+    elif tail:
+        parent.text = (parent.text or "") + tail
+    parent.remove(el)
+
+
+class CaptionStage(ProcessingStage):
+    name = "captions"
+    hint_attributes = (CAPTION_HINT, "data-style")
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        styles = options.get("styles")
+        styles = {str(s).casefold() for s in (_DEFAULT_STYLES if styles is None else styles or ())}
+        labels = caption_labels(document_language(context), options.get("labels"))
+        detect = bool(options.get("detect_labels", True))
+        strip = bool(options.get("strip_numbers", True))
+        strict = _pattern(labels, strict=True)
+
+        unbound = 0
+        for filename, root in context.topics.items():
+            counts = {"figure": 0, "table": 0}
+            captions: List[Tuple[Any, Optional[str], "re.Pattern[str]"]] = []
+            for para in root.iter("p"):
+                parent = para.getparent()
+                if parent is None or local_name(parent) not in _CONTAINERS:
+                    continue
+                text = _raw_text(para)
+                sequence = para.get(CAPTION_HINT)
+                if sequence is not None or (para.get("data-style") or "").casefold() in styles:
+                    known = dict(labels)
+                    if sequence:
+                        known.setdefault(sequence.casefold(), labels.get(sequence.casefold()))
+                    pattern = _pattern(known, strict=False)
+                    match = pattern.match(text)
+                    kind = known.get(match.group(1).casefold()) if match else labels.get((sequence or "").casefold())
+                    captions.append((para, kind, pattern))
+                elif detect:
+                    match = strict.match(text)
+                    if match:
+                        captions.append((para, labels[match.group(1).casefold()], strict))
+            for para, kind, pattern in captions:
+                target = _neighbour(para, kind)
+                if target is None:
+                    unbound += 1
+                    continue
+                target = _as_figure(target)
+                match = pattern.match(_raw_text(para)) if strip else None
+                title = _title(para, match.end() if match else 0)
+                # DITA: <title> comes first in both <fig> and <table>
+                target.insert(0, title)
+                if para.get("id") and not target.get("id"):
+                    target.set("id", para.get("id"))
+                add_bookmarks(target, (para.get(BOOKMARK_HINT) or "").split())
+                if para.getparent() is not target:
+                    _remove(para)
+                else:
+                    target.remove(para)
+                counts["table" if local_name(target) == "table" else "figure"] += 1
+            if any(counts.values()):
+                report.info(self.name, f"{counts['figure']} figure and {counts['table']} table caption(s) became "
+                                       "titles", topic=filename, figures=counts["figure"], tables=counts["table"])
+        if unbound:
+            report.warning(self.name, f"{unbound} caption paragraph(s) have no picture or table next to them and "
+                                      "were kept as paragraphs", captions=unbound,
+                           hint="Keep each caption directly above or below its picture or table in the source")
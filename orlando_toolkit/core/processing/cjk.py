@@ -0,0 +1,178 @@
+from __future__ import annotations
+
+"""Chinese/Japanese text handling.
+
+What the stage does:
+
+- Removes whitespace that converters insert between runs when both neighbours
+  are CJK characters (``<b>日本</b> 語`` → ``<b>日本</b>語``). Korean Hangul is
+  excluded because it uses spaces between words.
+- Removes spaces after full-width closing/terminal punctuation and before
+  full-width opening punctuation. Full-width characters are never converted to
+  their half-width forms; optionally, ASCII punctuation between CJK characters
+  is converted to full-width (``normalize_punctuation``).
+- Handles ruby (furigana) runs marked by plugins with ``data-ruby="reading"``:
+  ``preserve`` keeps base and reading as ``ph[@outputclass='ruby']`` with
+  ``rb``/``rt`` children, ``inline`` appends the reading in full-width
+  parentheses, ``drop`` keeps only the base text. Every ruby run is reported.
+
+Preformatted blocks (``pre``, ``codeblock``, ``lines``, ``xml:space="preserve"``)
+are left untouched.
+"""
+
+import logging
+import re
+from typing import Any, Dict, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import (
+    block_slots,
+    get_slot,
+    is_element,
+    is_preformatted,
+    iter_blocks,
+    local_name,
+    set_slot,
+)
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["CjkStage"]
+
+# Han, kana, bopomofo, CJK symbols and full-width forms (no Hangul)
+_CJK = (
+    "\u3000-\u303f\u3040-\u30ff\u3100-\u312f\u31f0-\u31ff\u3400-\u4dbf"
+    "\u4e00-\u9fff\uf900-\ufaff\uff00-\uffef\U00020000-\U0002fa1f"
+)
+_FW_TRAILING = "、。，．！？：；）」』】〕〉》～"
+_FW_OPENING = "（「『【〔〈《"
+
+_SPACE_RE = re.compile(
+    rf"(?<=[{_CJK}])[ \t\r\n]+(?=[{_CJK}])"
+    rf"|(?<=[{_FW_TRAILING}])[ \t\r\n]+(?=\S)"
+    rf"|(?<=\S)[ \t\r\n]+(?=[{_FW_OPENING}])"
+)
+_HALFWIDTH_PUNCT = {",": "，", "!": "！", "?": "？", ":": "：", ";": "；",
+                    "(": "（", ")": "）"}
+_PUNCT_RE = re.compile(rf"(?<=[{_CJK}])([,!?:;()])(?=[{_CJK}]|$)")
+
+_RUBY_MODES = ("preserve", "inline", "drop")
+
+
+class CjkStage(ProcessingStage):
+    name = "cjk"
+    hint_attributes = ("data-ruby",)
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        ruby_mode = str(options.get("ruby", "preserve")).lower()
+        if ruby_mode not in _RUBY_MODES:
+            logger.warning("Unknown ruby mode %r; using 'preserve'", ruby_mode)
+            ruby_mode = "preserve"
+        spacing = bool(options.get("remove_inter_run_spaces", True))
+        punctuation = bool(options.get("normalize_punctuation", False))
+
+        for filename, root in context.topics.items():
+            ruby = self._handle_ruby(root, ruby_mode)
+            removed = normalized = 0
+            if spacing or punctuation:
+                for block in iter_blocks(root):
+                    if is_preformatted(block):
+                        continue
+                    r, n = self._fix_block(block, spacing, punctuation)
+                    removed += r
+                    normalized += n
+            if ruby:
+                if ruby_mode == "drop":
+                    report.warning(self.name, f"Dropped {ruby} ruby annotation(s)", topic=filename, ruby=ruby)
+                else:
+                    report.info(self.name, f"Kept {ruby} ruby annotation(s) ({ruby_mode})",
+                                topic=filename, ruby=ruby)
+            if removed or normalized:
+                report.info(self.name, "CJK spacing/punctuation adjusted", topic=filename,
+                            spaces_removed=removed, punctuation_normalized=normalized)
+
+    # ------------------------------------------------------------------
+    # Spacing and punctuation across run boundaries
+    # ------------------------------------------------------------------
+    def _fix_block(self, block, spacing: bool, punctuation: bool) -> Tuple[int, int]:
+        slots = block_slots(block)
+        texts = [get_slot(el, slot) or "" for el, slot in slots]
+        combined = "".join(texts)
+        if not combined.strip():
+            return 0, 0
+
+        keep = [True] * len(combined)
+        replace: Dict[int, str] = {}
+        removed = normalized = 0
+        if spacing:
+            for m in _SPACE_RE.finditer(combined):
+                for i in range(m.start(), m.end()):
+                    keep[i] = False
+                removed += 1
+        if punctuation:
+            for m in _PUNCT_RE.finditer(combined):
+                replace[m.start()] = _HALFWIDTH_PUNCT[m.group(1)]
+                normalized += 1
+        if not removed and not normalized:
+            return 0, 0
+
+        pos = 0
+        for (el, slot), text in zip(slots, texts):
+            if not text:
+                continue
+            new = "".join(
+                replace.get(pos + i, ch) for i, ch in enumerate(text) if keep[pos + i]
+            )
+            pos += len(text)
+            if new != text:
+                set_slot(el, slot, new or None)
+        return removed, normalized
+
+    # ------------------------------------------------------------------
+    # Ruby
+    # ------------------------------------------------------------------
+    def _handle_ruby(self, root, mode: str) -> int:
+        count = 0
+        for el in list(root.iter()):
+            if not is_element(el):
+                continue
+            reading = el.attrib.pop("data-ruby", None)
+            if reading is None:
+                continue
+            count += 1
+            if mode == "drop" or not reading:
+                continue
+            if mode == "inline":
+                annotation = f"（{reading}）"
+                if len(el):
+                    last = el[-1]
+                    last.tail = (last.tail or "") + annotation
+                else:
+                    el.text = (el.text or "") + annotation
+                continue
+            # preserve: ph[@outputclass='ruby'] > ph[@outputclass='rb'] + ph[@outputclass='rt']
+            if local_name(el) == "ph" and not el.get("outputclass"):
+                container = el
+            else:
+                container = ET.Element("ph")
+                container.text = el.text
+                for child in list(el):
+                    container.append(child)
+                el.text = None
+                el.append(container)
+            container.set("outputclass", "ruby")
+            rb = ET.Element("ph")
+            rb.set("outputclass", "rb")
+            rb.text = container.text
+            for child in list(container):
+                rb.append(child)
+            container.text = None
+            container.append(rb)
+            rt = ET.SubElement(container, "ph")
+            rt.set("outputclass", "rt")
+            rt.text = reading
+        return count
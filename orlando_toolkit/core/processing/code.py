@@ -0,0 +1,214 @@
+from __future__ import annotations
+
+"""Code block detection and language tagging.
+
+Source documents rarely use a dedicated code style; code is usually recognised
+by its formatting. Plugins describe that formatting with hints and the stage
+scores each paragraph:
+
+==========================  =====  =================================================
+Signal                      Score  Source
+==========================  =====  =================================================
+code-like style name        3      ``data-style`` matches ``style_pattern``
+monospace font              2      ``data-font`` on the paragraph or its runs covers
+                                   at least ``monospace_ratio`` of the text
+shading                     1      ``data-shading`` (background fill) present
+code-like syntax            1      indentation, trailing ``;``/``{``/``}``, ``=>`` …
+==========================  =====  =================================================
+
+Paragraphs reaching ``min_score`` become ``codeblock`` (consecutive ones are
+merged, one paragraph per line, with ``xml:space="preserve"``). Monospace runs
+inside ordinary paragraphs become ``codeph`` when ``inline_code`` is set.
+
+With ``assign_language: guess`` each ``codeblock`` without ``@outputclass``
+gets ``language-<name>`` from ``data-code-language`` or a keyword heuristic;
+``assign_language: <name>`` labels every block with a fixed language.
+"""
+
+import logging
+import re
+from typing import Any, Dict, List, Optional, Tuple
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.preformatted import merge_into_block
+from orlando_toolkit.core.processing.text import BLOCK_TAGS, is_element, is_preformatted, local_name
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["CodeDetectionStage", "guess_code_language"]
+
+_MONOSPACE_FONTS = frozenset({
+    "consolas", "courier", "courier new", "lucida console", "lucida sans typewriter",
+    "menlo", "monaco", "andale mono", "source code pro", "dejavu sans mono",
+    "liberation mono", "cascadia code", "cascadia mono", "fira code", "fira mono",
+    "jetbrains mono", "roboto mono", "ubuntu mono", "inconsolata", "ms gothic", "monospace",
+})
+_DEFAULT_STYLE_PATTERN = r"code|source|listing|console|terminal|command|preformat|macro"
+_NO_SHADING = frozenset({"", "auto", "none", "clear", "ffffff", "#ffffff", "white"})
+_SYNTAX_RE = re.compile(r"^( {2,}|\t)\S|[;{}]\s*$|=>|::|</?\w+>|^\s*(#|//)\s?\S|\w+\(.*\)\s*$", re.M)
+
+_LANGUAGE_PATTERNS: Dict[str, List[str]] = {
+    "python": [r"^\s*def \w+\(.*\):", r"^\s*(import \w+|from [\w.]+ import)", r"\bself\.", r"\bprint\(",
+               r"^\s*(if|for|while) .+:\s*$"],
+    "javascript": [r"\bfunction\s*\w*\s*\(", r"\b(const|let|var) \w+ =", r"=>", r"console\.log\(",
+                   r"\brequire\(['\"]"],
+    "java": [r"\bpublic (static )?(final )?(class|void|int|String)\b", r"System\.out\.println",
+             r"^\s*import java\.", r"\bnew \w+\(.*\);"],
+    "csharp": [r"^\s*using System", r"\bnamespace \w+", r"Console\.Write(Line)?\(", r"\bpublic (async )?Task\b"],
+    "c": [r"^\s*#include\s*[<\"]", r"\bint main\s*\(", r"\bprintf\(", r"\bmalloc\("],
+    "sql": [r"(?i)\bselect\b.+\bfrom\b", r"(?i)\binsert into\b", r"(?i)\bcreate (table|view|index)\b",
+            r"(?i)\bupdate \w+ set\b", r"(?i)\bwhere\b.+="],
+    "xml": [r"^\s*<\?xml", r"<(\w+)[^>]*>.*</\1>", r"^\s*</\w+>\s*$"],
+    "json": [r"^\s*[\[{]\s*$", r"^\s*\"[\w-]+\"\s*:", r"[}\]],?\s*$"],
+    "bash": [r"^#!/(usr/)?bin/(env )?(ba)?sh", r"^\s*\$ \w", r"\b(echo|export|sudo|chmod|grep)\b",
+             r"\|\s*\w+"],
+    "powershell": [r"\b(Get|Set|New|Remove)-\w+", r"\bWrite-(Host|Output)\b", r"^\s*\$\w+\s*=", r"-\w+ \$\w+"],
+    "yaml": [r"^\s*[\w-]+:\s+\S", r"^\s*-\s+[\w\"']", r"^---\s*$"],
+}
+_COMPILED = {lang: [re.compile(p, re.M) for p in pats] for lang, pats in _LANGUAGE_PATTERNS.items()}
+
+
+def guess_code_language(text: str, *, min_matches: int = 2) -> Optional[str]:
+    """Return the best-matching language for *text*, or ``None`` when unsure."""
+    scores = {lang: sum(1 for rx in rxs if rx.search(text)) for lang, rxs in _COMPILED.items()}
+    best = max(scores.values(), default=0)
+    if best < min_matches:
+        return None
+    winners = [lang for lang, score in scores.items() if score == best]
+    return winners[0] if len(winners) == 1 else None
+
+
+def _is_monospace(font: Optional[str], extra: frozenset) -> bool:
+    if not font:
+        return False
+    name = font.strip().strip("'\"").lower()
+    return name in _MONOSPACE_FONTS or name in extra or "mono" in name
+
+
+def _font_coverage(para, extra: frozenset) -> float:
+    """Share of the paragraph's non-blank characters set in a monospace font."""
+    total = mono = 0
+
+    def _count(text: Optional[str], monospace: bool) -> None:
+        nonlocal total, mono
+        n = len("".join((text or "").split()))
+        total += n
+        mono += n if monospace else 0
+
+    def _walk(el, inherited: bool) -> None:
+        font = el.get("data-font")
+        current = _is_monospace(font, extra) if font else inherited
+        _count(el.text, current)
+        for child in el:
+            if is_element(child):
+                _walk(child, current)
+            _count(child.tail, current)
+
+    _walk(para, False)
+    return (mono / total) if total else 0.0
+
+
+class CodeDetectionStage(ProcessingStage):
+    name = "code"
+    hint_attributes = ("data-font", "data-shading", "data-code-language")
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        try:
+            style_rx = re.compile(options.get("style_pattern") or _DEFAULT_STYLE_PATTERN, re.I)
+        except re.error as exc:
+            logger.warning("Invalid code style_pattern: %s; using default", exc)
+            style_rx = re.compile(_DEFAULT_STYLE_PATTERN, re.I)
+        extra_fonts = frozenset(str(f).strip().lower() for f in options.get("monospace_fonts") or ())
+        min_score = int(options.get("min_score", 3) or 3)
+        ratio = float(options.get("monospace_ratio", 0.9) or 0.9)
+        inline = bool(options.get("inline_code", True))
+        language = str(options.get("assign_language", "none") or "none").strip().lower()
+        prefix = str(options.get("outputclass_prefix", "language-"))
+
+        for filename, root in context.topics.items():
+            blocks = self._detect_blocks(root, style_rx, extra_fonts, min_score, ratio)
+            phrases = self._detect_inline(root, extra_fonts) if inline else 0
+            labelled, unknown = self._assign_languages(root, language, prefix) if language != "none" else (0, 0)
+            if blocks or phrases or labelled:
+                report.info(self.name, "Code formatting detected", topic=filename,
+                            blocks=blocks, inline=phrases, labelled=labelled)
+            if unknown:
+                report.info(self.name, f"Language not recognised for {unknown} code block(s)",
+                            topic=filename, unlabelled=unknown)
+
+    # ------------------------------------------------------------------
+    # Block detection
+    # ------------------------------------------------------------------
+    def score(self, para, style_rx, extra_fonts: frozenset, ratio: float) -> int:
+        points = 0
+        style = para.get("data-style") or ""
+        if style and style_rx.search(style):
+            points += 3
+        if _font_coverage(para, extra_fonts) >= ratio:
+            points += 2
+        if (para.get("data-shading") or "").strip().lower() not in _NO_SHADING:
+            points += 1
+        if _SYNTAX_RE.search("".join(para.itertext())):
+            points += 1
+        return points
+
+    def _detect_blocks(self, root, style_rx, extra_fonts: frozenset, min_score: int, ratio: float) -> int:
+        groups: List[List[Any]] = []
+        for para in list(root.iter("p")):
+            if is_preformatted(para) or any(
+                is_element(d) and local_name(d) in BLOCK_TAGS for d in para.iterdescendants()
+            ):
+                continue
+            if not "".join(para.itertext()).strip():
+                continue
+            if self.score(para, style_rx, extra_fonts, ratio) < min_score:
+                continue
+            prev = groups[-1][-1] if groups else None
+            if prev is not None and prev.getnext() is para and not (prev.tail or "").strip():
+                groups[-1].append(para)
+            else:
+                groups.append([para])
+        for group in groups:
+            language = next((p.get("data-code-language") for p in group if p.get("data-code-language")), None)
+            for para in group:
+                for attr in ("data-font", "data-shading", "data-style", "data-code-language"):
+                    para.attrib.pop(attr, None)
+            block = merge_into_block(group, "codeblock")
+            if language:
+                block.set("data-code-language", language)
+        return len(groups)
+
+    def _detect_inline(self, root, extra_fonts: frozenset) -> int:
+        count = 0
+        for el in list(root.iter()):
+            if not is_element(el) or local_name(el) in BLOCK_TAGS or is_preformatted(el):
+                continue
+            if local_name(el) not in ("ph", "b", "i", "u", "tt"):
+                continue
+            if _is_monospace(el.get("data-font"), extra_fonts) and "".join(el.itertext()).strip():
+                el.tag = "codeph"
+                el.attrib.pop("data-font", None)
+                count += 1
+        return count
+
+    # ------------------------------------------------------------------
+    # Language labels
+    # ------------------------------------------------------------------
+    def _assign_languages(self, root, mode: str, prefix: str) -> Tuple[int, int]:
+        labelled = unknown = 0
+        for block in root.iter("codeblock"):
+            hint = (block.attrib.pop("data-code-language", None) or "").strip().lower()
+            if block.get("outputclass"):
+                continue
+            if mode == "guess":
+                lang = hint or guess_code_language("".join(block.itertext()))
+            else:
+                lang = mode
+            if lang:
+                block.set("outputclass", f"{prefix}{lang}")
+                labelled += 1
+            else:
+                unknown += 1
+        return labelled, unknown
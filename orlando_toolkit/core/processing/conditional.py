@@ -0,0 +1,138 @@
+from __future__ import annotations
+
+"""Hidden and highlighted text as conditional content.
+
+Authors often mark content for one audience or product with Word's hidden
+text or a highlight color. Plugins tag such runs and paragraphs with the
+``data-hidden="true"`` and ``data-highlight="<color>"`` hints; this stage
+turns them into DITA profiling so a DITAVAL filter can include or exclude
+them at publish time:
+
+- ``hidden``: settings for hidden text;
+- ``highlights``: settings per highlight color name (``yellow``, ``green``,
+  ``darkBlue``, … as Word names them); colors not listed stay plain text;
+- ``styles``: settings per paragraph or character style name (the
+  ``data-style`` hint, which this stage leaves for the style map).
+
+Each setting has an ``action``: ``profile`` adds ``attribute="value"`` to
+the element (values already present are kept, space-separated), ``drop``
+removes the content (its following text is kept), ``keep`` only removes the
+hint. ``attribute`` must be a DITA profiling attribute (``audience``,
+``platform``, ``product``, ``props``, ``otherprops``, ``deliveryTarget``).
+The DITAVAL files selecting one configuration of the manual are written by
+:mod:`orlando_toolkit.core.profiling`.
+"""
+
+import logging
+from typing import Any, Dict, Mapping, Optional, Tuple
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import is_element
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["ConditionalContentStage", "PROFILING_ATTRIBUTES"]
+
+PROFILING_ATTRIBUTES = frozenset({"audience", "platform", "product", "props", "otherprops", "deliveryTarget"})
+_ACTIONS = ("profile", "drop", "keep")
+_HIDDEN_HINT = "data-hidden"
+_HIGHLIGHT_HINT = "data-highlight"
+_STYLE_HINT = "data-style"
+
+
+def _rule(setting: Any, label: str) -> Optional[Tuple[str, str, str]]:
+    """``(action, attribute, value)`` for one setting, or ``None`` when invalid."""
+    if isinstance(setting, str):
+        setting = {"action": setting}
+    if not isinstance(setting, Mapping):
+        return None
+    action = str(setting.get("action", "profile"))
+    attribute = str(setting.get("attribute", "audience"))
+    value = " ".join(str(setting.get("value", "internal")).split())
+    if action not in _ACTIONS:
+        logger.warning("Unknown conditional action %r for %s (expected %s)", action, label, ", ".join(_ACTIONS))
+        return None
+    if action == "profile" and (attribute not in PROFILING_ATTRIBUTES or not value):
+        logger.warning("Conditional %s needs a profiling attribute and a value, got %s=%r", label, attribute, value)
+        return None
+    return action, attribute, value
+
+
+def _remove_keeping_tail(el) -> None:
+    parent = el.getparent()
+    if parent is None:
+        return
+    tail = el.tail
+    previous = el.getprevious()
+    if tail:
+        if previous is not None:
+            previous.tail = (previous.tail or "") + tail
+        else:
+            parent.text = (parent.text or "") + tail
+    parent.remove(el)
+
+
+def _attached(el, root) -> bool:
+    node = el
+    while node is not None and node is not root:
+        node = node.getparent()
+    return node is root
+
+
+def _add_value(el, attribute: str, value: str) -> None:
+    values = (el.get(attribute) or "").split()
+    for token in value.split():
+        if token not in values:
+            values.append(token)
+    el.set(attribute, " ".join(values))
+
+
+class ConditionalContentStage(ProcessingStage):
+    name = "conditional"
+    hint_attributes = (_HIDDEN_HINT, _HIGHLIGHT_HINT)
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        hidden = _rule(options.get("hidden", {}), "hidden text")
+        highlights: Dict[str, Tuple[str, str, str]] = {}
+        for color, setting in (options.get("highlights") or {}).items():
+            rule = _rule(setting, f"highlight {color}")
+            if rule is not None:
+                highlights[str(color).lower()] = rule
+        styles: Dict[str, Tuple[str, str, str]] = {}
+        for style, setting in (options.get("styles") or {}).items():
+            rule = _rule(setting, f"style {style}")
+            if rule is not None:
+                styles[str(style)] = rule
+
+        for filename, root in context.topics.items():
+            counts: Dict[str, int] = {}
+            marked = [el for el in root.iter() if is_element(el)
+                      and (el.get(_HIDDEN_HINT) is not None or el.get(_HIGHLIGHT_HINT) is not None
+                           or el.get(_STYLE_HINT) in styles)]
+            for el in marked:
+                if not _attached(el, root):
+                    continue  # inside content dropped already
+                rules = []
+                if (el.get(_HIDDEN_HINT) or "").lower() in ("true", "1", "yes") and hidden is not None:
+                    rules.append(hidden)
+                color = (el.get(_HIGHLIGHT_HINT) or "").lower()
+                if color in highlights:
+                    rules.append(highlights[color])
+                if el.get(_STYLE_HINT) in styles:
+                    rules.append(styles[el.get(_STYLE_HINT)])
+                el.attrib.pop(_HIDDEN_HINT, None)
+                el.attrib.pop(_HIGHLIGHT_HINT, None)
+                if any(action == "drop" for action, _, _ in rules):
+                    if el is not root:
+                        _remove_keeping_tail(el)
+                        counts["dropped"] = counts.get("dropped", 0) + 1
+                    continue
+                for action, attribute, value in rules:
+                    if action == "profile":
+                        _add_value(el, attribute, value)
+                        counts["profiled"] = counts.get("profiled", 0) + 1
+            if counts:
+                summary = ", ".join(f"{count} {what}" for what, count in sorted(counts.items()))
+                report.info(self.name, f"Conditional content: {summary}", topic=filename, **counts)
@@ -0,0 +1,220 @@
+from __future__ import annotations
+
+"""Cover page as front matter and map metadata.
+
+The cover page (the content before the table of contents) usually arrives as
+the first topic of the map: a title, a document number, an issue and a date,
+perhaps a logo. The stage recognises it when the plugin marked the topic
+root ``data-origin="cover"``, or (``detect: true``) when the first topic is
+short (``max_words``), has no lists, tables or sections, and at least one of
+the ``fields`` patterns matches one of its short paragraphs.
+
+Each field pattern (metadata key -> regular expression, group 1 is the
+value) is matched against the cover's title and paragraphs; the title
+without a field match is ``manual_title``. Values fill the job metadata
+(``manual_title``, ``manual_code``, ``revision_number``, ``revision_date``,
+…) where it is empty, or always with ``override: true``, so the packager
+writes them to the map's ``title`` and ``topicmeta``.
+
+With ``front_matter: generate`` the cover topic is replaced by a front-matter
+topic built from ``template`` (a DITA topic file whose text and attributes
+may use ``{field}`` placeholders; elements left without a value are
+dropped), or by default from the ``cover_*`` messages followed by the
+cover's images. ``keep`` leaves the topic as converted and ``drop`` removes
+it. The front-matter topicref is kept out of the TOC (``toc="no"``).
+"""
+
+import logging
+import re
+from copy import deepcopy
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.i18n import document_language, get_catalog
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import is_element, local_name
+from orlando_toolkit.core.utils import topic_body
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["CoverPageStage", "DEFAULT_FIELDS", "extract_cover_fields"]
+
+DEFAULT_FIELDS = {
+    "manual_code": r"(?i)^(?:doc(?:ument)?\.?\s*(?:no|number|n°|#)|reference|ref\.)\s*[.:#]?\s*(\S+)",
+    "revision_number": r"(?i)^(?:issue|revision|rev\.|version|edition)\s*[:#]?\s*([\w.-]*\d[\w.-]*)",
+    "revision_date": r"(\d{4}-\d{2}-\d{2}|\d{1,2}[/.]\d{1,2}[/.]\d{4})",
+}
+# Field -> message used by the built-in front-matter layout
+_LABELS = {"manual_code": "cover_document_number", "revision_number": "cover_issue", "revision_date": "cover_date"}
+_MODES = ("generate", "keep", "drop")
+_PLACEHOLDER = re.compile(r"\{([A-Za-z_][\w]*)\}")
+_STRUCTURE = frozenset({"ul", "ol", "dl", "table", "simpletable", "section", "steps"})
+
+
+def _text(el) -> str:
+    return " ".join("".join(el.itertext()).split())
+
+
+def _iso_date(value: str) -> str:
+    """``DD/MM/YYYY`` or ``DD.MM.YYYY`` as ``YYYY-MM-DD``; ISO dates unchanged."""
+    match = re.match(r"^(\d{1,2})[/.](\d{1,2})[/.](\d{4})$", value)
+    return f"{match.group(3)}-{int(match.group(2)):02d}-{int(match.group(1)):02d}" if match else value
+
+
+def extract_cover_fields(lines: List[str], patterns: Mapping[str, str]) -> Dict[str, str]:
+    """Field values found in *lines* (cover title first); see the module docstring."""
+    compiled = []
+    for name, pattern in patterns.items():
+        try:
+            compiled.append((str(name), re.compile(str(pattern))))
+        except re.error as exc:
+            logger.warning("Invalid cover field pattern for %s: %s", name, exc)
+    fields: Dict[str, str] = {}
+    leftovers: List[str] = []
+    for line in lines:
+        matched = False
+        for name, pattern in compiled:
+            match = pattern.search(line)
+            if match and name not in fields:
+                value = (match.group(1) if match.groups() else match.group(0)).strip()
+                if value:
+                    fields[name] = _iso_date(value) if name == "revision_date" else value
+                    matched = True
+        if not matched:
+            leftovers.append(line)
+    if "manual_title" not in fields and leftovers:
+        fields["manual_title"] = leftovers[0]
+    return fields
+
+
+class CoverPageStage(ProcessingStage):
+    name = "cover"
+    hint_attributes = ("data-origin",)
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        mode = str(options.get("front_matter", "generate"))
+        if mode not in _MODES:
+            report.warning(self.name, f"Unknown front_matter mode {mode!r} (expected {', '.join(_MODES)})")
+            return
+        patterns = options.get("fields")
+        patterns = DEFAULT_FIELDS if patterns is None else patterns
+        found = self._cover(context, options, patterns)
+        if found is None:
+            return
+        tref, filename, topic, lines = found
+        fields = extract_cover_fields(lines, patterns)
+        override = bool(options.get("override", False))
+        for name, value in fields.items():
+            if override or not str(context.metadata.get(name) or "").strip():
+                context.metadata[name] = value
+        context.metadata["cover"] = dict(fields)
+
+        if mode == "drop":
+            self._remove(context, tref, filename)
+        elif mode == "generate":
+            front = self._front_matter(context, topic, fields, options.get("template"), report)
+            if front is not None:
+                front.set("id", topic.get("id") or "front_matter")
+                context.topics[filename] = front
+            tref.set("toc", "no")
+        else:
+            tref.set("toc", "no")
+        summary = ", ".join(f"{k}={v}" for k, v in fields.items()) or "no fields"
+        report.info(self.name, f"Cover page detected ({mode}): {summary}", topic=filename, **fields)
+
+    @staticmethod
+    def _cover(context: DitaContext, options: Mapping[str, Any],
+               patterns: Mapping[str, str]) -> Optional[Tuple[Any, str, Any, List[str]]]:
+        root = context.ditamap_root
+        tref = next(root.iter("topicref"), None) if root is not None else None
+        if tref is None:
+            return None
+        filename = (tref.get("href") or "").split("#")[0].split("/")[-1]
+        topic = context.topics.get(filename)
+        body = topic_body(topic)
+        if topic is None or body is None:
+            return None
+        title = topic.find("title")
+        lines = [_text(title)] if title is not None and _text(title) else []
+        lines += [t for t in (_text(p) for p in body.iter("p")) if t]
+        if topic.get("data-origin") == "cover":
+            return tref, filename, topic, lines
+        if not options.get("detect", True) or any(is_element(c) and local_name(c) == "topicref" for c in tref):
+            return None
+        if any(local_name(el) in _STRUCTURE for el in body.iter() if is_element(el)):
+            return None
+        if sum(len(line.split()) for line in lines) > int(options.get("max_words", 120)):
+            return None
+        # Field lines of a cover are short ("Issue 3"), unlike prose mentioning a version
+        fields = extract_cover_fields([line for line in lines if len(line.split()) <= 10], patterns)
+        if not any(name != "manual_title" for name in fields):
+            return None
+        return tref, filename, topic, lines
+
+    @staticmethod
+    def _remove(context: DitaContext, tref, filename: str) -> None:
+        parent = tref.getparent()
+        if parent is not None:
+            parent.remove(tref)
+        context.topics.pop(filename, None)
+
+    def _front_matter(self, context: DitaContext, cover, fields: Mapping[str, str], template: Optional[str],
+                      report: ConversionReport) -> Optional[Any]:
+        if template:
+            try:
+                from orlando_toolkit.core.xml_security import parse_file
+
+                root = parse_file(Path(template).expanduser())
+                root = root.getroot() if hasattr(root, "getroot") else root
+            except Exception as exc:
+                report.warning(self.name, f"Front-matter template {template} could not be read: {exc}")
+                return None
+            front = deepcopy(root)
+            self._fill(front, fields)
+            return front
+
+        lang = document_language(context)
+        catalog = get_catalog()
+        front = ET.Element("concept")
+        ET.SubElement(front, "title").text = fields.get("manual_title") or catalog.get("untitled", lang)
+        body = ET.SubElement(front, "conbody")
+        for name, key in _LABELS.items():
+            if fields.get(name):
+                ET.SubElement(body, "p", outputclass=name.replace("_", "-")).text = \
+                    catalog.get(key, lang, value=fields[name])
+        cover_body = topic_body(cover)
+        for image in list(cover_body.iter("image")) if cover_body is not None else []:
+            holder = image.getparent() if image.getparent() is not None and local_name(image.getparent()) in (
+                "fig", "p") else image
+            if holder.getparent() is not None and holder not in body:
+                copy = deepcopy(holder)
+                copy.tail = None
+                body.append(copy)
+        return front
+
+    @staticmethod
+    def _fill(root, fields: Mapping[str, str]) -> None:
+        """Replace ``{field}`` placeholders; drop elements whose placeholders are all empty."""
+        def _sub(text: str) -> Tuple[str, bool, bool]:
+            names = _PLACEHOLDER.findall(text)
+            filled = _PLACEHOLDER.sub(lambda m: fields.get(m.group(1), ""), text)
+            return filled, bool(names), any(fields.get(n) for n in names)
+
+        for el in list(root.iter()):
+            if not is_element(el):
+                continue
+            for attr, value in list(el.attrib.items()):
+                el.set(attr, _sub(value)[0])
+            if el.text:
+                filled, had, any_value = _sub(el.text)
+                if had and not any_value and not len(el) and el.getparent() is not None:
+                    parent = el.getparent()
+                    parent.remove(el)
+                    continue
+                el.text = filled
+            if el.tail:
+                el.tail = _sub(el.tail)[0]
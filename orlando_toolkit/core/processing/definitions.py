@@ -0,0 +1,306 @@
+from __future__ import annotations
+
+"""Definition lists and glossaries from paragraph conventions.
+
+Glossaries and "terms and definitions" sections arrive as flat paragraphs.
+Two patterns are recognised among consecutive paragraphs of a body or
+section:
+
+- one paragraph per entry: "Term — definition" (``separators``), or a bold
+  lead term followed by a separator or a colon ("**Term**: definition");
+- a term paragraph followed by its definition paragraphs, which are
+  indented further (``data-indent`` hint, in points) or carry one of the
+  ``definition_styles``; term paragraphs are short, do not end with
+  punctuation and may carry one of the ``term_styles``.
+
+Terms have at most ``max_term_words`` words and a run needs ``min_entries``
+entries. With ``output: dl`` a run becomes ``<dl>``/``<dlentry>`` in place;
+with ``output: glossentry`` every entry becomes a glossary entry topic
+(``<glossentry>``/``<glossterm>``/``<glossdef>``) referenced under the topic
+that held it, and a topic left without content turns into a topichead.
+Merging an entry back into its parent turns it into a concept again
+(:func:`glossentry_to_concept`).
+"""
+
+import logging
+import re
+from dataclasses import dataclass
+from typing import Any, Dict, FrozenSet, List, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import is_element, local_name
+from orlando_toolkit.core.style_usage import PARAGRAPH_HINT
+from orlando_toolkit.core.utils import slugify, topic_body
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["DefinitionListStage", "glossentry_to_concept"]
+
+_OUTPUTS = ("dl", "glossentry")
+_DEFAULT_SEPARATORS = ("—", "–")
+# Body elements whose paragraphs may form definition lists
+_CONTAINERS = frozenset({"conbody", "section", "body", "refbody", "example"})
+_TERM_END = re.compile(r"[.;,!?…]\s*$")
+
+
+def _text(el) -> str:
+    return " ".join("".join(el.itertext()).split())
+
+
+def _style(el) -> str:
+    return (el.get("data-style") or "").casefold()
+
+
+def _indent(el) -> float:
+    try:
+        return float(el.get("data-indent") or 0)
+    except ValueError:
+        return 0.0
+
+
+def _move_content(source, target, text: Optional[str]) -> None:
+    """Append *text* and then *source*'s children to *target*."""
+    if text:
+        target.text = (target.text or "") + text
+    for child in list(source):
+        target.append(child)
+
+
+def glossentry_to_concept(topic) -> None:
+    """Rewrite a glossentry in place as a concept; the term becomes the title."""
+    if local_name(topic) != "glossentry":
+        return
+    topic.tag = "concept"
+    term = topic.find("glossterm")
+    if term is not None:
+        term.tag = "title"
+    definition = topic.find("glossdef")
+    if definition is None:
+        ET.SubElement(topic, "conbody")
+        return
+    definition.tag = "conbody"
+    if (definition.text or "").strip() or any(local_name(c) not in ("p", "ul", "ol", "dl", "note", "fig")
+                                              for c in definition if is_element(c)):
+        # Inline definition text needs a paragraph in a conbody
+        para = ET.Element("p")
+        _move_content(definition, para, definition.text)
+        definition.text = None
+        definition.append(para)
+
+
+@dataclass(frozen=True)
+class _Rules:
+    inline: Optional["re.Pattern[str]"]
+    lead: "re.Pattern[str]"
+    term_styles: FrozenSet[str]
+    definition_styles: FrozenSet[str]
+    max_words: int
+
+    @classmethod
+    def from_options(cls, options: Dict[str, Any]) -> "_Rules":
+        separators = [re.escape(str(s).strip()) for s in options.get("separators") or _DEFAULT_SEPARATORS
+                      if str(s).strip()]
+        inline = re.compile(r"^\s*(?P<term>\S.{0,80}?)\s+(?:" + "|".join(separators) + r")\s+(?=\S)",
+                            re.S) if separators else None
+        return cls(
+            inline=inline,
+            lead=re.compile(r"^\s*(?:" + "|".join([":"] + separators) + r")\s*"),
+            term_styles=frozenset(str(s).casefold() for s in options.get("term_styles") or ()),
+            definition_styles=frozenset(str(s).casefold() for s in options.get("definition_styles") or ()),
+            max_words=max(1, int(options.get("max_term_words", 6))),
+        )
+
+    def is_term(self, text: str) -> bool:
+        return bool(text) and len(text.split()) <= self.max_words and not _TERM_END.search(text)
+
+    def inline_entry(self, para) -> Optional[Tuple[str, Any]]:
+        """``(kind, (term, offset))`` when *para* holds a term and its definition."""
+        first = para[0] if len(para) else None
+        if not (para.text or "").strip() and first is not None and local_name(first) in ("b", "strong"):
+            inner = _text(first)
+            tail = first.tail or ""
+            # "**Term:** definition" or "**Term**: definition" / "**Term** — definition"
+            lead = re.match(r"^\s*", tail) if inner.endswith(":") else self.lead.match(tail)
+            term = inner.rstrip(":").strip()
+            if lead and self.is_term(term) and (tail[lead.end():].strip() or len(para) > 1):
+                return "bold", (term, lead.end())
+        if self.inline is not None and para.text:
+            match = self.inline.match(para.text)
+            if match and self.is_term(match.group("term").strip()):
+                return "plain", (match.group("term").strip(), match.end())
+        return None
+
+    def is_definition(self, para, term) -> bool:
+        if _style(para) in self.definition_styles:
+            return True
+        return para.get("data-indent") is not None and _indent(para) > _indent(term)
+
+
+class DefinitionListStage(ProcessingStage):
+    name = "definitions"
+    hint_attributes = ("data-style", "data-indent")
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        output = str(options.get("output", "dl"))
+        if output not in _OUTPUTS:
+            report.warning(self.name, f"Unknown definitions output {output!r} (expected {', '.join(_OUTPUTS)})")
+            return
+        rules = _Rules.from_options(options)
+        min_entries = max(1, int(options.get("min_entries", 2)))
+
+        for filename, root in list(context.topics.items()):
+            lists: List[Any] = []
+            containers = [el for el in root.iter() if is_element(el) and local_name(el) in _CONTAINERS]
+            for container in containers:
+                for run in self._runs(container, rules):
+                    if len(run) >= min_entries:
+                        lists.append(self._build_list(container, run))
+            if not lists:
+                continue
+            entries = sum(len(dl) for dl in lists)
+            if output == "glossentry" and self._to_glossentries(context, filename, root, lists):
+                report.info(self.name, f"{entries} glossary entries created", topic=filename, entries=entries)
+            else:
+                report.info(self.name, f"{len(lists)} definition list(s) with {entries} entries detected",
+                            topic=filename, lists=len(lists), entries=entries)
+
+    @staticmethod
+    def _runs(container, rules: _Rules) -> List[List[Tuple[str, Any, List[Any]]]]:
+        """Runs of consecutive entries: ``(kind, detail, paragraphs)``."""
+        runs: List[List[Tuple[str, Any, List[Any]]]] = [[]]
+        children = [c for c in container if is_element(c)]
+        i = 0
+        while i < len(children):
+            child = children[i]
+            if local_name(child) != "p":
+                runs.append([])
+                i += 1
+                continue
+            inline = rules.inline_entry(child)
+            if inline is not None and _style(child) not in rules.term_styles:
+                runs[-1].append((inline[0], inline[1], [child]))
+                i += 1
+                continue
+            definitions = []
+            j = i + 1
+            while j < len(children) and local_name(children[j]) == "p" and rules.is_definition(children[j], child):
+                definitions.append(children[j])
+                j += 1
+            term = _text(child)
+            if definitions and rules.is_term(term) and _style(child) not in rules.definition_styles:
+                runs[-1].append(("block", term, [child] + definitions))
+                i = j
+                continue
+            runs.append([])
+            i += 1
+        return [run for run in runs if run]
+
+    # ------------------------------------------------------------------
+    # Rewriting
+    # ------------------------------------------------------------------
+    @staticmethod
+    def _entry(kind: str, detail: Any, paragraphs: List[Any]) -> Any:
+        entry = ET.Element("dlentry")
+        dt = ET.SubElement(entry, "dt")
+        dd = ET.SubElement(entry, "dd")
+        para = paragraphs[0]
+        if para.get(PARAGRAPH_HINT):
+            entry.set(PARAGRAPH_HINT, para.get(PARAGRAPH_HINT))
+        if kind == "bold":
+            term, offset = detail
+            dt.text = term
+            first = para[0]
+            para.remove(first)
+            _move_content(para, dd, (first.tail or "")[offset:])
+        elif kind == "plain":
+            term, offset = detail
+            dt.text = term
+            _move_content(para, dd, para.text[offset:])
+        else:
+            dt.text = detail
+            definitions = paragraphs[1:]
+            if len(definitions) == 1:
+                _move_content(definitions[0], dd, definitions[0].text)
+            else:
+                for definition in definitions:
+                    definition.tail = None
+                    dd.append(definition)
+        if dd.text:
+            dd.text = dd.text.lstrip()
+        return entry
+
+    def _build_list(self, container, run: List[Tuple[str, Any, Any]]) -> Any:
+        first = run[0][2][0]
+        index = list(container).index(first)
+        tail = run[-1][2][-1].tail
+        dl = ET.Element("dl")
+        for kind, detail, paragraphs in run:
+            for para in paragraphs:
+                container.remove(para)
+            dl.append(self._entry(kind, detail, paragraphs))
+        dl.tail = tail
+        container.insert(index, dl)
+        return dl
+
+    @staticmethod
+    def _to_glossentries(context: DitaContext, filename: str, root, lists: List[Any]) -> bool:
+        """Move the entries of *lists* into glossentry topics under *filename*'s topicref."""
+        map_root = context.ditamap_root
+        tref = None
+        if map_root is not None:
+            tref = next((r for r in map_root.iter("topicref")
+                         if (r.get("href") or "").split("#")[0].split("/")[-1] == filename), None)
+        if tref is None:
+            return False
+        href = tref.get("href") or ""
+        folder = href.rsplit("/", 1)[0] + "/" if "/" in href else ""
+        stem = filename.rsplit(".", 1)[0]
+        position = 1 if len(tref) and local_name(tref[0]) == "topicmeta" else 0
+        for dl in lists:
+            for entry in dl.findall("dlentry"):
+                dt, dd = entry.find("dt"), entry.find("dd")
+                term = _text(dt)
+                slug = slugify(term) or "entry"
+                name, n = f"{stem}_{slug}.dita", 2
+                while name in context.topics:
+                    name, n = f"{stem}_{slug}_{n}.dita", n + 1
+                gloss = ET.Element("glossentry", id=name[:-5])
+                glossterm = ET.SubElement(gloss, "glossterm")
+                _move_content(dt, glossterm, dt.text)
+                glossdef = ET.SubElement(gloss, "glossdef")
+                _move_content(dd, glossdef, dd.text)
+                context.topics[name] = gloss
+                ref = ET.Element("topicref", href=folder + name, type="glossentry")
+                ET.SubElement(ET.SubElement(ref, "topicmeta"), "navtitle").text = term
+                tref.insert(position, ref)
+                position += 1
+            parent = dl.getparent()
+            if dl.tail and dl.tail.strip():
+                previous = dl.getprevious()
+                if previous is not None:
+                    previous.tail = (previous.tail or "") + dl.tail
+                else:
+                    parent.text = (parent.text or "") + dl.tail
+            parent.remove(dl)
+
+        body = topic_body(root)
+        if body is not None and not len(body) and not (body.text or "").strip():
+            # Nothing left but the heading: keep it as a section of the map
+            title = root.find("title")
+            tref.tag = "topichead"
+            for attr in ("href", "type", "format", "scope"):
+                tref.attrib.pop(attr, None)
+            meta = tref.find("topicmeta")
+            if meta is None:
+                meta = ET.Element("topicmeta")
+                tref.insert(0, meta)
+            navtitle = meta.find("navtitle")
+            if navtitle is None:
+                navtitle = ET.SubElement(meta, "navtitle")
+            navtitle.text = _text(title) if title is not None else stem
+            del context.topics[filename]
+        return True
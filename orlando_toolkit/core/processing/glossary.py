@@ -0,0 +1,344 @@
+from __future__ import annotations
+
+"""Glossary entry topics from acronym definitions and glossary sections.
+
+Terms come from two places:
+
+- acronyms defined in the text, "Extended Yaw Zone (EYZ)" or "EYZ
+  (Extended Yaw Zone)", the initials of the spelled out words matching the
+  acronym (the rules of the ``acronyms`` stage);
+- glossary-style sections: topics titled like one of ``sections``
+  ("Glossary", "Abbreviations", ...), whose definition lists (built by the
+  ``definitions`` stage) and two-column tables are read as term and
+  definition.
+
+Each term becomes a ``<glossentry>`` topic; for an acronym the spelled out
+form is the ``glossterm`` and the acronym a ``glossAlt/glossAcronym``. The
+entries are listed alphabetically under a ``title`` topichead appended to
+the map, each topicref defining a key; a glossary section holding nothing
+but its terms is replaced by that branch. With ``link: first`` the first use
+of each acronym in a topic (its definition included) becomes
+``<abbreviated-form keyref="..."/>``, which publishing expands on first
+use; ``link: all`` links every use. Glossary entries the ``definitions``
+stage created are kept and get a key; conflicting expansions are reported.
+"""
+
+import copy
+import logging
+import re
+from dataclasses import dataclass, field
+from typing import Any, Dict, List, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.i18n import XML_LANG
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.acronyms import _WORDS, _skipped, find_acronyms, is_expansion
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import get_slot, is_element, iter_text_slots, local_name, set_slot
+from orlando_toolkit.core.utils import clean_heading_text, slugify, topic_body
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["GlossaryStage", "find_definitions"]
+
+_LINK_MODES = ("first", "all", "none")
+_DEFAULT_SECTIONS = ("glossary", "abbreviations", "acronyms", "definitions", "terms and definitions")
+_AFTER = re.compile(r"\s*\(([^()]{3,120})\)")
+# Blocks a glossary section may hold besides its terms
+_INTRO_TAGS = frozenset({"p", "title"})
+
+
+@dataclass
+class _Term:
+    term: str
+    acronym: str = ""
+    definition: Any = None  # element whose content is the definition
+    sources: List[str] = field(default_factory=list)
+
+
+def _text(el) -> str:
+    return " ".join("".join(el.itertext()).split())
+
+
+def find_definitions(text: str) -> List[Tuple[str, str, int, int]]:
+    """``(acronym, expansion, start, end)`` of the acronym definitions in *text*."""
+    found: List[Tuple[str, str, int, int]] = []
+    for acronym, start, end in find_acronyms(text):
+        before = text[:start]
+        if before.rstrip().endswith("(") and text[end:].lstrip("s").startswith(")"):
+            opening = before.rstrip()
+            words = list(_WORDS.finditer(opening[:-1]))[-(len(acronym) + 4):]
+            for n in range(1, len(words) + 1):
+                chosen = words[-n:]
+                if is_expansion([w.group(0) for w in chosen], acronym):
+                    first = chosen[0].start()
+                    expansion = " ".join(opening[first:-1].split())
+                    found.append((acronym, expansion, first, end + text[end:].index(")") + 1))
+                    break
+            continue
+        match = _AFTER.match(text, end)
+        if match and is_expansion(_WORDS.findall(match.group(1)), acronym):
+            found.append((acronym, " ".join(match.group(1).split()), start, match.end()))
+    return found
+
+
+def _replace_spans(el, slot: str, spans: List[Tuple[int, int, str]]) -> None:
+    """Replace the ``(start, end, keyref)`` spans of a text slot by ``<abbreviated-form>``."""
+    text = get_slot(el, slot) or ""
+    spans = sorted(spans)
+    set_slot(el, slot, text[:spans[0][0]] or None)
+    if slot == "text":
+        parent, index = el, 0
+    else:
+        parent = el.getparent()
+        index = list(parent).index(el) + 1
+    for offset, (start, end, key) in enumerate(spans):
+        ref = ET.Element("abbreviated-form", keyref=key)
+        following = spans[offset + 1][0] if offset + 1 < len(spans) else len(text)
+        ref.tail = text[end:following] or None
+        parent.insert(index + offset, ref)
+
+
+class GlossaryStage(ProcessingStage):
+    name = "glossary"
+
+    def is_enabled(self, options: Dict[str, Any]) -> bool:
+        return bool(options.get("enabled", False))
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        link = str(options.get("link", "first"))
+        if link not in _LINK_MODES:
+            report.warning(self.name, f"Unknown glossary link mode {link!r} (expected {', '.join(_LINK_MODES)})")
+            return
+        sections = {str(s).casefold() for s in options.get("sections") or _DEFAULT_SECTIONS}
+        terms: Dict[str, _Term] = {}
+        expansions: Dict[str, List[str]] = {}
+
+        harvested: List[str] = []
+        for filename, root in list(context.topics.items()):
+            if local_name(root) == "glossentry":
+                continue
+            if self._is_glossary_section(root, sections):
+                if self._harvest(root, filename, terms):
+                    harvested.append(filename)
+            elif options.get("acronyms", True):
+                self._collect_definitions(root, filename, terms, expansions)
+        for acronym, found in expansions.items():
+            if len(set(map(str.casefold, found))) > 1:
+                report.warning(self.name, f"{acronym} is spelled out differently: {'; '.join(dict.fromkeys(found))}",
+                               acronym=acronym, expansions=list(dict.fromkeys(found)))
+
+        keys = self._existing_entries(context, terms)
+        created = self._create_entries(context, terms, keys, str(options.get("title") or "Glossary"), harvested)
+        if created:
+            acronyms = sum(1 for t in terms.values() if t.acronym)
+            report.info(self.name, f"{created} glossary entries generated ({acronyms} acronym(s))",
+                        entries=created, acronyms=acronyms)
+        if link != "none" and keys:
+            linked = 0
+            for filename, root in context.topics.items():
+                if local_name(root) != "glossentry":
+                    linked += self._link(root, keys, every=link == "all")
+            if linked:
+                report.info(self.name, f"{linked} acronym use(s) linked to glossary entries", linked=linked)
+
+    # ------------------------------------------------------------------
+    # Collecting terms
+    # ------------------------------------------------------------------
+    @staticmethod
+    def _is_glossary_section(root, sections) -> bool:
+        title = root.find("title")
+        if title is None:
+            return False
+        text = clean_heading_text(_text(title)).casefold().strip(" :.")
+        text = re.sub(r"^(?:appendix|annex|annexe|anhang)\s+\w+\s*[-–—:.]?\s*", "", text)
+        return any(text == s or text.endswith(" " + s) for s in sections)
+
+    @staticmethod
+    def _add(terms: Dict[str, _Term], term: str, definition: Any, filename: str) -> None:
+        term = " ".join(term.split()).rstrip(":")
+        if not term:
+            return
+        acronyms = [a for a, _, _ in find_acronyms(term)]
+        acronym = term if acronyms == [term] else ""
+        expansion = _text(definition) if definition is not None else ""
+        if acronym and expansion and is_expansion(_WORDS.findall(expansion.rstrip(".")), acronym):
+            entry = _Term(term=expansion.rstrip("."), acronym=acronym)
+        else:
+            entry = _Term(term=term, acronym=acronym, definition=definition)
+        key = (acronym or term).casefold()
+        existing = terms.get(key)
+        if existing is None:
+            terms[key] = entry
+        elif existing.definition is None and entry.definition is not None:
+            existing.definition = entry.definition
+        (existing or entry).sources.append(filename)
+
+    def _harvest(self, root, filename: str, terms: Dict[str, _Term]) -> bool:
+        """Read the terms of a glossary section; True when it holds nothing else."""
+        body = topic_body(root)
+        if body is None:
+            return False
+        only_terms = True
+        for child in body:
+            if not is_element(child):
+                continue
+            name = local_name(child)
+            if name == "dl":
+                for entry in child.iter("dlentry"):
+                    dt, dd = entry.find("dt"), entry.find("dd")
+                    if dt is not None:
+                        self._add(terms, _text(dt), dd, filename)
+            elif name in ("table", "simpletable"):
+                only_terms = self._table_terms(child, filename, terms) and only_terms
+            elif name not in _INTRO_TAGS:
+                only_terms = False
+        return only_terms
+
+    def _table_terms(self, table, filename: str, terms: Dict[str, _Term]) -> bool:
+        if local_name(table) == "simpletable":
+            rows = [[c for c in row if local_name(c) == "stentry"] for row in table.iter("strow")]
+        else:
+            rows = [[c for c in row if local_name(c) == "entry"] for body in table.iter("tbody")
+                    for row in body.iter("row")]
+        if not rows or any(len(r) != 2 for r in rows):
+            return False
+        for term, definition in rows:
+            self._add(terms, _text(term), definition, filename)
+        return True
+
+    def _collect_definitions(self, root, filename: str, terms: Dict[str, _Term],
+                             expansions: Dict[str, List[str]]) -> None:
+        body = topic_body(root)
+        if body is None:
+            return
+        for el, slot in iter_text_slots(body):
+            owner = el if slot == "text" else el.getparent()
+            text = get_slot(el, slot)
+            if not text or owner is None or _skipped(owner):
+                continue
+            for acronym, expansion, _, _ in find_definitions(text):
+                expansions.setdefault(acronym, []).append(expansion)
+                key = acronym.casefold()
+                if key not in terms:
+                    terms[key] = _Term(term=expansion, acronym=acronym)
+                terms[key].sources.append(filename)
+
+    # ------------------------------------------------------------------
+    # Entries and map
+    # ------------------------------------------------------------------
+    @staticmethod
+    def _existing_entries(context: DitaContext, terms: Dict[str, _Term]) -> Dict[str, str]:
+        """Key per acronym of the glossary entries already in the map; their terms are not generated again."""
+        keys: Dict[str, str] = {}
+        root = context.ditamap_root
+        refs = {(r.get("href") or "").split("/")[-1]: r for r in root.iter("topicref")} if root is not None else {}
+        for filename, topic in context.topics.items():
+            if local_name(topic) != "glossentry":
+                continue
+            glossterm = topic.find("glossterm")
+            term = _text(glossterm) if glossterm is not None else ""
+            alt = topic.find("glossBody/glossAlt/glossAcronym")
+            if alt is not None:
+                acronym = _text(alt)
+            else:
+                acronym = term if [a for a, _, _ in find_acronyms(term)] == [term] else ""
+            terms.pop((acronym or term).casefold(), None)
+            ref = refs.get(filename)
+            if acronym and ref is not None:
+                if not ref.get("keys"):
+                    ref.set("keys", topic.get("id") or filename.rsplit(".", 1)[0])
+                keys[acronym] = ref.get("keys").split()[0]
+        return keys
+
+    @staticmethod
+    def _entry(term: _Term, topic_id: str, lang: Optional[str]) -> Any:
+        gloss = ET.Element("glossentry", id=topic_id)
+        if lang:
+            gloss.set(XML_LANG, lang)
+        ET.SubElement(gloss, "glossterm").text = term.term
+        if term.definition is not None:
+            glossdef = ET.SubElement(gloss, "glossdef")
+            glossdef.text = term.definition.text
+            for child in term.definition:
+                glossdef.append(copy.deepcopy(child))
+            if glossdef.text:
+                glossdef.text = glossdef.text.lstrip()
+        if term.acronym and term.acronym != term.term:
+            alt = ET.SubElement(ET.SubElement(gloss, "glossBody"), "glossAlt")
+            ET.SubElement(alt, "glossAcronym").text = term.acronym
+        return gloss
+
+    def _create_entries(self, context: DitaContext, terms: Dict[str, _Term], keys: Dict[str, str], title: str,
+                        harvested: List[str]) -> int:
+        root = context.ditamap_root
+        if root is None or not terms:
+            return 0
+        lang = root.get(XML_LANG)
+        head = ET.Element("topichead")
+        ET.SubElement(ET.SubElement(head, "topicmeta"), "navtitle").text = title
+        for term in sorted(terms.values(), key=lambda t: t.term.casefold()):
+            slug = slugify(term.acronym or term.term) or "entry"
+            topic_id, n = f"gloss_{slug}", 2
+            while f"{topic_id}.dita" in context.topics:
+                topic_id, n = f"gloss_{slug}_{n}", n + 1
+            context.topics[f"{topic_id}.dita"] = self._entry(term, topic_id, lang)
+            ref = ET.SubElement(head, "topicref", href=f"topics/{topic_id}.dita", keys=topic_id, type="glossentry")
+            ET.SubElement(ET.SubElement(ref, "topicmeta"), "navtitle").text = term.term
+            if term.acronym:
+                keys[term.acronym] = topic_id
+
+        # A section that held only its terms gives way to the generated branch
+        replaced = None
+        for filename in harvested:
+            ref = next((r for r in root.iter("topicref")
+                        if (r.get("href") or "").split("#")[0].split("/")[-1] == filename), None)
+            if ref is None or ref.find("topicref") is not None or ref.getparent() is None:
+                continue
+            if replaced is None:
+                section_title = context.topics[filename].find("title")
+                head.find("topicmeta/navtitle").text = _text(section_title) or title
+                parent = ref.getparent()
+                parent.insert(list(parent).index(ref), head)
+                parent.remove(ref)
+                replaced = head
+            else:
+                ref.getparent().remove(ref)
+            del context.topics[filename]
+        if replaced is None:
+            root.append(head)
+        return len(terms)
+
+    # ------------------------------------------------------------------
+    # Linking
+    # ------------------------------------------------------------------
+    @staticmethod
+    def _link(root, keys: Dict[str, str], *, every: bool) -> int:
+        body = topic_body(root)
+        if body is None:
+            return 0
+        linked: set = set()
+        count = 0
+        for el, slot in list(iter_text_slots(body)):
+            owner = el if slot == "text" else el.getparent()
+            text = get_slot(el, slot)
+            if not text or owner is None or _skipped(owner):
+                continue
+            spans: List[Tuple[int, int, str]] = []
+            for acronym, _, start, end in find_definitions(text):
+                if acronym in keys and (every or acronym not in linked):
+                    spans.append((start, end, keys[acronym]))
+                    linked.add(acronym)
+            for acronym, start, end in find_acronyms(text):
+                if acronym not in keys or (acronym in linked and not every):
+                    continue
+                if any(s <= start < e for s, e, _ in spans):
+                    continue
+                spans.append((start, end, keys[acronym]))
+                linked.add(acronym)
+            if spans:
+                _replace_spans(el, slot, spans)
+                count += len(spans)
+        return count
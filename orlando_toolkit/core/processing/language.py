@@ -0,0 +1,323 @@
+from __future__ import annotations
+
+"""``xml:lang`` policy.
+
+What the stage does:
+
+- Applies plugin language hints (``data-lang`` on any element, e.g. from Word
+  ``w:lang``) as ``xml:lang``. A paragraph whose runs are mostly in one
+  language takes that language.
+- With ``detect``, guesses the language of the remaining paragraphs from
+  their common words (:func:`guess_language`), among ``candidates``.
+  Paragraphs shorter than ``min_words`` keep the inherited language.
+- Moves a language shared by all the blocks of a section or list up to it.
+- Sets ``xml:lang`` on the map root from the document language
+  (``metadata["language"]``, else the language already declared on the map,
+  else ``default_language``), and on every topic root from the language of
+  most of its text (the document language without ``detect``). Declarations
+  already present on topic roots are kept unless ``override_existing`` is set.
+- Removes nested declarations that repeat the inherited language.
+- Reports topics whose content declares more than one language.
+
+:func:`apply_document_language` re-tags the map and the topics that follow
+the document language when ``metadata["language"]`` is changed after the
+conversion (the **Default Language** of the Metadata tab).
+"""
+
+import logging
+import re
+from typing import Any, Dict, Iterable, Optional, Sequence
+
+from orlando_toolkit.core.i18n import XML_LANG, canonical_language_tag, normalize_language
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import is_element
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["LanguageStage", "apply_document_language", "guess_language"]
+
+_DEFAULT_LANGUAGE = "en-US"
+_DEFAULT_CANDIDATES = ("en-US", "fr-FR", "de-DE", "es-ES", "it-IT", "nl-NL", "pt-PT")
+
+# Frequent short words, distinctive enough to tell the languages apart
+_STOPWORDS: Dict[str, frozenset] = {
+    "en": frozenset("the and of to is are was in for with on this that be by it from or as not if when "
+                    "which can must should your you have has before after".split()),
+    "fr": frozenset("le la les des du de et est sont un une pour avec dans sur par ce cette ces que qui "
+                    "ne pas au aux vous votre il elle doit être avant après".split()),
+    "de": frozenset("der die das und ist sind ein eine einen für mit auf von zu den dem des nicht sie "
+                    "wird werden oder wenn vor nach im bei".split()),
+    "es": frozenset("el la los las y es son un una para con en por que del al se no su sus debe "
+                    "antes después como este esta".split()),
+    "it": frozenset("il lo la gli le e è sono un una per con di del della che non si su nel al deve "
+                    "prima dopo come questo questa".split()),
+    "nl": frozenset("de het een en is zijn van voor met op in dat die niet te bij als wordt worden of "
+                    "u uw moet na".split()),
+    "pt": frozenset("o a os as e é são um uma para com em no na do da que não se por deve antes depois "
+                    "como este esta".split()),
+}
+_WORD = re.compile(r"[^\W\d_]+", re.UNICODE)
+
+# Text blocks whose language is detected; containers take the language of their blocks
+_BLOCKS = frozenset({"title", "shortdesc", "p", "li", "sli", "note", "lq", "dt", "dd", "entry", "stentry",
+                     "cmd", "info", "stepresult", "context", "result", "fn", "desc", "navtitle"})
+_CONTAINERS = frozenset({"section", "conbody", "body", "taskbody", "refbody", "example", "ol", "ul", "sl",
+                         "dl", "dlentry", "steps", "step", "substeps", "substep", "fig"})
+_SKIP = frozenset({"codeblock", "pre", "codeph", "screen", "msgblock", "filepath", "cmdname", "apiname"})
+
+
+def guess_language(text: str, candidates: Optional[Sequence[str]] = None, *, min_words: int = 6,
+                   min_share: float = 0.6) -> Optional[str]:
+    """Return the candidate tag whose common words dominate *text*, or ``None`` when unsure.
+
+    Candidates are matched on their primary subtag (``fr-CA`` uses the French
+    word list). Words such as ``de`` or ``la`` belong to several lists: the
+    best language needs *min_share* of its own and the runner-up's matches.
+    """
+    words = [w.lower() for w in _WORD.findall(text or "")]
+    if len(words) < min_words:
+        return None
+    tags: Dict[str, str] = {}
+    for tag in candidates or _DEFAULT_CANDIDATES:
+        canonical = canonical_language_tag(tag)
+        primary = canonical.split("-", 1)[0].lower() if canonical else None
+        if primary in _STOPWORDS and primary not in tags:
+            tags[primary] = canonical
+    scores = sorted(((sum(1 for w in words if w in _STOPWORDS[primary]), primary) for primary in tags),
+                    reverse=True)
+    if not scores:
+        return None
+    best, primary = scores[0]
+    runner_up = scores[1][0] if len(scores) > 1 else 0
+    if best < 2 or best < (best + runner_up) * min_share:
+        return None
+    return tags[primary]
+
+
+def _document_language(context: DitaContext, options: Dict[str, Any]) -> str:
+    for candidate in (
+        (context.metadata or {}).get("language"),
+        context.ditamap_root.get(XML_LANG) if context.ditamap_root is not None else None,
+        options.get("default_language"),
+    ):
+        tag = canonical_language_tag(candidate)
+        if tag:
+            return tag
+    return _DEFAULT_LANGUAGE
+
+
+class LanguageStage(ProcessingStage):
+    name = "language"
+    hint_attributes = ("data-lang",)
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        lang = _document_language(context, options)
+        override = bool(options.get("override_existing", False))
+        dedupe = bool(options.get("remove_redundant", True))
+        report_mixed = bool(options.get("report_mixed", True))
+
+        root = context.ditamap_root
+        if root is not None:
+            self._apply_hints(root)
+            if override or not root.get(XML_LANG):
+                root.set(XML_LANG, lang)
+            if dedupe:
+                self._remove_redundant(root, None)
+
+        detect = bool(options.get("detect", True))
+        candidates = self._candidates(lang, options.get("candidates"))
+        min_words = int(options.get("min_words", 6))
+
+        for filename, topic in context.topics.items():
+            hinted = self._apply_hints(topic)
+            hinted += self._promote_runs(topic, float(options.get("run_share", 0.5)))
+            detected = 0
+            if detect and (override or not topic.get(XML_LANG)):
+                detected = self._detect(topic, candidates, min_words)
+            self._hoist(topic)
+            if override or not topic.get(XML_LANG):
+                topic_lang = self._dominant(topic, lang) if detect else lang
+                topic.set(XML_LANG, topic_lang)
+                if normalize_language(topic_lang) != normalize_language(lang):
+                    report.info(self.name, f"Topic written in {topic_lang}, document language {lang}",
+                                topic=filename, language=topic_lang)
+            elif normalize_language(topic.get(XML_LANG)) != normalize_language(lang):
+                report.info(self.name, f"Topic language {topic.get(XML_LANG)} differs from document language {lang}",
+                            topic=filename, language=topic.get(XML_LANG))
+            removed = self._remove_redundant(topic, None) if dedupe else 0
+            if hinted or detected or removed:
+                report.info(self.name, "Language attributes applied", topic=filename,
+                            hinted=hinted, detected=detected, redundant_removed=removed)
+            if report_mixed:
+                languages = self._declared_languages(topic)
+                if len(languages) > 1:
+                    listed = ", ".join(f"{code} ({n})" for code, n in sorted(languages.items()))
+                    report.warning(self.name, f"Topic mixes languages: {listed}",
+                                   topic=filename, languages=languages)
+
+    def _apply_hints(self, root) -> int:
+        count = 0
+        for el in root.iter():
+            if not is_element(el):
+                continue
+            hint = canonical_language_tag(el.attrib.pop("data-lang", None))
+            if hint and el.get(XML_LANG) is None:
+                el.set(XML_LANG, hint)
+                count += 1
+        return count
+
+    @staticmethod
+    def _candidates(lang: str, configured: Optional[Iterable[str]]) -> list:
+        """Configured candidates, with the document language standing for its primary subtag."""
+        tags = [canonical_language_tag(t) for t in (configured or _DEFAULT_CANDIDATES)]
+        primary = lang.split("-", 1)[0].lower()
+        return [lang] + [t for t in tags if t and t.split("-", 1)[0].lower() != primary]
+
+    def _promote_runs(self, topic, share: float) -> int:
+        """Give a block without ``xml:lang`` the language of most of its runs."""
+        count = 0
+        for block in topic.iter(*_BLOCKS):
+            if block.get(XML_LANG) is not None or self._inherited(block, topic):
+                continue
+            counts = self._text_languages(block, None)
+            total = sum(counts.values())
+            tagged = {code: n for code, n in counts.items() if code}
+            if not total or not tagged:
+                continue
+            code, n = max(tagged.items(), key=lambda item: item[1])
+            if n > total * share:
+                block.set(XML_LANG, code)
+                count += 1
+        return count
+
+    def _detect(self, topic, candidates: Sequence[str], min_words: int) -> int:
+        count = 0
+        for block in topic.iter(*_BLOCKS):
+            if (block.get(XML_LANG) is not None or self._inherited(block, topic)
+                    or any(is_element(d) and d.tag in _BLOCKS for d in block.iterdescendants())):
+                continue
+            guess = guess_language(self._prose(block), candidates, min_words=min_words)
+            if guess:
+                block.set(XML_LANG, guess)
+                count += 1
+        return count
+
+    def _prose(self, el) -> str:
+        """Text of *el* without code and other untranslated content."""
+        parts = [el.text or ""] if el.tag not in _SKIP else []
+        for child in el:
+            if is_element(child):
+                parts.append(self._prose(child))
+            parts.append(child.tail or "")
+        return " ".join(parts)
+
+    def _hoist(self, el) -> Optional[str]:
+        """Declare on a container the language all its blocks share; returns *el*'s own language."""
+        own = el.get(XML_LANG)
+        children = [c for c in el if is_element(c)]
+        languages = {self._hoist(c) for c in children}
+        if (own is None and el.tag in _CONTAINERS and children and len(languages) == 1 and None not in languages
+                and not (el.text or "").strip() and not any((c.tail or "").strip() for c in children)):
+            own = languages.pop()
+            el.set(XML_LANG, own)
+        return canonical_language_tag(own)
+
+    def _dominant(self, topic, default: str) -> str:
+        """Language of most of the topic's text, untagged text counting for *default*."""
+        counts = self._text_languages(topic, None)
+        merged: Dict[str, int] = {}
+        for code, n in counts.items():
+            key = code or default
+            merged[key] = merged.get(key, 0) + n
+        if not merged:
+            return default
+        best = max(merged.values())
+        winners = [code for code, n in merged.items() if n == best]
+        return default if default in winners else winners[0]
+
+    @staticmethod
+    def _inherited(el, stop) -> bool:
+        parent = el.getparent()
+        while parent is not None and parent is not stop:
+            if parent.get(XML_LANG) is not None:
+                return True
+            parent = parent.getparent()
+        return False
+
+    def _text_languages(self, el, inherited: Optional[str]) -> Dict[Optional[str], int]:
+        """Non-blank characters of *el* per language in effect (None: undeclared).
+
+        The language declared on *el* itself is ignored, *inherited* applies instead.
+        """
+        counts: Dict[Optional[str], int] = {}
+
+        def _walk(node, current: Optional[str]) -> None:
+            if node is not el:
+                current = canonical_language_tag(node.get(XML_LANG)) or current
+            n = len("".join((node.text or "").split()))
+            if n:
+                counts[current] = counts.get(current, 0) + n
+            for child in node:
+                if is_element(child):
+                    _walk(child, current)
+                tail = len("".join((child.tail or "").split()))
+                if tail:
+                    counts[current] = counts.get(current, 0) + tail
+
+        _walk(el, inherited)
+        return counts
+
+    def _remove_redundant(self, el, inherited: Optional[str]) -> int:
+        removed = 0
+        own = normalize_language(el.get(XML_LANG))
+        if own is not None and own == inherited:
+            del el.attrib[XML_LANG]
+            removed += 1
+        current = own or inherited
+        for child in el:
+            if is_element(child):
+                removed += self._remove_redundant(child, current)
+        return removed
+
+    def _declared_languages(self, topic) -> Dict[str, int]:
+        """Languages in effect across the topic's elements, with element counts."""
+        counts: Dict[str, int] = {}
+
+        def _walk(el, inherited: Optional[str]) -> None:
+            current = canonical_language_tag(el.get(XML_LANG)) or inherited
+            if current and (el.text or "").strip():
+                counts[current] = counts.get(current, 0) + 1
+            for child in el:
+                if is_element(child):
+                    _walk(child, current)
+
+        _walk(topic, None)
+        return counts
+
+
+def apply_document_language(context: DitaContext) -> int:
+    """Move the map and the topics in the former document language to ``metadata["language"]``.
+
+    Topics declaring another language (a detected French chapter) keep it.
+    Returns the number of topics re-tagged.
+    """
+    lang = canonical_language_tag((context.metadata or {}).get("language"))
+    root = context.ditamap_root
+    if not lang or root is None:
+        return 0
+    previous = normalize_language(root.get(XML_LANG))
+    if previous == normalize_language(lang):
+        return 0
+    root.set(XML_LANG, lang)
+    stage = LanguageStage()
+    changed = 0
+    for topic in context.topics.values():
+        current = normalize_language(topic.get(XML_LANG))
+        if current is None or current == previous:
+            topic.set(XML_LANG, lang)
+            stage._remove_redundant(topic, None)
+            changed += 1
+    logger.info("Document language %s -> %s: %d topic(s) re-tagged", previous, lang, changed)
+    return changed
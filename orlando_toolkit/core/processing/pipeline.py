@@ -0,0 +1,185 @@
+from __future__ import annotations
+
+"""Runner for post-conversion processing stages.
+
+Stages run in a fixed order after the plugin handler returns. Each stage is
+isolated: an exception is logged and recorded in the conversion report and the
+remaining stages still run. Cancellation aborts; an exhausted time budget
+skips the remaining stages and marks the report partial. The time spent in
+each stage is added to ``report.timings``, and a ``process`` phase
+:class:`~orlando_toolkit.core.progress.ProgressEvent` is sent to the
+optional progress callback before each enabled stage.
+"""
+
+import logging
+import time
+from contextlib import nullcontext
+from typing import Any, Callable, Dict, List, Mapping, Optional, Sequence
+
+from orlando_toolkit.core.cancellation import CancellationToken, OperationCancelledError, check_cancelled
+from orlando_toolkit.core.errors import ToolkitError, report_error
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.performance import IMAGE_STAGES, phase
+from orlando_toolkit.core.progress import emit
+from orlando_toolkit.core.style_map import resolve_style_rules
+from orlando_toolkit.core.time_budget import TimeBudget, is_expired
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "default_stages",
+    "resolve_conversion_options",
+    "run_processing_stages",
+    "strip_stage_hints",
+]
+
+
+def default_stages() -> List[ProcessingStage]:
+    """Return fresh instances of the built-in stages in execution order."""
+    from orlando_toolkit.core.processing.acronyms import AcronymStage
+    from orlando_toolkit.core.processing.admonitions import AdmonitionStage
+    from orlando_toolkit.core.processing.appendices import AppendixStage
+    from orlando_toolkit.core.processing.boilerplate import BoilerplateStage
+    from orlando_toolkit.core.processing.breaks import LineBreakStage
+    from orlando_toolkit.core.processing.captions import CaptionStage
+    from orlando_toolkit.core.processing.cjk import CjkStage
+    from orlando_toolkit.core.processing.code import CodeDetectionStage
+    from orlando_toolkit.core.processing.conditional import ConditionalContentStage
+    from orlando_toolkit.core.processing.cover import CoverPageStage
+    from orlando_toolkit.core.processing.definitions import DefinitionListStage
+    from orlando_toolkit.core.processing.glossary import GlossaryStage
+    from orlando_toolkit.core.processing.language import LanguageStage
+    from orlando_toolkit.core.processing.preformatted import PreformattedStage
+    from orlando_toolkit.core.processing.procedures import ProcedureStage
+    from orlando_toolkit.core.processing.raster_images import RasterImageStage
+    from orlando_toolkit.core.processing.reference_tables import ReferenceTableStage
+    from orlando_toolkit.core.processing.rtl import BidiStage
+    from orlando_toolkit.core.processing.sensitive import SensitiveContentStage
+    from orlando_toolkit.core.processing.styles import StyleMapStage
+    from orlando_toolkit.core.processing.spelling import SpellCheckStage
+    from orlando_toolkit.core.processing.symbols import SymbolFontStage
+    from orlando_toolkit.core.processing.typography import TypographyStage
+    from orlando_toolkit.core.processing.unicode_policy import UnicodeNormalizationStage
+    from orlando_toolkit.core.processing.variables import VariablesStage
+    from orlando_toolkit.core.processing.vector_images import VectorImageStage
+
+    return [
+        ConditionalContentStage(),
+        SymbolFontStage(),
+        UnicodeNormalizationStage(),
+        LanguageStage(),
+        CoverPageStage(),
+        AppendixStage(),
+        StyleMapStage(),
+        PreformattedStage(),
+        CodeDetectionStage(),
+        BoilerplateStage(),
+        ProcedureStage(),
+        AdmonitionStage(),
+        DefinitionListStage(),
+        CaptionStage(),
+        ReferenceTableStage(),
+        LineBreakStage(),
+        TypographyStage(),
+        BidiStage(),
+        CjkStage(),
+        VariablesStage(),
+        VectorImageStage(),
+        RasterImageStage(),
+        AcronymStage(),
+        GlossaryStage(),
+        SpellCheckStage(),
+        SensitiveContentStage(),
+    ]
+
+
+def _deep_merge(base: Dict[str, Any], override: Mapping[str, Any]) -> Dict[str, Any]:
+    merged = dict(base)
+    for key, value in override.items():
+        if isinstance(value, Mapping) and isinstance(merged.get(key), Mapping):
+            merged[key] = _deep_merge(dict(merged[key]), value)
+        else:
+            merged[key] = value
+    return merged
+
+
+def resolve_conversion_options(metadata: Optional[Mapping[str, Any]] = None) -> Dict[str, Any]:
+    """Return ``conversion.yml`` merged with ``metadata["conversion_options"]``."""
+    base: Dict[str, Any] = {}
+    try:
+        from orlando_toolkit.config import ConfigManager
+        base = dict(ConfigManager().get_conversion_config() or {})
+    except Exception as exc:
+        logger.debug("Conversion config unavailable, using defaults: %s", exc)
+    override = (metadata or {}).get("conversion_options") if metadata else None
+    if isinstance(override, Mapping):
+        base = _deep_merge(base, override)
+    return base
+
+
+def run_processing_stages(
+    context: DitaContext,
+    *,
+    metadata: Optional[Mapping[str, Any]] = None,
+    stages: Optional[Sequence[ProcessingStage]] = None,
+    cancel_token: Optional[CancellationToken] = None,
+    time_budget: Optional[TimeBudget] = None,
+    progress_callback: Optional[Callable[[str], None]] = None,
+) -> DitaContext:
+    """Run *stages* (default: :func:`default_stages`) over *context* in place."""
+    options = resolve_conversion_options(metadata if metadata is not None else context.metadata)
+    report = context.report
+    style_rules = None
+    stages = list(stages if stages is not None else default_stages())
+    for index, stage in enumerate(stages):
+        check_cancelled(cancel_token)
+        stage_opts = options.get(stage.name) or {}
+        if not isinstance(stage_opts, Mapping):
+            stage_opts = {"enabled": bool(stage_opts)}
+        stage_opts = dict(stage_opts)
+        if stage.uses_style_map:
+            if style_rules is None:
+                style_rules = resolve_style_rules(metadata if metadata is not None else context.metadata, report)
+            stage_opts["style_map"] = style_rules
+        if not stage.is_enabled(stage_opts):
+            continue
+        if is_expired(time_budget):
+            reason = time_budget.describe()
+            report.warning("time_budget", f"Processing stage '{stage.name}' skipped: {reason}")
+            report.mark_partial(reason)
+            continue
+        emit(progress_callback, f"Processing: {stage.name} ({index + 1}/{len(stages)})...", "process",
+             step=stage.name, done=index, total=len(stages))
+        started = time.perf_counter()
+        try:
+            with phase("images") if stage.name in IMAGE_STAGES else nullcontext():
+                stage.process(context, stage_opts, report)
+        except OperationCancelledError:
+            raise
+        except Exception as exc:
+            logger.error("Processing stage %s failed: %s", stage.name, exc, exc_info=True)
+            report_error(report, ToolkitError(f"Stage failed: {exc}", code="OTK410", cause=exc), stage.name)
+        finally:
+            report.record_timing(stage.name, time.perf_counter() - started)
+    return context
+
+
+def strip_stage_hints(context: DitaContext, stages: Optional[Sequence[ProcessingStage]] = None) -> None:
+    """Remove plugin hint attributes consumed by *stages* from all topics.
+
+    Hints are not valid DITA; they are dropped at packaging time whether or not
+    the owning stage was enabled.
+    """
+    names = set()
+    for stage in (stages if stages is not None else default_stages()):
+        names.update(stage.hint_attributes)
+    if not names:
+        return
+    for topic_el in context.topics.values():
+        for el in topic_el.iter():
+            attrib = getattr(el, "attrib", None)
+            if not attrib:
+                continue
+            for name in names:
+                attrib.pop(name, None)
@@ -0,0 +1,128 @@
+from __future__ import annotations
+
+"""Whitespace-preserving blocks.
+
+What the stage does:
+
+- Turns paragraphs marked as preformatted into ``codeblock``/``pre``. A
+  paragraph is preformatted when a plugin sets ``data-preformatted="pre|codeblock"``
+  or when its source style (``data-style``, e.g. Word "HTML Preformatted")
+  is listed under ``styles``. Consecutive paragraphs of the same kind are
+  merged into one block, one source paragraph per line.
+- Sets ``xml:space="preserve"`` on every ``pre``/``codeblock`` (and the other
+  preformatted elements) so indentation and line breaks survive downstream
+  tools exactly.
+
+Text inside the block is never trimmed or reflowed; inline children
+(``b``, ``ph``, …) are kept.
+"""
+
+import logging
+from typing import Any, Dict, List, Optional
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import PREFORMATTED_TAGS, XML_SPACE, is_element, local_name
+from orlando_toolkit.core.style_usage import PARAGRAPH_HINT
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["PreformattedStage", "merge_into_block"]
+
+_KINDS = ("pre", "codeblock")
+
+
+def merge_into_block(paragraphs: List[Any], tag: str) -> Any:
+    """Replace sibling *paragraphs* with a single *tag* element, one per line.
+
+    The new element takes the first paragraph's position and the last
+    paragraph's tail. Whitespace between the source paragraphs is markup
+    formatting, not content, and is dropped.
+    """
+    first = paragraphs[0]
+    parent = first.getparent()
+    block = ET.Element(tag)
+    block.set(XML_SPACE, "preserve")
+    for attr in ("id", "outputclass", "dir", "data-code-language", PARAGRAPH_HINT):
+        if first.get(attr) is not None:
+            block.set(attr, first.get(attr))
+
+    last_node = None  # element whose tail receives the next text
+    for i, para in enumerate(paragraphs):
+        pieces = ("\n" if i else "") + (para.text or "")
+        if last_node is None:
+            block.text = (block.text or "") + pieces
+        else:
+            last_node.tail = (last_node.tail or "") + pieces
+        for child in list(para):
+            block.append(child)
+            last_node = child
+    block.tail = paragraphs[-1].tail
+    parent.insert(parent.index(first), block)
+    for para in paragraphs:
+        parent.remove(para)
+    return block
+
+
+class PreformattedStage(ProcessingStage):
+    name = "preformatted"
+    hint_attributes = ("data-preformatted", "data-style")
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        styles = {
+            str(k).strip().lower(): str(v).strip().lower()
+            for k, v in (options.get("styles") or {}).items()
+            if str(v).strip().lower() in _KINDS
+        }
+        merge = bool(options.get("merge_consecutive", True))
+        for filename, root in context.topics.items():
+            created = self._convert_paragraphs(root, styles, merge)
+            preserved = self._mark_preserve(root)
+            if created or preserved:
+                report.info(self.name, "Preformatted blocks applied", topic=filename,
+                            blocks=created, preserved=preserved)
+
+    def kind_of(self, el, styles: Dict[str, str]) -> Optional[str]:
+        """Target element for paragraph *el*, or ``None``."""
+        if not is_element(el) or local_name(el) != "p":
+            return None
+        hint = (el.get("data-preformatted") or "").strip().lower()
+        if hint in _KINDS:
+            return hint
+        style = (el.get("data-style") or "").strip().lower()
+        return styles.get(style) if style else None
+
+    def _convert_paragraphs(self, root, styles: Dict[str, str], merge: bool) -> int:
+        groups: List[List[Any]] = []
+        kinds: List[str] = []
+        for el in list(root.iter()):
+            kind = self.kind_of(el, styles)
+            if kind is None:
+                continue
+            prev = groups[-1][-1] if groups else None
+            adjacent = (
+                merge and prev is not None and kinds[-1] == kind
+                and prev.getnext() is el and not (prev.tail or "").strip()
+            )
+            if adjacent:
+                groups[-1].append(el)
+            else:
+                groups.append([el])
+                kinds.append(kind)
+        for group, kind in zip(groups, kinds):
+            for para in group:
+                para.attrib.pop("data-preformatted", None)
+                para.attrib.pop("data-style", None)
+            merge_into_block(group, kind)
+        return len(groups)
+
+    def _mark_preserve(self, root) -> int:
+        count = 0
+        for el in root.iter():
+            if is_element(el) and local_name(el) in PREFORMATTED_TAGS and el.get(XML_SPACE) != "preserve":
+                el.set(XML_SPACE, "preserve")
+                count += 1
+        return count
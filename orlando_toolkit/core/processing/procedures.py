@@ -0,0 +1,357 @@
+from __future__ import annotations
+
+"""Numbered procedures as task steps.
+
+Word procedures arrive as plain ordered lists in concept topics. A list is a
+procedure when its items (or the list) carry a step style (``data-style``
+matching ``step_styles``, e.g. "List Step"), or when at least
+``imperative_ratio`` of its items start with an imperative verb ("Click",
+"Remove", …; ``extra_verbs`` adds more). Consecutive paragraphs in a step
+style count as a procedure too.
+
+A concept whose body holds exactly one procedure and no sections becomes a
+task: the content before the procedure goes into ``<context>`` (or
+``<prereq>`` for blocks in a ``prereq_styles`` style and those introduced by
+a "Prerequisites:" or "Before you begin" label), the procedure into
+``<steps>``, the content after it into ``<result>``. In each
+step the first paragraph (or the item's own text) is the ``<cmd>``; the
+following paragraphs become ``<stepresult>`` when they describe an outcome
+("The dialog closes.", "A message appears.") and ``<info>`` otherwise, as do
+notes, figures, tables and nested lists. Topics that do not qualify keep
+their lists and are reported. Merging content into a task turns it back into
+a concept (:func:`task_to_concept`). In the Structure tab a branch can be
+typed as task or concept (:func:`concept_to_task` with ``force``, which
+skips the wording heuristics).
+"""
+
+import logging
+import re
+from typing import Any, Dict, Iterable, List, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import is_element, local_name
+from orlando_toolkit.core.style_usage import PARAGRAPH_HINT
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["ProcedureStage", "IMPERATIVE_VERBS", "concept_to_task", "is_imperative", "is_result", "retype_topicrefs",
+           "task_to_concept"]
+
+IMPERATIVE_VERBS = frozenset("""
+    add adjust align apply attach check choose clean click close configure confirm connect copy create
+    cut delete detach disable disconnect double-click download drag drain drop edit enable ensure enter
+    expand fill find follow go hold insert install lift locate log loosen lower make measure mount move
+    navigate note open paste place plug power press pull push put read reboot record release remove
+    repeat replace reset restart review right-click rotate run save scroll select set sign slide specify
+    start stop switch tap tighten turn type unplug unscrew update upload use verify wait
+""".split())
+
+_STEP_STYLE = r"(?i)\bstep"
+_PREREQ_STYLE = r"(?i)prereq|before\s*you\s*begin"
+# Paragraphs made of these labels (or opening with "<label>:") introduce prerequisites
+_PREREQ_LABELS = ("prerequisites", "prerequisite", "requirements", "before you begin", "before you start",
+                  "required tools", "what you need")
+# "Step 3:", "3.", "a)" typed in front of the instruction
+_STEP_PREFIX = re.compile(r"^\s*(?:step\s*\d+\s*[:.)-]?|\d+[.)]|[a-z][.)])\s*", re.I)
+_WORD = re.compile(r"[A-Za-z][A-Za-z-]*")
+_RESULT = re.compile(
+    r"^(?:the|this|a|an|it|you)\b.*\b(?:appears?|opens?|closes?|displays?|starts?|stops?|lights?\s+up"
+    r"|(?:is|are)\s+now|(?:is|are)\s+(?:displayed|shown|visible|saved|created|updated|removed|installed|complete))\b"
+    r"|^(?:result|outcome)\s*:", re.I | re.S)
+_BLOCKS = frozenset({"p", "ul", "ol", "sl", "dl", "note", "fig", "table", "simpletable", "codeblock", "pre",
+                     "lines", "lq", "image", "object"})
+_STRUCTURE = frozenset({"section", "example"})
+
+
+def _text(el) -> str:
+    return " ".join("".join(el.itertext()).split())
+
+
+def is_imperative(text: str, verbs: Iterable[str] = IMPERATIVE_VERBS) -> bool:
+    """True when *text* starts with one of *verbs* (after a typed step number)."""
+    match = _WORD.match(_STEP_PREFIX.sub("", text, count=1))
+    return bool(match) and match.group(0).lower() in verbs
+
+
+def is_result(text: str) -> bool:
+    """True when *text* reads like the outcome of a step."""
+    return bool(_RESULT.search(text.strip()))
+
+
+def _move_content(source, target) -> None:
+    """Move *source*'s text and children into *target* (appended)."""
+    if source.text:
+        if len(target):
+            last = target[-1]
+            last.tail = (last.tail or "") + source.text
+        else:
+            target.text = (target.text or "") + source.text
+    for child in list(source):
+        target.append(child)
+
+
+def _build_step(item) -> Any:
+    step = ET.Element("step")
+    for attr in ("id", "outputclass", "audience", "platform", "product", "props", "otherprops", PARAGRAPH_HINT):
+        if item.get(attr) is not None:
+            step.set(attr, item.get(attr))
+    cmd = ET.SubElement(step, "cmd")
+    blocks = [c for c in item if is_element(c) and local_name(c) in _BLOCKS]
+    inline_text = (item.text or "").strip() or any(c not in blocks for c in item if is_element(c))
+    if inline_text or not blocks or local_name(blocks[0]) != "p":
+        # The item's own text (up to the first block) is the command
+        cmd.text = item.text
+        for child in list(item):
+            if child in blocks:
+                break
+            cmd.append(child)
+    else:
+        _move_content(blocks[0], cmd)
+        item.remove(blocks[0])
+    rest = [c for c in list(item) if is_element(c)]
+    if cmd.text:
+        cmd.text = cmd.text.lstrip()
+        cmd.text = _STEP_PREFIX.sub("", cmd.text, count=1) if _STEP_PREFIX.match(cmd.text) else cmd.text
+    if len(cmd) and cmd[-1].tail:
+        cmd[-1].tail = cmd[-1].tail.rstrip() or None
+    elif cmd.text:
+        cmd.text = cmd.text.rstrip()
+
+    info = None
+    for block in rest:
+        if block.tail is not None and not block.tail.strip():
+            block.tail = None
+        if local_name(block) not in _BLOCKS:
+            # Inline content after a block reads as a paragraph of its own
+            para = ET.Element("p")
+            para.append(block)
+            block = para
+        if local_name(block) == "p" and is_result(_text(block)):
+            result = step.find("stepresult")
+            if result is None:
+                result = ET.SubElement(step, "stepresult")
+            result.append(block)
+            info = None
+            continue
+        if info is None or step[-1] is not info:
+            info = ET.SubElement(step, "info")
+        info.append(block)
+    # stepresult must come after info in the content model
+    result = step.find("stepresult")
+    if result is not None and step[-1] is not result:
+        step.remove(result)
+        step.append(result)
+    return step
+
+
+def task_to_concept(topic) -> None:
+    """Rewrite a task in place as a concept; steps become an ordered list again."""
+    if local_name(topic) != "task":
+        return
+    topic.tag = "concept"
+    body = topic.find("taskbody")
+    if body is None:
+        return
+    body.tag = "conbody"
+    for part in [c for c in body if is_element(c)]:
+        position = list(body).index(part)
+        if local_name(part) in ("steps", "steps-unordered"):
+            replacement = [ET.Element("ol" if local_name(part) == "steps" else "ul")]
+            for step in part.iter("step"):
+                item = ET.SubElement(replacement[0], "li")
+                for attr, value in step.attrib.items():
+                    item.set(attr, value)
+                cmd = step.find("cmd")
+                if cmd is not None:
+                    _move_content(cmd, item)
+                for extra in step:
+                    if is_element(extra) and local_name(extra) != "cmd":
+                        for block in list(extra):
+                            item.append(block)
+        elif local_name(part) in ("context", "prereq", "postreq", "result", "steps-informal"):
+            replacement = list(part)
+        else:
+            continue
+        body.remove(part)
+        for offset, child in enumerate(replacement):
+            body.insert(position + offset, child)
+
+
+class _Rules:
+    """Compiled ``procedures`` options; raises :class:`re.error` for invalid patterns."""
+
+    def __init__(self, options: Optional[Mapping[str, Any]] = None, *, force: bool = False) -> None:
+        options = options or {}
+        self.style_rx = re.compile(str(options.get("step_styles") or _STEP_STYLE))
+        self.prereq_rx = re.compile(str(options.get("prereq_styles") or _PREREQ_STYLE))
+        self.verbs = IMPERATIVE_VERBS | {str(v).lower() for v in options.get("extra_verbs") or ()}
+        self.ratio = 0.0 if force else float(options.get("imperative_ratio", 0.6))
+        self.min_steps = 1 if force else max(1, int(options.get("min_steps", 2)))
+        labels = sorted({*_PREREQ_LABELS, *(str(v).lower() for v in options.get("prereq_labels") or ())},
+                        key=lambda label: -len(label))
+        self.label_rx = re.compile(rf"^\s*(?:{'|'.join(re.escape(label) for label in labels)})\s*(?:[:：]\s*|$)",
+                                   re.IGNORECASE)
+
+
+def _styled(el, style_rx) -> bool:
+    style = el.get("data-style")
+    return bool(style and style_rx.search(style))
+
+
+def _procedures(body, rules: _Rules) -> List[List[Any]]:
+    """Procedure candidates among *body*'s children: ``[ol]`` or runs of step-styled ``p``."""
+    groups: List[List[Any]] = []
+    run: List[Any] = []
+    for child in list(body):
+        if not is_element(child):
+            continue
+        if local_name(child) == "p" and _styled(child, rules.style_rx):
+            run.append(child)
+            continue
+        if len(run) >= rules.min_steps:
+            groups.append(run)
+        run = []
+        if local_name(child) != "ol":
+            continue
+        items = [li for li in child if is_element(li) and local_name(li) == "li"]
+        if len(items) < rules.min_steps:
+            continue
+        styled = _styled(child, rules.style_rx) or any(_styled(li, rules.style_rx) for li in items)
+        imperative = sum(1 for li in items if is_imperative(_text(li), rules.verbs))
+        if styled or imperative / len(items) >= rules.ratio:
+            groups.append([child])
+    if len(run) >= rules.min_steps:
+        groups.append(run)
+    return groups
+
+
+def _split_prereq(before: List[Any], rules: _Rules) -> Tuple[List[Any], List[Any]]:
+    """Split the content before a procedure into ``(prereq, context)``.
+
+    Prerequisites are blocks in a ``prereq_styles`` style, paragraphs opening
+    with a label ("Prerequisites: …") and the block after a paragraph that is
+    only a label ("Before you begin"), which is dropped.
+    """
+    prereq: List[Any] = []
+    context: List[Any] = []
+    label = None
+    for child in before:
+        if not is_element(child):
+            context.append(child)
+            continue
+        if label is not None:
+            prereq.append(child)
+            label = None
+            continue
+        if _styled(child, rules.prereq_rx):
+            prereq.append(child)
+            continue
+        match = rules.label_rx.match(child.text or "") if local_name(child) == "p" else None
+        if match and not len(child) and not (child.text or "")[match.end():].strip():
+            label = child
+        elif match and match.group(0).rstrip()[-1:] in (":", "："):
+            child.text = child.text[match.end():] or None
+            prereq.append(child)
+        else:
+            context.append(child)
+    if label is not None:
+        context.append(label)
+    return prereq, context
+
+
+def _to_task(root, body, group: List[Any], rules: _Rules) -> int:
+    children = [c for c in body]
+    first, last = children.index(group[0]), children.index(group[-1])
+    before, after = children[:first], children[last + 1:]
+
+    if local_name(group[0]) == "ol":
+        items = [li for li in group[0] if is_element(li) and local_name(li) == "li"]
+    else:
+        items = group
+    steps = ET.Element("steps")
+    for item in items:
+        steps.append(_build_step(item))
+
+    root.tag = "task"
+    body.tag = "taskbody"
+    for child in children:
+        body.remove(child)
+    body.text = None
+    prereq, before = _split_prereq(before, rules)
+    for name, blocks in (("prereq", prereq), ("context", before)):
+        if blocks:
+            part = ET.SubElement(body, name)
+            for child in blocks:
+                part.append(child)
+    body.append(steps)
+    if after:
+        result = ET.SubElement(body, "result")
+        for child in after:
+            result.append(child)
+    return len(items)
+
+
+def concept_to_task(topic, options: Optional[Mapping[str, Any]] = None, *, force: bool = False) -> int:
+    """Rewrite a concept holding one procedure as a task in place; return its step count.
+
+    Returns 0, leaving *topic* unchanged, when it is not a concept or does not
+    hold exactly one procedure outside sections. With *force* (a branch typed
+    as task in the Structure tab) every ordered list and run of step-styled
+    paragraphs is a procedure, whatever its wording.
+    """
+    if local_name(topic) != "concept":
+        return 0
+    body = topic.find("conbody")
+    if body is None:
+        return 0
+    rules = _Rules(options, force=force)
+    groups = _procedures(body, rules)
+    if len(groups) != 1 or any(local_name(c) in _STRUCTURE for c in body if is_element(c)):
+        return 0
+    return _to_task(topic, body, groups[0], rules)
+
+
+def retype_topicrefs(map_root, filename: str, topic_type: str) -> None:
+    """Update the ``type`` of the map entries pointing to *filename*, where set."""
+    if map_root is None:
+        return
+    for ref in map_root.iter("topicref"):
+        if (ref.get("href") or "").split("#")[0].endswith(filename) and ref.get("type"):
+            ref.set("type", topic_type)
+
+
+class ProcedureStage(ProcessingStage):
+    name = "procedures"
+    hint_attributes = ("data-style",)
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        try:
+            rules = _Rules(options)
+        except re.error as exc:
+            report.warning(self.name, f"Invalid step_styles or prereq_styles pattern: {exc}")
+            return
+
+        converted = 0
+        for filename, root in context.topics.items():
+            if local_name(root) != "concept":
+                continue
+            body = root.find("conbody")
+            if body is None:
+                continue
+            groups = _procedures(body, rules)
+            if not groups:
+                continue
+            if len(groups) > 1 or any(local_name(c) in _STRUCTURE for c in body if is_element(c)):
+                report.info(self.name, f"{len(groups)} procedure(s) kept as lists: the topic has several "
+                            "procedures or sections", topic=filename, procedures=len(groups))
+                continue
+            steps = _to_task(root, body, groups[0], rules)
+            retype_topicrefs(context.ditamap_root, filename, "task")
+            converted += 1
+            report.info(self.name, f"Procedure converted to a task with {steps} step(s)", topic=filename, steps=steps)
+        if converted:
+            logger.debug("%d topic(s) converted to tasks", converted)
@@ -0,0 +1,220 @@
+from __future__ import annotations
+
+"""Raster image downscaling and format normalization.
+
+Screenshots and scans are often far larger than a help viewer shows them, and
+TIFF or BMP files do not open in browsers. When enabled the stage decodes each
+raster image of ``context.images`` (SVG and metafiles are left to
+:mod:`~orlando_toolkit.core.processing.vector_images`, which runs first):
+
+- images wider than ``max_width`` or taller than ``max_height`` pixels are
+  scaled down, keeping the aspect ratio;
+- images declaring more than ``max_dpi`` are resampled to it (same printed
+  size, fewer pixels);
+- images whose format is listed in ``convert`` (``all`` for every format) are
+  written as ``format`` (``png`` or ``jpeg``, saved at ``quality``); transparent
+  images converted to JPEG are flattened on white.
+
+Converted images are renamed (``.png``/``.jpg``) with every ``<image href>``
+pointing to them. One report entry gives the sizes before and after, per image
+in its detail. Images that cannot be decoded are kept and listed in a warning.
+Images are processed with the ``media`` workers of ``pipeline.yml`` (within
+its memory budget) and the results applied in ``context.images`` order.
+"""
+
+import io
+import logging
+import posixpath
+from dataclasses import dataclass
+from typing import Any, Dict, List, Mapping, Optional, Tuple
+
+from orlando_toolkit.core.concurrency import PipelineSettings, ordered_map
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.vector_images import _rename_images, _unique, is_metafile, is_svg
+from orlando_toolkit.core.spool import blob_size
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["RasterImageSettings", "RasterImageStage", "plan_image"]
+
+_FORMATS = ("png", "jpeg")
+_EXTENSIONS = {"png": ".png", "jpeg": ".jpg"}
+_ALIASES = {"jpg": "jpeg", "tif": "tiff", "mpo": "jpeg"}
+_DEFAULT_CONVERT = ("tiff", "bmp")
+
+
+def _format_name(value: Any) -> str:
+    name = str(value or "").strip().lower().lstrip(".")
+    return _ALIASES.get(name, name)
+
+
+def _positive(value: Any) -> Optional[int]:
+    try:
+        number = int(value)
+    except (TypeError, ValueError):
+        return None
+    return number if number > 0 else None
+
+
+def _size_text(size: int) -> str:
+    if size >= 1024 * 1024:
+        return f"{size / (1024 * 1024):.1f} MB"
+    return f"{size / 1024:.0f} KB"
+
+
+@dataclass(frozen=True)
+class RasterImageSettings:
+    """The ``raster_images`` section of ``conversion.yml``."""
+
+    max_width: Optional[int] = None
+    max_height: Optional[int] = None
+    max_dpi: Optional[int] = None
+    format: Optional[str] = None
+    convert: Tuple[str, ...] = _DEFAULT_CONVERT
+    quality: int = 85
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "RasterImageSettings":
+        data = data or {}
+        target = _format_name(data.get("format")) or None
+        if target is not None and target not in _FORMATS:
+            logger.warning("Unknown raster_images format %r (expected %s)", data.get("format"), ", ".join(_FORMATS))
+            target = None
+        convert = data.get("convert", _DEFAULT_CONVERT)
+        if isinstance(convert, str):
+            convert = (convert,)
+        quality = _positive(data.get("quality")) or 85
+        return cls(
+            max_width=_positive(data.get("max_width")),
+            max_height=_positive(data.get("max_height")),
+            max_dpi=_positive(data.get("max_dpi")),
+            format=target,
+            convert=tuple(_format_name(v) for v in convert or ()),
+            quality=min(quality, 95),
+        )
+
+    def converts(self, source_format: str) -> bool:
+        source = _format_name(source_format)
+        return bool(self.format) and source != self.format and ("all" in self.convert or source in self.convert)
+
+
+def plan_image(settings: RasterImageSettings, source_format: str, size: Tuple[int, int],
+               dpi: Optional[float] = None) -> Tuple[Tuple[int, int], Optional[str]]:
+    """New pixel size and target format (``None``: keep) for one image."""
+    width, height = size
+    scale = 1.0
+    if settings.max_width and width > settings.max_width:
+        scale = min(scale, settings.max_width / width)
+    if settings.max_height and height > settings.max_height:
+        scale = min(scale, settings.max_height / height)
+    if settings.max_dpi and dpi and dpi > settings.max_dpi:
+        scale = min(scale, settings.max_dpi / dpi)
+    new_size = (max(1, round(width * scale)), max(1, round(height * scale))) if scale < 1 else (width, height)
+    return new_size, settings.format if settings.converts(source_format) else None
+
+
+def _encode(image: Any, target: str, settings: RasterImageSettings, dpi: Optional[float]) -> bytes:
+    from PIL import Image  # type: ignore
+
+    options: Dict[str, Any] = {}
+    if dpi:
+        options["dpi"] = (dpi, dpi)
+    if target == "jpeg":
+        if image.mode in ("RGBA", "LA", "P"):
+            image = image.convert("RGBA")
+            flat = Image.new("RGB", image.size, (255, 255, 255))
+            flat.paste(image, mask=image.getchannel("A"))
+            image = flat
+        elif image.mode not in ("RGB", "L"):
+            image = image.convert("RGB")
+        options.update(quality=settings.quality, optimize=True)
+    elif target == "png":
+        if image.mode not in ("1", "L", "LA", "P", "RGB", "RGBA", "I"):
+            image = image.convert("RGBA")
+        options["optimize"] = True
+    buffer = io.BytesIO()
+    image.save(buffer, format=target.upper(), **options)
+    return buffer.getvalue()
+
+
+def _process(name: str, data: bytes, settings: RasterImageSettings) -> Optional[Tuple[bytes, Optional[str], Any]]:
+    """(new bytes, new format or ``None``, pixel size) when the image changes, else ``None``.
+
+    Raises when the image cannot be decoded.
+    """
+    from PIL import Image  # type: ignore
+
+    with Image.open(io.BytesIO(data)) as image:
+        if getattr(image, "is_animated", False):
+            return None
+        source = _format_name(image.format or posixpath.splitext(name)[1])
+        dpi = image.info.get("dpi")
+        dpi = float(max(dpi)) if isinstance(dpi, tuple) and dpi else None
+        size, target = plan_image(settings, source, image.size, dpi)
+        if size == image.size and target is None:
+            return None
+        image.load()
+        if size != image.size:
+            if image.mode == "P":
+                image = image.convert("RGBA")
+            image = image.resize(size, Image.LANCZOS)
+            if dpi and settings.max_dpi and dpi > settings.max_dpi:
+                dpi = float(settings.max_dpi)
+        return _encode(image, target or source, settings, dpi), target, size
+
+
+def _is_raster(name: str, data: bytes) -> bool:
+    return not (is_svg(name, data) or is_metafile(name, data))
+
+
+class RasterImageStage(ProcessingStage):
+    name = "raster_images"
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        settings = RasterImageSettings.from_mapping(options)
+        renames: Dict[str, str] = {}
+        changed: List[Dict[str, Any]] = []
+        failed: List[str] = []
+        # Names only: context.images may be spooled to disk, entries are read when processed
+        images = [name for name in context.images if _is_raster(name, context.images[name])]
+
+        def _work(name: str) -> Tuple[Any, Optional[Exception]]:
+            try:
+                data = context.images[name]
+                return (_process(name, data, settings), len(data)), None
+            except Exception as exc:
+                return None, exc
+
+        pipeline = PipelineSettings.resolve(context.metadata)
+        results = ordered_map(_work, images, workers=pipeline.workers_for("media"), budget=pipeline.budget(),
+                              size_of=lambda name: blob_size(context.images, name))
+        for name, (processed, error) in zip(images, results):
+            if error is not None:
+                logger.debug("Image %s could not be processed: %s", name, error)
+                failed.append(name)
+                continue
+            result, before = processed
+            if result is None:
+                continue
+            new_data, target, size = result
+            new_name = name
+            if target is not None:
+                taken = {*(k for k in context.images if k != name), *renames.values()}
+                new_name = _unique(posixpath.splitext(name)[0] + _EXTENSIONS[target], dict.fromkeys(taken))
+                renames[name] = new_name
+            context.images[name] = new_data
+            changed.append({"image": new_name, "source": name, "before": before, "after": len(new_data),
+                            "width": size[0], "height": size[1]})
+
+        if renames:
+            _rename_images(context, renames)
+        if changed:
+            before = sum(e["before"] for e in changed)
+            after = sum(e["after"] for e in changed)
+            report.info(self.name, f"{len(changed)} image(s) resized or converted: {_size_text(before)} -> "
+                                   f"{_size_text(after)}", images=changed, before=before, after=after)
+        if failed:
+            report.warning(self.name, f"{len(failed)} image(s) could not be read and were kept: "
+                                      f"{', '.join(failed)}", images=failed)
@@ -0,0 +1,319 @@
+from __future__ import annotations
+
+"""Specification tables as reference topics with ``<properties>``.
+
+Specification tables ("Technical data", "Dimensions") list one property per
+row: a name, its value and sometimes a description. Inlined in a concept they
+bury the data readers look up most. A table qualifies when it has 2 or 3
+columns, no merged cells or nested tables, single-paragraph name and value
+cells, and at least ``min_rows`` body rows or a title matching one of
+``titles``.
+
+A qualifying table becomes a ``<reference>`` topic referenced under the
+topic that held it: its title (or the parent's title) is the topic title,
+its header row the ``<prophead>``, each body row a ``<property>`` with
+``<proptype>``, ``<propvalue>`` and, for a third column, ``<propdesc>``. In
+the parent a ``<p outputclass="reference-table">`` with an ``<xref>`` to the
+new topic takes the table's place. In the Structure tab the tables of a
+topic can be moved out one by one (:func:`table_to_reference`) and moved
+back (:func:`reference_to_table`, rebuilt as a CALS table).
+"""
+
+import logging
+import re
+from typing import Any, Dict, List, Optional, Sequence, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.i18n import XML_LANG
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import is_element, local_name
+from orlando_toolkit.core.utils import slugify, topic_body
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["LINK_CLASS", "ReferenceTableStage", "convertible", "reference_tables", "reference_to_table",
+           "table_label", "table_to_reference", "topic_tables"]
+
+LINK_CLASS = "reference-table"
+_COLUMNS = (2, 3)
+_SPANS = ("namest", "nameend", "spanname", "morerows")
+_BLOCKS = frozenset({"p", "ul", "ol", "sl", "dl", "note", "fig", "table", "simpletable", "pre", "codeblock", "lq",
+                     "section", "hazardstatement", "object"})
+_PROPS = ("proptype", "propvalue", "propdesc")
+_HEADS = ("proptypehd", "propvaluehd", "propdeschd")
+
+
+def _text(el) -> str:
+    return " ".join("".join(el.itertext()).split())
+
+
+def _cells(row) -> List[Any]:
+    return [c for c in row if is_element(c) and local_name(c) in ("entry", "stentry")]
+
+
+def _rows(table) -> Tuple[Optional[List[Any]], List[List[Any]]]:
+    """(header cells or None, body rows) of a CALS table or simpletable."""
+    if local_name(table) == "simpletable":
+        head = table.find("sthead")
+        return (_cells(head) if head is not None else None), [_cells(r) for r in table.findall("strow")]
+    heads = [_cells(r) for r in table.findall("tgroup/thead/row")]
+    body = [_cells(r) for r in table.findall("tgroup/tbody/row")]
+    if len(heads) > 1:
+        body = heads[1:] + body
+    return (heads[0] if heads else None), body
+
+
+def topic_tables(root) -> List[Any]:
+    """Tables of a topic body in document order (not those nested in another table)."""
+    body = topic_body(root)
+    if body is None:
+        return []
+    return [t for t in body.iter("table", "simpletable")
+            if not any(local_name(a) in ("table", "simpletable") for a in t.iterancestors())]
+
+
+def _inline(cell) -> bool:
+    """Whether *cell* holds phrase content only (a single paragraph counts)."""
+    children = [c for c in cell if is_element(c)]
+    if len(children) == 1 and local_name(children[0]) == "p" and not (cell.text or "").strip():
+        children = [c for c in children[0] if is_element(c)]
+    return not any(local_name(c) in _BLOCKS for c in children)
+
+
+def convertible(table) -> bool:
+    """Whether *table* can be written as ``<properties>`` (2 or 3 columns, no spans, phrase names and values)."""
+    head, body = _rows(table)
+    if not body:
+        return False
+    width = len(body[0])
+    if width not in _COLUMNS or any(len(r) != width for r in body) or (head is not None and len(head) != width):
+        return False
+    cells = [c for r in body + ([head] if head else []) for c in r]
+    if any(c.get(attr) for c in cells for attr in _SPANS):
+        return False
+    if any(local_name(t) in ("table", "simpletable") for c in cells for t in c.iter() if t is not c):
+        return False
+    return all(_inline(c) for r in body for c in r[:2])
+
+
+def table_label(table, index: int) -> str:
+    """Its title, else "Table <n>" and the text of its first cell, for menus and reports."""
+    title = table.find("title")
+    if title is not None and _text(title):
+        return _text(title)
+    head, body = _rows(table)
+    first = (head or (body[0] if body else None) or [None])[0]
+    return f"Table {index + 1}" + (f": {_text(first)}" if first is not None and _text(first) else "")
+
+
+def _move_content(cell, target, *, unwrap: bool) -> None:
+    """Move the content of *cell* into *target*; with *unwrap*, a lone paragraph gives its content."""
+    children = [c for c in cell if is_element(c)]
+    source = cell
+    if unwrap and len(children) == 1 and local_name(children[0]) == "p" and not (cell.text or "").strip():
+        source = children[0]
+    target.text = (source.text or "").lstrip() or None
+    for child in list(source):
+        target.append(child)
+    if len(target):
+        target[-1].tail = (target[-1].tail or "").rstrip() or None
+    elif target.text:
+        target.text = target.text.rstrip()
+
+
+def _properties(table) -> Any:
+    head, body = _rows(table)
+    props = ET.Element("properties")
+    if table.get("id"):
+        props.set("id", table.get("id"))
+    if head is not None and any(_text(c) for c in head):
+        prophead = ET.SubElement(props, "prophead")
+        for cell, tag in zip(head, _HEADS):
+            _move_content(cell, ET.SubElement(prophead, tag), unwrap=True)
+    for row in body:
+        prop = ET.SubElement(props, "property")
+        for n, (cell, tag) in enumerate(zip(row, _PROPS)):
+            _move_content(cell, ET.SubElement(prop, tag), unwrap=n < 2)
+    return props
+
+
+def _topicref(context: DitaContext, filename: str) -> Optional[Any]:
+    root = context.ditamap_root
+    if root is None:
+        return None
+    return next((r for r in root.iter("topicref")
+                 if (r.get("href") or "").split("#")[0].split("/")[-1] == filename), None)
+
+
+def _links(body) -> List[Any]:
+    return [p for p in body.iter("p") if p.get("outputclass") == LINK_CLASS and p.find("xref") is not None]
+
+
+def reference_tables(context: DitaContext, filename: str) -> List[Tuple[str, str]]:
+    """``(reference topic, title)`` of the tables moved out of *filename*, in the order of their links."""
+    body = topic_body(context.topics.get(filename))
+    found: List[Tuple[str, str]] = []
+    for link in _links(body) if body is not None else ():
+        name = (link.find("xref").get("href") or "").split("#")[0].split("/")[-1]
+        topic = context.topics.get(name)
+        if topic is not None and topic.get("outputclass") == LINK_CLASS:
+            title = topic.find("title")
+            found.append((name, _text(title) if title is not None else name))
+    return found
+
+
+def table_to_reference(context: DitaContext, filename: str, table) -> Optional[str]:
+    """Move *table* of topic *filename* into a new reference topic; returns its filename.
+
+    Returns None, leaving the topic unchanged, when the table cannot be
+    written as properties or the topic is not in the map.
+    """
+    root = context.topics.get(filename)
+    tref = _topicref(context, filename)
+    body = topic_body(root)
+    if root is None or tref is None or body is None or not convertible(table):
+        return None
+    title_el = table.find("title")
+    parent_title = root.find("title")
+    title = _text(title_el) if title_el is not None and _text(title_el) else (
+        _text(parent_title) if parent_title is not None else filename.rsplit(".", 1)[0])
+    stem = filename.rsplit(".", 1)[0]
+    slug = slugify(title) or "table"
+    name, n = f"{stem}_{slug}.dita", 2
+    while name in context.topics:
+        name, n = f"{stem}_{slug}_{n}.dita", n + 1
+
+    reference = ET.Element("reference", id=name[:-5], outputclass=LINK_CLASS)
+    if root.get(XML_LANG):
+        reference.set(XML_LANG, root.get(XML_LANG))
+    new_title = ET.SubElement(reference, "title")
+    if title_el is not None and _text(title_el):
+        _move_content(title_el, new_title, unwrap=False)
+    else:
+        new_title.text = title
+    desc = table.find("desc")
+    if desc is not None and _text(desc):
+        _move_content(desc, ET.SubElement(reference, "shortdesc"), unwrap=True)
+    ET.SubElement(reference, "refbody").append(_properties(table))
+
+    # The link takes the table's place; the entry goes under the parent in link order
+    before = 0
+    for el in body.iter():
+        if el is table:
+            break
+        before += el.tag == "p" and el.get("outputclass") == LINK_CLASS
+    link = ET.Element("p", outputclass=LINK_CLASS)
+    ET.SubElement(link, "xref", href=name, type="reference")
+    link.tail = table.tail
+    table.getparent().replace(table, link)
+    context.topics[name] = reference
+    href = tref.get("href") or ""
+    folder = href.rsplit("/", 1)[0] + "/" if "/" in href else ""
+    ref = ET.Element("topicref", href=folder + name, type="reference")
+    ET.SubElement(ET.SubElement(ref, "topicmeta"), "navtitle").text = title
+    position = 1 if len(tref) and local_name(tref[0]) == "topicmeta" else 0
+    tref.insert(position + before, ref)
+    return name
+
+
+def _entry(cell_tag: str, source) -> Any:
+    entry = ET.Element(cell_tag)
+    if source is None:
+        return entry
+    entry.text = source.text
+    for child in list(source):
+        entry.append(child)
+    return entry
+
+
+def reference_to_table(context: DitaContext, filename: str) -> Optional[str]:
+    """Put a reference topic made by :func:`table_to_reference` back into its parent as a CALS table.
+
+    Returns the parent topic's filename, or None when *filename* is not
+    such a topic or its link cannot be found.
+    """
+    topic = context.topics.get(filename)
+    tref = _topicref(context, filename)
+    parent_ref = tref.getparent() if tref is not None else None
+    if topic is None or topic.get("outputclass") != LINK_CLASS or parent_ref is None:
+        return None
+    parent_name = (parent_ref.get("href") or "").split("#")[0].split("/")[-1]
+    body = topic_body(context.topics.get(parent_name))
+    props = topic.find("refbody/properties")
+    link = next((p for p in _links(body) if (p.find("xref").get("href") or "").split("/")[-1] == filename),
+                None) if body is not None else None
+    if props is None or link is None:
+        return None
+
+    rows = [[prop.find(tag) for tag in _PROPS] for prop in props.findall("property")]
+    head = props.find("prophead")
+    cols = 3 if any(r[2] is not None for r in rows) or (head is not None and head.find("propdeschd") is not None) \
+        else 2
+    table = ET.Element("table")
+    if props.get("id"):
+        table.set("id", props.get("id"))
+    title = topic.find("title")
+    parent_title = context.topics[parent_name].find("title")
+    if title is not None and (parent_title is None or _text(title) != _text(parent_title)):
+        table.append(_entry("title", title))
+    shortdesc = topic.find("shortdesc")
+    if shortdesc is not None:
+        table.append(_entry("desc", shortdesc))
+    tgroup = ET.SubElement(table, "tgroup", cols=str(cols))
+    for n in range(cols):
+        ET.SubElement(tgroup, "colspec", colname=f"c{n + 1}", colnum=str(n + 1))
+    if head is not None:
+        row = ET.SubElement(ET.SubElement(tgroup, "thead"), "row")
+        for tag in _HEADS[:cols]:
+            row.append(_entry("entry", head.find(tag)))
+    tbody = ET.SubElement(tgroup, "tbody")
+    for cells in rows:
+        row = ET.SubElement(tbody, "row")
+        for cell in cells[:cols]:
+            row.append(_entry("entry", cell))
+
+    table.tail = link.tail
+    link.getparent().replace(link, table)
+    parent_ref.remove(tref)
+    del context.topics[filename]
+    return parent_name
+
+
+class _Rules:
+    def __init__(self, options: Dict[str, Any]) -> None:
+        self.min_rows = max(1, int(options.get("min_rows", 8)))
+        self.titles: Sequence[Any] = [re.compile(str(p), re.IGNORECASE) for p in options.get("titles") or ()]
+
+    def qualifies(self, table) -> bool:
+        if not convertible(table):
+            return False
+        title = table.find("title")
+        if title is not None and any(p.search(_text(title)) for p in self.titles):
+            return True
+        return len(_rows(table)[1]) >= self.min_rows
+
+
+class ReferenceTableStage(ProcessingStage):
+    name = "reference_tables"
+
+    def is_enabled(self, options: Dict[str, Any]) -> bool:
+        return bool(options.get("enabled", False))
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        try:
+            rules = _Rules(options)
+        except re.error as exc:
+            report.warning(self.name, f"Invalid titles pattern: {exc}")
+            return
+        for filename, root in list(context.topics.items()):
+            if local_name(root) not in ("concept", "topic", "task") or root.get("outputclass") == LINK_CLASS:
+                continue
+            created = [name for name in (table_to_reference(context, filename, t)
+                                         for t in topic_tables(root) if rules.qualifies(t)) if name]
+            if created:
+                report.info(self.name, f"{len(created)} specification table(s) moved to reference topics",
+                            topic=filename, created=created)
+                logger.debug("%s: %d table(s) moved to reference topics", filename, len(created))
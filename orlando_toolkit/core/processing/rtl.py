@@ -0,0 +1,250 @@
+from __future__ import annotations
+
+"""Right-to-left (Arabic, Hebrew, …) direction handling.
+
+What the stage does:
+
+- Applies plugin direction hints (``data-dir="rtl|ltr"`` on blocks, runs or
+  tables) as DITA ``@dir``.
+- Optionally infers ``@dir`` from the first strong character of each block
+  (UAX #9 rule P2) where it differs from the inherited direction, and sets
+  ``@dir="rtl"`` on topic roots whose blocks are predominantly RTL.
+- Keeps bidi control characters intact and closes embeddings/isolates left
+  open at the end of a block, which would otherwise leak into the following
+  content once downstream tools concatenate text.
+- Restores logical cell order for tables a plugin emitted in visual order
+  (``data-cell-order="visual"`` on ``table``). Cells are never reordered
+  otherwise; mixed LTR/RTL tables keep source order and get per-cell ``@dir``.
+"""
+
+import logging
+import re
+from typing import Any, Dict, List, Optional, Tuple
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import (
+    BLOCK_TAGS,
+    first_strong_direction,
+    get_slot,
+    is_element,
+    iter_blocks,
+    iter_text_slots,
+    local_name,
+    set_slot,
+)
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["BidiStage"]
+
+_PDF = "\u202c"
+_PDI = "\u2069"
+# Opening control -> matching terminator
+_OPENERS = {
+    "\u202a": _PDF, "\u202b": _PDF, "\u202d": _PDF, "\u202e": _PDF,  # LRE, RLE, LRO, RLO
+    "\u2066": _PDI, "\u2067": _PDI, "\u2068": _PDI,                  # LRI, RLI, FSI
+}
+_DIRS = ("ltr", "rtl")
+_COLUMN_RE = re.compile(r"column-(\d+)$")
+
+
+def _inherited_dir(el) -> str:
+    parent = el.getparent()
+    while parent is not None:
+        value = parent.get("dir")
+        if value in _DIRS:
+            return value
+        parent = parent.getparent()
+    return "ltr"
+
+
+def _is_leaf_block(el) -> bool:
+    return not any(
+        is_element(d) and local_name(d) in BLOCK_TAGS for d in el.iterdescendants()
+    )
+
+
+class BidiStage(ProcessingStage):
+    name = "bidi"
+    hint_attributes = ("data-dir", "data-cell-order")
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        detect = bool(options.get("detect_direction", True))
+        balance = bool(options.get("balance_controls", True))
+        for filename, root in context.topics.items():
+            hinted = self._apply_hints(root)
+            mirrored = self._restore_logical_tables(root, filename, report)
+            detected = self._detect_direction(root) if detect else 0
+            closed = self._balance_controls(root) if balance else 0
+            if closed:
+                report.warning(self.name, f"Closed {closed} unterminated bidi embedding(s)",
+                               topic=filename, closed=closed)
+            if hinted or mirrored or detected:
+                report.info(self.name, "Direction attributes applied", topic=filename,
+                            hinted=hinted, detected=detected, tables=mirrored)
+
+    # ------------------------------------------------------------------
+    # Hints
+    # ------------------------------------------------------------------
+    def _apply_hints(self, root) -> int:
+        count = 0
+        for el in root.iter():
+            if not is_element(el):
+                continue
+            hint = (el.attrib.pop("data-dir", None) or "").strip().lower()
+            if hint in _DIRS and el.get("dir") is None:
+                el.set("dir", hint)
+                count += 1
+        return count
+
+    # ------------------------------------------------------------------
+    # Direction inference
+    # ------------------------------------------------------------------
+    def _detect_direction(self, root) -> int:
+        blocks = list(iter_blocks(root))
+        if root.get("dir") is None:
+            counts = {"ltr": 0, "rtl": 0}
+            for block in blocks:
+                if _is_leaf_block(block):
+                    d = first_strong_direction("".join(block.itertext()))
+                    if d:
+                        counts[d] += 1
+            if counts["rtl"] > counts["ltr"]:
+                root.set("dir", "rtl")
+        count = 0
+        for block in blocks:
+            if block is root or block.get("dir") is not None:
+                continue
+            d = first_strong_direction("".join(block.itertext()))
+            if d and d != _inherited_dir(block):
+                block.set("dir", d)
+                count += 1
+        return count
+
+    # ------------------------------------------------------------------
+    # Bidi control balancing
+    # ------------------------------------------------------------------
+    def _balance_controls(self, root) -> int:
+        closed = 0
+        for block in iter_blocks(root):
+            if not _is_leaf_block(block):
+                continue
+            stack: List[str] = []
+            last_slot: Optional[Tuple[Any, str]] = None
+            for el, slot in iter_text_slots(block):
+                last_slot = (el, slot)
+                for ch in get_slot(el, slot) or "":
+                    if ch in _OPENERS:
+                        stack.append(_OPENERS[ch])
+                    elif ch in (_PDF, _PDI):
+                        # PDI also closes embeddings opened after the isolate
+                        while stack and ch == _PDI and stack[-1] == _PDF:
+                            stack.pop()
+                        if stack and stack[-1] == ch:
+                            stack.pop()
+            if stack and last_slot is not None:
+                el, slot = last_slot
+                set_slot(el, slot, (get_slot(el, slot) or "") + "".join(reversed(stack)))
+                closed += len(stack)
+        return closed
+
+    # ------------------------------------------------------------------
+    # Visual -> logical table order
+    # ------------------------------------------------------------------
+    def _restore_logical_tables(self, root, filename: str, report: ConversionReport) -> int:
+        count = 0
+        for table in root.iter("table"):
+            order = (table.attrib.pop("data-cell-order", None) or "").strip().lower()
+            if order != "visual":
+                continue
+            ok = all(self._mirror_tgroup(tgroup) for tgroup in table.findall("tgroup"))
+            if not ok:
+                report.warning(self.name, "Could not restore logical cell order for a table",
+                               topic=filename)
+                continue
+            if table.get("dir") is None:
+                table.set("dir", "rtl")
+            count += 1
+        return count
+
+    def _mirror_tgroup(self, tgroup) -> bool:
+        colspecs = tgroup.findall("colspec")
+        try:
+            cols = int(tgroup.get("cols") or len(colspecs))
+        except ValueError:
+            cols = len(colspecs)
+        if cols <= 0:
+            return False
+        names = [cs.get("colname") or f"column-{i}" for i, cs in enumerate(colspecs, 1)]
+        index_of = {n: i for i, n in enumerate(names, 1)}
+
+        def idx(name: Optional[str]) -> Optional[int]:
+            if name is None:
+                return None
+            if name in index_of:
+                return index_of[name]
+            m = _COLUMN_RE.match(name)
+            return int(m.group(1)) if m else None
+
+        def name_of(i: int) -> str:
+            return names[i - 1] if i <= len(names) else f"column-{i}"
+
+        plan = []
+        for section in [s for s in tgroup if is_element(s) and local_name(s) in ("thead", "tbody")]:
+            pending: Dict[int, int] = {}
+            for row in section.findall("row"):
+                occupied = set(pending)
+                cursor = 1
+                placed = []
+                for entry in row.findall("entry"):
+                    if entry.get("namest"):
+                        start, end = idx(entry.get("namest")), idx(entry.get("nameend") or entry.get("namest"))
+                    elif entry.get("colname"):
+                        start = end = idx(entry.get("colname"))
+                    else:
+                        while cursor in occupied:
+                            cursor += 1
+                        start = end = cursor
+                    if start is None or end is None or end < start or end > cols:
+                        return False
+                    cursor = end + 1
+                    placed.append((entry, start, end))
+                pending = {c: n - 1 for c, n in pending.items() if n > 1}
+                for entry, start, end in placed:
+                    try:
+                        more = int(entry.get("morerows") or 0)
+                    except ValueError:
+                        more = 0
+                    if more > 0:
+                        for c in range(start, end + 1):
+                            pending[c] = more
+                plan.append((row, placed))
+
+        for row, placed in plan:
+            mirrored = []
+            for entry, start, end in placed:
+                new_start, new_end = cols + 1 - end, cols + 1 - start
+                if new_start == new_end:
+                    entry.attrib.pop("namest", None)
+                    entry.attrib.pop("nameend", None)
+                    entry.set("colname", name_of(new_start))
+                else:
+                    entry.attrib.pop("colname", None)
+                    entry.set("namest", name_of(new_start))
+                    entry.set("nameend", name_of(new_end))
+                mirrored.append((new_start, entry))
+                row.remove(entry)
+            for _, entry in sorted(mirrored, key=lambda item: item[0]):
+                row.append(entry)
+
+        # Column properties (width, alignment) follow their columns
+        if colspecs:
+            props = [{k: v for k, v in cs.attrib.items() if k not in ("colname", "colnum")} for cs in colspecs]
+            for cs, src in zip(colspecs, reversed(props)):
+                for k in [k for k in cs.attrib if k not in ("colname", "colnum")]:
+                    del cs.attrib[k]
+                for k, v in src.items():
+                    cs.set(k, v)
+        return True
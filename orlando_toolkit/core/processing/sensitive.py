@@ -0,0 +1,193 @@
+from __future__ import annotations
+
+"""Scan for personal data and credentials.
+
+Like the spell-check stage this one never changes topic text. It looks for
+values that should rarely be published and reports each finding with its
+topic and location (element path and nearest ``id``) so authors can review
+it. Reported excerpts are masked; the report itself never carries the
+full value.
+
+Detectors (``detectors``):
+
+- ``email``: e-mail addresses;
+- ``credit_card``: 13–19 digit card numbers passing the Luhn check;
+- ``iban``: IBANs passing the ISO 7064 mod-97 check;
+- ``ssn``: US social security numbers (``123-45-6789``);
+- ``api_key``: well-known token formats (AWS, GitHub, GitLab, Slack,
+  Google, Stripe, JWT);
+- ``private_key``: PEM private key headers;
+- ``credential``: ``password=…``, ``api_key: …`` style assignments.
+
+``patterns`` adds named regular expressions (national ID formats, internal
+ticket numbers, …). Values matching an ``allow`` pattern (documentation
+addresses such as ``*@example.com``) are not reported. Text and ``href``
+attributes are scanned, code and preformatted content included: that is
+where credentials usually end up.
+"""
+
+import logging
+import re
+from typing import Any, Callable, Dict, Iterator, List, Mapping, Optional, Pattern, Tuple
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import get_slot, is_element, iter_text_slots, local_name
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["SensitiveContentStage", "DETECTORS", "scan_text", "mask"]
+
+
+def _luhn(value: str) -> bool:
+    digits = [int(c) for c in value if c.isdigit()]
+    if not 13 <= len(digits) <= 19:
+        return False
+    total = 0
+    for i, digit in enumerate(reversed(digits)):
+        if i % 2:
+            digit *= 2
+            if digit > 9:
+                digit -= 9
+        total += digit
+    return total % 10 == 0
+
+
+def _iban(value: str) -> bool:
+    compact = re.sub(r"\s", "", value).upper()
+    rearranged = compact[4:] + compact[:4]
+    try:
+        return int("".join(str(int(c, 36)) for c in rearranged)) % 97 == 1
+    except ValueError:
+        return False
+
+
+def _ssn(value: str) -> bool:
+    area, group, serial = value.split("-")
+    return area not in ("000", "666") and not area.startswith("9") and group != "00" and serial != "0000"
+
+
+# name -> (label, pattern, validator)
+DETECTORS: Dict[str, Tuple[str, Pattern[str], Optional[Callable[[str], bool]]]] = {
+    "email": ("e-mail address",
+              re.compile(r"(?<![\w.+-])[\w.+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}\b"), None),
+    "credit_card": ("payment card number", re.compile(r"(?<!\d)(?:\d[ -]?){12,18}\d(?!\d)"), _luhn),
+    "iban": ("IBAN", re.compile(r"\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,4})?\b"), _iban),
+    "ssn": ("social security number", re.compile(r"(?<![\d-])\d{3}-\d{2}-\d{4}(?![\d-])"), _ssn),
+    "api_key": ("API key or token", re.compile(
+        r"\b(?:AKIA|ASIA)[0-9A-Z]{16}\b"
+        r"|\bgh[pousr]_[A-Za-z0-9]{36,}\b"
+        r"|\bglpat-[A-Za-z0-9_-]{20,}\b"
+        r"|\bxox[abposr]-[A-Za-z0-9-]{10,}\b"
+        r"|\bAIza[0-9A-Za-z_-]{35}\b"
+        r"|\b[sr]k_(?:live|test)_[0-9A-Za-z]{16,}\b"
+        r"|\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}"
+    ), None),
+    "private_key": ("private key", re.compile(r"-----BEGIN (?:[A-Z]+ )?PRIVATE KEY-----"), None),
+    "credential": ("credential", re.compile(
+        r"(?i)\b(?:password|passwd|pwd|secret|api[_-]?key|access[_-]?token|auth[_-]?token)\b\s*[:=]\s*"
+        r"[\"']?[^\s\"'<>]{6,}"
+    ), None),
+}
+_DEFAULT_DETECTORS = tuple(DETECTORS)
+_PLACEHOLDER_RE = re.compile(r"(?i)[:=]\s*[\"']?(?:\*+|x{4,}|<[^>]*>|\$\{[^}]*\}|\{\{[^}]*\}\}|changeme|your[_-])")
+
+
+def mask(value: str) -> str:
+    """Keep the first and last two characters of *value*."""
+    if len(value) <= 6:
+        return "*" * len(value)
+    return f"{value[:2]}{'*' * min(len(value) - 4, 12)}{value[-2:]}"
+
+
+def scan_text(text: str, detectors: Mapping[str, Tuple[str, Pattern[str], Optional[Callable[[str], bool]]]],
+              allow: List[Pattern[str]]) -> Iterator[Tuple[str, str, str]]:
+    """Yield ``(detector, label, value)`` for every finding in *text*."""
+    for name, (label, pattern, validate) in detectors.items():
+        for match in pattern.finditer(text):
+            value = match.group(0)
+            if validate is not None and not validate(value):
+                continue
+            if name == "credential" and _PLACEHOLDER_RE.search(value):
+                continue
+            if any(a.search(value) for a in allow):
+                continue
+            yield name, label, value
+
+
+def _location(el) -> Tuple[str, Optional[str]]:
+    """Return an element path (``conbody/section[2]/p``) and the nearest ``id``."""
+    steps: List[str] = []
+    nearest_id: Optional[str] = None
+    node = el
+    while node is not None and is_element(node):
+        if nearest_id is None and node.get("id"):
+            nearest_id = node.get("id")
+        parent = node.getparent()
+        name = local_name(node)
+        if parent is None:
+            break  # the topic root is implied by the topic filename
+        same = [c for c in parent if is_element(c) and local_name(c) == name]
+        steps.append(f"{name}[{same.index(node) + 1}]" if len(same) > 1 else name)
+        node = parent
+    return "/".join(reversed(steps)) or local_name(el), nearest_id
+
+
+def _compile(options: Dict[str, Any]) -> Tuple[Dict[str, Any], List[Pattern[str]]]:
+    enabled = options.get("detectors")
+    detectors = {n: DETECTORS[n] for n in (enabled if enabled is not None else _DEFAULT_DETECTORS) if n in DETECTORS}
+    for name in set(enabled or ()) - set(DETECTORS):
+        logger.warning("Unknown sensitive-content detector %r", name)
+    for name, expression in (options.get("patterns") or {}).items():
+        try:
+            detectors[str(name)] = (str(name).replace("_", " "), re.compile(str(expression)), None)
+        except re.error as exc:
+            logger.warning("Invalid sensitive-content pattern %s: %s", name, exc)
+    allow: List[Pattern[str]] = []
+    for expression in options.get("allow") or ():
+        try:
+            allow.append(re.compile(str(expression)))
+        except re.error as exc:
+            logger.warning("Invalid sensitive-content allow pattern %r: %s", expression, exc)
+    return detectors, allow
+
+
+class SensitiveContentStage(ProcessingStage):
+    name = "sensitive"
+
+    def is_enabled(self, options: Dict[str, Any]) -> bool:
+        return bool(options.get("enabled", False))
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        detectors, allow = _compile(options)
+        if not detectors:
+            return
+        limit = int(options.get("max_findings", 100))
+        severity = "error" if str(options.get("severity", "warning")).lower() == "error" else "warning"
+        total = 0
+        for filename, root in context.topics.items():
+            for el, where, text in self._texts(root):
+                for detector, label, value in scan_text(text, detectors, allow):
+                    total += 1
+                    if total > limit:
+                        continue
+                    path, nearest_id = _location(el)
+                    report.add(severity, self.name, f"Possible {label} in {path}: {mask(value)}",
+                               topic=filename, detector=detector, element=path, id=nearest_id,
+                               where=where, excerpt=mask(value))
+        if total > limit:
+            report.warning(self.name, f"{total - limit} further finding(s) not listed", total=total)
+
+    @staticmethod
+    def _texts(root) -> Iterator[Tuple[Any, str, str]]:
+        for el, slot in iter_text_slots(root):
+            text = get_slot(el, slot)
+            if not text:
+                continue
+            owner = el if slot == "text" else el.getparent()
+            if owner is not None:
+                yield owner, "text", text
+        for el in root.iter():
+            if is_element(el) and el.get("href"):
+                yield el, "href", el.get("href")
@@ -0,0 +1,249 @@
+from __future__ import annotations
+
+"""Spell-check hook.
+
+The stage never changes topic text; it collects the words of every topic
+(code and preformatted content excluded) and asks a checker which ones look
+misspelled. Suspects are reported per topic with their counts so authors can
+review them before publishing.
+
+Checkers (``backend``):
+
+- ``hunspell`` (default): ``<lang>.dic``/``.aff`` pairs from ``dictionaries``
+  (directories). The ``hunspell`` Python binding is used when installed;
+  without it the ``.dic`` word list is matched as-is (no affix expansion), so
+  inflected forms may be flagged.
+- ``languagetool``: a LanguageTool-compatible HTTP service at ``url``; only
+  matches whose issue type is ``misspelling`` are kept.
+- ``plugin``: the first ``SpellChecker`` service registered by a plugin
+  (see :class:`orlando_toolkit.core.plugins.interfaces.SpellChecker`).
+
+Words listed in the term base (``term_base`` entries and ``term_base_file``,
+one term per line) are never reported, nor those of the project's ignore
+list (``metadata["spelling_ignore"]``, :mod:`orlando_toolkit.core.spellcheck`);
+matching is case-insensitive and multi-word terms whitelist each of their
+words.
+"""
+
+import json
+import logging
+import re
+import urllib.parse
+import urllib.request
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Optional, Sequence, Set
+
+from orlando_toolkit.core.i18n import XML_LANG, document_language, normalize_language
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import (
+    get_slot,
+    is_element,
+    is_preformatted,
+    iter_text_slots,
+    local_name,
+)
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "SpellCheckStage",
+    "HunspellChecker",
+    "LanguageToolChecker",
+    "load_term_base",
+    "extract_words",
+]
+
+_WORD_RE = re.compile(r"[^\W\d_]+(?:['’][^\W\d_]+)*")
+_SKIP_TAGS = frozenset({
+    "codeph", "filepath", "cmdname", "varname", "apiname", "userinput", "systemoutput",
+    "uicontrol", "xref", "keyword", "option", "parmname",
+})
+
+
+def load_term_base(terms: Optional[Iterable[Any]] = None, path: Optional[str] = None) -> Set[str]:
+    """Return the lowercase words allowed by the term base."""
+    entries: List[str] = [str(t) for t in (terms or ()) if t]
+    if path:
+        try:
+            for line in Path(path).expanduser().read_text(encoding="utf-8").splitlines():
+                line = line.strip()
+                if line and not line.startswith("#"):
+                    entries.append(line)
+        except OSError as exc:
+            logger.warning("Could not read term base %s: %s", path, exc)
+    words: Set[str] = set()
+    for entry in entries:
+        words.add(entry.strip().lower())
+        words.update(w.lower() for w in _WORD_RE.findall(entry))
+    return words
+
+
+def _skipped(el) -> bool:
+    node = el
+    while node is not None:
+        if is_element(node) and local_name(node) in _SKIP_TAGS:
+            return True
+        node = node.getparent()
+    return is_preformatted(el)
+
+
+def extract_words(root, *, min_length: int = 3, ignore_uppercase: bool = True) -> Dict[str, int]:
+    """Count checkable words of *root*, skipping code and preformatted content."""
+    counts: Dict[str, int] = {}
+    for el, slot in iter_text_slots(root):
+        text = get_slot(el, slot)
+        if not text:
+            continue
+        owner = el if slot == "text" else el.getparent()
+        if owner is None or _skipped(owner):
+            continue
+        for word in _WORD_RE.findall(text):
+            word = word.replace("’", "'")
+            if len(word) < min_length or (ignore_uppercase and word.isupper()):
+                continue
+            counts[word] = counts.get(word, 0) + 1
+    return counts
+
+
+class HunspellChecker:
+    """Hunspell dictionaries looked up by language tag (``en-US`` → ``en_US``)."""
+
+    def __init__(self, directories: Sequence[str]) -> None:
+        self._directories = [Path(d).expanduser() for d in directories or ()]
+        self._loaded: Dict[str, Any] = {}
+
+    def _find(self, language: str) -> Optional[Path]:
+        lang = normalize_language(language) or ""
+        parts = lang.split("-")
+        names = []
+        if len(parts) > 1:
+            names.append(f"{parts[0]}_{parts[1].upper()}")
+        names.append(parts[0])
+        for directory in self._directories:
+            for name in names:
+                candidate = directory / f"{name}.dic"
+                if candidate.is_file():
+                    return candidate
+            for candidate in sorted(directory.glob(f"{parts[0]}_*.dic")):
+                return candidate
+        return None
+
+    def _dictionary(self, language: str) -> Any:
+        if language in self._loaded:
+            return self._loaded[language]
+        dic = self._find(language)
+        checker: Any = None
+        if dic is not None:
+            try:
+                import hunspell  # type: ignore
+
+                checker = hunspell.HunSpell(str(dic), str(dic.with_suffix(".aff")))
+            except Exception:
+                checker = self._word_list(dic)
+        self._loaded[language] = checker
+        return checker
+
+    @staticmethod
+    def _word_list(dic: Path) -> Set[str]:
+        words: Set[str] = set()
+        lines = dic.read_text(encoding="utf-8", errors="replace").splitlines()
+        for line in lines[1:]:  # first line is the entry count
+            word = line.split("/", 1)[0].strip()
+            if word:
+                words.add(word.lower())
+        return words
+
+    def check(self, words: Sequence[str], language: str) -> List[str]:
+        dictionary = self._dictionary(language)
+        if dictionary is None:
+            raise LookupError(f"No Hunspell dictionary for {language}")
+        if isinstance(dictionary, set):
+            return [w for w in words if w.lower() not in dictionary]
+        return [w for w in words if not dictionary.spell(w)]
+
+
+class LanguageToolChecker:
+    """LanguageTool ``/v2/check`` client; one request per topic."""
+
+    def __init__(self, url: str, timeout: float = 10.0) -> None:
+        self._url = url.rstrip("/")
+        if not self._url.endswith("/v2/check"):
+            self._url += "/v2/check"
+        self._timeout = timeout
+
+    def check(self, words: Sequence[str], language: str) -> List[str]:
+        text = "\n".join(words)
+        data = urllib.parse.urlencode({"text": text, "language": language or "auto"}).encode("utf-8")
+        request = urllib.request.Request(self._url, data=data, headers={"Accept": "application/json"})
+        with urllib.request.urlopen(request, timeout=self._timeout) as response:
+            payload = json.loads(response.read().decode("utf-8"))
+        suspects = []
+        for match in payload.get("matches", []):
+            if (match.get("rule") or {}).get("issueType") != "misspelling":
+                continue
+            start, length = int(match.get("offset", 0)), int(match.get("length", 0))
+            suspects.append(text[start:start + length])
+        return suspects
+
+
+def _plugin_checker() -> Any:
+    from orlando_toolkit.core.context import get_app_context
+
+    app = get_app_context()
+    if app is None:
+        return None
+    return app.service_registry.get_service("SpellChecker")
+
+
+class SpellCheckStage(ProcessingStage):
+    name = "spelling"
+
+    def is_enabled(self, options: Dict[str, Any]) -> bool:
+        return bool(options.get("enabled", False))
+
+    def create_checker(self, options: Dict[str, Any]) -> Any:
+        """Return the checker selected by ``backend``, or ``None``."""
+        backend = str(options.get("backend", "hunspell") or "hunspell").strip().lower()
+        if backend == "hunspell":
+            return HunspellChecker(options.get("dictionaries") or [])
+        if backend == "languagetool":
+            url = options.get("url")
+            return LanguageToolChecker(str(url), float(options.get("timeout", 10))) if url else None
+        if backend == "plugin":
+            return _plugin_checker()
+        logger.warning("Unknown spell-check backend %r", backend)
+        return None
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        checker = self.create_checker(options)
+        if checker is None:
+            report.warning(self.name, "No spell checker available; spelling not checked",
+                           backend=options.get("backend", "hunspell"))
+            return
+        terms = load_term_base(options.get("term_base"), options.get("term_base_file"))
+        terms.update(str(w).lower() for w in context.metadata.get("spelling_ignore") or [] if w)
+        min_length = int(options.get("min_word_length", 3))
+        ignore_upper = bool(options.get("ignore_uppercase", True))
+        limit = int(options.get("max_reported_words", 50))
+        default_lang = document_language(context)
+
+        for filename, root in context.topics.items():
+            counts = extract_words(root, min_length=min_length, ignore_uppercase=ignore_upper)
+            words = [w for w in counts if w.lower() not in terms]
+            if not words:
+                continue
+            language = normalize_language(root.get(XML_LANG)) or default_lang
+            try:
+                suspects = set(checker.check(words, language))
+            except Exception as exc:
+                report.warning(self.name, f"Spell check failed: {exc}", topic=filename, language=language)
+                continue
+            found = {w: counts[w] for w in words if w in suspects}
+            if not found:
+                continue
+            ranked = sorted(found.items(), key=lambda item: (-item[1], item[0].lower()))
+            listed = ", ".join(w for w, _ in ranked[:10]) + (", …" if len(ranked) > 10 else "")
+            report.warning(self.name, f"{len(found)} suspected misspelling(s): {listed}", topic=filename,
+                           language=language, words=dict(ranked[:limit]), distinct=len(found))
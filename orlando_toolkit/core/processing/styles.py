@@ -0,0 +1,132 @@
+from __future__ import annotations
+
+"""Source styles mapped to DITA elements by the style map.
+
+Paragraphs and inline elements carrying a source style (``data-style``)
+that the style map (:mod:`orlando_toolkit.core.style_map`) maps to an
+element are rewritten:
+
+- a paragraph style mapped to ``note``, ``lq``, ``screen``, … renames the
+  ``p``; ``pre``/``codeblock`` hand the paragraph to the ``preformatted``
+  stage, which merges consecutive ones;
+- a character style mapped to ``uicontrol``, ``filepath``, … renames the
+  inline element; on a whole paragraph the content is wrapped instead;
+- the entry's other keys (``type``, ``outputclass``, …) are set as
+  attributes.
+
+Styles found in the topics that the map does not know, heading styles and
+those matching ``ignore`` (Word built-ins and styles other stages handle)
+aside, are listed in one ``styles`` warning so a template's custom styles
+can be added to the map. Every styled element also keeps its style as a
+``data-paragraph-style``/``data-character-style`` hint for the style usage
+report (:mod:`orlando_toolkit.core.style_usage`).
+"""
+
+import logging
+import re
+from collections import Counter
+from typing import Any, Dict, List, Mapping
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import BLOCK_TAGS, is_element, local_name
+from orlando_toolkit.core.style_map import StyleRule, lookup
+from orlando_toolkit.core.style_usage import CHARACTER_HINT, PARAGRAPH_HINT
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["DEFAULT_IGNORE", "StyleMapStage"]
+
+DEFAULT_IGNORE = (
+    r"^(Normal|Body Text( \d| Indent( \d)?| First Indent( \d)?)?|Default Paragraph Font|No Spacing)$",
+    r"^(Heading|TOC|Index|List|List Bullet|List Number|List Continue|List Paragraph)( ?\d)?$",
+    r"^(Title|Subtitle|Caption|Quote|Intense Quote|Header|Footer|Footnote Text|Endnote Text|Table Grid"
+    r"|Table of Figures|Hyperlink|Strong|Emphasis)$",
+    r"^(HTML Preformatted|Plain Text|Code|Source Code|Macro Text|Note|Tip|Important|Caution|Warning|Danger)$",
+    r"\bstep",
+)
+_TITLES = frozenset({"title", "navtitle"})
+_PREFORMATTED = ("pre", "codeblock")
+
+
+def _compile(patterns) -> List["re.Pattern[str]"]:
+    compiled = []
+    for pattern in DEFAULT_IGNORE if patterns is None else patterns:
+        try:
+            compiled.append(re.compile(str(pattern), re.IGNORECASE))
+        except re.error as exc:
+            logger.warning("Invalid styles ignore pattern %r: %s", pattern, exc)
+    return compiled
+
+
+def _set_attributes(el, rule: StyleRule) -> None:
+    for name, value in rule.attributes.items():
+        el.set(name, value)
+
+
+class StyleMapStage(ProcessingStage):
+    name = "styles"
+    hint_attributes = ("data-style", PARAGRAPH_HINT, CHARACTER_HINT)
+    uses_style_map = True
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        rules: Mapping[str, StyleRule] = options.get("style_map") or {}
+        ignore = _compile(options.get("ignore"))
+        unmapped: Counter = Counter()
+        for filename, root in context.topics.items():
+            applied = 0
+            for el in list(root.iter()):
+                style = el.get("data-style") if is_element(el) and el is not root else None
+                if not style or local_name(el) in _TITLES:
+                    continue
+                hint = PARAGRAPH_HINT if local_name(el) in BLOCK_TAGS else CHARACTER_HINT
+                marked = el
+                rule = lookup(rules, style)
+                if rule is None:
+                    if not any(p.search(style) for p in ignore):
+                        unmapped[style] += 1
+                elif rule.element is not None:
+                    mapped = self._apply(el, rule)
+                    if mapped is not None:
+                        marked = mapped
+                        applied += 1
+                marked.set(hint, style)
+            if applied:
+                report.info(self.name, f"{applied} element(s) mapped from source styles", topic=filename,
+                            elements=applied)
+        if unmapped and options.get("warn_unmapped", True):
+            limit = int(options.get("max_listed", 20))
+            listed = ", ".join(f"{style} ({count})" for style, count in unmapped.most_common(limit))
+            if len(unmapped) > limit:
+                listed += f", … {len(unmapped) - limit} more"
+            report.warning(self.name, f"{len(unmapped)} source style(s) not in the style map: {listed}",
+                           styles=dict(unmapped))
+
+    def _apply(self, el, rule: StyleRule):
+        """Apply *rule* to *el*; returns the element now holding the content, or None when not applied."""
+        block = local_name(el) in BLOCK_TAGS
+        if block and local_name(el) != "p":
+            return None
+        if not block and not rule.inline:
+            logger.debug("Style %s maps to block %s but is on <%s>", rule.style, rule.element, local_name(el))
+            return None
+        if rule.element in _PREFORMATTED:
+            el.set("data-preformatted", rule.element)
+            _set_attributes(el, rule)
+        elif block and rule.inline:
+            wrapper = ET.Element(rule.element)
+            wrapper.text, el.text = el.text, None
+            for child in list(el):
+                wrapper.append(child)
+            el.append(wrapper)
+            _set_attributes(wrapper, rule)
+            el.attrib.pop("data-style", None)
+            return wrapper
+        else:
+            el.tag = rule.element
+            _set_attributes(el, rule)
+        el.attrib.pop("data-style", None)
+        return el
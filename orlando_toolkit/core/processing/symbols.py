@@ -0,0 +1,198 @@
+from __future__ import annotations
+
+"""Symbol font character mapping.
+
+Characters typed in the Symbol, Wingdings and Webdings fonts are stored as
+plain Latin code points (``a`` for α) or as Microsoft private-use code points
+``U+F020``–``U+F0FF``. Once the font is dropped they render as the wrong
+characters. Inside runs whose ``data-font`` hint names one of these fonts the
+stage maps them to their Unicode equivalents (Greek letters, arrows, math
+operators, check marks, ballot boxes); private-use code points outside any
+known font run are mapped as Symbol when ``assume_symbol_for_pua`` is set.
+
+Characters without a known equivalent are kept and reported. Additional fonts
+or overrides can be supplied with ``extra_mappings`` (``{font: {"0x41": "U+2713"}}``).
+"""
+
+import logging
+import re
+from typing import Any, Dict, Mapping, Optional, Tuple
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import get_slot, is_element, iter_text_slots, set_slot
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["SymbolFontStage", "map_symbol_text"]
+
+
+def _table(pairs: str) -> Dict[int, int]:
+    """Parse ``"41:0391 42:0392 …"`` (font code:Unicode, hex) into a dict."""
+    out: Dict[int, int] = {}
+    for pair in pairs.split():
+        src, dst = pair.split(":")
+        out[int(src, 16)] = int(dst, 16)
+    return out
+
+
+# Adobe Symbol encoding (ASCII positions that are identical are omitted)
+_SYMBOL = _table("""
+    22:2200 24:2203 27:220B 2A:2217 2D:2212 40:2245
+    41:0391 42:0392 43:03A7 44:0394 45:0395 46:03A6 47:0393 48:0397 49:0399 4A:03D1
+    4B:039A 4C:039B 4D:039C 4E:039D 4F:039F 50:03A0 51:0398 52:03A1 53:03A3 54:03A4
+    55:03A5 56:03C2 57:03A9 58:039E 59:03A8 5A:0396 5C:2234 5E:22A5 60:203E
+    61:03B1 62:03B2 63:03C7 64:03B4 65:03B5 66:03C6 67:03B3 68:03B7 69:03B9 6A:03D5
+    6B:03BA 6C:03BB 6D:03BC 6E:03BD 6F:03BF 70:03C0 71:03B8 72:03C1 73:03C3 74:03C4
+    75:03C5 76:03D6 77:03C9 78:03BE 79:03C8 7A:03B6 7E:223C
+    A0:20AC A1:03D2 A2:2032 A3:2264 A4:2044 A5:221E A6:0192 A7:2663 A8:2666 A9:2665
+    AA:2660 AB:2194 AC:2190 AD:2191 AE:2192 AF:2193 B0:00B0 B1:00B1 B2:2033 B3:2265
+    B4:00D7 B5:221D B6:2202 B7:2022 B8:00F7 B9:2260 BA:2261 BB:2248 BC:2026 BD:23D0
+    BE:23AF BF:21B5 C0:2135 C1:2111 C2:211C C3:2118 C4:2297 C5:2295 C6:2205 C7:2229
+    C8:222A C9:2283 CA:2287 CB:2284 CC:2282 CD:2286 CE:2208 CF:2209 D0:2220 D1:2207
+    D2:00AE D3:00A9 D4:2122 D5:220F D6:221A D7:22C5 D8:00AC D9:2227 DA:2228 DB:21D4
+    DC:21D0 DD:21D1 DE:21D2 DF:21D3 E0:25CA E1:27E8 E2:00AE E3:00A9 E4:2122 E5:2211
+    E6:239B E7:239C E8:239D E9:23A1 EA:23A2 EB:23A3 EC:23A7 ED:23A8 EE:23A9 EF:23AA
+    F1:27E9 F2:222B F3:2320 F4:23AE F5:2321 F6:239E F7:239F F8:23A0 F9:23A4 FA:23A5
+    FB:23A6 FC:23AB FD:23AC FE:23AD
+""")
+
+# Commonly used Wingdings glyphs (bullets, boxes, check marks, hands, arrows)
+_WINGDINGS = _table("""
+    22:2702 23:2701 28:260E 29:2706 2A:2709 36:231B 37:2328 3F:2707
+    41:270C 43:1F44D 44:1F44E 45:261C 46:261E 47:261D 48:261F 4A:263A 4B:1F610 4C:2639
+    4E:2620 52:263C 54:2744 58:2720 59:2721 5B:262F 6C:25CF 6D:274D 6E:25A0 6F:25A1
+    71:2751 72:2752 73:2B27 74:29EB 75:25C6 76:2756 77:2B25 78:2327 9F:2022 A1:25CB
+    A7:25AA A8:25FB D5:232B D6:2326 DF:2190 E0:2192 E1:2191 E2:2193 E7:21E6 E8:21E8
+    E9:21E7 EA:21E9 F0:21E8 FB:2717 FC:2714 FD:2612 FE:2611
+""")
+
+_WINGDINGS_2 = _table("4F:2717 50:2713 51:2612 52:2611 53:2612 54:2612 A3:25A1 A2:25A0")
+
+_WINGDINGS_3 = _table("""
+    46:2190 47:2192 48:2191 49:2193 70:25B2 71:25BC 72:25C0 73:25B6 74:25C0 75:25B6
+    C5:25C0 C6:25B6 C7:25B2 C8:25BC
+""")
+
+_WEBDINGS = _table("33:25C0 34:25B6 35:25B2 36:25BC 61:2714 63:25A1 67:25A0 6E:25A0 72:2716 3D:2261")
+
+_FONTS: Dict[str, Dict[int, int]] = {
+    "symbol": _SYMBOL,
+    "wingdings": _WINGDINGS,
+    "wingdings 2": _WINGDINGS_2,
+    "wingdings 3": _WINGDINGS_3,
+    "webdings": _WEBDINGS,
+}
+_PUA_BASE = 0xF000
+_CODE_RE = re.compile(r"^(?:0x|U\+)?([0-9A-Fa-f]{1,6})$")
+
+
+def _parse_code(value: Any) -> Optional[int]:
+    if isinstance(value, int):
+        return value
+    text = str(value).strip()
+    if len(text) == 1:
+        return ord(text)
+    m = _CODE_RE.match(text)
+    return int(m.group(1), 16) if m else None
+
+
+def _font_key(name: Optional[str]) -> Optional[str]:
+    if not name:
+        return None
+    return str(name).strip().strip("'\"").lower() or None
+
+
+def _is_pua(cp: int) -> bool:
+    return _PUA_BASE + 0x20 <= cp <= _PUA_BASE + 0xFF
+
+
+def map_symbol_text(text: str, table: Mapping[int, int], *, ascii_identity: bool = False,
+                    pua_only: bool = False) -> Tuple[str, int, Dict[int, int]]:
+    """Map *text* through a symbol-font *table*.
+
+    Returns ``(mapped_text, changed, unmapped)``; *unmapped* counts font code
+    points (private-use offset removed) without an equivalent. Whitespace
+    passes through, so do ASCII positions when *ascii_identity* is set (Symbol
+    keeps digits and most punctuation). With *pua_only* only private-use code
+    points are considered.
+    """
+    out = []
+    changed = 0
+    unmapped: Dict[int, int] = {}
+    for ch in text:
+        cp = ord(ch)
+        pua = _is_pua(cp)
+        if pua_only and not pua:
+            out.append(ch)
+            continue
+        if pua:
+            cp -= _PUA_BASE
+        target = table.get(cp)
+        if target is None and (ch.isspace() or (ascii_identity and 0x20 <= cp < 0x7F)):
+            target = cp
+        if target is None:
+            out.append(ch)
+            unmapped[cp] = unmapped.get(cp, 0) + 1
+            continue
+        out.append(chr(target))
+        changed += 1 if chr(target) != ch else 0
+    return "".join(out), changed, unmapped
+
+
+def _effective_font(el) -> Optional[str]:
+    node = el
+    while node is not None:
+        if is_element(node) and node.get("data-font"):
+            return _font_key(node.get("data-font"))
+        node = node.getparent()
+    return None
+
+
+class SymbolFontStage(ProcessingStage):
+    name = "symbols"
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        fonts = {k: dict(v) for k, v in _FONTS.items()}
+        for font, entries in (options.get("extra_mappings") or {}).items():
+            key = _font_key(font)
+            if not key or not isinstance(entries, Mapping):
+                continue
+            table = fonts.setdefault(key, {})
+            for src, dst in entries.items():
+                s, d = _parse_code(src), _parse_code(dst)
+                if s is None or d is None:
+                    logger.warning("Ignoring symbol mapping %r -> %r for %s", src, dst, font)
+                    continue
+                table[s] = d
+        assume_pua = bool(options.get("assume_symbol_for_pua", True))
+
+        for filename, root in context.topics.items():
+            changed = 0
+            unmapped: Dict[Tuple[str, int], int] = {}
+            for el, slot in list(iter_text_slots(root)):
+                text = get_slot(el, slot)
+                if not text:
+                    continue
+                owner = el if slot == "text" else (el.getparent() if is_element(el) else None)
+                font = _effective_font(owner) if owner is not None else None
+                if font in fonts:
+                    new, n, missing = map_symbol_text(text, fonts[font], ascii_identity=(font == "symbol"))
+                elif assume_pua:
+                    font = "symbol"
+                    new, n, missing = map_symbol_text(text, fonts["symbol"], pua_only=True)
+                else:
+                    continue
+                if new != text:
+                    set_slot(el, slot, new)
+                changed += n
+                for cp, count in missing.items():
+                    unmapped[(font, cp)] = unmapped.get((font, cp), 0) + count
+            if changed:
+                report.info(self.name, f"Mapped {changed} symbol-font character(s) to Unicode",
+                            topic=filename, mapped=changed)
+            if unmapped:
+                listed = ", ".join(f"{font} 0x{cp:02X}" for (font, cp) in sorted(unmapped))
+                report.warning(self.name, f"No Unicode equivalent for: {listed}", topic=filename,
+                               unmapped={f"{font}:0x{cp:02X}": n for (font, cp), n in sorted(unmapped.items())})
@@ -0,0 +1,145 @@
+from __future__ import annotations
+
+"""Text helpers shared by processing stages.
+
+lxml stores character data in ``.text`` and ``.tail`` slots; stages that
+rewrite text iterate the slots in document order through
+:func:`iter_text_slots` so they never have to special-case mixed content.
+"""
+
+import unicodedata
+from typing import Any, Iterator, List, Optional, Tuple
+
+__all__ = [
+    "BLOCK_TAGS",
+    "BIDI_CONTROLS",
+    "iter_text_slots",
+    "get_slot",
+    "set_slot",
+    "iter_blocks",
+    "block_slots",
+    "first_strong_direction",
+    "is_element",
+    "local_name",
+    "is_preformatted",
+    "PREFORMATTED_TAGS",
+    "VERBATIM_TAGS",
+    "XML_SPACE",
+]
+
+# Elements that start a new paragraph-level run of text
+BLOCK_TAGS = frozenset({
+    "title", "shortdesc", "p", "li", "entry", "stentry", "note", "lq",
+    "dt", "dd", "pre", "codeblock", "lines", "fig", "figgroup", "sli",
+    "cmd", "info", "stepresult", "navtitle", "glossterm", "glossdef",
+})
+
+# Unicode bidi formatting characters; they are invisible but significant and
+# must survive every text rewrite untouched.
+BIDI_CONTROLS = frozenset({
+    "\u200e", "\u200f", "\u061c",                      # LRM, RLM, ALM
+    "\u202a", "\u202b", "\u202c", "\u202d", "\u202e",  # LRE, RLE, PDF, LRO, RLO
+    "\u2066", "\u2067", "\u2068", "\u2069",            # LRI, RLI, FSI, PDI
+})
+
+# Elements whose whitespace is content
+PREFORMATTED_TAGS = frozenset({"pre", "codeblock", "lines", "msgblock", "screen"})
+# Embedded markup whose text no stage may rewrite
+VERBATIM_TAGS = frozenset({"mathml"})
+XML_SPACE = "{http://www.w3.org/XML/1998/namespace}space"
+
+Slot = Tuple[Any, str]
+
+
+def is_element(node) -> bool:
+    """True for real elements (not comments or processing instructions)."""
+    return isinstance(getattr(node, "tag", None), str)
+
+
+def local_name(el) -> str:
+    """Tag without namespace."""
+    tag = el.tag if is_element(el) else ""
+    return tag.rsplit("}", 1)[-1]
+
+
+def is_preformatted(el) -> bool:
+    """True when *el* or an ancestor keeps whitespace verbatim (or is MathML)."""
+    node = el
+    while node is not None:
+        if is_element(node) and (local_name(node) in PREFORMATTED_TAGS or local_name(node) in VERBATIM_TAGS
+                                 or node.get(XML_SPACE) == "preserve"):
+            return True
+        node = node.getparent()
+    return False
+
+
+def get_slot(el, slot: str) -> Optional[str]:
+    return el.text if slot == "text" else el.tail
+
+
+def set_slot(el, slot: str, value: Optional[str]) -> None:
+    if slot == "text":
+        el.text = value
+    else:
+        el.tail = value
+
+
+def iter_text_slots(root, *, include_root_tail: bool = False) -> Iterator[Slot]:
+    """Yield ``(element, "text"|"tail")`` for every text slot under *root*.
+
+    Order is document order. Comments and processing instructions contribute
+    only their tails.
+    """
+    def _walk(el) -> Iterator[Slot]:
+        if is_element(el):
+            yield el, "text"
+            for child in el:
+                yield from _walk(child)
+        yield el, "tail"
+
+    if is_element(root):
+        yield root, "text"
+        for child in root:
+            yield from _walk(child)
+    if include_root_tail:
+        yield root, "tail"
+
+
+def iter_blocks(root) -> Iterator[Any]:
+    """Yield block-level elements under *root* (inclusive) in document order."""
+    for el in root.iter():
+        if is_element(el) and local_name(el) in BLOCK_TAGS:
+            yield el
+
+
+def block_slots(block) -> List[Slot]:
+    """Text slots of *block* in document order, excluding nested blocks.
+
+    Nested blocks contribute only their tails, which belong to *block*.
+    """
+    slots: List[Slot] = [(block, "text")]
+
+    def _walk(el) -> None:
+        if is_element(el) and local_name(el) in BLOCK_TAGS:
+            slots.append((el, "tail"))
+            return
+        if is_element(el):
+            slots.append((el, "text"))
+            for child in el:
+                _walk(child)
+        slots.append((el, "tail"))
+
+    for child in block:
+        _walk(child)
+    return slots
+
+
+def first_strong_direction(text: str) -> Optional[str]:
+    """Return ``"rtl"``/``"ltr"`` for the first strong character, per UAX #9 P2."""
+    for ch in text:
+        bidi = unicodedata.bidirectional(ch)
+        if bidi == "L":
+            return "ltr"
+        if bidi in ("R", "AL"):
+            return "rtl"
+    return None
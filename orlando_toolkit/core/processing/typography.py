@@ -0,0 +1,174 @@
+from __future__ import annotations
+
+"""Typographic normalization toggles.
+
+Style guides disagree on typographic characters, so each class has its own
+option (``preserve`` keeps source characters and is the default):
+
+- ``quotes``: ``straight`` turns curly quotes into ``"``/``'``; ``curly``
+  turns straight quotes into “ ” / ‘ ’ using the preceding character to pick
+  opening or closing forms (apostrophes become ’).
+- ``dashes``: ``hyphen`` turns en/em dashes into ``-``/``--``; ``en``/``em``
+  turns spaced hyphens (`` - ``, `` -- ``) into an en or em dash.
+- ``ellipses``: ``dots`` writes ``...``; ``character`` writes ``…``.
+- ``nonbreaking_hyphens``: ``hyphen`` replaces U+2011 with ``-``.
+- ``nbsp``: ``space`` turns no-break spaces (U+00A0, narrow U+202F) into
+  regular spaces; ``units`` keeps them only between a number and a unit or
+  symbol (``10 kg``, ``5 %``, ``20 °C``). Preserved NBSPs are written as
+  ``&#xA0;`` when listed in ``serialization.numeric_characters``.
+
+Text in ``codeblock``/``pre``/``codeph`` and other preformatted content is
+never changed. Context is tracked across the inline runs of a block, so a
+quote, a spaced hyphen or a unit NBSP at the edge of ``<b>`` or ``<ph>`` text
+is judged by the characters of the neighbouring runs. A `` -- `` whose two
+hyphens sit in different runs is not recognised.
+"""
+
+import logging
+import re
+from typing import Any, Dict, Optional
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import (
+    block_slots,
+    get_slot,
+    is_element,
+    is_preformatted,
+    iter_blocks,
+    local_name,
+    set_slot,
+)
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["TypographyStage"]
+
+_CHOICES = {
+    "quotes": ("preserve", "straight", "curly"),
+    "dashes": ("preserve", "hyphen", "en", "em"),
+    "ellipses": ("preserve", "dots", "character"),
+    "nonbreaking_hyphens": ("preserve", "hyphen"),
+    "nbsp": ("preserve", "space", "units"),
+}
+_STRAIGHT = str.maketrans({
+    "“": '"', "”": '"', "„": '"', "‟": '"',
+    "‘": "'", "’": "'", "‚": "'", "‛": "'",
+})
+_CURLY_SINGLE = ("‘", "’")
+_CURLY_DOUBLE = ("“", "”")
+# A quote preceded by one of these opens; anything else closes
+_OPENING_CONTEXT = set(" \t\r\n\u00a0([{<\u2014\u2013-/")
+_EN_DASH, _EM_DASH = "\u2013", "\u2014"
+_SPACED_HYPHEN_RE = re.compile(r"(?<=\s)--?(?=\s)")
+_NBSP = "\u00a0\u202f"
+_NBSP_RE = re.compile(f"[{_NBSP}]")
+# Number + unit: a digit before, then %, a symbol or a short unit word after
+_UNIT_NBSP_RE = re.compile(
+    rf"(?<=\d)[{_NBSP}](?=[%\u2030\u00b0\u20ac$\u00a3\u00a5]|[A-Za-z\u00b5\u03a9\u00b0/]{{1,3}}[\u00b2\u00b3]?(?![A-Za-z]))"
+)
+# Characters of the following runs the look-ahead patterns need (a unit and its boundary)
+_LOOKAHEAD = 6
+_INLINE_CODE = frozenset({"codeph", "filepath", "cmdname", "varname", "apiname", "userinput", "systemoutput"})
+
+
+def _is_code(el) -> bool:
+    node = el
+    while node is not None:
+        if is_element(node) and local_name(node) in _INLINE_CODE:
+            return True
+        node = node.getparent()
+    return is_preformatted(el)
+
+
+class TypographyStage(ProcessingStage):
+    name = "typography"
+
+    def is_enabled(self, options: Dict[str, Any]) -> bool:
+        if not super().is_enabled(options):
+            return False
+        return any(self._choice(options, key) != "preserve" for key in _CHOICES)
+
+    def _choice(self, options: Dict[str, Any], key: str) -> str:
+        value = str(options.get(key, "preserve") or "preserve").strip().lower()
+        if value not in _CHOICES[key]:
+            logger.warning("Unknown typography %s option %r; preserving", key, value)
+            return "preserve"
+        return value
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        choices = {key: self._choice(options, key) for key in _CHOICES}
+        roots = list(context.topics.items())
+        if context.ditamap_root is not None:
+            roots.append(("(map)", context.ditamap_root))
+        for filename, root in roots:
+            changed = 0
+            for block in iter_blocks(root):
+                if is_preformatted(block):
+                    continue
+                changed += self._fix_block(block, choices)
+            if changed:
+                report.info(self.name, f"Typography normalized in {changed} text run(s)",
+                            topic=None if filename == "(map)" else filename, runs=changed, **choices)
+
+    def _fix_block(self, block, choices: Dict[str, str]) -> int:
+        changed = 0
+        prev: Optional[str] = None  # last character seen in this block
+        slots = [(el, slot, get_slot(el, slot)) for el, slot in block_slots(block)]
+        slots = [(el, slot, text) for el, slot, text in slots if text]
+        for index, (el, slot, text) in enumerate(slots):
+            following = "".join(t for _el, _slot, t in slots[index + 1:index + 1 + _LOOKAHEAD])[:_LOOKAHEAD]
+            owner = el if slot == "text" else el.getparent()
+            if owner is not None and _is_code(owner):
+                prev = text[-1]
+                continue
+            new = self._fix_text(text, choices, prev, following)
+            prev = new[-1] if new else prev
+            if new != text:
+                set_slot(el, slot, new)
+                changed += 1
+        return changed
+
+    def _fix_text(self, text: str, choices: Dict[str, str], prev: Optional[str], following: str) -> str:
+        # Edges of the neighbouring runs, seen by the look-around patterns but never rewritten
+        before, after = prev or "", following
+        ellipses = choices["ellipses"]
+        if ellipses == "dots":
+            text = text.replace("…", "...")
+        elif ellipses == "character":
+            text = text.replace("...", "…")
+
+        dashes = choices["dashes"]
+        if dashes == "hyphen":
+            text = text.replace(_EM_DASH, "--").replace(_EN_DASH, "-")
+        elif dashes in ("en", "em"):
+            dash = _EN_DASH if dashes == "en" else _EM_DASH
+            # One character on each side is all the pattern looks at and is never part of a match
+            edged = _SPACED_HYPHEN_RE.sub(dash, before + text + after[:1])
+            text = edged[len(before):len(edged) - len(after[:1])]
+
+        if choices["nonbreaking_hyphens"] == "hyphen":
+            text = text.replace("\u2011", "-")
+
+        nbsp = choices["nbsp"]
+        if nbsp == "space":
+            text = _NBSP_RE.sub(" ", text)
+        elif nbsp == "units":
+            kept = {m.start() - len(before) for m in _UNIT_NBSP_RE.finditer(before + text + after)}
+            text = "".join(" " if ch in _NBSP and i not in kept else ch for i, ch in enumerate(text))
+
+        quotes = choices["quotes"]
+        if quotes == "straight":
+            text = text.translate(_STRAIGHT)
+        elif quotes == "curly":
+            out = []
+            for ch in text:
+                if ch in ("'", '"'):
+                    opening = prev is None or prev in _OPENING_CONTEXT
+                    pair = _CURLY_DOUBLE if ch == '"' else _CURLY_SINGLE
+                    ch = pair[0] if opening else pair[1]
+                out.append(ch)
+                prev = ch
+            text = "".join(out)
+        return text
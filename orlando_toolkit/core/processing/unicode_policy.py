@@ -0,0 +1,131 @@
+from __future__ import annotations
+
+"""Unicode normalization and repertoire checks.
+
+Runs first so later stages see canonical text. Every text slot of every topic
+and of the map is normalized to the configured form (NFC by default). Note
+that the compatibility forms (NFKC/NFKD) fold full-width punctuation and
+ligatures to their ASCII equivalents, which is rarely wanted for CJK content.
+
+When ``allowed_repertoire`` is configured, characters outside it are reported
+per topic (code point, name, count); the text itself is left unchanged.
+"""
+
+import logging
+import re
+import unicodedata
+from typing import Any, Dict, Iterable, List, Optional, Tuple
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import get_slot, iter_text_slots, set_slot
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["UnicodeNormalizationStage", "parse_repertoire"]
+
+_FORMS = ("NFC", "NFD", "NFKC", "NFKD")
+
+# Named repertoires usable in ``allowed_repertoire``
+_NAMED_REPERTOIRES: Dict[str, List[Tuple[int, int]]] = {
+    "ascii": [(0x00, 0x7F)],
+    "latin1": [(0x00, 0xFF)],
+    "windows-1252": [(0x00, 0x7F), (0xA0, 0xFF)] + [
+        (cp, cp) for cp in (0x20AC, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021, 0x02C6,
+                            0x2030, 0x0160, 0x2039, 0x0152, 0x017D, 0x2018, 0x2019, 0x201C,
+                            0x201D, 0x2022, 0x2013, 0x2014, 0x02DC, 0x2122, 0x0161, 0x203A,
+                            0x0153, 0x017E, 0x0178)
+    ],
+    "bmp": [(0x0000, 0xFFFF)],
+}
+_RANGE_RE = re.compile(r"^U\+([0-9A-Fa-f]{1,6})(?:\s*-\s*U\+([0-9A-Fa-f]{1,6}))?$")
+# Formatting whitespace is always allowed
+_ALWAYS_ALLOWED = frozenset("\t\n\r")
+
+
+def parse_repertoire(spec: Any) -> Optional[List[Tuple[int, int]]]:
+    """Parse ``allowed_repertoire`` into inclusive code point ranges.
+
+    Accepts a named set (``ascii``, ``latin1``, ``windows-1252``, ``bmp``),
+    ``"U+XXXX-U+YYYY"`` ranges, or a list mixing both. ``None`` disables the
+    check. Unknown entries are logged and ignored.
+    """
+    if spec in (None, "", []):
+        return None
+    items: Iterable[Any] = spec if isinstance(spec, (list, tuple)) else [spec]
+    ranges: List[Tuple[int, int]] = []
+    for item in items:
+        text = str(item).strip()
+        named = _NAMED_REPERTOIRES.get(text.lower())
+        if named:
+            ranges.extend(named)
+            continue
+        m = _RANGE_RE.match(text)
+        if m:
+            lo = int(m.group(1), 16)
+            hi = int(m.group(2), 16) if m.group(2) else lo
+            ranges.append((min(lo, hi), max(lo, hi)))
+            continue
+        logger.warning("Ignoring unknown repertoire entry %r", item)
+    return ranges or None
+
+
+def _allowed(ch: str, ranges: List[Tuple[int, int]]) -> bool:
+    if ch in _ALWAYS_ALLOWED:
+        return True
+    cp = ord(ch)
+    return any(lo <= cp <= hi for lo, hi in ranges)
+
+
+class UnicodeNormalizationStage(ProcessingStage):
+    name = "unicode"
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        form = options.get("normalization_form", "NFC")
+        form = str(form).upper() if form else None
+        if form in ("NONE", "OFF", "FALSE"):
+            form = None
+        if form is not None and form not in _FORMS:
+            logger.warning("Unknown normalization form %r; using NFC", form)
+            form = "NFC"
+        ranges = parse_repertoire(options.get("allowed_repertoire"))
+        max_listed = int(options.get("max_reported_characters", 20) or 20)
+
+        roots = list(context.topics.items())
+        if context.ditamap_root is not None:
+            roots.append(("(map)", context.ditamap_root))
+
+        for filename, root in roots:
+            changed = 0
+            outside: Dict[str, int] = {}
+            for el, slot in iter_text_slots(root):
+                text = get_slot(el, slot)
+                if not text:
+                    continue
+                if form is not None:
+                    normalized = unicodedata.normalize(form, text)
+                    if normalized != text:
+                        set_slot(el, slot, normalized)
+                        text = normalized
+                        changed += 1
+                if ranges is not None:
+                    for ch in text:
+                        if not _allowed(ch, ranges):
+                            outside[ch] = outside.get(ch, 0) + 1
+            topic = None if filename == "(map)" else filename
+            if changed:
+                report.info(self.name, f"Normalized {changed} text run(s) to {form}",
+                            topic=topic, runs=changed, form=form)
+            if outside:
+                listed = sorted(outside.items(), key=lambda kv: ord(kv[0]))[:max_listed]
+                chars = {
+                    f"U+{ord(ch):04X}": {"name": unicodedata.name(ch, "UNKNOWN"), "count": n}
+                    for ch, n in listed
+                }
+                summary = ", ".join(f"{cp} {info['name']}" for cp, info in list(chars.items())[:5])
+                report.warning(
+                    self.name,
+                    f"{sum(outside.values())} character(s) outside the allowed repertoire: {summary}",
+                    topic=topic, characters=chars, distinct=len(outside),
+                )
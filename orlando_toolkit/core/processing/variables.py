@@ -0,0 +1,265 @@
+from __future__ import annotations
+
+"""Variable substitution backed by keys.
+
+Product names, part numbers and versions typed as placeholders in the source
+(``{{ProductName}}``) become ``<keyword keyref="ProductName"/>`` so one key
+definition controls every occurrence. The values come from a variables file
+(``source``) and/or inline ``values``:
+
+- YAML: a mapping ``ProductName: Orlando``;
+- CSV: ``name,value`` rows (a ``key``/``name``/``variable`` header row is
+  skipped).
+
+For each variable used, the map gets ``<keydef keys="ProductName">`` with the
+value as its keyword text. Placeholders are matched by ``placeholder`` (a
+regular expression whose first group is the variable name). Runs in one of
+the ``styles`` character styles (``data-style`` hint on inline elements) are
+variables too: their text must be a variable name or value. Unknown names are
+left as they are and reported. Code and preformatted content is not changed.
+
+The key table of the Metadata tab (``metadata["variables"]``, see
+:mod:`orlando_toolkit.core.keys`) adds to and overrides these values.
+:func:`replace_phrases` turns literal occurrences of the values into key
+references; the package step uses it for the whole table.
+"""
+
+import csv
+import io
+import logging
+import re
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional, Set, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import (
+    get_slot,
+    is_element,
+    is_preformatted,
+    iter_text_slots,
+    local_name,
+    set_slot,
+)
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["VariablesStage", "load_variables", "phrase_pattern", "replace_phrases", "DEFAULT_PLACEHOLDER"]
+
+DEFAULT_PLACEHOLDER = r"\{\{\s*([A-Za-z_][\w.-]*)\s*\}\}"
+_STYLE_HINT = "data-style"
+_HEADER_NAMES = frozenset({"key", "keys", "name", "variable"})
+# Characters DITA does not allow in key names
+_INVALID_KEY = re.compile(r"[\s{}\[\]/#?]")
+# Elements whose text is never replaced by key references
+_NO_PHRASES = frozenset({"keyword", "xref", "indexterm", "data", "codeph"})
+
+
+def _from_csv(text: str) -> Dict[str, str]:
+    values: Dict[str, str] = {}
+    for number, row in enumerate(csv.reader(io.StringIO(text))):
+        if len(row) < 2 or not row[0].strip() or row[0].lstrip().startswith("#"):
+            continue
+        if number == 0 and row[0].strip().lower() in _HEADER_NAMES:
+            continue
+        values[row[0].strip()] = row[1].strip()
+    return values
+
+
+def _from_yaml(text: str) -> Dict[str, str]:
+    import yaml  # type: ignore
+
+    data = yaml.safe_load(text) or {}
+    if not isinstance(data, Mapping):
+        raise ValueError("expected a mapping of variable names to values")
+    return {str(k): "" if v is None else str(v) for k, v in data.items()}
+
+
+def load_variables(path: Optional[str] = None, values: Optional[Mapping[str, Any]] = None) -> Dict[str, str]:
+    """Variables from the file at *path* (CSV or YAML) overridden by *values*.
+
+    Names DITA cannot use as keys are skipped with a warning.
+    """
+    variables: Dict[str, str] = {}
+    if path:
+        file = Path(path).expanduser()
+        try:
+            text = file.read_text(encoding="utf-8-sig")
+            variables.update(_from_csv(text) if file.suffix.lower() == ".csv" else _from_yaml(text))
+        except Exception as exc:
+            logger.warning("Could not read variables file %s: %s", path, exc)
+    variables.update({str(k): "" if v is None else str(v) for k, v in (values or {}).items()})
+    for name in [n for n in variables if _INVALID_KEY.search(n)]:
+        logger.warning("Variable name %r cannot be used as a key; ignored", name)
+        del variables[name]
+    return variables
+
+
+def _keyword(name: str) -> ET._Element:
+    return ET.Element("keyword", keyref=name)
+
+
+def _split_slot(el, slot: str, pattern: "re.Pattern[str]", variables: Mapping[str, str],
+                used: Dict[str, int], unknown: Dict[str, int], names: Optional[Mapping[str, str]] = None) -> None:
+    text = get_slot(el, slot)
+    if not text:
+        return
+    pieces: List[Any] = []
+    position = 0
+    for match in pattern.finditer(text):
+        name = match.group(1) if names is None else names.get(" ".join(match.group(1).split()), "")
+        if name not in variables:
+            unknown[name] = unknown.get(name, 0) + 1
+            continue
+        pieces.append(text[position:match.start()])
+        pieces.append(_keyword(name))
+        used[name] = used.get(name, 0) + 1
+        position = match.end()
+    if not pieces:
+        return
+    rest = text[position:]
+    set_slot(el, slot, pieces[0] or None)
+    if slot == "text":
+        parent, index = el, 0
+    else:
+        parent = el.getparent()
+        index = list(parent).index(el) + 1
+    keywords = pieces[1::2]
+    texts = pieces[2::2] + [rest]
+    for offset, (keyword, tail) in enumerate(zip(keywords, texts)):
+        keyword.tail = tail or None
+        parent.insert(index + offset, keyword)
+
+
+def phrase_pattern(variables: Mapping[str, str]) -> Optional[Tuple["re.Pattern[str]", Dict[str, str]]]:
+    """Pattern matching the values of *variables* as whole words, longest first, and value -> name."""
+    names: Dict[str, str] = {}
+    for name, value in variables.items():
+        value = " ".join(str(value or "").split())
+        if value and value not in names:
+            names[value] = name
+    if not names:
+        return None
+    alternatives = "|".join(re.escape(v).replace(r"\ ", r"\s+") for v in sorted(names, key=len, reverse=True))
+    return re.compile(rf"(?<!\w)({alternatives})(?!\w)"), names
+
+
+def _in_keyword(el) -> bool:
+    node = el
+    while node is not None:
+        if is_element(node) and (local_name(node) in _NO_PHRASES or node.get("translate") == "no"):
+            return True
+        node = node.getparent()
+    return False
+
+
+def replace_phrases(root, variables: Mapping[str, str], used: Optional[Dict[str, int]] = None) -> Dict[str, int]:
+    """Replace the values of *variables* typed in the text of *root* by key references.
+
+    Text already inside a ``<keyword>``, link, index term or preformatted
+    element is left as it is. Returns occurrences replaced per name.
+    """
+    used = {} if used is None else used
+    compiled = phrase_pattern(variables)
+    if compiled is None:
+        return used
+    pattern, names = compiled
+    slots = [(el, slot) for el, slot in iter_text_slots(root)
+             if not is_preformatted(el if slot == "text" else el.getparent())
+             and not _in_keyword(el if slot == "text" else el.getparent())]
+    for el, slot in slots:
+        _split_slot(el, slot, pattern, variables, used, {}, names=names)
+    return used
+
+
+def _styled_name(text: str, variables: Mapping[str, str]) -> Optional[str]:
+    text = " ".join(text.split())
+    if text in variables:
+        return text
+    return next((name for name, value in variables.items() if value and value == text), None)
+
+
+class VariablesStage(ProcessingStage):
+    name = "variables"
+    hint_attributes = (_STYLE_HINT,)
+
+    def is_enabled(self, options: Dict[str, Any]) -> bool:
+        return bool(options.get("enabled", False))
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        from orlando_toolkit.core.keys import key_table
+
+        variables = key_table(context.metadata, options)
+        if not variables:
+            report.warning(self.name, "Variable substitution is enabled but no variables were loaded",
+                           source=options.get("source"))
+            return
+        try:
+            pattern = re.compile(str(options.get("placeholder") or DEFAULT_PLACEHOLDER))
+        except re.error as exc:
+            report.warning(self.name, f"Invalid placeholder pattern: {exc}")
+            return
+        if pattern.groups < 1:
+            report.warning(self.name, "Placeholder pattern needs a group capturing the variable name")
+            return
+        styles = options.get("styles") or ()
+        styles = {styles} if isinstance(styles, str) else {str(s) for s in styles}
+
+        used: Dict[str, int] = {}
+        unknown: Dict[str, int] = {}
+        for filename, root in context.topics.items():
+            before = sum(used.values())
+            if styles:
+                self._replace_styled(root, styles, variables, used, unknown)
+            slots = [(el, slot) for el, slot in iter_text_slots(root)
+                     if not is_preformatted(el if slot == "text" else el.getparent())]
+            for el, slot in slots:
+                _split_slot(el, slot, pattern, variables, used, unknown)
+            replaced = sum(used.values()) - before
+            if replaced:
+                report.info(self.name, f"{replaced} variable(s) replaced by key references",
+                            topic=filename, replaced=replaced)
+        for name, count in sorted(unknown.items()):
+            report.warning(self.name, f"Unknown variable '{name}' left as text ({count} occurrence(s))",
+                           variable=name, occurrences=count)
+        if used:
+            self._define_keys(context, {name: variables[name] for name in sorted(used)})
+
+    @staticmethod
+    def _replace_styled(root, styles: Set[str], variables: Mapping[str, str],
+                        used: Dict[str, int], unknown: Dict[str, int]) -> None:
+        runs = [el for el in root.iter() if is_element(el) and el.get(_STYLE_HINT) in styles
+                and local_name(el) in ("ph", "keyword", "b", "i", "u") and not is_preformatted(el)]
+        for run in runs:
+            text = "".join(run.itertext())
+            name = _styled_name(text, variables)
+            if name is None:
+                label = " ".join(text.split())
+                if label:
+                    unknown[label] = unknown.get(label, 0) + 1
+                continue
+            keyword = _keyword(name)
+            keyword.tail = run.tail
+            parent = run.getparent()
+            parent.insert(list(parent).index(run), keyword)
+            parent.remove(run)
+            used[name] = used.get(name, 0) + 1
+
+    @staticmethod
+    def _define_keys(context: DitaContext, values: Mapping[str, str]) -> None:
+        root = context.ditamap_root
+        if root is None:
+            return
+        defined = {k for keydef in root.iter("keydef") for k in (keydef.get("keys") or "").split()}
+        position = len(root.findall("title")) + len(root.findall("topicmeta"))
+        for name, value in values.items():
+            if name in defined:
+                continue
+            keydef = ET.Element("keydef", keys=name)
+            keywords = ET.SubElement(ET.SubElement(keydef, "topicmeta"), "keywords")
+            ET.SubElement(keywords, "keyword").text = value
+            root.insert(position, keydef)
+            position += 1
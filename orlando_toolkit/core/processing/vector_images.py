@@ -0,0 +1,207 @@
+from __future__ import annotations
+
+"""EMF/WMF conversion and SVG clean-up.
+
+Windows metafiles (``.emf``, ``.wmf``, also recognised by their header when
+the extension is missing) do not display in browsers or most DITA
+processors. The stage converts each one through a sandboxed external tool
+(:class:`~orlando_toolkit.core.external_tools.ToolExecutor`) to ``svg``
+(default, keeps line art sharp) or to a ``png`` rendered at ``dpi``. The
+image is renamed in ``context.images`` and every ``<image href>`` pointing to
+it is updated. When the tool is missing or fails the metafile is kept as-is
+and one warning lists the files left unconverted. Metafiles are converted
+with the ``media`` workers of ``pipeline.yml``, renamed in their original
+order.
+
+Native SVGs are preserved; scripts, event handler attributes and
+``javascript:`` links are removed from them (``sanitize_svg``).
+"""
+
+import logging
+import posixpath
+import threading
+from typing import Any, Dict, List, Mapping, Optional, Tuple
+
+from orlando_toolkit.core.concurrency import PipelineSettings, ordered_map
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.report import ConversionReport
+from orlando_toolkit.core.processing.base import ProcessingStage
+from orlando_toolkit.core.processing.text import local_name
+from orlando_toolkit.core.spool import blob_size, rename_blobs
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["VectorImageStage", "is_metafile", "is_svg", "sanitize_svg", "svg_info"]
+
+_FORMATS = ("svg", "png")
+_METAFILE_EXTENSIONS = (".emf", ".wmf")
+_DEFAULT_ARGS = ("{input}", "--export-type={format}", "--export-filename={output}", "--export-dpi={dpi}")
+
+
+def is_metafile(name: str, data: bytes = b"") -> bool:
+    """Whether an image is an EMF or WMF metafile, by extension or header."""
+    if name.lower().endswith(_METAFILE_EXTENSIONS):
+        return True
+    # EMF: " EMF" signature in the header record; WMF: placeable header key
+    return data[40:44] == b" EMF" or data[:4] == b"\xd7\xcd\xc6\x9a"
+
+
+def is_svg(name: str, data: bytes = b"") -> bool:
+    """Whether an image is an SVG document, by extension or content."""
+    if name.lower().endswith((".svg", ".svgz")):
+        return True
+    head = data[:512].lstrip().lower()
+    return head.startswith((b"<svg", b"<?xml")) and b"<svg" in head
+
+
+def _parse_svg(data: bytes) -> Any:
+    from orlando_toolkit.core.xml_security import parse_bytes
+
+    return parse_bytes(data, source="<svg>")
+
+
+def sanitize_svg(data: bytes) -> Optional[bytes]:
+    """Return *data* without scripts and event handlers, or ``None`` if nothing changed.
+
+    Raises when the SVG cannot be parsed.
+    """
+    from lxml import etree as ET
+
+    root = _parse_svg(data)
+    changed = False
+    for el in list(root.iter()):
+        if not isinstance(el.tag, str):
+            continue
+        if local_name(el) == "script":
+            el.getparent().remove(el)
+            changed = True
+            continue
+        for attr in list(el.attrib):
+            value = el.get(attr) or ""
+            handler = attr.rsplit("}", 1)[-1].lower().startswith("on")
+            if handler or value.strip().lower().startswith("javascript:"):
+                del el.attrib[attr]
+                changed = True
+    return ET.tostring(root, encoding="utf-8", xml_declaration=True) if changed else None
+
+
+def svg_info(data: bytes) -> Dict[str, str]:
+    """Declared ``width``, ``height`` and ``viewBox`` of an SVG (empty on parse errors)."""
+    try:
+        root = _parse_svg(data)
+    except Exception:
+        return {}
+    return {key: root.get(key) for key in ("width", "height", "viewBox") if root.get(key)}
+
+
+def _unique(name: str, taken: Mapping[str, Any]) -> str:
+    stem, ext = posixpath.splitext(name)
+    candidate, n = name, 2
+    while candidate in taken:
+        candidate, n = f"{stem}_{n}{ext}", n + 1
+    return candidate
+
+
+def _rename_images(context: DitaContext, renames: Mapping[str, str]) -> int:
+    """Rename ``context.images`` keys (order kept) and the hrefs pointing to them."""
+    context.images = rename_blobs(context.images, renames)
+    updated = 0
+    for topic in context.topics.values():
+        for el in topic.iter("image"):
+            href = el.get("href") or ""
+            folder, base = posixpath.split(href)
+            if base in renames:
+                el.set("href", posixpath.join(folder, renames[base]))
+                updated += 1
+    return updated
+
+
+class _Converter:
+    def __init__(self, options: Mapping[str, Any]) -> None:
+        self.tool = options.get("tool")
+        self.args = [str(a) for a in options.get("args") or _DEFAULT_ARGS]
+        self.format = str(options.get("format") or "svg").lower()
+        self.dpi = int(options.get("dpi") or 300)
+        self.timeout = options.get("timeout")
+        self._executor = None
+        self._lock = threading.Lock()
+
+    def convert(self, name: str, data: bytes) -> bytes:
+        from orlando_toolkit.core.external_tools import ToolExecutor
+
+        with self._lock:
+            if self._executor is None:
+                self._executor = ToolExecutor()
+        ext = posixpath.splitext(name)[1].lower()
+        source = "image" + (ext if ext in _METAFILE_EXTENSIONS else ".emf")
+        output = f"image.{self.format}"
+        with self._executor.workspace() as workspace:
+            (workspace.path / source).write_bytes(data)
+            args = [a.format(input=source, output=output, format=self.format, dpi=self.dpi) for a in self.args]
+            kwargs = {"timeout": float(self.timeout)} if self.timeout else {}
+            self._executor.run(self.tool, args, workspace=workspace, check=True, **kwargs)
+            return (workspace.path / output).read_bytes()
+
+
+class VectorImageStage(ProcessingStage):
+    name = "vector_images"
+
+    def process(self, context: DitaContext, options: Dict[str, Any], report: ConversionReport) -> None:
+        converter = _Converter(options)
+        if converter.format not in _FORMATS:
+            report.warning(self.name, f"Unknown vector_images format {converter.format!r} "
+                                      f"(expected {', '.join(_FORMATS)})")
+            return
+
+        renames: Dict[str, str] = {}
+        converted: Dict[str, bytes] = {}
+        failed: List[str] = []
+        sanitized = 0
+        metafiles: List[str] = []
+        # One image in memory at a time: context.images may be spooled to disk
+        for name in list(context.images):
+            data = context.images[name]
+            if is_svg(name, data):
+                if not options.get("sanitize_svg", True):
+                    continue
+                try:
+                    clean = sanitize_svg(data)
+                except Exception as exc:
+                    logger.debug("SVG %s could not be parsed: %s", name, exc)
+                    continue
+                if clean is not None:
+                    context.images[name] = clean
+                    sanitized += 1
+            elif converter.tool and is_metafile(name, data):
+                metafiles.append(name)
+
+        def _work(name: str) -> Tuple[Optional[bytes], Optional[Exception]]:
+            try:
+                return converter.convert(name, context.images[name]), None
+            except Exception as exc:
+                return None, exc
+
+        pipeline = PipelineSettings.resolve(context.metadata)
+        results = ordered_map(_work, metafiles, workers=pipeline.workers_for("media"), budget=pipeline.budget(),
+                              size_of=lambda name: blob_size(context.images, name))
+        for name, (result, error) in zip(metafiles, results):
+            if error is not None:
+                logger.debug("Metafile %s could not be converted: %s", name, error)
+                failed.append(name)
+                continue
+            taken = dict.fromkeys([*context.images, *converted])
+            target = _unique(f"{posixpath.splitext(name)[0]}.{converter.format}", taken)
+            renames[name] = target
+            converted[target] = result
+
+        if renames:
+            for old, new in renames.items():
+                context.images[old] = converted[new]
+            hrefs = _rename_images(context, renames)
+            report.info(self.name, f"{len(renames)} EMF/WMF image(s) converted to {converter.format.upper()}",
+                        images=len(renames), references=hrefs, format=converter.format)
+        if failed:
+            report.warning(self.name, f"{len(failed)} EMF/WMF image(s) could not be converted and were kept: "
+                                      f"{', '.join(failed)}", images=failed)
+        if sanitized:
+            report.info(self.name, f"Removed scripts from {sanitized} SVG image(s)", images=sanitized)
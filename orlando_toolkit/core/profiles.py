@@ -0,0 +1,248 @@
+from __future__ import annotations
+
+"""Conversion profiles saved as files, to reuse and share settings.
+
+An output profile (:class:`~orlando_toolkit.options.OutputProfile`) written
+to its own YAML or JSON file holds the keys of a ``profiles.yml`` entry
+plus its ``name``. The files in the ``profiles`` folder of the user
+configuration (``~/.orlando_toolkit/profiles``) are available by name like
+the entries of ``profiles.yml``, and replace an entry of the same name:
+
+- :func:`save_profile` writes settings to that folder;
+  :func:`profile_from_metadata` takes them from a conversion (topic depth,
+  style maps, image naming, conversion options, metadata defaults) but
+  leaves out the fields of one document (title, revision date, …);
+- :func:`export_profile` writes a self-contained copy: the profiles it
+  extends, its style map file and XSLT stylesheets are folded in, so the
+  file works on any machine;
+- :func:`import_profile` checks such a file and copies it into the folder.
+"""
+
+import copy
+import json
+import logging
+import re
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional, Tuple
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "PROFILE_SUFFIXES",
+    "available_profiles",
+    "delete_profile",
+    "export_profile",
+    "import_profile",
+    "profile_from_metadata",
+    "profiles_dir",
+    "read_profile_file",
+    "save_profile",
+]
+
+PROFILE_SUFFIXES = (".yml", ".yaml", ".json")
+
+_KEYS = ("description", "extends", "metadata", "conversion_options", "pipeline", "style_map", "style_map_file",
+         "templates")
+_MAPPINGS = ("metadata", "conversion_options", "pipeline", "style_map", "templates")
+# Job metadata worth reusing; title, code, dates and revision belong to one document
+_METADATA_KEYS = ("topic_depth", "map_type", "dialect", "language", "author", "copyright_holder", "image_naming",
+                  "exclude_style_map", "exclude_styles", "variables", "profiling", "preview_theme")
+_NAME = re.compile(r"^[\w][\w .-]*$")
+
+
+def profiles_dir() -> Path:
+    """Folder of the profile files (``profiles`` in the user configuration folder)."""
+    from orlando_toolkit.config.manager import _get_user_config_dir
+
+    return _get_user_config_dir() / "profiles"
+
+
+def _check_name(name: str) -> str:
+    name = str(name or "").strip()
+    if not _NAME.match(name):
+        raise ValueError(f"Invalid profile name {name!r} (letters, digits, spaces, '.', '-' and '_')")
+    return name
+
+
+def _validate(name: str, data: Mapping[str, Any]) -> Dict[str, Any]:
+    unknown = sorted(set(data) - set(_KEYS))
+    if unknown:
+        raise ValueError(f"Profile {name!r}: unknown key(s) {', '.join(unknown)}")
+    for key in _MAPPINGS:
+        if data.get(key) is not None and not isinstance(data[key], Mapping):
+            raise ValueError(f"Profile {name!r}: {key} must be a mapping")
+    from orlando_toolkit.core.style_map import parse_style_rule
+
+    for style, value in (data.get("style_map") or {}).items():
+        parse_style_rule(str(style), value)
+    return copy.deepcopy(dict(data))
+
+
+def read_profile_file(path: str | Path) -> Tuple[str, Dict[str, Any]]:
+    """``(name, settings)`` of a YAML or JSON profile file; raises ``ValueError`` when invalid.
+
+    The name is the file's ``name`` key, or the file name without suffix.
+    """
+    path = Path(path)
+    text = path.read_text(encoding="utf-8")
+    try:
+        if path.suffix.lower() == ".json":
+            data = json.loads(text)
+        else:
+            import yaml  # type: ignore
+
+            data = yaml.safe_load(text)
+    except Exception as exc:  # json.JSONDecodeError, yaml.YAMLError
+        raise ValueError(f"{path.name}: {exc}") from exc
+    if not isinstance(data, Mapping):
+        raise ValueError(f"{path.name}: expected a mapping of profile settings")
+    data = dict(data)
+    name = _check_name(data.pop("name", None) or path.stem)
+    return name, _validate(name, data)
+
+
+def _profile_files() -> List[Path]:
+    folder = profiles_dir()
+    if not folder.is_dir():
+        return []
+    return sorted(p for p in folder.iterdir() if p.is_file() and p.suffix.lower() in PROFILE_SUFFIXES)
+
+
+def available_profiles() -> Dict[str, Dict[str, Any]]:
+    """Settings of every profile by name: ``profiles.yml`` entries, then the profile files.
+
+    Unreadable files are logged and skipped.
+    """
+    try:
+        from orlando_toolkit.config import ConfigManager
+
+        profiles = {str(k): v for k, v in (ConfigManager().get_profiles_config() or {}).items()
+                    if isinstance(v, Mapping)}
+    except Exception as exc:
+        logger.debug("Profiles config unavailable: %s", exc)
+        profiles = {}
+    for path in _profile_files():
+        try:
+            name, data = read_profile_file(path)
+        except (OSError, ValueError) as exc:
+            logger.warning("Skipping profile file %s: %s", path, exc)
+            continue
+        profiles[name] = data
+    return profiles
+
+
+def profile_from_metadata(metadata: Optional[Mapping[str, Any]], description: str = "") -> Dict[str, Any]:
+    """The reusable settings of a conversion's metadata, as profile settings."""
+    from orlando_toolkit.options import ConversionOptions
+
+    options = ConversionOptions.from_metadata(metadata)
+    data: Dict[str, Any] = {}
+    if description:
+        data["description"] = str(description)
+    fields = {key: options.metadata[key] for key in _METADATA_KEYS if options.metadata.get(key) not in (None, "")}
+    for key, value in (("metadata", fields), ("conversion_options", options.conversion_options),
+                       ("pipeline", options.pipeline), ("style_map", options.style_map)):
+        if value:
+            data[key] = copy.deepcopy(value)
+    return data
+
+
+def _write(path: Path, name: str, data: Mapping[str, Any]) -> Path:
+    content = {"name": name, **data}
+    path.parent.mkdir(parents=True, exist_ok=True)
+    if path.suffix.lower() == ".json":
+        path.write_text(json.dumps(content, ensure_ascii=False, indent=2) + "\n", encoding="utf-8")
+    else:
+        import yaml  # type: ignore
+
+        path.write_text(yaml.safe_dump(content, default_flow_style=False, sort_keys=False, allow_unicode=True),
+                        encoding="utf-8")
+    return path
+
+
+def _saved_path(name: str) -> Optional[Path]:
+    for path in _profile_files():
+        try:
+            if read_profile_file(path)[0] == name:
+                return path
+        except (OSError, ValueError):
+            continue
+    return None
+
+
+def save_profile(name: str, data: Mapping[str, Any], *, overwrite: bool = True) -> Path:
+    """Write profile *name* to the profiles folder; returns the file path.
+
+    Raises ``FileExistsError`` when *name* is taken and *overwrite* is false.
+    """
+    name = _check_name(name)
+    data = _validate(name, data)
+    existing = _saved_path(name)
+    if existing is not None and not overwrite:
+        raise FileExistsError(f"A profile named {name!r} already exists")
+    path = existing or profiles_dir() / f"{name}.yml"
+    _write(path, name, data)
+    logger.info("Saved conversion profile %s: %s", name, path)
+    return path
+
+
+def delete_profile(name: str) -> bool:
+    """Remove the file of profile *name*; ``profiles.yml`` entries are left alone."""
+    path = _saved_path(name)
+    if path is None:
+        return False
+    path.unlink()
+    return True
+
+
+def export_profile(name: str, path: str | Path) -> Path:
+    """Write profile *name* as a self-contained YAML or JSON file (by suffix)."""
+    from orlando_toolkit.options import build_options, get_output_profile, with_output_profile
+
+    profile = get_output_profile(name)
+    options = build_options(with_output_profile(profile))
+    data: Dict[str, Any] = {}
+    if profile.description:
+        data["description"] = profile.description
+    for key in ("metadata", "conversion_options", "pipeline", "style_map"):
+        value = getattr(options, key)
+        if value:
+            data[key] = copy.deepcopy(value)
+    if data.get("conversion_options"):
+        from orlando_toolkit.core.xslt import inline_stylesheets
+
+        data["conversion_options"] = inline_stylesheets(data["conversion_options"])
+    templates = available_profiles().get(profile.name, {}).get("templates")
+    if templates:
+        data["templates"] = copy.deepcopy(templates)
+    return _write(Path(path), profile.name, data)
+
+
+def import_profile(path: str | Path, *, name: Optional[str] = None, overwrite: bool = False) -> str:
+    """Copy the profile file *path* into the profiles folder; returns its name.
+
+    A relative ``style_map_file`` is read from the file's folder and folded
+    into ``style_map``, relative XSLT stylesheet paths likewise into their
+    entries; ``extends`` must name known profiles.
+    """
+    path = Path(path)
+    file_name, data = read_profile_file(path)
+    name = _check_name(name or file_name)
+    extends = data.get("extends") or ()
+    extends = [extends] if isinstance(extends, str) else list(extends)
+    missing = [str(parent) for parent in extends if str(parent) not in available_profiles()]
+    if missing:
+        raise ValueError(f"Profile {name!r} extends unknown profile(s): {', '.join(missing)}")
+    style_map_file = data.pop("style_map_file", None)
+    if style_map_file:
+        from orlando_toolkit.core.style_map import load_style_map_file
+
+        source = Path(str(style_map_file)).expanduser()
+        source = source if source.is_absolute() else path.parent / source
+        data["style_map"] = {**load_style_map_file(source), **(data.get("style_map") or {})}
+    if isinstance(data.get("conversion_options"), dict):
+        from orlando_toolkit.core.xslt import inline_stylesheets
+
+        data["conversion_options"] = inline_stylesheets(data["conversion_options"], base=path.parent)
+    save_profile(name, data, overwrite=overwrite)
+    return name
@@ -0,0 +1,152 @@
+from __future__ import annotations
+
+"""DITAVAL files for the configurations of a profiled manual.
+
+One manual shipped in several configurations carries profiling attributes
+(``product="pro"``, ``audience="internal"``; set from Word highlights and
+styles by the ``conditional`` stage or by authors). A *configuration* names
+the values it keeps per attribute::
+
+    profiling:
+      configurations:
+        basic: {product: [basic]}
+        service: {product: [basic, pro], audience: [internal, user]}
+
+Configurations come from the ``profiling`` section of ``conversion.yml``
+(a conversion profile can set them) and from ``metadata["profiling"]``,
+which the Metadata tab edits; the metadata wins for a name it defines.
+When the package is written, :func:`write_profile_ditavals` adds
+``DATA/<configuration>.ditaval`` for each one: values of a listed attribute
+used in the content are included when kept and excluded otherwise;
+attributes a configuration does not list are not filtered. Publish with
+``--filter=<configuration>.ditaval``.
+"""
+
+import logging
+import re
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.processing.conditional import PROFILING_ATTRIBUTES
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "ProfilingConfiguration",
+    "build_profile_ditaval",
+    "format_selection",
+    "parse_selection",
+    "profiling_configurations",
+    "profiling_values",
+    "write_profile_ditavals",
+]
+
+_ORDER = ("audience", "platform", "product", "props", "otherprops", "deliveryTarget")
+_UNSAFE = re.compile(r"[^\w.-]+")
+
+
+@dataclass(frozen=True)
+class ProfilingConfiguration:
+    """Name and kept values per attribute of one deliverable."""
+
+    name: str
+    select: Mapping[str, Tuple[str, ...]] = field(default_factory=dict)
+
+    @classmethod
+    def from_mapping(cls, name: str, data: Any) -> "ProfilingConfiguration":
+        select: Dict[str, Tuple[str, ...]] = {}
+        for attribute, values in (data or {}).items() if isinstance(data, Mapping) else ():
+            if attribute not in PROFILING_ATTRIBUTES:
+                logger.warning("Configuration %s: %r is not a profiling attribute", name, attribute)
+                continue
+            if isinstance(values, str):
+                values = values.split()
+            select[attribute] = tuple(dict.fromkeys(str(v) for v in values or ()))
+        return cls(name=str(name), select=select)
+
+    @property
+    def file_name(self) -> str:
+        return (_UNSAFE.sub("_", self.name).strip("._") or "configuration") + ".ditaval"
+
+    def to_mapping(self) -> Dict[str, List[str]]:
+        return {attribute: list(values) for attribute, values in self.select.items()}
+
+
+def parse_selection(text: str) -> Dict[str, List[str]]:
+    """``"product=basic,pro; audience=user"`` as ``{attribute: [values]}``; raises ``ValueError``."""
+    selection: Dict[str, List[str]] = {}
+    for part in re.split(r"[;\n]", text or ""):
+        if not part.strip():
+            continue
+        attribute, sep, values = part.partition("=")
+        attribute = attribute.strip()
+        if not sep or attribute not in PROFILING_ATTRIBUTES:
+            raise ValueError(f"Expected attribute=value[,value] with one of {', '.join(_ORDER)}: {part.strip()!r}")
+        selection.setdefault(attribute, []).extend(v for v in re.split(r"[,\s]+", values) if v)
+    return selection
+
+
+def format_selection(select: Mapping[str, Any]) -> str:
+    """Inverse of :func:`parse_selection`."""
+    return "; ".join(f"{attribute}={','.join(values)}" for attribute, values in select.items())
+
+
+def profiling_configurations(metadata: Optional[Mapping[str, Any]] = None) -> List[ProfilingConfiguration]:
+    """Configurations of ``conversion.yml`` (and the job's options) overridden by ``metadata["profiling"]``."""
+    metadata = metadata or {}
+    merged: Dict[str, Any] = {}
+    try:
+        from orlando_toolkit.core.processing import resolve_conversion_options
+        merged.update((resolve_conversion_options(metadata).get("profiling") or {}).get("configurations") or {})
+    except Exception as exc:
+        logger.debug("Profiling options unavailable: %s", exc)
+    own = metadata.get("profiling")
+    if isinstance(own, Mapping):
+        merged.update(own.get("configurations") or {})
+    return [ProfilingConfiguration.from_mapping(name, data) for name, data in merged.items() if data is not None]
+
+
+def profiling_values(context: DitaContext) -> Dict[str, List[str]]:
+    """Profiling attribute -> values used in the map and topics, sorted."""
+    found: Dict[str, set] = {}
+    roots = list(context.topics.values())
+    if context.ditamap_root is not None:
+        roots.append(context.ditamap_root)
+    for root in roots:
+        for el in root.iter():
+            if not isinstance(el.tag, str):
+                continue
+            for attribute in PROFILING_ATTRIBUTES.intersection(el.attrib):
+                found.setdefault(attribute, set()).update((el.get(attribute) or "").split())
+    return {a: sorted(found[a]) for a in sorted(found, key=_ORDER.index) if found[a]}
+
+
+def build_profile_ditaval(configuration: ProfilingConfiguration, used: Mapping[str, List[str]]) -> ET._Element:
+    """DITAVAL keeping the values *configuration* selects among those *used*."""
+    root = ET.Element("val")
+    for attribute, kept in configuration.select.items():
+        for value in dict.fromkeys([*kept, *used.get(attribute, ())]):
+            ET.SubElement(root, "prop", att=attribute, val=value, action="include" if value in kept else "exclude")
+    return root
+
+
+def write_profile_ditavals(context: DitaContext, data_dir: str | Path) -> List[Path]:
+    """Write one ``<configuration>.ditaval`` next to the map per configuration."""
+    configurations = profiling_configurations(context.metadata)
+    if not configurations:
+        return []
+    used = profiling_values(context)
+    paths: List[Path] = []
+    for configuration in configurations:
+        path = Path(data_dir) / configuration.file_name
+        if path in paths:
+            logger.warning("Configurations share the file name %s; keeping the first", path.name)
+            continue
+        root = build_profile_ditaval(configuration, used)
+        path.write_bytes(ET.tostring(root, pretty_print=True, xml_declaration=True, encoding="UTF-8"))
+        paths.append(path)
+    return paths
@@ -0,0 +1,76 @@
+from __future__ import annotations
+
+"""Structured progress events for long conversions.
+
+Progress callbacks throughout the toolkit receive a message string. A
+:class:`ProgressEvent` *is* such a string, so existing callbacks keep working
+unchanged, and it also tells which pipeline phase it belongs to and how far
+that phase has got (``done`` of ``total``). Phases run in :data:`PHASES`
+order; :func:`overall_fraction` turns an event into a rough 0–1 estimate of
+the whole job for progress bars.
+"""
+
+from typing import Any, Callable, Dict, Optional
+
+__all__ = ["PHASES", "ProgressEvent", "as_event", "emit", "overall_fraction"]
+
+#: Pipeline phases in execution order: reading the source, the processing
+#: stages, package preparation, writing the package folder, zipping it.
+PHASES = ("parse", "process", "prepare", "write", "archive")
+
+# Share of a typical job spent in each phase, for overall_fraction
+_WEIGHTS = {"parse": 0.35, "process": 0.25, "prepare": 0.1, "write": 0.25, "archive": 0.05}
+
+
+class ProgressEvent(str):
+    """Progress message carrying its phase, step name and ``done``/``total`` counts."""
+
+    phase: str
+    step: Optional[str]
+    done: int
+    total: int
+
+    def __new__(cls, message: str, phase: str = "parse", *, step: Optional[str] = None,
+                done: int = 0, total: int = 0) -> "ProgressEvent":
+        event = super().__new__(cls, message)
+        event.phase = phase if phase in PHASES else "parse"
+        event.step = step
+        event.done = max(0, int(done))
+        event.total = max(0, int(total))
+        return event
+
+    @property
+    def fraction(self) -> Optional[float]:
+        """Completed share of the phase, or ``None`` when the phase has no count."""
+        if not self.total:
+            return None
+        return min(1.0, self.done / self.total)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {"message": str(self), "phase": self.phase, "step": self.step,
+                "done": self.done, "total": self.total}
+
+
+def as_event(message: Any, phase: str = "parse") -> ProgressEvent:
+    """Return *message* as a :class:`ProgressEvent`; plain strings are put in *phase*."""
+    if isinstance(message, ProgressEvent):
+        return message
+    return ProgressEvent(str(message), phase)
+
+
+def emit(callback: Optional[Callable[[str], None]], message: str, phase: str, *,
+         step: Optional[str] = None, done: int = 0, total: int = 0) -> None:
+    """Send a :class:`ProgressEvent` to *callback* when there is one."""
+    if callback is not None:
+        callback(ProgressEvent(message, phase, step=step, done=done, total=total))
+
+
+def overall_fraction(event: Any) -> float:
+    """Estimate how much of the whole job is done when *event* is emitted."""
+    event = as_event(event)
+    before = 0.0
+    for phase in PHASES:
+        if phase == event.phase:
+            break
+        before += _WEIGHTS[phase]
+    return min(1.0, before + _WEIGHTS[event.phase] * (event.fraction or 0.0))
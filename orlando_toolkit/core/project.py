@@ -0,0 +1,273 @@
+from __future__ import annotations
+
+"""Project files: save and reopen a working session.
+
+A project (``.otkproj``) captures a half-finished restructuring so it can be
+reopened later or handed to a colleague:
+
+- the source document (path, name, size, SHA-256), checked on reopen;
+- the working structure exactly as edited: map, topics, images, videos and
+  attachments, plus the pre-merge original kept for reversible depth
+  filtering;
+- the job metadata, i.e. manual overrides (title, code, depth, per-branch
+  split depths in ``depth_overrides``, exclusions) and conversion settings
+  (``conversion_options``, ``pipeline``, ``style_map``, ``output_profiles``)
+  and the spelling ignore list (``spelling_ignore``);
+- the conversion report and an optional :class:`EditJournal` of structure
+  edits, which can be replayed on a fresh conversion when the source changed.
+
+The file is a ZIP archive with ``project.json`` and the XML and media parts.
+It is not a DITA package; generate one from the reopened session as usual.
+Reopening checks the archive limits and parses XML through
+:mod:`orlando_toolkit.core.xml_security`.
+"""
+
+import hashlib
+import json
+import logging
+import os
+import tempfile
+import zipfile
+from dataclasses import dataclass, field
+from datetime import datetime, timezone
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.archive_limits import check_archive
+from orlando_toolkit.core.errors import SourceLocation, ToolkitError
+from orlando_toolkit.core.history import get_history_store
+from orlando_toolkit.core.models import ConversionReport, DitaContext
+from orlando_toolkit.core.models.edit_journal import EditJournal
+from orlando_toolkit.core.xml_security import parse_bytes
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "PROJECT_EXTENSION",
+    "Project",
+    "ProjectError",
+    "SourceInfo",
+    "load_project",
+    "save_project",
+]
+
+PROJECT_EXTENSION = ".otkproj"
+_FORMAT = "orlando-project"
+_VERSION = 1
+_MANIFEST = "project.json"
+
+
+class ProjectError(ToolkitError, ValueError):
+    """Raised when a project file cannot be written or read."""
+
+    code = "OTK103"
+
+
+@dataclass(frozen=True)
+class SourceInfo:
+    path: Optional[str] = None
+    name: Optional[str] = None
+    size: Optional[int] = None
+    sha256: Optional[str] = None
+
+    @classmethod
+    def of(cls, path: Optional[str | Path]) -> "SourceInfo":
+        if not path:
+            return cls()
+        path = Path(path)
+        if not path.is_file():
+            return cls(path=str(path), name=path.name)
+        digest = hashlib.sha256()
+        with open(path, "rb") as fh:
+            for chunk in iter(lambda: fh.read(1 << 20), b""):
+                digest.update(chunk)
+        return cls(path=str(path.resolve()), name=path.name, size=path.stat().st_size, sha256=digest.hexdigest())
+
+    def status(self) -> str:
+        """``unchanged``, ``modified``, ``missing`` or ``unknown`` (no source recorded)."""
+        if not self.path:
+            return "unknown"
+        current = SourceInfo.of(self.path)
+        if current.sha256 is None:
+            return "missing"
+        return "unchanged" if current.sha256 == self.sha256 else "modified"
+
+
+@dataclass
+class Project:
+    """A reopened session: the working context and what produced it."""
+
+    context: DitaContext
+    source: SourceInfo = field(default_factory=SourceInfo)
+    journal: EditJournal = field(default_factory=EditJournal)
+    saved_at: Optional[str] = None
+    toolkit_version: Optional[str] = None
+    path: Optional[Path] = None
+
+    @property
+    def source_status(self) -> str:
+        return self.source.status()
+
+    @property
+    def profiles(self) -> List[str]:
+        return list(self.context.metadata.get("output_profiles") or [])
+
+
+def _json_safe(mapping: Mapping[str, Any], what: str) -> Dict[str, Any]:
+    safe: Dict[str, Any] = {}
+    for key, value in mapping.items():
+        try:
+            json.dumps(value)
+        except (TypeError, ValueError):
+            logger.debug("Project: not saving %s[%r] (not JSON serializable)", what, key)
+            continue
+        safe[key] = value
+    return safe
+
+
+def _write_parts(archive: zipfile.ZipFile, prefix: str, ditamap_root: Optional[ET._Element],
+                 topics: Mapping[str, ET._Element], images: Mapping[str, bytes],
+                 videos: Mapping[str, bytes], attachments: Optional[Mapping[str, bytes]] = None
+                 ) -> Dict[str, List[str]]:
+    if ditamap_root is not None:
+        archive.writestr(f"{prefix}/map.ditamap", ET.tostring(ditamap_root, encoding="UTF-8", xml_declaration=True))
+    # Names are kept in a list so they round-trip even when not valid archive paths
+    index: Dict[str, List[str]] = {"topics": [], "images": [], "videos": []}
+    parts = [("topics", topics), ("images", images), ("videos", videos)]
+    if attachments:
+        index["attachments"] = []
+        parts.append(("attachments", attachments))
+    for kind, items in parts:
+        for number, (name, value) in enumerate(items.items()):
+            data = ET.tostring(value, encoding="UTF-8", xml_declaration=True) if kind == "topics" else value
+            archive.writestr(f"{prefix}/{kind}/{number}", data)
+            index[kind].append(name)
+    return index
+
+
+def _read_parts(archive: zipfile.ZipFile, prefix: str, index: Mapping[str, List[str]],
+                source: str) -> Dict[str, Any]:
+    names = set(archive.namelist())
+    map_name = f"{prefix}/map.ditamap"
+    parts: Dict[str, Any] = {
+        "ditamap_root": parse_bytes(archive.read(map_name), source=f"{source}!{map_name}") if map_name in names else None,
+        "topics": {}, "images": {}, "videos": {},
+    }
+    if index.get("attachments"):
+        parts["attachments"] = {}
+    for kind in [k for k in ("topics", "images", "videos", "attachments") if k in parts]:
+        for number, name in enumerate(index.get(kind) or []):
+            member = f"{prefix}/{kind}/{number}"
+            if member not in names:
+                raise ProjectError(f"{source}: missing {kind[:-1]} {name!r}",
+                                   location=SourceLocation(file=source, part=member))
+            data = archive.read(member)
+            parts[kind][name] = parse_bytes(data, source=f"{source}!{member}") if kind == "topics" else data
+    return parts
+
+
+def save_project(context: DitaContext, path: str | Path, *, source: Optional[str | Path] = None,
+                 journal: Optional[EditJournal] = None, record: bool = True) -> Path:
+    """Write *context* as a project file at *path*.
+
+    *source* defaults to ``metadata["source_file"]``. The file is written to a
+    temporary name first, so an interrupted save never leaves a damaged
+    project behind. Internal snapshots pass ``record=False`` to stay out of
+    the conversion history.
+    """
+    from orlando_toolkit.version import get_app_version
+
+    path = Path(path)
+    if not path.suffix:
+        path = path.with_suffix(PROJECT_EXTENSION)
+    metadata = dict(context.metadata)
+    original = metadata.pop("original_structure", None)
+    source_info = SourceInfo.of(source or metadata.get("source_file"))
+    manifest: Dict[str, Any] = {
+        "format": _FORMAT,
+        "version": _VERSION,
+        "saved_at": datetime.now(timezone.utc).isoformat(timespec="seconds"),
+        "toolkit_version": get_app_version(),
+        "source": {k: v for k, v in vars(source_info).items() if v is not None},
+        "metadata": _json_safe(metadata, "metadata"),
+        "plugin_data": _json_safe(getattr(context, "plugin_data", None) or {}, "plugin_data"),
+        "report": context.report.to_dict() if getattr(context, "report", None) is not None else None,
+        "journal": (journal or EditJournal()).serialize(),
+    }
+
+    path.parent.mkdir(parents=True, exist_ok=True)
+    fd, tmp_name = tempfile.mkstemp(prefix=".otk_", suffix=PROJECT_EXTENSION, dir=str(path.parent))
+    os.close(fd)
+    try:
+        with zipfile.ZipFile(tmp_name, "w", zipfile.ZIP_DEFLATED) as archive:
+            manifest["context"] = _write_parts(archive, "context", context.ditamap_root, context.topics,
+                                               context.images, context.videos,
+                                               getattr(context, "attachments", None))
+            if isinstance(original, Mapping):
+                manifest["original"] = _write_parts(archive, "original", original.get("ditamap_root"),
+                                                    original.get("topics") or {}, original.get("images") or {},
+                                                    original.get("videos") or {})
+                manifest["original"]["metadata_snapshot"] = _json_safe(
+                    original.get("metadata_snapshot") or {}, "original metadata")
+            archive.writestr(_MANIFEST, json.dumps(manifest, ensure_ascii=False, indent=2))
+        os.replace(tmp_name, path)
+    except Exception as exc:
+        try:
+            os.unlink(tmp_name)
+        except OSError:
+            pass
+        if isinstance(exc, ProjectError):
+            raise
+        raise ProjectError(f"Could not save project {path.name}: {exc}", cause=exc) from exc
+    logger.info("Project saved: %s (%d topics)", path, len(context.topics))
+    if not record:
+        return path
+    get_history_store().record("project", source=manifest["source"], target=path, context=context,
+                               stats={"action": "save"})
+    return path
+
+
+def load_project(path: str | Path, *, record: bool = True) -> Project:
+    """Read the project file at *path*; raises :class:`ProjectError`."""
+    path = Path(path)
+    where = SourceLocation(file=path.name)
+    try:
+        check_archive(path)
+        with zipfile.ZipFile(path) as archive:
+            try:
+                manifest = json.loads(archive.read(_MANIFEST).decode("utf-8"))
+            except KeyError:
+                raise ProjectError(f"{path.name} is not an Orlando Toolkit project", location=where)
+            if manifest.get("format") != _FORMAT:
+                raise ProjectError(f"{path.name} is not an Orlando Toolkit project", location=where)
+            if int(manifest.get("version", 0)) > _VERSION:
+                raise ProjectError(
+                    f"{path.name} was saved by a newer version of the toolkit (format {manifest.get('version')})",
+                    location=where, hint="Update Orlando Toolkit to open this project.")
+            parts = _read_parts(archive, "context", manifest.get("context") or {}, path.name)
+            original = None
+            if manifest.get("original"):
+                original = _read_parts(archive, "original", manifest["original"], path.name)
+                original["metadata_snapshot"] = dict(manifest["original"].get("metadata_snapshot") or {})
+    except ProjectError:
+        raise
+    except Exception as exc:
+        raise ProjectError(f"Could not open project {path.name}: {exc}", location=where, cause=exc) from exc
+
+    context = DitaContext(**parts, metadata=dict(manifest.get("metadata") or {}),
+                          plugin_data=dict(manifest.get("plugin_data") or {}))
+    if manifest.get("report"):
+        context.report = ConversionReport.from_dict(manifest["report"])
+    if original is not None:
+        context.metadata["original_structure"] = original
+    source = SourceInfo(**{k: manifest.get("source", {}).get(k) for k in ("path", "name", "size", "sha256")})
+    project = Project(context=context, source=source, journal=EditJournal.deserialize(manifest.get("journal") or []),
+                      saved_at=manifest.get("saved_at"), toolkit_version=manifest.get("toolkit_version"), path=path)
+    logger.info("Project opened: %s (%d topics, source %s)", path, len(context.topics), project.source_status)
+    if not record:
+        return project
+    get_history_store().record("project", source=manifest.get("source"), target=path.resolve(), context=context,
+                               stats={"action": "open"})
+    return project
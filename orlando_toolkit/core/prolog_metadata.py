@@ -0,0 +1,276 @@
+from __future__ import annotations
+
+"""Prolog metadata entered for the whole manual or for a branch of the map.
+
+The Metadata tab and the Structure tab's *Metadata…* entry edit a
+:class:`PrologMetadata`: author, critical dates (created, revised),
+audience, product and version, permissions and custom ``othermeta`` rows.
+
+- Values for the whole manual are stored in ``metadata["prolog"]``
+  (:data:`METADATA_KEY`); :func:`apply_prolog_metadata` writes them into the
+  map's ``topicmeta`` (``bookmeta`` of a bookmap) and the prolog of every
+  topic.
+- Values for a branch are stored in the ``topicmeta`` of its map entry
+  (:func:`set_entry_metadata`), where DITA cascades them, and are written
+  into the prolog of every topic of the branch. A branch overrides the
+  manual, an inner branch an outer one.
+
+Both are written while the package is prepared, after the topic templates
+(:mod:`orlando_toolkit.core.topic_templates`), whose elements they replace;
+``othermeta`` rows replace those of the same name. :meth:`PrologMetadata.errors`
+checks the values before they are stored: ISO dates (``2024``,
+``2024-05`` or ``2024-05-31``) with the revision not before the creation, a
+known ``permissions`` view, named and unique ``othermeta`` rows with a
+value. Stored values that fail (an edited project file) are left out and
+reported under ``prolog_metadata``.
+"""
+
+import logging
+import re
+from dataclasses import dataclass, field
+from datetime import date
+from typing import Any, Dict, List, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.topic_templates import _PROLOG_ORDER, _merge, _topic_prolog
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["AUDIENCE_TYPES", "METADATA_KEY", "PERMISSION_VIEWS", "PrologMetadata", "apply_prolog_metadata",
+           "entry_metadata", "set_entry_metadata"]
+
+METADATA_KEY = "prolog"
+#: ``audience/@type`` values of DITA; other audiences are written as ``type="other"``
+AUDIENCE_TYPES = ("user", "purchaser", "administrator", "programmer", "executive", "services", "other")
+#: ``permissions/@view`` values of DITA
+PERMISSION_VIEWS = ("internal", "classified", "all", "entitled")
+_DATE = re.compile(r"^(\d{4})(?:-(\d{2})(?:-(\d{2}))?)?$")
+_TOPICMETA_ORDER = ("navtitle", "linktext", "searchtitle", "shortdesc") + _PROLOG_ORDER
+_FIELDS = ("author", "created", "revised", "audience", "product", "version", "permissions")
+# Elements of a prolog or topicmeta the editor owns
+_OWNED = ("author", "critdates", "permissions")
+_OWNED_METADATA = ("audience", "prodinfo", "othermeta")
+
+
+def _clean(value: Any) -> str:
+    return " ".join(str(value or "").split())
+
+
+def _parse_date(value: str) -> Optional[Tuple[int, int, int]]:
+    match = _DATE.match(value)
+    if not match:
+        return None
+    year, month, day = (int(part) if part else 1 for part in match.groups())
+    try:
+        date(year, month, day)
+    except ValueError:
+        return None
+    return year, month, day
+
+
+@dataclass
+class PrologMetadata:
+    author: str = ""
+    created: str = ""
+    revised: str = ""
+    audience: str = ""
+    product: str = ""
+    version: str = ""
+    permissions: str = ""
+    othermeta: List[Tuple[str, str]] = field(default_factory=list)
+
+    def __bool__(self) -> bool:
+        return any(getattr(self, name) for name in _FIELDS) or bool(self.othermeta)
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "PrologMetadata":
+        data = data if isinstance(data, Mapping) else {}
+        rows = []
+        for row in data.get("othermeta") or ():
+            if isinstance(row, Mapping):
+                rows.append((_clean(row.get("name")), _clean(row.get("content"))))
+            elif isinstance(row, (list, tuple)) and len(row) == 2:
+                rows.append((_clean(row[0]), _clean(row[1])))
+        return cls(**{name: _clean(data.get(name)) for name in _FIELDS}, othermeta=rows)
+
+    def to_mapping(self) -> Dict[str, Any]:
+        data: Dict[str, Any] = {name: getattr(self, name) for name in _FIELDS if getattr(self, name)}
+        if self.othermeta:
+            data["othermeta"] = [{"name": name, "content": content} for name, content in self.othermeta]
+        return data
+
+    @classmethod
+    def from_element(cls, holder: Any) -> "PrologMetadata":
+        """Values found in a ``prolog``, ``topicmeta`` or ``bookmeta`` element."""
+        values = cls()
+        if holder is None:
+            return values
+        author = holder.find("author")
+        values.author = _clean("".join(author.itertext())) if author is not None else ""
+        created = holder.find("critdates/created")
+        revised = holder.findall("critdates/revised")
+        values.created = _clean(created.get("date")) if created is not None else ""
+        values.revised = _clean(revised[-1].get("modified")) if revised else ""
+        permissions = holder.find("permissions")
+        values.permissions = _clean(permissions.get("view")) if permissions is not None else ""
+        scopes = [holder] + holder.findall("metadata")
+        audience = next((a for s in scopes for a in s.findall("audience")), None)
+        if audience is not None:
+            values.audience = _clean(audience.get("othertype") if audience.get("type") == "other"
+                                     and audience.get("othertype") else audience.get("type"))
+        prodinfo = next((p for s in scopes for p in s.findall("prodinfo")), None)
+        if prodinfo is not None:
+            values.product = _clean(prodinfo.findtext("prodname"))
+            vrm = prodinfo.find("vrmlist/vrm")
+            values.version = _clean(vrm.get("version")) if vrm is not None else ""
+        values.othermeta = [(_clean(m.get("name")), _clean(m.get("content")))
+                            for s in scopes for m in s.findall("othermeta") if m.get("name")]
+        return values
+
+    def errors(self) -> List[str]:
+        """Problems that keep the values from being written; empty when they are valid."""
+        problems: List[str] = []
+        dates = {}
+        for name, label in (("created", "Created"), ("revised", "Revised")):
+            value = getattr(self, name)
+            if value:
+                dates[name] = _parse_date(value)
+                if dates[name] is None:
+                    problems.append(f"{label} must be a date such as 2024-05-31")
+        if dates.get("created") and dates.get("revised") and dates["revised"] < dates["created"]:
+            problems.append("Revised cannot be before Created")
+        if self.revised and not self.created:
+            problems.append("Enter the creation date before a revision date")
+        if self.permissions and self.permissions not in PERMISSION_VIEWS:
+            problems.append(f"Permissions must be one of {', '.join(PERMISSION_VIEWS)}")
+        if self.version and not self.product:
+            problems.append("Enter the product the version belongs to")
+        seen = set()
+        for name, content in self.othermeta:
+            if not name:
+                problems.append("Every metadata row needs a name")
+            elif name in seen:
+                problems.append(f"Metadata row '{name}' is entered twice")
+            elif not content:
+                problems.append(f"Metadata row '{name}' needs a value")
+            seen.add(name)
+        return problems
+
+    def to_prolog(self) -> Any:
+        """The values as a DITA ``<prolog>`` (empty values left out)."""
+        prolog = ET.Element("prolog")
+        if self.author:
+            ET.SubElement(prolog, "author").text = self.author
+        if self.created:
+            critdates = ET.SubElement(prolog, "critdates")
+            ET.SubElement(critdates, "created", date=self.created)
+            if self.revised:
+                ET.SubElement(critdates, "revised", modified=self.revised)
+        if self.permissions:
+            ET.SubElement(prolog, "permissions", view=self.permissions)
+        metadata = ET.Element("metadata")
+        if self.audience:
+            if self.audience in AUDIENCE_TYPES:
+                ET.SubElement(metadata, "audience", type=self.audience)
+            else:
+                ET.SubElement(metadata, "audience", type="other", othertype=self.audience)
+        if self.product:
+            prodinfo = ET.SubElement(metadata, "prodinfo")
+            ET.SubElement(prodinfo, "prodname").text = self.product
+            # vrmlist is required in prodinfo, vrm/@version in vrm
+            ET.SubElement(ET.SubElement(prodinfo, "vrmlist"), "vrm", version=self.version)
+        for name, content in self.othermeta:
+            ET.SubElement(metadata, "othermeta", name=name, content=content)
+        if len(metadata):
+            prolog.append(metadata)
+        return prolog
+
+
+def _clear(holder: Any) -> None:
+    """Remove what the editor owns from a ``topicmeta``, keeping titles and other metadata."""
+    for scope in [holder] + holder.findall("metadata"):
+        for child in list(scope):
+            if isinstance(child.tag, str) and (child.tag in _OWNED_METADATA or scope is holder and child.tag in _OWNED):
+                scope.remove(child)
+        if scope is not holder and not len(scope):
+            holder.remove(scope)
+
+
+def entry_metadata(entry: Any) -> PrologMetadata:
+    """Values stored on a map entry (``topicref`` or ``topichead``)."""
+    return PrologMetadata.from_element(entry.find("topicmeta") if entry is not None else None)
+
+
+def set_entry_metadata(entry: Any, values: PrologMetadata) -> None:
+    """Store *values* in the ``topicmeta`` of a map entry (replacing what it had)."""
+    topicmeta = entry.find("topicmeta")
+    if topicmeta is None:
+        if not values:
+            return
+        topicmeta = ET.Element("topicmeta")
+        entry.insert(0, topicmeta)
+    _clear(topicmeta)
+    _merge(topicmeta, values.to_prolog(), _TOPICMETA_ORDER, True)
+    if not len(topicmeta) and not (topicmeta.text or "").strip():
+        entry.remove(topicmeta)
+
+
+def _map_topicmeta(root: Any) -> Any:
+    topicmeta = root.find("topicmeta")
+    if topicmeta is None:
+        topicmeta = ET.Element("topicmeta")
+        root.insert(len(root.findall("title")), topicmeta)
+    return topicmeta
+
+
+def _valid(values: PrologMetadata, where: str, report: Any) -> bool:
+    problems = values.errors()
+    if problems and report is not None:
+        report.warning("prolog_metadata", f"Metadata of {where} left out: {'; '.join(problems)}",
+                       problems=problems,
+                       hint="Correct them in the Metadata tab or with Metadata… in the Structure tab")
+    return not problems
+
+
+def apply_prolog_metadata(context: Any) -> int:
+    """Write the manual's and branches' metadata into the map and the topics; returns the topics changed."""
+    root = getattr(context, "ditamap_root", None)
+    report = getattr(context, "report", None)
+    document = PrologMetadata.from_mapping((context.metadata or {}).get(METADATA_KEY))
+    if document and not _valid(document, "the manual", report):
+        document = PrologMetadata()
+    if document and root is not None:
+        topicmeta = _map_topicmeta(root)
+        _clear(topicmeta)
+        _merge(topicmeta, document.to_prolog(), _TOPICMETA_ORDER, True)
+
+    layers: Dict[str, List[Any]] = {}
+    if root is not None:
+        def _walk(node: Any, inherited: List[Any]) -> None:
+            for entry in node:
+                if not isinstance(entry.tag, str) or entry.tag not in ("topicref", "topichead"):
+                    continue
+                own = entry_metadata(entry)
+                label = entry.findtext("topicmeta/navtitle") or entry.get("href") or "a map entry"
+                stack = inherited + [own.to_prolog()] if own and _valid(own, f"'{label}'", report) else inherited
+                name = (entry.get("href") or "").split("#")[0].rsplit("/", 1)[-1]
+                if name in context.topics and stack:
+                    layers.setdefault(name, stack)
+                _walk(entry, stack)
+
+        _walk(root, [])
+
+    base = [document.to_prolog()] if document else []
+    changed = 0
+    for name, topic in context.topics.items():
+        prologs = base + layers.get(name, [])
+        if not prologs:
+            continue
+        prolog = _topic_prolog(topic)
+        for values in prologs:
+            _merge(prolog, values, _PROLOG_ORDER, True)
+        changed += 1
+    if changed:
+        logger.info("Prolog metadata written to %d topic(s)", changed)
+    return changed
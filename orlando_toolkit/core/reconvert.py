@@ -0,0 +1,320 @@
+from __future__ import annotations
+
+"""Incremental re-conversion of a changed source document.
+
+When the author changes one chapter, converting again from scratch loses
+the structure edits made since. Instead, :func:`reconvert` merges a fresh
+conversion of the changed document into the edited context:
+
+- a conversion records the heading outline of its topics (title path,
+  title and a fingerprint of the content, ids and ``data-*`` hints left out)
+  in ``metadata["source_outline"]`` (:func:`record_source_outline`); it is
+  saved with projects;
+- the outline is matched against the fresh conversion by heading path, then
+  by content for renamed headings. Only unique matches are used: headings
+  that appear twice with the same path and content are *ambiguous* and left
+  alone;
+- matched topics whose content changed get the new content; their title too,
+  unless the topic was renamed in the editor. Topics the editor moved keep
+  their place, and unchanged topics are not touched at all;
+- new headings are inserted after the topic that precedes them in the new
+  document (or as first child of their parent), headings removed from the
+  document are deleted (their subtopics take their place);
+- changes to topics that were merged away (depth limit, manual merge) or
+  deleted in the editor cannot be placed and are reported as skipped.
+
+Depth and style settings are metadata of the edited context and are kept;
+they apply to new topics when the package is prepared.
+"""
+
+import hashlib
+import logging
+from copy import deepcopy
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["ReconvertResult", "reconversion_metadata", "reconvert", "record_source_outline", "source_outline"]
+
+OUTLINE_KEY = "source_outline"
+_STRUCTURAL = ("topicref", "topichead")
+# Session state that must not be passed to the fresh conversion
+_SESSION_KEYS = ("original_structure", "merged_depth", "merged_exclude_styles", "merged_exclude", OUTLINE_KEY)
+
+
+@dataclass
+class ReconvertResult:
+    """What :func:`reconvert` did, by topic file name of the edited context."""
+
+    updated: List[str] = field(default_factory=list)
+    added: List[str] = field(default_factory=list)
+    removed: List[str] = field(default_factory=list)
+    skipped: List[str] = field(default_factory=list)
+    unchanged: int = 0
+
+    @property
+    def changed(self) -> bool:
+        return bool(self.updated or self.added or self.removed)
+
+    def summary(self) -> str:
+        parts = [f"{len(self.updated)} updated", f"{len(self.added)} added", f"{len(self.removed)} removed",
+                 f"{self.unchanged} unchanged"]
+        if self.skipped:
+            parts.append(f"{len(self.skipped)} skipped")
+        return ", ".join(parts)
+
+
+def _text(el: Optional[ET.Element]) -> str:
+    return " ".join("".join(el.itertext()).split()) if el is not None else ""
+
+
+def _canonical(el: ET.Element, parts: List[str]) -> None:
+    attrs = sorted((k, v) for k, v in el.attrib.items() if k != "id" and not k.startswith("data-"))
+    parts.append(f"<{el.tag} {attrs!r}>")
+    parts.append(" ".join((el.text or "").split()))
+    for child in el:
+        if isinstance(child.tag, str):
+            _canonical(child, parts)
+        parts.append(" ".join((child.tail or "").split()))
+    parts.append("</>")
+
+
+def _fingerprint(topic: ET.Element) -> str:
+    parts: List[str] = []
+    for child in topic:
+        if isinstance(child.tag, str) and child.tag != "title":
+            _canonical(child, parts)
+    return hashlib.sha1("\x1f".join(parts).encode("utf-8")).hexdigest()
+
+
+def _filename(node: ET.Element) -> str:
+    return (node.get("href") or "").split("#")[0].split("/")[-1]
+
+
+def source_outline(context: DitaContext) -> List[Dict[str, Any]]:
+    """``{"file", "path", "title", "fingerprint"}`` for every topic of the map, in map order."""
+    outline: List[Dict[str, Any]] = []
+
+    def _walk(node: ET.Element, path: Tuple[str, ...]) -> None:
+        for child in node:
+            if getattr(child, "tag", None) not in _STRUCTURAL:
+                continue
+            name = _filename(child)
+            topic = context.topics.get(name) if name else None
+            title = _text(topic.find("title")) if topic is not None else _text(child.find("topicmeta/navtitle"))
+            child_path = path + (title,)
+            if topic is not None:
+                outline.append({"file": name, "path": list(child_path), "title": title,
+                                "fingerprint": _fingerprint(topic)})
+            _walk(child, child_path)
+
+    if getattr(context, "ditamap_root", None) is not None:
+        _walk(context.ditamap_root, ())
+    return outline
+
+
+def record_source_outline(context: DitaContext) -> None:
+    """Note the outline of a fresh conversion so it can be re-converted incrementally later."""
+    context.metadata[OUTLINE_KEY] = source_outline(context)
+
+
+def reconversion_metadata(context: DitaContext) -> Dict[str, Any]:
+    """Metadata for converting the changed source with the settings of *context*."""
+    return {k: deepcopy(v) for k, v in context.metadata.items() if k not in _SESSION_KEYS}
+
+
+def _key(entry: Dict[str, Any], key: str) -> Any:
+    value = entry[key]
+    return tuple(value) if isinstance(value, list) else value
+
+
+def _unique(entries: List[Dict[str, Any]], key: str) -> Dict[Any, Dict[str, Any]]:
+    counts: Dict[Any, int] = {}
+    for entry in entries:
+        counts[_key(entry, key)] = counts.get(_key(entry, key), 0) + 1
+    return {_key(e, key): e for e in entries if counts[_key(e, key)] == 1}
+
+
+def _match(base: List[Dict[str, Any]], fresh: List[Dict[str, Any]]) -> Dict[str, Dict[str, Any]]:
+    """Base file -> fresh entry, by unique heading path, then by unique content."""
+    matches: Dict[str, Dict[str, Any]] = {}
+    for key in ("path", "fingerprint"):
+        free_base = [e for e in base if e["file"] not in matches]
+        taken = {id(e) for e in matches.values()}
+        free_fresh = [e for e in fresh if id(e) not in taken]
+        fresh_by_key = _unique(free_fresh, key)
+        for value, entry in _unique(free_base, key).items():
+            if value in fresh_by_key:
+                matches[entry["file"]] = fresh_by_key[value]
+    return matches
+
+
+def _refs(context: DitaContext, name: str) -> List[ET.Element]:
+    root = context.ditamap_root
+    return [n for n in root.iter("topicref") if _filename(n) == name] if root is not None else []
+
+
+def _update_topic(edited: DitaContext, name: str, base: Dict[str, Any], fresh_topic: ET.Element) -> None:
+    topic = edited.topics[name]
+    title = topic.find("title")
+    renamed = title is not None and _text(title) != base["title"]
+    for child in list(topic):
+        if not (isinstance(child.tag, str) and child.tag == "title" and renamed):
+            topic.remove(child)
+    position = 1 if renamed else 0
+    for child in fresh_topic:
+        if renamed and isinstance(child.tag, str) and child.tag == "title":
+            continue
+        topic.insert(position, deepcopy(child))
+        position += 1
+    if not renamed:
+        new_title = _text(topic.find("title"))
+        for ref in _refs(edited, name):
+            navtitle = ref.find("topicmeta/navtitle")
+            if navtitle is not None:
+                for child in list(navtitle):
+                    navtitle.remove(child)
+                navtitle.text = new_title
+
+
+def _remove_topic(edited: DitaContext, name: str) -> None:
+    for ref in _refs(edited, name):
+        parent = ref.getparent()
+        index = list(parent).index(ref)
+        for child in [c for c in ref if getattr(c, "tag", None) in _STRUCTURAL]:
+            index += 1
+            parent.insert(index, child)
+        parent.remove(ref)
+    edited.topics.pop(name, None)
+
+
+def _free_name(edited: DitaContext, name: str) -> str:
+    if name not in edited.topics:
+        return name
+    stem, suffix = Path(name).stem, Path(name).suffix
+    number = 2
+    while f"{stem}_{number}{suffix}" in edited.topics:
+        number += 1
+    return f"{stem}_{number}{suffix}"
+
+
+def _insert_topic(edited: DitaContext, fresh: DitaContext, fresh_ref: ET.Element, name: str,
+                  placed: Dict[str, str]) -> None:
+    """Add the fresh topic of *fresh_ref* as *name*, next to where its neighbours went."""
+    new_ref = deepcopy(fresh_ref)
+    for child in [c for c in new_ref if getattr(c, "tag", None) in _STRUCTURAL]:
+        new_ref.remove(child)
+    new_ref.tail = None
+    href = fresh_ref.get("href") or ""
+    new_ref.set("href", (href.rsplit("/", 1)[0] + "/" if "/" in href else "") + name)
+
+    previous = fresh_ref.getprevious()
+    while previous is not None and not (previous.tag == "topicref" and _filename(previous) in placed):
+        previous = previous.getprevious()
+    if previous is not None:
+        anchor = _refs(edited, placed[_filename(previous)])[0]
+        parent = anchor.getparent()
+        parent.insert(list(parent).index(anchor) + 1, new_ref)
+    else:
+        parent_ref = fresh_ref.getparent()
+        if parent_ref.tag != "topicref":
+            target = edited.ditamap_root
+        else:
+            parents = _refs(edited, placed.get(_filename(parent_ref), ""))
+            target = parents[0] if parents else None
+        if target is None:
+            # Parent merged away or deleted in the editor: keep the topic, at the end of the map
+            edited.ditamap_root.append(new_ref)
+        else:
+            first = next((i for i, c in enumerate(target) if getattr(c, "tag", None) in _STRUCTURAL), len(target))
+            target.insert(first, new_ref)
+    edited.topics[name] = deepcopy(fresh.topics[_filename(fresh_ref)])
+
+
+def _copy_images(edited: DitaContext, fresh: DitaContext, names: List[str]) -> None:
+    for name in names:
+        for image in edited.topics[name].iter("image"):
+            image_name = (image.get("href") or "").split("/")[-1]
+            if image_name in fresh.images:
+                edited.images[image_name] = fresh.images[image_name]
+
+
+def reconvert(edited: DitaContext, fresh: DitaContext) -> ReconvertResult:
+    """Merge *fresh* (a new conversion of the changed source) into *edited*; see the module notes.
+
+    *edited* needs the outline recorded at its conversion; afterwards it holds
+    the outline of *fresh* under the edited topic names, ready for the next
+    update.
+    """
+    base = list(edited.metadata.get(OUTLINE_KEY) or [])
+    if not base:
+        raise ValueError("This structure has no record of its source outline; convert the document again.")
+    fresh_outline = source_outline(fresh)
+    matches = _match(base, fresh_outline)
+    result = ReconvertResult()
+    placed: Dict[str, str] = {}
+
+    for entry in base:
+        match = matches.get(entry["file"])
+        if match is None:
+            continue
+        if entry["file"] not in edited.topics:
+            if match["fingerprint"] != entry["fingerprint"] or match["title"] != entry["title"]:
+                result.skipped.append(entry["file"])
+            continue
+        placed[match["file"]] = entry["file"]
+        if match["fingerprint"] != entry["fingerprint"] or match["title"] != entry["title"]:
+            _update_topic(edited, entry["file"], entry, fresh.topics[match["file"]])
+            result.updated.append(entry["file"])
+        else:
+            result.unchanged += 1
+
+    matched_fresh = {id(m) for m in matches.values()}
+    unmatched_fresh = [e for e in fresh_outline if id(e) not in matched_fresh]
+    ambiguous = {tuple(e["path"]) for e in unmatched_fresh}
+    for entry in base:
+        if entry["file"] in matches or entry["file"] not in edited.topics:
+            continue
+        if tuple(entry["path"]) in ambiguous:
+            result.skipped.append(entry["file"])
+        else:
+            _remove_topic(edited, entry["file"])
+            result.removed.append(entry["file"])
+
+    base_paths = {tuple(e["path"]) for e in base if e["file"] not in matches}
+    fresh_refs = {_filename(n): n for n in fresh.ditamap_root.iter("topicref")}
+    for entry in unmatched_fresh:
+        if tuple(entry["path"]) in base_paths or entry["file"] not in fresh_refs:
+            continue
+        name = _free_name(edited, entry["file"])
+        _insert_topic(edited, fresh, fresh_refs[entry["file"]], name, placed)
+        placed[entry["file"]] = name
+        result.added.append(name)
+
+    _copy_images(edited, fresh, result.updated + result.added)
+    # Next outline: the fresh entries under edited names; skipped topics keep their old entry
+    base_by_file = {e["file"]: e for e in base}
+    reverse = {id(m): f for f, m in matches.items()}
+    outline = []
+    for entry in fresh_outline:
+        base_file = reverse.get(id(entry))
+        if base_file in result.skipped:
+            outline.append(base_by_file[base_file])
+        elif base_file is not None or entry["file"] in placed:
+            outline.append(dict(entry, file=base_file or placed[entry["file"]]))
+    outline.extend(base_by_file[f] for f in result.skipped if f not in matches)
+    edited.metadata[OUTLINE_KEY] = outline
+    if fresh.metadata.get("source_file"):
+        edited.metadata["source_file"] = fresh.metadata["source_file"]
+    if result.changed:
+        # Like any structure edit: later depth merges start from the updated structure
+        for key in ("original_structure", "merged_depth", "merged_exclude_styles"):
+            edited.metadata.pop(key, None)
+    logger.info("Re-conversion: %s", result.summary())
+    return result
@@ -0,0 +1,137 @@
+from __future__ import annotations
+
+"""Reproducible package output.
+
+Two conversions of the same source should give the same ZIP so packages can
+be reviewed with ``git diff``. The archive itself is always written with
+fixed timestamps and sorted entries
+(:func:`orlando_toolkit.core.package_utils.write_zip_archive`); with
+``reproducible.enabled`` in ``conversion.yml`` the rest of the package
+follows:
+
+- topic filenames and ids come from heading anchors and heading paths
+  (``ids.stable``, :mod:`orlando_toolkit.core.stable_ids`) and image names
+  from ``reproducible.image_pattern``, by default the ``{hash}`` of the
+  image bytes;
+- element ids generated at random (``id-<uuid>``, e.g. when topics are
+  merged) are replaced by :func:`stabilize_ids` with a hash of the topic,
+  the element and its text, and the references to them are rewritten;
+- ``critdates`` get :func:`package_date` (``reproducible.date``, else the
+  ``SOURCE_DATE_EPOCH`` environment variable, else 1980-01-01) instead of
+  today;
+- attributes are written in name order (:func:`normalize_attributes`).
+"""
+
+import logging
+import os
+import re
+from datetime import datetime, timezone
+from typing import Any, Dict, Mapping, Optional
+
+from orlando_toolkit.core.stable_ids import stable_hash
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["GENERATED_ID", "is_reproducible", "normalize_attributes", "package_date", "reproducible_options",
+           "stabilize_ids"]
+
+#: Ids made by :func:`orlando_toolkit.core.utils.generate_dita_id`
+GENERATED_ID = re.compile(r"^id-[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$")
+_DEFAULT_DATE = "1980-01-01"
+_REFERENCE_ATTRIBUTES = ("href", "conref", "conrefend")
+
+
+def reproducible_options(metadata: Optional[Mapping[str, Any]] = None) -> Dict[str, Any]:
+    """The ``reproducible`` section of the conversion options for *metadata*."""
+    try:
+        from orlando_toolkit.core.processing import resolve_conversion_options
+        return dict(resolve_conversion_options(metadata).get("reproducible") or {})
+    except Exception as exc:
+        logger.debug("Reproducible output options unavailable: %s", exc)
+        return {}
+
+
+def is_reproducible(metadata: Optional[Mapping[str, Any]] = None) -> bool:
+    return bool(reproducible_options(metadata).get("enabled", False))
+
+
+def package_date(options: Optional[Mapping[str, Any]] = None) -> str:
+    """Fixed ``YYYY-MM-DD`` written where the package would otherwise hold today's date."""
+    value = (options or {}).get("date")
+    if value:
+        try:
+            return datetime.strptime(str(value), "%Y-%m-%d").strftime("%Y-%m-%d")
+        except ValueError:
+            logger.warning("Ignoring invalid reproducible.date %r (expected YYYY-MM-DD)", value)
+    epoch = os.environ.get("SOURCE_DATE_EPOCH")
+    if epoch:
+        try:
+            return datetime.fromtimestamp(int(epoch), tz=timezone.utc).strftime("%Y-%m-%d")
+        except (ValueError, OverflowError, OSError):
+            logger.warning("Ignoring invalid SOURCE_DATE_EPOCH %r", epoch)
+    return _DEFAULT_DATE
+
+
+def _text(el: Any) -> str:
+    return " ".join("".join(el.itertext()).split())[:200]
+
+
+def stabilize_ids(context: Any) -> int:
+    """Replace generated ``id-<uuid>`` ids of *context* by content hashes; returns the number replaced.
+
+    The new id hashes the topic file, the element name and its text, so it
+    does not change when unrelated content moves; repeats within a topic are
+    numbered in document order. ``#topic/element`` references in the topics
+    and the map are rewritten.
+    """
+    renamed: Dict[str, Dict[str, str]] = {}       # topic file -> {old id: new id}
+    count = 0
+    for filename, topic in context.topics.items():
+        used = {el.get("id") for el in topic.iter() if isinstance(el.tag, str) and el.get("id")}
+        seen: Dict[str, int] = {}
+        for el in topic.iter():
+            old = el.get("id") if isinstance(el.tag, str) else None
+            if not old or not GENERATED_ID.match(old):
+                continue
+            seed = f"{filename}|{el.tag}|{_text(el)}"
+            seen[seed] = seen.get(seed, 0) + 1
+            new = f"id-{stable_hash(seed + '|' + str(seen[seed]), 12)}"
+            while new in used:
+                new = f"id-{stable_hash(new, 12)}"
+            used.add(new)
+            el.set("id", new)
+            renamed.setdefault(filename, {})[old] = new
+            count += 1
+    if not renamed:
+        return 0
+    roots = [(None, context.ditamap_root)] if context.ditamap_root is not None else []
+    for filename, root in roots + list(context.topics.items()):
+        for el in root.iter():
+            if not isinstance(el.tag, str):
+                continue
+            for attr in _REFERENCE_ATTRIBUTES:
+                value = el.get(attr)
+                if not value or "#" not in value:
+                    continue
+                path, _, fragment = value.partition("#")
+                target = os.path.basename(path) if path else filename
+                ids = renamed.get(target or "")
+                if not ids:
+                    continue
+                parts = [ids.get(part, part) for part in fragment.split("/")]
+                if parts != fragment.split("/"):
+                    el.set(attr, f"{path}#{'/'.join(parts)}")
+    logger.debug("Replaced %d generated ids with content hashes", count)
+    return count
+
+
+def normalize_attributes(root: Any) -> None:
+    """Put the attributes of every element under *root* in name order."""
+    for el in root.iter():
+        if not isinstance(el.tag, str) or len(el.attrib) < 2:
+            continue
+        items = sorted(el.attrib.items())
+        if items != list(el.attrib.items()):
+            el.attrib.clear()
+            for name, value in items:
+                el.set(name, value)
@@ -0,0 +1,449 @@
+from __future__ import annotations
+
+"""Detection of repeated blocks and their replacement by conrefs.
+
+Manuals converted from Word repeat the same warnings, notes and procedures in
+many topics. :func:`find_reuse_candidates` fingerprints the blocks of every
+topic body (``note``, ``hazardstatement``, ``steps``, ``step`` and ``p`` by
+default): two blocks match when they have the same elements, attributes and
+text, ignoring ``id``, conversion hints (``data-*``) and whitespace. A block
+repeated at least ``min_occurrences`` times with at least ``min_words`` words
+is a :class:`ReuseCandidate`; blocks inside a larger candidate are not
+proposed separately.
+
+:func:`apply_reuse` moves the chosen candidates to a shared warehouse topic
+(``reuse.dita``, referenced from the map with
+``processing-role="resource-only"``) and replaces every copy with an empty
+element that conrefs it. Procedures go to a task warehouse
+(``reuse_steps.dita``) and a repeated ``<steps>`` becomes a single
+``<step conref=… conrefend=…/>`` range. The owner of a copy keeps its
+``id``; copies containing ids that links point to are left in place.
+
+Nothing is changed until :func:`apply_reuse` is called, so the GUI can list
+the proposed substitutions for review first (options: ``reuse`` in
+``conversion.yml``).
+
+Safety notices are single-sourced even when they occur once or their
+wording drifted: :func:`find_notice_groups` collects every ``hazardstatement``
+and every ``note`` of the listed ``types`` and groups those of one element
+and type whose words are at least ``similarity`` alike
+(:class:`difflib.SequenceMatcher` on the words). The wording found most often
+is kept. :func:`apply_notice_warehouse` copies it to a safety notices
+warehouse topic (``safety_notices.dita``, resource-only) and conrefs every
+accepted occurrence to it; a rejected occurrence keeps its own wording in
+place. With ``safety_notices.enabled`` all groups are applied at the end of
+the conversion; otherwise the GUI lists them for review.
+"""
+
+import difflib
+import hashlib
+import logging
+import re
+from copy import deepcopy
+from dataclasses import dataclass, field
+from typing import Any, Dict, Iterable, List, Mapping, Optional, Sequence, Set, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.i18n import document_language, get_catalog
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.processing.text import is_element, local_name
+from orlando_toolkit.core.utils import topic_body
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["DEFAULT_KINDS", "DEFAULT_NOTICE_TYPES", "NoticeGroup", "NoticeOccurrence", "ReuseCandidate",
+           "apply_notice_warehouse", "apply_reuse", "consolidate_notices", "find_notice_groups",
+           "find_reuse_candidates"]
+
+#: Block elements compared by default
+DEFAULT_KINDS = ("steps", "hazardstatement", "note", "step", "p")
+#: Note types collected into the safety notices warehouse by default (hazard statements always are)
+DEFAULT_NOTICE_TYPES = ("danger", "warning", "caution", "attention", "notice")
+_STEP_KINDS = frozenset({"steps", "step"})
+_WAREHOUSE = "reuse"
+_NOTICE_WAREHOUSE = "safety_notices"
+_WORD = re.compile(r"\w+")
+_FRAGMENT = re.compile(r"#(?:[^/#]+/)?([^/#]+)$")
+
+
+@dataclass
+class ReuseCandidate:
+    """A block found :attr:`count` times; :attr:`occurrences` are ``(topic filename, element)`` pairs."""
+
+    key: str
+    kind: str
+    text: str
+    occurrences: List[Tuple[str, Any]] = field(default_factory=list)
+
+    @property
+    def count(self) -> int:
+        return len(self.occurrences)
+
+    @property
+    def topics(self) -> List[str]:
+        return list(dict.fromkeys(name for name, _ in self.occurrences))
+
+    @property
+    def words(self) -> int:
+        return len(self.text.split())
+
+
+def _ignored(attr: str) -> bool:
+    name = attr.rsplit("}", 1)[-1]
+    return name == "id" or name.startswith("data-")
+
+
+def _canonical(el, parts: List[str]) -> None:
+    attrs = sorted((k, v) for k, v in el.attrib.items() if not _ignored(k))
+    parts.append(f"<{local_name(el)} {attrs!r}>")
+    parts.append(" ".join((el.text or "").split()))
+    for child in el:
+        if is_element(child):
+            _canonical(child, parts)
+        parts.append(" ".join((child.tail or "").split()))
+    parts.append("</>")
+
+
+def _fingerprint(el) -> str:
+    parts: List[str] = []
+    _canonical(el, parts)
+    return hashlib.sha1("\x1f".join(parts).encode("utf-8")).hexdigest()
+
+
+def _linked_ids(context: DitaContext) -> Set[str]:
+    """Element ids that ``href``/``conref`` fragments point to."""
+    roots = list(context.topics.values())
+    if context.ditamap_root is not None:
+        roots.append(context.ditamap_root)
+    ids: Set[str] = set()
+    for root in roots:
+        for el in root.iter():
+            if not is_element(el):
+                continue
+            for attr in ("href", "conref", "conrefend"):
+                match = _FRAGMENT.search(el.get(attr) or "")
+                if match:
+                    ids.add(match.group(1))
+    return ids
+
+
+def _reused(el) -> bool:
+    """Whether *el* or one of its ancestors is already a conref."""
+    while el is not None:
+        if el.get("conref"):
+            return True
+        el = el.getparent()
+    return False
+
+
+def _overlaps(el, owners: Set[Any]) -> bool:
+    """Whether *el* is inside, or contains, a block already proposed."""
+    if any(d in owners for d in el.iter()):
+        return True
+    parent = el.getparent()
+    while parent is not None:
+        if parent in owners:
+            return True
+        parent = parent.getparent()
+    return False
+
+
+def find_reuse_candidates(context: DitaContext, options: Optional[Mapping[str, Any]] = None) -> List[ReuseCandidate]:
+    """Repeated blocks of *context*, most repeated first; see the module docstring."""
+    options = options or {}
+    kinds = [str(k) for k in options.get("elements") or DEFAULT_KINDS]
+    min_occurrences = max(2, int(options.get("min_occurrences", 3)))
+    min_words = max(1, int(options.get("min_words", 8)))
+    warehouse = str(options.get("warehouse") or _WAREHOUSE)
+    linked = _linked_ids(context)
+
+    groups: Dict[str, ReuseCandidate] = {}
+    for filename, root in context.topics.items():
+        if filename in (f"{warehouse}.dita", f"{warehouse}_steps.dita"):
+            continue
+        body = topic_body(root)
+        if body is None:
+            continue
+        for el in body.iter():
+            if not is_element(el) or local_name(el) not in kinds or _reused(el):
+                continue
+            if any(d.get("id") in linked for d in el.iter() if is_element(d) and d is not el):
+                continue
+            text = " ".join("".join(el.itertext()).split())
+            if len(text.split()) < min_words:
+                continue
+            key = _fingerprint(el)
+            group = groups.setdefault(key, ReuseCandidate(key, local_name(el), text))
+            group.occurrences.append((filename, el))
+
+    # Outermost blocks first: a note repeated as a whole is proposed once, not its paragraphs
+    ordered = sorted(groups.values(), key=lambda g: (-g.words, kinds.index(g.kind)))
+    owners: Set[Any] = set()
+    found: List[ReuseCandidate] = []
+    for group in ordered:
+        group.occurrences = [(name, el) for name, el in group.occurrences if not _overlaps(el, owners)]
+        if group.count >= min_occurrences:
+            found.append(group)
+            owners.update(el for _, el in group.occurrences)
+    found.sort(key=lambda g: (-g.count, -g.words))
+    logger.debug("Reuse: %d candidate block(s) in %d topic(s)", len(found), len(context.topics))
+    return found
+
+
+def _map_folder(context: DitaContext) -> str:
+    root = context.ditamap_root
+    first = next(root.iter("topicref"), None) if root is not None else None
+    href = first.get("href") if first is not None else ""
+    return href.rsplit("/", 1)[0] + "/" if href and "/" in href else "topics/"
+
+
+def _warehouse(context: DitaContext, name: str, task: bool, title_key: str = "reuse_title") -> Tuple[str, Any, Any]:
+    """The warehouse topic (created and referenced from the map on first use) and its container."""
+    filename = f"{name}_steps.dita" if task else f"{name}.dita"
+    topic = context.topics.get(filename)
+    if topic is None:
+        topic = ET.Element("task" if task else "concept", id=filename[:-5])
+        ET.SubElement(topic, "title").text = get_catalog().get(title_key, document_language(context))
+        body = ET.SubElement(topic, "taskbody" if task else "conbody")
+        if task:
+            ET.SubElement(body, "steps")
+        context.topics[filename] = topic
+        if context.ditamap_root is not None:
+            ET.SubElement(context.ditamap_root, "topicref", {
+                "href": _map_folder(context) + filename, "processing-role": "resource-only", "toc": "no"})
+    body = topic_body(topic)
+    container = body.find("steps") if task else body
+    if container is None:
+        container = ET.SubElement(body, "steps")
+    return filename, topic, container
+
+
+def _next_id(topic, prefix: str) -> Iterable[str]:
+    taken = {el.get("id") for el in topic.iter() if is_element(el)}
+    n = 1
+    while True:
+        if f"{prefix}_{n}" not in taken:
+            taken.add(f"{prefix}_{n}")
+            yield f"{prefix}_{n}"
+        n += 1
+
+
+def _shared_copy(el, new_id: str) -> Any:
+    shared = deepcopy(el)
+    shared.tail = None
+    for part in shared.iter():
+        for attr in [a for a in part.attrib if _ignored(a)] if is_element(part) else []:
+            del part.attrib[attr]
+    shared.set("id", new_id)
+    return shared
+
+
+def _replace(el, reference) -> None:
+    if el.get("id"):
+        reference.set("id", el.get("id"))
+    reference.tail = el.tail
+    parent = el.getparent()
+    parent.insert(list(parent).index(el), reference)
+    parent.remove(el)
+
+
+def apply_reuse(context: DitaContext, candidates: Sequence[ReuseCandidate],
+                options: Optional[Mapping[str, Any]] = None) -> int:
+    """Move *candidates* to the warehouse topics and conref every copy; return the copies replaced."""
+    options = options or {}
+    name = str(options.get("warehouse") or _WAREHOUSE)
+    replaced = 0
+    for candidate in candidates:
+        occurrences = [(f, el) for f, el in candidate.occurrences if el.getparent() is not None]
+        if not occurrences:
+            continue
+        filename, topic, container = _warehouse(context, name, candidate.kind in _STEP_KINDS)
+        ids = _next_id(topic, name)
+        first = occurrences[0][1]
+        target = f"{filename}#{topic.get('id')}"
+        if candidate.kind == "steps":
+            steps = [_shared_copy(s, next(ids)) for s in first if is_element(s) and local_name(s) == "step"]
+            if not steps:
+                continue
+            container.extend(steps)
+            for _, el in occurrences:
+                reference = ET.Element("steps")
+                ET.SubElement(reference, "step", conref=f"{target}/{steps[0].get('id')}",
+                              conrefend=f"{target}/{steps[-1].get('id')}")
+                _replace(el, reference)
+        else:
+            shared = _shared_copy(first, next(ids))
+            container.append(shared)
+            for _, el in occurrences:
+                _replace(el, ET.Element(local_name(el), conref=f"{target}/{shared.get('id')}"))
+        replaced += len(occurrences)
+    if replaced:
+        logger.info("Reuse: %d block(s) replaced by conrefs to %s", replaced, name)
+    return replaced
+
+
+# ----------------------------------------------------------------------
+# Safety notices warehouse
+# ----------------------------------------------------------------------
+
+@dataclass
+class NoticeOccurrence:
+    """One notice in a topic; :attr:`similarity` compares its words with the kept wording."""
+
+    topic: str
+    element: Any
+    text: str
+    similarity: float = 1.0
+    accepted: bool = True
+
+    @property
+    def identical(self) -> bool:
+        return self.similarity >= 1.0
+
+
+@dataclass
+class NoticeGroup:
+    """Notices of one element and type worded alike; :attr:`text` is the wording kept."""
+
+    kind: str                      # note | hazardstatement
+    type: str
+    text: str
+    source: Any                    # element holding the kept wording
+    occurrences: List[NoticeOccurrence] = field(default_factory=list)
+
+    @property
+    def count(self) -> int:
+        return len(self.occurrences)
+
+    @property
+    def variants(self) -> List[NoticeOccurrence]:
+        """Occurrences worded differently from :attr:`text`."""
+        return [o for o in self.occurrences if not o.identical]
+
+    @property
+    def accepted(self) -> List[NoticeOccurrence]:
+        return [o for o in self.occurrences if o.accepted]
+
+
+def _words(text: str) -> List[str]:
+    return _WORD.findall(text.casefold())
+
+
+def _similarity(a: List[str], b: List[str]) -> float:
+    return difflib.SequenceMatcher(None, a, b, autojunk=False).ratio()
+
+
+def _notice_options(options: Optional[Mapping[str, Any]]) -> Tuple[Set[str], float, str]:
+    options = options or {}
+    types = options.get("types")
+    types = {str(t) for t in (DEFAULT_NOTICE_TYPES if types is None else types or ())}
+    try:
+        similarity = min(1.0, max(0.5, float(options.get("similarity", 0.85))))
+    except (TypeError, ValueError):
+        similarity = 0.85
+    return types, similarity, str(options.get("warehouse") or _NOTICE_WAREHOUSE)
+
+
+def find_notice_groups(context: DitaContext, options: Optional[Mapping[str, Any]] = None) -> List[NoticeGroup]:
+    """Hazard statements and safety notes of *context* grouped by wording, most used first.
+
+    *options* is the ``safety_notices`` section; see the module docstring.
+    """
+    types, threshold, warehouse = _notice_options(options)
+    linked = _linked_ids(context)
+    # (kind, type) -> [(words, occurrences by fingerprint)]
+    clusters: Dict[Tuple[str, str], List[Tuple[List[str], Dict[str, List[NoticeOccurrence]]]]] = {}
+    for filename, root in context.topics.items():
+        if filename == f"{warehouse}.dita":
+            continue
+        body = topic_body(root)
+        if body is None:
+            continue
+        for el in body.iter("hazardstatement", "note"):
+            kind = local_name(el)
+            note_type = el.get("type") or ("caution" if kind == "hazardstatement" else "note")
+            if kind == "note" and note_type not in types:
+                continue
+            if _reused(el) or any(a.tag in ("note", "hazardstatement") for a in el.iterancestors()):
+                continue
+            if any(d.get("id") in linked for d in el.iter() if is_element(d) and d is not el):
+                continue
+            text = " ".join("".join(el.itertext()).split())
+            words = _words(text)
+            if not words:
+                continue
+            occurrence = NoticeOccurrence(filename, el, text)
+            candidates = clusters.setdefault((kind, note_type), [])
+            best, best_ratio = None, threshold
+            for cluster in candidates:
+                matcher = difflib.SequenceMatcher(None, cluster[0], words, autojunk=False)
+                if matcher.real_quick_ratio() < best_ratio or matcher.quick_ratio() < best_ratio:
+                    continue
+                ratio = matcher.ratio()
+                if ratio >= best_ratio:
+                    best, best_ratio = cluster, ratio
+            if best is None:
+                best = (words, {})
+                candidates.append(best)
+            best[1].setdefault(_fingerprint(el), []).append(occurrence)
+
+    groups: List[NoticeGroup] = []
+    for (kind, note_type), found in clusters.items():
+        for _, wordings in found:
+            # The wording used most often (first found on a tie) is the one kept
+            kept = max(wordings.values(), key=len)
+            group = NoticeGroup(kind, note_type, kept[0].text, kept[0].element)
+            kept_words = _words(group.text)
+            for wording in wordings.values():
+                ratio = 1.0 if wording is kept else min(0.999, _similarity(kept_words, _words(wording[0].text)))
+                for occurrence in wording:
+                    occurrence.similarity = ratio
+                    group.occurrences.append(occurrence)
+            groups.append(group)
+    groups.sort(key=lambda g: (-g.count, g.kind, g.type, g.text))
+    logger.debug("Safety notices: %d notice(s) in %d group(s)", sum(g.count for g in groups), len(groups))
+    return groups
+
+
+def apply_notice_warehouse(context: DitaContext, groups: Sequence[NoticeGroup],
+                           options: Optional[Mapping[str, Any]] = None) -> int:
+    """Copy the kept wording of *groups* to the safety notices warehouse and conref the accepted occurrences.
+
+    Returns the occurrences replaced; rejected occurrences are left in place.
+    """
+    _types, _threshold, name = _notice_options(options)
+    replaced = 0
+    for group in groups:
+        accepted = [o for o in group.accepted if o.element.getparent() is not None]
+        if not accepted:
+            continue
+        filename, topic, container = _warehouse(context, name, False, "safety_notices_title")
+        shared = _shared_copy(group.source, next(_next_id(topic, group.type)))
+        container.append(shared)
+        target = f"{filename}#{topic.get('id')}/{shared.get('id')}"
+        for occurrence in accepted:
+            reference = ET.Element(group.kind, conref=target)
+            if group.kind == "note" and occurrence.element.get("type"):
+                reference.set("type", occurrence.element.get("type"))
+            _replace(occurrence.element, reference)
+        replaced += len(accepted)
+    if replaced:
+        logger.info("Safety notices: %d notice(s) replaced by conrefs to %s", replaced, name)
+    return replaced
+
+
+def consolidate_notices(context: DitaContext, options: Optional[Mapping[str, Any]] = None) -> int:
+    """Apply every notice group when ``safety_notices.enabled`` is set; returns the notices replaced."""
+    options = options or {}
+    if not options.get("enabled", False):
+        return 0
+    groups = find_notice_groups(context, options)
+    replaced = apply_notice_warehouse(context, groups, options)
+    report = getattr(context, "report", None)
+    if report is not None and replaced:
+        variants = sum(len(g.variants) for g in groups)
+        report.info("safety_notices", f"{replaced} safety notice(s) single-sourced as {len(groups)} shared "
+                                      f"notice(s); {variants} reworded to the most used wording",
+                    notices=replaced, shared=len(groups), reworded=variants)
+    return replaced
@@ -0,0 +1,189 @@
+from __future__ import annotations
+
+"""Review bundle: the topics as standalone HTML pages, zipped for e-mailing.
+
+Reviewers without DITA tooling read the package in a browser.
+:func:`build_review_bundle` renders every topic of the map with the preview
+compiler (:func:`orlando_toolkit.core.preview.xml_compiler.render_html_preview`),
+images pointing at the bundled copies and links between topics at their
+pages, and puts a sidebar mirroring the map on each page (topic heads as
+labels, the current topic highlighted). :meth:`ReviewBundle.write_zip`
+writes ``index.html`` (title page), ``topics/<topic>.html``, ``images/``
+and ``review.css``. A topic the compiler cannot render is shown as its XML
+and reported in :attr:`ReviewBundle.warnings`.
+"""
+
+import html
+import logging
+import posixpath
+import zipfile
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Optional
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.preview import xml_compiler
+from orlando_toolkit.core.processing.text import is_element, local_name
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["ReviewBundle", "ReviewPage", "build_review_bundle"]
+
+_MAP_ENTRIES = ("topicref", "topichead", "chapter", "part", "appendix", "appendices", "preface", "notices",
+                "frontmatter", "backmatter", "topicgroup")
+_EXTERNAL = ("http:", "https:", "mailto:", "ftp:", "file:")
+
+_CSS = """body{margin:0;font-family:system-ui,-apple-system,'Segoe UI',Roboto,Arial,sans-serif;color:#222}
+nav{position:fixed;top:0;bottom:0;left:0;width:18rem;overflow:auto;background:#f4f5f7;border-right:1px solid #ddd;
+padding:1rem 0.75rem;box-sizing:border-box;font-size:90%}
+nav h1{font-size:110%;margin:0 0 0.75rem}nav h1 a{color:inherit;text-decoration:none}
+nav ul{list-style:none;margin:0;padding-left:0.9rem}nav>ul{padding-left:0}nav li{margin:0.2rem 0}
+nav a{color:#0b5394;text-decoration:none}nav a:hover{text-decoration:underline}
+nav .head{font-weight:bold;color:#555}nav .current>a{font-weight:bold;color:#222}
+main{margin-left:18rem;padding:1.5rem 2.5rem;max-width:52rem}main img{max-width:100%}
+table{border-collapse:collapse}td,th{border:1px solid #ccc;padding:4px 6px;vertical-align:top}
+pre{white-space:pre-wrap;background:#f6f8fa;padding:0.75rem;border-radius:4px}
+.meta{color:#666}
+"""
+
+
+@dataclass
+class ReviewPage:
+    """One topic page: :attr:`name` (``topics/<topic>.html``) and its rendered :attr:`body`."""
+
+    topic: str
+    name: str
+    title: str
+    body: str = ""
+
+
+@dataclass
+class ReviewBundle:
+    title: str = ""
+    pages: List[ReviewPage] = field(default_factory=list)
+    images: Dict[str, bytes] = field(default_factory=dict)
+    sidebar: List[Dict[str, Any]] = field(default_factory=list)
+    details: List[str] = field(default_factory=list)
+    warnings: List[str] = field(default_factory=list)
+
+    def _nav(self, prefix: str, current: Optional[str]) -> str:
+        def _items(nodes: List[Dict[str, Any]]) -> str:
+            parts = []
+            for node in nodes:
+                label = html.escape(node["title"])
+                if node["page"]:
+                    current_class = ' class="current"' if node["page"] == current else ""
+                    parts.append(f'<li{current_class}><a href="{prefix}{node["page"]}">{label}</a>')
+                else:
+                    parts.append(f'<li><span class="head">{label}</span>')
+                if node["children"]:
+                    parts.append(f"<ul>{_items(node['children'])}</ul>")
+                parts.append("</li>")
+            return "".join(parts)
+
+        return (f'<nav><h1><a href="{prefix}index.html">{html.escape(self.title)}</a></h1>'
+                f"<ul>{_items(self.sidebar)}</ul></nav>")
+
+    def _document(self, title: str, prefix: str, current: Optional[str], body: str) -> str:
+        return ("<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\">"
+                f"<title>{html.escape(title)}</title><link rel=\"stylesheet\" href=\"{prefix}review.css\"></head>"
+                f"<body>{self._nav(prefix, current)}<main>{body}</main></body></html>\n")
+
+    def index_html(self) -> str:
+        details = "".join(f'<p class="meta">{html.escape(line)}</p>' for line in self.details)
+        body = (f"<h1>{html.escape(self.title)}</h1>{details}"
+                f"<p>{len(self.pages)} topic(s). Choose a topic in the contents on the left.</p>")
+        return self._document(self.title, "", None, body)
+
+    def page_html(self, page: ReviewPage) -> str:
+        return self._document(f"{page.title} – {self.title}" if self.title else page.title, "../", page.name,
+                              page.body)
+
+    def write_zip(self, path: str | Path) -> Path:
+        """Write the bundle as a zip opening on ``index.html``; returns its path."""
+        target = Path(path)
+        with zipfile.ZipFile(target, "w", zipfile.ZIP_DEFLATED) as archive:
+            archive.writestr("index.html", self.index_html())
+            archive.writestr("review.css", _CSS)
+            for page in self.pages:
+                archive.writestr(page.name, self.page_html(page))
+            for name, data in sorted(self.images.items()):
+                archive.writestr(f"images/{name}", data)
+        logger.info("Review bundle written to %s (%d page(s))", target, len(self.pages))
+        return target
+
+
+def _entry_title(entry: Any, topic: Any) -> str:
+    navtitle = entry.find("topicmeta/navtitle")
+    text = "".join(navtitle.itertext()) if navtitle is not None else entry.get("navtitle") or ""
+    if not text.strip() and topic is not None and topic.find("title") is not None:
+        text = "".join(topic.find("title").itertext())
+    return " ".join(text.split())
+
+
+def _page_name(topic: str) -> str:
+    return f"topics/{posixpath.splitext(topic)[0]}.html"
+
+
+def build_review_bundle(context: DitaContext, *, title: Optional[str] = None) -> ReviewBundle:
+    """HTML pages of the topics of *context*, with the map as sidebar."""
+    metadata = getattr(context, "metadata", None) or {}
+    bundle = ReviewBundle(title=title or str(metadata.get("manual_title") or "") or "Review")
+    for label, key in (("Reference", "manual_code"), ("Revision", "revision_number"), ("Date", "revision_date")):
+        if metadata.get(key):
+            bundle.details.append(f"{label}: {metadata[key]}")
+    first_ref: Dict[str, Any] = {}
+
+    def _walk(parent_el: Any) -> List[Dict[str, Any]]:
+        nodes: List[Dict[str, Any]] = []
+        for entry in parent_el:
+            if not is_element(entry) or local_name(entry) not in _MAP_ENTRIES:
+                continue
+            if entry.get("processing-role") == "resource-only":
+                continue
+            name = posixpath.basename((entry.get("href") or "").partition("#")[0])
+            topic = context.topics.get(name) if name else None
+            label = _entry_title(entry, topic)
+            if topic is None and not label:
+                nodes.extend(_walk(entry))  # topicgroup: its children belong to the parent
+                continue
+            if topic is not None and name not in first_ref:
+                first_ref[name] = entry
+                bundle.pages.append(ReviewPage(name, _page_name(name), label or name))
+            nodes.append({"title": label or name, "page": _page_name(name) if topic is not None else None,
+                          "children": _walk(entry)})
+        return nodes
+
+    root = getattr(context, "ditamap_root", None)
+    if root is not None:
+        bundle.sidebar = _walk(root)
+
+    images = getattr(context, "images", None) or {}
+
+    def _image_href(name: str) -> Optional[str]:
+        bundle.images.setdefault(name, images[name])
+        return f"../images/{name}"
+
+    def _link_href(href: str) -> Optional[str]:
+        if not href or href.lower().startswith(_EXTERNAL):
+            return None
+        path, _, fragment = href.partition("#")
+        name = posixpath.basename(path)
+        if name not in first_ref:
+            return None
+        element = fragment.split("/")[-1] if "/" in fragment else ""
+        return posixpath.basename(_page_name(name)) + (f"#{element}" if element else "")
+
+    for page in bundle.pages:
+        try:
+            page.body = xml_compiler.render_html_preview(context, first_ref[page.topic], image_href=_image_href,
+                                                         link_href=_link_href)
+        except Exception as exc:
+            logger.warning("Review bundle: could not render %s: %s", page.topic, exc)
+            bundle.warnings.append(f"{page.topic}: shown as XML ({exc})")
+            xml = ET.tostring(context.topics[page.topic], encoding="unicode")
+            page.body = f"<h2>{html.escape(page.title)}</h2><pre>{html.escape(xml)}</pre>"
+    logger.info("Review bundle: %d page(s), %d image(s)", len(bundle.pages), len(bundle.images))
+    return bundle
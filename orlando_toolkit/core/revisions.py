@@ -0,0 +1,187 @@
+from __future__ import annotations
+
+"""Mark what changed since a previous conversion with ``@rev``.
+
+With ``revisions.enabled`` the packager compares every topic with the
+matching topic of a previous conversion (``revisions.previous``, or
+``ids.previous_map``; topics are matched like stable ids: heading path,
+content fingerprint, unique title) and sets ``rev`` on:
+
+- the root of topics that did not exist before (``mark_new_topics``);
+- changed titles;
+- paragraphs and other text blocks that are new or changed, found by
+  aligning the topic's blocks with the previous ones.
+
+The revision value is ``revisions.rev``, else the document's
+``revision_number``, else its ``revision_date``. With ``ditaval: true`` the
+package also gets ``DATA/revisions.ditaval`` flagging that value with a
+change bar, so "what changed in issue 12" renders with any DITA-OT
+transformation (``--filter=revisions.ditaval``). Removed content cannot be
+flagged; it is counted in the report.
+"""
+
+import difflib
+import logging
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.processing.text import BLOCK_TAGS, is_element, local_name
+from orlando_toolkit.core.stable_ids import PreviousConversion, fingerprint
+from orlando_toolkit.core.utils import topic_body
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "RevisionResult",
+    "block_text",
+    "build_revision_ditaval",
+    "mark_revisions",
+    "text_blocks",
+    "write_revision_ditaval",
+]
+
+DITAVAL_NAME = "revisions.ditaval"
+
+
+@dataclass
+class RevisionResult:
+    rev: str
+    new_topics: int = 0
+    changed_topics: int = 0
+    unchanged_topics: int = 0
+    changed_blocks: int = 0
+    removed_blocks: int = 0
+
+    @property
+    def marked(self) -> bool:
+        return bool(self.new_topics or self.changed_topics)
+
+
+def block_text(el) -> str:
+    """Text of *el* with whitespace collapsed."""
+    return " ".join("".join(el.itertext()).split())
+
+
+def text_blocks(topic_el) -> List[Any]:
+    """Innermost text blocks of the topic body, in document order."""
+    body = topic_body(topic_el)
+    if body is None:
+        return []
+    blocks = [el for el in body.iter() if is_element(el) and local_name(el) in BLOCK_TAGS]
+    inner = []
+    for block in blocks:
+        if not any(is_element(d) and local_name(d) in BLOCK_TAGS for d in block.iter() if d is not block):
+            inner.append(block)
+    return inner
+
+
+def _resolve_options(context: DitaContext, options: Optional[Mapping[str, Any]]) -> Dict[str, Any]:
+    if options is not None:
+        return dict(options)
+    try:
+        from orlando_toolkit.core.processing import resolve_conversion_options
+
+        resolved = resolve_conversion_options(context.metadata)
+    except Exception as exc:
+        logger.debug("Revision options unavailable: %s", exc)
+        return {}
+    merged = dict(resolved.get("revisions") or {})
+    if not merged.get("previous"):
+        merged["previous"] = (resolved.get("ids") or {}).get("previous_map")
+    return merged
+
+
+def _revision_value(context: DitaContext, options: Mapping[str, Any]) -> Optional[str]:
+    for value in (options.get("rev"), context.metadata.get("revision_number"), context.metadata.get("revision_date")):
+        if value not in (None, ""):
+            return " ".join(str(value).split())
+    return None
+
+
+def mark_revisions(context: DitaContext, previous: Optional[PreviousConversion] = None, *,
+                   options: Optional[Mapping[str, Any]] = None) -> Optional[RevisionResult]:
+    """Set ``rev`` on what differs from *previous*; ``None`` when disabled or nothing to compare."""
+    options = _resolve_options(context, options)
+    if not options.get("enabled", False) or context.ditamap_root is None:
+        return None
+    rev = _revision_value(context, options)
+    if not rev:
+        context.report.warning("revisions", "Revision marking skipped: no revision number or date")
+        return None
+    if previous is None and options.get("previous"):
+        previous = PreviousConversion.load(options["previous"])
+    if previous is None or not previous.topics:
+        context.report.warning("revisions", "Revision marking skipped: no previous conversion to compare with",
+                               previous=options.get("previous"))
+        return None
+
+    result = RevisionResult(rev=rev)
+    used: set = set()
+    mark_new = bool(options.get("mark_new_topics", True))
+    for tref in context.ditamap_root.iter("topicref"):
+        filename = (tref.get("href") or "").split("/")[-1]
+        topic = context.topics.get(filename)
+        if topic is None:
+            continue
+        fp = fingerprint(topic, tref, lambda h: context.topics.get(h.split("/")[-1]))
+        match = previous.match(fp, used)
+        old = previous.topics.get(match) if match else None
+        if old is None:
+            result.new_topics += 1
+            if mark_new:
+                topic.set("rev", rev)
+            continue
+        used.add(match)
+        changed = 0
+        title, old_title = topic.find("title"), old.find("title")
+        if title is not None and block_text(title) != (block_text(old_title) if old_title is not None else ""):
+            title.set("rev", rev)
+            changed += 1
+        new_blocks, old_blocks = text_blocks(topic), text_blocks(old)
+        matcher = difflib.SequenceMatcher(None, [block_text(b) for b in old_blocks],
+                                          [block_text(b) for b in new_blocks], autojunk=False)
+        for tag, i1, i2, j1, j2 in matcher.get_opcodes():
+            if tag in ("replace", "insert"):
+                for block in new_blocks[j1:j2]:
+                    block.set("rev", rev)
+                changed += j2 - j1
+            if tag in ("replace", "delete"):
+                result.removed_blocks += max(0, (i2 - i1) - (j2 - j1))
+        if changed:
+            result.changed_topics += 1
+            result.changed_blocks += changed
+        else:
+            result.unchanged_topics += 1
+
+    if result.marked and options.get("ditaval", True):
+        context.metadata["revision_ditaval"] = {"rev": rev, "changebar": options.get("changebar") or "solid"}
+    context.report.info(
+        "revisions",
+        f"Revision {rev}: {result.new_topics} new topic(s), {result.changed_topics} changed "
+        f"({result.changed_blocks} block(s)), {result.removed_blocks} block(s) removed",
+        rev=rev, new_topics=result.new_topics, changed_topics=result.changed_topics,
+        changed_blocks=result.changed_blocks, removed_blocks=result.removed_blocks,
+    )
+    return result
+
+
+def build_revision_ditaval(rev: str, changebar: str = "solid") -> ET._Element:
+    """DITAVAL flagging content with ``rev="<rev>"`` by a change bar."""
+    root = ET.Element("val")
+    ET.SubElement(root, "revprop", action="flag", val=rev, changebar=changebar)
+    return root
+
+
+def write_revision_ditaval(context: DitaContext, data_dir: str | Path) -> Optional[Path]:
+    """Write ``revisions.ditaval`` next to the map when revisions were marked."""
+    settings = context.metadata.get("revision_ditaval")
+    if not isinstance(settings, Mapping) or not settings.get("rev"):
+        return None
+    path = Path(data_dir) / DITAVAL_NAME
+    root = build_revision_ditaval(str(settings["rev"]), str(settings.get("changebar") or "solid"))
+    path.write_bytes(ET.tostring(root, pretty_print=True, xml_declaration=True, encoding="UTF-8"))
+    return path
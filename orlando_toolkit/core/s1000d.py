@@ -0,0 +1,667 @@
+from __future__ import annotations
+
+"""S1000D export (experimental): descriptive data modules, a DMRL and a publication module.
+
+Aerospace and defence programmes deliver S1000D rather than DITA. The
+export reuses the converted and edited context: :func:`export_s1000d` walks
+the map and writes one descriptive data module (``descript`` schema,
+information code ``040`` unless configured otherwise) per referenced topic.
+Data module codes are allocated in map order from the ``s1000d`` section of
+``pipeline.yml``: model identification, system difference and system codes
+are fixed there and the assembly code counts the modules (``0001``…).
+
+Topic content becomes ``levelledPara`` with the topic and section titles,
+``para`` for paragraphs, random and sequential lists (steps included),
+definition lists, CALS tables (simple tables converted), notes, warnings
+and cautions (moved to the start of their levelled paragraph, where S1000D
+wants them), figures with ``graphic`` and inline ``symbol`` references to
+the images, renamed as CAGE-based ICNs. Links to other topics become
+``dmRef``; external links keep their address as text.
+
+The data module requirement list (DMRL, ``00R``) lists every module with
+its title and the publication module keeps the map hierarchy, topic heads
+included, as ``pmEntry`` levels. Everything the S1000D models have no room
+for is simplified and listed in :attr:`S1000DExport.changes`.
+
+The export is off unless ``s1000d.enabled`` is true; codes are checked
+against the S1000D patterns before anything is written. Failures raise
+:class:`S1000DError` (``OTK505``).
+"""
+
+import logging
+import posixpath
+import re
+import zipfile
+from dataclasses import dataclass, field, replace
+from datetime import date
+from pathlib import Path
+from typing import Any, Callable, Dict, List, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.errors import ToolkitError
+from orlando_toolkit.core.i18n import canonical_language_tag
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.processing.text import is_element, local_name
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["DataModule", "S1000DError", "S1000DExport", "S1000DSettings", "dm_file_name", "export_s1000d"]
+
+_XSI = "http://www.w3.org/2001/XMLSchema-instance"
+_SCHEMAS = "http://www.s1000d.org/S1000D_4-1/xml_schema_flat/"
+# Default business rules exchange data module of Issue 4.1
+_BREX = {"modelIdentCode": "S1000D", "systemDiffCode": "F", "systemCode": "04", "subSystemCode": "1",
+         "subSubSystemCode": "0", "assyCode": "0301", "disassyCode": "00", "disassyCodeVariant": "A",
+         "infoCode": "022", "infoCodeVariant": "A", "itemLocationCode": "D"}
+_CODE_PATTERNS = {"model_ident_code": r"[A-Z0-9]{2,14}", "system_diff_code": r"[A-Z0-9]{1,4}",
+                  "system_code": r"[A-Z0-9]{2,3}", "sub_system_code": r"[A-Z0-9]",
+                  "sub_sub_system_code": r"[A-Z0-9]", "info_code": r"[A-Z0-9]{3}",
+                  "item_location_code": r"[A-DTZ]", "enterprise_code": r"[A-Z0-9]{5}",
+                  "security_classification": r"[0-9]{2}", "issue_number": r"[0-9]{3}"}
+_MAX_MODULES = 9999
+
+# (title, topic file or None for a topic head, children) of a map entry
+_Node = Tuple[str, Optional[str], List[Any]]
+
+_MAP_ENTRIES = ("topicref", "topichead", "chapter", "part", "appendix", "appendices", "preface", "notices",
+                "frontmatter", "backmatter", "topicgroup")
+_TOPICS = ("topic", "concept", "task", "reference", "glossentry", "troubleshooting")
+_SKIPPED = frozenset({"prolog", "titlealts", "related-links", "indexterm", "draft-comment", "required-cleanup",
+                      "data", "data-about", "foreign", "unknown", "metadata", "alt", "navtitle", "desc"})
+_EMPHASIS = {"b": "em01", "uicontrol": "em01", "wintitle": "em01", "i": "em02", "term": "em02", "cite": "em02",
+             "u": "em03", "line-through": "em05"}
+_VERBATIM = ("codeph", "tt", "filepath", "cmdname", "userinput", "systemoutput", "varname", "apiname",
+             "parmname", "option")
+_CODE = ("codeblock", "pre", "screen", "msgblock")
+_LISTS = {"ul": "randomList", "sl": "randomList", "steps-unordered": "randomList", "choices": "randomList",
+          "ol": "sequentialList", "steps": "sequentialList", "substeps": "sequentialList"}
+_SECTIONS = ("section", "example", "refsyn") + _TOPICS
+_TABLES = ("table", "simpletable", "properties", "choicetable")
+_WARNINGS = {"warning": "warning", "danger": "warning", "caution": "caution"}
+_INLINE = frozenset(_EMPHASIS) | frozenset(_VERBATIM) | {"sup", "sub", "xref", "ph", "keyword", "q", "fn", "text",
+                                                         "menucascade", "tm", "abbreviated-form", "cmd"}
+
+
+class S1000DError(ToolkitError, RuntimeError):
+    """Raised when the S1000D export is disabled, its codes are invalid or the map has too many modules."""
+
+    code = "OTK505"
+
+
+@dataclass(frozen=True)
+class S1000DSettings:
+    enabled: bool = False
+    model_ident_code: str = "ORLANDO"
+    system_diff_code: str = "A"
+    system_code: str = "00"
+    sub_system_code: str = "0"
+    sub_sub_system_code: str = "0"
+    info_code: str = "040"
+    info_name: str = "Description"
+    item_location_code: str = "D"
+    enterprise_code: str = "00000"
+    enterprise_name: str = ""
+    security_classification: str = "01"
+    issue_number: str = "001"
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "S1000DSettings":
+        data = dict(data or {})
+        defaults = cls()
+
+        def _code(key: str) -> str:
+            value = data.get(key)
+            return str(value).strip().upper() if value not in (None, "") else getattr(defaults, key)
+
+        return cls(enabled=bool(data.get("enabled", False)),
+                   **{key: _code(key) for key in _CODE_PATTERNS},
+                   info_name=str(data.get("info_name") or defaults.info_name),
+                   enterprise_name=str(data.get("enterprise_name") or ""))
+
+    @classmethod
+    def from_config(cls) -> "S1000DSettings":
+        try:
+            from orlando_toolkit.config import ConfigManager
+            return cls.from_mapping((ConfigManager().get_pipeline_config() or {}).get("s1000d"))
+        except Exception as exc:
+            logger.debug("Pipeline config unavailable, using default S1000D settings: %s", exc)
+            return cls()
+
+    def invalid_codes(self) -> List[str]:
+        """Settings whose value does not match its S1000D pattern."""
+        return [f"{key}={getattr(self, key)!r}" for key, pattern in _CODE_PATTERNS.items()
+                if not re.fullmatch(pattern, getattr(self, key))]
+
+
+@dataclass
+class DataModule:
+    """One data module; :attr:`code` holds the ``dmCode`` attributes."""
+
+    code: Dict[str, str]
+    title: str
+    root: Any
+    topic: Optional[str] = None
+
+    @property
+    def file_name(self) -> str:
+        return dm_file_name(self.code, self.root)
+
+
+@dataclass
+class S1000DExport:
+    """Data modules in map order, the DMRL, the publication module and the ICN files."""
+
+    modules: List[DataModule] = field(default_factory=list)
+    dmrl: Optional[DataModule] = None
+    pm: Any = None
+    images: Dict[str, bytes] = field(default_factory=dict)
+    changes: List[str] = field(default_factory=list)
+
+    def write_zip(self, path: str | Path) -> Path:
+        """Write the ``DMC-*.XML`` modules, the ``PMC-*.XML`` publication module and ``ICN-*`` files to *path*."""
+        path = Path(path)
+        path.parent.mkdir(parents=True, exist_ok=True)
+        with zipfile.ZipFile(path, "w", zipfile.ZIP_DEFLATED) as zf:
+            for module in ([self.dmrl] if self.dmrl is not None else []) + self.modules:
+                zf.writestr(module.file_name, _serialize(module.root))
+            if self.pm is not None:
+                zf.writestr(pm_file_name(self.pm), _serialize(self.pm))
+            for name, data in self.images.items():
+                zf.writestr(name, data)
+        logger.info("S1000D export: %d data module(s) written to %s", len(self.modules), path.name)
+        return path
+
+
+def _serialize(root: Any) -> bytes:
+    return ET.tostring(root, xml_declaration=True, encoding="UTF-8", pretty_print=True)
+
+
+def _language(root: Any) -> Tuple[str, str]:
+    language = root.find("identAndStatusSection/*/*/language")
+    return language.get("languageIsoCode"), language.get("countryIsoCode")
+
+
+def _issue(root: Any) -> Tuple[str, str]:
+    issue = root.find("identAndStatusSection/*/*/issueInfo")
+    return issue.get("issueNumber"), issue.get("inWork")
+
+
+def dm_file_name(code: Mapping[str, str], root: Any) -> str:
+    """``DMC-…_001-00_EN-US.XML`` name of the data module *root* with the ``dmCode`` attributes *code*."""
+    c = code
+    language, country = _language(root)
+    number, in_work = _issue(root)
+    return (f"DMC-{c['modelIdentCode']}-{c['systemDiffCode']}-{c['systemCode']}-"
+            f"{c['subSystemCode']}{c['subSubSystemCode']}-{c['assyCode']}-{c['disassyCode']}"
+            f"{c['disassyCodeVariant']}-{c['infoCode']}{c['infoCodeVariant']}-{c['itemLocationCode']}_"
+            f"{number}-{in_work}_{language}-{country}.XML").upper()
+
+
+def pm_file_name(root: Any) -> str:
+    code = root.find("identAndStatusSection/pmAddress/pmIdent/pmCode")
+    language, country = _language(root)
+    number, in_work = _issue(root)
+    return (f"PMC-{code.get('modelIdentCode')}-{code.get('pmIssuer')}-{code.get('pmNumber')}-"
+            f"{code.get('pmVolume')}_{number}-{in_work}_{language}-{country}.XML").upper()
+
+
+# -- content ----------------------------------------------------------------
+
+def _plain(el: Any) -> str:
+    return " ".join("".join(el.itertext()).split()) if el is not None else ""
+
+
+def _add_text(parent: Any, text: Optional[str]) -> None:
+    if not text:
+        return
+    if len(parent):
+        parent[-1].tail = (parent[-1].tail or "") + text
+    else:
+        parent.text = (parent.text or "") + text
+
+
+def _sub(parent: Any, tag: str, text: Optional[str] = None, **attrs: str) -> Any:
+    el = ET.SubElement(parent, tag, {k: v for k, v in attrs.items() if v is not None})
+    if text:
+        el.text = text
+    return el
+
+
+class _Writer:
+    """Descriptive content of one topic; shares the ICN names and change notes of the export."""
+
+    def __init__(self, refs: Mapping[str, Dict[str, str]], icn: Callable[[str], Optional[str]],
+                 note: Callable[[str], None]) -> None:
+        self.refs, self.icn, self.note = refs, icn, note
+
+    @staticmethod
+    def _is_block(el: Any) -> bool:
+        name = local_name(el)
+        if name == "image":
+            return el.get("placement") == "break"
+        return name not in _INLINE
+
+    def blocks(self, src: Any, out: Any, skip: Tuple[str, ...] = ()) -> None:
+        """Children of *src* as blocks of *out*; loose text and inline runs are wrapped in ``para``."""
+        para: List[Any] = []
+
+        def _run() -> Any:
+            if not para:
+                para.append(_sub(out, "para"))
+            return para[0]
+
+        if (src.text or "").strip():
+            _add_text(_run(), src.text)
+        for child in src:
+            if is_element(child) and local_name(child) not in skip and local_name(child) not in _SKIPPED:
+                if self._is_block(child):
+                    para.clear()
+                    self.block(child, out)
+                else:
+                    self.inline(child, _run())
+            if para or (child.tail or "").strip():
+                _add_text(_run(), child.tail)
+
+    def block(self, el: Any, out: Any) -> None:
+        name = local_name(el)
+        if name in _SECTIONS:
+            level = _sub(out, "levelledPara")
+            if _plain(el.find("title")):
+                self.inline_children(el.find("title"), _sub(level, "title"))
+            self.blocks(el, level, skip=("title",))
+        elif name == "note":
+            self.notice(el, out)
+        elif name in ("fig", "image"):
+            self.figure(el, out)
+        elif name in _TABLES:
+            self.table(el, out)
+        elif name in _CODE or name in _LISTS or name == "dl":
+            self.inline(el, _sub(out, "para"))
+        elif name in ("p", "shortdesc", "lines", "abstract") or not any(
+                is_element(c) and self._is_block(c) for c in el):
+            if _plain(el) or any(is_element(c) for c in el):
+                self.inline_children(el, _sub(out, "para"))
+        else:
+            self.blocks(el, out)
+
+    def inline_children(self, src: Any, dst: Any) -> None:
+        _add_text(dst, src.text)
+        for child in src:
+            if is_element(child) and local_name(child) not in _SKIPPED:
+                self.inline(child, dst)
+            _add_text(dst, child.tail)
+
+    def inline(self, el: Any, dst: Any) -> None:
+        name = local_name(el)
+        if name in _EMPHASIS:
+            self.inline_children(el, _sub(dst, "emphasis", emphasisType=_EMPHASIS[name]))
+        elif name in ("sup", "sub"):
+            self.inline_children(el, _sub(dst, "superScript" if name == "sup" else "subScript"))
+        elif name in _VERBATIM or name in _CODE:
+            _sub(dst, "verbatimText", "".join(el.itertext()))
+        elif name in _LISTS:
+            self.items(el, _sub(dst, _LISTS[name]))
+        elif name == "dl":
+            self.definitions(el, _sub(dst, "definitionList"))
+        elif name == "image":
+            ident = self.icn(el.get("href") or "")
+            if ident:
+                _sub(dst, "symbol", infoEntityIdent=ident)
+            else:
+                _add_text(dst, _plain(el.find("alt")) or el.get("alt"))
+        elif name == "xref":
+            self.link(el, dst)
+        elif name == "fn":
+            para = _sub(_sub(dst, "footnote"), "para")
+            self.inline_children(el, para)
+        elif name == "q":
+            _add_text(dst, "“")
+            self.inline_children(el, dst)
+            _add_text(dst, "”")
+        elif name == "menucascade":
+            for i, item in enumerate(c for c in el if is_element(c)):
+                if i:
+                    _add_text(dst, " > ")
+                self.inline(item, dst)
+        elif name in _SKIPPED:
+            return
+        else:
+            if self._is_block(el) and name not in ("p", "cmd", "ph"):
+                self.note("block content inside paragraphs flattened to text")
+            self.inline_children(el, dst)
+
+    def link(self, el: Any, dst: Any) -> None:
+        href = el.get("href") or ""
+        text = _plain(el)
+        if el.get("scope") == "external" or href.lower().startswith(("http:", "https:", "mailto:", "ftp:")):
+            _add_text(dst, f"{text} ({href})" if text and text != href else href)
+            return
+        code = self.refs.get(posixpath.basename(href.partition("#")[0]))
+        self.inline_children(el, dst)
+        if code is not None:
+            if text:
+                _add_text(dst, " ")
+            _sub(_sub(_sub(dst, "dmRef"), "dmRefIdent"), "dmCode", **code)
+
+    def items(self, el: Any, out: Any) -> None:
+        for item in el:
+            if not is_element(item) or local_name(item) in _SKIPPED or local_name(item) in ("title", "lh"):
+                continue
+            list_item = _sub(out, "listItem")
+            self.blocks(item, list_item)
+            self._only_paras(list_item)
+
+    def definitions(self, el: Any, out: Any) -> None:
+        for entry in el:
+            if not is_element(entry) or local_name(entry) not in ("dlentry", "dlhead"):
+                continue
+            item = _sub(out, "definitionListItem")
+            term = _sub(item, "listItemTerm")
+            for dt in entry:
+                if is_element(dt) and local_name(dt) in ("dt", "dthd"):
+                    self.inline_children(dt, term)
+            definition = _sub(item, "listItemDefinition")
+            for dd in entry:
+                if is_element(dd) and local_name(dd) in ("dd", "ddhd"):
+                    self.blocks(dd, definition)
+            self._only_paras(definition)
+
+    def _only_paras(self, container: Any, allowed: Tuple[str, ...] = ("para", "note")) -> None:
+        """Reduce blocks *container* cannot hold (figures, tables) to a paragraph of their text."""
+        for child in list(container):
+            if child.tag not in allowed:
+                para = ET.Element("para")
+                para.text = _plain(child)
+                para.tail = child.tail
+                container.replace(child, para)
+                self.note(f"{child.tag} inside list items and table cells reduced to text")
+
+    def notice(self, el: Any, out: Any) -> None:
+        kind = _WARNINGS.get(el.get("type") or "", "note")
+        holder = ET.Element("levelledPara")
+        self.blocks(el, holder)
+        notice = _sub(out, kind)
+        para_tag = "notePara" if kind == "note" else "warningAndCautionPara"
+        for child in holder:
+            para = _sub(notice, para_tag)
+            if kind == "note" and child.tag == "para":
+                para.text = child.text
+                para.extend(list(child))
+            else:
+                para.text = _plain(child)
+        if not len(notice):
+            _sub(notice, para_tag)
+
+    def figure(self, el: Any, out: Any) -> None:
+        images = [el] if local_name(el) == "image" else [i for i in el.iter("image")]
+        idents = [ident for ident in (self.icn(i.get("href") or "") for i in images) if ident]
+        title = _plain(el.find("title")) or _plain(el.find("alt")) or el.get("alt") or ""
+        if not idents:
+            if title:
+                _sub(out, "para", title)
+            return
+        figure = _sub(out, "figure")
+        _sub(figure, "title", title or "Figure")
+        for ident in idents:
+            _sub(figure, "graphic", infoEntityIdent=ident)
+
+    def table(self, el: Any, out: Any) -> None:
+        table = _sub(out, "table")
+        if _plain(el.find("title")):
+            self.inline_children(el.find("title"), _sub(table, "title"))
+        if local_name(el) == "table":
+            for source in el.findall("tgroup"):
+                tgroup = _sub(table, "tgroup", cols=source.get("cols") or "1")
+                for spec in source.findall("colspec"):
+                    _sub(tgroup, "colspec", colname=spec.get("colname"), colwidth=spec.get("colwidth"))
+                for part in source:
+                    if local_name(part) in ("thead", "tbody"):
+                        self._rows(part.findall("row"), _sub(tgroup, local_name(part)))
+            return
+        rows = [r for r in el if is_element(r) and local_name(r) not in ("title", "desc")]
+        cols = max((len([c for c in r if is_element(c)]) for r in rows), default=1)
+        tgroup = _sub(table, "tgroup", cols=str(cols))
+        head = [r for r in rows if local_name(r) in ("sthead", "prophead", "chhead")]
+        if head:
+            self._rows(head, _sub(tgroup, "thead"))
+        self._rows([r for r in rows if r not in head], _sub(tgroup, "tbody"))
+
+    def _rows(self, rows: List[Any], out: Any) -> None:
+        for source in rows:
+            row = _sub(out, "row")
+            for cell in source:
+                if not is_element(cell):
+                    continue
+                entry = _sub(row, "entry", **{a: cell.get(a) for a in ("namest", "nameend", "morerows", "align",
+                                                                        "valign", "colname")})
+                self.blocks(cell, entry)
+                self._only_paras(entry)
+
+    def topic(self, topic: Any) -> Any:
+        """``description`` with one levelled paragraph titled like *topic*."""
+        description = ET.Element("description")
+        level = _sub(description, "levelledPara")
+        if _plain(topic.find("title")):
+            self.inline_children(topic.find("title"), _sub(level, "title"))
+        self.blocks(topic, level, skip=("title",))
+        _order(level, self.note)
+        return description
+
+
+def _order(level: Any, note: Callable[[str], None]) -> None:
+    """Warnings and cautions first, nested levelled paragraphs last, as the schema wants."""
+    for child in level:
+        if child.tag == "levelledPara":
+            _order(child, note)
+    position = 1 if len(level) and level[0].tag == "title" else 0
+    body = list(level)[position:]
+    notices = [c for c in body if c.tag in ("warning", "caution")]
+    if body[:len(notices)] != notices:
+        note("warnings and cautions moved to the start of their levelled paragraph")
+        for offset, child in enumerate(notices):
+            level.remove(child)
+            level.insert(position + offset, child)
+    # Blocks after a subsection go into an untitled levelled paragraph of their own
+    holder, nested = None, False
+    for child in list(level)[position + len(notices):]:
+        if child.tag == "levelledPara":
+            holder, nested = None, True
+        elif nested:
+            if holder is None:
+                holder = ET.Element("levelledPara")
+                child.addprevious(holder)
+            holder.append(child)
+
+
+# -- modules ----------------------------------------------------------------
+
+def _status(parent: Any, tag: str, settings: S1000DSettings) -> None:
+    status = _sub(parent, tag, issueType="new")
+    _sub(status, "security", securityClassification=settings.security_classification)
+    company = _sub(status, "responsiblePartnerCompany", enterpriseCode=settings.enterprise_code)
+    if settings.enterprise_name:
+        _sub(company, "enterpriseName", settings.enterprise_name)
+    originator = _sub(status, "originator", enterpriseCode=settings.enterprise_code)
+    if settings.enterprise_name:
+        _sub(originator, "enterpriseName", settings.enterprise_name)
+    _sub(_sub(_sub(status, "applic"), "displayText"), "simplePara", "All")
+    _sub(_sub(_sub(_sub(status, "brexDmRef"), "dmRef"), "dmRefIdent"), "dmCode", **_BREX)
+    _sub(_sub(status, "qualityAssurance"), "unverified")
+
+
+def _ident(parent: Any, language: Tuple[str, str], settings: S1000DSettings) -> None:
+    _sub(parent, "language", languageIsoCode=language[0], countryIsoCode=language[1])
+    _sub(parent, "issueInfo", issueNumber=settings.issue_number, inWork="00")
+
+
+def _issue_date(parent: Any, issued: date) -> None:
+    _sub(parent, "issueDate", year=f"{issued.year:04d}", month=f"{issued.month:02d}", day=f"{issued.day:02d}")
+
+
+def _data_module(code: Dict[str, str], tech_name: str, info_name: str, schema: str, content: Any,
+                 settings: S1000DSettings, language: Tuple[str, str], issued: date) -> Any:
+    root = ET.Element("dmodule", nsmap={"xsi": _XSI})
+    root.set(f"{{{_XSI}}}noNamespaceSchemaLocation", _SCHEMAS + schema)
+    address = _sub(_sub(root, "identAndStatusSection"), "dmAddress")
+    ident = _sub(address, "dmIdent")
+    _sub(ident, "dmCode", **code)
+    _ident(ident, language, settings)
+    items = _sub(address, "dmAddressItems")
+    _issue_date(items, issued)
+    title = _sub(items, "dmTitle")
+    _sub(title, "techName", tech_name or "Untitled")
+    _sub(title, "infoName", info_name)
+    _status(root.find("identAndStatusSection"), "dmStatus", settings)
+    _sub(root, "content").append(content)
+    return root
+
+
+def _dm_ref(parent: Any, module: DataModule) -> None:
+    ref = _sub(parent, "dmRef")
+    _sub(_sub(ref, "dmRefIdent"), "dmCode", **module.code)
+    title = _sub(_sub(ref, "dmRefAddressItems"), "dmTitle")
+    _sub(title, "techName", module.root.findtext("identAndStatusSection/dmAddress/dmAddressItems/dmTitle/techName"))
+    _sub(title, "infoName", module.root.findtext("identAndStatusSection/dmAddress/dmAddressItems/dmTitle/infoName"))
+
+
+def _entry_title(entry: Any, topic: Any) -> str:
+    navtitle = entry.find("topicmeta/navtitle")
+    return _plain(navtitle) or entry.get("navtitle") or (_plain(topic.find("title")) if topic is not None else "")
+
+
+def _issue_day(metadata: Mapping[str, Any]) -> date:
+    try:
+        return date.fromisoformat(str(metadata.get("revision_date") or "")[:10])
+    except ValueError:
+        return date.today()
+
+
+def export_s1000d(context: DitaContext, settings: Optional[S1000DSettings] = None) -> S1000DExport:
+    """Data modules of the topics of *context* in map order, with their DMRL and publication module.
+
+    Raises :class:`S1000DError` when the export is not enabled, a code in
+    *settings* is invalid or the map references more than 9999 topics.
+    """
+    settings = settings or S1000DSettings.from_config()
+    if not settings.enabled:
+        raise S1000DError("The S1000D export is experimental; set s1000d.enabled: true in pipeline.yml to use it")
+    invalid = settings.invalid_codes()
+    if invalid:
+        raise S1000DError("Invalid S1000D code(s) in pipeline.yml s1000d: " + ", ".join(invalid))
+    metadata = context.metadata or {}
+    tag = canonical_language_tag(metadata.get("language")) or "en-US"
+    language = (tag.split("-")[0].lower(), (tag.split("-") + ["US"])[1].upper())
+    revision = str(metadata.get("revision_number") or "")
+    if revision.isdigit() and int(revision) < 1000:
+        settings = replace(settings, issue_number=f"{int(revision):03d}")
+    issued = _issue_day(metadata)
+    export = S1000DExport()
+    icns: Dict[str, str] = {}
+
+    def _note(message: str) -> None:
+        if message not in export.changes:
+            export.changes.append(message)
+
+    def _icn(href: str) -> Optional[str]:
+        name = posixpath.basename(href)
+        if name not in context.images:
+            return None
+        if name not in icns:
+            icns[name] = f"ICN-{settings.enterprise_code}-{len(icns) + 1:05d}-001-{settings.security_classification}"
+            export.images[icns[name] + posixpath.splitext(name)[1].lower()] = context.images[name]
+        return icns[name]
+
+    def _code(number: int, info_code: str) -> Dict[str, str]:
+        return {"modelIdentCode": settings.model_ident_code, "systemDiffCode": settings.system_diff_code,
+                "systemCode": settings.system_code, "subSystemCode": settings.sub_system_code,
+                "subSubSystemCode": settings.sub_sub_system_code, "assyCode": f"{number:04d}",
+                "disassyCode": "00", "disassyCodeVariant": "A", "infoCode": info_code, "infoCodeVariant": "A",
+                "itemLocationCode": settings.item_location_code}
+
+    # Codes first, so links can point to modules later in the map
+    refs: Dict[str, Dict[str, str]] = {}
+    titles: Dict[str, str] = {}
+
+    def _walk(parent_el: Any) -> List[_Node]:
+        nodes: List[_Node] = []
+        for entry in parent_el:
+            if not is_element(entry) or local_name(entry) not in _MAP_ENTRIES:
+                continue
+            if entry.get("processing-role") == "resource-only":
+                continue
+            name = posixpath.basename((entry.get("href") or "").partition("#")[0]) or None
+            topic = context.topics.get(name) if name else None
+            title = _entry_title(entry, topic)
+            if topic is None and not title:
+                nodes.extend(_walk(entry))  # topicgroup: its children belong to the parent
+                continue
+            if topic is not None and name not in refs:
+                if len(refs) >= _MAX_MODULES:
+                    raise S1000DError(f"The map references more than {_MAX_MODULES} topics; "
+                                      "split it into several publications")
+                refs[name] = _code(len(refs) + 1, settings.info_code)
+                titles[name] = title
+            nodes.append((title, name if topic is not None else None, _walk(entry)))
+        return nodes
+
+    root = getattr(context, "ditamap_root", None)
+    outline = _walk(root) if root is not None else []
+    writer = _Writer(refs, _icn, _note)
+    modules: Dict[str, DataModule] = {}
+    for name, code in refs.items():
+        topic = context.topics[name]
+        if local_name(topic) == "task":
+            _note("tasks written as descriptive data modules (steps as sequential lists)")
+        content = writer.topic(topic)
+        module = DataModule(code, titles[name], _data_module(code, titles[name], settings.info_name, "descript.xsd",
+                                                             content, settings, language, issued), name)
+        modules[name] = module
+        export.modules.append(module)
+
+    manual = str(metadata.get("manual_title") or metadata.get("title") or "Publication")
+    dmrl = ET.Element("dmrl")
+    for module in export.modules:
+        entry = _sub(dmrl, "dmrlEntry", dmrlEntryType="n", issueType="new")
+        _dm_ref(entry, module)
+        _sub(entry, "responsiblePartnerCompany", enterpriseCode=settings.enterprise_code)
+    dmrl_code = _code(0, "00R")
+    export.dmrl = DataModule(dmrl_code, manual, _data_module(dmrl_code, manual, "Data module requirement list",
+                                                             "dmrl.xsd", dmrl, settings, language, issued))
+    export.pm = _publication(manual, outline, modules, settings, language, issued)
+    for message in export.changes:
+        logger.info("S1000D export: %s", message)
+    logger.info("S1000D export: %d data module(s), %d ICN(s)", len(export.modules), len(export.images))
+    return export
+
+
+def _publication(title: str, outline: List[_Node], modules: Mapping[str, DataModule], settings: S1000DSettings,
+                 language: Tuple[str, str], issued: date) -> Any:
+    """Publication module with the map hierarchy as ``pmEntry`` levels."""
+    root = ET.Element("pm", nsmap={"xsi": _XSI})
+    root.set(f"{{{_XSI}}}noNamespaceSchemaLocation", _SCHEMAS + "pm.xsd")
+    address = _sub(_sub(root, "identAndStatusSection"), "pmAddress")
+    ident = _sub(address, "pmIdent")
+    _sub(ident, "pmCode", modelIdentCode=settings.model_ident_code, pmIssuer=settings.enterprise_code,
+         pmNumber="00001", pmVolume="00")
+    _ident(ident, language, settings)
+    items = _sub(address, "pmAddressItems")
+    _issue_date(items, issued)
+    _sub(items, "pmTitle", title)
+    _status(root.find("identAndStatusSection"), "pmStatus", settings)
+    _pm_entries(_sub(root, "content"), outline, modules, top=True)
+    return root
+
+
+def _pm_entries(parent: Any, nodes: List[_Node], modules: Mapping[str, DataModule], *, top: bool = False) -> None:
+    for title, name, children in nodes:
+        if name is None and not children:
+            continue
+        if name is not None and not children and not top:
+            _dm_ref(parent, modules[name])
+            continue
+        entry = _sub(parent, "pmEntry")
+        _sub(entry, "pmEntryTitle", title or "Untitled")
+        if name is not None:
+            _dm_ref(entry, modules[name])
+        _pm_entries(entry, children, modules)
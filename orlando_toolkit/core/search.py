@@ -0,0 +1,88 @@
+from __future__ import annotations
+
+"""Search the structure tree by title and topic text.
+
+:func:`search_structure` returns the map entries (``topicref`` and
+``topichead``) whose title, or file name, contains the term; with
+``body_text`` the text of the topic body is searched too. The term is a
+plain substring or, with ``regex``, a Python regular expression; both match
+case-insensitively. An invalid expression raises :class:`ValueError` with a
+message fit for the search box.
+
+:func:`matching_branches` gives the entries to keep when the tree shows
+only matching branches: the matches and their ancestors.
+"""
+
+import logging
+import re
+from typing import Iterable, List, Optional, Pattern, Set
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.utils import topic_body
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["compile_query", "matching_branches", "search_structure"]
+
+_STRUCTURAL = ("topicref", "topichead")
+
+
+def compile_query(term: str, *, regex: bool = False) -> Optional[Pattern[str]]:
+    """Case-insensitive pattern for *term*; None for an empty term."""
+    term = term or ""
+    if not term.strip():
+        return None
+    if not regex:
+        return re.compile(re.escape(term), re.IGNORECASE)
+    try:
+        return re.compile(term, re.IGNORECASE)
+    except re.error as exc:
+        raise ValueError(f"Invalid pattern: {exc}") from exc
+
+
+def _entry_title(node: ET.Element, context: DitaContext) -> str:
+    navtitle = node.find("topicmeta/navtitle")
+    if navtitle is not None and "".join(navtitle.itertext()).strip():
+        return "".join(navtitle.itertext()).strip()
+    filename = (node.get("href") or "").split("/")[-1]
+    topic = context.topics.get(filename) if filename else None
+    if topic is not None and topic.find("title") is not None:
+        return "".join(topic.find("title").itertext()).strip()
+    return (node.get("navtitle") or "").strip()
+
+
+def search_structure(
+    context: DitaContext, term: str, *, regex: bool = False, body_text: bool = False
+) -> List[ET.Element]:
+    """Map entries matching *term*, in document order."""
+    pattern = compile_query(term, regex=regex)
+    root = getattr(context, "ditamap_root", None)
+    if pattern is None or root is None:
+        return []
+    matches: List[ET.Element] = []
+    for node in root.iter():
+        if getattr(node, "tag", None) not in _STRUCTURAL:
+            continue
+        href = node.get("href") or ""
+        filename = href.split("/")[-1]
+        if pattern.search(_entry_title(node, context)) or (filename and pattern.search(filename)):
+            matches.append(node)
+            continue
+        if body_text and filename:
+            body = topic_body(context.topics.get(filename))
+            if body is not None and pattern.search(" ".join("".join(body.itertext()).split())):
+                matches.append(node)
+    logger.debug("Search: term=%r regex=%s body=%s matches=%d", term, regex, body_text, len(matches))
+    return matches
+
+
+def matching_branches(matches: Iterable[ET.Element]) -> Set[ET.Element]:
+    """The matches and all their structural ancestors."""
+    keep: Set[ET.Element] = set()
+    for node in matches:
+        while node is not None and getattr(node, "tag", None) in _STRUCTURAL and node not in keep:
+            keep.add(node)
+            node = node.getparent()
+    return keep
@@ -11,6 +11,8 @@ from .structure_editing_service import StructureEditingService  # noqa: F401
 from .undo_service import UndoService  # noqa: F401
 from .preview_service import PreviewService  # noqa: F401
 from .progress_service import ProgressService  # noqa: F401
+from .publishing_service import PublishingService  # noqa: F401
+from .validation_service import ValidationService  # noqa: F401
 
 __all__: list[str] = [
     "ConversionService",
@@ -18,6 +20,8 @@ __all__: list[str] = [
     "UndoService",
     "PreviewService",
     "ProgressService",
+    "PublishingService",
+    "ValidationService",
 ]
 
 
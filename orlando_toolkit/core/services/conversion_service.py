@@ -7,14 +7,72 @@ supported documents into a DITA package. Provides a clean, stable API for
 document conversion operations through plugin architecture.
 """
 
+import hashlib
+import inspect
 import logging
 import shutil
 import tempfile
+import time
+import zipfile
 from pathlib import Path
 from typing import Dict, Any, Optional, List, Callable
 
 from orlando_toolkit.core.models import DitaContext
 from orlando_toolkit.core.utils import slugify
+from orlando_toolkit.core.cancellation import CancellationToken, OperationCancelledError, check_cancelled
+from orlando_toolkit.core.concurrency import PipelineSettings
+from orlando_toolkit.core.jobs import PackagingWorkspace, ResumeSettings
+from orlando_toolkit.core.performance import phase
+from orlando_toolkit.core.progress import emit
+from orlando_toolkit.core.time_budget import TimeBudget
+from orlando_toolkit.core.processing import resolve_conversion_options, run_processing_stages, strip_stage_hints
+from orlando_toolkit.core.processing.language import apply_document_language
+from orlando_toolkit.core.audit import get_audit_log
+from orlando_toolkit.core.content_stats import record_content_stats
+from orlando_toolkit.core.diagnostics import capture_log_warnings, merge_log_entries
+from orlando_toolkit.core.history import get_history_store
+from orlando_toolkit.core.hooks import run_hooks
+from orlando_toolkit.core.keys import apply_key_table
+from orlando_toolkit.core.reconvert import record_source_outline
+from orlando_toolkit.core.reproducible import is_reproducible, stabilize_ids
+from orlando_toolkit.core.reuse import consolidate_notices
+from orlando_toolkit.core.revisions import mark_revisions
+from orlando_toolkit.core.style_usage import record_style_usage
+from orlando_toolkit.core.templates import apply_template_profile, record_template_match
+from orlando_toolkit.core.alt_text import restore_word_alt_text
+from orlando_toolkit.core.attachments import apply_attachments
+from orlando_toolkit.core.charts import restore_word_charts
+from orlando_toolkit.core.comments import restore_word_comments
+from orlando_toolkit.core.equations import restore_word_equations
+from orlando_toolkit.core.external_media import externalize_media
+from orlando_toolkit.core.footnotes import restore_word_notes
+from orlando_toolkit.core.front_matter import KEEP_KEY, FrontMatter, record_front_matter, strip_front_matter
+from orlando_toolkit.core.index_terms import restore_word_index_terms
+from orlando_toolkit.core.internal_links import mark_word_bookmarks, resolve_bookmark_links
+from orlando_toolkit.core.style_map import heading_levels, resolve_style_rules
+from orlando_toolkit.core.tables import restore_word_tables
+from orlando_toolkit.core.text_boxes import restore_word_text_boxes
+from orlando_toolkit.core.toc_check import check_toc
+from orlando_toolkit.core.prolog_metadata import apply_prolog_metadata
+from orlando_toolkit.core.topic_templates import apply_topic_templates
+from orlando_toolkit.core.track_changes import TrackedChanges, record_tracked_changes, resolve_tracked_changes
+from orlando_toolkit.core.usage_stats import get_usage_stats
+from orlando_toolkit.core.word_fields import WordFields, record_word_fields, resolve_word_fields
+from orlando_toolkit.core.word_media import restore_word_media
+from orlando_toolkit.core.ole_objects import restore_word_objects
+from orlando_toolkit.core.word_numbering import WordHeadings, record_word_headings, resolve_word_headings, \
+    restore_word_lists
+from orlando_toolkit.core.word_source import WordSource
+from orlando_toolkit.core.xslt import apply_stylesheets
+from orlando_toolkit.core.errors import HandlerError
+from orlando_toolkit.core.archive_limits import ArchiveLimits, check_archive
+from orlando_toolkit.core.active_content import (
+    ActiveContentFindings,
+    ActiveContentPolicy,
+    inspect_container,
+    record_decisions,
+    sanitize_container,
+)
 from orlando_toolkit.core.plugins.registry import ServiceRegistry
 from orlando_toolkit.core.plugins.interfaces import DocumentHandler
 from orlando_toolkit.core.plugins.models import FileFormat
@@ -23,18 +81,46 @@ from orlando_toolkit.core.plugins.exceptions import UnsupportedFormatError
 # Core package utilities
 from orlando_toolkit.core.package_utils import (
     save_dita_package,
+    write_zip_archive,
     update_image_references_and_names,
     update_topic_references_and_names,
     prune_empty_topics,
 )
 
 # DITA import functionality  
-from orlando_toolkit.core.importers import DitaPackageImporter
+from orlando_toolkit.core.importers import DitaPackageImporter, MarkupDocumentImporter
+from orlando_toolkit.core.services.validation_service import ValidationService
+from orlando_toolkit.core.spool import spool_context
 
 logger = logging.getLogger(__name__)
 
 __all__ = ["ConversionService"]
 
+# Passes that put back what the plugin handler dropped, in order, with their conversion.yml section
+_RESTORE_PASSES = (
+    (check_toc, "toc_check"),
+    (restore_word_tables, "tables"),
+    (restore_word_lists, "lists"),
+    (mark_word_bookmarks, "links"),
+    (restore_word_notes, "footnotes"),
+    (restore_word_equations, "equations"),
+    (restore_word_charts, "charts"),
+    (restore_word_media, "media"),
+    (restore_word_objects, "ole_objects"),
+    (restore_word_text_boxes, "text_boxes"),
+    (restore_word_comments, "comments"),
+    (restore_word_index_terms, "index_terms"),
+    (restore_word_alt_text, "alt_text"),
+)
+
+
+def _file_sha256(path: Path) -> str:
+    digest = hashlib.sha256()
+    with open(path, "rb") as fh:
+        for chunk in iter(lambda: fh.read(1 << 20), b""):
+            digest.update(chunk)
+    return digest.hexdigest()
+
 
 class ConversionService:
     """Business-logic façade with zero GUI / Tkinter dependencies."""
@@ -46,6 +132,9 @@ class ConversionService:
         
         # DITA package importer for core DITA-only functionality
         self.dita_importer = DitaPackageImporter()
+
+        # Built-in Markdown/AsciiDoc intake (plugin handlers for these formats take precedence)
+        self.markup_importer = MarkupDocumentImporter()
         
         # Track whether we're in DITA-only mode (no plugins)
         self._dita_only_mode = service_registry is None
@@ -54,7 +143,9 @@ class ConversionService:
     # PUBLIC API
     # ---------------------------------------------------------------------
     def convert(self, file_path: str | Path, metadata: Dict[str, Any], 
-                progress_callback: Optional[Callable[[str], None]] = None) -> DitaContext:
+                progress_callback: Optional[Callable[[str], None]] = None,
+                *, cancel_token: Optional[CancellationToken] = None,
+                time_budget: Optional[TimeBudget] = None) -> DitaContext:
         """Convert any supported document to an in-memory DitaContext.
         
         This method finds a compatible DocumentHandler plugin for the file format
@@ -65,15 +156,125 @@ class ConversionService:
             file_path: Path to the document to convert
             metadata: Conversion metadata and configuration
             progress_callback: Optional callback for progress updates
+            cancel_token: Optional token checked between pipeline stages; handlers
+                accepting a ``cancel_token`` keyword receive it as well
+            time_budget: Optional per-job budget; defaults to ``time_budget_seconds``
+                from the pipeline configuration. Work skipped because the budget
+                ran out is recorded in ``context.report`` (marked partial)
             
         Returns:
             DitaContext containing the converted DITA archive
             
         Raises:
             UnsupportedFormatError: If no plugin can handle the file format
+            ArchiveLimitError: If a ZIP-based source exceeds the ``security.yml``
+                archive limits (size, entries, nesting, image dimensions)
+            ActiveContentRefusedError: If the source carries macros or other active
+                content the ``security.yml`` policy refuses
+            HandlerError: If the plugin handler fails (``cause`` holds its exception)
+            OperationCancelledError: If *cancel_token* was cancelled
             Exception: If conversion fails for other reasons
+
+            Errors carry a ``code`` and remediation ``hint``; see
+            ``orlando_toolkit.core.errors.describe_error``. Warnings logged while
+            converting are added to ``context.report`` under ``log``
+            (:mod:`orlando_toolkit.core.diagnostics`).
         """
-        file_path = Path(file_path)
+        audit = get_audit_log()
+        started = time.monotonic()
+        try:
+            with capture_log_warnings() as logged, phase("parse"):
+                context = self._convert_source(Path(file_path), metadata, progress_callback, cancel_token,
+                                               time_budget)
+            if getattr(context, "report", None) is not None:
+                merge_log_entries(context.report, logged)
+            # The source preview and Update from Source read the original document
+            context.metadata.setdefault("source_file", str(file_path))
+        except OperationCancelledError:
+            audit.record("convert", str(file_path), outcome="cancelled")
+            raise
+        except Exception as e:
+            audit.record("convert", str(file_path), outcome="failure", detail={"error": str(e)})
+            get_usage_stats().record_failure(e)
+            raise
+        if audit.enabled:
+            report = getattr(context, "report", None)
+            audit.record("convert", str(file_path), detail={
+                "sha256": _file_sha256(Path(file_path)),
+                "plugin": (getattr(context, "plugin_data", None) or {}).get("_source_plugin", "dita_package"),
+                "topics": len(context.topics),
+                "images": len(context.images),
+                "partial": bool(report is not None and report.partial),
+            })
+        get_history_store().record("conversion", source=file_path, context=context,
+                                   stats={"duration_seconds": round(time.monotonic() - started, 3)})
+        usage = get_usage_stats()
+        if usage.enabled:
+            try:
+                size = Path(file_path).stat().st_size
+            except OSError:
+                size = None
+            usage.record_conversion(context, source_size=size)
+        return context
+
+    def convert_set(self, file_paths: List[str | Path], metadata: Dict[str, Any],
+                    progress_callback: Optional[Callable[[str], None]] = None, *,
+                    cancel_token: Optional[CancellationToken] = None) -> List[DitaContext]:
+        """Convert several documents as one set, linking them to each other.
+
+        Each file is converted with :meth:`convert` (with a copy of
+        *metadata*, ``manual_title`` defaulting to the file name), then links
+        between members become key references; see
+        :mod:`orlando_toolkit.core.cross_links`. Contexts are returned in the
+        order of *file_paths*.
+        """
+        from orlando_toolkit.core.cross_links import resolve_cross_document_links
+
+        documents = []
+        for number, file_path in enumerate(file_paths, start=1):
+            check_cancelled(cancel_token)
+            if progress_callback:
+                progress_callback(f"Converting document {number} of {len(file_paths)}: {Path(file_path).name}")
+            job = dict(metadata)
+            job.setdefault("manual_title", Path(file_path).stem)
+            documents.append((file_path, self.convert(file_path, job, progress_callback, cancel_token=cancel_token)))
+        resolve_cross_document_links(documents)
+        return [context for _, context in documents]
+
+    def convert_combined(self, file_paths: List[str | Path], metadata: Dict[str, Any],
+                         progress_callback: Optional[Callable[[str], None]] = None, *,
+                         cancel_token: Optional[CancellationToken] = None,
+                         base_dir: Optional[str | Path] = None) -> DitaContext:
+        """Convert several documents into one context, one chapter per document.
+
+        Each file is converted with :meth:`convert` (``manual_title`` set to
+        the file name, which titles its chapter), then the results are
+        stitched in the order of *file_paths* under the hierarchy of the
+        ``combine`` pipeline settings; *metadata* applies to the combined map.
+        See :mod:`orlando_toolkit.core.combine`.
+        """
+        from orlando_toolkit.core.combine import combine_documents
+
+        if not file_paths:
+            raise ValueError("No documents to combine")
+        documents = []
+        for number, file_path in enumerate(file_paths, start=1):
+            check_cancelled(cancel_token)
+            if progress_callback:
+                progress_callback(f"Converting document {number} of {len(file_paths)}: {Path(file_path).name}")
+            job = dict(metadata)
+            job["manual_title"] = Path(file_path).stem
+            documents.append((file_path, self.convert(file_path, job, progress_callback, cancel_token=cancel_token)))
+        if progress_callback:
+            progress_callback("Combining documents...")
+        return combine_documents(documents, metadata, base_dir=base_dir)
+
+    def _convert_source(self, file_path: Path, metadata: Dict[str, Any],
+                        progress_callback: Optional[Callable[[str], None]],
+                        cancel_token: Optional[CancellationToken],
+                        time_budget: Optional[TimeBudget]) -> DitaContext:
+        """Body of :meth:`convert`, without auditing."""
+        check_cancelled(cancel_token)
         if progress_callback:
             progress_callback("Parsing document...")
         self.logger.debug("Converting document -> DITA: %s", file_path)
@@ -85,54 +286,69 @@ class ConversionService:
         if not file_path.is_file():
             raise ValueError(f"Path is not a file: {file_path}")
         
+        if time_budget is None:
+            time_budget = PipelineSettings.resolve(metadata).time_budget()
+        
         # Check for DITA package import (core functionality, available without plugins)
         if self.dita_importer.can_import(file_path):
             try:
                 self.logger.debug("Using DITA package importer for file: %s", file_path)
-                context = self.dita_importer.import_package(file_path, metadata, progress_callback)
+                context = self.dita_importer.import_package(file_path, metadata, progress_callback,
+                                                            cancel_token=cancel_token,
+                                                            time_budget=time_budget)
+                spool_context(context, file_path)
                 if progress_callback:
                     progress_callback("DITA package import successful")
                 return context
+            except OperationCancelledError:
+                self.logger.info("DITA package import cancelled: %s", file_path)
+                raise
             except Exception as e:
                 self.logger.error("DITA package import failed: %s", e)
                 raise RuntimeError(f"DITA package import failed: {e}") from e
         
+        if self.markup_importer.can_import(file_path) and (
+                self.service_registry is None or self.service_registry.find_handler_for_file(file_path) is None):
+            return self._convert_markup(file_path, metadata, progress_callback, cancel_token, time_budget)
+
         # Plugin-aware conversion
         if self.service_registry is not None:
-            # Try to find a compatible handler from plugins
-            handler = self.service_registry.find_handler_for_file(file_path)
-            if handler:
-                try:
-                    plugin_id = self._get_plugin_id_for_handler(handler)
-                    self.logger.debug("Using plugin handler from %s for conversion: %s", 
-                                    plugin_id, handler.__class__.__name__)
-                    
-                    # Call plugin handler with error boundary
-                    context = handler.convert_to_dita(file_path, metadata)
-                    
-                    if not isinstance(context, DitaContext):
-                        raise ValueError(f"Plugin handler returned invalid type: {type(context)}")
-                    
-                    # Add plugin attribution to context for UI capability checks
-                    if not hasattr(context, 'plugin_data') or context.plugin_data is None:
-                        context.plugin_data = {}
-                    context.plugin_data['_source_plugin'] = plugin_id
-                    
-                    if progress_callback:
-                        progress_callback(f"Conversion successful using plugin: {plugin_id}")
-                    return context
-                    
-                except Exception as e:
-                    plugin_id = self._get_plugin_id_for_handler(handler)
-                    self.logger.error("Plugin handler from %s failed: %s", plugin_id, e)
-                    # Re-raise with plugin context preserved
-                    raise RuntimeError(f"Conversion failed in plugin {plugin_id}: {e}") from e
-            
-            # No handler found - collect available formats for error
-            supported_formats = self.get_supported_formats()
-            extensions = [fmt.extension for fmt in supported_formats]
-            raise UnsupportedFormatError(str(file_path), extensions)
-        
+            # Untrusted containers are size-checked before anything is inflated
+            if zipfile.is_zipfile(file_path):
+                check_archive(file_path, ArchiveLimits.from_config())
+            # Macros/ActiveX are stripped (or the source refused) before any plugin sees the file
+            policy = ActiveContentPolicy.from_config()
+            findings = inspect_container(file_path)
+            sanitized_dir = tempfile.mkdtemp(prefix="otk_sanitized_")
+            try:
+                source_path = (sanitize_container(file_path, findings, policy, sanitized_dir)
+                               if findings else file_path)
+                # The Word passes below edit one parsed copy of the source, written once for the plugin
+                source = WordSource.of(source_path)
+                shared = source if source is not None else source_path
+                # Tracked insertions/deletions are resolved so the plugin sees one version
+                options = resolve_conversion_options(metadata)
+                tracked = resolve_tracked_changes(shared, options.get("track_changes"))
+                check_cancelled(cancel_token)
+                # Fields evaluated and content controls unwrapped, so the plugin sees literal text
+                fields = resolve_word_fields(shared, options.get("fields"), options.get("content_controls"))
+                check_cancelled(cancel_token)
+                # Outline-numbered and outline-level paragraphs get heading styles the plugin splits at
+                levels = heading_levels(resolve_style_rules(metadata))
+                headings = resolve_word_headings(shared, options.get("headings"), style_map=levels)
+                check_cancelled(cancel_token)
+                # Cover pages, tables of contents and revision tables never reach topic splitting
+                front_matter = strip_front_matter(shared, options.get("front_matter"), style_map=levels,
+                                                  keep=metadata.get(KEEP_KEY))
+                check_cancelled(cancel_token)
+                if source is not None:
+                    source_path = source.save(Path(sanitized_dir) / "resolved")
+                return self._convert_with_plugin(file_path, source_path, findings, policy, metadata,
+                                                 progress_callback, cancel_token, time_budget, tracked, fields,
+                                                 headings, front_matter, source)
+            finally:
+                shutil.rmtree(sanitized_dir, ignore_errors=True)
+
         # No plugin registry configured - only DITA import is supported
         else:
             self.logger.debug("Running in DITA-only mode (no plugin registry)")
@@ -140,6 +356,107 @@ class ConversionService:
             supported_formats = [fmt.extension for fmt in self.get_supported_formats()]
             raise UnsupportedFormatError(str(file_path), supported_formats)
 
+    def _convert_markup(self, file_path: Path, metadata: Dict[str, Any],
+                        progress_callback: Optional[Callable[[str], None]],
+                        cancel_token: Optional[CancellationToken],
+                        time_budget: Optional[TimeBudget]) -> DitaContext:
+        """Convert a text source with the built-in :class:`DocumentParser` for its format."""
+        parser = self.markup_importer.parser_for(file_path)
+        try:
+            context = self.markup_importer.import_document(file_path, metadata, progress_callback,
+                                                           cancel_token=cancel_token, time_budget=time_budget)
+        except OperationCancelledError:
+            self.logger.info("Conversion cancelled: %s", file_path)
+            raise
+        except Exception as e:
+            self.logger.error("Built-in %s parser failed: %s", parser.format_name, e)
+            raise HandlerError(f"Conversion failed in the built-in {parser.format_name} parser: {e}",
+                               cause=e) from e
+        check_cancelled(cancel_token)
+        spool_context(context, file_path)
+        context = run_hooks("parse", context, registry=self.service_registry, metadata=metadata)
+        context = self.finalize_conversion(context, metadata, cancel_token=cancel_token, time_budget=time_budget,
+                                           progress_callback=progress_callback)
+        if progress_callback:
+            progress_callback(f"Conversion successful using the built-in {parser.format_name} parser")
+        return context
+
+    def _convert_with_plugin(self, file_path: Path, source_path: Path, findings: ActiveContentFindings,
+                             policy: ActiveContentPolicy, metadata: Dict[str, Any],
+                             progress_callback: Optional[Callable[[str], None]],
+                             cancel_token: Optional[CancellationToken],
+                             time_budget: Optional[TimeBudget],
+                             tracked: Optional[TrackedChanges] = None,
+                             fields: Optional[WordFields] = None,
+                             headings: Optional[WordHeadings] = None,
+                             front_matter: Optional[FrontMatter] = None,
+                             source: Optional[WordSource] = None) -> DitaContext:
+        """Convert *source_path* (the sanitized or resolved copy of *file_path*, if any) with a plugin handler.
+
+        *source* is the parsed Word package at *source_path*, shared by the
+        passes that restore what the handler dropped.
+        """
+        # Try to find a compatible handler from plugins
+        handler = self.service_registry.find_handler_for_file(source_path)
+        if handler:
+            try:
+                plugin_id = self._get_plugin_id_for_handler(handler)
+                self.logger.debug("Using plugin handler from %s for conversion: %s", 
+                                plugin_id, handler.__class__.__name__)
+                
+                if source is None:
+                    source = WordSource.of(source_path)
+                shared = source if source is not None else source_path
+                # Template presets: a profile matching the document's Word template
+                metadata, template_match = apply_template_profile(shared, metadata)
+
+                # Call plugin handler with error boundary
+                context = self._call_handler(handler, source_path, metadata, progress_callback,
+                                             cancel_token, time_budget)
+                check_cancelled(cancel_token)
+                
+                if not isinstance(context, DitaContext):
+                    raise ValueError(f"Plugin handler returned invalid type: {type(context)}")
+                
+                spool_context(context, file_path)
+                # Add plugin attribution to context for UI capability checks
+                if not hasattr(context, 'plugin_data') or context.plugin_data is None:
+                    context.plugin_data = {}
+                context.plugin_data['_source_plugin'] = plugin_id
+                record_decisions(getattr(context, "report", None), findings, policy)
+                record_template_match(getattr(context, "report", None), template_match)
+                conversion_options = resolve_conversion_options(metadata)
+                for restore, key in _RESTORE_PASSES:
+                    check_cancelled(cancel_token)
+                    restore(shared, context, conversion_options.get(key))
+                check_cancelled(cancel_token)
+                record_tracked_changes(context, tracked, conversion_options.get("track_changes"))
+                record_word_fields(context, fields, conversion_options.get("content_controls"))
+                record_word_headings(context, headings)
+                record_front_matter(context, front_matter)
+                context = run_hooks("parse", context, registry=self.service_registry, metadata=metadata)
+
+                context = self.finalize_conversion(context, metadata, cancel_token=cancel_token,
+                                                   time_budget=time_budget, progress_callback=progress_callback)
+                
+                if progress_callback:
+                    progress_callback(f"Conversion successful using plugin: {plugin_id}")
+                return context
+                
+            except OperationCancelledError:
+                self.logger.info("Conversion cancelled: %s", file_path)
+                raise
+            except Exception as e:
+                plugin_id = self._get_plugin_id_for_handler(handler)
+                self.logger.error("Plugin handler from %s failed: %s", plugin_id, e)
+                # Re-raise with plugin context preserved
+                raise HandlerError(f"Conversion failed in plugin {plugin_id}: {e}", cause=e) from e
+        
+        # No handler found - collect available formats for error
+        supported_formats = self.get_supported_formats()
+        extensions = [fmt.extension for fmt in supported_formats]
+        raise UnsupportedFormatError(str(file_path), extensions)
+
     def get_supported_formats(self) -> List[FileFormat]:
         """Get all supported file formats from loaded plugins.
         
@@ -152,7 +469,7 @@ class ConversionService:
         dita_extensions = self.dita_importer.get_supported_extensions()
         for ext in dita_extensions:
             formats.append(FileFormat.from_extension(
-                ext, 'built-in', 'Zipped DITA Package Archive'
+                ext, 'built-in', self.dita_importer.get_format_description(ext)
             ))
         
         if self.service_registry is not None:
@@ -199,7 +516,7 @@ class ConversionService:
         file_path = Path(file_path)
         
         # Always check DITA package support first (core functionality)
-        if self.dita_importer.can_import(file_path):
+        if self.dita_importer.can_import(file_path) or self.markup_importer.can_import(file_path):
             return True
         
         if self.service_registry is not None:
@@ -210,6 +527,62 @@ class ConversionService:
             # No plugins available - only DITA import is supported
             return False
 
+    @staticmethod
+    def _call_handler(handler: DocumentHandler, file_path: Path, metadata: Dict[str, Any],
+                      progress_callback: Optional[Callable[[str], None]],
+                      cancel_token: Optional[CancellationToken],
+                      time_budget: Optional[TimeBudget] = None) -> DitaContext:
+        """Invoke *handler*, passing optional arguments only when it accepts them.
+
+        Older plugins implement ``convert_to_dita(file_path, metadata)``; newer ones
+        may accept ``progress_callback`` and ``cancel_token``/``time_budget`` keywords.
+        """
+        try:
+            params = inspect.signature(handler.convert_to_dita).parameters
+        except (TypeError, ValueError):
+            params = {}
+        accepts_kwargs = any(p.kind is inspect.Parameter.VAR_KEYWORD for p in params.values())
+        kwargs: Dict[str, Any] = {}
+        if progress_callback is not None and ("progress_callback" in params or accepts_kwargs):
+            kwargs["progress_callback"] = progress_callback
+        if cancel_token is not None and ("cancel_token" in params or accepts_kwargs):
+            kwargs["cancel_token"] = cancel_token
+        if time_budget is not None and ("time_budget" in params or accepts_kwargs):
+            kwargs["time_budget"] = time_budget
+        return handler.convert_to_dita(file_path, metadata, **kwargs)
+
+    def finalize_conversion(self, context: DitaContext, metadata: Optional[Dict[str, Any]] = None, *,
+                            cancel_token: Optional[CancellationToken] = None,
+                            time_budget: Optional[TimeBudget] = None,
+                            progress_callback: Optional[Callable[[str], None]] = None) -> DitaContext:
+        """Run format-agnostic processing stages on a plugin-produced context.
+
+        Called by :meth:`convert` after the handler returns; front-ends that
+        invoke handlers directly must call it themselves. Stage failures are
+        recorded in ``context.report`` and never abort the conversion; each
+        stage is announced to *progress_callback* as a ``process`` phase
+        :class:`~orlando_toolkit.core.progress.ProgressEvent`. The
+        ``topics`` conversion hooks (:mod:`orlando_toolkit.core.hooks`) run
+        next, then safety notices move to their warehouse topic when
+        ``safety_notices.enabled`` is set (:mod:`orlando_toolkit.core.reuse`);
+        the content statistics, the source style usage and the heading
+        outline used for incremental re-conversion
+        (:mod:`orlando_toolkit.core.reconvert`) are noted last.
+        """
+        if getattr(context, "report", None) is None:
+            from orlando_toolkit.core.models import ConversionReport
+            context.report = ConversionReport()
+        with phase("analysis"):
+            context = run_processing_stages(context, metadata=metadata, cancel_token=cancel_token,
+                                            time_budget=time_budget, progress_callback=progress_callback)
+            context = run_hooks("topics", context, registry=self.service_registry, metadata=metadata)
+            consolidate_notices(context, resolve_conversion_options(
+                metadata if metadata is not None else context.metadata).get("safety_notices"))
+            record_content_stats(context)
+            record_style_usage(context)
+            record_source_outline(context)
+        return context
+
     def _get_plugin_id_for_handler(self, handler: DocumentHandler) -> str:
         """Get plugin ID for a handler instance."""
         if self.service_registry is not None:
@@ -217,9 +590,24 @@ class ConversionService:
             return getattr(self.service_registry, '_get_plugin_for_handler', lambda x: 'unknown')(handler)
         return 'built-in'
 
-    def prepare_package(self, context: DitaContext) -> DitaContext:
-        """Apply final renaming of topics and images inside *context*."""
+    def prepare_package(self, context: DitaContext, *,
+                        cancel_token: Optional[CancellationToken] = None,
+                        progress_callback: Optional[Callable[[str], None]] = None) -> DitaContext:
+        """Apply final renaming of topics and images inside *context*.
+
+        *cancel_token* is checked between stages; the context may be partially
+        prepared if cancellation interrupts the call.
+        """
+        with phase("topics"):
+            return self._prepare_package(context, cancel_token=cancel_token, progress_callback=progress_callback)
+
+    def _prepare_package(self, context: DitaContext, *,
+                         cancel_token: Optional[CancellationToken] = None,
+                         progress_callback: Optional[Callable[[str], None]] = None) -> DitaContext:
+        """Body of :meth:`prepare_package`, outside the ``topics`` performance phase."""
         self.logger.info("Export: preparing content for packaging")
+        check_cancelled(cancel_token)
+        emit(progress_callback, "Preparing package...", "prepare")
         # Determine effective depth from metadata, keeping previously applied merge depth if larger
         # so we do not inadvertently reduce the structure compared to the UI state.
         # Determine base depth: prefer metadata; else compute from style analysis
@@ -269,6 +657,8 @@ class ConversionService:
                 self.logger.warning("Failed to apply depth limit: %s", _res.message)
                 # Continue with current context - packaging will still succeed
 
+        check_cancelled(cancel_token)
+
         # Handle legacy title-based exclusions separately (if still needed)
         exclude_titles = set(context.metadata.get("exclude_headings", []))
         if exclude_titles and not context.metadata.get("merged_exclude"):
@@ -297,12 +687,44 @@ class ConversionService:
             }
             context.topics = {fn: el for fn, el in context.topics.items() if fn in hrefs}
 
+        check_cancelled(cancel_token)
+
         # 3) Convert empty topics into structural headings
         context = prune_empty_topics(context)
 
+        # 3b) Point bookmark links at the topics now holding the bookmarks
+        resolve_bookmark_links(context, resolve_conversion_options(context.metadata).get("links"))
+
         # 4) Rename items
-        context = update_topic_references_and_names(context)
-        context = update_image_references_and_names(context)
+        context = update_topic_references_and_names(context, registry=self.service_registry)
+        with phase("images"):
+            context = update_image_references_and_names(context)
+        if is_reproducible(context.metadata):
+            stabilize_ids(context)
+
+        # 4a) Media served from an asset server (external_media.base_url) get their URL
+        with phase("images"):
+            externalize_media(context)
+
+        # 4b) Mark changes against a previous conversion (revisions.enabled)
+        mark_revisions(context)
+
+        # 4c) Product names and values of the key table become key references
+        apply_key_table(context)
+
+        # 4d) Prolog of the topic templates (topic_templates.enabled), from the current metadata,
+        #     then the metadata entered for the manual and its branches
+        apply_topic_templates(context)
+        apply_prolog_metadata(context)
+
+        # 4f) Default language changed in the Metadata tab after the conversion
+        apply_document_language(context)
+
+        # 4g) Attached files become resource-only entries at the end of the map
+        apply_attachments(context)
+
+        # 4e) Plugin and installed conversion hooks see the final structure
+        context = run_hooks("structure", context, registry=self.service_registry)
 
         # 5) Strip helper attributes (e.g., data-level) that are not valid DITA
         if context.ditamap_root is not None:
@@ -310,29 +732,198 @@ class ConversionService:
                 el.attrib.pop('data-level', None)
                 el.attrib.pop('data-style', None)
                 el.attrib.pop('data-origin', None)
+        strip_stage_hints(context)
+
+        # 6) Customer stylesheets (xslt conversion options) see the package as written
+        apply_stylesheets(context, resolve_conversion_options(context.metadata).get("xslt"))
+        emit(progress_callback, "Package prepared", "prepare", done=1, total=1)
         return context
 
     def write_package(self, context: DitaContext, output_zip: str | Path, *,
-                      debug_copy_dir: Optional[str | Path] = None) -> None:
+                      debug_copy_dir: Optional[str | Path] = None,
+                      cancel_token: Optional[CancellationToken] = None,
+                      progress_callback: Optional[Callable[[str], None]] = None,
+                      workspace: Optional[PackagingWorkspace] = None) -> None:
         """Write *context* to *output_zip* (a ``.zip`` path).
 
         If *debug_copy_dir* is provided, the un-zipped folder is also copied
         there for inspection.
+
+        With ``validation.block_on_errors`` in ``pipeline.yml``, a context
+        failing validation raises
+        :class:`~orlando_toolkit.core.services.validation_service.PackageValidationError`
+        and nothing is written.
+
+        When *cancel_token* is cancelled mid-write, the temporary folder is
+        removed and no partial archive is left at *output_zip*.
+
+        The package folder is a :class:`~orlando_toolkit.core.jobs.PackagingWorkspace`
+        (*workspace*, or a new one with ``resume.enabled``) so that a crash
+        can be recovered with :meth:`resume_package`; ``write`` and
+        ``archive`` phase progress events go to *progress_callback*.
         """
         output_zip = Path(output_zip)
+        audit = get_audit_log()
+        try:
+            with phase("packaging"):
+                ValidationService().check_package(context)
+                if workspace is None:
+                    check_cancelled(cancel_token)
+                    workspace = self._new_workspace(context, output_zip)
+                self._write_package(context, output_zip, debug_copy_dir, cancel_token, progress_callback,
+                                    workspace)
+        except OperationCancelledError:
+            audit.record("publish", str(output_zip), outcome="cancelled")
+            raise
+        except Exception as e:
+            audit.record("publish", str(output_zip), outcome="failure", detail={"error": str(e)})
+            raise
+        if audit.enabled and output_zip.exists():
+            report = getattr(context, "report", None)
+            audit.record("publish", str(output_zip), detail={
+                "source": context.metadata.get("source_file"),
+                "topics": len(context.topics),
+                "size_bytes": output_zip.stat().st_size,
+                "sha256": _file_sha256(output_zip),
+                "partial": bool(report is not None and report.partial),
+            })
+        if output_zip.exists():
+            get_history_store().record("package", source=context.metadata.get("source_file"),
+                                       target=output_zip, context=context,
+                                       stats={"size_bytes": output_zip.stat().st_size})
+
+    def _new_workspace(self, context: DitaContext, output_zip: Path) -> Optional[PackagingWorkspace]:
+        """Journaled workspace for packaging *context*, or ``None`` when resuming is off or impossible."""
+        settings = ResumeSettings.from_config()
+        if not settings.enabled:
+            return None
+        try:
+            workspace = PackagingWorkspace.create(settings.root)
+        except OSError as exc:
+            self.logger.warning("Packaging workspace unavailable, the package cannot be resumed: %s", exc)
+            return None
+        try:
+            workspace.begin(context, output_zip)
+        except Exception as exc:
+            self.logger.warning("Could not snapshot the package, it cannot be resumed: %s", exc)
+            workspace.discard()
+            return None
+        return workspace
+
+    def _write_package(self, context: DitaContext, output_zip: Path, debug_copy_dir: Optional[str | Path],
+                       cancel_token: Optional[CancellationToken],
+                       progress_callback: Optional[Callable[[str], None]] = None,
+                       workspace: Optional[PackagingWorkspace] = None) -> None:
+        """Body of :meth:`write_package`, without auditing."""
         self.logger.info("Export: writing ZIP package")
         self.logger.debug("Destination: %s", output_zip)
 
-        with tempfile.TemporaryDirectory(prefix="otk_") as tmp_dir:
-            save_dita_package(context, tmp_dir)
+        if workspace is None:
+            check_cancelled(cancel_token)
+            with tempfile.TemporaryDirectory(prefix="otk_") as tmp_dir:
+                self._write_package_dir(context, Path(tmp_dir), output_zip, debug_copy_dir, cancel_token,
+                                        progress_callback)
+            return
+        try:
+            check_cancelled(cancel_token)
+            self._write_package_dir(context, workspace.package_dir, output_zip, debug_copy_dir, cancel_token,
+                                    progress_callback, workspace)
+        except OperationCancelledError:
+            workspace.discard()
+            raise
+        except Exception as exc:
+            # Kept for resume_package, e.g. after a full disk
+            workspace.mark("failed", error=str(exc))
+            raise
+        workspace.discard()
+
+    def _write_package_dir(self, context: DitaContext, package_dir: Path, output_zip: Path,
+                           debug_copy_dir: Optional[str | Path], cancel_token: Optional[CancellationToken],
+                           progress_callback: Optional[Callable[[str], None]],
+                           workspace: Optional[PackagingWorkspace] = None) -> None:
+        """Write the package folder in *package_dir*, then zip it to *output_zip*."""
+        archive_started = False
+        try:
+            if workspace is None or workspace.state != "written":
+                if workspace is not None:
+                    workspace.reset_package()
+                save_dita_package(context, str(package_dir), cancel_token=cancel_token,
+                                  progress_callback=progress_callback)
+                run_hooks("package", context, registry=self.service_registry, package_dir=package_dir)
+                check_cancelled(cancel_token)
+                if workspace is not None:
+                    workspace.mark("written")
             if debug_copy_dir:
                 debug_dest = Path(debug_copy_dir)
                 if debug_dest.exists():
                     shutil.rmtree(debug_dest)
-                shutil.copytree(tmp_dir, debug_dest)
+                shutil.copytree(package_dir, debug_dest)
                 self.logger.info("Debug copy written to %s", debug_dest)
-            shutil.make_archive(output_zip.with_suffix(""), "zip", tmp_dir)
-            self.logger.info("Export OK: zip_written size_bytes=%s", str(output_zip.stat().st_size) if output_zip.exists() else "unknown")
+            archive_started = True
+            emit(progress_callback, f"Writing archive {output_zip.name}...", "archive")
+            write_zip_archive(package_dir, output_zip)
+            check_cancelled(cancel_token)
+            emit(progress_callback, f"Package written: {output_zip.name}", "archive", done=1, total=1)
+        except OperationCancelledError:
+            self.logger.info("Export cancelled before completion: %s", output_zip)
+            if archive_started:
+                try:
+                    output_zip.unlink()
+                except FileNotFoundError:
+                    pass
+                except OSError as exc:
+                    self.logger.warning("Could not remove partial archive %s: %s", output_zip, exc)
+            raise
+        self.logger.info("Export OK: zip_written size_bytes=%s", str(output_zip.stat().st_size) if output_zip.exists() else "unknown")
+        report = getattr(context, "report", None)
+        if report is not None and report.partial:
+            self.logger.warning("Exported package is partial: %s", "; ".join(report.partial_reasons))
+
+    def resume_package(self, workspace: PackagingWorkspace, *,
+                       cancel_token: Optional[CancellationToken] = None,
+                       progress_callback: Optional[Callable[[str], None]] = None) -> Path:
+        """Finish the packaging interrupted in *workspace* and return the archive path.
+
+        A package folder written completely before the interruption is only
+        zipped; otherwise the package is written again from the context
+        snapshot. The workspace is removed on success.
+        """
+        from orlando_toolkit.core.project import ProjectError
+
+        output_zip = workspace.output
+        if output_zip is None or not workspace.snapshot_path.is_file():
+            workspace.discard()
+            raise ProjectError(f"Packaging workspace {workspace.id} is incomplete and cannot be resumed")
+        self.logger.info("Export: resuming packaging of %s (%s)", output_zip, workspace.state)
+        context = workspace.load_context()
+        output_zip.parent.mkdir(parents=True, exist_ok=True)
+        self.write_package(context, output_zip, cancel_token=cancel_token, progress_callback=progress_callback,
+                           workspace=workspace)
+        return output_zip
+
+    def write_package_tree(self, context: DitaContext, target_dir: str | Path, *, force: bool = False,
+                           prune: bool = True, cancel_token: Optional[CancellationToken] = None):
+        """Write *context* as a ``maps/``, ``topics/``, ``media/`` folder tree for version control.
+
+        See :mod:`orlando_toolkit.core.package_tree`; validation and package
+        hooks run as for :meth:`write_package`. Returns the
+        :class:`~orlando_toolkit.core.package_tree.TreeWriteResult`.
+        """
+        from orlando_toolkit.core.package_tree import write_package_tree
+
+        target_dir = Path(target_dir)
+        self.logger.info("Export: writing package tree to %s", target_dir)
+        with phase("packaging"), tempfile.TemporaryDirectory(prefix="otk_") as tmp_dir:
+            ValidationService().check_package(context)
+            save_dita_package(context, tmp_dir, cancel_token=cancel_token)
+            run_hooks("package", context, registry=self.service_registry, package_dir=Path(tmp_dir))
+            check_cancelled(cancel_token)
+            result = write_package_tree(tmp_dir, target_dir, force=force, prune=prune)
+        get_audit_log().record("publish", str(target_dir), detail={
+            "source": context.metadata.get("source_file"), "topics": len(context.topics),
+            "tree": True, "written": len(result.written), "removed": len(result.removed),
+        })
+        return result
 
     # Convenience one-shot -------------------------------------------------
     def convert_and_package(
@@ -342,9 +933,18 @@ class ConversionService:
         output_zip: str | Path,
         *,
         debug_copy_dir: Optional[str | Path] = None,
+        cancel_token: Optional[CancellationToken] = None,
+        time_budget: Optional[TimeBudget] = None,
+        progress_callback: Optional[Callable[[str], None]] = None,
     ) -> Path:
-        """Full pipeline: convert document and immediately write a ZIP archive."""
-        context = self.convert(input_path, metadata)
-        context = self.prepare_package(context)
-        self.write_package(context, output_zip, debug_copy_dir=debug_copy_dir)
+        """Full pipeline: convert document and immediately write a ZIP archive.
+
+        With a *time_budget*, conversion stops early and the resulting partial
+        package is still written completely; see ``context.report``.
+        """
+        context = self.convert(input_path, metadata, progress_callback, cancel_token=cancel_token,
+                               time_budget=time_budget)
+        context = self.prepare_package(context, cancel_token=cancel_token, progress_callback=progress_callback)
+        self.write_package(context, output_zip, debug_copy_dir=debug_copy_dir, cancel_token=cancel_token,
+                           progress_callback=progress_callback)
         return Path(output_zip)
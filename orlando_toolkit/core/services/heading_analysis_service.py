@@ -13,6 +13,7 @@ environment differences lead to empty results rather than exceptions.
 
 from typing import Dict, List, Optional, Set, Tuple
 
+from orlando_toolkit.core.i18n import document_language, message
 from orlando_toolkit.core.models import DitaContext
 
 
@@ -114,7 +115,7 @@ def build_heading_occurrences(context: Optional[DitaContext]) -> Dict[str, List[
       1) topicmeta/navtitle text
       2) <title> text
       3) @href
-      4) "Untitled"
+      4) the localized "untitled" message
     """
     occurrences: Dict[str, List[Dict[str, str]]] = {}
     if context is None:
@@ -122,6 +123,7 @@ def build_heading_occurrences(context: Optional[DitaContext]) -> Dict[str, List[
     root = getattr(context, "ditamap_root", None)
     if root is None:
         return occurrences
+    untitled = message("untitled", document_language(context))
 
     def resolve_style(node: object) -> str:
         # Prefer explicit style attribute; fall back to synthesized from level
@@ -187,7 +189,7 @@ def build_heading_occurrences(context: Optional[DitaContext]) -> Dict[str, List[
         except Exception:
             href_val = None
 
-        title_final = navtitle or title_text or (href_val if href_val else "Untitled")
+        title_final = navtitle or title_text or (href_val if href_val else untitled)
         return str(title_final), (str(href_val) if href_val else None)
 
     def iter_children(node: object):
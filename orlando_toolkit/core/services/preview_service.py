@@ -6,7 +6,7 @@ from html import escape
 from typing import Optional, Dict, Any, Iterable, Tuple
 
 from orlando_toolkit.core.models import DitaContext
-from orlando_toolkit.core.preview import xml_compiler
+from orlando_toolkit.core.preview import source_section, themes, xml_compiler
 
 logger = logging.getLogger(__name__)
 
@@ -168,14 +168,24 @@ class PreviewService:
         except Exception as exc:
             return PreviewResult(success=False, content=None, message="Failed to compile XML preview.", details={"reason": "exception", "exception_type": exc.__class__.__name__})
 
-    def render_html_preview_for_node(self, context: DitaContext, node: object) -> PreviewResult:
-        """Render HTML for a topicref or topichead element without re-resolving by href."""
+    def render_html_preview_for_node(self, context: DitaContext, node: object, *,
+                                     highlight_revisions: bool = False,
+                                     misspelled: Optional[Iterable[str]] = None) -> PreviewResult:
+        """Render HTML for a topicref or topichead element without re-resolving by href.
+
+        With *highlight_revisions* the elements marked with ``@rev`` get a revision bar;
+        the words of *misspelled* are underlined. The page is styled with the
+        conversion's preview theme (``core.preview.themes``).
+        """
         if context is None or not isinstance(context, DitaContext):
             return PreviewResult(success=False, content=None, message="Invalid context.", details={"reason": "invalid_input", "field": "context"})
         if node is None or not hasattr(node, 'tag'):
             return PreviewResult(success=False, content=None, message="Invalid XML node.", details={"reason": "invalid_input", "field": "node"})
         try:
-            html = xml_compiler.render_html_preview(context, node)  # type: ignore[arg-type]
+            html = xml_compiler.render_html_preview(context, node,  # type: ignore[arg-type]
+                                                    highlight_revisions=highlight_revisions,
+                                                    misspelled=misspelled,
+                                                    theme=themes.theme_name(context.metadata))
             if isinstance(html, str):
                 return PreviewResult(success=True, content=html, message="", details=None)
             return PreviewResult(success=False, content=None, message="HTML rendering is not available.", details={"reason": "not_implemented"})
@@ -190,6 +200,26 @@ class PreviewService:
                 pass
             return PreviewResult(success=False, content=None, message="Failed to render HTML preview.", details={"reason": "exception", "exception_type": exc.__class__.__name__})
 
+    def render_source_preview_for_node(self, context: DitaContext, node: object) -> PreviewResult:
+        """Render the section of the Word source a topicref was converted from, as HTML.
+
+        Shown next to the topic preview for side-by-side comparison; see
+        ``core.preview.source_section``. Reads the source file recorded in
+        ``context.metadata["source_file"]``.
+        """
+        if context is None or not isinstance(context, DitaContext):
+            return PreviewResult(success=False, content=None, message="Invalid context.", details={"reason": "invalid_input", "field": "context"})
+        if node is None or not hasattr(node, 'tag'):
+            return PreviewResult(success=False, content=None, message="Invalid XML node.", details={"reason": "invalid_input", "field": "node"})
+        try:
+            html = source_section.render_source_section(context, node)  # type: ignore[arg-type]
+            return PreviewResult(success=True, content=html, message="", details=None)
+        except source_section.SourcePreviewUnavailable as exc:
+            return PreviewResult(success=False, content=None, message=str(exc), details={"reason": "unavailable"})
+        except Exception as exc:
+            logger.error("Source preview failed: %s", exc, exc_info=True)
+            return PreviewResult(success=False, content=None, message="Failed to render the source section.", details={"reason": "exception", "exception_type": exc.__class__.__name__})
+
     def render_html_preview(self, context: DitaContext, topic_ref: str) -> PreviewResult:
         """Render a topic as HTML suitable for quick preview.
 
@@ -396,7 +426,7 @@ class PreviewService:
         if tref_el is None:
             return {"_ni": True, "reason": "topicref_not_found", "topic_ref": topic_ref}
 
-        result = fn(context, tref_el)  # type: ignore[misc]
+        result = fn(context, tref_el, theme=themes.theme_name(context.metadata))  # type: ignore[misc]
         if isinstance(result, str):
             return result
         return {"_ni": True, "reason": "unexpected_return_type", "attempted_call": "render_html_preview"}
@@ -0,0 +1,233 @@
+from __future__ import annotations
+
+"""Publishing generated DITA archives to PDF and HTML5 through DITA-OT.
+
+:class:`PublishingService` finds a DITA-OT install (``publishing.dita_ot_home``
+in ``pipeline.yml``, ``DITA_HOME``, ``dita`` on ``PATH``, then a copy
+downloaded earlier into ``install_dir``), or downloads one from
+``download_url`` on request. :meth:`PublishingService.publish` extracts the
+archive into a :class:`~orlando_toolkit.core.external_tools.ToolWorkspace`,
+runs ``dita`` for each transtype (``pdf2``, ``html5``, …) against its map and
+copies the result next to the archive as ``<archive name>_<transtype>/``.
+
+DITA-OT runs through :class:`~orlando_toolkit.core.external_tools.ToolExecutor`,
+so ``security.yml`` ``external_tools`` applies: with ``allow_unlisted: false``
+the ``dita`` tool must be listed there. Its log lines are passed to
+``on_output`` as they are written.
+"""
+
+import logging
+import os
+import shutil
+import sys
+import tempfile
+import urllib.request
+from dataclasses import dataclass, field, replace
+from pathlib import Path
+from typing import Any, Callable, Dict, List, Mapping, Optional, Sequence, Tuple
+
+from orlando_toolkit.core.archive_limits import ArchiveLimits, safe_extract
+from orlando_toolkit.core.cancellation import CancellationToken, check_cancelled
+from orlando_toolkit.core.errors import ToolkitError
+from orlando_toolkit.core.external_tools import ToolExecutor, ToolPolicy
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["PublishingError", "PublishingService", "PublishingSettings"]
+
+_DEFAULT_URL = "https://github.com/dita-ot/dita-ot/releases/download/4.2.3/dita-ot-4.2.3.zip"
+_EXECUTABLE = "dita.bat" if sys.platform == "win32" else "dita"
+_CHUNK = 1 << 20
+# Passed through to DITA-OT when set (Java runtime location and options)
+_JAVA_ENV = ("JAVA_HOME", "JAVA_OPTS", "ANT_OPTS")
+
+
+class PublishingError(ToolkitError, RuntimeError):
+    """Raised when DITA-OT is missing, cannot be installed or fails to publish."""
+
+    code = "OTK503"
+
+
+def _default_install_dir() -> Path:
+    from orlando_toolkit.config.manager import _get_user_config_dir
+
+    base = _get_user_config_dir()
+    return (base.parent if base.name == "config" else base) / "dita-ot"
+
+
+@dataclass(frozen=True)
+class PublishingSettings:
+    dita_ot_home: Optional[str] = None
+    transtypes: Tuple[str, ...] = ("pdf2", "html5")
+    download_url: str = _DEFAULT_URL
+    install_dir: Optional[str] = None
+    timeout_seconds: float = 1800.0
+    parameters: Mapping[str, str] = field(default_factory=dict)
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "PublishingSettings":
+        data = data or {}
+        defaults = cls()
+        try:
+            return cls(
+                dita_ot_home=str(data["dita_ot_home"]) if data.get("dita_ot_home") else None,
+                transtypes=tuple(str(t) for t in data.get("transtypes") or defaults.transtypes),
+                download_url=str(data.get("download_url") or defaults.download_url),
+                install_dir=str(data["install_dir"]) if data.get("install_dir") else None,
+                timeout_seconds=float(data.get("timeout_seconds", defaults.timeout_seconds)),
+                parameters={str(k): str(v) for k, v in (data.get("parameters") or {}).items()},
+            )
+        except (TypeError, ValueError) as exc:
+            logger.warning("Invalid publishing settings (%s); using defaults", exc)
+            return defaults
+
+    @classmethod
+    def from_config(cls) -> "PublishingSettings":
+        try:
+            from orlando_toolkit.config import ConfigManager
+            return cls.from_mapping((ConfigManager().get_pipeline_config() or {}).get("publishing"))
+        except Exception as exc:
+            logger.debug("Pipeline config unavailable, using default publishing settings: %s", exc)
+            return cls()
+
+    @property
+    def install_path(self) -> Path:
+        return Path(self.install_dir).expanduser() if self.install_dir else _default_install_dir()
+
+
+def _home_of(executable: Optional[str]) -> Optional[Path]:
+    """DITA-OT folder of a ``bin/dita`` executable."""
+    if not executable:
+        return None
+    path = Path(executable).resolve()
+    return path.parent.parent if path.parent.name == "bin" else None
+
+
+def _is_home(path: Optional[Path]) -> bool:
+    return path is not None and (path / "bin" / _EXECUTABLE).is_file()
+
+
+def _find_map(folder: Path) -> Path:
+    maps = sorted(folder.glob("DATA/*.ditamap")) or sorted(folder.rglob("*.ditamap"))
+    if not maps:
+        raise PublishingError(f"No .ditamap found in {folder.name}", code="OTK102")
+    return maps[0]
+
+
+def _replace_dir(source: Path, target: Path) -> None:
+    if target.exists():
+        shutil.rmtree(target)
+    shutil.copytree(source, target)
+
+
+class PublishingService:
+    """Locates, installs and runs DITA-OT for generated archives."""
+
+    def __init__(self, settings: Optional[PublishingSettings] = None,
+                 policy: Optional[ToolPolicy] = None) -> None:
+        self.settings = settings or PublishingSettings.from_config()
+        self.policy = policy or ToolPolicy.from_config()
+
+    # ------------------------------------------------------------------
+    # Install
+    # ------------------------------------------------------------------
+
+    def locate(self) -> Optional[Path]:
+        """Return the DITA-OT folder to use, or ``None`` when there is none."""
+        candidates: List[Optional[Path]] = [
+            Path(self.settings.dita_ot_home).expanduser() if self.settings.dita_ot_home else None,
+            _home_of(self.policy.tools.get("dita")),
+            Path(os.environ["DITA_HOME"]) if os.environ.get("DITA_HOME") else None,
+            _home_of(shutil.which(_EXECUTABLE)),
+        ]
+        install = self.settings.install_path
+        if install.is_dir():
+            candidates.extend(sorted(install.iterdir(), reverse=True))
+        return next((c for c in candidates if _is_home(c)), None)
+
+    def install(self, *, on_output: Optional[Callable[[str], None]] = None,
+                cancel_token: Optional[CancellationToken] = None) -> Path:
+        """Download and unpack DITA-OT from ``download_url`` into ``install_dir``."""
+        url = self.settings.download_url
+        target = self.settings.install_path
+        target.mkdir(parents=True, exist_ok=True)
+        say = on_output or (lambda _line: None)
+        say(f"Downloading DITA-OT from {url}")
+        with tempfile.TemporaryDirectory(prefix="otk_ditaot_") as tmp:
+            archive = Path(tmp) / "dita-ot.zip"
+            try:
+                with urllib.request.urlopen(url, timeout=60) as response, open(archive, "wb") as out:
+                    total = int(response.headers.get("Content-Length") or 0)
+                    done = 0
+                    while True:
+                        check_cancelled(cancel_token)
+                        chunk = response.read(_CHUNK)
+                        if not chunk:
+                            break
+                        out.write(chunk)
+                        done += len(chunk)
+                        if done % (10 * _CHUNK) < _CHUNK:
+                            say(f"Downloaded {done >> 20} MB" + (f" of {total >> 20} MB" if total else ""))
+            except OSError as exc:
+                raise PublishingError(f"DITA-OT could not be downloaded from {url}: {exc}",
+                                      hint="Check the network connection or set publishing.dita_ot_home "
+                                           "in pipeline.yml to an existing install.", cause=exc) from exc
+            # The distribution is large and trusted: lift the per-document entry limits
+            limits = ArchiveLimits.from_config()
+            limits = replace(limits, max_entries=max(limits.max_entries, 50_000),
+                             max_uncompressed_bytes=max(limits.max_uncompressed_bytes, 2 << 30))
+            say(f"Unpacking into {target}")
+            safe_extract(archive, target, limits, cancel_token=cancel_token)
+        home = self.locate()
+        if home is None:
+            raise PublishingError(f"The download from {url} does not contain bin/{_EXECUTABLE}")
+        if os.name == "posix":
+            for script in (home / "bin").iterdir():
+                script.chmod(script.stat().st_mode | 0o111)
+        say(f"DITA-OT installed in {home}")
+        return home
+
+    # ------------------------------------------------------------------
+    # Publish
+    # ------------------------------------------------------------------
+
+    def _executor(self, home: Path) -> ToolExecutor:
+        policy = self.policy
+        if "dita" not in policy.tools and policy.allow_unlisted:
+            policy = replace(policy, tools={**policy.tools, "dita": str(home / "bin" / _EXECUTABLE)})
+        return ToolExecutor(policy)
+
+    def publish(self, archive: str | Path, transtypes: Optional[Sequence[str]] = None, *,
+                home: Optional[Path] = None, on_output: Optional[Callable[[str], None]] = None,
+                cancel_token: Optional[CancellationToken] = None) -> Dict[str, Path]:
+        """Run DITA-OT on the map in *archive*; return the output folder per transtype.
+
+        Raises :class:`PublishingError` when no DITA-OT install is found, and
+        :class:`~orlando_toolkit.core.external_tools.ToolExecutionError` when a
+        build fails.
+        """
+        archive = Path(archive)
+        home = home or self.locate()
+        if home is None:
+            raise PublishingError("DITA-OT is not installed",
+                                  hint="Set publishing.dita_ot_home in pipeline.yml, or publish from the "
+                                       "application, which offers to download it.")
+        executor = self._executor(home)
+        env = {k: os.environ[k] for k in _JAVA_ENV if os.environ.get(k)}
+        parameters = [f"--{k}={v}" for k, v in self.settings.parameters.items()]
+        outputs: Dict[str, Path] = {}
+        with executor.workspace() as workspace:
+            safe_extract(archive, workspace.path / "package", cancel_token=cancel_token)
+            ditamap = _find_map(workspace.path / "package").relative_to(workspace.path).as_posix()
+            for transtype in transtypes or self.settings.transtypes:
+                check_cancelled(cancel_token)
+                if on_output is not None:
+                    on_output(f"Publishing {transtype}…")
+                args = [f"--input={ditamap}", f"--format={transtype}", f"--output=out/{transtype}", *parameters]
+                executor.run("dita", args, workspace=workspace, timeout=self.settings.timeout_seconds, env=env,
+                             cancel_token=cancel_token, check=True, on_output=on_output)
+                target = archive.parent / f"{archive.stem}_{transtype}"
+                _replace_dir(workspace.path / "out" / transtype, target)
+                outputs[transtype] = target
+                logger.info("Published %s to %s", transtype, target)
+        return outputs
@@ -11,6 +11,7 @@ Scope and guarantees:
 - Conservative behavior with boundary checks; invalid operations return
   OperationResult(success=False, ...) with clear messaging, never raise.
 - Keeps API stable and isolates uncertain internals into helpers with TODO notes.
+- Public edits are recorded in the audit log when it is enabled (``core.audit``).
 
 This service focuses on topicref/topichead manipulation inside context.ditamap_root
 and synchronizes with context.topics when necessary.
@@ -26,12 +27,14 @@ Basic usage:
 
 """
 
+from copy import copy, deepcopy
 from dataclasses import dataclass
 import logging
-from typing import Any, Dict, List, Optional, Literal, Callable
+from typing import Any, Dict, List, Optional, Literal, Callable, Tuple
 
 from lxml import etree as ET  # type: ignore
 
+from orlando_toolkit.core.audit import audited_edit
 from orlando_toolkit.core.models import DitaContext
 
 
@@ -229,6 +232,7 @@ class StructureEditingService:
         except Exception:
             return False
 
+    @audited_edit("move_topic")
     def move_topic(
         self,
         context,
@@ -273,6 +277,7 @@ class StructureEditingService:
             return res
         return OperationResult(False, f"Unsupported move direction '{direction}'.", {"allowed": ["up", "down"]})
 
+    @audited_edit("move_consecutive_topics")
     def move_consecutive_topics(
         self,
         context,
@@ -386,6 +391,7 @@ class StructureEditingService:
             )
 
     # ---------------- XML-centric movement wrappers ----------------
+    @audited_edit("move_element_up")
     def move_element_up(self, context: DitaContext, node) -> OperationResult:
         """Move a topicref/topichead element up using intelligent algorithm."""
         try:
@@ -394,6 +400,7 @@ class StructureEditingService:
         except Exception as e:
             return OperationResult(False, "Move up failed.", {"error": str(e)})
 
+    @audited_edit("move_element_down")
     def move_element_down(self, context: DitaContext, node) -> OperationResult:
         """Move a topicref/topichead element down using intelligent algorithm."""
         try:
@@ -402,6 +409,7 @@ class StructureEditingService:
         except Exception as e:
             return OperationResult(False, "Move down failed.", {"error": str(e)})
 
+    @audited_edit("move_elements_consecutive")
     def move_elements_consecutive(self, context: DitaContext, nodes: List, direction: Literal["up", "down"]) -> OperationResult:
         """Move a consecutive run of elements preserving order (topics preferred)."""
         if not nodes:
@@ -430,6 +438,7 @@ class StructureEditingService:
         except Exception as e:
             return OperationResult(False, "Failed to move elements.", {"error": str(e)})
 
+    @audited_edit("merge_topics")
     def merge_topics(self, context, source_ids: List[str], target_id: str) -> OperationResult:
         """Manually merge selected topics into the first selected target.
 
@@ -507,6 +516,7 @@ class StructureEditingService:
             logger.error("Edit FAIL: merge_topics error=%s", e, exc_info=True)
             return OperationResult(False, "Manual merge failed.", {"error": str(e)})
 
+    @audited_edit("rename_topic")
     def rename_topic(self, context, topic_id: str, new_title: str) -> OperationResult:
         """Rename a topic by topic_id (href or filename). Canonical API uses topic_id only; topic refs/elements are not accepted."""
         logger.info("Edit: rename_topic topic=%s", topic_id)
@@ -529,6 +539,105 @@ class StructureEditingService:
         logger.warning("Edit FAIL: rename_topic topic=%s", topic_id)
         return OperationResult(False, "Rename failed.", {"topic_id": topic_id})
 
+    @audited_edit("rename_topics")
+    def rename_topics(self, context, renames: Dict[str, str]) -> OperationResult:
+        """Rename several topics at once; *renames* maps topic_id (href or filename) to the new title."""
+        logger.info("Edit: rename_topics count=%d", len(renames or {}))
+        if getattr(context, "ditamap_root", None) is None:
+            return OperationResult(False, "No ditamap available in context.", {"reason": "missing_ditamap"})
+
+        renamed: List[str] = []
+        skipped: List[str] = []
+        for topic_id, new_title in (renames or {}).items():
+            node = self._find_topic_ref(context, topic_id)
+            if node is not None and self._rename(context, node, new_title):
+                renamed.append(self._normalize_filename(topic_id))
+            else:
+                skipped.append(topic_id)
+        if renamed:
+            self._invalidate_original_structure(context)
+        details = {"renamed": len(renamed), "skipped": skipped}
+        if not renamed:
+            logger.info("Edit noop: rename_topics renamed=0")
+            return OperationResult(False, "No topics renamed.", details)
+        logger.info("Edit OK: rename_topics renamed=%d skipped=%d", len(renamed), len(skipped))
+        return OperationResult(True, f"Renamed {len(renamed)} topic(s).", details)
+
+    def batch_rename_targets(self, context, topic_ids: List[str], section_index_paths: List[List[int]],
+                             include_subtopics: bool = False) -> List[str]:
+        """Topic filenames a batch rename of the selection applies to, in map order.
+
+        Sections stand for every topic under them; topics for themselves, or
+        their whole subtree with *include_subtopics*.
+        """
+        root = getattr(context, "ditamap_root", None)
+        if root is None:
+            return []
+        wanted: set = set()
+        for topic_id in topic_ids or []:
+            node = self._find_topic_ref(context, topic_id)
+            if node is not None:
+                wanted.update(node.iter("topicref") if include_subtopics else [node])
+        for path in section_index_paths or []:
+            node = self._locate_node_by_index_path(context, path)
+            if node is not None:
+                wanted.update(node.iter("topicref"))
+        filenames: List[str] = []
+        for tref in root.iter("topicref"):
+            filename = self._normalize_filename(tref.get("href") or "")
+            if tref in wanted and filename in context.topics and filename not in filenames:
+                filenames.append(filename)
+        return filenames
+
+    @audited_edit("apply_outline")
+    def apply_outline(self, context: DitaContext, rows: List[Any]) -> OperationResult:
+        """Apply a reviewed outline (``core.outline``): titles, order and levels of every map entry.
+
+        The rows must list every entry once (see ``check_outline``); the map
+        is left untouched when they do not.
+        """
+        from orlando_toolkit.core.outline import check_outline, entry_title, outline_entries
+
+        logger.info("Edit: apply_outline rows=%d", len(rows or []))
+        root = getattr(context, "ditamap_root", None)
+        if root is None:
+            return OperationResult(False, "No ditamap available in context.", {"reason": "missing_ditamap"})
+        pairs, problems = check_outline(context, rows or [])
+        if problems:
+            logger.warning("Edit FAIL: apply_outline problems=%d", len(problems))
+            return OperationResult(False, "The outline does not match the map.", {"problems": problems})
+
+        before = {id(node): number for number, node in outline_entries(root)}
+        for _row, node in pairs:
+            node.getparent().remove(node)
+        parents: List[ET.Element] = []
+        renamed = 0
+        for row, node in pairs:
+            del parents[row.level - 1:]
+            parent = parents[-1] if parents else root
+            entries = [el for el in parent if el.tag in ("topicref", "topichead")]
+            if entries:
+                entries[-1].addnext(node)
+            elif parent is root and parent.find("reltable") is not None:
+                parent.find("reltable").addprevious(node)
+            else:
+                parent.append(node)
+            parents.append(node)
+            if node.get("data-level") != str(row.level):
+                self._apply_level_adaptation(node, row.level)
+            if entry_title(node, context) != row.title and self._rename(context, node, row.title):
+                renamed += 1
+        moved = sum(1 for number, node in outline_entries(root) if before.get(id(node)) != number)
+        details = {"renamed": renamed, "moved": moved}
+        if not renamed and not moved:
+            logger.info("Edit noop: apply_outline")
+            return OperationResult(False, "The outline has no changes.", details)
+        self._invalidate_original_structure(context)
+        logger.info("Edit OK: apply_outline renamed=%d moved=%d", renamed, moved)
+        return OperationResult(True, f"Applied the outline: {renamed} title(s) changed, {moved} entr"
+                                     f"{'y' if moved == 1 else 'ies'} renumbered.", details)
+
+    @audited_edit("delete_topics")
     def delete_topics(self, context, topic_ids: List[str]) -> OperationResult:
         """Delete topics by topic_ids (hrefs or filenames). Canonical API uses topic_ids only; topic refs/elements are not accepted."""
         logger.info("Edit: delete_topics count=%d", len(topic_ids or []))
@@ -548,6 +657,419 @@ class StructureEditingService:
             logger.info("Edit noop: delete_topics deleted=0")
         return result
 
+    @audited_edit("set_book_role")
+    def set_book_role(
+        self,
+        context: DitaContext,
+        topic_ids: List[str],
+        section_index_paths: List[List[int]],
+        role: str,
+    ) -> OperationResult:
+        """Mark top-level entries as chapter, preface or appendix for bookmap output.
+
+        Topics are given by topic_id, sections by index_path. Only direct
+        children of the map can take a role; other entries are skipped. Any
+        role other than ``chapter`` switches the job to bookmap output.
+        """
+        from orlando_toolkit.core.bookmap import BOOK_ROLES
+
+        logger.info("Edit: set_book_role role=%s", role)
+        root = getattr(context, "ditamap_root", None)
+        if root is None:
+            return OperationResult(False, "No ditamap available in context.", {"reason": "missing_ditamap"})
+        if role not in BOOK_ROLES:
+            return OperationResult(False, f"Unknown book role '{role}'.", {"role": role})
+
+        nodes = [self._find_topic_ref(context, t) for t in topic_ids or []]
+        nodes += [self._locate_node_by_index_path(context, p) for p in section_index_paths or []]
+        changed = skipped = 0
+        for node in nodes:
+            if node is None or node.getparent() is not root:
+                skipped += 1
+                continue
+            if role == "chapter":
+                node.attrib.pop("outputclass", None)
+            else:
+                node.set("outputclass", role)
+            changed += 1
+
+        details = {"role": role, "updated": changed, "skipped": skipped}
+        if not changed:
+            logger.info("Edit noop: set_book_role skipped=%d", skipped)
+            return OperationResult(False, "Book roles apply to top-level entries only.", details)
+        if role != "chapter":
+            context.metadata["map_type"] = "bookmap"
+        self._invalidate_original_structure(context)
+        logger.info("Edit OK: set_book_role role=%s updated=%d skipped=%d", role, changed, skipped)
+        return OperationResult(True, f"Marked {changed} entr{'y' if changed == 1 else 'ies'} as {role}.", details)
+
+    @audited_edit("set_branch_metadata")
+    def set_branch_metadata(
+        self,
+        context: DitaContext,
+        topic_ids: List[str],
+        section_index_paths: List[List[int]],
+        values: Any,
+    ) -> OperationResult:
+        """Store prolog metadata (author, dates, audience, ...) on the selected map entries.
+
+        *values* is a :class:`~orlando_toolkit.core.prolog_metadata.PrologMetadata`
+        or its mapping; empty values clear the entries' metadata. The topics of
+        each branch get the values in their prolog when the package is written.
+        """
+        from orlando_toolkit.core.prolog_metadata import PrologMetadata, set_entry_metadata
+
+        logger.info("Edit: set_branch_metadata")
+        if getattr(context, "ditamap_root", None) is None:
+            return OperationResult(False, "No ditamap available in context.", {"reason": "missing_ditamap"})
+        if not isinstance(values, PrologMetadata):
+            values = PrologMetadata.from_mapping(values)
+        problems = values.errors()
+        if problems:
+            return OperationResult(False, "\n".join(problems), {"reason": "invalid", "problems": problems})
+
+        nodes = [self._find_topic_ref(context, t) for t in topic_ids or []]
+        nodes += [self._locate_node_by_index_path(context, p) for p in section_index_paths or []]
+        nodes = [n for n in nodes if n is not None]
+        if not nodes:
+            return OperationResult(False, "No entries selected.", {"reason": "no_selection"})
+        for node in nodes:
+            set_entry_metadata(node, values)
+        details = {"updated": len(nodes), "cleared": not values}
+        logger.info("Edit OK: set_branch_metadata updated=%d", len(nodes))
+        verb = "Cleared" if not values else "Set"
+        return OperationResult(True, f"{verb} the metadata of {len(nodes)} entr{'y' if len(nodes) == 1 else 'ies'}.",
+                               details)
+
+    @audited_edit("set_topic_type")
+    def set_topic_type(
+        self,
+        context: DitaContext,
+        topic_ids: List[str],
+        section_index_paths: List[List[int]],
+        topic_type: Literal["task", "concept"],
+        options: Optional[Dict[str, Any]] = None,
+    ) -> OperationResult:
+        """Convert the topics of the given branches to tasks or concepts.
+
+        Each topic and section stands for its whole subtree. Concepts holding
+        exactly one procedure become tasks (``options`` are the ``procedures``
+        settings); tasks become concepts. Topics that cannot be converted are
+        counted as skipped.
+        """
+        from orlando_toolkit.core.processing.procedures import concept_to_task, retype_topicrefs, task_to_concept
+
+        logger.info("Edit: set_topic_type type=%s", topic_type)
+        root = getattr(context, "ditamap_root", None)
+        if root is None:
+            return OperationResult(False, "No ditamap available in context.", {"reason": "missing_ditamap"})
+        if topic_type not in ("task", "concept"):
+            return OperationResult(False, f"Unknown topic type '{topic_type}'.", {"topic_type": topic_type})
+
+        branches = [self._find_topic_ref(context, t) for t in topic_ids or []]
+        branches += [self._locate_node_by_index_path(context, p) for p in section_index_paths or []]
+        filenames: List[str] = []
+        for branch in branches:
+            for tref in (branch.iter("topicref") if branch is not None else ()):
+                filename = self._normalize_filename(tref.get("href") or "")
+                if filename in context.topics and filename not in filenames:
+                    filenames.append(filename)
+
+        converted: List[str] = []
+        skipped: List[str] = []
+        for filename in filenames:
+            topic = context.topics[filename]
+            if topic.tag == topic_type:
+                continue
+            try:
+                if topic_type == "task":
+                    ok = concept_to_task(topic, options, force=True) > 0
+                else:
+                    ok = topic.tag == "task"
+                    task_to_concept(topic)
+            except Exception as e:
+                logger.warning("Edit: set_topic_type failed topic=%s error=%s", filename, e)
+                ok = False
+            if ok:
+                retype_topicrefs(root, filename, topic_type)
+                converted.append(filename)
+            else:
+                skipped.append(filename)
+
+        details = {"topic_type": topic_type, "converted": converted, "skipped": skipped}
+        if not converted:
+            logger.info("Edit noop: set_topic_type skipped=%d", len(skipped))
+            reason = " (one procedure, outside sections, is needed)" if skipped and topic_type == "task" else ""
+            return OperationResult(False, f"No topic converted to {topic_type}{reason}.", details)
+        self._invalidate_original_structure(context)
+        logger.info("Edit OK: set_topic_type type=%s converted=%d skipped=%d", topic_type, len(converted), len(skipped))
+        message = f"Converted {len(converted)} topic(s) to {topic_type}"
+        return OperationResult(True, message + (f"; {len(skipped)} skipped." if skipped else "."), details)
+
+    def check_topic_source(self, context: DitaContext, topic_id: str, xml_text: str, *,
+                           validator: Optional[Any] = None) -> OperationResult:
+        """Parse and validate edited topic XML without applying it (the raw XML editor's Validate)."""
+        return self._checked_source(context, topic_id, xml_text, validator)[1]
+
+    def _checked_source(self, context: DitaContext, topic_id: str, xml_text: str,
+                        validator: Optional[Any]) -> Tuple[Optional[ET.Element], OperationResult]:
+        """``(topic, result)``: the parsed topic (None when refused) and the outcome of the checks."""
+        from orlando_toolkit.core.services.validation_service import ValidationService
+        from orlando_toolkit.core.topic_source import TopicSourceError, parse_topic_source
+
+        tref = self._find_topic_ref(context, topic_id)
+        filename = self._normalize_filename((tref.get("href") if tref is not None else None) or "")
+        if filename not in context.topics:
+            return None, OperationResult(False, f"Topic not found for id '{topic_id}'.", {"topic_id": topic_id})
+        try:
+            topic = parse_topic_source(xml_text, source=filename)
+        except TopicSourceError as e:
+            return None, OperationResult(False, str(e), {"reason": "parse_error", "line": e.line, "topic_id": topic_id})
+
+        report = (validator or ValidationService()).validate_topic(filename, topic, metadata=context.metadata)
+        details = {"topic_id": topic_id, "grammar": report.grammar, "issues": report.errors}
+        if not report.errors:
+            return topic, OperationResult(True, f"No validation errors ({report.grammar}).", details)
+        first = report.errors[0]
+        line = f" (line {first.line})" if first.line else ""
+        details.update(reason="invalid", line=first.line)
+        return topic, OperationResult(False, f"{len(report.errors)} validation error(s) ({report.grammar}); "
+                                             f"first{line}: {first.message}", details)
+
+    @audited_edit("replace_topic_source")
+    def replace_topic_source(
+        self,
+        context: DitaContext,
+        topic_id: str,
+        xml_text: str,
+        *,
+        force: bool = False,
+        validator: Optional[Any] = None,
+    ) -> OperationResult:
+        """Replace a topic with XML edited in the raw XML editor.
+
+        The text must be well-formed; it is then validated like the package
+        (``ValidationService.validate_topic``) and refused when it has errors,
+        unless *force* is set. A changed title also renames the map entry and a
+        changed root element retypes it.
+        """
+        from orlando_toolkit.core.processing.procedures import retype_topicrefs
+
+        logger.info("Edit: replace_topic_source topic=%s", topic_id)
+        root = getattr(context, "ditamap_root", None)
+        if root is None:
+            return OperationResult(False, "No ditamap available in context.", {"reason": "missing_ditamap"})
+        topic, checked = self._checked_source(context, topic_id, xml_text, validator)
+        if topic is None or not (checked.success or force):
+            logger.info("Edit FAIL: replace_topic_source topic=%s reason=%s", topic_id, checked.details.get("reason"))
+            return checked
+
+        tref = self._find_topic_ref(context, topic_id)
+        filename = self._normalize_filename(tref.get("href"))
+        previous = context.topics[filename]
+        context.topics[filename] = topic
+        title = self._title_text(topic)
+        if title and title != self._title_text(previous):
+            self._ensure_navtitle(tref, title)
+        if topic.tag != previous.tag:
+            retype_topicrefs(root, filename, topic.tag)
+        self._invalidate_original_structure(context)
+        errors = len(checked.details.get("issues") or ())
+        logger.info("Edit OK: replace_topic_source topic=%s errors=%d", filename, errors)
+        suffix = f" with {errors} validation error(s)." if errors else "."
+        details = {k: v for k, v in checked.details.items() if k not in ("reason", "line")}
+        return OperationResult(True, f"Saved the XML of '{filename}'{suffix}", details)
+
+    @staticmethod
+    def _title_text(topic: ET.Element) -> str:
+        title = topic.find("title")
+        return " ".join("".join(title.itertext()).split()) if title is not None else ""
+
+    @staticmethod
+    def _transaction(context: DitaContext, edit: Callable[[DitaContext], OperationResult]) -> OperationResult:
+        """Run *edit* on a copy of the map and topics; the copy replaces them only on success."""
+        work = copy(context)
+        work.ditamap_root = deepcopy(context.ditamap_root)
+        work.topics = {name: deepcopy(el) for name, el in context.topics.items()}
+        work.metadata = dict(context.metadata)
+        result = edit(work)
+        if result.success:
+            context.ditamap_root, context.topics, context.metadata = work.ditamap_root, work.topics, work.metadata
+        return result
+
+    @staticmethod
+    def _merge_target(tref: ET.Element, into: str) -> Optional[ET.Element]:
+        """The content-bearing entry *tref* merges into: previous structural sibling or parent."""
+        if into == "parent":
+            target = tref.getparent()
+        else:
+            target = tref.getprevious()
+            while target is not None and getattr(target, "tag", None) not in ("topicref", "topichead"):
+                target = target.getprevious()
+        if target is None or getattr(target, "tag", None) != "topicref" or not target.get("href"):
+            return None
+        return target
+
+    def restructure_options(self, context: DitaContext, topic_id: str) -> Dict[str, Any]:
+        """What a topic can be merged into (``previous``, ``parent``) and its ``split_points`` headings."""
+        from orlando_toolkit.core.merge import split_points
+
+        options: Dict[str, Any] = {"previous": False, "parent": False, "split_points": []}
+        tref = self._find_topic_ref(context, topic_id)
+        if tref is None or not tref.get("href"):
+            return options
+        for into in ("previous", "parent"):
+            options[into] = self._merge_target(tref, into) is not None
+        topic = context.topics.get(self._normalize_filename(tref.get("href")))
+        options["split_points"] = split_points(topic) if topic is not None else []
+        return options
+
+    def table_options(self, context: DitaContext, topic_id: str) -> List[Dict[str, Any]]:
+        """Tables of a topic that can move to a reference topic, and those already moved out.
+
+        Each entry has a ``label``, ``promoted`` and the ``key`` to pass to
+        :meth:`toggle_table_reference` (table position, or reference topic filename).
+        """
+        from orlando_toolkit.core.processing.reference_tables import (convertible, reference_tables, table_label,
+                                                                      topic_tables)
+
+        tref = self._find_topic_ref(context, topic_id)
+        name = self._normalize_filename(tref.get("href") or "") if tref is not None else ""
+        topic = context.topics.get(name)
+        if topic is None:
+            return []
+        entries: List[Dict[str, Any]] = [{"label": table_label(t, n), "promoted": False, "key": n}
+                                         for n, t in enumerate(topic_tables(topic)) if convertible(t)]
+        entries += [{"label": title, "promoted": True, "key": ref} for ref, title in reference_tables(context, name)]
+        return entries
+
+    @audited_edit("toggle_table_reference")
+    def toggle_table_reference(self, context: DitaContext, topic_id: str, key: Any) -> OperationResult:
+        """Move a table of a topic to a reference topic under it, or a moved table back.
+
+        *key* is the table's position among the topic's tables or the
+        filename of a reference topic listed by :meth:`table_options`. The
+        edit runs on a copy of the structure: on failure the context is unchanged.
+        """
+        from orlando_toolkit.core.processing.reference_tables import (reference_to_table, table_to_reference,
+                                                                      topic_tables)
+
+        logger.info("Edit: toggle_table_reference topic=%s key=%s", topic_id, key)
+        if getattr(context, "ditamap_root", None) is None:
+            return OperationResult(False, "No ditamap available in context.", {"reason": "missing_ditamap"})
+
+        def _edit(work: DitaContext) -> OperationResult:
+            tref = self._find_topic_ref(work, topic_id)
+            name = self._normalize_filename(tref.get("href") or "") if tref is not None else ""
+            if name not in work.topics:
+                return OperationResult(False, "Topic not found.", {"topic": topic_id})
+            if isinstance(key, str):
+                if reference_to_table(work, key) != name:
+                    return OperationResult(False, "The reference topic could not be put back.", {"topic": key})
+                return OperationResult(True, "Moved the table back into the topic.", {"topic": name, "removed": key})
+            tables = topic_tables(work.topics[name])
+            created = table_to_reference(work, name, tables[key]) if isinstance(key, int) and \
+                0 <= key < len(tables) else None
+            if created is None:
+                return OperationResult(False, "This table cannot become a reference topic (2 or 3 columns without "
+                                              "merged cells are needed).", {"topic": name, "table": key})
+            return OperationResult(True, "Moved the table to a reference topic.", {"topic": name, "created": created})
+
+        try:
+            result = self._transaction(context, _edit)
+        except Exception as e:
+            logger.error("Edit FAIL: toggle_table_reference error=%s", e, exc_info=True)
+            return OperationResult(False, "Table change failed.", {"error": str(e)})
+        if result.success:
+            self._invalidate_original_structure(context)
+            logger.info("Edit OK: toggle_table_reference topic=%s details=%s", result.details["topic"], result.details)
+        return result
+
+    @audited_edit("merge_topic_with_neighbor")
+    def merge_topic_with_neighbor(
+        self,
+        context: DitaContext,
+        topic_id: str,
+        into: Literal["previous", "parent"],
+    ) -> OperationResult:
+        """Merge a topic into the previous topic at its level, or into its parent topic.
+
+        The title becomes a merged-title paragraph in the target, entries below
+        the topic are kept, and links to the topic or its elements follow the
+        content. The edit runs on a copy of the structure: on failure the
+        context is unchanged.
+        """
+        from orlando_toolkit.core.merge import merge_topicref_into_neighbor
+
+        logger.info("Edit: merge_topic_with_neighbor topic=%s into=%s", topic_id, into)
+        if getattr(context, "ditamap_root", None) is None:
+            return OperationResult(False, "No ditamap available in context.", {"reason": "missing_ditamap"})
+        if into not in ("previous", "parent"):
+            return OperationResult(False, f"Unknown merge target '{into}'.", {"into": into})
+
+        def _edit(work: DitaContext) -> OperationResult:
+            tref = self._find_topic_ref(work, topic_id)
+            if tref is None or not tref.get("href"):
+                return OperationResult(False, "Topic not found.", {"topic": topic_id})
+            target = self._merge_target(tref, into)
+            if target is None:
+                reason = "No previous topic to merge with." if into == "previous" else "The parent is not a topic."
+                return OperationResult(False, reason, {"topic": topic_id, "into": into})
+            merged = merge_topicref_into_neighbor(work, tref, target)
+            if merged is None:
+                return OperationResult(False, "Topic content not found.", {"topic": topic_id})
+            target_name = self._normalize_filename(target.get("href"))
+            return OperationResult(True, f"Merged topic into the {into} topic.",
+                                   {"merged": merged, "target": target_name, "into": into})
+
+        try:
+            result = self._transaction(context, _edit)
+        except Exception as e:
+            logger.error("Edit FAIL: merge_topic_with_neighbor error=%s", e, exc_info=True)
+            return OperationResult(False, "Merge failed.", {"error": str(e)})
+        if result.success:
+            self._invalidate_original_structure(context)
+            logger.info("Edit OK: merge_topic_with_neighbor merged=%s target=%s",
+                        result.details["merged"], result.details["target"])
+        return result
+
+    @audited_edit("split_topic")
+    def split_topic(self, context: DitaContext, topic_id: str, point: int) -> OperationResult:
+        """Split a topic at one of its headings (see ``core.merge.split_points``).
+
+        The content from the heading on moves to a new topic that follows the
+        split one and takes over its child entries. The edit runs on a copy of
+        the structure: on failure the context is unchanged.
+        """
+        from orlando_toolkit.core.merge import split_points, split_topic_at
+
+        logger.info("Edit: split_topic topic=%s point=%s", topic_id, point)
+        if getattr(context, "ditamap_root", None) is None:
+            return OperationResult(False, "No ditamap available in context.", {"reason": "missing_ditamap"})
+
+        def _edit(work: DitaContext) -> OperationResult:
+            tref = self._find_topic_ref(work, topic_id)
+            name = self._normalize_filename(tref.get("href") or "") if tref is not None else ""
+            if name not in work.topics:
+                return OperationResult(False, "Topic not found.", {"topic": topic_id})
+            headings = split_points(work.topics[name])
+            created = split_topic_at(work, tref, point) if isinstance(point, int) else None
+            if created is None:
+                return OperationResult(False, "No heading to split at.", {"topic": name, "point": point})
+            return OperationResult(True, f"Split '{headings[point]}' into a new topic.",
+                                   {"topic": name, "created": created})
+
+        try:
+            result = self._transaction(context, _edit)
+        except Exception as e:
+            logger.error("Edit FAIL: split_topic error=%s", e, exc_info=True)
+            return OperationResult(False, "Split failed.", {"error": str(e)})
+        if result.success:
+            self._invalidate_original_structure(context)
+            logger.info("Edit OK: split_topic topic=%s created=%s", result.details["topic"], result.details["created"])
+        return result
+
+    @audited_edit("apply_depth_limit")
     def apply_depth_limit(self, context, depth_limit: int, style_exclusions: dict[int, set[str]] | None = None) -> OperationResult:
         """Apply a depth limit merge to the current context with reversible behavior.
 
@@ -583,7 +1105,7 @@ class StructureEditingService:
 
             # 6) Apply merge on clean original structure
             from orlando_toolkit.core.merge import merge_topics_unified  # local import by design
-            merge_topics_unified(context, depth_limit, style_exclusions)
+            merge_topics_unified(context, depth_limit, style_exclusions, context.metadata.get("depth_overrides"))
             
             result = OperationResult(True, "Applied depth limit", {"depth_limit": depth_limit, "merged": True})
             # Merge summary for diagnostics
@@ -601,10 +1123,113 @@ class StructureEditingService:
             logger.error("Edit FAIL: apply_depth_limit depth=%d error=%s", depth_limit, e, exc_info=True)
             return OperationResult(False, "Failed to apply depth limit", {"error": str(e)})
 
+    @audited_edit("set_branch_depth")
+    def set_branch_depth(
+        self,
+        context: DitaContext,
+        topic_ids: List[str],
+        section_index_paths: List[List[int]],
+        depth: Optional[int],
+        style_exclusions: dict[int, set[str]] | None = None,
+        depth_limit: Optional[int] = None,
+    ) -> OperationResult:
+        """Give the selected branches their own split depth; ``None`` returns them to the global depth.
+
+        Overrides are kept in ``metadata["depth_overrides"]`` by branch title path, so they are
+        saved with the project and honoured by every later depth merge. Only the selected
+        subtrees are rebuilt from the original structure; the rest of the map is left as is.
+        *depth_limit* is the global depth (defaults to the one last applied).
+        """
+        from orlando_toolkit.core.merge import branch_key, merge_topics_unified  # local import by design
+
+        logger.info("Edit: set_branch_depth depth=%s", depth)
+        root = getattr(context, "ditamap_root", None)
+        if root is None:
+            return OperationResult(False, "No ditamap available in context.", {"reason": "missing_ditamap"})
+        nodes = self._selection_roots(context, topic_ids, section_index_paths)
+        if not nodes:
+            return OperationResult(False, "No entries selected.", {"depth": depth})
+        levels = [self._node_level(n) for n in nodes]
+        if depth is not None and int(depth) < max(levels):
+            return OperationResult(False, f"Split depth must be at least {max(levels)} for the selected entries.",
+                                   {"depth": depth, "minimum": max(levels)})
+        if depth_limit is None:
+            depth_limit = context.metadata.get("merged_depth") or context.metadata.get("topic_depth")
+        if depth_limit is None:
+            depth_limit = max([self._node_level(n) for n in root.iter() if n.tag in ("topicref", "topichead")] or [1])
+
+        try:
+            keys = [branch_key(n) for n in nodes]
+            overrides = dict(context.metadata.get("depth_overrides") or {})
+            for key in keys:
+                if depth is None:
+                    overrides.pop(key, None)
+                else:
+                    overrides[key] = int(depth)
+            if overrides:
+                context.metadata["depth_overrides"] = overrides
+            else:
+                context.metadata.pop("depth_overrides", None)
+
+            # Rebuild each branch from the pre-merge structure, then merge that branch alone
+            context.save_original_structure()
+            original = context.metadata.get("original_structure") or {}
+            for key in keys:
+                node = self._find_branch(context.ditamap_root, key)
+                if node is None:
+                    continue
+                node = self._restore_branch(context, node, key, original)
+                merge_topics_unified(context, int(depth_limit), style_exclusions, overrides, branch=node)
+        except Exception as e:
+            logger.error("Edit FAIL: set_branch_depth error=%s", e, exc_info=True)
+            return OperationResult(False, "Failed to apply the branch depth.", {"error": str(e)})
+
+        details = {"depth": depth, "branches": keys, "depth_limit": int(depth_limit)}
+        logger.info("Edit OK: set_branch_depth depth=%s branches=%d", depth, len(keys))
+        if depth is None:
+            return OperationResult(True, f"{len(keys)} branch(es) follow the global depth again.", details)
+        return OperationResult(True, f"Split depth {depth} set on {len(keys)} branch(es).", details)
+
+    @staticmethod
+    def _node_level(node: ET.Element) -> int:
+        try:
+            return int(node.get("data-level") or 1)
+        except (TypeError, ValueError):
+            return 1
+
+    @staticmethod
+    def _find_branch(root: Optional[ET.Element], key: str) -> Optional[ET.Element]:
+        """First topicref/topichead under *root* whose title path is *key*."""
+        from orlando_toolkit.core.merge import branch_key
+
+        if root is None:
+            return None
+        for node in root.iter():
+            if getattr(node, "tag", None) in ("topicref", "topichead") and branch_key(node) == key:
+                return node
+        return None
+
+    def _restore_branch(self, context: DitaContext, node: ET.Element, key: str, original: Dict[str, Any]) -> ET.Element:
+        """Swap *node* for its pre-merge copy (with its topics); keep *node* when there is none."""
+        source = self._find_branch(original.get("ditamap_root"), key)
+        parent = node.getparent()
+        if source is None or parent is None:
+            return node
+        restored = deepcopy(source)
+        parent.replace(node, restored)
+        original_topics = original.get("topics") or {}
+        for tref in restored.iter("topicref"):
+            fname = (tref.get("href") or "").split("/")[-1]
+            if fname in original_topics:
+                context.topics[fname] = deepcopy(original_topics[fname])
+        self._purge_unreferenced_topics(context)
+        return restored
+
     # -------------------------------------------------------------------------
     # Direct move operations to a destination
     # -------------------------------------------------------------------------
 
+    @audited_edit("move_topics_to_target")
     def move_topics_to_target(
         self,
         context: DitaContext,
@@ -703,6 +1328,7 @@ class StructureEditingService:
             logger.error("Edit FAIL: move_topics_to_target error=%s", e, exc_info=True)
             return OperationResult(False, "Failed to move topics to destination.", {"error": str(e)})
 
+    @audited_edit("move_section_to_target")
     def move_section_to_target(
         self,
         context: DitaContext,
@@ -755,6 +1381,7 @@ class StructureEditingService:
             logger.error("Edit FAIL: move_section_to_target error=%s", e, exc_info=True)
             return OperationResult(False, "Failed to move section to destination.", {"error": str(e)})
 
+    @audited_edit("move_sections_to_target")
     def move_sections_to_target(
         self,
         context: DitaContext,
@@ -834,6 +1461,7 @@ class StructureEditingService:
             logger.error("Edit FAIL: move_sections_to_target error=%s", e, exc_info=True)
             return OperationResult(False, "Failed to move sections to destination.", {"error": str(e)})
 
+    @audited_edit("move_mixed_selection_to_target")
     def move_mixed_selection_to_target(
         self,
         context: DitaContext,
@@ -958,6 +1586,241 @@ class StructureEditingService:
             logger.error("Edit FAIL: move_mixed_selection_to_target error=%s", e, exc_info=True)
             return OperationResult(False, "Failed to move mixed selection to destination.", {"error": str(e)})
 
+    # -------------------------------------------------------------------------
+    # Group operations on multi-selections (topics + sections)
+    # -------------------------------------------------------------------------
+
+    def _selection_roots(
+        self, context: DitaContext, topic_ids: List[str], section_index_paths: List[List[int]]
+    ) -> List[ET.Element]:
+        """Selected entries in document order, leaving out those nested under another selected entry."""
+        root = getattr(context, "ditamap_root", None)
+        if root is None:
+            return []
+        nodes = [self._find_topic_ref(context, t) for t in topic_ids or []]
+        nodes += [self._locate_node_by_index_path(context, p) for p in section_index_paths or []]
+        selected = {id(n): n for n in nodes if n is not None and n is not root}
+        roots: List[ET.Element] = []
+        for node in root.iter():
+            if id(node) in selected and not any(self._is_ancestor(r, node) for r in roots):
+                roots.append(node)
+        return roots
+
+    def _relevel_subtree(self, node: ET.Element, target_level: int) -> None:
+        """Give *node* target_level and shift the levels of its descendants by the same amount."""
+        try:
+            delta = target_level - int(node.get("data-level") or target_level)
+        except (TypeError, ValueError):
+            delta = 0
+        self._apply_level_adaptation(node, target_level)
+        for child in node.iter():
+            if child is node or getattr(child, "tag", None) not in ("topicref", "topichead"):
+                continue
+            try:
+                level = int(child.get("data-level") or 0)
+            except (TypeError, ValueError):
+                continue
+            if level:
+                self._apply_level_adaptation(child, max(1, level + delta))
+
+    @audited_edit("delete_selection")
+    def delete_selection(
+        self, context: DitaContext, topic_ids: List[str], section_index_paths: List[List[int]]
+    ) -> OperationResult:
+        """Delete the selected topics and sections (with their subtrees) in one edit."""
+        logger.info("Edit: delete_selection topics=%d sections=%d",
+                    len(topic_ids or []), len(section_index_paths or []))
+        if getattr(context, "ditamap_root", None) is None:
+            return OperationResult(False, "No ditamap available in context.", {"reason": "missing_ditamap"})
+        roots = self._selection_roots(context, topic_ids, section_index_paths)
+        if not roots:
+            return OperationResult(False, "No elements to delete.")
+        for node in roots:
+            node.getparent().remove(node)
+        self._purge_unreferenced_topics(context)
+        self._invalidate_original_structure(context)
+        logger.info("Edit OK: delete_selection deleted=%d", len(roots))
+        return OperationResult(True, f"Deleted {len(roots)} element(s).", {"deleted": len(roots)})
+
+    @audited_edit("shift_selection_level")
+    def shift_selection_level(
+        self,
+        context: DitaContext,
+        topic_ids: List[str],
+        section_index_paths: List[List[int]],
+        direction: Literal["promote", "demote"],
+    ) -> OperationResult:
+        """Promote or demote the selected entries by one level, keeping their order.
+
+        Promoting moves an entry after its parent; demoting makes it the last
+        child of the previous entry at the same level. Consecutive selected
+        siblings therefore move as a group. Entries that cannot move (already at
+        the top level, or without a previous entry) are skipped.
+        """
+        logger.info("Edit: shift_selection_level direction=%s", direction)
+        root = getattr(context, "ditamap_root", None)
+        if root is None:
+            return OperationResult(False, "No ditamap available in context.", {"reason": "missing_ditamap"})
+        if direction not in ("promote", "demote"):
+            return OperationResult(False, f"Unknown direction '{direction}'.", {"direction": direction})
+
+        roots = self._selection_roots(context, topic_ids, section_index_paths)
+        moved = 0
+        if direction == "promote":
+            # Reverse order: each entry lands right after its old parent, so the group keeps its order
+            for node in reversed(roots):
+                parent = node.getparent()
+                grandparent = parent.getparent() if parent is not None else None
+                if parent is None or parent is root or grandparent is None:
+                    continue
+                self._reparent_node(node, parent, grandparent, list(grandparent).index(parent) + 1)
+                self._relevel_subtree(node, self._calculate_target_level(node, grandparent))
+                moved += 1
+        else:
+            for node in roots:
+                target = node.getprevious()
+                while target is not None and getattr(target, "tag", None) not in ("topicref", "topichead"):
+                    target = target.getprevious()
+                if target is None:
+                    continue
+                self._reparent_node(node, node.getparent(), target, 10**9)
+                self._relevel_subtree(node, self._calculate_target_level(node, target))
+                moved += 1
+
+        details = {"direction": direction, "moved": moved, "skipped": len(roots) - moved}
+        if not moved:
+            logger.info("Edit noop: shift_selection_level direction=%s", direction)
+            return OperationResult(False, f"Cannot {direction} selection.", details)
+        self._invalidate_original_structure(context)
+        logger.info("Edit OK: shift_selection_level direction=%s moved=%d", direction, moved)
+        return OperationResult(True, f"{direction.capitalize()}d {moved} element(s).", details)
+
+    @audited_edit("apply_style")
+    def apply_style(
+        self, context: DitaContext, topic_ids: List[str], section_index_paths: List[List[int]], style: str
+    ) -> OperationResult:
+        """Set the heading style (``data-style``) of every selected topic and section."""
+        logger.info("Edit: apply_style style=%s", style)
+        if getattr(context, "ditamap_root", None) is None:
+            return OperationResult(False, "No ditamap available in context.", {"reason": "missing_ditamap"})
+        style = (style or "").strip()
+        if not style:
+            return OperationResult(False, "No style given.")
+        nodes = [self._find_topic_ref(context, t) for t in topic_ids or []]
+        nodes += [self._locate_node_by_index_path(context, p) for p in section_index_paths or []]
+        changed = 0
+        for node in nodes:
+            if node is not None and node.get("data-style") != style:
+                node.set("data-style", style)
+                changed += 1
+        if not changed:
+            logger.info("Edit noop: apply_style style=%s", style)
+            return OperationResult(False, f"Selection already uses {style}.", {"style": style, "updated": 0})
+        self._invalidate_original_structure(context)
+        logger.info("Edit OK: apply_style style=%s updated=%d", style, changed)
+        return OperationResult(True, f"Applied {style} to {changed} element(s).", {"style": style, "updated": changed})
+
+    @audited_edit("move_selection_to_position")
+    def move_selection_to_position(
+        self,
+        context: DitaContext,
+        topic_ids: List[str],
+        section_index_paths: List[List[int]],
+        target_index_path: List[int],
+        position: Literal["before", "after", "inside"],
+    ) -> OperationResult:
+        """Move the selected entries before, after or inside the target entry (drag-and-drop).
+
+        The selection keeps its document order and each moved entry takes the
+        level of its new place; moving into the selection's own subtree fails.
+        """
+        logger.info("Edit: move_selection_to_position dest=%s position=%s", str(target_index_path), position)
+        if getattr(context, "ditamap_root", None) is None:
+            return OperationResult(False, "No ditamap available in context.", {"reason": "missing_ditamap"})
+        if position not in ("before", "after", "inside"):
+            return OperationResult(False, f"Unknown position '{position}'.", {"position": position})
+        target_path = list(target_index_path or [])
+        target = self._locate_node_by_index_path(context, target_path) if target_path else None
+        if target is None:
+            return OperationResult(False, "Destination not found.", {"target_index_path": target_path})
+        roots = self._selection_roots(context, topic_ids, section_index_paths)
+        if not roots:
+            return OperationResult(False, "No elements to move.")
+        if any(self._is_ancestor(node, target) for node in roots):
+            return OperationResult(False, "Cannot move element into its own descendant.")
+
+        dest_parent = target if position == "inside" else target.getparent()
+        for node in roots:
+            node.getparent().remove(node)
+        index = len(dest_parent) if position == "inside" else list(dest_parent).index(target) + (position == "after")
+        for offset, node in enumerate(roots):
+            dest_parent.insert(index + offset, node)
+            self._relevel_subtree(node, self._calculate_target_level(node, dest_parent))
+
+        self._invalidate_original_structure(context)
+        logger.info("Edit OK: move_selection_to_position moved=%d", len(roots))
+        return OperationResult(True, f"Moved {len(roots)} element(s).", {"count": len(roots), "position": position})
+
+    def copy_selection(
+        self, context: DitaContext, topic_ids: List[str], section_index_paths: List[List[int]]
+    ):
+        """Clip of the selected entries with their topics and media (``core.structure_clipboard``)."""
+        from orlando_toolkit.core.structure_clipboard import copy_entries
+
+        roots = self._selection_roots(context, topic_ids, section_index_paths)
+        return copy_entries(context, roots, source=str(context.metadata.get("manual_title") or ""))
+
+    @audited_edit("paste_clip")
+    def paste_clip(
+        self,
+        context: DitaContext,
+        clip: Any,
+        target_index_path: List[int],
+        position: Literal["before", "after", "inside"] = "after",
+    ) -> OperationResult:
+        """Paste a structure clip before, after or inside the target entry (at the end of an empty map).
+
+        Topic files, media, topic ids and bookmarks already used here are
+        renamed; pasted entries take the level of their new place.
+        """
+        from orlando_toolkit.core.structure_clipboard import paste_clip
+
+        logger.info("Edit: paste_clip dest=%s position=%s", str(target_index_path), position)
+        if getattr(context, "ditamap_root", None) is None:
+            return OperationResult(False, "No ditamap available in context.", {"reason": "missing_ditamap"})
+        if not clip:
+            return OperationResult(False, "The clipboard is empty.", {"reason": "empty_clipboard"})
+        if position not in ("before", "after", "inside"):
+            return OperationResult(False, f"Unknown position '{position}'.", {"position": position})
+        target_path = list(target_index_path or [])
+
+        def _edit(work: DitaContext) -> OperationResult:
+            target = self._locate_node_by_index_path(work, target_path) if target_path else None
+            if target_path and target is None:
+                return OperationResult(False, "Destination not found.", {"target_index_path": target_path})
+            if target is None:
+                parent, index = work.ditamap_root, None
+            elif position == "inside":
+                parent, index = target, None
+            else:
+                parent = target.getparent()
+                index = list(parent).index(target) + (position == "after")
+            pasted = paste_clip(work, clip, parent, index)
+            for node in pasted["entries"]:
+                self._relevel_subtree(node, self._calculate_target_level(node, parent))
+            self._invalidate_original_structure(work)
+            details = {key: value for key, value in pasted.items() if key != "entries"}
+            message = f"Pasted {len(pasted['entries'])} element(s) with {pasted['topics']} topic(s)."
+            if pasted["renamed"] or pasted["ids"]:
+                message += f" {pasted['renamed']} file(s) and {pasted['ids']} id(s) renamed to avoid conflicts."
+            if pasted["outside"]:
+                message += f" {pasted['outside']} link(s) point to topics that were not copied."
+            return OperationResult(True, message, details)
+
+        result = self._transaction(context, _edit)
+        logger.info("Edit %s: paste_clip %s", "OK" if result.success else "FAIL", result.message)
+        return result
+
     # -------------------------------------------------------------------------
     # Internal helpers (non-destructive, isolated)
     # -------------------------------------------------------------------------
@@ -1663,6 +2526,7 @@ class StructureEditingService:
 
     # ----------------------- Section operations -----------------------
 
+    @audited_edit("insert_section_after_index_path")
     def insert_section_after_index_path(self, context: DitaContext, index_path: List[int], title: str) -> OperationResult:
         """Insert a new section (topichead) directly below the structural node at index_path.
 
@@ -1746,6 +2610,7 @@ class StructureEditingService:
             logger.error("Edit FAIL: insert_section_after_index_path error=%s", e, exc_info=True)
             return OperationResult(False, "Failed to insert section.", {"error": str(e)})
 
+    @audited_edit("insert_section_as_first_child")
     def insert_section_as_first_child(self, context: DitaContext, index_path: List[int], title: str) -> OperationResult:
         """Insert a new section (topichead) as the first structural child of the section at index_path.
 
@@ -1807,6 +2672,7 @@ class StructureEditingService:
             logger.error("Edit FAIL: insert_section_as_first_child error=%s", e, exc_info=True)
             return OperationResult(False, "Failed to insert section as first child.", {"error": str(e)})
 
+    @audited_edit("convert_section_to_topic")
     def convert_section_to_topic(self, context: DitaContext, index_path: List[int]) -> OperationResult:
         """Convert a section (topichead) located by index_path into a topic that hosts its subtree.
 
@@ -1847,6 +2713,7 @@ class StructureEditingService:
             logger.error("Edit FAIL: convert_section_to_topic error=%s", e, exc_info=True)
             return OperationResult(False, "Failed to convert section.", {"error": str(e)})
 
+    @audited_edit("rename_section")
     def rename_section(self, context: DitaContext, index_path: List[int], new_title: str) -> OperationResult:
         """Rename the navtitle of a section (topichead) located by index_path."""
         logger.info("Edit: rename_section index_path=%s", str(index_path))
@@ -1891,6 +2758,7 @@ class StructureEditingService:
         except Exception:
             return None
 
+    @audited_edit("delete_section")
     def delete_section(self, context: DitaContext, index_path: List[int]) -> OperationResult:
         """Delete a section (topichead) and its subtree; then purge unreferenced topics."""
         logger.info("Edit: delete_section index_path=%s", str(index_path))
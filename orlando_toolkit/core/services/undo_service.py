@@ -13,6 +13,11 @@ Design principles
 - Snapshots are immutable blobs once stored.
 - Redo stack is cleared on every new snapshot push (standard undo/redo behavior).
 - Memory usage controlled by a max_history ring-like policy (trim oldest).
+- Each snapshot carries the label of the operation that produced it, so the
+  stack doubles as an operation journal (``journal()``, ``undo_label()``).
+- A snapshot may also carry opaque UI state (depth limit, style filters and
+  markers) that callers restore after undo/redo; pushing a state identical to
+  the current one is a no-op.
 
 Notes
 -----
@@ -22,13 +27,16 @@ lxml.etree.tostring() for map and topics, while copying images and metadata.
 
 """
 
-from dataclasses import dataclass
+from dataclasses import dataclass, field
 import logging
-from typing import Optional, List, Dict, Any, Tuple
+import time
+from typing import Optional, List, Dict, Any, MutableMapping, Tuple
 
 from lxml import etree as ET
 
 from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.spool import copy_blobs
+from orlando_toolkit.core.xml_security import parse_bytes
 
 logger = logging.getLogger(__name__)
 
@@ -50,12 +58,26 @@ class _Snapshot:
         Mapping of image filename -> raw bytes (copied reference).
     metadata :
         Shallow-copied metadata dictionary.
+    label :
+        Operation that produced this state, or None for a baseline.
+    state :
+        Caller-provided UI state restored alongside the context.
+    timestamp :
+        Creation time (seconds since the epoch).
     """
 
     ditamap_xml: Optional[bytes]
     topics_xml: Dict[str, bytes]
-    images: Dict[str, bytes]
+    images: MutableMapping[str, bytes]
     metadata: Dict[str, Any]
+    label: Optional[str] = None
+    state: Dict[str, Any] = field(default_factory=dict)
+    timestamp: float = field(default_factory=time.time)
+
+    def same_content(self, other: "_Snapshot") -> bool:
+        """Return True if *other* captures the same context state."""
+        return (self.ditamap_xml == other.ditamap_xml and self.topics_xml == other.topics_xml
+                and self.images == other.images and self.metadata == other.metadata)
 
 
 class UndoService:
@@ -98,16 +120,33 @@ class UndoService:
 
     # --------------------------------------------------------------------- API
 
-    def push_snapshot(self, context: DitaContext) -> None:
+    def push_snapshot(
+        self,
+        context: DitaContext,
+        label: Optional[str] = None,
+        state: Optional[Dict[str, Any]] = None,
+    ) -> bool:
         """Capture current context state and push onto the undo stack.
 
         The redo stack is cleared to follow standard undo/redo semantics.
         If the undo stack exceeds max_history, the oldest snapshot is dropped.
+        Nothing is pushed when the context (and *state*, if given) is unchanged
+        since the last snapshot, so pre-edit snapshots do not add empty steps.
 
         Parameters
         ----------
         context : DitaContext
             The context whose state is to be captured.
+        label : Optional[str]
+            Human-readable name of the operation that produced this state.
+        state : Optional[Dict[str, Any]]
+            UI state to restore with this snapshot (see :meth:`current_state`).
+            None keeps the state of the previous snapshot.
+
+        Returns
+        -------
+        bool
+            True if a snapshot was pushed.
 
         Notes
         -----
@@ -115,11 +154,19 @@ class UndoService:
           issue occurs during snapshotting, the snapshot is simply not pushed.
         - Snapshots are immutable blobs and will not be mutated after push.
         """
-        snap = self._create_snapshot(context)
+        snap = self._create_snapshot(context, label, state)
         if snap is None:
             # Graceful no-op if serialization failed
             logger.warning("Undo: snapshot skipped due to serialization failure")
-            return
+            return False
+        top = self._undo_stack[-1] if self._undo_stack else None
+        if top is not None and top.same_content(snap):
+            if state is None or dict(state) == top.state:
+                return False
+            # UI-only change: share the serialized blobs of the previous state
+            snap = _Snapshot(top.ditamap_xml, top.topics_xml, top.images, top.metadata, label, dict(state))
+        elif state is None and top is not None:
+            snap = _Snapshot(snap.ditamap_xml, snap.topics_xml, snap.images, snap.metadata, label, top.state)
         self._undo_stack.append(snap)
         # New user action invalidates redo history
         self._redo_stack.clear()
@@ -132,6 +179,7 @@ class UndoService:
                 del self._undo_stack[0:overflow]
         if logger.isEnabledFor(logging.DEBUG):
             logger.debug("Undo: snapshot pushed (undo=%d redo=%d)", len(self._undo_stack), len(self._redo_stack))
+        return True
 
     def undo(self, context: DitaContext) -> bool:
         """Restore the previous state into the provided context.
@@ -158,8 +206,9 @@ class UndoService:
         # Previous snapshot on top is the baseline to restore
         baseline_snap = self._undo_stack[-1]
     
-        # Attempt restore of baseline
-        if not self._restore_snapshot_into_context(context, baseline_snap):
+        # Attempt restore of baseline (UI-only steps leave the context untouched)
+        if not baseline_snap.same_content(post_snap) and not self._restore_snapshot_into_context(
+                context, baseline_snap):
             # Restoration failed; revert stack and signal failure
             self._undo_stack.append(post_snap)
             return False
@@ -188,7 +237,10 @@ class UndoService:
         post_snap = self._redo_stack.pop()
 
         # Attempt restore of post state
-        if not self._restore_snapshot_into_context(context, post_snap):
+        current = self._undo_stack[-1] if self._undo_stack else None
+        if (current is None or not current.same_content(post_snap)) and not self._restore_snapshot_into_context(
+                context, post_snap):
+            self._redo_stack.append(post_snap)
             return False
 
         # Push restored post state back onto undo stack
@@ -216,9 +268,34 @@ class UndoService:
         self._undo_stack.clear()
         self._redo_stack.clear()
 
+    def undo_label(self) -> Optional[str]:
+        """Label of the operation :meth:`undo` would revert, if any."""
+        return self._undo_stack[-1].label if self.can_undo() else None
+
+    def redo_label(self) -> Optional[str]:
+        """Label of the operation :meth:`redo` would re-apply, if any."""
+        return self._redo_stack[-1].label if self._redo_stack else None
+
+    def current_state(self) -> Dict[str, Any]:
+        """UI state recorded with the current snapshot (empty if none)."""
+        return dict(self._undo_stack[-1].state) if self._undo_stack else {}
+
+    def journal(self) -> List[Dict[str, Any]]:
+        """Operations in session order: ``label``, ``timestamp`` and ``undone`` (on the redo stack)."""
+        entries = [{"label": s.label or "Edit", "timestamp": s.timestamp, "undone": False}
+                   for s in self._undo_stack[1:]]
+        entries.extend({"label": s.label or "Edit", "timestamp": s.timestamp, "undone": True}
+                       for s in reversed(self._redo_stack))
+        return entries
+
     # --------------------------------------------------------------- Internals
 
-    def _create_snapshot(self, context: DitaContext) -> Optional[_Snapshot]:
+    def _create_snapshot(
+        self,
+        context: DitaContext,
+        label: Optional[str] = None,
+        state: Optional[Dict[str, Any]] = None,
+    ) -> Optional[_Snapshot]:
         """Serialize the entire DitaContext into an immutable snapshot.
 
         Uses conservative serialization via lxml.etree.tostring for XML content.
@@ -227,6 +304,8 @@ class UndoService:
         Parameters
         ----------
         context : DitaContext
+        label, state :
+            Stored as-is on the snapshot (see :meth:`push_snapshot`).
 
         Returns
         -------
@@ -249,8 +328,9 @@ class UndoService:
                     continue
                 topics_xml[name] = ET.tostring(elem, encoding="utf-8")
 
-            # Copy images and metadata (no deep serialization needed here)
-            images_copy: Dict[str, bytes] = dict(context.images)
+            # Copy images and metadata (no deep serialization needed here;
+            # spooled images are linked, not read)
+            images_copy = copy_blobs(context.images)
             metadata_copy: Dict[str, Any] = dict(context.metadata)
 
             return _Snapshot(
@@ -258,6 +338,8 @@ class UndoService:
                 topics_xml=topics_xml,
                 images=images_copy,
                 metadata=metadata_copy,
+                label=label,
+                state=dict(state or {}),
             )
         except Exception:
             # Graceful failure
@@ -292,17 +374,17 @@ class UndoService:
         try:
             # Rebuild ditamap
             if snap.ditamap_xml is not None:
-                new_map_root = ET.fromstring(snap.ditamap_xml)
+                new_map_root = parse_bytes(snap.ditamap_xml, source="undo snapshot")
             else:
                 new_map_root = None  # type: ignore[assignment]
 
             # Rebuild topics
             new_topics: Dict[str, ET.Element] = {}
             for name, xml_bytes in snap.topics_xml.items():
-                new_topics[name] = ET.fromstring(xml_bytes)
+                new_topics[name] = parse_bytes(xml_bytes, source=name)
 
             # Prepare new images/metadata
-            new_images: Dict[str, bytes] = dict(snap.images)
+            new_images = copy_blobs(snap.images)
             new_metadata: Dict[str, Any] = dict(snap.metadata)
 
             # If everything parsed fine, swap into the context atomically
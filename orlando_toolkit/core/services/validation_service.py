@@ -0,0 +1,358 @@
+from __future__ import annotations
+
+"""Validation of generated topics and maps against the grammars of the output dialect.
+
+:class:`ValidationService` checks every topic and the map before they reach a
+CCMS, as they will be written: in DITA 1.3, or converted to DITA 2.0 or XDITA
+when that is the output dialect (:mod:`orlando_toolkit.core.dialects`). The
+grammars come from ``validation.grammar_dir`` in ``pipeline.yml`` (DITA 1.3;
+``grammar_dirs`` per dialect for the others), or from the dialect's plugin
+(``org.oasis-open.dita.v1_3``, ``org.oasis-open.dita.v2_0``,
+``org.oasis-open.xdita.v0_2_2``) of the DITA-OT install found by
+:class:`~orlando_toolkit.core.services.publishing_service.PublishingService`;
+``validation.grammar`` picks the DTD (default) or RelaxNG shells. Each root
+element is validated against the shell of the same name (``concept.dtd``,
+``map.dtd``, ``lw-topic.dtd`` in XDITA, …).
+
+Without grammars, built-in checks cover what usually gets an upload
+rejected: known topic types with an id and a leading title, the body element
+of the type, unique ids, map references to missing topics and leftover
+``data-*`` helper attributes, plus elements and attributes the dialect does
+not have. :attr:`ValidationReport.grammar` tells which validation ran.
+
+With ``validation.block_on_errors: true``,
+:meth:`~orlando_toolkit.core.services.conversion_service.ConversionService.write_package`
+raises :class:`PackageValidationError` (``OTK420``) instead of writing an
+archive with errors.
+"""
+
+import logging
+import re
+from copy import deepcopy
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional, Sequence
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.dialects import DIALECT_LABELS, convert_context, convert_topic, dialect_issues, \
+    grammar_plugin, output_dialect, shell_name
+from orlando_toolkit.core.errors import SourceLocation, ToolkitError
+from orlando_toolkit.core.models import DitaContext
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "PackageValidationError",
+    "ValidationIssue",
+    "ValidationReport",
+    "ValidationService",
+    "ValidationSettings",
+]
+
+_GRAMMARS = ("dtd", "rng")
+_MAP_TYPES = ("map", "bookmap")
+# topic type -> (first child, body element)
+_TOPIC_TYPES: Dict[str, tuple] = {
+    "topic": ("title", "body"),
+    "concept": ("title", "conbody"),
+    "task": ("title", "taskbody"),
+    "reference": ("title", "refbody"),
+    "troubleshooting": ("title", "troublebody"),
+    "glossentry": ("glossterm", "glossBody"),
+}
+_TOPIC_PARTS = {"title", "titlealts", "shortdesc", "abstract", "prolog", "related-links",
+                "glossterm", "glossdef"}
+# Helper attributes removed by prepare_package on the map
+_MAP_HINTS = ("data-level", "data-style", "data-origin")
+_NAME = re.compile(r"^[A-Za-z_][\w.\-]*$")
+
+
+class PackageValidationError(ToolkitError):
+    """Raised when packaging is blocked by validation errors; :attr:`issues` lists them."""
+
+    code = "OTK420"
+
+    def __init__(self, message: str, *, issues: Sequence["ValidationIssue"] = (), **kwargs: Any) -> None:
+        super().__init__(message, **kwargs)
+        self.issues = list(issues)
+
+
+@dataclass(frozen=True)
+class ValidationIssue:
+    """One problem; :attr:`topic` is the topic file name, or None for the map."""
+
+    message: str
+    topic: Optional[str] = None
+    line: Optional[int] = None
+    severity: str = "error"
+
+    @property
+    def location(self) -> SourceLocation:
+        return SourceLocation(topic=self.topic or "map", line=self.line)
+
+
+@dataclass
+class ValidationReport:
+    """Issues found and the validation used (``DITA 1.3 DTD``, ``XDITA built-in checks``, …)."""
+
+    grammar: str
+    issues: List[ValidationIssue] = field(default_factory=list)
+
+    @property
+    def errors(self) -> List[ValidationIssue]:
+        return [issue for issue in self.issues if issue.severity == "error"]
+
+    @property
+    def ok(self) -> bool:
+        return not self.errors
+
+
+@dataclass(frozen=True)
+class ValidationSettings:
+    grammar_dir: Optional[str] = None
+    grammar: str = "dtd"
+    block_on_errors: bool = False
+    # dialect -> grammar folder, for dialects other than DITA 1.3
+    grammar_dirs: Mapping[str, str] = field(default_factory=dict)
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "ValidationSettings":
+        data = data or {}
+        grammar = str(data.get("grammar") or "dtd").lower()
+        if grammar not in _GRAMMARS:
+            logger.warning("Unknown validation grammar %r; using dtd", grammar)
+            grammar = "dtd"
+        return cls(
+            grammar_dir=str(data["grammar_dir"]) if data.get("grammar_dir") else None,
+            grammar=grammar,
+            block_on_errors=bool(data.get("block_on_errors", False)),
+            grammar_dirs={str(k): str(v) for k, v in (data.get("grammar_dirs") or {}).items() if v},
+        )
+
+    @classmethod
+    def from_config(cls) -> "ValidationSettings":
+        try:
+            from orlando_toolkit.config import ConfigManager
+            return cls.from_mapping((ConfigManager().get_pipeline_config() or {}).get("validation"))
+        except Exception as exc:
+            logger.debug("Pipeline config unavailable, using default validation settings: %s", exc)
+            return cls()
+
+
+def _filename(href: Optional[str]) -> str:
+    return (href or "").split("#")[0].split("/")[-1]
+
+
+def _local_name(tag: Any) -> str:
+    return tag.split("}")[-1] if isinstance(tag, str) else ""
+
+
+class ValidationService:
+    """Validates the topics and map of a context against their output dialect.
+
+    *dialect* forces one; by default it is that of the validated context.
+    """
+
+    def __init__(self, settings: Optional[ValidationSettings] = None, *, dialect: Optional[str] = None) -> None:
+        self.settings = settings or ValidationSettings.from_config()
+        self.dialect = dialect
+        self._grammar_roots: Dict[str, Optional[Path]] = {}
+        self._schemas: Dict[tuple, Any] = {}
+
+    # ------------------------------------------------------------------
+    # Grammars
+    # ------------------------------------------------------------------
+
+    def grammar_root(self, dialect: str = "dita-1.3") -> Optional[Path]:
+        """Folder holding the grammars of *dialect*, or None when there is none."""
+        if dialect not in self._grammar_roots:
+            candidates: List[Optional[Path]] = []
+            folder = self.settings.grammar_dirs.get(dialect) or (
+                self.settings.grammar_dir if dialect == "dita-1.3" else None)
+            if folder:
+                candidates.append(Path(folder).expanduser())
+            try:
+                from orlando_toolkit.core.services.publishing_service import PublishingService
+                home = PublishingService().locate()
+            except Exception as exc:
+                logger.debug("DITA-OT lookup failed: %s", exc)
+                home = None
+            if home is not None:
+                candidates.append(home / "plugins" / grammar_plugin(dialect))
+            self._grammar_roots[dialect] = next((c for c in candidates if (c / self.settings.grammar).is_dir()),
+                                                None)
+        return self._grammar_roots[dialect]
+
+    def _schema(self, root_tag: str, dialect: str) -> Optional[Any]:
+        """Loaded DTD/RelaxNG shell for *root_tag* in *dialect*, or None when it is not available."""
+        if (root_tag, dialect) in self._schemas:
+            return self._schemas[(root_tag, dialect)]
+        schema = None
+        base = self.grammar_root(dialect)
+        ext = self.settings.grammar
+        name = shell_name(root_tag, dialect)
+        shell = next(iter(sorted((base / ext).rglob(f"{name}.{ext}"))), None) if base is not None else None
+        if shell is not None:
+            try:
+                schema = ET.DTD(str(shell)) if ext == "dtd" else ET.RelaxNG(file=str(shell))
+            except Exception as exc:
+                logger.warning("Could not load grammar %s: %s", shell, exc)
+        self._schemas[(root_tag, dialect)] = schema
+        return schema
+
+    def _label(self, dialect: str, grammar_used: bool) -> str:
+        name = DIALECT_LABELS.get(dialect, dialect)
+        return f"{name} {self.settings.grammar.upper()}" if grammar_used else \
+            ("built-in checks" if dialect == "dita-1.3" else f"{name} built-in checks")
+
+    # ------------------------------------------------------------------
+    # Validation
+    # ------------------------------------------------------------------
+
+    def validate(self, context: DitaContext, *, strip_hints: bool = False) -> ValidationReport:
+        """Validate the map and every topic of *context*.
+
+        With *strip_hints*, a copy without the helper attributes removed at
+        packaging time is validated, so content still being edited can be
+        checked under its current topic names.
+        """
+        if strip_hints:
+            context = _without_hints(context)
+        dialect = self.dialect or output_dialect(context.metadata)
+        context = convert_context(context, dialect)
+        grammar_used = self.grammar_root(dialect) is not None
+        report = ValidationReport(grammar=self._label(dialect, grammar_used))
+
+        root = getattr(context, "ditamap_root", None)
+        if root is not None:
+            report.issues.extend(self._check(root, None, grammar_used, dialect))
+            report.issues.extend(_check_references(root, context))
+        for name, topic in (context.topics or {}).items():
+            report.issues.extend(self._check(topic, name, grammar_used, dialect))
+        logger.info("Validation (%s): %d error(s) in %d topic(s)", report.grammar, len(report.errors),
+                    len(context.topics or {}))
+        return report
+
+    def validate_topic(self, name: str, topic: ET.Element, *,
+                       metadata: Optional[Mapping[str, Any]] = None) -> ValidationReport:
+        """Validate one topic, ignoring the helper attributes removed at packaging time.
+
+        Used before a topic edited as raw XML replaces the original; the
+        dialect is that of *metadata* unless the service forces one.
+        """
+        dialect = self.dialect or output_dialect(metadata)
+        grammar_used = self.grammar_root(dialect) is not None
+        report = ValidationReport(grammar=self._label(dialect, grammar_used))
+        stripped = _without_hints(DitaContext(topics={name: topic}))
+        converted = convert_topic(stripped.topics[name], dialect)
+        report.issues.extend(self._check(converted, name, grammar_used, dialect))
+        return report
+
+    def _check(self, element: ET.Element, topic: Optional[str], grammar_used: bool,
+               dialect: str = "dita-1.3") -> List[ValidationIssue]:
+        tag = _local_name(element.tag)
+        schema = self._schema(tag, dialect) if grammar_used else None
+        if schema is None:
+            return _builtin_checks(element, topic, dialect)
+        if schema.validate(element):
+            return []
+        return [ValidationIssue(entry.message, topic=topic, line=entry.line or None)
+                for entry in schema.error_log.filter_from_errors()]
+
+    def check_package(self, context: DitaContext) -> None:
+        """Raise :class:`PackageValidationError` when blocking is enabled and *context* has errors."""
+        if not self.settings.block_on_errors:
+            return
+        report = self.validate(context)
+        if report.errors:
+            first = report.errors[0]
+            raise PackageValidationError(
+                f"{len(report.errors)} validation error(s) ({report.grammar}); first: {first.message}",
+                issues=report.errors, location=first.location,
+            )
+
+
+def _without_hints(context: DitaContext) -> DitaContext:
+    from orlando_toolkit.core.processing.pipeline import strip_stage_hints
+
+    copy = deepcopy(context)
+    if copy.ditamap_root is not None:
+        for el in copy.ditamap_root.iter():
+            if isinstance(el.tag, str):
+                for name in _MAP_HINTS:
+                    el.attrib.pop(name, None)
+    strip_stage_hints(copy)
+    return copy
+
+
+def _check_references(root: ET.Element, context: DitaContext) -> List[ValidationIssue]:
+    issues: List[ValidationIssue] = []
+    for node in root.iter():
+        if node.tag not in ("topicref", "topichead"):
+            continue
+        href = node.get("href")
+        if node.tag == "topichead" and not (node.findtext("topicmeta/navtitle") or node.get("navtitle") or "").strip():
+            issues.append(ValidationIssue("topichead has no navtitle", line=node.sourceline))
+        if node.get("format") not in (None, "dita"):
+            continue  # attachments (core.attachments) and other non-DITA files
+        if href and node.get("scope") != "external" and not href.startswith(("http:", "https:")) \
+                and _filename(href) not in context.topics:
+            issues.append(ValidationIssue(f"topicref points to a missing topic: {href}", line=node.sourceline))
+    return issues
+
+
+def _builtin_checks(element: ET.Element, topic: Optional[str], dialect: str = "dita-1.3") -> List[ValidationIssue]:
+    issues: List[ValidationIssue] = []
+
+    def _issue(message: str, node: ET.Element) -> None:
+        issues.append(ValidationIssue(message, topic=topic, line=node.sourceline))
+
+    tag = _local_name(element.tag)
+    types = ("topic",) if dialect == "xdita" else tuple(_TOPIC_TYPES)
+    if topic is None:
+        if tag not in (("map",) if dialect == "xdita" else _MAP_TYPES):
+            _issue(f"<{tag}> is not a {DIALECT_LABELS.get(dialect, 'DITA')} map", element)
+    elif tag not in types:
+        _issue(f"<{tag}> is not a {DIALECT_LABELS.get(dialect, 'DITA')} topic type", element)
+    else:
+        _check_topic(element, _issue)
+    for message, node in dialect_issues(element, dialect):
+        _issue(message, node)
+
+    seen: set = set()
+    for node in element.iter():
+        if not isinstance(node.tag, str):
+            continue
+        for name in node.attrib:
+            if name.startswith("data-"):
+                _issue(f"Attribute {name} on <{_local_name(node.tag)}> is not valid DITA", node)
+        node_id = node.get("id")
+        if topic is not None and node_id is not None and node is not element:
+            if node_id in seen:
+                _issue(f"Duplicate id '{node_id}'", node)
+            seen.add(node_id)
+    return issues
+
+
+def _check_topic(element: ET.Element, _issue) -> None:
+    tag = _local_name(element.tag)
+    first, body = _TOPIC_TYPES[tag]
+    topic_id = element.get("id")
+    if not topic_id:
+        _issue(f"<{tag}> has no id", element)
+    elif not _NAME.match(topic_id):
+        _issue(f"<{tag}> id '{topic_id}' is not a valid XML name", element)
+    children = [child for child in element if isinstance(child.tag, str)]
+    if not children or _local_name(children[0].tag) != first:
+        _issue(f"<{tag}> must start with <{first}>", element)
+    for child in children:
+        name = _local_name(child.tag)
+        if name in _TOPIC_PARTS or name == body or name in _TOPIC_TYPES:
+            if name in _TOPIC_TYPES:
+                _check_topic(child, _issue)
+            continue
+        if name in {b for _f, b in _TOPIC_TYPES.values()}:
+            _issue(f"<{name}> is not allowed in <{tag}> (expected <{body}>)", child)
+        else:
+            _issue(f"<{name}> is not allowed directly in <{tag}>", child)
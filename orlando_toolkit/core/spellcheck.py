@@ -0,0 +1,203 @@
+from __future__ import annotations
+
+"""Spelling of the edited topics.
+
+The ``spelling`` stage (:mod:`orlando_toolkit.core.processing.spelling`)
+checks the converted text once; typos introduced while restructuring (a
+renamed title, an edited topic) only show up here, on the current content:
+
+- :func:`check_spelling` lists the suspected misspellings per topic and
+  word, with their count and context, for the **Spelling** panel and its
+  CSV export (:func:`issues_to_csv`);
+- :func:`suspect_words` gives the words of one topic to underline in the
+  preview, :func:`misspelled_spans` the character ranges to underline in the
+  XML editor.
+
+The checker and its options (dictionaries per language, term base, minimum
+word length) are those of ``spelling`` in ``conversion.yml``; each topic is
+checked in its ``xml:lang``, else the document language. Words the author
+chose to ignore are kept in ``metadata["spelling_ignore"]`` and therefore
+saved with the project file (:func:`ignored_words`, :func:`ignore_words`).
+"""
+
+import csv
+import io
+import logging
+import re
+from dataclasses import dataclass, field
+from typing import Any, Dict, Iterable, List, Mapping, MutableMapping, Optional, Set, Tuple
+
+from orlando_toolkit.core.i18n import XML_LANG, document_language, normalize_language
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.placement import topic_order
+from orlando_toolkit.core.processing.spelling import SpellCheckStage, extract_words, load_term_base
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["IGNORE_KEY", "SpellSettings", "SpellingIssue", "check_spelling", "ignore_words", "ignored_words",
+           "issues_to_csv", "misspelled_spans", "suspect_words", "topic_language"]
+
+#: Metadata key of the ignore list (saved with the project)
+IGNORE_KEY = "spelling_ignore"
+_MAX_SNIPPETS = 3
+_SNIPPET_CONTEXT = 30
+# Words of XML source text: outside tags, comments and entity references
+_MARKUP = re.compile(r"<!--.*?-->|<!\[CDATA\[.*?\]\]>|<[^>]*>|&[#\w]+;", re.S)
+_WORD = re.compile(r"[^\W\d_]+(?:['’][^\W\d_]+)*")
+
+
+@dataclass
+class SpellSettings:
+    """The ``spelling`` options of a job and the checker they select."""
+
+    options: Dict[str, Any] = field(default_factory=dict)
+
+    @classmethod
+    def from_metadata(cls, metadata: Optional[Mapping[str, Any]] = None) -> "SpellSettings":
+        try:
+            from orlando_toolkit.core.processing import resolve_conversion_options
+            return cls(dict(resolve_conversion_options(metadata).get("spelling") or {}))
+        except Exception as exc:
+            logger.debug("Conversion options unavailable, using default spelling settings: %s", exc)
+            return cls()
+
+    @property
+    def min_length(self) -> int:
+        return int(self.options.get("min_word_length", 3))
+
+    @property
+    def ignore_uppercase(self) -> bool:
+        return bool(self.options.get("ignore_uppercase", True))
+
+    def checker(self) -> Any:
+        """Checker selected by ``backend`` (Hunspell by default), or None."""
+        return SpellCheckStage().create_checker(self.options)
+
+    def allowed(self, metadata: Optional[Mapping[str, Any]] = None) -> Set[str]:
+        """Lowercase words never reported: the term base and the ignore list of *metadata*."""
+        words = load_term_base(self.options.get("term_base"), self.options.get("term_base_file"))
+        words.update(w.lower() for w in ignored_words(metadata))
+        return words
+
+
+@dataclass
+class SpellingIssue:
+    topic: str
+    title: str
+    word: str
+    count: int
+    language: str
+    snippets: List[str] = field(default_factory=list)
+
+
+def ignored_words(metadata: Optional[Mapping[str, Any]]) -> List[str]:
+    return [str(w) for w in (metadata or {}).get(IGNORE_KEY) or [] if w]
+
+
+def ignore_words(metadata: MutableMapping[str, Any], words: Iterable[str]) -> List[str]:
+    """Add *words* to the ignore list of *metadata*; returns the list."""
+    current = ignored_words(metadata)
+    known = {w.lower() for w in current}
+    for word in words:
+        word = str(word).strip()
+        if word and word.lower() not in known:
+            current.append(word)
+            known.add(word.lower())
+    metadata[IGNORE_KEY] = sorted(current, key=str.lower)
+    return metadata[IGNORE_KEY]
+
+
+def topic_language(context: DitaContext, root: Any) -> str:
+    """Language a topic is checked in: its ``xml:lang``, else the document language."""
+    return normalize_language(root.get(XML_LANG)) or document_language(context)
+
+
+def _title(root: Any) -> str:
+    title = root.find("title")
+    return " ".join("".join(title.itertext()).split()) if title is not None else ""
+
+
+def _snippets(text: str, word: str) -> List[str]:
+    snippets = []
+    for match in re.finditer(r"(?<!\w)" + re.escape(word) + r"(?!\w)", text):
+        start, end = max(0, match.start() - _SNIPPET_CONTEXT), min(len(text), match.end() + _SNIPPET_CONTEXT)
+        snippets.append(("…" if start else "") + text[start:end].strip() + ("…" if end < len(text) else ""))
+        if len(snippets) >= _MAX_SNIPPETS:
+            break
+    return snippets
+
+
+def suspect_words(root: Any, checker: Any, language: str, *, settings: Optional[SpellSettings] = None,
+                  allowed: Iterable[str] = ()) -> Dict[str, int]:
+    """Suspected misspellings of the topic *root* with their counts."""
+    settings = settings or SpellSettings()
+    allowed = {w.lower() for w in allowed}
+    counts = extract_words(root, min_length=settings.min_length, ignore_uppercase=settings.ignore_uppercase)
+    words = [w for w in counts if w.lower() not in allowed]
+    if not words:
+        return {}
+    suspects = set(checker.check(words, language))
+    return {w: counts[w] for w in words if w in suspects}
+
+
+def check_spelling(context: DitaContext, checker: Any, *, settings: Optional[SpellSettings] = None,
+                   topics: Optional[Iterable[str]] = None, errors: Optional[List[str]] = None) -> List[SpellingIssue]:
+    """Suspected misspellings per topic, in map order, most frequent word first within a topic.
+
+    The term base and the ignore list of ``context.metadata`` are never reported.
+    A topic the checker fails on (no dictionary for its language) is skipped;
+    the reason is appended to *errors* once per language.
+    """
+    settings = settings or SpellSettings.from_metadata(context.metadata)
+    allowed = settings.allowed(context.metadata)
+    wanted = set(topics) if topics is not None else None
+    issues: List[SpellingIssue] = []
+    for name in topic_order(context):
+        if wanted is not None and name not in wanted:
+            continue
+        root = context.topics[name]
+        language = topic_language(context, root)
+        try:
+            found = suspect_words(root, checker, language, settings=settings, allowed=allowed)
+        except Exception as exc:
+            logger.warning("Spelling: %s not checked (%s): %s", name, language, exc)
+            if errors is not None and str(exc) not in errors:
+                errors.append(str(exc))
+            continue
+        text = " ".join("".join(root.itertext()).split())
+        for word, count in sorted(found.items(), key=lambda item: (-item[1], item[0].lower())):
+            issues.append(SpellingIssue(name, _title(root), word, count, language, _snippets(text, word)))
+    logger.info("Spelling: %d suspected word(s) in %d topic(s)", len(issues), len({i.topic for i in issues}))
+    return issues
+
+
+def misspelled_spans(xml_text: str, checker: Any, language: str, *, settings: Optional[SpellSettings] = None,
+                     allowed: Iterable[str] = ()) -> List[Tuple[int, int]]:
+    """``(start, end)`` offsets of suspected words in the text content of the XML source *xml_text*."""
+    settings = settings or SpellSettings()
+    allowed = {w.lower() for w in allowed}
+    spans: List[Tuple[int, int, str]] = []
+    position = 0
+    for markup in list(_MARKUP.finditer(xml_text)) + [None]:
+        end = markup.start() if markup is not None else len(xml_text)
+        for match in _WORD.finditer(xml_text, position, end):
+            word = match.group(0).replace("’", "'")
+            if len(word) < settings.min_length or (settings.ignore_uppercase and word.isupper()):
+                continue
+            if word.lower() not in allowed:
+                spans.append((match.start(), match.end(), word))
+        position = markup.end() if markup is not None else end
+    if not spans:
+        return []
+    suspects = set(checker.check(sorted({w for _, _, w in spans}), language))
+    return [(start, end) for start, end, word in spans if word in suspects]
+
+
+def issues_to_csv(issues: Iterable[SpellingIssue]) -> str:
+    buffer = io.StringIO()
+    writer = csv.writer(buffer, lineterminator="\n")
+    writer.writerow(("topic", "title", "word", "count", "language", "context"))
+    for issue in issues:
+        writer.writerow((issue.topic, issue.title, issue.word, issue.count, issue.language,
+                         " | ".join(issue.snippets)))
+    return buffer.getvalue()
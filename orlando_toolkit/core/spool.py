@@ -0,0 +1,218 @@
+from __future__ import annotations
+
+"""Disk-backed media for low-memory conversions.
+
+A document with thousands of images holds every one of them as bytes in
+``context.images`` for the whole session. In low-memory mode
+(``low_memory`` in ``pipeline.yml``) media go to a :class:`SpooledBlobs`
+instead: a mapping with the same interface whose values live in files of a
+temporary folder and are read back only when asked for.
+
+- :func:`media_store` returns the mapping a handler or importer should fill
+  (a spool in low-memory mode, a plain ``dict`` otherwise);
+- :func:`spool_context` moves the media of a converted context to disk,
+  for handlers that filled plain dictionaries;
+- :func:`write_blob`, :func:`copy_blobs`, :func:`rename_blobs` and
+  :func:`blob_size` write, copy (undo snapshots, original structure),
+  rename and measure media without loading spooled files into memory.
+
+Package writing copies the spooled files into the package folder
+and :func:`~orlando_toolkit.core.package_utils.write_zip_archive` streams
+every file into the archive, so peak memory no longer grows with the media.
+Spool folders are removed when their mapping is garbage collected or
+:meth:`SpooledBlobs.close` is called.
+"""
+
+import itertools
+import logging
+import os
+import re
+import shutil
+import tempfile
+import weakref
+from pathlib import Path
+from typing import Any, Dict, Iterable, Iterator, Mapping, MutableMapping, Optional, Tuple
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["SpooledBlobs", "blob_size", "copy_blobs", "media_store", "rename_blobs", "spool_context", "write_blob"]
+
+_UNSAFE = re.compile(r"[^\w.-]+")
+
+
+class SpooledBlobs(MutableMapping[str, bytes]):
+    """Mapping of file names to bytes kept in files of a temporary folder.
+
+    Entries keep their insertion order. A stored file is never modified:
+    assigning a value writes a new file, so copies can share files through
+    hard links.
+    """
+
+    def __init__(self, items: Optional[Iterable[Tuple[str, bytes]]] = None, *,
+                 directory: Optional[str | Path] = None) -> None:
+        if directory is not None:
+            Path(directory).mkdir(parents=True, exist_ok=True)
+        self._dir = Path(tempfile.mkdtemp(prefix="otk_spool_", dir=str(directory) if directory else None))
+        self._root = directory
+        self._files: Dict[str, Path] = {}
+        self._counter = itertools.count(1)
+        self._finalizer = weakref.finalize(self, shutil.rmtree, str(self._dir), True)
+        for name, data in items or ():
+            self[name] = data
+
+    @property
+    def directory(self) -> Path:
+        return self._dir
+
+    def _new_path(self, name: str) -> Path:
+        return self._dir / f"{next(self._counter):06d}_{_UNSAFE.sub('_', name)[-80:]}"
+
+    def __getitem__(self, name: str) -> bytes:
+        return self._files[name].read_bytes()
+
+    def __setitem__(self, name: str, data: bytes) -> None:
+        path = self._new_path(name)
+        path.write_bytes(bytes(data))
+        old = self._files.get(name)
+        self._files[name] = path
+        if old is not None:
+            old.unlink(missing_ok=True)
+
+    def __delitem__(self, name: str) -> None:
+        self._files.pop(name).unlink(missing_ok=True)
+
+    def __iter__(self) -> Iterator[str]:
+        return iter(list(self._files))
+
+    def __len__(self) -> int:
+        return len(self._files)
+
+    def __contains__(self, name: object) -> bool:
+        return name in self._files
+
+    def __repr__(self) -> str:
+        return f"SpooledBlobs({len(self)} file(s) in {self._dir})"
+
+    def path_of(self, name: str) -> Path:
+        """File holding *name*; read it, do not modify it."""
+        return self._files[name]
+
+    def size_of(self, name: str) -> int:
+        return self._files[name].stat().st_size
+
+    def total_bytes(self) -> int:
+        return sum(path.stat().st_size for path in self._files.values())
+
+    def add_file(self, name: str, source: str | Path, *, link: bool = False) -> None:
+        """Store the content of file *source* under *name* without reading it into memory.
+
+        With *link*, a hard link is made where possible; only for files
+        nobody modifies afterwards (other spools).
+        """
+        path = self._new_path(name)
+        if link:
+            _link_or_copy(Path(source), path)
+        else:
+            shutil.copyfile(source, path)
+        old = self._files.get(name)
+        self._files[name] = path
+        if old is not None:
+            old.unlink(missing_ok=True)
+
+    def empty_like(self) -> "SpooledBlobs":
+        """New empty spool in the same parent folder."""
+        return SpooledBlobs(directory=self._root)
+
+    def copy(self) -> "SpooledBlobs":
+        """Independent copy sharing the files (hard links where the file system allows)."""
+        clone = self.empty_like()
+        for name, path in self._files.items():
+            clone.add_file(name, path, link=True)
+        return clone
+
+    def __deepcopy__(self, memo: Dict[int, Any]) -> "SpooledBlobs":
+        return self.copy()
+
+    def rename(self, renames: Mapping[str, str]) -> None:
+        """Rename entries in place, keeping their order."""
+        self._files = {renames.get(name, name): path for name, path in self._files.items()}
+
+    def close(self) -> None:
+        """Delete the spool folder; the mapping is empty afterwards."""
+        self._files = {}
+        self._finalizer()
+
+
+def _link_or_copy(source: Path, target: Path) -> None:
+    try:
+        os.link(source, target)
+    except OSError:
+        shutil.copyfile(source, target)
+
+
+def media_store(metadata: Optional[Mapping[str, Any]] = None, source: Optional[str | Path] = None,
+                ) -> MutableMapping[str, bytes]:
+    """Empty media mapping for a conversion: a :class:`SpooledBlobs` in low-memory mode, else a ``dict``."""
+    from orlando_toolkit.core.concurrency import PipelineSettings
+
+    settings = PipelineSettings.resolve(metadata)
+    if not settings.low_memory_for(source):
+        return {}
+    return SpooledBlobs(directory=settings.spool_dir)
+
+
+def spool_context(context: Any, source: Optional[str | Path] = None) -> bool:
+    """Move ``context.images`` and ``context.videos`` to disk when low-memory mode applies.
+
+    Returns whether the context's media are spooled afterwards.
+    """
+    from orlando_toolkit.core.concurrency import PipelineSettings
+
+    media = [getattr(context, key, None) for key in ("images", "videos")]
+    if not any(isinstance(m, SpooledBlobs) for m in media):
+        settings = PipelineSettings.resolve(getattr(context, "metadata", None))
+        if not settings.low_memory_for(source):
+            return False
+        for key in ("images", "videos"):
+            current = getattr(context, key, None)
+            if current is None:
+                continue
+            spool = SpooledBlobs(directory=settings.spool_dir)
+            # Drop each entry from memory as soon as it is on disk
+            for name in list(current):
+                spool[name] = current.pop(name) if isinstance(current, dict) else current[name]
+            setattr(context, key, spool)
+    files = sum(len(getattr(context, key, None) or ()) for key in ("images", "videos"))
+    report = getattr(context, "report", None)
+    if report is not None and not any(e.category == "low_memory" for e in getattr(report, "entries", ())):
+        report.info("low_memory", f"Low-memory mode: {files} media file(s) held on disk", files=files)
+    return True
+
+
+def write_blob(blobs: Mapping[str, bytes], name: str, target: str | Path) -> None:
+    """Write entry *name* of *blobs* to *target*; spooled files are copied, not read into memory."""
+    if isinstance(blobs, SpooledBlobs):
+        shutil.copyfile(blobs.path_of(name), target)
+    else:
+        Path(target).write_bytes(blobs[name])
+
+
+def blob_size(blobs: Mapping[str, bytes], name: str) -> int:
+    """Size in bytes of entry *name*, without reading a spooled file."""
+    return blobs.size_of(name) if isinstance(blobs, SpooledBlobs) else len(blobs[name])
+
+
+def copy_blobs(blobs: Mapping[str, bytes]) -> MutableMapping[str, bytes]:
+    """Shallow copy of a media mapping; spools are copied file by file, not read."""
+    return blobs.copy() if isinstance(blobs, SpooledBlobs) else dict(blobs)
+
+
+def rename_blobs(blobs: MutableMapping[str, bytes], renames: Mapping[str, str]) -> MutableMapping[str, bytes]:
+    """*blobs* with entries renamed per *renames*, in the same order.
+
+    A spool is renamed in place and returned; dictionaries are rebuilt.
+    """
+    if isinstance(blobs, SpooledBlobs):
+        blobs.rename(renames)
+        return blobs
+    return {renames.get(name, name): data for name, data in blobs.items()}
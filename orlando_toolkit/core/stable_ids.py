@@ -0,0 +1,179 @@
+from __future__ import annotations
+
+"""Topic id stability across reconversions.
+
+With ``ids.stable: true`` the packager names topics from what they are, not
+where they sit: ``topic_<slug>-<hash>.dita`` where the hash comes from the
+source heading anchor (``data-anchor`` on the topic root, e.g. a Word
+bookmark) or, without one, from the heading path (ancestor titles + title).
+Section numbers are left out because they shift whenever anything above a
+topic is inserted.
+
+``ids.previous_map`` points at a previous conversion (a ``.ditamap``, an
+extracted package folder or the package ``.zip``). Each new topic is matched
+against it, in order, by heading path, by content fingerprint (the topic
+moved but its text did not) and by title when that title is unique; a match
+reuses the previous filename and id.
+"""
+
+import hashlib
+import logging
+import posixpath
+import zipfile
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Callable, Dict, List, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.utils import topic_body
+from orlando_toolkit.core.xml_security import parse_bytes, parse_file
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["ANCHOR_HINT", "TopicFingerprint", "PreviousConversion", "fingerprint", "stable_hash"]
+
+#: Topic-root hint carrying a source anchor that survives edits (bookmark name)
+ANCHOR_HINT = "data-anchor"
+
+
+def _norm(text: Optional[str]) -> str:
+    return " ".join((text or "").split()).lower()
+
+
+def stable_hash(text: str, length: int = 8) -> str:
+    return hashlib.sha1(text.encode("utf-8")).hexdigest()[:length]
+
+
+@dataclass(frozen=True)
+class TopicFingerprint:
+    path: str
+    title: str
+    content: str
+    anchor: Optional[str] = None
+
+    @property
+    def key(self) -> str:
+        """Seed for the derived filename hash."""
+        return f"anchor:{self.anchor}" if self.anchor else f"path:{self.path}"
+
+
+def _title_of(topic_el, tref_el) -> str:
+    title = topic_el.find("title") if topic_el is not None else None
+    if title is not None:
+        return "".join(title.itertext())
+    nav = tref_el.find("topicmeta/navtitle") if tref_el is not None else None
+    return "".join(nav.itertext()) if nav is not None else ""
+
+
+TopicReader = Callable[[str], Optional[ET._Element]]
+
+
+def fingerprint(topic_el, tref_el, read_topic: TopicReader) -> TopicFingerprint:
+    """Fingerprint *topic_el* referenced by *tref_el*.
+
+    *read_topic* resolves an ancestor ``@href`` to its topic so ancestor
+    titles come from the same place as the topic's own title.
+    """
+    title = _norm(_title_of(topic_el, tref_el))
+    body = topic_body(topic_el)
+    content = stable_hash(_norm("".join(body.itertext())), 16) if body is not None else ""
+    anchor = None
+    if topic_el is not None:
+        anchor = (topic_el.get(ANCHOR_HINT) or "").strip() or None
+    ancestors: List[str] = []
+    parent = tref_el.getparent()
+    while parent is not None and parent.tag in ("topicref", "topichead"):
+        href = parent.get("href")
+        ancestors.insert(0, _norm(_title_of(read_topic(href) if href else None, parent)))
+        parent = parent.getparent()
+    return TopicFingerprint(path="/".join(ancestors + [title]), title=title, content=content, anchor=anchor)
+
+
+class PreviousConversion:
+    """Fingerprints of a previous conversion, keyed for matching."""
+
+    def __init__(self, entries: List[Tuple[TopicFingerprint, str]]) -> None:
+        self._by_path: Dict[str, str] = {}
+        self._by_content: Dict[str, str] = {}
+        titles: Dict[str, List[str]] = {}
+        for fp, filename in entries:
+            self._by_path.setdefault(fp.path, filename)
+            if fp.content:
+                self._by_content.setdefault(fp.content, filename)
+            titles.setdefault(fp.title, []).append(filename)
+        self._by_title = {t: names[0] for t, names in titles.items() if len(names) == 1}
+        self.filenames = {filename for _, filename in entries}
+        #: Topic roots by filename, when loaded from a map
+        self.topics: Dict[str, ET._Element] = {}
+
+    def __len__(self) -> int:
+        return len(self.filenames)
+
+    def match(self, fp: TopicFingerprint, used: set) -> Optional[str]:
+        for table, key in ((self._by_path, fp.path), (self._by_content, fp.content), (self._by_title, fp.title)):
+            name = table.get(key) if key else None
+            if name and name not in used:
+                return name
+        return None
+
+    @classmethod
+    def from_map(cls, map_root, read_topic: TopicReader) -> "PreviousConversion":
+        cache: Dict[str, Optional[ET._Element]] = {}
+
+        def _cached(href: str):
+            if href not in cache:
+                cache[href] = read_topic(href)
+            return cache[href]
+
+        entries: List[Tuple[TopicFingerprint, str]] = []
+        topics: Dict[str, ET._Element] = {}
+        for tref in map_root.iter("topicref"):
+            href = tref.get("href")
+            if href:
+                entries.append((fingerprint(_cached(href), tref, _cached), href.split("/")[-1]))
+                if _cached(href) is not None:
+                    topics.setdefault(href.split("/")[-1], _cached(href))
+        previous = cls(entries)
+        previous.topics = topics
+        return previous
+
+    @classmethod
+    def load(cls, path: str | Path) -> Optional["PreviousConversion"]:
+        """Load a previous conversion from a map, package folder or package zip."""
+        path = Path(path).expanduser()
+        try:
+            if path.suffix.lower() == ".zip":
+                return cls._load_zip(path)
+            if path.is_dir():
+                maps = sorted(path.rglob("*.ditamap"))
+                if not maps:
+                    logger.warning("No .ditamap found in %s", path)
+                    return None
+                path = maps[0]
+            base = path.parent
+
+            def _read(href: str):
+                target = base / href
+                return parse_file(target) if target.is_file() else None
+
+            return cls.from_map(parse_file(path), _read)
+        except Exception as exc:
+            logger.warning("Could not load previous conversion %s: %s", path, exc)
+            return None
+
+    @classmethod
+    def _load_zip(cls, path: Path) -> Optional["PreviousConversion"]:
+        with zipfile.ZipFile(path) as archive:
+            names = archive.namelist()
+            maps = sorted(n for n in names if n.lower().endswith(".ditamap"))
+            if not maps:
+                logger.warning("No .ditamap found in %s", path)
+                return None
+            base = posixpath.dirname(maps[0])
+
+            def _read(href: str):
+                member = posixpath.normpath(posixpath.join(base, href))
+                return parse_bytes(archive.read(member), source=member) if member in names else None
+
+            return cls.from_map(parse_bytes(archive.read(maps[0]), source=maps[0]), _read)
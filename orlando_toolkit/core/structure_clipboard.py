@@ -0,0 +1,257 @@
+from __future__ import annotations
+
+"""Structure clipboard: map branches copied from one project and pasted into another.
+
+A standard chapter (safety, warranty) is often reused between manuals.
+:func:`copy_entries` takes map entries (topics and sections, with their
+subtrees) together with the topics they reference and the images and videos
+those topics use. The clip is kept in a file of the user configuration
+folder (:func:`clipboard_path`), so it can be pasted in another window of
+the application or after opening another project.
+
+:func:`paste_clip` inserts a clip into a context, with the same renaming as
+:mod:`orlando_toolkit.core.combine`:
+
+- topic, image and video file names already used in the target are
+  numbered (``safety_2.dita``); identical media files are shared;
+- topic ``id`` values already used by a target topic get a number
+  (``safety_2``), with links and conrefs of the clip pointing at them;
+- bookmarks already used in the target are prefixed with ``pasted``.
+
+Links and conrefs from the clip to topics that were not copied are kept as
+they are and counted as ``outside``.
+"""
+
+import copy
+import json
+import logging
+import os
+import zipfile
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Sequence
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.combine import _REF_ATTRS, _rename_bookmarks, _rename_media, _retarget
+from orlando_toolkit.core.internal_links import topic_bookmarks
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.xml_security import parse_bytes
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["StructureClip", "clipboard_path", "copy_entries", "paste_clip", "read_clip", "write_clip"]
+
+CLIP_FILE = "structure_clipboard.zip"
+_FORMAT = 1
+_STRUCTURAL = ("topicref", "topichead")
+_MEDIA_PREFIX = "../media/"
+_EXTERNAL = ("http:", "https:", "mailto:", "ftp:", "file:", "www.")
+
+
+@dataclass
+class StructureClip:
+    """Copied map entries with their topics (file name -> root) and media."""
+
+    entries: List[ET._Element] = field(default_factory=list)
+    topics: Dict[str, ET._Element] = field(default_factory=dict)
+    images: Dict[str, bytes] = field(default_factory=dict)
+    videos: Dict[str, bytes] = field(default_factory=dict)
+    source: str = ""
+
+    def __bool__(self) -> bool:
+        return bool(self.entries)
+
+    def summary(self) -> str:
+        text = f"{len(self.entries)} entr{'y' if len(self.entries) == 1 else 'ies'}, {len(self.topics)} topic(s)"
+        media = len(self.images) + len(self.videos)
+        if media:
+            text += f", {media} media file(s)"
+        return f"{text} from {self.source}" if self.source else text
+
+
+def clipboard_path() -> Path:
+    """File holding the clip (``structure_clipboard.zip`` in the user configuration folder)."""
+    from orlando_toolkit.config.manager import _get_user_config_dir
+
+    return _get_user_config_dir() / CLIP_FILE
+
+
+def _topic_name(href: str) -> str:
+    return href.partition("#")[0].rsplit("/", 1)[-1]
+
+
+def _media_names(topics: Sequence[ET._Element]) -> List[str]:
+    names: List[str] = []
+    for topic in topics:
+        for el in topic.iter():
+            if not isinstance(el.tag, str):
+                continue
+            for attr in _REF_ATTRS:
+                value = (el.get(attr) or "").partition("#")[0]
+                if value.startswith(_MEDIA_PREFIX) and value[len(_MEDIA_PREFIX):] not in names:
+                    names.append(value[len(_MEDIA_PREFIX):])
+    return names
+
+
+def copy_entries(context: DitaContext, entries: Sequence[ET._Element], *, source: str = "") -> StructureClip:
+    """Clip of *entries* (map elements, in document order) and everything they use.
+
+    Entries nested under another of *entries* are taken with their parent only.
+    """
+    roots = [e for e in entries if not any(o is not e and e in o.iter() for o in entries)]
+    clip = StructureClip(entries=[copy.deepcopy(e) for e in roots], source=source)
+    for entry in clip.entries:
+        for ref in entry.iter("topicref"):
+            name = _topic_name(ref.get("href") or "")
+            if name in context.topics and name not in clip.topics:
+                clip.topics[name] = copy.deepcopy(context.topics[name])
+    for name in _media_names(list(clip.topics.values())):
+        if name in context.images:
+            clip.images[name] = context.images[name]
+        elif name in context.videos:
+            clip.videos[name] = context.videos[name]
+    return clip
+
+
+def write_clip(clip: StructureClip, path: Optional[str | Path] = None) -> Path:
+    """Save *clip* (by default to :func:`clipboard_path`); returns the file."""
+    target = Path(path) if path is not None else clipboard_path()
+    target.parent.mkdir(parents=True, exist_ok=True)
+    holder = ET.Element("clip")
+    holder.extend(copy.deepcopy(e) for e in clip.entries)
+    temp = target.with_name(target.name + ".tmp")
+    with zipfile.ZipFile(temp, "w", zipfile.ZIP_DEFLATED) as archive:
+        archive.writestr("clip.json", json.dumps({"format": _FORMAT, "source": clip.source,
+                                                  "topics": list(clip.topics), "images": list(clip.images),
+                                                  "videos": list(clip.videos)}, indent=2))
+        archive.writestr("map.xml", ET.tostring(holder, encoding="utf-8"))
+        for name, topic in clip.topics.items():
+            archive.writestr(f"topics/{name}", ET.tostring(topic, encoding="utf-8"))
+        for folder, media in (("images", clip.images), ("videos", clip.videos)):
+            for name in media:
+                archive.writestr(f"{folder}/{name}", media[name])
+    os.replace(temp, target)
+    logger.info("Structure clip written to %s (%s)", target, clip.summary())
+    return target
+
+
+def read_clip(path: Optional[str | Path] = None) -> Optional[StructureClip]:
+    """The saved clip, or None when there is none or it cannot be read."""
+    source = Path(path) if path is not None else clipboard_path()
+    if not source.is_file():
+        return None
+    try:
+        with zipfile.ZipFile(source) as archive:
+            info = json.loads(archive.read("clip.json"))
+            if info.get("format") != _FORMAT:
+                logger.warning("Structure clip %s has unknown format %r", source, info.get("format"))
+                return None
+            holder = parse_bytes(archive.read("map.xml"), source="clip map")
+            return StructureClip(
+                entries=[e for e in holder if isinstance(e.tag, str)],
+                topics={n: parse_bytes(archive.read(f"topics/{n}"), source=n) for n in info.get("topics", [])},
+                images={n: archive.read(f"images/{n}") for n in info.get("images", [])},
+                videos={n: archive.read(f"videos/{n}") for n in info.get("videos", [])},
+                source=str(info.get("source") or ""))
+    except Exception as exc:
+        logger.warning("Structure clip %s could not be read: %s", source, exc)
+        return None
+
+
+def _free_id(value: str, taken: set) -> str:
+    number = 2
+    while f"{value}_{number}" in taken:
+        number += 1
+    return f"{value}_{number}"
+
+
+def _retarget_id(value: str, topics: Dict[str, str], ids: Dict[str, str]) -> Optional[str]:
+    """*value* (``file.dita#topic/element``) with a renamed topic id of that file, or None."""
+    path, sep, fragment = value.partition("#")
+    topic_id, slash, rest = fragment.partition("/")
+    name = _topic_name(path) if path else None
+    old_name = next((old for old, new in topics.items() if new == name), name)
+    if sep and old_name in ids and ids[old_name][0] == topic_id:
+        return f"{path}#{ids[old_name][1]}{slash}{rest}"
+    return None
+
+
+def paste_clip(context: DitaContext, clip: StructureClip, parent: ET._Element,
+               index: Optional[int] = None) -> Dict[str, Any]:
+    """Insert a copy of *clip* into *parent* of *context*'s map at *index* (end when None).
+
+    Returns the inserted entries and the counts of renamed files, ids and
+    bookmarks and of links pointing at topics that were not copied.
+    Levels are left to the caller.
+    """
+    clip = copy.deepcopy(clip)
+    topics = {}
+    for name in clip.topics:
+        new_name = name
+        if new_name in context.topics:
+            stem, suffix = os.path.splitext(name)
+            number = 2
+            while f"{stem}_{number}{suffix}" in context.topics or f"{stem}_{number}{suffix}" in clip.topics:
+                number += 1
+            new_name = f"{stem}_{number}{suffix}"
+        topics[name] = new_name
+    renamed_topics = {old: new for old, new in topics.items() if old != new}
+    media = _rename_media(context.images, clip.images)
+    media.update(_rename_media(context.videos, clip.videos))
+
+    taken_ids = {t.get("id") for t in context.topics.values() if t.get("id")}
+    ids: Dict[str, tuple] = {}
+    for name, topic in clip.topics.items():
+        old_id = topic.get("id")
+        if old_id and old_id in taken_ids:
+            ids[name] = (old_id, _free_id(old_id, taken_ids))
+            topic.set("id", ids[name][1])
+        taken_ids.add(topic.get("id"))
+
+    elements = [*(e for entry in clip.entries for e in entry.iter()), *(e for t in clip.topics.values() for e in t.iter())]
+    for el in elements:
+        if not isinstance(el.tag, str):
+            continue
+        for attr in _REF_ATTRS:
+            value = el.get(attr)
+            if not value:
+                continue
+            new_value = _retarget(value, renamed_topics, media) if (renamed_topics or media) else None
+            if new_value is not None:
+                el.set(attr, new_value)
+                value = new_value
+            new_value = _retarget_id(value, topics, ids) if ids else None
+            if new_value is not None:
+                el.set(attr, new_value)
+    # Links inside one topic (#topic/element) follow its own id
+    for name, (old_id, new_id) in ids.items():
+        for el in clip.topics[name].iter():
+            for attr in ("href", "conref"):
+                value = el.get(attr) if isinstance(el.tag, str) else None
+                if value and value.startswith(f"#{old_id}/"):
+                    el.set(attr, f"#{new_id}/{value[len(old_id) + 2:]}")
+
+    used = {n for t in context.topics.values() for el in t.iter() if isinstance(el.tag, str)
+            for n in topic_bookmarks(el)}
+    bookmarks = _rename_bookmarks(DitaContext(topics=clip.topics), "pasted", used)
+
+    outside = 0
+    for topic in clip.topics.values():
+        for el in topic.iter():
+            if not isinstance(el.tag, str) or el.get("scope") == "external":
+                continue
+            for attr in ("href", "conref"):
+                value = el.get(attr) or ""
+                path = value.partition("#")[0]
+                if (path and not path.startswith(_MEDIA_PREFIX) and not path.lower().startswith(_EXTERNAL)
+                        and path.endswith(".dita") and _topic_name(path) not in topics.values()):
+                    outside += 1
+
+    for old, new in topics.items():
+        context.topics[new] = clip.topics[old]
+    position = len(parent) if index is None else index
+    for offset, entry in enumerate(clip.entries):
+        parent.insert(position + offset, entry)
+    return {"entries": clip.entries, "topics": len(clip.topics), "renamed": len(renamed_topics) + len(media),
+            "ids": len(ids), "bookmarks": len(bookmarks), "outside": outside}
@@ -0,0 +1,162 @@
+from __future__ import annotations
+
+"""Source style → DITA mapping.
+
+The style map (``default_style_map.yml``, a profile's ``style_map`` or
+``style_map_file``, the job's ``metadata["style_map"]``) maps source style
+names to what they become. An entry is one of::
+
+    "Chapter": 1                                # heading level (as before)
+    "Annex Title": {heading: 1, role: appendix} # heading with a role
+    "Manual Title": {heading: 1, role: map_title}
+    "Corp Warning": {element: note, type: warning}
+    "Corp Code": codeblock                      # paragraph style -> block
+    "Corp Button": uicontrol                    # character style -> inline
+
+Heading levels go to the converter (:func:`heading_levels`, through
+``resolve_style_map``). Elements are applied by the ``styles`` processing
+stage to blocks and inline elements carrying the style as ``data-style``;
+the other keys of a mapping (``type``, ``outputclass``, …) become attributes.
+Roles: ``map_title`` makes the first heading of that style the map title (a
+heading rule), ``appendix`` makes its top-level entries appendices (the
+``appendices`` stage).
+
+A style map file is YAML or JSON holding the entries, either at the top
+level or under ``styles``.
+"""
+
+import json
+import logging
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, Mapping, Optional
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "BLOCK_ELEMENTS",
+    "INLINE_ELEMENTS",
+    "ROLES",
+    "StyleRule",
+    "heading_levels",
+    "load_style_map_file",
+    "lookup",
+    "parse_style_map",
+    "parse_style_rule",
+    "resolve_style_rules",
+]
+
+BLOCK_ELEMENTS = frozenset({"p", "note", "lq", "codeblock", "pre", "screen", "msgblock", "lines", "div"})
+INLINE_ELEMENTS = frozenset({"ph", "b", "i", "u", "tt", "sup", "sub", "codeph", "uicontrol", "wintitle",
+                             "userinput", "systemoutput", "cmdname", "filepath", "varname", "parmname",
+                             "option", "apiname", "msgph", "term", "keyword", "cite", "q"})
+ROLES = ("map_title", "appendix")
+
+
+@dataclass(frozen=True)
+class StyleRule:
+    style: str
+    heading: Optional[int] = None
+    role: Optional[str] = None
+    element: Optional[str] = None
+    attributes: Mapping[str, str] = field(default_factory=dict)
+
+    @property
+    def inline(self) -> bool:
+        return self.element in INLINE_ELEMENTS
+
+
+def parse_style_rule(style: str, value: Any) -> StyleRule:
+    """Read one style map entry; raises ``ValueError`` when it is not valid."""
+    if isinstance(value, bool):
+        raise ValueError(f"style {style!r}: expected a level, an element or a mapping")
+    if isinstance(value, int) or (isinstance(value, str) and value.strip().isdigit()):
+        value = {"heading": int(value)}
+    elif isinstance(value, str):
+        value = {"element": value}
+    if not isinstance(value, Mapping):
+        raise ValueError(f"style {style!r}: expected a level, an element or a mapping")
+    data = dict(value)
+    heading = data.pop("heading", data.pop("level", None))
+    role = data.pop("role", None)
+    element = data.pop("element", None)
+    if heading is not None:
+        heading = int(heading)
+        if heading < 1:
+            raise ValueError(f"style {style!r}: heading level must be at least 1")
+    if role is not None and role not in ROLES:
+        raise ValueError(f"style {style!r}: unknown role {role!r} (expected {', '.join(ROLES)})")
+    if role is not None and heading is None:
+        raise ValueError(f"style {style!r}: a role needs a heading level")
+    if element is not None:
+        element = str(element)
+        if element not in BLOCK_ELEMENTS | INLINE_ELEMENTS:
+            raise ValueError(f"style {style!r}: unsupported element {element!r}")
+        if heading is not None:
+            raise ValueError(f"style {style!r}: a heading cannot also map to an element")
+    if heading is None and element is None:
+        raise ValueError(f"style {style!r}: needs a heading level or an element")
+    attributes = {str(k): str(v) for k, v in data.items() if v is not None and not isinstance(v, (Mapping, list))}
+    return StyleRule(style=str(style), heading=heading, role=role, element=element, attributes=attributes)
+
+
+def parse_style_map(mapping: Optional[Mapping[str, Any]], report: Any = None) -> Dict[str, StyleRule]:
+    """Rules of *mapping* by style name; invalid entries are skipped with a warning."""
+    rules: Dict[str, StyleRule] = {}
+    for style, value in (mapping or {}).items():
+        try:
+            rules[str(style)] = parse_style_rule(str(style), value)
+        except (TypeError, ValueError) as exc:
+            logger.warning("Ignoring style map entry: %s", exc)
+            if report is not None:
+                report.warning("styles", f"Ignoring style map entry: {exc}")
+    return rules
+
+
+def load_style_map_file(path: str | Path) -> Dict[str, Any]:
+    """Entries of a YAML or JSON style map file; raises ``ValueError``."""
+    path = Path(path)
+    try:
+        text = path.read_text(encoding="utf-8")
+    except OSError as exc:
+        raise ValueError(f"Cannot read style map {path}: {exc}") from exc
+    if path.suffix.lower() == ".json":
+        data = json.loads(text)
+    else:
+        import yaml  # type: ignore
+
+        data = yaml.safe_load(text) or {}
+    if isinstance(data, Mapping) and isinstance(data.get("styles"), Mapping):
+        data = data["styles"]
+    if not isinstance(data, Mapping):
+        raise ValueError(f"{path.name}: expected a mapping of styles")
+    return dict(data)
+
+
+def resolve_style_rules(metadata: Optional[Mapping[str, Any]] = None, report: Any = None) -> Dict[str, StyleRule]:
+    """``default_style_map.yml`` merged with ``metadata["style_map"]``, parsed."""
+    merged: Dict[str, Any] = {}
+    try:
+        from orlando_toolkit.config import ConfigManager
+        merged = dict(ConfigManager().get_style_map() or {})
+    except Exception as exc:
+        logger.debug("Style map config unavailable: %s", exc)
+    override = (metadata or {}).get("style_map") if metadata else None
+    if isinstance(override, Mapping):
+        merged.update(override)
+    return parse_style_map(merged, report)
+
+
+def heading_levels(rules: Mapping[str, StyleRule]) -> Dict[str, int]:
+    return {style: rule.heading for style, rule in rules.items() if rule.heading is not None}
+
+
+def lookup(rules: Mapping[str, StyleRule], style: Optional[str]) -> Optional[StyleRule]:
+    """Rule for *style*: exact name first, then case-insensitively."""
+    if not style:
+        return None
+    rule = rules.get(style)
+    if rule is None:
+        folded = style.strip().casefold()
+        rule = next((r for name, r in rules.items() if name.strip().casefold() == folded), None)
+    return rule
@@ -0,0 +1,173 @@
+from __future__ import annotations
+
+"""Which source styles a document uses and what they became.
+
+The ``styles`` stage (:class:`~orlando_toolkit.core.processing.styles.StyleMapStage`)
+records the source style of every styled paragraph and inline element as a
+``data-paragraph-style`` / ``data-character-style`` hint that survives the
+later stages renaming the element. :func:`compute_style_usage` lists, for the
+current topics, every style with its kind, occurrence count, the DITA
+elements it ended up as and a status:
+
+- ``mapped`` – the style map gives it an element;
+- ``converted`` – a processing stage turned it into something other than a
+  plain ``p``/``ph`` (code blocks, steps, …) or moved it into a note, list
+  or definition (``note/p``);
+- ``built-in`` – a Word built-in or a style other stages handle
+  (``styles.ignore`` patterns), left as plain text on purpose;
+- ``unmapped`` – a custom style that fell through to a plain paragraph or
+  phrase; add it to the style map.
+
+Heading styles are listed from the map entries they produced.
+:meth:`ConversionService.finalize_conversion` notes the list under
+``style_usage`` in the report; :func:`style_usage_csv` exports it.
+"""
+
+import csv
+import io
+import logging
+from dataclasses import asdict, dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Tuple
+
+from orlando_toolkit.core.models import DitaContext
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "CHARACTER_HINT",
+    "PARAGRAPH_HINT",
+    "StyleUsage",
+    "compute_style_usage",
+    "record_style_usage",
+    "style_usage_csv",
+    "write_style_usage_csv",
+]
+
+PARAGRAPH_HINT = "data-paragraph-style"
+CHARACTER_HINT = "data-character-style"
+_KINDS = ((PARAGRAPH_HINT, "paragraph"), (CHARACTER_HINT, "character"))
+_PLAIN = frozenset({"p", "ph"})
+# A plain paragraph moved into one of these was converted ("note/p")
+_CONTAINERS = frozenset({"note", "lq", "li", "dd", "step", "info", "stepresult", "fig"})
+_CSV_COLUMNS = ("style", "kind", "count", "elements", "status")
+
+
+@dataclass
+class StyleUsage:
+    style: str
+    kind: str  # paragraph, character or heading
+    count: int = 0
+    elements: List[str] = field(default_factory=list)
+    status: str = "unmapped"
+
+    @property
+    def unmapped(self) -> bool:
+        return self.status == "unmapped"
+
+    def to_dict(self) -> Dict[str, Any]:
+        return asdict(self)
+
+
+def _local(tag: Any) -> str:
+    return tag.rsplit("}", 1)[-1] if isinstance(tag, str) else ""
+
+
+def _element_name(el: Any) -> str:
+    name = _local(el.tag)
+    parent = el.getparent()
+    if name in _PLAIN and parent is not None and _local(parent.tag) in _CONTAINERS:
+        return f"{_local(parent.tag)}/{name}"
+    return name
+
+
+def _style_options(context: DitaContext) -> Tuple[Dict[str, Any], List[Any]]:
+    from orlando_toolkit.core.processing import resolve_conversion_options
+    from orlando_toolkit.core.processing.styles import _compile
+    from orlando_toolkit.core.style_map import resolve_style_rules
+
+    options = resolve_conversion_options(context.metadata).get("styles")
+    ignore = options.get("ignore") if isinstance(options, dict) else None
+    return resolve_style_rules(context.metadata), _compile(ignore)
+
+
+def _status(usage: StyleUsage, rules: Dict[str, Any], ignore: List[Any]) -> str:
+    from orlando_toolkit.core.style_map import lookup
+
+    rule = lookup(rules, usage.style)
+    if usage.kind == "heading" or (rule is not None and rule.element is not None):
+        return "mapped"
+    if not set(usage.elements) <= _PLAIN:
+        return "converted"
+    if any(pattern.search(usage.style) for pattern in ignore):
+        return "built-in"
+    return "unmapped"
+
+
+def compute_style_usage(context: DitaContext) -> List[StyleUsage]:
+    """Styles of the current map and topics: headings first, then by kind and count."""
+    found: Dict[Tuple[str, str], StyleUsage] = {}
+
+    def _count(style: str, kind: str, element: str) -> None:
+        usage = found.setdefault((kind, style), StyleUsage(style, kind))
+        usage.count += 1
+        if element not in usage.elements:
+            usage.elements.append(element)
+
+    root = getattr(context, "ditamap_root", None)
+    for ref in root.iter() if root is not None else ():
+        style = ref.get("data-style") if ref.tag in ("topicref", "topichead") else None
+        if style:
+            topic = context.topics.get((ref.get("href") or "").rsplit("/", 1)[-1])
+            _count(style, "heading", _local(topic.tag) if topic is not None else _local(ref.tag))
+    for topic in (context.topics or {}).values():
+        for el in topic.iter():
+            if not isinstance(el.tag, str):
+                continue
+            for hint, kind in _KINDS:
+                style = el.get(hint)
+                if style:
+                    _count(style, kind, _element_name(el))
+
+    rules, ignore = _style_options(context)
+    order = {"heading": 0, "paragraph": 1, "character": 2}
+    usages = sorted(found.values(), key=lambda u: (order[u.kind], -u.count, u.style.casefold()))
+    for usage in usages:
+        usage.status = _status(usage, rules, ignore)
+    return usages
+
+
+def record_style_usage(context: DitaContext) -> Optional[List[StyleUsage]]:
+    """Compute the style usage and note it under ``style_usage``; never raises."""
+    report = getattr(context, "report", None)
+    try:
+        usages = compute_style_usage(context)
+    except Exception as exc:
+        logger.warning("Style usage unavailable: %s", exc)
+        return None
+    if report is not None and usages:
+        unmapped = [u.style for u in usages if u.unmapped]
+        message = f"{len(usages)} source style(s) in use"
+        if unmapped:
+            message += f", {len(unmapped)} unmapped: {', '.join(unmapped[:10])}"
+            if len(unmapped) > 10:
+                message += f", … {len(unmapped) - 10} more"
+        report.info("style_usage", message, styles=[u.to_dict() for u in usages], unmapped=len(unmapped))
+    return usages
+
+
+def style_usage_csv(usages: List[StyleUsage]) -> str:
+    """CSV text with one row per style (elements joined with ``;``)."""
+    buffer = io.StringIO()
+    writer = csv.writer(buffer, lineterminator="\n")
+    writer.writerow(_CSV_COLUMNS)
+    for usage in usages:
+        writer.writerow([usage.style, usage.kind, usage.count, ";".join(usage.elements), usage.status])
+    return buffer.getvalue()
+
+
+def write_style_usage_csv(usages: List[StyleUsage], path: str | Path) -> Path:
+    """Write :func:`style_usage_csv` to *path* (UTF-8 with BOM, so spreadsheets detect the encoding)."""
+    path = Path(path)
+    path.write_text(style_usage_csv(usages), encoding="utf-8-sig")
+    return path
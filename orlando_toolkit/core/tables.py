@@ -0,0 +1,519 @@
+from __future__ import annotations
+
+"""CALS tables with merged and nested cells.
+
+Converters emit merged cells inconsistently (a vertically merged Word cell
+as several empty entries, a horizontal span without ``namest``/``nameend``)
+and DITA has no nested tables. This module models a table as positioned
+cells and writes valid CALS:
+
+- :class:`TableModel` holds :class:`TableCell`\\ s with their row, column,
+  ``rowspan`` and ``colspan``; a cell's content may include nested tables;
+- :func:`flatten` merges nested tables into their host: the columns and
+  rows of the nested table subdivide the host cell, and every other cell of
+  the host row or column spans the new subdivisions, so the layout is kept
+  without a table inside an entry;
+- :func:`build_cals` writes ``table/tgroup`` with one ``colspec`` per column
+  (relative widths), ``namest``/``nameend`` for horizontal spans and
+  ``morerows`` for vertical ones, header rows in ``thead``;
+- :func:`place_cells` fills a grid from cells given in reading order with
+  their spans (text formats such as AsciiDoc).
+
+For Word sources, :func:`restore_word_tables` runs after the plugin handler:
+it reads the tables of ``word/document.xml`` (``gridSpan``, ``vMerge``,
+``hMerge``, ``gridBefore``/``gridAfter``, nested ``w:tbl``, header rows),
+and each table with merged or nested cells replaces the converted table
+holding the same text. The converter's cell content (formatting, images) is
+kept where cells line up by text; the ``tables`` section of
+``conversion.yml`` controls the pass.
+"""
+
+import difflib
+import logging
+from dataclasses import dataclass, field
+from fractions import Fraction
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Mapping, Optional, Sequence, Tuple, Union
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.placement import normalize, topic_order
+from orlando_toolkit.core.word_source import WordSource, w
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "TableCell",
+    "TableModel",
+    "build_cals",
+    "flatten",
+    "place_cells",
+    "read_word_tables",
+    "restore_word_tables",
+]
+
+_WRAPPERS = ("sdt", "sdtContent", "customXml", "smartTag")
+_TABLES = ("table", "simpletable")
+
+# A cell's content: paragraphs (text, or converted elements) and nested tables, in order
+Content = List[Union[str, Any, "TableModel"]]
+
+
+@dataclass
+class TableCell:
+    row: int
+    col: int
+    rowspan: int = 1
+    colspan: int = 1
+    content: Content = field(default_factory=list)
+    header: bool = False
+
+    @property
+    def text(self) -> str:
+        return normalize(" ".join(_item_text(item) for item in self.content))
+
+
+@dataclass
+class TableModel:
+    cells: List[TableCell] = field(default_factory=list)
+    rows: int = 0
+    cols: int = 0
+    widths: List[float] = field(default_factory=list)     # relative column widths
+
+    @property
+    def nested(self) -> bool:
+        return any(isinstance(item, TableModel) for cell in self.cells for item in cell.content)
+
+    @property
+    def spanned(self) -> bool:
+        return any(cell.rowspan > 1 or cell.colspan > 1 for cell in self.cells)
+
+    @property
+    def text(self) -> str:
+        return normalize(" ".join(cell.text for cell in sorted(self.cells, key=lambda c: (c.row, c.col))))
+
+
+def _item_text(item: Any) -> str:
+    if isinstance(item, TableModel):
+        return item.text
+    if isinstance(item, str):
+        return item
+    return "".join(item.itertext())
+
+
+def place_cells(rows: Iterable[Sequence[Tuple[int, int, Content]]], columns: int,
+                header_rows: int = 0) -> TableModel:
+    """Model of cells given row by row as ``(colspan, rowspan, content)``.
+
+    A cell takes the next column not covered by a row span from above;
+    cells beyond *columns* start a new row (text formats flow cells).
+    """
+    model = TableModel(cols=columns)
+    covered: Dict[Tuple[int, int], bool] = {}
+    row = col = 0
+
+    def _next_free(r: int, c: int) -> Tuple[int, int]:
+        while True:
+            if c >= columns:
+                r, c = r + 1, 0
+            elif covered.get((r, c)):
+                c += 1
+            else:
+                return r, c
+
+    for cells in rows:
+        for colspan, rowspan, content in cells:
+            row, col = _next_free(row, col)
+            colspan = max(1, min(colspan, columns - col))
+            rowspan = max(1, rowspan)
+            model.cells.append(TableCell(row, col, rowspan, colspan, list(content), header=row < header_rows))
+            for r in range(row, row + rowspan):
+                for c in range(col, col + colspan):
+                    covered[(r, c)] = True
+            col += colspan
+        if col:
+            row, col = row + 1, 0
+    model.rows = max((c.row + c.rowspan for c in model.cells), default=0)
+    return model
+
+
+def _widths(model: TableModel) -> List[Fraction]:
+    raw = [Fraction(w).limit_denominator(10000) if w and w > 0 else Fraction(0) for w in model.widths[:model.cols]]
+    raw += [Fraction(0)] * (model.cols - len(raw))
+    known = [w for w in raw if w]
+    default = sum(known) / len(known) if known else Fraction(1)
+    raw = [w or default for w in raw]
+    total = sum(raw)
+    return [w / total for w in raw]
+
+
+def _bands(content: Content) -> List[Union[List[Any], TableModel]]:
+    """Content split into runs of paragraphs and nested tables."""
+    bands: List[Union[List[Any], TableModel]] = []
+    for item in content:
+        if isinstance(item, TableModel):
+            bands.append(flatten(item))
+        elif bands and isinstance(bands[-1], list):
+            bands[-1].append(item)
+        else:
+            bands.append([item])
+    return bands
+
+
+def flatten(model: TableModel) -> TableModel:
+    """Equivalent table without nested tables (see the module documentation)."""
+    if not model.nested:
+        return model
+    widths = _widths(model)
+    edges = [sum(widths[:i], Fraction(0)) for i in range(model.cols + 1)]
+    # Leaf pieces: (x0, x1, y0, y1, content, header) in outer column-width and row-index space
+    pieces: List[Tuple[Fraction, Fraction, Fraction, Fraction, Content, bool]] = []
+    for cell in model.cells:
+        x0, x1 = edges[cell.col], edges[min(cell.col + cell.colspan, model.cols)]
+        y0, y1 = Fraction(cell.row), Fraction(cell.row + cell.rowspan)
+        bands = _bands(cell.content) or [[]]
+        if len(bands) == 1 and isinstance(bands[0], list):
+            pieces.append((x0, x1, y0, y1, bands[0], cell.header))
+            continue
+        height = (y1 - y0) / sum(b.rows if isinstance(b, TableModel) else 1 for b in bands)
+        top = y0
+        for band in bands:
+            if not isinstance(band, TableModel):
+                pieces.append((x0, x1, top, top + height, band, cell.header))
+                top += height
+                continue
+            inner = [x0 + (x1 - x0) * e for e in
+                     [sum(_widths(band)[:i], Fraction(0)) for i in range(band.cols + 1)]]
+            for sub in band.cells:
+                pieces.append((inner[sub.col], inner[min(sub.col + sub.colspan, band.cols)],
+                               top + height * sub.row, top + height * (sub.row + sub.rowspan),
+                               list(sub.content), cell.header or sub.header))
+            top += height * band.rows
+    xs = sorted({p[0] for p in pieces} | {p[1] for p in pieces})
+    ys = sorted({p[2] for p in pieces} | {p[3] for p in pieces})
+    column = {x: i for i, x in enumerate(xs)}
+    line = {y: i for i, y in enumerate(ys)}
+    flat = TableModel(rows=len(ys) - 1, cols=len(xs) - 1,
+                      widths=[float(xs[i + 1] - xs[i]) for i in range(len(xs) - 1)])
+    for x0, x1, y0, y1, content, header in pieces:
+        flat.cells.append(TableCell(line[y0], column[x0], line[y1] - line[y0], column[x1] - column[x0],
+                                    content, header))
+    return _compact(flat)
+
+
+def _compact(model: TableModel) -> TableModel:
+    """Drop rows where no cell starts (CALS rows need an entry)."""
+    starts = {cell.row for cell in model.cells}
+    empty = [r for r in range(model.rows) if r not in starts]
+    for r in reversed(empty):
+        for cell in model.cells:
+            if cell.row > r:
+                cell.row -= 1
+            elif cell.row < r < cell.row + cell.rowspan:
+                cell.rowspan -= 1
+        model.rows -= 1
+    return model
+
+
+def _fill(entry: Any, content: Content) -> None:
+    paragraphs = [item for item in content if not isinstance(item, TableModel)]
+    if len(paragraphs) == 1 and isinstance(paragraphs[0], str):
+        entry.text = paragraphs[0]
+        return
+    for item in paragraphs:
+        if isinstance(item, str):
+            ET.SubElement(entry, "p").text = item
+        elif item.tag == "entry":           # a converted entry: take over its content
+            entry.text = (entry.text or "") + (item.text or "")
+            for child in list(item):
+                entry.append(child)
+        else:
+            entry.append(item)
+
+
+def build_cals(model: TableModel, table: Optional[Any] = None) -> Any:
+    """Write *model* (nested tables are flattened first) as a CALS ``table``.
+
+    With *table*, its ``tgroup`` (or a simpletable's rows) is replaced and its
+    attributes, title and description are kept.
+    """
+    model = flatten(model)
+    if table is None or table.tag != "table":
+        new = ET.Element("table")
+        if table is not None:
+            for name in ("id", "outputclass", "frame", "rowheader"):
+                if table.get(name) is not None:
+                    new.set(name, table.get(name))
+        table = new
+    for child in [c for c in table if c.tag in ("tgroup", "sthead", "strow")]:
+        table.remove(child)
+    tgroup = ET.SubElement(table, "tgroup", cols=str(model.cols))
+    widths = _widths(model)
+    smallest = min(widths) if widths else Fraction(1)
+    for index, width in enumerate(widths, start=1):
+        relative = round(float(width / smallest), 2)
+        ET.SubElement(tgroup, "colspec", colname=f"c{index}", colnum=str(index),
+                      colwidth=f"{relative:g}*")
+    by_row: Dict[int, List[TableCell]] = {}
+    for cell in model.cells:
+        by_row.setdefault(cell.row, []).append(cell)
+    # Leading rows of header cells are the thead, if no header cell spans into the body
+    head_rows = 0
+    while head_rows < model.rows and by_row.get(head_rows) and all(c.header for c in by_row[head_rows]):
+        head_rows += 1
+    while head_rows and any(c.row < head_rows < c.row + c.rowspan for c in model.cells):
+        head_rows -= 1
+    sections = {}
+    for r in range(model.rows):
+        name = "thead" if r < head_rows else "tbody"
+        if name not in sections:
+            sections[name] = ET.SubElement(tgroup, name)
+        row = ET.SubElement(sections[name], "row")
+        for cell in sorted(by_row.get(r, []), key=lambda c: c.col):
+            entry = ET.SubElement(row, "entry")
+            if cell.colspan > 1:
+                entry.set("namest", f"c{cell.col + 1}")
+                entry.set("nameend", f"c{cell.col + cell.colspan}")
+            else:
+                entry.set("colname", f"c{cell.col + 1}")
+            if cell.rowspan > 1:
+                entry.set("morerows", str(cell.rowspan - 1))
+            _fill(entry, cell.content)
+    return table
+
+
+# -- Word --------------------------------------------------------------------------------------------
+
+def _children(node: Any, tag: str) -> Iterable[Any]:
+    """Children named *tag*, looking through content controls and custom XML."""
+    for child in node:
+        if child.tag == w(tag):
+            yield child
+        elif isinstance(child.tag, str) and child.tag.rsplit("}", 1)[-1] in _WRAPPERS:
+            yield from _children(child, tag)
+
+
+def _val(el: Optional[Any], default: Optional[str] = None) -> Optional[str]:
+    return default if el is None else el.get(w("val"), default)
+
+
+def _paragraph_text(paragraph: Any) -> str:
+    parts = []
+    for el in paragraph.iter():
+        if el.tag == w("t"):
+            parts.append(el.text or "")
+        elif el.tag in (w("tab"), w("br")):
+            parts.append(" ")
+    return normalize("".join(parts))
+
+
+def _cell_content(tc: Any) -> Content:
+    content: Content = []
+    for child in tc:
+        if child.tag == w("p"):
+            text = _paragraph_text(child)
+            if text:
+                content.append(text)
+        elif child.tag == w("tbl"):
+            content.append(word_table(child))
+        elif isinstance(child.tag, str) and child.tag.rsplit("}", 1)[-1] in _WRAPPERS:
+            content.extend(_cell_content(child))
+    return content
+
+
+def word_table(tbl: Any) -> TableModel:
+    """Model of one ``w:tbl`` (nested tables included)."""
+    grid = tbl.find(w("tblGrid"))
+    widths = [float(g.get(w("w")) or 0) for g in grid.findall(w("gridCol"))] if grid is not None else []
+    model = TableModel(widths=widths)
+    open_cells: Dict[int, TableCell] = {}        # grid column -> cell a vMerge continues
+    header = True
+    for r, tr in enumerate(_children(tbl, "tr")):
+        props = tr.find(w("trPr"))
+        header = header and props is not None and props.find(w("tblHeader")) is not None \
+            and _val(props.find(w("tblHeader")), "1") not in ("0", "false")
+        col = int(_val(props.find(w("gridBefore")) if props is not None else None, "0") or 0)
+        previous: Optional[TableCell] = None
+        for tc in _children(tr, "tc"):
+            tc_props = tc.find(w("tcPr"))
+            span = int(_val(tc_props.find(w("gridSpan")) if tc_props is not None else None, "1") or 1)
+            vmerge = tc_props.find(w("vMerge")) if tc_props is not None else None
+            hmerge = tc_props.find(w("hMerge")) if tc_props is not None else None
+            if vmerge is not None and _val(vmerge, "continue") == "continue" and col in open_cells:
+                above = open_cells[col]
+                above.rowspan = r - above.row + 1
+                above.content.extend(_cell_content(tc))
+            elif hmerge is not None and _val(hmerge, "continue") == "continue" and previous is not None:
+                previous.colspan += span
+                previous.content.extend(_cell_content(tc))
+            else:
+                previous = TableCell(r, col, 1, span, _cell_content(tc), header=header)
+                model.cells.append(previous)
+                for c in range(col, col + span):
+                    open_cells.pop(c, None)
+                if vmerge is not None:
+                    open_cells[col] = previous
+            col += span
+        model.rows = r + 1
+        model.cols = max(model.cols, col)
+    model.cols = max(model.cols, len(widths), max((c.col + c.colspan for c in model.cells), default=0))
+    return model
+
+
+def read_word_tables(path: str | Path | WordSource) -> List[TableModel]:
+    """Body tables of a ``.docx`` in document order (nested ones inside their cells)."""
+    docx = WordSource.of(path)
+    body = docx.body if docx is not None else None
+    return [word_table(tbl) for tbl in _children(body, "tbl")] if body is not None else []
+
+
+# -- Restoring converted tables ----------------------------------------------------------------------
+
+def _squash(text: str) -> str:
+    return "".join(text.split())
+
+
+def _iter(node: Any, tags: Sequence[str]) -> Iterable[Any]:
+    return (el for el in node.iter() if el.tag in tags)
+
+
+def _converted_leaves(node: Any) -> List[Any]:
+    """Entries (or runs of an entry's blocks beside a nested table) in reading order."""
+    leaves: List[Any] = []
+    for entry in (e for e in _iter(node, ("entry", "stentry")) if _host(e) is node):
+        if next(_iter(entry, _TABLES), None) is None:
+            leaves.append(entry)
+            continue
+        group: List[Any] = []
+        for child in entry:
+            if child.tag in _TABLES:
+                if group:
+                    leaves.append(group)
+                    group = []
+                leaves.extend(_converted_leaves(child))
+            else:
+                group.append(child)
+        if group:
+            leaves.append(group)
+    return leaves
+
+
+def _host(entry: Any) -> Any:
+    return next(a for a in entry.iterancestors() if a.tag in _TABLES)
+
+
+def _model_leaves(model: TableModel) -> List[Tuple[TableCell, int]]:
+    """(cell, index of the paragraph run in the cell) in reading order, nested tables expanded."""
+    leaves: List[Tuple[TableCell, int]] = []
+    for cell in sorted(model.cells, key=lambda c: (c.row, c.col)):
+        run = 0
+        in_run = False
+        for item in cell.content:
+            if isinstance(item, TableModel):
+                leaves.extend(_model_leaves(item))
+                in_run = False
+            elif not in_run:
+                leaves.append((cell, run))
+                run += 1
+                in_run = True
+        if not cell.content:
+            leaves.append((cell, 0))
+    return leaves
+
+
+def _leaf_text(leaf: Union[Any, List[Any]]) -> str:
+    items = leaf if isinstance(leaf, list) else [leaf]
+    return _squash("".join("".join(i.itertext()) for i in items))
+
+
+def _model_leaf_text(cell: TableCell, run: int) -> str:
+    runs: List[List[Any]] = []
+    in_run = False
+    for item in cell.content:
+        if isinstance(item, TableModel):
+            in_run = False
+        elif in_run:
+            runs[-1].append(item)
+        else:
+            runs.append([item])
+            in_run = True
+    return _squash(" ".join(_item_text(i) for i in runs[run])) if run < len(runs) else ""
+
+
+def _reuse_content(model: TableModel, converted: Any) -> None:
+    """Replace the model's plain text by the converted content of matching cells."""
+    ours = _model_leaves(model)
+    theirs = _converted_leaves(converted)
+    matcher = difflib.SequenceMatcher(a=[_model_leaf_text(c, r) for c, r in ours],
+                                      b=[_leaf_text(leaf) for leaf in theirs], autojunk=False)
+    replacements: Dict[Tuple[int, int], Any] = {}
+    for block in matcher.get_matching_blocks():
+        for k in range(block.size):
+            cell, run = ours[block.a + k]
+            replacements[(id(cell), run)] = theirs[block.b + k]
+    for cell in {id(c): c for c, _ in ours}.values():
+        content: Content = []
+        run, in_run = 0, False
+        for item in cell.content:
+            if isinstance(item, TableModel):
+                content.append(item)
+                in_run = False
+                continue
+            if not in_run:
+                leaf = replacements.get((id(cell), run))
+                run, in_run = run + 1, True
+                if leaf is not None:
+                    content.extend(leaf if isinstance(leaf, list) else [leaf])
+                    continue
+            elif replacements.get((id(cell), run - 1)) is not None:
+                continue                  # covered by the converted run already taken
+            content.append(item)
+        cell.content = content
+
+
+def _replace(old: Any, new: Any) -> None:
+    if new is old:
+        return
+    parent = old.getparent()
+    new.tail = old.tail
+    parent.insert(parent.index(old), new)
+    parent.remove(old)
+
+
+def restore_word_tables(path: str | Path | WordSource, context: Any, options: Optional[Mapping[str, Any]] = None,
+                        report: Any = None) -> int:
+    """Rebuild converted tables whose Word table has merged or nested cells; returns the number rebuilt."""
+    options = dict(options or {})
+    if not options.get("enabled", True):
+        return 0
+    report = report if report is not None else getattr(context, "report", None)
+    try:
+        tables = [t for t in read_word_tables(path) if t.spanned or t.nested]
+    except Exception as exc:
+        logger.warning("Could not read the tables of %s: %s", Path(path).name, exc)
+        return 0
+    if not tables:
+        return 0
+    candidates = [(name, el) for name in topic_order(context) for el in _iter(context.topics[name], _TABLES)
+                  if not any(a.tag in _TABLES for a in el.iterancestors())]
+    texts = [_squash("".join(_leaf_text(leaf) for leaf in _converted_leaves(el))) for _, el in candidates]
+    rebuilt = unplaced = 0
+    cursor = 0
+    for model in tables:
+        wanted = _squash("".join(_model_leaf_text(c, r) for c, r in _model_leaves(model)))
+        hit = next((i for i in range(cursor, len(candidates)) if texts[i] == wanted), None)
+        if hit is None:
+            unplaced += 1
+            continue
+        cursor = hit + 1
+        name, converted = candidates[hit]
+        _reuse_content(model, converted)
+        _replace(converted, build_cals(model, converted))
+        rebuilt += 1
+    if report is not None:
+        if rebuilt:
+            report.info("tables", f"{rebuilt} table(s) with merged or nested cells rebuilt", tables=rebuilt)
+        if unplaced:
+            report.warning("tables", f"{unplaced} table(s) with merged or nested cells could not be matched "
+                                     "with a converted table; their spans may be wrong")
+    return rebuilt
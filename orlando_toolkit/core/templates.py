@@ -0,0 +1,212 @@
+from __future__ import annotations
+
+"""Word template detection and template-specific presets.
+
+Documents produced from a corporate template usually need the same settings
+every time. Output profiles in ``profiles.yml`` can declare which templates
+they fit::
+
+    operator_manual:
+      templates:
+        names: ["Operator Manual.dotx"]     # attachedTemplate / docProps Template
+        fingerprints: ["3fa9c1d2e4b5"]       # styles fingerprint, see below
+        styles: ["OM Chapter", "OM Task"]    # custom styles that must all exist
+      style_map: {"OM Chapter": 1, "OM Task": 2}
+
+:func:`detect_template` reads the attached template name (``word/settings.xml``
+``w:attachedTemplate`` and ``docProps/app.xml`` ``Template``) and a styles
+fingerprint: the first 12 hex digits of the SHA-256 of the sorted custom style
+names in ``word/styles.xml``, stable across documents made from one template.
+
+:func:`match_template` scores each profile (fingerprint 3, template name 2,
+required styles 1) and selects the single best one. When several profiles tie
+the match is ambiguous: the GUI asks which to use, other callers get no
+automatic profile and a report warning. A job decides itself with
+``metadata["template_profile"]`` (a profile name, or ``"none"``); jobs that
+already name ``output_profiles`` are left alone.
+"""
+
+import hashlib
+import logging
+from dataclasses import dataclass, field
+from pathlib import Path, PurePosixPath
+from typing import Any, Dict, List, Mapping, Optional, Tuple
+
+from orlando_toolkit.core.word_source import PKG_REL_NS, R_NS, W_NS, WordSource
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "NO_TEMPLATE_PROFILE",
+    "TemplateInfo",
+    "TemplateMatch",
+    "apply_template_profile",
+    "detect_template",
+    "match_template",
+    "record_template_match",
+]
+
+NO_TEMPLATE_PROFILE = "none"
+
+_EXT_PROPS = "http://schemas.openxmlformats.org/officeDocument/2006/extended-properties"
+_STYLES_PART = "word/styles.xml"
+
+
+def _template_name(value: Optional[str]) -> Optional[str]:
+    """``file:///C:/Templates/Manual.dotx`` -> ``Manual.dotx``."""
+    if not value:
+        return None
+    return PurePosixPath(value.replace("\\", "/")).name or None
+
+
+@dataclass(frozen=True)
+class TemplateInfo:
+    """What a document says about the template it was made from."""
+
+    attached_template: Optional[str] = None
+    app_template: Optional[str] = None
+    fingerprint: Optional[str] = None
+    custom_styles: Tuple[str, ...] = ()
+
+    @property
+    def names(self) -> List[str]:
+        return [n for n in dict.fromkeys((self.attached_template, self.app_template)) if n]
+
+    @property
+    def known(self) -> bool:
+        return bool(self.names or self.fingerprint)
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {"names": self.names, "fingerprint": self.fingerprint, "custom_styles": len(self.custom_styles)}
+
+
+@dataclass(frozen=True)
+class TemplateMatch:
+    """Outcome of matching a document against the template presets."""
+
+    template: TemplateInfo
+    profile: Optional[str] = None
+    candidates: Tuple[str, ...] = ()
+    scores: Mapping[str, int] = field(default_factory=dict)
+
+    @property
+    def ambiguous(self) -> bool:
+        return self.profile is None and len(self.candidates) > 1
+
+
+def detect_template(path: str | Path | WordSource) -> TemplateInfo:
+    """Return the template name(s) and styles fingerprint of a Word document.
+
+    Non-Word files and unreadable parts give an empty :class:`TemplateInfo`.
+    """
+    docx = WordSource.of(path)
+    if docx is None:
+        return TemplateInfo()
+    try:
+        attached = None
+        settings = docx.part("word/settings.xml")
+        element = settings.find(f"{{{W_NS}}}attachedTemplate") if settings is not None else None
+        if element is not None:
+            rel_id = element.get(f"{{{R_NS}}}id")
+            rels = docx.part("word/_rels/settings.xml.rels")
+            for rel in (rels if rels is not None else ()):
+                if rel.tag == f"{{{PKG_REL_NS}}}Relationship" and rel.get("Id") == rel_id:
+                    attached = _template_name(rel.get("Target"))
+        app = docx.part("docProps/app.xml")
+        app_template = _template_name(app.findtext(f"{{{_EXT_PROPS}}}Template")) if app is not None else None
+
+        styles = docx.part(_STYLES_PART)
+        names: List[str] = []
+        for style in (styles.iter(f"{{{W_NS}}}style") if styles is not None else ()):
+            if style.get(f"{{{W_NS}}}customStyle") in ("1", "true"):
+                name = style.find(f"{{{W_NS}}}name")
+                names.append(name.get(f"{{{W_NS}}}val") if name is not None else style.get(f"{{{W_NS}}}styleId"))
+    except Exception as exc:
+        logger.warning("Could not read template information from %s: %s", docx.path.name, exc)
+        return TemplateInfo()
+    custom = tuple(sorted({n for n in names if n}))
+    fingerprint = hashlib.sha256("\n".join(custom).encode("utf-8")).hexdigest()[:12] if custom else None
+    return TemplateInfo(attached_template=attached, app_template=app_template,
+                        fingerprint=fingerprint, custom_styles=custom)
+
+
+def _template_rules(profiles: Optional[Mapping[str, Any]]) -> Dict[str, Mapping[str, Any]]:
+    if profiles is None:
+        from orlando_toolkit.core.profiles import available_profiles
+
+        profiles = available_profiles()
+    return {name: data["templates"] for name, data in profiles.items()
+            if isinstance(data, Mapping) and isinstance(data.get("templates"), Mapping)}
+
+
+def _as_list(value: Any) -> List[str]:
+    if value is None:
+        return []
+    return [str(value)] if isinstance(value, str) else [str(v) for v in value]
+
+
+def match_template(template: TemplateInfo, profiles: Optional[Mapping[str, Any]] = None) -> TemplateMatch:
+    """Score the profiles declaring ``templates`` against *template*."""
+    scores: Dict[str, int] = {}
+    names = {n.lower() for n in template.names}
+    for profile, rules in _template_rules(profiles).items():
+        score = 0
+        if template.fingerprint and template.fingerprint in _as_list(rules.get("fingerprints")):
+            score += 3
+        if names & {n.lower() for n in _as_list(rules.get("names"))}:
+            score += 2
+        required = _as_list(rules.get("styles"))
+        if required and set(required) <= set(template.custom_styles):
+            score += 1
+        if score:
+            scores[profile] = score
+    if not scores:
+        return TemplateMatch(template)
+    best = max(scores.values())
+    candidates = tuple(sorted(p for p, s in scores.items() if s == best))
+    return TemplateMatch(template, profile=candidates[0] if len(candidates) == 1 else None,
+                         candidates=candidates, scores=scores)
+
+
+def apply_template_profile(path: str | Path | WordSource, metadata: Mapping[str, Any],
+                           profiles: Optional[Mapping[str, Any]] = None) -> Tuple[Dict[str, Any], Optional[TemplateMatch]]:
+    """Return *metadata* with the template preset applied, and the match.
+
+    Explicit job settings win over the preset. Returns ``(metadata, None)``
+    when the job already chose its profiles or disabled presets.
+    """
+    from orlando_toolkit.options import build_options, with_options, with_output_profile
+
+    metadata = dict(metadata)
+    choice = metadata.pop("template_profile", None)
+    if metadata.get("output_profiles") or choice == NO_TEMPLATE_PROFILE:
+        return metadata, None
+    match = match_template(detect_template(path), profiles)
+    selected = choice or match.profile
+    if not selected:
+        return metadata, match
+    if choice and choice != match.profile:
+        match = TemplateMatch(match.template, profile=choice, candidates=match.candidates, scores=match.scores)
+    if profiles is not None and selected in profiles:
+        from orlando_toolkit.options import OutputProfile
+
+        preset = with_output_profile(OutputProfile.from_mapping(selected, profiles[selected]))
+    else:
+        preset = with_output_profile(selected)
+    merged = build_options(preset, with_options(metadata)).to_metadata()
+    return merged, match
+
+
+def record_template_match(report: Any, match: Optional[TemplateMatch]) -> None:
+    """Note the detected template and the preset used (or why none was)."""
+    if report is None or match is None or not match.template.known:
+        return
+    detail = match.template.to_dict()
+    label = ", ".join(match.template.names) or f"styles {match.template.fingerprint}"
+    if match.profile:
+        report.info("template", f"Template {label}: using profile '{match.profile}'", profile=match.profile, **detail)
+    elif match.ambiguous:
+        report.warning("template", f"Template {label} matches several profiles ({', '.join(match.candidates)}); "
+                       "none applied", candidates=list(match.candidates), **detail)
+    else:
+        report.info("template", f"Template {label}: no matching profile", **detail)
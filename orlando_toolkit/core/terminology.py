@@ -0,0 +1,224 @@
+from __future__ import annotations
+
+"""Terminology consistency against banned-term lists.
+
+Style guides ban terms in favour of others ("airplane" -> "aircraft").
+:class:`TermSettings` (``terminology`` in ``conversion.yml``) gathers the
+rules from ``terms`` and from ``term_files``, read by
+:func:`read_term_list`:
+
+- CSV (``.csv``, or ``.tsv`` tab separated): banned term, preferred term and
+  an optional note per row; a first row naming the columns is skipped;
+- TBX (``.tbx``, TBX 2 ``termEntry/langSet/tig`` or TBX 3
+  ``conceptEntry/langSec/termSec``): the terms whose administrative status
+  or normative authorization is deprecated or superseded are banned in
+  favour of the preferred (else the first admitted) term of the same entry
+  and language; the entry definition becomes the note.
+
+:func:`check_terminology` lists the violations per topic with the find and
+replace engine (:mod:`orlando_toolkit.core.find_replace`), whole words only,
+and :func:`replace_term` replaces them through it, keeping the capitalisation
+of each occurrence ("Airplane" -> "Aircraft").
+"""
+
+import csv
+import io
+import logging
+import re
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, Iterable, List, Mapping, Optional
+
+from orlando_toolkit.core.find_replace import find_matches, replace_text
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.placement import topic_order
+from orlando_toolkit.core.processing.text import is_element, local_name
+from orlando_toolkit.core.xml_security import parse_bytes
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["TermRule", "TermSettings", "TermViolation", "check_terminology", "read_term_list", "replace_term"]
+
+_HEADER_NAMES = frozenset({"banned", "deprecated", "avoid", "term", "do not use"})
+_BANNED_STATUS = ("deprecated", "superseded", "notrecommended")
+_XML_LANG = "{http://www.w3.org/XML/1998/namespace}lang"
+
+
+@dataclass
+class TermRule:
+    """Use :attr:`preferred` instead of :attr:`banned`; :attr:`note` explains why."""
+
+    banned: str
+    preferred: str = ""
+    note: str = ""
+    case_sensitive: bool = False
+
+    @property
+    def pattern(self) -> str:
+        """Regular expression matching :attr:`banned` as whole words, any spacing between them."""
+        words = [re.escape(word) for word in self.banned.split()]
+        return r"(?<!\w)" + r"\s+".join(words) + r"(?!\w)"
+
+
+@dataclass
+class TermViolation:
+    """Uses of a banned term in one topic: :attr:`count` and up to three :attr:`snippets`."""
+
+    topic: str
+    title: str
+    rule: TermRule
+    count: int = 0
+    snippets: List[str] = field(default_factory=list)
+
+
+@dataclass
+class TermSettings:
+    terms: Dict[str, str] = field(default_factory=dict)
+    term_files: List[str] = field(default_factory=list)
+    language: Optional[str] = None
+    case_sensitive: bool = False
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "TermSettings":
+        data = dict(data or {})
+        files = data.get("term_files") or []
+        return cls(terms={str(k): "" if v is None else str(v) for k, v in dict(data.get("terms") or {}).items()},
+                   term_files=[str(files)] if isinstance(files, str) else [str(f) for f in files],
+                   language=str(data["language"]) if data.get("language") else None,
+                   case_sensitive=bool(data.get("case_sensitive", False)))
+
+    @classmethod
+    def from_config(cls) -> "TermSettings":
+        try:
+            from orlando_toolkit.config import ConfigManager
+            return cls.from_mapping((ConfigManager().get_conversion_config() or {}).get("terminology"))
+        except Exception as exc:
+            logger.debug("Conversion config unavailable, using no term list: %s", exc)
+            return cls()
+
+    def read_list(self, path: str | Path) -> List[TermRule]:
+        """Rules of the term list *path* read with these settings (see :func:`read_term_list`)."""
+        rules = read_term_list(path, language=self.language)
+        for rule in rules:
+            rule.case_sensitive = self.case_sensitive
+        return rules
+
+    def rules(self) -> List[TermRule]:
+        """Rules of :attr:`terms` then of each readable :attr:`term_files`, banned terms once."""
+        rules = [TermRule(banned, preferred, case_sensitive=self.case_sensitive)
+                 for banned, preferred in self.terms.items() if banned.strip()]
+        for path in self.term_files:
+            try:
+                rules.extend(self.read_list(path))
+            except (OSError, ValueError) as exc:
+                logger.warning("Could not read term list %s: %s", path, exc)
+        unique: Dict[str, TermRule] = {}
+        for rule in rules:
+            unique.setdefault(rule.banned if self.case_sensitive else rule.banned.lower(), rule)
+        return list(unique.values())
+
+
+def _from_csv(text: str, delimiter: str) -> List[TermRule]:
+    rules: List[TermRule] = []
+    for number, row in enumerate(csv.reader(io.StringIO(text), delimiter=delimiter)):
+        row = [cell.strip() for cell in row]
+        if not row or not row[0] or row[0].startswith("#"):
+            continue
+        if number == 0 and row[0].lower() in _HEADER_NAMES:
+            continue
+        rules.append(TermRule(" ".join(row[0].split()), row[1] if len(row) > 1 else "",
+                              row[2] if len(row) > 2 else ""))
+    return rules
+
+
+def _children(el: Any, *names: str) -> List[Any]:
+    return [child for child in el if is_element(child) and local_name(child) in names]
+
+
+def _term_status(term_group: Any) -> str:
+    for el in term_group.iter():
+        if is_element(el) and local_name(el) == "termNote" and \
+                el.get("type") in ("administrativeStatus", "normativeAuthorization"):
+            return "".join(el.itertext()).strip()
+    return ""
+
+
+def _term_text(term_group: Any) -> str:
+    for el in term_group.iter():
+        if is_element(el) and local_name(el) == "term":
+            return " ".join("".join(el.itertext()).split())
+    return ""
+
+
+def _from_tbx(data: bytes, source: str, language: Optional[str]) -> List[TermRule]:
+    root = parse_bytes(data, source=source)
+    rules: List[TermRule] = []
+    for entry in root.iter():
+        if not is_element(entry) or local_name(entry) not in ("termEntry", "conceptEntry"):
+            continue
+        note = next((" ".join("".join(d.itertext()).split()) for d in entry.iter()
+                     if is_element(d) and local_name(d) == "descrip" and d.get("type") == "definition"), "")
+        for lang_set in _children(entry, "langSet", "langSec"):
+            lang = (lang_set.get(_XML_LANG) or "").lower()
+            if language and lang.split("-")[0] != language.lower().split("-")[0]:
+                continue
+            terms = [(_term_text(t), _term_status(t).lower().replace(" ", ""))
+                     for t in _children(lang_set, "tig", "ntig", "termSec")]
+            banned = [text for text, status in terms if text and any(s in status for s in _BANNED_STATUS)]
+            allowed = [(text, status) for text, status in terms if text and text not in banned]
+            preferred = next((text for text, status in allowed if "preferred" in status),
+                             allowed[0][0] if allowed else "")
+            rules.extend(TermRule(text, preferred, note) for text in banned)
+    return rules
+
+
+def read_term_list(path: str | Path, *, language: Optional[str] = None) -> List[TermRule]:
+    """Rules of the CSV, TSV or TBX file *path*; *language* restricts a TBX file to one language.
+
+    Raises OSError when the file cannot be read and ValueError when it is not a term list.
+    """
+    file = Path(path).expanduser()
+    suffix = file.suffix.lower()
+    if suffix in (".tbx", ".xml"):
+        data = file.read_bytes()
+        try:
+            return _from_tbx(data, file.name, language)
+        except Exception as exc:
+            raise ValueError(f"{file.name} is not a TBX term base: {exc}") from exc
+    if suffix not in (".csv", ".tsv", ".txt"):
+        raise ValueError(f"Unsupported term list {file.name}: use a .csv, .tsv or .tbx file")
+    return _from_csv(file.read_text(encoding="utf-8-sig"), "\t" if suffix == ".tsv" else ",")
+
+
+def check_terminology(context: DitaContext, rules: Iterable[TermRule]) -> List[TermViolation]:
+    """Uses of banned terms per topic, in map order, rules in list order within a topic."""
+    by_topic: Dict[str, List[TermViolation]] = {}
+    for rule in rules:
+        for hit in find_matches(context, rule.pattern, regex=True, case_sensitive=rule.case_sensitive):
+            by_topic.setdefault(hit.topic, []).append(TermViolation(hit.topic, hit.title, rule, hit.count,
+                                                                    hit.snippets))
+    violations = [v for name in topic_order(context) for v in by_topic.get(name, ())]
+    logger.info("Terminology: %d banned term use(s) in %d topic(s)", sum(v.count for v in violations),
+                len(by_topic))
+    return violations
+
+
+def _matching_case(found: str, preferred: str) -> str:
+    if len(found) > 1 and found.isupper():
+        return preferred.upper()
+    if found[:1].isupper():
+        return preferred[:1].upper() + preferred[1:]
+    return preferred
+
+
+def replace_term(context: DitaContext, rule: TermRule, topics: Optional[Iterable[str]] = None) -> Dict[str, int]:
+    """Replace the uses of ``rule.banned`` by ``rule.preferred`` in *topics* (all by default).
+
+    Returns the replacements per changed topic; raises ValueError when the
+    rule has no preferred term.
+    """
+    if not rule.preferred.strip():
+        raise ValueError(f"No preferred term is given for “{rule.banned}”")
+    preferred = " ".join(rule.preferred.split())
+    return replace_text(context, rule.pattern, lambda match: _matching_case(match.group(0), preferred), regex=True,
+                        case_sensitive=rule.case_sensitive, topics=topics)
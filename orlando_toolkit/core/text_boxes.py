@@ -0,0 +1,334 @@
+from __future__ import annotations
+
+"""Word text boxes and frames as notes or figures.
+
+Converters read the main flow of the body only, so text placed in text
+boxes (``w:txbxContent`` of a DrawingML ``wps:txbx`` or a VML
+``v:textbox``), which Word uses for callouts and title blocks, is lost.
+After the handler returns, :func:`restore_word_text_boxes` reads
+``word/document.xml`` of the source and turns every text box, and every
+run of framed paragraphs (``w:framePr``), into a ``<note>`` (or a
+``<fig>`` with ``text_boxes.element: fig``):
+
+- its paragraphs become ``<p>`` with bold, italic, underline and
+  super-/subscript runs kept and the Word paragraph style as
+  ``data-style``, so the style map applies to them like to any paragraph;
+- the element is placed after the paragraph anchoring the box (located by
+  text, :mod:`orlando_toolkit.core.placement`), or at a converter's
+  ``<ph data-text-box="N"/>`` placeholder (``N`` counts the boxes and
+  frames of the document from 1);
+- paragraphs the converter already produced (frames are part of the body
+  flow) are wrapped where they are instead of being added twice.
+
+Boxes Word marks as decorative are skipped unless ``ignore_decorative`` is
+false; a box's title (Alt Text pane) becomes the figure title. Findings are
+reported under ``text_boxes``.
+"""
+
+import logging
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.placement import block_text, find_block, normalize, text_blocks, topic_order
+from orlando_toolkit.core.utils import topic_body
+from orlando_toolkit.core.word_source import WordSource, w
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["TEXT_BOX_HINT", "WordTextBox", "read_word_text_boxes", "restore_word_text_boxes"]
+
+TEXT_BOX_HINT = "data-text-box"
+_WP = "http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing"
+_MC = "http://schemas.openxmlformats.org/markup-compatibility/2006"
+_DECORATIVE = "http://schemas.microsoft.com/office/drawing/2017/decorative"
+_ELEMENTS = ("note", "fig")
+_RUN_STYLES = (("b", "b"), ("i", "i"), ("u", "u"))
+
+Runs = List[Tuple[str, str]]        # (text, "b"/"i"/"u"/"sup"/"sub"/"")
+
+
+@dataclass
+class WordTextBox:
+    number: int
+    kind: str                       # text_box | frame
+    anchor: str                     # text of the paragraph holding or preceding the box
+    inside: bool = False            # the anchor paragraph holds the box
+    title: str = ""
+    decorative: bool = False
+    paragraphs: List[Tuple[str, Runs]] = field(default_factory=list)   # (style name, runs)
+    paragraph: int = 0              # 1-based index of its anchor paragraph in word/document.xml
+
+    @property
+    def texts(self) -> List[str]:
+        return [normalize("".join(t for t, _ in runs)) for _, runs in self.paragraphs]
+
+
+def _style_names(docx: WordSource) -> Dict[str, str]:
+    styles = docx.part("word/styles.xml")
+    names: Dict[str, str] = {}
+    for style in styles.iter(w("style")) if styles is not None else ():
+        name = style.find(w("name"))
+        names[style.get(w("styleId")) or ""] = name.get(w("val")) if name is not None else ""
+    return names
+
+
+def _in_box(el: Any) -> bool:
+    return any(a.tag == w("txbxContent") for a in el.iterancestors())
+
+
+def _anchor_text(paragraph: Any) -> str:
+    """Text of a body paragraph without the text boxes it anchors."""
+    parts = []
+    for el in paragraph.iter():
+        if el.tag not in (w("t"), w("tab"), w("br")) or _in_box(el):
+            continue
+        parts.append(" " if el.tag != w("t") else el.text or "")
+    return normalize("".join(parts))
+
+
+def _run_style(run: Any) -> str:
+    props = run.find(w("rPr"))
+    if props is None:
+        return ""
+    align = props.find(w("vertAlign"))
+    if align is not None and align.get(w("val")) in ("superscript", "subscript"):
+        return "sup" if align.get(w("val")) == "superscript" else "sub"
+    for tag, style in _RUN_STYLES:
+        flag = props.find(w(tag))
+        if flag is not None and flag.get(w("val")) not in ("0", "false", "none"):
+            return style
+    return ""
+
+
+def _paragraph(paragraph: Any, names: Dict[str, str]) -> Tuple[str, Runs]:
+    style = paragraph.find(f"{w('pPr')}/{w('pStyle')}")
+    style_id = (style.get(w("val")) if style is not None else "") or ""
+    owner = next((a for a in paragraph.iterancestors() if a.tag == w("txbxContent")), None)
+    runs: Runs = []
+    for run in paragraph.iter(w("r")):
+        if next((a for a in run.iterancestors() if a.tag == w("txbxContent")), None) is not owner:
+            continue
+        text = "".join(el.text or "" if el.tag == w("t") else " " for el in run
+                       if el.tag in (w("t"), w("tab"), w("br")))
+        if text:
+            runs.append((text, _run_style(run)))
+    return names.get(style_id) or style_id, runs
+
+
+def _box_paragraphs(content: Any, names: Dict[str, str]) -> List[Tuple[str, Runs]]:
+    paragraphs = []
+    for paragraph in content.iter(w("p")):
+        # Boxes nested in this one are read as boxes of their own
+        if next(a for a in paragraph.iterancestors() if a.tag == w("txbxContent")) is not content:
+            continue
+        style, runs = _paragraph(paragraph, names)
+        if normalize("".join(t for t, _ in runs)):
+            paragraphs.append((style, runs))
+    return paragraphs
+
+
+def _shape_props(content: Any) -> Tuple[str, bool]:
+    """``(title, decorative)`` of the drawing holding *content*."""
+    holder = next((a for a in content.iterancestors() if a.tag in (f"{{{_WP}}}inline", f"{{{_WP}}}anchor")), None)
+    doc_pr = holder.find(f"{{{_WP}}}docPr") if holder is not None else None
+    if doc_pr is None:
+        return "", False
+    decorative = any(el.get("val") in ("1", "true") for el in doc_pr.iter(f"{{{_DECORATIVE}}}decorative"))
+    return (doc_pr.get("title") or "").strip(), decorative
+
+
+def read_word_text_boxes(path: str | Path | WordSource) -> List[WordTextBox]:
+    """Text boxes and framed paragraphs of the body of the ``.docx`` *path*, in document order."""
+    docx = WordSource.of(path)
+    if docx is None or docx.body is None:
+        return []
+    names = _style_names(docx)
+    boxes: List[WordTextBox] = []
+    anchor = ""
+    frame: Optional[WordTextBox] = None
+    index = 0
+    for paragraph in docx.paragraphs:
+        if _in_box(paragraph):
+            continue
+        index += 1
+        text = _anchor_text(paragraph)
+        if paragraph.find(f"{w('pPr')}/{w('framePr')}") is not None:
+            if frame is None:
+                frame = WordTextBox(len(boxes) + 1, "frame", anchor, paragraph=index)
+                boxes.append(frame)
+            style, runs = _paragraph(paragraph, names)
+            if text:
+                frame.paragraphs.append((style, runs))
+            continue
+        frame = None
+        for content in paragraph.iter(w("txbxContent")):
+            if any(a.tag == f"{{{_MC}}}Fallback" for a in content.iterancestors()) or \
+                    next(a for a in content.iterancestors() if a.tag in (w("p"), w("txbxContent"))) is not paragraph:
+                continue
+            title, decorative = _shape_props(content)
+            box = WordTextBox(len(boxes) + 1, "text_box", text or anchor, inside=bool(text), title=title,
+                              decorative=decorative, paragraphs=_box_paragraphs(content, names), paragraph=index)
+            boxes.append(box)
+            # Boxes nested in this one follow it
+            for inner in content.iter(w("txbxContent")):
+                if inner is not content and not any(a.tag == f"{{{_MC}}}Fallback" for a in inner.iterancestors()):
+                    title, decorative = _shape_props(inner)
+                    boxes.append(WordTextBox(len(boxes) + 1, "text_box", box.anchor, box.inside, title, decorative,
+                                             _box_paragraphs(inner, names), index))
+        if text:
+            anchor = text
+    return [b for b in boxes if b.paragraphs]
+
+
+# ----------------------------------------------------------------------
+# Placement
+# ----------------------------------------------------------------------
+
+def _fill(parent: Any, runs: Runs) -> None:
+    last = None
+    for text, style in runs:
+        if style:
+            last = ET.SubElement(parent, style)
+            last.text = text
+        elif last is None:
+            parent.text = (parent.text or "") + text
+        else:
+            last.tail = (last.tail or "") + text
+    if parent.text:
+        parent.text = parent.text.lstrip()
+
+
+def _container(box: WordTextBox, element: str, note_type: str) -> Any:
+    el = ET.Element(element, outputclass=box.kind.replace("_", "-"))
+    if element == "note" and note_type:
+        el.set("type", note_type)
+    if element == "fig" and box.title:
+        ET.SubElement(el, "title").text = box.title
+    return el
+
+
+def _element(box: WordTextBox, element: str, note_type: str) -> Any:
+    el = _container(box, element, note_type)
+    for style, runs in box.paragraphs:
+        p = ET.SubElement(el, "p")
+        if style:
+            p.set("data-style", style)
+        _fill(p, runs)
+    return el
+
+
+def _after(block: Any, new: Any) -> None:
+    if block.tag in ("li", "entry", "stentry", "dd") or block.getparent() is None:
+        block.append(new)
+        return
+    parent = block.getparent()
+    new.tail = block.tail
+    block.tail = None
+    parent.insert(parent.index(block) + 1, new)
+
+
+def _converted(blocks: List[Tuple[str, Any, str]], box: WordTextBox, cursor: int) -> Optional[List[Any]]:
+    """The sibling ``<p>`` the converter made of the box's paragraphs, when it kept them all."""
+    texts = box.texts
+    hit, _ = find_block(blocks, texts[0], cursor)
+    if hit is None or blocks[hit][1].tag != "p":
+        return None
+    found = [blocks[hit][1]]
+    for text in texts[1:]:
+        following = found[-1].getnext()
+        if following is None or following.tag != "p" or block_text(following) != text:
+            return None
+        found.append(following)
+    return found
+
+
+def _wrap(paragraphs: List[Any], container: Any) -> None:
+    first = paragraphs[0]
+    parent = first.getparent()
+    parent.insert(parent.index(first), container)
+    container.tail = paragraphs[-1].tail
+    for p in paragraphs:
+        p.tail = None
+        container.append(p)
+
+
+def restore_word_text_boxes(path: str | Path | WordSource, context: Any, options: Optional[Mapping[str, Any]] = None,
+                            report: Any = None) -> int:
+    """Add the text boxes and frames of the ``.docx`` *path* to *context*; returns the number placed."""
+    options = dict(options or {})
+    if not options.get("enabled", True):
+        return 0
+    report = report if report is not None else getattr(context, "report", None)
+    try:
+        boxes = read_word_text_boxes(path)
+    except Exception as exc:
+        logger.warning("Could not read the text boxes of %s: %s", Path(path).name, exc)
+        return 0
+    if not boxes:
+        return 0
+    element = str(options.get("element") or "note")
+    if element not in _ELEMENTS:
+        logger.warning("Unknown text_boxes.element %r (expected %s)", element, ", ".join(_ELEMENTS))
+        element = "note"
+    note_type = str(options.get("note_type") or "")
+    placeholders = {el.get(TEXT_BOX_HINT): el for name in topic_order(context)
+                    for el in context.topics[name].iter("ph") if el.get(TEXT_BOX_HINT) is not None}
+    blocks = text_blocks(context)
+    cursor = 0
+    placed: List[WordTextBox] = []
+    wrapped = decorative = 0
+    unplaced: List[int] = []
+    for box in boxes:
+        placeholder = placeholders.get(str(box.number))
+        if box.decorative and options.get("ignore_decorative", True):
+            decorative += 1
+            if placeholder is not None:
+                placeholder.getparent().remove(placeholder)
+            continue
+        if placeholder is not None:
+            parent = placeholder.getparent()
+            target = parent if parent.tag == "p" and len(parent) == 1 and not normalize(
+                (parent.text or "") + (placeholder.tail or "")) else placeholder
+            new = _element(box, element, note_type)
+            new.tail = target.tail
+            holder = target.getparent()
+            holder.insert(holder.index(target), new)
+            holder.remove(target)
+            placed.append(box)
+            continue
+        existing = _converted(blocks, box, cursor)
+        if existing is not None:
+            _wrap(existing, _container(box, element, note_type))
+            placed.append(box)
+            wrapped += 1
+            continue
+        hit, after = find_block(blocks, box.anchor, cursor) if box.anchor else (None, cursor)
+        if hit is not None:
+            cursor = after
+            _after(blocks[hit][1], _element(box, element, note_type))
+        elif not box.anchor and context.topics:
+            body = topic_body(context.topics[topic_order(context)[0]])
+            if body is None:
+                unplaced.append(box.number)
+                continue
+            body.insert(0, _element(box, element, note_type))
+        else:
+            unplaced.append(box.number)
+            continue
+        placed.append(box)
+
+    if report is not None:
+        kinds = [b.kind for b in placed]
+        if placed:
+            report.info("text_boxes", f"{kinds.count('text_box')} text box(es) and {kinds.count('frame')} frame(s) "
+                                      f"added as <{element}>, {wrapped} of them already converted",
+                        text_boxes=kinds.count("text_box"), frames=kinds.count("frame"), wrapped=wrapped,
+                        decorative=decorative)
+        if unplaced:
+            report.warning("text_boxes", f"{len(unplaced)} text box(es) or frame(s) could not be placed: the "
+                                         "paragraph before them was not found in the converted topics",
+                           numbers=unplaced, paragraphs=[b.paragraph for b in boxes if b.number in unplaced])
+    return len(placed)
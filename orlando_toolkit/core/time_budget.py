@@ -0,0 +1,56 @@
+from __future__ import annotations
+
+"""Per-job time budgets.
+
+Unlike cancellation, an exhausted :class:`TimeBudget` does not abort work.
+Stages check :attr:`TimeBudget.expired` at safe points, skip the remaining
+optional work, and record what was skipped in the conversion report so the
+job finishes cleanly with partial, clearly marked output. Package writing is
+never interrupted by the budget.
+"""
+
+import time
+from typing import Callable, Optional
+
+__all__ = ["TimeBudget", "is_expired"]
+
+
+class TimeBudget:
+    """Deadline measured from construction; ``seconds <= 0`` means unlimited."""
+
+    def __init__(self, seconds: Optional[float] = None, *,
+                 clock: Callable[[], float] = time.monotonic) -> None:
+        self._clock = clock
+        self._seconds = float(seconds) if seconds and seconds > 0 else None
+        self._started = clock()
+
+    @property
+    def unlimited(self) -> bool:
+        return self._seconds is None
+
+    @property
+    def seconds(self) -> Optional[float]:
+        return self._seconds
+
+    @property
+    def elapsed(self) -> float:
+        return self._clock() - self._started
+
+    @property
+    def remaining(self) -> Optional[float]:
+        if self._seconds is None:
+            return None
+        return max(0.0, self._seconds - self.elapsed)
+
+    @property
+    def expired(self) -> bool:
+        return self._seconds is not None and self.elapsed >= self._seconds
+
+    def describe(self) -> str:
+        """Reason string used when marking a report partial."""
+        return f"time budget of {self._seconds:g}s exceeded" if self._seconds else "no time budget"
+
+
+def is_expired(budget: Optional[TimeBudget]) -> bool:
+    """Checkpoint helper accepting an optional budget."""
+    return budget is not None and budget.expired
@@ -0,0 +1,228 @@
+from __future__ import annotations
+
+"""Cross-check a Word document's table of contents against the generated map.
+
+Word builds its TOC field from the heading styles, so a heading typed in a
+body style with manual bold (or a body paragraph styled as a heading) shows
+up as a difference between the TOC and the map the converter produced:
+
+- a TOC entry without a matching topic: the heading was probably not styled
+  as a heading and was left inside another topic;
+- a topic within the TOC's levels without a TOC entry: a paragraph styled as
+  a heading, or a TOC that was not updated;
+- an entry found at different levels in the TOC and the map.
+
+Titles are compared case- and whitespace-insensitively, without leading
+section numbers. Differences are reported as warnings under ``toc``; the
+``toc_check`` section of ``conversion.yml`` controls the check. Documents
+without a TOC field are skipped.
+"""
+
+import logging
+import re
+import unicodedata
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.word_source import WordSource, w
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["TocComparison", "TocEntry", "check_toc", "compare_toc", "map_outline", "read_word_toc"]
+
+_LEVELS_SWITCH = re.compile(r'\\o\s+"(\d+)-(\d+)"')
+_SECTION_NUMBER = re.compile(r"^(\d+|[A-Z])(\.\d+)*\.?\s+")
+_PAGE_NUMBER = re.compile(r"^\s*([0-9]+|[ivxlcdm]+)\s*$", re.I)
+
+
+@dataclass(frozen=True)
+class TocEntry:
+    level: int
+    title: str
+    anchor: Optional[str] = None
+
+
+@dataclass
+class TocComparison:
+    toc_entries: int = 0
+    levels: Tuple[int, int] = (1, 9)
+    missing_in_map: List[TocEntry] = field(default_factory=list)
+    missing_in_toc: List[TocEntry] = field(default_factory=list)
+    level_mismatches: List[Tuple[TocEntry, int]] = field(default_factory=list)
+
+    @property
+    def consistent(self) -> bool:
+        return not (self.missing_in_map or self.missing_in_toc or self.level_mismatches)
+
+
+def _normalize(title: str) -> str:
+    text = unicodedata.normalize("NFKC", title or "")
+    text = " ".join(text.split())
+    return _SECTION_NUMBER.sub("", text).casefold()
+
+
+def _paragraph_text(paragraph: ET._Element) -> str:
+    pieces: List[str] = []
+    for node in paragraph.iter():
+        if node.tag in (w("t"), w("tab")):
+            pieces.append("\t" if node.tag == w("tab") else (node.text or ""))
+    text = "".join(pieces)
+    head, sep, tail = text.rpartition("\t")
+    if sep and _PAGE_NUMBER.match(tail):
+        text = head
+    return " ".join(text.replace("\t", " ").split())
+
+
+def _paragraph_level(paragraph: ET._Element, default: int) -> int:
+    style = paragraph.find(f"{w('pPr')}/{w('pStyle')}")
+    match = re.search(r"(\d)$", style.get(w("val")) or "") if style is not None else None
+    return int(match.group(1)) if match else default
+
+
+def read_word_toc(path: str | Path | WordSource) -> Tuple[List[TocEntry], Tuple[int, int]]:
+    """Return the entries of the first TOC field in a ``.docx`` and the levels it covers.
+
+    Returns ``([], (1, 9))`` when the document has no TOC field.
+    """
+    docx = WordSource.of(path)
+    if docx is None or docx.document is None:
+        return [], (1, 9)
+
+    entries: List[TocEntry] = []
+    levels = (1, 9)
+    depth = 0              # fields opened inside the TOC (PAGEREF, hyperlinks)
+    in_toc = False
+    instruction: Optional[str] = None   # instruction text of a field being opened
+    for paragraph in docx.paragraphs:
+        toc_paragraph = in_toc
+        for node in paragraph.iter():
+            if node.tag not in (w("fldChar"), w("instrText")):
+                continue
+            if node.tag == w("instrText"):
+                if instruction is not None:
+                    instruction += node.text or ""
+                continue
+            kind = node.get(w("fldCharType"))
+            if in_toc:
+                depth += {"begin": 1, "end": -1}.get(kind, 0)
+                if depth < 0:
+                    in_toc = False
+            elif kind == "begin":
+                instruction = ""
+            elif kind == "separate" and instruction is not None:
+                if instruction.split()[:1] == ["TOC"]:
+                    in_toc, depth, toc_paragraph = True, 0, True
+                    match = _LEVELS_SWITCH.search(instruction)
+                    if match:
+                        levels = (int(match.group(1)), int(match.group(2)))
+                instruction = None
+        if toc_paragraph:
+            title = _paragraph_text(paragraph)
+            if title:
+                link = paragraph.find(f".//{w('hyperlink')}")
+                entries.append(TocEntry(level=_paragraph_level(paragraph, levels[0]), title=title,
+                                        anchor=link.get(w("anchor")) if link is not None else None))
+        if entries and not in_toc:
+            break
+    return entries, levels
+
+
+def _topicref_title(context: Any, ref: ET._Element) -> str:
+    navtitle = ref.find("topicmeta/navtitle")
+    if navtitle is not None and "".join(navtitle.itertext()).strip():
+        return " ".join("".join(navtitle.itertext()).split())
+    href = ref.get("href")
+    if href:
+        topic = (getattr(context, "topics", {}) or {}).get(Path(href.split("#")[0]).name)
+        if topic is not None and topic.find("title") is not None:
+            return " ".join("".join(topic.find("title").itertext()).split())
+    return ref.get("navtitle") or ""
+
+
+def map_outline(context: Any) -> List[TocEntry]:
+    """Topic titles of the generated map, in document order, with their depth."""
+    root = getattr(context, "ditamap_root", None)
+    outline: List[TocEntry] = []
+    if root is None:
+        return outline
+
+    def _walk(parent: ET._Element, depth: int) -> None:
+        for child in parent:
+            if child.tag in ("topicref", "topichead"):
+                title = _topicref_title(context, child)
+                if title:
+                    outline.append(TocEntry(level=depth, title=title, anchor=child.get("href")))
+                _walk(child, depth + 1)
+
+    _walk(root, 1)
+    return outline
+
+
+def compare_toc(toc: List[TocEntry], outline: List[TocEntry], levels: Tuple[int, int] = (1, 9)) -> TocComparison:
+    """Differences between TOC entries and the map outline within *levels*."""
+    result = TocComparison(toc_entries=len(toc), levels=levels)
+    low, high = levels
+    remaining: Dict[str, List[TocEntry]] = {}
+    for item in outline:
+        remaining.setdefault(_normalize(item.title), []).append(item)
+    for entry in toc:
+        candidates = remaining.get(_normalize(entry.title))
+        if not candidates:
+            result.missing_in_map.append(entry)
+            continue
+        same_level = next((c for c in candidates if c.level == entry.level), None)
+        matched = same_level or candidates[0]
+        candidates.remove(matched)
+        if matched.level != entry.level:
+            result.level_mismatches.append((entry, matched.level))
+    for items in remaining.values():
+        result.missing_in_toc.extend(i for i in items if low <= i.level <= high)
+    order = {id(item): n for n, item in enumerate(outline)}
+    result.missing_in_toc.sort(key=lambda i: order.get(id(i), 0))
+    return result
+
+
+def check_toc(path: str | Path | WordSource, context: Any, options: Optional[Mapping[str, Any]] = None,
+              report: Any = None) -> Optional[TocComparison]:
+    """Compare the TOC of *path* with *context*'s map and record the differences.
+
+    Returns ``None`` when the check is disabled or the document has no TOC.
+    """
+    options = dict(options or {})
+    if not options.get("enabled", True):
+        return None
+    try:
+        toc, levels = read_word_toc(path)
+    except Exception as exc:
+        logger.warning("Could not read the table of contents of %s: %s", Path(path).name, exc)
+        return None
+    if not toc:
+        logger.debug("No TOC field in %s; cross-check skipped", Path(path).name)
+        return None
+    result = compare_toc(toc, map_outline(context), levels)
+    report = report if report is not None else getattr(context, "report", None)
+    if report is None:
+        return result
+
+    limit = max(0, int(options.get("max_listed", 20)))
+    findings: List[Tuple[str, Dict[str, Any]]] = []
+    for entry in result.missing_in_map:
+        findings.append((f"TOC entry '{entry.title}' (level {entry.level}) has no topic; "
+                         "its heading may not use a heading style", {"toc_level": entry.level}))
+    for item in result.missing_in_toc:
+        findings.append((f"Topic '{item.title}' (level {item.level}) is not in the document's TOC; "
+                         "check its style or update the TOC", {"map_level": item.level}))
+    if options.get("compare_levels", True):
+        for entry, map_level in result.level_mismatches:
+            findings.append((f"'{entry.title}' is level {entry.level} in the TOC but level {map_level} in the map",
+                             {"toc_level": entry.level, "map_level": map_level}))
+    for message, detail in findings[:limit]:
+        report.warning("toc", message, **detail)
+    if len(findings) > limit:
+        report.warning("toc", f"{len(findings) - limit} more TOC difference(s) not listed")
+    report.info("toc", f"TOC cross-check: {result.toc_entries} entries (levels {levels[0]}-{levels[1]}), "
+                       f"{len(findings)} difference(s)", differences=len(findings))
+    return result
@@ -0,0 +1,126 @@
+from __future__ import annotations
+
+"""XML source of a topic for the raw XML editor.
+
+- :func:`topic_source` serialises a topic as indented XML text;
+- :func:`parse_topic_source` parses edited text with the secure parser and
+  raises :class:`TopicSourceError` with the offending line when it is not
+  well-formed;
+- :func:`pretty_print` re-indents edited text, :func:`indent_element` an
+  element in place (also used by the package tree);
+- :func:`highlight_spans` tokenises text for syntax highlighting (tag names,
+  attribute names and values, comments, entities, processing instructions).
+
+Indentation only touches element-only content: elements holding text or
+inline markup (a ``<p>`` with ``<b>`` runs) and ``codeblock``, ``pre`` and
+the other whitespace-preserving elements are kept as they are, so
+pretty-printing never changes the rendered text.
+
+Saving goes through
+:meth:`~orlando_toolkit.core.services.structure_editing_service.StructureEditingService.replace_topic_source`,
+which validates the topic before replacing it.
+"""
+
+import logging
+import re
+from typing import List, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.xml_security import XmlSecurityError, parse_bytes
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["TopicSourceError", "highlight_spans", "indent_element", "parse_topic_source", "pretty_print",
+           "topic_source"]
+
+_INDENT = "  "
+_PRESERVE = {"codeblock", "pre", "lines", "msgblock", "screen", "codeph"}
+_INLINE = {"b", "i", "u", "sup", "sub", "tt", "ph", "xref", "keyword", "term", "image", "fn", "q", "cite",
+           "uicontrol", "menucascade", "wintitle", "filepath", "varname", "userinput", "systemoutput",
+           "indexterm", "line-through", "overline", "tm", "data", "text", "msgph", "option", "cmdname"}
+_TOKENS = re.compile(
+    r"(?P<comment><!--.*?(?:-->|\Z))"
+    r"|(?P<pi><\?.*?(?:\?>|\Z))"
+    r"|(?P<cdata><!\[CDATA\[.*?(?:\]\]>|\Z))"
+    r"|(?P<tag></?[\w:.-]+)(?P<attrs>[^<>]*)(?P<close>/?>)?"
+    r"|(?P<entity>&[#\w]+;)",
+    re.DOTALL,
+)
+_ATTR = re.compile(r"(?P<name>[\w:.-]+)\s*=\s*(?P<value>\"[^\"]*\"|'[^']*')")
+
+
+class TopicSourceError(ValueError):
+    """Edited topic text that cannot be parsed; *line* is 1-based when known."""
+
+    def __init__(self, message: str, line: Optional[int] = None) -> None:
+        super().__init__(message)
+        self.line = line
+
+
+def _blank(text: Optional[str]) -> bool:
+    return text is None or not text.strip()
+
+
+def indent_element(element: ET.Element, level: int = 0) -> None:
+    """Indent the element-only content under *element* in place; text and preformatted content are kept."""
+    children = list(element)
+    if not children or not isinstance(element.tag, str) or element.tag in _PRESERVE:
+        return
+    if not _blank(element.text) or any(not _blank(child.tail) or child.tag in _INLINE for child in children):
+        return  # mixed content
+    inner = "\n" + _INDENT * (level + 1)
+    element.text = inner
+    for child in children:
+        child.tail = inner
+        indent_element(child, level + 1)
+    children[-1].tail = "\n" + _INDENT * level
+
+
+def topic_source(topic: ET.Element) -> str:
+    """Indented XML text of *topic* (the element is not modified)."""
+    clone = ET.fromstring(ET.tostring(topic))
+    indent_element(clone)
+    return ET.tostring(clone, encoding="unicode")
+
+
+def parse_topic_source(text: str, *, source: str = "<topic editor>") -> ET.Element:
+    """Parse edited topic text; raises :class:`TopicSourceError` when it is refused or malformed."""
+    try:
+        return parse_bytes(text.strip(), source=source)
+    except XmlSecurityError as exc:
+        raise TopicSourceError(str(exc)) from exc
+    except ET.XMLSyntaxError as exc:
+        position = getattr(exc, "position", None) or (getattr(exc, "lineno", None),)
+        raise TopicSourceError(f"XML is not well-formed: {exc}", position[0]) from exc
+
+
+def pretty_print(text: str) -> str:
+    """Re-indented copy of *text*; raises :class:`TopicSourceError` when it does not parse."""
+    return topic_source(parse_topic_source(text))
+
+
+def highlight_spans(text: str) -> List[Tuple[str, int, int]]:
+    """``(kind, start, end)`` character spans of *text* to highlight.
+
+    Kinds are ``tag``, ``attribute``, ``value``, ``comment``, ``pi`` (also
+    CDATA sections) and ``entity``. Unterminated comments run to the end of
+    the text, so highlighting stays sensible while typing.
+    """
+    spans: List[Tuple[str, int, int]] = []
+    for match in _TOKENS.finditer(text):
+        if match.group("comment") is not None:
+            spans.append(("comment", match.start(), match.end()))
+        elif match.group("pi") is not None or match.group("cdata") is not None:
+            spans.append(("pi", match.start(), match.end()))
+        elif match.group("entity") is not None:
+            spans.append(("entity", match.start(), match.end()))
+        else:
+            spans.append(("tag", match.start("tag"), match.end("tag")))
+            offset = match.start("attrs")
+            for attr in _ATTR.finditer(match.group("attrs")):
+                spans.append(("attribute", offset + attr.start("name"), offset + attr.end("name")))
+                spans.append(("value", offset + attr.start("value"), offset + attr.end("value")))
+            if match.group("close"):
+                spans.append(("tag", match.start("close"), match.end("close")))
+    return spans
@@ -0,0 +1,271 @@
+from __future__ import annotations
+
+"""Prolog metadata from topic templates.
+
+Content management systems often require every topic to carry an author, a
+copyright and ``othermeta`` entries. The ``topic_templates`` section of
+``conversion.yml`` (or of a conversion profile) holds a ``<prolog>``
+fragment per topic type (the root element: ``concept``, ``task``,
+``reference``, …; ``default`` for the others), inline or in a file, with
+``{placeholder}`` fields::
+
+    <prolog>
+      <author>{author}</author>
+      <copyright><copyryear year="{year}"/><copyrholder>{copyright_holder}</copyrholder></copyright>
+      <metadata><othermeta name="docNumber" content="{manual_code}"/></metadata>
+    </prolog>
+
+:func:`apply_topic_templates` runs while the package is prepared, so the
+values are those of the Metadata tab at that time. Placeholders are the
+metadata fields (``manual_title``, ``manual_code``, ``revision_date``, the
+keys a profile's ``metadata`` sets, …) and:
+
+- ``{author}`` - ``author``, else the Metadata tab's author;
+- ``{copyright_holder}`` - ``copyright_holder``, else the publisher;
+- ``{date}`` - today (the fixed date of reproducible packages), ``{year}``
+  the year of the revision date, else of today;
+- ``{topic_title}``, ``{topic_id}``, ``{topic_type}``, ``{topic_file}``.
+
+An element whose placeholders are all empty is left out. The template's
+elements are added where DITA expects them; an element the topic already
+has (``othermeta`` and ``data`` by name) is kept unless ``overwrite``.
+Placeholders that had no value are reported under ``topic_templates``.
+"""
+
+import logging
+import re
+from copy import deepcopy
+from dataclasses import dataclass, field
+from datetime import datetime, timezone
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional, Set, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.xml_security import parse_bytes
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["TopicTemplates", "apply_topic_templates", "template_fields"]
+
+DEFAULT_TEMPLATE = "default"
+_PLACEHOLDER = re.compile(r"\{([A-Za-z_][\w]*)\}")
+_BEFORE_PROLOG = ("title", "titlealts", "shortdesc", "abstract")
+_PROLOG_ORDER = ("author", "source", "publisher", "copyright", "critdates", "permissions", "metadata",
+                 "resourceid", "data", "data-about", "foreign", "unknown")
+_METADATA_ORDER = ("audience", "category", "keywords", "prodinfo", "othermeta", "data", "data-about", "foreign",
+                   "unknown")
+_NAMED = ("othermeta", "data", "resourceid")
+# Elements worthless once empty
+_WRAPPERS = ("author", "source", "publisher", "copyright", "critdates", "metadata", "keywords", "prodinfo", "category")
+
+
+def _template_path(value: str) -> Path:
+    path = Path(value).expanduser()
+    if path.is_absolute():
+        return path
+    from orlando_toolkit.config.manager import _get_user_config_dir
+
+    return _get_user_config_dir() / path
+
+
+def _load(name: str, value: Any) -> Optional[Any]:
+    """The ``<prolog>`` of a template: inline XML, or the path of an XML file."""
+    text = str(value or "").strip()
+    if not text:
+        return None
+    try:
+        data = text.encode("utf-8") if text.startswith("<") else _template_path(text).read_bytes()
+        root = parse_bytes(data, source=f"topic_templates.{name}")
+    except Exception as exc:
+        logger.warning("Ignoring topic template %r: %s", name, exc)
+        return None
+    if root.tag != "prolog":
+        logger.warning("Ignoring topic template %r: the root element must be <prolog>, not <%s>", name, root.tag)
+        return None
+    return root
+
+
+@dataclass
+class TopicTemplates:
+    enabled: bool = False
+    overwrite: bool = False
+    templates: Dict[str, Any] = field(default_factory=dict)   # topic type -> <prolog>
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "TopicTemplates":
+        data = dict(data or {})
+        templates: Dict[str, Any] = {}
+        raw = data.get("templates") or {}
+        if not isinstance(raw, Mapping):
+            logger.warning("Ignoring topic_templates.templates: expected a mapping of topic types")
+            raw = {}
+        for name, value in raw.items():
+            prolog = _load(str(name), value)
+            if prolog is not None:
+                templates[str(name)] = prolog
+        return cls(enabled=bool(data.get("enabled", False)), overwrite=bool(data.get("overwrite", False)),
+                   templates=templates)
+
+    @classmethod
+    def resolve(cls, metadata: Optional[Mapping[str, Any]]) -> "TopicTemplates":
+        """Settings of ``conversion.yml`` merged with the conversion options of *metadata*."""
+        from orlando_toolkit.core.processing import resolve_conversion_options
+        return cls.from_mapping(resolve_conversion_options(metadata).get("topic_templates"))
+
+    def template_for(self, topic_type: str) -> Optional[Any]:
+        return self.templates.get(topic_type, self.templates.get(DEFAULT_TEMPLATE))
+
+
+def _today(metadata: Mapping[str, Any]) -> str:
+    from orlando_toolkit.core.reproducible import package_date, reproducible_options
+
+    options = reproducible_options(metadata)
+    return package_date(options) if options.get("enabled") else datetime.now(timezone.utc).strftime("%Y-%m-%d")
+
+
+def template_fields(metadata: Mapping[str, Any], topic: Any = None, topic_file: str = "") -> Dict[str, str]:
+    """Placeholder values of the document *metadata* and, when given, of one *topic*."""
+    fields = {str(k): str(v).strip() for k, v in metadata.items()
+              if isinstance(v, (str, int, float)) and not isinstance(v, bool)}
+    fields["author"] = fields.get("author") or fields.get("book_author", "")
+    fields["copyright_holder"] = fields.get("copyright_holder") or fields.get("book_publisher", "")
+    fields["date"] = _today(metadata)
+    year = re.search(r"\b(\d{4})\b", fields.get("revision_date", ""))
+    fields["year"] = year.group(1) if year else fields["date"][:4]
+    if topic is not None:
+        fields.update(_topic_fields(topic, topic_file))
+    return fields
+
+
+def _topic_fields(topic: Any, topic_file: str) -> Dict[str, str]:
+    title = topic.find("title")
+    return {"topic_title": " ".join("".join(title.itertext()).split()) if title is not None else "",
+            "topic_id": topic.get("id") or "", "topic_type": topic.tag, "topic_file": topic_file}
+
+
+def _fill(prolog: Any, fields: Mapping[str, str], missing: Set[str]) -> Any:
+    """Copy of *prolog* with the placeholders filled; elements whose placeholders are all empty are dropped."""
+    filled = deepcopy(prolog)
+    for el in list(filled.iter()):
+        if not isinstance(el.tag, str):
+            continue
+        names: List[str] = []
+
+        def _sub(text: str) -> str:
+            found = _PLACEHOLDER.findall(text)
+            names.extend(found)
+            return _PLACEHOLDER.sub(lambda m: fields.get(m.group(1), ""), text)
+
+        for attr, value in list(el.attrib.items()):
+            el.set(attr, _sub(value))
+        if el.text:
+            el.text = _sub(el.text)
+        empty = [n for n in names if not fields.get(n)]
+        missing.update(empty)
+        if names and len(empty) == len(names) and el.getparent() is not None:
+            el.getparent().remove(el)
+    for el in reversed(list(filled.iter())):
+        # Wrappers left without their content: <critdates/>, a <copyright> without holder
+        parent = el.getparent()
+        if parent is None or not isinstance(el.tag, str):
+            continue
+        hollow = el.tag in _WRAPPERS and not len(el) and not (el.text or "").strip()
+        if hollow or el.tag == "copyright" and el.find("copyrholder") is None:
+            parent.remove(el)
+    return filled
+
+
+def _key(el: Any) -> Tuple[str, Optional[str]]:
+    return el.tag, (el.get("name") or el.get("appname")) if el.tag in _NAMED else None
+
+
+def _insert(parent: Any, el: Any, order: Tuple[str, ...]) -> None:
+    def _rank(tag: Any) -> int:
+        return order.index(tag) if tag in order else len(order)
+
+    rank = _rank(el.tag)
+    for index, child in enumerate(parent):
+        if isinstance(child.tag, str) and _rank(child.tag) > rank:
+            parent.insert(index, el)
+            return
+    parent.append(el)
+
+
+def _merge(target: Any, source: Any, order: Tuple[str, ...], overwrite: bool) -> int:
+    existing: Dict[Tuple[str, Optional[str]], List[Any]] = {}
+    for child in target:
+        if isinstance(child.tag, str):
+            existing.setdefault(_key(child), []).append(child)
+    changed = 0
+    for child in source:
+        if not isinstance(child.tag, str):
+            continue
+        if child.tag == "metadata" and target.tag in ("prolog", "topicmeta"):
+            metadata = target.find("metadata")
+            if metadata is None:
+                metadata = ET.Element("metadata")
+                _insert(target, metadata, order)
+            changed += _merge(metadata, child, _METADATA_ORDER, overwrite)
+            continue
+        key = _key(child)
+        if key in existing:
+            if not overwrite:
+                continue
+            for old in existing.pop(key):
+                target.remove(old)
+        new = deepcopy(child)
+        new.tail = None
+        _insert(target, new, order)
+        changed += 1
+    return changed
+
+
+def _topic_prolog(topic: Any) -> Any:
+    prolog = topic.find("prolog")
+    if prolog is None:
+        position = 0
+        for index, child in enumerate(topic):
+            if child.tag in _BEFORE_PROLOG:
+                position = index + 1
+        prolog = ET.Element("prolog")
+        topic.insert(position, prolog)
+    return prolog
+
+
+def apply_topic_templates(context: DitaContext, settings: Optional[TopicTemplates] = None) -> int:
+    """Add the prolog of the topic templates to every topic of *context*; returns the topics changed."""
+    settings = settings or TopicTemplates.resolve(context.metadata)
+    if not settings.enabled or not settings.templates:
+        return 0
+    document = template_fields(context.metadata)
+    missing: Set[str] = set()
+    changed: Dict[str, int] = {}
+    for name, topic in context.topics.items():
+        template = settings.template_for(topic.tag)
+        if template is None:
+            continue
+        fields = {**document, **_topic_fields(topic, name)}
+        filled = _fill(template, fields, missing)
+        prolog = _topic_prolog(topic)
+        metadata = prolog.find("metadata")
+        if _merge(prolog, filled, _PROLOG_ORDER, settings.overwrite):
+            changed[topic.tag] = changed.get(topic.tag, 0) + 1
+        if metadata is None and prolog.find("metadata") is not None and not len(prolog.find("metadata")):
+            prolog.remove(prolog.find("metadata"))
+        if not len(prolog):
+            topic.remove(prolog)
+
+    report = getattr(context, "report", None)
+    if report is not None:
+        if changed:
+            report.info("topic_templates", f"Prolog template applied to {sum(changed.values())} topic(s)",
+                        topic_types=dict(sorted(changed.items())))
+        if missing:
+            names = sorted(missing)
+            report.warning("topic_templates", "No value for the template placeholder(s) "
+                                              + ", ".join("{" + n + "}" for n in names), placeholders=names,
+                           hint="Fill them in the Metadata tab or in the profile's metadata")
+    logger.info("Topic templates: prolog added to %d topic(s)", sum(changed.values()))
+    return sum(changed.values())
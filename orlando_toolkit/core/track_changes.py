@@ -0,0 +1,303 @@
+from __future__ import annotations
+
+"""Resolve Word tracked changes before a plugin converts the document.
+
+A ``.docx`` with unaccepted revisions keeps both the inserted runs
+(``w:ins``) and the deleted ones (``w:del``/``w:delText``); converters
+render them inconsistently, so deleted text may show up next to its
+replacement. Before the plugin handler runs, :func:`resolve_tracked_changes`
+resolves every revision of the source (the shared
+:class:`~orlando_toolkit.core.word_source.WordSource`) with the
+``track_changes.mode`` of ``conversion.yml``:
+
+- ``accept`` – insertions and moves are kept, deletions removed, tracked
+  formatting and paragraph-mark changes applied (Word's "Accept All");
+- ``reject`` – insertions removed, deleted text restored, the previous
+  formatting put back (Word's "Reject All");
+- ``rev`` – changes are accepted and, after conversion,
+  :func:`record_tracked_changes` sets ``@rev`` on the blocks whose Word
+  paragraph had tracked insertions or deletions. The value is
+  ``track_changes.rev``, else the document's ``revision_number``, else the
+  date of the paragraph's latest change. A document produced by Word's
+  *Compare* is an ordinary tracked-changes document and is handled alike.
+
+In ``rev`` mode the revisions are also summarized per author and day
+(:func:`change_history`) into ``metadata["change_history"]``; a bookmap
+package writes it as ``bookchangehistory`` in its ``bookmeta``
+(``track_changes.history: false`` leaves it out).
+
+Body, headers, footers, notes and comments are resolved alike. The report
+gets one ``track_changes`` entry with the counts.
+"""
+
+import logging
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional, Tuple
+
+from orlando_toolkit.core.placement import find_block, innermost_block, normalize, text_blocks
+from orlando_toolkit.core.word_source import DOCUMENT, WordSource, w
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["TRACK_CHANGES_MODES", "TrackedChanges", "change_history", "record_tracked_changes",
+           "resolve_tracked_changes"]
+
+TRACK_CHANGES_MODES = ("accept", "reject", "rev")
+
+_PARTS = ("word/document.xml", "word/footnotes.xml", "word/endnotes.xml", "word/comments.xml")
+_PART_PREFIXES = ("word/header", "word/footer")
+_INSERTED = ("ins", "moveTo")
+_DELETED = ("del", "moveFrom")
+_RANGE_MARKS = ("moveFromRangeStart", "moveFromRangeEnd", "moveToRangeStart", "moveToRangeEnd",
+                "customXmlInsRangeStart", "customXmlInsRangeEnd", "customXmlDelRangeStart",
+                "customXmlDelRangeEnd")
+_PROPERTY_CHANGES = ("rPrChange", "pPrChange", "sectPrChange", "tblPrChange", "tblGridChange",
+                     "trPrChange", "tcPrChange", "tblPrExChange", "numberingChange")
+_KEPT_ON_REJECT = ("rPr", "sectPr")         # pPr children a pPrChange does not describe
+
+
+def _local(el: Any) -> str:
+    return el.tag.split("}")[-1] if isinstance(el.tag, str) else ""
+
+
+@dataclass
+class TrackedChanges:
+    """What :func:`resolve_tracked_changes` did; ``path`` is the file to convert."""
+    path: Path
+    mode: str = "accept"
+    insertions: int = 0
+    deletions: int = 0
+    formatting: int = 0
+    paragraphs: List[Tuple[str, str]] = field(default_factory=list)   # (resolved text, latest change date)
+    revisions: List[Tuple[str, str, bool]] = field(default_factory=list)  # (author, date, inserted)
+
+    def __bool__(self) -> bool:
+        return bool(self.insertions or self.deletions or self.formatting)
+
+
+def _is_part(name: str) -> bool:
+    return name in _PARTS or (name.startswith(_PART_PREFIXES) and name.endswith(".xml"))
+
+
+def _unwrap(el: Any) -> None:
+    parent = el.getparent()
+    index = parent.index(el)
+    for offset, child in enumerate(list(el)):
+        parent.insert(index + offset, child)
+    parent.remove(el)
+
+
+def _drop(el: Any) -> None:
+    el.getparent().remove(el)
+
+
+def _merge_with_next(paragraph: Any) -> None:
+    """Join *paragraph* with the following one (its paragraph mark is gone)."""
+    following = paragraph.getnext()
+    if following is None or following.tag != w("p"):
+        return
+    for child in list(following):
+        if child.tag != w("pPr"):
+            paragraph.append(child)
+    _drop(following)
+
+
+def _restore_properties(change: Any) -> None:
+    """Reject a ``*PrChange``: its parent gets the properties recorded in it back."""
+    parent = change.getparent()
+    previous = next((c for c in change if isinstance(c.tag, str)), None)
+    kept = _KEPT_ON_REJECT if _local(parent) == "pPr" else ()
+    for child in list(parent):
+        if child is not change and _local(child) not in kept:
+            parent.remove(child)
+    if previous is not None:
+        for index, child in enumerate(list(previous)):
+            parent.insert(index, child)
+    parent.remove(change)
+
+
+def _paragraph_text(paragraph: Any) -> str:
+    parts = []
+    for el in paragraph.iter():
+        name = _local(el)
+        if name == "t":
+            parts.append(el.text or "")
+        elif name in ("tab", "br", "cr"):
+            parts.append(" ")
+    return normalize("".join(parts))
+
+
+def _changed_paragraphs(root: Any) -> List[Tuple[Any, str]]:
+    """Paragraphs with tracked insertions or deletions and the date of their latest change."""
+    changed = []
+    for paragraph in root.iter(w("p")):
+        if any(p.tag == w("p") for p in paragraph.iterancestors()):
+            continue
+        dates = [el.get(w("date")) or "" for el in paragraph.iter()
+                 if _local(el) in _INSERTED + _DELETED]
+        if dates:
+            changed.append((paragraph, max(dates)[:10]))
+    return changed
+
+
+def _revisions(root: Any) -> List[Tuple[str, str, bool]]:
+    """Author, day and kind of each tracked insertion or deletion (paragraph and row marks left out)."""
+    found = []
+    for el in root.iter():
+        name = _local(el)
+        if name in _INSERTED + _DELETED and el.getparent() is not None \
+                and _local(el.getparent()) not in ("rPr", "trPr"):
+            found.append((" ".join((el.get(w("author")) or "").split()), (el.get(w("date")) or "")[:10],
+                          name in _INSERTED))
+    return found
+
+
+def _resolve(root: Any, accept: bool, result: TrackedChanges) -> None:
+    keep, remove = (_INSERTED, _DELETED) if accept else (_DELETED, _INSERTED)
+    for el in [e for e in root.iter() if _local(e) in _INSERTED + _DELETED]:
+        parent = el.getparent()
+        if parent is None:
+            continue
+        inserted = _local(el) in _INSERTED
+        owner = _local(parent)
+        if owner == "rPr" and parent.getparent() is not None and _local(parent.getparent()) == "pPr":
+            # A tracked paragraph mark: removing it joins the paragraph with the next one
+            paragraph = parent.getparent().getparent()
+            _drop(el)
+            if _local(el) in remove and paragraph is not None:
+                _merge_with_next(paragraph)
+        elif owner == "trPr":
+            row = parent.getparent()
+            _drop(el)
+            if _local(el) in remove and row is not None and row.getparent() is not None:
+                _drop(row)
+        elif owner == "rPr":
+            _drop(el)             # run-level mark of a changed numbering/mark run
+        elif _local(el) in keep:
+            _unwrap(el)
+        else:
+            _drop(el)
+        if owner not in ("rPr", "trPr"):
+            if inserted:
+                result.insertions += 1
+            else:
+                result.deletions += 1
+    if not accept:
+        for name, restored in (("delText", "t"), ("delInstrText", "instrText")):
+            for el in root.iter(w(name)):
+                el.tag = w(restored)
+    for el in [e for e in root.iter() if _local(e) in _RANGE_MARKS + ("cellIns", "cellDel", "cellMerge")]:
+        _drop(el)
+    for el in [e for e in root.iter() if _local(e) in _PROPERTY_CHANGES]:
+        if el.getparent() is None:
+            continue
+        result.formatting += 1
+        if accept:
+            _drop(el)
+        else:
+            _restore_properties(el)
+
+
+def resolve_tracked_changes(path: str | Path | WordSource, options: Optional[Mapping[str, Any]],
+                            out_dir: Optional[str | Path] = None) -> TrackedChanges:
+    """Resolve the tracked changes of the ``.docx`` *path*.
+
+    With *out_dir* a copy is written there and becomes ``result.path``;
+    without it the parts of a shared :class:`WordSource` are edited for the
+    caller to save. ``result.path`` is the source itself when the pass is
+    disabled, the file is not a Word package or it has no tracked changes.
+    """
+    options = dict(options or {})
+    mode = str(options.get("mode") or "accept")
+    docx = WordSource.of(path)
+    result = TrackedChanges(path=docx.path if docx is not None else Path(path), mode=mode)
+    if not options.get("enabled", True) or mode not in TRACK_CHANGES_MODES or docx is None:
+        if mode not in TRACK_CHANGES_MODES:
+            logger.warning("Unknown track_changes mode %r (expected %s)", mode, ", ".join(TRACK_CHANGES_MODES))
+        return result
+    resolved = 0
+    for name in docx.names:
+        if not _is_part(name):
+            continue
+        root = docx.part(name)
+        before = (result.insertions, result.deletions, result.formatting)
+        changed = _changed_paragraphs(root) if name == DOCUMENT and mode == "rev" else []
+        revisions = _revisions(root) if mode == "rev" else []
+        _resolve(root, mode != "reject", result)
+        if before == (result.insertions, result.deletions, result.formatting):
+            continue
+        result.paragraphs.extend((_paragraph_text(p), date) for p, date in changed)
+        result.revisions.extend(revisions)
+        docx.touch(name)
+        resolved += 1
+    if not resolved:
+        return result
+    logger.info("Resolved tracked changes in %s (%s)", docx.path.name, mode)
+    if out_dir is not None:
+        result.path = docx.save(Path(out_dir) / "resolved")
+    return result
+
+
+def _rev_value(context: Any, options: Mapping[str, Any], date: str) -> Optional[str]:
+    metadata = getattr(context, "metadata", None) or {}
+    for value in (options.get("rev"), metadata.get("revision_number"), date):
+        if value not in (None, ""):
+            return " ".join(str(value).split())
+    return None
+
+
+def change_history(tracked: Optional[TrackedChanges], context: Any = None,
+                   options: Optional[Mapping[str, Any]] = None) -> List[Dict[str, Any]]:
+    """Revisions of *tracked* grouped by day and author, oldest first.
+
+    Each entry has ``date``, ``author``, ``insertions``, ``deletions`` and
+    ``rev`` (the value given to the blocks changed that day).
+    """
+    grouped: Dict[Tuple[str, str], Dict[str, Any]] = {}
+    for author, date, inserted in (tracked.revisions if tracked else []):
+        entry = grouped.setdefault((date, author), {"date": date, "author": author, "insertions": 0,
+                                                    "deletions": 0,
+                                                    "rev": _rev_value(context, dict(options or {}), date)})
+        entry["insertions" if inserted else "deletions"] += 1
+    return [grouped[key] for key in sorted(grouped)]
+
+
+def record_tracked_changes(context: Any, tracked: Optional[TrackedChanges],
+                           options: Optional[Mapping[str, Any]] = None, report: Any = None) -> int:
+    """Report what was resolved and, in ``rev`` mode, set ``@rev``; returns the blocks marked."""
+    if not tracked:
+        return 0
+    options = dict(options or {})
+    report = report if report is not None else getattr(context, "report", None)
+    verb = "Rejected" if tracked.mode == "reject" else "Accepted"
+    marked = unplaced = 0
+    history: List[Dict[str, Any]] = []
+    if tracked.mode == "rev":
+        blocks = text_blocks(context)
+        cursor = 0
+        for text, date in tracked.paragraphs:
+            if not text:
+                continue              # the whole paragraph was deleted
+            hit, cursor = find_block(blocks, text, cursor)
+            rev = _rev_value(context, options, date)
+            if hit is None or not rev:
+                unplaced += 1
+                continue
+            innermost_block(blocks[hit][1], text).set("rev", rev)
+            marked += 1
+        history = change_history(tracked, context, options) if options.get("history", True) else []
+        if history and getattr(context, "metadata", None) is not None:
+            context.metadata["change_history"] = history
+    if report is not None:
+        message = (f"{verb} {tracked.insertions} tracked insertion(s), {tracked.deletions} deletion(s) and "
+                   f"{tracked.formatting} formatting change(s)")
+        if tracked.mode == "rev":
+            message += f"; {marked} block(s) marked with @rev"
+        report.info("track_changes", message, mode=tracked.mode, insertions=tracked.insertions,
+                    deletions=tracked.deletions, formatting=tracked.formatting, marked=marked,
+                    history=len(history))
+        if unplaced:
+            report.warning("track_changes", f"{unplaced} changed paragraph(s) could not be marked: their text "
+                                            "was not found in the converted topics")
+    return marked
@@ -0,0 +1,190 @@
+from __future__ import annotations
+
+"""Opt-in, anonymous usage statistics.
+
+When ``usage_stats.enabled`` is set in ``pipeline.yml``, each conversion adds
+to a set of aggregate counters kept in one local JSON file:
+
+- number of conversions, failures (by error code) and partial results;
+- source plugins used;
+- document size and topic count, as coarse buckets;
+- time per processing stage (count, total, maximum);
+- report entries per severity and category.
+
+Nothing identifying is stored: no file names, paths, titles, content or
+per-run records, and the only date is the day collection started. Nothing
+leaves the machine; ``python -m orlando_toolkit stats export FILE`` writes the
+counters to a file teams can share to tune their profiles or attach to an
+issue. Recording never fails a conversion.
+"""
+
+import json
+import logging
+import os
+import threading
+from datetime import date
+from pathlib import Path
+from typing import Any, Dict, Mapping, Optional
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["UsageStats", "get_usage_stats", "set_usage_stats"]
+
+_FORMAT = "orlando-usage-stats"
+# (exclusive upper bound, label); the final None bound catches everything larger
+_SIZE_BUCKETS = ((100 * 1024, "<100KB"), (1024 * 1024, "100KB-1MB"), (10 * 1024 * 1024, "1-10MB"),
+                 (100 * 1024 * 1024, "10-100MB"), (None, ">100MB"))
+_TOPIC_BUCKETS = ((1, "0"), (11, "1-10"), (51, "11-50"), (201, "51-200"), (1001, "201-1000"), (None, ">1000"))
+
+
+def _bucket(value: int, buckets) -> str:
+    for limit, label in buckets:
+        if limit is None or value < limit:
+            return label
+    return buckets[-1][1]
+
+
+def _default_path() -> Path:
+    from orlando_toolkit.config.manager import _get_user_config_dir
+
+    base = _get_user_config_dir()
+    return (base.parent if base.name == "config" else base) / "usage_stats.json"
+
+
+def _empty() -> Dict[str, Any]:
+    return {
+        "format": _FORMAT,
+        "since": date.today().isoformat(),
+        "conversions": 0,
+        "failures": {},
+        "partial": 0,
+        "plugins": {},
+        "source_size": {},
+        "topics": {},
+        "stages": {},
+        "report": {},
+    }
+
+
+def _bump(counter: Dict[str, Any], key: str, by: int = 1) -> None:
+    counter[key] = int(counter.get(key, 0)) + by
+
+
+class UsageStats:
+    """Aggregate counters in a local JSON file; records only when enabled."""
+
+    def __init__(self, path: Optional[str | Path] = None, *, enabled: bool = False) -> None:
+        self.path = Path(path).expanduser() if path else _default_path()
+        self.enabled = enabled
+        self._lock = threading.Lock()
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "UsageStats":
+        data = data or {}
+        return cls(data.get("path") or None, enabled=bool(data.get("enabled", False)))
+
+    @classmethod
+    def from_config(cls) -> "UsageStats":
+        try:
+            from orlando_toolkit.config import ConfigManager
+            return cls.from_mapping((ConfigManager().get_pipeline_config() or {}).get("usage_stats"))
+        except Exception as exc:
+            logger.debug("Pipeline config unavailable, usage statistics disabled: %s", exc)
+            return cls()
+
+    # ------------------------------------------------------------------
+    # Recording
+    # ------------------------------------------------------------------
+    def record_conversion(self, context: Any, *, source_size: Optional[int] = None) -> None:
+        """Add one successful conversion of *context*."""
+        def _update(stats: Dict[str, Any]) -> None:
+            stats["conversions"] += 1
+            plugin = (getattr(context, "plugin_data", None) or {}).get("_source_plugin", "dita_package")
+            _bump(stats["plugins"], str(plugin))
+            if source_size is not None:
+                _bump(stats["source_size"], _bucket(int(source_size), _SIZE_BUCKETS))
+            _bump(stats["topics"], _bucket(len(getattr(context, "topics", {}) or {}), _TOPIC_BUCKETS))
+            report = getattr(context, "report", None)
+            if report is None:
+                return
+            if report.partial:
+                stats["partial"] += 1
+            for name, seconds in report.timings.items():
+                stage = stats["stages"].setdefault(name, {"count": 0, "total_seconds": 0.0, "max_seconds": 0.0})
+                stage["count"] += 1
+                stage["total_seconds"] = round(stage["total_seconds"] + seconds, 4)
+                stage["max_seconds"] = round(max(stage["max_seconds"], seconds), 4)
+            for entry in report.entries:
+                _bump(stats["report"], f"{entry.severity}:{entry.category}")
+        self._update(_update)
+
+    def record_failure(self, exc: BaseException) -> None:
+        """Add one failed conversion, counted by error code only."""
+        from orlando_toolkit.core.errors import describe_error
+
+        code = describe_error(exc).code
+        self._update(lambda stats: _bump(stats["failures"], code))
+
+    def _update(self, change) -> None:
+        if not self.enabled:
+            return
+        try:
+            with self._lock:
+                stats = self.snapshot()
+                change(stats)
+                self.path.parent.mkdir(parents=True, exist_ok=True)
+                tmp = self.path.with_suffix(".tmp")
+                tmp.write_text(json.dumps(stats, indent=1, sort_keys=True), encoding="utf-8")
+                os.replace(tmp, self.path)
+        except Exception as exc:
+            logger.debug("Could not update usage statistics: %s", exc)
+
+    # ------------------------------------------------------------------
+    # Queries
+    # ------------------------------------------------------------------
+    def snapshot(self) -> Dict[str, Any]:
+        """Current counters (empty counters when nothing was recorded)."""
+        stats = _empty()
+        try:
+            data = json.loads(self.path.read_text(encoding="utf-8"))
+        except FileNotFoundError:
+            return stats
+        except Exception as exc:
+            logger.warning("Usage statistics file %s unreadable (%s); starting over", self.path, exc)
+            return stats
+        if isinstance(data, dict) and data.get("format") == _FORMAT:
+            stats.update(data)
+        return stats
+
+    def export(self, path: str | Path) -> Path:
+        """Write the counters to *path* as JSON."""
+        path = Path(path)
+        path.write_text(json.dumps(self.snapshot(), indent=2, sort_keys=True), encoding="utf-8")
+        return path
+
+    def reset(self) -> None:
+        with self._lock:
+            try:
+                self.path.unlink()
+            except FileNotFoundError:
+                pass
+
+
+_stats: Optional[UsageStats] = None
+_stats_lock = threading.Lock()
+
+
+def get_usage_stats() -> UsageStats:
+    """Return the process-wide statistics collector, configured on first use."""
+    global _stats
+    if _stats is None:
+        with _stats_lock:
+            if _stats is None:
+                _stats = UsageStats.from_config()
+    return _stats
+
+
+def set_usage_stats(stats: Optional[UsageStats]) -> None:
+    """Replace the process-wide collector (``None`` re-reads the configuration)."""
+    global _stats
+    _stats = stats
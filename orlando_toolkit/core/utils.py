@@ -15,6 +15,7 @@ import xml.dom.minidom as _minidom
 
 if False:  # TYPE_CHECKING pragma
     from orlando_toolkit.core.models import DitaContext
+    from orlando_toolkit.core.escaping import EscapingPolicy
 
 __all__ = [
     "slugify",
@@ -25,6 +26,8 @@ __all__ = [
     "calculate_section_numbers",
     "get_section_number_for_topicref",
     "find_topicref_for_image",
+    "topic_body",
+    "topic_doctype",
 ]
 
 logger = logging.getLogger(__name__)
@@ -92,6 +95,29 @@ def clean_heading_text(text: str) -> str:
     return cleaned if cleaned else text  # Fallback to original if everything was removed
 
 
+# Topic type -> body element and DOCTYPE written by the packager
+_TOPIC_TYPES = {
+    "concept": ("conbody", '<!DOCTYPE concept PUBLIC "-//OASIS//DTD DITA Concept//EN" "concept.dtd">'),
+    "task": ("taskbody", '<!DOCTYPE task PUBLIC "-//OASIS//DTD DITA Task//EN" "task.dtd">'),
+    "reference": ("refbody", '<!DOCTYPE reference PUBLIC "-//OASIS//DTD DITA Reference//EN" "reference.dtd">'),
+    "topic": ("body", '<!DOCTYPE topic PUBLIC "-//OASIS//DTD DITA Topic//EN" "topic.dtd">'),
+    "glossentry": ("glossdef", '<!DOCTYPE glossentry PUBLIC "-//OASIS//DTD DITA Glossary Entry//EN" "glossentry.dtd">'),
+}
+
+
+def topic_body(topic_el: Optional[ET.Element]) -> Optional[ET.Element]:
+    """Return the body element of a concept, task, reference or generic topic (a glossentry's glossdef)."""
+    if topic_el is None:
+        return None
+    name = _TOPIC_TYPES.get(topic_el.tag, _TOPIC_TYPES["concept"])[0]
+    return topic_el.find(name)
+
+
+def topic_doctype(topic_el: ET.Element) -> str:
+    """DOCTYPE declaration matching the root element of *topic_el* (concept by default)."""
+    return _TOPIC_TYPES.get(topic_el.tag, _TOPIC_TYPES["concept"])[1]
+
+
 def generate_dita_id() -> str:
     """Generate a globally unique ID suitable for DITA elements."""
     return f"id-{uuid.uuid4()}"
@@ -104,7 +130,8 @@ def generate_dita_id() -> str:
 # We keep exact behaviour of legacy functions to guarantee no regression.
 
 
-def save_xml_file(element: ET.Element, path: str, doctype_str: str, *, pretty: bool = True) -> None:
+def save_xml_file(element: ET.Element, path: str, doctype_str: str, *, pretty: bool = True,
+                  escaping: Optional["EscapingPolicy"] = None) -> None:
     """Write *element* to *path* with XML declaration and supplied doctype.
 
     Parameters
@@ -119,6 +146,8 @@ def save_xml_file(element: ET.Element, path: str, doctype_str: str, *, pretty: b
             '\n<!DOCTYPE concept PUBLIC "-//OASIS//DTD DITA Concept//EN" "concept.dtd">'
     pretty
         When *True* (default) lxml pretty-prints the output for readability.
+    escaping
+        Character escaping policy; raw UTF-8 when omitted.
     """
 
     xml_bytes = ET.tostring(
@@ -128,6 +157,8 @@ def save_xml_file(element: ET.Element, path: str, doctype_str: str, *, pretty: b
         encoding="UTF-8",
         doctype=doctype_str,
     )
+    if escaping is not None and not escaping.is_raw:
+        xml_bytes = escaping.apply(xml_bytes.decode("utf-8")).encode("utf-8")
     try:
         with open(path, "wb") as fh:
             fh.write(xml_bytes)
@@ -139,10 +170,12 @@ def save_xml_file(element: ET.Element, path: str, doctype_str: str, *, pretty: b
         raise
 
 
-def save_minified_xml_file(element: ET.Element, path: str, doctype_str: str) -> None:
+def save_minified_xml_file(element: ET.Element, path: str, doctype_str: str, *,
+                           escaping: Optional["EscapingPolicy"] = None) -> None:
     """Save *element* on a single line (minified) to *path*.
 
-    This reproduces the logic previously embedded in the converter.
+    This reproduces the logic previously embedded in the converter. *escaping*
+    selects the character escaping policy (raw UTF-8 when omitted).
     """
 
     xml_bytes = ET.tostring(element, encoding="UTF-8")
@@ -150,6 +183,8 @@ def save_minified_xml_file(element: ET.Element, path: str, doctype_str: str) ->
     minified_content = dom.documentElement.toxml() if dom.documentElement else ""
 
     full = f'<?xml version="1.0" encoding="UTF-8"?>{doctype_str}{minified_content}'
+    if escaping is not None:
+        full = escaping.apply(full)
     try:
         with open(path, "w", encoding="utf-8") as fh:
             fh.write(full)
@@ -0,0 +1,607 @@
+from __future__ import annotations
+
+"""Evaluate Word fields and unwrap content controls before a plugin converts the document.
+
+Converters render Word fields by their cached result, when they keep them:
+a ``SEQ`` caption number or a ``STYLEREF`` running title shows whatever
+Word last computed, ``PAGE`` numbers are meaningless in topics, and the text
+of content controls (``w:sdt``) is often lost. Before the plugin handler
+runs, :func:`resolve_word_fields` edits the source (the shared
+:class:`~orlando_toolkit.core.word_source.WordSource`) so that:
+
+- ``DATE``/``TIME`` become the conversion date (``fields.date: cached``
+  keeps Word's text), ``CREATEDATE``/``SAVEDATE``/``PRINTDATE`` the dates of
+  the document properties, formatted by the field's ``\\@`` picture or
+  ``fields.date_format``;
+- ``SEQ`` fields are numbered again in document order (``\\r``, ``\\c``,
+  ``\\h``, ``\\s`` and the ``\\*`` number formats);
+- ``STYLEREF`` fields become the text of the last paragraph of that style
+  (or heading level);
+- the new text takes the formatting of Word's result, as ``\\* MERGEFORMAT``
+  asks;
+- ``PAGE``, ``NUMPAGES``, ``SECTIONPAGES``, ``SECTION`` and ``PAGEREF``
+  are removed with their result (``fields.page_fields: keep`` leaves the
+  cached text);
+- content controls are replaced by their content, placeholder text
+  ("Click here to enter text.") being dropped.
+
+Fields nested in others or spanning paragraphs (``TOC``, ``INDEX``) and the
+fields other passes read (``REF``, ``XE``, ``HYPERLINK``, ``EQ``) are kept.
+After conversion, :func:`record_word_fields` maps the controls whose tag is
+listed in ``content_controls.map`` to a DITA element (``ProductName:
+keyword``, ``Warning: {element: note, type: warning}``): inline controls
+wrap their text, block controls their paragraphs, located by text like
+footnotes are (:mod:`orlando_toolkit.core.placement`). Body paragraphs
+holding a ``SEQ`` field are caption paragraphs: they get a
+:data:`CAPTION_HINT` with the sequence name (``Figure``, ``Table``) for the
+``captions`` stage. The counts go to the report under ``word_fields``.
+"""
+
+import logging
+import re
+from dataclasses import dataclass, field
+from datetime import datetime
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.placement import find_block, innermost_block, insert_at, normalize, offset_of, \
+    text_blocks
+from orlando_toolkit.core.word_source import DOCUMENT, WordSource, w
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["CAPTION_HINT", "ContentControl", "WordFields", "format_word_date", "record_word_fields",
+           "resolve_word_fields"]
+
+#: Sequence name of a caption paragraph (Word ``SEQ`` field), read by the ``captions`` stage
+CAPTION_HINT = "data-caption"
+
+_CORE_NS = {"dcterms": "http://purl.org/dc/terms/",
+            "cp": "http://schemas.openxmlformats.org/package/2006/metadata/core-properties"}
+_PARTS = ("word/document.xml", "word/footnotes.xml", "word/endnotes.xml")
+_PAGE_FIELDS = frozenset({"PAGE", "NUMPAGES", "SECTIONPAGES", "SECTION", "PAGEREF"})
+# field -> core property holding its date (None: the conversion date)
+_DATE_FIELDS = {"DATE": None, "TIME": None, "CREATEDATE": "dcterms:created", "SAVEDATE": "dcterms:modified",
+                "PRINTDATE": "cp:lastPrinted"}
+_DEFAULT_DATE_FORMAT = "yyyy-MM-dd"
+_DEFAULT_TIME_FORMAT = "HH:mm"
+_TOKENS = re.compile(r'"[^"]*"|\S+')
+# \* switches that keep the result's formatting rather than format the number
+_FORMAT_ONLY = frozenset({"MERGEFORMAT", "CHARFORMAT"})
+_PICTURE = re.compile(r"'[^']*'|yyyy|yy|MMMM|MMM|MM|M|dddd|ddd|dd|d|HH|H|hh|h|mm|m|ss|s|AM/PM|am/pm|.")
+_MONTHS = ("January", "February", "March", "April", "May", "June", "July", "August", "September", "October",
+           "November", "December")
+_DAYS = ("Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday")
+_ROMAN = ((1000, "m"), (900, "cm"), (500, "d"), (400, "cd"), (100, "c"), (90, "xc"), (50, "l"), (40, "xl"),
+          (10, "x"), (9, "ix"), (5, "v"), (4, "iv"), (1, "i"))
+
+
+def _local(el: Any) -> str:
+    return el.tag.split("}")[-1] if isinstance(el.tag, str) else ""
+
+
+@dataclass
+class ContentControl:
+    """A tagged content control, as found in the source paragraph(s)."""
+    tag: str
+    text: str
+    paragraphs: List[str] = field(default_factory=list)   # inline: the paragraph holding it
+    offset: int = 0                                       # inline: where the text starts in it
+    block: bool = False
+
+
+@dataclass
+class WordFields:
+    """What :func:`resolve_word_fields` did; ``path`` is the file to convert."""
+    path: Path
+    evaluated: Dict[str, int] = field(default_factory=dict)   # field type -> count
+    removed: int = 0
+    unwrapped: int = 0
+    placeholders: int = 0
+    controls: List[ContentControl] = field(default_factory=list)
+    captions: List[Tuple[str, str]] = field(default_factory=list)   # (paragraph text, SEQ name), body only
+
+    def __bool__(self) -> bool:
+        return bool(self.evaluated or self.removed or self.unwrapped or self.placeholders)
+
+
+def format_word_date(value: datetime, picture: str) -> str:
+    """*value* formatted with a Word date-time picture (``d MMMM yyyy``, ``HH:mm``)."""
+    hour12 = value.hour % 12 or 12
+    parts = {
+        "yyyy": f"{value.year:04d}", "yy": f"{value.year % 100:02d}",
+        "MMMM": _MONTHS[value.month - 1], "MMM": _MONTHS[value.month - 1][:3],
+        "MM": f"{value.month:02d}", "M": str(value.month),
+        "dddd": _DAYS[value.weekday()], "ddd": _DAYS[value.weekday()][:3],
+        "dd": f"{value.day:02d}", "d": str(value.day),
+        "HH": f"{value.hour:02d}", "H": str(value.hour), "hh": f"{hour12:02d}", "h": str(hour12),
+        "mm": f"{value.minute:02d}", "m": str(value.minute), "ss": f"{value.second:02d}", "s": str(value.second),
+        "AM/PM": "AM" if value.hour < 12 else "PM", "am/pm": "am" if value.hour < 12 else "pm",
+    }
+    out = []
+    for token in _PICTURE.findall(picture):
+        if len(token) > 1 and token.startswith("'") and token.endswith("'"):
+            out.append(token[1:-1])
+        else:
+            out.append(parts.get(token, token))
+    return "".join(out)
+
+
+def _number(value: int, style: Optional[str]) -> str:
+    if style in ("ROMAN", "roman", "Roman"):
+        text, rest = "", value
+        for amount, numeral in _ROMAN:
+            while rest >= amount:
+                text, rest = text + numeral, rest - amount
+        return text.upper() if style == "ROMAN" else text
+    if style in ("ALPHABETIC", "alphabetic") and value > 0:
+        letter = chr(ord("a") + (value - 1) % 26) * ((value - 1) // 26 + 1)
+        return letter.upper() if style == "ALPHABETIC" else letter
+    return str(value)
+
+
+def _tokens(instruction: str) -> List[str]:
+    return [t[1:-1] if len(t) > 1 and t.startswith('"') and t.endswith('"') else t
+            for t in _TOKENS.findall(instruction)]
+
+
+def _switch(tokens: List[str], name: str) -> Optional[str]:
+    """Argument of switch *name* (``\\@ "format"``), ``""`` for a flag, None when absent."""
+    for index, token in enumerate(tokens):
+        if token.lower() == name.lower():
+            following = tokens[index + 1] if index + 1 < len(tokens) else ""
+            return "" if following.startswith("\\") else following
+    return None
+
+
+def _number_format(tokens: List[str]) -> Optional[str]:
+    """The ``\\*`` number format of a field (``ROMAN``, ``alphabetic``, ...), ``MERGEFORMAT`` skipped."""
+    for index, token in enumerate(tokens[:-1]):
+        if token == "\\*" and tokens[index + 1].upper() not in _FORMAT_ONLY:
+            return tokens[index + 1]
+    return None
+
+
+def _paragraph_text(paragraph: Any) -> str:
+    parts = []
+    for el in paragraph.iter():
+        name = _local(el)
+        if name == "t":
+            parts.append(el.text or "")
+        elif name in ("tab", "br", "cr"):
+            parts.append(" ")
+    return normalize("".join(parts))
+
+
+class _Styles:
+    """Style names and outline levels of ``word/styles.xml``."""
+
+    def __init__(self, root: Any) -> None:
+        self.names: Dict[str, str] = {}
+        self.levels: Dict[str, int] = {}
+        for style in root.iter(w("style")) if root is not None else ():
+            style_id = style.get(w("styleId")) or ""
+            name = style.find(w("name"))
+            self.names[style_id] = name.get(w("val")) if name is not None else style_id
+            outline = style.find(f"{w('pPr')}/{w('outlineLvl')}")
+            if outline is not None and (outline.get(w("val")) or "").isdigit():
+                self.levels[style_id] = int(outline.get(w("val"))) + 1
+
+    def of(self, paragraph: Any) -> Tuple[str, Optional[int]]:
+        """Style id and heading level (1-9) of *paragraph*."""
+        ppr = paragraph.find(w("pPr"))
+        style = ppr.find(w("pStyle")) if ppr is not None else None
+        style_id = style.get(w("val")) if style is not None else ""
+        outline = ppr.find(w("outlineLvl")) if ppr is not None else None
+        if outline is not None and (outline.get(w("val")) or "").isdigit():
+            return style_id, int(outline.get(w("val"))) + 1
+        level = self.levels.get(style_id)
+        match = re.fullmatch(r"heading\s*(\d)", self.names.get(style_id, style_id), re.IGNORECASE)
+        return style_id, level or (int(match.group(1)) if match else None)
+
+    def matches(self, style_id: str, wanted: str) -> bool:
+        wanted = wanted.casefold()
+        return wanted in (style_id.casefold(), self.names.get(style_id, "").casefold())
+
+
+class _Field:
+    def __init__(self, begin: Any, nested: bool = False) -> None:
+        self.runs: List[Any] = [begin]
+        self.paragraphs = {_paragraph_of(begin)}
+        self.instruction: List[str] = []
+        self.separated = False
+        self.result: List[Any] = []
+        self.nested = nested          # inside or around another field: left alone
+
+
+def _paragraph_of(el: Any) -> Any:
+    return next((a for a in el.iterancestors() if a.tag == w("p")), None)
+
+
+class _Evaluator:
+    """Evaluates the fields of one part in document order."""
+
+    def __init__(self, options: Mapping[str, Any], styles: _Styles, dates: Dict[str, datetime],
+                 result: WordFields, body: bool = False) -> None:
+        self.options = options
+        self.styles = styles
+        self.dates = dates
+        self.result = result
+        self.sequences: Dict[str, Tuple[int, Tuple[int, ...]]] = {}
+        self.headings = [0] * 9
+        self.styled: Dict[str, Any] = {}       # style id -> last finished paragraph of that style
+        self.levels: Dict[int, Any] = {}       # heading level -> last finished heading
+        self.body = body                       # record caption paragraphs (word/document.xml)
+        self.sequence: Optional[str] = None    # SEQ name of the current paragraph
+
+    def paragraph_done(self, paragraph: Any) -> None:
+        if self.sequence and self.body:
+            self.result.captions.append((_paragraph_text(paragraph), self.sequence))
+        self.sequence = None
+        style_id, level = self.styles.of(paragraph)
+        if style_id:
+            self.styled.pop(style_id, None)
+            self.styled[style_id] = paragraph
+        if level:
+            self.levels[level] = paragraph
+
+    def paragraph_started(self, paragraph: Any) -> None:
+        _style, level = self.styles.of(paragraph)
+        if level:
+            self.headings[level - 1] += 1
+            for lower in range(level, 9):
+                self.headings[lower] = 0
+
+    def value(self, instruction: str, cached: str) -> Optional[str]:
+        """Literal text of the field, ``""`` to remove it, None to keep it as it is."""
+        tokens = _tokens(instruction)
+        kind = tokens[0].upper() if tokens else ""
+        if kind in _PAGE_FIELDS:
+            return "" if str(self.options.get("page_fields") or "remove") == "remove" else cached
+        if kind in _DATE_FIELDS:
+            return self._date(kind, tokens)
+        if kind == "SEQ" and len(tokens) > 1:
+            self.sequence = self.sequence or tokens[1]
+            return self._sequence(tokens)
+        if kind == "STYLEREF" and len(tokens) > 1:
+            return self._style_ref(tokens[1])
+        return None
+
+    def _date(self, kind: str, tokens: List[str]) -> Optional[str]:
+        prop = _DATE_FIELDS[kind]
+        if prop is None and str(self.options.get("date") or "today") == "cached":
+            return None
+        value = datetime.now() if prop is None else self.dates.get(prop)
+        if value is None:
+            return None
+        default = _DEFAULT_TIME_FORMAT if kind == "TIME" else self.options.get("date_format") or _DEFAULT_DATE_FORMAT
+        return format_word_date(value, _switch(tokens, "\\@") or str(default))
+
+    def _sequence(self, tokens: List[str]) -> str:
+        name = tokens[1]
+        current, stamp = self.sequences.get(name, (0, ()))
+        level = _switch(tokens, "\\s")
+        if level and level.isdigit():
+            heading = tuple(self.headings[:int(level)])
+            if heading != stamp:
+                current, stamp = 0, heading
+        reset = _switch(tokens, "\\r")
+        if reset and reset.lstrip("-").isdigit():
+            current = int(reset)
+        elif _switch(tokens, "\\c") is None:
+            current += 1
+        self.sequences[name] = (current, stamp)
+        if _switch(tokens, "\\h") is not None:
+            return ""
+        return _number(current, _number_format(tokens))
+
+    def _style_ref(self, wanted: str) -> Optional[str]:
+        if wanted.isdigit():
+            paragraph = self.levels.get(int(wanted))
+        else:
+            paragraph = next((p for style_id, p in reversed(list(self.styled.items()))
+                              if self.styles.matches(style_id, wanted)), None)
+        return _paragraph_text(paragraph) if paragraph is not None else None
+
+
+def _replace(runs: List[Any], template: Optional[Any], text: str) -> None:
+    """Put one run holding *text* (formatted like *template*) where *runs* were."""
+    first = runs[0]
+    parent = first.getparent()
+    if text and parent is not None:
+        run = ET.Element(w("r"))
+        rpr = template.find(w("rPr")) if template is not None else None
+        if rpr is not None:
+            run.append(ET.fromstring(ET.tostring(rpr)))
+        t = ET.SubElement(run, w("t"))
+        t.text = text
+        t.set("{http://www.w3.org/XML/1998/namespace}space", "preserve")
+        parent.insert(parent.index(first), run)
+    for run in runs:
+        if run.getparent() is not None:
+            run.getparent().remove(run)
+
+
+def _cached(runs: List[Any]) -> str:
+    return "".join(t.text or "" for run in runs for t in run.iter(w("t")))
+
+
+def _evaluate(evaluator: _Evaluator, instruction: str, runs: List[Any], result_runs: List[Any]) -> None:
+    value = evaluator.value(instruction, _cached(result_runs))
+    if value is None:
+        return
+    kind = _tokens(instruction)[0].upper()
+    if kind in _PAGE_FIELDS and value == "":
+        evaluator.result.removed += 1
+    else:
+        evaluator.result.evaluated[kind] = evaluator.result.evaluated.get(kind, 0) + 1
+    _replace(runs, result_runs[0] if result_runs else None, value)
+
+
+def _resolve_fields(root: Any, evaluator: _Evaluator) -> None:
+    stack: List[_Field] = []
+    paragraph = None
+    for el in list(root.iter()):
+        name = _local(el)
+        if name == "p":
+            if paragraph is not None:
+                evaluator.paragraph_done(paragraph)
+            paragraph = el
+            evaluator.paragraph_started(el)
+        elif name == "fldSimple":
+            if not stack:
+                _evaluate(evaluator, el.get(w("instr")) or "", [el], list(el.iter(w("r"))))
+        elif name == "r":
+            for open_field in stack:
+                if el not in open_field.runs:
+                    open_field.runs.append(el)
+                    open_field.paragraphs.add(_paragraph_of(el))
+            if stack and stack[-1].separated and el.find(w("fldChar")) is None:
+                stack[-1].result.append(el)
+        elif name == "instrText" and stack and not stack[-1].separated:
+            stack[-1].instruction.append(el.text or "")
+        elif name == "fldChar":
+            kind = el.get(w("fldCharType"))
+            run = el.getparent()
+            if kind == "begin":
+                for open_field in stack:
+                    open_field.nested = True
+                stack.append(_Field(run, nested=bool(stack)))
+            elif kind == "separate" and stack:
+                stack[-1].separated = True
+            elif kind == "end" and stack:
+                done = stack.pop()
+                if not done.nested and len(done.paragraphs) == 1:
+                    _evaluate(evaluator, "".join(done.instruction), done.runs, done.result)
+    if paragraph is not None:
+        evaluator.paragraph_done(paragraph)
+
+
+def _unwrap(el: Any) -> None:
+    parent = el.getparent()
+    index = parent.index(el)
+    for offset, child in enumerate(list(el)):
+        parent.insert(index + offset, child)
+    parent.remove(el)
+
+
+def _offset_in(paragraph: Any, target: Any) -> int:
+    """Offset in the normalized text of *paragraph* where *target* begins."""
+    parts = []
+    for el in paragraph.iter():
+        if el is target:
+            break
+        name = _local(el)
+        if name == "t":
+            parts.append(el.text or "")
+        elif name in ("tab", "br", "cr"):
+            parts.append(" ")
+    return offset_of("".join(parts))
+
+
+def _resolve_controls(root: Any, options: Mapping[str, Any], mapped: Mapping[str, Any], result: WordFields) -> None:
+    drop_placeholders = str(options.get("placeholders") or "drop") == "drop"
+    # Placeholders first, so the paragraph texts recorded below are final
+    for sdt in list(root.iter(w("sdt"))):
+        props = sdt.find(w("sdtPr"))
+        content = sdt.find(w("sdtContent"))
+        placeholder = drop_placeholders and props is not None and props.find(w("showingPlcHdr")) is not None
+        if sdt.getparent() is not None and (content is None or placeholder):
+            sdt.getparent().remove(sdt)
+            result.placeholders += content is not None
+    for sdt in list(root.iter(w("sdt"))):
+        if sdt.getparent() is None:
+            continue
+        props = sdt.find(w("sdtPr"))
+        content = sdt.find(w("sdtContent"))
+        tag = props.find(w("tag")) if props is not None else None
+        tag = tag.get(w("val")) if tag is not None else ""
+        if tag and tag in mapped:
+            block = any(_local(c) in ("p", "tbl") for c in content)
+            if block:
+                texts = [_paragraph_text(p) for p in content.iter(w("p")) if _paragraph_of(p) is None]
+                result.controls.append(ContentControl(tag, " ".join(texts), [t for t in texts if t], block=True))
+            else:
+                paragraph = _paragraph_of(sdt)
+                text = _paragraph_text(content)
+                if paragraph is not None and text:
+                    result.controls.append(ContentControl(tag, text, [_paragraph_text(paragraph)],
+                                                          offset=_offset_in(paragraph, sdt)))
+        for child in list(sdt):
+            if child is not content:
+                sdt.remove(child)
+        _unwrap(content)
+        _unwrap(sdt)
+        result.unwrapped += 1
+
+
+def _core_dates(docx: WordSource) -> Dict[str, datetime]:
+    dates: Dict[str, datetime] = {}
+    try:
+        root = docx.part("docProps/core.xml")
+    except Exception as exc:
+        logger.debug("Document properties unreadable: %s", exc)
+        return dates
+    if root is None:
+        return dates
+    for prop in ("dcterms:created", "dcterms:modified", "cp:lastPrinted"):
+        prefix, name = prop.split(":")
+        el = root.find(f"{{{_CORE_NS[prefix]}}}{name}")
+        text = (el.text or "").strip() if el is not None else ""
+        try:
+            dates[prop] = datetime.fromisoformat(text.replace("Z", "+00:00"))
+        except ValueError:
+            continue
+    return dates
+
+
+def resolve_word_fields(path: str | Path | WordSource, options: Optional[Mapping[str, Any]],
+                        control_options: Optional[Mapping[str, Any]],
+                        out_dir: Optional[str | Path] = None) -> WordFields:
+    """Evaluate the fields and unwrap the content controls of the ``.docx`` *path*.
+
+    *options* is the ``fields`` section, *control_options* the
+    ``content_controls`` one. With *out_dir* a copy is written there and
+    becomes ``result.path``; without it the parts of a shared
+    :class:`WordSource` are edited for the caller to save. ``result.path``
+    is the source itself when both are disabled, the file is not a Word
+    package or nothing changed.
+    """
+    options = dict(options or {})
+    control_options = dict(control_options or {})
+    docx = WordSource.of(path)
+    result = WordFields(path=docx.path if docx is not None else Path(path))
+    fields_on = options.get("enabled", True)
+    controls_on = control_options.get("enabled", True)
+    if not (fields_on or controls_on) or docx is None or DOCUMENT not in docx:
+        return result
+    mapped = control_options.get("map") or {}
+    styles = _Styles(docx.part("word/styles.xml"))
+    dates = _core_dates(docx)
+    resolved = 0
+    for name in _PARTS:
+        root = docx.part(name)
+        if root is None:
+            continue
+        has_fields = fields_on and next(root.iter(w("fldChar"), w("fldSimple")), None) is not None
+        has_controls = controls_on and next(root.iter(w("sdt")), None) is not None
+        if not (has_fields or has_controls):
+            continue
+        before = (dict(result.evaluated), result.removed, result.unwrapped, result.placeholders)
+        if has_fields:
+            _resolve_fields(root, _Evaluator(options, styles, dates, result, name == DOCUMENT))
+        if has_controls:
+            _resolve_controls(root, control_options, mapped if name == DOCUMENT else {}, result)
+        if before != (result.evaluated, result.removed, result.unwrapped, result.placeholders):
+            docx.touch(name)
+            resolved += 1
+    if not resolved:
+        return result
+    logger.info("Evaluated Word fields and content controls in %s", docx.path.name)
+    if out_dir is not None:
+        result.path = docx.save(Path(out_dir) / "fields")
+    return result
+
+
+def _rule(value: Any) -> Tuple[str, Dict[str, str]]:
+    if isinstance(value, Mapping):
+        attributes = {str(k): str(v) for k, v in value.items() if k != "element" and v is not None}
+        return str(value.get("element") or "ph"), attributes
+    return str(value or "ph"), {}
+
+
+def _wrap(blocks: List[Any], element: str, attributes: Dict[str, str]) -> bool:
+    first = blocks[0]
+    parent = first.getparent()
+    if parent is None or any(b.getparent() is not parent for b in blocks):
+        return False
+    if all(b.tag == element for b in blocks):
+        for block in blocks:
+            block.attrib.update(attributes)
+        return True
+    wrapper = ET.Element(element, attributes)
+    parent.insert(parent.index(first), wrapper)
+    for block in blocks:
+        wrapper.append(block)
+    return True
+
+
+def _detach(el: Any) -> None:
+    """Remove an element :func:`insert_at` placed, keeping its tail."""
+    parent = el.getparent()
+    previous = el.getprevious()
+    if previous is not None:
+        previous.tail = (previous.tail or "") + (el.tail or "") or None
+    else:
+        parent.text = (parent.text or "") + (el.tail or "") or None
+    parent.remove(el)
+
+
+def _place(context: Any, controls: List[ContentControl], mapped: Mapping[str, Any]) -> Tuple[int, int]:
+    blocks = text_blocks(context)
+    cursor = placed = 0
+    for control in controls:
+        element, attributes = _rule(mapped.get(control.tag))
+        if control.block:
+            found = []
+            for text in control.paragraphs:
+                hit, cursor = find_block(blocks, text, cursor)
+                if hit is not None:
+                    found.append(blocks[hit][1])
+            ok = bool(found) and len(found) == len(control.paragraphs) and _wrap(found, element, attributes)
+        else:
+            hit, cursor = find_block(blocks, control.paragraphs[0], cursor)
+            ok = False
+            if hit is not None:
+                block = innermost_block(blocks[hit][1], control.paragraphs[0])
+                new = ET.Element(element, attributes)
+                new.text = control.text
+                ok = insert_at(block, control.offset, new, remove=control.text)
+                if not ok:
+                    _detach(new)
+        placed += ok
+    return placed, len(controls) - placed
+
+
+def _mark_captions(context: Any, captions: List[Tuple[str, str]]) -> int:
+    blocks = text_blocks(context)
+    cursor = marked = 0
+    for text, sequence in captions:
+        if not text:
+            continue
+        hit, cursor = find_block(blocks, text, cursor)
+        if hit is None:
+            continue
+        innermost_block(blocks[hit][1], text).set(CAPTION_HINT, sequence)
+        marked += 1
+    return marked
+
+
+def record_word_fields(context: Any, fields: Optional[WordFields], options: Optional[Mapping[str, Any]] = None,
+                       report: Any = None) -> int:
+    """Map the tagged content controls of *fields*, mark caption paragraphs and report the counts.
+
+    *options* is the ``content_controls`` section. Returns the controls mapped.
+    """
+    if not fields:
+        return 0
+    options = dict(options or {})
+    report = report if report is not None else getattr(context, "report", None)
+    placed, unplaced = _place(context, fields.controls, options.get("map") or {}) if fields.controls else (0, 0)
+    _mark_captions(context, fields.captions)
+    if report is not None:
+        evaluated = sum(fields.evaluated.values())
+        kinds = ", ".join(f"{kind} {count}" for kind, count in sorted(fields.evaluated.items()))
+        message = (f"Evaluated {evaluated} field(s){f' ({kinds})' if kinds else ''}, removed {fields.removed} "
+                   f"page field(s) and unwrapped {fields.unwrapped} content control(s)")
+        if fields.controls:
+            message += f"; {placed} mapped to DITA elements"
+        report.info("word_fields", message, evaluated=dict(fields.evaluated), removed=fields.removed,
+                    controls=fields.unwrapped, placeholders=fields.placeholders, mapped=placed)
+        if unplaced:
+            report.warning("word_fields", f"{unplaced} content control(s) could not be mapped: their text was not "
+                                          "found in the converted topics",
+                           tags=sorted({c.tag for c in fields.controls}))
+    return placed
@@ -0,0 +1,391 @@
+from __future__ import annotations
+
+"""Word embedded and linked video and audio as DITA ``<object>``.
+
+Converters keep the poster picture of a video inserted in Word and drop the
+clip itself. After the plugin handler returns, :func:`restore_word_media`
+reads ``word/document.xml`` of the source and finds:
+
+- pictures carrying a media file (``a:videoFile``, ``a:audioFile``,
+  ``a:quickTimeFile`` or ``p14:media``), embedded in the document or linked
+  to a file next to it;
+- online videos (``wp15:webVideoPr``), kept as the address of their player;
+- OLE objects whose embedded part is a media file (``w:object``).
+
+Each clip is added to ``context.videos`` (audio included) as
+``video_N``/``audio_N`` with its extension and written as::
+
+    <object data="../media/video_1.mp4" type="video/mp4" outputclass="video">
+      <desc>Drawing title</desc>
+      <param name="poster" value="../media/poster.png" valuetype="ref"/>
+    </object>
+
+The poster is the picture Word shows for the clip. When the plugin already
+converted it to an ``<image>`` (matched by the bytes of the file), the
+object takes that image's place; otherwise the poster is added to
+``context.images`` and the object placed after the paragraph holding the
+drawing, located by text (:mod:`orlando_toolkit.core.placement`). Poster
+references follow image renaming. Linked local files are copied into the
+package when ``copy_linked`` and found; the others stay links. Findings are
+reported under ``media``.
+"""
+
+import hashlib
+import logging
+import posixpath
+import re
+import urllib.parse
+import urllib.request
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Any, Dict, Iterator, List, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.placement import find_block, normalize, text_blocks, topic_order
+from orlando_toolkit.core.utils import topic_body
+from orlando_toolkit.core.word_source import DOCUMENT, R_NS, WordSource, w
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["MEDIA_TYPES", "WordMedia", "is_audio", "media_poster", "read_word_media", "restore_word_media"]
+
+MEDIA_TYPES = {
+    ".mp4": "video/mp4", ".m4v": "video/mp4", ".mov": "video/quicktime", ".webm": "video/webm",
+    ".avi": "video/x-msvideo", ".wmv": "video/x-ms-wmv", ".mkv": "video/x-matroska",
+    ".mp3": "audio/mpeg", ".m4a": "audio/mp4", ".wav": "audio/wav", ".ogg": "audio/ogg", ".wma": "audio/x-ms-wma",
+}
+_WP = "http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing"
+_WP15 = "http://schemas.microsoft.com/office/word/2012/wordprocessingDrawing"
+_A = "http://schemas.openxmlformats.org/drawingml/2006/main"
+_PIC = "http://schemas.openxmlformats.org/drawingml/2006/picture"
+_P14 = "http://schemas.microsoft.com/office/powerpoint/2010/main"
+_MC = "http://schemas.openxmlformats.org/markup-compatibility/2006"
+_V = "urn:schemas-microsoft-com:vml"
+_O = "urn:schemas-microsoft-com:office:office"
+_MEDIA_TAGS = {f"{{{_A}}}videoFile": "video", f"{{{_A}}}quickTimeFile": "video", f"{{{_A}}}audioFile": "audio",
+               f"{{{_P14}}}media": ""}
+_IFRAME_SRC = re.compile(r"""<iframe[^>]*\bsrc\s*=\s*["']([^"']+)["']""", re.IGNORECASE)
+
+
+def is_audio(name: str) -> bool:
+    """Whether the media file *name* is an audio clip (by extension)."""
+    return MEDIA_TYPES.get(posixpath.splitext(name)[1].lower(), "").startswith("audio/")
+
+
+@dataclass
+class WordMedia:
+    number: int                     # 1-based, in document order
+    kind: str                       # video | audio
+    anchor: str                     # text of the paragraph holding the drawing (or the one before it)
+    ext: str = ""                   # extension of the clip, ".mp4"
+    data: Optional[bytes] = None    # embedded (or copied) clip
+    url: str = ""                   # linked file or online video, when not embedded
+    online: bool = False
+    title: str = ""
+    poster: Optional[Tuple[str, bytes]] = None  # (part name, bytes) of the picture shown for the clip
+
+    @property
+    def media_type(self) -> str:
+        return "text/html" if self.online else MEDIA_TYPES.get(self.ext, "")
+
+
+def _paragraph_text(paragraph: Any) -> str:
+    parts = []
+    for el in paragraph.iter():
+        if el.tag == w("t"):
+            parts.append(el.text or "")
+        elif el.tag in (w("tab"), w("br")):
+            parts.append(" ")
+    return normalize("".join(parts))
+
+
+def _part(docx: WordSource, targets: Dict[str, Tuple[str, bool]],
+          rel: Optional[str]) -> Optional[Tuple[str, bytes]]:
+    target, external = targets.get(rel or "", ("", True))
+    if not target or external:
+        return None
+    try:
+        return target, docx.read(target)
+    except KeyError:
+        return None
+
+
+def _linked_file(url: str, folder: Path) -> Optional[Path]:
+    """The clip a link points to when a copy sits next to the document (its relative path or its name)."""
+    parsed = urllib.parse.urlparse(url)
+    if parsed.scheme.lower() not in ("", "file") and len(parsed.scheme) > 1:    # "C:" parses as a scheme
+        return None
+    path = urllib.request.url2pathname(parsed.path) if parsed.scheme.lower() == "file" else url
+    name = re.split(r"[\\/]", path)[-1]
+    if posixpath.splitext(name)[1].lower() not in MEDIA_TYPES:
+        return None
+    relative = Path(path.replace("\\", "/"))
+    candidates = [folder / name]
+    if not relative.is_absolute() and ".." not in relative.parts:
+        candidates.insert(0, folder / relative)
+    return next((c for c in candidates if c.is_file()), None)
+
+
+def _drawings(paragraph: Any) -> Iterator[Tuple[Any, str]]:
+    """``(element, "pic" | "ole")`` of the media candidates of *paragraph*, outside ``mc:Fallback``."""
+    for el in paragraph.iter():
+        if el.tag not in (f"{{{_PIC}}}pic", w("object")):
+            continue
+        if any(a.tag == f"{{{_MC}}}Fallback" for a in el.iterancestors()):
+            continue
+        yield el, "pic" if el.tag == f"{{{_PIC}}}pic" else "ole"
+
+
+def _online_video(nv_pr: Any, number: int, anchor: str) -> Optional[WordMedia]:
+    for el in nv_pr.iter(f"{{{_WP15}}}webVideoPr"):
+        match = _IFRAME_SRC.search(el.get("embeddedHtml") or "")
+        if match:
+            source = match.group(1)
+            return WordMedia(number, "video", anchor, url="https:" + source if source.startswith("//") else source,
+                             online=True)
+    return None
+
+
+def _media_file(nv_pr: Any, docx: WordSource, targets: Dict[str, Tuple[str, bool]],
+                folder: Path, copy_linked: bool, number: int, anchor: str) -> Optional[WordMedia]:
+    found = next((el for el in nv_pr.iter() if el.tag in _MEDIA_TAGS), None)
+    if found is None:
+        return None
+    media = WordMedia(number, _MEDIA_TAGS[found.tag], anchor)
+    embedded = _part(docx, targets, found.get(f"{{{R_NS}}}embed"))
+    if embedded is None and found.get(f"{{{R_NS}}}link"):
+        target, external = targets.get(found.get(f"{{{R_NS}}}link"), ("", True))
+        embedded = _part(docx, targets, found.get(f"{{{R_NS}}}link")) if not external else None
+        if embedded is None:
+            media.url = target
+            linked = _linked_file(target, folder) if copy_linked and target else None
+            if linked is not None:
+                embedded = (linked.name, linked.read_bytes())
+    if embedded is None and not media.url:
+        return None
+    if embedded is not None:
+        media.data = embedded[1]
+        media.url = ""
+        media.ext = posixpath.splitext(embedded[0])[1].lower()
+    else:
+        media.ext = posixpath.splitext(urllib.parse.urlparse(media.url).path)[1].lower()
+    if not media.kind:
+        media.kind = "audio" if is_audio("x" + media.ext) else "video"
+    return media
+
+
+def _picture_media(pic: Any, docx: WordSource, targets: Dict[str, Tuple[str, bool]],
+                   folder: Path, copy_linked: bool, number: int, anchor: str) -> Optional[WordMedia]:
+    nv_pr = pic.find(f"{{{_PIC}}}nvPicPr/{{{_PIC}}}nvPr")
+    if nv_pr is None:
+        return None
+    media = _online_video(nv_pr, number, anchor) or _media_file(nv_pr, docx, targets, folder, copy_linked,
+                                                                 number, anchor)
+    if media is None:
+        return None
+    blip = pic.find(f"{{{_PIC}}}blipFill/{{{_A}}}blip")
+    media.poster = _part(docx, targets, blip.get(f"{{{R_NS}}}embed")) if blip is not None else None
+    return media
+
+
+def _ole_media(obj: Any, docx: WordSource, targets: Dict[str, Tuple[str, bool]],
+               number: int, anchor: str) -> Optional[WordMedia]:
+    ole = obj.find(f"{{{_O}}}OLEObject")
+    embedded = _part(docx, targets, ole.get(f"{{{R_NS}}}id")) if ole is not None else None
+    ext = posixpath.splitext(embedded[0])[1].lower() if embedded is not None else ""
+    if ext not in MEDIA_TYPES:
+        return None
+    media = WordMedia(number, "audio" if is_audio("x" + ext) else "video", anchor, ext=ext, data=embedded[1])
+    shape = obj.find(f"{{{_V}}}shape")
+    imagedata = shape.find(f"{{{_V}}}imagedata") if shape is not None else None
+    if imagedata is not None:
+        media.poster = _part(docx, targets, imagedata.get(f"{{{R_NS}}}id"))
+    media.title = (shape.get("alt") or "").strip() if shape is not None else ""
+    return media
+
+
+def read_word_media(path: str | Path | WordSource, options: Optional[Mapping[str, Any]] = None) -> List[WordMedia]:
+    """Video and audio clips of the body of the ``.docx`` *path*, in document order."""
+    options = dict(options or {})
+    docx = WordSource.of(path)
+    if docx is None or docx.document is None:
+        return []
+    copy_linked = bool(options.get("copy_linked", True))
+    clips: List[WordMedia] = []
+    targets = docx.relationships(DOCUMENT)
+    anchor = ""
+    for paragraph in docx.paragraphs:
+        if any(a.tag == w("p") for a in paragraph.iterancestors()):
+            continue
+        text = _paragraph_text(paragraph)
+        for el, kind in _drawings(paragraph):
+            number = len(clips) + 1
+            if kind == "pic":
+                media = _picture_media(el, docx, targets, docx.path.parent, copy_linked, number, text or anchor)
+            else:
+                media = _ole_media(el, docx, targets, number, text or anchor)
+            if media is None:
+                continue
+            props = next((p for p in el.iterancestors() if p.tag in (f"{{{_WP}}}inline", f"{{{_WP}}}anchor")),
+                         None)
+            doc_pr = props.find(f"{{{_WP}}}docPr") if props is not None else None
+            if doc_pr is not None:
+                media.title = (doc_pr.get("title") or doc_pr.get("descr") or "").strip() or media.title
+            clips.append(media)
+        if text:
+            anchor = text
+    return clips
+
+
+# ----------------------------------------------------------------------
+# Placement
+# ----------------------------------------------------------------------
+
+def _unique(store: Any, name: str) -> str:
+    stem, ext = posixpath.splitext(name)
+    candidate, n = name, 2
+    while candidate in store:
+        candidate, n = f"{stem}_{n}{ext}", n + 1
+    return candidate
+
+
+@dataclass
+class _Placed:
+    clip: WordMedia
+    href: str                       # @data of the object
+    poster: str = ""                # poster file name in context.images
+
+
+def _object(media: _Placed) -> Any:
+    obj = ET.Element("object", data=media.href, outputclass=media.clip.kind)
+    if media.clip.media_type:
+        obj.set("type", media.clip.media_type)
+    if media.clip.title:
+        ET.SubElement(obj, "desc").text = media.clip.title
+    if media.poster:
+        ET.SubElement(obj, "param", name="poster", value=f"../media/{media.poster}", valuetype="ref")
+    return obj
+
+
+def _after(block: Any, new: Any) -> None:
+    if block.tag in ("li", "entry", "stentry", "dd") or block.getparent() is None:
+        block.append(new)
+        return
+    parent = block.getparent()
+    new.tail = block.tail
+    block.tail = None
+    parent.insert(parent.index(block) + 1, new)
+
+
+def _replace(el: Any, new: Any) -> None:
+    parent = el.getparent()
+    new.tail = el.tail
+    parent.insert(parent.index(el), new)
+    parent.remove(el)
+
+
+def _digest(data: bytes) -> str:
+    return hashlib.sha1(data).hexdigest()
+
+
+def media_poster(context: Any, name: str) -> Optional[str]:
+    """Poster image (file name in ``context.images``) of the first ``<object>`` playing the media file *name*."""
+    for topic_name in topic_order(context):
+        for obj in context.topics[topic_name].iter("object"):
+            if posixpath.basename(obj.get("data") or "") != name:
+                continue
+            for param in obj.iter("param"):
+                if param.get("name") == "poster" and param.get("value"):
+                    return posixpath.basename(param.get("value"))
+    return None
+
+
+def restore_word_media(path: str | Path | WordSource, context: Any, options: Optional[Mapping[str, Any]] = None,
+                       report: Any = None) -> int:
+    """Add the video and audio clips of the ``.docx`` *path* to *context* as objects; returns the number placed."""
+    options = dict(options or {})
+    if not options.get("enabled", True):
+        return 0
+    report = report if report is not None else getattr(context, "report", None)
+    try:
+        clips = read_word_media(path, options)
+    except Exception as exc:
+        logger.warning("Could not read the media of %s: %s", Path(path).name, exc)
+        return 0
+    if not clips:
+        return 0
+    if getattr(context, "videos", None) is None:
+        context.videos = {}
+    posters = bool(options.get("posters", True))
+    images: Dict[str, List[Any]] = {}
+    for topic_name in topic_order(context):
+        for el in context.topics[topic_name].iter("image"):
+            images.setdefault(posixpath.basename(el.get("href") or ""), []).append(el)
+    by_digest = {_digest(data): name for name, data in (context.images or {}).items() if name in images}
+
+    blocks = text_blocks(context)
+    cursor = placed = 0
+    last: Dict[int, Any] = {}
+    added: Dict[str, str] = {}      # poster digest -> name, for posters shared by several clips
+    linked: List[int] = []
+    unplaced: List[int] = []
+    for clip in clips:
+        if clip.data is not None:
+            name = _unique(context.videos, f"{clip.kind}_{clip.number}{clip.ext}")
+            ref = _Placed(clip, f"../media/{name}")
+        else:
+            name, ref = "", _Placed(clip, clip.url)
+            if not clip.online and not clip.url.lower().startswith(("http:", "https:")):
+                linked.append(clip.number)
+        existing = by_digest.get(_digest(clip.poster[1])) if clip.poster else None
+        target = images[existing].pop(0) if existing and images.get(existing) else None
+        if target is not None:
+            ref.poster = existing if posters else ""
+            if not images[existing]:
+                by_digest.pop(_digest(clip.poster[1]), None)
+            _replace(target, _object(ref))
+        else:
+            if clip.poster and posters:
+                ext = posixpath.splitext(clip.poster[0])[1] or ".png"
+                ref.poster = added.get(_digest(clip.poster[1])) or \
+                    _unique(context.images, f"{clip.kind}_{clip.number}_poster{ext}")
+            hit, after = find_block(blocks, clip.anchor, cursor) if clip.anchor else (None, cursor)
+            if hit is not None:
+                cursor = after
+                obj = _object(ref)
+                block = blocks[hit][1]
+                _after(last.get(id(block), block), obj)     # clips after the same paragraph keep their order
+                last[id(block)] = obj
+            elif not clip.anchor and context.topics:
+                body = topic_body(context.topics[topic_order(context)[0]])
+                if body is None:
+                    unplaced.append(clip.number)
+                    continue
+                body.insert(0, _object(ref))
+            else:
+                unplaced.append(clip.number)
+                continue
+            if ref.poster:
+                context.images[ref.poster] = clip.poster[1]
+                added[_digest(clip.poster[1])] = ref.poster
+        if name:
+            context.videos[name] = clip.data
+        placed += 1
+
+    if report is not None:
+        done = [c for c in clips if c.number not in unplaced]
+        if placed:
+            videos = sum(1 for c in done if c.kind == "video")
+            online = sum(1 for c in done if c.online)
+            report.info("media", f"{videos} video(s) and {len(done) - videos} audio clip(s) added as objects, "
+                                 f"{online} of them online", videos=videos, audio=len(done) - videos, online=online)
+        if linked:
+            report.warning("media", f"{len(linked)} linked clip(s) were not found next to the document and stay "
+                                    "links to the original files", numbers=linked,
+                           hint="Embed the clips in Word or copy them next to the document")
+        if unplaced:
+            report.warning("media", f"{len(unplaced)} clip(s) could not be placed: the paragraph before them was "
+                                    "not found in the converted topics", numbers=unplaced)
+    logger.info("Word media: %d clip(s) placed", placed)
+    return placed
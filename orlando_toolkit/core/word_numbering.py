@@ -0,0 +1,600 @@
+from __future__ import annotations
+
+"""Word outline numbering: headings without Heading styles, and multi-level lists.
+
+Converters find headings by their style. Documents that build the hierarchy
+with outline numbering (``1``, ``1.2``, ``1.2.3`` from ``numbering.xml``) or
+with paragraph outline levels on ordinary styles come out as one long topic.
+Before the plugin handler runs, :func:`resolve_word_headings` gives such
+paragraphs the built-in ``heading N`` style (added to ``styles.xml`` when
+the document lacks it) in the shared
+:class:`~orlando_toolkit.core.word_source.WordSource`, so they are split like
+any heading. The level is the paragraph's own outline level, else its
+style's (``basedOn`` included), else its level in a *legal* numbering, one
+whose second level repeats the first level's number (``%1.%2``). Paragraphs
+of more than ``headings.max_words`` words stay body text; ``headings.numbering``
+and ``headings.outline_levels`` turn either source off.
+:func:`read_word_headings` lists the resulting heading outline without
+converting, for the heading review (:mod:`orlando_toolkit.core.heading_review`).
+
+After conversion, :func:`restore_word_lists` rebuilds the lists from the
+Word numbering, located by item text like footnotes are
+(:mod:`orlando_toolkit.core.placement`):
+
+- nesting follows the item levels (``w:ilvl``), and each level is ``ol`` or
+  ``ul`` by its number format, so bullets under numbered steps stay bullets;
+- numbered lists whose first item is not 1 (numbering continued after an
+  interruption, a ``w:start`` or ``w:startOverride``) get
+  ``outputclass="start-N"``; a restart inside a run of items starts a new list;
+- letter and roman formats become ``lower-alpha``, ``upper-roman``, …
+  and bullets other than the round default ``bullet-square``,
+  ``bullet-circle``, ``bullet-dash``, ``bullet-check``, ``bullet-arrow``
+  (``lists.bullets`` maps more characters) in ``@outputclass``.
+
+Counts go to the report under ``headings`` and ``lists``.
+"""
+
+import logging
+import re
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.heading_rules import Heading
+from orlando_toolkit.core.placement import RESTORED_TAGS, normalize, topic_order
+from orlando_toolkit.core.word_source import DOCUMENT, WordSource, w
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["NumberingLevel", "WordHeadings", "WordList", "WordListItem", "read_word_headings", "read_word_lists",
+           "record_word_headings", "resolve_word_headings", "restore_word_lists"]
+
+_HEADING_NAME = re.compile(r"^heading\s*([1-9])$", re.IGNORECASE)
+_DEFAULT_MAX_WORDS = 15
+_ORDERED_CLASSES = {"lowerLetter": "lower-alpha", "upperLetter": "upper-alpha", "lowerRoman": "lower-roman",
+                    "upperRoman": "upper-roman"}
+# Bullet characters (as typed, or as Symbol/Wingdings private-use codes) -> outputclass
+_BULLETS = {"\u2022": None, "\uf0b7": None, "\u00b7": None, "\u25cf": None,
+            "o": "bullet-circle", "\u25cb": "bullet-circle", "\u25e6": "bullet-circle",
+            "\u25aa": "bullet-square", "\u25a0": "bullet-square", "\uf0a7": "bullet-square", "\u00a7": "bullet-square",
+            "-": "bullet-dash", "\u2013": "bullet-dash", "\u2014": "bullet-dash",
+            "\u2713": "bullet-check", "\u2714": "bullet-check", "\uf0fc": "bullet-check", "\u00fc": "bullet-check",
+            "\u27a2": "bullet-arrow", "\u2192": "bullet-arrow", "\uf0d8": "bullet-arrow", "\u00d8": "bullet-arrow"}
+_LISTS = ("ul", "ol")
+
+
+def _val(el: Any) -> Optional[str]:
+    return el.get(w("val")) if el is not None else None
+
+
+def _int(value: Optional[str], default: Optional[int] = None) -> Optional[int]:
+    try:
+        return int(value) if value is not None else default
+    except ValueError:
+        return default
+
+
+@dataclass(frozen=True)
+class NumberingLevel:
+    """One level of a Word numbering definition."""
+    fmt: str = "decimal"
+    text: str = ""
+    start: int = 1
+    restart: Optional[int] = None       # w:lvlRestart (0: never)
+
+    @property
+    def ordered(self) -> bool:
+        return self.fmt not in ("bullet", "none")
+
+
+class _Numbering:
+    """Definitions of ``word/numbering.xml``."""
+
+    def __init__(self, root: Any) -> None:
+        self.abstract: Dict[str, Dict[int, NumberingLevel]] = {}
+        self.nums: Dict[str, str] = {}                               # numId -> abstractNumId
+        self.overrides: Dict[str, Dict[int, int]] = {}               # numId -> {ilvl: start}
+        self.override_levels: Dict[str, Dict[int, NumberingLevel]] = {}
+        for abstract in root.iter(w("abstractNum")) if root is not None else ():
+            self.abstract[abstract.get(w("abstractNumId")) or ""] = {
+                _int(lvl.get(w("ilvl")), 0): self._level(lvl) for lvl in abstract.iter(w("lvl"))}
+        for num in root.iter(w("num")) if root is not None else ():
+            num_id = num.get(w("numId")) or ""
+            self.nums[num_id] = _val(num.find(w("abstractNumId"))) or ""
+            for override in num.iter(w("lvlOverride")):
+                ilvl = _int(override.get(w("ilvl")), 0)
+                start = _int(_val(override.find(w("startOverride"))))
+                if start is not None:
+                    self.overrides.setdefault(num_id, {})[ilvl] = start
+                lvl = override.find(w("lvl"))
+                if lvl is not None:
+                    self.override_levels.setdefault(num_id, {})[ilvl] = self._level(lvl)
+
+    @staticmethod
+    def _level(lvl: Any) -> NumberingLevel:
+        return NumberingLevel(fmt=_val(lvl.find(w("numFmt"))) or "decimal", text=_val(lvl.find(w("lvlText"))) or "",
+                              start=_int(_val(lvl.find(w("start"))), 1),
+                              restart=_int(_val(lvl.find(w("lvlRestart")))))
+
+    def level(self, num_id: str, ilvl: int) -> Optional[NumberingLevel]:
+        override = self.override_levels.get(num_id, {}).get(ilvl)
+        if override is not None:
+            return override
+        return self.abstract.get(self.nums.get(num_id, ""), {}).get(ilvl)
+
+    def is_legal(self, num_id: str) -> bool:
+        """Whether *num_id* numbers an outline: its second level shows the first level's number."""
+        second = self.level(num_id, 1)
+        first = self.level(num_id, 0)
+        return (first is not None and second is not None and first.ordered and second.ordered
+                and "%1" in second.text and "%2" in second.text)
+
+
+class _Styles:
+    """Paragraph styles of ``word/styles.xml`` with their outline levels and numbering."""
+
+    def __init__(self, root: Any) -> None:
+        self.root = root
+        self.names: Dict[str, str] = {}
+        self.based_on: Dict[str, str] = {}
+        self.levels: Dict[str, int] = {}
+        self.numbering: Dict[str, Tuple[str, int]] = {}
+        for style in root.iter(w("style")) if root is not None else ():
+            style_id = style.get(w("styleId")) or ""
+            self.names[style_id] = _val(style.find(w("name"))) or style_id
+            based = _val(style.find(w("basedOn")))
+            if based:
+                self.based_on[style_id] = based
+            ppr = style.find(w("pPr"))
+            level = _int(_val(ppr.find(w("outlineLvl")))) if ppr is not None else None
+            if level is not None:
+                self.levels[style_id] = level
+            num = ppr.find(w("numPr")) if ppr is not None else None
+            if num is not None and _val(num.find(w("numId"))):
+                self.numbering[style_id] = (_val(num.find(w("numId"))) or "", _int(_val(num.find(w("ilvl"))), 0))
+
+    def _chain(self, style_id: str) -> List[str]:
+        chain: List[str] = []
+        while style_id and style_id not in chain:
+            chain.append(style_id)
+            style_id = self.based_on.get(style_id, "")
+        return chain
+
+    def outline_level(self, style_id: str) -> Optional[int]:
+        return next((self.levels[s] for s in self._chain(style_id) if s in self.levels), None)
+
+    def style_numbering(self, style_id: str) -> Optional[Tuple[str, int]]:
+        return next((self.numbering[s] for s in self._chain(style_id) if s in self.numbering), None)
+
+    def heading_level(self, style_id: str) -> Optional[int]:
+        """Level of a ``heading N`` style (the ones converters already split at)."""
+        match = _HEADING_NAME.match(self.names.get(style_id, "").strip())
+        return int(match.group(1)) if match else None
+
+    def heading_style(self, level: int) -> Tuple[str, bool]:
+        """Id of the ``heading <level>`` style, and whether it had to be added."""
+        existing = next((s for s in self.names if self.heading_level(s) == level), None)
+        if existing is not None:
+            return existing, False
+        style_id = f"Heading{level}" if f"Heading{level}" not in self.names else f"OtkHeading{level}"
+        style = ET.SubElement(self.root, w("style"), {w("type"): "paragraph", w("styleId"): style_id})
+        ET.SubElement(style, w("name"), {w("val"): f"heading {level}"})
+        ET.SubElement(style, w("basedOn"), {w("val"): "Normal"})
+        ET.SubElement(style, w("next"), {w("val"): "Normal"})
+        ET.SubElement(style, w("qFormat"))
+        ppr = ET.SubElement(style, w("pPr"))
+        ET.SubElement(ppr, w("keepNext"))
+        ET.SubElement(ppr, w("outlineLvl"), {w("val"): str(level - 1)})
+        self.names[style_id] = f"heading {level}"
+        return style_id, True
+
+
+def _owned(el: Any, paragraph: Any) -> bool:
+    """Whether *el* belongs to *paragraph* itself rather than a text box anchored in it."""
+    node = el.getparent()
+    while node is not None and node is not paragraph:
+        if node.tag in (w("txbxContent"), w("p")):
+            return False
+        node = node.getparent()
+    return True
+
+
+def _paragraph_text(paragraph: Any) -> str:
+    parts = [" " if el.tag == w("tab") else el.text or ""
+             for el in paragraph.iter() if el.tag in (w("t"), w("tab")) and _owned(el, paragraph)]
+    return normalize("".join(parts))
+
+
+def _paragraph_style(paragraph: Any) -> str:
+    ppr = paragraph.find(w("pPr"))
+    return (_val(ppr.find(w("pStyle"))) if ppr is not None else None) or ""
+
+
+def _paragraph_numbering(paragraph: Any, styles: _Styles) -> Optional[Tuple[str, int]]:
+    ppr = paragraph.find(w("pPr"))
+    num = ppr.find(w("numPr")) if ppr is not None else None
+    if num is not None:
+        num_id = _val(num.find(w("numId")))
+        if num_id is not None:
+            return (num_id, _int(_val(num.find(w("ilvl"))), 0)) if num_id != "0" else None
+    return styles.style_numbering(_paragraph_style(paragraph))
+
+
+def _read_parts(docx: WordSource) -> Tuple[_Styles, _Numbering]:
+    return _Styles(docx.part("word/styles.xml")), _Numbering(docx.part("word/numbering.xml"))
+
+
+# ----------------------------------------------------------------------
+# Headings
+# ----------------------------------------------------------------------
+
+@dataclass
+class WordHeadings:
+    """What :func:`resolve_word_headings` did; ``path`` is the file to convert."""
+    path: Path
+    promoted: List[Tuple[int, str, str]] = field(default_factory=list)   # (level, text, "outline_level"|"numbering")
+    added_styles: List[str] = field(default_factory=list)
+
+    def __bool__(self) -> bool:
+        return bool(self.promoted)
+
+
+def _detected_level(paragraph: Any, styles: _Styles, numbering: _Numbering, options: Mapping[str, Any],
+                    known: Mapping[str, Any]) -> Optional[Tuple[int, str]]:
+    style_id = _paragraph_style(paragraph)
+    if styles.heading_level(style_id) is not None or styles.names.get(style_id, style_id) in known:
+        return None
+    if options.get("outline_levels", True):
+        ppr = paragraph.find(w("pPr"))
+        level = _int(_val(ppr.find(w("outlineLvl")))) if ppr is not None else None
+        if level is None:
+            level = styles.outline_level(style_id)
+        if level is not None and level < 9:          # 9 is Word's "body text"
+            return level + 1, "outline_level"
+    if options.get("numbering", True):
+        num = _paragraph_numbering(paragraph, styles)
+        if num is not None and numbering.is_legal(num[0]) and num[1] < 9:
+            return num[1] + 1, "numbering"
+    return None
+
+
+def resolve_word_headings(path: str | Path | WordSource, options: Optional[Mapping[str, Any]],
+                          out_dir: Optional[str | Path] = None,
+                          style_map: Optional[Mapping[str, Any]] = None) -> WordHeadings:
+    """Give the numbered and outline paragraphs of the ``.docx`` *path* a heading style.
+
+    *options* is the ``headings`` section; paragraphs of a style the
+    *style_map* already makes a heading keep it. With *out_dir* a copy is
+    written there and becomes ``result.path``; without it the parts of a
+    shared :class:`WordSource` are edited for the caller to save.
+    ``result.path`` is the source itself when detection is off, the file is
+    not a Word package or no paragraph was promoted.
+    """
+    options = dict(options or {})
+    docx = WordSource.of(path)
+    result = WordHeadings(path=docx.path if docx is not None else Path(path))
+    if not (options.get("numbering", True) or options.get("outline_levels", True)) or docx is None:
+        return result
+    max_words = _int(str(options.get("max_words", _DEFAULT_MAX_WORDS)), _DEFAULT_MAX_WORDS)
+    if DOCUMENT not in docx or "word/styles.xml" not in docx:
+        return result
+    styles, numbering = _read_parts(docx)
+    body = docx.body
+    for paragraph in body.findall(w("p")) if body is not None else ():
+        text = _paragraph_text(paragraph)
+        if not text or (max_words and len(text.split()) > max_words):
+            continue
+        detected = _detected_level(paragraph, styles, numbering, options, style_map or {})
+        if detected is None:
+            continue
+        level, reason = detected
+        style_id, added = styles.heading_style(level)
+        if added:
+            result.added_styles.append(style_id)
+        ppr = paragraph.find(w("pPr"))
+        if ppr is None:
+            ppr = ET.Element(w("pPr"))
+            paragraph.insert(0, ppr)
+        pstyle = ppr.find(w("pStyle"))
+        if pstyle is None:
+            pstyle = ET.Element(w("pStyle"))
+            ppr.insert(0, pstyle)
+        pstyle.set(w("val"), style_id)
+        outline = ppr.find(w("outlineLvl"))
+        if outline is not None:
+            ppr.remove(outline)
+        result.promoted.append((level, text, reason))
+    if not result.promoted:
+        return result
+    docx.touch(DOCUMENT)
+    if result.added_styles:
+        docx.touch("word/styles.xml")
+    logger.info("Promoted %d numbered or outline paragraph(s) to headings in %s", len(result.promoted),
+                docx.path.name)
+    if out_dir is not None:
+        result.path = docx.save(Path(out_dir) / "headings")
+    return result
+
+
+def read_word_headings(path: str | Path, options: Optional[Mapping[str, Any]] = None,
+                       style_map: Optional[Mapping[str, int]] = None) -> List[Heading]:
+    """The headings of the ``.docx`` *path* in document order, as the plugin will see them.
+
+    Paragraphs with a ``heading N`` style or a style the *style_map* makes
+    a heading, and those :func:`resolve_word_headings` would promote
+    (with the name of the heading style they would get). Empty for files
+    that are not Word packages.
+    """
+    options = dict(options or {})
+    style_map = style_map or {}
+    docx = WordSource.of(path)   # its own copy: detection may add heading styles
+    if docx is None or DOCUMENT not in docx:
+        return []
+    max_words = _int(str(options.get("max_words", _DEFAULT_MAX_WORDS)), _DEFAULT_MAX_WORDS)
+    styles, numbering = _read_parts(docx)
+    body = docx.body
+    headings: List[Heading] = []
+    for paragraph in body.findall(w("p")) if body is not None else ():
+        text = _paragraph_text(paragraph)
+        if not text:
+            continue
+        style_id = _paragraph_style(paragraph)
+        name = styles.names.get(style_id, style_id)
+        level = styles.heading_level(style_id) or style_map.get(name)
+        if level is None and not (max_words and len(text.split()) > max_words):
+            detected = _detected_level(paragraph, styles, numbering, options, style_map)
+            if detected is not None:
+                level = detected[0]
+                name = styles.names[styles.heading_style(level)[0]]
+        if level is not None:
+            headings.append(Heading(level=int(level), title=text, style=name or None))
+    return headings
+
+
+def record_word_headings(context: Any, headings: Optional[WordHeadings], report: Any = None) -> int:
+    """Report the paragraphs :func:`resolve_word_headings` promoted; returns their number."""
+    if not headings:
+        return 0
+    report = report if report is not None else getattr(context, "report", None)
+    if report is not None:
+        by_reason = {reason: sum(1 for p in headings.promoted if p[2] == reason)
+                     for reason in ("numbering", "outline_level")}
+        report.info("headings", f"Detected {len(headings.promoted)} heading(s) without a Heading style "
+                                f"({by_reason['numbering']} from outline numbering, "
+                                f"{by_reason['outline_level']} from outline levels)",
+                    numbering=by_reason["numbering"], outline_levels=by_reason["outline_level"],
+                    titles=[text for _, text, _ in headings.promoted[:20]])
+    return len(headings.promoted)
+
+
+# ----------------------------------------------------------------------
+# Lists
+# ----------------------------------------------------------------------
+
+@dataclass(frozen=True)
+class WordListItem:
+    text: str
+    level: int
+    ordered: bool
+    number: int = 1
+    outputclass: Optional[str] = None
+    restart: bool = False           # numbering started again at this item
+
+
+@dataclass
+class WordList:
+    """Consecutive numbered or bulleted paragraphs of the source."""
+    items: List[WordListItem] = field(default_factory=list)
+
+
+def _bullet_class(level: NumberingLevel, bullets: Mapping[str, Any]) -> Optional[str]:
+    char = level.text.strip()[:1]
+    if char in bullets:
+        return str(bullets[char]) if bullets[char] else None
+    if char in _BULLETS:
+        return _BULLETS[char]
+    return "bullet-custom" if char else None
+
+
+def read_word_lists(path: str | Path | WordSource, options: Optional[Mapping[str, Any]] = None) -> List[WordList]:
+    """The lists of the ``.docx`` *path* in document order (list paragraphs of the body and table cells)."""
+    options = dict(options or {})
+    bullets = dict(options.get("bullets") or {})
+    docx = WordSource.of(path)
+    if docx is None or DOCUMENT not in docx:
+        return []
+    styles, numbering = _read_parts(docx)
+    lists: List[WordList] = []
+    counters: Dict[str, Dict[int, int]] = {}        # abstractNumId -> {ilvl: last number}
+    started: set = set()                             # (numId, ilvl) whose startOverride was applied
+    current: Optional[WordList] = None
+    previous_parent = None
+    lists_at: Dict[int, str] = {}                    # ilvl -> abstractNumId of the current list's items
+    for paragraph in docx.paragraphs:
+        if any(a.tag == w("txbxContent") for a in paragraph.iterancestors()):
+            continue
+        text = _paragraph_text(paragraph)
+        if not text:
+            continue
+        num = _paragraph_numbering(paragraph, styles)
+        style_id = _paragraph_style(paragraph)
+        level = numbering.level(*num) if num is not None else None
+        if (level is None or styles.heading_level(style_id) is not None or level.fmt == "none"
+                or numbering.is_legal(num[0])):
+            current = None
+            continue
+        if current is not None and paragraph.getparent() is not previous_parent:
+            current = None                                   # a table cell or the body around it
+        previous_parent = paragraph.getparent()
+        num_id, ilvl = num
+        abstract = numbering.nums.get(num_id, num_id)
+        levels = counters.setdefault(abstract, {})
+        if current is None:
+            lists_at = {}
+        restart = lists_at.get(ilvl, abstract) != abstract      # another list definition at this level
+        lists_at = {k: v for k, v in lists_at.items() if k < ilvl}
+        lists_at[ilvl] = abstract
+        override = numbering.overrides.get(num_id, {}).get(ilvl)
+        if override is not None and (num_id, ilvl) not in started:
+            started.add((num_id, ilvl))
+            number, restart = override, restart or ilvl in levels
+        elif ilvl in levels:
+            number = levels[ilvl] + 1
+        else:
+            number = level.start
+        levels[ilvl] = number
+        for deeper in [k for k in levels if k > ilvl]:
+            upper = numbering.level(num_id, deeper)
+            if upper is None or upper.restart != 0:
+                del levels[deeper]
+        outputclass = (_ORDERED_CLASSES.get(level.fmt) if level.ordered else _bullet_class(level, bullets))
+        if current is None:
+            current = WordList()
+            lists.append(current)
+        current.items.append(WordListItem(text=text, level=ilvl, ordered=level.ordered, number=number,
+                                          outputclass=outputclass, restart=restart))
+    return lists
+
+
+def _item_text(li: Any) -> str:
+    """Text of *li* without its nested lists and restored content."""
+    parts: List[str] = []
+
+    def _collect(node: Any) -> None:
+        parts.append(node.text or "")
+        for child in node:
+            if isinstance(child.tag, str) and child.tag not in RESTORED_TAGS and child.tag not in _LISTS + ("sl",):
+                _collect(child)
+            parts.append(child.tail or "")
+
+    _collect(li)
+    return normalize("".join(parts))
+
+
+def _list_items(context: Any) -> List[Tuple[str, Any, str]]:
+    items: List[Tuple[str, Any, str]] = []
+    for name in topic_order(context):
+        for li in context.topics[name].iter("li"):
+            if li.getparent() is not None and li.getparent().tag in _LISTS:
+                items.append((name, li, _item_text(li)))
+    return items
+
+
+def _top_list(li: Any) -> Any:
+    node = li.getparent()
+    while node.getparent() is not None and node.getparent().tag == "li" \
+            and node.getparent().getparent() is not None and node.getparent().getparent().tag in _LISTS:
+        node = node.getparent().getparent()
+    return node
+
+
+def _classes(item: WordListItem, first: bool) -> Optional[str]:
+    classes = [item.outputclass] if item.outputclass else []
+    if first and item.ordered and item.number != 1:
+        classes.append(f"start-{item.number}")
+    return " ".join(classes) or None
+
+
+def _rebuild(source: WordList, lis: List[Any]) -> Optional[Tuple[List[Any], Dict[str, int]]]:
+    """New top-level lists holding *lis* as *source* nests them, or None when the DITA lists hold more."""
+    roots: List[Any] = []
+    for li in lis:
+        root = _top_list(li)
+        if root not in roots:
+            roots.append(root)
+    parent = roots[0].getparent()
+    if parent is None or any(r.getparent() is not parent for r in roots):
+        return None
+    wanted = set(map(id, lis))
+    if any(id(li) not in wanted for root in roots for li in root.iter("li")):
+        return None
+    for li in lis:
+        for nested in [c for c in li if c.tag in _LISTS]:
+            li.remove(nested)
+    counts = {"restarts": 0, "custom_bullets": 0, "mixed": 0}
+    tops: List[Any] = []
+    stack: List[Tuple[int, Any]] = []
+    for item, li in zip(source.items, lis):
+        tag = "ol" if item.ordered else "ul"
+        while stack and stack[-1][0] > item.level:
+            stack.pop()
+        if stack and stack[-1][0] == item.level and (stack[-1][1].tag != tag or item.restart):
+            stack.pop()
+        if not stack or stack[-1][0] < item.level:
+            outputclass = _classes(item, True)
+            new = ET.Element(tag, outputclass=outputclass) if outputclass else ET.Element(tag)
+            counts["restarts"] += item.ordered and item.number != 1
+            counts["custom_bullets"] += not item.ordered and bool(item.outputclass)
+            if stack:
+                owner = stack[-1][1][-1]
+                counts["mixed"] += owner.getparent().tag != tag
+                owner.append(new)
+            else:
+                tops.append(new)
+            stack.append((item.level, new))
+        stack[-1][1].append(li)
+    position = parent.index(roots[0])
+    tail = roots[-1].tail
+    for root in roots:
+        parent.remove(root)
+    for offset, top in enumerate(tops):
+        parent.insert(position + offset, top)
+    tops[0].attrib.update({k: v for k, v in roots[0].attrib.items() if k not in ("outputclass",)})
+    tops[-1].tail = tail
+    return tops, counts
+
+
+def restore_word_lists(path: str | Path | WordSource, context: Any, options: Optional[Mapping[str, Any]] = None,
+                       report: Any = None) -> int:
+    """Rebuild the converted lists of *context* from the Word numbering of *path*; returns the lists rebuilt.
+
+    *options* is the ``lists`` section (``enabled``, ``bullets``).
+    """
+    options = dict(options or {})
+    docx = WordSource.of(path)
+    if not options.get("enabled", True) or docx is None:
+        return 0
+    try:
+        lists = read_word_lists(docx, options)
+    except Exception as exc:
+        logger.warning("Word lists unreadable in %s: %s", docx.path.name, exc)
+        return 0
+    lists = [lst for lst in lists if lst.items]
+    if not lists:
+        return 0
+    report = report if report is not None else getattr(context, "report", None)
+    items = _list_items(context)
+    cursor = rebuilt = unplaced = 0
+    totals = {"restarts": 0, "custom_bullets": 0, "mixed": 0}
+    for source in lists:
+        found: List[Any] = []
+        topic = None
+        position = cursor
+        for item in source.items:
+            hit = next((i for i in range(position, len(items)) if items[i][2] == item.text), None)
+            if hit is None or (topic is not None and items[hit][0] != topic):
+                break
+            topic = items[hit][0]
+            found.append(items[hit][1])
+            position = hit + 1
+        if len(found) != len(source.items) or len(set(map(id, found))) != len(found):
+            unplaced += 1
+            continue
+        cursor = position
+        outcome = _rebuild(source, found)
+        if outcome is None:
+            unplaced += 1
+            continue
+        rebuilt += 1
+        for key, value in outcome[1].items():
+            totals[key] += value
+    if report is not None and (rebuilt or unplaced):
+        report.info("lists", f"Rebuilt {rebuilt} list(s) from the Word numbering ({totals['restarts']} not starting "
+                             f"at 1, {totals['custom_bullets']} with custom bullets, {totals['mixed']} nested "
+                             "ordered/unordered)", lists=rebuilt, **totals)
+        if unplaced:
+            report.warning("lists", f"{unplaced} list(s) were left as converted: their items were not found together "
+                                    "in the converted topics")
+    return rebuilt
@@ -0,0 +1,168 @@
+from __future__ import annotations
+
+"""One parsed Word source shared by the passes that read it.
+
+About twenty passes look at a ``.docx`` around the plugin conversion:
+tracked changes, fields, headings and front matter are resolved in the
+source before the plugin runs, then tables, lists, notes, equations,
+media, comments… are restored from it. A :class:`WordSource` opens the
+package once for all of them:
+
+- :meth:`WordSource.part` parses an XML part on first use, through
+  :func:`~orlando_toolkit.core.xml_security.parse_bytes`, and
+  :meth:`WordSource.read` returns the bytes of any part;
+- :attr:`WordSource.document`, :attr:`WordSource.body` and
+  :attr:`WordSource.paragraphs` (every ``w:p`` of the main document in
+  document order, table cells and text boxes included) give the main
+  document;
+- :meth:`WordSource.relationships` resolves the relationship ids of a part;
+- passes that change the source edit the parsed parts and call
+  :meth:`WordSource.touch`; :meth:`WordSource.save` then writes a single
+  copy holding all their changes.
+
+:func:`w` and :data:`W_NS` name WordprocessingML elements; :data:`R_NS` and
+:data:`PKG_REL_NS` are the relationship namespaces. The passes still accept
+a path (scripts, tests, the preview): :meth:`WordSource.of` opens it for
+that one pass. A :class:`WordSource` is itself path-like (its current
+file), so ``Path(source).name`` works for both in messages.
+"""
+
+import logging
+import posixpath
+import zipfile
+from pathlib import Path
+from typing import Any, Dict, List, Optional, Set, Tuple
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.xml_security import parse_bytes
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["DOCUMENT", "PKG_REL_NS", "R_NS", "W_NS", "WordSource", "w"]
+
+W_NS = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+R_NS = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+PKG_REL_NS = "http://schemas.openxmlformats.org/package/2006/relationships"
+DOCUMENT = "word/document.xml"
+
+
+def w(tag: str) -> str:
+    """Clark name of the WordprocessingML element or attribute *tag*."""
+    return f"{{{W_NS}}}{tag}"
+
+
+class WordSource:
+    """The parts of the ``.docx`` at :attr:`path`, parsed once and shared."""
+
+    def __init__(self, path: str | Path) -> None:
+        self.path = Path(path)
+        with zipfile.ZipFile(self.path) as archive:
+            self.names: List[str] = archive.namelist()
+        self._names = set(self.names)
+        self._parts: Dict[str, Any] = {}
+        self._relationships: Dict[str, Dict[str, Tuple[str, bool]]] = {}
+        self._touched: Set[str] = set()
+        self._paragraphs: Optional[List[Any]] = None
+
+    @classmethod
+    def of(cls, source: "str | Path | WordSource") -> Optional["WordSource"]:
+        """*source* when it already is a :class:`WordSource`, else the package at that path.
+
+        ``None`` when the file is not a ZIP package.
+        """
+        if isinstance(source, WordSource):
+            return source
+        path = Path(source)
+        if not zipfile.is_zipfile(path):
+            return None
+        try:
+            return cls(path)
+        except (OSError, zipfile.BadZipFile) as exc:
+            logger.debug("Unreadable Word package %s: %s", path, exc)
+            return None
+
+    def __contains__(self, name: str) -> bool:
+        return name in self._names
+
+    def __fspath__(self) -> str:
+        return str(self.path)
+
+    def read(self, name: str) -> bytes:
+        """Bytes of the part *name*, with the edits of a touched part; ``KeyError`` when absent."""
+        if name in self._touched:
+            return ET.tostring(self._parts[name], xml_declaration=True, encoding="UTF-8", standalone=True)
+        with zipfile.ZipFile(self.path) as archive:
+            return archive.read(name)
+
+    def part(self, name: str) -> Optional[Any]:
+        """Root element of the XML part *name*, parsed on first use; ``None`` when there is no such part."""
+        if name not in self._names:
+            return None
+        if name not in self._parts:
+            self._parts[name] = parse_bytes(self.read(name), source=f"{self.path.name}!{name}")
+        return self._parts[name]
+
+    @property
+    def document(self) -> Optional[Any]:
+        return self.part(DOCUMENT)
+
+    @property
+    def body(self) -> Optional[Any]:
+        document = self.document
+        return document.find(w("body")) if document is not None else None
+
+    @property
+    def paragraphs(self) -> List[Any]:
+        """Every ``w:p`` of the main document in document order."""
+        if self._paragraphs is None:
+            document = self.document
+            self._paragraphs = list(document.iter(w("p"))) if document is not None else []
+        return self._paragraphs
+
+    def relationships(self, name: str) -> Dict[str, Tuple[str, bool]]:
+        """Relationship id -> ``(target, external)`` of the part *name*.
+
+        Internal targets are part names (resolved against the part's folder).
+        """
+        if name not in self._relationships:
+            folder, base = posixpath.split(name)
+            rels = self.part(posixpath.join(folder, "_rels", base + ".rels"))
+            targets: Dict[str, Tuple[str, bool]] = {}
+            for rel in rels.iter(f"{{{PKG_REL_NS}}}Relationship") if rels is not None else ():
+                target = rel.get("Target") or ""
+                if rel.get("TargetMode") == "External":
+                    targets[rel.get("Id") or ""] = (target, True)
+                else:
+                    targets[rel.get("Id") or ""] = (target.lstrip("/") if target.startswith("/") else
+                                                    posixpath.normpath(posixpath.join(folder, target)), False)
+            self._relationships[name] = targets
+        return self._relationships[name]
+
+    def touch(self, name: str) -> None:
+        """Record that the parsed part *name* was edited; :meth:`save` writes it."""
+        self._touched.add(name)
+        if name == DOCUMENT:
+            self._paragraphs = None
+
+    @property
+    def changed(self) -> bool:
+        return bool(self._touched)
+
+    def save(self, out_dir: str | Path) -> Path:
+        """Write the package with the touched parts into *out_dir* and read it from there on.
+
+        Returns :attr:`path` unchanged when no part was touched.
+        """
+        if not self._touched:
+            return self.path
+        target_dir = Path(out_dir)
+        target_dir.mkdir(parents=True, exist_ok=True)
+        target = target_dir / self.path.name
+        with zipfile.ZipFile(self.path) as archive, zipfile.ZipFile(target, "w", zipfile.ZIP_DEFLATED) as copy:
+            for info in archive.infolist():
+                name = info.filename
+                copy.writestr(info, self.read(name) if name in self._touched else archive.read(name))
+        self.path = target
+        self._touched.clear()
+        return target
@@ -0,0 +1,518 @@
+from __future__ import annotations
+
+"""XLIFF 2.1 translation packages.
+
+:func:`export_xliff` writes the translatable text of a document as one
+XLIFF 2.1 file a translation agency or CAT tool can work on:
+
+- one ``<file>`` per topic (``original`` is the topic file name) plus one
+  for the map (``original="map"``) with the manual title and navtitles;
+- one ``<unit>`` per block (titles, paragraphs, list items, table
+  entries, notes, steps, alt text, ...); ``name`` holds the path of the
+  block in its topic so the translation finds its way back;
+- sentences become ``<segment>`` elements (``segment=False`` keeps one
+  segment per block) and the spaces between them ``<ignorable>``;
+- inline markup is protected: elements with text become paired codes
+  (``<pc>``), empty elements, code phrases, ``translate="no"`` content and
+  nested blocks become placeholders (``<ph>``); the original tags are in
+  ``<originalData>`` for display only.
+
+:func:`import_xliff` merges the targets into a copy of the context with
+``xml:lang`` and ``metadata["language"]`` set to the target language. Codes
+are restored from the document being edited, so the translator can move
+them but not change them. Segments without a target keep the source text;
+blocks whose text changed since the export are left alone. Both are
+reported under ``translation`` in the copy's report.
+"""
+
+import copy
+import logging
+import re
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, Iterator, List, Optional, Tuple, Union
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.i18n import XML_LANG, canonical_language_tag, document_language
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.placement import topic_order
+from orlando_toolkit.core.xml_security import parse_bytes
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["XLIFF_NS", "XliffImport", "export_xliff", "import_xliff", "split_sentences"]
+
+XLIFF_NS = "urn:oasis:names:tc:xliff:document:2.0"
+_X = f"{{{XLIFF_NS}}}"
+_MAP_FILE = "map"
+_TITLE_UNIT = "metadata/manual_title"
+
+# Blocks translated as one unit; a block nested in another is a unit of its own
+_UNIT_TAGS = frozenset({
+    "title", "shortdesc", "navtitle", "searchtitle", "linktext", "p", "li", "sli", "dt", "dd", "entry",
+    "stentry", "note", "lq", "q", "fn", "cmd", "info", "stepresult", "steptroubleshooting", "tutorialinfo",
+    "choice", "alt", "desc", "glossterm", "glossdef", "lines", "indexterm",
+})
+# Inline content never offered for translation
+_PROTECTED = frozenset({"codeph", "codeblock", "pre", "filepath", "cmdname", "varname", "apiname", "msgph",
+                        "systemoutput", "userinput", "option", "parmname", "keyword", "draft-comment",
+                        "required-cleanup", "data", "data-about", "foreign", "unknown"})
+
+_ABBREVIATIONS = frozenset({"e.g", "i.e", "etc", "fig", "no", "vs", "approx", "ref", "incl", "max", "min",
+                            "mr", "mrs", "ms", "dr", "st", "p", "pp", "vol", "ch", "sec", "cf"})
+_BOUNDARY = re.compile(r"(?<=[.!?…])([\"'”’)\]]*)(\s+)(?=[\"'“‘(\[]?[A-Z0-9À-ɏ])")
+_TRAILING_STOP = re.compile(r"[.!?…][\"'”’)\]]*\s+$")
+
+
+@dataclass
+class _Code:
+    """An inline element of a block: a paired code with content or a placeholder."""
+
+    id: str
+    kind: str  # "pc" or "ph"
+    element: Any = None
+    content: List["_Item"] = field(default_factory=list)
+    can_delete: bool = True
+
+
+_Item = Union[str, _Code]
+
+
+@dataclass
+class XliffImport:
+    """Outcome of :func:`import_xliff`."""
+
+    context: DitaContext
+    language: str = ""
+    units: int = 0
+    translated: int = 0
+    untranslated: int = 0
+    skipped: List[str] = field(default_factory=list)
+    unknown: List[str] = field(default_factory=list)
+    missing_codes: int = 0
+
+
+# ----------------------------------------------------------------------
+# Reading blocks
+# ----------------------------------------------------------------------
+
+def _is_unit(el: Any) -> bool:
+    return (isinstance(el.tag, str) and el.tag in _UNIT_TAGS and el.get("translate") != "no"
+            and _has_text(el))
+
+
+def _has_text(el: Any) -> bool:
+    """Whether *el* has text of its own, outside nested blocks and protected content."""
+    if (el.text or "").strip():
+        return True
+    for child in el:
+        if (child.tail or "").strip():
+            return True
+        if (isinstance(child.tag, str) and child.tag not in _UNIT_TAGS and child.tag not in _PROTECTED
+                and child.get("translate") != "no" and _has_text(child)):
+            return True
+    return False
+
+
+def _contains_unit(el: Any) -> bool:
+    return any(_is_unit(d) for d in el.iterdescendants())
+
+
+def _codes(el: Any, counter: List[int]) -> List[_Item]:
+    """Content of *el* as text and codes; numbering follows document order."""
+    items: List[_Item] = []
+    if el.text:
+        items.append(el.text)
+    for child in el:
+        counter[0] += 1
+        code = _Code(id=str(counter[0]), kind="ph", element=child)
+        if not isinstance(child.tag, str):
+            pass
+        elif _is_unit(child) or child.tag in _UNIT_TAGS or _contains_unit(child):
+            code.can_delete = False
+        elif child.tag not in _PROTECTED and child.get("translate") != "no" and (
+                (child.text or "").strip() or len(child)):
+            code.kind = "pc"
+            code.content = _codes(child, counter)
+        items.append(code)
+        if child.tail:
+            items.append(child.tail)
+    return items
+
+
+def _code_index(items: List[_Item], index: Optional[Dict[str, _Code]] = None) -> Dict[str, _Code]:
+    index = {} if index is None else index
+    for item in items:
+        if isinstance(item, _Code):
+            index[item.id] = item
+            _code_index(item.content, index)
+    return index
+
+
+def _plain(items: List[_Item]) -> str:
+    parts: List[str] = []
+    for item in items:
+        parts.append(item if isinstance(item, str) else _plain(item.content))
+    return "".join(parts)
+
+
+def _units(root: Any) -> Iterator[Any]:
+    for el in root.iter():
+        if _is_unit(el):
+            yield el
+
+
+# ----------------------------------------------------------------------
+# Segmentation
+# ----------------------------------------------------------------------
+
+def _ends_sentence(text: str, match_end: int) -> bool:
+    word = text[:match_end].rstrip().rstrip("\"'”’)]").rstrip(".!?…").rsplit(None, 1)[-1:] or [""]
+    return word[0].lower().lstrip("(") not in _ABBREVIATIONS
+
+
+def split_sentences(text: str) -> List[str]:
+    """*text* cut after sentence-ending punctuation; the pieces keep their trailing spaces."""
+    pieces: List[str] = []
+    start = 0
+    for match in _BOUNDARY.finditer(text):
+        if _ends_sentence(text, match.start()):
+            pieces.append(text[start:match.end()])
+            start = match.end()
+    pieces.append(text[start:])
+    return [p for p in pieces if p]
+
+
+def _segments(items: List[_Item], segment: bool) -> List[Tuple[str, List[_Item]]]:
+    """``("segment"|"ignorable", items)`` covering *items*; codes are never split."""
+    parts: List[Tuple[str, List[_Item]]] = []
+    current: List[_Item] = []
+
+    def _close(space: str = "") -> None:
+        if current:
+            parts.append(("segment" if _has_content(current) else "ignorable", list(current)))
+            current.clear()
+        if space:
+            parts.append(("ignorable", [space]))
+
+    for index, item in enumerate(items):
+        if isinstance(item, _Code) or not segment:
+            current.append(item)
+            continue
+        pieces = split_sentences(item)
+        before_code = index + 1 < len(items)
+        for number, piece in enumerate(pieces):
+            body = piece.rstrip()
+            last = number == len(pieces) - 1
+            if last and not (before_code and _TRAILING_STOP.search(piece) and _ends_sentence(piece, len(body))):
+                current.append(piece)
+                continue
+            if body:
+                current.append(body)
+            _close(piece[len(body):])
+    _close()
+
+    if segment and parts:
+        # Spaces around the block are not translatable either
+        kind, first = parts[0]
+        if kind == "segment" and isinstance(first[0], str) and first[0] != first[0].lstrip():
+            space = first[0][:len(first[0]) - len(first[0].lstrip())]
+            first[0] = first[0].lstrip()
+            parts.insert(0, ("ignorable", [space]))
+        kind, last_items = parts[-1]
+        if kind == "segment" and isinstance(last_items[-1], str) and last_items[-1] != last_items[-1].rstrip():
+            space = last_items[-1][len(last_items[-1].rstrip()):]
+            last_items[-1] = last_items[-1].rstrip()
+            parts.append(("ignorable", [space]))
+    return [(kind, [i for i in part if i != ""]) for kind, part in parts]
+
+
+def _has_content(items: List[_Item]) -> bool:
+    return any(isinstance(i, _Code) or i.strip() for i in items)
+
+
+# ----------------------------------------------------------------------
+# Export
+# ----------------------------------------------------------------------
+
+def _start_tag(el: Any) -> str:
+    if not isinstance(el.tag, str):
+        return ET.tostring(el, encoding="unicode", with_tail=False)
+    attrs = "".join(f' {k}="{v}"' for k, v in el.attrib.items() if isinstance(k, str) and "}" not in k)
+    return f"<{el.tag}{attrs}>"
+
+
+def _write_items(parent: Any, items: List[_Item], data: Dict[str, str]) -> None:
+    last = None
+    for item in items:
+        if isinstance(item, str):
+            if last is None:
+                parent.text = (parent.text or "") + item
+            else:
+                last.tail = (last.tail or "") + item
+            continue
+        if item.kind == "pc":
+            start, end = f"d{len(data) + 1}", f"d{len(data) + 2}"
+            data[start], data[end] = _start_tag(item.element), f"</{item.element.tag}>"
+            last = ET.SubElement(parent, _X + "pc", id=item.id, dataRefStart=start, dataRefEnd=end)
+            _write_items(last, item.content, data)
+        else:
+            ref = f"d{len(data) + 1}"
+            el = item.element
+            data[ref] = _start_tag(el) if not isinstance(el.tag, str) or not len(el) and not el.text \
+                else f"{_start_tag(el)}…</{el.tag}>"
+            last = ET.SubElement(parent, _X + "ph", id=item.id, dataRef=ref)
+            if not item.can_delete:
+                last.set("canDelete", "no")
+
+
+def _add_unit(file_el: Any, unit_id: str, name: str, items: List[_Item], segment: bool) -> None:
+    unit = ET.SubElement(file_el, _X + "unit", id=unit_id, name=name)
+    data: Dict[str, str] = {}
+    original = ET.SubElement(unit, _X + "originalData")
+    number = 0
+    for kind, part in _segments(items, segment):
+        if kind == "segment":
+            number += 1
+            holder = ET.SubElement(unit, _X + "segment", id=f"s{number}", state="initial")
+        else:
+            holder = ET.SubElement(unit, _X + "ignorable")
+        _write_items(ET.SubElement(holder, _X + "source"), part, data)
+    if data:
+        for ref, value in data.items():
+            ET.SubElement(original, _X + "data", id=ref).text = value
+    else:
+        unit.remove(original)
+
+
+def _path(root: Any, el: Any) -> str:
+    """``/concept/conbody/p[2]``: *el* addressed from *root* by tag and position."""
+    steps: List[str] = []
+    while el is not None and el is not root:
+        parent = el.getparent()
+        same = [c for c in parent if c.tag == el.tag] if parent is not None else [el]
+        steps.append(f"{el.tag}[{same.index(el) + 1}]" if len(same) > 1 else el.tag)
+        el = parent
+    return "/" + "/".join([root.tag, *reversed(steps)])
+
+
+def _find(root: Any, path: str) -> Any:
+    """Inverse of :func:`_path`; ``None`` when the block is gone."""
+    steps = path.strip("/").split("/")
+    if root is None or not steps or steps[0] != root.tag:
+        return None
+    el = root
+    for step in steps[1:]:
+        match = re.fullmatch(r"([^\[\]]+)(?:\[(\d+)\])?", step)
+        if match is None:
+            return None
+        same = [c for c in el if c.tag == match.group(1)]
+        position = int(match.group(2) or 1)
+        if not 0 < position <= len(same):
+            return None
+        el = same[position - 1]
+    return el
+
+
+def _local(el: Any) -> str:
+    return el.tag.split("}")[-1] if isinstance(el.tag, str) else ""
+
+
+def export_xliff(context: DitaContext, path: str | Path, *, target_language: str,
+                 source_language: Optional[str] = None, segment: bool = True) -> int:
+    """Write the translatable text of *context* to the XLIFF 2.1 file *path*; returns the number of units."""
+    source = canonical_language_tag(source_language or document_language(context)) or "en-US"
+    target = canonical_language_tag(target_language)
+    if not target:
+        raise ValueError("A target language is required")
+    root = ET.Element(_X + "xliff", nsmap={None: XLIFF_NS}, version="2.1", srcLang=source, trgLang=target)
+    count = 0
+
+    map_file = ET.SubElement(root, _X + "file", id="f1", original=_MAP_FILE)
+    title = str(context.metadata.get("manual_title") or "").strip()
+    if title:
+        _add_unit(map_file, "u1", _TITLE_UNIT, [title], False)
+        count += 1
+    if context.ditamap_root is not None:
+        for el in _units(context.ditamap_root):
+            if el.tag == "title" and el.getparent() is context.ditamap_root:
+                continue  # the map title is written from the manual title
+            count += 1
+            _add_unit(map_file, f"u{count}", _path(context.ditamap_root, el), _codes(el, [0]), segment)
+    if not len(map_file):
+        root.remove(map_file)
+
+    for number, name in enumerate(topic_order(context), start=2):
+        topic = context.topics[name]
+        file_el = ET.SubElement(root, _X + "file", id=f"f{number}", original=name)
+        for index, el in enumerate(_units(topic), start=1):
+            _add_unit(file_el, f"u{index}", _path(topic, el), _codes(el, [0]), segment)
+            count += 1
+        if not len(file_el):
+            root.remove(file_el)
+
+    # Not pretty-printed: indentation would add text to sources made only of codes
+    Path(path).write_bytes(ET.tostring(root, xml_declaration=True, encoding="UTF-8"))
+    logger.info("XLIFF export: %d unit(s) for %s to %s", count, source, target)
+    return count
+
+
+# ----------------------------------------------------------------------
+# Import
+# ----------------------------------------------------------------------
+
+def _read_items(el: Any) -> List[Any]:
+    """Content of an XLIFF ``<source>``/``<target>``: text, ``("pc", id, items)`` and ``("ph", id)``."""
+    items: List[Any] = []
+    if el.text:
+        items.append(el.text)
+    for child in el:
+        tag = _local(child)
+        if tag == "pc":
+            items.append(("pc", child.get("id"), _read_items(child)))
+        elif tag == "ph":
+            items.append(("ph", child.get("id")))
+        elif tag == "cp":
+            try:
+                items.append(chr(int(child.get("hex") or "", 16)))
+            except ValueError:
+                pass
+        elif tag:  # <mrk>, <sm/>, ... : keep the text
+            items.extend(_read_items(child))
+        if child.tail:
+            items.append(child.tail)
+    return items
+
+
+def _read_plain(items: List[Any]) -> str:
+    return "".join(i if isinstance(i, str) else _read_plain(i[2]) if i[0] == "pc" else "" for i in items)
+
+
+def _unit_content(unit: Any) -> Tuple[List[Any], List[Any], int, int]:
+    """Source items, merged target items, translated and untranslated segment counts."""
+    source: List[Any] = []
+    target: List[Any] = []
+    translated = untranslated = 0
+    for part in unit:
+        tag = _local(part)
+        if tag not in ("segment", "ignorable"):
+            continue
+        src = part.find(_X + "source")
+        tgt = part.find(_X + "target")
+        src_items = _read_items(src) if src is not None else []
+        source.extend(src_items)
+        if tgt is not None and (tag == "ignorable" or _read_plain(_read_items(tgt)).strip() or len(tgt)):
+            target.extend(_read_items(tgt))
+            translated += tag == "segment"
+        else:
+            target.extend(src_items)
+            untranslated += tag == "segment"
+    return source, target, translated, untranslated
+
+
+def _fill(el: Any, items: List[Any], codes: Dict[str, _Code], used: set) -> None:
+    for child in list(el):
+        el.remove(child)
+    el.text = None
+    last = None
+    for item in items:
+        if isinstance(item, str):
+            if last is None:
+                el.text = (el.text or "") + item
+            else:
+                last.tail = (last.tail or "") + item
+            continue
+        code = codes.get(item[1] or "")
+        if code is None or code.id in used:
+            continue
+        used.add(code.id)
+        el.append(code.element)
+        code.element.tail = None
+        last = code.element
+        if code.kind == "pc":
+            _fill(code.element, item[2] if item[0] == "pc" else [], codes, used)
+
+
+def _normalized(text: str) -> str:
+    return " ".join(text.split())
+
+
+def _set_language(context: DitaContext, language: str) -> None:
+    context.metadata["language"] = language
+    for root in [context.ditamap_root, *context.topics.values()]:
+        if root is not None:
+            root.set(XML_LANG, language)
+
+
+def import_xliff(context: DitaContext, path: str | Path) -> XliffImport:
+    """Merge the translated XLIFF *path* into a copy of *context*."""
+    path = Path(path)
+    document = parse_bytes(path.read_bytes(), source=path.name)
+    if not isinstance(document.tag, str) or not document.tag.startswith(_X):
+        raise ValueError(f"{path.name} is not an XLIFF 2 file")
+    translated = copy.deepcopy(context)
+    language = canonical_language_tag(document.get("trgLang")) or ""
+    result = XliffImport(context=translated, language=language)
+
+    pending: List[Tuple[Any, Any, str]] = []
+    for file_el in document.iter(_X + "file"):
+        original = file_el.get("original") or ""
+        root = translated.ditamap_root if original == _MAP_FILE else translated.topics.get(original)
+        for unit in file_el.iter(_X + "unit"):
+            result.units += 1
+            name = unit.get("name") or ""
+            label = f"{original}#{unit.get('id')}"
+            if original == _MAP_FILE and name == _TITLE_UNIT:
+                pending.append((unit, None, label))
+                continue
+            el = _find(root, name)
+            if el is None or not _is_unit(el):
+                result.unknown.append(label)
+                continue
+            pending.append((unit, el, label))
+
+    # Resolve every block first: restoring codes moves nested blocks
+    for unit, el, label in pending:
+        source, target, done, todo = _unit_content(unit)
+        if el is None:
+            if _read_plain(target).strip():
+                translated.metadata["manual_title"] = _normalized(_read_plain(target))
+            result.translated += done
+            result.untranslated += todo
+            continue
+        items = _codes(el, [0])
+        if _normalized(_plain(items)) != _normalized(_read_plain(source)):
+            result.skipped.append(label)
+            continue
+        codes = _code_index(items)
+        used: set = set()
+        _fill(el, target, codes, used)
+        for code_id, code in codes.items():
+            if code_id in used:
+                continue
+            result.missing_codes += 1
+            if not code.can_delete:
+                el.append(code.element)
+                code.element.tail = None
+        result.translated += done
+        result.untranslated += todo
+
+    if language:
+        _set_language(translated, language)
+    report = translated.report
+    report.info("translation", f"XLIFF import ({language or 'unknown language'}): {result.translated} segment(s) "
+                f"translated in {result.units} unit(s)", file=path.name)
+    if result.untranslated:
+        report.warning("translation", f"{result.untranslated} segment(s) have no translation and keep the "
+                       "source text")
+    if result.skipped:
+        report.warning("translation", f"{len(result.skipped)} block(s) changed since the export were not "
+                       f"translated: {', '.join(result.skipped[:10])}", units=result.skipped)
+    if result.unknown:
+        report.warning("translation", f"{len(result.unknown)} unit(s) match no block of the document",
+                       units=result.unknown)
+    if result.missing_codes:
+        report.warning("translation", f"{result.missing_codes} inline code(s) missing from the translation",
+                       hint="Nested blocks were kept at the end of their parent; formatting was dropped")
+    return result
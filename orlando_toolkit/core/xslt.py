@@ -0,0 +1,243 @@
+from __future__ import annotations
+
+"""Customer XSLT stylesheets applied to the package before it is written.
+
+Structural tweaks specific to one customer are kept out of the code: the
+``xslt`` conversion options (usually set by a conversion profile, under
+``conversion_options``) list stylesheets that
+:meth:`ConversionService.prepare_package` applies, in order, to every topic
+and optionally to the map once the package is otherwise final::
+
+    xslt:
+      on_error: report          # report: keep the file unchanged; fail: stop packaging (OTK430)
+      stylesheets:
+        - corp/notes.xsl        # relative to the user configuration folder
+        - path: corp/map.xsl
+          topics: false
+          map: true
+          parameters: {brand: ACME}
+
+An entry may give the stylesheet text itself as ``source`` instead of a
+``path``; :func:`inline_stylesheets` does this for exported profiles.
+
+The stylesheet's ``version`` attribute picks the processor: XSLT 1.0 runs
+in-process (lxml, no file writes or network access), 2.0 and 3.0 with the
+``saxon`` external tool (``security.yml`` ``external_tools``) in a private
+workspace. A stylesheet failing on a file is reported under ``xslt`` with
+the file and stylesheet names; the file keeps its content from before that
+stylesheet.
+"""
+
+import copy
+import logging
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.errors import SourceLocation, ToolkitError
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.xml_security import parse_bytes
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "Stylesheet",
+    "XsltError",
+    "apply_stylesheets",
+    "inline_stylesheets",
+    "load_stylesheets",
+]
+
+_TOOL = "saxon"
+_TOOL_ARGS = ("-s:{input}", "-xsl:{stylesheet}", "-o:{output}")
+_ON_ERROR = ("report", "fail")
+
+
+class XsltError(ToolkitError):
+    """A stylesheet failed and ``xslt.on_error`` is ``fail``."""
+
+    code = "OTK430"
+
+
+@dataclass
+class Stylesheet:
+    name: str
+    source: bytes
+    topics: bool = True
+    map: bool = False
+    parameters: Dict[str, str] = field(default_factory=dict)
+    version: str = "1.0"
+
+    @property
+    def native(self) -> bool:
+        """Whether lxml can run it (XSLT 1.0)."""
+        return self.version.split(".", 1)[0] in ("", "1")
+
+
+def _entry(item: Any) -> Dict[str, Any]:
+    if isinstance(item, (str, Path)):
+        return {"path": str(item)}
+    if isinstance(item, Mapping):
+        return dict(item)
+    raise ValueError(f"expected a path or a mapping, got {type(item).__name__}")
+
+
+def _resolve(name: str, base: Optional[Path]) -> Path:
+    if base is None:
+        from orlando_toolkit.options import _profile_file
+
+        return _profile_file(name)
+    path = Path(name).expanduser()
+    return path if path.is_absolute() else base / path
+
+
+def load_stylesheets(options: Optional[Mapping[str, Any]], *, base: Optional[Path] = None,
+                     report: Any = None) -> List[Stylesheet]:
+    """The stylesheets of the ``xslt`` options, in order.
+
+    Relative paths are read from *base* (default: the user configuration
+    folder). Entries that cannot be read or parsed are reported and left out.
+    """
+    if not isinstance(options, Mapping) or options.get("enabled", True) is False:
+        return []
+    sheets: List[Stylesheet] = []
+    for index, item in enumerate(options.get("stylesheets") or (), 1):
+        name = f"stylesheet {index}"
+        try:
+            entry = _entry(item)
+            if entry.get("source"):
+                source = str(entry["source"]).encode("utf-8")
+                name = str(entry.get("name") or name)
+            else:
+                path = _resolve(str(entry["path"]), base)
+                name = path.name
+                source = path.read_bytes()
+            root = parse_bytes(source, source=name)
+        except Exception as exc:
+            if report is not None:
+                report.error("xslt", f"Stylesheet {name} could not be loaded: {exc}", stylesheet=name)
+            logger.warning("Stylesheet %s could not be loaded: %s", name, exc)
+            continue
+        parameters = entry.get("parameters") or {}
+        sheets.append(Stylesheet(name=name, source=source, topics=bool(entry.get("topics", True)),
+                                 map=bool(entry.get("map", False)),
+                                 parameters={str(k): str(v) for k, v in parameters.items()},
+                                 version=str(root.get("version") or "1.0")))
+    return sheets
+
+
+def inline_stylesheets(options: Optional[Mapping[str, Any]], *, base: Optional[Path] = None) -> Dict[str, Any]:
+    """Copy of conversion *options* with each ``xslt`` stylesheet file read into ``source``.
+
+    Used so exported and imported profiles do not depend on files of one machine.
+    """
+    result = copy.deepcopy(dict(options or {}))
+    xslt = result.get("xslt")
+    if not isinstance(xslt, dict) or not xslt.get("stylesheets"):
+        return result
+    entries = []
+    for item in xslt["stylesheets"]:
+        entry = _entry(item)
+        if not entry.get("source") and entry.get("path"):
+            path = _resolve(str(entry.pop("path")), base)
+            entry.setdefault("name", path.name)
+            entry["source"] = path.read_text(encoding="utf-8")
+        entries.append(entry)
+    xslt["stylesheets"] = entries
+    return result
+
+
+class _Processor:
+    """Runs stylesheets; the external tool workspace is created on first use."""
+
+    def __init__(self, options: Mapping[str, Any]) -> None:
+        self.tool = str(options.get("tool") or _TOOL)
+        self.args = [str(a) for a in options.get("args") or _TOOL_ARGS]
+        self.timeout = options.get("timeout")
+        self._compiled: Dict[str, Any] = {}
+        self._executor = None
+
+    def transform(self, sheet: Stylesheet, element: ET._Element, name: str) -> ET._Element:
+        if sheet.native:
+            return self._native(sheet, element)
+        return self._external(sheet, element, name)
+
+    def _native(self, sheet: Stylesheet, element: ET._Element) -> ET._Element:
+        transform = self._compiled.get(sheet.name)
+        if transform is None:
+            access = ET.XSLTAccessControl(read_file=True, write_file=False, create_dir=False,
+                                          read_network=False, write_network=False)
+            transform = ET.XSLT(parse_bytes(sheet.source, source=sheet.name), access_control=access)
+            self._compiled[sheet.name] = transform
+        params = {key: ET.XSLT.strparam(value) for key, value in sheet.parameters.items()}
+        result = transform(ET.ElementTree(copy.deepcopy(element)), **params)
+        root = result.getroot()
+        if root is None:
+            raise ValueError("the stylesheet produced no element")
+        return root
+
+    def _external(self, sheet: Stylesheet, element: ET._Element, name: str) -> ET._Element:
+        from orlando_toolkit.core.external_tools import ToolExecutor
+
+        if self._executor is None:
+            self._executor = ToolExecutor()
+        with self._executor.workspace() as workspace:
+            (workspace.path / "input.xml").write_bytes(ET.tostring(element, encoding="utf-8", xml_declaration=True))
+            (workspace.path / "stylesheet.xsl").write_bytes(sheet.source)
+            args = [a.format(input="input.xml", stylesheet="stylesheet.xsl", output="output.xml") for a in self.args]
+            args += [f"{key}={value}" for key, value in sheet.parameters.items()]
+            kwargs = {"timeout": float(self.timeout)} if self.timeout else {}
+            self._executor.run(self.tool, args, workspace=workspace, check=True, **kwargs)
+            return parse_bytes((workspace.path / "output.xml").read_bytes(), source=name)
+
+
+def apply_stylesheets(context: DitaContext, options: Optional[Mapping[str, Any]] = None,
+                      *, base: Optional[Path] = None) -> int:
+    """Apply the ``xslt`` stylesheets to the topics and map of *context*.
+
+    Returns the number of files changed. Failures are reported per file and
+    stylesheet; with ``on_error: fail`` the first one raises :class:`XsltError`.
+    """
+    report = getattr(context, "report", None)
+    sheets = load_stylesheets(options, base=base, report=report)
+    if not sheets:
+        return 0
+    on_error = str(options.get("on_error") or "report").lower()
+    if on_error not in _ON_ERROR:
+        logger.warning("Unknown xslt.on_error %r (expected %s)", on_error, ", ".join(_ON_ERROR))
+        on_error = "report"
+    processor = _Processor(options)
+    changed = set()
+
+    def _run(sheet: Stylesheet, element: ET._Element, name: str) -> Optional[ET._Element]:
+        try:
+            return processor.transform(sheet, element, name)
+        except Exception as exc:
+            if on_error == "fail":
+                raise XsltError(f"Stylesheet {sheet.name} failed on {name}: {exc}",
+                                location=SourceLocation(file=sheet.name, topic=name), cause=exc) from exc
+            if report is not None:
+                report.error("xslt", f"Stylesheet {sheet.name} failed, {name} left unchanged: {exc}",
+                             topic=name, stylesheet=sheet.name)
+            logger.warning("Stylesheet %s failed on %s: %s", sheet.name, name, exc)
+            return None
+
+    for sheet in sheets:
+        if sheet.topics:
+            for name, topic in list(context.topics.items()):
+                result = _run(sheet, topic, name)
+                if result is not None:
+                    context.topics[name] = result
+                    changed.add(name)
+        if sheet.map and context.ditamap_root is not None:
+            result = _run(sheet, context.ditamap_root, "map")
+            if result is not None:
+                context.ditamap_root = result
+                changed.add("map")
+
+    if report is not None:
+        report.info("xslt", f"Applied {len(sheets)} stylesheet(s) to {len(changed)} file(s)",
+                    stylesheets=[s.name for s in sheets], files=len(changed))
+    return len(changed)
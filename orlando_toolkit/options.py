@@ -0,0 +1,329 @@
+from __future__ import annotations
+
+"""Conversion options for the library API.
+
+A conversion is configured by composing option functions instead of filling
+one large metadata dictionary by hand::
+
+    from orlando_toolkit import convert, with_output_profile, with_style_map, with_title
+
+    convert("manual.docx",
+            with_output_profile("stable"),
+            with_style_map({"Chapter": 1, "Section": 2}),
+            with_title("Operator Manual"))
+
+Options apply in order, later ones overriding earlier ones (nested settings
+are merged). Output profiles are named bundles from ``profiles.yml`` or
+saved profile files (:mod:`orlando_toolkit.core.profiles`) and may
+``extend`` other profiles; a profile name (or :class:`OutputProfile`) given
+where an option is expected means ``with_output_profile(name)``, so
+``convert("manual.docx", "stable")`` works. A style map can also come from a YAML or JSON file
+(:func:`with_style_map_file`, ``style_map_file`` in a profile or job file).
+
+The dictionaries used so far keep working: a plain metadata mapping passed
+where an option is expected is read by :meth:`ConversionOptions.from_metadata`
+(``conversion_options``, ``pipeline`` and ``style_map`` keys included), and
+:meth:`ConversionOptions.load` reads the same shapes from a YAML or JSON job
+file. :meth:`ConversionOptions.to_metadata` produces the metadata dictionary
+handlers and processing stages receive.
+"""
+
+import copy
+import json
+import logging
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Any, Callable, Dict, Iterable, List, Mapping, Optional, Tuple, Union
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "ConversionOptions",
+    "Option",
+    "OutputProfile",
+    "build_options",
+    "get_output_profile",
+    "resolve_style_map",
+    "with_metadata",
+    "with_options",
+    "with_output_profile",
+    "with_pipeline",
+    "with_stage",
+    "with_style_map",
+    "with_style_map_file",
+    "with_title",
+    "with_topic_depth",
+]
+
+Option = Callable[["ConversionOptions"], None]
+OptionLike = Union[Option, "ConversionOptions", Mapping[str, Any], str, "OutputProfile"]
+
+# Metadata keys holding nested settings rather than document fields.
+_SECTIONS = ("conversion_options", "pipeline", "style_map")
+
+
+def _merge(base: Dict[str, Any], override: Mapping[str, Any]) -> Dict[str, Any]:
+    for key, value in override.items():
+        if isinstance(value, Mapping) and isinstance(base.get(key), dict):
+            _merge(base[key], value)
+        else:
+            base[key] = copy.deepcopy(value)
+    return base
+
+
+@dataclass(frozen=True)
+class OutputProfile:
+    """Named bundle of settings from ``profiles.yml`` or a profile file."""
+
+    name: str
+    description: str = ""
+    extends: Tuple[str, ...] = ()
+    metadata: Mapping[str, Any] = field(default_factory=dict)
+    conversion_options: Mapping[str, Any] = field(default_factory=dict)
+    pipeline: Mapping[str, Any] = field(default_factory=dict)
+    style_map: Mapping[str, Any] = field(default_factory=dict)
+    style_map_file: Optional[str] = None
+
+    @classmethod
+    def from_mapping(cls, name: str, data: Optional[Mapping[str, Any]]) -> "OutputProfile":
+        data = data or {}
+        extends = data.get("extends") or ()
+        if isinstance(extends, str):
+            extends = (extends,)
+        return cls(
+            name=name,
+            description=str(data.get("description") or ""),
+            extends=tuple(str(v) for v in extends),
+            metadata=dict(data.get("metadata") or {}),
+            conversion_options=dict(data.get("conversion_options") or {}),
+            pipeline=dict(data.get("pipeline") or {}),
+            style_map=dict(data.get("style_map") or {}),
+            style_map_file=str(data["style_map_file"]) if data.get("style_map_file") else None,
+        )
+
+
+def get_output_profile(name: str) -> OutputProfile:
+    """Return the profile *name* from ``profiles.yml`` or the saved profile files; raises ``ValueError``.
+
+    *name* may also be the path of a YAML or JSON profile file
+    (:mod:`orlando_toolkit.core.profiles`).
+    """
+    from orlando_toolkit.core.profiles import PROFILE_SUFFIXES, available_profiles, read_profile_file
+
+    path = Path(name).expanduser()
+    if path.suffix.lower() in PROFILE_SUFFIXES and path.is_file():
+        try:
+            return OutputProfile.from_mapping(*read_profile_file(path))
+        except OSError as exc:
+            raise ValueError(f"Cannot read profile file {path}: {exc}") from exc
+    profiles = available_profiles()
+    if name not in profiles:
+        known = ", ".join(sorted(profiles)) or "none"
+        raise ValueError(f"Unknown output profile {name!r} (known: {known})")
+    return OutputProfile.from_mapping(name, profiles[name])
+
+
+@dataclass
+class ConversionOptions:
+    """Settings for one conversion, built from option functions."""
+
+    metadata: Dict[str, Any] = field(default_factory=dict)
+    conversion_options: Dict[str, Any] = field(default_factory=dict)
+    pipeline: Dict[str, Any] = field(default_factory=dict)
+    style_map: Dict[str, Any] = field(default_factory=dict)
+    profiles: List[str] = field(default_factory=list)
+
+    def apply(self, *options: OptionLike) -> "ConversionOptions":
+        for option in options:
+            if option is None:
+                continue
+            if isinstance(option, (ConversionOptions, Mapping)):
+                with_options(option)(self)
+            elif isinstance(option, (str, OutputProfile)):
+                with_output_profile(option)(self)
+            elif callable(option):
+                option(self)
+            else:
+                raise TypeError(f"Not a conversion option: {option!r}")
+        return self
+
+    def to_metadata(self) -> Dict[str, Any]:
+        """Return the metadata dictionary passed to ``ConversionService.convert``."""
+        metadata = copy.deepcopy(self.metadata)
+        for key in _SECTIONS:
+            value = getattr(self, key)
+            if value:
+                metadata[key] = copy.deepcopy(value)
+        if self.profiles:
+            metadata["output_profiles"] = list(self.profiles)
+        return metadata
+
+    @classmethod
+    def from_metadata(cls, metadata: Optional[Mapping[str, Any]]) -> "ConversionOptions":
+        """Read a legacy metadata mapping (nested sections included)."""
+        options = cls()
+        for key, value in (metadata or {}).items():
+            if key in _SECTIONS and isinstance(value, Mapping):
+                _merge(getattr(options, key), value)
+            elif key == "output_profiles":
+                options.profiles.extend(str(v) for v in value or ())
+            else:
+                options.metadata[key] = copy.deepcopy(value)
+        return options
+
+    @classmethod
+    def load(cls, path: str | Path) -> "ConversionOptions":
+        """Read a YAML or JSON job file.
+
+        The file holds either a legacy metadata mapping or the structured
+        form ``{profile, metadata, conversion_options, pipeline, style_map}``
+        where ``profile`` is a name or a list of names applied first. A
+        ``style_map_file`` (relative to the job file) applies before
+        ``style_map``.
+        """
+        path = Path(path)
+        text = path.read_text(encoding="utf-8")
+        if path.suffix.lower() == ".json":
+            data = json.loads(text)
+        else:
+            import yaml  # type: ignore
+
+            data = yaml.safe_load(text)
+        if not isinstance(data, Mapping):
+            raise ValueError(f"{path.name}: expected a mapping of options")
+        data = dict(data)
+        profiles = data.pop("profile", None) or ()
+        if isinstance(profiles, str):
+            profiles = (profiles,)
+        nested = data.pop("metadata", None)
+        style_map_file = data.pop("style_map_file", None)
+        options = cls()
+        options.apply(*(with_output_profile(name) for name in profiles))
+        if style_map_file:
+            options.apply(with_style_map_file(path.parent / str(style_map_file)))
+        options.apply(cls.from_metadata(data))
+        if isinstance(nested, Mapping):
+            options.apply(cls.from_metadata(nested))
+        return options
+
+
+def build_options(*options: OptionLike) -> ConversionOptions:
+    """Compose *options* in order into a :class:`ConversionOptions`."""
+    return ConversionOptions().apply(*options)
+
+
+def with_options(options: Union[ConversionOptions, Mapping[str, Any]]) -> Option:
+    """Merge existing options or a legacy metadata mapping."""
+    def _apply(target: ConversionOptions) -> None:
+        source = options if isinstance(options, ConversionOptions) else ConversionOptions.from_metadata(options)
+        _merge(target.metadata, source.metadata)
+        for key in _SECTIONS:
+            _merge(getattr(target, key), getattr(source, key))
+        target.profiles.extend(p for p in source.profiles if p not in target.profiles)
+    return _apply
+
+
+def with_metadata(**fields: Any) -> Option:
+    """Set document metadata fields (``manual_code``, ``revision_date``, …)."""
+    def _apply(target: ConversionOptions) -> None:
+        _merge(target.metadata, fields)
+    return _apply
+
+
+def with_title(title: str) -> Option:
+    return with_metadata(manual_title=title)
+
+
+def with_topic_depth(depth: int) -> Option:
+    """Limit the topic hierarchy to *depth* heading levels."""
+    if int(depth) < 1:
+        raise ValueError("topic depth must be at least 1")
+    return with_metadata(topic_depth=int(depth))
+
+
+def with_style_map(mapping: Mapping[str, Any]) -> Option:
+    """Map source style names to heading levels or DITA elements, over ``default_style_map.yml``.
+
+    Values are those of :mod:`orlando_toolkit.core.style_map` (``1``,
+    ``"uicontrol"``, ``{"element": "note", "type": "warning"}``, …); an
+    invalid entry raises ``ValueError``.
+    """
+    from orlando_toolkit.core.style_map import parse_style_rule
+
+    entries: Dict[str, Any] = {}
+    for style, value in mapping.items():
+        rule = parse_style_rule(str(style), value)
+        level_only = rule.heading is not None and not isinstance(value, Mapping)
+        entries[str(style)] = rule.heading if level_only else copy.deepcopy(value)
+
+    def _apply(target: ConversionOptions) -> None:
+        target.style_map.update(entries)
+    return _apply
+
+
+def with_style_map_file(path: str | Path) -> Option:
+    """Apply the style map of a YAML or JSON file (entries at the top level or under ``styles``)."""
+    from orlando_toolkit.core.style_map import load_style_map_file
+
+    return with_style_map(load_style_map_file(path))
+
+
+def with_stage(name: str, **settings: Any) -> Option:
+    """Override one ``conversion.yml`` section, e.g. ``with_stage("bidi", detect_direction=False)``."""
+    def _apply(target: ConversionOptions) -> None:
+        _merge(target.conversion_options.setdefault(name, {}), settings)
+    return _apply
+
+
+def with_pipeline(**settings: Any) -> Option:
+    """Override ``pipeline.yml`` settings (worker pools, memory budget)."""
+    def _apply(target: ConversionOptions) -> None:
+        _merge(target.pipeline, settings)
+    return _apply
+
+
+def _profile_chain(profile: OutputProfile, seen: Tuple[str, ...] = ()) -> Iterable[OutputProfile]:
+    seen = seen + (profile.name,)
+    for parent in profile.extends:
+        if parent in seen:
+            raise ValueError(f"Output profile {parent!r} extends itself ({' -> '.join(seen + (parent,))})")
+        yield from _profile_chain(get_output_profile(parent), seen)
+    yield profile
+
+
+def _profile_file(name: str) -> Path:
+    """A profile's file path; relative paths are under the user configuration folder."""
+    path = Path(name).expanduser()
+    if path.is_absolute():
+        return path
+    from orlando_toolkit.config.manager import _get_user_config_dir
+
+    return _get_user_config_dir() / path
+
+
+def with_output_profile(profile: Union[str, OutputProfile]) -> Option:
+    """Apply a named output profile (and the profiles it extends)."""
+    resolved = get_output_profile(profile) if isinstance(profile, str) else profile
+
+    def _apply(target: ConversionOptions) -> None:
+        for item in _profile_chain(resolved):
+            if item.style_map_file:
+                with_style_map_file(_profile_file(item.style_map_file))(target)
+            _merge(target.metadata, item.metadata)
+            for key in _SECTIONS:
+                _merge(getattr(target, key), getattr(item, key))
+            if item.name not in target.profiles:
+                target.profiles.append(item.name)
+    return _apply
+
+
+def resolve_style_map(metadata: Optional[Mapping[str, Any]] = None) -> Dict[str, int]:
+    """Return the heading levels of ``default_style_map.yml`` merged with ``metadata["style_map"]``.
+
+    Handlers mapping source styles to heading levels call this instead of
+    reading the configuration directly so per-job maps apply. Element
+    mappings are left to the ``styles`` processing stage.
+    """
+    from orlando_toolkit.core.style_map import heading_levels, resolve_style_rules
+
+    return heading_levels(resolve_style_rules(metadata))
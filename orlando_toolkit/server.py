@@ -0,0 +1,484 @@
+from __future__ import annotations
+
+"""HTTP service mode (``python -m orlando_toolkit serve``).
+
+Lets a web form or a CMS submit documents and collect the packages without
+the GUI. Jobs run on a pool of ``server.workers`` threads through the
+library API (:mod:`orlando_toolkit.api`); each job keeps the messages of the
+conversion's progress callback. Endpoints, all answering JSON except the
+package download:
+
+- ``POST /jobs`` submits a document: ``multipart/form-data`` with a
+  ``file`` part and optional ``profile`` (profile name) and ``options``
+  (JSON job options, as in a ``--options`` file) fields, or the raw file
+  as the body with ``?filename=manual.docx&profile=NAME``. Answers
+  ``202`` with the job;
+- ``GET /jobs`` lists the jobs, ``GET /jobs/ID`` returns one: ``status``
+  (``queued``, ``running``, ``done``, ``failed`` or ``cancelled``), the last
+  ``progress`` message and ``messages``, the report ``summary`` and
+  ``counts`` once done, the ``error`` (code, message, hint) once failed;
+- ``GET /jobs/ID/package`` downloads the ZIP (``409`` until the job is
+  done), ``GET /jobs/ID/report`` returns the full conversion report;
+- ``DELETE /jobs/ID`` cancels a queued or running job, or removes a
+  finished one and its files;
+- ``GET /health`` answers ``{"status": "ok"}`` for load balancers.
+
+The ``server`` section of ``pipeline.yml`` sets the address (localhost by
+default), the upload limit, how many jobs may wait or run at once
+(``max_queued``; further uploads get ``503``), how long finished jobs are
+kept and an optional bearer ``token`` every request must carry. Audit
+records of a job (:mod:`orlando_toolkit.core.audit`) name the user an
+authenticating proxy puts in the ``user_header`` request header, or the
+client address when that is not configured. Uploads
+need a ``Content-Length`` (``411`` without one) and exactly that many bytes
+are read. Uploads are limited to the
+extensions the active plugins convert.
+"""
+
+import hmac
+import json
+import logging
+import re
+import shutil
+import tempfile
+import threading
+import time
+import uuid
+from collections import deque
+from concurrent.futures import ThreadPoolExecutor
+from dataclasses import dataclass, field
+from email.parser import BytesParser
+from email.policy import HTTP
+from http import HTTPStatus
+from http.server import BaseHTTPRequestHandler, ThreadingHTTPServer
+from pathlib import Path
+from typing import Any, Deque, Dict, List, Mapping, Optional, Tuple
+from urllib.parse import parse_qs, urlsplit
+
+from orlando_toolkit.core.audit import acting_as
+from orlando_toolkit.core.cancellation import CancellationToken, OperationCancelledError
+from orlando_toolkit.core.errors import describe_error
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["STATUSES", "Job", "JobManager", "QueueFullError", "ServerSettings", "create_server", "serve"]
+
+STATUSES = ("queued", "running", "done", "failed", "cancelled")
+_FINISHED = ("done", "failed", "cancelled")
+_JOB_PATH = re.compile(r"^/jobs/([0-9a-f]{32})(?:/(package|report))?/?$")
+_SAFE_NAME = re.compile(r"[^\w.\- ]+")
+
+
+@dataclass(frozen=True)
+class ServerSettings:
+    host: str = "127.0.0.1"
+    port: int = 8765
+    workers: int = 2
+    max_queued: int = 20
+    max_upload_mb: float = 100.0
+    retention_minutes: float = 60.0
+    token: Optional[str] = None
+    user_header: Optional[str] = None
+    work_dir: Optional[str] = None
+    max_messages: int = 50
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "ServerSettings":
+        data = data or {}
+        defaults = cls()
+        try:
+            return cls(
+                host=str(data.get("host") or defaults.host),
+                port=int(data.get("port", defaults.port)),
+                workers=max(1, int(data.get("workers", defaults.workers))),
+                max_queued=max(1, int(data.get("max_queued", defaults.max_queued))),
+                max_upload_mb=float(data.get("max_upload_mb", defaults.max_upload_mb)),
+                retention_minutes=float(data.get("retention_minutes", defaults.retention_minutes)),
+                token=str(data["token"]) if data.get("token") else None,
+                user_header=str(data["user_header"]) if data.get("user_header") else None,
+                work_dir=str(data["work_dir"]) if data.get("work_dir") else None,
+                max_messages=max(1, int(data.get("max_messages", defaults.max_messages))),
+            )
+        except (TypeError, ValueError) as exc:
+            logger.warning("Invalid server settings (%s); using defaults", exc)
+            return defaults
+
+    @classmethod
+    def from_config(cls) -> "ServerSettings":
+        try:
+            from orlando_toolkit.config import ConfigManager
+            return cls.from_mapping((ConfigManager().get_pipeline_config() or {}).get("server"))
+        except Exception as exc:
+            logger.debug("Pipeline config unavailable, using default server settings: %s", exc)
+            return cls()
+
+
+class QueueFullError(RuntimeError):
+    """``max_queued`` jobs are already waiting or running."""
+
+
+@dataclass
+class Job:
+    id: str
+    filename: str
+    folder: Path
+    profile: Optional[str] = None
+    actor: Optional[str] = None
+    status: str = "queued"
+    created: float = field(default_factory=time.time)
+    started: Optional[float] = None
+    finished: Optional[float] = None
+    messages: Deque[str] = field(default_factory=deque)
+    error: Optional[Dict[str, Any]] = None
+    report: Optional[Dict[str, Any]] = None
+    summary: Optional[str] = None
+    package: Optional[Path] = None
+    cancel_token: CancellationToken = field(default_factory=CancellationToken)
+
+    @property
+    def source(self) -> Path:
+        return self.folder / self.filename
+
+    def progress(self, message: str) -> None:
+        self.messages.append(str(message))
+
+    def to_dict(self) -> Dict[str, Any]:
+        data: Dict[str, Any] = {
+            "id": self.id,
+            "filename": self.filename,
+            "profile": self.profile,
+            "status": self.status,
+            "created": _timestamp(self.created),
+            "started": _timestamp(self.started),
+            "finished": _timestamp(self.finished),
+            "progress": self.messages[-1] if self.messages else None,
+            "messages": list(self.messages),
+            "links": {"self": f"/jobs/{self.id}"},
+        }
+        if self.status == "done":
+            data["summary"] = self.summary
+            data["counts"] = (self.report or {}).get("counts")
+            data["links"].update(package=f"/jobs/{self.id}/package", report=f"/jobs/{self.id}/report")
+        if self.error is not None:
+            data["error"] = self.error
+        return data
+
+
+def _timestamp(value: Optional[float]) -> Optional[str]:
+    if value is None:
+        return None
+    return time.strftime("%Y-%m-%dT%H:%M:%SZ", time.gmtime(value))
+
+
+class JobManager:
+    """Queue of conversion jobs run on a thread pool.
+
+    *toolkit* is the :class:`orlando_toolkit.api.Toolkit` running the
+    conversions; one is created with the plugins active in the application
+    (*plugins*) when omitted.
+    """
+
+    def __init__(self, settings: Optional[ServerSettings] = None, *, toolkit: Any = None,
+                 plugins: Any = True) -> None:
+        self.settings = settings or ServerSettings.from_config()
+        if toolkit is None:
+            from orlando_toolkit.api import Toolkit
+            toolkit = Toolkit(plugins=plugins)
+        self.toolkit = toolkit
+        self._owns_dir = not self.settings.work_dir
+        self.root = Path(self.settings.work_dir or tempfile.mkdtemp(prefix="otk_server_"))
+        self.root.mkdir(parents=True, exist_ok=True)
+        self._jobs: Dict[str, Job] = {}
+        self._lock = threading.Lock()
+        self._executor = ThreadPoolExecutor(max_workers=self.settings.workers, thread_name_prefix="otk-job")
+
+    # -- submission ------------------------------------------------------
+    def submit(self, filename: str, data: bytes, *, profile: Optional[str] = None,
+               options: Optional[Mapping[str, Any]] = None, actor: Optional[str] = None) -> Job:
+        """Queue *data* (the document named *filename*) on behalf of *actor* (for the audit log).
+
+        Raises ``ValueError`` for an unusable request and :class:`QueueFullError`
+        when ``max_queued`` jobs are already waiting or running.
+        """
+        from orlando_toolkit.options import build_options, with_options, with_output_profile
+
+        name = _SAFE_NAME.sub("_", Path(filename or "").name).strip(" .")
+        if not name or not Path(name).suffix:
+            raise ValueError("The document needs a file name with an extension")
+        if not self.toolkit.service.can_handle_file(name):
+            supported = sorted({ext.lower() for ext in self.toolkit.supported_extensions()})
+            raise ValueError(f"Unsupported file type {Path(name).suffix!r} (supported: {', '.join(supported)}, "
+                             "Markdown and AsciiDoc)")
+        if len(data) > self.settings.max_upload_mb * 1024 * 1024:
+            raise ValueError(f"The document is larger than {self.settings.max_upload_mb:g} MB")
+        if not data:
+            raise ValueError("The document is empty")
+        try:
+            built = build_options(*((with_output_profile(profile),) if profile else ()),
+                                  *((with_options(options),) if options else ()))
+        except OSError as exc:
+            raise ValueError(str(exc)) from exc
+
+        self.purge()
+        job_id = uuid.uuid4().hex
+        job = Job(job_id, name, self.root / job_id, profile=profile, actor=actor,
+                  messages=deque(maxlen=self.settings.max_messages))
+        with self._lock:
+            pending = sum(1 for j in self._jobs.values() if j.status not in _FINISHED)
+            if pending >= self.settings.max_queued:
+                raise QueueFullError(f"{pending} job(s) are already waiting or running; try again later")
+            self._jobs[job_id] = job
+        try:
+            job.folder.mkdir(parents=True)
+            job.source.write_bytes(data)
+        except OSError:
+            self._remove(job)
+            raise
+        self._executor.submit(self._run, job, built)
+        logger.info("Job %s queued: %s", job_id, name)
+        return job
+
+    def _run(self, job: Job, options: Any) -> None:
+        from orlando_toolkit.api import ConversionError
+
+        if job.cancel_token.is_cancelled:
+            return
+        job.status, job.started = "running", time.time()
+        try:
+            with acting_as(job.actor):
+                result = self.toolkit.convert(job.source, options, progress=job.progress,
+                                              cancel_token=job.cancel_token)
+                package = result.write_archive(job.folder / f"{job.source.stem}.zip", cancel_token=job.cancel_token)
+        except OperationCancelledError:
+            job.status = "cancelled"
+        except ConversionError as exc:
+            job.status, job.error = "failed", exc.info.to_dict()
+        except Exception as exc:
+            logger.exception("Job %s failed", job.id)
+            job.status, job.error = "failed", describe_error(exc).to_dict()
+        else:
+            job.report, job.summary = result.report.to_dict(), result.report.summary()
+            job.package, job.status = package, "done"
+        finally:
+            job.finished = time.time()
+        logger.info("Job %s %s", job.id, job.status)
+
+    # -- queries -----------------------------------------------------------
+    def get(self, job_id: str) -> Optional[Job]:
+        with self._lock:
+            return self._jobs.get(job_id)
+
+    def jobs(self) -> List[Job]:
+        with self._lock:
+            return sorted(self._jobs.values(), key=lambda j: j.created)
+
+    def cancel(self, job_id: str) -> Optional[Job]:
+        """Cancel a queued or running job; a finished one is removed with its files."""
+        job = self.get(job_id)
+        if job is None:
+            return None
+        if job.status in _FINISHED:
+            self._remove(job)
+        else:
+            job.cancel_token.cancel()
+            if job.status == "queued":
+                job.status, job.finished = "cancelled", time.time()
+        return job
+
+    def purge(self) -> int:
+        """Remove finished jobs older than ``retention_minutes``; returns how many."""
+        limit = time.time() - self.settings.retention_minutes * 60
+        expired = [j for j in self.jobs() if j.finished is not None and j.finished < limit]
+        for job in expired:
+            self._remove(job)
+        return len(expired)
+
+    def _remove(self, job: Job) -> None:
+        with self._lock:
+            self._jobs.pop(job.id, None)
+        shutil.rmtree(job.folder, ignore_errors=True)
+
+    def shutdown(self, wait: bool = True) -> None:
+        for job in self.jobs():
+            if job.status not in _FINISHED:
+                job.cancel_token.cancel()
+        self._executor.shutdown(wait=wait)
+        if self._owns_dir:
+            shutil.rmtree(self.root, ignore_errors=True)
+
+
+# ----------------------------------------------------------------------
+# HTTP front-end
+# ----------------------------------------------------------------------
+
+class _RequestError(Exception):
+    def __init__(self, status: HTTPStatus, message: str) -> None:
+        super().__init__(message)
+        self.status = status
+
+
+def _multipart(content_type: str, body: bytes) -> Tuple[Optional[Tuple[str, bytes]], Dict[str, str]]:
+    """``((filename, data) of the file part, other fields)`` of a ``multipart/form-data`` body."""
+    message = BytesParser(policy=HTTP).parsebytes(b"Content-Type: " + content_type.encode("latin-1") + b"\r\n\r\n"
+                                                 + body)
+    upload, fields = None, {}
+    if not message.is_multipart():
+        raise _RequestError(HTTPStatus.BAD_REQUEST, "Malformed multipart body")
+    for part in message.iter_parts():
+        name = part.get_param("name", header="content-disposition")
+        payload = part.get_payload(decode=True) or b""
+        if name == "file":
+            upload = (part.get_filename() or "", payload)
+        elif name:
+            fields[str(name)] = payload.decode("utf-8", "replace")
+    return upload, fields
+
+
+class _Handler(BaseHTTPRequestHandler):
+    server_version = "OrlandoToolkit"
+    manager: JobManager
+
+    def log_message(self, format: str, *args: Any) -> None:
+        logger.debug("%s %s", self.address_string(), format % args)
+
+    # -- responses ---------------------------------------------------------
+    def _send_json(self, status: HTTPStatus, data: Any) -> None:
+        body = json.dumps(data, ensure_ascii=False).encode("utf-8")
+        self.send_response(status)
+        self.send_header("Content-Type", "application/json; charset=utf-8")
+        self.send_header("Content-Length", str(len(body)))
+        self.end_headers()
+        self.wfile.write(body)
+
+    def _send_file(self, path: Path) -> None:
+        self.send_response(HTTPStatus.OK)
+        self.send_header("Content-Type", "application/zip")
+        self.send_header("Content-Length", str(path.stat().st_size))
+        self.send_header("Content-Disposition", f'attachment; filename="{path.name}"')
+        self.end_headers()
+        with open(path, "rb") as handle:
+            shutil.copyfileobj(handle, self.wfile)
+
+    def _dispatch(self, method: str) -> None:
+        try:
+            token = self.manager.settings.token
+            supplied = (self.headers.get("Authorization") or "").encode("utf-8")
+            # Constant-time comparison: response timing must not reveal how much of the token matched
+            if token and not hmac.compare_digest(supplied, f"Bearer {token}".encode("utf-8")):
+                raise _RequestError(HTTPStatus.UNAUTHORIZED, "Missing or wrong bearer token")
+            getattr(self, f"_{method}")(urlsplit(self.path))
+        except _RequestError as exc:
+            self._send_json(exc.status, {"error": str(exc)})
+        except Exception as exc:
+            logger.exception("Request %s %s failed", method, self.path)
+            self._send_json(HTTPStatus.INTERNAL_SERVER_ERROR, {"error": str(exc)})
+
+    def do_GET(self) -> None:       # noqa: N802 - http.server naming
+        self._dispatch("get")
+
+    def do_POST(self) -> None:      # noqa: N802
+        self._dispatch("post")
+
+    def do_DELETE(self) -> None:    # noqa: N802
+        self._dispatch("delete")
+
+    # -- routes ------------------------------------------------------------
+    def _job(self, url: Any) -> Tuple[Job, Optional[str]]:
+        match = _JOB_PATH.match(url.path)
+        job = self.manager.get(match.group(1)) if match else None
+        if job is None:
+            raise _RequestError(HTTPStatus.NOT_FOUND, f"No job at {url.path}")
+        return job, match.group(2)
+
+    def _get(self, url: Any) -> None:
+        if url.path == "/health":
+            self._send_json(HTTPStatus.OK, {"status": "ok", "jobs": len(self.manager.jobs())})
+            return
+        if url.path.rstrip("/") == "/jobs":
+            self._send_json(HTTPStatus.OK, {"jobs": [job.to_dict() for job in self.manager.jobs()]})
+            return
+        job, part = self._job(url)
+        if part is None:
+            self._send_json(HTTPStatus.OK, job.to_dict())
+        elif job.status != "done":
+            raise _RequestError(HTTPStatus.CONFLICT, f"Job {job.id} is {job.status}")
+        elif part == "package":
+            self._send_file(job.package)
+        else:
+            self._send_json(HTTPStatus.OK, job.report)
+
+    def _post(self, url: Any) -> None:
+        if url.path.rstrip("/") != "/jobs":
+            raise _RequestError(HTTPStatus.NOT_FOUND, f"Nothing to post at {url.path}")
+        header = (self.headers.get("Content-Length") or "").strip()
+        if not header:
+            raise _RequestError(HTTPStatus.LENGTH_REQUIRED, "The upload needs a Content-Length")
+        if not (header.isascii() and header.isdigit()):
+            raise _RequestError(HTTPStatus.BAD_REQUEST, f"Invalid Content-Length {header[:20]!r}")
+        length = int(header)
+        if length > self.manager.settings.max_upload_mb * 1024 * 1024:
+            raise _RequestError(HTTPStatus.REQUEST_ENTITY_TOO_LARGE,
+                                f"The document is larger than {self.manager.settings.max_upload_mb:g} MB")
+        body = self.rfile.read(length)
+        if len(body) != length:
+            raise _RequestError(HTTPStatus.BAD_REQUEST, "The upload ended before Content-Length bytes")
+        query = {key: values[-1] for key, values in parse_qs(url.query).items()}
+        content_type = self.headers.get("Content-Type") or ""
+        if content_type.startswith("multipart/form-data"):
+            upload, fields = _multipart(content_type, body)
+            if upload is None:
+                raise _RequestError(HTTPStatus.BAD_REQUEST, "The form has no 'file' part")
+            filename, body = upload
+            query.update(fields)
+        else:
+            filename = query.get("filename") or ""
+        try:
+            options = json.loads(query["options"]) if query.get("options") else None
+            if options is not None and not isinstance(options, dict):
+                raise ValueError("'options' must be a JSON object")
+            job = self.manager.submit(filename, body, profile=query.get("profile") or None, options=options,
+                                      actor=self._actor())
+        except ValueError as exc:
+            raise _RequestError(HTTPStatus.BAD_REQUEST, str(exc)) from exc
+        except QueueFullError as exc:
+            raise _RequestError(HTTPStatus.SERVICE_UNAVAILABLE, str(exc)) from exc
+        self._send_json(HTTPStatus.ACCEPTED, job.to_dict())
+
+    def _actor(self) -> str:
+        """The requesting user for the audit log: the ``user_header`` value, else the client address."""
+        header = self.manager.settings.user_header
+        user = (self.headers.get(header) or "").strip() if header else ""
+        return user or f"client:{self.client_address[0]}"
+
+    def _delete(self, url: Any) -> None:
+        job, part = self._job(url)
+        if part is not None:
+            raise _RequestError(HTTPStatus.METHOD_NOT_ALLOWED, "Delete the job itself")
+        self._send_json(HTTPStatus.OK, self.manager.cancel(job.id).to_dict())
+
+
+def create_server(settings: Optional[ServerSettings] = None, *, manager: Optional[JobManager] = None,
+                  plugins: Any = True) -> ThreadingHTTPServer:
+    """HTTP server bound to ``settings.host:settings.port`` (port 0 picks a free one); not started."""
+    settings = settings or (manager.settings if manager is not None else ServerSettings.from_config())
+    manager = manager or JobManager(settings, plugins=plugins)
+    handler = type("JobHandler", (_Handler,), {"manager": manager})
+    server = ThreadingHTTPServer((settings.host, settings.port), handler)
+    server.daemon_threads = True
+    server.manager = manager
+    return server
+
+
+def serve(settings: Optional[ServerSettings] = None, *, plugins: Any = True) -> None:
+    """Run the service until interrupted."""
+    server = create_server(settings, plugins=plugins)
+    host, port = server.server_address[:2]
+    logger.info("Serving conversion jobs on http://%s:%s", host, port)
+    print(f"Serving conversion jobs on http://{host}:{port} (Ctrl+C to stop)")
+    try:
+        server.serve_forever()
+    except KeyboardInterrupt:
+        pass
+    finally:
+        server.server_close()
+        server.manager.shutdown(wait=False)
@@ -1,4 +1,4 @@
-from typing import List, Dict, Literal, Optional, Callable, Any, Set
+from typing import List, Dict, Literal, Optional, Callable, Any, Set, Tuple
 import xml.etree.ElementTree as ET
 
 from orlando_toolkit.core.models import DitaContext
@@ -8,6 +8,7 @@ from orlando_toolkit.core.services.structure_editing_service import (
 )
 from orlando_toolkit.core.services.undo_service import UndoService
 from orlando_toolkit.core.services.preview_service import PreviewService, PreviewResult
+from orlando_toolkit.ui.i18n import tr
 try:
     from orlando_toolkit.core.context import get_app_context
 except Exception:
@@ -58,20 +59,49 @@ class StructureController:
         self.search_term: str = ""
         self.search_results: List[ET.Element] = []  # XML nodes matching search
         self.search_index: int = -1  # current index into search_results for navigation
+        self.search_regex: bool = False  # treat the term as a regular expression
+        self.search_body_text: bool = False  # also search topic body text
+        self.search_error: str = ""  # message for an invalid regular expression
         self.selected_items: List[str] = []
         self.filter_exclusions: Dict[str, bool] = {}  # group_key -> excluded
         self.style_visibility: Dict[str, bool] = {}  # style -> visible (show marker)
+        self._spelling: Optional[Tuple[Any, Any]] = None  # (SpellSettings, checker), created on first use
 
     # ---------------------------------------------------------------------------------
     # Internal helpers
     # ---------------------------------------------------------------------------------
 
-    def _recorded_edit(self, mutate: Callable[[], Any]) -> Any:
+    def _ui_state(self) -> Dict[str, Any]:
+        """View state recorded with each undo snapshot (depth limit, style filters and markers)."""
+        return {
+            "max_depth": self.max_depth,
+            "filter_exclusions": dict(self.filter_exclusions),
+            "style_visibility": dict(self.style_visibility),
+        }
+
+    def _restore_ui_state(self) -> None:
+        state = self.undo_service.current_state()
+        if not state:
+            return
+        self.max_depth = int(state.get("max_depth", self.max_depth))
+        self.filter_exclusions = dict(state.get("filter_exclusions", {}))
+        self.style_visibility = dict(state.get("style_visibility", {}))
+
+    def _record_ui_change(self, label: str) -> None:
+        """Record a view-only change (no XML mutation); non-fatal on failure."""
+        try:
+            self.undo_service.push_snapshot(self.context, label, self._ui_state())
+        except Exception:
+            pass
+
+    def _recorded_edit(self, mutate: Callable[[], Any], label: Optional[str] = None) -> Any:
         """Execute a mutating operation with pre/post undo snapshots.
 
-        - Pushes a snapshot before mutation; failure is non-fatal.
+        - Pushes a snapshot before mutation; failure is non-fatal. It is a no-op
+          when nothing changed since the last recorded operation.
         - Executes the provided callable.
-        - On success (OperationResult.success True or boolean True), pushes a post snapshot.
+        - On success (OperationResult.success True or boolean True), pushes a post
+          snapshot carrying *label* (shown in the undo journal) and the UI state.
         - Returns the original result.
 
         This preserves business logic in services and keeps snapshot policy DRY.
@@ -97,7 +127,7 @@ class StructureController:
 
         if success:
             try:
-                self.undo_service.push_snapshot(self.context)
+                self.undo_service.push_snapshot(self.context, label, self._ui_state())
             except Exception:
                 # Non-fatal – the operation already succeeded; redo may be limited
                 pass
@@ -141,11 +171,9 @@ class StructureController:
 
         # Only proceed when the clamped value differs from current max_depth.
         # Push an undo snapshot (non-fatal on failure).
-        pre_failed = False
         try:
             self.undo_service.push_snapshot(self.context)
         except Exception:
-            pre_failed = True
             if hasattr(self, "logger"):
                 self.logger.warning("Failed to push undo snapshot for depth change", exc_info=True)
 
@@ -182,7 +210,7 @@ class StructureController:
             pass
         # Push post-mutation snapshot to enable redo
         try:
-            self.undo_service.push_snapshot(self.context)
+            self.undo_service.push_snapshot(self.context, f"Set depth limit to {clamped}", self._ui_state())
         except Exception:
             # Non-fatal: operation already applied
             pass
@@ -350,41 +378,481 @@ class StructureController:
         first_ref: Optional[str] = self.selected_items[0] if self.selected_items else None
         if not first_ref:
             # Construct a conservative unsuccessful result without raising.
-            return OperationResult(success=False, message="No selection")
+            return OperationResult(success=False, message=tr("No selection"))
 
         try:
             return self._recorded_edit(
-                lambda: self.editing_service.move_topic(self.context, first_ref, direction)
+                lambda: self.editing_service.move_topic(self.context, first_ref, direction),
+                "Move topic",
             )
         except Exception:
             # Non-raising for routine errors: return an unsuccessful result.
-            return OperationResult(success=False, message="Move operation failed")
+            return OperationResult(success=False, message=tr("Move operation failed"))
 
 
     def handle_rename(self, topic_ref: str, new_title: str) -> OperationResult:
         """Rename a topic via the editing service wrapped with undo snapshots."""
         if not isinstance(topic_ref, str) or not topic_ref:
-            return OperationResult(success=False, message="No topic selected to rename")
+            return OperationResult(success=False, message=tr("No topic selected to rename"))
         if not isinstance(new_title, str) or not new_title.strip():
-            return OperationResult(success=False, message="Empty title is not allowed")
+            return OperationResult(success=False, message=tr("Empty title is not allowed"))
         try:
             return self._recorded_edit(
-                lambda: self.editing_service.rename_topic(self.context, topic_ref, new_title)
+                lambda: self.editing_service.rename_topic(self.context, topic_ref, new_title),
+                "Rename topic",
             )
         except Exception:
-            return OperationResult(success=False, message="Rename operation failed")
+            return OperationResult(success=False, message=tr("Rename operation failed"))
+
+    def get_batch_rename_targets(self, topic_refs: List[str], section_paths: List[List[int]],
+                                 include_subtopics: bool = False) -> List[str]:
+        """Topic filenames a batch rename of the selection applies to, in map order."""
+        try:
+            return self.editing_service.batch_rename_targets(self.context, list(topic_refs or []),
+                                                             [list(p) for p in (section_paths or []) if p],
+                                                             include_subtopics)
+        except Exception:
+            return []
+
+    def handle_batch_rename(self, renames: Dict[str, str]) -> OperationResult:
+        """Rename several topics (filename -> new title) as one undoable edit."""
+        renames = {ref: title for ref, title in (renames or {}).items()
+                   if isinstance(ref, str) and ref and isinstance(title, str) and title.strip()}
+        if not renames:
+            return OperationResult(success=False, message=tr("No topics to rename"))
+        try:
+            return self._recorded_edit(
+                lambda: self.editing_service.rename_topics(self.context, renames),
+                f"Rename {len(renames)} topics",
+            )
+        except Exception:
+            return OperationResult(success=False, message=tr("Batch rename failed"))
+
+    def handle_apply_outline(self, rows: List[Any]) -> OperationResult:
+        """Apply a reviewed outline sheet (titles, order, levels) as one undoable edit."""
+        if not rows:
+            return OperationResult(success=False, message=tr("The outline has no rows"))
+        try:
+            return self._recorded_edit(
+                lambda: self.editing_service.apply_outline(self.context, rows),
+                "Apply outline",
+            )
+        except Exception:
+            return OperationResult(success=False, message=tr("Applying the outline failed"))
 
     def handle_delete(self, topic_refs: List[str]) -> OperationResult:
         """Delete topics via the editing service wrapped with undo snapshots."""
         refs = [r for r in (topic_refs or []) if isinstance(r, str) and r]
         if not refs:
-            return OperationResult(success=False, message="No topics selected to delete")
+            return OperationResult(success=False, message=tr("No topics selected to delete"))
+        try:
+            return self._recorded_edit(
+                lambda: self.editing_service.delete_topics(self.context, refs),
+                "Delete topics",
+            )
+        except Exception:
+            return OperationResult(success=False, message=tr("Delete operation failed"))
+
+    def handle_set_book_role(
+        self, topic_refs: List[str], section_paths: List[List[int]], role: str
+    ) -> OperationResult:
+        """Set the bookmap role (chapter, preface, appendix) of top-level entries with undo snapshots."""
+        refs = [r for r in (topic_refs or []) if isinstance(r, str) and r]
+        paths = [list(p) for p in (section_paths or []) if p]
+        if not refs and not paths:
+            return OperationResult(success=False, message=tr("No entries selected"))
+        try:
+            return self._recorded_edit(
+                lambda: self.editing_service.set_book_role(self.context, refs, paths, role),
+                "Set book role",
+            )
+        except Exception:
+            return OperationResult(success=False, message=tr("Book role change failed"))
+
+    def get_branch_metadata(self, topic_refs: List[str], section_paths: List[List[int]]) -> Dict[str, Any]:
+        """Prolog metadata stored on the first selected entry, as a mapping (empty when none)."""
+        from orlando_toolkit.core.prolog_metadata import entry_metadata
+
+        try:
+            nodes = [self.context.ditamap_root.find(f".//topicref[@href='{r}']") for r in topic_refs or []]
+            nodes += [self._locate_node_by_index_path(p) for p in section_paths or []]
+            node = next((n for n in nodes if n is not None), None)
+            return entry_metadata(node).to_mapping() if node is not None else {}
+        except Exception:
+            return {}
+
+    def handle_set_branch_metadata(
+        self, topic_refs: List[str], section_paths: List[List[int]], values: Dict[str, Any]
+    ) -> OperationResult:
+        """Store prolog metadata on the selected entries with undo snapshots."""
+        refs = [r for r in (topic_refs or []) if isinstance(r, str) and r]
+        paths = [list(p) for p in (section_paths or []) if p]
+        if not refs and not paths:
+            return OperationResult(success=False, message=tr("No entries selected"))
         try:
             return self._recorded_edit(
-                lambda: self.editing_service.delete_topics(self.context, refs)
+                lambda: self.editing_service.set_branch_metadata(self.context, refs, paths, values),
+                "Set branch metadata",
             )
         except Exception:
-            return OperationResult(success=False, message="Delete operation failed")
+            return OperationResult(success=False, message=tr("Metadata change failed"))
+
+    def get_branch_depth_choices(self, topic_refs: List[str], section_paths: List[List[int]]) -> List[int]:
+        """Split depths offered for the selected branches: from their deepest level to the deepest heading."""
+        try:
+            nodes = [self.context.ditamap_root.find(f".//topicref[@href='{r}']") for r in topic_refs or []]
+            nodes += [self._locate_node_by_index_path(p) for p in section_paths or []]
+            levels = [int(n.get("data-level") or 1) for n in nodes if n is not None]
+            if not levels:
+                return []
+            original = (self.context.metadata.get("original_structure") or {}).get("ditamap_root")
+            root = original if original is not None else self.context.ditamap_root
+            deepest = max([int(n.get("data-level") or 1) for n in root.iter()
+                           if getattr(n, "tag", None) in ("topicref", "topichead")] + levels + [self.max_depth])
+            return list(range(max(levels), deepest + 1))
+        except Exception:
+            return []
+
+    def handle_set_branch_depth(
+        self, topic_refs: List[str], section_paths: List[List[int]], depth: Optional[int]
+    ) -> OperationResult:
+        """Override (or, with None, reset) the split depth of the selected branches with undo snapshots."""
+        refs = [r for r in (topic_refs or []) if isinstance(r, str) and r]
+        paths = [list(p) for p in (section_paths or []) if p]
+        if not refs and not paths:
+            return OperationResult(success=False, message=tr("No entries selected"))
+        style_excl_map = None
+        try:
+            if isinstance(getattr(self, "filter_exclusions", None), dict):
+                style_excl_map = self.build_style_exclusion_map_from_flags(self.filter_exclusions) or None
+        except Exception:
+            style_excl_map = None
+        try:
+            return self._recorded_edit(
+                lambda: self.editing_service.set_branch_depth(
+                    self.context, refs, paths, depth, style_excl_map, self.max_depth),
+                "Set split depth",
+            )
+        except Exception:
+            return OperationResult(success=False, message=tr("Split depth change failed"))
+
+    def handle_set_topic_type(
+        self, topic_refs: List[str], section_paths: List[List[int]], topic_type: str
+    ) -> OperationResult:
+        """Convert the selected branches to tasks or concepts with undo snapshots."""
+        from orlando_toolkit.core.processing import resolve_conversion_options
+
+        refs = [r for r in (topic_refs or []) if isinstance(r, str) and r]
+        paths = [list(p) for p in (section_paths or []) if p]
+        if not refs and not paths:
+            return OperationResult(success=False, message=tr("No entries selected"))
+        try:
+            options = resolve_conversion_options(self.context.metadata).get("procedures") or {}
+            return self._recorded_edit(
+                lambda: self.editing_service.set_topic_type(self.context, refs, paths, topic_type, options),
+                "Change topic type",
+            )
+        except Exception:
+            return OperationResult(success=False, message=tr("Topic type change failed"))
+
+    def get_restructure_options(self, topic_ref: str) -> Dict[str, Any]:
+        """Merge targets and split headings available for a topic (see ``restructure_options``)."""
+        try:
+            return self.editing_service.restructure_options(self.context, topic_ref)
+        except Exception:
+            return {"previous": False, "parent": False, "split_points": []}
+
+    def handle_merge_with_neighbor(self, topic_ref: str, into: Literal["previous", "parent"]) -> OperationResult:
+        """Merge a topic into the previous topic or its parent with undo snapshots."""
+        if not isinstance(topic_ref, str) or not topic_ref:
+            return OperationResult(success=False, message=tr("No topic selected"))
+        try:
+            return self._recorded_edit(
+                lambda: self.editing_service.merge_topic_with_neighbor(self.context, topic_ref, into),
+                "Merge with previous topic" if into == "previous" else "Merge into parent topic",
+            )
+        except Exception:
+            return OperationResult(success=False, message=tr("Merge operation failed"))
+
+    def handle_split_topic(self, topic_ref: str, point: int) -> OperationResult:
+        """Split a topic at one of its headings with undo snapshots."""
+        if not isinstance(topic_ref, str) or not topic_ref:
+            return OperationResult(success=False, message=tr("No topic selected"))
+        try:
+            return self._recorded_edit(
+                lambda: self.editing_service.split_topic(self.context, topic_ref, point),
+                "Split topic",
+            )
+        except Exception:
+            return OperationResult(success=False, message=tr("Split operation failed"))
+
+    def get_table_options(self, topic_ref: str) -> List[Dict[str, Any]]:
+        """Tables of a topic that can move to reference topics (see ``table_options``)."""
+        try:
+            return self.editing_service.table_options(self.context, topic_ref)
+        except Exception:
+            return []
+
+    def handle_toggle_table_reference(self, topic_ref: str, key: Any) -> OperationResult:
+        """Move a table to a reference topic, or back into its topic, with undo snapshots."""
+        if not isinstance(topic_ref, str) or not topic_ref:
+            return OperationResult(success=False, message=tr("No topic selected"))
+        try:
+            return self._recorded_edit(
+                lambda: self.editing_service.toggle_table_reference(self.context, topic_ref, key),
+                "Reference table",
+            )
+        except Exception:
+            return OperationResult(success=False, message=tr("Table change failed"))
+
+    def get_topic_source(self, topic_ref: str) -> Optional[str]:
+        """Indented XML of a topic for the raw XML editor, or None for structural entries."""
+        from orlando_toolkit.core.topic_source import topic_source
+
+        if not isinstance(topic_ref, str) or not topic_ref or self.context is None:
+            return None
+        topic = self.context.topics.get(topic_ref.split("/")[-1])
+        return topic_source(topic) if topic is not None else None
+
+    def check_topic_source(self, topic_ref: str, xml_text: str) -> OperationResult:
+        """Parse and validate edited topic XML without applying it."""
+        try:
+            return self.editing_service.check_topic_source(self.context, topic_ref, xml_text)
+        except Exception:
+            return OperationResult(success=False, message=tr("Validation failed"))
+
+    def handle_edit_topic_source(self, topic_ref: str, xml_text: str, force: bool = False) -> OperationResult:
+        """Replace a topic with edited XML (refused when invalid unless *force*) with undo snapshots."""
+        if not isinstance(topic_ref, str) or not topic_ref:
+            return OperationResult(success=False, message=tr("No topic selected"))
+        try:
+            return self._recorded_edit(
+                lambda: self.editing_service.replace_topic_source(self.context, topic_ref, xml_text, force=force),
+                f"Edit XML of {self.get_title_for_ref(topic_ref) or topic_ref}",
+            )
+        except Exception:
+            return OperationResult(success=False, message=tr("XML edit failed"))
+
+    def handle_apply_reuse(self, candidates: List[Any], options: Optional[Dict[str, Any]] = None) -> OperationResult:
+        """Replace the reviewed repeated blocks with conrefs (``core.reuse``) with undo snapshots."""
+        from orlando_toolkit.core.reuse import apply_reuse
+
+        if not candidates:
+            return OperationResult(success=False, message=tr("No blocks selected"))
+
+        def _apply() -> OperationResult:
+            replaced = apply_reuse(self.context, candidates, options)
+            return OperationResult(success=replaced > 0, message=tr("Replaced {n} block(s) with conrefs", n=replaced),
+                                   details={"replaced": replaced})
+
+        try:
+            return self._recorded_edit(_apply, "Reuse content")
+        except Exception:
+            return OperationResult(success=False, message=tr("Reuse operation failed"))
+
+    def handle_apply_notice_warehouse(self, groups: List[Any],
+                                      options: Optional[Dict[str, Any]] = None) -> OperationResult:
+        """Single-source the reviewed safety notices (``core.reuse``) with undo snapshots."""
+        from orlando_toolkit.core.reuse import apply_notice_warehouse
+
+        if not groups:
+            return OperationResult(success=False, message=tr("No notices selected"))
+
+        def _apply() -> OperationResult:
+            replaced = apply_notice_warehouse(self.context, groups, options)
+            return OperationResult(success=replaced > 0, message=tr("Replaced {n} notice(s) with conrefs", n=replaced),
+                                   details={"replaced": replaced})
+
+        try:
+            return self._recorded_edit(_apply, "Single-source safety notices")
+        except Exception:
+            return OperationResult(success=False, message=tr("Safety notices operation failed"))
+
+    def get_find_matches(self, find: str, *, regex: bool = False, case_sensitive: bool = False) -> List[Any]:
+        """Topics containing *find* (``core.find_replace.ReplaceHit``); raises ValueError for a bad pattern."""
+        from orlando_toolkit.core.find_replace import find_matches
+
+        return find_matches(self.context, find, regex=regex, case_sensitive=case_sensitive)
+
+    def handle_replace_text(
+        self,
+        find: str,
+        replacement: str,
+        *,
+        regex: bool = False,
+        case_sensitive: bool = False,
+        topics: Optional[List[str]] = None,
+    ) -> OperationResult:
+        """Replace *find* in the text of the given topics (all by default) with undo snapshots."""
+        from orlando_toolkit.core.find_replace import replace_text
+
+        def _apply() -> OperationResult:
+            try:
+                changed = replace_text(self.context, find, replacement, regex=regex,
+                                       case_sensitive=case_sensitive, topics=topics)
+            except ValueError as e:
+                return OperationResult(success=False, message=str(e))
+            total = sum(changed.values())
+            return OperationResult(success=total > 0,
+                                   message=tr("Replaced {n} occurrence(s) in {topics} topic(s)", n=total,
+                                              topics=len(changed)),
+                                   details={"replaced": changed})
+
+        try:
+            return self._recorded_edit(_apply, f"Replace “{find}” with “{replacement}”")
+        except Exception:
+            return OperationResult(success=False, message=tr("Replace operation failed"))
+
+    def get_term_violations(self, rules: List[Any]) -> List[Any]:
+        """Uses of banned terms per topic (``core.terminology.TermViolation``)."""
+        from orlando_toolkit.core.terminology import check_terminology
+
+        return check_terminology(self.context, rules)
+
+    def handle_term_replace(self, rule: Any, topics: Optional[List[str]] = None) -> OperationResult:
+        """Replace a banned term by its preferred term in the given topics (all by default) with undo snapshots."""
+        from orlando_toolkit.core.terminology import replace_term
+
+        def _apply() -> OperationResult:
+            try:
+                changed = replace_term(self.context, rule, topics=topics)
+            except ValueError as e:
+                return OperationResult(success=False, message=str(e))
+            total = sum(changed.values())
+            return OperationResult(success=total > 0,
+                                   message=tr("Replaced {n} use(s) of “{term}” in {topics} topic(s)", n=total,
+                                              term=rule.banned, topics=len(changed)),
+                                   details={"replaced": changed})
+
+        try:
+            return self._recorded_edit(_apply, f"Replace “{rule.banned}” with “{rule.preferred}”")
+        except Exception:
+            return OperationResult(success=False, message=tr("Replace operation failed"))
+
+    def _spell_checker(self) -> Tuple[Any, Any]:
+        """Spelling settings of the job and their checker (None when unavailable), created once."""
+        if self._spelling is None:
+            from orlando_toolkit.core.spellcheck import SpellSettings
+
+            settings = SpellSettings.from_metadata(self.context.metadata if self.context is not None else None)
+            self._spelling = (settings, settings.checker())
+        return self._spelling
+
+    def get_spelling_issues(self, errors: Optional[List[str]] = None) -> List[Any]:
+        """Suspected misspellings per topic and word (``core.spellcheck.SpellingIssue``)."""
+        from orlando_toolkit.core.spellcheck import check_spelling
+
+        settings, checker = self._spell_checker()
+        if checker is None:
+            if errors is not None:
+                errors.append("No spell checker available; set spelling.backend in conversion.yml")
+            return []
+        return check_spelling(self.context, checker, settings=settings, errors=errors)
+
+    def get_misspelled_spans(self, topic_ref: str, xml_text: str) -> List[Tuple[int, int]]:
+        """Character ranges of suspected words in edited topic XML (empty when no checker)."""
+        from orlando_toolkit.core.i18n import document_language
+        from orlando_toolkit.core.spellcheck import misspelled_spans, topic_language
+
+        settings, checker = self._spell_checker()
+        if checker is None or self.context is None:
+            return []
+        topic = self.context.topics.get((topic_ref or "").split("/")[-1])
+        language = topic_language(self.context, topic) if topic is not None else document_language(self.context)
+        try:
+            return misspelled_spans(xml_text, checker, language, settings=settings,
+                                    allowed=settings.allowed(self.context.metadata))
+        except Exception:
+            return []
+
+    def handle_ignore_words(self, words: List[str]) -> OperationResult:
+        """Add *words* to the spelling ignore list saved with the project (not an undoable edit)."""
+        from orlando_toolkit.core.spellcheck import ignore_words
+
+        if self.context is None or not words:
+            return OperationResult(success=False, message=tr("No word selected"))
+        ignored = ignore_words(self.context.metadata, words)
+        message = tr("{n} word(s) ignored ({total} in the list)", n=len(words), total=len(ignored))
+        return OperationResult(success=True, message=message, details={"ignored": ignored})
+
+    def _misspelled_words(self, node: ET.Element) -> List[str]:
+        """Suspected words of the topic *node* refers to, for the preview."""
+        from orlando_toolkit.core.spellcheck import suspect_words, topic_language
+
+        settings, checker = self._spell_checker()
+        topic = self.context.topics.get((node.get("href") or "").split("/")[-1]) if self.context else None
+        if checker is None or topic is None:
+            return []
+        try:
+            return list(suspect_words(topic, checker, topic_language(self.context, topic), settings=settings,
+                                      allowed=settings.allowed(self.context.metadata)))
+        except Exception:
+            return []
+
+    def get_integrity_issues(self) -> List[Any]:
+        """Dangling links and conrefs, orphaned topics and unused images (``core.integrity.IntegrityIssue``)."""
+        from orlando_toolkit.core.integrity import check_integrity
+
+        return check_integrity(self.context)
+
+    def handle_integrity_fix(self, issue: Any, action: str, target: Optional[str] = None) -> OperationResult:
+        """Apply an integrity quick fix (retarget, remove, delete, reattach) with undo snapshots."""
+        from orlando_toolkit.core.integrity import apply_fix
+
+        def _apply() -> OperationResult:
+            try:
+                message = apply_fix(self.context, issue, action, target)
+            except ValueError as e:
+                return OperationResult(success=False, message=str(e))
+            return OperationResult(success=True, message=message, details={"action": action, "target": issue.target})
+
+        labels = {"retarget": "Retarget link", "remove": "Remove broken link", "reattach": "Re-attach topic",
+                  "delete": "Delete image" if getattr(issue, "kind", "") == "unused_image" else "Delete orphan topic"}
+        try:
+            return self._recorded_edit(_apply, labels.get(action, "Fix link"))
+        except Exception:
+            return OperationResult(success=False, message=tr("Fix failed"))
+
+    def get_accessibility_issues(self) -> List[Any]:
+        """Missing alt text and table headers, empty titles, low contrast (``core.accessibility``)."""
+        from orlando_toolkit.core.accessibility import check_accessibility
+
+        return check_accessibility(self.context)
+
+    def handle_accessibility_fix(self, issue: Any, action: str, text: Optional[str] = None) -> OperationResult:
+        """Apply an accessibility quick fix with undo snapshots."""
+        from orlando_toolkit.core.accessibility import apply_fix
+
+        def _apply() -> OperationResult:
+            try:
+                message = apply_fix(self.context, issue, action, text)
+            except ValueError as e:
+                return OperationResult(success=False, message=str(e))
+            return OperationResult(success=True, message=message, details={"action": action, "target": issue.target})
+
+        labels = {"add_alt": "Add alternative text", "decorative": "Mark image decorative",
+                  "mark_header": "Mark table header", "set_title": "Set title", "remove_color": "Remove colour"}
+        try:
+            return self._recorded_edit(_apply, labels.get(action, "Fix accessibility"))
+        except Exception:
+            return OperationResult(success=False, message=tr("Fix failed"))
+
+    def handle_reconvert(self, fresh: DitaContext) -> OperationResult:
+        """Merge a new conversion of the changed source into the edited structure (``core.reconvert``)."""
+        from orlando_toolkit.core.reconvert import reconvert
+
+        def _apply() -> OperationResult:
+            try:
+                result = reconvert(self.context, fresh)
+            except ValueError as e:
+                return OperationResult(success=False, message=str(e))
+            return OperationResult(success=result.changed, message=tr("Topics: {summary}", summary=result.summary()),
+                                   details={"result": result})
+
+        try:
+            return self._recorded_edit(_apply, "Update from source")
+        except Exception:
+            return OperationResult(success=False, message=tr("Update from source failed"))
 
     def handle_merge(self, topic_refs: List[str]) -> OperationResult:
         """Merge topics via the editing service with undo snapshots.
@@ -394,7 +862,7 @@ class StructureController:
         """
         refs = [r for r in (topic_refs or []) if isinstance(r, str) and r]
         if len(refs) < 2:
-            return OperationResult(success=False, message="Select at least two topics to merge")
+            return OperationResult(success=False, message=tr("Select at least two topics to merge"))
         target_id = refs[0]
         source_ids = refs[1:]
 
@@ -407,9 +875,9 @@ class StructureController:
                 return self.editing_service.merge_topics(self.context, refs)  # type: ignore[arg-type]
 
         try:
-            return self._recorded_edit(_call_merge)
+            return self._recorded_edit(_call_merge, "Merge topics")
         except Exception:
-            return OperationResult(success=False, message="Merge operation failed")
+            return OperationResult(success=False, message=tr("Merge operation failed"))
 
     # --- Section-specific operations ---
 
@@ -419,13 +887,14 @@ class StructureController:
         Delegates to editing_service.convert_section_to_topic with undo snapshots.
         """
         if not isinstance(index_path, list) or not index_path:
-            return OperationResult(success=False, message="No section selected to merge")
+            return OperationResult(success=False, message=tr("No section selected to merge"))
         try:
             return self._recorded_edit(
-                lambda: self.editing_service.convert_section_to_topic(self.context, index_path)
+                lambda: self.editing_service.convert_section_to_topic(self.context, index_path),
+                "Merge section",
             )
         except Exception:
-            return OperationResult(success=False, message="Section merge failed")
+            return OperationResult(success=False, message=tr("Section merge failed"))
 
     def handle_add_section_after(self, index_path: List[int], title: str) -> OperationResult:
         """Insert a new section below the structural node at index_path.
@@ -433,28 +902,30 @@ class StructureController:
         Delegates to editing_service.insert_section_after_index_path with undo snapshots.
         """
         if not isinstance(index_path, list) or not index_path:
-            return OperationResult(success=False, message="No reference selected to insert after")
+            return OperationResult(success=False, message=tr("No reference selected to insert after"))
         if not isinstance(title, str) or not title.strip():
-            return OperationResult(success=False, message="Empty title is not allowed")
+            return OperationResult(success=False, message=tr("Empty title is not allowed"))
         try:
             return self._recorded_edit(
-                lambda: self.editing_service.insert_section_after_index_path(self.context, index_path, title)
+                lambda: self.editing_service.insert_section_after_index_path(self.context, index_path, title),
+                "Add section",
             )
         except Exception:
-            return OperationResult(success=False, message="Failed to add section")
+            return OperationResult(success=False, message=tr("Failed to add section"))
 
     def handle_add_section_inside(self, index_path: List[int], title: str) -> OperationResult:
         """Insert a new section as first child of the section at index_path (undo-wrapped)."""
         if not isinstance(index_path, list) or not index_path:
-            return OperationResult(success=False, message="No section selected to insert into")
+            return OperationResult(success=False, message=tr("No section selected to insert into"))
         if not isinstance(title, str) or not title.strip():
-            return OperationResult(success=False, message="Empty title is not allowed")
+            return OperationResult(success=False, message=tr("Empty title is not allowed"))
         try:
             return self._recorded_edit(
-                lambda: self.editing_service.insert_section_as_first_child(self.context, index_path, title)
+                lambda: self.editing_service.insert_section_as_first_child(self.context, index_path, title),
+                "Add section",
             )
         except Exception:
-            return OperationResult(success=False, message="Failed to add section inside")
+            return OperationResult(success=False, message=tr("Failed to add section inside"))
 
     def handle_rename_section(self, index_path: List[int], new_title: str) -> OperationResult:
         """Rename a section (topichead) title at index_path.
@@ -462,15 +933,16 @@ class StructureController:
         Delegates to editing_service.rename_section.
         """
         if not isinstance(index_path, list) or not index_path:
-            return OperationResult(success=False, message="No section selected to rename")
+            return OperationResult(success=False, message=tr("No section selected to rename"))
         if not isinstance(new_title, str) or not new_title.strip():
-            return OperationResult(success=False, message="Empty title is not allowed")
+            return OperationResult(success=False, message=tr("Empty title is not allowed"))
         try:
             return self._recorded_edit(
-                lambda: self.editing_service.rename_section(self.context, index_path, new_title)
+                lambda: self.editing_service.rename_section(self.context, index_path, new_title),
+                "Rename section",
             )
         except Exception:
-            return OperationResult(success=False, message="Section rename failed")
+            return OperationResult(success=False, message=tr("Section rename failed"))
 
     def handle_delete_section(self, index_path: List[int]) -> OperationResult:
         """Delete a section (topichead) located by index_path.
@@ -479,13 +951,14 @@ class StructureController:
         that are still referenced elsewhere; after removal, unreferenced topics are purged.
         """
         if not isinstance(index_path, list) or not index_path:
-            return OperationResult(success=False, message="No section selected to delete")
+            return OperationResult(success=False, message=tr("No section selected to delete"))
         try:
             return self._recorded_edit(
-                lambda: self.editing_service.delete_section(self.context, index_path)
+                lambda: self.editing_service.delete_section(self.context, index_path),
+                "Delete section",
             )
         except Exception:
-            return OperationResult(success=False, message="Section delete failed")
+            return OperationResult(success=False, message=tr("Section delete failed"))
 
     # --- Title getters for pre-filling rename dialogs ---
 
@@ -534,10 +1007,11 @@ class StructureController:
         """Apply filters via StructureEditingService with undo snapshots."""
         try:
             return self._recorded_edit(
-                lambda: self.editing_service.apply_depth_limit(self.context, self.max_depth, style_excl_map)
+                lambda: self.editing_service.apply_depth_limit(self.context, self.max_depth, style_excl_map),
+                "Apply style filters",
             )
         except Exception:
-            return OperationResult(success=False, message="Failed to apply filters")
+            return OperationResult(success=False, message=tr("Failed to apply filters"))
 
     # ---------------------------------------------------------------------
     # Heading analysis wrappers (UI queries)
@@ -576,6 +1050,19 @@ class StructureController:
         except Exception:
             return {}
 
+    def get_applicable_styles(self) -> List[str]:
+        """Styles offered for bulk application: provider styles plus those already used in the map, by level."""
+        levels: Dict[str, Optional[int]] = dict(self.get_style_levels() or {})
+        root = getattr(self.context, "ditamap_root", None)
+        for node in (root.iter() if root is not None else ()):
+            style = node.get("data-style") if getattr(node, "tag", None) in ("topicref", "topichead") else None
+            if style and style not in levels:
+                try:
+                    levels[style] = int(node.get("data-level") or 0) or None
+                except (TypeError, ValueError):
+                    levels[style] = None
+        return sorted(levels, key=lambda st: (levels[st] if levels[st] is not None else 99, st))
+
     def estimate_unmergable(self, style_excl_map: Dict[int, Set[str]]) -> int:
         try:
             app_ctx = get_app_context()
@@ -599,12 +1086,17 @@ class StructureController:
             pass
         return style_excl_map
 
+    def set_search_options(self, *, regex: bool = False, body_text: bool = False) -> None:
+        """Choose regex or substring matching and whether topic body text is searched."""
+        self.search_regex = bool(regex)
+        self.search_body_text = bool(body_text)
+
     def handle_search(self, term: str) -> List[ET.Element]:
         """Handle a search request and store transient search state.
 
-        Attempts to compute conservative search results using the available
-        context if possible. If underlying data is unavailable or access fails,
-        returns an empty list while persisting state.
+        Matches map entries (topics and sections) by title or file name and,
+        when ``search_body_text`` is set, by topic body text; ``search_regex``
+        switches from substring to regular expression matching.
 
         Parameters
         ----------
@@ -614,192 +1106,24 @@ class StructureController:
         Returns
         -------
         List[ET.Element]
-            A list of matched XML nodes. Empty list on failure or no match.
-
-        Notes
-        -----
-        - Method should not raise for data access issues; returns [] in such cases.
-        - Results are intentionally conservative given limited knowledge of the context.
+            Matched XML nodes in document order. Empty list when nothing matches,
+            the term is empty or the expression is invalid (see ``search_error``).
         """
+        from orlando_toolkit.core.search import search_structure
+
         self.search_term = term or ""
         self.search_results = []
         self.search_index = -1
-
-        if not self.search_term:
-            return self.search_results
-
-        # Prefer searching the structured ditamap when available so that we
-        # return hrefs that the tree widget understands and can highlight.
+        self.search_error = ""
         try:
-            root = getattr(self.context, "ditamap_root", None)
-        except Exception:
-            root = None
-
-        term_lower = self.search_term.lower()
-
-        if root is not None:
-            try:
-                def iter_heading_children(node: object):
-                    try:
-                        if hasattr(node, "iterchildren"):
-                            for child in node.iterchildren():
-                                try:
-                                    tag = str(getattr(child, "tag", "") or "")
-                                except Exception:
-                                    tag = ""
-                                if tag.endswith("topicref") or tag.endswith("topichead") or tag in {"topicref", "topichead"}:
-                                    yield child
-                            return
-                    except Exception:
-                        pass
-                    try:
-                        if hasattr(node, "getchildren"):
-                            for child in node.getchildren():  # type: ignore[attr-defined]
-                                try:
-                                    tag = str(getattr(child, "tag", "") or "")
-                                except Exception:
-                                    tag = ""
-                                if tag.endswith("topicref") or tag.endswith("topichead") or tag in {"topicref", "topichead"}:
-                                    yield child
-                            return
-                    except Exception:
-                        pass
-                    try:
-                        if hasattr(node, "findall"):
-                            for child in list(node.findall("./topicref")) + list(node.findall("./topichead")):
-                                yield child
-                    except Exception:
-                        return
-
-                def extract_title(node: object) -> str:
-                    # Prefer topicmeta/navtitle, then <title>, else @navtitle
-                    try:
-                        if hasattr(node, "find"):
-                            nav = node.find("topicmeta/navtitle")
-                            if nav is not None:
-                                txt = getattr(nav, "text", None)
-                                if isinstance(txt, str) and txt.strip():
-                                    return txt.strip()
-                            tnode = node.find("title")
-                            if tnode is not None:
-                                ttxt = getattr(tnode, "text", None)
-                                if isinstance(ttxt, str) and ttxt.strip():
-                                    return ttxt.strip()
-                    except Exception:
-                        pass
-                    try:
-                        if hasattr(node, "get"):
-                            alt = node.get("navtitle")
-                            if isinstance(alt, str) and alt.strip():
-                                return alt.strip()
-                    except Exception:
-                        pass
-                    return ""
-
-                def extract_href(node: object) -> str:
-                    try:
-                        if hasattr(node, "get"):
-                            href = node.get("href")
-                            if isinstance(href, str) and href.strip():
-                                return href.strip()
-                    except Exception:
-                        pass
-                    return ""
-
-                matches: List[str] = []
-                stack = [root]
-                visited = 0
-                max_nodes = 200000
-
-                while stack and visited < max_nodes:
-                    node = stack.pop()
-                    visited += 1
-                    try:
-                        tag = str(getattr(node, "tag", "") or "")
-                    except Exception:
-                        tag = ""
-
-                    is_heading = tag.endswith("topicref") or tag.endswith("topichead") or tag in {"topicref", "topichead"}
-                    if is_heading:
-                        title = extract_title(node)
-                        href = extract_href(node)
-                        # Match on title text, full href, file basename
-                        basename = href.split("/")[-1] if href else ""
-                        def _contains(s: str) -> bool:
-                            return bool(s) and term_lower in s.lower()
-                        if _contains(title) or _contains(href) or _contains(basename):
-                            if href:
-                                matches.append(node)
-
-                    try:
-                        # Push children in reverse order so the DFS visit preserves
-                        # the original left-to-right visual order when popping.
-                        children = []
-                        for child in iter_heading_children(node):
-                            children.append(child)
-                        for child in reversed(children):
-                            stack.append(child)
-                    except Exception:
-                        pass
-
-                # Deduplicate while preserving order
-                seen: Dict[int, bool] = {}
-                uniq: List[ET.Element] = []
-                for node in matches:
-                    node_id = id(node)  # Use object identity for deduplication
-                    if node_id not in seen:
-                        seen[node_id] = True
-                        uniq.append(node)
-                self.search_results = uniq
-                self.search_index = 0 if self.search_results else -1
-                return self.search_results
-            except Exception:
-                # Fall through to attribute-based best-effort search
-                pass
-
-        # Best-effort fallback: probe common attributes and filter by substring
-        try:
-            candidates: List[str] = []
-            possible_attrs = [
-                "topics",
-                "topic_refs",
-                "all_topics",
-                "all_topic_refs",
-                "nodes",
-                "items",
-            ]
-            for attr in possible_attrs:
-                if hasattr(self.context, attr):
-                    value = getattr(self.context, attr)
-                    if isinstance(value, dict):
-                        candidates.extend(list(value.keys()))
-                    elif isinstance(value, list):
-                        for entry in value:
-                            if isinstance(entry, str):
-                                candidates.append(entry)
-                            else:
-                                for id_attr in ["id", "ref", "topic_ref", "uid"]:
-                                    if hasattr(entry, id_attr):
-                                        ref_val = getattr(entry, id_attr)
-                                        if isinstance(ref_val, str):
-                                            candidates.append(ref_val)
-                                            break
-
-            # Deduplicate
-            seen2: Dict[str, bool] = {}
-            unique_candidates: List[str] = []
-            for c in candidates:
-                if c not in seen2:
-                    seen2[c] = True
-                    unique_candidates.append(c)
-
-            # Fallback search now returns empty - XML-based search above should handle most cases
-            self.search_results = []
-            self.search_index = 0 if self.search_results else -1
+            self.search_results = search_structure(
+                self.context, self.search_term, regex=self.search_regex, body_text=self.search_body_text
+            )
+        except ValueError as e:
+            self.search_error = str(e)
         except Exception:
             self.search_results = []
-            self.search_index = -1
-
+        self.search_index = 0 if self.search_results else -1
         return self.search_results
 
     def handle_filter_toggle(self, style: str, enabled: bool) -> Dict[str, bool]:
@@ -819,6 +1143,10 @@ class StructureController:
         -------
         Dict[str, bool]
             The current mapping of style to excluded flag.
+
+        Notes
+        -----
+        The change is recorded in the undo journal.
         """
         if not isinstance(style, str) or not style:
             # Do nothing if invalid style label; keep conservative behavior
@@ -826,6 +1154,7 @@ class StructureController:
 
         excluded = not enabled
         self.filter_exclusions[style] = excluded
+        self._record_ui_change(f"{'Exclude' if excluded else 'Include'} style {style}")
         return self.filter_exclusions
 
     def handle_style_visibility_toggle(self, style: str, visible: bool) -> Dict[str, bool]:
@@ -844,6 +1173,10 @@ class StructureController:
         -------
         Dict[str, bool]
             Current mapping style -> visible.
+
+        Notes
+        -----
+        The change is recorded in the undo journal.
         """
         if not isinstance(style, str) or not style:
             return self.style_visibility
@@ -855,6 +1188,7 @@ class StructureController:
                 # Ignore request if it would exceed the limit
                 return self.style_visibility
         self.style_visibility[style] = bool(visible)
+        self._record_ui_change(f"{'Show' if visible else 'Hide'} markers for {style}")
         return self.style_visibility
         
     def get_style_visibility(self) -> Dict[str, bool]:
@@ -912,6 +1246,21 @@ class StructureController:
         except Exception:
             return None
 
+    @staticmethod
+    def _index_path_for_node(node: ET.Element) -> List[int]:
+        """Inverse of _locate_node_by_index_path: positions among structural siblings from the map down."""
+        path: List[int] = []
+        try:
+            while node is not None and node.getparent() is not None:
+                parent = node.getparent()
+                siblings = [el for el in list(parent) if getattr(el, "tag", None) in ("topicref", "topichead")]
+                path.append(siblings.index(node))
+                node = parent
+        except ValueError:
+            return []
+        path.reverse()
+        return path
+
     def select_items(self, xml_nodes: List[ET.Element]) -> None:
         """Set the current selection using XML nodes.
 
@@ -986,13 +1335,19 @@ class StructureController:
     def undo(self) -> bool:
         """Perform an undo operation by restoring the previous snapshot into the context.
 
+        The depth limit, style filters and marker visibility recorded with the
+        snapshot are restored as well.
+
         Returns
         -------
         bool
             True if the undo succeeded, False otherwise.
         """
         try:
-            return self.undo_service.undo(self.context)
+            if not self.undo_service.undo(self.context):
+                return False
+            self._restore_ui_state()
+            return True
         except Exception:
             return False
 
@@ -1005,10 +1360,42 @@ class StructureController:
             True if the redo succeeded, False otherwise.
         """
         try:
-            return self.undo_service.redo(self.context)
+            if not self.undo_service.redo(self.context):
+                return False
+            self._restore_ui_state()
+            return True
         except Exception:
             return False
 
+    def undo_label(self) -> Optional[str]:
+        """Label of the operation Ctrl+Z would revert, or None."""
+        try:
+            return self.undo_service.undo_label()
+        except Exception:
+            return None
+
+    def redo_label(self) -> Optional[str]:
+        """Label of the operation Ctrl+Y would re-apply, or None."""
+        try:
+            return self.undo_service.redo_label()
+        except Exception:
+            return None
+
+    def get_journal(self) -> List[Dict[str, Any]]:
+        """Operations of the session in order (see ``UndoService.journal``)."""
+        try:
+            return self.undo_service.journal()
+        except Exception:
+            return []
+
+    def start_history(self) -> None:
+        """Forget previous operations and record the current state as the undo baseline."""
+        try:
+            self.undo_service.clear()
+            self.undo_service.push_snapshot(self.context, None, self._ui_state())
+        except Exception:
+            pass
+
 
     def render_html_preview(self, topic_ref: str) -> PreviewResult:
         """Render an HTML preview for the provided or selected topic reference.
@@ -1028,25 +1415,36 @@ class StructureController:
         """
         ref = topic_ref or (self.selected_items[0] if self.selected_items else "")
         if not isinstance(ref, str) or not ref:
-            return PreviewResult(success=False, message="No topic reference provided")
+            return PreviewResult(success=False, message=tr("No topic reference provided"))
 
         try:
             return self.preview_service.render_html_preview(self.context, ref)
         except Exception:
-            return PreviewResult(success=False, message="HTML rendering failed")
+            return PreviewResult(success=False, message=tr("HTML rendering failed"))
 
     # XML-centric preview forwarding
     def compile_preview_for_node(self, node: ET.Element) -> PreviewResult:
         try:
             return self.preview_service.compile_node_preview(self.context, node)
         except Exception:
-            return PreviewResult(success=False, message="Failed to compile XML preview")
+            return PreviewResult(success=False, message=tr("Failed to compile XML preview"))
 
-    def render_html_preview_for_node(self, node: ET.Element) -> PreviewResult:
+    def render_html_preview_for_node(self, node: ET.Element, highlight_revisions: bool = False,
+                                     spelling: bool = False) -> PreviewResult:
         try:
+            if highlight_revisions or spelling:
+                return self.preview_service.render_html_preview_for_node(
+                    self.context, node, highlight_revisions=highlight_revisions,
+                    misspelled=self._misspelled_words(node) if spelling else None)
             return self.preview_service.render_html_preview_for_node(self.context, node)
         except Exception:
-            return PreviewResult(success=False, message="Failed to render HTML preview")
+            return PreviewResult(success=False, message=tr("Failed to render HTML preview"))
+
+    def render_source_preview_for_node(self, node: ET.Element) -> PreviewResult:
+        try:
+            return self.preview_service.render_source_preview_for_node(self.context, node)
+        except Exception:
+            return PreviewResult(success=False, content=None, message=tr("Failed to render the source section"))
 
     # ---------------------------------------------------------------------
     # Send-to operations and destination listing
@@ -1061,13 +1459,14 @@ class StructureController:
         """
         refs = [r for r in (topic_refs or []) if isinstance(r, str) and r]
         if not refs:
-            return OperationResult(success=False, message="No topics to move")
+            return OperationResult(success=False, message=tr("No topics to move"))
         try:
             return self._recorded_edit(
-                lambda: self.editing_service.move_topics_to_target(self.context, refs, target_index_path)
+                lambda: self.editing_service.move_topics_to_target(self.context, refs, target_index_path),
+                "Send topics",
             )
         except Exception:
-            return OperationResult(success=False, message="Failed to move topics")
+            return OperationResult(success=False, message=tr("Failed to move topics"))
 
     def handle_send_section_to(
         self, target_index_path: Optional[List[int]], section_index_path: List[int]
@@ -1075,13 +1474,14 @@ class StructureController:
         """Move a section (topichead) to another section or root (append at end)."""
         idx = list(section_index_path or [])
         if not idx:
-            return OperationResult(success=False, message="No section to move")
+            return OperationResult(success=False, message=tr("No section to move"))
         try:
             return self._recorded_edit(
-                lambda: self.editing_service.move_section_to_target(self.context, idx, target_index_path)
+                lambda: self.editing_service.move_section_to_target(self.context, idx, target_index_path),
+                "Send section",
             )
         except Exception:
-            return OperationResult(success=False, message="Failed to move section")
+            return OperationResult(success=False, message=tr("Failed to move section"))
 
     def handle_send_mixed_selection_to(
         self, 
@@ -1112,10 +1512,133 @@ class StructureController:
             return self._recorded_edit(
                 lambda: self.editing_service.move_mixed_selection_to_target(
                     self.context, topic_refs, section_paths, target_index_path
-                )
+                ),
+                "Send selection",
+            )
+        except Exception:
+            return OperationResult(success=False, message=tr("Failed to move mixed selection"))
+
+    # ---------------------------------------------------------------------
+    # Group operations on multi-selections
+    # ---------------------------------------------------------------------
+
+    @staticmethod
+    def _selection_args(topic_refs: List[str], section_paths: List[List[int]]) -> tuple[List[str], List[List[int]]]:
+        refs = [r for r in (topic_refs or []) if isinstance(r, str) and r]
+        paths = [list(p) for p in (section_paths or []) if p]
+        return refs, paths
+
+    def handle_delete_selection(self, topic_refs: List[str], section_paths: List[List[int]]) -> OperationResult:
+        """Delete selected topics and sections together as one undoable edit."""
+        refs, paths = self._selection_args(topic_refs, section_paths)
+        if not refs and not paths:
+            return OperationResult(success=False, message=tr("No entries selected"))
+        try:
+            return self._recorded_edit(
+                lambda: self.editing_service.delete_selection(self.context, refs, paths),
+                "Delete selection",
             )
         except Exception:
-            return OperationResult(success=False, message="Failed to move mixed selection")
+            return OperationResult(success=False, message=tr("Delete operation failed"))
+
+    def handle_shift_level(
+        self, topic_refs: List[str], section_paths: List[List[int]], direction: Literal["promote", "demote"]
+    ) -> OperationResult:
+        """Promote or demote the selected entries by one level as one undoable edit."""
+        refs, paths = self._selection_args(topic_refs, section_paths)
+        if not refs and not paths:
+            return OperationResult(success=False, message=tr("No entries selected"))
+        try:
+            return self._recorded_edit(
+                lambda: self.editing_service.shift_selection_level(self.context, refs, paths, direction),
+                f"{direction.capitalize()} selection",
+            )
+        except Exception:
+            return OperationResult(success=False, message=tr("Failed to promote selection") if direction == "promote"
+                                   else tr("Failed to demote selection"))
+
+    def handle_apply_style(self, topic_refs: List[str], section_paths: List[List[int]], style: str) -> OperationResult:
+        """Apply one heading style to every selected entry."""
+        refs, paths = self._selection_args(topic_refs, section_paths)
+        if not refs and not paths:
+            return OperationResult(success=False, message=tr("No entries selected"))
+        try:
+            return self._recorded_edit(
+                lambda: self.editing_service.apply_style(self.context, refs, paths, style),
+                f"Apply style {style}",
+            )
+        except Exception:
+            return OperationResult(success=False, message=tr("Style change failed"))
+
+    def handle_drop_selection(
+        self,
+        topic_refs: List[str],
+        section_paths: List[List[int]],
+        target_index_path: List[int],
+        position: Literal["before", "after", "inside"],
+    ) -> OperationResult:
+        """Move dragged entries before, after or inside the drop target."""
+        refs, paths = self._selection_args(topic_refs, section_paths)
+        if not refs and not paths:
+            return OperationResult(success=False, message=tr("Nothing to move"))
+        try:
+            return self._recorded_edit(
+                lambda: self.editing_service.move_selection_to_position(
+                    self.context, refs, paths, list(target_index_path or []), position
+                ),
+                "Drag selection",
+            )
+        except Exception:
+            return OperationResult(success=False, message=tr("Failed to move selection"))
+
+    def handle_copy_selection(
+        self, topic_refs: List[str], section_paths: List[List[int]], *, cut: bool = False
+    ) -> OperationResult:
+        """Put the selected branches, their topics and media on the structure clipboard.
+
+        The clipboard is a file shared by every window of the application; with
+        *cut* the branches are then deleted as one undoable edit.
+        """
+        from orlando_toolkit.core.structure_clipboard import write_clip
+
+        refs, paths = self._selection_args(topic_refs, section_paths)
+        if not refs and not paths:
+            return OperationResult(success=False, message=tr("No entries selected"))
+        try:
+            clip = self.editing_service.copy_selection(self.context, refs, paths)
+            if not clip:
+                return OperationResult(success=False, message=tr("Nothing to copy"))
+            write_clip(clip)
+        except Exception as exc:
+            return OperationResult(success=False, message=tr("Could not copy the selection: {error}", error=exc))
+        if cut:
+            return self.handle_delete_selection(refs, paths)
+        return OperationResult(success=True, message=tr("Copied {summary}", summary=clip.summary()),
+                               details={"topics": len(clip.topics)})
+
+    def get_clipboard_summary(self) -> str:
+        """What the structure clipboard holds (empty when nothing can be pasted)."""
+        from orlando_toolkit.core.structure_clipboard import read_clip
+
+        clip = read_clip()
+        return clip.summary() if clip else ""
+
+    def handle_paste(
+        self, target_index_path: List[int], position: Literal["before", "after", "inside"] = "after"
+    ) -> OperationResult:
+        """Paste the structure clipboard after or inside the target entry as one undoable edit."""
+        from orlando_toolkit.core.structure_clipboard import read_clip
+
+        clip = read_clip()
+        if not clip:
+            return OperationResult(success=False, message=tr("The clipboard is empty."))
+        try:
+            return self._recorded_edit(
+                lambda: self.editing_service.paste_clip(self.context, clip, list(target_index_path or []), position),
+                "Paste",
+            )
+        except Exception:
+            return OperationResult(success=False, message=tr("Failed to paste"))
 
     # XML-centric movement API
     def handle_move_selection(self, nodes: List[ET.Element], direction: Literal["up", "down", "promote", "demote"]) -> OperationResult:
@@ -1124,11 +1647,16 @@ class StructureController:
         - Otherwise move nodes one-by-one using intelligent algorithms (supports sections too)
         """
         if not nodes:
-            return OperationResult(success=False, message="No selection")
+            return OperationResult(success=False, message=tr("No selection"))
         # Filter out non-elements
         xml_nodes = [n for n in nodes if hasattr(n, 'tag')]
         if not xml_nodes:
-            return OperationResult(success=False, message="No selection")
+            return OperationResult(success=False, message=tr("No selection"))
+
+        if direction in ("promote", "demote"):
+            refs = [n.get('href') for n in xml_nodes if n.tag == 'topicref' and n.get('href')]
+            paths = [self._index_path_for_node(n) for n in xml_nodes if n.tag == 'topichead']
+            return self.handle_shift_level(refs, [p for p in paths if p], direction)
 
         # Group consecutive topics path - but prefer single-item movement for stability
         only_topics = all(getattr(n, 'tag', None) == 'topicref' for n in xml_nodes)
@@ -1144,7 +1672,10 @@ class StructureController:
                     pass
             if len(hrefs) >= 3:
                 try:
-                    return self._recorded_edit(lambda: self.editing_service.move_consecutive_topics(self.context, hrefs, direction))
+                    return self._recorded_edit(
+                        lambda: self.editing_service.move_consecutive_topics(self.context, hrefs, direction),
+                        f"Move selection {direction}",
+                    )
                 except Exception:
                     # Fallback to one-by-one
                     pass
@@ -1157,19 +1688,21 @@ class StructureController:
                     return bool(self.editing_service.move_element_up(self.context, n).success)
                 elif direction == 'down':
                     return bool(self.editing_service.move_element_down(self.context, n).success)
-                # Promote/demote could be implemented later
                 return False
             except Exception:
                 return False
 
-        # Order matters for stability
-        ordered = list(xml_nodes) if direction == 'up' else list(reversed(xml_nodes))
-        moved_any = False
-        for n in ordered:
-            if _move_one(n):
-                moved_any = True
-
-        return OperationResult(success=moved_any, message=("Moved selection." if moved_any else "Cannot move selection."))
+        def _move_all() -> OperationResult:
+            # Order matters for stability
+            ordered = list(xml_nodes) if direction == 'up' else list(reversed(xml_nodes))
+            moved_any = False
+            for n in ordered:
+                if _move_one(n):
+                    moved_any = True
+            return OperationResult(success=moved_any,
+                                   message=("Moved selection." if moved_any else "Cannot move selection."))
+
+        return self._recorded_edit(_move_all, f"Move selection {direction}")
 
     def list_send_to_destinations(self) -> List[Dict[str, object]]:
         """Return list of possible destinations for Send-to menu.
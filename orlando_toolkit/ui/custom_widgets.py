@@ -7,6 +7,7 @@ Reusable UI components and specialized controls.
 import tkinter as tk
 from tkinter import ttk
 from PIL import Image, ImageTk
+from orlando_toolkit.ui.i18n import tr
 
 
 class Tooltip:
@@ -15,7 +16,7 @@ class Tooltip:
     Shows a small label near the mouse pointer on hover. Safe across platforms
     and themes, avoids complex dependencies. Use as:
 
-        Tooltip(widget, text="Your text")
+        Tooltip(widget, text=tr("Your text"))
 
     The instance keeps itself alive by holding references on the target widget.
     """
@@ -152,7 +153,7 @@ class Thumbnail(tk.Frame):
                 self.image_label.config(image=self.photo_image)
         except Exception as e:
             # Display placeholder on failure
-            self.image_label.config(text=f"Error\n{e}", relief="solid", width=20, height=10)
+            self.image_label.config(text=tr("Error\n{error}", error=e), relief="solid", width=20, height=10)
 
     # --- Selection helpers -------------------------------------------------
 
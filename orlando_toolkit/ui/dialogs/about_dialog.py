@@ -13,13 +13,14 @@ import webbrowser
 from pathlib import Path
 
 from orlando_toolkit.version import get_app_version
+from orlando_toolkit.ui.i18n import tr
 
 
 def show_about_dialog(root: tk.Tk) -> None:
     """Display a compact, centered About dialog (single pane)."""
     top = tk.Toplevel(root)
     try:
-        top.title("About Orlando Toolkit")
+        top.title(tr("About Orlando Toolkit"))
     except Exception:
         pass
     try:
@@ -100,14 +101,14 @@ def show_about_dialog(root: tk.Tk) -> None:
 
     btn_w = 16
     ttk.Button(links, text="GitHub", width=btn_w, style="Accent.TButton", command=lambda: _open("https://github.com/Orsso/orlando-toolkit")).pack(side="left", padx=(0, 8))
-    ttk.Button(links, text="Report an issue", width=btn_w, command=lambda: _open("https://github.com/Orsso/orlando-toolkit/issues/new/choose")).pack(side="left")
+    ttk.Button(links, text=tr("Report an issue"), width=btn_w, command=lambda: _open("https://github.com/Orsso/orlando-toolkit/issues/new/choose")).pack(side="left")
 
-    ttk.Label(content, text="MIT Licensed. © Orsso.", foreground="#777777").pack(pady=(12, 0), anchor="center")
-    ttk.Label(container, text="Built with love in France", foreground="#777777").pack(anchor="center", pady=(2, 0))
+    ttk.Label(content, text=tr("MIT Licensed. © Orsso."), foreground="#777777").pack(pady=(12, 0), anchor="center")
+    ttk.Label(container, text=tr("Built with love in France"), foreground="#777777").pack(anchor="center", pady=(2, 0))
 
     # Footer with Close button aligned right
     footer = ttk.Frame(container)
     footer.pack(fill="x", pady=(8, 0))
-    ttk.Button(footer, text="Close", command=top.destroy).pack(side="right")
+    ttk.Button(footer, text=tr("Close"), command=top.destroy).pack(side="right")
 
 
@@ -0,0 +1,119 @@
+from __future__ import annotations
+
+import tkinter as tk
+from tkinter import ttk
+from typing import Any, Callable, Dict, List, Optional
+from orlando_toolkit.ui.i18n import tr
+
+_KINDS = {
+    "missing_alt": "Missing alt text",
+    "table_header": "No table header",
+    "empty_title": "Empty title",
+    "low_contrast": "Low contrast",
+}
+
+
+class AccessibilityPanel(tk.Toplevel):
+    """Accessibility problems of the content with jump-to-topic and quick fixes.
+
+    ``check()`` returns the ``AccessibilityIssue`` list shown; ``fix(issue,
+    action, text)`` applies a fix and returns its ``OperationResult``, after
+    which the list is checked again. Selecting a row calls ``on_select(topic)``
+    so the structure tree shows the topic concerned. The text field holds the
+    alt text or title to use; left empty, a suggestion is made.
+    """
+
+    def __init__(self, master: tk.Widget, *, check: Callable[[], List[Any]],
+                 fix: Callable[[Any, str, Optional[str]], Any], on_select: Callable[[Optional[str]], None]) -> None:
+        super().__init__(master)
+        self.title(tr("Accessibility"))
+        self.transient(master)
+        self.geometry("860x420")
+        self._check, self._fix, self._on_select = check, fix, on_select
+        self._issues: Dict[str, Any] = {}
+
+        self.columnconfigure(0, weight=1)
+        self.rowconfigure(1, weight=1)
+        self._status = tk.StringVar()
+        ttk.Label(self, textvariable=self._status).grid(row=0, column=0, sticky="w", padx=10, pady=(10, 4))
+
+        frame = ttk.Frame(self)
+        frame.grid(row=1, column=0, sticky="nsew", padx=10)
+        frame.columnconfigure(0, weight=1)
+        frame.rowconfigure(0, weight=1)
+        columns = ("kind", "topic", "target", "message")
+        self._tree = ttk.Treeview(frame, columns=columns, show="headings", selectmode="browse")
+        for column, heading, width, stretch in (("kind", "Problem", 130, False), ("topic", "Topic", 180, False),
+                                                ("target", "Element", 200, False), ("message", "Details", 330, True)):
+            self._tree.heading(column, text=heading)
+            self._tree.column(column, width=width, stretch=stretch, anchor="w")
+        self._tree.grid(row=0, column=0, sticky="nsew")
+        vsb = ttk.Scrollbar(frame, orient="vertical", command=self._tree.yview)
+        self._tree.configure(yscrollcommand=vsb.set)
+        vsb.grid(row=0, column=1, sticky="ns")
+        self._tree.bind("<<TreeviewSelect>>", self._on_row_selected)
+
+        actions = ttk.Frame(self)
+        actions.grid(row=2, column=0, sticky="ew", padx=10, pady=(8, 0))
+        self._text_var = tk.StringVar()
+        self._text_entry = ttk.Entry(actions, textvariable=self._text_var, width=36)
+        self._text_entry.pack(side="left")
+        self._buttons = {
+            "add_alt": ttk.Button(actions, text=tr("Set Alt Text"), command=lambda: self._apply("add_alt")),
+            "set_title": ttk.Button(actions, text=tr("Set Title"), command=lambda: self._apply("set_title")),
+            "decorative": ttk.Button(actions, text=tr("Decorative"), command=lambda: self._apply("decorative")),
+            "mark_header": ttk.Button(actions, text=tr("First Row Is Header"),
+                                      command=lambda: self._apply("mark_header")),
+            "remove_color": ttk.Button(actions, text=tr("Remove Colour"), command=lambda: self._apply("remove_color")),
+        }
+        for action, button in self._buttons.items():
+            button.pack(side="left", padx=(6 if action in ("add_alt", "set_title") else 12, 0))
+
+        btns = ttk.Frame(self)
+        btns.grid(row=3, column=0, sticky="ew", padx=10, pady=10)
+        ttk.Button(btns, text=tr("Check Again"), command=self.refresh).pack(side="left")
+        ttk.Button(btns, text=tr("Close"), command=self.destroy).pack(side="right")
+        self.bind("<Escape>", lambda _e: self.destroy())
+
+        self.refresh()
+
+    def refresh(self) -> None:
+        """Check again and list the issues found."""
+        self._tree.delete(*self._tree.get_children(""))
+        self._issues.clear()
+        for index, issue in enumerate(self._check()):
+            iid = str(index)
+            self._issues[iid] = issue
+            self._tree.insert("", "end", iid=iid, values=(_KINDS.get(issue.kind, issue.kind), issue.topic or "",
+                                                          issue.target, issue.message))
+        self._status.set(f"{len(self._issues)} problem(s) found." if self._issues else "No problem found.")
+        self._update_buttons()
+
+    def _selected(self) -> Optional[Any]:
+        selection = self._tree.selection()
+        return self._issues.get(selection[0]) if selection else None
+
+    def _update_buttons(self) -> None:
+        issue = self._selected()
+        fixes = issue.fixes if issue is not None else ()
+        for action, button in self._buttons.items():
+            button.configure(state="normal" if action in fixes else "disabled")
+        self._text_entry.configure(state="normal" if {"add_alt", "set_title"} & set(fixes) else "disabled")
+
+    def _on_row_selected(self, _event: Optional[tk.Event] = None) -> None:
+        self._update_buttons()
+        issue = self._selected()
+        if issue is not None and issue.topic:
+            self._on_select(issue.topic)
+
+    def _apply(self, action: str) -> None:
+        issue = self._selected()
+        if issue is None:
+            return
+        text = self._text_var.get().strip() if action in ("add_alt", "set_title") else None
+        result = self._fix(issue, action, text or None)
+        if getattr(result, "success", False):
+            self._text_var.set("")
+        self.refresh()
+        if result is not None:
+            self._status.set(getattr(result, "message", "") or self._status.get())
@@ -0,0 +1,143 @@
+"""Rename many topics at once from a template, with a live preview."""
+
+from __future__ import annotations
+
+import logging
+import tkinter as tk
+from tkinter import ttk
+from typing import Any, Callable, Dict, List, Optional
+
+from orlando_toolkit.core.batch_rename import RenamePattern, RenameProposal, propose_renames
+from orlando_toolkit.ui.i18n import tr
+
+logger = logging.getLogger(__name__)
+
+_HELP = ("{title} current title, {parent} parent title, {section} section number, {level} depth, "
+         "{counter} position ({counter:03} padded), {1}, {2}… or {name} groups of the regular expression")
+
+
+class BatchRenameDialog:
+    """Template, regular expression and counter fields above a preview of the old and new titles.
+
+    Use: renames = BatchRenameDialog(parent, context, targets).show_modal()
+    *targets* returns the topic filenames to rename, with or without the
+    subtopics of the selected topics. ``renames`` maps filename to new title,
+    or is ``None`` when the dialog was cancelled.
+    """
+
+    def __init__(self, parent: tk.Misc, context: Any, targets: Callable[[bool], List[str]]):
+        self.parent = parent
+        self.context = context
+        self.targets = targets
+        self.result: Optional[Dict[str, str]] = None
+        self.dialog: Optional[tk.Toplevel] = None
+        self.template_var = tk.StringVar(value="{title}")
+        self.match_var = tk.StringVar(value="")
+        self.start_var = tk.StringVar(value="1")
+        self.step_var = tk.StringVar(value="1")
+        self.pad_var = tk.StringVar(value="0")
+        self.subtopics_var = tk.BooleanVar(value=False)
+        self.status_var = tk.StringVar(value="")
+        self._proposals: List[RenameProposal] = []
+
+    def show_modal(self) -> Optional[Dict[str, str]]:
+        self.dialog = tk.Toplevel(self.parent)
+        self.dialog.title(tr("Batch Rename Topics"))
+        self.dialog.geometry("760x520")
+        self.dialog.transient(self.parent)
+        self._setup_layout()
+        for var in (self.template_var, self.match_var, self.start_var, self.step_var, self.pad_var,
+                    self.subtopics_var):
+            var.trace_add("write", lambda *_a: self._update_preview())
+        self._update_preview()
+        self.dialog.grab_set()
+        self.parent.wait_window(self.dialog)
+        return self.result
+
+    # ------------------------------------------------------------------
+    # Layout
+    # ------------------------------------------------------------------
+    def _setup_layout(self) -> None:
+        frame = ttk.Frame(self.dialog, padding=12)
+        frame.pack(fill="both", expand=True)
+        frame.columnconfigure(1, weight=1)
+        frame.rowconfigure(5, weight=1)
+
+        ttk.Label(frame, text=tr("New title:")).grid(row=0, column=0, sticky="w")
+        template = ttk.Entry(frame, textvariable=self.template_var)
+        template.grid(row=0, column=1, columnspan=3, sticky="ew", padx=(6, 0))
+        ttk.Label(frame, text=tr("Match (regex):")).grid(row=1, column=0, sticky="w", pady=(6, 0))
+        ttk.Entry(frame, textvariable=self.match_var).grid(row=1, column=1, columnspan=3, sticky="ew",
+                                                           padx=(6, 0), pady=(6, 0))
+        ttk.Label(frame, text=_HELP, foreground="gray", wraplength=720,
+                  justify="left").grid(row=2, column=0, columnspan=4, sticky="w", pady=(4, 0))
+
+        counter = ttk.Frame(frame)
+        counter.grid(row=3, column=0, columnspan=4, sticky="w", pady=(8, 0))
+        for label, var in (("Counter start:", self.start_var), ("Step:", self.step_var), ("Digits:", self.pad_var)):
+            ttk.Label(counter, text=label).pack(side="left")
+            ttk.Spinbox(counter, from_=0, to=9999, width=6, textvariable=var).pack(side="left", padx=(4, 12))
+        ttk.Checkbutton(counter, text=tr("Include subtopics"), variable=self.subtopics_var).pack(side="left")
+
+        ttk.Label(frame, textvariable=self.status_var, foreground="gray").grid(row=4, column=0, columnspan=4,
+                                                                               sticky="w", pady=(8, 4))
+        tree_frame = ttk.Frame(frame)
+        tree_frame.grid(row=5, column=0, columnspan=4, sticky="nsew")
+        tree_frame.columnconfigure(0, weight=1)
+        tree_frame.rowconfigure(0, weight=1)
+        self.tree = ttk.Treeview(tree_frame, columns=("old", "new"), show="headings", selectmode="none")
+        self.tree.heading("old", text=tr("Current title"))
+        self.tree.heading("new", text=tr("New title"))
+        self.tree.tag_configure("unchanged", foreground="gray")
+        scroll = ttk.Scrollbar(tree_frame, orient="vertical", command=self.tree.yview)
+        self.tree.configure(yscrollcommand=scroll.set)
+        self.tree.grid(row=0, column=0, sticky="nsew")
+        scroll.grid(row=0, column=1, sticky="ns")
+
+        bottom = ttk.Frame(frame)
+        bottom.grid(row=6, column=0, columnspan=4, sticky="ew", pady=(10, 0))
+        ttk.Button(bottom, text=tr("Cancel"), command=self.dialog.destroy).pack(side="right")
+        self.apply_button = ttk.Button(bottom, text=tr("Rename"), style="Accent.TButton", command=self._apply)
+        self.apply_button.pack(side="right", padx=(0, 6))
+        template.focus_set()
+        template.icursor("end")
+
+    # ------------------------------------------------------------------
+    # Preview
+    # ------------------------------------------------------------------
+    def _pattern(self) -> Optional[RenamePattern]:
+        try:
+            return RenamePattern(template=self.template_var.get(), match=self.match_var.get(),
+                                 start=int(self.start_var.get() or 0), step=int(self.step_var.get() or 0),
+                                 pad=int(self.pad_var.get() or 0))
+        except ValueError:
+            return None
+
+    def _update_preview(self) -> None:
+        self.tree.delete(*self.tree.get_children())
+        pattern = self._pattern()
+        error = "Counter fields must be whole numbers" if pattern is None else pattern.validate()
+        if error:
+            self._proposals = []
+            self.status_var.set(error)
+            self.apply_button.state(["disabled"])
+            return
+        try:
+            self._proposals = propose_renames(self.context, self.targets(self.subtopics_var.get()), pattern)
+        except (ValueError, TypeError, IndexError, KeyError) as exc:
+            self._proposals = []
+            self.status_var.set(f"Invalid template: {exc}")
+            self.apply_button.state(["disabled"])
+            return
+        for proposal in self._proposals:
+            self.tree.insert("", "end", values=(proposal.old, proposal.new if proposal.changed else proposal.old),
+                             tags=() if proposal.changed else ("unchanged",))
+        changed = sum(1 for p in self._proposals if p.changed)
+        unmatched = sum(1 for p in self._proposals if not p.matched)
+        status = f"{changed} of {len(self._proposals)} topic(s) will be renamed"
+        self.status_var.set(status + (f"; {unmatched} do not match" if unmatched else ""))
+        self.apply_button.state(["!disabled"] if changed else ["disabled"])
+
+    def _apply(self) -> None:
+        self.result = {p.filename: p.new for p in self._proposals if p.changed}
+        self.dialog.destroy()
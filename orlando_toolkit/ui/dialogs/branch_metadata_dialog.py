@@ -0,0 +1,60 @@
+"""Prolog metadata of a map branch (author, dates, audience, product, permissions, othermeta)."""
+
+from __future__ import annotations
+
+import tkinter as tk
+from tkinter import ttk
+from typing import Any, Dict, Optional
+
+from orlando_toolkit.core.prolog_metadata import PrologMetadata
+from orlando_toolkit.ui.widgets.prolog_editor import PrologEditor
+from orlando_toolkit.ui.i18n import tr
+
+
+class BranchMetadataDialog:
+    """The prolog editor for the selected entries, with Save, Clear and Cancel.
+
+    Use: values = BranchMetadataDialog(parent, title, current).show_modal()
+    ``values`` is the mapping to store (empty to clear the branch's
+    metadata), or ``None`` when the dialog was cancelled.
+    """
+
+    def __init__(self, parent: tk.Misc, title: str, current: Optional[Dict[str, Any]] = None):
+        self.parent = parent
+        self.title = title
+        self.current = PrologMetadata.from_mapping(current)
+        self.result: Optional[Dict[str, Any]] = None
+        self.dialog: Optional[tk.Toplevel] = None
+
+    def show_modal(self) -> Optional[Dict[str, Any]]:
+        self.dialog = tk.Toplevel(self.parent)
+        self.dialog.title(f"Metadata – {self.title}")
+        self.dialog.transient(self.parent)
+        frame = ttk.Frame(self.dialog, padding=12)
+        frame.pack(fill="both", expand=True)
+        ttk.Label(frame, text=tr("Written into the prolog of every topic of this branch; "
+                              "overrides the metadata of the whole manual."),
+                  foreground="gray", wraplength=520, justify="left").pack(anchor="w", pady=(0, 8))
+        self.editor = PrologEditor(frame, text=tr("Branch metadata"))
+        self.editor.pack(fill="both", expand=True)
+        self.editor.set_values(self.current)
+
+        bottom = ttk.Frame(frame)
+        bottom.pack(fill="x", pady=(10, 0))
+        ttk.Button(bottom, text=tr("Cancel"), command=self.dialog.destroy).pack(side="right")
+        ttk.Button(bottom, text=tr("Save"), style="Accent.TButton", command=self._save).pack(side="right", padx=(0, 6))
+        ttk.Button(bottom, text=tr("Clear"), command=self._clear).pack(side="left")
+        self.dialog.grab_set()
+        self.parent.wait_window(self.dialog)
+        return self.result
+
+    def _save(self) -> None:
+        values = self.editor.validate()
+        if values is None:
+            return
+        self.result = values.to_mapping()
+        self.dialog.destroy()
+
+    def _clear(self) -> None:
+        self.result = {}
+        self.dialog.destroy()
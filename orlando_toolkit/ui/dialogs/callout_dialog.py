@@ -0,0 +1,199 @@
+from __future__ import annotations
+
+import io
+import tkinter as tk
+from tkinter import ttk
+from typing import Callable, List, Optional, Sequence, Tuple
+
+from PIL import Image, ImageTk
+
+from orlando_toolkit.core.callouts import Callout
+from orlando_toolkit.ui.i18n import tr
+
+_MAX_SIZE = (820, 560)
+_MARKER_RADIUS = 11
+_MARKER_COLOUR = "#c0392b"
+_SELECTED_COLOUR = "#0b5394"
+
+
+class CalloutEditor(tk.Toplevel):
+    """Place numbered callouts on an image and name the part each one points at.
+
+    A click on the image adds the next number; dragging a marker moves it.
+    The part name typed for the selected callout goes into the callout table
+    of the topic. **Renumber** numbers the callouts 1, 2, 3… in list order.
+    Positions are kept in pixels of the image whatever the zoom.
+    ``on_save(callouts, mode)`` writes the burned-in PNG (``png``) or SVG
+    overlay (``svg``) and returns a status message, or raises ValueError for
+    an invalid callout.
+    """
+
+    def __init__(self, master: tk.Widget, *, name: str, data: bytes, callouts: Sequence[Callout], mode: str,
+                 on_save: Callable[[List[Callout], str], str]) -> None:
+        super().__init__(master)
+        self.title(f"Callouts – {name}")
+        self.transient(master)
+        self._callouts: List[Callout] = [Callout(c.number, c.x, c.y, c.label) for c in callouts]
+        self._on_save = on_save
+        self._dragging: Optional[int] = None
+        self._moved = False
+
+        image = Image.open(io.BytesIO(data))
+        self._size = image.size
+        self._scale = min(1.0, _MAX_SIZE[0] / max(1, image.width), _MAX_SIZE[1] / max(1, image.height))
+        shown = image.resize((max(1, int(image.width * self._scale)), max(1, int(image.height * self._scale))))
+        self._photo = ImageTk.PhotoImage(shown)
+
+        self.columnconfigure(0, weight=1)
+        self.rowconfigure(1, weight=1)
+        tools = ttk.Frame(self)
+        tools.grid(row=0, column=0, columnspan=2, sticky="ew", padx=10, pady=(10, 4))
+        self._mode = tk.StringVar(value=mode)
+        ttk.Radiobutton(tools, text=tr("Burned-in PNG"), value="png", variable=self._mode).pack(side="left")
+        ttk.Radiobutton(tools, text=tr("SVG overlay"), value="svg", variable=self._mode).pack(side="left", padx=(8, 0))
+        self._status = tk.StringVar(value="Click the image to add a callout; drag a number to move it.")
+        ttk.Label(tools, textvariable=self._status, foreground="#555555").pack(side="left", padx=(16, 0))
+
+        self._canvas = tk.Canvas(self, width=self._photo.width(), height=self._photo.height(),
+                                 highlightthickness=0, cursor="crosshair")
+        self._canvas.grid(row=1, column=0, sticky="nw", padx=(10, 6))
+        self._canvas.create_image(0, 0, image=self._photo, anchor="nw")
+        self._canvas.bind("<ButtonPress-1>", self._on_press)
+        self._canvas.bind("<B1-Motion>", self._on_drag)
+        self._canvas.bind("<ButtonRelease-1>", self._on_release)
+
+        side = ttk.Frame(self)
+        side.grid(row=1, column=1, sticky="nsew", padx=(0, 10))
+        side.rowconfigure(0, weight=1)
+        self._list = ttk.Treeview(side, columns=("number", "label"), show="headings", selectmode="browse",
+                                  height=12)
+        self._list.heading("number", text=tr("No."))
+        self._list.heading("label", text=tr("Part"))
+        self._list.column("number", width=50, stretch=False, anchor="center")
+        self._list.column("label", width=220)
+        self._list.grid(row=0, column=0, columnspan=2, sticky="nsew")
+        self._list.bind("<<TreeviewSelect>>", self._on_callout_selected)
+
+        ttk.Label(side, text=tr("Part:")).grid(row=1, column=0, sticky="w", pady=(8, 0))
+        self._label = ttk.Entry(side)
+        self._label.grid(row=2, column=0, columnspan=2, sticky="ew")
+        self._label.bind("<FocusOut>", self._update_callout)
+        self._label.bind("<Return>", self._update_callout)
+        ttk.Button(side, text=tr("Delete"), command=self._delete_callout).grid(row=3, column=0, sticky="w",
+                                                                            pady=(8, 0))
+        ttk.Button(side, text=tr("Renumber"), command=self._renumber).grid(row=3, column=1, sticky="e", pady=(8, 0))
+
+        btns = ttk.Frame(self)
+        btns.grid(row=2, column=0, columnspan=2, sticky="ew", padx=10, pady=10)
+        ttk.Button(btns, text=tr("Save"), style="Accent.TButton", command=self._save).pack(side="right")
+        ttk.Button(btns, text=tr("Close"), command=self.destroy).pack(side="right", padx=(0, 6))
+
+        self._redraw()
+
+    # -- coordinates ------------------------------------------------------
+
+    def _to_image(self, x: float, y: float) -> Tuple[int, int]:
+        return (min(self._size[0], max(0, int(round(x / self._scale)))),
+                min(self._size[1], max(0, int(round(y / self._scale)))))
+
+    def _hit(self, x: float, y: float) -> Optional[int]:
+        for index in reversed(range(len(self._callouts))):
+            callout = self._callouts[index]
+            if (callout.x * self._scale - x) ** 2 + (callout.y * self._scale - y) ** 2 <= _MARKER_RADIUS ** 2:
+                return index
+        return None
+
+    # -- placing ----------------------------------------------------------
+
+    def _on_press(self, event: tk.Event) -> None:
+        self._update_callout()
+        self._dragging = self._hit(event.x, event.y)
+        self._moved = False
+        if self._dragging is not None:
+            self._redraw(select=self._dragging)
+
+    def _on_drag(self, event: tk.Event) -> None:
+        if self._dragging is None:
+            return
+        callout = self._callouts[self._dragging]
+        callout.x, callout.y = self._to_image(event.x, event.y)
+        self._moved = True
+        self._draw(self._dragging)
+
+    def _on_release(self, event: tk.Event) -> None:
+        if self._dragging is not None:
+            index, self._dragging = self._dragging, None
+            if self._moved:
+                self._status.set(f"Callout {self._callouts[index].number} moved.")
+            return
+        x, y = self._to_image(event.x, event.y)
+        number = max((c.number for c in self._callouts), default=0) + 1
+        self._callouts.append(Callout(number, x, y))
+        self._redraw(select=len(self._callouts) - 1)
+        self._label.focus_set()
+        self._status.set(f"Callout {number} added: type the part it points at.")
+
+    def _redraw(self, select: Optional[int] = None) -> None:
+        current = self._selected_index() if select is None else select
+        self._draw(current)
+        self._list.delete(*self._list.get_children(""))
+        for index, callout in enumerate(self._callouts):
+            self._list.insert("", "end", iid=str(index), values=(callout.number, callout.label or "—"))
+        if current is not None and 0 <= current < len(self._callouts):
+            self._list.selection_set(str(current))
+
+    def _draw(self, current: Optional[int]) -> None:
+        self._canvas.delete("callout")
+        r = _MARKER_RADIUS
+        for index, callout in enumerate(self._callouts):
+            colour = _SELECTED_COLOUR if index == current else _MARKER_COLOUR
+            x, y = callout.x * self._scale, callout.y * self._scale
+            self._canvas.create_oval(x - r, y - r, x + r, y + r, fill="#ffffff", outline=colour, width=2,
+                                     tags="callout")
+            self._canvas.create_text(x, y, text=str(callout.number), fill=colour, font=("TkDefaultFont", 9, "bold"),
+                                     tags="callout")
+
+    # -- callout list -----------------------------------------------------
+
+    def _selected_index(self) -> Optional[int]:
+        selection = self._list.selection()
+        return int(selection[0]) if selection else None
+
+    def _on_callout_selected(self, _event: Optional[tk.Event] = None) -> None:
+        index = self._selected_index()
+        if index is None:
+            return
+        self._label.delete(0, tk.END)
+        self._label.insert(0, self._callouts[index].label)
+        self._draw(index)
+
+    def _update_callout(self, _event: Optional[tk.Event] = None) -> None:
+        index = self._selected_index()
+        if index is None:
+            return
+        callout = self._callouts[index]
+        label = self._label.get().strip()
+        if label != callout.label:
+            callout.label = label
+            self._redraw(select=index)
+
+    def _delete_callout(self) -> None:
+        index = self._selected_index()
+        if index is None:
+            return
+        del self._callouts[index]
+        self._redraw(select=min(index, len(self._callouts) - 1) if self._callouts else None)
+
+    def _renumber(self) -> None:
+        self._update_callout()
+        for number, callout in enumerate(self._callouts, 1):
+            callout.number = number
+        self._redraw()
+
+    def _save(self) -> None:
+        self._update_callout()
+        try:
+            self._status.set(self._on_save([Callout(c.number, c.x, c.y, c.label) for c in self._callouts],
+                                           self._mode.get()))
+        except ValueError as exc:
+            self._status.set(str(exc))
@@ -23,6 +23,7 @@ import tkinter as tk
 from tkinter import ttk  # noqa: F401  # Imported per requirements; reserved for future extension
 from tkinter import Menu
 from typing import Callable, List, Optional, Dict, Any
+from orlando_toolkit.ui.i18n import tr
 
 
 class ContextMenuHandler:
@@ -160,7 +161,7 @@ class ContextMenuHandler:
             can_add_section = False
         if custom_add_section_command is not None:
             menu.add_command(
-                label="➕ Add section…",
+                label=tr("➕ Add section…"),
                 state=(tk.NORMAL if can_add_section else tk.DISABLED),
                 command=lambda: self._execute_simple_command(custom_add_section_command),
             )
@@ -174,13 +175,13 @@ class ContextMenuHandler:
             custom_merge_command = None
         if custom_merge_command is not None:
             menu.add_command(
-                label="🔀 Merge",
+                label=tr("🔀 Merge"),
                 state=(tk.NORMAL if can_merge else tk.DISABLED),
                 command=lambda: self._execute_simple_command(custom_merge_command),
             )
         else:
             menu.add_command(
-                label="🔀 Merge",
+                label=tr("🔀 Merge"),
                 state=(tk.NORMAL if can_merge else tk.DISABLED),
                 command=lambda: self._execute_command(self._on_merge, selected_items),
             )
@@ -194,17 +195,42 @@ class ContextMenuHandler:
             custom_rename_command = None
         if custom_rename_command is not None:
             menu.add_command(
-                label="✍ Rename",
+                label=tr("✍ Rename"),
                 state=(tk.NORMAL if can_rename else tk.DISABLED),
                 command=lambda: self._execute_simple_command(custom_rename_command),
             )
         else:
             menu.add_command(
-                label="✍ Rename",
+                label=tr("✍ Rename"),
                 state=(tk.NORMAL if can_rename else tk.DISABLED),
                 command=lambda: self._execute_command(self._on_rename, selected_items),
             )
 
+        # Optional: Batch rename (whole selection, custom zero-arg command)
+        batch_rename_command = context.get("on_batch_rename_command") if isinstance(context, dict) else None
+        if callable(batch_rename_command):
+            menu.add_command(
+                label=tr("✍ Batch Rename…"),
+                command=lambda: self._execute_simple_command(batch_rename_command),
+            )
+
+        # Optional: Metadata of the selected branches (custom zero-arg command)
+        metadata_command = context.get("on_metadata_command") if isinstance(context, dict) else None
+        if callable(metadata_command):
+            menu.add_command(
+                label=tr("🏷 Metadata…"),
+                command=lambda: self._execute_simple_command(metadata_command),
+            )
+
+        # Optional: Edit XML (single topic, custom zero-arg command)
+        edit_xml_command = context.get("on_edit_xml_command") if isinstance(context, dict) else None
+        if callable(edit_xml_command):
+            menu.add_command(
+                label=tr("🧾 Edit XML…"),
+                state=(tk.NORMAL if can_open else tk.DISABLED),
+                command=lambda: self._execute_simple_command(edit_xml_command),
+            )
+
         # Delete
         custom_delete_command = None
         try:
@@ -214,13 +240,13 @@ class ContextMenuHandler:
             custom_delete_command = None
         if custom_delete_command is not None:
             menu.add_command(
-                label="🗑️ Delete",
+                label=tr("🗑️ Delete"),
                 state=(tk.NORMAL if can_delete else tk.DISABLED),
                 command=lambda: self._execute_simple_command(custom_delete_command),
             )
         else:
             menu.add_command(
-                label="🗑️ Delete",
+                label=tr("🗑️ Delete"),
                 state=(tk.NORMAL if can_delete else tk.DISABLED),
                 command=lambda: self._execute_command(self._on_delete, selected_items),
             )
@@ -241,10 +267,27 @@ class ContextMenuHandler:
                         send_menu.add_command(label=label, command=lambda cb=callback: self._execute_simple_command(cb))
                     except Exception:
                         continue
-                menu.add_cascade(label="📤 Send to", menu=send_menu)
+                menu.add_cascade(label=tr("📤 Send to"), menu=send_menu)
         except Exception:
             pass
 
+        # Optional: Clipboard, Level, Apply style, Merge/split, Tables, Split depth, Topic type and Book role submenus
+        for key, title in (("clipboard_entries", "📋 Clipboard"), ("level_entries", "⇆ Level"), ("style_entries", "🎨 Apply style"),
+                           ("restructure_entries", "✂ Merge / split"), ("table_entries", "▦ Tables"),
+                           ("branch_depth_entries", "⤓ Split depth"),
+                           ("topic_type_entries", "🧩 Topic type"), ("book_role_entries", "📖 Book role")):
+            try:
+                entries = context.get(key) if isinstance(context, dict) else None
+                if isinstance(entries, list) and entries:
+                    submenu = Menu(menu, tearoff=False)
+                    for label, callback in entries:
+                        if isinstance(label, str) and callable(callback):
+                            submenu.add_command(label=label,
+                                                command=lambda cb=callback: self._execute_simple_command(cb))
+                    menu.add_cascade(label=title, menu=submenu)
+            except Exception:
+                pass
+
         # Ensure we tear down the menu on focus loss.
         try:
             menu.bind("<FocusOut>", lambda _e: self._teardown_menu_safe(), add=True)
@@ -3,6 +3,7 @@ from __future__ import annotations
 import tkinter as tk
 from tkinter import ttk
 from typing import List, Optional, Dict, Any
+from orlando_toolkit.ui.i18n import tr
 
 
 class DestinationPicker(tk.Toplevel):
@@ -16,7 +17,7 @@ class DestinationPicker(tk.Toplevel):
 
     def __init__(self, master: tk.Widget, *, destinations: List[Dict[str, Any]]) -> None:
         super().__init__(master)
-        self.title("Choose destination")
+        self.title(tr("Choose destination"))
         self.resizable(True, True)
         self.transient(master)
         self.grab_set()
@@ -33,7 +34,7 @@ class DestinationPicker(tk.Toplevel):
         search_frame = ttk.Frame(self)
         search_frame.grid(row=0, column=0, sticky="ew", padx=8, pady=(8, 4))
         search_frame.columnconfigure(1, weight=1)
-        ttk.Label(search_frame, text="Search:").grid(row=0, column=0, sticky="w")
+        ttk.Label(search_frame, text=tr("Search:")).grid(row=0, column=0, sticky="w")
         self._search_var = tk.StringVar()
         entry = ttk.Entry(search_frame, textvariable=self._search_var)
         entry.grid(row=0, column=1, sticky="ew")
@@ -54,8 +55,8 @@ class DestinationPicker(tk.Toplevel):
         # Buttons
         btns = ttk.Frame(self)
         btns.grid(row=2, column=0, sticky="e", padx=8, pady=(0, 8))
-        ttk.Button(btns, text="Cancel", command=self._on_cancel).grid(row=0, column=0, padx=(0, 6))
-        ttk.Button(btns, text="Send here", command=self._on_accept).grid(row=0, column=1)
+        ttk.Button(btns, text=tr("Cancel"), command=self._on_cancel).grid(row=0, column=0, padx=(0, 6))
+        ttk.Button(btns, text=tr("Send here"), command=self._on_accept).grid(row=0, column=1)
 
         self._populate()
         try:
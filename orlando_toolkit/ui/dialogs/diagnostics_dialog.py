@@ -0,0 +1,148 @@
+from __future__ import annotations
+
+import json
+import tkinter as tk
+from tkinter import filedialog, ttk
+from typing import Any, Callable, Dict, List, Optional
+
+from orlando_toolkit.core.diagnostics import entry_location, export_diagnostics, filter_entries
+from orlando_toolkit.core.models.report import SEVERITIES
+from orlando_toolkit.ui.i18n import tr
+
+_ALL = "All categories"
+_ICONS = {"error": "✖ Error", "warning": "⚠ Warning", "info": "ℹ Info"}
+_COLOURS = {"error": "#c62828", "warning": "#ef6c00", "info": "#555555"}
+_SKIPPED_DETAIL = ("hint", "paragraph", "paragraphs")
+
+
+class ConversionLogPanel(tk.Toplevel):
+    """Conversion report entries, filtered by severity, category and text.
+
+    Clicking a row shows its hint and details below the list and calls
+    ``on_select(topic)`` when the entry names a topic, so the structure tree
+    selects it; the panel stays open. **Export JSON…** writes the rows shown
+    (the whole report when no filter applies) in the ``--report`` format.
+    """
+
+    def __init__(self, master: tk.Widget, report: Any, *, on_select: Callable[[Optional[str]], None],
+                 source: Optional[str] = None) -> None:
+        super().__init__(master)
+        self.title(tr("Conversion Log"))
+        self.transient(master)
+        self.geometry("960x440")
+        self._report = report
+        self._on_select = on_select
+        self._source = source
+        self._shown: Dict[str, Any] = {}
+
+        self.columnconfigure(0, weight=1)
+        self.rowconfigure(1, weight=1)
+        filters = ttk.Frame(self)
+        filters.grid(row=0, column=0, sticky="ew", padx=10, pady=(10, 4))
+        self._severities: Dict[str, tk.BooleanVar] = {}
+        for severity in reversed(SEVERITIES):
+            var = tk.BooleanVar(value=severity != "info")
+            self._severities[severity] = var
+            ttk.Checkbutton(filters, text=_ICONS[severity], variable=var, command=self.refresh).pack(
+                side="left", padx=(0, 8))
+        self._category = tk.StringVar(value=_ALL)
+        self._categories = ttk.Combobox(filters, textvariable=self._category, state="readonly", width=22)
+        self._categories.pack(side="left", padx=(8, 8))
+        self._categories.bind("<<ComboboxSelected>>", lambda _e: self.refresh())
+        ttk.Label(filters, text=tr("Search:")).pack(side="left")
+        self._text = tk.StringVar()
+        search = ttk.Entry(filters, textvariable=self._text, width=28)
+        search.pack(side="left", padx=(4, 0))
+        search.bind("<KeyRelease>", lambda _e: self.refresh())
+        self._count = tk.StringVar()
+        ttk.Label(filters, textvariable=self._count, foreground="#555555").pack(side="right")
+
+        frame = ttk.Frame(self)
+        frame.grid(row=1, column=0, sticky="nsew", padx=10)
+        frame.columnconfigure(0, weight=1)
+        frame.rowconfigure(0, weight=1)
+        columns = ("severity", "category", "topic", "location", "message")
+        self._tree = ttk.Treeview(frame, columns=columns, show="headings", selectmode="browse")
+        for column, heading, width, stretch in (("severity", "Severity", 90, False),
+                                                ("category", "Category", 130, False),
+                                                ("topic", "Topic", 170, False), ("location", "Source", 80, False),
+                                                ("message", "Message", 460, True)):
+            self._tree.heading(column, text=heading)
+            self._tree.column(column, width=width, stretch=stretch, anchor="w")
+        for severity, colour in _COLOURS.items():
+            self._tree.tag_configure(severity, foreground=colour)
+        self._tree.grid(row=0, column=0, sticky="nsew")
+        vsb = ttk.Scrollbar(frame, orient="vertical", command=self._tree.yview)
+        self._tree.configure(yscrollcommand=vsb.set)
+        vsb.grid(row=0, column=1, sticky="ns")
+        self._tree.bind("<<TreeviewSelect>>", self._on_row_selected)
+
+        self._detail = tk.StringVar()
+        ttk.Label(self, textvariable=self._detail, wraplength=920, foreground="#555555",
+                  justify="left").grid(row=2, column=0, sticky="w", padx=10, pady=(6, 0))
+
+        btns = ttk.Frame(self)
+        btns.grid(row=3, column=0, sticky="ew", padx=10, pady=10)
+        ttk.Button(btns, text=tr("Export JSON…"), command=self._export).pack(side="left")
+        ttk.Button(btns, text=tr("Close"), command=self.destroy).pack(side="right")
+        self.bind("<Escape>", lambda _e: self.destroy())
+
+        self.show(report)
+
+    def show(self, report: Any) -> None:
+        """List the entries of *report* with the current filters."""
+        self._report = report
+        categories = sorted({entry.category for entry in report.entries})
+        self._categories.configure(values=[_ALL] + categories)
+        if self._category.get() not in categories:
+            self._category.set(_ALL)
+        self.refresh()
+
+    def _entries(self) -> List[Any]:
+        category = self._category.get()
+        return filter_entries(self._report.entries, [s for s, var in self._severities.items() if var.get()],
+                              None if category == _ALL else category, self._text.get())
+
+    def refresh(self) -> None:
+        self._tree.delete(*self._tree.get_children(""))
+        self._shown.clear()
+        self._detail.set("")
+        entries = self._entries()
+        for index, entry in enumerate(entries):
+            iid = str(index)
+            self._shown[iid] = entry
+            self._tree.insert("", "end", iid=iid, tags=(entry.severity,), values=(
+                _ICONS.get(entry.severity, entry.severity), entry.category, entry.topic or "",
+                entry_location(entry), entry.message))
+        counts = ", ".join(f"{self._report.count(s)} {s}(s)" for s in reversed(SEVERITIES))
+        partial = " — PARTIAL OUTPUT" if self._report.partial else ""
+        self._count.set(f"{len(entries)} shown of {len(self._report)}: {counts}{partial}")
+
+    def _on_row_selected(self, _event: Optional[tk.Event] = None) -> None:
+        selection = self._tree.selection()
+        entry = self._shown.get(selection[0]) if selection else None
+        if entry is None:
+            return
+        lines = []
+        if entry.hint:
+            lines.append(f"Hint: {entry.hint}")
+        extra = {k: v for k, v in entry.detail.items() if k not in _SKIPPED_DETAIL}
+        if extra:
+            lines.append(json.dumps(extra, ensure_ascii=False, default=str))
+        self._detail.set("\n".join(lines))
+        if entry.topic:
+            self._on_select(entry.topic)
+
+    def _export(self) -> None:
+        path = filedialog.asksaveasfilename(parent=self, title=tr("Export Conversion Log"), defaultextension=".json",
+                                            filetypes=[("JSON", "*.json")], initialfile="conversion_report.json")
+        if not path:
+            return
+        entries = self._entries()
+        filtered = len(entries) != len(self._report)
+        try:
+            export_diagnostics(self._report, path, entries=entries if filtered else None, source=self._source)
+        except OSError as exc:
+            self._detail.set(f"Export failed: {exc}")
+            return
+        self._detail.set(f"Exported {len(entries)} entr{'y' if len(entries) == 1 else 'ies'} to {path}")
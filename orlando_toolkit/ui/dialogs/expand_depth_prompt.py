@@ -3,6 +3,7 @@ from __future__ import annotations
 import tkinter as tk
 from tkinter import ttk
 from typing import Tuple
+from orlando_toolkit.ui.i18n import tr
 
 
 class ExpandDepthPrompt(tk.Toplevel):
@@ -13,7 +14,7 @@ class ExpandDepthPrompt(tk.Toplevel):
 
     def __init__(self, parent: tk.Widget, *, new_level: int, current_depth: int, target_depth: int) -> None:
         super().__init__(parent)
-        self.title("Expand depth?")
+        self.title(tr("Expand depth?"))
         self.transient(parent)
         self.resizable(False, False)
         self.grab_set()
@@ -39,13 +40,13 @@ class ExpandDepthPrompt(tk.Toplevel):
         lbl.grid(row=0, column=0, columnspan=3, sticky="w", pady=(0, 8))
 
         # Don't ask again
-        chk = ttk.Checkbutton(frm, text="Don't ask again for this session", variable=self._dont_ask)
+        chk = ttk.Checkbutton(frm, text=tr("Don't ask again for this session"), variable=self._dont_ask)
         chk.grid(row=1, column=0, columnspan=3, sticky="w")
 
         # Buttons
-        btn_expand = ttk.Button(frm, text="Expand to level {td}".format(td=int(target_depth)), command=self._on_expand)
-        btn_keep = ttk.Button(frm, text="Keep current depth", command=self._on_keep)
-        btn_cancel = ttk.Button(frm, text="Cancel", command=self._on_cancel)
+        btn_expand = ttk.Button(frm, text=tr("Expand to level {td}", td=int(target_depth)), command=self._on_expand)
+        btn_keep = ttk.Button(frm, text=tr("Keep current depth"), command=self._on_keep)
+        btn_cancel = ttk.Button(frm, text=tr("Cancel"), command=self._on_cancel)
         btn_expand.grid(row=2, column=0, sticky="w", pady=(10, 0))
         btn_keep.grid(row=2, column=1, sticky="w", padx=(8, 0), pady=(10, 0))
         btn_cancel.grid(row=2, column=2, sticky="w", padx=(8, 0), pady=(10, 0))
@@ -0,0 +1,125 @@
+from __future__ import annotations
+
+import tkinter as tk
+from tkinter import ttk
+from typing import Any, Callable, Dict, List, Optional
+from orlando_toolkit.ui.i18n import tr
+
+_CHECKED = "☑"
+_UNCHECKED = "☐"
+
+
+class FindReplaceDialog:
+    """Find and replace across all topics, with a preview of the affected topics.
+
+    Use: request = FindReplaceDialog.ask(parent, find_matches)
+    ``find_matches(find, regex=…, case_sensitive=…)`` returns the
+    ``ReplaceHit`` list shown in the preview (ValueError for a bad pattern).
+    Every affected topic is checked by default and a click toggles it.
+    Returns a dict with ``find``, ``replacement``, ``regex``,
+    ``case_sensitive`` and the checked ``topics``, or None if cancelled.
+    """
+
+    @staticmethod
+    def ask(parent: tk.Widget, find_matches: Callable[..., List[Any]]) -> Optional[Dict[str, Any]]:
+        top = tk.Toplevel(parent)
+        top.title(tr("Find & Replace"))
+        try:
+            top.transient(parent.winfo_toplevel())
+            top.grab_set()
+        except Exception:
+            pass
+        top.geometry("860x460")
+        top.columnconfigure(0, weight=1)
+        top.rowconfigure(2, weight=1)
+
+        form = ttk.Frame(top)
+        form.grid(row=0, column=0, sticky="ew", padx=10, pady=(10, 4))
+        form.columnconfigure(1, weight=1)
+        find_var, replace_var = tk.StringVar(), tk.StringVar()
+        regex_var, case_var = tk.BooleanVar(value=False), tk.BooleanVar(value=False)
+        ttk.Label(form, text=tr("Find")).grid(row=0, column=0, sticky="w", padx=(0, 8))
+        find_entry = ttk.Entry(form, textvariable=find_var)
+        find_entry.grid(row=0, column=1, sticky="ew", pady=2)
+        ttk.Label(form, text=tr("Replace with")).grid(row=1, column=0, sticky="w", padx=(0, 8))
+        ttk.Entry(form, textvariable=replace_var).grid(row=1, column=1, sticky="ew", pady=2)
+        options = ttk.Frame(form)
+        options.grid(row=0, column=2, rowspan=2, sticky="n", padx=(12, 0))
+        ttk.Checkbutton(options, text=tr("Regular expression"), variable=regex_var).pack(anchor="w")
+        ttk.Checkbutton(options, text=tr("Match case"), variable=case_var).pack(anchor="w")
+
+        status_var = tk.StringVar(value="Text nodes only: markup, attributes and ids are left unchanged.")
+        ttk.Label(top, textvariable=status_var, foreground="#666666").grid(row=1, column=0, sticky="w", padx=10)
+
+        frame = ttk.Frame(top)
+        frame.grid(row=2, column=0, sticky="nsew", padx=10, pady=(4, 0))
+        frame.columnconfigure(0, weight=1)
+        frame.rowconfigure(0, weight=1)
+        columns = ("apply", "topic", "count", "text")
+        tree = ttk.Treeview(frame, columns=columns, show="headings", selectmode="browse")
+        for column, heading, width, stretch in (("apply", "", 32, False), ("topic", "Topic", 220, False),
+                                                ("count", "Matches", 70, False), ("text", "Context", 500, True)):
+            tree.heading(column, text=heading)
+            tree.column(column, width=width, stretch=stretch, anchor="w" if column != "count" else "center")
+        tree.grid(row=0, column=0, sticky="nsew")
+        vsb = ttk.Scrollbar(frame, orient="vertical", command=tree.yview)
+        tree.configure(yscrollcommand=vsb.set)
+        vsb.grid(row=0, column=1, sticky="ns")
+
+        checked: Dict[str, bool] = {}
+        previewed: List[Optional[tuple]] = [None]
+
+        def _preview() -> None:
+            tree.delete(*tree.get_children(""))
+            checked.clear()
+            previewed[0] = None
+            try:
+                hits = find_matches(find_var.get(), regex=regex_var.get(), case_sensitive=case_var.get())
+            except ValueError as e:
+                status_var.set(str(e))
+                return
+            for hit in hits:
+                checked[hit.topic] = True
+                tree.insert("", "end", iid=hit.topic,
+                            values=(_CHECKED, hit.title or hit.topic, hit.count, " | ".join(hit.snippets)))
+            status_var.set(f"{sum(h.count for h in hits)} match(es) in {len(hits)} topic(s)."
+                           if hits else "No match.")
+            previewed[0] = (find_var.get(), regex_var.get(), case_var.get())
+
+        def _toggle(event) -> None:
+            iid = tree.identify_row(event.y)
+            if iid:
+                checked[iid] = not checked[iid]
+                tree.set(iid, "apply", _CHECKED if checked[iid] else _UNCHECKED)
+
+        tree.bind("<Button-1>", _toggle, add=True)
+
+        result: List[Optional[Dict[str, Any]]] = [None]
+
+        def _replace() -> None:
+            # Replace what the preview shows; preview first when the search changed since
+            if previewed[0] != (find_var.get(), regex_var.get(), case_var.get()):
+                _preview()
+                return
+            topics = [name for name, on in checked.items() if on]
+            if not topics:
+                status_var.set("No topic selected.")
+                return
+            result[0] = {"find": find_var.get(), "replacement": replace_var.get(), "regex": regex_var.get(),
+                         "case_sensitive": case_var.get(), "topics": topics}
+            top.destroy()
+
+        btns = ttk.Frame(top)
+        btns.grid(row=3, column=0, sticky="ew", padx=10, pady=10)
+        ttk.Button(btns, text=tr("Preview"), command=_preview).pack(side="left")
+        ttk.Button(btns, text=tr("Replace"), style="Accent.TButton", command=_replace).pack(side="right")
+        ttk.Button(btns, text=tr("Cancel"), command=top.destroy).pack(side="right", padx=(0, 6))
+        find_entry.bind("<Return>", lambda _e: _preview())
+        top.bind("<Escape>", lambda _e: top.destroy())
+        find_entry.focus_set()
+
+        try:
+            top.wait_window()
+        except Exception:
+            pass
+        return result[0]
@@ -0,0 +1,101 @@
+from __future__ import annotations
+
+import tkinter as tk
+from tkinter import ttk
+from typing import Dict, List, Optional, Sequence
+
+from orlando_toolkit.core.front_matter import SkippedBlock
+from orlando_toolkit.ui.i18n import tr
+
+_CHECKED = "☑"
+_UNCHECKED = "☐"
+_REASONS = {"style": "Style", "toc": "Contents", "title": "Title rule", "page": "Page"}
+
+
+class FrontMatterDialog:
+    """Preview of the Word front matter left out before converting.
+
+    Use: keep = FrontMatterDialog.ask(parent, blocks, source_name)
+    Lists each paragraph, table or table of contents the ``front_matter``
+    rules remove, with its page and the rule that matched. Blocks are left
+    out by default; a click keeps one. Returns the first paragraph numbers of
+    the blocks to keep (empty when all go), or None if cancelled.
+    """
+
+    @staticmethod
+    def ask(parent: tk.Widget, blocks: Sequence[SkippedBlock], source_name: str = "") -> Optional[List[int]]:
+        top = tk.Toplevel(parent)
+        top.title(f"Front Matter — {source_name}" if source_name else "Front Matter")
+        try:
+            top.transient(parent.winfo_toplevel())
+            top.grab_set()
+        except Exception:
+            pass
+        top.geometry("860x440")
+        top.columnconfigure(0, weight=1)
+        top.rowconfigure(1, weight=1)
+
+        pages = sorted({b.page for b in blocks})
+        ttk.Label(top, text=tr("{n} block(s) on {pages} page(s) will be left out before the document is split into "
+                               "topics. Uncheck the ones to keep.", n=len(blocks), pages=len(pages)),
+                  wraplength=820).grid(row=0, column=0, sticky="w", padx=10, pady=(10, 6))
+
+        frame = ttk.Frame(top)
+        frame.grid(row=1, column=0, sticky="nsew", padx=10)
+        frame.columnconfigure(0, weight=1)
+        frame.rowconfigure(0, weight=1)
+        columns = ("apply", "page", "kind", "reason", "text")
+        tree = ttk.Treeview(frame, columns=columns, show="headings", selectmode="browse")
+        for column, heading, width, stretch in (("apply", "", 32, False), ("page", "Page", 50, False),
+                                                ("kind", "Block", 80, False), ("reason", "Rule", 90, False),
+                                                ("text", "Text", 560, True)):
+            tree.heading(column, text=heading)
+            tree.column(column, width=width, stretch=stretch, anchor="w" if stretch else "center")
+        tree.grid(row=0, column=0, sticky="nsew")
+        vsb = ttk.Scrollbar(frame, orient="vertical", command=tree.yview)
+        tree.configure(yscrollcommand=vsb.set)
+        vsb.grid(row=0, column=1, sticky="ns")
+
+        numbers: Dict[str, int] = {}
+        checked: Dict[str, bool] = {}
+        for block in blocks:
+            iid = str(block.paragraph)
+            numbers[iid] = block.paragraph
+            checked[iid] = True
+            tree.insert("", "end", iid=iid, values=(_CHECKED, block.page, block.kind,
+                                                    _REASONS.get(block.reason, block.reason), block.text))
+
+        def _set(iid: str, value: bool) -> None:
+            checked[iid] = value
+            tree.set(iid, "apply", _CHECKED if value else _UNCHECKED)
+
+        def _toggle(event) -> None:
+            if tree.identify_column(event.x) != "#1":
+                return
+            iid = tree.identify_row(event.y)
+            if iid:
+                _set(iid, not checked[iid])
+
+        tree.bind("<Button-1>", _toggle, add=True)
+        tree.bind("<space>", lambda _e: [_set(i, not checked[i]) for i in tree.selection()])
+
+        result: List[Optional[List[int]]] = [None]
+
+        def _apply() -> None:
+            result[0] = [numbers[iid] for iid, value in checked.items() if not value]
+            top.destroy()
+
+        btns = ttk.Frame(top)
+        btns.grid(row=2, column=0, sticky="ew", padx=10, pady=10)
+        ttk.Button(btns, text=tr("Select All"), command=lambda: [_set(i, True) for i in checked]).pack(side="left")
+        ttk.Button(btns, text=tr("Select None"),
+                   command=lambda: [_set(i, False) for i in checked]).pack(side="left", padx=(6, 0))
+        ttk.Button(btns, text=tr("Convert"), style="Accent.TButton", command=_apply).pack(side="right")
+        ttk.Button(btns, text=tr("Cancel"), command=top.destroy).pack(side="right", padx=(0, 6))
+        top.bind("<Escape>", lambda _e: top.destroy())
+
+        try:
+            top.wait_window()
+        except Exception:
+            pass
+        return result[0]
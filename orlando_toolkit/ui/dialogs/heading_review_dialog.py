@@ -0,0 +1,152 @@
+"""Review the detected heading outline before a document is converted."""
+
+from __future__ import annotations
+
+import logging
+import tkinter as tk
+from tkinter import ttk
+from typing import List, Optional, Sequence
+
+from orlando_toolkit.core.heading_review import OutlineHeading
+from orlando_toolkit.ui.i18n import tr
+
+logger = logging.getLogger(__name__)
+
+_MAX_LEVEL = 9
+_EXCLUDED = "excluded"
+
+
+class HeadingReviewDialog:
+    """Lists the headings (style, level, title) and lets the user promote, demote or exclude them.
+
+    Use: levels = HeadingReviewDialog(parent, outline, name).show_modal()
+    ``levels`` holds the reviewed level of each heading (``None`` when it is
+    excluded or the map title), or is ``None`` when the conversion was cancelled.
+    """
+
+    def __init__(self, parent: tk.Misc, outline: Sequence[OutlineHeading], source_name: str = ""):
+        self.parent = parent
+        self.outline = list(outline)
+        self.source_name = source_name
+        self.levels: List[Optional[int]] = [h.level for h in self.outline]
+        self.result: Optional[List[Optional[int]]] = None
+        self.dialog: Optional[tk.Toplevel] = None
+        self.subtree_var = tk.BooleanVar(value=True)
+        self.status_var = tk.StringVar(value="")
+
+    def show_modal(self) -> Optional[List[Optional[int]]]:
+        self.dialog = tk.Toplevel(self.parent)
+        self.dialog.title(tr("Review Headings"))
+        self.dialog.geometry("720x480")
+        self.dialog.transient(self.parent)
+        self._setup_layout()
+        self._refresh()
+        self.dialog.protocol("WM_DELETE_WINDOW", self.dialog.destroy)
+        self.dialog.grab_set()
+        self.parent.wait_window(self.dialog)
+        return self.result
+
+    # ------------------------------------------------------------------
+    # Layout
+    # ------------------------------------------------------------------
+    def _setup_layout(self) -> None:
+        frame = ttk.Frame(self.dialog, padding=12)
+        frame.pack(fill="both", expand=True)
+        frame.columnconfigure(0, weight=1)
+        frame.rowconfigure(1, weight=1)
+
+        intro = f"Headings detected in {self.source_name}." if self.source_name else "Headings detected."
+        ttk.Label(frame, text=intro + " Each heading becomes a topic at its level; excluded headings stay "
+                                      "in the topic before them.", wraplength=680,
+                  justify="left").grid(row=0, column=0, columnspan=2, sticky="w", pady=(0, 8))
+
+        tree_frame = ttk.Frame(frame)
+        tree_frame.grid(row=1, column=0, sticky="nsew")
+        tree_frame.columnconfigure(0, weight=1)
+        tree_frame.rowconfigure(0, weight=1)
+        self.tree = ttk.Treeview(tree_frame, columns=("level", "style"), selectmode="extended")
+        self.tree.heading("#0", text=tr("Title"))
+        self.tree.heading("level", text=tr("Level"))
+        self.tree.heading("style", text=tr("Style"))
+        self.tree.column("#0", width=420)
+        self.tree.column("level", width=70, stretch=False, anchor="center")
+        self.tree.column("style", width=150, stretch=False)
+        self.tree.tag_configure(_EXCLUDED, foreground="gray")
+        scroll = ttk.Scrollbar(tree_frame, orient="vertical", command=self.tree.yview)
+        self.tree.configure(yscrollcommand=scroll.set)
+        self.tree.grid(row=0, column=0, sticky="nsew")
+        scroll.grid(row=0, column=1, sticky="ns")
+
+        side = ttk.Frame(frame)
+        side.grid(row=1, column=1, sticky="n", padx=(8, 0))
+        ttk.Button(side, text=tr("Promote"), command=lambda: self._shift(-1)).pack(fill="x")
+        ttk.Button(side, text=tr("Demote"), command=lambda: self._shift(1)).pack(fill="x", pady=(4, 0))
+        ttk.Checkbutton(side, text=tr("With subheadings"), variable=self.subtree_var).pack(anchor="w", pady=(4, 0))
+        ttk.Button(side, text=tr("Exclude/Include"), command=self._toggle_excluded).pack(fill="x", pady=(12, 0))
+        ttk.Button(side, text=tr("Reset"), command=self._reset).pack(fill="x", pady=(12, 0))
+
+        bottom = ttk.Frame(frame)
+        bottom.grid(row=2, column=0, columnspan=2, sticky="ew", pady=(10, 0))
+        ttk.Label(bottom, textvariable=self.status_var, foreground="gray").pack(side="left")
+        ttk.Button(bottom, text=tr("Cancel"), command=self.dialog.destroy).pack(side="right")
+        ttk.Button(bottom, text=tr("Convert"), style="Accent.TButton",
+                   command=self._accept).pack(side="right", padx=(0, 6))
+
+    # ------------------------------------------------------------------
+    # Outline
+    # ------------------------------------------------------------------
+    def _refresh(self) -> None:
+        selection = self.tree.selection()
+        self.tree.delete(*self.tree.get_children())
+        for index, (heading, level) in enumerate(zip(self.outline, self.levels)):
+            indent = "    " * ((level or 1) - 1)
+            self.tree.insert("", "end", iid=str(index), text=indent + heading.title,
+                             values=(level if level is not None else "-", heading.style or ""),
+                             tags=(_EXCLUDED,) if level is None else ())
+        if selection:
+            self.tree.selection_set(selection)
+        changed = sum(1 for h, level in zip(self.outline, self.levels) if h.level != level)
+        excluded = sum(1 for level in self.levels if level is None)
+        self.status_var.set(f"{len(self.outline)} heading(s), {len(self.outline) - excluded} topic(s); "
+                            f"{changed} changed")
+
+    def _selected(self) -> List[int]:
+        return sorted(int(iid) for iid in self.tree.selection())
+
+    def _subtree(self, index: int) -> List[int]:
+        """*index* and, with "With subheadings", the deeper headings after it."""
+        root = self.levels[index]
+        indexes = [index]
+        if root is None or not self.subtree_var.get():
+            return indexes
+        for position in range(index + 1, len(self.levels)):
+            level = self.levels[position]
+            if level is not None and level <= root:
+                break
+            indexes.append(position)
+        return indexes
+
+    def _shift(self, step: int) -> None:
+        moved = set()
+        for index in self._selected():
+            for position in self._subtree(index):
+                level = self.levels[position]
+                if position in moved or level is None:
+                    continue
+                self.levels[position] = min(_MAX_LEVEL, max(1, level + step))
+                moved.add(position)
+        self._refresh()
+
+    def _toggle_excluded(self) -> None:
+        for index in self._selected():
+            original = self.outline[index].level or self.outline[index].source_level
+            self.levels[index] = original if self.levels[index] is None else None
+        self._refresh()
+
+    def _reset(self) -> None:
+        self.levels = [h.level for h in self.outline]
+        self._refresh()
+
+    def _accept(self) -> None:
+        self.result = list(self.levels)
+        self.dialog.destroy()
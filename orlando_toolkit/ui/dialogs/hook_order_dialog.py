@@ -0,0 +1,150 @@
+"""Order and enable the conversion hooks, for all jobs or one output profile."""
+
+from __future__ import annotations
+
+import logging
+import tkinter as tk
+from tkinter import messagebox, ttk
+from typing import Any, Dict, List, Optional
+
+from orlando_toolkit.core.hooks import HOOK_SERVICE, hook_points
+from orlando_toolkit.ui.i18n import tr
+
+logger = logging.getLogger(__name__)
+
+_ALL_JOBS = "All jobs"
+
+
+class HookOrderDialog:
+    """Lists the hooks of active plugins and installed packages in the order they run.
+
+    Use: HookOrderDialog(parent, service_registry).show_modal()
+    Saved settings go to the user ``conversion.yml`` (all jobs) or to the
+    selected profile in the user ``profiles.yml``.
+    """
+
+    def __init__(self, parent: tk.Misc, service_registry: Any):
+        self.parent = parent
+        self.hooks: Dict[str, Any] = service_registry.get_service_providers(HOOK_SERVICE) if service_registry else {}
+        self.dialog: Optional[tk.Toplevel] = None
+        self.profile_var = tk.StringVar(value=_ALL_JOBS)
+        self.status_var = tk.StringVar(value="")
+        self._disabled: set = set()
+
+    def show_modal(self) -> None:
+        self.dialog = tk.Toplevel(self.parent)
+        self.dialog.title(tr("Conversion Hooks"))
+        self.dialog.geometry("560x360")
+        self.dialog.transient(self.parent)
+        self._setup_layout()
+        self._load()
+        self.dialog.grab_set()
+        self.parent.wait_window(self.dialog)
+
+    # ------------------------------------------------------------------
+    # Layout
+    # ------------------------------------------------------------------
+    def _setup_layout(self) -> None:
+        frame = ttk.Frame(self.dialog, padding=12)
+        frame.pack(fill="both", expand=True)
+        frame.columnconfigure(0, weight=1)
+        frame.rowconfigure(1, weight=1)
+
+        top = ttk.Frame(frame)
+        top.grid(row=0, column=0, columnspan=2, sticky="ew", pady=(0, 8))
+        ttk.Label(top, text=tr("Settings for:")).pack(side="left")
+        profiles = ttk.Combobox(top, textvariable=self.profile_var, state="readonly", width=24,
+                                values=[_ALL_JOBS] + self._profile_names())
+        profiles.pack(side="left", padx=(6, 0))
+        profiles.bind("<<ComboboxSelected>>", lambda _e: self._load())
+
+        self.tree = ttk.Treeview(frame, columns=("points", "enabled"), height=8, selectmode="browse")
+        self.tree.heading("#0", text=tr("Hook"))
+        self.tree.heading("points", text=tr("Runs at"))
+        self.tree.heading("enabled", text=tr("Enabled"))
+        self.tree.column("#0", width=180)
+        self.tree.column("enabled", width=70, stretch=False, anchor="center")
+        self.tree.grid(row=1, column=0, sticky="nsew")
+        self.tree.bind("<Double-1>", lambda _e: self._toggle())
+
+        side = ttk.Frame(frame)
+        side.grid(row=1, column=1, sticky="n", padx=(8, 0))
+        ttk.Button(side, text=tr("Move Up"), command=lambda: self._move(-1)).pack(fill="x")
+        ttk.Button(side, text=tr("Move Down"), command=lambda: self._move(1)).pack(fill="x", pady=(4, 0))
+        ttk.Button(side, text=tr("Enable/Disable"), command=self._toggle).pack(fill="x", pady=(12, 0))
+
+        bottom = ttk.Frame(frame)
+        bottom.grid(row=2, column=0, columnspan=2, sticky="ew", pady=(10, 0))
+        ttk.Label(bottom, textvariable=self.status_var, foreground="gray").pack(side="left")
+        ttk.Button(bottom, text=tr("Close"), command=self.dialog.destroy).pack(side="right")
+        ttk.Button(bottom, text=tr("Save"), style="Accent.TButton", command=self._save).pack(side="right", padx=(0, 6))
+
+    # ------------------------------------------------------------------
+    # Settings
+    # ------------------------------------------------------------------
+    @staticmethod
+    def _profile_names() -> List[str]:
+        try:
+            from orlando_toolkit.config import ConfigManager
+            return sorted(ConfigManager().get_profiles_config() or {})
+        except Exception as exc:
+            logger.debug("Profiles unavailable: %s", exc)
+            return []
+
+    def _profile(self) -> Optional[str]:
+        name = self.profile_var.get()
+        return None if name == _ALL_JOBS else name
+
+    def _settings(self) -> Dict[str, Any]:
+        from orlando_toolkit.config import ConfigManager
+        from orlando_toolkit.options import build_options, with_output_profile
+
+        settings = dict(ConfigManager().get_conversion_config().get("hooks") or {})
+        profile = self._profile()
+        if profile:
+            try:
+                settings.update(build_options(with_output_profile(profile)).conversion_options.get("hooks") or {})
+            except ValueError as exc:
+                logger.warning("Profile %s unavailable: %s", profile, exc)
+        return settings
+
+    def _load(self) -> None:
+        settings = self._settings()
+        order = [str(n) for n in settings.get("order") or () if str(n) in self.hooks]
+        self._disabled = {str(n) for n in settings.get("disabled") or ()}
+        self.tree.delete(*self.tree.get_children())
+        for name in dict.fromkeys(order + sorted(self.hooks)):
+            points = ", ".join(p for p, present in hook_points(self.hooks[name]).items() if present)
+            self.tree.insert("", "end", iid=name, text=name, values=(points, self._enabled_text(name)))
+        self.status_var.set("" if self.hooks else "No plugin or installed package provides conversion hooks.")
+
+    def _enabled_text(self, name: str) -> str:
+        return "No" if name in self._disabled else "Yes"
+
+    def _move(self, step: int) -> None:
+        selection = self.tree.selection()
+        if not selection:
+            return
+        index = self.tree.index(selection[0]) + step
+        if 0 <= index < len(self.tree.get_children()):
+            self.tree.move(selection[0], "", index)
+
+    def _toggle(self) -> None:
+        selection = self.tree.selection()
+        if not selection:
+            return
+        name = selection[0]
+        self._disabled.symmetric_difference_update({name})
+        self.tree.set(name, "enabled", self._enabled_text(name))
+
+    def _save(self) -> None:
+        from orlando_toolkit.config import ConfigManager
+
+        order = list(self.tree.get_children())
+        # Hooks not installed right now keep their disabled setting
+        disabled = [name for name in order if name in self._disabled] + sorted(self._disabled - set(order))
+        if ConfigManager().update_hook_settings(order, disabled, self._profile()):
+            self.status_var.set(f"Saved ({self.profile_var.get()})")
+        else:
+            messagebox.showerror(tr("Conversion Hooks"), tr("The settings could not be saved; see the log."),
+                                 parent=self.dialog)
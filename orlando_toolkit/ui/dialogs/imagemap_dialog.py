@@ -0,0 +1,249 @@
+from __future__ import annotations
+
+import io
+import tkinter as tk
+from tkinter import ttk
+from typing import Callable, List, Optional, Sequence, Tuple
+
+from PIL import Image, ImageTk
+
+from orlando_toolkit.core.imagemaps import MapArea
+from orlando_toolkit.ui.i18n import tr
+
+_MAX_SIZE = (820, 560)
+_AREA_COLOUR = "#d9822b"
+_SELECTED_COLOUR = "#0b5394"
+
+
+class ImageMapEditor(tk.Toplevel):
+    """Draw clickable areas over an image and link each one to a topic.
+
+    *targets* are the ``(topic file, title, depth)`` entries of the map
+    (``link_targets``), offered indented like the structure tree; an
+    ``http(s)://`` address may be typed instead. **Rectangle** draws by
+    dragging; **Polygon** adds a point per click and closes on double-click
+    or Return (Escape drops the points). Coordinates are kept in pixels of
+    the image whatever the zoom. ``on_save(areas)`` writes the image map and
+    returns a status message, or raises ValueError for an invalid area.
+    """
+
+    def __init__(self, master: tk.Widget, *, name: str, data: bytes, areas: Sequence[MapArea],
+                 targets: Sequence[Tuple[str, str, int]], on_save: Callable[[List[MapArea]], str]) -> None:
+        super().__init__(master)
+        self.title(f"Image Map – {name}")
+        self.transient(master)
+        self._areas: List[MapArea] = [MapArea(a.shape, list(a.coords), a.target, a.text) for a in areas]
+        self._targets = list(targets)
+        self._labels = [("    " * depth) + title for _name, title, depth in self._targets]
+        self._on_save = on_save
+        self._points: List[Tuple[int, int]] = []
+        self._drag_start: Optional[Tuple[int, int]] = None
+        self._rubber: Optional[int] = None
+
+        image = Image.open(io.BytesIO(data))
+        self._size = image.size
+        self._scale = min(1.0, _MAX_SIZE[0] / max(1, image.width), _MAX_SIZE[1] / max(1, image.height))
+        shown = image.resize((max(1, int(image.width * self._scale)), max(1, int(image.height * self._scale))))
+        self._photo = ImageTk.PhotoImage(shown)
+
+        self.columnconfigure(0, weight=1)
+        self.rowconfigure(1, weight=1)
+        tools = ttk.Frame(self)
+        tools.grid(row=0, column=0, columnspan=2, sticky="ew", padx=10, pady=(10, 4))
+        self._mode = tk.StringVar(value="rect")
+        ttk.Radiobutton(tools, text=tr("Rectangle"), value="rect", variable=self._mode,
+                        command=self._cancel_polygon).pack(side="left")
+        ttk.Radiobutton(tools, text=tr("Polygon"), value="poly", variable=self._mode,
+                        command=self._cancel_polygon).pack(side="left", padx=(8, 0))
+        self._status = tk.StringVar(value="Drag over the image to add an area.")
+        ttk.Label(tools, textvariable=self._status, foreground="#555555").pack(side="left", padx=(16, 0))
+
+        self._canvas = tk.Canvas(self, width=self._photo.width(), height=self._photo.height(),
+                                 highlightthickness=0, cursor="crosshair")
+        self._canvas.grid(row=1, column=0, sticky="nw", padx=(10, 6))
+        self._canvas.create_image(0, 0, image=self._photo, anchor="nw")
+        self._canvas.bind("<ButtonPress-1>", self._on_press)
+        self._canvas.bind("<B1-Motion>", self._on_drag)
+        self._canvas.bind("<ButtonRelease-1>", self._on_release)
+        self._canvas.bind("<Double-Button-1>", lambda _e: self._close_polygon())
+        self.bind("<Return>", lambda _e: self._close_polygon())
+        self.bind("<Escape>", lambda _e: self._cancel_polygon())
+
+        side = ttk.Frame(self)
+        side.grid(row=1, column=1, sticky="nsew", padx=(0, 10))
+        side.rowconfigure(0, weight=1)
+        self._list = ttk.Treeview(side, columns=("shape", "target"), show="headings", selectmode="browse", height=12)
+        self._list.heading("shape", text=tr("Area"))
+        self._list.heading("target", text=tr("Links To"))
+        self._list.column("shape", width=80, stretch=False)
+        self._list.column("target", width=200)
+        self._list.grid(row=0, column=0, columnspan=2, sticky="nsew")
+        self._list.bind("<<TreeviewSelect>>", self._on_area_selected)
+
+        ttk.Label(side, text=tr("Target:")).grid(row=1, column=0, sticky="w", pady=(8, 0))
+        self._target = ttk.Combobox(side, values=self._labels, width=32)
+        self._target.grid(row=2, column=0, columnspan=2, sticky="ew")
+        self._target.bind("<<ComboboxSelected>>", self._update_area)
+        self._target.bind("<FocusOut>", self._update_area)
+        ttk.Label(side, text=tr("Link text:")).grid(row=3, column=0, sticky="w", pady=(8, 0))
+        self._text = ttk.Entry(side)
+        self._text.grid(row=4, column=0, columnspan=2, sticky="ew")
+        self._text.bind("<FocusOut>", self._update_area)
+        self._text.bind("<Return>", self._update_area)
+        ttk.Button(side, text=tr("Delete Area"), command=self._delete_area).grid(row=5, column=0, sticky="w",
+                                                                             pady=(8, 0))
+
+        btns = ttk.Frame(self)
+        btns.grid(row=2, column=0, columnspan=2, sticky="ew", padx=10, pady=10)
+        ttk.Button(btns, text=tr("Save"), style="Accent.TButton", command=self._save).pack(side="right")
+        ttk.Button(btns, text=tr("Close"), command=self.destroy).pack(side="right", padx=(0, 6))
+
+        self._redraw()
+
+    # -- coordinates ------------------------------------------------------
+
+    def _to_image(self, x: float, y: float) -> Tuple[int, int]:
+        return (min(self._size[0], max(0, int(round(x / self._scale)))),
+                min(self._size[1], max(0, int(round(y / self._scale)))))
+
+    def _to_canvas(self, coords: Sequence[int]) -> List[float]:
+        return [value * self._scale for value in coords]
+
+    # -- targets ----------------------------------------------------------
+
+    def _target_value(self) -> str:
+        typed = self._target.get().strip()
+        for (name, _title, _depth), label in zip(self._targets, self._labels):
+            if typed == label.strip() or typed == name:
+                return name
+        return typed
+
+    def _target_label(self, target: str) -> str:
+        for (name, title, _depth) in self._targets:
+            if name == target:
+                return title
+        return target
+
+    # -- drawing ----------------------------------------------------------
+
+    def _on_press(self, event: tk.Event) -> None:
+        if self._mode.get() == "poly":
+            self._points.append(self._to_image(event.x, event.y))
+            self._status.set(f"{len(self._points)} point(s): double-click or Return to close, Escape to drop.")
+            self._redraw()
+            return
+        self._drag_start = (event.x, event.y)
+
+    def _on_drag(self, event: tk.Event) -> None:
+        if self._drag_start is None:
+            return
+        if self._rubber is not None:
+            self._canvas.delete(self._rubber)
+        self._rubber = self._canvas.create_rectangle(*self._drag_start, event.x, event.y, outline=_SELECTED_COLOUR,
+                                                     dash=(4, 2), width=2)
+
+    def _on_release(self, event: tk.Event) -> None:
+        if self._drag_start is None:
+            return
+        (x1, y1), (x2, y2) = self._to_image(*self._drag_start), self._to_image(event.x, event.y)
+        self._drag_start = None
+        if self._rubber is not None:
+            self._canvas.delete(self._rubber)
+            self._rubber = None
+        if abs(x2 - x1) < 3 or abs(y2 - y1) < 3:
+            return
+        self._add(MapArea("rect", [min(x1, x2), min(y1, y2), max(x1, x2), max(y1, y2)]))
+
+    def _close_polygon(self) -> None:
+        points = list(dict.fromkeys(self._points))
+        self._points = []
+        if len(points) >= 3:
+            self._add(MapArea("poly", [value for point in points for value in point]))
+        else:
+            self._status.set("A polygon needs at least three points.")
+            self._redraw()
+
+    def _cancel_polygon(self) -> None:
+        self._points = []
+        self._status.set("Drag over the image to add an area." if self._mode.get() == "rect"
+                         else "Click the corners of the area.")
+        self._redraw()
+
+    def _add(self, area: MapArea) -> None:
+        area.target = self._target_value()
+        area.text = self._text.get().strip()
+        self._areas.append(area)
+        self._redraw(select=len(self._areas) - 1)
+        self._status.set("Area added: choose the topic it links to." if not area.target else "Area added.")
+
+    def _redraw(self, select: Optional[int] = None) -> None:
+        current = self._selected_index() if select is None else select
+        self._draw(current)
+        self._list.delete(*self._list.get_children(""))
+        for index, area in enumerate(self._areas):
+            self._list.insert("", "end", iid=str(index),
+                              values=(f"{index + 1}. {area.shape}", self._target_label(area.target) or "—"))
+        if current is not None and 0 <= current < len(self._areas):
+            self._list.selection_set(str(current))
+
+    def _draw(self, current: Optional[int]) -> None:
+        self._canvas.delete("area")
+        for index, area in enumerate(self._areas):
+            colour = _SELECTED_COLOUR if index == current else _AREA_COLOUR
+            coords = self._to_canvas(area.coords)
+            if area.shape == "rect" and len(coords) == 4:
+                self._canvas.create_rectangle(*coords, outline=colour, width=2, tags="area")
+            elif area.shape == "poly" and len(coords) >= 6:
+                self._canvas.create_polygon(*coords, outline=colour, fill="", width=2, tags="area")
+            elif area.shape == "circle" and len(coords) == 3:
+                x, y, r = coords
+                self._canvas.create_oval(x - r, y - r, x + r, y + r, outline=colour, width=2, tags="area")
+        if self._points:
+            coords = self._to_canvas([value for point in self._points for value in point])
+            if len(coords) >= 4:
+                self._canvas.create_line(*coords, fill=_SELECTED_COLOUR, width=2, tags="area")
+            for x, y in zip(coords[::2], coords[1::2]):
+                self._canvas.create_oval(x - 3, y - 3, x + 3, y + 3, fill=_SELECTED_COLOUR, tags="area")
+
+    # -- area list --------------------------------------------------------
+
+    def _selected_index(self) -> Optional[int]:
+        selection = self._list.selection()
+        return int(selection[0]) if selection else None
+
+    def _on_area_selected(self, _event: Optional[tk.Event] = None) -> None:
+        index = self._selected_index()
+        if index is None:
+            return
+        area = self._areas[index]
+        label = next((label.strip() for (name, _t, _d), label in zip(self._targets, self._labels)
+                      if name == area.target), area.target)
+        self._target.set(label)
+        self._text.delete(0, tk.END)
+        self._text.insert(0, area.text)
+        self._draw(index)
+
+    def _update_area(self, _event: Optional[tk.Event] = None) -> None:
+        index = self._selected_index()
+        if index is None:
+            return
+        area = self._areas[index]
+        target, text = self._target_value(), self._text.get().strip()
+        if (target, text) != (area.target, area.text):
+            area.target, area.text = target, text
+            self._redraw(select=index)
+
+    def _delete_area(self) -> None:
+        index = self._selected_index()
+        if index is None:
+            return
+        del self._areas[index]
+        self._redraw(select=min(index, len(self._areas) - 1) if self._areas else None)
+
+    def _save(self) -> None:
+        self._update_area()
+        try:
+            self._status.set(self._on_save([MapArea(a.shape, list(a.coords), a.target, a.text)
+                                            for a in self._areas]))
+        except ValueError as exc:
+            self._status.set(str(exc))
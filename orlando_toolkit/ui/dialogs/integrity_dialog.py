@@ -0,0 +1,123 @@
+from __future__ import annotations
+
+import tkinter as tk
+from tkinter import ttk
+from typing import Any, Callable, Dict, List, Optional, Tuple
+from orlando_toolkit.ui.i18n import tr
+
+_KINDS = {
+    "dangling_link": "Broken link",
+    "dangling_conref": "Broken conref",
+    "orphan_topic": "Orphaned topic",
+    "unused_image": "Unused image",
+}
+
+
+class IntegrityPanel(tk.Toplevel):
+    """Broken links, orphaned topics and unused images with quick fixes.
+
+    ``check()`` returns the ``IntegrityIssue`` list shown; ``fix(issue,
+    action, target)`` applies a fix and returns its ``OperationResult``, after
+    which the list is checked again. Selecting a row calls ``on_select(topic)``
+    so the structure tree shows the topic concerned. *topics* are the
+    ``(file name, title)`` pairs offered as retarget destinations.
+    """
+
+    def __init__(self, master: tk.Widget, *, check: Callable[[], List[Any]],
+                 fix: Callable[[Any, str, Optional[str]], Any], on_select: Callable[[Optional[str]], None],
+                 topics: Callable[[], List[Tuple[str, str]]]) -> None:
+        super().__init__(master)
+        self.title(tr("Check Links"))
+        self.transient(master)
+        self.geometry("860x420")
+        self._check, self._fix, self._on_select, self._topics = check, fix, on_select, topics
+        self._issues: Dict[str, Any] = {}
+        self._targets: Dict[str, str] = {}
+
+        self.columnconfigure(0, weight=1)
+        self.rowconfigure(1, weight=1)
+        self._status = tk.StringVar()
+        ttk.Label(self, textvariable=self._status).grid(row=0, column=0, sticky="w", padx=10, pady=(10, 4))
+
+        frame = ttk.Frame(self)
+        frame.grid(row=1, column=0, sticky="nsew", padx=10)
+        frame.columnconfigure(0, weight=1)
+        frame.rowconfigure(0, weight=1)
+        columns = ("kind", "topic", "target", "message")
+        self._tree = ttk.Treeview(frame, columns=columns, show="headings", selectmode="browse")
+        for column, heading, width, stretch in (("kind", "Problem", 120, False), ("topic", "Topic", 180, False),
+                                                ("target", "Target", 200, False), ("message", "Details", 340, True)):
+            self._tree.heading(column, text=heading)
+            self._tree.column(column, width=width, stretch=stretch, anchor="w")
+        self._tree.grid(row=0, column=0, sticky="nsew")
+        vsb = ttk.Scrollbar(frame, orient="vertical", command=self._tree.yview)
+        self._tree.configure(yscrollcommand=vsb.set)
+        vsb.grid(row=0, column=1, sticky="ns")
+        self._tree.bind("<<TreeviewSelect>>", self._on_row_selected)
+
+        actions = ttk.Frame(self)
+        actions.grid(row=2, column=0, sticky="ew", padx=10, pady=(8, 0))
+        self._target_var = tk.StringVar()
+        self._target_box = ttk.Combobox(actions, textvariable=self._target_var, state="readonly", width=36)
+        self._target_box.pack(side="left")
+        self._buttons = {
+            "retarget": ttk.Button(actions, text=tr("Retarget"), command=lambda: self._apply("retarget")),
+            "remove": ttk.Button(actions, text=tr("Remove Link"), command=lambda: self._apply("remove")),
+            "reattach": ttk.Button(actions, text=tr("Re-attach to Map"), command=lambda: self._apply("reattach")),
+            "delete": ttk.Button(actions, text=tr("Delete"), command=lambda: self._apply("delete")),
+        }
+        for action, button in self._buttons.items():
+            button.pack(side="left", padx=(6 if action == "retarget" else 12, 0))
+
+        btns = ttk.Frame(self)
+        btns.grid(row=3, column=0, sticky="ew", padx=10, pady=10)
+        ttk.Button(btns, text=tr("Check Again"), command=self.refresh).pack(side="left")
+        ttk.Button(btns, text=tr("Close"), command=self.destroy).pack(side="right")
+        self.bind("<Escape>", lambda _e: self.destroy())
+
+        self.refresh()
+
+    def refresh(self) -> None:
+        """Check again and list the issues found."""
+        self._tree.delete(*self._tree.get_children(""))
+        self._issues.clear()
+        for index, issue in enumerate(self._check()):
+            iid = str(index)
+            self._issues[iid] = issue
+            self._tree.insert("", "end", iid=iid, values=(_KINDS.get(issue.kind, issue.kind), issue.topic or "",
+                                                          issue.target, issue.message))
+        choices = self._topics()
+        self._targets = {f"{title} ({name})" if title else name: name for name, title in choices}
+        self._target_box.configure(values=list(self._targets))
+        self._status.set(f"{len(self._issues)} problem(s) found." if self._issues else "No problem found.")
+        self._update_buttons()
+
+    def _selected(self) -> Optional[Any]:
+        selection = self._tree.selection()
+        return self._issues.get(selection[0]) if selection else None
+
+    def _update_buttons(self) -> None:
+        issue = self._selected()
+        fixes = issue.fixes if issue is not None else ()
+        for action, button in self._buttons.items():
+            button.configure(state="normal" if action in fixes else "disabled")
+        self._target_box.configure(state="readonly" if "retarget" in fixes else "disabled")
+
+    def _on_row_selected(self, _event: Optional[tk.Event] = None) -> None:
+        self._update_buttons()
+        issue = self._selected()
+        if issue is not None and issue.topic:
+            self._on_select(issue.topic)
+
+    def _apply(self, action: str) -> None:
+        issue = self._selected()
+        if issue is None:
+            return
+        target = self._targets.get(self._target_var.get()) if action == "retarget" else None
+        if action == "retarget" and not target:
+            self._status.set("Choose the topic the link should point to.")
+            return
+        result = self._fix(issue, action, target)
+        self.refresh()
+        if result is not None:
+            self._status.set(getattr(result, "message", "") or self._status.get())
@@ -11,6 +11,7 @@ from pathlib import Path
 from PIL import Image, ImageTk
 from orlando_toolkit.core.plugins.manager import PluginManager
 from orlando_toolkit.ui.widgets.universal_spinner import UniversalSpinner
+from orlando_toolkit.ui.i18n import tr
 
 logger = logging.getLogger(__name__)
 
@@ -46,7 +47,7 @@ class SimplePluginManagerDialog:
     def _create_dialog(self) -> None:
         """Create the dialog window."""
         self.dialog = tk.Toplevel(self.parent)
-        self.dialog.title("Plugin Manager")
+        self.dialog.title(tr("Plugin Manager"))
         self.dialog.geometry("800x500")
         self.dialog.minsize(700, 400)
         self.dialog.protocol("WM_DELETE_WINDOW", self._on_close)
@@ -61,7 +62,7 @@ class SimplePluginManagerDialog:
         main_frame.grid_rowconfigure(2, weight=1)
         
         # Title
-        ttk.Label(main_frame, text="Plugin Manager", font=("Arial", 14, "bold")).grid(
+        ttk.Label(main_frame, text=tr("Plugin Manager"), font=("Arial", 14, "bold")).grid(
             row=0, column=0, pady=(0, 15), sticky="w")
         
         # URL input section
@@ -69,10 +70,10 @@ class SimplePluginManagerDialog:
         url_frame.grid(row=1, column=0, sticky="ew", pady=(0, 15))
         url_frame.grid_columnconfigure(1, weight=1)
         
-        ttk.Label(url_frame, text="GitHub Repository URL:").grid(row=0, column=0, sticky="w")
+        ttk.Label(url_frame, text=tr("GitHub Repository URL:")).grid(row=0, column=0, sticky="w")
         ttk.Entry(url_frame, textvariable=self.github_url_var, font=("Arial", 10)).grid(
             row=0, column=1, sticky="ew", padx=(10, 10))
-        ttk.Button(url_frame, text="Add Plugin", 
+        ttk.Button(url_frame, text=tr("Add Plugin"), 
                   command=self._import_from_github).grid(row=0, column=2, sticky="e")
         
         # Scrollable plugin list
@@ -101,7 +102,9 @@ class SimplePluginManagerDialog:
         status_frame = ttk.Frame(main_frame)
         status_frame.grid(row=3, column=0, columnspan=2, sticky="ew")
         ttk.Label(status_frame, textvariable=self.status_var).pack(side="left")
-        ttk.Button(status_frame, text="Close", command=self._on_close).pack(side="right")
+        ttk.Button(status_frame, text=tr("Close"), command=self._on_close).pack(side="right")
+        ttk.Button(status_frame, text=tr("Conversion Hooks…"),
+                   command=self._open_hook_settings).pack(side="right", padx=(0, 6))
     
     def _center_dialog(self) -> None:
         """Center dialog on parent window."""
@@ -171,7 +174,7 @@ class SimplePluginManagerDialog:
                 empty_frame.grid_columnconfigure(0, weight=1)
                 
                 empty_label = ttk.Label(empty_frame,
-                    text="No plugins available.\nAdd plugins from GitHub to get started.",
+                    text=tr("No plugins available.\nAdd plugins from GitHub to get started."),
                     justify="center", foreground="gray", font=("Arial", 12))
                 empty_label.grid(row=0, column=0, sticky="")
             else:
@@ -258,9 +261,10 @@ class SimplePluginManagerDialog:
         name_label.grid(row=0, column=1, sticky="w", pady=(0, 2))
         
         # Metadata row: Author and version
-        author = metadata.get("author", "Unknown Author")
+        author = metadata.get("author", tr("Unknown Author"))
         version = metadata["version"]
-        author_label = ttk.Label(card_frame, text=f"By {author}", font=("Arial", 9), foreground="#666666")
+        author_label = ttk.Label(card_frame, text=tr("By {author}", author=author), font=("Arial", 9),
+                                 foreground="#666666")
         author_label.grid(row=1, column=1, sticky="w")
         version_label = ttk.Label(card_frame, text=f"v{version}", font=("Arial", 9), foreground="#666666")
         version_label.grid(row=1, column=1, sticky="e")
@@ -275,7 +279,7 @@ class SimplePluginManagerDialog:
         status_frame = ttk.Frame(card_frame)
         status_frame.grid(row=3, column=1, sticky="w", pady=(4, 0))
         ttk.Label(status_frame, text="\u2713", font=("Arial", 10), foreground="green").pack(side="left")
-        ttk.Label(status_frame, text="Ready to Install", foreground="green", font=("Arial", 9)).pack(side="left", padx=(4, 0))
+        ttk.Label(status_frame, text=tr("Ready to Install"), foreground="green", font=("Arial", 9)).pack(side="left", padx=(4, 0))
         
         # Action buttons
         button_frame = ttk.Frame(card_frame)
@@ -287,7 +291,7 @@ class SimplePluginManagerDialog:
         repo_btn.pack(side="left")
         
         # Install button
-        install_btn = ttk.Button(button_frame, text="Install", width=10,
+        install_btn = ttk.Button(button_frame, text=tr("Install"), width=10,
                                command=lambda: self._install_fetched_plugin(repo_url))
         install_btn.pack(side="right")
     
@@ -307,9 +311,10 @@ class SimplePluginManagerDialog:
         name_label.grid(row=0, column=1, sticky="w", pady=(0, 2))
         
         # Metadata row: Author and version
-        author = metadata.author or "Unknown Author"
+        author = metadata.author or tr("Unknown Author")
         version = metadata.version or '1.0.0'
-        author_label = ttk.Label(card_frame, text=f"By {author}", font=("Arial", 9), foreground="#666666")
+        author_label = ttk.Label(card_frame, text=tr("By {author}", author=author), font=("Arial", 9),
+                                 foreground="#666666")
         author_label.grid(row=1, column=1, sticky="w")
         version_label = ttk.Label(card_frame, text=f"v{version}", font=("Arial", 9), foreground="#666666")
         version_label.grid(row=1, column=1, sticky="e")
@@ -339,7 +344,7 @@ class SimplePluginManagerDialog:
         # Repository link button - try to get the actual repository URL
         repo_url = self._get_plugin_repository_url(plugin_id, metadata)
         if repo_url:
-            repo_btn = ttk.Button(button_frame, text="\U0001f517 Repo", width=8,
+            repo_btn = ttk.Button(button_frame, text=tr("\U0001f517 Repo"), width=8,
                                  command=lambda: self._open_repository_url(repo_url))
             repo_btn.pack(side="left")
         
@@ -348,13 +353,13 @@ class SimplePluginManagerDialog:
         controls_frame.pack(side="right")
         
         if is_active:
-            ttk.Button(controls_frame, text="Deactivate", width=10,
+            ttk.Button(controls_frame, text=tr("Deactivate"), width=10,
                      command=lambda: self._plugin_action("deactivate", plugin_id)).pack(side="left", padx=(0, 4))
         else:
-            ttk.Button(controls_frame, text="Activate", width=10,
+            ttk.Button(controls_frame, text=tr("Activate"), width=10,
                      command=lambda: self._plugin_action("activate", plugin_id)).pack(side="left", padx=(0, 4))
         
-        ttk.Button(controls_frame, text="Remove", width=8,
+        ttk.Button(controls_frame, text=tr("Remove"), width=8,
                  command=lambda: self._plugin_action("remove", plugin_id)).pack(side="left")
     
     def _create_plugin_icon_from_data(self, parent: ttk.Frame, plugin_info: dict) -> ttk.Label:
@@ -408,10 +413,10 @@ class SimplePluginManagerDialog:
         """Import plugin from GitHub URL."""
         repo_url = self.github_url_var.get().strip()
         if not repo_url:
-            messagebox.showerror("Invalid URL", "Please enter a GitHub repository URL", parent=self.dialog)
+            messagebox.showerror(tr("Invalid URL"), tr("Please enter a GitHub repository URL"), parent=self.dialog)
         elif not repo_url.startswith("https://github.com/"):
-            messagebox.showerror("Invalid URL", 
-                               "Please enter a valid GitHub repository URL\\n(https://github.com/owner/repo)", 
+            messagebox.showerror(tr("Invalid URL"), 
+                               tr("Please enter a valid GitHub repository URL\n(https://github.com/owner/repo)"), 
                                parent=self.dialog)
         else:
             self._run_operation("add", repo_url)
@@ -527,10 +532,10 @@ class SimplePluginManagerDialog:
             # Handle failure case
             if isinstance(result, dict) and 'success' in result and not result['success']:
                 self._set_status("Operation failed")
-                messagebox.showerror("Operation Failed", result.get('message', 'Unknown error'), parent=self.dialog)
+                messagebox.showerror(tr("Operation Failed"), result.get('message', 'Unknown error'), parent=self.dialog)
             elif hasattr(result, 'success') and not result.success:
                 self._set_status("Operation failed") 
-                messagebox.showerror("Operation Failed", getattr(result, 'message', 'Unknown error'), parent=self.dialog)
+                messagebox.showerror(tr("Operation Failed"), getattr(result, 'message', 'Unknown error'), parent=self.dialog)
             else:
                 self._set_status("Operation completed")
     
@@ -658,7 +663,17 @@ class SimplePluginManagerDialog:
             self._logger.debug("Opened repository URL: %s", url)
         except Exception as e:
             self._logger.error("Failed to open repository URL %s: %s", url, e)
-            messagebox.showerror("Error", f"Failed to open repository URL:\\n{url}", parent=self.dialog)
+            messagebox.showerror(tr("Error"), f"Failed to open repository URL:\\n{url}", parent=self.dialog)
+
+    def _open_hook_settings(self) -> None:
+        """Order and enable the conversion hooks per output profile."""
+        try:
+            from orlando_toolkit.ui.dialogs.hook_order_dialog import HookOrderDialog
+            loader = getattr(self.plugin_manager, "plugin_loader", None)
+            HookOrderDialog(self.dialog, getattr(loader, "service_registry", None)).show_modal()
+        except Exception as e:
+            self._logger.error("Failed to open conversion hook settings: %s", e)
+            messagebox.showerror(tr("Error"), f"Failed to open conversion hook settings:\n{e}", parent=self.dialog)
 
     def _on_close(self) -> None:
         """Handle dialog close event."""
@@ -0,0 +1,117 @@
+from __future__ import annotations
+
+import os
+import subprocess
+import tkinter as tk
+from pathlib import Path
+from tkinter import ttk
+from typing import Dict, Optional
+
+from orlando_toolkit.core.cancellation import CancellationToken
+from orlando_toolkit.ui.i18n import tr
+
+
+class PublishDialog(tk.Toplevel):
+    """Progress dialog for DITA-OT publishing.
+
+    Shows the DITA-OT log as it is produced. :meth:`append` and
+    :meth:`finish` may be called from worker threads; they are scheduled on
+    the Tk main loop. Cancel cancels *cancel_token*.
+    """
+
+    def __init__(self, master: tk.Widget, *, cancel_token: CancellationToken) -> None:
+        super().__init__(master)
+        self.title(tr("Publishing"))
+        self.resizable(True, True)
+        self.transient(master)
+        self.geometry("720x420")
+        self._token = cancel_token
+        self._folder: Optional[Path] = None
+
+        self.columnconfigure(0, weight=1)
+        self.rowconfigure(1, weight=1)
+
+        self._status = ttk.Label(self, text=tr("Generating package…"))
+        self._status.grid(row=0, column=0, sticky="w", padx=8, pady=(8, 4))
+
+        log_frame = ttk.Frame(self)
+        log_frame.grid(row=1, column=0, sticky="nsew", padx=8)
+        log_frame.columnconfigure(0, weight=1)
+        log_frame.rowconfigure(0, weight=1)
+        self._log = tk.Text(log_frame, height=16, wrap="none", state="disabled", font=("Courier", 9))
+        self._log.grid(row=0, column=0, sticky="nsew")
+        vsb = ttk.Scrollbar(log_frame, orient="vertical", command=self._log.yview)
+        self._log.configure(yscrollcommand=vsb.set)
+        vsb.grid(row=0, column=1, sticky="ns")
+
+        self._progress = ttk.Progressbar(self, mode="indeterminate")
+        self._progress.grid(row=2, column=0, sticky="ew", padx=8, pady=8)
+        self._progress.start(12)
+
+        btns = ttk.Frame(self)
+        btns.grid(row=3, column=0, sticky="e", padx=8, pady=(0, 8))
+        self._open_button = ttk.Button(btns, text=tr("Open Folder"), command=self._open_folder, state="disabled")
+        self._open_button.grid(row=0, column=0, padx=(0, 6))
+        self._close_button = ttk.Button(btns, text=tr("Cancel"), command=self._on_cancel)
+        self._close_button.grid(row=0, column=1)
+        self.protocol("WM_DELETE_WINDOW", self._on_cancel)
+
+    # Thread-safe entry points -------------------------------------------
+
+    def append(self, line: str) -> None:
+        self.after(0, self._append, line)
+
+    def status(self, message: str) -> None:
+        self.after(0, lambda: self._status.configure(text=message))
+
+    def finish(self, outputs: Optional[Dict[str, Path]] = None, error: Optional[str] = None) -> None:
+        self.after(0, self._finish, outputs, error)
+
+    # Main loop ----------------------------------------------------------
+
+    def _append(self, line: str) -> None:
+        try:
+            self._log.configure(state="normal")
+            self._log.insert(tk.END, line + "\n")
+            self._log.see(tk.END)
+            self._log.configure(state="disabled")
+        except tk.TclError:
+            pass
+
+    def _finish(self, outputs: Optional[Dict[str, Path]], error: Optional[str]) -> None:
+        try:
+            self._progress.stop()
+            self._progress.configure(mode="determinate", value=0 if error else 100)
+            if error:
+                self._status.configure(text=tr("Publishing failed"))
+                self._append(error)
+            elif outputs:
+                self._status.configure(text=tr("Published: {outputs}", outputs=", ".join(outputs)))
+                for transtype, folder in outputs.items():
+                    self._append(f"{transtype}: {folder}")
+                self._folder = next(iter(outputs.values())).parent
+                self._open_button.configure(state="normal")
+            else:
+                self._status.configure(text=tr("Cancelled"))
+            self._close_button.configure(text=tr("Close"), command=self.destroy)
+            self.protocol("WM_DELETE_WINDOW", self.destroy)
+        except tk.TclError:
+            pass
+
+    def _on_cancel(self) -> None:
+        self._token.cancel("Publishing cancelled")
+        self._status.configure(text=tr("Cancelling…"))
+        self._close_button.configure(state="disabled")
+
+    def _open_folder(self) -> None:
+        if self._folder is None:
+            return
+        try:
+            if os.name == "nt":
+                os.startfile(str(self._folder))
+            elif "darwin" in os.uname().sysname.lower():
+                subprocess.run(["open", str(self._folder)])
+            else:
+                subprocess.run(["xdg-open", str(self._folder)])
+        except Exception:
+            pass
@@ -0,0 +1,81 @@
+"""Point the references to one image (often a deleted one) at another image."""
+
+from __future__ import annotations
+
+import tkinter as tk
+from tkinter import ttk
+from typing import Dict, List, Optional, Tuple
+from orlando_toolkit.ui.i18n import tr
+
+
+class RelinkImageDialog:
+    """Choose the referenced image to relink and the image to show instead.
+
+    Use: choice = RelinkImageDialog(parent, usage, images, missing, target).show_modal()
+    ``choice`` is ``(old, new, remove_old)``, or ``None`` when cancelled.
+    *usage* maps each referenced name to the topics using it; names in
+    *missing* are listed first and marked as missing.
+    """
+
+    def __init__(self, parent: tk.Misc, usage: Dict[str, List[str]], images: List[str], missing: List[str],
+                 target: Optional[str] = None):
+        self.parent = parent
+        self.usage = usage
+        self.images = images
+        self.sources = list(missing) + [name for name in usage if name not in missing]
+        self.missing = set(missing)
+        self.target = target if target in images else (images[0] if images else "")
+        self.result: Optional[Tuple[str, str, bool]] = None
+        self.dialog: Optional[tk.Toplevel] = None
+
+    def _label(self, name: str) -> str:
+        count = len(self.usage.get(name, []))
+        return f"{name} ({'missing, ' if name in self.missing else ''}{count} topic(s))"
+
+    def show_modal(self) -> Optional[Tuple[str, str, bool]]:
+        self.dialog = tk.Toplevel(self.parent)
+        self.dialog.title(tr("Relink Image References"))
+        self.dialog.transient(self.parent)
+        frame = ttk.Frame(self.dialog, padding=12)
+        frame.pack(fill="both", expand=True)
+        frame.columnconfigure(1, weight=1)
+        ttk.Label(frame, text=tr("Every topic showing the first image will show the second one instead."),
+                  foreground="gray", wraplength=460, justify="left").grid(row=0, column=0, columnspan=2,
+                                                                           sticky="w", pady=(0, 8))
+        labels = [self._label(name) for name in self.sources]
+        ttk.Label(frame, text=tr("References to:")).grid(row=1, column=0, sticky="w", padx=(0, 6))
+        self.source_combo = ttk.Combobox(frame, values=labels, state="readonly", width=48)
+        self.source_combo.grid(row=1, column=1, sticky="ew", pady=2)
+        if labels:
+            self.source_combo.current(0)
+        ttk.Label(frame, text=tr("Show instead:")).grid(row=2, column=0, sticky="w", padx=(0, 6))
+        self.target_var = tk.StringVar(value=self.target)
+        ttk.Combobox(frame, values=self.images, textvariable=self.target_var, state="readonly",
+                     width=48).grid(row=2, column=1, sticky="ew", pady=2)
+        self.remove_var = tk.BooleanVar(value=False)
+        ttk.Checkbutton(frame, text=tr("Remove the old image file from the package"),
+                        variable=self.remove_var).grid(row=3, column=1, sticky="w", pady=(6, 0))
+        self.error_label = ttk.Label(frame, text="", foreground="#cc0000")
+        self.error_label.grid(row=4, column=0, columnspan=2, sticky="w", pady=(6, 0))
+
+        bottom = ttk.Frame(frame)
+        bottom.grid(row=5, column=0, columnspan=2, sticky="ew", pady=(10, 0))
+        ttk.Button(bottom, text=tr("Cancel"), command=self.dialog.destroy).pack(side="right")
+        ttk.Button(bottom, text=tr("Relink"), style="Accent.TButton", command=self._relink).pack(side="right",
+                                                                                            padx=(0, 6))
+        self.dialog.grab_set()
+        self.parent.wait_window(self.dialog)
+        return self.result
+
+    def _relink(self) -> None:
+        index = self.source_combo.current()
+        target = self.target_var.get()
+        if index < 0 or not target:
+            self.error_label.configure(text=tr("Choose both images"))
+            return
+        source = self.sources[index]
+        if source == target:
+            self.error_label.configure(text=tr("Choose a different image to show"))
+            return
+        self.result = (source, target, bool(self.remove_var.get()))
+        self.dialog.destroy()
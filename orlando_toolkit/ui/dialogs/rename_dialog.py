@@ -2,6 +2,7 @@ from __future__ import annotations
 
 import tkinter as tk
 from tkinter import ttk
+from orlando_toolkit.ui.i18n import tr
 
 
 class RenameDialog:
@@ -70,8 +71,8 @@ class RenameDialog:
             except Exception:
                 pass
 
-        btn_ok = ttk.Button(btns, text="OK", style="Accent.TButton", command=_ok)
-        btn_cancel = ttk.Button(btns, text="Cancel", command=_cancel)
+        btn_ok = ttk.Button(btns, text=tr("OK"), style="Accent.TButton", command=_ok)
+        btn_cancel = ttk.Button(btns, text=tr("Cancel"), command=_cancel)
         btn_cancel.grid(row=0, column=0, padx=(0, 6))
         btn_ok.grid(row=0, column=1)
 
@@ -0,0 +1,94 @@
+from __future__ import annotations
+
+import tkinter as tk
+from tkinter import ttk
+from typing import List, Optional, Sequence
+
+from orlando_toolkit.core.reuse import ReuseCandidate
+from orlando_toolkit.ui.i18n import tr
+
+_CHECKED = "☑"
+_UNCHECKED = "☐"
+_PREVIEW_CHARS = 90
+
+
+class ReuseDialog:
+    """Review of the repeated blocks proposed for conref reuse.
+
+    Use: chosen = ReuseDialog.ask(parent, candidates)
+    Lists each block with its number of copies and topics; every block is
+    checked by default and a click toggles it. Returns the checked
+    candidates, or None if cancelled.
+    """
+
+    @staticmethod
+    def ask(parent: tk.Widget, candidates: Sequence[ReuseCandidate]) -> Optional[List[ReuseCandidate]]:
+        top = tk.Toplevel(parent)
+        top.title(tr("Reuse Content"))
+        try:
+            top.transient(parent.winfo_toplevel())
+            top.grab_set()
+        except Exception:
+            pass
+        top.geometry("860x420")
+        top.columnconfigure(0, weight=1)
+        top.rowconfigure(1, weight=1)
+
+        ttk.Label(top, text=tr("{n} repeated block(s) can be shared. Checked blocks move to a reuse topic and each "
+                               "copy becomes a reference to it.", n=len(candidates)),
+                  wraplength=820).grid(row=0, column=0, sticky="w", padx=10, pady=(10, 6))
+
+        frame = ttk.Frame(top)
+        frame.grid(row=1, column=0, sticky="nsew", padx=10)
+        frame.columnconfigure(0, weight=1)
+        frame.rowconfigure(0, weight=1)
+        columns = ("apply", "copies", "topics", "kind", "text")
+        tree = ttk.Treeview(frame, columns=columns, show="headings", selectmode="browse")
+        for column, heading, width, stretch in (("apply", "", 32, False), ("copies", "Copies", 60, False),
+                                                ("topics", "Topics", 60, False), ("kind", "Element", 110, False),
+                                                ("text", "Text", 540, True)):
+            tree.heading(column, text=heading)
+            tree.column(column, width=width, stretch=stretch, anchor="w" if stretch else "center")
+        tree.grid(row=0, column=0, sticky="nsew")
+        vsb = ttk.Scrollbar(frame, orient="vertical", command=tree.yview)
+        tree.configure(yscrollcommand=vsb.set)
+        vsb.grid(row=0, column=1, sticky="ns")
+
+        checked = {str(i): True for i in range(len(candidates))}
+        for i, candidate in enumerate(candidates):
+            text = candidate.text if len(candidate.text) <= _PREVIEW_CHARS else candidate.text[:_PREVIEW_CHARS] + "…"
+            tree.insert("", "end", iid=str(i),
+                        values=(_CHECKED, candidate.count, len(candidate.topics), candidate.kind, text))
+
+        def _set(iid: str, value: bool) -> None:
+            checked[iid] = value
+            tree.set(iid, "apply", _CHECKED if value else _UNCHECKED)
+
+        def _toggle(event) -> None:
+            iid = tree.identify_row(event.y)
+            if iid:
+                _set(iid, not checked[iid])
+
+        tree.bind("<Button-1>", _toggle, add=True)
+        tree.bind("<space>", lambda _e: [_set(i, not checked[i]) for i in tree.selection()])
+
+        result: List[Optional[List[ReuseCandidate]]] = [None]
+
+        def _apply() -> None:
+            result[0] = [c for i, c in enumerate(candidates) if checked[str(i)]]
+            top.destroy()
+
+        btns = ttk.Frame(top)
+        btns.grid(row=2, column=0, sticky="ew", padx=10, pady=10)
+        ttk.Button(btns, text=tr("Select All"), command=lambda: [_set(i, True) for i in checked]).pack(side="left")
+        ttk.Button(btns, text=tr("Select None"),
+                   command=lambda: [_set(i, False) for i in checked]).pack(side="left", padx=(6, 0))
+        ttk.Button(btns, text=tr("Apply"), style="Accent.TButton", command=_apply).pack(side="right")
+        ttk.Button(btns, text=tr("Cancel"), command=top.destroy).pack(side="right", padx=(0, 6))
+        top.bind("<Escape>", lambda _e: top.destroy())
+
+        try:
+            top.wait_window()
+        except Exception:
+            pass
+        return result[0]
@@ -0,0 +1,140 @@
+from __future__ import annotations
+
+import tkinter as tk
+from tkinter import ttk
+from typing import Callable, Dict, List, Optional, Sequence
+
+from orlando_toolkit.core.reuse import NoticeGroup, NoticeOccurrence
+from orlando_toolkit.ui.i18n import tr
+
+_CHECKED = "☑"
+_UNCHECKED = "☐"
+_PREVIEW_CHARS = 90
+
+
+def _preview(text: str) -> str:
+    return text if len(text) <= _PREVIEW_CHARS else text[:_PREVIEW_CHARS] + "…"
+
+
+class SafetyNoticeDialog:
+    """Review of the safety notices proposed for the warehouse topic.
+
+    Use: chosen = SafetyNoticeDialog.ask(parent, groups, title_for=...)
+    Lists each shared notice with the wording kept and, below it, every
+    occurrence with its topic and how close its wording is. Occurrences are
+    accepted by default; a click rejects or accepts one, a click on the
+    notice row all of them. Returns the groups with ``accepted`` set on their
+    occurrences, or None if cancelled.
+    """
+
+    @staticmethod
+    def ask(parent: tk.Widget, groups: Sequence[NoticeGroup],
+            title_for: Optional[Callable[[str], str]] = None) -> Optional[List[NoticeGroup]]:
+        top = tk.Toplevel(parent)
+        top.title(tr("Safety Notices"))
+        try:
+            top.transient(parent.winfo_toplevel())
+            top.grab_set()
+        except Exception:
+            pass
+        top.geometry("900x460")
+        top.columnconfigure(0, weight=1)
+        top.rowconfigure(1, weight=1)
+
+        notices = sum(g.count for g in groups)
+        reworded = sum(len(g.variants) for g in groups)
+        ttk.Label(top, text=tr("{n} safety notice(s) can be single-sourced as {shared} shared notice(s); {reworded} "
+                               "would take the wording shown on the notice row. Unchecked occurrences keep their "
+                               "own text.", n=notices, shared=len(groups), reworded=reworded),
+                  wraplength=860).grid(row=0, column=0, sticky="w", padx=10, pady=(10, 6))
+
+        frame = ttk.Frame(top)
+        frame.grid(row=1, column=0, sticky="nsew", padx=10)
+        frame.columnconfigure(0, weight=1)
+        frame.rowconfigure(0, weight=1)
+        columns = ("apply", "type", "match", "where", "text")
+        tree = ttk.Treeview(frame, columns=columns, show="tree headings", selectmode="browse")
+        tree.column("#0", width=24, stretch=False)
+        for column, heading, width, stretch in (("apply", "", 32, False), ("type", "Type", 90, False),
+                                                ("match", "Match", 60, False), ("where", "Topic", 180, False),
+                                                ("text", "Text", 480, True)):
+            tree.heading(column, text=heading)
+            tree.column(column, width=width, stretch=stretch, anchor="w" if stretch or column == "where" else "center")
+        tree.grid(row=0, column=0, sticky="nsew")
+        vsb = ttk.Scrollbar(frame, orient="vertical", command=tree.yview)
+        tree.configure(yscrollcommand=vsb.set)
+        vsb.grid(row=0, column=1, sticky="ns")
+
+        occurrences: Dict[str, NoticeOccurrence] = {}
+        checked: Dict[str, bool] = {}
+        for g, group in enumerate(groups):
+            parent_iid = f"g{g}"
+            label = group.type if group.kind == "note" else f"{group.type} (hazard)"
+            tree.insert("", "end", iid=parent_iid, open=bool(group.variants),
+                        values=(_CHECKED, label, f"×{group.count}", "", _preview(group.text)))
+            for o, occurrence in enumerate(group.occurrences):
+                iid = f"g{g}o{o}"
+                occurrences[iid] = occurrence
+                checked[iid] = True
+                where = title_for(occurrence.topic) if title_for else occurrence.topic
+                match = "same" if occurrence.identical else f"{occurrence.similarity:.0%}"
+                tree.insert(parent_iid, "end", iid=iid, values=(
+                    _CHECKED, "", match, where or occurrence.topic,
+                    "" if occurrence.identical else _preview(occurrence.text)))
+
+        def _set(iid: str, value: bool) -> None:
+            checked[iid] = value
+            tree.set(iid, "apply", _CHECKED if value else _UNCHECKED)
+
+        def _refresh_group(parent_iid: str) -> None:
+            children = tree.get_children(parent_iid)
+            tree.set(parent_iid, "apply", _CHECKED if any(checked[c] for c in children) else _UNCHECKED)
+
+        def _toggle_row(iid: str) -> None:
+            if iid in occurrences:
+                _set(iid, not checked[iid])
+                _refresh_group(tree.parent(iid))
+                return
+            children = tree.get_children(iid)
+            value = not any(checked[c] for c in children)
+            for child in children:
+                _set(child, value)
+            _refresh_group(iid)
+
+        def _toggle(event) -> None:
+            if tree.identify_column(event.x) != "#1":
+                return
+            iid = tree.identify_row(event.y)
+            if iid:
+                _toggle_row(iid)
+
+        def _set_all(value: bool) -> None:
+            for iid in occurrences:
+                _set(iid, value)
+            for parent_iid in tree.get_children(""):
+                _refresh_group(parent_iid)
+
+        tree.bind("<Button-1>", _toggle, add=True)
+        tree.bind("<space>", lambda _e: [_toggle_row(i) for i in tree.selection()])
+
+        result: List[Optional[List[NoticeGroup]]] = [None]
+
+        def _apply() -> None:
+            for iid, occurrence in occurrences.items():
+                occurrence.accepted = checked[iid]
+            result[0] = [g for g in groups if g.accepted]
+            top.destroy()
+
+        btns = ttk.Frame(top)
+        btns.grid(row=2, column=0, sticky="ew", padx=10, pady=10)
+        ttk.Button(btns, text=tr("Select All"), command=lambda: _set_all(True)).pack(side="left")
+        ttk.Button(btns, text=tr("Select None"), command=lambda: _set_all(False)).pack(side="left", padx=(6, 0))
+        ttk.Button(btns, text=tr("Apply"), style="Accent.TButton", command=_apply).pack(side="right")
+        ttk.Button(btns, text=tr("Cancel"), command=top.destroy).pack(side="right", padx=(0, 6))
+        top.bind("<Escape>", lambda _e: top.destroy())
+
+        try:
+            top.wait_window()
+        except Exception:
+            pass
+        return result[0]
@@ -0,0 +1,116 @@
+from __future__ import annotations
+
+import tkinter as tk
+from pathlib import Path
+from tkinter import filedialog, ttk
+from typing import Any, Callable, Dict, List, Optional
+from orlando_toolkit.ui.i18n import tr
+
+
+class SpellingPanel(tk.Toplevel):
+    """Suspected misspellings of the whole project, per topic and word.
+
+    ``check(errors)`` returns the ``SpellingIssue`` list shown and appends to
+    *errors* why topics could not be checked (no dictionary). **Ignore Word**
+    passes the selected words to ``ignore(words)``, which keeps them in the
+    project's ignore list, then checks again. ``to_csv(issues)`` renders the
+    list for **Export CSV…**. Selecting a row calls ``on_select(topic)``.
+    """
+
+    def __init__(self, master: tk.Widget, *, check: Callable[[List[str]], List[Any]],
+                 ignore: Callable[[List[str]], Any], to_csv: Callable[[List[Any]], str],
+                 on_select: Callable[[Optional[str]], None]) -> None:
+        super().__init__(master)
+        self.title(tr("Spelling"))
+        self.transient(master)
+        self.geometry("860x440")
+        self._check, self._ignore, self._to_csv, self._on_select = check, ignore, to_csv, on_select
+        self._issues: Dict[str, Any] = {}
+
+        self.columnconfigure(0, weight=1)
+        self.rowconfigure(1, weight=1)
+        self._status = tk.StringVar()
+        ttk.Label(self, textvariable=self._status, wraplength=820).grid(row=0, column=0, sticky="w",
+                                                                        padx=10, pady=(10, 4))
+
+        frame = ttk.Frame(self)
+        frame.grid(row=1, column=0, sticky="nsew", padx=10)
+        frame.columnconfigure(0, weight=1)
+        frame.rowconfigure(0, weight=1)
+        columns = ("topic", "word", "count", "language", "context")
+        self._tree = ttk.Treeview(frame, columns=columns, show="headings", selectmode="extended")
+        for column, heading, width, stretch in (("topic", "Topic", 200, False), ("word", "Word", 130, False),
+                                                ("count", "Uses", 50, False), ("language", "Language", 70, False),
+                                                ("context", "Context", 360, True)):
+            self._tree.heading(column, text=heading)
+            self._tree.column(column, width=width, stretch=stretch, anchor="w")
+        self._tree.grid(row=0, column=0, sticky="nsew")
+        vsb = ttk.Scrollbar(frame, orient="vertical", command=self._tree.yview)
+        self._tree.configure(yscrollcommand=vsb.set)
+        vsb.grid(row=0, column=1, sticky="ns")
+        self._tree.bind("<<TreeviewSelect>>", self._on_row_selected)
+
+        actions = ttk.Frame(self)
+        actions.grid(row=2, column=0, sticky="ew", padx=10, pady=(6, 0))
+        self._ignore_btn = ttk.Button(actions, text=tr("Ignore Word"), command=self._ignore_selected)
+        self._ignore_btn.pack(side="left")
+        ttk.Button(actions, text=tr("Export CSV…"), command=self._export).pack(side="left", padx=(6, 0))
+
+        btns = ttk.Frame(self)
+        btns.grid(row=3, column=0, sticky="ew", padx=10, pady=10)
+        ttk.Button(btns, text=tr("Check Again"), command=self.refresh).pack(side="left")
+        ttk.Button(btns, text=tr("Close"), command=self.destroy).pack(side="right")
+        self.bind("<Escape>", lambda _e: self.destroy())
+
+        self.refresh()
+
+    def refresh(self) -> None:
+        """Check again and list the suspected words."""
+        self._tree.delete(*self._tree.get_children(""))
+        self._issues.clear()
+        errors: List[str] = []
+        for index, issue in enumerate(self._check(errors)):
+            iid = str(index)
+            self._issues[iid] = issue
+            self._tree.insert("", "end", iid=iid, values=(issue.title or issue.topic, issue.word, issue.count,
+                                                          issue.language, " | ".join(issue.snippets)))
+        topics = len({i.topic for i in self._issues.values()})
+        status = (f"{len(self._issues)} suspected word(s) in {topics} topic(s)." if self._issues
+                  else "No suspected misspelling.")
+        if errors:
+            status += " Not checked: " + "; ".join(errors)
+        self._status.set(status)
+        self._update_buttons()
+
+    def _selected(self) -> List[Any]:
+        return [self._issues[iid] for iid in self._tree.selection() if iid in self._issues]
+
+    def _update_buttons(self) -> None:
+        self._ignore_btn.configure(state="normal" if self._selected() else "disabled")
+
+    def _on_row_selected(self, _event: Optional[tk.Event] = None) -> None:
+        self._update_buttons()
+        selected = self._selected()
+        if len(selected) == 1:
+            self._on_select(selected[0].topic)
+
+    def _ignore_selected(self) -> None:
+        words = sorted({issue.word for issue in self._selected()})
+        if not words:
+            return
+        result = self._ignore(words)
+        self.refresh()
+        if result is not None:
+            self._status.set(getattr(result, "message", "") or self._status.get())
+
+    def _export(self) -> None:
+        path = filedialog.asksaveasfilename(parent=self, title=tr("Export spelling report"), defaultextension=".csv",
+                                            filetypes=[("CSV", "*.csv")], initialfile="spelling.csv")
+        if not path:
+            return
+        try:
+            Path(path).write_text(self._to_csv(list(self._issues.values())), encoding="utf-8", newline="")
+        except OSError as exc:
+            self._status.set(f"Could not write {path}: {exc}")
+            return
+        self._status.set(f"Report written to {path}")
@@ -0,0 +1,86 @@
+from __future__ import annotations
+
+import tkinter as tk
+from tkinter import filedialog, messagebox, ttk
+from typing import Any, Callable, List
+
+from orlando_toolkit.core.style_usage import write_style_usage_csv
+from orlando_toolkit.ui.i18n import tr
+
+
+class StyleUsagePanel(tk.Toplevel):
+    """Source styles of the document, one row per style.
+
+    ``compute()`` returns the :class:`~orlando_toolkit.core.style_usage.StyleUsage`
+    list shown; Refresh calls it again after edits. Unmapped styles are
+    highlighted and can be listed alone; Export CSV writes the full list.
+    """
+
+    def __init__(self, master: tk.Widget, compute: Callable[[], List[Any]]) -> None:
+        super().__init__(master)
+        self.title(tr("Style Usage"))
+        self.transient(master)
+        self.geometry("760x420")
+        self._compute = compute
+        self._usages: List[Any] = []
+
+        self.columnconfigure(0, weight=1)
+        self.rowconfigure(1, weight=1)
+        top = ttk.Frame(self)
+        top.grid(row=0, column=0, sticky="ew", padx=10, pady=(10, 4))
+        self._status = tk.StringVar()
+        ttk.Label(top, textvariable=self._status).pack(side="left")
+        self._unmapped_only = tk.BooleanVar(value=False)
+        ttk.Checkbutton(top, text=tr("Unmapped only"), variable=self._unmapped_only,
+                        command=self._fill).pack(side="right")
+
+        frame = ttk.Frame(self)
+        frame.grid(row=1, column=0, sticky="nsew", padx=10)
+        frame.columnconfigure(0, weight=1)
+        frame.rowconfigure(0, weight=1)
+        columns = ("style", "kind", "count", "elements", "status")
+        self._tree = ttk.Treeview(frame, columns=columns, show="headings", selectmode="browse")
+        for column, heading, width, stretch in (("style", "Style", 220, True), ("kind", "Kind", 90, False),
+                                                ("count", "Count", 60, False), ("elements", "DITA element", 200, True),
+                                                ("status", "Status", 90, False)):
+            self._tree.heading(column, text=heading)
+            self._tree.column(column, width=width, stretch=stretch, anchor="center" if column == "count" else "w")
+        self._tree.tag_configure("unmapped", foreground="#b00020")
+        self._tree.grid(row=0, column=0, sticky="nsew")
+        vsb = ttk.Scrollbar(frame, orient="vertical", command=self._tree.yview)
+        self._tree.configure(yscrollcommand=vsb.set)
+        vsb.grid(row=0, column=1, sticky="ns")
+
+        btns = ttk.Frame(self)
+        btns.grid(row=2, column=0, sticky="ew", padx=10, pady=10)
+        ttk.Button(btns, text=tr("Refresh"), command=self.refresh).pack(side="left")
+        ttk.Button(btns, text=tr("Export CSV…"), command=self._export).pack(side="left", padx=(6, 0))
+        ttk.Button(btns, text=tr("Close"), command=self.destroy).pack(side="right")
+        self.bind("<Escape>", lambda _e: self.destroy())
+
+        self.refresh()
+
+    def refresh(self) -> None:
+        """Recompute the style usage of the current structure."""
+        self._usages = list(self._compute() or [])
+        self._fill()
+
+    def _fill(self) -> None:
+        self._tree.delete(*self._tree.get_children(""))
+        unmapped = [u for u in self._usages if u.unmapped]
+        for index, usage in enumerate(unmapped if self._unmapped_only.get() else self._usages):
+            self._tree.insert("", "end", iid=str(index), tags=("unmapped",) if usage.unmapped else (),
+                              values=(usage.style, usage.kind, usage.count, ", ".join(usage.elements), usage.status))
+        self._status.set(f"{len(self._usages)} style(s), {len(unmapped)} unmapped" if self._usages
+                         else "No source styles recorded for this document.")
+
+    def _export(self) -> None:
+        path = filedialog.asksaveasfilename(parent=self, title=tr("Export style usage"), defaultextension=".csv",
+                                            filetypes=[("CSV files", "*.csv"), ("All files", "*.*")],
+                                            initialfile="style_usage.csv")
+        if not path:
+            return
+        try:
+            write_style_usage_csv(self._usages, path)
+        except OSError as exc:
+            messagebox.showerror(tr("Style Usage"), f"Could not write {path}:\n{exc}", parent=self)
@@ -0,0 +1,133 @@
+from __future__ import annotations
+
+import tkinter as tk
+from tkinter import filedialog, ttk
+from typing import Any, Callable, Dict, List, Optional
+from orlando_toolkit.ui.i18n import tr
+
+
+class TerminologyPanel(tk.Toplevel):
+    """Banned terms used per topic, with one-click replacement by the preferred term.
+
+    *rules* are the ``TermRule`` objects checked (``terminology`` in
+    ``conversion.yml``); **Load List…** adds those of a CSV or TBX file read
+    by ``read_list(path)``. ``check(rules)`` returns the ``TermViolation``
+    list shown; ``replace(rule, topics)`` replaces in those topics (all when
+    None) and returns its ``OperationResult``, after which the list is checked
+    again. Selecting a row calls ``on_select(topic)``.
+    """
+
+    def __init__(self, master: tk.Widget, *, rules: List[Any], check: Callable[[List[Any]], List[Any]],
+                 replace: Callable[[Any, Optional[List[str]]], Any], read_list: Callable[[str], List[Any]],
+                 on_select: Callable[[Optional[str]], None]) -> None:
+        super().__init__(master)
+        self.title(tr("Terminology"))
+        self.transient(master)
+        self.geometry("900x440")
+        self._rules = list(rules)
+        self._check, self._replace, self._read_list, self._on_select = check, replace, read_list, on_select
+        self._violations: Dict[str, Any] = {}
+
+        self.columnconfigure(0, weight=1)
+        self.rowconfigure(1, weight=1)
+        header = ttk.Frame(self)
+        header.grid(row=0, column=0, sticky="ew", padx=10, pady=(10, 4))
+        self._status = tk.StringVar()
+        ttk.Label(header, textvariable=self._status).pack(side="left")
+        ttk.Button(header, text=tr("Load List…"), command=self._load_list).pack(side="right")
+
+        frame = ttk.Frame(self)
+        frame.grid(row=1, column=0, sticky="nsew", padx=10)
+        frame.columnconfigure(0, weight=1)
+        frame.rowconfigure(0, weight=1)
+        columns = ("topic", "banned", "preferred", "count", "context")
+        self._tree = ttk.Treeview(frame, columns=columns, show="headings", selectmode="browse")
+        for column, heading, width, stretch in (("topic", "Topic", 180, False), ("banned", "Term", 130, False),
+                                                ("preferred", "Use Instead", 130, False),
+                                                ("count", "Uses", 50, False), ("context", "Context", 330, True)):
+            self._tree.heading(column, text=heading)
+            self._tree.column(column, width=width, stretch=stretch, anchor="w")
+        self._tree.grid(row=0, column=0, sticky="nsew")
+        vsb = ttk.Scrollbar(frame, orient="vertical", command=self._tree.yview)
+        self._tree.configure(yscrollcommand=vsb.set)
+        vsb.grid(row=0, column=1, sticky="ns")
+        self._tree.bind("<<TreeviewSelect>>", self._on_row_selected)
+
+        self._note = tk.StringVar()
+        ttk.Label(self, textvariable=self._note, foreground="#555555").grid(row=2, column=0, sticky="w",
+                                                                             padx=10, pady=(6, 0))
+        actions = ttk.Frame(self)
+        actions.grid(row=3, column=0, sticky="ew", padx=10, pady=(6, 0))
+        self._replace_here = ttk.Button(actions, text=tr("Replace in Topic"), command=lambda: self._apply(False))
+        self._replace_here.pack(side="left")
+        self._replace_all = ttk.Button(actions, text=tr("Replace in All Topics"), command=lambda: self._apply(True))
+        self._replace_all.pack(side="left", padx=(6, 0))
+
+        btns = ttk.Frame(self)
+        btns.grid(row=4, column=0, sticky="ew", padx=10, pady=10)
+        ttk.Button(btns, text=tr("Check Again"), command=self.refresh).pack(side="left")
+        ttk.Button(btns, text=tr("Close"), command=self.destroy).pack(side="right")
+        self.bind("<Escape>", lambda _e: self.destroy())
+
+        self.refresh()
+
+    def refresh(self) -> None:
+        """Check again and list the banned terms found."""
+        self._tree.delete(*self._tree.get_children(""))
+        self._violations.clear()
+        for index, violation in enumerate(self._check(self._rules)):
+            iid = str(index)
+            self._violations[iid] = violation
+            rule = violation.rule
+            self._tree.insert("", "end", iid=iid, values=(violation.title or violation.topic, rule.banned,
+                                                          rule.preferred, violation.count,
+                                                          " | ".join(violation.snippets)))
+        if not self._rules:
+            self._status.set("No term list: load a CSV or TBX file, or set terminology in conversion.yml.")
+        elif self._violations:
+            uses = sum(v.count for v in self._violations.values())
+            self._status.set(f"{uses} banned term use(s) in {len({v.topic for v in self._violations.values()})} "
+                             f"topic(s), {len(self._rules)} term(s) checked.")
+        else:
+            self._status.set(f"No banned term used ({len(self._rules)} term(s) checked).")
+        self._update_buttons()
+
+    def _selected(self) -> Optional[Any]:
+        selection = self._tree.selection()
+        return self._violations.get(selection[0]) if selection else None
+
+    def _update_buttons(self) -> None:
+        violation = self._selected()
+        state = "normal" if violation is not None and violation.rule.preferred else "disabled"
+        self._replace_here.configure(state=state)
+        self._replace_all.configure(state=state)
+        self._note.set(violation.rule.note if violation is not None else "")
+
+    def _on_row_selected(self, _event: Optional[tk.Event] = None) -> None:
+        self._update_buttons()
+        violation = self._selected()
+        if violation is not None:
+            self._on_select(violation.topic)
+
+    def _apply(self, everywhere: bool) -> None:
+        violation = self._selected()
+        if violation is None:
+            return
+        result = self._replace(violation.rule, None if everywhere else [violation.topic])
+        self.refresh()
+        if result is not None:
+            self._status.set(getattr(result, "message", "") or self._status.get())
+
+    def _load_list(self) -> None:
+        path = filedialog.askopenfilename(parent=self, title=tr("Load term list"),
+                                          filetypes=[("Term lists", "*.csv *.tsv *.tbx"), ("All files", "*.*")])
+        if not path:
+            return
+        try:
+            added = self._read_list(path)
+        except (OSError, ValueError) as exc:
+            self._status.set(str(exc))
+            return
+        known = {rule.banned.lower() for rule in self._rules}
+        self._rules.extend(rule for rule in added if rule.banned.lower() not in known)
+        self.refresh()
@@ -0,0 +1,172 @@
+"""Edit the XML source of one topic, with syntax highlighting, spelling and validation on save."""
+
+from __future__ import annotations
+
+import logging
+import tkinter as tk
+from tkinter import messagebox, ttk
+from typing import Any, Callable, List, Optional, Tuple
+
+from orlando_toolkit.core.topic_source import TopicSourceError, highlight_spans, pretty_print
+from orlando_toolkit.ui.i18n import tr
+
+logger = logging.getLogger(__name__)
+
+_COLORS = {
+    "tag": "#1f5fbf",
+    "attribute": "#a0522d",
+    "value": "#2e7d32",
+    "comment": "#808080",
+    "pi": "#7b1fa2",
+    "entity": "#c62828",
+}
+_HIGHLIGHT_DELAY_MS = 300
+
+
+class TopicXmlDialog:
+    """Raw XML editor for a topic.
+
+    Use: TopicXmlDialog(parent, title, xml_text, check=..., save=...).show_modal()
+    *check(text)* and *save(text, force)* return an ``OperationResult``; a
+    failed result with ``details["line"]`` marks that line. Saving invalid XML
+    asks for confirmation before saving with *force*. With *spell_check(text)*
+    returning ``(start, end)`` offsets, **Check Spelling** underlines them.
+    """
+
+    def __init__(self, parent: tk.Misc, title: str, xml_text: str, *,
+                 check: Callable[[str], Any], save: Callable[[str, bool], Any],
+                 spell_check: Optional[Callable[[str], List[Tuple[int, int]]]] = None):
+        self.parent = parent
+        self.title = title
+        self.xml_text = xml_text
+        self._check = check
+        self._save_cb = save
+        self._spell_check = spell_check
+        self.dialog: Optional[tk.Toplevel] = None
+        self.status_var = tk.StringVar(value="")
+        self.saved = False
+        self._pending: Optional[str] = None
+
+    def show_modal(self) -> bool:
+        """Show the editor; returns whether the topic was saved."""
+        self.dialog = tk.Toplevel(self.parent)
+        self.dialog.title(f"Edit XML – {self.title}")
+        self.dialog.geometry("820x600")
+        self.dialog.transient(self.parent)
+        self._setup_layout()
+        self.text.insert("1.0", self.xml_text)
+        self.text.edit_reset()
+        self._highlight()
+        self.dialog.grab_set()
+        self.parent.wait_window(self.dialog)
+        return self.saved
+
+    # ------------------------------------------------------------------
+    # Layout
+    # ------------------------------------------------------------------
+    def _setup_layout(self) -> None:
+        frame = ttk.Frame(self.dialog, padding=12)
+        frame.pack(fill="both", expand=True)
+        frame.columnconfigure(0, weight=1)
+        frame.rowconfigure(0, weight=1)
+
+        self.text = tk.Text(frame, wrap="none", undo=True, font=("Courier New", 10))
+        yscroll = ttk.Scrollbar(frame, orient="vertical", command=self.text.yview)
+        xscroll = ttk.Scrollbar(frame, orient="horizontal", command=self.text.xview)
+        self.text.configure(yscrollcommand=yscroll.set, xscrollcommand=xscroll.set)
+        self.text.grid(row=0, column=0, sticky="nsew")
+        yscroll.grid(row=0, column=1, sticky="ns")
+        xscroll.grid(row=1, column=0, sticky="ew")
+        for kind, color in _COLORS.items():
+            self.text.tag_configure(kind, foreground=color)
+        self.text.tag_configure("error_line", background="#fde2e2")
+        self.text.tag_configure("misspelled", underline=True, foreground="#c62828")
+        self.text.bind("<<Modified>>", self._on_modified)
+
+        bottom = ttk.Frame(frame)
+        bottom.grid(row=2, column=0, columnspan=2, sticky="ew", pady=(10, 0))
+        ttk.Label(bottom, textvariable=self.status_var, foreground="gray", wraplength=460).pack(side="left")
+        ttk.Button(bottom, text=tr("Cancel"), command=self.dialog.destroy).pack(side="right")
+        ttk.Button(bottom, text=tr("Save"), style="Accent.TButton", command=self._save).pack(side="right", padx=(0, 6))
+        ttk.Button(bottom, text=tr("Validate"), command=self._validate).pack(side="right", padx=(0, 6))
+        if self._spell_check is not None:
+            ttk.Button(bottom, text=tr("Check Spelling"), command=self._check_spelling).pack(side="right", padx=(0, 6))
+        ttk.Button(bottom, text=tr("Pretty Print"), command=self._pretty_print).pack(side="right", padx=(0, 6))
+
+    # ------------------------------------------------------------------
+    # Highlighting
+    # ------------------------------------------------------------------
+    def _on_modified(self, _event: Any = None) -> None:
+        if not self.text.edit_modified():
+            return
+        self.text.edit_modified(False)
+        # Offsets of the last check no longer match the edited text
+        self.text.tag_remove("misspelled", "1.0", "end")
+        if self._pending is not None:
+            self.dialog.after_cancel(self._pending)
+        self._pending = self.dialog.after(_HIGHLIGHT_DELAY_MS, self._highlight)
+
+    def _highlight(self) -> None:
+        self._pending = None
+        content = self.text.get("1.0", "end-1c")
+        for kind in _COLORS:
+            self.text.tag_remove(kind, "1.0", "end")
+        for kind, start, end in highlight_spans(content):
+            self.text.tag_add(kind, f"1.0+{start}c", f"1.0+{end}c")
+
+    def _mark_line(self, line: Optional[int]) -> None:
+        self.text.tag_remove("error_line", "1.0", "end")
+        if line:
+            self.text.tag_add("error_line", f"{line}.0", f"{line}.end+1c")
+            self.text.see(f"{line}.0")
+
+    # ------------------------------------------------------------------
+    # Actions
+    # ------------------------------------------------------------------
+    def _content(self) -> str:
+        return self.text.get("1.0", "end-1c")
+
+    def _show(self, result: Any) -> bool:
+        ok = bool(getattr(result, "success", False))
+        details = getattr(result, "details", None) or {}
+        self._mark_line(None if ok else details.get("line"))
+        self.status_var.set(getattr(result, "message", "") or "")
+        return ok
+
+    def _pretty_print(self) -> None:
+        try:
+            formatted = pretty_print(self._content())
+        except TopicSourceError as exc:
+            self._mark_line(exc.line)
+            self.status_var.set(str(exc))
+            return
+        self.text.delete("1.0", "end")
+        self.text.insert("1.0", formatted)
+        self._mark_line(None)
+        self._highlight()
+        self.status_var.set("")
+
+    def _validate(self) -> None:
+        self._show(self._check(self._content()))
+
+    def _check_spelling(self) -> None:
+        self.text.tag_remove("misspelled", "1.0", "end")
+        spans = self._spell_check(self._content()) if self._spell_check is not None else []
+        for start, end in spans:
+            self.text.tag_add("misspelled", f"1.0+{start}c", f"1.0+{end}c")
+        self.status_var.set(f"{len(spans)} suspected misspelling(s)" if spans else "No suspected misspelling")
+
+    def _save(self) -> None:
+        text = self._content()
+        result = self._save_cb(text, False)
+        if not self._show(result):
+            details = getattr(result, "details", None) or {}
+            if details.get("reason") != "invalid":
+                return
+            if not messagebox.askyesno(tr("Edit XML"), f"{getattr(result, 'message', '')}\n\nSave anyway?",
+                                       parent=self.dialog):
+                return
+            if not self._show(self._save_cb(text, True)):
+                return
+        self.saved = True
+        self.dialog.destroy()
@@ -0,0 +1,76 @@
+from __future__ import annotations
+
+import tkinter as tk
+from tkinter import ttk
+from typing import Any, Callable, Dict, Optional
+from orlando_toolkit.ui.i18n import tr
+
+
+class ValidationPanel(tk.Toplevel):
+    """Validation results, one row per issue.
+
+    Clicking a row calls ``on_select(topic)`` with the topic file name (None
+    for the map) so the structure tree can select the offending topic; the
+    panel stays open while the content is fixed. Revalidate calls
+    ``revalidate()`` and shows the report it returns.
+    """
+
+    def __init__(self, master: tk.Widget, report: Any, *, on_select: Callable[[Optional[str]], None],
+                 revalidate: Optional[Callable[[], Any]] = None) -> None:
+        super().__init__(master)
+        self.title(tr("Validation"))
+        self.transient(master)
+        self.geometry("820x380")
+        self._on_select = on_select
+        self._revalidate = revalidate
+        self._topics: Dict[str, Optional[str]] = {}
+
+        self.columnconfigure(0, weight=1)
+        self.rowconfigure(1, weight=1)
+        self._status = tk.StringVar()
+        ttk.Label(self, textvariable=self._status).grid(row=0, column=0, sticky="w", padx=10, pady=(10, 4))
+
+        frame = ttk.Frame(self)
+        frame.grid(row=1, column=0, sticky="nsew", padx=10)
+        frame.columnconfigure(0, weight=1)
+        frame.rowconfigure(0, weight=1)
+        columns = ("topic", "line", "message")
+        self._tree = ttk.Treeview(frame, columns=columns, show="headings", selectmode="browse")
+        for column, heading, width, stretch in (("topic", "Topic", 220, False), ("line", "Line", 60, False),
+                                                ("message", "Problem", 500, True)):
+            self._tree.heading(column, text=heading)
+            self._tree.column(column, width=width, stretch=stretch, anchor="center" if column == "line" else "w")
+        self._tree.grid(row=0, column=0, sticky="nsew")
+        vsb = ttk.Scrollbar(frame, orient="vertical", command=self._tree.yview)
+        self._tree.configure(yscrollcommand=vsb.set)
+        vsb.grid(row=0, column=1, sticky="ns")
+        self._tree.bind("<<TreeviewSelect>>", self._on_row_selected)
+
+        btns = ttk.Frame(self)
+        btns.grid(row=2, column=0, sticky="ew", padx=10, pady=10)
+        if revalidate is not None:
+            ttk.Button(btns, text=tr("Revalidate"), command=self._on_revalidate).pack(side="left")
+        ttk.Button(btns, text=tr("Close"), command=self.destroy).pack(side="right")
+        self.bind("<Escape>", lambda _e: self.destroy())
+
+        self.show(report)
+
+    def show(self, report: Any) -> None:
+        """Replace the listed issues with those of *report*."""
+        self._tree.delete(*self._tree.get_children(""))
+        self._topics.clear()
+        for index, issue in enumerate(report.issues):
+            iid = str(index)
+            self._topics[iid] = issue.topic
+            self._tree.insert("", "end", iid=iid, values=(issue.topic or "(map)", issue.line or "", issue.message))
+        count = len(report.errors)
+        self._status.set(f"{count} error(s) — {report.grammar}" if count else f"No errors — {report.grammar}")
+
+    def _on_row_selected(self, _event: Optional[tk.Event] = None) -> None:
+        selection = self._tree.selection()
+        if selection:
+            self._on_select(self._topics.get(selection[0]))
+
+    def _on_revalidate(self) -> None:
+        if self._revalidate is not None:
+            self.show(self._revalidate())
@@ -0,0 +1,204 @@
+from __future__ import annotations
+
+"""Translation of the application interface.
+
+Interface strings are written in English in the code and wrapped in
+:func:`tr`, which returns them in the selected language. The English text is
+the key, as with gettext: ``tr("Save Project")`` or, with placeholders,
+``tr("Profile {name} imported.", name=name)``. A string without a
+translation is shown in English, so a partial catalog never breaks a window.
+
+Catalogs are YAML files named after the language, with an optional display
+name::
+
+    name: Français
+    strings:
+      "Save Project": "Enregistrer le projet"
+
+Lookup order for a string: the user's catalog in ``locales/`` of the
+configuration folder (to adjust wording or add a language), the catalogs
+shipped in ``orlando_toolkit/ui/locales``, then plugin catalogs (a plugin's
+own ``locales/`` folder, loaded when it is activated, or entries registered
+through ``UIRegistry.register_translations``).
+
+The language comes from ``ui.yml`` (``language: auto`` follows the system
+locale). Widgets take their text when they are built, so a change applies
+to windows opened afterwards.
+"""
+
+import locale
+import logging
+import os
+from pathlib import Path
+from typing import Any, Dict, List, Mapping, Optional
+
+logger = logging.getLogger(__name__)
+
+__all__ = [
+    "DEFAULT_LANGUAGE",
+    "available_languages",
+    "configured_language",
+    "current_language",
+    "language_name",
+    "load_translation_files",
+    "register_translations",
+    "set_language",
+    "tr",
+    "unregister_translations",
+]
+
+DEFAULT_LANGUAGE = "en"
+AUTO = "auto"
+_PACKAGED_DIR = Path(__file__).resolve().parent / "locales"
+# Owners of the catalogs searched before and after plugin catalogs
+_USER = "user"
+_PACKAGED = "orlando_toolkit"
+
+_catalogs: Dict[str, Dict[str, Dict[str, str]]] = {}
+_names: Dict[str, str] = {DEFAULT_LANGUAGE: "English"}
+_language = DEFAULT_LANGUAGE
+_loaded = False
+
+
+def _primary(language: Any) -> str:
+    return str(language or "").strip().replace("_", "-").split("-", 1)[0].lower()
+
+
+def _read_catalog(path: Path) -> Optional[Dict[str, Any]]:
+    try:
+        import yaml  # type: ignore
+
+        data = yaml.safe_load(path.read_text(encoding="utf-8")) or {}
+    except Exception as exc:
+        logger.warning("Cannot read translation catalog %s: %s", path, exc)
+        return None
+    if not isinstance(data, Mapping) or not isinstance(data.get("strings", {}), Mapping):
+        logger.warning("Translation catalog %s has no 'strings' mapping", path)
+        return None
+    return dict(data)
+
+
+def load_translation_files(owner: str, directory: str | Path) -> List[str]:
+    """Register the ``<language>.yml`` catalogs of *directory* for *owner*; returns their languages."""
+    _ensure_loaded()
+    return _load_directory(owner, Path(directory))
+
+
+def _load_directory(owner: str, directory: Path) -> List[str]:
+    languages: List[str] = []
+    if not directory.is_dir():
+        return languages
+    for path in sorted(directory.glob("*.yml")) + sorted(directory.glob("*.yaml")):
+        data = _read_catalog(path)
+        if data is None:
+            continue
+        language = _primary(path.stem)
+        register_translations(owner, language, data.get("strings") or {})
+        if data.get("name") and language not in _names:
+            _names[language] = str(data["name"])
+        languages.append(language)
+    return languages
+
+
+def register_translations(owner: str, language: str, entries: Mapping[str, Any]) -> None:
+    """Add *entries* (English text -> translation) for *language*, on behalf of *owner*.
+
+    Entries of the same owner and language registered earlier are kept
+    unless given again.
+    """
+    language = _primary(language)
+    if not language:
+        raise ValueError("A translation needs a language code")
+    table = _catalogs.setdefault(owner, {}).setdefault(language, {})
+    table.update({str(k): str(v) for k, v in (entries or {}).items() if v not in (None, "")})
+
+
+def unregister_translations(owner: str) -> None:
+    """Forget every translation registered by *owner* (a deactivated plugin)."""
+    _catalogs.pop(owner, None)
+
+
+def _ensure_loaded() -> None:
+    global _loaded, _language
+    if _loaded:
+        return
+    _loaded = True
+    _load_directory(_PACKAGED, _PACKAGED_DIR)
+    try:
+        from orlando_toolkit.config.manager import _get_user_config_dir
+
+        _load_directory(_USER, _get_user_config_dir() / "locales")
+    except Exception as exc:
+        logger.debug("User translations unavailable: %s", exc)
+    _language = _resolve(configured_language())
+
+
+def _system_language() -> str:
+    for name in ("LC_ALL", "LC_MESSAGES", "LANG"):
+        if os.environ.get(name):
+            return _primary(os.environ[name])
+    try:
+        return _primary(locale.getlocale()[0])
+    except Exception:
+        return ""
+
+
+def _resolve(language: Any) -> str:
+    language = _primary(language) if language and str(language).strip().lower() != AUTO else _system_language()
+    return language if language in available_languages() else DEFAULT_LANGUAGE
+
+
+def configured_language() -> str:
+    """The ``language`` of ``ui.yml``: a language code or ``auto``."""
+    try:
+        from orlando_toolkit.config import ConfigManager
+
+        return str((ConfigManager().get_ui_config() or {}).get("language") or AUTO)
+    except Exception:
+        return AUTO
+
+
+def available_languages() -> List[str]:
+    """Language codes with a catalog, English first."""
+    _ensure_loaded()
+    found = {language for tables in _catalogs.values() for language in tables}
+    return [DEFAULT_LANGUAGE] + sorted(found - {DEFAULT_LANGUAGE})
+
+
+def language_name(language: str) -> str:
+    """Display name of *language* in that language (``Français``), else its code."""
+    _ensure_loaded()
+    return _names.get(_primary(language), language)
+
+
+def current_language() -> str:
+    _ensure_loaded()
+    return _language
+
+
+def set_language(language: Any) -> str:
+    """Show the interface in *language* (``auto`` for the system locale); returns the language used."""
+    global _language
+    _ensure_loaded()
+    _language = _resolve(language)
+    return _language
+
+
+def tr(text: str, **params: Any) -> str:
+    """*text* in the current language, formatted with *params*."""
+    _ensure_loaded()
+    translated = text
+    if _language != DEFAULT_LANGUAGE:
+        owners = [_USER, _PACKAGED] + [owner for owner in _catalogs if owner not in (_USER, _PACKAGED)]
+        for owner in owners:
+            value = _catalogs.get(owner, {}).get(_language, {}).get(text)
+            if value is not None:
+                translated = value
+                break
+    if not params:
+        return translated
+    try:
+        return translated.format(**params)
+    except (KeyError, IndexError, ValueError) as exc:
+        logger.warning("Cannot format interface string %r (%s): %s", text, _language, exc)
+        return text.format(**params)
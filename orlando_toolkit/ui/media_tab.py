@@ -1,7 +1,9 @@
 # -*- coding: utf-8 -*-
 """
 Media Tab: unified images and videos management with inline previews.
-Keeps ImageTab parity for image naming, preview, and editing.
+Keeps ImageTab parity for image naming, preview, and editing. The
+Attachments section adds files shipped under ``resources/``
+(``orlando_toolkit.core.attachments``).
 """
 
 import tkinter as tk
@@ -22,6 +24,14 @@ if TYPE_CHECKING:
     from orlando_toolkit.core.models import DitaContext
 
 from orlando_toolkit.config import ConfigManager
+from orlando_toolkit.core.alt_text import image_alt_text, images_missing_alt, set_image_alt_text
+from orlando_toolkit.core.attachments import attach_file, attachment_format, attachment_title, remove_attachment, \
+    set_attachment_title
+from orlando_toolkit.core.external_media import ExternalMediaSettings, is_marked_external, mark_external
+from orlando_toolkit.core.image_naming import PLACEHOLDERS, ImageNaming, propose_names
+from orlando_toolkit.core.processing.vector_images import is_metafile, is_svg, svg_info
+from orlando_toolkit.core.word_media import is_audio, media_poster
+from orlando_toolkit.ui.i18n import tr
 
 logger = logging.getLogger(__name__)
 
@@ -38,8 +48,13 @@ class MediaTab(ttk.Frame):
         # Image state
         self.image_listbox: Optional[tk.Listbox] = None
         self.prefix_entry: Optional[ttk.Entry] = None
+        self.pattern_entry: Optional[ttk.Entry] = None
+        self.pattern_status: Optional[ttk.Label] = None
         self.preview_label: Optional[ttk.Label] = None
         self.info_label: Optional[ttk.Label] = None
+        self.alt_entry: Optional[ttk.Entry] = None
+        self.alt_status: Optional[ttk.Label] = None
+        self._alt_image: Optional[str] = None
         self._proposed_names: Dict[str, str] = {}
         self._current_preview_bytes: Optional[bytes] = None
         self._disk_paths: Dict[str, str] = {}
@@ -60,6 +75,11 @@ class MediaTab(ttk.Frame):
         self._is_playing = False
         self._current_video_path: Optional[str] = None
 
+        # Attachment state
+        self.attachment_tree: Optional[ttk.Treeview] = None
+        self.attachment_title_entry: Optional[ttk.Entry] = None
+        self.attachment_status: Optional[ttk.Label] = None
+
         # Build UI
         self._create_widgets()
 
@@ -79,10 +99,10 @@ class MediaTab(ttk.Frame):
         header.pack(fill="x", pady=(0, 10))
 
         # Global naming options (prefix applies to images AND videos)
-        options = ttk.LabelFrame(main_frame, text="Naming Options", padding=12)
+        options = ttk.LabelFrame(main_frame, text=tr("Naming Options"), padding=12)
         options.pack(fill="x", pady=(0, 10))
         options.columnconfigure(1, weight=1)
-        ttk.Label(options, text="Prefix:").grid(row=0, column=0, sticky="w", padx=(0, 8), pady=4)
+        ttk.Label(options, text=tr("Prefix:")).grid(row=0, column=0, sticky="w", padx=(0, 8), pady=4)
         self.prefix_entry = ttk.Entry(options)
         self.prefix_entry.grid(row=0, column=1, sticky="ew", pady=4)
         # Debounce frequent updates while typing to avoid UI lag with many images
@@ -100,6 +120,15 @@ class MediaTab(ttk.Frame):
                 # Fallback: direct update
                 self._apply_prefix_change()
         self.prefix_entry.bind("<KeyRelease>", _on_prefix_key)
+        # Image file name template (see orlando_toolkit.core.image_naming)
+        ttk.Label(options, text=tr("Image pattern:")).grid(row=1, column=0, sticky="w", padx=(0, 8), pady=4)
+        self.pattern_entry = ttk.Entry(options)
+        self.pattern_entry.grid(row=1, column=1, sticky="ew", pady=4)
+        self.pattern_entry.bind("<KeyRelease>", _on_prefix_key)
+        hint = "Placeholders: " + " ".join("{%s}" % name for name in PLACEHOLDERS) + "  (e.g. {counter:03})"
+        self.pattern_status = ttk.Label(options, text=hint, foreground="gray")
+        self.pattern_status.grid(row=2, column=1, sticky="w")
+        self._pattern_hint = hint
 
         # Notebook tabs for Images/Videos
         self.notebook = ttk.Notebook(main_frame)
@@ -109,6 +138,7 @@ class MediaTab(ttk.Frame):
         # Sections as tabs
         self._create_images_section()
         self._create_videos_section()
+        self._create_attachments_section()
 
     def _create_media_type_selector(self, parent: ttk.Frame) -> None:
         # Deprecated (using simplified header in _create_widgets)
@@ -127,7 +157,7 @@ class MediaTab(ttk.Frame):
         split.rowconfigure(0, weight=1)
 
         # Left list
-        left = ttk.LabelFrame(split, text="File Names Preview", padding=10)
+        left = ttk.LabelFrame(split, text=tr("File Names Preview"), padding=10)
         left.grid(row=0, column=0, sticky="nsew", padx=(0, 10))
         try:
             left.grid_propagate(False)
@@ -145,18 +175,18 @@ class MediaTab(ttk.Frame):
 
         buttons = ttk.Frame(left)
         buttons.grid(row=1, column=0, columnspan=2, sticky="w", pady=(8, 0))
-        folder_btn = ttk.Button(buttons, text="Open Folder", command=self.open_images_folder)
+        folder_btn = ttk.Button(buttons, text=tr("Open Folder"), command=self.open_images_folder)
         folder_btn.grid(row=0, column=0, padx=(0, 8))
         try:
             from orlando_toolkit.ui.custom_widgets import Tooltip
             Tooltip(folder_btn, "Open images folder", delay_ms=800)
         except Exception:
             pass
-        ttk.Button(buttons, text="Download", command=self.download_selected_image).grid(row=0, column=1, padx=(0, 8))
-        ttk.Button(buttons, text="Download All", command=self.download_all_images).grid(row=0, column=2)
+        ttk.Button(buttons, text=tr("Download"), command=self.download_selected_image).grid(row=0, column=1, padx=(0, 8))
+        ttk.Button(buttons, text=tr("Download All"), command=self.download_all_images).grid(row=0, column=2)
 
         # Right preview
-        right = ttk.LabelFrame(split, text="Image Preview", padding=10)
+        right = ttk.LabelFrame(split, text=tr("Image Preview"), padding=10)
         right.grid(row=0, column=1, sticky="nsew", padx=(10, 0))
         try:
             right.grid_propagate(False)
@@ -165,16 +195,32 @@ class MediaTab(ttk.Frame):
         right.columnconfigure(0, weight=1)
         right.rowconfigure(0, weight=1)
 
-        self.preview_label = ttk.Label(right, text="Select an image to preview", foreground="gray", anchor="center")
+        self.preview_label = ttk.Label(right, text=tr("Select an image to preview"), foreground="gray", anchor="center")
         self.preview_label.grid(row=0, column=0, sticky="nsew", pady=(0, 8))
         self.preview_label.bind("<Configure>", self._on_preview_resize)
 
         self.info_label = ttk.Label(right, text="", foreground="gray")
         self.info_label.grid(row=1, column=0, sticky="w", pady=(0, 8))
 
+        # Alt text of the selected image, applied to every <image> showing it
+        alt_row = ttk.Frame(right)
+        alt_row.grid(row=2, column=0, sticky="ew", pady=(0, 8))
+        alt_row.columnconfigure(1, weight=1)
+        ttk.Label(alt_row, text=tr("Alt text:")).grid(row=0, column=0, padx=(0, 6))
+        self.alt_entry = ttk.Entry(alt_row)
+        self.alt_entry.grid(row=0, column=1, sticky="ew")
+        self.alt_entry.bind("<Return>", self._apply_alt_text)
+        self.alt_entry.bind("<FocusOut>", self._apply_alt_text)
+        self.alt_status = ttk.Label(alt_row, text="", foreground="gray")
+        self.alt_status.grid(row=0, column=2, sticky="e", padx=(8, 0))
+        # Referenced from the asset server in the package (core.external_media)
+        self._image_external_var = tk.BooleanVar(value=False)
+        ttk.Checkbutton(alt_row, text=tr("Reference from asset server"), variable=self._image_external_var,
+                        command=self._toggle_image_external).grid(row=0, column=3, sticky="e", padx=(12, 0))
+
         actions = ttk.Frame(right)
-        actions.grid(row=2, column=0, sticky="w")
-        ttk.Label(actions, text="Editor:").grid(row=0, column=0, padx=(0, 6))
+        actions.grid(row=3, column=0, sticky="w")
+        ttk.Label(actions, text=tr("Editor:")).grid(row=0, column=0, padx=(0, 6))
         self._editor_choice_var = tk.StringVar(value="")
         self._editor_paths = self._detect_available_editors()
         editor_order = ["Paint", "GIMP", "Photoshop", "System default"]
@@ -188,18 +234,22 @@ class MediaTab(ttk.Frame):
         self._editor_choice_var.set(default_choice)
         editor_combo.grid(row=0, column=1, padx=(0, 10))
         editor_combo.bind("<<ComboboxSelected>>", self._on_editor_changed)
-        ttk.Button(actions, text="Edit Image", command=self.edit_image).grid(row=0, column=2, padx=(0, 6))
-        ttk.Button(actions, text="Reload", width=8, command=self.reload_edited_image).grid(row=0, column=3, padx=(0, 8))
+        ttk.Button(actions, text=tr("Edit Image"), command=self.edit_image).grid(row=0, column=2, padx=(0, 6))
+        ttk.Button(actions, text=tr("Reload"), width=8, command=self.reload_edited_image).grid(row=0, column=3, padx=(0, 8))
+        ttk.Button(actions, text=tr("Image Map…"), command=self.edit_image_map).grid(row=0, column=4, padx=(0, 8))
+        ttk.Button(actions, text=tr("Callouts…"), command=self.edit_callouts).grid(row=0, column=5, padx=(0, 8))
+        ttk.Button(actions, text=tr("Replace File…"), command=self.replace_image_file).grid(row=0, column=6, padx=(0, 6))
+        ttk.Button(actions, text=tr("Relink…"), command=self.relink_image_references).grid(row=0, column=7, padx=(0, 8))
         try:
-            actions.columnconfigure(4, weight=1)
+            actions.columnconfigure(8, weight=1)
         except Exception:
             pass
         self._actions_status = ttk.Label(actions, text="", foreground="#cc0000")
-        self._actions_status.grid(row=0, column=4, sticky="e")
+        self._actions_status.grid(row=0, column=8, sticky="e")
 
         # Add as tab
         try:
-            self.notebook.add(self.images_frame, text="Images")
+            self.notebook.add(self.images_frame, text=tr("Images"))
         except Exception:
             pass
 
@@ -211,7 +261,7 @@ class MediaTab(ttk.Frame):
         self.videos_frame.rowconfigure(0, weight=1)
 
         # Left: list
-        left = ttk.LabelFrame(self.videos_frame, text="Videos", padding=10)
+        left = ttk.LabelFrame(self.videos_frame, text=tr("Videos"), padding=10)
         left.grid(row=0, column=0, sticky="nsew", padx=(0, 10))
         left.columnconfigure(0, weight=1)
         left.rowconfigure(0, weight=1)
@@ -223,12 +273,12 @@ class MediaTab(ttk.Frame):
         self.video_listbox.configure(yscrollcommand=vsb.set)
         video_buttons = ttk.Frame(left)
         video_buttons.grid(row=1, column=0, columnspan=2, sticky="w", pady=(8, 0))
-        ttk.Button(video_buttons, text="Open Folder", command=self.open_videos_folder).pack(side="left", padx=(0, 6))
-        ttk.Button(video_buttons, text="Download", command=self._download_selected_video).pack(side="left", padx=(0, 6))
-        ttk.Button(video_buttons, text="Download All", command=self._download_all_videos).pack(side="left")
+        ttk.Button(video_buttons, text=tr("Open Folder"), command=self.open_videos_folder).pack(side="left", padx=(0, 6))
+        ttk.Button(video_buttons, text=tr("Download"), command=self._download_selected_video).pack(side="left", padx=(0, 6))
+        ttk.Button(video_buttons, text=tr("Download All"), command=self._download_all_videos).pack(side="left")
 
         # Right: player + info
-        right = ttk.LabelFrame(self.videos_frame, text="Video Preview", padding=10)
+        right = ttk.LabelFrame(self.videos_frame, text=tr("Video Preview"), padding=10)
         right.grid(row=0, column=1, sticky="nsew", padx=(10, 0))
         right.columnconfigure(0, weight=1)
         right.rowconfigure(1, weight=1)
@@ -242,20 +292,140 @@ class MediaTab(ttk.Frame):
         controls.grid(row=2, column=0, sticky="ew", pady=(10, 0))
         self.video_canvas = tk.Canvas(self.video_player_frame, bg="black", highlightthickness=0)
         self.video_canvas.grid(row=0, column=0, sticky="nsew")
-        self.play_pause_btn = ttk.Button(controls, text="Play", command=self._on_play_clicked, state="disabled")
+        self.play_pause_btn = ttk.Button(controls, text=tr("Play"), command=self._on_play_clicked, state="disabled")
         self.play_pause_btn.pack(side="left", padx=(0, 6))
-        self.stop_btn = ttk.Button(controls, text="Stop", command=self._stop_video, state="disabled")
+        self.stop_btn = ttk.Button(controls, text=tr("Stop"), command=self._stop_video, state="disabled")
         self.stop_btn.pack(side="left", padx=(0, 10))
         self.time_label = ttk.Label(controls, text="00:00 / 00:00")
         self.time_label.pack(side="left")
+        self._video_external_var = tk.BooleanVar(value=False)
+        self._video_external_name: Optional[str] = None
+        ttk.Checkbutton(controls, text=tr("Reference from asset server"), variable=self._video_external_var,
+                        command=self._toggle_video_external).pack(side="right")
         self._show_no_video_selected()
 
         # Add as tab
         try:
-            self.notebook.add(self.videos_frame, text="Videos")
+            self.notebook.add(self.videos_frame, text=tr("Videos"))
+        except Exception:
+            pass
+
+    def _create_attachments_section(self) -> None:
+        """Files shipped under resources/ with a resource-only map entry each."""
+        self.attachments_frame = ttk.Frame(self.notebook)
+        frame = ttk.LabelFrame(self.attachments_frame, text=tr("Attachments"), padding=10)
+        frame.pack(expand=True, fill="both")
+        frame.columnconfigure(0, weight=1)
+        frame.rowconfigure(1, weight=1)
+        ttk.Label(frame, text=tr("PDFs, checklists and other files shipped in the package's resources/ folder; "
+                              "each gets a resource-only entry in the map."),
+                  foreground="gray").grid(row=0, column=0, columnspan=2, sticky="w", pady=(0, 8))
+        self.attachment_tree = ttk.Treeview(frame, columns=("title", "format", "size"), selectmode="browse")
+        self.attachment_tree.heading("#0", text=tr("File"))
+        self.attachment_tree.heading("title", text=tr("Title"))
+        self.attachment_tree.heading("format", text=tr("Format"))
+        self.attachment_tree.heading("size", text=tr("Size"))
+        self.attachment_tree.column("format", width=70, stretch=False)
+        self.attachment_tree.column("size", width=90, stretch=False, anchor="e")
+        self.attachment_tree.grid(row=1, column=0, sticky="nsew")
+        self.attachment_tree.bind("<<TreeviewSelect>>", self._on_attachment_select)
+        sb = ttk.Scrollbar(frame, orient="vertical", command=self.attachment_tree.yview)
+        sb.grid(row=1, column=1, sticky="ns")
+        self.attachment_tree.configure(yscrollcommand=sb.set)
+
+        title_row = ttk.Frame(frame)
+        title_row.grid(row=2, column=0, columnspan=2, sticky="ew", pady=(8, 0))
+        title_row.columnconfigure(1, weight=1)
+        ttk.Label(title_row, text=tr("Title:")).grid(row=0, column=0, padx=(0, 6))
+        self.attachment_title_entry = ttk.Entry(title_row)
+        self.attachment_title_entry.grid(row=0, column=1, sticky="ew")
+        self.attachment_title_entry.bind("<Return>", lambda _e: self._set_attachment_title())
+        ttk.Button(title_row, text=tr("Set Title"), command=self._set_attachment_title).grid(row=0, column=2, padx=(6, 0))
+
+        buttons = ttk.Frame(frame)
+        buttons.grid(row=3, column=0, columnspan=2, sticky="ew", pady=(8, 0))
+        ttk.Button(buttons, text=tr("Add Files…"), command=self._add_attachments).pack(side="left", padx=(0, 6))
+        ttk.Button(buttons, text=tr("Remove"), command=self._remove_attachment).pack(side="left", padx=(0, 6))
+        ttk.Button(buttons, text=tr("Download"), command=self._download_attachment).pack(side="left")
+        self.attachment_status = ttk.Label(buttons, text="", foreground="gray")
+        self.attachment_status.pack(side="right")
+
+        try:
+            self.notebook.add(self.attachments_frame, text=tr("Attachments"))
         except Exception:
             pass
 
+    def _refresh_attachments(self, select: Optional[str] = None) -> None:
+        if self.attachment_tree is None:
+            return
+        self.attachment_tree.delete(*self.attachment_tree.get_children())
+        attachments = getattr(self.context, "attachments", None) or {}
+        for name in sorted(attachments):
+            size = len(attachments[name])
+            self.attachment_tree.insert("", "end", iid=name, text=name, values=(
+                attachment_title(self.context, name), attachment_format(name),
+                f"{size / 1024:.0f} KB" if size >= 1024 else f"{size} B"))
+        if select and self.attachment_tree.exists(select):
+            self.attachment_tree.selection_set(select)
+            self.attachment_tree.see(select)
+
+    def _selected_attachment(self) -> Optional[str]:
+        selection = self.attachment_tree.selection() if self.attachment_tree is not None else ()
+        return selection[0] if selection else None
+
+    def _on_attachment_select(self, _event=None) -> None:
+        name = self._selected_attachment()
+        if name and self.attachment_title_entry is not None:
+            self.attachment_title_entry.delete(0, tk.END)
+            self.attachment_title_entry.insert(0, attachment_title(self.context, name))
+
+    def _add_attachments(self) -> None:
+        if not self.context:
+            return
+        from tkinter import filedialog
+        paths = filedialog.askopenfilenames(title=tr("Attach Files"), filetypes=[
+            ("Documents", "*.pdf *.xlsx *.xls *.docx *.csv *.zip"), ("All files", "*.*")])
+        added, problems = [], []
+        for path in paths:
+            try:
+                added.append(attach_file(self.context, path))
+            except (OSError, ValueError) as exc:
+                problems.append(str(exc))
+        if added or problems:
+            self._refresh_attachments(select=added[-1] if added else None)
+        if self.attachment_status is not None:
+            message = f"{len(added)} file(s) attached" if added else ""
+            self.attachment_status.configure(text="; ".join(([message] if message else []) + problems),
+                                             foreground="#cc0000" if problems else "gray")
+
+    def _remove_attachment(self) -> None:
+        name = self._selected_attachment()
+        if name and self.context and remove_attachment(self.context, name):
+            self._refresh_attachments()
+            if self.attachment_title_entry is not None:
+                self.attachment_title_entry.delete(0, tk.END)
+            if self.attachment_status is not None:
+                self.attachment_status.configure(text=tr("{name} removed", name=name), foreground="gray")
+
+    def _set_attachment_title(self) -> None:
+        name = self._selected_attachment()
+        if name and self.context and self.attachment_title_entry is not None:
+            set_attachment_title(self.context, name, self.attachment_title_entry.get())
+            self._refresh_attachments(select=name)
+
+    def _download_attachment(self) -> None:
+        name = self._selected_attachment()
+        if not name or not self.context:
+            return
+        try:
+            from tkinter import filedialog
+            path = filedialog.asksaveasfilename(title=tr("Save Attachment"), initialfile=name,
+                                                defaultextension=Path(name).suffix)
+            if path:
+                Path(path).write_bytes(self.context.attachments[name])
+        except Exception as e:
+            logger.error(f"Failed to save attachment: {e}")
+
     def open_videos_folder(self) -> None:
         """Open the folder where videos were materialized."""
         try:
@@ -285,6 +455,8 @@ class MediaTab(ttk.Frame):
                 self.after(0, self._refresh_images)
             elif current == str(self.videos_frame):
                 self.after(0, self._refresh_videos)
+            elif current == str(self.attachments_frame):
+                self.after(0, self._refresh_attachments)
         except Exception:
             # Best effort fallbacks
             self.after(0, self._refresh_images)
@@ -328,13 +500,13 @@ class MediaTab(ttk.Frame):
     def _refresh_images(self) -> None:
         if not (self.context and self.image_listbox and self.prefix_entry):
             return
-        prefix = self.context.metadata.get("prefix")
-        if not prefix:
-            cfg = ConfigManager().get_image_naming()
-            prefix = cfg["prefix"]
-            self.context.metadata["prefix"] = prefix
+        naming = ImageNaming.resolve(self.context.metadata)
+        self.context.metadata["prefix"] = naming.prefix
         self.prefix_entry.delete(0, tk.END)
-        self.prefix_entry.insert(0, prefix)
+        self.prefix_entry.insert(0, naming.prefix)
+        if self.pattern_entry is not None:
+            self.pattern_entry.delete(0, tk.END)
+            self.pattern_entry.insert(0, naming.pattern)
         self.update_image_names()
         self._materialize_images_async()
 
@@ -354,7 +526,8 @@ class MediaTab(ttk.Frame):
                 config_manager.update_image_naming_config({"prefix": new_prefix})
             except Exception as e:
                 logger.warning("Failed to persist prefix change: %s", e)
-        
+        self._apply_pattern_change()
+
         self.image_listbox.delete(0, tk.END)
         if not getattr(self.context, "images", None):
             self.image_listbox.insert(tk.END, "No images found.")
@@ -364,6 +537,7 @@ class MediaTab(ttk.Frame):
         for original_filename in self.context.images.keys():
             new_filename = image_names.get(original_filename, original_filename)
             self.image_listbox.insert(tk.END, new_filename)
+        self._mark_missing_alt_text()
         if self._last_selected_key and self._last_selected_key in self.context.images:
             try:
                 idx = list(self.context.images.keys()).index(self._last_selected_key)
@@ -373,6 +547,51 @@ class MediaTab(ttk.Frame):
             except ValueError:
                 pass
 
+    def _mark_missing_alt_text(self) -> None:
+        """Show images without alt text in red and count them next to the alt text field."""
+        if not (self.context and self.image_listbox and getattr(self.context, "images", None)):
+            return
+        try:
+            missing = set(images_missing_alt(self.context))
+            for idx, name in enumerate(self.context.images.keys()):
+                self.image_listbox.itemconfig(idx, foreground="#cc0000" if name in missing else "")
+            if self.alt_status is not None:
+                self.alt_status.configure(text=tr("{n} image(s) without alt text", n=len(missing)) if missing else "")
+        except Exception as e:
+            logger.debug("Alt text markers unavailable: %s", e)
+
+    def _apply_alt_text(self, _event=None) -> None:
+        """Store the alt text typed for the selected image in its topics."""
+        if not (self.context and self.alt_entry and self._alt_image):
+            return
+        text = self.alt_entry.get().strip()
+        if text == image_alt_text(self.context, self._alt_image):
+            return
+        if not set_image_alt_text(self.context, self._alt_image, text):
+            self._set_status("Image is not used by any topic")
+            return
+        self._mark_missing_alt_text()
+
+    def _apply_pattern_change(self) -> None:
+        """Use the pattern typed in the Images tab when valid; report why otherwise."""
+        if not (self.context and self.pattern_entry):
+            return
+        new_pattern = self.pattern_entry.get()
+        error = ImageNaming.validate(new_pattern)
+        if self.pattern_status is not None:
+            self.pattern_status.configure(text=error or self._pattern_hint, foreground="red" if error else "gray")
+        if error:
+            return
+        settings = self.context.metadata.get("image_naming")
+        if not isinstance(settings, dict):
+            settings = self.context.metadata["image_naming"] = {}
+        if new_pattern != ImageNaming.resolve(self.context.metadata).pattern:
+            settings["pattern"] = new_pattern
+            try:
+                ConfigManager().update_image_naming_config({"pattern": new_pattern})
+            except Exception as e:
+                logger.warning("Failed to persist image pattern change: %s", e)
+
     def _apply_prefix_change(self) -> None:
         """Apply debounced prefix change to both images and videos."""
         try:
@@ -402,7 +621,7 @@ class MediaTab(ttk.Frame):
         """
         if not self.context:
             return {}
-        prefix = self.context.metadata.get("prefix") or ConfigManager().get_image_naming()["prefix"]
+        prefix = self.context.metadata.get("prefix") or ImageNaming.resolve(self.context.metadata).prefix
         manual_code = self.context.metadata.get("manual_code", "")
 
         # Try to infer section numbers by scanning topics for media references
@@ -449,147 +668,14 @@ class MediaTab(ttk.Frame):
         return out
 
     def _create_per_section_image_names(self) -> Dict[str, str]:
-        """Create new filenames with per-section numbering (ImageTab rules)."""
+        """Proposed file names, as applied when the package is prepared."""
         if self.context is None or getattr(self.context, "ditamap_root", None) is None:
             return {}
-        from orlando_toolkit.core.utils import find_topicref_for_image, get_section_number_for_topicref
-        prefix = self.context.metadata.get("prefix")
-        if not prefix:
-            prefix = ConfigManager().get_image_naming()["prefix"]
-        manual_code = self.context.metadata.get("manual_code", "")
-        section_images: Dict[str, List[str]] = {}
-        for image_filename in self.context.images.keys():
-            topicref = find_topicref_for_image(image_filename, self.context)
-            if topicref is not None:
-                section_number = get_section_number_for_topicref(topicref, self.context.ditamap_root)
-            else:
-                section_number = "0"
-            section_images.setdefault(section_number, []).append(image_filename)
-        image_names: Dict[str, str] = {}
-        for section_number, images_in_section in section_images.items():
-            for i, image_filename in enumerate(images_in_section):
-                extension = os.path.splitext(image_filename)[1]
-                base = f"{prefix}-{manual_code}-{section_number}" if manual_code else f"{prefix}-{section_number}"
-                if len(images_in_section) > 1:
-                    image_names[image_filename] = f"{base}-{i+1}{extension}"
-                else:
-                    image_names[image_filename] = f"{base}{extension}"
-        return image_names
-
-    def open_images_folder(self) -> None:
-        try:
-            from orlando_toolkit.core.session_storage import get_session_storage
-            storage = get_session_storage()
-            folder = storage.base_dir
-        except Exception:
-            folder = Path(tempfile.gettempdir())
-        try:
-            if os.name == 'nt':
-                os.startfile(str(folder))
-            elif os.name == 'posix':
-                if 'darwin' in os.uname().sysname.lower():
-                    subprocess.run(['open', str(folder)])
-                else:
-                    subprocess.run(['xdg-open', str(folder)])
-        except Exception:
-            pass
-
-    def download_selected_image(self) -> None:
-        if not (self.context and self.image_listbox):
-            return
-        selection = self.image_listbox.curselection()
-        if not selection:
-            return
-        index = selection[0]
-        if index >= len(self.context.images):
-            self._set_status("No image selected")
-            return
-        original_filename = list(self.context.images.keys())[index]
-        proposed = self._proposed_names.get(original_filename, original_filename)
-        try:
-            from tkinter import filedialog
-            save_path = filedialog.asksaveasfilename(
-                title="Save Image As",
-                initialfile=proposed,
-                defaultextension=os.path.splitext(proposed)[1] or ".png",
-                filetypes=(("Images", "*.png;*.jpg;*.jpeg;*.gif;*.bmp;*.tiff"), ("All files", "*.*")),
-            )
-        except Exception:
-            save_path = ""
-        if not save_path:
-            return
-        try:
-            image_data = self.context.images[original_filename]
-            with open(save_path, "wb") as f:
-                f.write(image_data)
-        except Exception:
-            pass
-
-    def download_all_images(self) -> None:
-        if not self.context or not getattr(self.context, "images", None):
-            return
-        if not self._proposed_names:
-            self._proposed_names = self._create_per_section_image_names()
-        try:
-            manual_code = str(self.context.metadata.get("manual_code") or "").strip()
-        except Exception:
-            manual_code = ""
-        if not manual_code:
-            try:
-                from orlando_toolkit.core.utils import slugify
-                title = str(self.context.metadata.get("manual_title") or "").strip()
-                manual_code = slugify(title) if title else "images"
-            except Exception:
-                manual_code = "images"
-        folder_name = f"{manual_code}_images"
         try:
-            from tkinter import filedialog
-            directory = filedialog.askdirectory(title=f"Choose parent folder for '{folder_name}'")
-        except Exception:
-            directory = ""
-        if not directory:
-            return
-        try:
-            target_root = os.path.join(directory, folder_name)
-            os.makedirs(target_root, exist_ok=True)
-        except Exception:
-            pass
-        for original_filename, image_data in self.context.images.items():
-            target_name = self._proposed_names.get(original_filename, original_filename)
-            target_path = os.path.join(target_root, target_name)
-            try:
-                with open(target_path, "wb") as f:
-                    f.write(image_data)
-            except Exception:
-                continue
-
-    def _materialize_images_async(self) -> None:
-        if not (self.context and getattr(self.context, "images", None)):
-            return
-        self._set_status("Preparing images on disk...")
-
-        def _work():
-            try:
-                from orlando_toolkit.core.session_storage import get_session_storage
-                storage = get_session_storage()
-            except Exception:
-                storage = None
-            for original_filename, data in list(self.context.images.items()):
-                try:
-                    if storage:
-                        path = storage.ensure_image_written(original_filename, data)
-                        self._disk_paths[original_filename] = str(path)
-                except Exception:
-                    continue
-            try:
-                self.after(0, lambda: self._set_status(""))
-            except Exception:
-                pass
-
-        threading.Thread(target=_work, daemon=True).start()
-
-    def _get_disk_path(self, original_filename: str) -> Optional[str]:
-        return self._disk_paths.get(original_filename)
+            return propose_names(self.context)
+        except Exception as e:
+            logger.warning("Image naming failed: %s", e)
+            return {}
 
     def _show_no_media_message(self) -> None:
         pass
@@ -597,8 +683,11 @@ class MediaTab(ttk.Frame):
     def load_context(self, context: "DitaContext") -> None:
         """Load media from DITA context and populate both sections."""
         self.context = context
+        self._alt_image = None
+        if self.alt_entry is not None:
+            self.alt_entry.delete(0, tk.END)
         if "prefix" not in self.context.metadata:
-            self.context.metadata["prefix"] = ConfigManager().get_image_naming()["prefix"]
+            self.context.metadata["prefix"] = ImageNaming.resolve(self.context.metadata).prefix
         videos = getattr(context, "videos", {})
         images = getattr(context, "images", {})
         # Select default tab in the notebook
@@ -613,6 +702,7 @@ class MediaTab(ttk.Frame):
         # Initial refreshes
         self._refresh_images()
         self._refresh_videos()
+        self._refresh_attachments()
 
     def clear_context(self) -> None:
         self.context = None
@@ -630,6 +720,8 @@ class MediaTab(ttk.Frame):
                 self.preview_label.image = None
             if self.info_label:
                 self.info_label.configure(text="")
+            if self.attachment_tree is not None:
+                self.attachment_tree.delete(*self.attachment_tree.get_children())
         except Exception:
             pass
         logger.info("Cleared media context")
@@ -766,6 +858,76 @@ class MediaTab(ttk.Frame):
 
         threading.Thread(target=_wait_and_reload, daemon=True).start()
 
+    def edit_image_map(self) -> None:
+        """Draw clickable areas over the selected image, linked to topics (DITA ``<imagemap>``)."""
+        if not (self.context and self.image_listbox):
+            return
+        sel = self.image_listbox.curselection()
+        if not sel or sel[0] >= len(self.context.images):
+            self._set_status("No image selected")
+            return
+        name = list(self.context.images.keys())[sel[0]]
+        data = self.context.images[name]
+        if is_svg(data):
+            self._set_status("Image maps need a raster image (PNG, JPEG, GIF)")
+            return
+        from orlando_toolkit.core.imagemaps import image_map_areas, link_targets, set_image_map
+        from orlando_toolkit.ui.dialogs.imagemap_dialog import ImageMapEditor
+
+        context = self.context
+
+        def _save(areas) -> str:
+            count = set_image_map(context, name, areas)
+            if not count:
+                return "Image is not used by any topic"
+            return f"Image map saved: {len(areas)} area(s) on {count} image(s)"
+
+        try:
+            ImageMapEditor(self, name=name, data=data, areas=image_map_areas(context, name),
+                           targets=link_targets(context), on_save=_save)
+        except Exception as exc:
+            logger.error("Image map editor failed for %s: %s", name, exc, exc_info=True)
+            self._set_status("Failed to open the image map editor")
+
+    def edit_callouts(self) -> None:
+        """Place numbered callouts on the selected image and list their parts in the owning topics."""
+        if not (self.context and self.image_listbox):
+            return
+        sel = self.image_listbox.curselection()
+        if not sel or sel[0] >= len(self.context.images):
+            self._set_status("No image selected")
+            return
+        from orlando_toolkit.core.callouts import METADATA_KEY, image_callouts, set_image_callouts
+        from orlando_toolkit.ui.dialogs.callout_dialog import CalloutEditor
+
+        context = self.context
+        name = list(context.images.keys())[sel[0]]
+        # The annotated copy is edited through its original
+        for original, saved in (context.metadata.get(METADATA_KEY) or {}).items():
+            if saved.get("output") == name and original in context.images:
+                name = original
+                break
+        data = context.images[name]
+        if is_svg(name, data) or is_metafile(name, data):
+            self._set_status("Callouts need a raster image (PNG, JPEG, GIF)")
+            return
+        callouts, mode = image_callouts(context.metadata, name)
+
+        def _save(callouts, mode) -> str:
+            count = set_image_callouts(context, name, callouts, mode)
+            self.update_image_names()
+            if not count:
+                return "Image is not used by any topic"
+            if not callouts:
+                return f"Callouts removed from {count} image(s)"
+            return f"Callouts saved: {len(callouts)} marker(s) on {count} image(s)"
+
+        try:
+            CalloutEditor(self, name=name, data=data, callouts=callouts, mode=mode, on_save=_save)
+        except Exception as exc:
+            logger.error("Callout editor failed for %s: %s", name, exc, exc_info=True)
+            self._set_status("Failed to open the callout editor")
+
     def reload_edited_image(self) -> None:
         if not (self.context and self.image_listbox):
             return
@@ -794,6 +956,92 @@ class MediaTab(ttk.Frame):
         except Exception:
             self._set_status("Failed to reload edited image")
 
+    def _selected_image_key(self) -> Optional[str]:
+        if not (self.context and self.image_listbox):
+            return None
+        sel = self.image_listbox.curselection()
+        if sel and sel[0] < len(self.context.images):
+            return list(self.context.images.keys())[sel[0]]
+        if self._last_selected_key and self._last_selected_key in self.context.images:
+            return self._last_selected_key
+        return None
+
+    def _forget_session_copy(self, *names: str) -> None:
+        """Drop the copies written for the external editors, so they are written again from the new bytes."""
+        from orlando_toolkit.core.session_storage import get_session_storage
+        storage = get_session_storage()
+        for name in names:
+            try:
+                storage.get_image_path(name).unlink()
+            except OSError:
+                pass
+
+    def _show_image(self, name: str) -> None:
+        """Refresh the list and select and preview *name*."""
+        self._last_selected_key = name
+        self.update_image_names()
+        if self.alt_entry is not None:
+            self._alt_image = name
+            self.alt_entry.delete(0, tk.END)
+            self.alt_entry.insert(0, image_alt_text(self.context, name))
+        self.show_image_preview(name, self.context.images[name])
+
+    def replace_image_file(self) -> None:
+        """Swap the selected image for another file; every topic showing it shows the new file."""
+        name = self._selected_image_key()
+        if not name:
+            self._set_status("No image selected")
+            return
+        from tkinter import filedialog, messagebox
+        path = filedialog.askopenfilename(title=tr("Replace {name}", name=name), filetypes=[
+            ("Images", "*.png *.jpg *.jpeg *.gif *.bmp *.tif *.tiff *.webp *.svg *.emf *.wmf"), ("All files", "*.*")])
+        if not path:
+            return
+        keep = messagebox.askyesnocancel(
+            tr("Replace Image"), f"Keep the name {name}?\n\nYes keeps it (the extension follows the new file's "
+                             f"format); No names the image after {Path(path).name}.", parent=self)
+        if keep is None:
+            return
+        from orlando_toolkit.core.image_relink import image_usage, replace_image
+        try:
+            data = Path(path).read_bytes()
+            new = replace_image(self.context, name, data, filename=path, keep_name=keep)
+        except (OSError, ValueError) as exc:
+            self._set_status(f"Replace failed: {exc}")
+            return
+        self._forget_session_copy(name, new)
+        self._show_image(new)
+        topics = len(image_usage(self.context).get(new, []))
+        renamed = f" as {new}" if new != name else ""
+        self._set_status(f"Image updated{renamed}; shown in {topics} topic(s)")
+
+    def relink_image_references(self) -> None:
+        """Point the references to one image, deleted or not, at another image."""
+        if not (self.context and getattr(self.context, "images", None)):
+            self._set_status("No image selected")
+            return
+        from orlando_toolkit.core.image_relink import image_usage, missing_images, relink_image
+        from orlando_toolkit.ui.dialogs.relink_image_dialog import RelinkImageDialog
+
+        usage = image_usage(self.context)
+        if not usage:
+            self._set_status("No topic shows an image")
+            return
+        choice = RelinkImageDialog(self, usage, list(self.context.images), missing_images(self.context),
+                                   target=self._selected_image_key()).show_modal()
+        if choice is None:
+            return
+        old, new, remove_old = choice
+        try:
+            count = relink_image(self.context, old, new, remove_old=remove_old)
+        except ValueError as exc:
+            self._set_status(f"Relink failed: {exc}")
+            return
+        if remove_old:
+            self._forget_session_copy(old)
+        self._show_image(new)
+        self._set_status(f"Relinked {count} reference(s) from {old} to {new}")
+
     def _set_status(self, message: str) -> None:
         self._status_message = message or ""
         if self._current_preview_bytes:
@@ -846,7 +1094,7 @@ class MediaTab(ttk.Frame):
         folder_name = f"{manual_code}_images"
         try:
             from tkinter import filedialog
-            directory = filedialog.askdirectory(title=f"Choose parent folder for '{folder_name}'")
+            directory = filedialog.askdirectory(title=tr("Choose parent folder for '{folder}'", folder=folder_name))
         except Exception:
             directory = ""
         if not directory:
@@ -876,10 +1124,11 @@ class MediaTab(ttk.Frame):
                 storage = get_session_storage()
             except Exception:
                 storage = None
-            for original_filename, data in list(self.context.images.items()):
+            # One image in memory at a time (images may be spooled to disk)
+            for original_filename in list(self.context.images):
                 try:
                     if storage:
-                        path = storage.ensure_image_written(original_filename, data)
+                        path = storage.ensure_image_written(original_filename, self.context.images[original_filename])
                         self._disk_paths[original_filename] = str(path)
                 except Exception:
                     continue
@@ -924,6 +1173,8 @@ class MediaTab(ttk.Frame):
             self._show_no_video_selected()
             return
         video_filename = keys[idx]
+        self._video_external_name = video_filename
+        self._video_external_var.set(is_marked_external(self.context.metadata, video_filename))
         self._show_video_details(video_filename)
 
     def _show_video_details(self, video_filename: str) -> None:
@@ -932,7 +1183,13 @@ class MediaTab(ttk.Frame):
         for w in (self.video_player_frame.winfo_children() if self.video_player_frame else []):
             w.destroy()
 
-        ttk.Label(self.video_info_frame, text=f"Video: {video_filename}", font=("TkDefaultFont", 9, "bold")).pack(anchor="w")
+        label = (tr("Audio: {name}", name=video_filename) if is_audio(video_filename)
+                 else tr("Video: {name}", name=video_filename))
+        ttk.Label(self.video_info_frame, text=label,
+                  font=("TkDefaultFont", 9, "bold")).pack(anchor="w")
+        poster = media_poster(self.context, video_filename) if self.context else None
+        if poster and poster in getattr(self.context, 'images', {}):
+            ttk.Label(self.video_info_frame, text=tr("Poster: {name}", name=poster)).pack(anchor="w")
         try:
             import cv2
             if self.context and video_filename in getattr(self.context, 'videos', {}):
@@ -951,30 +1208,46 @@ class MediaTab(ttk.Frame):
                         duration = frame_count / fps if fps > 0 else 0
                         cap.release()
                         for k, v in {
-                            "Resolution": f"{width}x{height}",
-                            "Frame Rate": f"{fps:.1f} fps" if fps > 0 else "Unknown",
-                            "Duration": f"{duration:.1f}s" if duration > 0 else "Unknown",
-                            "Frames": str(frame_count),
+                            tr("Resolution"): f"{width}x{height}",
+                            tr("Frame Rate"): f"{fps:.1f} fps" if fps > 0 else tr("Unknown"),
+                            tr("Duration"): f"{duration:.1f}s" if duration > 0 else tr("Unknown"),
+                            tr("Frames"): str(frame_count),
                         }.items():
-                            ttk.Label(self.video_info_frame, text=f"{k}: {v}").pack(anchor="w")
+                            ttk.Label(self.video_info_frame,
+                                      text=tr("{label}: {value}", label=k, value=v)).pack(anchor="w")
                 finally:
                     try:
                         os.unlink(temp_path)
                     except Exception:
                         pass
         except Exception:
-            ttk.Label(self.video_info_frame, text="Limited metadata (OpenCV not available)").pack(anchor="w")
+            ttk.Label(self.video_info_frame, text=tr("Limited metadata (OpenCV not available)")).pack(anchor="w")
 
         # Player canvas (recreate to reset)
         self.video_canvas = tk.Canvas(self.video_player_frame, bg="black", highlightthickness=0)
         self.video_canvas.grid(row=0, column=0, sticky="nsew")
+        if poster and poster in getattr(self.context, 'images', {}):
+            self._show_video_poster(self.context.images[poster])
         # Make the right-side Play button active to start playback
         if self.play_pause_btn:
             try:
-                self.play_pause_btn.configure(state="normal", text="Play")
+                self.play_pause_btn.configure(state="normal", text=tr("Play"))
             except Exception:
                 pass
 
+    def _show_video_poster(self, data: bytes) -> None:
+        """Draw the poster picture of a clip on the player canvas until playback starts."""
+        try:
+            image = Image.open(io.BytesIO(data))
+            image.thumbnail((640, 360))
+            self._video_poster_photo = ImageTk.PhotoImage(image)
+            self.video_canvas.update_idletasks()
+            width = max(self.video_canvas.winfo_width(), image.width)
+            height = max(self.video_canvas.winfo_height(), image.height)
+            self.video_canvas.create_image(width // 2, height // 2, image=self._video_poster_photo, anchor="center")
+        except Exception as e:
+            logger.debug("Poster not shown: %s", e)
+
     def _play_selected_video(self) -> None:
         if not self.video_listbox or not self.context:
             return
@@ -986,6 +1259,10 @@ class MediaTab(ttk.Frame):
         if idx >= len(keys):
             return
         video_filename = keys[idx]
+        if is_audio(video_filename):
+            # The embedded player shows frames only; audio opens in the system player
+            self._play_video_externally(video_filename)
+            return
         self._play_video_embedded(video_filename)
 
     def _on_play_clicked(self) -> None:
@@ -1018,7 +1295,7 @@ class MediaTab(ttk.Frame):
             frame_count = int(self._video_player.get(cv2.CAP_PROP_FRAME_COUNT) or 0)
             duration = frame_count / fps if fps > 0 else 0
             if self.play_pause_btn:
-                self.play_pause_btn.configure(state="normal", text="Pause")
+                self.play_pause_btn.configure(state="normal", text=tr("Pause"))
             if self.stop_btn:
                 self.stop_btn.configure(state="normal")
             self._is_playing = True
@@ -1067,7 +1344,7 @@ class MediaTab(ttk.Frame):
         folder_name = f"{manual_code}_videos"
         try:
             from tkinter import filedialog
-            directory = filedialog.askdirectory(title=f"Choose parent folder for '{folder_name}'")
+            directory = filedialog.askdirectory(title=tr("Choose parent folder for '{folder}'", folder=folder_name))
         except Exception:
             directory = ''
         if not directory:
@@ -1175,13 +1452,13 @@ class MediaTab(ttk.Frame):
     def _pause_video(self) -> None:
         self._is_playing = False
         if self.play_pause_btn:
-            self.play_pause_btn.configure(text="Play")
+            self.play_pause_btn.configure(text=tr("Play"))
 
     def _resume_video(self) -> None:
         if self._video_player and self._video_player.isOpened():
             self._is_playing = True
             if self.play_pause_btn:
-                self.play_pause_btn.configure(text="Pause")
+                self.play_pause_btn.configure(text=tr("Pause"))
 
     def _stop_video(self) -> None:
         self._is_playing = False
@@ -1198,7 +1475,7 @@ class MediaTab(ttk.Frame):
                 pass
         self._current_video_path = None
         if self.play_pause_btn:
-            self.play_pause_btn.configure(text="Play", state="disabled")
+            self.play_pause_btn.configure(text=tr("Play"), state="disabled")
         if self.stop_btn:
             self.stop_btn.configure(state="disabled")
         if self.time_label:
@@ -1212,7 +1489,7 @@ class MediaTab(ttk.Frame):
             self.video_canvas.create_text(
                 self.video_canvas.winfo_width() // 2,
                 self.video_canvas.winfo_height() // 2,
-                text="Video Ended",
+                text=tr("Video Ended"),
                 fill="white",
                 font=("Arial", 14),
             )
@@ -1232,7 +1509,7 @@ class MediaTab(ttk.Frame):
             video_filename = self.video_listbox.get(selection[0])
             from tkinter import filedialog
             filepath = filedialog.asksaveasfilename(
-                title="Save Video",
+                title=tr("Save Video"),
                 defaultextension=Path(video_filename).suffix,
                 initialname=video_filename,
             )
@@ -1248,9 +1525,9 @@ class MediaTab(ttk.Frame):
         for widget in (self.video_player_frame.winfo_children() if self.video_player_frame else []):
             widget.destroy()
         if self.video_info_frame:
-            ttk.Label(self.video_info_frame, text="No video selected").pack()
+            ttk.Label(self.video_info_frame, text=tr("No video selected")).pack()
         if self.video_player_frame:
-            ttk.Label(self.video_player_frame, text="Select a video from the list to view details").pack(expand=True)
+            ttk.Label(self.video_player_frame, text=tr("Select a video from the list to view details")).pack(expand=True)
     def on_image_select(self, _event) -> None:
         if not (self.context and self.image_listbox):
             return
@@ -1263,8 +1540,31 @@ class MediaTab(ttk.Frame):
         original_filename = list(self.context.images.keys())[index]
         self._last_selected_key = original_filename
         self._clear_status()
+        if self.alt_entry is not None:
+            self._alt_image = original_filename
+            self.alt_entry.delete(0, tk.END)
+            self.alt_entry.insert(0, image_alt_text(self.context, original_filename))
+        self._image_external_var.set(is_marked_external(self.context.metadata, original_filename))
         self.show_image_preview(original_filename, self.context.images[original_filename])
 
+    def _toggle_image_external(self) -> None:
+        if self.context and self._last_selected_key in getattr(self.context, "images", {}):
+            self._set_external(self._last_selected_key, self._image_external_var.get())
+
+    def _toggle_video_external(self) -> None:
+        if self.context and self._video_external_name in getattr(self.context, "videos", {}):
+            self._set_external(self._video_external_name, self._video_external_var.get())
+
+    def _set_external(self, name: str, external: bool) -> None:
+        """Mark *name* to be referenced from the asset server instead of embedded when packaging."""
+        mark_external(self.context.metadata, name, external)
+        if external and not ExternalMediaSettings.resolve(self.context.metadata).active:
+            self._set_status("Set external_media.base_url in conversion.yml to reference marked media")
+        elif external:
+            self._set_status("Referenced from the asset server when packaging")
+        else:
+            self._set_status("Embedded in the package")
+
     def show_image_preview(self, filename: str, image_data: bytes) -> None:
         if not self.preview_label:
             return
@@ -1273,16 +1573,19 @@ class MediaTab(ttk.Frame):
             self._render_preview_from_bytes(image_data)
         except Exception as e:
             try:
-                self.preview_label.configure(image="", text=f"Cannot preview image\n{str(e)}")
+                self.preview_label.configure(image="", text=tr("Cannot preview image\n{error}", error=e))
                 self.preview_label.image = None
             except Exception:
                 pass
             if self.info_label:
-                self.info_label.configure(text="Preview unavailable")
+                self.info_label.configure(text=tr("Preview unavailable"))
 
     def _render_preview_from_bytes(self, image_data: bytes) -> None:
         if not self.preview_label:
             return
+        if is_svg("", image_data):
+            self._render_svg_info(image_data)
+            return
         try:
             image = Image.open(io.BytesIO(image_data))
             original_w, original_h = image.size
@@ -1307,11 +1610,29 @@ class MediaTab(ttk.Frame):
             self.info_label.configure(text="\n".join(info))
         except Exception:
             try:
-                self.preview_label.configure(image="", text="Preview unavailable")
+                self.preview_label.configure(image="", text=tr("Preview unavailable"))
                 self.preview_label.image = None
             except Exception:
                 pass
 
+    def _render_svg_info(self, image_data: bytes) -> None:
+        """SVGs cannot be rasterized here: show their declared geometry instead."""
+        declared = svg_info(image_data)
+        size = f"{declared['width']}x{declared['height']}" if "width" in declared and "height" in declared else None
+        lines = ["Vector image (SVG)", "Size: " + (size or declared.get("viewBox") or "not declared")]
+        try:
+            self.preview_label.configure(image="", text="\n".join(lines))
+            self.preview_label.image = None
+        except Exception:
+            pass
+        if self.info_label:
+            info = [f"Size: {len(image_data) / 1024:.1f} KB", "Format: SVG"]
+            if declared.get("viewBox"):
+                info.append(f"viewBox: {declared['viewBox']}")
+            if self._status_message:
+                info.append(self._status_message)
+            self.info_label.configure(text="\n".join(info))
+
     def _on_preview_resize(self, _event) -> None:
         if self._current_preview_bytes:
             try:
@@ -1353,10 +1674,10 @@ class MediaTab(ttk.Frame):
         try:
             from tkinter import filedialog
             save_path = filedialog.asksaveasfilename(
-                title="Save Image As",
+                title=tr("Save Image As"),
                 initialfile=proposed,
                 defaultextension=os.path.splitext(proposed)[1] or ".png",
-                filetypes=(("Images", "*.png;*.jpg;*.jpeg;*.gif;*.bmp;*.tiff"), ("All files", "*.*")),
+                filetypes=(("Images", "*.png;*.jpg;*.jpeg;*.gif;*.bmp;*.tiff;*.svg"), ("All files", "*.*")),
             )
         except Exception:
             save_path = ""
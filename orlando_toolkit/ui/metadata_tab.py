@@ -9,6 +9,9 @@ from tkinter import ttk
 from typing import TYPE_CHECKING
 import logging
 
+from orlando_toolkit.core.prolog_metadata import METADATA_KEY, PrologMetadata
+from orlando_toolkit.ui.i18n import tr
+
 if TYPE_CHECKING:
     from orlando_toolkit.core.models import DitaContext
 
@@ -19,6 +22,9 @@ class MetadataTab(ttk.Frame):
     def __init__(self, parent, *args, **kwargs):
         super().__init__(parent, *args, **kwargs)
         from orlando_toolkit.ui.widgets.metadata_form import MetadataForm
+        from orlando_toolkit.ui.widgets.key_table_editor import KeyTableEditor
+        from orlando_toolkit.ui.widgets.profiling_editor import ProfilingEditor
+        from orlando_toolkit.ui.widgets.prolog_editor import PrologEditor
 
         self.context: "DitaContext" | None = None
         self.on_metadata_change_callback = None  # Callback for notifying other tabs
@@ -26,7 +32,7 @@ class MetadataTab(ttk.Frame):
         wrapper = ttk.Frame(self, padding=20)
         wrapper.pack(fill="both", expand=True)
 
-        title_label = ttk.Label(wrapper, text="Document Metadata", font=("Arial", 16, "bold"))
+        title_label = ttk.Label(wrapper, text=tr("Document Metadata"), font=("Arial", 16, "bold"))
         title_label.pack(anchor="w", pady=(0, 14))
 
         # Reuse the unified metadata form widget to ensure consistent style.
@@ -40,12 +46,24 @@ class MetadataTab(ttk.Frame):
 
         help_text = ttk.Label(
             wrapper,
-            text="These metadata fields will be included in the generated DITA archive.",
+            text=tr("These metadata fields will be included in the generated DITA archive."),
             font=("Arial", 10),
             foreground="gray",
         )
         help_text.pack(anchor="w", pady=(12, 0))
 
+        # Author, dates, audience, product, permissions and othermeta of every topic and of the map
+        self._prolog = PrologEditor(wrapper, text=tr("Prolog metadata (whole manual)"), on_apply=self._on_prolog_apply)
+        self._prolog.pack(fill="x", pady=(16, 0))
+
+        # Configurations of a profiled manual, one DITAVAL file each in the package
+        self._profiling = ProfilingEditor(wrapper, on_change=self._on_form_change)
+        self._profiling.pack(fill="x", pady=(16, 0))
+
+        # Product names and values kept as keys, defined once in the package
+        self._keys = KeyTableEditor(wrapper, on_change=self._on_form_change)
+        self._keys.pack(fill="x", pady=(16, 0))
+
     # ---------------------------------------------------------------------
     # Public API
     # ---------------------------------------------------------------------
@@ -53,6 +71,9 @@ class MetadataTab(ttk.Frame):
     def load_context(self, context: "DitaContext") -> None:
         self.context = context
         self._form.load_context(context)
+        self._prolog.set_values(PrologMetadata.from_mapping(context.metadata.get(METADATA_KEY)))
+        self._profiling.load_context(context)
+        self._keys.load_context(context)
 
     def commit(self) -> None:
         """Persist current form values into context.metadata immediately."""
@@ -64,6 +85,13 @@ class MetadataTab(ttk.Frame):
         except Exception:
             pass
 
+    def _on_prolog_apply(self, values: PrologMetadata) -> None:
+        if not self.context:
+            return
+        # An empty mapping (not a removed key) so clearing reaches the Structure tab's copies
+        self.context.metadata[METADATA_KEY] = values.to_mapping()
+        self._on_form_change()
+
     def _on_form_change(self) -> None:
         # Form already synced context; just propagate to other tabs and caller
         if not self.context:
@@ -28,7 +28,7 @@ Notes:
 
 from __future__ import annotations
 
-from typing import Optional, List
+from typing import Any, Dict, Optional, List
 import threading
 import tkinter as tk
 from tkinter import ttk
@@ -66,6 +66,7 @@ from orlando_toolkit.ui.tabs.structure.search_coordinator import SearchCoordinat
 from orlando_toolkit.ui.tabs.structure.context_actions import ContextActions
 from orlando_toolkit.ui.tabs.structure.tree_refresh_coordinator import TreeRefreshCoordinator
 from orlando_toolkit.ui.widgets.universal_spinner import UniversalSpinner
+from orlando_toolkit.ui.i18n import tr
 
 logger = logging.getLogger(__name__)
 
@@ -156,7 +157,12 @@ class StructureTab(ttk.Frame):
         search_row.grid(row=0, column=0, sticky="ew", padx=8, pady=(8, 4))
         toolbar_row.grid(row=1, column=0, sticky="ew", padx=8, pady=(0, 6))
 
-        self._toolbar = ToolbarWidget(toolbar_row, on_move=self._on_toolbar_move_clicked)
+        self._toolbar = ToolbarWidget(
+            toolbar_row,
+            on_move=self._on_toolbar_move_clicked,
+            on_undo=lambda: self._on_shortcut_undo(None),
+            on_redo=lambda: self._on_shortcut_redo(None),
+        )
         self._toolbar.grid(row=0, column=0, sticky="w")
 
         # Search widget: make it noticeably narrower via explicit entry width
@@ -164,6 +170,7 @@ class StructureTab(ttk.Frame):
             search_row,
             on_term_changed=self._on_search_term_changed,
             on_navigate=self._on_search_navigate,
+            on_options_changed=self._on_search_options_changed,
             entry_width=30,
         )
         # Do not stretch the search widget; keep compact
@@ -181,7 +188,7 @@ class StructureTab(ttk.Frame):
             depth_container = ttk.Frame(search_row)
             depth_container.grid(row=0, column=2, padx=(12, 0), sticky="w")
 
-            depth_label = ttk.Label(depth_container, text="Depth")
+            depth_label = ttk.Label(depth_container, text=tr("Depth"))
             depth_label.grid(row=0, column=0, sticky="w")
 
             self._depth_spin = ttk.Spinbox(
@@ -299,6 +306,9 @@ class StructureTab(ttk.Frame):
             on_selection_changed=self._on_tree_selection_changed,
             on_item_activated=self._on_tree_item_activated,
             on_context_menu=self._on_tree_context_menu,
+            on_drop=self._on_tree_drop,
+            on_delete=self._on_tree_delete,
+            on_clipboard=self._on_tree_clipboard,
         )
         self._tree.grid(row=0, column=0, sticky="nsew")
         # Loading spinner (replaces hourglass cursor with elegant animation)
@@ -314,6 +324,9 @@ class StructureTab(ttk.Frame):
             right,
             on_mode_changed=self._on_preview_mode_changed,
             on_breadcrumb_clicked=self._on_breadcrumb_clicked,
+            on_split_changed=self._on_preview_split_changed,
+            on_revisions_changed=self._on_preview_revisions_changed,
+            on_spelling_changed=self._on_preview_spelling_changed,
         )
         self._preview_panel.grid(row=0, column=0, sticky="nsew")
         self._filter_panel: Optional[object] = None
@@ -418,6 +431,8 @@ class StructureTab(ttk.Frame):
                 tree=self._tree,
                 preview=self._preview_coordinator,
                 update_legend=self._update_style_legend,
+                get_options=self._search.get_options,
+                set_status=self._search.set_status,
             )
         except Exception:
             self._search_coord = None  # type: ignore[assignment]
@@ -465,6 +480,9 @@ class StructureTab(ttk.Frame):
         # Up/Down perform intelligent movement with level adaptation
         self.bind("<Alt-Up>",   lambda e: self._on_shortcut_move("up"))
         self.bind("<Alt-Down>", lambda e: self._on_shortcut_move("down"))
+        # Left/Right promote/demote the whole selection
+        self.bind("<Alt-Left>",  lambda e: self._on_shortcut_move("promote"))
+        self.bind("<Alt-Right>", lambda e: self._on_shortcut_move("demote"))
         # Ensure shortcuts work regardless of focused child by binding at the application level
         try:
             self.bind_all("<Control-z>", self._on_shortcut_undo, add=True)
@@ -472,6 +490,8 @@ class StructureTab(ttk.Frame):
             # Ensure Alt-based movement works when focus is in preview or other widgets
             self.bind_all("<Alt-Up>",   lambda e: self._on_shortcut_move("up"), add=True)
             self.bind_all("<Alt-Down>", lambda e: self._on_shortcut_move("down"), add=True)
+            self.bind_all("<Alt-Left>",  lambda e: self._on_shortcut_move("promote"), add=True)
+            self.bind_all("<Alt-Right>", lambda e: self._on_shortcut_move("demote"), add=True)
         except Exception:
             pass
         # Ensure this widget can receive keyboard focus
@@ -520,8 +540,13 @@ class StructureTab(ttk.Frame):
         context : DitaContext
             New context to use for this tab. Constructs default services and a
             new StructureController as specified, stores it as self._controller,
-            then refreshes the tree.
+            then refreshes the tree. Reloading the same context keeps the
+            controller, so the undo journal lasts for the whole session.
         """
+        if self._controller is not None and self._controller.context is context:
+            self._wire_movement_predicates()
+            self.after(100, self._perform_deferred_tree_refresh)
+            return
         editing_service = StructureEditingService()
         undo_service = UndoService(max_history=50)
         preview_service = PreviewService()
@@ -529,6 +554,8 @@ class StructureTab(ttk.Frame):
         # Re-wire movement predicates after controller replacement
         self._wire_movement_predicates()
         self._sync_depth_control()
+        # The initial depth sync is not a user operation: start the journal after it
+        self._controller.start_history()
         # Defer tree refresh to avoid blocking with large documents
         self.after(100, self._perform_deferred_tree_refresh)
         # Plugin UI visibility will be updated in deferred initialization
@@ -551,6 +578,133 @@ class StructureTab(ttk.Frame):
         except Exception:
             return None
 
+    def apply_reuse(self, candidates: List[Any], options: Optional[Dict[str, Any]] = None) -> Any:
+        """Replace reviewed repeated blocks with conrefs (undoable) and refresh the tree."""
+        if self._controller is None:
+            return None
+        result = self._controller.handle_apply_reuse(candidates, options)
+        if getattr(result, "success", False):
+            self._refresh_tree()
+        return result
+
+    def apply_outline(self, rows: List[Any]) -> Any:
+        """Apply a reviewed outline sheet (undoable) and refresh the tree."""
+        if self._controller is None:
+            return None
+        result = self._controller.handle_apply_outline(rows)
+        if getattr(result, "success", False):
+            self._refresh_tree()
+        return result
+
+    def apply_notice_warehouse(self, groups: List[Any], options: Optional[Dict[str, Any]] = None) -> Any:
+        """Replace reviewed safety notices with conrefs to their warehouse topic (undoable) and refresh the tree."""
+        if self._controller is None:
+            return None
+        result = self._controller.handle_apply_notice_warehouse(groups, options)
+        if getattr(result, "success", False):
+            self._refresh_tree()
+        return result
+
+    def find_matches(self, find: str, **options: bool) -> List[Any]:
+        """Topics containing *find* for the find/replace preview (ValueError for a bad pattern)."""
+        if self._controller is None:
+            return []
+        return self._controller.get_find_matches(find, **options)
+
+    def replace_text(self, find: str, replacement: str, topics: Optional[List[str]] = None, **options: bool) -> Any:
+        """Replace text across topics (undoable), then refresh the tree and preview."""
+        if self._controller is None:
+            return None
+        result = self._controller.handle_replace_text(find, replacement, topics=topics, **options)
+        if getattr(result, "success", False):
+            self._refresh_tree()
+            self._update_side_preview()
+        return result
+
+    def term_violations(self, rules: List[Any]) -> List[Any]:
+        """Uses of the banned terms of *rules* per topic."""
+        if self._controller is None:
+            return []
+        return self._controller.get_term_violations(rules)
+
+    def replace_term(self, rule: Any, topics: Optional[List[str]] = None) -> Any:
+        """Replace a banned term by its preferred term (undoable), then refresh the tree and preview."""
+        if self._controller is None:
+            return None
+        result = self._controller.handle_term_replace(rule, topics)
+        if getattr(result, "success", False):
+            self._refresh_tree()
+            self._update_side_preview()
+        return result
+
+    def spelling_issues(self, errors: Optional[List[str]] = None) -> List[Any]:
+        """Suspected misspellings of the edited content per topic and word."""
+        if self._controller is None:
+            return []
+        return self._controller.get_spelling_issues(errors)
+
+    def ignore_spelling(self, words: List[str]) -> Any:
+        """Add words to the project's spelling ignore list, then refresh the preview."""
+        if self._controller is None:
+            return None
+        result = self._controller.handle_ignore_words(words)
+        if getattr(result, "success", False):
+            self._update_side_preview()
+        return result
+
+    def integrity_issues(self) -> List[Any]:
+        """Broken links, orphaned topics and unused images of the edited content."""
+        if self._controller is None:
+            return []
+        return self._controller.get_integrity_issues()
+
+    def fix_integrity(self, issue: Any, action: str, target: Optional[str] = None) -> Any:
+        """Apply an integrity quick fix (undoable), then refresh the tree and preview."""
+        if self._controller is None:
+            return None
+        result = self._controller.handle_integrity_fix(issue, action, target)
+        if getattr(result, "success", False):
+            self._refresh_tree()
+            self._update_side_preview()
+        return result
+
+    def accessibility_issues(self) -> List[Any]:
+        """Accessibility problems of the edited content (alt text, table headers, titles, contrast)."""
+        if self._controller is None:
+            return []
+        return self._controller.get_accessibility_issues()
+
+    def fix_accessibility(self, issue: Any, action: str, text: Optional[str] = None) -> Any:
+        """Apply an accessibility quick fix (undoable), then refresh the tree and preview."""
+        if self._controller is None:
+            return None
+        result = self._controller.handle_accessibility_fix(issue, action, text)
+        if getattr(result, "success", False):
+            self._refresh_tree()
+            self._update_side_preview()
+        return result
+
+    def update_from_source(self, fresh: DitaContext) -> Any:
+        """Merge a new conversion of the changed source document (undoable), then refresh."""
+        if self._controller is None:
+            return None
+        result = self._controller.handle_reconvert(fresh)
+        if getattr(result, "success", False):
+            self._refresh_tree()
+            self._update_side_preview()
+        return result
+
+    def select_topic(self, filename: Optional[str]) -> None:
+        """Select and center the map entry of topic *filename* (e.g. from the validation panel)."""
+        ctx = self.context
+        if ctx is None or not filename or getattr(ctx, "ditamap_root", None) is None:
+            return
+        for node in ctx.ditamap_root.iter("topicref"):
+            if (node.get("href") or "").split("/")[-1] == filename:
+                self._tree.update_selection_by_xml_nodes([node])
+                self._tree.focus_item_centered(node)
+                return
+
     @property
     def max_depth(self) -> Optional[int]:
         try:
@@ -722,6 +876,9 @@ class StructureTab(ttk.Frame):
             try:
                 if getattr(self, "_tree_refresh", None) is not None:
                     self._tree_refresh.refresh()  # type: ignore[attr-defined]
+                    # Keep search highlights and the matching-branches filter after repopulation
+                    if getattr(self, "_search_coord", None) is not None:
+                        self._search_coord.refresh()  # type: ignore[attr-defined]
                 return
             except Exception:
                 pass
@@ -735,6 +892,17 @@ class StructureTab(ttk.Frame):
                 self._set_busy(False)
             except Exception:
                 pass
+            self._update_history_buttons()
+
+    def _update_history_buttons(self) -> None:
+        ctrl = self._controller
+        try:
+            if ctrl is not None:
+                self._toolbar.set_history_state(ctrl.undo_label(), ctrl.redo_label())
+            else:
+                self._toolbar.set_history_state(None, None)
+        except Exception:
+            pass
 
     def _update_plugin_panel_buttons(self, available_panels: List[str]) -> None:
         """Update plugin panel buttons based on available panels.
@@ -1178,6 +1346,14 @@ class StructureTab(ttk.Frame):
         # No fallback: SearchCoordinator is the single entrance for search handling (DRY)
         return
 
+    def _on_search_options_changed(self, options: Dict[str, bool]) -> None:
+        """Re-run the search when the regex, body text or matching-branches toggle changes."""
+        try:
+            if getattr(self, "_search_coord", None) is not None:
+                self._search_coord.options_changed(options)  # type: ignore[attr-defined]
+        except Exception:
+            pass
+
     def _on_search_navigate(self, direction: "str") -> None:
         """Navigate among stored search results and update selection."""
         try:
@@ -1464,6 +1640,52 @@ class StructureTab(ttk.Frame):
         except Exception:
             pass
 
+    def _selection_args(self, nodes: List[ET.Element]) -> tuple[List[str], List[List[int]]]:
+        """Split XML nodes into topic hrefs and section index paths for controller calls."""
+        refs = [n.get("href") for n in nodes if getattr(n, "tag", None) == "topicref" and n.get("href")]
+        paths = [self._tree.get_index_path_for_xml_node(n) for n in nodes if getattr(n, "tag", None) == "topichead"]
+        return refs, [p for p in paths if p]
+
+    def _on_tree_drop(self, nodes: List[ET.Element], target: ET.Element, position: str) -> None:
+        """Move dragged rows before, after or inside the drop target, keeping them selected."""
+        ctrl = self._controller
+        if ctrl is None:
+            return
+        try:
+            refs, paths = self._selection_args(nodes)
+            target_path = self._tree.get_index_path_for_xml_node(target)
+            if not target_path:
+                return
+            result = ctrl.handle_drop_selection(refs, paths, target_path, position)
+            if getattr(result, "success", False):
+                self._refresh_tree()
+                self._tree.restore_captured_selection(list(nodes))
+        except Exception:
+            pass
+
+    def _on_tree_delete(self, nodes: List[ET.Element]) -> None:
+        """Delete the selected rows (Delete key) as one undoable edit."""
+        try:
+            if getattr(self, "_ctx_actions", None) is not None:
+                refs, paths = self._selection_args(nodes)
+                self._ctx_actions.delete_selection(refs, paths)  # type: ignore[attr-defined]
+        except Exception:
+            pass
+
+    def _on_tree_clipboard(self, action: str, nodes: List[ET.Element]) -> None:
+        """Ctrl+C / Ctrl+X copy or cut the selection; Ctrl+V pastes after the last selected row."""
+        try:
+            if getattr(self, "_ctx_actions", None) is None:
+                return
+            if action == "paste":
+                target = self._tree.get_index_path_for_xml_node(nodes[-1]) if nodes else []
+                self._ctx_actions.paste(target, "after")  # type: ignore[attr-defined]
+            else:
+                refs, paths = self._selection_args(nodes)
+                self._ctx_actions.copy_selection(refs, paths, action == "cut")  # type: ignore[attr-defined]
+        except Exception:
+            pass
+
     def _on_context_action(self, action: str, payload: object) -> None:
         """Router for context menu actions emitted by coordinator."""
         try:
@@ -1493,6 +1715,47 @@ class StructureTab(ttk.Frame):
             elif action == "send_mixed_selection_to":
                 topics, sections, target = payload  # type: ignore[misc]
                 self._ctx_actions.send_mixed_selection_to(topics, sections, target)
+            elif action == "set_book_role":
+                topics, sections, role = payload  # type: ignore[misc]
+                self._ctx_actions.set_book_role(topics, sections, role)
+            elif action == "set_branch_depth":
+                topics, sections, depth = payload  # type: ignore[misc]
+                self._ctx_actions.set_branch_depth(topics, sections, depth)
+            elif action == "set_topic_type":
+                topics, sections, topic_type = payload  # type: ignore[misc]
+                self._ctx_actions.set_topic_type(topics, sections, topic_type)
+            elif action == "delete_selection":
+                topics, sections = payload  # type: ignore[misc]
+                self._ctx_actions.delete_selection(topics, sections)
+            elif action == "shift_level":
+                topics, sections, direction = payload  # type: ignore[misc]
+                self._ctx_actions.shift_level(topics, sections, direction)
+            elif action == "apply_style":
+                topics, sections, style = payload  # type: ignore[misc]
+                self._ctx_actions.apply_style(topics, sections, style)
+            elif action == "merge_with_neighbor":
+                ref, into = payload  # type: ignore[misc]
+                self._ctx_actions.merge_with_neighbor(ref, into)
+            elif action == "split_topic":
+                ref, point = payload  # type: ignore[misc]
+                self._ctx_actions.split_topic(ref, point)
+            elif action == "toggle_table_reference":
+                ref, key = payload  # type: ignore[misc]
+                self._ctx_actions.toggle_table_reference(ref, key)
+            elif action == "copy_selection":
+                topics, sections, cut = payload  # type: ignore[misc]
+                self._ctx_actions.copy_selection(topics, sections, cut)
+            elif action == "paste":
+                target, position = payload  # type: ignore[misc]
+                self._ctx_actions.paste(target, position)
+            elif action == "edit_xml":
+                self._ctx_actions.edit_xml(str(payload))
+            elif action == "batch_rename":
+                topics, sections = payload  # type: ignore[misc]
+                self._ctx_actions.batch_rename(topics, sections)
+            elif action == "branch_metadata":
+                topics, sections = payload  # type: ignore[misc]
+                self._ctx_actions.branch_metadata(topics, sections)
         except Exception:
             pass
 
@@ -1731,6 +1994,7 @@ class StructureTab(ttk.Frame):
             
             # Update the legend
             self._update_style_legend()
+            self._update_history_buttons()
             
         except Exception:
             pass
@@ -1747,11 +2011,29 @@ class StructureTab(ttk.Frame):
             return "break"
         try:
             if ctrl.undo():
-                self._refresh_tree()
+                self._after_history_step()
         except Exception:
             pass
         return "break"
 
+    def _after_history_step(self) -> None:
+        """Reflect the depth limit, filters and markers restored by undo/redo, then refresh."""
+        ctrl = self._controller
+        try:
+            if hasattr(self, "_depth_var"):
+                self._depth_var.set(int(ctrl.max_depth))
+        except Exception:
+            pass
+        try:
+            self._tree.set_style_visibility(ctrl.get_style_visibility())
+            self._tree.update_style_colors(ctrl.get_style_colors())
+            if getattr(self, "_filter_coordinator", None) is not None:
+                self._filter_coordinator.populate()  # type: ignore[attr-defined]
+            self._update_style_legend()
+        except Exception:
+            pass
+        self._refresh_tree()
+
     # ---------------------------------------------------------------------------------
     # Preview helper
     # ---------------------------------------------------------------------------------
@@ -1763,7 +2045,7 @@ class StructureTab(ttk.Frame):
             return "break"
         try:
             if ctrl.redo():
-                self._refresh_tree()
+                self._after_history_step()
         except Exception:
             pass
         return "break"
@@ -1849,6 +2131,27 @@ class StructureTab(ttk.Frame):
         except Exception:
             pass
 
+    def _on_preview_split_changed(self, enabled: bool) -> None:
+        """Re-render preview to fill (or drop) the side-by-side source section."""
+        try:
+            self._update_side_preview()
+        except Exception:
+            pass
+
+    def _on_preview_revisions_changed(self, enabled: bool) -> None:
+        """Re-render preview with (or without) revision bars."""
+        try:
+            self._update_side_preview()
+        except Exception:
+            pass
+
+    def _on_preview_spelling_changed(self, enabled: bool) -> None:
+        """Re-render preview with (or without) misspelling underlines."""
+        try:
+            self._update_side_preview()
+        except Exception:
+            pass
+
     # -------------------------------------------------------------------------
     # Toggle buttons behavior and visuals
     # -------------------------------------------------------------------------
@@ -1997,9 +2300,9 @@ class StructureTab(ttk.Frame):
     # Removed: no button binds to this; keep preview refresh via mode/selection changes
 
     def _on_shortcut_move(self, direction: str) -> str:
-        """Handle keyboard shortcut move operations (Alt+Up/Down).
+        """Handle keyboard shortcut move operations (Alt+Up/Down, Alt+Left/Right).
         
-        This method now supports both single-topic and multi-topic movement,
+        This method supports both single-topic and multi-topic movement,
         similar to the toolbar handler.
         """
         ctrl = self._controller
@@ -2013,22 +2316,11 @@ class StructureTab(ttk.Frame):
             except Exception:
                 pass
             
-            # Get current selection for controller operations
-            selected_refs = getattr(ctrl, "selected_items", []) or []
-            
-            # Handle based on selection size and type  
-            if len(selected_refs) <= 1:
-                # Prefer XML-centric movement for single/mixed selections
-                if hasattr(ctrl, 'handle_move_selection'):
-                    result = ctrl.handle_move_selection(selected_elements, direction)  # type: ignore[attr-defined]
-                else:
-                    result = ctrl.handle_move_operation(direction)  # OperationResult
-            elif len(selected_refs) >= 2 and direction in ["up", "down"]:
-                # Legacy path: fall back to single-item operation
-                result = ctrl.handle_move_operation(direction)
+            # Prefer XML-centric movement for single/mixed selections
+            if hasattr(ctrl, 'handle_move_selection'):
+                result = ctrl.handle_move_selection(selected_elements, direction)  # type: ignore[attr-defined]
             else:
-                # Multi-selection for promote/demote - use single topic logic for now
-                result = ctrl.handle_move_operation(direction)
+                result = ctrl.handle_move_operation(direction)  # OperationResult
             
             if getattr(result, "success", False):
                 # After successful move, refresh tree and maintain selection
@@ -1,6 +1,7 @@
 from __future__ import annotations
 
 from typing import Callable, List, Optional, Tuple
+from orlando_toolkit.ui.i18n import tr
 
 
 class ContextActions:
@@ -139,7 +140,7 @@ class ContextActions:
                 new_title = RenameDialog.ask_string(None, "Rename topic", "New title:", initialvalue=current_title or "")
             except Exception:
                 from tkinter import simpledialog
-                new_title = simpledialog.askstring("Rename topic", "New title:")
+                new_title = simpledialog.askstring(tr("Rename topic"), tr("New title:"))
             if not new_title:
                 return
             res = ctrl.handle_rename(refs[0], new_title)  # type: ignore[attr-defined]
@@ -239,7 +240,7 @@ class ContextActions:
                 title = RenameDialog.ask_string(None, "Add section", "Section title:", initialvalue="")
             except Exception:
                 from tkinter import simpledialog
-                title = simpledialog.askstring("Add section", "Section title:")
+                title = simpledialog.askstring(tr("Add section"), tr("Section title:"))
             if not title:
                 return
             res = ctrl.handle_add_section_after(index_path, title)  # type: ignore[attr-defined]
@@ -266,7 +267,7 @@ class ContextActions:
                 title = RenameDialog.ask_string(None, "Add section", "Section title:", initialvalue="")
             except Exception:
                 from tkinter import simpledialog
-                title = simpledialog.askstring("Add section", "Section title:")
+                title = simpledialog.askstring(tr("Add section"), tr("Section title:"))
             if not title:
                 return
             res = ctrl.handle_add_section_inside(index_path, title)  # type: ignore[attr-defined]
@@ -292,7 +293,7 @@ class ContextActions:
                 new_title = RenameDialog.ask_string(None, "Rename section", "New title:", initialvalue=current_title or "")
             except Exception:
                 from tkinter import simpledialog
-                new_title = simpledialog.askstring("Rename section", "New title:", initialvalue=current_title or "")
+                new_title = simpledialog.askstring(tr("Rename section"), tr("New title:"), initialvalue=current_title or "")
             if not new_title:
                 return
             res = ctrl.handle_rename_section(index_path, new_title)  # type: ignore[attr-defined]
@@ -444,4 +445,192 @@ class ContextActions:
         except Exception:
             pass
 
+    def _edit_keeping_selection(self, edit: Callable[[object], object]) -> object:
+        """Run *edit* on the controller, refreshing the tree and restoring the selection on success."""
+        ctrl = self._get_controller()
+        if ctrl is None:
+            return None
+        selected_elements = []
+        try:
+            if hasattr(self._tree, 'capture_current_selection'):
+                selected_elements = self._tree.capture_current_selection()
+        except Exception:
+            pass
+        res = None
+        try:
+            res = edit(ctrl)
+            if getattr(res, "success", False):
+                self._refresh()
+                if selected_elements and hasattr(self._tree, 'restore_captured_selection'):
+                    self._tree.restore_captured_selection(selected_elements)
+        except Exception:
+            pass
+        return res
+
+    def merge_with_neighbor(self, topic_ref: str, into: str) -> None:
+        """Merge a topic into the previous topic or its parent; explain when it cannot be merged."""
+        res = self._edit_keeping_selection(lambda ctrl: ctrl.handle_merge_with_neighbor(topic_ref, into))
+        self._explain_failure("Merge", res)
+
+    def split_topic(self, topic_ref: str, point: int) -> None:
+        """Split a topic at one of its headings; explain when it cannot be split."""
+        res = self._edit_keeping_selection(lambda ctrl: ctrl.handle_split_topic(topic_ref, point))
+        self._explain_failure("Split", res)
+
+    def toggle_table_reference(self, topic_ref: str, key: object) -> None:
+        """Move a table to a reference topic or back; explain when it cannot."""
+        res = self._edit_keeping_selection(lambda ctrl: ctrl.handle_toggle_table_reference(topic_ref, key))
+        self._explain_failure("Tables", res)
+
+    def copy_selection(self, topic_refs: List[str], section_paths: List[List[int]], cut: bool = False) -> None:
+        """Copy (or cut) the selection to the structure clipboard shared by all windows."""
+        ctrl = self._get_controller()
+        if ctrl is None:
+            return
+        if cut:
+            res = self._edit_keeping_selection(
+                lambda c: c.handle_copy_selection(topic_refs, section_paths, cut=True))
+        else:
+            try:
+                res = ctrl.handle_copy_selection(topic_refs, section_paths)  # type: ignore[attr-defined]
+            except Exception:
+                res = None
+        self._explain_failure("Cut" if cut else "Copy", res)
+
+    def paste(self, target_path: List[int], position: str) -> None:
+        """Paste the structure clipboard; tell what was renamed or left pointing outside."""
+        res = self._edit_keeping_selection(lambda ctrl: ctrl.handle_paste(target_path, position))
+        self._explain_failure("Paste", res)
+        details = getattr(res, "details", None) or {}
+        if getattr(res, "success", False) and (details.get("renamed") or details.get("ids") or details.get("outside")):
+            try:
+                from tkinter import messagebox
+                messagebox.showinfo(tr("Paste"), getattr(res, "message", ""))
+            except Exception:
+                pass
+
+    def edit_xml(self, topic_ref: str) -> None:
+        """Open the raw XML editor for a topic; a save is one undoable edit."""
+        ctrl = self._get_controller()
+        if ctrl is None:
+            return
+        try:
+            source = ctrl.get_topic_source(topic_ref)  # type: ignore[attr-defined]
+            if source is None:
+                return
+            from orlando_toolkit.ui.dialogs.topic_xml_dialog import TopicXmlDialog
+
+            parent = self._tree.winfo_toplevel() if hasattr(self._tree, "winfo_toplevel") else None
+            dialog = TopicXmlDialog(
+                parent,  # type: ignore[arg-type]
+                ctrl.get_title_for_ref(topic_ref) or topic_ref,  # type: ignore[attr-defined]
+                source,
+                check=lambda text: ctrl.check_topic_source(topic_ref, text),  # type: ignore[attr-defined]
+                save=lambda text, force: self._edit_keeping_selection(
+                    lambda c: c.handle_edit_topic_source(topic_ref, text, force)),
+                spell_check=lambda text: ctrl.get_misspelled_spans(topic_ref, text),  # type: ignore[attr-defined]
+            )
+            dialog.show_modal()
+        except Exception:
+            pass
+
+    def batch_rename(self, topic_refs: List[str], section_paths: List[List[int]]) -> None:
+        """Rename the selected topics from a template; the renaming is one undoable edit."""
+        ctrl = self._get_controller()
+        if ctrl is None:
+            return
+        try:
+            from orlando_toolkit.ui.dialogs.batch_rename_dialog import BatchRenameDialog
+
+            parent = self._tree.winfo_toplevel() if hasattr(self._tree, "winfo_toplevel") else None
+            renames = BatchRenameDialog(
+                parent,  # type: ignore[arg-type]
+                ctrl.context,  # type: ignore[attr-defined]
+                lambda subtopics: ctrl.get_batch_rename_targets(  # type: ignore[attr-defined]
+                    topic_refs, section_paths, subtopics),
+            ).show_modal()
+            if renames:
+                self._edit_keeping_selection(lambda c: c.handle_batch_rename(renames))
+        except Exception:
+            pass
+
+    def branch_metadata(self, topic_refs: List[str], section_paths: List[List[int]]) -> None:
+        """Edit the prolog metadata of the selected branches; saving is one undoable edit."""
+        ctrl = self._get_controller()
+        if ctrl is None:
+            return
+        try:
+            from orlando_toolkit.ui.dialogs.branch_metadata_dialog import BranchMetadataDialog
+
+            parent = self._tree.winfo_toplevel() if hasattr(self._tree, "winfo_toplevel") else None
+            count = len(topic_refs) + len(section_paths)
+            if count == 1 and topic_refs:
+                title = ctrl.get_title_for_ref(topic_refs[0]) or topic_refs[0]  # type: ignore[attr-defined]
+            else:
+                title = f"{count} entries" if count > 1 else "Section"
+            values = BranchMetadataDialog(
+                parent,  # type: ignore[arg-type]
+                title,
+                ctrl.get_branch_metadata(topic_refs, section_paths),  # type: ignore[attr-defined]
+            ).show_modal()
+            if values is not None:
+                res = self._edit_keeping_selection(
+                    lambda c: c.handle_set_branch_metadata(topic_refs, section_paths, values))
+                self._explain_failure("Metadata", res)
+        except Exception:
+            pass
+
+    @staticmethod
+    def _explain_failure(title: str, res: object) -> None:
+        if res is not None and not getattr(res, "success", False) and getattr(res, "message", ""):
+            try:
+                from tkinter import messagebox
+                messagebox.showinfo(title, getattr(res, "message", ""))
+            except Exception:
+                pass
 
+    def delete_selection(self, topic_refs: List[str], section_paths: List[List[int]]) -> None:
+        """Delete the selected topics and sections in one edit."""
+        ctrl = self._get_controller()
+        if ctrl is None:
+            return
+        try:
+            res = ctrl.handle_delete_selection(topic_refs, section_paths)  # type: ignore[attr-defined]
+            if getattr(res, "success", False):
+                try:
+                    ctrl.select_items([])  # type: ignore[attr-defined]
+                except Exception:
+                    pass
+                self._refresh()
+        except Exception:
+            pass
+
+    def shift_level(self, topic_refs: List[str], section_paths: List[List[int]], direction: str) -> None:
+        """Promote or demote the selection; explain when nothing could move."""
+        res = self._edit_keeping_selection(lambda ctrl: ctrl.handle_shift_level(topic_refs, section_paths, direction))
+        self._explain_failure(direction.capitalize(), res)
+
+    def apply_style(self, topic_refs: List[str], section_paths: List[List[int]], style: str) -> None:
+        """Apply one heading style to every selected entry."""
+        self._edit_keeping_selection(lambda ctrl: ctrl.handle_apply_style(topic_refs, section_paths, style))
+
+    def set_book_role(self, topic_refs: List[str], section_paths: List[List[int]], role: str) -> None:
+        """Mark the selected top-level entries as chapter, preface or appendix."""
+        self._edit_keeping_selection(lambda ctrl: ctrl.handle_set_book_role(topic_refs, section_paths, role))
+
+    def set_branch_depth(self, topic_refs: List[str], section_paths: List[List[int]], depth: Optional[int]) -> None:
+        """Give the selected branches their own split depth (None: back to the global depth)."""
+        res = self._edit_keeping_selection(
+            lambda ctrl: ctrl.handle_set_branch_depth(topic_refs, section_paths, depth))
+        self._explain_failure("Split depth", res)
+
+    def set_topic_type(self, topic_refs: List[str], section_paths: List[List[int]], topic_type: str) -> None:
+        """Convert the selected branches to tasks or concepts; explain when nothing could be converted."""
+        res = self._edit_keeping_selection(
+            lambda ctrl: ctrl.handle_set_topic_type(topic_refs, section_paths, topic_type))
+        if res is not None and not getattr(res, "success", False) and getattr(res, "details", None):
+            try:
+                from tkinter import messagebox
+                messagebox.showinfo(tr("Topic type"), getattr(res, "message", ""))
+            except Exception:
+                pass
@@ -123,6 +123,72 @@ class ContextMenuCoordinator:
         except Exception:
             pass
 
+        # Merge with a neighbour / split at a heading (single topic)
+        if ctx.get("is_topic"):
+            try:
+                ctx["restructure_entries"] = self._build_restructure_entries(current_refs[0])
+            except Exception:
+                pass
+            try:
+                ctx["table_entries"] = self._build_table_entries(current_refs[0])
+            except Exception:
+                pass
+            ctx["on_edit_xml_command"] = (lambda r=current_refs[0]: self._emit("edit_xml", r))
+
+        # Group operations on the whole selection: level, style and delete
+        try:
+            topics, sections = self._selection(current_refs, info)
+            if topics or sections:
+                ctx["level_entries"] = [
+                    (label, lambda d=direction: self._emit("shift_level", (topics, sections, d)))
+                    for label, direction in (("Promote (Alt+Left)", "promote"), ("Demote (Alt+Right)", "demote"))
+                ]
+                ctrl = self._get_controller()
+                styles = ctrl.get_applicable_styles() if hasattr(ctrl, "get_applicable_styles") else []
+                ctx["style_entries"] = [
+                    (st, lambda s=st: self._emit("apply_style", (topics, sections, s))) for st in styles
+                ]
+                ctx["on_batch_rename_command"] = (lambda: self._emit("batch_rename", (topics, sections)))
+                ctx["on_metadata_command"] = (lambda: self._emit("branch_metadata", (topics, sections)))
+            if sections and len(topics) + len(sections) > 1:
+                ctx["on_delete_command"] = (lambda: self._emit("delete_selection", (topics, sections)))
+                ctx["force_can_delete"] = True
+        except Exception:
+            pass
+
+        # Structure clipboard: copy/cut the selection, paste next to or inside the clicked entry
+        try:
+            topics, sections = self._selection(current_refs, info)
+            ctx["clipboard_entries"] = self._build_clipboard_entries(topics, sections, info)
+        except Exception:
+            pass
+
+        # Topic type entries (whole branches)
+        try:
+            topics, sections = self._selection(current_refs, info)
+            ctx["topic_type_entries"] = [
+                (label, lambda t=topic_type: self._emit("set_topic_type", (topics, sections, t)))
+                for label, topic_type in (("Task", "task"), ("Concept", "concept"))
+            ]
+        except Exception:
+            pass
+
+        # Book role entries (top-level entries only; applied when the map is written as a bookmap)
+        try:
+            item_id = info.get("item_id") if isinstance(info, dict) else ""
+            if isinstance(item_id, str) and item_id and len(self._tree.get_index_path_for_item_id(item_id)) == 1:
+                ctx["book_role_entries"] = self._build_book_role_entries(current_refs, info)
+        except Exception:
+            pass
+
+        # Split depth entries (per-branch override of the global depth)
+        try:
+            topics, sections = self._selection(current_refs, info)
+            if topics or sections:
+                ctx["branch_depth_entries"] = self._build_branch_depth_entries(topics, sections)
+        except Exception:
+            pass
+
         # Hook for style primary action (only if source plugin has style_toggle capability)
         if is_single and not is_section and isinstance(style, str) and style:
             # Check if document source plugin has style_toggle capability
@@ -146,6 +212,89 @@ class ContextMenuCoordinator:
         except Exception:
             pass
 
+    def _selection(self, current_refs: List[str], info: Dict[str, object]) -> tuple[List[str], List[List[int]]]:
+        """Selected topic refs and section index paths (the clicked section when nothing is selected)."""
+        topics = list(current_refs or [])
+        try:
+            sections = self._tree.get_selected_sections_index_paths()
+        except Exception:
+            sections = []
+        if not topics and not sections and info.get("is_section") and info.get("item_id"):
+            try:
+                sections = [self._tree.get_index_path_for_item_id(info.get("item_id"))]
+            except Exception:
+                sections = []
+        return topics, sections
+
+    def _build_restructure_entries(self, ref: str) -> List[tuple[str, Callable[[], None]]]:
+        """Build Merge with previous / into parent and Split at heading entries for one topic."""
+        ctrl = self._get_controller()
+        if ctrl is None or not hasattr(ctrl, "get_restructure_options"):
+            return []
+        options = ctrl.get_restructure_options(ref)
+        entries: List[tuple[str, Callable[[], None]]] = []
+        for into, label in (("previous", "Merge with previous topic"), ("parent", "Merge into parent topic")):
+            if options.get(into):
+                entries.append((label, lambda i=into: self._emit("merge_with_neighbor", (ref, i))))
+        for point, heading in enumerate(options.get("split_points") or []):
+            entries.append((f"Split at “{heading}”", lambda p=point: self._emit("split_topic", (ref, p))))
+        return entries
+
+    def _build_clipboard_entries(
+        self, topics: List[str], sections: List[List[int]], info: Dict[str, object]
+    ) -> List[tuple[str, Callable[[], None]]]:
+        """Copy and Cut for the selection; Paste entries when the clipboard holds something."""
+        entries: List[tuple[str, Callable[[], None]]] = []
+        if topics or sections:
+            entries.append(("Copy (Ctrl+C)", lambda: self._emit("copy_selection", (topics, sections, False))))
+            entries.append(("Cut (Ctrl+X)", lambda: self._emit("copy_selection", (topics, sections, True))))
+        ctrl = self._get_controller()
+        summary = ctrl.get_clipboard_summary() if ctrl is not None and hasattr(ctrl, "get_clipboard_summary") else ""
+        item_id = info.get("item_id") if isinstance(info, dict) else ""
+        target = self._tree.get_index_path_for_item_id(item_id) if isinstance(item_id, str) and item_id else []
+        if summary and target:
+            entries.append((f"Paste after (Ctrl+V): {summary}", lambda: self._emit("paste", (target, "after"))))
+            entries.append(("Paste before", lambda: self._emit("paste", (target, "before"))))
+            if info.get("is_section"):
+                entries.append(("Paste inside", lambda: self._emit("paste", (target, "inside"))))
+        return entries
+
+    def _build_table_entries(self, ref: str) -> List[tuple[str, Callable[[], None]]]:
+        """One checkable entry per table of a topic: checked tables are reference topics."""
+        ctrl = self._get_controller()
+        if ctrl is None or not hasattr(ctrl, "get_table_options"):
+            return []
+        return [(("☑ " if entry["promoted"] else "☐ ") + entry["label"],
+                 lambda k=entry["key"]: self._emit("toggle_table_reference", (ref, k)))
+                for entry in ctrl.get_table_options(ref)]
+
+    def _build_book_role_entries(
+        self, current_refs: List[str], info: Dict[str, object]
+    ) -> List[tuple[str, Callable[[], None]]]:
+        """Build Book role entries (chapter, preface, appendix) for the current selection."""
+        from orlando_toolkit.core.bookmap import BOOK_ROLES
+
+        topics, sections = self._selection(current_refs, info)
+        return [
+            (role.capitalize(), lambda r=role: self._emit("set_book_role", (topics, sections, r)))
+            for role in BOOK_ROLES
+        ]
+
+    def _build_branch_depth_entries(
+        self, topics: List[str], sections: List[List[int]]
+    ) -> List[tuple[str, Callable[[], None]]]:
+        """Build Split depth entries (one per allowed depth, then the global depth) for the selection."""
+        ctrl = self._get_controller()
+        if ctrl is None or not hasattr(ctrl, "get_branch_depth_choices"):
+            return []
+        entries: List[tuple[str, Callable[[], None]]] = [
+            (f"Depth {depth}", lambda d=depth: self._emit("set_branch_depth", (topics, sections, d)))
+            for depth in ctrl.get_branch_depth_choices(topics, sections)
+        ]
+        if entries:
+            entries.append(("Use global depth", lambda: self._emit("set_branch_depth", (topics, sections, None))))
+        return entries
+
     def _build_send_to_entries(self, current_refs: List[str], info: Dict[str, object], destinations: List[dict]) -> List[tuple[str, Callable[[], None]]]:
         """Build unified Send To entries for any selection type.
         
@@ -16,7 +16,11 @@ class PreviewCoordinator:
         Zero-arg callable returning the current controller (or None).
     panel : object
         Preview panel object with get_mode, set_loading, set_title, set_content,
-        show_error, set_breadcrumb_path methods.
+        show_error, set_breadcrumb_path methods. Panels with ``is_split`` and
+        ``set_source_content`` also receive the source document section of
+        the topic while side-by-side mode is on; panels with
+        ``highlights_revisions`` get revision bars in the HTML when it is true,
+        and panels with ``highlights_spelling`` suspected misspellings underlined.
     schedule_ui : Callable[[int, Callable[[], None]], object]
         Tk-style scheduler (e.g., widget.after) used for UI thread callbacks.
     run_in_thread : Callable[[Callable[[], object], Optional[Callable[[object], None]]], None]
@@ -56,6 +60,21 @@ class PreviewCoordinator:
         except Exception:
             mode = "html"
 
+        # Side-by-side: the Word section the topic came from
+        try:
+            split = bool(panel.is_split())  # type: ignore[attr-defined]
+        except Exception:
+            split = False
+        source_box: list = []
+        try:
+            revisions = bool(panel.highlights_revisions())  # type: ignore[attr-defined]
+        except Exception:
+            revisions = False
+        try:
+            spelling = bool(panel.highlights_spelling())  # type: ignore[attr-defined]
+        except Exception:
+            spelling = False
+
         # Pre-state
         try:
             panel.set_title("Preview")
@@ -71,9 +90,17 @@ class PreviewCoordinator:
         job_id = int(self._job_seq)
 
         def _work():
+            if split:
+                try:
+                    source_box.append(ctrl.render_source_preview_for_node(node))  # type: ignore[attr-defined]
+                except Exception as ex:  # pragma: no cover
+                    source_box.append(ex)
             try:
                 if mode == "xml":
                     return ("xml", ctrl.compile_preview_for_node(node))  # type: ignore[attr-defined]
+                if revisions or spelling:
+                    return ("html", ctrl.render_html_preview_for_node(  # type: ignore[attr-defined]
+                        node, highlight_revisions=revisions, spelling=spelling))
                 return ("html", ctrl.render_html_preview_for_node(node))  # type: ignore[attr-defined]
             except Exception as ex:  # pragma: no cover
                 return ("err", ex)
@@ -127,6 +154,8 @@ class PreviewCoordinator:
                     panel.set_loading(False)
                 except Exception:
                     pass
+                if split:
+                    self._show_source(source_box[0] if source_box else None)
 
         self._run_in_thread(_work, _done)
 
@@ -164,6 +193,19 @@ class PreviewCoordinator:
         pass
 
     # ------------------------------------------------------------- Internals
+    def _show_source(self, result: object) -> None:
+        panel = self._panel
+        if getattr(result, "success", False) and isinstance(getattr(result, "content", None), str):
+            content = getattr(result, "content")
+        else:
+            from html import escape as _escape
+            msg = getattr(result, "message", None) or "Unable to render the source section"
+            content = f"<html><body><p style=\"color:#666;\">{_escape(str(msg))}</p></body></html>"
+        try:
+            panel.set_source_content(content)  # type: ignore[attr-defined]
+        except Exception:
+            pass
+
     def _update_breadcrumb_for_ref(self, topic_ref: str) -> None:
         ctrl = self._get_controller()
         panel = self._panel
@@ -1,11 +1,15 @@
 from __future__ import annotations
 
-from typing import Callable, List
+from typing import Callable, Dict, List, Optional
 import xml.etree.ElementTree as ET
 
 
 class SearchCoordinator:
-    """Coordinate search interactions between UI tree, controller, and preview."""
+    """Coordinate search interactions between UI tree, controller, and preview.
+
+    ``get_options`` returns the search box toggles (``regex``, ``body_text``,
+    ``only_matching``) and ``set_status`` shows the match count or a pattern error.
+    """
 
     def __init__(
         self,
@@ -14,22 +18,59 @@ class SearchCoordinator:
         tree: object,
         preview: object,
         update_legend: Callable[[], None],
+        get_options: Optional[Callable[[], Dict[str, bool]]] = None,
+        set_status: Optional[Callable[[str], None]] = None,
     ) -> None:
         self._get_controller = controller_getter
         self._tree = tree
         self._preview = preview
         self._update_legend = update_legend
+        self._get_options = get_options or (lambda: {})
+        self._set_status = set_status or (lambda _text: None)
+        self._term = ""
 
     # ------------------------------------------------------------------
+    def _search(self, ctrl: object, term: str) -> List[ET.Element]:
+        """Run the controller search with the current options and update the tree accordingly."""
+        options = dict(self._get_options() or {})
+        try:
+            if hasattr(ctrl, "set_search_options"):
+                ctrl.set_search_options(regex=bool(options.get("regex")),  # type: ignore[attr-defined]
+                                        body_text=bool(options.get("body_text")))
+        except Exception:
+            pass
+        try:
+            # XML-centric: controller returns List[ET.Element]
+            results: List[ET.Element] = list(ctrl.handle_search(term) or [])  # type: ignore[attr-defined]
+        except Exception:
+            results = []
+
+        # Only matching branches: hide everything else while a term is active
+        try:
+            if hasattr(self._tree, "show_only_branches"):
+                if options.get("only_matching") and term.strip():
+                    from orlando_toolkit.core.search import matching_branches
+                    self._tree.show_only_branches(matching_branches(results))  # type: ignore[attr-defined]
+                else:
+                    self._tree.show_only_branches(None)  # type: ignore[attr-defined]
+        except Exception:
+            pass
+
+        error = str(getattr(ctrl, "search_error", "") or "")
+        if error:
+            self._set_status(error)
+        elif term.strip():
+            self._set_status(f"{len(results)} match{'es' if len(results) != 1 else ''}")
+        else:
+            self._set_status("")
+        return results
+
     def term_changed(self, term: str) -> None:
         ctrl = self._get_controller()
         if ctrl is None:
             return
-        try:
-            # XML-centric: controller returns List[ET.Element]
-            results: List[ET.Element] = list(ctrl.handle_search(term) or [])  # type: ignore[assignment]
-        except Exception:
-            results = []  # type: ignore[assignment]
+        self._term = term or ""
+        results = self._search(ctrl, self._term)
 
         # Highlight all matches without altering selection
         try:
@@ -74,6 +115,28 @@ class SearchCoordinator:
         except Exception:
             pass
 
+    def options_changed(self, _options: Optional[Dict[str, bool]] = None) -> None:
+        """Re-run the current search after a toggle changed."""
+        self.term_changed(self._term)
+
+    def refresh(self) -> None:
+        """Re-apply the current search after the tree was repopulated, without moving focus."""
+        ctrl = self._get_controller()
+        if ctrl is None or not self._term.strip():
+            return
+        index = getattr(ctrl, "search_index", -1)
+        results = self._search(ctrl, self._term)
+        if 0 <= index < len(results):
+            try:
+                ctrl.search_index = index  # type: ignore[attr-defined]
+            except Exception:
+                pass
+        try:
+            if results and hasattr(self._tree, "set_highlight_xml_nodes"):
+                self._tree.set_highlight_xml_nodes(results)  # type: ignore[attr-defined]
+        except Exception:
+            pass
+
     # ------------------------------------------------------------------
     def navigate(self, direction: str) -> None:
         ctrl = self._get_controller()
@@ -94,6 +157,7 @@ class SearchCoordinator:
             ctrl.search_index = idx  # type: ignore[attr-defined]
         except Exception:
             pass
+        self._set_status(f"{idx + 1}/{len(results)}")
         
         selected_node = results[idx]
         # Select current match, update tree/preview
@@ -0,0 +1,128 @@
+# -*- coding: utf-8 -*-
+"""
+Key table editor for the Metadata tab.
+
+Lists the keys of the manual (product names, model numbers, revision
+strings; ``orlando_toolkit.core.keys``) with their value and the number of
+places using them. Edits are stored in ``context.metadata["variables"]``;
+removing a key defined in ``conversion.yml`` stores ``None`` under its name.
+Typed values become key references when the package is generated.
+"""
+
+from __future__ import annotations
+
+import re
+from typing import Callable, Optional, TYPE_CHECKING
+import tkinter as tk
+from tkinter import ttk
+
+from orlando_toolkit.core.keys import key_table, key_usage
+from orlando_toolkit.ui.i18n import tr
+
+if TYPE_CHECKING:
+    from orlando_toolkit.core.models import DitaContext
+
+_KEY_NAME = re.compile(r"[A-Za-z_][\w.-]*")
+
+
+class KeyTableEditor(ttk.LabelFrame):
+    def __init__(self, parent, *, on_change: Optional[Callable[[], None]] = None, **kwargs):
+        super().__init__(parent, text=tr("Keys (product names and values)"), padding=10, **kwargs)
+        self.context: Optional["DitaContext"] = None
+        self.on_change = on_change
+        self.columnconfigure(1, weight=1)
+
+        self.tree = ttk.Treeview(self, columns=("value", "uses"), height=4, selectmode="browse")
+        self.tree.heading("#0", text=tr("Key"))
+        self.tree.heading("value", text=tr("Value"))
+        self.tree.heading("uses", text=tr("Uses"))
+        self.tree.column("#0", width=160, stretch=False)
+        self.tree.column("uses", width=60, stretch=False, anchor="e")
+        self.tree.grid(row=0, column=0, columnspan=4, sticky="ew", pady=(0, 8))
+        self.tree.bind("<<TreeviewSelect>>", lambda _e: self._on_select())
+
+        ttk.Label(self, text=tr("Key:")).grid(row=1, column=0, sticky="w")
+        self.name_var = tk.StringVar()
+        ttk.Entry(self, textvariable=self.name_var, width=18).grid(row=1, column=1, sticky="w")
+        ttk.Label(self, text=tr("Value:")).grid(row=2, column=0, sticky="w", pady=(4, 0))
+        self.value_var = tk.StringVar()
+        ttk.Entry(self, textvariable=self.value_var).grid(row=2, column=1, sticky="ew", pady=(4, 0))
+        buttons = ttk.Frame(self)
+        buttons.grid(row=2, column=2, sticky="e", padx=(8, 0), pady=(4, 0))
+        ttk.Button(buttons, text=tr("Save"), command=self._save).pack(side="left", padx=(0, 6))
+        ttk.Button(buttons, text=tr("Remove"), command=self._remove).pack(side="left")
+        ttk.Label(self, text=tr("Typed values are replaced by key references in the generated package."),
+                  foreground="gray").grid(row=3, column=0, columnspan=4, sticky="w", pady=(4, 0))
+        self.status = ttk.Label(self, text="", foreground="#cc0000")
+        self.status.grid(row=4, column=0, columnspan=4, sticky="w")
+
+    # ------------------------------------------------------------------
+    # Public API
+    # ------------------------------------------------------------------
+    def load_context(self, context: "DitaContext") -> None:
+        self.context = context
+        self.name_var.set("")
+        self.value_var.set("")
+        self.status.configure(text="")
+        self.refresh()
+
+    def refresh(self) -> None:
+        if not self.context:
+            return
+        table = key_table(self.context.metadata)
+        usage = key_usage(self.context, table)
+        self.tree.delete(*self.tree.get_children())
+        for name, value in table.items():
+            self.tree.insert("", "end", iid=name, text=name, values=(value, usage.get(name, 0)))
+
+    # ------------------------------------------------------------------
+    # Internal helpers
+    # ------------------------------------------------------------------
+    def _keys(self) -> dict:
+        keys = self.context.metadata.get("variables")
+        if not isinstance(keys, dict):
+            keys = self.context.metadata["variables"] = {}
+        return keys
+
+    def _on_select(self) -> None:
+        selection = self.tree.selection()
+        if not selection:
+            return
+        self.name_var.set(self.tree.item(selection[0], "text"))
+        self.value_var.set(self.tree.set(selection[0], "value"))
+        self.status.configure(text="")
+
+    def _save(self) -> None:
+        if not self.context:
+            return
+        name = self.name_var.get().strip()
+        if not _KEY_NAME.fullmatch(name):
+            self.status.configure(text=tr("Key names start with a letter and use letters, digits, '_', '.' or '-'"))
+            return
+        value = " ".join(self.value_var.get().split())
+        if not value:
+            self.status.configure(text=tr("Enter the value of the key"))
+            return
+        self._keys()[name] = value
+        self.status.configure(text="")
+        self._changed()
+
+    def _remove(self) -> None:
+        if not self.context:
+            return
+        name = self.name_var.get().strip()
+        if name not in self.tree.get_children():
+            self.status.configure(text=tr("Select a key to remove"))
+            return
+        self._keys()[name] = None
+        self.name_var.set("")
+        self.value_var.set("")
+        self._changed()
+
+    def _changed(self) -> None:
+        self.refresh()
+        if self.on_change:
+            try:
+                self.on_change()
+            except Exception:
+                pass
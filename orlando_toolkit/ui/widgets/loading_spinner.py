@@ -5,6 +5,7 @@ from __future__ import annotations
 import tkinter as tk
 from tkinter import ttk
 from typing import Optional, Callable
+from orlando_toolkit.ui.i18n import tr
 
 
 class LoadingSpinner:
@@ -25,6 +26,7 @@ class LoadingSpinner:
         subtitle: str = "Please wait...",
         on_show: Optional[Callable[[], None]] = None,
         on_hide: Optional[Callable[[], None]] = None,
+        on_cancel: Optional[Callable[[], None]] = None,
     ):
         """Initialize loading spinner.
         
@@ -34,12 +36,14 @@ class LoadingSpinner:
             subtitle: Secondary loading message  
             on_show: Optional callback when spinner is shown
             on_hide: Optional callback when spinner is hidden
+            on_cancel: Optional callback; when set, a Cancel button is shown
         """
         self._parent = parent
         self._title = title
         self._subtitle = subtitle
         self._on_show = on_show
         self._on_hide = on_hide
+        self._on_cancel = on_cancel
         
         # Animation state
         self._spinner_chars = ["⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"]
@@ -51,6 +55,7 @@ class LoadingSpinner:
         self._spinner_label: Optional[ttk.Label] = None
         self._title_label: Optional[ttk.Label] = None
         self._subtitle_label: Optional[ttk.Label] = None
+        self._cancel_button: Optional[ttk.Button] = None
         
         self._is_visible = False
 
@@ -128,6 +133,39 @@ class LoadingSpinner:
         """Check if spinner is currently visible."""
         return self._is_visible
 
+    def set_cancel_callback(self, on_cancel: Optional[Callable[[], None]]) -> None:
+        """Show (callback set) or hide (None) the Cancel button."""
+        self._on_cancel = on_cancel
+        if not self._is_visible or not self._overlay:
+            return
+        try:
+            if on_cancel is None:
+                if self._cancel_button:
+                    self._cancel_button.destroy()
+                    self._cancel_button = None
+            elif self._cancel_button is None:
+                self._create_cancel_button()
+            else:
+                self._cancel_button.configure(state="normal")
+        except Exception:
+            pass
+
+    def _handle_cancel(self) -> None:
+        """Disable the button (one-shot) and notify the owner."""
+        try:
+            if self._cancel_button:
+                self._cancel_button.configure(state="disabled")
+        except Exception:
+            pass
+        if self._on_cancel:
+            self._on_cancel()
+
+    def _create_cancel_button(self) -> None:
+        self._cancel_button = ttk.Button(self._overlay, text=tr("Cancel"), command=self._handle_cancel)
+        # Survive the host's recursive "disable all widgets" pass during processing
+        self._cancel_button._keep_enabled = True  # type: ignore[attr-defined]
+        self._cancel_button.pack(pady=(12, 0))
+
     def _create_overlay(self) -> None:
         """Create minimal spinner UI without overlay."""
         # Simple frame that packs into parent without covering everything
@@ -161,6 +199,9 @@ class LoadingSpinner:
         )
         self._subtitle_label.pack()
 
+        if self._on_cancel:
+            self._create_cancel_button()
+
     def _destroy_overlay(self) -> None:
         """Destroy spinner overlay UI."""
         if self._overlay and self._overlay.winfo_exists():
@@ -169,6 +210,7 @@ class LoadingSpinner:
         self._spinner_label = None
         self._title_label = None
         self._subtitle_label = None
+        self._cancel_button = None
 
     def _start_animation(self) -> None:
         """Start 150ms spinner animation cycle."""
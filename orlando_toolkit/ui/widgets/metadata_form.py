@@ -13,9 +13,15 @@ from typing import Callable, Optional, TYPE_CHECKING
 import tkinter as tk
 from tkinter import ttk
 
+from orlando_toolkit.core.dialects import DEFAULT_DIALECT, DIALECTS, output_dialect
+from orlando_toolkit.core.i18n import XML_LANG, canonical_language_tag, normalize_language
+from orlando_toolkit.ui.i18n import tr
+
 if TYPE_CHECKING:
     from orlando_toolkit.core.models import DitaContext
 
+_LANGUAGES = ("en-US", "en-GB", "fr-FR", "fr-CA", "de-DE", "es-ES", "it-IT", "nl-NL", "pt-PT", "pt-BR")
+
 
 class MetadataForm(ttk.Frame):
     def __init__(self, parent, *, padding: int = 8, font_size: int = 11, on_change: Optional[Callable[[], None]] = None, **kwargs):
@@ -32,6 +38,10 @@ class MetadataForm(ttk.Frame):
             "manual_title": "Manual Title:",
             "manual_code": "Manual Short Name:",
             "revision_date": "Revision Date:",
+            "book_subtitle": "Subtitle:",
+            "book_author": "Author:",
+            "book_publisher": "Publisher:",
+            "copyright_holder": "Copyright Holder:",
         }
 
         for i, (key, label_text) in enumerate(metadata_fields.items(), start=0):
@@ -44,6 +54,34 @@ class MetadataForm(ttk.Frame):
             entry.bind("<FocusOut>", lambda _e, k=key: self._on_field_blur(k))
             self.entries[key] = var
 
+        # map: plain ditamap; bookmap: chapters, preface/appendix roles and bookmeta from the fields above
+        row = len(metadata_fields)
+        ttk.Label(self, text=tr("Output Structure:"), font=self._font_main).grid(
+            row=row, column=0, sticky="w", padx=(0, 14), pady=6)
+        self.map_type = tk.StringVar(value="map")
+        combo = ttk.Combobox(self, textvariable=self.map_type, values=("map", "bookmap"), state="readonly",
+                             font=self._font_main, width=12)
+        combo.grid(row=row, column=1, sticky="w", pady=6)
+        combo.bind("<<ComboboxSelected>>", lambda _e: self._on_map_type_selected())
+
+        # Document language; topics detected in another language keep theirs
+        ttk.Label(self, text=tr("Default Language:"), font=self._font_main).grid(
+            row=row + 1, column=0, sticky="w", padx=(0, 14), pady=6)
+        self.language = tk.StringVar()
+        lang_combo = ttk.Combobox(self, textvariable=self.language, values=_LANGUAGES, font=self._font_main, width=12)
+        lang_combo.grid(row=row + 1, column=1, sticky="w", pady=6)
+        lang_combo.bind("<<ComboboxSelected>>", lambda _e: self._on_language_changed())
+        lang_combo.bind("<FocusOut>", lambda _e: self._on_language_changed())
+
+        # DITA 1.3, DITA 2.0 or Lightweight DITA; the content stays DITA 1.3 until written
+        ttk.Label(self, text=tr("Output Dialect:"), font=self._font_main).grid(
+            row=row + 2, column=0, sticky="w", padx=(0, 14), pady=6)
+        self.dialect = tk.StringVar(value=DEFAULT_DIALECT)
+        dialect_combo = ttk.Combobox(self, textvariable=self.dialect, values=DIALECTS, state="readonly",
+                                     font=self._font_main, width=12)
+        dialect_combo.grid(row=row + 2, column=1, sticky="w", pady=6)
+        dialect_combo.bind("<<ComboboxSelected>>", lambda _e: self._on_dialect_selected())
+
     # ------------------------------------------------------------------
     # Public API
     # ------------------------------------------------------------------
@@ -51,6 +89,11 @@ class MetadataForm(ttk.Frame):
         self.context = context
         for key, var in self.entries.items():
             var.set(self.context.metadata.get(key, ""))
+        self.map_type.set("bookmap" if self.context.metadata.get("map_type") == "bookmap" else "map")
+        root = self.context.ditamap_root
+        declared = root.get(XML_LANG) if root is not None else None
+        self.language.set(canonical_language_tag(self.context.metadata.get("language") or declared) or "")
+        self.dialect.set(output_dialect(self.context.metadata))
 
     def set_on_change(self, callback: Optional[Callable[[], None]]) -> None:
         self.on_change = callback
@@ -72,3 +115,41 @@ class MetadataForm(ttk.Frame):
                     pass
 
 
+
+    def _on_map_type_selected(self) -> None:
+        if not self.context:
+            return
+        value = self.map_type.get()
+        if (self.context.metadata.get("map_type") or "map") != value:
+            self.context.metadata["map_type"] = value
+            if self.on_change:
+                try:
+                    self.on_change()
+                except Exception:
+                    pass
+
+    def _on_language_changed(self) -> None:
+        if not self.context:
+            return
+        value = canonical_language_tag(self.language.get()) or ""
+        self.language.set(value)
+        if not value or normalize_language(self.context.metadata.get("language")) == normalize_language(value):
+            return
+        self.context.metadata["language"] = value
+        if self.on_change:
+            try:
+                self.on_change()
+            except Exception:
+                pass
+
+    def _on_dialect_selected(self) -> None:
+        if not self.context:
+            return
+        value = self.dialect.get()
+        if output_dialect(self.context.metadata) != value:
+            self.context.metadata["dialect"] = value
+            if self.on_change:
+                try:
+                    self.on_change()
+                except Exception:
+                    pass
@@ -14,15 +14,24 @@ Public API (UI-only, no services/I/O):
 - set_content(text: str) -> None
 - show_error(message: str) -> None
 - clear() -> None
+- set_split(enabled: bool) -> None / is_split() -> bool
+- set_source_content(text: str) -> None  # source document section shown side by side
+- set_highlight_revisions(enabled: bool) -> None / highlights_revisions() -> bool
+- set_highlight_spelling(enabled: bool) -> None / highlights_spelling() -> bool
 
 Callbacks:
 - on_mode_changed: Optional[Callable[[Literal["html","xml"]], None]]
 - on_refresh: Optional[Callable[[], None]]  # accepted for compatibility; no button is rendered
+- on_split_changed: Optional[Callable[[bool], None]]
+- on_revisions_changed: Optional[Callable[[bool], None]]  # revision bars toggled
+- on_spelling_changed: Optional[Callable[[bool], None]]  # misspelling underlines toggled
 
 Notes:
 - No business logic is included here. This widget is purely presentational.
 - HTML content is rendered visually via tkinterweb when available; otherwise plain text.
 - Automatic fallback ensures the widget works even if tkinterweb is missing.
+- In side-by-side mode both views scroll together (same relative position).
+- HTML views are zoomed to the screen density (see core.preview.themes.preview_scale).
 """
 
 from __future__ import annotations
@@ -43,6 +52,7 @@ except Exception:
 
 # Local imports
 from orlando_toolkit.ui.widgets.breadcrumb_widget import BreadcrumbWidget, BreadcrumbItem
+from orlando_toolkit.ui.i18n import tr
 
 
 Mode = Literal["html", "xml"]
@@ -61,6 +71,9 @@ class PreviewPanel(ttk.Frame):
         on_mode_changed: Optional[Callable[[Mode], None]] = None,
         on_refresh: Optional[Callable[[], None]] = None,
         on_breadcrumb_clicked: Optional[Callable[[str], None]] = None,
+        on_split_changed: Optional[Callable[[bool], None]] = None,
+        on_revisions_changed: Optional[Callable[[bool], None]] = None,
+        on_spelling_changed: Optional[Callable[[bool], None]] = None,
         **kwargs,
     ) -> None:
         super().__init__(parent, **kwargs)
@@ -69,6 +82,9 @@ class PreviewPanel(ttk.Frame):
         # Keep for API compatibility, but no UI control triggers it.
         self.on_refresh: Optional[Callable[[], None]] = on_refresh
         self.on_breadcrumb_clicked: Optional[Callable[[str], None]] = on_breadcrumb_clicked
+        self.on_split_changed: Optional[Callable[[bool], None]] = on_split_changed
+        self.on_revisions_changed: Optional[Callable[[bool], None]] = on_revisions_changed
+        self.on_spelling_changed: Optional[Callable[[bool], None]] = on_spelling_changed
 
         # Layout
         self.columnconfigure(0, weight=1)
@@ -77,7 +93,7 @@ class PreviewPanel(ttk.Frame):
 
         # Header row (compact)
         header = ttk.Frame(self)
-        header.grid(row=0, column=0, sticky="ew", padx=4, pady=2)
+        header.grid(row=0, column=0, columnspan=2, sticky="ew", padx=4, pady=2)
         # Columns: 0=left group (radiobuttons + status), 1=spacer, 2=breadcrumb
         header.columnconfigure(0, weight=0)
         header.columnconfigure(1, weight=1)  # stretch spacer
@@ -104,6 +120,27 @@ class PreviewPanel(ttk.Frame):
         self._status_label = ttk.Label(toggle, textvariable=self._status_var)
         self._status_label.grid(row=0, column=2, padx=(8, 0), pady=0, sticky="w")
 
+        # Side-by-side toggle: source document section next to the topic
+        self._split_var = tk.BooleanVar(value=False)
+        self._cb_split = ttk.Checkbutton(
+            toggle, text=tr("Source"), variable=self._split_var, command=self._on_split_toggle
+        )
+        self._cb_split.grid(row=0, column=3, padx=(8, 0), pady=0, sticky="w")
+
+        # Revision bars: highlight the elements marked with @rev
+        self._revisions_var = tk.BooleanVar(value=False)
+        self._cb_revisions = ttk.Checkbutton(
+            toggle, text=tr("Revisions"), variable=self._revisions_var, command=self._on_revisions_toggle
+        )
+        self._cb_revisions.grid(row=0, column=4, padx=(8, 0), pady=0, sticky="w")
+
+        # Spelling: underline the words the spell checker suspects
+        self._spelling_var = tk.BooleanVar(value=False)
+        self._cb_spelling = ttk.Checkbutton(
+            toggle, text=tr("Spelling"), variable=self._spelling_var, command=self._on_spelling_toggle
+        )
+        self._cb_spelling.grid(row=0, column=5, padx=(8, 0), pady=0, sticky="w")
+
         # Breadcrumb widget (wider spacing in preview panel)
         self._breadcrumb = BreadcrumbWidget(
             header,
@@ -119,10 +156,24 @@ class PreviewPanel(ttk.Frame):
         self._title_var = tk.StringVar(value="")  # retained for API compatibility
         self._title_label = None  # type: ignore[assignment]
 
-        # Prefer a real HTML widget when available
-        self._html_rendering_enabled = False
-        self._html_widget_kind = "text"  # 'tkinterweb' | 'text'
+        # Prefer a real HTML widget when available ('tkinterweb' | 'text')
+        self._text, self._html_widget_kind = self._create_view()
+        self._html_rendering_enabled = self._html_widget_kind == "tkinterweb"
+
+        # Place directly under header and expand
+        self._text.grid(row=1, column=0, sticky="nsew", padx=4, pady=(0, 4))
 
+        # Side-by-side source section (created when first shown)
+        self._source_view = None
+        self._source_kind = "text"
+        self._scroll_positions: tuple = (0.0, 0.0)
+        self._scroll_job = None
+
+        # Loading spinner (covers entire preview area)
+        self._loading_spinner = UniversalSpinner(self, "Loading preview...")
+
+    def _create_view(self):
+        """Body widget: a tkinterweb HtmlFrame when available, else a read-only ScrolledText."""
         if HTML_WEB_AVAILABLE:
             try:
                 # Define external link handler for tkinterweb so clicks open in system browser
@@ -136,15 +187,13 @@ class PreviewPanel(ttk.Frame):
                         pass
                     # Fallback: allow default handling inside the HtmlFrame
                     return ""
-                self._text = HtmlFrame(
+                view = HtmlFrame(
                     self,
                     horizontal_scrollbar="auto",  # type: ignore[arg-type]
                     vertical_scrollbar="auto",    # type: ignore[arg-type]
                     messages_enabled=False,        # silence debug banner
                     on_link_click=_open_external,  # open external links in system browser
                 )
-                self._html_rendering_enabled = True
-                self._html_widget_kind = "tkinterweb"
                 # Best-effort: open links in external browser when supported by tkinterweb
                 try:
                     def _open_external(url: str) -> str:
@@ -156,7 +205,7 @@ class PreviewPanel(ttk.Frame):
                             pass
                         return "break"
                     for attr_name in ("on_link_click", "on_link", "set_on_link_click", "set_on_link"):
-                        cb = getattr(self._text, attr_name, None)
+                        cb = getattr(view, attr_name, None)
                         if callable(cb):
                             try:
                                 cb(_open_external)  # type: ignore[misc]
@@ -165,24 +214,33 @@ class PreviewPanel(ttk.Frame):
                                 pass
                 except Exception:
                     pass
+                self._apply_scale(view)
+                return view, "tkinterweb"
             except Exception:
                 # Fallback continues below
                 pass
 
-        if not self._html_rendering_enabled:
-            self._text = ScrolledText(self, wrap="word", height=10)
-            self._html_widget_kind = "text"
-        
-        # Place directly under header and expand
-        self._text.grid(row=1, column=0, sticky="nsew", padx=4, pady=(0, 4))
-        
-        # Configure read-only behavior for text fallback
-        if self._html_widget_kind == "text":
-            # For ScrolledText, make it read-only but selectable
-            self._text.configure(state="disabled")
+        view = ScrolledText(self, wrap="word", height=10)
+        # For ScrolledText, make it read-only but selectable
+        view.configure(state="disabled")
+        return view, "text"
 
-        # Loading spinner (covers entire preview area)
-        self._loading_spinner = UniversalSpinner(self, "Loading preview...")
+    def _apply_scale(self, view) -> None:
+        """Zoom the HTML view to the screen density (``preview.scale`` in preview_styles.yml)."""
+        try:
+            from orlando_toolkit.core.preview.themes import preview_scale
+            scale = preview_scale(self.winfo_fpixels("1i"))
+        except Exception:
+            return
+        if scale == 1.0:
+            return
+        # tkinterweb 3 has set_zoom(); tkinterweb 4 takes a zoom option
+        for apply in (lambda: view.set_zoom(scale), lambda: view.configure(zoom=scale)):
+            try:
+                apply()
+                return
+            except Exception:
+                continue
 
     # Public API
 
@@ -246,35 +304,81 @@ class PreviewPanel(ttk.Frame):
 
     def set_content(self, text: str) -> None:
         """Set body content with HTML rendering if available and in HTML mode."""
+        self._load_into(self._text, self._html_widget_kind, text)
+
+    def set_split(self, enabled: bool) -> None:
+        """Show or hide the source document section next to the topic."""
+        enabled = bool(enabled)
+        if self._split_var.get() != enabled:
+            self._split_var.set(enabled)
+        self._apply_split()
+
+    def is_split(self) -> bool:
+        """Whether the side-by-side source section is shown."""
+        try:
+            return bool(self._split_var.get())
+        except Exception:
+            return False
+
+    def set_highlight_revisions(self, enabled: bool) -> None:
+        """Turn the revision bars (elements marked with @rev) on or off; the caller re-renders."""
+        self._revisions_var.set(bool(enabled))
+
+    def highlights_revisions(self) -> bool:
+        """Whether revised elements are highlighted."""
+        try:
+            return bool(self._revisions_var.get())
+        except Exception:
+            return False
+
+    def set_highlight_spelling(self, enabled: bool) -> None:
+        """Turn the misspelling underlines on or off; the caller re-renders."""
+        self._spelling_var.set(bool(enabled))
+
+    def highlights_spelling(self) -> bool:
+        """Whether suspected misspellings are underlined."""
+        try:
+            return bool(self._spelling_var.get())
+        except Exception:
+            return False
+
+    def set_source_content(self, text: str) -> None:
+        """Set the source section shown in side-by-side mode (HTML)."""
+        if self._source_view is None:
+            return
+        self._load_into(self._source_view, self._source_kind, text)
+        self._scroll_positions = (0.0, 0.0)
+
+    @staticmethod
+    def _load_into(view, kind: str, text: str) -> None:
         try:
             # Prefer HTML engine whenever content looks like HTML (both HTML mode and XML-wrapped-in-<pre>)
             content_str = text or ""
             looks_like_html = isinstance(content_str, str) and content_str.lstrip().startswith("<")
 
-            if self._html_rendering_enabled and looks_like_html:
-                if self._html_widget_kind == "tkinterweb" and hasattr(self._text, 'load_html'):
-                    try:
-                        self._text.load_html(content_str)
-                        return
-                    except Exception:
-                        pass
+            if kind == "tkinterweb" and looks_like_html and hasattr(view, 'load_html'):
+                try:
+                    view.load_html(content_str)
+                    return
+                except Exception:
+                    pass
 
             # Fallback: render as plain text (only reliable on ScrolledText)
-            if isinstance(getattr(self, "_html_widget_kind", None), str) and self._html_widget_kind == "text":
+            if kind == "text":
                 try:
-                    self._text.configure(state="normal")
-                    self._text.delete("1.0", "end")
-                    self._text.insert("1.0", content_str)
-                    self._text.configure(state="disabled")
+                    view.configure(state="normal")
+                    view.delete("1.0", "end")
+                    view.insert("1.0", content_str)
+                    view.configure(state="disabled")
                     return
                 except Exception:
                     pass
-            
+
         except Exception:
             # Ensure widget remains in a consistent state
             try:
-                if hasattr(self._text, 'configure') and not self._html_rendering_enabled:
-                    self._text.configure(state="disabled")
+                if hasattr(view, 'configure') and kind == "text":
+                    view.configure(state="disabled")
             except Exception:
                 pass
 
@@ -288,6 +392,7 @@ class PreviewPanel(ttk.Frame):
         """Clear content and reset loading; title row is removed to save space."""
         self._title_var.set("")
         self.set_content("")
+        self.set_source_content("")
         self.set_loading(False)
         self._breadcrumb.clear()
 
@@ -309,6 +414,86 @@ class PreviewPanel(ttk.Frame):
             except Exception:
                 pass
 
+    def _on_revisions_toggle(self) -> None:
+        cb = self.on_revisions_changed
+        if callable(cb):
+            try:
+                cb(self.highlights_revisions())
+            except Exception:
+                pass
+
+    def _on_spelling_toggle(self) -> None:
+        cb = self.on_spelling_changed
+        if callable(cb):
+            try:
+                cb(self.highlights_spelling())
+            except Exception:
+                pass
+
+    def _on_split_toggle(self) -> None:
+        self._apply_split()
+        cb = self.on_split_changed
+        if callable(cb):
+            try:
+                cb(self.is_split())
+            except Exception:
+                pass
+
+    def _apply_split(self) -> None:
+        if self.is_split():
+            if self._source_view is None:
+                self._source_view, self._source_kind = self._create_view()
+            self.columnconfigure(1, weight=1, uniform="preview")
+            self.columnconfigure(0, weight=1, uniform="preview")
+            self._source_view.grid(row=1, column=1, sticky="nsew", padx=(0, 4), pady=(0, 4))
+            self._schedule_scroll_sync()
+        else:
+            if self._source_view is not None:
+                self._source_view.grid_remove()
+            self.columnconfigure(1, weight=0, uniform="")
+            self.columnconfigure(0, weight=1, uniform="")
+            if self._scroll_job is not None:
+                try:
+                    self.after_cancel(self._scroll_job)
+                except Exception:
+                    pass
+                self._scroll_job = None
+
+    # Synchronized scrolling: poll both views and move the one that did not scroll
+    @staticmethod
+    def _scroll_target(view):
+        for target in (view, getattr(view, "html", None)):
+            if target is not None and callable(getattr(target, "yview", None)):
+                return target
+        return None
+
+    def _schedule_scroll_sync(self) -> None:
+        if self._scroll_job is None:
+            try:
+                self._scroll_job = self.after(150, self._sync_scroll)
+            except Exception:
+                self._scroll_job = None
+
+    def _sync_scroll(self) -> None:
+        self._scroll_job = None
+        if not self.is_split() or self._source_view is None:
+            return
+        try:
+            topic, source = self._scroll_target(self._text), self._scroll_target(self._source_view)
+            if topic is not None and source is not None:
+                positions = (float(topic.yview()[0]), float(source.yview()[0]))
+                last = self._scroll_positions
+                if abs(positions[0] - last[0]) > 1e-3:
+                    source.yview_moveto(positions[0])
+                    positions = (positions[0], positions[0])
+                elif abs(positions[1] - last[1]) > 1e-3:
+                    topic.yview_moveto(positions[1])
+                    positions = (positions[1], positions[1])
+                self._scroll_positions = positions
+        except Exception:
+            pass
+        self._schedule_scroll_sync()
+
     # Refresh callback retained for compatibility but unused (no button rendered)
     def _on_refresh_clicked(self) -> None:
         cb = self.on_refresh
@@ -0,0 +1,130 @@
+# -*- coding: utf-8 -*-
+"""
+Profiling editor for the Metadata tab.
+
+Lists the profiling values used in the document and the configurations the
+package gets a DITAVAL file for (``orlando_toolkit.core.profiling``). Edits
+are stored in ``context.metadata["profiling"]``; removing a configuration
+defined in ``conversion.yml`` stores ``None`` under its name so it is skipped
+for this document.
+"""
+
+from __future__ import annotations
+
+from typing import Callable, Optional, TYPE_CHECKING
+import tkinter as tk
+from tkinter import ttk
+
+from orlando_toolkit.core.profiling import format_selection, parse_selection, profiling_configurations, \
+    profiling_values
+from orlando_toolkit.ui.i18n import tr
+
+if TYPE_CHECKING:
+    from orlando_toolkit.core.models import DitaContext
+
+
+class ProfilingEditor(ttk.LabelFrame):
+    def __init__(self, parent, *, on_change: Optional[Callable[[], None]] = None, **kwargs):
+        super().__init__(parent, text=tr("Profiling (DITAVAL configurations)"), padding=10, **kwargs)
+        self.context: Optional["DitaContext"] = None
+        self.on_change = on_change
+        self.columnconfigure(1, weight=1)
+
+        ttk.Label(self, text=tr("Values in this document:")).grid(row=0, column=0, sticky="nw", padx=(0, 10))
+        self.values_label = ttk.Label(self, text="", foreground="gray", wraplength=520, justify="left")
+        self.values_label.grid(row=0, column=1, columnspan=3, sticky="w")
+
+        self.tree = ttk.Treeview(self, columns=("keeps",), height=4, selectmode="browse")
+        self.tree.heading("#0", text=tr("Configuration"))
+        self.tree.heading("keeps", text=tr("Keeps (attribute=value,value; ...)"))
+        self.tree.column("#0", width=160, stretch=False)
+        self.tree.grid(row=1, column=0, columnspan=4, sticky="ew", pady=(8, 8))
+        self.tree.bind("<<TreeviewSelect>>", lambda _e: self._on_select())
+
+        ttk.Label(self, text=tr("Name:")).grid(row=2, column=0, sticky="w")
+        self.name_var = tk.StringVar()
+        ttk.Entry(self, textvariable=self.name_var, width=18).grid(row=2, column=1, sticky="w")
+        ttk.Label(self, text=tr("Keeps:")).grid(row=3, column=0, sticky="w", pady=(4, 0))
+        self.keeps_var = tk.StringVar()
+        ttk.Entry(self, textvariable=self.keeps_var).grid(row=3, column=1, sticky="ew", pady=(4, 0))
+        buttons = ttk.Frame(self)
+        buttons.grid(row=3, column=2, sticky="e", padx=(8, 0), pady=(4, 0))
+        ttk.Button(buttons, text=tr("Save"), command=self._save).pack(side="left", padx=(0, 6))
+        ttk.Button(buttons, text=tr("Remove"), command=self._remove).pack(side="left")
+        self.status = ttk.Label(self, text="", foreground="#cc0000")
+        self.status.grid(row=4, column=0, columnspan=4, sticky="w", pady=(4, 0))
+
+    # ------------------------------------------------------------------
+    # Public API
+    # ------------------------------------------------------------------
+    def load_context(self, context: "DitaContext") -> None:
+        self.context = context
+        self.name_var.set("")
+        self.keeps_var.set("")
+        self.status.configure(text="")
+        self.refresh()
+
+    def refresh(self) -> None:
+        if not self.context:
+            return
+        used = profiling_values(self.context)
+        self.values_label.configure(text="; ".join(f"{a}: {', '.join(v)}" for a, v in used.items()) or "none")
+        self.tree.delete(*self.tree.get_children())
+        for configuration in profiling_configurations(self.context.metadata):
+            self.tree.insert("", "end", iid=configuration.name, text=configuration.name,
+                             values=(format_selection(configuration.select),))
+
+    # ------------------------------------------------------------------
+    # Internal helpers
+    # ------------------------------------------------------------------
+    def _configurations(self) -> dict:
+        profiling = self.context.metadata.get("profiling")
+        if not isinstance(profiling, dict):
+            profiling = self.context.metadata["profiling"] = {}
+        if not isinstance(profiling.get("configurations"), dict):
+            profiling["configurations"] = {}
+        return profiling["configurations"]
+
+    def _on_select(self) -> None:
+        selection = self.tree.selection()
+        if not selection:
+            return
+        self.name_var.set(self.tree.item(selection[0], "text"))
+        self.keeps_var.set(self.tree.set(selection[0], "keeps"))
+        self.status.configure(text="")
+
+    def _save(self) -> None:
+        if not self.context:
+            return
+        name = self.name_var.get().strip()
+        if not name:
+            self.status.configure(text=tr("Enter a configuration name"))
+            return
+        try:
+            selection = parse_selection(self.keeps_var.get())
+        except ValueError as exc:
+            self.status.configure(text=str(exc))
+            return
+        self._configurations()[name] = selection
+        self.status.configure(text="")
+        self._changed()
+
+    def _remove(self) -> None:
+        if not self.context:
+            return
+        name = self.name_var.get().strip()
+        if name not in self.tree.get_children():
+            self.status.configure(text=tr("Select a configuration to remove"))
+            return
+        self._configurations()[name] = None
+        self.name_var.set("")
+        self.keeps_var.set("")
+        self._changed()
+
+    def _changed(self) -> None:
+        self.refresh()
+        if self.on_change:
+            try:
+                self.on_change()
+            except Exception:
+                pass
@@ -0,0 +1,147 @@
+# -*- coding: utf-8 -*-
+"""
+Prolog metadata editor.
+
+Fields for the author, critical dates, audience, product and version and
+permissions, and a table of custom ``othermeta`` rows
+(``orlando_toolkit.core.prolog_metadata``). Used for the whole manual in the
+Metadata tab and for a branch in the Structure tab's metadata dialog; the
+caller stores the values returned by :meth:`PrologEditor.get_values` once
+they are valid.
+"""
+
+from __future__ import annotations
+
+from typing import Callable, List, Optional, Tuple
+import tkinter as tk
+from tkinter import ttk
+
+from orlando_toolkit.core.prolog_metadata import AUDIENCE_TYPES, PERMISSION_VIEWS, PrologMetadata
+from orlando_toolkit.ui.i18n import tr
+
+_FIELDS = (
+    ("author", "Author:"),
+    ("created", "Created (YYYY-MM-DD):"),
+    ("revised", "Revised (YYYY-MM-DD):"),
+    ("audience", "Audience:"),
+    ("product", "Product:"),
+    ("version", "Version:"),
+    ("permissions", "Permissions:"),
+)
+
+
+class PrologEditor(ttk.LabelFrame):
+    def __init__(self, parent, *, text: str = "Prolog metadata",
+                 on_apply: Optional[Callable[[PrologMetadata], None]] = None, **kwargs):
+        super().__init__(parent, text=text, padding=10, **kwargs)
+        self.on_apply = on_apply
+        self.columnconfigure(1, weight=1)
+        self.vars: dict[str, tk.StringVar] = {}
+        self._rows: List[Tuple[str, str]] = []
+
+        for row, (key, label) in enumerate(_FIELDS):
+            ttk.Label(self, text=label).grid(row=row, column=0, sticky="w", padx=(0, 10), pady=2)
+            var = tk.StringVar()
+            if key == "audience":
+                widget = ttk.Combobox(self, textvariable=var, values=AUDIENCE_TYPES, width=20)
+            elif key == "permissions":
+                widget = ttk.Combobox(self, textvariable=var, values=("",) + PERMISSION_VIEWS, state="readonly",
+                                      width=20)
+            else:
+                widget = ttk.Entry(self, textvariable=var)
+            widget.grid(row=row, column=1, columnspan=3, sticky="ew" if key not in ("audience", "permissions")
+                        else "w", pady=2)
+            self.vars[key] = var
+
+        row = len(_FIELDS)
+        self.tree = ttk.Treeview(self, columns=("content",), height=3, selectmode="browse")
+        self.tree.heading("#0", text=tr("Other metadata"))
+        self.tree.heading("content", text=tr("Value"))
+        self.tree.column("#0", width=160, stretch=False)
+        self.tree.grid(row=row, column=0, columnspan=4, sticky="ew", pady=(8, 4))
+        self.tree.bind("<<TreeviewSelect>>", lambda _e: self._on_select())
+        self.name_var = tk.StringVar()
+        self.content_var = tk.StringVar()
+        ttk.Label(self, text=tr("Name:")).grid(row=row + 1, column=0, sticky="w")
+        ttk.Entry(self, textvariable=self.name_var, width=18).grid(row=row + 1, column=1, sticky="w")
+        ttk.Label(self, text=tr("Value:")).grid(row=row + 2, column=0, sticky="w", pady=(4, 0))
+        ttk.Entry(self, textvariable=self.content_var).grid(row=row + 2, column=1, sticky="ew", pady=(4, 0))
+        buttons = ttk.Frame(self)
+        buttons.grid(row=row + 2, column=2, sticky="e", padx=(8, 0), pady=(4, 0))
+        ttk.Button(buttons, text=tr("Set row"), command=self._save_row).pack(side="left", padx=(0, 6))
+        ttk.Button(buttons, text=tr("Remove row"), command=self._remove_row).pack(side="left")
+
+        self.status = ttk.Label(self, text="", foreground="#cc0000", justify="left")
+        self.status.grid(row=row + 3, column=0, columnspan=3, sticky="w", pady=(4, 0))
+        if on_apply is not None:
+            ttk.Button(self, text=tr("Apply"), command=self._apply).grid(row=row + 3, column=3, sticky="e", pady=(4, 0))
+
+    # ------------------------------------------------------------------
+    # Public API
+    # ------------------------------------------------------------------
+    def set_values(self, values: PrologMetadata) -> None:
+        for key, var in self.vars.items():
+            var.set(getattr(values, key))
+        self._rows = list(values.othermeta)
+        self.name_var.set("")
+        self.content_var.set("")
+        self.status.configure(text="")
+        self._refresh_rows()
+
+    def get_values(self) -> PrologMetadata:
+        return PrologMetadata.from_mapping({**{k: v.get() for k, v in self.vars.items()},
+                                            "othermeta": list(self._rows)})
+
+    def validate(self) -> Optional[PrologMetadata]:
+        """The values, or None after showing why they cannot be stored."""
+        values = self.get_values()
+        problems = values.errors()
+        self.status.configure(text="\n".join(problems))
+        return None if problems else values
+
+    # ------------------------------------------------------------------
+    # Internal helpers
+    # ------------------------------------------------------------------
+    def _refresh_rows(self) -> None:
+        self.tree.delete(*self.tree.get_children())
+        for index, (name, content) in enumerate(self._rows):
+            self.tree.insert("", "end", iid=str(index), text=name, values=(content,))
+
+    def _on_select(self) -> None:
+        selection = self.tree.selection()
+        if selection:
+            name, content = self._rows[int(selection[0])]
+            self.name_var.set(name)
+            self.content_var.set(content)
+
+    def _save_row(self) -> None:
+        name = " ".join(self.name_var.get().split())
+        content = " ".join(self.content_var.get().split())
+        if not name or not content:
+            self.status.configure(text=tr("Enter the name and the value of the row"))
+            return
+        existing = next((i for i, (n, _c) in enumerate(self._rows) if n == name), None)
+        if existing is None:
+            self._rows.append((name, content))
+        else:
+            self._rows[existing] = (name, content)
+        self.status.configure(text="")
+        self._refresh_rows()
+
+    def _remove_row(self) -> None:
+        name = " ".join(self.name_var.get().split())
+        if not any(n == name for n, _c in self._rows):
+            self.status.configure(text=tr("Select a row to remove"))
+            return
+        self._rows = [(n, c) for n, c in self._rows if n != name]
+        self.name_var.set("")
+        self.content_var.set("")
+        self._refresh_rows()
+
+    def _apply(self) -> None:
+        values = self.validate()
+        if values is not None and self.on_apply:
+            try:
+                self.on_apply(values)
+            except Exception:
+                pass
@@ -2,17 +2,19 @@ from __future__ import annotations
 
 import tkinter as tk
 from tkinter import ttk
-from typing import Callable, Optional
+from typing import Callable, Dict, Optional
 from typing import Literal
 
 
 class SearchWidget(ttk.Frame):
     """A reusable Tkinter search widget with navigation controls.
 
-    This widget encapsulates a search entry field with optional clear button and
-    "Prev"/"Next" navigation buttons. It exposes callback hooks for responding to
-    search term changes and navigation requests, while keeping a conservative and
-    robust behavior suitable for embedding in various UI contexts.
+    This widget encapsulates a search entry field with optional clear button,
+    "Prev"/"Next" navigation buttons, search option toggles (regular expression,
+    body text, only matching branches) and a match count. It exposes callback hooks
+    for responding to search term, option changes and navigation requests, while
+    keeping a conservative and robust behavior suitable for embedding in various UI
+    contexts.
 
     Parameters
     ----------
@@ -26,6 +28,9 @@ class SearchWidget(ttk.Frame):
         Callback invoked when navigation is requested, either by clicking the
         "Prev"/"Next" buttons or by pressing Return/Shift+Return in the entry. The
         callback receives either "prev" or "next".
+    on_options_changed : Optional[Callable[[Dict[str, bool]], None]], optional
+        Callback invoked when an option toggle changes. Receives the mapping
+        returned by ``get_options`` (``regex``, ``body_text``, ``only_matching``).
 
     Notes
     -----
@@ -46,12 +51,14 @@ class SearchWidget(ttk.Frame):
         *,
         on_term_changed: Optional[Callable[[str], None]] = None,
         on_navigate: Optional[Callable[[Literal["prev", "next"]], None]] = None,
+        on_options_changed: Optional[Callable[[Dict[str, bool]], None]] = None,
         entry_width: Optional[int] = None,
     ) -> None:
         super().__init__(master)
 
         self._on_term_changed = on_term_changed
         self._on_navigate = on_navigate
+        self._on_options_changed = on_options_changed
 
         # Internal state
         self._term_var = tk.StringVar(value="")
@@ -77,11 +84,34 @@ class SearchWidget(ttk.Frame):
         self._next_btn = ttk.Button(self, text="▶", width=3, command=lambda: self.navigate_results("next"))
         self._next_btn.grid(row=0, column=3, padx=0, pady=0, sticky="nsew")
 
+        # Option toggles: regular expression, body text, only matching branches
+        self._option_vars: Dict[str, tk.BooleanVar] = {}
+        self._option_buttons: Dict[str, ttk.Checkbutton] = {}
+        toggles = (
+            ("regex", ".*", "Regular expression"),
+            ("body_text", "¶", "Search topic text too"),
+            ("only_matching", "⊟", "Show only matching branches"),
+        )
+        for col, (key, text, _hint) in enumerate(toggles, start=4):
+            var = tk.BooleanVar(value=False)
+            self._option_vars[key] = var
+            btn = ttk.Checkbutton(self, text=text, variable=var, style="Toolbutton",
+                                  command=self._on_option_toggled)
+            btn.grid(row=0, column=col, padx=(4 if col == 4 else 0, 0), pady=0, sticky="nsew")
+            self._option_buttons[key] = btn
+
+        # Match count or pattern error
+        self._status_var = tk.StringVar(value="")
+        self._status = ttk.Label(self, textvariable=self._status_var, foreground="#666666")
+        self._status.grid(row=0, column=7, padx=(6, 0), pady=0, sticky="w")
+
         # Optional hover hints
         try:
             from orlando_toolkit.ui.custom_widgets import Tooltip
             Tooltip(self._prev_btn, "Previous match")
             Tooltip(self._next_btn, "Next match")
+            for key, _text, hint in toggles:
+                Tooltip(self._option_buttons[key], hint)
         except Exception:
             pass
 
@@ -135,6 +165,20 @@ class SearchWidget(ttk.Frame):
             # Robust fallback
             return ""
 
+    def get_options(self) -> Dict[str, bool]:
+        """Return the option toggles: ``regex``, ``body_text`` and ``only_matching``."""
+        try:
+            return {key: bool(var.get()) for key, var in self._option_vars.items()}
+        except Exception:
+            return {}
+
+    def set_status(self, text: str) -> None:
+        """Show a short status next to the toggles (match position/count or pattern error)."""
+        try:
+            self._status_var.set(text or "")
+        except Exception:
+            pass
+
     def navigate_results(self, direction: Literal["prev", "next"]) -> None:
         """Request navigation in the specified direction.
 
@@ -186,6 +230,14 @@ class SearchWidget(ttk.Frame):
             # Suppress exceptions to keep UI stable
             pass
 
+    def _on_option_toggled(self) -> None:
+        if self._on_options_changed is None:
+            return
+        try:
+            self._on_options_changed(self.get_options())
+        except Exception:
+            pass
+
     def _on_term_var_changed(self, *args) -> None:
         # Variable changes (programmatic or user typing)
         self._maybe_notify_term_changed()
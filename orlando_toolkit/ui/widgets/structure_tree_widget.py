@@ -36,6 +36,11 @@ class StructureTreeWidget(ttk.Frame):
           activated topic_ref string, if known, else None.
         - on_context_menu: Invoked on right click (<Button-3>). Receives the Tk event
           and a list of currently selected topic_ref strings (unknown items omitted).
+        - on_drop: Invoked when selected rows are dragged onto another row. Receives the
+          dragged XML nodes, the target XML node and "before", "after" or "inside".
+        - on_delete: Invoked on the Delete key with the selected XML nodes.
+        - on_clipboard: Invoked on Ctrl+C, Ctrl+X and Ctrl+V with "copy", "cut" or
+          "paste" and the selected XML nodes.
 
     Notes
     -----
@@ -53,6 +58,9 @@ class StructureTreeWidget(ttk.Frame):
         on_selection_changed: Optional[Callable[[List[ET.Element]], None]] = None,
         on_item_activated: Optional[Callable[[Optional[ET.Element]], None]] = None,
         on_context_menu: Optional[Callable[[tk.Event, List[ET.Element]], None]] = None,
+        on_drop: Optional[Callable[[List[ET.Element], ET.Element, str], None]] = None,
+        on_delete: Optional[Callable[[List[ET.Element]], None]] = None,
+        on_clipboard: Optional[Callable[[str, List[ET.Element]], None]] = None,
     ) -> None:
         """Initialize the StructureTreeWidget.
 
@@ -69,11 +77,26 @@ class StructureTreeWidget(ttk.Frame):
         on_context_menu : Optional[Callable[[tk.Event, List[ET.Element]], None]], optional
             Callback invoked on context menu (right-click). Receives the event
             and a list of currently selected XML nodes.
+        on_drop : Optional[Callable[[List[ET.Element], ET.Element, str], None]], optional
+            Callback invoked when a drag-and-drop ends on another row. Receives the
+            dragged XML nodes, the target XML node and the drop position.
+        on_delete : Optional[Callable[[List[ET.Element]], None]], optional
+            Callback invoked on the Delete key. Receives the selected XML nodes.
+        on_clipboard : Optional[Callable[[str, List[ET.Element]], None]], optional
+            Callback invoked on Ctrl+C/X/V. Receives "copy", "cut" or "paste" and the
+            selected XML nodes.
         """
         super().__init__(master)
         self._on_selection_changed = on_selection_changed
         self._on_item_activated = on_item_activated
         self._on_context_menu = on_context_menu
+        self._on_drop = on_drop
+        self._on_delete = on_delete
+        self._on_clipboard = on_clipboard
+        # Drag-and-drop state: pressed row, selection before the press, start point, current drop
+        self._drag: Optional[Dict[str, Any]] = None
+        # Full child order per parent while only matching branches are shown (None when unfiltered)
+        self._full_children: Optional[Dict[str, List[str]]] = None
 
         # Configure a custom Treeview style to match Heading Filter (no bg change, blue text)
         try:
@@ -162,6 +185,16 @@ class StructureTreeWidget(ttk.Frame):
         self._tree.bind("<Double-1>", self._on_double_click_event, add="+")
         self._tree.bind("<Button-1>", self._on_single_click_event, add="+")
         self._tree.bind("<Button-3>", self._on_right_click_event, add="+")
+        self._tree.bind("<ButtonPress-1>", self._on_drag_press, add="+")
+        self._tree.bind("<B1-Motion>", self._on_drag_motion, add="+")
+        self._tree.bind("<ButtonRelease-1>", self._on_drag_release, add="+")
+        self._tree.bind("<Delete>", self._on_delete_key, add="+")
+        for sequence, action in (("<Control-c>", "copy"), ("<Control-x>", "cut"), ("<Control-v>", "paste")):
+            self._tree.bind(sequence, lambda e, a=action: self._on_clipboard_key(a), add="+")
+        try:
+            self._tree.tag_configure("drop_target", background="#dcefff")
+        except Exception:
+            pass
         # Marker bar updates when branches open/close
         try:
             self._tree.bind("<<TreeviewOpen>>", lambda e: self._on_section_toggle(e, True), add="+")
@@ -335,6 +368,8 @@ class StructureTreeWidget(ttk.Frame):
 
     def populate_tree(self, context: DitaContext, max_depth: int = 999) -> None:
         """Delegate population to module function (keeps widget lean)."""
+        # Reattach rows hidden by a branch filter so they are cleared with the rest
+        self._restore_filtered_items()
         # UNIFIED: Clear XML mappings before repopulation (prevents memory leaks)
         try:
             self._xml_node_to_id.clear()
@@ -1066,6 +1101,7 @@ class StructureTreeWidget(ttk.Frame):
 
         This method rebuilds the widget to a pristine state.
         """
+        self._restore_filtered_items()
         try:
             self._tree.delete(*self._tree.get_children(""))
         except Exception:
@@ -1512,6 +1548,108 @@ class StructureTreeWidget(ttk.Frame):
         except Exception:
             pass
 
+    # --- Drag-and-drop and Delete key ---
+
+    _DRAG_THRESHOLD = 5
+    _DROP_CURSORS = {"before": "sb_up_arrow", "after": "sb_down_arrow", "inside": "sb_right_arrow"}
+
+    def _on_drag_press(self, event: tk.Event) -> None:
+        """Remember the pressed row and the selection as it was before Tk updates it."""
+        try:
+            state = int(getattr(event, "state", 0))
+        except Exception:
+            state = 0
+        item_id = self._tree.identify_row(event.y)
+        if not item_id or not self._on_drop or (state & 0x0001) or (state & 0x0004):
+            self._drag = None
+            return
+        self._drag = {"item": item_id, "selection": tuple(self._tree.selection()),
+                      "x": event.x, "y": event.y, "active": False, "drop": None}
+
+    def _drop_position(self, item_id: str, y: int) -> str:
+        """Upper quarter drops before the row, lower quarter after it, the middle inside it."""
+        try:
+            _x, top, _w, height = self._tree.bbox(item_id)
+            ratio = (y - top) / float(height or 1)
+        except Exception:
+            return "inside"
+        if ratio < 0.25:
+            return "before"
+        if ratio > 0.75:
+            return "after"
+        return "inside"
+
+    def _set_drop_highlight(self, item_id: Optional[str]) -> None:
+        for other in self._tree.tag_has("drop_target"):
+            tags = tuple(t for t in self._tree.item(other, "tags") or () if t != "drop_target")
+            self._tree.item(other, tags=tags)
+        if item_id:
+            self._tree.item(item_id, tags=tuple(self._tree.item(item_id, "tags") or ()) + ("drop_target",))
+
+    def _on_drag_motion(self, event: tk.Event) -> Optional[str]:
+        drag = self._drag
+        if not drag:
+            return None
+        try:
+            if not drag["active"]:
+                if abs(event.x - drag["x"]) + abs(event.y - drag["y"]) < self._DRAG_THRESHOLD:
+                    return None
+                drag["active"] = True
+                # Dragging a row of a multi-selection drags the whole selection
+                if drag["item"] in drag["selection"]:
+                    self._tree.selection_set(drag["selection"])
+            target = self._tree.identify_row(event.y)
+            if not target or target in self._tree.selection():
+                drag["drop"] = None
+                self._set_drop_highlight(None)
+                self._tree.configure(cursor="X_cursor")
+                return "break"
+            position = self._drop_position(target, event.y)
+            drag["drop"] = (target, position)
+            self._set_drop_highlight(target)
+            self._tree.configure(cursor=self._DROP_CURSORS[position])
+        except Exception:
+            pass
+        return "break"
+
+    def _on_drag_release(self, _event: tk.Event) -> None:
+        drag, self._drag = self._drag, None
+        if not drag or not drag["active"]:
+            return
+        try:
+            self._set_drop_highlight(None)
+            self._tree.configure(cursor="")
+            if drag["drop"] and self._on_drop:
+                target_id, position = drag["drop"]
+                target = self._id_to_xml_node.get(target_id)
+                nodes = self.get_selected_xml_nodes()
+                if target is not None and nodes:
+                    self._on_drop(nodes, target, position)
+        except Exception:
+            pass
+
+    def _on_delete_key(self, _event: tk.Event) -> Optional[str]:
+        if not self._on_delete:
+            return None
+        try:
+            nodes = self.get_selected_xml_nodes()
+            if nodes:
+                self._on_delete(nodes)
+        except Exception:
+            pass
+        return "break"
+
+    def _on_clipboard_key(self, action: str) -> Optional[str]:
+        if not self._on_clipboard:
+            return None
+        try:
+            nodes = self.get_selected_xml_nodes()
+            if nodes or action == "paste":
+                self._on_clipboard(action, nodes)
+        except Exception:
+            pass
+        return "break"
+
     # --- Context helpers for external callers (e.g., to build context menus) ---
 
     def get_item_context_at(self, event: tk.Event) -> Dict[str, Any]:
@@ -1571,7 +1709,8 @@ class StructureTreeWidget(ttk.Frame):
             current = item_id
             while current:
                 parent = self._tree.parent(current)
-                siblings = list(self._tree.get_children(parent))
+                # Rows hidden by a branch filter still count, so paths match the map
+                siblings = list((self._full_children or {}).get(parent) or self._tree.get_children(parent))
                 try:
                     idx = siblings.index(current)
                 except ValueError:
@@ -1583,6 +1722,50 @@ class StructureTreeWidget(ttk.Frame):
             return []
         return path
 
+    def show_only_branches(self, xml_nodes: Optional[Any]) -> None:
+        """Show only the rows of *xml_nodes* (a match and its ancestors each); None shows every row again.
+
+        Hidden rows are detached, not deleted: index paths and selection helpers keep
+        working on the full structure, and kept rows are opened so matches are visible.
+        """
+        self._restore_filtered_items()
+        if xml_nodes is None:
+            return
+        try:
+            keep = {self._xml_node_to_id[n] for n in xml_nodes if n in self._xml_node_to_id}
+            snapshot: Dict[str, List[str]] = {}
+            for item_id in [""] + self._iter_all_item_ids():
+                children = list(self._tree.get_children(item_id))
+                if children:
+                    snapshot[item_id] = children
+            self._full_children = snapshot
+            for parent, children in snapshot.items():
+                if parent and parent not in keep:
+                    continue
+                for child in children:
+                    if child not in keep:
+                        self._tree.detach(child)
+                    elif parent:
+                        self._tree.item(parent, open=True)
+        except Exception:
+            self._restore_filtered_items()
+
+    def _restore_filtered_items(self) -> None:
+        snapshot, self._full_children = self._full_children, None
+        if not snapshot:
+            return
+        try:
+            for parent, children in snapshot.items():
+                for index, child in enumerate(children):
+                    self._tree.move(child, parent, index)
+        except Exception:
+            pass
+
+    def get_index_path_for_xml_node(self, xml_node: ET.Element) -> List[int]:
+        """Return the index path of the row showing *xml_node*, or [] when it is not shown."""
+        item_id = self._xml_node_to_id.get(xml_node)
+        return self.get_index_path_for_item_id(item_id) if item_id else []
+
     # --------------------------- Marker bar integration ---------------------------
     def _on_tree_yscroll(self, first: str, last: str) -> None:
         """Proxy yscrollcommand to scrollbar and marker bar viewport."""
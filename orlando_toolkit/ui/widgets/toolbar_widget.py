@@ -11,23 +11,29 @@ class ToolbarWidget(ttk.Frame):
     """A compact toolbar widget providing move controls for structural editing.
 
     This presentation-only widget provides Up and Down buttons that perform intelligent
-    movement with automatic level adaptation in the hierarchy structure.
+    movement with automatic level adaptation in the hierarchy structure, Promote and
+    Demote buttons that change the level of the selection, followed by Undo and Redo buttons.
 
     Parameters
     ----------
     master : tk.Widget
         Parent widget.
-    on_move : Optional[Callable[[Literal["up", "down"]], None]], optional
+    on_move : Optional[Callable[[Literal["up", "down", "promote", "demote"]], None]], optional
         Callback invoked when a button is pressed, receiving the direction literal.
         If not provided, button presses are no-ops (beyond local state handling).
+    on_undo, on_redo : Optional[Callable[[], None]], optional
+        Callbacks for the Undo and Redo buttons.
 
     Notes
     -----
     - Up/Down buttons move topics up/down visually and adapt their hierarchy level automatically.
+    - Promote/Demote buttons move the whole selection one level up or down.
     - All callbacks are wrapped in try/except to prevent exceptions from propagating
       into the Tkinter mainloop.
     - The widget provides methods to enable/disable all buttons together and to set
       per-button enabled states individually.
+    - Undo/Redo buttons are driven separately by ``set_history_state``; the move
+      button methods leave them untouched.
     """
 
     def __init__(
@@ -35,8 +41,10 @@ class ToolbarWidget(ttk.Frame):
         master: "tk.Widget",
         *,
         on_move: Optional[
-            Callable[[Literal["up", "down"]], None]
+            Callable[[Literal["up", "down", "promote", "demote"]], None]
         ] = None,
+        on_undo: Optional[Callable[[], None]] = None,
+        on_redo: Optional[Callable[[], None]] = None,
     ) -> None:
         super().__init__(master)
         self._on_move = on_move
@@ -44,24 +52,40 @@ class ToolbarWidget(ttk.Frame):
         # Create buttons with compact pictograms (arrows) instead of text labels.
         self._btn_up = ttk.Button(self, text="↑", width=3, command=self._make_handler("up"))
         self._btn_down = ttk.Button(self, text="↓", width=3, command=self._make_handler("down"))
+        self._btn_promote = ttk.Button(self, text="←", width=3, command=self._make_handler("promote"))
+        self._btn_demote = ttk.Button(self, text="→", width=3, command=self._make_handler("demote"))
+
+        self._btn_undo = ttk.Button(self, text="↶", width=3, command=self._make_safe(on_undo))
+        self._btn_redo = ttk.Button(self, text="↷", width=3, command=self._make_safe(on_redo))
 
         # Hover tooltips
+        self._tip_undo: Optional[Tooltip] = None
+        self._tip_redo: Optional[Tooltip] = None
         try:
             Tooltip(self._btn_up, "Move up with smart level adaptation")
             Tooltip(self._btn_down, "Move down with smart level adaptation")
+            Tooltip(self._btn_promote, "Promote selection one level (Alt+Left)")
+            Tooltip(self._btn_demote, "Demote selection one level (Alt+Right)")
+            self._tip_undo = Tooltip(self._btn_undo, "Nothing to undo")
+            self._tip_redo = Tooltip(self._btn_redo, "Nothing to redo")
         except Exception:
             pass
 
         # Compact row layout.
         self._btn_up.grid(row=0, column=0, padx=(0, 4), pady=2)
-        self._btn_down.grid(row=0, column=1, padx=(0, 0), pady=2)
+        self._btn_down.grid(row=0, column=1, padx=(0, 4), pady=2)
+        self._btn_promote.grid(row=0, column=2, padx=(0, 4), pady=2)
+        self._btn_demote.grid(row=0, column=3, padx=(0, 0), pady=2)
+        self._btn_undo.grid(row=0, column=4, padx=(12, 4), pady=2)
+        self._btn_redo.grid(row=0, column=5, padx=(0, 0), pady=2)
+        self.set_history_state(None, None)
 
         # Prevent column expansion for compactness.
-        for idx in range(2):
+        for idx in range(6):
             self.grid_columnconfigure(idx, weight=0)
         self.grid_rowconfigure(0, weight=0)
 
-    def _make_handler(self, direction: Literal["up", "down"]) -> Callable[[], None]:
+    def _make_handler(self, direction: Literal["up", "down", "promote", "demote"]) -> Callable[[], None]:
         """Create a safe event handler that invokes the on_move callback if provided."""
         def _handler() -> None:
             if self._on_move is None:
@@ -75,6 +99,37 @@ class ToolbarWidget(ttk.Frame):
 
         return _handler
 
+    @staticmethod
+    def _make_safe(callback: Optional[Callable[[], None]]) -> Callable[[], None]:
+        def _handler() -> None:
+            if callback is None:
+                return
+            try:
+                callback()
+            except Exception:
+                pass
+
+        return _handler
+
+    def set_history_state(self, undo_label: Optional[str], redo_label: Optional[str]) -> None:
+        """Enable Undo/Redo when an operation is available and name it in the tooltip.
+
+        Parameters
+        ----------
+        undo_label, redo_label : Optional[str]
+            Label of the operation that would be undone/redone; None disables the button.
+        """
+        for btn, tip, label, verb, key in (
+            (self._btn_undo, self._tip_undo, undo_label, "Undo", "Ctrl+Z"),
+            (self._btn_redo, self._tip_redo, redo_label, "Redo", "Ctrl+Y"),
+        ):
+            try:
+                btn.configure(state="normal" if label else "disabled")
+                if tip is not None:
+                    tip.text = f"{verb} {label} ({key})" if label else f"Nothing to {verb.lower()}"
+            except Exception:
+                pass
+
     def enable_buttons(self, enabled: bool) -> None:
         """Enable or disable all toolbar buttons at once.
 
@@ -88,7 +143,7 @@ class ToolbarWidget(ttk.Frame):
         Safe to call regardless of the widget realization state.
         """
         state_value = "normal" if enabled else "disabled"
-        for btn in (self._btn_up, self._btn_down):
+        for btn in (self._btn_up, self._btn_down, self._btn_promote, self._btn_demote):
             try:
                 btn.configure(state=state_value)
             except Exception:
@@ -0,0 +1,347 @@
+from __future__ import annotations
+
+"""Watch-folder mode (``python -m orlando_toolkit watch``).
+
+Authors drop finished documents into shared folders; this mode converts
+them unattended. Every ``poll_seconds`` each folder of the ``watch``
+section of ``pipeline.yml`` is scanned for documents the toolkit converts.
+A file is taken once it has not changed for ``settle_seconds`` (a copy over
+the network may still be running) and is converted with the profile bound
+to its folder; the package goes to the folder's ``output``.
+
+- converted sources move to the ``processed`` sub-folder, so a file dropped
+  again under the same name is converted again;
+- a failed conversion is retried ``retries`` times, ``retry_delay_seconds``
+  apart, then the source moves to the ``quarantine`` sub-folder with a
+  ``<name>.error.json`` explaining why;
+- after each scan that handled files, a summary is logged, appended to
+  ``report_path`` and, with ``email.to`` set, mailed through ``email.smtp_host``
+  (password read from the ``password_env`` environment variable).
+
+Office lock files (``~$name.docx``) and hidden files are ignored.
+"""
+
+import json
+import logging
+import os
+import shutil
+import smtplib
+import threading
+import time
+from dataclasses import dataclass, field
+from email.message import EmailMessage
+from pathlib import Path
+from typing import Any, Callable, Dict, List, Mapping, Optional, Tuple
+
+from orlando_toolkit.core.errors import describe_error
+
+logger = logging.getLogger(__name__)
+
+__all__ = ["EmailSettings", "FolderWatcher", "WatchFolder", "WatchResult", "WatchSettings", "summary_text", "watch"]
+
+OUTCOMES = ("converted", "retrying", "quarantined")
+
+
+@dataclass(frozen=True)
+class WatchFolder:
+    path: str
+    profile: Optional[str] = None
+    output: Optional[str] = None
+
+    @property
+    def output_dir(self) -> Path:
+        return Path(self.output) if self.output else Path(self.path) / "output"
+
+
+@dataclass(frozen=True)
+class EmailSettings:
+    to: Tuple[str, ...] = ()
+    sender: str = "orlando-toolkit@localhost"
+    smtp_host: str = "localhost"
+    smtp_port: int = 25
+    use_tls: bool = False
+    username: Optional[str] = None
+    password_env: str = "ORLANDO_SMTP_PASSWORD"
+    subject: str = "Orlando Toolkit: {converted} converted, {failed} failed"
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "EmailSettings":
+        data = data or {}
+        defaults = cls()
+        to = data.get("to") or ()
+        return cls(
+            to=tuple(str(a) for a in ([to] if isinstance(to, str) else to) if a),
+            sender=str(data.get("from") or defaults.sender),
+            smtp_host=str(data.get("smtp_host") or defaults.smtp_host),
+            smtp_port=int(data.get("smtp_port", defaults.smtp_port)),
+            use_tls=bool(data.get("use_tls", defaults.use_tls)),
+            username=str(data["username"]) if data.get("username") else None,
+            password_env=str(data.get("password_env") or defaults.password_env),
+            subject=str(data.get("subject") or defaults.subject),
+        )
+
+
+@dataclass(frozen=True)
+class WatchSettings:
+    folders: Tuple[WatchFolder, ...] = ()
+    poll_seconds: float = 10.0
+    settle_seconds: float = 5.0
+    retries: int = 2
+    retry_delay_seconds: float = 60.0
+    processed_dir: str = "processed"
+    quarantine_dir: str = "quarantine"
+    report_path: Optional[str] = None
+    email: EmailSettings = field(default_factory=EmailSettings)
+
+    @classmethod
+    def from_mapping(cls, data: Optional[Mapping[str, Any]]) -> "WatchSettings":
+        data = data or {}
+        defaults = cls()
+        try:
+            folders = []
+            for item in data.get("folders") or ():
+                item = {"path": item} if isinstance(item, str) else dict(item)
+                if item.get("path"):
+                    folders.append(WatchFolder(str(item["path"]), item.get("profile") or None,
+                                               str(item["output"]) if item.get("output") else None))
+            return cls(
+                folders=tuple(folders),
+                poll_seconds=max(1.0, float(data.get("poll_seconds", defaults.poll_seconds))),
+                settle_seconds=max(0.0, float(data.get("settle_seconds", defaults.settle_seconds))),
+                retries=max(0, int(data.get("retries", defaults.retries))),
+                retry_delay_seconds=max(0.0, float(data.get("retry_delay_seconds", defaults.retry_delay_seconds))),
+                processed_dir=str(data.get("processed_dir") or defaults.processed_dir),
+                quarantine_dir=str(data.get("quarantine_dir") or defaults.quarantine_dir),
+                report_path=str(data["report_path"]) if data.get("report_path") else None,
+                email=EmailSettings.from_mapping(data.get("email")),
+            )
+        except (TypeError, ValueError) as exc:
+            logger.warning("Invalid watch settings (%s); using defaults", exc)
+            return defaults
+
+    @classmethod
+    def from_config(cls) -> "WatchSettings":
+        try:
+            from orlando_toolkit.config import ConfigManager
+            return cls.from_mapping((ConfigManager().get_pipeline_config() or {}).get("watch"))
+        except Exception as exc:
+            logger.debug("Pipeline config unavailable, using default watch settings: %s", exc)
+            return cls()
+
+
+@dataclass
+class WatchResult:
+    """What happened to one source file during a scan (:data:`OUTCOMES`)."""
+
+    source: Path
+    outcome: str
+    attempts: int = 1
+    package: Optional[Path] = None
+    summary: Optional[str] = None
+    error: Optional[Dict[str, Any]] = None
+
+    def to_dict(self) -> Dict[str, Any]:
+        return {"source": str(self.source), "outcome": self.outcome, "attempts": self.attempts,
+                "package": str(self.package) if self.package else None, "summary": self.summary,
+                "error": self.error}
+
+
+def summary_text(results: List[WatchResult]) -> str:
+    """Plain-text report of one scan, one line per file."""
+    counts = {outcome: sum(1 for r in results if r.outcome == outcome) for outcome in OUTCOMES}
+    lines = [f"{counts['converted']} converted, {counts['retrying']} to retry, "
+             f"{counts['quarantined']} quarantined ({time.strftime('%Y-%m-%d %H:%M:%S')})", ""]
+    for result in results:
+        if result.outcome == "converted":
+            lines.append(f"OK     {result.source.name} -> {result.package} ({result.summary})")
+        else:
+            error = result.error or {}
+            label = "RETRY " if result.outcome == "retrying" else "FAILED"
+            lines.append(f"{label} {result.source.name} (attempt {result.attempts}): "
+                         f"[{error.get('code')}] {error.get('message')}")
+            if result.outcome == "quarantined" and error.get("hint"):
+                lines.append(f"       {error['hint']}")
+    return "\n".join(lines)
+
+
+def _unique(path: Path) -> Path:
+    if not path.exists():
+        return path
+    for n in range(2, 10000):
+        candidate = path.with_name(f"{path.stem} ({n}){path.suffix}")
+        if not candidate.exists():
+            return candidate
+    raise OSError(f"No free name for {path}")
+
+
+class FolderWatcher:
+    """Converts the documents dropped into the watched folders.
+
+    *toolkit* is the :class:`orlando_toolkit.api.Toolkit` running the
+    conversions; one is created with the plugins active in the application
+    (*plugins*) when omitted. *send_mail* replaces the SMTP delivery of the
+    summary (it receives the ``EmailMessage``).
+    """
+
+    def __init__(self, settings: Optional[WatchSettings] = None, *, toolkit: Any = None, plugins: Any = True,
+                 send_mail: Optional[Callable[[EmailMessage], None]] = None,
+                 clock: Callable[[], float] = time.time) -> None:
+        self.settings = settings or WatchSettings.from_config()
+        for folder in self.settings.folders:
+            if folder.output_dir.resolve() == Path(folder.path).resolve():
+                raise ValueError(f"The output of {folder.path} must not be the watched folder itself")
+        if toolkit is None:
+            from orlando_toolkit.api import Toolkit
+            toolkit = Toolkit(plugins=plugins)
+        self.toolkit = toolkit
+        self._send_mail = send_mail or self._smtp
+        self._clock = clock
+        # source path -> (failed attempts, time of the next attempt)
+        self._failures: Dict[Path, Tuple[int, float]] = {}
+
+    # -- scanning ----------------------------------------------------------
+    def pending(self, folder: WatchFolder) -> List[Path]:
+        """Documents of *folder* ready to convert, oldest first."""
+        root = Path(folder.path)
+        now = self._clock()
+        ready: List[Tuple[float, Path]] = []
+        try:
+            entries = list(root.iterdir())
+        except OSError as exc:
+            logger.warning("Cannot read watched folder %s: %s", root, exc)
+            return []
+        for path in entries:
+            if not path.is_file() or path.name.startswith(("~$", ".")):
+                continue
+            if not self.toolkit.service.can_handle_file(path.name):
+                continue
+            try:
+                modified = path.stat().st_mtime
+            except OSError:
+                continue
+            if now - modified < self.settings.settle_seconds:
+                continue
+            attempts, next_try = self._failures.get(path, (0, 0.0))
+            if attempts and now < next_try:
+                continue
+            ready.append((modified, path))
+        return [path for _modified, path in sorted(ready)]
+
+    def scan(self) -> List[WatchResult]:
+        """Convert every ready document once; reports the results when there were any."""
+        results: List[WatchResult] = []
+        for folder in self.settings.folders:
+            for source in self.pending(folder):
+                results.append(self.convert(source, folder))
+        if results:
+            self.report(results)
+        return results
+
+    def convert(self, source: Path, folder: WatchFolder) -> WatchResult:
+        """Convert *source* with the profile of *folder*, then file it as processed or failed."""
+        from orlando_toolkit.api import ConversionError
+        from orlando_toolkit.options import build_options, with_output_profile
+
+        attempts = self._failures.get(source, (0, 0.0))[0] + 1
+        logger.info("Watch: converting %s (attempt %d)", source, attempts)
+        try:
+            options = build_options(*((with_output_profile(folder.profile),) if folder.profile else ()))
+            result = self.toolkit.convert(source, options)
+            folder.output_dir.mkdir(parents=True, exist_ok=True)
+            package = result.write_archive(folder.output_dir / f"{source.stem}.zip")
+        except ConversionError as exc:
+            error = exc.info.to_dict()
+        except Exception as exc:
+            logger.error("Watch: %s failed", source, exc_info=True)
+            error = describe_error(exc).to_dict()
+        else:
+            self._failures.pop(source, None)
+            self._file(source, folder, self.settings.processed_dir)
+            return WatchResult(source, "converted", attempts, package, result.report.summary())
+
+        if attempts <= self.settings.retries:
+            self._failures[source] = (attempts, self._clock() + self.settings.retry_delay_seconds)
+            return WatchResult(source, "retrying", attempts, error=error)
+        self._failures.pop(source, None)
+        moved = self._file(source, folder, self.settings.quarantine_dir)
+        if moved is not None:
+            note = moved.with_name(moved.name + ".error.json")
+            note.write_text(json.dumps({"source": source.name, "attempts": attempts, "error": error},
+                                       ensure_ascii=False, indent=2), encoding="utf-8")
+        return WatchResult(source, "quarantined", attempts, error=error)
+
+    def _file(self, source: Path, folder: WatchFolder, name: str) -> Optional[Path]:
+        target = _unique(Path(folder.path) / name / source.name)
+        try:
+            target.parent.mkdir(parents=True, exist_ok=True)
+            shutil.move(str(source), str(target))
+        except OSError as exc:
+            logger.error("Watch: cannot move %s to %s: %s", source, target.parent, exc)
+            return None
+        return target
+
+    # -- reporting ---------------------------------------------------------
+    def report(self, results: List[WatchResult]) -> None:
+        """Log the summary of *results*, append it to ``report_path`` and mail it."""
+        text = summary_text(results)
+        logger.info("Watch summary:\n%s", text)
+        if self.settings.report_path:
+            path = Path(self.settings.report_path)
+            try:
+                path.parent.mkdir(parents=True, exist_ok=True)
+                with path.open("a", encoding="utf-8") as handle:
+                    for result in results:
+                        handle.write(json.dumps({"time": time.strftime("%Y-%m-%dT%H:%M:%S"), **result.to_dict()},
+                                                ensure_ascii=False) + "\n")
+            except OSError as exc:
+                logger.error("Watch: cannot write the report %s: %s", path, exc)
+        email = self.settings.email
+        if not email.to:
+            return
+        message = EmailMessage()
+        message["Subject"] = email.subject.format(
+            converted=sum(1 for r in results if r.outcome == "converted"),
+            failed=sum(1 for r in results if r.outcome == "quarantined"))
+        message["From"] = email.sender
+        message["To"] = ", ".join(email.to)
+        message.set_content(text)
+        try:
+            self._send_mail(message)
+        except (OSError, smtplib.SMTPException) as exc:
+            logger.error("Watch: cannot send the summary to %s: %s", message["To"], exc)
+
+    def _smtp(self, message: EmailMessage) -> None:
+        email = self.settings.email
+        with smtplib.SMTP(email.smtp_host, email.smtp_port, timeout=60) as smtp:
+            if email.use_tls:
+                smtp.starttls()
+            if email.username:
+                smtp.login(email.username, os.environ.get(email.password_env, ""))
+            smtp.send_message(message)
+
+    # -- loop --------------------------------------------------------------
+    def run(self, stop: Optional[threading.Event] = None) -> None:
+        """Scan every ``poll_seconds`` until *stop* is set."""
+        stop = stop or threading.Event()
+        while not stop.is_set():
+            try:
+                self.scan()
+            except Exception:
+                logger.exception("Watch: scan failed")
+            stop.wait(self.settings.poll_seconds)
+
+
+def watch(settings: Optional[WatchSettings] = None, *, plugins: Any = True, once: bool = False) -> List[WatchResult]:
+    """Run the watcher until interrupted (one scan with *once*, returning its results)."""
+    settings = settings or WatchSettings.from_config()
+    watcher = FolderWatcher(settings, plugins=plugins)
+    if once:
+        return watcher.scan()
+    folders = ", ".join(f.path for f in settings.folders)
+    logger.info("Watching %s every %gs", folders, settings.poll_seconds)
+    print(f"Watching {folders} every {settings.poll_seconds:g}s (Ctrl+C to stop)")
+    try:
+        watcher.run()
+    except KeyboardInterrupt:
+        pass
+    return []
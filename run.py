@@ -12,13 +12,33 @@ import logging
 from orlando_toolkit.logging_config import setup_logging
 from orlando_toolkit.app import OrlandoToolkit
 
+def _enable_high_dpi() -> None:
+    """Ask Windows for real pixels so text is sharp on high-DPI screens (no-op elsewhere)."""
+    if sys.platform != "win32":
+        return
+    try:
+        import ctypes
+        ctypes.windll.shcore.SetProcessDpiAwareness(1)  # per-monitor aware
+    except Exception:
+        try:
+            ctypes.windll.user32.SetProcessDPIAware()
+        except Exception:
+            pass
+
+
 def main():
     """
     Configure logging, main window, and launch application.
     """
     setup_logging()
+    _enable_high_dpi()
     
     root = tk.Tk()
+    # Size fonts in points against the real screen density (72 points per inch)
+    try:
+        root.tk.call("tk", "scaling", root.winfo_fpixels("1i") / 72.0)
+    except Exception:
+        pass
     root.title("Orlando Toolkit")
     # Desired window size (slightly larger to accommodate inline metadata + summary)
     window_width, window_height = 400, 620
@@ -11,6 +11,7 @@ import shutil
 import logging
 import json
 import time
+import zipfile
 from pathlib import Path
 from typing import Dict, Any, Optional, List
 from unittest.mock import Mock, MagicMock
@@ -29,6 +30,7 @@ from orlando_toolkit.core.plugins.metadata import PluginMetadata
 from orlando_toolkit.core.plugins.interfaces import DocumentHandler
 from orlando_toolkit.core.services import ConversionService
 from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.word_source import PKG_REL_NS, W_NS
 
 # Configure test logging
 logging.basicConfig(
@@ -57,6 +59,30 @@ def temp_dir():
     shutil.rmtree(temp_path, ignore_errors=True)
 
 
+@pytest.fixture
+def docx_factory():
+    """Writes minimal Word packages: ``docx_factory(path, body, ...)`` returns *path*.
+
+    *body* goes inside ``w:body``; *namespaces* replaces the ``xmlns`` declarations
+    of ``w:document`` (``w`` only by default). *rels* maps relationship ids of the
+    main document to targets, targets with a scheme being external, and *parts*
+    maps further part names to their text or bytes.
+    """
+    def make(path, body="", *, namespaces=f'xmlns:w="{W_NS}"', rels=None, parts=None):
+        with zipfile.ZipFile(path, "w") as zf:
+            zf.writestr("word/document.xml", f"<w:document {namespaces}><w:body>{body}</w:body></w:document>")
+            if rels is not None:
+                zf.writestr("word/_rels/document.xml.rels", f'<Relationships xmlns="{PKG_REL_NS}">' + "".join(
+                    f'<Relationship Id="{rid}" Target="{target}"'
+                    + (' TargetMode="External"/>' if "://" in target else "/>") for rid, target in rels.items())
+                    + "</Relationships>")
+            for name, data in (parts or {}).items():
+                zf.writestr(name, data)
+        return path
+
+    return make
+
+
 @pytest.fixture
 def mock_service_registry():
     """Creates a fresh ServiceRegistry instance for testing."""
@@ -284,7 +310,6 @@ def sample_dita_package(test_data_dir):
     (package_dir / "sample_map.ditamap").write_text(map_content, encoding='utf-8')
     
     # Create zip package
-    import zipfile
     zip_path = test_data_dir / "sample_dita_package.zip"
     with zipfile.ZipFile(zip_path, 'w') as zf:
         for file_path in package_dir.rglob('*'):
@@ -304,6 +329,15 @@ def reset_singletons():
         delattr(loader_module, '_user_plugins_dir')
 
 
+@pytest.fixture(autouse=True)
+def isolated_history(tmp_path):
+    """Record conversion history under the test's temporary directory."""
+    from orlando_toolkit.core.history import HistoryStore, set_history_store
+    set_history_store(HistoryStore(tmp_path / "history"))
+    yield
+    set_history_store(None)
+
+
 # Test markers
 pytest.mark.integration = pytest.mark.integration
 pytest.mark.performance = pytest.mark.performance
@@ -0,0 +1,86 @@
+import pytest
+from lxml import etree as ET
+
+from orlando_toolkit.core.accessibility import apply_fix, check_accessibility, contrast_ratio
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.services.structure_editing_service import StructureEditingService
+from orlando_toolkit.core.services.undo_service import UndoService
+from orlando_toolkit.ui.controllers.structure_controller import StructureController
+
+
+def _context():
+    topics = {
+        "a.dita": "<concept id='a'><title>Pump</title><conbody>"
+                  "<fig><title>Pump housing</title><image href='../media/housing.png'/></fig>"
+                  "<image href='../media/wiring_diagram.png'/><image href='../media/rule.png'><alt/></image>"
+                  "<p>Warning: <ph outputclass='color-ffff00 keep'>hot surface</ph> and "
+                  "<ph outputclass='highlight-yellow'>marked</ph> <ph outputclass='color-c0c0c0'>"
+                  "<b outputclass='highlight-black'>inverted</b></ph>.</p>"
+                  "<table><tgroup cols='1'><tbody><row><entry>Part</entry></row><row><entry>Seal</entry></row>"
+                  "</tbody></tgroup></table></conbody></concept>",
+        "b.dita": "<concept id='b'><title> </title><conbody><p>Check the seal before every start of the pump.</p>"
+                  "<section><title/><p>Torque values</p></section>"
+                  "<simpletable><strow><stentry>Bolt</stentry></strow><strow><stentry>M8</stentry></strow>"
+                  "</simpletable></conbody></concept>",
+    }
+    root = ET.fromstring("<map><topicref href='topics/a.dita'><topicmeta><navtitle>Pump</navtitle></topicmeta>"
+                         "</topicref><topicref href='topics/b.dita'><topicmeta><navtitle/></topicmeta></topicref>"
+                         "</map>")
+    return DitaContext(ditamap_root=root, topics={k: ET.fromstring(v) for k, v in topics.items()}, metadata={})
+
+
+def _summary(issues):
+    return [(i.kind, i.topic, i.target) for i in issues]
+
+
+def test_audit_lists_alt_text_headers_titles_and_contrast():
+    ctx = _context()
+    issues = check_accessibility(ctx)
+    assert _summary(issues) == [
+        ("missing_alt", "a.dita", "housing.png"),
+        ("missing_alt", "a.dita", "wiring_diagram.png"),
+        ("low_contrast", "a.dita", "hot surface"),
+        ("table_header", "a.dita", "table"),
+        ("empty_title", "b.dita", "b.dita"),
+        ("empty_title", "b.dita", "section"),
+        ("table_header", "b.dita", "simpletable"),
+    ]
+    assert issues[2].message == "Text contrast 1.1:1 is below 4.5:1"
+    assert round(contrast_ratio("000000", "ffffff"), 1) == 21.0
+    assert issues[0].fixes == ("add_alt", "decorative") and issues[3].fixes == ("mark_header",)
+    with pytest.raises(ValueError):
+        apply_fix(ctx, issues[3], "set_title", "Parts")
+    assert len(check_accessibility(ctx, min_contrast=1.0)) == 6
+
+
+def test_quick_fixes_are_undoable_edits():
+    ctx = _context()
+    ctrl = StructureController(ctx, StructureEditingService(), UndoService(), None)
+    ctrl.start_history()
+
+    def _issue(kind, target):
+        return next(i for i in ctrl.get_accessibility_issues() if i.kind == kind and i.target == target)
+
+    assert ctrl.handle_accessibility_fix(_issue("missing_alt", "housing.png"), "add_alt").success
+    assert ctrl.handle_accessibility_fix(_issue("missing_alt", "wiring_diagram.png"), "add_alt").success
+    assert ctrl.handle_accessibility_fix(_issue("low_contrast", "hot surface"), "remove_color").success
+    assert ctrl.handle_accessibility_fix(_issue("table_header", "table"), "mark_header").success
+    assert ctrl.handle_accessibility_fix(_issue("table_header", "simpletable"), "mark_header").success
+    assert ctrl.handle_accessibility_fix(_issue("empty_title", "section"), "set_title", "Torque").success
+    assert ctrl.handle_accessibility_fix(_issue("empty_title", "b.dita"), "set_title").success
+
+    ctx = ctrl.context
+    a, b = ctx.topics["a.dita"], ctx.topics["b.dita"]
+    assert [im.findtext("alt") for im in a.iter("image")] == ["Pump housing", "Wiring diagram", ""]
+    assert a.find("conbody/p/ph").get("outputclass") == "keep"
+    tgroup = a.find("conbody/table/tgroup")
+    assert [c.tag for c in tgroup] == ["thead", "tbody"] and tgroup.findtext("thead/row/entry") == "Part"
+    assert b.find("conbody/simpletable")[0].tag == "sthead"
+    assert b.findtext("conbody/section/title") == "Torque"
+    assert b.findtext("title") == "Check the seal before every start of the"
+    assert ctx.ditamap_root.findtext("topicref[2]/topicmeta/navtitle") == b.findtext("title")
+    assert check_accessibility(ctx) == []
+
+    assert ctrl.undo_label() == "Set title"
+    assert ctrl.undo()
+    assert _summary(ctrl.get_accessibility_issues()) == [("empty_title", "b.dita", "b.dita")]
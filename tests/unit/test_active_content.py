@@ -0,0 +1,89 @@
+import zipfile
+
+import pytest
+
+from orlando_toolkit.core.active_content import (
+    ActiveContentPolicy,
+    ActiveContentRefusedError,
+    inspect_container,
+    record_decisions,
+    sanitize_container,
+)
+from orlando_toolkit.core.models import ConversionReport
+
+_CONTENT_TYPES = (
+    '<?xml version="1.0" encoding="UTF-8"?>'
+    '<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">'
+    '<Default Extension="xml" ContentType="application/xml"/>'
+    '<Override PartName="/word/document.xml" ContentType="application/vnd.ms-word.document.macroEnabled.main+xml"/>'
+    '<Override PartName="/word/vbaProject.bin" ContentType="application/vnd.ms-office.vbaProject"/>'
+    '<Override PartName="/word/activeX/activeX1.xml" ContentType="application/vnd.ms-office.activeX+xml"/>'
+    '</Types>'
+)
+_RELS = (
+    '<?xml version="1.0" encoding="UTF-8"?>'
+    '<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">'
+    '<Relationship Id="rId1" Type="vba" Target="vbaProject.bin"/>'
+    '<Relationship Id="rId2" Type="control" Target="activeX/activeX1.xml"/>'
+    '<Relationship Id="rId3" Type="oleObject" Target="embeddings/oleObject1.bin"/>'
+    '<Relationship Id="rId4" Type="styles" Target="styles.xml"/>'
+    '</Relationships>'
+)
+
+
+def _docm(tmp_path):
+    path = tmp_path / "manual.docm"
+    with zipfile.ZipFile(path, "w") as z:
+        z.writestr("[Content_Types].xml", _CONTENT_TYPES)
+        z.writestr("word/document.xml", "<document/>")
+        z.writestr("word/styles.xml", "<styles/>")
+        z.writestr("word/_rels/document.xml.rels", _RELS)
+        z.writestr("word/vbaProject.bin", b"\x00VBA")
+        z.writestr("word/activeX/activeX1.xml", "<ocx/>")
+        z.writestr("word/embeddings/oleObject1.bin", b"\x00OLE")
+    return path
+
+
+def test_inspect_finds_macros_activex_and_ole(tmp_path):
+    findings = inspect_container(_docm(tmp_path))
+    assert findings.kinds() == ["macros", "activex", "ole_objects"]
+    assert findings.parts["macros"] == ["word/vbaProject.bin"]
+
+
+def test_strip_writes_macro_free_copy_and_reports(tmp_path):
+    source = _docm(tmp_path)
+    findings = inspect_container(source)
+    out = tmp_path / "out"
+    out.mkdir()
+    policy = ActiveContentPolicy()
+    clean = sanitize_container(source, findings, policy, out)
+    assert clean.name == "manual.docx"
+    with zipfile.ZipFile(clean) as z:
+        names = set(z.namelist())
+        rels = z.read("word/_rels/document.xml.rels").decode()
+        types = z.read("[Content_Types].xml").decode()
+    assert "word/vbaProject.bin" not in names and "word/activeX/activeX1.xml" not in names
+    assert "word/embeddings/oleObject1.bin" in names  # OLE objects are allowed by default
+    assert "vbaProject" not in rels and "activeX" not in rels and "styles.xml" in rels
+    assert "macroEnabled" not in types and "vbaProject" not in types
+
+    report = ConversionReport()
+    record_decisions(report, findings, policy)
+    assert report.count("warning", "active_content") == 2
+    assert report.count("info", "active_content") == 1
+
+
+def test_refuse_policy_aborts(tmp_path):
+    source = _docm(tmp_path)
+    policy = ActiveContentPolicy.from_mapping({"macros": "refuse"})
+    with pytest.raises(ActiveContentRefusedError, match="macros"):
+        sanitize_container(source, inspect_container(source), policy, tmp_path)
+
+
+def test_plain_docx_is_left_alone(tmp_path):
+    path = tmp_path / "plain.docx"
+    with zipfile.ZipFile(path, "w") as z:
+        z.writestr("word/document.xml", "<document/>")
+    findings = inspect_container(path)
+    assert not findings
+    assert sanitize_container(path, findings, ActiveContentPolicy(), tmp_path) == path
@@ -0,0 +1,69 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.alt_text import (
+    image_alt_text,
+    images_missing_alt,
+    read_word_alt_texts,
+    restore_word_alt_text,
+    set_image_alt_text,
+)
+from orlando_toolkit.core.models import DitaContext
+
+W = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+WP = "http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing"
+A = "http://schemas.openxmlformats.org/drawingml/2006/main"
+R = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+ADEC = "http://schemas.microsoft.com/office/drawing/2017/decorative"
+NS = f'xmlns:w="{W}" xmlns:wp="{WP}" xmlns:a="{A}" xmlns:r="{R}" xmlns:adec="{ADEC}"'
+
+
+def _drawing(rid, descr="", title="", decorative=False):
+    ext = '<a:extLst><a:ext><adec:decorative val="1"/></a:ext></a:extLst>' if decorative else ""
+    return (f'<w:p><w:r><w:drawing><wp:inline><wp:docPr id="1" name="Picture" descr="{descr}" title="{title}">'
+            f'{ext}</wp:docPr><a:graphic><a:graphicData><a:blip r:embed="{rid}"/></a:graphicData></a:graphic>'
+            f'</wp:inline></w:drawing></w:r></w:p>')
+
+
+def _context(images, body):
+    topic = ET.fromstring(f"<concept id='c'><title>Pump</title><conbody>{body}</conbody></concept>")
+    return DitaContext(ditamap_root=ET.fromstring("<map><topicref href='topics/c.dita'/></map>"),
+                       topics={"c.dita": topic}, images=images)
+
+
+def test_word_alt_text_becomes_image_alt(tmp_path, docx_factory):
+    body = (_drawing("r1", descr="Pump  front view") + _drawing("r2", title="Valve") + _drawing("r3", decorative=True)
+            + _drawing("r4", descr="Wiring diagram") + _drawing("r1", descr="Second use"))
+    media = {"r1": "image1.png", "r2": "image2.png", "r3": "image3.png", "r4": "image4.emf"}
+    path = docx_factory(tmp_path / "pump.docx", body, namespaces=NS,
+                        rels={**{rid: f"media/{name}" for rid, name in media.items()}, "rLink": "https://example.com"},
+                        parts={f"word/media/{name}": f"bytes{i}" for i, name in enumerate(media.values())})
+    drawings = read_word_alt_texts(path)
+    assert [(d.media, d.description, d.title, d.decorative) for d in drawings][:3] == [
+        ("word/media/image1.png", "Pump  front view", "", False), ("word/media/image2.png", "", "Valve", False),
+        ("word/media/image3.png", "", "", True)]
+
+    # The plugin renamed the files; image4 was re-encoded so only its position identifies it
+    ctx = _context({"pump.png": b"bytes0", "valve.png": b"bytes1", "line.png": b"bytes2", "wiring.svg": b"<svg/>"},
+                   "<image href='../media/pump.png'/><image href='../media/valve.png'><alt>Kept</alt></image>"
+                   "<image href='../media/line.png'/><image href='../media/wiring.svg'/>"
+                   "<image href='../media/pump.png'/>")
+    assert restore_word_alt_text(path, ctx) == 3
+    alts = [(i.get("href")[9:], i.findtext("alt")) for i in ctx.topics["c.dita"].iter("image")]
+    assert alts == [("pump.png", "Pump front view"), ("valve.png", "Kept"), ("line.png", ""),
+                    ("wiring.svg", "Wiring diagram"), ("pump.png", "Second use")]
+    assert ctx.topics["c.dita"].find("conbody/image[3]/alt") is not None  # decorative: empty <alt/>
+    assert images_missing_alt(ctx) == []
+    assert [e.message for e in ctx.report.entries] == ["Alt text restored for 3 image(s)"]
+
+
+def test_alt_text_edits_apply_to_every_use_and_missing_images_are_reported(tmp_path):
+    ctx = _context({"a.png": b"a", "b.png": b"b"},
+                   "<image href='../media/a.png'/><p><image href='../media/b.png'/><image href='../media/a.png'/></p>")
+    restore_word_alt_text(tmp_path / "missing.docx", ctx, {"use_title": False})
+    assert ctx.report.entries[-1].message == "2 image(s) have no alt text: a.png, b.png"
+    assert restore_word_alt_text(tmp_path / "missing.docx", ctx, {"enabled": False}) == 0
+
+    assert set_image_alt_text(ctx, "a.png", "  Pump\n housing ") == 2
+    assert image_alt_text(ctx, "a.png") == "Pump housing" and images_missing_alt(ctx) == ["b.png"]
+    assert set_image_alt_text(ctx, "a.png", "") == 2 and images_missing_alt(ctx) == ["a.png", "b.png"]
+    assert set_image_alt_text(ctx, "unused.png", "x") == 0
@@ -0,0 +1,88 @@
+import zipfile
+
+import pytest
+
+from orlando_toolkit import ConversionError, convert
+from orlando_toolkit.core.cancellation import CancellationToken, OperationCancelledError
+from orlando_toolkit.core.plugins.exceptions import UnsupportedFormatError
+
+_MAP = '<?xml version="1.0"?><map><title>Guide</title><topicref href="topics/intro.dita"/></map>'
+_TOPIC = ('<?xml version="1.0"?><concept id="intro"><title>Intro</title>'
+          '<conbody><p>Hello</p></conbody></concept>')
+
+
+def _package(tmp_path):
+    path = tmp_path / "guide.zip"
+    with zipfile.ZipFile(path, "w") as z:
+        z.writestr("DATA/guide.ditamap", _MAP)
+        z.writestr("DATA/topics/intro.dita", _TOPIC)
+    return path
+
+
+def test_convert_and_write_archive(tmp_path):
+    result = convert(_package(tmp_path), plugins=False)
+    assert list(result.context.topics) == ["intro.dita"]
+    assert result.metadata["source_type"] == "dita_package"
+    assert not result.partial and result.report.count("error") == 0
+
+    out = result.write_archive(tmp_path / "out" / "guide.zip")
+    result.write_archive(tmp_path / "again.zip")
+    names = zipfile.ZipFile(out).namelist()
+    assert any(n.startswith("DATA/topics/") and n.endswith(".dita") for n in names)
+    assert zipfile.ZipFile(tmp_path / "again.zip").namelist() == names
+
+
+def test_failures_raise_conversion_error_with_cause(tmp_path):
+    source = tmp_path / "notes.docx"
+    source.write_bytes(b"not a zip")
+    with pytest.raises(ConversionError) as info:
+        convert(source, plugins=False)
+    assert isinstance(info.value.cause, UnsupportedFormatError)
+
+    token = CancellationToken()
+    token.cancel()
+    with pytest.raises(OperationCancelledError):
+        convert(_package(tmp_path), plugins=False, cancel_token=token)
+
+
+def test_structure_edits_and_validation(tmp_path, monkeypatch):
+    from orlando_toolkit import API_VERSION, Package, StructureError
+    from orlando_toolkit.core.services.publishing_service import PublishingService
+
+    monkeypatch.setattr(PublishingService, "locate", lambda self: None)
+    path = tmp_path / "guide.zip"
+    with zipfile.ZipFile(path, "w") as z:
+        z.writestr("DATA/guide.ditamap", '<?xml version="1.0"?><map><title>Guide</title>'
+                   '<topicref href="topics/intro.dita"/><topicref href="topics/usage.dita"/></map>')
+        z.writestr("DATA/topics/intro.dita", _TOPIC)
+        z.writestr("DATA/topics/usage.dita", '<?xml version="1.0"?><concept id="usage"><title>Usage</title>'
+                   '<conbody><p>Press start.</p></conbody></concept>')
+    package = convert(path, plugins=False)
+    assert isinstance(package, Package) and API_VERSION.split(".")[0] == "1"
+    assert [(t["id"], t["title"], t["level"]) for t in package.topics()] == [
+        ("intro.dita", "Intro", 1), ("usage.dita", "Usage", 1)]
+
+    package.rename_topic("usage.dita", "Operation")
+    package.move_topic("usage.dita", "up")
+    assert [t["title"] for t in package.topics()] == ["Operation", "Intro"]
+    with pytest.raises(StructureError):
+        package.rename_topic("missing.dita", "Nothing")
+    with pytest.raises(ValueError):
+        package.move_topic("intro.dita", "sideways")
+
+    package.merge_topics(["intro.dita"], into="usage.dita")
+    assert [t["id"] for t in package.topics()] == ["usage.dita"]
+    report = package.validate()
+    assert report.ok, report.issues
+    assert zipfile.ZipFile(package.write_archive(tmp_path / "out.zip")).namelist()
+
+
+def test_profile_names_are_accepted_as_options(monkeypatch):
+    from orlando_toolkit import options as option_functions
+    from orlando_toolkit.options import OutputProfile, build_options
+
+    profile = OutputProfile(name="stable", metadata={"revision_number": "3"})
+    monkeypatch.setattr(option_functions, "get_output_profile", lambda name: profile)
+    options = build_options("stable", {"manual_title": "Guide"}).to_metadata()
+    assert options["revision_number"] == "3" and options["manual_title"] == "Guide"
+    assert options["output_profiles"] == ["stable"]
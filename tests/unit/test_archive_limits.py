@@ -0,0 +1,66 @@
+import io
+import struct
+import zipfile
+
+import pytest
+
+from orlando_toolkit.core.archive_limits import (
+    ArchiveLimitError,
+    ArchiveLimits,
+    check_archive,
+    image_size,
+    safe_extract,
+)
+
+
+def _png(width, height):
+    return b"\x89PNG\r\n\x1a\n" + b"\x00\x00\x00\rIHDR" + struct.pack(">II", width, height) + b"\x08\x02\x00\x00\x00"
+
+
+def _zip(path, members):
+    with zipfile.ZipFile(path, "w", zipfile.ZIP_DEFLATED) as z:
+        for name, data in members.items():
+            z.writestr(name, data)
+    return path
+
+
+def test_highly_compressed_member_is_refused(tmp_path):
+    bomb = _zip(tmp_path / "bomb.zip", {"topics/a.dita": b"\x00" * (4 << 20)})
+    with pytest.raises(ArchiveLimitError, match="max_compression_ratio"):
+        check_archive(bomb, ArchiveLimits())
+    assert check_archive(bomb, ArchiveLimits(max_compression_ratio=10_000)).entries == 1
+
+
+def test_entry_count_size_and_nesting_limits(tmp_path):
+    many = _zip(tmp_path / "many.zip", {f"f{i}.txt": b"x" for i in range(5)})
+    with pytest.raises(ArchiveLimitError, match="max_entries"):
+        check_archive(many, ArchiveLimits(max_entries=4))
+    with pytest.raises(ArchiveLimitError, match="max_uncompressed_bytes"):
+        check_archive(many, ArchiveLimits(max_uncompressed_bytes=4))
+
+    inner = io.BytesIO()
+    with zipfile.ZipFile(inner, "w") as z:
+        z.writestr("doc.xml", "<a/>")
+    outer = _zip(tmp_path / "outer.zip", {"embedded.docx": inner.getvalue()})
+    assert check_archive(outer, ArchiveLimits()).nesting == 1
+    with pytest.raises(ArchiveLimitError, match="max_nesting"):
+        check_archive(outer, ArchiveLimits(max_nesting=0))
+
+
+def test_image_dimensions_from_headers_are_limited(tmp_path):
+    assert image_size(_png(640, 480)) == (640, 480)
+    assert image_size(b"GIF89a" + struct.pack("<HH", 10, 20)) == (10, 20)
+    huge = _zip(tmp_path / "pkg.zip", {"media/big.png": _png(50_000, 50_000)})
+    with pytest.raises(ArchiveLimitError, match="max_image_dimension"):
+        check_archive(huge, ArchiveLimits())
+
+
+def test_safe_extract_refuses_traversal_and_extracts_within_limits(tmp_path):
+    out = tmp_path / "out"
+    out.mkdir()
+    evil = _zip(tmp_path / "evil.zip", {"../escape.txt": b"x"})
+    with pytest.raises(ArchiveLimitError, match="unsafe path"):
+        safe_extract(evil, out, ArchiveLimits())
+    good = _zip(tmp_path / "good.zip", {"DATA/map.ditamap": b"<map/>"})
+    stats = safe_extract(good, out, ArchiveLimits())
+    assert stats.entries == 1 and (out / "DATA" / "map.ditamap").read_bytes() == b"<map/>"
@@ -0,0 +1,62 @@
+import zipfile
+
+import pytest
+
+from orlando_toolkit.core.attachments import apply_attachments, attach_file, attachment_title, remove_attachment, \
+    set_attachment_title
+from orlando_toolkit.core.bookmap import to_bookmap
+from orlando_toolkit.core.project import load_project, save_project
+from orlando_toolkit.core.services.conversion_service import ConversionService
+
+
+def _converted(tmp_path):
+    source = tmp_path / "notes.md"
+    source.write_text("# Notes\n\n## First\n\nText.\n", encoding="utf-8")
+    return ConversionService().convert(source, {"manual_code": "NOTES"})
+
+
+def test_attachments_ship_under_resources_with_resource_only_entries(tmp_path):
+    context = _converted(tmp_path)
+    checklist = tmp_path / "Daily checklist.xlsx"
+    checklist.write_bytes(b"PK-sheet")
+    assert attach_file(context, checklist) == "Daily_checklist.xlsx"
+    assert attach_file(context, "manual.pdf", data=b"%PDF-1", title="Wiring  diagrams") == "manual.pdf"
+    assert attach_file(context, "manual.pdf", data=b"%PDF-2") == "manual_2.pdf"
+    assert remove_attachment(context, "manual_2.pdf") and not remove_attachment(context, "manual_2.pdf")
+    set_attachment_title(context, "Daily_checklist.xlsx", "")
+    assert attachment_title(context, "Daily_checklist.xlsx") == "Daily checklist"
+    for name, data in (("empty.pdf", b""), ("README", b"x"), ("extra.dita", b"<topic/>")):
+        with pytest.raises(ValueError):
+            attach_file(context, name, data=data)
+
+    service = ConversionService()
+    prepared = service.prepare_package(context)
+    assert apply_attachments(prepared) == 2  # applying again does not repeat the entries
+    entries = [e for e in prepared.ditamap_root if e.get("processing-role") == "resource-only"]
+    assert [(e.get("href"), e.get("format"), e.get("scope"), e.findtext("topicmeta/navtitle")) for e in entries] == [
+        ("resources/Daily_checklist.xlsx", "xlsx", "local", "Daily checklist"),
+        ("resources/manual.pdf", "pdf", "local", "Wiring diagrams")]
+    assert prepared.ditamap_root[-1] is entries[-1]
+    book = to_bookmap(prepared.ditamap_root)
+    assert [e.get("href") for e in book.find("frontmatter")] == ["resources/Daily_checklist.xlsx",
+                                                                  "resources/manual.pdf"]
+
+    service.write_package(prepared, tmp_path / "out.zip")
+    with zipfile.ZipFile(tmp_path / "out.zip") as archive:
+        assert archive.read("DATA/resources/manual.pdf") == b"%PDF-1"
+        assert "DATA/resources/Daily_checklist.xlsx" in archive.namelist()
+
+
+def test_attachments_survive_projects_and_package_import(tmp_path):
+    context = _converted(tmp_path)
+    attach_file(context, "manual.pdf", data=b"%PDF-1", title="Wiring diagrams")
+    reopened = load_project(save_project(context, tmp_path / "notes.otkproj", record=False), record=False).context
+    assert reopened.attachments == {"manual.pdf": b"%PDF-1"}
+    assert attachment_title(reopened, "manual.pdf") == "Wiring diagrams"
+
+    service = ConversionService()
+    service.write_package(service.prepare_package(reopened), tmp_path / "out.zip")
+    imported = service.convert(tmp_path / "out.zip", {})
+    assert imported.attachments == {"manual.pdf": b"%PDF-1"}
+    assert attachment_title(imported, "manual.pdf") == "Wiring diagrams"
+    assert not any((e.get("href") or "").startswith("resources/") for e in imported.ditamap_root.iter())
@@ -0,0 +1,53 @@
+import json
+
+from orlando_toolkit.core.audit import AuditLog, JsonLinesSink, acting_as, audited_edit, set_audit_log, verify_chain
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.services.structure_editing_service import OperationResult
+
+
+def test_jsonl_records_are_hash_chained_and_tampering_is_detected(tmp_path):
+    path = tmp_path / "audit.jsonl"
+    log = AuditLog([JsonLinesSink(path)], actor="svc-docs")
+    log.record("convert", "manual.docx", detail={"topics": 3})
+    with acting_as("alice"):
+        log.record("publish", "manual.zip")
+    # A new sink instance continues the existing chain
+    AuditLog([JsonLinesSink(path)]).record("publish", "again.zip", outcome="failure")
+
+    records = [json.loads(line) for line in path.read_text(encoding="utf-8").splitlines()]
+    assert [r["actor"] for r in records[:2]] == ["svc-docs", "alice"]
+    assert records[2]["prev"] == records[1]["hash"]
+    assert verify_chain(path) == (True, None)
+
+    lines = path.read_text(encoding="utf-8").splitlines()
+    path.write_text("\n".join([lines[0], lines[2]]) + "\n", encoding="utf-8")
+    assert verify_chain(path) == (False, 2)
+
+
+def test_audited_edit_records_outermost_call_only(tmp_path):
+    path = tmp_path / "audit.jsonl"
+    set_audit_log(AuditLog([JsonLinesSink(path)], actor="bob"))
+    try:
+        class Service:
+            @audited_edit("inner")
+            def inner(self, context):
+                return OperationResult(True, "inner done")
+
+            @audited_edit("outer")
+            def outer(self, context, name):
+                self.inner(context)
+                return OperationResult(False, "nothing to do", {"name": name})
+
+        ctx = DitaContext()
+        ctx.metadata["source_file"] = "guide.docx"
+        Service().outer(ctx, "intro")
+    finally:
+        set_audit_log(None)
+    [record] = [json.loads(line) for line in path.read_text(encoding="utf-8").splitlines()]
+    assert record["action"] == "edit.outer" and record["target"] == "guide.docx"
+    assert record["outcome"] == "failure" and record["detail"]["name"] == "intro"
+
+
+def test_disabled_by_default():
+    assert not AuditLog.from_mapping({}).enabled
+    assert AuditLog().record("convert", "x.docx") is None
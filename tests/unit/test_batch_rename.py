@@ -0,0 +1,73 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.batch_rename import RenamePattern, propose_renames
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.services.structure_editing_service import StructureEditingService
+from orlando_toolkit.core.services.undo_service import UndoService
+from orlando_toolkit.ui.controllers.structure_controller import StructureController
+
+
+def _ref(name, title, children=""):
+    return (f"<topicref href='topics/{name}.dita'><topicmeta><navtitle>{title}</navtitle></topicmeta>"
+            f"{children}</topicref>")
+
+
+def _context():
+    titles = {"install": "Installation", "mount": "Step 1 - Mount the pump", "wire": "Step 2 - Wire it",
+              "notes": "Notes", "faq": "FAQ"}
+    root = ET.fromstring("<map>" + _ref("install", "Installation", _ref("mount", "Step 1 - Mount the pump")
+                                                                  + _ref("wire", "Step 2 - Wire it")
+                                                                  + _ref("notes", "Notes"))
+                         + "<topichead><topicmeta><navtitle>Help</navtitle></topicmeta>" + _ref("faq", "FAQ")
+                         + "</topichead></map>")
+    topics = {f"{n}.dita": ET.fromstring(f"<concept id='{n}'><title>{t}</title><conbody/></concept>")
+              for n, t in titles.items()}
+    return DitaContext(ditamap_root=root, topics=topics, metadata={})
+
+
+def test_templates_number_titles_with_counters_sections_and_captures():
+    ctx = _context()
+    order = ["wire.dita", "mount.dita", "faq.dita", "notes.dita"]
+    proposals = propose_renames(ctx, order, RenamePattern(template="{counter:02}. {parent}: {title}", start=10,
+                                                          step=5))
+    assert [(p.filename, p.new) for p in proposals] == [
+        ("mount.dita", "10. Installation: Step 1 - Mount the pump"),
+        ("wire.dita", "15. Installation: Step 2 - Wire it"),
+        ("notes.dita", "20. Installation: Notes"), ("faq.dita", "25. Help: FAQ")]
+
+    pattern = RenamePattern(template="{section} {action} (step {1})", match=r"^Step (\d+) - (?P<action>.+)$", pad=3)
+    proposals = propose_renames(ctx, order, pattern)
+    assert [(p.new, p.matched, p.changed) for p in proposals] == [
+        ("1.1 Mount the pump (step 1)", True, True), ("1.2 Wire it (step 2)", True, True),
+        ("Notes", False, False), ("FAQ", False, False)]
+    numbered = propose_renames(ctx, ["faq.dita"], RenamePattern(template="HLP-{counter}-{level} {title}", pad=3))
+    assert numbered[0].new == "HLP-001-2 FAQ"
+
+
+def test_invalid_templates_and_one_undoable_batch_edit():
+    assert RenamePattern(template="{title} {2}", match=r"(\d+)").validate() == \
+        "{2} needs a group in the regular expression"
+    assert RenamePattern(template="{chapter}").validate() == "Unknown placeholder {chapter}"
+    assert RenamePattern(template="{title}", match="(").validate().startswith("Invalid regular expression")
+    assert RenamePattern(template="{counter:q}").validate().startswith("Invalid template")
+    assert RenamePattern(template="  ").validate() == "The template is empty"
+    assert propose_renames(_context(), ["faq.dita"], RenamePattern(template="{x}")) == []
+
+    ctx = _context()
+    ctrl = StructureController(ctx, StructureEditingService(), UndoService(), None)
+    ctrl.start_history()
+    assert ctrl.get_batch_rename_targets(["topics/install.dita"], []) == ["install.dita"]
+    assert ctrl.get_batch_rename_targets(["topics/install.dita"], [[1]], True) == [
+        "install.dita", "mount.dita", "wire.dita", "notes.dita", "faq.dita"]
+
+    targets = ctrl.get_batch_rename_targets([], [[0]])
+    renames = {p.filename: p.new for p in propose_renames(ctx, targets, RenamePattern("{section} {title}"))
+               if p.changed}
+    res = ctrl.handle_batch_rename(renames)
+    assert res.success and res.details["renamed"] == 4
+    assert ctx.topics["wire.dita"].findtext("title") == "1.2 Step 2 - Wire it"
+    assert [n.text for n in ctx.ditamap_root.iter("navtitle")][:2] == ["1 Installation", "1.1 Step 1 - Mount the pump"]
+    assert ctrl.undo_label() == "Rename 4 topics"
+    assert ctrl.undo()
+    assert ctrl.context.topics["wire.dita"].findtext("title") == "Step 2 - Wire it"
+    assert not ctrl.handle_batch_rename({"wire.dita": "  "}).success
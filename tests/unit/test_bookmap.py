@@ -0,0 +1,70 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.bookmap import from_bookmap, is_bookmap, to_bookmap
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.package_utils import save_dita_package
+from orlando_toolkit.core.services.structure_editing_service import StructureEditingService
+
+_MAP = ("<map xml:lang='en-US'><title>Manual</title><topicmeta><othermeta name='manualCode' content='M1'/></topicmeta>"
+        "<topicref href='topics/cover.dita' toc='no'/><keydef keys='product'/>"
+        "<topicref href='topics/a.dita'><topicref href='topics/a1.dita'/></topicref>"
+        "<topicref href='topics/b.dita' outputclass='appendix'/><topicref href='topics/glossary.dita'/></map>")
+
+
+def test_map_is_written_as_a_bookmap_with_front_and_back_matter():
+    book = to_bookmap(ET.fromstring(_MAP))
+    assert [c.tag for c in book] == ["booktitle", "bookmeta", "frontmatter", "chapter", "appendix", "backmatter"]
+    assert book.find("booktitle/mainbooktitle").text == "Manual"
+    assert [c.tag for c in book.find("frontmatter")] == ["topicref", "keydef"]
+    assert book.find("chapter/topicref").get("href") == "topics/a1.dita" and book.find("appendix").get("outputclass") is None
+    assert book.find("backmatter/topicref").get("href") == "topics/glossary.dita"
+
+    back = from_bookmap(book)
+    assert [c.tag for c in back] == ["title", "topicmeta", "topicref", "keydef", "topicref", "topicref", "topicref"]
+    assert back.find("title").text == "Manual" and back[5].get("outputclass") == "appendix"
+
+
+def test_package_uses_the_bookmap_doctype_only_when_asked(tmp_path):
+    topics = {name: ET.fromstring(f"<concept id='{name[:-5]}'><title>{name}</title><conbody/></concept>")
+              for name in ("cover.dita", "a.dita", "a1.dita", "b.dita", "glossary.dita")}
+    ctx = DitaContext(ditamap_root=ET.fromstring(_MAP), topics=topics, metadata={"manual_code": "M1"})
+    save_dita_package(ctx, str(tmp_path / "plain"))
+    assert b"<!DOCTYPE map " in (tmp_path / "plain" / "DATA" / "M1.ditamap").read_bytes()
+
+    ctx.metadata["map_type"] = "bookmap"
+    assert is_bookmap(ctx.metadata)
+    save_dita_package(ctx, str(tmp_path / "book"))
+    written = (tmp_path / "book" / "DATA" / "M1.ditamap").read_bytes()
+    assert b"<!DOCTYPE bookmap " in written and b"<appendix" in written
+    assert ctx.ditamap_root.tag == "map"
+
+
+def test_prefaces_title_page_and_booklists():
+    metadata = {"book_subtitle": "Operator guide", "book_author": "Tech Pubs", "book_publisher": "Orsso",
+                "manual_code": "M1", "revision_number": "3"}
+    root = ET.fromstring(_MAP.replace("<topicref href='topics/a.dita'>",
+                                      "<topicref href='topics/intro.dita' outputclass='preface'/>"
+                                      "<topicref href='topics/a.dita'>"))
+    book = to_bookmap(root, metadata, toc=True, index=True)
+    assert [c.text for c in book.find("booktitle")] == ["Manual", "Operator guide"]
+    assert [c.tag for c in book.find("bookmeta")] == ["author", "publisherinformation", "othermeta", "bookid"]
+    assert book.find("bookmeta/bookid/edition").text == "3" and book.find("bookmeta/bookid/booknumber").text == "M1"
+    assert [c.tag for c in book.find("frontmatter")] == ["topicref", "keydef", "booklists", "preface"]
+    assert book.find("frontmatter/booklists/toc") is not None
+    assert book.find("backmatter")[-1].find("indexlist") is not None
+
+    back = from_bookmap(book)
+    assert [c.get("href") for c in back if c.tag == "topicref"][1:3] == ["topics/intro.dita", "topics/a.dita"]
+    assert back.find("topicref[@outputclass='preface']") is not None and back.find("booklists") is None
+
+
+def test_structure_service_sets_book_roles_on_top_level_entries():
+    ctx = DitaContext(ditamap_root=ET.fromstring(_MAP), metadata={})
+    service = StructureEditingService()
+    assert not service.set_book_role(ctx, ["topics/a1.dita"], [], "appendix").success
+    assert not service.set_book_role(ctx, ["topics/a.dita"], [], "index").success
+    res = service.set_book_role(ctx, ["topics/a.dita"], [], "preface")
+    assert res.success and ctx.metadata["map_type"] == "bookmap"
+    assert ctx.ditamap_root.find("topicref[@href='topics/a.dita']").get("outputclass") == "preface"
+    assert service.set_book_role(ctx, ["topics/b.dita"], [], "chapter").success
+    assert ctx.ditamap_root.find("topicref[@href='topics/b.dita']").get("outputclass") is None
@@ -0,0 +1,64 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.project import load_project, save_project
+from orlando_toolkit.core.services.structure_editing_service import StructureEditingService
+
+
+def _ref(name, title, level, children=""):
+    return (f"<topicref href='topics/{name}.dita' data-level='{level}'><topicmeta><navtitle>{title}</navtitle>"
+            f"</topicmeta>{children}</topicref>")
+
+
+def _context():
+    root = ET.fromstring("<map>"
+                         + _ref("p", "Procedures", 1, _ref("s", "Pump", 2, _ref("t", "Priming", 3,
+                                                                                 _ref("d", "Valves", 4))))
+                         + _ref("x", "Appendix", 1, _ref("y", "Wiring", 2, _ref("z", "Colours", 3)))
+                         + "</map>")
+    titles = {"p": "Procedures", "s": "Pump", "t": "Priming", "d": "Valves", "x": "Appendix", "y": "Wiring",
+              "z": "Colours"}
+    topics = {f"{n}.dita": ET.fromstring(f"<concept id='{n}'><title>{t}</title><conbody><p>{t} text</p>"
+                                         "</conbody></concept>") for n, t in titles.items()}
+    return DitaContext(ditamap_root=root, topics=topics)
+
+
+def _visible(ctx):
+    return sorted(t.get("href").rsplit("/", 1)[-1] for t in ctx.ditamap_root.iter("topicref") if t.get("href"))
+
+
+def test_branch_depth_rebuilds_only_that_branch():
+    ctx, service = _context(), StructureEditingService()
+    assert service.apply_depth_limit(ctx, 2).success
+    assert _visible(ctx) == ["p.dita", "s.dita", "x.dita", "y.dita"]
+    wiring = ctx.topics["y.dita"]
+
+    res = service.set_branch_depth(ctx, ["topics/p.dita"], [], 4)
+    assert res.success and res.details["branches"] == ["Procedures"]
+    assert ctx.metadata["depth_overrides"] == {"Procedures": 4} and ctx.metadata["merged_depth"] == 2
+    assert _visible(ctx) == ["d.dita", "p.dita", "s.dita", "t.dita", "x.dita", "y.dita"]
+    # The rest of the map keeps its merged topics untouched
+    assert ctx.topics["y.dita"] is wiring and "Colours text" in ET.tostring(wiring, encoding="unicode")
+    assert "Priming text" not in ET.tostring(ctx.topics["s.dita"], encoding="unicode")
+
+    # A later global change honours the override
+    assert service.apply_depth_limit(ctx, 1).success
+    assert _visible(ctx) == ["d.dita", "p.dita", "s.dita", "t.dita", "x.dita"]
+
+    assert not service.set_branch_depth(ctx, ["topics/s.dita"], [], 1).success
+    assert service.set_branch_depth(ctx, ["topics/p.dita"], [], None).success
+    assert "depth_overrides" not in ctx.metadata and _visible(ctx) == ["p.dita", "x.dita"]
+    assert "Valves text" in ET.tostring(ctx.topics["p.dita"], encoding="unicode")
+
+
+def test_branch_depth_is_saved_with_the_project(tmp_path):
+    ctx, service = _context(), StructureEditingService()
+    assert service.apply_depth_limit(ctx, 3).success
+    assert service.set_branch_depth(ctx, ["topics/x.dita"], [], 1, depth_limit=3).success
+    assert _visible(ctx) == ["p.dita", "s.dita", "t.dita", "x.dita"]
+    assert "Colours text" in ET.tostring(ctx.topics["x.dita"], encoding="unicode")
+
+    reopened = load_project(save_project(ctx, tmp_path / "manual")).context
+    assert reopened.metadata["depth_overrides"] == {"Appendix": 1}
+    assert service.apply_depth_limit(reopened, 2).success
+    assert _visible(reopened) == ["p.dita", "s.dita", "x.dita"]
@@ -0,0 +1,76 @@
+import io
+
+import pytest
+from lxml import etree as ET
+
+from orlando_toolkit.core.callouts import Callout, image_callouts, rename_callouts, set_image_callouts
+from orlando_toolkit.core.models import DitaContext
+
+Image = pytest.importorskip("PIL.Image")
+SVG = "{http://www.w3.org/2000/svg}"
+
+
+def _png(size=(200, 120)):
+    buffer = io.BytesIO()
+    Image.new("RGB", size, (240, 240, 240)).save(buffer, format="PNG")
+    return buffer.getvalue()
+
+
+def _context():
+    topics = {
+        "pump.dita": "<concept id='pump'><title>Pump</title><conbody><p>Exploded view:</p>"
+                     "<fig><title>Pump</title><image href='../media/pump.png'/></fig>"
+                     "<p>Again <image href='../media/pump.png'/></p></conbody></concept>",
+        "spares.dita": "<concept id='spares'><title>Spares</title><conbody><ul><li><p>"
+                       "<image href='../media/pump.png'/></p></li></ul></conbody></concept>",
+    }
+    return DitaContext(ditamap_root=ET.fromstring("<map><topicref href='topics/pump.dita'/>"
+                                                  "<topicref href='topics/spares.dita'/></map>"),
+                       topics={k: ET.fromstring(v) for k, v in topics.items()},
+                       images={"pump.png": _png()}, metadata={})
+
+
+def test_burned_in_callouts_point_images_to_the_copy_and_add_one_table_per_topic():
+    ctx = _context()
+    callouts = [Callout(2, 150, 60, "Seal"), Callout(1, 40, 30, "Impeller")]
+    assert set_image_callouts(ctx, "pump.png", callouts) == 3
+
+    pump = ctx.topics["pump.dita"]
+    assert [i.get("href") for i in pump.iter("image")] == ["../media/pump_callouts.png"] * 2
+    [table] = pump.findall(".//simpletable")
+    assert table.getprevious().tag == "fig" and table.get("id") == "callouts_pump"
+    assert [[e.text for e in row] for row in table] == [["No.", "Part"], ["1", "Impeller"], ["2", "Seal"]]
+    assert ctx.topics["spares.dita"].find("conbody/ul/li/simpletable") is not None
+    with Image.open(io.BytesIO(ctx.images["pump_callouts.png"])) as annotated:
+        assert annotated.size == (200, 120) and annotated.getpixel((40, 30))[:3] != (240, 240, 240)
+    assert ctx.images["pump.png"] == _png()
+    assert image_callouts(ctx.metadata, "pump.png") == (callouts, "png")
+
+    # Switching to an SVG overlay replaces the PNG copy and the tables
+    assert set_image_callouts(ctx, "pump.png", callouts[1:], "svg") == 3
+    assert "pump_callouts.png" not in ctx.images and len(pump.findall(".//simpletable")) == 1
+    svg = ET.fromstring(ctx.images["pump_callouts.svg"])
+    assert svg.get("viewBox") == "0 0 200 120" and [t.text for t in svg.iter(f"{SVG}text")] == ["1"]
+    assert svg.find(f"{SVG}image").get("{http://www.w3.org/1999/xlink}href").startswith("data:image/png;base64,")
+
+    assert set_image_callouts(ctx, "pump.png", []) == 3
+    assert {i.get("href") for i in pump.iter("image")} == {"../media/pump.png"}
+    assert pump.find(".//simpletable") is None and pump.find("conbody/p").text == "Exploded view:"
+    assert set(ctx.images) == {"pump.png"} and "image_callouts" not in ctx.metadata
+
+
+def test_invalid_callouts_are_refused_and_renames_are_followed():
+    ctx = _context()
+    for callouts, message in (([Callout(1, 10, 10), Callout(1, 20, 20)], "used twice"),
+                              ([Callout(0, 10, 10)], "start at 1"),
+                              ([Callout(1, 500, 10)], "outside the image")):
+        with pytest.raises(ValueError, match=message):
+            set_image_callouts(ctx, "pump.png", callouts)
+    with pytest.raises(ValueError, match="Unknown callout output"):
+        set_image_callouts(ctx, "pump.png", [Callout(1, 5, 5)], "gif")
+    assert set(ctx.images) == {"pump.png"} and not ctx.metadata
+
+    set_image_callouts(ctx, "pump.png", [Callout(1, 5, 5, "Cover")])
+    rename_callouts(ctx.metadata, {"pump.png": "IMG-01.png", "pump_callouts.png": "IMG-02.png"})
+    assert ctx.metadata["image_callouts"] == {"IMG-01.png": {"mode": "png", "output": "IMG-02.png",
+                                                             "callouts": [[1, 5, 5, "Cover"]]}}
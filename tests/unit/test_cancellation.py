@@ -0,0 +1,76 @@
+import threading
+
+import pytest
+
+from orlando_toolkit.core.cancellation import (
+    CancellationToken,
+    OperationCancelledError,
+    check_cancelled,
+)
+from orlando_toolkit.core.services.conversion_service import ConversionService
+
+
+def test_token_raises_after_cancel():
+    token = CancellationToken()
+    token.raise_if_cancelled()  # no-op while active
+
+    token.cancel("stop")
+
+    assert token.is_cancelled
+    with pytest.raises(OperationCancelledError) as info:
+        token.raise_if_cancelled()
+    assert info.value.reason == "stop"
+
+
+def test_cancel_is_idempotent_and_runs_callbacks_once():
+    token = CancellationToken()
+    calls = []
+    token.add_callback(lambda: calls.append("a"))
+
+    token.cancel()
+    token.cancel()
+    # Registering after cancellation fires immediately
+    token.add_callback(lambda: calls.append("b"))
+
+    assert calls == ["a", "b"]
+
+
+def test_check_cancelled_accepts_none():
+    check_cancelled(None)
+
+
+def test_wait_returns_when_cancelled_from_other_thread():
+    token = CancellationToken()
+    threading.Timer(0.01, token.cancel).start()
+    assert token.wait(timeout=2.0) is True
+
+
+def test_call_handler_passes_token_only_when_declared():
+    token = CancellationToken()
+    seen = {}
+
+    class LegacyHandler:
+        def convert_to_dita(self, file_path, metadata):
+            seen["legacy"] = True
+            return "legacy"
+
+    class AwareHandler:
+        def convert_to_dita(self, file_path, metadata, progress_callback=None, *, cancel_token=None):
+            seen["token"] = cancel_token
+            return "aware"
+
+    assert ConversionService._call_handler(LegacyHandler(), "x", {}, None, token) == "legacy"
+    assert ConversionService._call_handler(AwareHandler(), "x", {}, None, token) == "aware"
+    assert seen == {"legacy": True, "token": token}
+
+
+def test_write_package_rejects_cancelled_token(tmp_path):
+    from orlando_toolkit.core.models import DitaContext
+
+    token = CancellationToken()
+    token.cancel()
+    service = ConversionService()
+
+    with pytest.raises(OperationCancelledError):
+        service.write_package(DitaContext(), tmp_path / "out.zip", cancel_token=token)
+    assert not (tmp_path / "out.zip").exists()
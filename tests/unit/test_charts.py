@@ -0,0 +1,128 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.charts import render_chart_svg, render_diagram_svg, restore_word_charts
+from orlando_toolkit.core.models import DitaContext
+
+W = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+NS = ('xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" '
+      'xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing" '
+      'xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" '
+      'xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" '
+      'xmlns:dgm="http://schemas.openxmlformats.org/drawingml/2006/diagram" '
+      'xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" '
+      'xmlns:mc="http://schemas.openxmlformats.org/markup-compatibility/2006" '
+      'xmlns:v="urn:schemas-microsoft-com:vml"')
+C = "http://schemas.openxmlformats.org/drawingml/2006/chart"
+CHART = (f'<c:chartSpace xmlns:c="{C}" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main">'
+         '<c:chart><c:title><c:tx><c:rich><a:p><a:r><a:t>Sales by region</a:t></a:r></a:p></c:rich></c:tx>'
+         '</c:title><c:plotArea><c:barChart><c:barDir val="col"/><c:ser><c:tx><c:strRef><c:strCache>'
+         '<c:pt idx="0"><c:v>2025</c:v></c:pt></c:strCache></c:strRef></c:tx><c:cat><c:strRef><c:strCache>'
+         '<c:ptCount val="2"/><c:pt idx="0"><c:v>North</c:v></c:pt><c:pt idx="1"><c:v>South</c:v></c:pt>'
+         '</c:strCache></c:strRef></c:cat><c:val><c:numRef><c:numCache><c:ptCount val="2"/>'
+         '<c:pt idx="0"><c:v>12</c:v></c:pt><c:pt idx="1"><c:v>30</c:v></c:pt></c:numCache></c:numRef></c:val>'
+         '</c:ser></c:barChart></c:plotArea></c:chart></c:chartSpace>')
+
+
+def _drawing(uri, inner, name):
+    return (f'<w:r><w:drawing><wp:inline><wp:docPr id="1" name="{name}"/><a:graphic>'
+            f'<a:graphicData uri="{uri}">{inner}</a:graphicData></a:graphic></wp:inline></w:drawing></w:r>')
+
+
+CHART_RUN = _drawing(C, '<c:chart r:id="rId1"/>', "Chart 1")
+DIAGRAM = ('<mc:AlternateContent><mc:Choice Requires="dgm">'
+           + _drawing("http://schemas.openxmlformats.org/drawingml/2006/diagram", '<dgm:relIds r:dm="rId2"/>',
+                      "Diagram 1")
+           + '</mc:Choice><mc:Fallback><w:pict><v:shape><v:imagedata r:id="rId3"/></v:shape></w:pict>'
+           '</mc:Fallback></mc:AlternateContent>')
+BODY = ('<w:p><w:r><w:t>Results are shown below.</w:t></w:r></w:p>'
+        f'<w:p>{CHART_RUN}</w:p>'
+        '<w:p><w:pPr><w:pStyle w:val="Caption"/></w:pPr><w:r><w:t>Figure 1: Yearly sales</w:t></w:r></w:p>'
+        f'<w:p><w:r><w:t xml:space="preserve">The process: </w:t></w:r>{DIAGRAM}</w:p>')
+DATA = '<dgm:dataModel xmlns:dgm="http://schemas.openxmlformats.org/drawingml/2006/diagram"/>'
+RELS = {"rId1": "charts/chart1.xml", "rId2": "diagrams/data1.xml", "rId3": "media/image9.png"}
+PARTS = {"word/charts/chart1.xml": CHART, "word/diagrams/data1.xml": DATA, "word/media/image9.png": b"\x89PNG preview"}
+
+
+def _context():
+    topic = ET.fromstring("<concept id='c'><title>C</title><conbody><p>Results are shown below.</p>"
+                          "<p>Figure 1: Yearly sales</p><p>The process:</p></conbody></concept>")
+    return DitaContext(ditamap_root=ET.fromstring("<map><topicref href='topics/c.dita'/></map>"),
+                       topics={"c.dita": topic})
+
+
+def test_charts_and_diagrams_become_figures_with_titles(tmp_path, docx_factory):
+    ctx = _context()
+    source = docx_factory(tmp_path / "report.docx", BODY, namespaces=NS, rels=RELS, parts=PARTS)
+    assert restore_word_charts(source, ctx) == 2
+    body = ctx.topics["c.dita"].find("conbody")
+    assert [el.tag for el in body] == ["p", "fig", "p", "fig"]
+    chart, diagram = body.findall("fig")
+    assert chart.findtext("title") == "Figure 1: Yearly sales" and chart.find("image/alt").text == "Sales by region"
+    assert chart.find("image").get("href") == "../media/chart_1.svg"
+    assert diagram.find("image").get("href") == "../media/diagram_2.png"
+    assert ctx.images["diagram_2.png"] == b"\x89PNG preview"
+    entry = next(e for e in ctx.report.entries if e.category == "charts")
+    assert entry.detail == {"charts": 1, "diagrams": 1, "previews": 1}
+
+
+def test_chart_values_are_drawn_as_svg():
+    svg = ET.fromstring(render_chart_svg(ET.fromstring(CHART)))
+    texts = [el.text for el in svg.iter() if el.tag.endswith("text")]
+    assert "Sales by region" in texts and "North" in texts and "South" in texts
+    bars = [el for el in svg.iter() if el.tag.endswith("rect") and el.get("fill") == "#4472c4"]
+    assert len(bars) == 2 and float(bars[1].get("height")) > 2 * float(bars[0].get("height"))
+
+    pie = CHART.replace("<c:barChart><c:barDir val=\"col\"/>", "<c:pieChart>").replace("</c:barChart>", "</c:pieChart>")
+    labels = [el.text for el in ET.fromstring(render_chart_svg(ET.fromstring(pie))).iter() if el.tag.endswith("text")]
+    assert "North (29%)" in labels and "South (71%)" in labels
+    assert render_chart_svg(ET.fromstring(f'<c:chartSpace xmlns:c="{C}"><c:chart/></c:chartSpace>')) is None
+
+
+def test_charts_without_text_image_or_block_are_placed_or_reported(tmp_path, docx_factory):
+    nodes = "".join(f'<dgm:pt modelId="{n}"{kind}><dgm:t><a:p><a:r><a:t>{text}</a:t></a:r></a:p></dgm:t></dgm:pt>'
+                    for n, kind, text in ((1, ' type="doc"', "Root"), (2, "", "Plan"), (3, "", "Build")))
+    data = (f'<dgm:dataModel xmlns:dgm="http://schemas.openxmlformats.org/drawingml/2006/diagram" '
+            f'xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"><dgm:ptLst>{nodes}</dgm:ptLst>'
+            '</dgm:dataModel>')
+    chart = _drawing(C, '<c:chart r:id="rId1"/>', "Chart")
+    body = (f'<w:p>{chart}</w:p>'
+            '<w:p><w:r><w:t>Intro text.</w:t></w:r></w:p>'
+            '<w:p><w:pPr><w:pStyle w:val="Caption"/></w:pPr><w:r><w:t>Figure 2: Flow</w:t></w:r></w:p>'
+            '<w:p>' + _drawing("http://schemas.openxmlformats.org/drawingml/2006/diagram",
+                               '<dgm:relIds r:dm="rId2"/>', "Diagram") + '</w:p>'
+            '<w:p><w:r><w:t>Placeholder here.</w:t></w:r>' + _drawing(C, '<c:chart r:id="rId9"/>', "Lost") + '</w:p>'
+            f'<w:p><w:r><w:t>Not converted.</w:t></w:r>{chart}</w:p>'
+            f'<w:p>{chart}</w:p>')
+    docx_factory(tmp_path / "report.docx", body, namespaces=NS,
+                 rels={"rId1": "charts/chart1.xml", "rId2": "diagrams/data1.xml"}, parts={"word/charts/chart1.xml": CHART, "word/diagrams/data1.xml": data})
+    topic = ET.fromstring("<concept id='c'><title>C</title><conbody><p>Intro text.</p><p>Figure 2: Flow</p>"
+                          "<p>Placeholder here.</p><p><ph data-chart='5'/></p></conbody></concept>")
+    ctx = DitaContext(ditamap_root=ET.fromstring("<map><topicref href='topics/c.dita'/></map>"),
+                      topics={"c.dita": topic})
+
+    assert restore_word_charts(tmp_path / "report.docx", ctx) == 3
+    body = topic.find("conbody")
+    assert [el.tag for el in body] == ["fig", "p", "fig", "p", "fig"]
+    assert body[0].findtext("title") == "Sales by region"  # no paragraph before it: top of the first topic
+    assert body[2].findtext("title") == "Figure 2: Flow"    # the caption above it became its title
+    assert [f.find("image").get("href") for f in body.findall("fig")] == [
+        "../media/chart_1.svg", "../media/diagram_2.svg", "../media/chart_5.svg"]
+    diagram = ET.fromstring(ctx.images["diagram_2.svg"])
+    assert [el.text for el in diagram.iter() if el.tag.endswith("text")] == ["Plan", "Build"]
+    assert sorted(ctx.images) == ["chart_1.svg", "chart_5.svg", "diagram_2.svg"]
+    assert [(e.severity, e.detail) for e in ctx.report.entries if e.category == "charts"] == [
+        ("info", {"charts": 2, "diagrams": 1, "previews": 0}), ("warning", {"numbers": [3]}),
+        ("warning", {"numbers": [4]})]
+
+
+def test_missing_points_and_negative_values_are_drawn_from_zero():
+    line = (f'<c:chartSpace xmlns:c="{C}"><c:chart><c:plotArea><c:lineChart><c:ser><c:val><c:numRef><c:numCache>'
+            '<c:ptCount val="3"/><c:pt idx="0"><c:v>-5</c:v></c:pt><c:pt idx="2"><c:v>10</c:v></c:pt>'
+            '</c:numCache></c:numRef></c:val></c:ser></c:lineChart></c:plotArea></c:chart></c:chartSpace>')
+    svg = ET.fromstring(render_chart_svg(ET.fromstring(line)))
+    polyline = next(el for el in svg.iter() if el.tag.endswith("polyline"))
+    assert [float(point.split(",")[1]) for point in polyline.get("points").split()] == [360.0, 250.0, 30.0]
+    assert not [el for el in svg.iter() if el.tag.endswith("text") and el.get("font-weight") == "bold"]
+
+    empty = '<dgm:dataModel xmlns:dgm="http://schemas.openxmlformats.org/drawingml/2006/diagram"/>'
+    assert render_diagram_svg(None, ET.fromstring(empty)) is None
@@ -0,0 +1,39 @@
+import json
+import zipfile
+
+from orlando_toolkit.cli import EXIT_FAILED, EXIT_OK, EXIT_REPORT, EXIT_USAGE, main
+
+
+def _sources(tmp_path):
+    docs = tmp_path / "docs"
+    docs.mkdir()
+    (docs / "guide.md").write_text("# Guide\n\n## Start\n\nSee [later](#missing).\n", encoding="utf-8")
+    (docs / "notes.adoc").write_text("= Notes\n\n== First\n\nText.\n", encoding="utf-8")
+    return docs
+
+
+def test_convert_globs_into_a_folder_with_metadata_and_reports(tmp_path, capsys):
+    docs = _sources(tmp_path)
+    out = tmp_path / "build"
+    code = main(["convert", str(docs / "*"), "--no-plugins", "--out", str(out),
+                 "--metadata", "manual_code=OM-12", "--report"])
+    assert code == EXIT_OK
+    assert "2 of 2 document(s) converted" in capsys.readouterr().out
+    assert sorted(p.name for p in out.glob("*.zip")) == ["guide.zip", "notes.zip"]
+    with zipfile.ZipFile(out / "guide.zip") as archive:
+        assert any(name.endswith("OM-12.ditamap") for name in archive.namelist())
+    report = json.loads((out / "guide.report.json").read_text(encoding="utf-8"))
+    assert report["counts"]["warning"] >= 1
+
+
+def test_convert_exit_codes(tmp_path, capsys):
+    docs = _sources(tmp_path)
+    guide = str(docs / "guide.md")
+    assert main(["convert", guide, "--no-plugins", "--out", str(tmp_path / "g.zip"),
+                 "--fail-on", "warning", "--quiet"]) == EXIT_REPORT
+    assert (tmp_path / "g.zip").is_file()
+    assert main(["convert", str(docs / "*.docx"), "--no-plugins"]) == EXIT_USAGE
+    assert main(["convert", guide, "--metadata", "no-equals-sign", "--no-plugins"]) == EXIT_USAGE
+    (docs / "plain.txt").write_text("text", encoding="utf-8")
+    assert main(["convert", str(docs / "plain.txt"), "--no-plugins", "--out", str(tmp_path)]) == EXIT_FAILED
+    assert "FAILED" in capsys.readouterr().err
@@ -0,0 +1,100 @@
+from pathlib import Path
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.combine import CombineSettings, collect_sources, combine_documents
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.services.conversion_service import ConversionService
+
+
+def _document(title, topics, images=None):
+    """A converted document: *topics* is a list of (file, title, body xml)."""
+    root = ET.Element("map")
+    context = DitaContext(ditamap_root=root, metadata={"manual_title": title, "source_outline": [], "language": "en"})
+    for name, heading, body in topics:
+        context.topics[name] = ET.fromstring(f"<concept id='{name[:-5]}'><title>{heading}</title>"
+                                             f"<conbody>{body}</conbody></concept>")
+        ref = ET.SubElement(root, "topicref", href=f"topics/{name}")
+        ref.set("data-level", "1")
+        ET.SubElement(ET.SubElement(ref, "topicmeta"), "navtitle").text = heading
+    context.images = dict(images or {})
+    return context
+
+
+def _outline(node):
+    return [(el.tag, el.findtext("topicmeta/navtitle"), el.get("href"), el.get("data-level"), _outline(el))
+            for el in node if el.tag in ("topicref", "topichead")]
+
+
+def test_combine_renames_clashing_files_and_links_documents():
+    first = _document("Chapter1", [
+        ("topic_1.dita", "Intro", "<p data-bookmarks='Start'>See <xref href='Chapter2.docx#Setup'>setup</xref>, "
+                                  "<xref href='Chapter2.docx#Gone'>gone</xref> and <xref href='#Start'>here</xref>"
+                                  "</p><image href='../media/image1.png'/><image href='../media/logo.png'/>"),
+    ], images={"image1.png": b"one", "logo.png": b"logo"})
+    second = _document("Chapter2", [
+        ("topic_1.dita", "Setup", "<p data-bookmarks='Start'>Begin <xref href='#Start'>again</xref></p>"
+                                  "<image href='../media/image1.png'/>"),
+        ("topic_2.dita", "Use", "<p conref='topic_1.dita#topic_1/p1'/><image href='../media/logo.png'/>"),
+    ], images={"image1.png": b"two", "logo.png": b"logo"})
+    second.topics["topic_1.dita"].set("data-anchor", "Setup")
+
+    combined = combine_documents([("/docs/Chapter1.docx", first), ("/docs/Chapter2.docx", second)],
+                                 {"manual_title": "Pump Manual"}, settings=CombineSettings())
+
+    assert _outline(combined.ditamap_root) == [
+        ("topichead", "Chapter1", None, "1", [("topicref", "Intro", "topics/topic_1.dita", "2", [])]),
+        ("topichead", "Chapter2", None, "1", [("topicref", "Setup", "topics/topic_1_2.dita", "2", []),
+                                              ("topicref", "Use", "topics/topic_2.dita", "2", [])]),
+    ]
+    assert list(combined.topics) == ["topic_1.dita", "topic_1_2.dita", "topic_2.dita"]
+    assert combined.images == {"image1.png": b"one", "logo.png": b"logo", "image1_2.png": b"two"}
+    intro, setup, use = combined.topics.values()
+    assert [x.get("href") for x in intro.iter("xref")] == ["#Setup", "topic_1_2.dita", "#Start"]
+    assert [i.get("href") for i in intro.iter("image")] == ["../media/image1.png", "../media/logo.png"]
+    # The second document's clashing bookmark and its links are renamed
+    assert setup.find("conbody/p").get("data-bookmarks") == "Chapter2_Start"
+    assert setup.find("conbody/p/xref").get("href") == "#Chapter2_Start"
+    assert setup.find("conbody/image").get("href") == "../media/image1_2.png"
+    assert use.find("conbody/p").get("conref") == "topic_1_2.dita#topic_1/p1"
+    assert use.find("conbody/image").get("href") == "../media/logo.png"
+
+    assert combined.ditamap_root.findtext("title") == "Pump Manual"
+    assert combined.metadata["source_documents"] == ["Chapter1.docx", "Chapter2.docx"]
+    assert combined.metadata["language"] == "en" and "source_outline" not in combined.metadata
+    messages = [e.message for e in combined.report.entries if e.category == "combine"]
+    assert any("Bookmark 'Gone' not found" in m for m in messages)
+    assert "Combined 2 documents (3 topics); 2 file(s) renamed to avoid conflicts" in messages
+
+
+def test_convert_combined_collects_a_folder_by_sub_folder(tmp_path, monkeypatch):
+    for name in ("b.docx", "a.docx", "parts/c.docx", "parts/~$c.docx", "notes.txt"):
+        (tmp_path / name).parent.mkdir(exist_ok=True)
+        (tmp_path / name).write_bytes(b"x")
+    sources = collect_sources([tmp_path], lambda p: p.suffix == ".docx")
+    assert [p.relative_to(tmp_path).as_posix() for p in sources] == ["a.docx", "b.docx", "parts/c.docx"]
+
+    service = ConversionService()
+    jobs = []
+
+    def _convert(path, metadata, progress_callback=None, *, cancel_token=None):
+        jobs.append(metadata["manual_title"])
+        return _document(metadata["manual_title"], [("topic_1.dita", f"{Path(path).stem} intro", "<p>x</p>")])
+
+    monkeypatch.setattr(service, "convert", _convert)
+    monkeypatch.setattr(CombineSettings, "from_config", classmethod(lambda cls: cls(hierarchy="folders")))
+    combined = service.convert_combined(sources, {"manual_title": "Set"}, base_dir=tmp_path)
+
+    assert jobs == ["a", "b", "c"]
+    assert _outline(combined.ditamap_root) == [
+        ("topichead", "a", None, "1", [("topicref", "a intro", "topics/topic_1.dita", "2", [])]),
+        ("topichead", "b", None, "1", [("topicref", "b intro", "topics/topic_1_2.dita", "2", [])]),
+        ("topichead", "parts", None, "1", [
+            ("topichead", "c", None, "2", [("topicref", "c intro", "topics/topic_1_3.dita", "3", [])])]),
+    ]
+
+    flat = combine_documents([(p, _document(p.stem, [("t.dita", p.stem, "<p/>")])) for p in sources],
+                             settings=CombineSettings.from_mapping({"hierarchy": "flat"}))
+    assert [(e[1], e[2], e[3]) for e in _outline(flat.ditamap_root)] == [
+        ("a", "topics/t.dita", "1"), ("b", "topics/t_2.dita", "1"), ("c", "topics/t_3.dita", "1")]
+    assert CombineSettings.from_mapping({"hierarchy": "bogus"}).hierarchy == "chapters"
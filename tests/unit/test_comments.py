@@ -0,0 +1,67 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.comments import read_word_comments, restore_word_comments
+from orlando_toolkit.core.models import DitaContext
+
+W = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+W14 = "http://schemas.microsoft.com/office/word/2010/wordml"
+W15 = "http://schemas.microsoft.com/office/word/2012/wordml"
+
+
+BODY = (
+    '<w:p><w:commentRangeStart w:id="0"/><w:r><w:t>Pump</w:t></w:r><w:commentRangeEnd w:id="0"/>'
+    '<w:r><w:commentReference w:id="0"/></w:r></w:p>'
+    '<w:p><w:r><w:t xml:space="preserve">Open the </w:t></w:r><w:commentRangeStart w:id="1"/>'
+    '<w:r><w:t>drain valve</w:t></w:r><w:commentRangeEnd w:id="1"/><w:r><w:commentReference w:id="1"/></w:r>'
+    '<w:r><w:t xml:space="preserve"> slowly.</w:t></w:r><w:r><w:commentReference w:id="2"/></w:r></w:p>'
+)
+COMMENTS = (
+    '<w:comment w:id="0" w:author="Ana" w:date="2026-05-02T08:00:00Z"><w:p w14:paraId="A1">'
+    '<w:r><w:annotationRef/></w:r><w:r><w:t>Rename to pump unit.</w:t></w:r></w:p></w:comment>'
+    '<w:comment w:id="1" w:author="Ben" w:date="2026-05-03T09:30:00Z"><w:p w14:paraId="B1"><w:r>'
+    '<w:t xml:space="preserve">Which </w:t></w:r><w:r><w:rPr><w:b/></w:rPr><w:t>valve</w:t></w:r></w:p>'
+    '<w:p w14:paraId="B2"><w:r><w:t>Add a figure.</w:t></w:r></w:p></w:comment>'
+    '<w:comment w:id="2" w:author="Ana"><w:p w14:paraId="C1"><w:r><w:t>Done.</w:t></w:r></w:p></w:comment>'
+)
+PARTS = {"word/comments.xml": f'<w:comments xmlns:w="{W}" xmlns:w14="{W14}">{COMMENTS}</w:comments>',
+         "word/commentsExtended.xml": f'<w15:commentsEx xmlns:w15="{W15}">'
+                                     '<w15:commentEx w15:paraId="C1" w15:done="1"/></w15:commentsEx>'}
+
+
+def _context(body):
+    topic = ET.fromstring(f"<concept id='c'><title>Pump</title><conbody>{body}</conbody></concept>")
+    return DitaContext(ditamap_root=ET.fromstring("<map><topicref href='topics/c.dita'/></map>"),
+                       topics={"c.dita": topic})
+
+
+def test_comments_are_anchored_at_the_commented_text(tmp_path, docx_factory):
+    path = docx_factory(tmp_path / "review.docx", BODY, parts=PARTS)
+    read = read_word_comments(path)
+    assert read.comments["2"].resolved and not read.comments["1"].resolved
+    assert read.paragraphs == [("Pump", [("0", 0)]), ("Open the drain valve slowly.", [("1", 9), ("2", 28)])]
+
+    ctx = _context("<p>Open the <b>drain valve</b> slowly.</p>")
+    assert restore_word_comments(path, ctx, {"enabled": True}) == 3
+    topic = ctx.topics["c.dita"]
+    on_title = topic.find("conbody")[0]
+    assert on_title.tag == "draft-comment" and on_title.get("author") == "Ana"
+    assert on_title.text == "Rename to pump unit."
+    p = topic.find("conbody/p")
+    first, last = p.findall("draft-comment")
+    assert p.text == "Open the " and first.tail is None and p.find("b").text == "drain valve"
+    assert first.get("time") == "2026-05-03T09:30:00Z" and first.find("p/b").text == "valve"
+    assert last.get("disposition") == "resolved" and last.get("time") is None
+    assert any(e.category == "comments" and "3 review comment(s)" in e.message for e in ctx.report.entries)
+
+
+def test_comments_are_off_by_default_and_placeholders_win(tmp_path, docx_factory):
+    path = docx_factory(tmp_path / "review.docx", BODY, parts=PARTS)
+    ctx = _context("<p>Open<ph data-comment='1'/> it.</p>")
+    assert restore_word_comments(path, ctx, {}) == 0
+    p = ctx.topics["c.dita"].find(".//p")
+    assert p.find("ph") is None and p.text == "Open it."
+
+    ctx = _context("<p>Open<ph data-comment='1'/> it<ph data-comment='2'/>.</p>")
+    assert restore_word_comments(path, ctx, {"enabled": True, "resolved": "drop"}) == 1
+    p = ctx.topics["c.dita"].find(".//p")
+    assert [c.tag for c in p] == ["draft-comment"] and p[0].tail == " it."
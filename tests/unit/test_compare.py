@@ -0,0 +1,55 @@
+import json
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.compare import compare_contexts, compare_documents, word_diff
+from orlando_toolkit.core.models import DitaContext
+
+
+def _context(topics) -> DitaContext:
+    refs = "".join(f"<topicref href='topics/{name}'/>" for name in topics)
+    return DitaContext(
+        ditamap_root=ET.fromstring(f"<map>{refs}</map>"),
+        topics={name: ET.fromstring(f"<concept id='{name[:-5]}'><title>{title}</title><conbody>{body}</conbody></concept>")
+                for name, (title, body) in topics.items()},
+    )
+
+
+def test_word_diff_keeps_unchanged_words():
+    assert word_diff("Tighten the bolt firmly.", "Tighten the nut firmly.") == [
+        ("equal", "Tighten the "), ("delete", "bolt"), ("insert", "nut"), ("equal", " firmly."),
+    ]
+
+
+def test_compare_reports_topic_and_paragraph_changes():
+    old = _context({"a.dita": ("Install", "<p>Unpack.</p><p>Tighten the bolt.</p>"),
+                    "b.dita": ("Legacy", "<p>Old part.</p>")})
+    new = _context({"a.dita": ("Install", "<p>Unpack.</p><p>Tighten the nut.</p><p>Test it.</p>"),
+                    "c.dita": ("Upgrade", "<p>New.</p>")})
+    report = compare_contexts(old, new, old_source="v1.docx", new_source="v2.docx")
+
+    statuses = {t.title: t.status for t in report.topics}
+    assert statuses == {"Install": "changed", "Upgrade": "added", "Legacy": "removed"}
+    install = report.topics[0]
+    assert [b.status for b in install.blocks] == ["changed", "added"]
+    assert ("insert", "nut.") in install.blocks[0].words
+    data = json.loads(report.to_json())
+    assert data["summary"]["changed_blocks"] == 2
+    page = report.to_html()
+    assert "<del>bolt.</del>" in page and "<ins>nut.</ins>" in page
+
+
+def test_compare_documents_converts_both_revisions():
+    class _Service:
+        def __init__(self):
+            self.calls = []
+
+        def convert(self, path, metadata):
+            self.calls.append(path)
+            body = "<p>One</p>" if path == "v1.docx" else "<p>Two</p>"
+            return _context({"a.dita": ("Intro", body)})
+
+    service = _Service()
+    report = compare_documents("v1.docx", "v2.docx", service=service)
+    assert service.calls == ["v1.docx", "v2.docx"]
+    assert report.count("changed") == 1 and report.new_source == "v2.docx"
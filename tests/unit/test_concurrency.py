@@ -0,0 +1,120 @@
+import threading
+import time
+
+import pytest
+
+from orlando_toolkit.core.cancellation import CancellationToken, OperationCancelledError
+from orlando_toolkit.core.concurrency import MemoryBudget, PipelineSettings, ordered_map, progress_steps
+
+
+def test_settings_from_mapping_per_stage_and_scalar():
+    s = PipelineSettings.from_mapping({"workers": {"parser": 2, "media": 1, "serializer": 3},
+                                       "memory_budget_mb": 64})
+    assert (s.parser_workers, s.media_workers, s.serializer_workers) == (2, 1, 3)
+    assert s.budget().limit_bytes == 64 * 1024 * 1024
+
+    s = PipelineSettings.from_mapping({"workers": 5})
+    assert s.parser_workers == s.media_workers == s.serializer_workers == 5
+
+
+def test_settings_invalid_values_fall_back():
+    s = PipelineSettings.from_mapping({"workers": {"parser": 0}, "memory_budget_mb": "lots"})
+    assert s.parser_workers == 1
+    assert s.memory_budget_mb == 0
+
+
+def test_ordered_map_preserves_input_order():
+    def slow_double(x):
+        time.sleep(0.005 * (5 - x))
+        return x * 2
+
+    assert ordered_map(slow_double, range(5), workers=4) == [0, 2, 4, 6, 8]
+
+
+def test_memory_budget_limits_concurrent_work():
+    budget = MemoryBudget(100)
+    active = []
+    lock = threading.Lock()
+
+    def work(_):
+        with lock:
+            active.append(1)
+        time.sleep(0.01)
+        with lock:
+            active.pop()
+
+    ordered_map(work, range(6), workers=4, budget=budget, size_of=lambda _: 60)
+
+    # Only one 60-byte item fits into a 100-byte budget at a time
+    assert budget.peak_bytes == 60
+    assert budget.in_use_bytes == 0
+
+
+def test_memory_budget_admits_oversized_item_alone():
+    budget = MemoryBudget(10)
+    with budget.reserve(50):
+        assert budget.in_use_bytes == 50
+    assert budget.in_use_bytes == 0
+
+
+def test_ordered_map_honours_cancellation():
+    token = CancellationToken()
+    token.cancel()
+    with pytest.raises(OperationCancelledError):
+        ordered_map(lambda x: x, [1, 2], workers=2, cancel_token=token)
+
+
+def _sample_context(pipeline):
+    from lxml import etree as ET
+
+    from orlando_toolkit.core.models import DitaContext
+
+    root = ET.Element("map")
+    topics = {}
+    for i in range(12):
+        ET.SubElement(root, "topicref", href=f"topics/t{i}.dita")
+        topic = ET.Element("concept", id=f"t{i}")
+        ET.SubElement(topic, "title").text = f"Topic {i}"
+        ET.SubElement(ET.SubElement(topic, "conbody"), "p").text = "x" * (i * 50)
+        topics[f"t{i}.dita"] = topic
+    images = {f"img{i}.png": bytes([i]) * (i + 1) * 100 for i in range(8)}
+    return DitaContext(
+        ditamap_root=root,
+        topics=topics,
+        images=images,
+        metadata={"manual_title": "Sample", "manual_code": "SAMPLE", "pipeline": pipeline},
+    )
+
+
+def test_parallel_and_serial_packages_are_byte_identical(tmp_path):
+    from orlando_toolkit.core.services.conversion_service import ConversionService
+
+    service = ConversionService()
+    serial_zip = tmp_path / "serial.zip"
+    parallel_zip = tmp_path / "parallel.zip"
+    service.write_package(_sample_context({"workers": 1}), serial_zip)
+    service.write_package(_sample_context({"workers": 4, "memory_budget_mb": 1}), parallel_zip)
+
+    assert serial_zip.read_bytes() == parallel_zip.read_bytes()
+
+
+def test_ordered_map_reports_progress_in_order_on_the_calling_thread():
+    calls = []
+    caller = threading.current_thread()
+
+    def slow(x):
+        time.sleep(0.004 * (4 - x))
+        return x
+
+    def progress(done, total):
+        calls.append((done, total, threading.current_thread() is caller))
+
+    assert ordered_map(slow, range(4), workers=3, progress=progress) == [0, 1, 2, 3]
+    assert calls == [(1, 4, True), (2, 4, True), (3, 4, True), (4, 4, True)]
+
+    messages = []
+    ordered_map(lambda x: x, range(100), progress=progress_steps(messages.append, "Building topics", steps=4))
+    assert messages == [f"Building topics ({n}/100)..." for n in (25, 50, 75, 100)]
+    assert progress_steps(None, "Building topics") is None
+    s = PipelineSettings.from_mapping({"workers": {"topics": 3}})
+    assert s.workers_for("topics") == 3 and s.serial().topics_workers == 1
@@ -0,0 +1,191 @@
+import json
+import threading
+from contextlib import contextmanager
+from http.server import BaseHTTPRequestHandler, ThreadingHTTPServer
+
+import pytest
+from lxml import etree as ET
+
+from orlando_toolkit.core.confluence import (
+    ConfluenceError,
+    ConfluenceExport,
+    ConfluenceSettings,
+    export_confluence,
+    push_confluence,
+)
+from orlando_toolkit.core.models import DitaContext
+
+
+def _context():
+    topics = {
+        "intro.dita": "<concept id='intro'><title>Introduction</title><shortdesc>About the pump.</shortdesc>"
+                      "<conbody><p>Read <xref href='wiring.dita'>the wiring</xref> and "
+                      "<xref href='https://x.org' scope='external'>x</xref><fn>Sold apart.</fn>.</p>"
+                      "<note type='warning'><p>Hot &amp; <b>loud</b></p></note>"
+                      "<codeblock outputclass='language-bash'>make &lt;all&gt;</codeblock>"
+                      "<fig><title>Pump</title><image href='../media/pump.png' placement='break'>"
+                      "<alt>Pump front</alt></image></fig></conbody></concept>",
+        "wiring.dita": "<task id='wiring'><title>Wiring</title><taskbody><steps><step><cmd>Open the "
+                       "<uicontrol>cover</uicontrol></cmd><info><p>Use gloves.</p></info></step></steps>"
+                       "<result><table><tgroup cols='2'><colspec colname='c1'/><colspec colname='c2'/>"
+                       "<thead><row><entry>Wire</entry><entry>Colour</entry></row></thead><tbody>"
+                       "<row><entry morerows='1'>L1</entry><entry>Brown</entry></row>"
+                       "<row><entry>Black</entry></row>"
+                       "<row><entry namest='c1' nameend='c2'>Earth</entry></row></tbody></tgroup></table>"
+                       "</result></taskbody></task>",
+        "more.dita": "<concept id='more'><title>Wiring</title><conbody><p>Again.</p></conbody></concept>",
+        "reuse.dita": "<concept id='reuse'><title>Reuse</title><conbody/></concept>",
+    }
+    root = ET.fromstring(
+        "<map><topicref href='topics/intro.dita'><topicmeta><navtitle>Introduction</navtitle></topicmeta>"
+        "<topicref href='topics/wiring.dita'/></topicref>"
+        "<topichead><topicmeta><navtitle>Appendix</navtitle></topicmeta><topicgroup>"
+        "<topicref href='topics/more.dita'/></topicgroup></topichead>"
+        "<topicref href='topics/reuse.dita' processing-role='resource-only'/></map>")
+    ctx = DitaContext(ditamap_root=root, topics={k: ET.fromstring(v) for k, v in topics.items()}, metadata={})
+    ctx.images = {"pump.png": b"PNG", "spare.png": b"GIF"}
+    return ctx
+
+
+def test_pages_follow_the_map_in_storage_format(tmp_path):
+    export = export_confluence(_context(), ConfluenceSettings(title_prefix="OM "))
+    assert [(p.key, p.title, p.parent, p.topic) for p in export.pages] == [
+        ("p1", "OM Introduction", None, "intro.dita"), ("p2", "OM Wiring", "p1", "wiring.dita"),
+        ("p3", "OM Appendix", None, None), ("p4", "OM Wiring (2)", "p3", "more.dita")]
+    intro, wiring, appendix = export.pages[0], export.pages[1], export.pages[2]
+    assert intro.body.startswith("<p>About the pump.</p><p>Read <ac:link><ri:page ri:content-title=\"OM Wiring\"/>"
+                                 "<ac:plain-text-link-body><![CDATA[the wiring]]></ac:plain-text-link-body>"
+                                 "</ac:link> and <a href=\"https://x.org\">x</a><sup>1</sup>.</p>")
+    assert ('<ac:structured-macro ac:name="warning"><ac:rich-text-body><p>Hot &amp; <strong>loud</strong></p>'
+            in intro.body)
+    assert ('<ac:parameter ac:name="language">bash</ac:parameter><ac:plain-text-body><![CDATA[make <all>]]>'
+            in intro.body)
+    assert ('<p><strong>Pump</strong></p><p><ac:image ac:alt="Pump front"><ri:attachment ri:filename="pump.png"/>'
+            in intro.body)
+    assert intro.body.endswith("<hr/><ol><li>Sold apart.</li></ol>") and intro.attachments == ["pump.png"]
+    assert "<ol><li><p>Open the <strong>cover</strong></p><p>Use gloves.</p></li></ol>" in wiring.body
+    assert ("<tr><th><p>Wire</p></th><th><p>Colour</p></th></tr><tr><td rowspan=\"2\"><p>L1</p></td>"
+            in wiring.body and '<td colspan="2"><p>Earth</p></td>' in wiring.body)
+    assert appendix.body == '<ac:structured-macro ac:name="children"/>'
+    assert export.images == {"pump.png": b"PNG"}
+
+    again = ConfluenceExport.read_zip(export.write_zip(tmp_path / "pages.zip"))
+    assert [(p.title, p.parent, p.body) for p in again.pages] == [(p.title, p.parent, p.body) for p in export.pages]
+    assert again.images == {"pump.png": b"PNG"} and again.pages[0].attachments == ["pump.png"]
+
+
+class _Confluence(BaseHTTPRequestHandler):
+    calls = []
+
+    def log_message(self, *args):
+        pass
+
+    def _answer(self, payload):
+        body = json.dumps(payload).encode("utf-8")
+        self.send_response(200)
+        self.send_header("Content-Type", "application/json")
+        self.send_header("Content-Length", str(len(body)))
+        self.end_headers()
+        self.wfile.write(body)
+
+    def _record(self):
+        length = int(self.headers.get("Content-Length") or 0)
+        data = self.rfile.read(length) if length else b""
+        self.calls.append((self.command, self.path, self.headers.get("Authorization"), data))
+        return data
+
+    def do_GET(self):
+        self._record()
+        if "title=Wiring" in self.path:
+            return self._answer({"results": [{"id": "42", "version": {"number": 3}}]})
+        self._answer({"results": []})
+
+    def do_POST(self):
+        data = self._record()
+        self._answer({"id": "7"} if self.path.endswith("/content") and b"Introduction" in data else {"id": "8"})
+
+    def do_PUT(self):
+        self._record()
+        self._answer({"id": "42"})
+
+
+class _Refusing(_Confluence):
+    """Refuses the POST requests whose path ends with :attr:`refused`."""
+
+    refused, status, body = "/content", 400, b""
+
+    def do_POST(self):
+        self._record()
+        if not self.path.split("?")[0].endswith(self.refused):
+            return self._answer({"id": "7"})
+        self.send_response(self.status)
+        self.send_header("Content-Length", str(len(self.body)))
+        self.end_headers()
+        self.wfile.write(self.body)
+
+
+@contextmanager
+def _fake_confluence(handler=_Confluence):
+    handler.calls = []
+    server = ThreadingHTTPServer(("127.0.0.1", 0), handler)
+    threading.Thread(target=server.serve_forever, daemon=True).start()
+    try:
+        yield "http://%s:%d/wiki" % server.server_address[:2]
+    finally:
+        server.shutdown()
+        server.server_close()
+
+
+def test_pages_are_created_updated_and_given_their_images(monkeypatch):
+    export = export_confluence(_context())
+    export.pages = export.pages[:2]
+    with _fake_confluence() as url:
+        settings = ConfluenceSettings.from_mapping({"base_url": url + "/", "space_key": "DOC", "parent_page_id": 5,
+                                                    "username": "me@example.org"})
+        with pytest.raises(ConfluenceError):
+            push_confluence(export, settings)
+        monkeypatch.setenv("CONFLUENCE_TOKEN", "t0k")
+        published = []
+        assert push_confluence(export, settings, on_progress=published.append) == {"p1": "7", "p2": "42"}
+
+    calls = [(method, path.split("?")[0]) for method, path, _auth, _data in _Confluence.calls]
+    assert calls == [("GET", "/wiki/rest/api/content"), ("POST", "/wiki/rest/api/content"),
+                     ("GET", "/wiki/rest/api/content/7/child/attachment"),
+                     ("POST", "/wiki/rest/api/content/7/child/attachment"),
+                     ("GET", "/wiki/rest/api/content"), ("PUT", "/wiki/rest/api/content/42")]
+    created = json.loads(_Confluence.calls[1][3])
+    assert created["space"] == {"key": "DOC"} and created["ancestors"] == [{"id": "5"}]
+    assert created["body"]["storage"]["representation"] == "storage"
+    updated = json.loads(_Confluence.calls[5][3])
+    assert updated["version"] == {"number": 4} and updated["ancestors"] == [{"id": "7"}]
+    assert b'filename="pump.png"' in _Confluence.calls[3][3]
+    assert _Confluence.calls[0][2] == "Basic bWVAZXhhbXBsZS5vcmc6dDBr"
+    assert published == ["Published “Introduction”", "Published “Wiring”"]
+
+
+def test_failed_pushes_stop_with_the_reason(monkeypatch):
+    monkeypatch.setenv("CONFLUENCE_TOKEN", "t0k")
+    export = export_confluence(_context())
+    export.pages = export.pages[:2]
+    published = []
+
+    monkeypatch.setattr(_Refusing, "body", b'{"message": "A page with this title already exists"}')
+    with _fake_confluence(_Refusing) as url:
+        settings = ConfluenceSettings.from_mapping({"base_url": url, "space_key": "DOC"})
+        with pytest.raises(ConfluenceError, match=r"refused POST content \(400\): A page with this title already"):
+            push_confluence(export, settings, on_progress=published.append)
+    assert [method for method, *_ in _Refusing.calls] == ["GET", "POST"] and not published
+
+    monkeypatch.setattr(_Refusing, "refused", "/child/attachment")
+    monkeypatch.setattr(_Refusing, "status", 500)
+    monkeypatch.setattr(_Refusing, "body", b"Internal error")
+    with _fake_confluence(_Refusing) as url:
+        settings = ConfluenceSettings.from_mapping({"base_url": url, "space_key": "DOC"})
+        with pytest.raises(ConfluenceError, match=r"refused POST content/7/child/attachment \(500\): Internal error"):
+            push_confluence(export, settings, on_progress=published.append)
+    assert len(_Refusing.calls) == 4 and not published  # the second page is not pushed
+
+    with pytest.raises(ConfluenceError, match="Cannot reach Confluence"):
+        push_confluence(export, settings)  # the server is gone
+    with pytest.raises(ConfluenceError, match=r"Set confluence\.space_key in pipeline\.yml"):
+        push_confluence(export, ConfluenceSettings.from_mapping({"base_url": url}))
@@ -0,0 +1,41 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.content_stats import compute_content_stats, readability, record_content_stats
+from orlando_toolkit.core.models import DitaContext
+
+_SHARED = "Keep the device away from water at all times."
+
+
+def _context() -> DitaContext:
+    topics = {
+        "a.dita": ET.fromstring(
+            "<concept id='a'><title>Install</title><conbody><p>Unpack the box. Plug in the cable.</p>"
+            f"<p>{_SHARED}</p><fig><image href='x.png'/></fig>"
+            "<table><tgroup cols='1'><tbody><row><entry>Cell</entry></row></tbody></tgroup></table>"
+            "</conbody></concept>"),
+        "b.dita": ET.fromstring(
+            f"<concept id='b'><title>Clean</title><conbody><p>Wipe it.</p><p>{_SHARED}</p></conbody></concept>"),
+    }
+    refs = "<topicref href='topics/b.dita'/><topicref href='topics/a.dita'/>"
+    return DitaContext(ditamap_root=ET.fromstring(f"<map>{refs}</map>"), topics=topics)
+
+
+def test_topic_and_map_statistics():
+    stats = compute_content_stats(_context())
+    assert [t.filename for t in stats.topics] == ["b.dita", "a.dita"]
+    a = stats.topic("a.dita")
+    assert (a.title, a.words, a.sentences, a.images, a.tables) == ("Install", 17, 3, 1, 1)
+    assert a.reused_words == 9 and a.reuse == 52.9
+    assert stats.words == 28 and stats.images == 1 and stats.tables == 1
+    assert 0 < stats.readability <= 100
+    data = stats.to_dict()
+    assert data["map"]["topics"] == 2 and data["topics"][1]["reuse"] == 52.9
+
+
+def test_statistics_are_recorded_in_the_report():
+    ctx = _context()
+    record_content_stats(ctx)
+    entry = ctx.report.entries[-1]
+    assert entry.category == "content_stats" and entry.detail["words"] == 28
+    assert [t["filename"] for t in entry.detail["per_topic"]] == ["b.dita", "a.dita"]
+    assert readability(0, 0, 0) is None and readability(10, 1, 10) == 100.0
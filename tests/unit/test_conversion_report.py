@@ -0,0 +1,76 @@
+import copy
+import zipfile
+
+from orlando_toolkit.core.models import ConversionReport, DitaContext
+from orlando_toolkit.core.services.conversion_service import ConversionService
+from orlando_toolkit.core.time_budget import TimeBudget
+
+
+def _make_package(path):
+    with zipfile.ZipFile(path, "w") as zf:
+        zf.writestr("DATA/manual.ditamap",
+                    "<map><topicref href='topics/a.dita'/><topicref href='topics/b.dita'/></map>")
+        zf.writestr("DATA/topics/a.dita", "<concept id='a'><title>A</title><conbody/></concept>")
+        zf.writestr("DATA/topics/b.dita", "<concept id='b'><title>B</title><conbody/></concept>")
+        zf.writestr("DATA/media/pic.png", b"png")
+    return path
+
+
+def test_report_records_entries_in_order_and_serializes():
+    report = ConversionReport()
+    report.warning("fonts", "Missing font", topic="a.dita")
+    report.info("stats", "Done", topics=2)
+    report.mark_partial("time budget exceeded")
+    report.mark_partial("time budget exceeded")
+
+    data = report.to_dict()
+    assert [e["category"] for e in data["entries"]] == ["fonts", "stats"]
+    assert data["counts"] == {"info": 1, "warning": 1, "error": 0}
+    assert data["partial"] is True
+    assert data["partial_reasons"] == ["time budget exceeded"]
+    assert "timings" not in data
+
+    report.record_timing("bidi", 0.5)
+    report.record_timing("bidi", 0.25)
+    assert ConversionReport.from_dict(report.to_dict()).timings == {"bidi": 0.75}
+
+
+def test_report_survives_context_deepcopy():
+    context = DitaContext()
+    context.report.error("io", "boom")
+
+    clone = copy.deepcopy(context)
+
+    assert clone.report.count("error") == 1
+    clone.report.info("x", "y")
+    assert len(context.report) == 1
+
+
+def test_time_budget_unlimited_and_expired():
+    assert not TimeBudget(0).expired
+    assert TimeBudget(None).remaining is None
+
+    now = [100.0]
+    budget = TimeBudget(5, clock=lambda: now[0])
+    assert budget.remaining == 5
+    now[0] = 106.0
+    assert budget.expired and budget.remaining == 0
+
+
+def test_exhausted_budget_yields_partial_import(tmp_path):
+    package = _make_package(tmp_path / "pkg.zip")
+    service = ConversionService()
+
+    full = service.convert(package, {})
+    assert len(full.topics) == 2 and not full.report.partial
+
+    partial = service.convert(package, {}, time_budget=TimeBudget(1e-9))
+    assert partial.report.partial
+    assert partial.report.count("warning", "time_budget") >= 1
+    assert len(partial.topics) < 2
+
+    # Partial output is still written as a complete archive
+    out = tmp_path / "out.zip"
+    service.write_package(partial, out)
+    with zipfile.ZipFile(out) as zf:
+        assert zf.testzip() is None
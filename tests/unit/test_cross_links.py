@@ -0,0 +1,67 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.cross_links import build_set_map, resolve_cross_document_links
+from orlando_toolkit.core.models import DitaContext
+
+
+def _doc(topics):
+    ditamap = ET.fromstring("<map><title>T</title>" + "".join(
+        f"<topicref href='topics/{name}'/>" for name in topics) + "</map>")
+    return DitaContext(ditamap_root=ditamap, topics={name: ET.fromstring(xml) for name, xml in topics.items()})
+
+
+def test_links_between_members_become_scoped_keyrefs():
+    guide = _doc({
+        "intro.dita": "<concept id='intro'><title>Intro</title><conbody>"
+                      "<p><xref href='file:///C:/Docs/Reference%20Manual.docx#Limits' scope='external' format='docx'/></p>"
+                      "<p><xref href='..\\Docs\\reference manual.docx#_Ref42'/></p>"
+                      "<p><xref href='Reference Manual.docx#Missing'/></p>"
+                      "<p><xref href='Guide.docx#Setup'/></p>"
+                      "<p><xref href='https://example.com/Guide.docx' scope='external'/></p>"
+                      "</conbody></concept>",
+        "setup.dita": "<concept id='setup' data-anchor='Setup'><title>Setup</title></concept>",
+    })
+    reference = _doc({
+        "overview.dita": "<concept id='overview'><title>Overview</title></concept>",
+        "limits.dita": "<concept id='limits' data-anchor='Limits'><title>Limits</title>"
+                       "<conbody><table id='_Ref42'/></conbody></concept>",
+    })
+
+    result = resolve_cross_document_links([("in/Guide.docx", guide), ("in/Reference Manual.docx", reference)])
+
+    refs = [x.get("keyref") for x in guide.topics["intro.dita"].iter("xref")]
+    assert refs == ["Reference_Manual.limits", "Reference_Manual.limits/_Ref42",
+                    "Reference_Manual.overview", "setup", None]
+    first = guide.topics["intro.dita"].find(".//xref")
+    assert first.get("href") is None and first.get("scope") is None
+    assert result.resolved == 4 and result.unresolved_bookmarks == [("Guide.docx", "Reference Manual.docx#Missing")]
+    keydefs = {k.get("keys"): k.get("href") for k in reference.ditamap_root.iter("keydef")}
+    assert keydefs == {"limits": "topics/limits.dita", "overview": "topics/overview.dita"}
+    assert guide.ditamap_root[1].tag == "keydef" and guide.metadata["key_scope"] == "Guide"
+    assert guide.report.count("warning", "cross_links") == 1
+
+    root = build_set_map([("Guide.docx", guide), ("Reference Manual.docx", reference)], "Library")
+    assert [(m.get("href"), m.get("keyscope")) for m in root.iter("mapref")] == [
+        ("Guide.ditamap", "Guide"), ("Reference_Manual.ditamap", "Reference_Manual")]
+
+
+def test_convert_set_links_members_converted_together(tmp_path):
+    import zipfile
+
+    from orlando_toolkit.core.services.conversion_service import ConversionService
+
+    def _package(name, topic):
+        path = tmp_path / name
+        with zipfile.ZipFile(path, "w") as zf:
+            zf.writestr("DATA/m.ditamap", "<map><topicref href='topics/t.dita'/></map>")
+            zf.writestr("DATA/topics/t.dita", topic)
+        return path
+
+    first = _package("first.zip", "<concept id='t'><title>A</title><conbody>"
+                                  "<p><xref href='second.zip#b' scope='external'/></p></conbody></concept>")
+    second = _package("second.zip", "<concept id='b'><title>B</title></concept>")
+
+    contexts = ConversionService().convert_set([first, second], {})
+    xref = next(iter(contexts[0].topics.values())).find(".//xref")
+    assert xref.get("keyref", "").startswith("second.")
+    assert [c.metadata["manual_title"] for c in contexts] == ["first", "second"]
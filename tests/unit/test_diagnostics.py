@@ -0,0 +1,47 @@
+import json
+import logging
+
+from orlando_toolkit.core.diagnostics import (capture_log_warnings, entry_location, export_diagnostics,
+                                              filter_entries, merge_log_entries)
+from orlando_toolkit.core.models import ConversionReport
+
+
+def test_logged_warnings_join_the_report_once():
+    report = ConversionReport()
+    report.warning("ole_objects", "2 object(s) could not be placed", paragraphs=[12, 40])
+    with capture_log_warnings() as logged:
+        logging.getLogger("orlando_docx_plugin.styles").warning("Unknown style %r", "Heading X")
+        logging.getLogger("orlando_toolkit.core.ole_objects").warning("2 object(s) could not be placed")
+        logging.getLogger("orlando_toolkit.core.tables").info("Not collected")
+        for _ in range(25):
+            logging.getLogger("orlando_toolkit.core.word_media").error("Picture too large")
+    logging.getLogger("orlando_toolkit").warning("After the conversion")
+
+    assert merge_log_entries(report, logged) == 3
+    style, picture, repeats = report.entries[1:]
+    assert (style.severity, style.category, style.message) == ("warning", "log", "Unknown style 'Heading X'")
+    assert style.detail == {"logger": "orlando_docx_plugin.styles"}
+    assert picture.severity == "error" and report.count("error", "log") == 1
+    assert repeats.severity == "info" and repeats.detail["repeats"] == 25
+    assert entry_location(report.entries[0]) == "¶ 12, 40" and entry_location(style) == ""
+
+
+def test_console_filters_and_json_export(tmp_path):
+    report = ConversionReport()
+    report.info("captions", "3 caption(s) became titles", topic="pump.dita")
+    report.warning("footnotes", "1 note(s) could not be placed", topic="pump.dita", paragraph=7)
+    report.error("log", "Image image3.emf could not be read", logger="orlando_docx_plugin")
+
+    assert [e.category for e in filter_entries(report.entries, ["warning", "error"])] == ["footnotes", "log"]
+    assert [e.category for e in filter_entries(report.entries, category="captions")] == ["captions"]
+    assert [e.category for e in filter_entries(report.entries, text="PUMP")] == ["captions", "footnotes"]
+    assert report.entries[1].paragraphs == [7]
+
+    shown = filter_entries(report.entries, ["error"])
+    data = json.loads(export_diagnostics(report, tmp_path / "log.json", entries=shown,
+                                         source="manual.docx").read_text(encoding="utf-8"))
+    assert data["source"] == "manual.docx" and data["counts"] == {"info": 0, "warning": 0, "error": 1}
+    assert data["entries"] == [{"severity": "error", "category": "log", "message": "Image image3.emf could not be "
+                                "read", "detail": {"logger": "orlando_docx_plugin"}}]
+    full = json.loads(export_diagnostics(report, tmp_path / "all.json").read_text(encoding="utf-8"))
+    assert full == ConversionReport.from_dict(full).to_dict() and len(full["entries"]) == 3
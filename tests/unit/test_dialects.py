@@ -0,0 +1,126 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.dialects import (
+    convert_map,
+    convert_topic,
+    dialect_issues,
+    map_doctype,
+    output_dialect,
+    topic_doctype,
+)
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.package_utils import save_dita_package
+from orlando_toolkit.core.services.validation_service import ValidationService, ValidationSettings
+
+
+def _task() -> ET._Element:
+    return ET.fromstring(
+        "<task id='t'><title>Replace the filter</title><titlealts><navtitle>Filter</navtitle></titlealts>"
+        "<taskbody><steps><step><cmd>Open the <uicontrol>cover</uicontrol>.</cmd>"
+        "<info>Wear gloves.</info></step></steps>"
+        "<result audience='expert'>Done <image href='../media/a.png' alt='Filter'/></result></taskbody></task>")
+
+
+def test_output_dialect_from_metadata_with_aliases():
+    assert output_dialect({"dialect": "lwdita"}) == "xdita"
+    assert output_dialect({"dialect": "2.0"}) == "dita-2.0"
+    assert output_dialect({"dialect": "docbook"}) == "dita-1.3"
+
+
+def test_dita13_is_written_unchanged():
+    task = _task()
+    assert convert_topic(task, "dita-1.3") is task
+    assert 'DTD DITA Task//EN' in topic_doctype(task, "dita-1.3")
+
+
+def test_dita20_moves_alternative_titles_and_alt_text():
+    task = _task()
+    changes = []
+    converted = convert_topic(task, "dita-2.0", changes)
+
+    assert converted is not task and task.find("titlealts") is not None
+    assert converted.find("titlealts") is None
+    assert converted.find("prolog/navtitle").text == "Filter"
+    assert [c.tag for c in converted][:3] == ["title", "prolog", "taskbody"]
+    image = converted.find(".//image")
+    assert image.get("alt") is None and image.findtext("alt") == "Filter"
+    assert "titlealts moved to the prolog" in changes
+    assert dialect_issues(converted, "dita-2.0") == []
+    assert dialect_issues(task, "dita-2.0")
+    assert "DITA 2.0 Task" in topic_doctype(converted, "dita-2.0")
+
+
+def test_dita20_map_navtitles_and_chunk_values():
+    root = ET.fromstring("<map><topicref href='topics/a.dita' navtitle='A' chunk='to-content' print='no'/>"
+                         "<anchor id='x'/></map>")
+    converted = convert_map(root, "dita-2.0")
+
+    ref = converted.find("topicref")
+    assert ref.findtext("topicmeta/navtitle") == "A" and ref.get("navtitle") is None
+    assert ref.get("chunk") == "combine" and ref.get("print") is None
+    assert converted.find("anchor") is None
+
+
+def test_xdita_topic_uses_lightweight_elements():
+    changes = []
+    converted = convert_topic(_task(), "xdita", changes)
+
+    assert converted.tag == "topic" and converted.find("body") is not None
+    li = converted.find("body/ol/li")
+    assert li.find("p/ph").get("outputclass") == "uicontrol"
+    assert li.find("div/p").text == "Wear gloves."
+    result = converted.find("body/section")
+    assert result.get("props") == "audience(expert)"
+    assert result.find("p").text.strip() == "Done"
+    assert result.find("p/image/alt").text == "Filter"
+    assert converted.find("titlealts") is None
+    assert dialect_issues(converted, "xdita") == []
+    assert "LIGHTWEIGHT DITA Topic" in topic_doctype(converted, "xdita")
+    assert any("not in XDITA" in change for change in changes)
+
+
+def test_xdita_tables_become_simpletables():
+    topic = ET.fromstring(
+        "<reference id='r'><title>Specs</title><refbody><table><title>Sizes</title><tgroup cols='2'>"
+        "<thead><row><entry>Part</entry><entry>Size</entry></row></thead>"
+        "<tbody><row><entry namest='c1' nameend='c2'>Bolt</entry></row></tbody></tgroup></table></refbody>"
+        "</reference>")
+    changes = []
+    converted = convert_topic(topic, "xdita", changes)
+
+    table = converted.find("body/simpletable")
+    assert table.findtext("title") == "Sizes"
+    assert [e.findtext("p") for e in table.iter("stentry")] == ["Part", "Size", "Bolt"]
+    assert "table cell spans dropped (simpletable has none)" in changes
+
+
+def test_xdita_map_has_no_headings():
+    root = ET.fromstring("<map><title>Manual</title><topichead navtitle='Part 1'>"
+                         "<topicref href='topics/a.dita'/></topichead><reltable/></map>")
+    converted = convert_map(root, "xdita")
+
+    assert converted.findtext("topicmeta/navtitle") == "Manual" and converted.find("title") is None
+    head = converted.find("topicref")
+    assert head.get("href") is None and head.findtext("topicmeta/navtitle") == "Part 1"
+    assert converted.find("reltable") is None
+    assert "lw-map.dtd" in map_doctype("xdita", bookmap=True)
+
+
+def test_package_written_in_dialect_keeps_context(tmp_path):
+    ctx = DitaContext(ditamap_root=ET.fromstring("<map><title>M</title><topicref href='topics/t.dita'/></map>"),
+                      topics={"t.dita": _task()},
+                      metadata={"manual_code": "m", "manual_title": "M", "dialect": "xdita"})
+    save_dita_package(ctx, str(tmp_path))
+
+    written = (tmp_path / "DATA" / "topics" / "t.dita").read_text(encoding="utf-8")
+    assert "lw-topic.dtd" in written and "<taskbody" not in written
+    assert "lw-map.dtd" in (tmp_path / "DATA" / "m.ditamap").read_text(encoding="utf-8")
+    assert ctx.topics["t.dita"].tag == "task"
+
+
+def test_validation_checks_the_written_dialect():
+    ctx = DitaContext(topics={"t.dita": _task()}, metadata={"dialect": "xdita"})
+    report = ValidationService(ValidationSettings()).validate(ctx)
+
+    assert report.grammar.startswith("XDITA")
+    assert report.ok, report.issues
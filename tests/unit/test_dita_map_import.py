@@ -0,0 +1,105 @@
+import zipfile
+
+from orlando_toolkit.core.importers.dita_importer import DitaPackageImporter
+from orlando_toolkit.core.services.conversion_service import ConversionService
+from orlando_toolkit.core.spool import SpooledBlobs
+
+
+def _write(root, files):
+    for name, content in files.items():
+        path = root / name
+        path.parent.mkdir(parents=True, exist_ok=True)
+        path.write_bytes(content if isinstance(content, bytes) else content.encode("utf-8"))
+
+
+def _entries(node):
+    return [(ref.get("href"), ref.findtext("topicmeta/navtitle"), ref.get("data-level"), _entries(ref))
+            for ref in node if ref.tag in ("topicref", "topichead")]
+
+
+def test_plain_map_with_foreign_layout_is_flattened(tmp_path):
+    _write(tmp_path, {
+        "guide/guide.ditamap": "<map><title>Pump Guide</title>"
+                               "<topicref href='concepts/intro.dita'>"
+                               "<topicref href='tasks/intro.xml' navtitle='Install'/></topicref>"
+                               "<mapref href='parts/more.ditamap'/>"
+                               "<topicref href='concepts/missing.dita'/>"
+                               "<topicref href='https://example.com/x.dita' scope='external'/></map>",
+        "guide/concepts/intro.dita": "<concept id='c'><title>About <b>pumps</b></title><conbody>"
+                                     "<p>See <xref href='../tasks/intro.xml#t/s1'>install</xref>.</p>"
+                                     "<image href='../images/pic.png'/></conbody></concept>",
+        "guide/tasks/intro.xml": "<task id='t'><title>Install</title><taskbody><steps><step id='s1'>"
+                                 "<cmd>Go</cmd></step></steps></taskbody></task>",
+        "guide/parts/more.ditamap": "<map><topicref href='../../shared/care.dita'/></map>",
+        "shared/care.dita": "<concept id='k'><title>Care</title><conbody>"
+                            "<p conref='../guide/concepts/intro.dita#c/p1'/><image href='pic.png'/>"
+                            "</conbody></concept>",
+        "guide/images/pic.png": b"one",
+        "shared/pic.png": b"two",
+    })
+    map_path = tmp_path / "guide" / "guide.ditamap"
+    assert ConversionService().can_handle_file(map_path)
+
+    context = DitaPackageImporter().import_package(map_path, {})
+
+    assert _entries(context.ditamap_root) == [
+        ("topics/intro.dita", "About pumps", "1", [("topics/intro_2.dita", "Install", "2", [])]),
+        ("topics/care.dita", "Care", "1", []),
+        ("concepts/missing.dita", None, "1", []),
+        ("https://example.com/x.dita", None, "1", []),
+    ]
+    assert list(context.topics) == ["intro.dita", "intro_2.dita", "care.dita"]
+    intro, care = context.topics["intro.dita"], context.topics["care.dita"]
+    assert intro.find("conbody/p/xref").get("href") == "intro_2.dita#t/s1"
+    assert intro.find("conbody/image").get("href") == "../media/pic.png"
+    assert care.find("conbody/p").get("conref") == "intro.dita#c/p1"
+    assert care.find("conbody/image").get("href") == "../media/pic_2.png"
+    assert context.images == {"pic.png": b"one", "pic_2.png": b"two"}
+    assert context.metadata["source_type"] == "dita_map" and context.metadata["manual_title"] == "Pump Guide"
+    assert any("concepts/missing.dita" in e.message for e in context.report.entries if e.category == "dita_import")
+
+
+def test_exported_package_loads_unchanged_and_stays_inside_the_archive(tmp_path):
+    archive = tmp_path / "manual.zip"
+    with zipfile.ZipFile(archive, "w") as zf:
+        zf.writestr("DATA/manual.ditamap",
+                    "<map><title>Manual</title><topicref href='topics/a.dita' data-level='2'>"
+                    "<topicmeta><navtitle>Alpha</navtitle></topicmeta></topicref>"
+                    "<topicref href='topics/../../../outside.dita'/></map>")
+        zf.writestr("DATA/topics/a.dita", "<concept id='a'><title>A</title><conbody>"
+                                          "<image href='../media/logo.png'/></conbody></concept>")
+        zf.writestr("DATA/media/logo.png", b"logo")
+    (tmp_path / "outside.dita").write_text("<concept id='o'><title>O</title></concept>")
+
+    context = DitaPackageImporter().import_package(archive, {"manual_title": "Manual"})
+
+    assert _entries(context.ditamap_root) == [
+        ("topics/a.dita", "Alpha", "2", []),
+        ("topics/../../../outside.dita", None, "1", []),
+    ]
+    assert list(context.topics) == ["a.dita"] and context.images == {"logo.png": b"logo"}
+    assert context.topics["a.dita"].find("conbody/image").get("href") == "../media/logo.png"
+    assert context.metadata["source_type"] == "dita_package"
+
+
+def test_media_beyond_the_memory_budget_are_held_on_disk(tmp_path):
+    archive = tmp_path / "manual.zip"
+    with zipfile.ZipFile(archive, "w") as zf:
+        zf.writestr("DATA/manual.ditamap", "<map><title>Manual</title><topicref href='topics/a.dita'/></map>")
+        zf.writestr("DATA/topics/a.dita", "<concept id='a'><title>A</title><conbody>"
+                                          "<image href='../media/logo.png'/></conbody></concept>")
+        zf.writestr("DATA/media/logo.png", b"p" * 600_000)
+        zf.writestr("DATA/media/clip.mp4", b"v" * 600_000)
+    metadata = {"manual_title": "Manual", "pipeline": {"memory_budget_mb": 1,
+                                                       "low_memory": {"spool_dir": str(tmp_path / "spool")}}}
+
+    context = DitaPackageImporter().import_package(archive, metadata)
+
+    # The images fit the budget; the videos on top of them do not
+    assert isinstance(context.images, dict) and len(context.images["logo.png"]) == 600_000
+    assert isinstance(context.videos, SpooledBlobs) and context.videos["clip.mp4"] == b"v" * 600_000
+    assert [e.detail.get("kind") for e in context.report.entries if e.category == "low_memory"] == ["video"]
+
+    metadata["pipeline"]["memory_budget_mb"] = 0
+    context = DitaPackageImporter().import_package(archive, metadata)
+    assert isinstance(context.videos, dict) and not any(e.category == "low_memory" for e in context.report.entries)
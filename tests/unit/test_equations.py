@@ -0,0 +1,88 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core import external_tools
+from orlando_toolkit.core.equations import MATHML_NS, omml_to_mathml, restore_word_equations
+from orlando_toolkit.core.models import DitaContext
+
+W = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+M = "http://schemas.openxmlformats.org/officeDocument/2006/math"
+NS = f'xmlns:w="{W}" xmlns:m="{M}"'
+
+
+def _r(text):
+    return f"<m:r><m:t>{text}</m:t></m:r>"
+
+
+INLINE = f"<m:oMath>{_r('E=m')}<m:sSup><m:e>{_r('c')}</m:e><m:sup>{_r('2')}</m:sup></m:sSup></m:oMath>"
+DISPLAY = (f"<m:oMathPara><m:oMath>{_r('x=')}<m:f><m:num>{_r('-b±')}<m:rad><m:radPr><m:degHide m:val='1'/>"
+           f"</m:radPr><m:deg/><m:e>{_r('Δ')}</m:e></m:rad></m:num><m:den>{_r('2a')}</m:den></m:f>"
+           "</m:oMath></m:oMathPara>")
+
+
+BODY = (f'<w:p><w:r><w:t xml:space="preserve">Energy </w:t></w:r>{INLINE}'
+        f'<w:r><w:t xml:space="preserve"> holds.</w:t></w:r></w:p><w:p>{DISPLAY}</w:p>'
+        '<w:p><w:r><w:t>After.</w:t></w:r></w:p>')
+
+
+def _context(body):
+    topic = ET.fromstring(f"<concept id='c'><title>C</title><conbody>{body}</conbody></concept>")
+    return DitaContext(ditamap_root=ET.fromstring("<map><topicref href='topics/c.dita'/></map>"),
+                       topics={"c.dita": topic})
+
+
+def _names(el):
+    return [e.tag.split("}")[-1] for e in el.iter() if isinstance(e.tag, str)]
+
+
+def test_omml_structures_become_mathml():
+    nary = (f"<m:oMath {NS}><m:nary><m:naryPr><m:chr m:val='∑'/></m:naryPr><m:sub>{_r('i=1')}</m:sub>"
+            f"<m:sup>{_r('n')}</m:sup><m:e>{_r('i')}</m:e></m:nary><m:d><m:e>{_r('a')}</m:e><m:e>{_r('b')}</m:e>"
+            f"</m:d><m:func><m:fName><m:r><m:rPr><m:sty m:val='p'/></m:rPr><m:t>sin</m:t></m:r></m:fName>"
+            f"<m:e>{_r('x')}</m:e></m:func></m:oMath>")
+    math = omml_to_mathml(ET.fromstring(nary))
+    assert math.tag == f"{{{MATHML_NS}}}math" and math.get("display") == "inline"
+    names = _names(math)
+    assert "munderover" in names and names.count("mo") >= 5
+    assert [e.text for e in math.iter(f"{{{MATHML_NS}}}mo") if e.get("fence")] == ["(", ")"]
+    assert math.find(f".//{{{MATHML_NS}}}mi[@mathvariant='normal']").text == "sin"
+
+    block = omml_to_mathml(ET.fromstring(f"<w:p {NS}>{DISPLAY}</w:p>")[0])
+    assert block.get("display") == "block"
+    assert {"mfrac", "msqrt"} <= set(_names(block))
+
+
+def test_equations_replace_garbled_text_or_fill_dropped_spots(tmp_path, docx_factory):
+    path = docx_factory(tmp_path / "eq.docx", BODY, namespaces=NS)
+    ctx = _context("<p>Energy E=mc<sup>2</sup> holds.</p><p>x=-b±Δ2a</p><p>After.</p>")
+    assert restore_word_equations(path, ctx, {}) == 2
+    body = ctx.topics["c.dita"].find("conbody")
+    first = body[0]
+    assert [c.tag for c in first] == ["equation-inline"] and first.text == "Energy "
+    assert first[0].tail == " holds." and first.find("equation-inline/mathml") is not None
+    assert body[1].tag == "equation-block" and body[2].text == "After."
+
+    ctx = _context("<p>Energy holds.</p><p>After.</p>")
+    assert restore_word_equations(path, ctx, {}) == 2
+    body = ctx.topics["c.dita"].find("conbody")
+    assert [c.tag for c in body] == ["p", "equation-block", "p"]
+    assert body[0][0].tail == " holds."
+
+
+def test_placeholders_and_png_fallback(tmp_path, monkeypatch, docx_factory):
+    path = docx_factory(tmp_path / "eq.docx", BODY, namespaces=NS)
+
+    class _Executor:
+        def workspace(self):
+            return external_tools.ToolWorkspace()
+
+        def run(self, tool, args, *, workspace, check=False, **kwargs):
+            (workspace.path / args[1]).write_bytes(b"\x89PNG")
+
+    monkeypatch.setattr(external_tools, "ToolExecutor", _Executor)
+    ctx = _context("<p>See <ph data-equation='2'/> and <ph data-equation='9'/>.</p>")
+    assert restore_word_equations(path, ctx, {"fallback_tool": "mathml2png"}) == 1
+    p = ctx.topics["c.dita"].find(".//p")
+    image = p.find("equation-block/image")
+    assert image.get("href") == "../media/equation_2.png" and ctx.images["equation_2.png"] == b"\x89PNG"
+    assert p.find("ph") is None and p.find("equation-block").tail == " and ."
+    assert any(e.severity == "warning" and "could not be placed" in e.message for e in ctx.report.entries)
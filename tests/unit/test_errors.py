@@ -0,0 +1,50 @@
+import struct
+import zipfile
+
+import pytest
+
+from orlando_toolkit.api import ConversionError
+from orlando_toolkit.core.archive_limits import ArchiveLimitError, ArchiveLimits, check_archive
+from orlando_toolkit.core.errors import ContentError, SourceLocation, describe_error, report_error
+from orlando_toolkit.core.importers.dita_importer import DitaImportError
+from orlando_toolkit.core.models import ConversionReport
+
+
+def _huge_png_archive(path):
+    png = b"\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR" + struct.pack(">II", 50_000, 10) + b"\x08\x02\x00\x00\x00"
+    with zipfile.ZipFile(path, "w") as z:
+        z.writestr("media/big.png", png)
+    return path
+
+
+def test_most_specific_error_in_chain_provides_code_hint_and_location(tmp_path):
+    with pytest.raises(ArchiveLimitError) as info:
+        check_archive(_huge_png_archive(tmp_path / "doc.zip"), ArchiveLimits())
+    limit = info.value
+    assert limit.code == "OTK201"
+    assert limit.location == SourceLocation(file="doc.zip", part="media/big.png")
+
+    wrapped = ConversionError("Could not convert doc.zip: refused",
+                              DitaImportError(f"Archive refused: {limit}", tmp_path / "doc.zip", limit))
+    described = wrapped.info
+    assert (described.code, described.location) == ("OTK201", limit.location)
+    assert wrapped.code == "OTK201" and "archive_limits" in wrapped.hint
+    assert described.message.startswith("Could not convert doc.zip")
+    text = described.format()
+    assert "Where: doc.zip › media/big.png" in text and text.endswith("Error code: OTK201")
+
+
+def test_uncoded_errors_fall_back_to_generic_code():
+    described = describe_error(KeyError("styles"))
+    assert described.code == "OTK000" and described.hint and described.type == "KeyError"
+    assert describe_error(DitaImportError("No .ditamap file found in package")).code == "OTK102"
+
+
+def test_report_error_records_code_hint_and_location():
+    report = ConversionReport()
+    error = ContentError("Heading 2 used inside a table", code="OTK401",
+                         location=SourceLocation(file="guide.docx", topic="install.dita", element="table/row[2]/entry"))
+    entry = report_error(report, error, "structure", severity="warning")
+    assert (entry.severity, entry.topic, entry.code) == ("warning", "install.dita", "OTK401")
+    assert entry.hint == "Move the heading out of the table."
+    assert entry.to_dict()["detail"]["location"]["element"] == "table/row[2]/entry"
@@ -0,0 +1,69 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.escaping import EscapingPolicy
+from orlando_toolkit.core.utils import save_minified_xml_file, save_xml_file
+
+_DOCTYPE = '<!DOCTYPE concept PUBLIC "-//OASIS//DTD DITA Concept//EN" "concept.dtd">'
+
+
+def _topic() -> ET._Element:
+    root = ET.Element("concept", id="t")
+    title = ET.SubElement(root, "title")
+    title.text = "Caf\u00e9 \u00a0\u2013 <ok>"
+    title.set("outputclass", "é")
+    return root
+
+
+def test_raw_utf8_is_the_default(tmp_path):
+    path = tmp_path / "t.dita"
+    save_minified_xml_file(_topic(), str(path), _DOCTYPE)
+    assert "Café" in path.read_text(encoding="utf-8")
+
+
+def test_numeric_references_in_text_and_attributes(tmp_path):
+    path = tmp_path / "t.dita"
+    policy = EscapingPolicy.from_mapping({"characters": "numeric"})
+    save_minified_xml_file(_topic(), str(path), _DOCTYPE, escaping=policy)
+
+    content = path.read_bytes().decode("ascii")
+    assert "Caf&#xE9; &#xA0;&#x2013; &lt;ok&gt;" in content
+    assert 'outputclass="&#xE9;"' in content
+    # References round-trip to the same text
+    body = content.split(_DOCTYPE, 1)[1]
+    assert ET.fromstring(body.encode()).find("title").text == "Caf\u00e9 \u00a0\u2013 <ok>"
+
+
+def test_named_entities_only_where_listed(tmp_path):
+    path = tmp_path / "t.dita"
+    policy = EscapingPolicy.from_mapping(
+        {"characters": "named", "numeric_format": "decimal", "named_entities": ["eacute", "&nbsp;", "bogus"]}
+    )
+    assert policy.named_entities == frozenset({"eacute", "nbsp"})
+    save_xml_file(_topic(), str(path), _DOCTYPE, escaping=policy)
+
+    content = path.read_bytes().decode("ascii")
+    assert "Caf&eacute; &nbsp;&#8211;" in content
+
+
+def test_numeric_characters_are_referenced_even_in_utf8_mode(tmp_path):
+    path = tmp_path / "t.dita"
+    policy = EscapingPolicy.from_mapping({"numeric_characters": ["U+00A0"], "numeric_format": "decimal"})
+    save_minified_xml_file(_topic(), str(path), _DOCTYPE, escaping=policy)
+
+    content = path.read_text(encoding="utf-8")
+    assert "Caf\u00e9 &#160;\u2013" in content
+
+
+def test_comments_instructions_and_cdata_keep_their_characters(tmp_path):
+    path = tmp_path / "t.dita"
+    root = _topic()
+    root.insert(0, ET.Comment(" relu par Zoë "))
+    root.insert(1, ET.ProcessingInstruction("note", "déjà"))
+    ET.SubElement(root, "codeblock").text = ET.CDATA("ü > 1")
+    policy = EscapingPolicy.from_mapping({"characters": "numeric"})
+    save_xml_file(root, str(path), _DOCTYPE, escaping=policy)
+
+    content = path.read_text(encoding="utf-8")
+    assert "<!-- relu par Zoë -->" in content and "<?note déjà?>" in content
+    assert "<![CDATA[ü > 1]]>" in content
+    assert "Caf&#xE9;" in content and 'outputclass="&#xE9;"' in content
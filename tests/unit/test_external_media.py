@@ -0,0 +1,64 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.external_media import ExternalMediaSettings, externalize_media, mark_external
+from orlando_toolkit.core.image_naming import ImageNaming
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.package_utils import update_image_references_and_names
+
+
+def _context(metadata=None):
+    topic = ET.fromstring("<concept id='t1'><title>Setup</title><conbody><image href='../media/wiring.png'/>"
+                          "<image href='../media/logo.png'/><object data='../media/tour.mp4'/>"
+                          "<image href='https://cdn.example.org/wiring.png'/></conbody></concept>")
+    root = ET.fromstring("<map><topicref href='topics/setup.dita'><topicmeta><navtitle>Setup</navtitle>"
+                         "</topicmeta></topicref></map>")
+    ctx = DitaContext(ditamap_root=root, topics={"setup.dita": topic},
+                      images={"wiring.png": b"W" * 4096, "logo.png": b"L"},
+                      metadata={"manual_code": "PM 1", **(metadata or {})})
+    ctx.videos = {"tour.mp4": b"V"}
+    return ctx
+
+
+def test_selected_media_get_the_template_url_and_leave_the_package():
+    ctx = _context()
+    mark_external(ctx.metadata, "logo.png")
+    settings = ExternalMediaSettings.from_mapping({"base_url": "https://assets.example.org/docs/",
+                                                   "url_template": "{base_url}/{manual_code}/{kind}/{name}",
+                                                   "files": ["*.MP4"]})
+    urls = externalize_media(ctx, settings)
+    assert urls == {"logo.png": "https://assets.example.org/docs/PM%201/images/logo.png",
+                    "tour.mp4": "https://assets.example.org/docs/PM%201/videos/tour.mp4"}
+    body = ctx.topics["setup.dita"].find("conbody")
+    assert [(i.get("href"), i.get("scope")) for i in body.iter("image")] == [
+        ("../media/wiring.png", None), ("https://assets.example.org/docs/PM%201/images/logo.png", "external"),
+        ("https://cdn.example.org/wiring.png", None)]
+    assert body.find("object").get("data") == "https://assets.example.org/docs/PM%201/videos/tour.mp4"
+    assert list(ctx.images) == ["wiring.png"] and ctx.videos == {}
+    entry = [e for e in ctx.report.entries if e.category == "external_media"][0]
+    assert entry.detail["files"] == ["logo.png", "tour.mp4"]
+
+    ctx = _context()
+    by_size = ExternalMediaSettings.from_mapping({"base_url": "https://a.org", "min_size_kb": 4,
+                                                  "url_template": "{base_url}/{hash}{ext}", "keep_in_package": True})
+    assert list(externalize_media(ctx, by_size)) == ["wiring.png"] and len(ctx.images) == 2
+    assert externalize_media(_context({"external_media": ["logo.png"]}), ExternalMediaSettings()) == {}
+
+
+def test_templates_are_checked_and_marks_follow_image_renaming(monkeypatch):
+    assert ExternalMediaSettings.validate("{base_url}/{stem}{ext}") is None
+    assert ExternalMediaSettings.validate("{base_url}/{section}") == "Unknown placeholder {section}"
+    assert ExternalMediaSettings.validate("{base_url}/static.png").startswith("The template must contain")
+    assert ExternalMediaSettings.from_mapping({"url_template": "{base_url", "min_size_kb": "big"}) == \
+        ExternalMediaSettings()
+
+    monkeypatch.setattr(ImageNaming, "from_config", classmethod(lambda cls: cls()))
+    ctx = _context({"image_naming": {"pattern": "fig_{name}"}})
+    mark_external(ctx.metadata, "wiring.png")
+    mark_external(ctx.metadata, "logo.png")
+    mark_external(ctx.metadata, "logo.png", False)
+    ctx = update_image_references_and_names(ctx)
+    assert ctx.metadata["external_media"] == ["fig_wiring.png"]
+    assert externalize_media(ctx, ExternalMediaSettings(base_url="https://a.org")) == {
+        "fig_wiring.png": "https://a.org/fig_wiring.png"}
+    mark_external(ctx.metadata, "fig_wiring.png", False)
+    assert "external_media" not in ctx.metadata
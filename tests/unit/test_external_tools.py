@@ -0,0 +1,69 @@
+import sys
+
+import pytest
+
+from orlando_toolkit.core.cancellation import CancellationToken, OperationCancelledError
+from orlando_toolkit.core.external_tools import (
+    ToolExecutionError,
+    ToolExecutor,
+    ToolNotFoundError,
+    ToolPolicy,
+    ToolTimeoutError,
+)
+
+_PY = {"python": sys.executable}
+
+
+def test_tool_runs_in_workspace_with_restricted_environment(tmp_path, monkeypatch):
+    monkeypatch.setenv("SECRET_TOKEN", "hunter2")
+    (tmp_path / "in.txt").write_text("hello", encoding="utf-8")
+    executor = ToolExecutor(ToolPolicy(tools=_PY))
+    script = ("import os, pathlib; print(os.getcwd() == os.environ['HOME'].rsplit(os.sep, 1)[0], "
+              "'SECRET_TOKEN' in os.environ, pathlib.Path('in.txt').read_text(), os.environ['EXTRA'])")
+    with executor.workspace() as ws:
+        name = ws.add(tmp_path / "in.txt")
+        result = executor.run("python", ["-c", script], workspace=ws, env={"EXTRA": "x"})
+        assert name == "in.txt"
+    assert result.ok and result.stdout.split() == ["True", "False", "hello", "x"]
+    assert not ws.path.exists()
+
+
+def test_arguments_outside_workspace_and_unlisted_tools_are_refused(tmp_path):
+    executor = ToolExecutor(ToolPolicy(tools=_PY, allow_unlisted=False))
+    with executor.workspace() as ws:
+        with pytest.raises(ToolExecutionError, match="outside the tool workspace"):
+            executor.run("python", [str(tmp_path / "x.txt")], workspace=ws)
+        with pytest.raises(ToolExecutionError, match="outside"):
+            executor.run("python", [f"--out={tmp_path}"], workspace=ws)
+        for arg in ("../../etc/passwd", "--out=../x", "-o:../x", "sub/../../x"):
+            with pytest.raises(ToolExecutionError, match="outside"):
+                executor.run("python", [arg], workspace=ws)
+        assert executor.run("python", ["-c", "pass", "--format=html5", "out/html5"], workspace=ws).ok
+        with pytest.raises(ToolNotFoundError, match="not allowed"):
+            executor.run("soffice", ["--version"], workspace=ws)
+
+
+def test_timeout_and_cancellation_stop_the_tool():
+    executor = ToolExecutor(ToolPolicy(tools=_PY))
+    with executor.workspace() as ws:
+        with pytest.raises(ToolTimeoutError):
+            executor.run("python", ["-c", "import time; time.sleep(30)"], workspace=ws, timeout=0.3)
+        token = CancellationToken()
+        token.cancel()
+        with pytest.raises(OperationCancelledError):
+            executor.run("python", ["-c", "import time; time.sleep(30)"], workspace=ws, cancel_token=token)
+        with pytest.raises(ToolExecutionError, match="exited with code 3"):
+            executor.run("python", ["-c", "import sys; sys.exit(3)"], workspace=ws, check=True)
+
+
+def test_output_is_capped_while_the_tool_runs():
+    executor = ToolExecutor(ToolPolicy(tools=_PY, max_output_bytes=1000))
+    script = ("import sys\nfor i in range(100000):\n    print('out', i)\n    print('err', i, file=sys.stderr)")
+    lines = []
+    with executor.workspace() as ws:
+        result = executor.run("python", ["-c", script], workspace=ws, on_output=lines.append)
+        assert sorted(p.name for p in ws.path.iterdir()) == ["home", "tmp"]
+    assert result.ok and result.truncated
+    assert len(result.stdout) == len(result.stderr) == 1000
+    assert result.stdout.startswith("out 0\n") and result.stderr.endswith("err 99999\n")
+    assert len(lines) == 200000 and "out 99999" in lines
@@ -0,0 +1,67 @@
+import pytest
+from lxml import etree as ET
+
+from orlando_toolkit.core.fidelity import FidelityItem, FidelityUnavailable, fidelity_report
+from orlando_toolkit.core.models import DitaContext
+
+W = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+NS = (f'xmlns:w="{W}" xmlns:m="http://schemas.openxmlformats.org/officeDocument/2006/math" '
+      'xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing" '
+      'xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" '
+      'xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture"')
+IMAGE = ('<w:r><w:drawing><wp:inline><wp:docPr id="1" name="Picture 1" descr="Pump photo"/>'
+         '<a:graphic><a:graphicData><pic:pic/></a:graphicData></a:graphic></wp:inline></w:drawing></w:r>')
+
+
+def _p(text="", extra=""):
+    return f"<w:p><w:r><w:t>{text}</w:t></w:r>{extra}</w:p>"
+
+
+BODY = (_p("Pump Manual") + _p("Introduction")
+        + _p("The pump moves water.", '<w:r><w:footnoteReference w:id="1"/></w:r>')
+        + f"<w:tbl><w:tr><w:tc>{_p('Flow')}</w:tc><w:tc>{_p('10 l/min')}</w:tc></w:tr></w:tbl>"
+        + _p("Install the pump.", IMAGE)
+        + _p("Speed", "<m:oMath><m:r><m:t>x=2</m:t></m:r></m:oMath>")
+        + _p("Lost paragraph") + "<w:sectPr/>")
+FOOTNOTES = {"word/footnotes.xml": f'<w:footnotes {NS}><w:footnote w:id="1"><w:p><w:r><w:footnoteRef/></w:r>'
+                                   f'<w:r><w:t>Rated at 20 °C</w:t></w:r></w:p></w:footnote></w:footnotes>'}
+
+
+def _context(source):
+    root = ET.Element("map")
+    ET.SubElement(root, "topicref", {"href": "topics/topic_1.dita"})
+    context = DitaContext(ditamap_root=root, metadata={"source_file": str(source)})
+    # The table was flattened, the image and the last paragraph were lost
+    context.topics["topic_1.dita"] = ET.fromstring(
+        "<concept id='topic_1'><title>Introduction</title><conbody>"
+        "<p>The pump moves water.<fn>Rated at 20 °C</fn></p><p>Flow 10 l/min</p><p>Install the pump.</p>"
+        "<p>Speed <equation-inline>x=2</equation-inline></p></conbody></concept>")
+    context.report.info("front_matter", "1 front matter block(s) left out before splitting into topics",
+                        paragraphs=[1])
+    return context
+
+
+def test_report_counts_constructs_and_lists_what_was_lost(tmp_path, docx_factory):
+    source = docx_factory(tmp_path / "manual.docx", BODY, namespaces=NS, parts=FOOTNOTES)
+    report = fidelity_report(_context(source))
+
+    assert report.counts == {"paragraph": (5, 5), "table": (1, 0), "image": (1, 0), "footnote": (1, 1),
+                             "equation": (1, 1)}
+    assert report.items == [
+        FidelityItem("paragraph", 1, "excluded", "Pump Manual"),
+        FidelityItem("table", 4, "unconverted", "Flow 10 l/min", "topic_1.dita"),
+        FidelityItem("image", 6, "dropped", "Pump photo", "topic_1.dita"),
+        FidelityItem("paragraph", 8, "dropped", "Lost paragraph", "topic_1.dita"),
+    ]
+    assert report.summary().endswith("; 2 dropped, 1 unconverted, 1 excluded")
+    assert report.to_csv().splitlines()[:2] == ["kind,status,paragraph,topic,text",
+                                                "paragraph,excluded,1,,Pump Manual"]
+    assert "<td>Lost paragraph</td>" in report.to_html()
+
+
+def test_report_needs_the_word_source(tmp_path):
+    context = DitaContext(metadata={"source_file": str(tmp_path / "manual.zip")})
+    with pytest.raises(FidelityUnavailable):
+        fidelity_report(context)
+    with pytest.raises(FidelityUnavailable):
+        fidelity_report(DitaContext())
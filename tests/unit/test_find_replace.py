@@ -0,0 +1,57 @@
+import pytest
+from lxml import etree as ET
+
+from orlando_toolkit.core.find_replace import find_matches, replace_text
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.services.structure_editing_service import StructureEditingService
+from orlando_toolkit.core.services.undo_service import UndoService
+from orlando_toolkit.ui.controllers.structure_controller import StructureController
+
+
+def _context():
+    topics = {
+        "a.dita": "<concept id='a'><title>AcmePump setup</title><conbody>"
+                  "<p id='acmepump'>Install the <b>AcmePump</b> P-100 and the acmepump hose.</p></conbody></concept>",
+        "b.dita": "<concept id='b'><title>Spares</title><conbody><p>Order P-100 or P-200.<!-- AcmePump --> Done"
+                  "</p></conbody></concept>",
+        "c.dita": "<concept id='c'><title>Other</title><conbody><p>Nothing here.</p></conbody></concept>",
+    }
+    root = ET.fromstring("<map>" + "".join(
+        f"<topicref href='topics/{n}'><topicmeta><navtitle>{n}</navtitle></topicmeta></topicref>" for n in topics
+    ) + "</map>")
+    root.find("topicref/topicmeta/navtitle").text = "AcmePump setup"
+    return DitaContext(ditamap_root=root, topics={k: ET.fromstring(v) for k, v in topics.items()}, metadata={})
+
+
+def test_find_matches_text_nodes_only_with_case_and_regex():
+    ctx = _context()
+    hits = find_matches(ctx, "acmepump")
+    # Comments and attributes (the id) are not searched
+    assert [(h.topic, h.count) for h in hits] == [("a.dita", 3)]
+    assert hits[0].title == "AcmePump setup" and hits[0].snippets[:2] == ["AcmePump setup", "AcmePump"]
+    assert [h.count for h in find_matches(ctx, "AcmePump", case_sensitive=True)] == [2]
+    assert [(h.topic, h.count) for h in find_matches(ctx, r"P-\d{3}", regex=True)] == [("a.dita", 1), ("b.dita", 2)]
+    with pytest.raises(ValueError):
+        find_matches(ctx, "P-(", regex=True)
+
+
+def test_replace_is_one_undoable_edit_and_updates_navtitles():
+    ctx = _context()
+    ctrl = StructureController(ctx, StructureEditingService(), UndoService(), None)
+    ctrl.start_history()
+    assert not ctrl.handle_replace_text("P-(\\d+)", "\\2", regex=True).success
+    assert "P-100" in "".join(ctx.topics["b.dita"].itertext())
+
+    res = ctrl.handle_replace_text("P-(\\d+)", "PN-\\1", regex=True, topics=["b.dita"])
+    assert res.success and res.details["replaced"] == {"b.dita": 2}
+    assert ctx.topics["b.dita"].find("conbody/p").text.startswith("Order PN-100 or PN-200.")
+    assert ctx.topics["a.dita"].find("conbody/p/b").tail == " P-100 and the acmepump hose."
+
+    assert replace_text(ctx, "AcmePump", "Nova", case_sensitive=True) == {"a.dita": 2}
+    assert ctx.topics["a.dita"].findtext("title") == "Nova setup"
+    assert ctx.ditamap_root.find("topicref/topicmeta/navtitle").text == "Nova setup"
+    assert ctx.topics["a.dita"].find("conbody/p").get("id") == "acmepump"
+
+    assert ctrl.undo_label() == "Replace “P-(\\d+)” with “PN-\\1”"
+    assert ctrl.undo()
+    assert ctrl.context.topics["b.dita"].find("conbody/p").text.startswith("Order P-100 or P-200.")
@@ -0,0 +1,71 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.footnotes import read_word_notes, restore_word_notes
+from orlando_toolkit.core.models import DitaContext
+
+W = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+
+
+BODY = (
+    '<w:p><w:r><w:t>Wear gloves</w:t></w:r><w:bookmarkStart w:id="0" w:name="_Ref1"/>'
+    '<w:r><w:footnoteReference w:id="2"/></w:r><w:bookmarkEnd w:id="0"/>'
+    '<w:r><w:t xml:space="preserve"> at all times.</w:t></w:r></w:p>'
+    '<w:p><w:r><w:t>Voltage</w:t></w:r><w:r><w:footnoteReference w:customMarkFollows="1" w:id="3"/></w:r>'
+    '<w:r><w:t>*</w:t></w:r><w:r><w:t xml:space="preserve"> is live, see note </w:t></w:r>'
+    '<w:r><w:fldChar w:fldCharType="begin"/></w:r><w:r><w:instrText> NOTEREF _Ref1 \\h </w:instrText></w:r>'
+    '<w:r><w:fldChar w:fldCharType="separate"/></w:r><w:r><w:t>1</w:t></w:r>'
+    '<w:r><w:fldChar w:fldCharType="end"/></w:r><w:r><w:t>.</w:t></w:r>'
+    '<w:r><w:endnoteReference w:id="1"/></w:r></w:p>'
+)
+FOOTNOTES = (
+    '<w:footnote w:type="separator" w:id="-1"><w:p><w:r><w:separator/></w:r></w:p></w:footnote>'
+    '<w:footnote w:id="2"><w:p><w:r><w:footnoteRef/></w:r><w:r><w:t xml:space="preserve"> Nitrile, EN 374.</w:t>'
+    '</w:r></w:p></w:footnote>'
+    '<w:footnote w:id="3"><w:p><w:r><w:t xml:space="preserve">Up to </w:t></w:r><w:r><w:rPr><w:b/></w:rPr>'
+    '<w:t>400 V</w:t></w:r></w:p><w:p><w:r><w:t>Isolate first.</w:t></w:r></w:p></w:footnote>'
+)
+NOTES = {"word/footnotes.xml": f'<w:footnotes xmlns:w="{W}">{FOOTNOTES}</w:footnotes>',
+         "word/endnotes.xml": f'<w:endnotes xmlns:w="{W}"><w:endnote w:id="1"><w:p><w:r>'
+                              '<w:t>Source: IEC 60204.</w:t></w:r></w:p></w:endnote></w:endnotes>'}
+
+
+def _context(*topics):
+    ditamap = ET.fromstring("<map>" + "".join(f"<topicref href='topics/{n}.dita'/>" for n, _ in topics) + "</map>")
+    return DitaContext(ditamap_root=ditamap,
+                       topics={f"{n}.dita": ET.fromstring(f"<concept id='{n}'><title>{n}</title><conbody>{body}"
+                                                          "</conbody></concept>") for n, body in topics})
+
+
+def test_notes_are_placed_by_text_with_callouts_and_cross_references(tmp_path, docx_factory):
+    path = docx_factory(tmp_path / "manual.docx", BODY, parts=NOTES)
+    notes = read_word_notes(path)
+    assert sorted(notes.notes) == [("endnote", "1"), ("footnote", "2"), ("footnote", "3")]
+    assert [refs[0].offset for _, refs in notes.paragraphs] == [11, 7]
+
+    ctx = _context(("safety", "<p>Wear gloves at <b>all</b> times.</p>"),
+                   ("power", "<ul><li><p>Voltage* is live, see note 1.</p></li></ul>"))
+    assert restore_word_notes(path, ctx, {}) == 3
+    first = ctx.topics["safety.dita"].find(".//p")
+    assert first.find("fn").text == "Nitrile, EN 374." and first.find("fn").tail == " at "
+    second = ctx.topics["power.dita"].find(".//li/p")
+    fn = second.find("fn")
+    assert fn.get("callout") == "*" and [p.text for p in fn.findall("p")] == ["Up to ", "Isolate first."]
+    assert fn.find("p/b").text == "400 V" and fn.tail == " is live, see note "
+    xref = second.find("xref")
+    assert (xref.get("href"), xref.get("type"), xref.tail) == ("safety.dita#safety/fn_f2", "fn", ".")
+    assert second.findall("fn")[1].get("outputclass") == "endnote"
+    assert any(e.category == "footnotes" and "2 footnote(s)" in e.message for e in ctx.report.entries)
+
+
+def test_placeholders_win_and_unplaced_notes_are_reported(tmp_path, docx_factory):
+    path = docx_factory(tmp_path / "manual.docx", BODY, parts=NOTES)
+    ctx = _context(("a", "<p>Gloves<ph data-footnote='2'/> and again<ph data-footnote='2'/>, "
+                         "see<ph data-endnote='1'/>.</p>"))
+    restore_word_notes(path, ctx, {"endnotes": "drop"})
+    p = ctx.topics["a.dita"].find(".//p")
+    assert [c.tag for c in p] == ["fn", "xref"] and p.find("xref").get("href") == "#a/fn_f2"
+    assert "".join(p.itertext()).endswith(", see.")
+
+    ctx = _context(("b", "<p>Unrelated text.</p>"))
+    assert restore_word_notes(path, ctx, {}) == 0
+    assert any(e.severity == "warning" and "could not be placed" in e.message for e in ctx.report.entries)
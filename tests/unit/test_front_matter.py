@@ -0,0 +1,83 @@
+import zipfile
+
+import pytest
+from lxml import etree as ET
+
+from orlando_toolkit.core.front_matter import page_numbers, plan_front_matter, record_front_matter, \
+    strip_front_matter
+from orlando_toolkit.core.models import ConversionReport
+
+W = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+NS = f'xmlns:w="{W}"'
+STYLES = (f"<w:styles {NS}>"
+          + "".join(f'<w:style w:type="paragraph" w:styleId="{sid}"><w:name w:val="{name}"/></w:style>'
+                    for sid, name in (("Heading1", "heading 1"), ("Heading2", "heading 2"),
+                                      ("CoverTitle", "Cover Title"), ("TOC1", "toc 1")))
+          + "</w:styles>")
+
+
+def _p(text="", style=None, extra=""):
+    ppr = f'<w:pPr><w:pStyle w:val="{style}"/></w:pPr>' if style else ""
+    return f"<w:p>{ppr}{extra}<w:r><w:t>{text}</w:t></w:r></w:p>"
+
+
+def _paragraphs(path):
+    with zipfile.ZipFile(path) as zf:
+        body = ET.fromstring(zf.read("word/document.xml")).find(f"{{{W}}}body")
+    return ["".join(p.itertext()) for p in body.iter(f"{{{W}}}p")]
+
+
+BODY = (_p("Pump manual", "CoverTitle")
+        + f'<w:sdt><w:sdtPr><w:docPartObj><w:docPartGallery w:val="Table of Contents"/></w:docPartObj></w:sdtPr>'
+          f'<w:sdtContent>{_p("Contents")}{_p("1 Introduction", "TOC1")}</w:sdtContent></w:sdt>'
+        + _p("Revision history", "Heading1")
+        + f"<w:tbl><w:tr><w:tc>{_p('A')}</w:tc><w:tc>{_p('2024')}</w:tc></w:tr></w:tbl>"
+        + _p("Changes", "Heading2")
+        + _p("Introduction", "Heading1") + _p("The pump moves water.") + "<w:sectPr/>")
+PARTS = {"word/styles.xml": STYLES}
+
+
+def test_rules_leave_out_cover_contents_and_revision_section(tmp_path, docx_factory):
+    source = docx_factory(tmp_path / "manual.docx", BODY, parts=PARTS)
+    options = {"styles": ["cover title"], "toc": True, "titles": ["^revision history$"]}
+
+    planned = plan_front_matter(source, options)
+    assert [(b.paragraph, b.kind, b.reason) for b in planned] == [
+        (1, "paragraph", "style"), (2, "toc", "toc"), (4, "paragraph", "title"), (5, "table", "title"),
+        (7, "paragraph", "title")]
+    assert planned[3].text == "A 2024" and plan_front_matter(source, {"toc": False}) == []
+
+    result = strip_front_matter(source, options, tmp_path / "out", keep=[7])
+    assert result.path != source and result.kept == [7] and len(result.skipped) == 4
+    # Every removed paragraph leaves an empty one, so paragraph numbers still match the source
+    assert _paragraphs(result.path) == ["", "", "", "", "", "", "Changes", "Introduction", "The pump moves water."]
+    assert _paragraphs(source)[6:] == ["Changes", "Introduction", "The pump moves water."]
+
+    report = ConversionReport()
+    assert record_front_matter(None, result, report) == 4
+    [entry] = report.entries
+    assert entry.category == "front_matter" and entry.paragraphs == [1, 2, 4, 5]
+    assert entry.detail["title"] == 2 and entry.detail["kept"] == 1 and "1 kept" in entry.message
+
+    unchanged = strip_front_matter(source, {"enabled": False, "toc": True}, tmp_path / "out")
+    assert unchanged.path == source and not unchanged
+
+
+def test_pages_follow_rendered_breaks_or_explicit_ones(tmp_path, docx_factory):
+    explicit = docx_factory(tmp_path / "explicit.docx",
+                            _p("Cover") + '<w:p><w:r><w:t>Logo</w:t><w:br w:type="page"/></w:r></w:p>' + _p("Contents")
+                            + _p("Intro", extra='<w:pPr><w:pageBreakBefore/></w:pPr>') + _p("Body") + "<w:sectPr/>",
+                            parts=PARTS)
+    assert [(b.text, b.page) for b in plan_front_matter(explicit, {"pages": "1-2"})] == [
+        ("Cover", 1), ("Logo", 1), ("Contents", 2)]
+
+    rendered = docx_factory(tmp_path / "rendered.docx",
+                            _p("Cover") + '<w:p><w:r><w:lastRenderedPageBreak/><w:t>Contents</w:t></w:r></w:p>'
+                            + _p("Intro", extra='<w:r><w:br w:type="page"/></w:r>') + _p("Body") + "<w:sectPr/>",
+                            parts=PARTS)
+    # Word's last layout wins over manual page breaks once the document has one
+    assert [b.text for b in plan_front_matter(rendered, {"pages": [2]})] == ["Contents", "Intro", "Body"]
+
+    assert page_numbers("1-3, 5") == {1, 2, 3, 5} and page_numbers(None) == set()
+    with pytest.raises(ValueError):
+        page_numbers("3-1")
@@ -0,0 +1,70 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.services.structure_editing_service import StructureEditingService
+from orlando_toolkit.core.services.undo_service import UndoService
+from orlando_toolkit.ui.controllers.structure_controller import StructureController
+
+
+def _context():
+    names = ("a", "b", "c", "d", "e")
+    topics = {f"{n}.dita": ET.fromstring(f"<concept id='{n}'><title>{n.upper()}</title><conbody/></concept>")
+              for n in names}
+    root = ET.fromstring(
+        "<map><topicref href='topics/a.dita' data-level='1' data-style='Heading 1'/>"
+        "<topicref href='topics/b.dita' data-level='1' data-style='Heading 1'/>"
+        "<topicref href='topics/c.dita' data-level='1' data-style='Heading 1'>"
+        "<topicref href='topics/d.dita' data-level='2' data-style='Heading 2'/></topicref>"
+        "<topichead data-level='1'><topicmeta><navtitle>Annex</navtitle></topicmeta>"
+        "<topicref href='topics/e.dita' data-level='2'/></topichead></map>")
+    return DitaContext(ditamap_root=root, topics=topics, metadata={})
+
+
+def _outline(node, depth=0):
+    out = []
+    for child in node:
+        if child.tag in ("topicref", "topichead"):
+            name = (child.get("href") or "section").rsplit("/", 1)[-1].replace(".dita", "")
+            out.append(f"{'  ' * depth}{name}:{child.get('data-level')}")
+            out += _outline(child, depth + 1)
+    return out
+
+
+def test_group_promote_demote_style_and_delete():
+    ctx, service = _context(), StructureEditingService()
+    res = service.shift_selection_level(ctx, ["topics/b.dita", "topics/c.dita"], [], "demote")
+    assert res.success and res.details["moved"] == 2
+    assert _outline(ctx.ditamap_root) == ["a:1", "  b:2", "  c:2", "    d:3", "section:1", "  e:2"]
+    assert ctx.ditamap_root.find("topicref/topicref").get("data-style") == "Heading 2"
+
+    assert service.shift_selection_level(ctx, ["topics/b.dita", "topics/c.dita"], [], "promote").success
+    assert _outline(ctx.ditamap_root) == ["a:1", "b:1", "c:1", "  d:2", "section:1", "  e:2"]
+    assert not service.shift_selection_level(ctx, ["topics/a.dita"], [], "promote").success
+
+    res = service.apply_style(ctx, ["topics/a.dita", "topics/d.dita"], [[3]], "Annex Title")
+    assert res.success and res.details["updated"] == 3
+    assert ctx.ditamap_root.find("topichead").get("data-style") == "Annex Title"
+
+    # A selected section stands for its subtree; nested selections are not deleted twice
+    res = service.delete_selection(ctx, ["topics/b.dita", "topics/e.dita"], [[3]])
+    assert res.success and res.details["deleted"] == 2
+    assert _outline(ctx.ditamap_root) == ["a:1", "c:1", "  d:2"]
+    assert sorted(ctx.topics) == ["a.dita", "c.dita", "d.dita"]
+
+
+def test_drag_and_drop_moves_selection_as_one_undoable_step():
+    ctx = _context()
+    ctrl = StructureController(ctx, StructureEditingService(), UndoService(), None)
+    ctrl.start_history()
+    assert not ctrl.handle_drop_selection(["topics/c.dita"], [], [2, 0], "after").success
+
+    res = ctrl.handle_drop_selection(["topics/a.dita", "topics/b.dita"], [], [3], "inside")
+    assert res.success
+    assert _outline(ctx.ditamap_root) == ["c:1", "  d:2", "section:1", "  e:2", "  a:2", "  b:2"]
+    assert ctrl.handle_drop_selection(["topics/d.dita"], [], [1, 0], "before").success
+    assert _outline(ctx.ditamap_root) == ["c:1", "section:1", "  d:2", "  e:2", "  a:2", "  b:2"]
+    assert ctrl.undo_label() == "Drag selection"
+
+    assert ctrl.undo() and ctrl.undo()
+    assert _outline(ctrl.context.ditamap_root)[:3] == ["a:1", "b:1", "c:1"]
+    assert ctrl.get_applicable_styles()[:2] == ["Heading 1", "Heading 2"]
@@ -0,0 +1,82 @@
+from orlando_toolkit.core.heading_review import read_heading_outline, review_enabled, review_overrides
+from orlando_toolkit.core.heading_rules import Heading, apply_heading_rules, load_heading_overrides
+from orlando_toolkit.core.importers import MarkupDocumentImporter
+from orlando_toolkit.core.models import ConversionReport
+
+_MARKDOWN = """# Pump Manual
+
+Intro.
+
+## Safety
+
+Wear gloves.
+
+## Notes {#notes}
+
+First notes.
+
+### Wiring
+
+Red to red.
+
+## Notes
+
+Second notes, see [the first](#notes).
+"""
+
+W = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+
+
+def test_reviewed_markdown_outline_drives_the_topics(tmp_path):
+    source = tmp_path / "pump.md"
+    source.write_text(_MARKDOWN, encoding="utf-8")
+    outline = read_heading_outline(source, {})
+    assert [(h.title, h.level, h.occurrence) for h in outline] == [
+        ("Pump Manual", 1, 1), ("Safety", 2, 1), ("Notes", 2, 1), ("Wiring", 3, 1), ("Notes", 2, 2)]
+
+    levels = [1, 1, 3, 3, None]        # promote Safety, demote the first Notes, exclude the second
+    overrides = review_overrides(outline, levels)
+    assert overrides == [{"title": "Safety", "occurrence": 1, "level": 1},
+                         {"title": "Notes", "occurrence": 1, "level": 3},
+                         {"title": "Notes", "occurrence": 2, "exclude": True}]
+
+    ctx = MarkupDocumentImporter().import_document(source, {"heading_overrides": overrides,
+                                                            "manual_title": "Pump Manual"})
+    refs = list(ctx.ditamap_root.iter("topicref"))
+    assert [(r.findtext("topicmeta/navtitle"), r.get("data-level")) for r in refs] == [
+        ("Pump Manual", "1"), ("Safety", "1"), ("Notes", "3"), ("Wiring", "3")]
+    wiring = ctx.topics[refs[-1].get("href").split("/")[-1]]
+    kept = wiring.find("conbody/p[@outputclass='excluded-heading']")
+    assert kept is not None and kept.findtext("b") == "Notes"
+    assert wiring.find(".//xref").get("href") == refs[2].get("href").split("/")[-1]
+    entry = next(e for e in ctx.report.entries if e.message.startswith("Heading review"))
+    assert (entry.detail["promoted"], entry.detail["demoted"], entry.detail["excluded"]) == (1, 1, 1)
+
+
+def test_word_outline_and_override_matching(tmp_path, docx_factory):
+    def _p(text, style=None, outline=None):
+        ppr = (f'<w:pStyle w:val="{style}"/>' if style else "") + (
+            f'<w:outlineLvl w:val="{outline}"/>' if outline is not None else "")
+        return f'<w:p><w:pPr>{ppr}</w:pPr><w:r><w:t>{text}</w:t></w:r></w:p>'
+
+    styles = (f'<w:styles xmlns:w="{W}"><w:style w:type="paragraph" w:styleId="Heading1">'
+              '<w:name w:val="heading 1"/></w:style><w:style w:type="paragraph" '
+              'w:styleId="Chapter"><w:name w:val="Chapter"/></w:style></w:styles>')
+    body = _p("Overview", "Heading1") + _p("Body text.") + _p("Annex", "Chapter") + _p("Wiring   details", outline=1)
+    source = docx_factory(tmp_path / "manual.docx", body, parts={"word/styles.xml": styles})
+    metadata = {"style_map": {"Chapter": {"heading": 1}}, "conversion_options": {"headings": {"review": True, "rules": [
+        {"match": {"level": 1}, "action": "demote", "subtree": False}]}}}
+    assert review_enabled(metadata) and not review_enabled({})
+    outline = read_heading_outline(source, metadata)
+    assert [(h.title, h.style, h.source_level, h.level) for h in outline] == [
+        ("Overview", "heading 1", 1, 2), ("Annex", "Chapter", 1, 2), ("Wiring details", "heading 2", 2, 2)]
+
+    metadata["heading_overrides"] = review_overrides(outline, [1, 2, 3]) + [{"title": "Missing", "level": 1},
+                                                                              {"level": 2}, {"title": "X"}]
+    assert len(load_heading_overrides(metadata)) == 3
+    report = ConversionReport()
+    headings = [Heading(1, "Overview"), Heading(1, "Annex"), Heading(2, "Wiring details")]
+    result = apply_heading_rules(headings, metadata, report=report)
+    assert result.levels == [1, 2, 3] and result.excluded == []
+    entry = report.entries[-1]
+    assert entry.detail["not_found"] == ["Missing"] and entry.detail["promoted"] == 1
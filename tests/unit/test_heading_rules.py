@@ -0,0 +1,52 @@
+from orlando_toolkit.core.heading_rules import Heading, HeadingRule, apply_heading_rules, load_heading_rules
+from orlando_toolkit.core.models import ConversionReport
+
+OUTLINE = [
+    Heading(1, "Operator Manual"),
+    Heading(2, "Safety"),
+    Heading(3, "Warnings"),
+    Heading(2, "Operation"),
+    Heading(3, "Appendix A", style="Appendix"),
+    Heading(4, "Tables"),
+    Heading(3, "Index"),
+]
+
+
+def _rules(*data):
+    return [HeadingRule.from_mapping(d) for d in data]
+
+
+def test_first_h1_becomes_map_title_and_topics_start_at_h2():
+    outline = apply_heading_rules(OUTLINE, rules=_rules({"match": {"level": 1}, "action": "map_title"}))
+    assert outline.map_title == "Operator Manual"
+    assert outline.levels == [None, 1, 2, 1, 2, 3, 2]
+
+
+def test_appendix_subtree_shifts_and_rules_chain():
+    report = ConversionReport()
+    outline = apply_heading_rules(OUTLINE, report=report, rules=_rules(
+        {"match": {"style": "Appendix"}, "action": "promote"},
+        {"match": {"title": "^index$"}, "action": "demote", "by": 2, "subtree": False},
+        {"match": {"level": 1}, "action": "promote", "subtree": False},
+    ))
+    assert outline.levels == [1, 2, 3, 2, 2, 3, 5]
+    assert [e.category for e in report.entries] == ["headings"] * 3
+    assert outline.applied[0].startswith("promote by 1 (style Appendix)")
+
+
+def test_rules_come_from_job_options_and_bad_rules_are_skipped():
+    metadata = {"conversion_options": {"headings": {"rules": [
+        {"match": {"level": [2, 3]}, "action": "demote", "subtree": False},
+        {"action": "flatten"},
+    ]}}}
+    rules = load_heading_rules(metadata)
+    assert len(rules) == 1
+    assert apply_heading_rules(OUTLINE[:3], metadata).levels == [1, 3, 4]
+    assert apply_heading_rules(OUTLINE[:3], {"conversion_options": {"headings": {"enabled": False}}}).levels == [1, 2, 3]
+
+
+def test_style_map_role_makes_the_map_title():
+    metadata = {"style_map": {"Manual Title": {"heading": 1, "role": "map_title"}}}
+    headings = [Heading(1, "Operator Manual", style="Manual Title"), Heading(2, "Safety"), Heading(2, "Use")]
+    outline = apply_heading_rules(headings, metadata)
+    assert outline.map_title == "Operator Manual" and outline.levels == [None, 1, 1]
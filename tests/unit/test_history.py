@@ -0,0 +1,71 @@
+import json
+
+from lxml import etree as ET
+
+from orlando_toolkit.cli import main
+from orlando_toolkit.core.history import HistoryStore, compare_entries
+from orlando_toolkit.core.models import DitaContext
+
+
+def _context(warnings=0):
+    ctx = DitaContext(
+        ditamap_root=ET.fromstring("<map><title>Guide</title></map>"),
+        topics={"a.dita": ET.fromstring("<topic id='a'><title>A</title></topic>")},
+        metadata={"manual_title": "Guide", "conversion_options": {"ids": {"stable": True}}, "not_json": object()},
+        plugin_data={"_source_plugin": "docx"},
+    )
+    for _ in range(warnings):
+        ctx.report.warning("structure", "Heading inside table", topic="a.dita")
+    return ctx
+
+
+def test_records_are_listed_newest_first_and_pruned(tmp_path):
+    source = tmp_path / "guide.docx"
+    source.write_bytes(b"v1")
+    store = HistoryStore(tmp_path / "history", max_entries=2)
+    first = store.record("conversion", source=source, context=_context(), stats={"duration_seconds": 1.5})
+    second = store.record("package", source=source, target=tmp_path / "out.zip", context=_context(1))
+    third = store.record("conversion", source=source, context=_context(2))
+
+    entries = store.list()
+    assert [e.id for e in entries] == [third.id, second.id]
+    assert store.get(first.id) is None
+    assert store.list(kind="package")[0].target == str(tmp_path / "out.zip")
+    assert store.list(source="other") == []
+
+    entry = store.get(third.id[:-3])
+    assert entry.plugin == "docx"
+    assert entry.source["sha256"] and entry.source["name"] == "guide.docx"
+    assert entry.settings == {"manual_title": "Guide", "conversion_options": {"ids": {"stable": True}}}
+    assert entry.report["counts"]["warning"] == 2
+
+    assert HistoryStore(tmp_path / "off", enabled=False).record("conversion", context=_context()) is None
+    assert not (tmp_path / "off").exists()
+
+
+def test_compare_reports_changes_between_runs(tmp_path):
+    store = HistoryStore(tmp_path)
+    old = store.record("conversion", source={"name": "g.docx", "sha256": "a"}, context=_context(1))
+    newer = _context(3)
+    newer.metadata["topic_depth"] = 2
+    new = store.record("conversion", source={"name": "g.docx", "sha256": "b"}, context=newer)
+
+    diff = compare_entries(old, new)
+    assert diff["report"] == {"warning:structure": {"old": 1, "new": 3}}
+    assert diff["settings"] == {"topic_depth": {"old": None, "new": 2}}
+    assert diff["source_changed"] is True
+    assert diff["stats"] == {}
+
+
+def test_history_cli_lists_and_shows_records(tmp_path, monkeypatch, capsys):
+    import orlando_toolkit.cli as cli
+
+    store = HistoryStore(tmp_path)
+    entry = store.record("conversion", source={"name": "guide.docx"}, context=_context(1))
+    monkeypatch.setattr(cli, "get_history_store", lambda: store)
+
+    assert main(["history", "list"]) == 0
+    assert entry.id in capsys.readouterr().out
+    assert main(["history", "show", entry.id, "--json"]) == 0
+    assert json.loads(capsys.readouterr().out)["report"]["counts"]["warning"] == 1
+    assert main(["history", "show", "nope"]) == 1
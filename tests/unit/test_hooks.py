@@ -0,0 +1,86 @@
+import zipfile
+
+from orlando_toolkit.core import hooks
+from orlando_toolkit.core.hooks import HOOK_SERVICE, discover_hooks, resolve_hooks
+from orlando_toolkit.core.plugins.registry import ServiceRegistry
+from orlando_toolkit.core.services.conversion_service import ConversionService
+
+
+class _Recorder:
+    def __init__(self, calls, name):
+        self.calls, self.name = calls, name
+
+    def on_parse(self, context):
+        self.calls.append((self.name, "parse", len(context.topics)))
+
+    def on_topics(self, context):
+        self.calls.append((self.name, "topics", len(context.topics)))
+
+    def on_structure(self, context):
+        self.calls.append((self.name, "structure", len(context.topics)))
+        context.ditamap_root.set("otherprops", self.name)
+
+    def on_package(self, context, package_dir):
+        self.calls.append((self.name, "package", (package_dir / "DATA").is_dir()))
+        (package_dir / "DATA" / f"{self.name}.txt").write_text("added")
+
+
+class _Broken:
+    def on_topics(self, context):
+        raise RuntimeError("local hack failed")
+
+
+def test_hooks_run_at_each_stage_in_configured_order(tmp_path):
+    calls = []
+    registry = ServiceRegistry()
+    registry.register_service(HOOK_SERVICE, _Recorder(calls, "stamp"), "stamp")
+    registry.register_service(HOOK_SERVICE, _Recorder(calls, "audit"), "audit")
+    registry.register_service(HOOK_SERVICE, _Recorder(calls, "legacy"), "legacy")
+    registry.register_service(HOOK_SERVICE, _Broken(), "broken")
+    source = tmp_path / "notes.md"
+    source.write_text("# Notes\n\n## First\n\nText.\n\n## Second\n\nMore.\n", encoding="utf-8")
+    metadata = {"conversion_options": {"hooks": {"order": ["stamp"], "disabled": ["legacy"]}}}
+
+    service = ConversionService(service_registry=registry)
+    context = service.convert(source, metadata)
+    context = service.prepare_package(context)
+    service.write_package(context, tmp_path / "out.zip")
+
+    assert [(name, point) for name, point, _ in calls] == [
+        ("stamp", "parse"), ("audit", "parse"), ("stamp", "topics"), ("audit", "topics"),
+        ("stamp", "structure"), ("audit", "structure"), ("stamp", "package"), ("audit", "package")]
+    assert all(value for _, point, value in calls if point == "package")
+    with zipfile.ZipFile(tmp_path / "out.zip") as archive:
+        assert {"DATA/stamp.txt", "DATA/audit.txt"} <= set(archive.namelist())
+    assert context.ditamap_root.get("otherprops") == "audit"
+    failure = [e for e in context.report.entries if e.category == "hooks"]
+    assert len(failure) == 1 and failure[0].detail["code"] == "OTK302" and failure[0].detail["hook"] == "broken"
+    assert "hook:broken" in context.report.timings
+
+
+class _EntryPoint:
+    def __init__(self, name, target):
+        self.name, self.value, self._target = name, f"pkg:{name}", target
+
+    def load(self):
+        if isinstance(self._target, Exception):
+            raise self._target
+        return self._target
+
+
+def test_entry_point_hooks_are_registered_once(monkeypatch):
+    instance = _Broken()
+    entries = [_EntryPoint("instance", instance), _EntryPoint("cls", _Broken),
+               _EntryPoint("factory", lambda: _Broken()), _EntryPoint("missing", ImportError("no module"))]
+    monkeypatch.setattr(hooks, "_entry_points", lambda: entries)
+    registry = ServiceRegistry()
+
+    assert discover_hooks(registry) == ["instance", "cls", "factory"]
+    assert discover_hooks(registry) == []
+    providers = registry.get_service_providers(HOOK_SERVICE)
+    assert providers["instance"] is instance and isinstance(providers["cls"], _Broken)
+    assert isinstance(providers["factory"], _Broken)
+    assert [name for name, _ in resolve_hooks({"order": ["instance", "gone"], "disabled": ["cls"]}, registry)] == [
+        "instance", "factory"]
+    assert resolve_hooks({"enabled": False}, registry) == []
+    assert discover_hooks(None) == []
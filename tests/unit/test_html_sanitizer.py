@@ -0,0 +1,31 @@
+from orlando_toolkit.core.html_sanitizer import HtmlSanitizerPolicy, sanitize_html
+
+
+def test_script_handlers_and_javascript_urls_are_removed():
+    stats = {}
+    out = sanitize_html(
+        '<p onclick="x()">Hi <a href="javascript:alert(1)">a</a> <a href=" JaVa\tscript:x">b</a>'
+        '<a href="https://example.com/x?a=1&amp;b=2">c</a><a href="#sec">d</a></p>'
+        '<script>alert("x")</script><style>p{}</style>',
+        HtmlSanitizerPolicy(),
+        stats,
+    )
+    assert out == ('<p>Hi <a>a</a> <a>b</a><a href="https://example.com/x?a=1&amp;b=2">c</a>'
+                   '<a href="#sec">d</a></p>')
+    assert stats == {"attributes": 1, "urls": 2, "dropped": 2}
+
+
+def test_disallowed_elements_are_unwrapped_and_output_balanced():
+    out = sanitize_html('<div><font color="red">old <b>bold</div><!-- note --><p>a &lt; b', HtmlSanitizerPolicy())
+    assert out == "<div>old <b>bold</b></div><p>a &lt; b</p>"
+
+
+def test_policy_from_mapping_overrides_lists():
+    policy = HtmlSanitizerPolicy.from_mapping({
+        "allowed_elements": ["p", "img"],
+        "allowed_attributes": {"img": ["src"]},
+        "allow_data_images": True,
+    })
+    out = sanitize_html('<p class="x"><em>e</em><img src="data:image/png;base64,AA" alt="a"></p>', policy)
+    assert out == '<p>e<img src="data:image/png;base64,AA"></p>'
+    assert sanitize_html('<a href="ftp://h/f">f</a>', HtmlSanitizerPolicy()) == "<a>f</a>"
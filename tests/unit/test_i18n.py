@@ -0,0 +1,36 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.i18n import MessageCatalog, document_language
+from orlando_toolkit.core.models import DitaContext
+
+
+def _catalog() -> MessageCatalog:
+    return MessageCatalog(
+        {
+            "en": {"untitled": "Untitled", "note_heading": "{label}:", "table_caption": "Table {number}"},
+            "fr": {"untitled": "Sans titre", "note_heading": "{label} :"},
+            "pt-BR": {"untitled": "Sem título"},
+        },
+        default_language="en",
+    )
+
+
+def test_lookup_falls_back_from_region_to_language_to_default():
+    catalog = _catalog()
+
+    assert catalog.get("untitled", "pt-BR") == "Sem título"
+    assert catalog.get("untitled", "fr_CA") == "Sans titre"
+    assert catalog.get("table_caption", "fr", number=3) == "Table 3"
+    assert catalog.get("note_heading", "fr", label="Remarque") == "Remarque :"
+    # Unknown keys never raise
+    assert catalog.get("no_such_key", "fr") == "no_such_key"
+
+
+def test_document_language_prefers_metadata_then_xml_lang():
+    root = ET.Element("map")
+    root.set("{http://www.w3.org/XML/1998/namespace}lang", "de-DE")
+    ctx = DitaContext(ditamap_root=root)
+    assert document_language(ctx) == "de-de"
+
+    ctx.metadata["language"] = "fr"
+    assert document_language(ctx) == "fr"
@@ -0,0 +1,56 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.image_naming import ImageNaming, propose_names
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.package_utils import update_image_references_and_names
+
+
+def _context(metadata=None):
+    root = ET.Element("map")
+    topics = {}
+    for index, (title, images) in enumerate([("Safety Rules", ["a.png", "b.jpg"]), ("Setup", ["c.png"])], 1):
+        name = f"topic_{index}.dita"
+        body = "".join(f"<image href='../media/{image}'/>" for image in images)
+        topics[name] = ET.fromstring(f"<concept id='t{index}'><title>{title}</title>"
+                                     f"<conbody>{body}</conbody></concept>")
+        ref = ET.SubElement(root, "topicref", href=f"topics/{name}")
+        ET.SubElement(ET.SubElement(ref, "topicmeta"), "navtitle").text = title
+    images = {"a.png": b"a", "b.jpg": b"b", "c.png": b"c", "unused image.gif": b"d"}
+    return DitaContext(ditamap_root=root, topics=topics, images=images,
+                       metadata={"manual_code": "MAN-CODE", **(metadata or {})})
+
+
+def test_pattern_placeholders_and_profile_override(monkeypatch):
+    monkeypatch.setattr(ImageNaming, "from_config", classmethod(lambda cls: cls(prefix="IMG")))
+    ctx = _context()
+    # Default pattern: index only when a section holds several images
+    assert propose_names(ctx) == {"a.png": "IMG-MAN-CODE-1-1.png", "b.jpg": "IMG-MAN-CODE-1-2.jpg",
+                                  "c.png": "IMG-MAN-CODE-2.png", "unused image.gif": "IMG-MAN-CODE-0.gif"}
+
+    ctx = _context({"image_naming": {"pattern": "{manual_code}_{section}_{counter:03}", "index_start": 0}})
+    assert list(propose_names(ctx).values()) == [
+        "MAN-CODE_1_000.png", "MAN-CODE_1_001.jpg", "MAN-CODE_2_000.png", "MAN-CODE_0_000.gif"]
+
+    naming = ImageNaming(pattern="{topic}/{name}-{number}{ext}", index_zero_pad=2)
+    assert list(propose_names(ctx, naming).values()) == [
+        "safety_rules_a-01.png", "safety_rules_b-02.jpg", "setup_c-03.png", "unused_image-04.gif"]
+    # Names produced twice are numbered
+    assert list(propose_names(ctx, ImageNaming(pattern="{prefix}{ext}")).values()) == [
+        "IMG.png", "IMG.jpg", "IMG_2.png", "IMG.gif"]
+
+
+def test_invalid_patterns_are_refused_and_packaging_renames(monkeypatch):
+    assert ImageNaming.validate("{prefix}-{counter:03}{ext}") is None
+    assert ImageNaming.validate("{prefix}-{chapter}") == "Unknown placeholder {chapter}"
+    assert ImageNaming.validate("{prefix").startswith("Invalid pattern")
+    assert ImageNaming.validate(" ") == "The pattern is empty"
+    base = ImageNaming(prefix="P")
+    assert ImageNaming.from_mapping({"pattern": "{bogus}", "index_start": "x"}, base) == base
+    resolved = ImageNaming.resolve({"prefix": "FIG", "image_naming": {"prefix": "X", "pattern": "{prefix}{-index}"}})
+    assert (resolved.prefix, resolved.pattern) == ("FIG", "{prefix}{-index}")
+
+    monkeypatch.setattr(ImageNaming, "from_config", classmethod(lambda cls: cls()))
+    ctx = update_image_references_and_names(_context({"image_naming": {"pattern": "{name}_{section}"}}))
+    assert sorted(ctx.images) == ["a_1.png", "b_1.jpg", "c_2.png", "unused_image_0.gif"]
+    assert [i.get("href") for i in ctx.topics["topic_1.dita"].iter("image")] == ["../media/a_1.png",
+                                                                                 "../media/b_1.jpg"]
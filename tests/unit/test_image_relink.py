@@ -0,0 +1,61 @@
+import pytest
+from lxml import etree as ET
+
+from orlando_toolkit.core.external_media import METADATA_KEY as EXTERNAL_KEY
+from orlando_toolkit.core.image_relink import image_format, image_usage, missing_images, relink_image, replace_image
+from orlando_toolkit.core.models import DitaContext
+
+PNG = b"\x89PNG\r\n\x1a\n" + b"\x00" * 24
+JPEG = b"\xff\xd8\xff\xe0" + b"\x00" * 24
+
+
+def _context():
+    topics = {
+        "pump.dita": "<concept id='pump'><title>Pump</title><conbody>"
+                     "<fig><image href='../media/pump.png'><alt>Pump</alt></image></fig>"
+                     "<object><param name='poster' valuetype='ref' value='../media/pump.png'/></object>"
+                     "</conbody></concept>",
+        "seal.dita": "<concept id='seal'><title>Seal</title><conbody><p><image href='../media/old_seal.png'/>"
+                     "<image href='../media/pump.png'/></p></conbody></concept>",
+    }
+    return DitaContext(ditamap_root=ET.fromstring("<map><topicref href='topics/pump.dita'/>"
+                                                  "<topicref href='topics/seal.dita'/></map>"),
+                       topics={k: ET.fromstring(v) for k, v in topics.items()},
+                       images={"pump.png": PNG, "seal.png": PNG}, metadata={EXTERNAL_KEY: ["pump.png"]})
+
+
+def _hrefs(ctx, topic):
+    return [el.get("href") or el.get("value") for el in ctx.topics[topic].iter("image", "param")]
+
+
+def test_replacing_keeps_or_changes_the_name_and_every_reference_follows():
+    ctx = _context()
+    assert image_format(JPEG) == "jpg" and image_format(b"<svg xmlns='x'/>") == "svg" and image_format(b"text") == ""
+    assert replace_image(ctx, "pump.png", PNG + b"new") == "pump.png"
+    assert ctx.images["pump.png"].endswith(b"new")
+
+    assert replace_image(ctx, "pump.png", JPEG, filename="C:/shots/pump v2.jpeg") == "pump.jpg"
+    assert list(ctx.images) == ["pump.jpg", "seal.png"]
+    assert _hrefs(ctx, "pump.dita") == ["../media/pump.jpg", "../media/pump.jpg"]
+    assert ctx.topics["pump.dita"].findtext(".//alt") == "Pump"
+    assert ctx.metadata[EXTERNAL_KEY] == ["pump.jpg"]
+
+    assert replace_image(ctx, "pump.jpg", PNG, filename="seal.png", keep_name=False) == "seal_2.png"
+    assert _hrefs(ctx, "seal.dita") == ["../media/old_seal.png", "../media/seal_2.png"]
+    with pytest.raises(ValueError):
+        replace_image(ctx, "seal.png", b"not an image", filename="notes.txt")
+    assert ctx.images["seal.png"] == PNG
+
+
+def test_relinking_points_missing_references_at_another_image():
+    ctx = _context()
+    assert missing_images(ctx) == ["old_seal.png"]
+    assert image_usage(ctx) == {"pump.png": ["pump.dita", "seal.dita"], "old_seal.png": ["seal.dita"]}
+    with pytest.raises(ValueError):
+        relink_image(ctx, "old_seal.png", "gone.png")
+
+    assert relink_image(ctx, "old_seal.png", "seal.png") == 1
+    assert missing_images(ctx) == []
+    assert relink_image(ctx, "pump.png", "seal.png", remove_old=True) == 3
+    assert list(ctx.images) == ["seal.png"] and ctx.metadata[EXTERNAL_KEY] == ["seal.png"]
+    assert set(_hrefs(ctx, "pump.dita") + _hrefs(ctx, "seal.dita")) == {"../media/seal.png"}
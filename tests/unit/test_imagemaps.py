@@ -0,0 +1,64 @@
+import pytest
+from lxml import etree as ET
+
+from orlando_toolkit.core.imagemaps import MapArea, image_map_areas, link_targets, set_image_map
+from orlando_toolkit.core.models import DitaContext
+
+
+def _context():
+    topics = {
+        "wiring.dita": "<concept id='wiring'><title>Wiring</title><conbody><fig><title>Harness</title>"
+                       "<image href='../media/harness.png'><alt>Harness</alt></image></fig>"
+                       "<p>Detail: <image href='../media/harness.png'/> shown again.</p></conbody></concept>",
+        "fuses.dita": "<concept id='fuses'><title>Fuse box</title><conbody/></concept>",
+        "relay.dita": "<concept id='relay'><title>Relay</title><conbody/></concept>",
+        "shared.dita": "<concept id='shared'><title>Shared</title><conbody/></concept>",
+    }
+    root = ET.fromstring(
+        "<map><topicref href='topics/wiring.dita'><topicmeta><navtitle>Wiring diagram</navtitle></topicmeta>"
+        "<topichead><topicmeta><navtitle>Parts</navtitle></topicmeta><topicgroup>"
+        "<topicref href='topics/fuses.dita'/><topicref href='topics/relay.dita'/></topicgroup></topichead>"
+        "</topicref><topicref href='topics/shared.dita' processing-role='resource-only'/></map>")
+    return DitaContext(ditamap_root=root, topics={k: ET.fromstring(v) for k, v in topics.items()}, metadata={})
+
+
+def test_areas_wrap_every_image_and_can_be_removed_again():
+    ctx = _context()
+    areas = [MapArea("rect", [10, 20, 110, 80], "fuses.dita", "Fuse box"),
+             MapArea("poly", [0, 0, 40, 0, 20, 30], "https://example.org/relay")]
+    assert set_image_map(ctx, "harness.png", areas) == 2
+    topic = ctx.topics["wiring.dita"]
+    fig_map = topic.find("conbody/fig/imagemap")
+    assert [child.tag for child in fig_map] == ["image", "area", "area"]
+    assert fig_map.find("image/alt").text == "Harness"
+    first = fig_map.find("area")
+    assert (first.findtext("shape"), first.findtext("coords")) == ("rect", "10,20,110,80")
+    assert first.find("xref").get("href") == "fuses.dita" and first.findtext("xref") == "Fuse box"
+    external = fig_map.findall("area")[1].find("xref")
+    assert (external.get("scope"), external.get("format"), external.text) == ("external", "html", None)
+    paragraph = topic.find("conbody/p")
+    assert paragraph.text == "Detail: " and paragraph.find("imagemap").tail == " shown again."
+
+    read = image_map_areas(ctx, "harness.png")
+    assert [(a.shape, a.coords, a.target, a.text) for a in read] == [
+        ("rect", [10, 20, 110, 80], "fuses.dita", "Fuse box"),
+        ("poly", [0, 0, 40, 0, 20, 30], "https://example.org/relay", "")]
+    assert set_image_map(ctx, "harness.png", read[:1]) == 2 and len(fig_map.findall("area")) == 1
+
+    assert set_image_map(ctx, "harness.png", []) == 2
+    assert topic.find(".//imagemap") is None and topic.find("conbody/fig/image/alt").text == "Harness"
+    assert "".join(paragraph.itertext()) == "Detail:  shown again." and paragraph.find("image") is not None
+    assert image_map_areas(ctx, "harness.png") == [] and set_image_map(ctx, "other.png", areas) == 0
+
+
+def test_invalid_areas_are_refused_and_targets_follow_the_map():
+    ctx = _context()
+    for area, message in ((MapArea("rect", [1, 2, 3], "fuses.dita"), "four coordinates"),
+                          (MapArea("poly", [1, 2, 3, 4], "fuses.dita"), "three points"),
+                          (MapArea("rect", [1, 2, 3, 4]), "no link target"),
+                          (MapArea("star", [1, 2, 3, 4], "fuses.dita"), "Unknown shape")):
+        with pytest.raises(ValueError, match=message):
+            set_image_map(ctx, "harness.png", [MapArea("rect", [0, 0, 5, 5], "relay.dita"), area])
+    assert ctx.topics["wiring.dita"].find(".//imagemap") is None
+    assert link_targets(ctx) == [("wiring.dita", "Wiring diagram", 0), ("fuses.dita", "Fuse box", 2),
+                                 ("relay.dita", "Relay", 2)]
@@ -0,0 +1,81 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.index_terms import parse_xe_field, read_word_index, restore_word_index_terms
+from orlando_toolkit.core.models import DitaContext
+
+W = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+
+
+def _run(text):
+    return f'<w:r><w:t xml:space="preserve">{text}</w:t></w:r>'
+
+
+def _xe(instr):
+    return ('<w:r><w:fldChar w:fldCharType="begin"/></w:r>'
+            f'<w:r><w:instrText xml:space="preserve"> {instr} </w:instrText></w:r>'
+            '<w:r><w:fldChar w:fldCharType="end"/></w:r>')
+
+
+SEALS = _xe(r"XE &quot;Pump:Seals:Replacing&quot; \r SealWork")
+GASKETS = _xe(r"XE &quot;Gaskets&quot; \t &quot;See Pump:Seals&quot;")
+BODY = (
+    f'<w:p>{_run("Pump")}{_xe("XE &quot;Pump&quot;")}</w:p>'
+    f'<w:p><w:bookmarkStart w:id="0" w:name="SealWork"/>{_run("Replace the seal")}'
+    f'{SEALS}{_run(" and the gasket.")}{GASKETS}</w:p>'
+    f'<w:p>{_run("Torque the cover.")}<w:bookmarkEnd w:id="0"/></w:p>'
+)
+
+
+def _context(body="<p>Replace the seal and the gasket.</p><p>Torque the cover.</p>"):
+    topic = ET.fromstring(f"<concept id='c'><title>Pump</title><shortdesc>Pump care.</shortdesc>"
+                          f"<conbody>{body}</conbody></concept>")
+    return DitaContext(ditamap_root=ET.fromstring("<map><topicref href='topics/c.dita'/></map>"),
+                       topics={"c.dita": topic})
+
+
+def _terms(el):
+    return [t.text for t in el.iter("indexterm")]
+
+
+def test_xe_fields_become_nested_indexterms_in_place(tmp_path, docx_factory):
+    entry = parse_xe_field(r'XE "Valve\:Type A:Ball" \t "See also Cocks" \b')
+    assert entry.terms == ["Valve:Type A", "Ball"] and entry.see_also == ["Cocks"]
+    path = docx_factory(tmp_path / "manual.docx", BODY)
+    index = read_word_index(path)
+    assert [e.number for e in index.entries] == [1, 2, 3]
+    assert [(text, [o for o, _ in items]) for text, items in index.paragraphs] == [
+        ("Pump", [4]), ("Replace the seal and the gasket.", [16, 32]), ("Torque the cover.", [17])]
+
+    # A footnote restored earlier in the paragraph does not hide it
+    ctx = _context("<p>Replace the seal<fn>Kit 4</fn> and the gasket.</p><p>Torque the cover.</p>")
+    assert restore_word_index_terms(path, ctx) == 3
+    topic = ctx.topics["c.dita"]
+    # The heading's entry goes to the prolog, after the short description
+    assert [c.tag for c in topic] == ["title", "shortdesc", "prolog", "conbody"]
+    assert _terms(topic.find("prolog/metadata/keywords")) == ["Pump"]
+    first, second = topic.findall("conbody/p")
+    seal, gasket = first.findall("indexterm")
+    assert first.text == "Replace the seal" and _terms(seal) == ["Pump", "Seals", "Replacing"]
+    assert seal.find("indexterm/indexterm").get("start") == "SealWork"
+    assert gasket.find("index-see").text == "Pump" and gasket.find("index-see/indexterm").text == "Seals"
+    assert second[0].get("end") == "SealWork" and second.text == "Torque the cover."
+    assert any(e.category == "index" and "3 index entries" in e.message for e in ctx.report.entries)
+
+
+def test_prolog_placement_and_placeholders(tmp_path, docx_factory):
+    path = docx_factory(tmp_path / "manual.docx", BODY)
+    ctx = _context()
+    assert restore_word_index_terms(path, ctx, {"placement": "prolog"}) == 3
+    topic = ctx.topics["c.dita"]
+    keywords = topic.find("prolog/metadata/keywords")
+    assert [_terms(t)[0] for t in keywords] == ["Pump", "Pump", "Gaskets"]
+    assert not topic.findall("conbody//indexterm") and not any(t.get("start") for t in keywords.iter())
+
+    ctx = _context("<p>Replace the seal<ph data-indexterm='2'/> and the gasket.<ph data-indexterm='9'/></p>")
+    assert restore_word_index_terms(path, ctx) == 1
+    p = ctx.topics["c.dita"].find("conbody/p")
+    assert [c.tag for c in p] == ["indexterm"] and p[0].tail == " and the gasket."
+
+    ctx = _context("<p>Replace<ph data-indexterm='1'/> it.</p>")
+    assert restore_word_index_terms(path, ctx, {"enabled": False}) == 0
+    assert ctx.topics["c.dita"].find("conbody/p").text == "Replace it."
@@ -0,0 +1,77 @@
+import pytest
+from lxml import etree as ET
+
+from orlando_toolkit.core.integrity import apply_fix, check_integrity
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.services.structure_editing_service import StructureEditingService
+from orlando_toolkit.core.services.undo_service import UndoService
+from orlando_toolkit.ui.controllers.structure_controller import StructureController
+
+
+def _context():
+    topics = {
+        "a.dita": "<concept id='a'><title>Alpha</title><conbody>"
+                  "<p>See <xref href='gone.dita'>the old page</xref> and <xref href='b.dita#b/p2'>step</xref>.</p>"
+                  "<p>Also <xref href='#Bookmark'>here</xref>, <xref href='https://x.org' scope='external'/>"
+                  " and <xref href='b.dita#b/p9'>this</xref>.</p>"
+                  "<note conref='reuse.dita#reuse/w1'/><image href='../media/used.png'/></conbody></concept>",
+        "b.dita": "<concept id='b'><title>Beta</title><conbody><p id='p2' data-bookmarks='Bookmark'>B</p>"
+                  "<p id='p9'>Nine</p></conbody></concept>",
+        "c.dita": "<concept id='c'><title>Gamma</title><conbody><p id='p9'>C</p></conbody></concept>",
+    }
+    root = ET.fromstring("<map><topicref href='topics/a.dita'/><topicref href='topics/b.dita'/></map>")
+    ctx = DitaContext(ditamap_root=root, topics={k: ET.fromstring(v) for k, v in topics.items()}, metadata={})
+    ctx.images = {"used.png": b"1", "spare.png": b"2"}
+    ctx.topics["b.dita"].find("conbody/p[@id='p9']").set("id", "p8")
+    return ctx
+
+
+def _summary(issues):
+    return [(i.kind, i.topic, i.target) for i in issues]
+
+
+def test_check_lists_dangling_references_orphans_and_unused_images():
+    ctx = _context()
+    issues = check_integrity(ctx)
+    assert _summary(issues) == [
+        ("dangling_link", "a.dita", "gone.dita"),
+        ("dangling_link", "a.dita", "b.dita#b/p9"),
+        ("dangling_conref", "a.dita", "reuse.dita#reuse/w1"),
+        ("orphan_topic", "c.dita", "c.dita"),
+        ("unused_image", None, "spare.png"),
+    ]
+    assert issues[1].message == "Link target missing: b.dita has no element 'p9'"
+    assert issues[0].fixes == ("retarget", "remove") and issues[3].fixes == ("reattach", "delete")
+    with pytest.raises(ValueError):
+        apply_fix(ctx, issues[4], "retarget", "b.dita")
+    with pytest.raises(ValueError):
+        apply_fix(ctx, issues[2], "retarget", "b.dita")
+
+
+def test_quick_fixes_are_undoable_edits():
+    ctx = _context()
+    ctrl = StructureController(ctx, StructureEditingService(), UndoService(), None)
+    ctrl.start_history()
+
+    def _issue(kind, target):
+        return next(i for i in ctrl.get_integrity_issues() if i.kind == kind and i.target == target)
+
+    assert ctrl.handle_integrity_fix(_issue("dangling_link", "b.dita#b/p9"), "retarget", "c.dita").success
+    assert ctrl.handle_integrity_fix(_issue("dangling_link", "gone.dita"), "remove").success
+    assert ctrl.handle_integrity_fix(_issue("orphan_topic", "c.dita"), "reattach").success
+    assert ctrl.handle_integrity_fix(_issue("unused_image", "spare.png"), "delete").success
+    assert ctrl.handle_integrity_fix(_issue("dangling_conref", "reuse.dita#reuse/w1"), "remove").success
+
+    ctx = ctrl.context
+    first, second = ctx.topics["a.dita"].findall("conbody/p")
+    assert "".join(first.itertext()) == "See the old page and step."
+    assert [x.get("href") for x in first.findall("xref")] == ["b.dita#b/p2"]
+    assert second.findall("xref")[-1].get("href") == "c.dita#c/p9"
+    assert ctx.ditamap_root.findall("topicref")[-1].get("href") == "topics/c.dita"
+    assert ctx.ditamap_root.findtext("topicref[3]/topicmeta/navtitle") == "Gamma"
+    assert ctx.topics["a.dita"].find("conbody/note") is None and list(ctx.images) == ["used.png"]
+    assert check_integrity(ctx) == []
+
+    assert ctrl.undo_label() == "Remove broken link"
+    assert ctrl.undo()
+    assert ctrl.context.topics["a.dita"].find("conbody/note") is not None
@@ -0,0 +1,83 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.internal_links import mark_word_bookmarks, read_word_links, resolve_bookmark_links
+from orlando_toolkit.core.merge import merge_topicref_into
+from orlando_toolkit.core.models import DitaContext
+
+W = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+
+
+def _run(text):
+    return f'<w:r><w:t xml:space="preserve">{text}</w:t></w:r>'
+
+
+BODY = (
+    # TOC entries link to the headings too, and are ignored
+    '<w:p><w:r><w:fldChar w:fldCharType="begin"/></w:r><w:r><w:instrText>TOC \\o "1-3" \\h</w:instrText></w:r>'
+    '<w:r><w:fldChar w:fldCharType="separate"/></w:r>'
+    f'<w:hyperlink w:anchor="_Toc1">{_run("Pump")}</w:hyperlink></w:p>'
+    '<w:p><w:r><w:fldChar w:fldCharType="end"/></w:r></w:p>'
+    f'<w:bookmarkStart w:id="0" w:name="_Ref100"/><w:p>{_run("Pump")}</w:p><w:bookmarkEnd w:id="0"/>'
+    f'<w:p>{_run("See ")}<w:r><w:fldChar w:fldCharType="begin"/></w:r>'
+    '<w:r><w:instrText xml:space="preserve"> REF _Ref200 \\h </w:instrText></w:r>'
+    f'<w:r><w:fldChar w:fldCharType="separate"/></w:r>{_run("Filters")}'
+    f'<w:r><w:fldChar w:fldCharType="end"/></w:r>{_run(" and ")}'
+    f'<w:hyperlink w:anchor="_Ref300">{_run("the torque table")}</w:hyperlink>{_run(".")}</w:p>'
+    f'<w:p><w:bookmarkStart w:id="1" w:name="_Ref200"/>{_run("Filters")}<w:bookmarkEnd w:id="1"/></w:p>'
+    f'<w:p><w:bookmarkStart w:id="2" w:name="_Ref300"/>{_run("Torque values")}<w:bookmarkEnd w:id="2"/>'
+    f'<w:bookmarkStart w:id="3" w:name="_GoBack"/></w:p>'
+    f'<w:p><w:fldSimple w:instr=" REF _Ref999 \\h ">{_run("Annex")}</w:fldSimple></w:p>'
+)
+
+
+def _context():
+    topics = {
+        "topic_1.dita": "<concept id='topic_1'><title>Pump</title><conbody>"
+                        "<p>See Filters and <b>the torque table</b>.</p></conbody></concept>",
+        "topic_2.dita": "<concept id='topic_2'><title>Filters</title><conbody><p>Torque values</p>"
+                        "<p>Annex</p></conbody></concept>",
+    }
+    root = ET.fromstring("<map><topicref href='topics/topic_1.dita'><topicref href='topics/topic_2.dita'/>"
+                         "</topicref></map>")
+    return DitaContext(ditamap_root=root, topics={k: ET.fromstring(v) for k, v in topics.items()})
+
+
+def test_word_links_become_xrefs_to_the_bookmarked_topics(tmp_path, docx_factory):
+    path = docx_factory(tmp_path / "manual.docx", BODY)
+    read = read_word_links(path)
+    assert [(l.bookmark, l.offset, l.text, l.kind) for l in read.links] == [
+        ("_Ref200", 4, "Filters", "ref"), ("_Ref300", 16, "the torque table", "hyperlink"),
+        ("_Ref999", 0, "Annex", "ref")]
+    # Only linked bookmarks are kept; TOC entries and _GoBack are left out
+    assert [bookmarks for _, bookmarks, _ in read.paragraphs] == [[], ["_Ref200"], ["_Ref300"], []]
+
+    ctx = _context()
+    assert mark_word_bookmarks(path, ctx) == 3
+    p = ctx.topics["topic_1.dita"].find("conbody/p")
+    first, second = p.findall("xref")
+    assert p.text == "See " and first.get("href") == "#_Ref200" and first.text == "Filters"
+    assert second.get("href") == "#_Ref300" and second.find("b").text == "the torque table"
+    assert ctx.topics["topic_2.dita"].get("data-bookmarks") == "_Ref200"
+
+    assert resolve_bookmark_links(ctx) == 2
+    assert first.get("href") == "topic_2.dita"
+    torque = ctx.topics["topic_2.dita"].find("conbody/p")
+    assert second.get("href") == f"topic_2.dita#topic_2/{torque.get('id')}"
+    # The Annex link had no bookmark in the document: left as text
+    annex = ctx.topics["topic_2.dita"].findall("conbody/p")[1]
+    assert annex.find("xref") is None and annex.text == "Annex"
+    assert not any(el.get("data-bookmarks") for t in ctx.topics.values() for el in t.iter())
+    assert any(e.category == "links" and "_Ref999" in e.message for e in ctx.report.entries)
+
+
+def test_links_follow_a_merged_topic(tmp_path):
+    ctx = _context()
+    ctx.topics["topic_1.dita"].find("conbody/p/b").tag = "xref"
+    ctx.topics["topic_1.dita"].find("conbody/p/xref").set("href", "#_Filters")
+    ctx.topics["topic_2.dita"].set("data-anchor", "_Filters")
+    merge_topicref_into(ctx, ctx.topics["topic_1.dita"], ctx.ditamap_root.find(".//topicref/topicref"))
+    del ctx.topics["topic_2.dita"]
+
+    assert resolve_bookmark_links(ctx) == 1
+    merged_title = ctx.topics["topic_1.dita"].find("conbody/p[@outputclass='merged-title']")
+    assert ctx.topics["topic_1.dita"].find(".//xref").get("href") == f"topic_1.dita#topic_1/{merged_title.get('id')}"
@@ -0,0 +1,103 @@
+import zipfile
+
+import pytest
+
+from orlando_toolkit.api import Toolkit
+from orlando_toolkit.core.cancellation import OperationCancelledError
+from orlando_toolkit.core.concurrency import progress_steps
+from orlando_toolkit.core.jobs import ConversionJob, PackagingWorkspace, ResumeSettings, pending_workspaces
+from orlando_toolkit.core.progress import ProgressEvent, overall_fraction
+
+
+def _prepared(tmp_path):
+    source = tmp_path / "manual.md"
+    source.write_text("# Manual\n\n## Wiring\n\nConnect the cable.\n\n## Testing\n\nPower on.\n", encoding="utf-8")
+    job = ConversionJob("manual")
+    toolkit = Toolkit(plugins=False)
+    result = toolkit.convert(source, progress=job, cancel_token=job.token)
+    return toolkit.service, toolkit.service.prepare_package(result.context, progress_callback=job), job
+
+
+def test_progress_events_are_strings_with_a_phase():
+    event = ProgressEvent("Writing topics (5/10)...", "write", step="Writing topics", done=5, total=10)
+    assert event == "Writing topics (5/10)..." and event.fraction == 0.5
+    assert overall_fraction(event) == pytest.approx(0.35 + 0.25 + 0.1 + 0.125)
+    assert ProgressEvent("odd", "nowhere").phase == "parse"
+
+    seen = []
+    report = progress_steps(seen.append, "Writing images", steps=2, phase="write")
+    for done in range(1, 5):
+        report(done, 4)
+    assert seen == ["Writing images (2/4)...", "Writing images (4/4)..."]
+    assert all(isinstance(e, ProgressEvent) and e.phase == "write" for e in seen)
+
+
+def test_job_records_stage_events_and_tracks_state(tmp_path):
+    _, _, job = _prepared(tmp_path)
+    phases = [event.phase for event in job.events]
+    assert "process" in phases and phases[-1] == "prepare"
+    assert any(event.step == "typography" for event in job.events)
+    # Plain messages after the stages keep the phase of the event before them
+    done = next(event for event in job.events if event.startswith("Conversion successful"))
+    assert done.phase == "process"
+
+    seen = []
+    job = ConversionJob("x")
+    job.subscribe(seen.append)
+    assert job.run(lambda j: j("Parsing document...") or 7) == 7
+    assert job.state == "done" and seen == ["Parsing document..."] and job.fraction == 1.0
+
+    def _cancelled(j):
+        j.cancel()
+        j.token.raise_if_cancelled()
+
+    with pytest.raises(OperationCancelledError):
+        job.run(_cancelled)
+    assert job.state == "cancelled"
+
+
+def test_interrupted_packaging_is_resumed_from_the_snapshot(tmp_path):
+    service, context, _ = _prepared(tmp_path)
+    output = tmp_path / "out" / "manual.zip"
+    workspace = PackagingWorkspace.create(tmp_path / "jobs")
+    workspace.begin(context, output)
+    (workspace.package_dir / "DATA").mkdir()
+    (workspace.package_dir / "DATA" / "half-written.dita").write_text("<topic", encoding="utf-8")
+
+    # The process died here; the workspace waits for resume
+    [pending] = pending_workspaces(tmp_path / "jobs")
+    assert pending.state == "prepared" and pending.output == output.resolve()
+
+    events = []
+    assert service.resume_package(pending, progress_callback=events.append) == output.resolve()
+    with zipfile.ZipFile(output) as zf:
+        names = zf.namelist()
+    assert any(name.endswith(".ditamap") for name in names)
+    assert "DATA/half-written.dita" not in names
+    assert [e.phase for e in events][-1] == "archive"
+    assert pending_workspaces(tmp_path / "jobs") == [] and not workspace.directory.exists()
+
+
+def test_written_package_is_only_zipped_again(tmp_path):
+    service, context, _ = _prepared(tmp_path)
+    output = tmp_path / "manual.zip"
+    workspace = PackagingWorkspace.create(tmp_path / "jobs")
+    workspace.begin(context, output)
+    (workspace.package_dir / "marker.txt").write_text("kept", encoding="utf-8")
+    workspace.mark("written")
+
+    service.resume_package(workspace)
+    with zipfile.ZipFile(output) as zf:
+        assert zf.namelist() == ["marker.txt"]
+
+
+def test_cancelled_packaging_leaves_no_workspace(tmp_path):
+    service, context, job = _prepared(tmp_path)
+    workspace = PackagingWorkspace.create(tmp_path / "jobs")
+    workspace.begin(context, tmp_path / "manual.zip")
+    job.cancel()
+
+    with pytest.raises(OperationCancelledError):
+        service.write_package(context, tmp_path / "manual.zip", cancel_token=job.token, workspace=workspace)
+    assert not workspace.directory.exists() and not (tmp_path / "manual.zip").exists()
+    assert ResumeSettings.from_mapping({"enabled": False, "workspace_dir": "~/otk"}).root.name == "otk"
@@ -0,0 +1,57 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.keys import apply_key_table, key_table, key_usage, write_keydef_map
+from orlando_toolkit.core.models import DitaContext
+
+
+def _context(body, **metadata):
+    topic = ET.fromstring(f"<concept id='t'><title>Orlando X200 care</title><conbody>{body}</conbody></concept>")
+    root = ET.fromstring("<map><title>Guide</title><topicref href='topics/t.dita'/></map>")
+    return DitaContext(ditamap_root=root, topics={"t.dita": topic}, metadata=dict(metadata))
+
+
+def test_typed_values_of_the_key_table_become_keyrefs():
+    ctx = _context("<p>Start the Orlando\n X200 pump. Orlando is gone.</p><p><codeph>Orlando X200</codeph> "
+                   "<xref href='#x'>Orlando X200</xref> <keyword keyref='Product'/></p>"
+                   "<codeblock>Orlando X200</codeblock><p>Rev C, not Rev Cx</p>",
+                   conversion_options={"variables": {"values": {"Brand": "Orlando", "Revision": "Rev C",
+                                                                "Legacy": "Old"}}},
+                   variables={"Product": "Orlando X200", "Legacy": None})
+    assert key_table(ctx.metadata) == {"Brand": "Orlando", "Revision": "Rev C", "Product": "Orlando X200"}
+    assert key_usage(ctx) == {"Brand": 1, "Revision": 1, "Product": 6}
+
+    assert apply_key_table(ctx) == {"Product": 2, "Brand": 1, "Revision": 1}
+    topic = ctx.topics["t.dita"]
+    first = topic.find("conbody/p")
+    assert first.text == "Start the " and [k.get("keyref") for k in first] == ["Product", "Brand"]
+    assert first[0].tail == " pump. " and first[1].tail == " is gone."
+    assert topic.find("title")[0].get("keyref") == "Product" and topic.find("title")[0].tail == " care"
+    second = topic.findall("conbody/p")[1]
+    assert second.find("codeph").text == "Orlando X200" and second.find("xref").text == "Orlando X200"
+    assert topic.findtext("conbody/codeblock") == "Orlando X200"
+    last = topic.findall("conbody/p")[2]
+    assert last.text is None and last[0].get("keyref") == "Revision" and last[0].tail == ", not Rev Cx"
+    assert ctx.report.count("info", "variables") == 1
+
+    ctx.metadata["conversion_options"]["variables"]["match_values"] = False
+    assert apply_key_table(_context("<p>Orlando</p>", **ctx.metadata)) == {}
+
+
+def test_keydefs_are_written_to_a_key_definition_map(tmp_path):
+    ctx = _context("<p>x</p>", variables={"Product": "Orlando X200", "Version": "2.1"})
+    ctx.ditamap_root.insert(1, ET.fromstring(
+        "<keydef keys='Version'><topicmeta><keywords><keyword>2.0</keyword></keywords></topicmeta></keydef>"))
+    ctx.ditamap_root.append(ET.fromstring("<keydef keys='intro' href='topics/t.dita'/>"))
+    path = write_keydef_map(ctx, tmp_path)
+    assert path == tmp_path / "keydefs.ditamap"
+    keymap = ET.parse(str(path)).getroot()
+    assert [(k.get("keys"), k.findtext("topicmeta/keywords/keyword")) for k in keymap.findall("keydef")] == [
+        ("Version", "2.0"), ("Product", "Orlando X200")]
+    assert [el.tag for el in ctx.ditamap_root] == ["title", "mapref", "topicref", "keydef"]
+    mapref = ctx.ditamap_root.find("mapref")
+    assert (mapref.get("href"), mapref.get("processing-role")) == ("keydefs.ditamap", "resource-only")
+
+    inline = _context("<p>x</p>", variables={"Product": "Orlando X200"},
+                      conversion_options={"variables": {"keydef_map": None}})
+    assert write_keydef_map(inline, tmp_path / "other") is None
+    assert [k.get("keys") for k in inline.ditamap_root.findall("keydef")] == ["Product"]
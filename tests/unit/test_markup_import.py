@@ -0,0 +1,177 @@
+from orlando_toolkit.core.importers import MarkupDocumentImporter
+from orlando_toolkit.core.importers.asciidoc import AsciiDocParser
+from orlando_toolkit.core.importers.markdown import MarkdownParser
+from orlando_toolkit.core.services.conversion_service import ConversionService
+
+_MARKDOWN = """# User Manual
+
+Intro paragraph.
+
+## Installation {#install}
+
+Run the installer, then see [usage](#usage).
+
+```python
+print("hello")
+```
+
+![Wiring](img/wiring.png)
+
+## Usage
+
+> [!WARNING]
+> Unplug the unit first.
+
+| Key | Action |
+| --- | ------ |
+| F1  | Help   |
+"""
+
+
+def _write(tmp_path, name, text):
+    path = tmp_path / name
+    path.write_text(text, encoding="utf-8")
+    return path
+
+
+def test_markdown_headings_become_topics_with_links_and_images(tmp_path):
+    (tmp_path / "img").mkdir()
+    (tmp_path / "img" / "wiring.png").write_bytes(b"\x89PNG")
+    ctx = MarkupDocumentImporter().import_document(_write(tmp_path, "manual.md", _MARKDOWN), {})
+
+    assert ctx.ditamap_root.find("title").text == "User Manual"
+    refs = ctx.ditamap_root.findall("topicref")
+    assert [r.get("data-level") for r in refs] == ["1", "1", "1"]
+    assert [r.findtext("topicmeta/navtitle") for r in refs] == ["User Manual", "Installation", "Usage"]
+    install = ctx.topics[refs[1].get("href").split("/")[-1]]
+    assert install.find(".//xref").get("href") == refs[2].get("href").split("/")[-1]
+    assert install.find(".//codeblock").get("outputclass") == "language-python"
+    assert install.find(".//fig/image").get("href") == "../media/wiring.png" and "wiring.png" in ctx.images
+    usage = ctx.topics[refs[2].get("href").split("/")[-1]]
+    assert usage.find(".//note").get("type") == "warning"
+    assert [e.text for e in usage.findall(".//sthead/stentry")] == ["Key", "Action"]
+    assert ctx.plugin_data["_source_plugin"] == "built-in:markdown"
+
+
+def test_asciidoc_title_attributes_and_blocks():
+    parsed = AsciiDocParser().parse(
+        "= Service Guide\n:lang: de-DE\n:product: Widget\n\n[[setup]]\n== Setup\n\n"
+        "NOTE: The {product} ships calibrated.\n\n[source,bash]\n----\nmake install\n----\n\n"
+        "[%header]\n|===\n|Name |Value\n|size |10\n|===\n\nSee <<setup,the setup>>.\n")
+    assert parsed.title == "Service Guide" and parsed.attributes["lang"] == "de-DE"
+    section = parsed.sections[0]
+    assert (section.level, section.title, section.anchor) == (1, "Setup", "setup")
+    tags = [b.tag for b in section.blocks]
+    assert tags[:3] == ["note", "codeblock", "simpletable"]
+    assert "Widget ships calibrated" in "".join(section.blocks[0].itertext())
+    assert section.blocks[1].get("outputclass") == "language-bash"
+    assert section.blocks[-1].find("xref").get("href") == "#setup"
+    assert MarkdownParser().can_parse("README.MD") and not AsciiDocParser().can_parse("README.MD")
+
+
+def test_conversion_service_converts_markdown_without_plugins(tmp_path):
+    service = ConversionService()
+    path = _write(tmp_path, "notes.md", "# Notes\n\n## First\n\nText.\n\n### Detail\n\nMore.\n")
+    assert service.can_handle_file(path)
+    ctx = service.convert(path, {})
+    assert ctx.metadata["manual_title"] == "Notes"
+    first = ctx.ditamap_root.find("topicref")
+    assert first.findtext("topicmeta/navtitle") == "First"
+    assert first.find("topicref/topicmeta/navtitle").text == "Detail"
+
+
+def test_parallel_import_matches_serial_and_reports_progress(tmp_path):
+    from lxml import etree as ET
+
+    (tmp_path / "img").mkdir()
+    parts = ["# Big Manual\n"]
+    for n in range(40):
+        (tmp_path / "img" / f"fig{n % 7}.png").write_bytes(b"\x89PNG" + bytes([n % 7]))
+        parts.append(f"## Section {n} {{#s{n}}}\n\nSee [next](#s{n + 1}).\n\n![Figure](img/fig{n % 7}.png)\n")
+    source = _write(tmp_path, "big.md", "\n".join(parts))
+
+    def run(workers):
+        messages = []
+        ctx = MarkupDocumentImporter().import_document(source, {"pipeline": {"workers": workers}}, messages.append)
+        dump = [ET.tostring(ctx.ditamap_root)] + [ET.tostring(t) for t in ctx.topics.values()]
+        return ctx, dump, messages
+
+    serial, serial_dump, _ = run(1)
+    parallel, parallel_dump, messages = run(4)
+    assert list(parallel.topics) == list(serial.topics) and parallel_dump == serial_dump
+    assert parallel.images == serial.images and len(parallel.images) == 7
+    assert messages[-2] == "Building topics (40/40)..." and "Building topics (20/40)..." in messages
+
+
+def test_ragged_and_unruled_tables():
+    markdown = MarkdownParser().parse("| A | B |\n|---|---|\n| 1 |\n| 2 | 3 | 4 |\n\n| A | B |\n| 1 | 2 |\n")
+    table, para = markdown.preamble
+    assert [[e.text or "" for e in row] for row in table.findall("strow")] == [["1", ""], ["2", "3"]]
+    assert para.tag == "p" and para.text == "| A | B | | 1 | 2 |"
+
+    asciidoc = AsciiDocParser().parse("|===\n|a |b\n|c\n|===\n")
+    table = asciidoc.preamble[0]
+    assert table.find("sthead") is None
+    assert [[e.text or "" for e in row] for row in table.findall("strow")] == [["a", "b"], ["c", ""]]
+
+
+def test_skipped_heading_levels_nest_under_the_closest_shallower_heading(tmp_path):
+    ctx = MarkupDocumentImporter().import_document(_write(tmp_path, "guide.md", "## A\n\n#### B\n\n### C\n"), {})
+    top = ctx.ditamap_root.findall("topicref")
+    assert [r.findtext("topicmeta/navtitle") for r in top] == ["A"]
+    nested = top[0].findall("topicref")
+    assert [(r.findtext("topicmeta/navtitle"), r.get("data-level")) for r in nested] == [("B", "4"), ("C", "3")]
+
+
+def test_unclosed_fences_run_to_the_end_of_the_document():
+    markdown = MarkdownParser().parse("# Build\n\n```sh\nmake\n\n## Later\n")
+    assert len(markdown.sections) == 1 and [b.tag for b in markdown.sections[0].blocks] == ["codeblock"]
+    assert markdown.sections[0].blocks[0].text.startswith("make\n\n## Later")
+
+    asciidoc = AsciiDocParser().parse("== Build\n\n----\nmake\n\n== Later\n")
+    assert len(asciidoc.sections) == 1 and [b.tag for b in asciidoc.sections[0].blocks] == ["codeblock"]
+    assert asciidoc.sections[0].blocks[0].text.startswith("make\n\n== Later")
+
+
+def test_nested_lists():
+    def shape(element):
+        return [(li.text, [(child.tag, shape(child)) for child in li]) for li in element]
+
+    markdown = MarkdownParser().parse("- one\n  - nested\n  - more\n- two\n  1. first\n").preamble[0]
+    assert markdown.tag == "ul"
+    assert shape(markdown) == [("one", [("ul", [("nested", []), ("more", [])])]),
+                               ("two", [("ol", [("first", [])])])]
+
+    asciidoc = AsciiDocParser().parse("* one\n** nested\n* two\n. step\n").preamble[0]
+    assert asciidoc.tag == "ul"
+    assert shape(asciidoc) == [("one", [("ul", [("nested", [])])]), ("two", [("ol", [("step", [])])])]
+
+
+def test_admonitions():
+    markdown = MarkdownParser().parse("> [!TIP]\n> Use the **torque** wrench.\n>\n> Then check.\n\n"
+                                      "> [!caution]\n> Hot.\n\n> [!UNKNOWN]\n> Plain quote.\n").preamble
+    assert [(b.tag, b.get("type")) for b in markdown] == [("note", "tip"), ("note", "caution"), ("lq", None)]
+    assert [p.tag for p in markdown[0]] == ["p", "p"] and markdown[0][0].find("b").text == "torque"
+    assert "".join(markdown[2].itertext()) == "[!UNKNOWN] Plain quote."
+
+    asciidoc = AsciiDocParser().parse("== Safety\n\nWARNING: Hot surface.\n\n[TIP]\nUse gloves.\n\n"
+                                      "[IMPORTANT]\n====\nFirst.\n\nSecond.\n====\n\nCAUTION:no space\n")
+    blocks = asciidoc.sections[0].blocks
+    assert [(b.tag, b.get("type")) for b in blocks] == [("note", "warning"), ("note", "tip"),
+                                                        ("note", "important"), ("p", None)]
+    assert blocks[0].findtext("p") == "Hot surface." and blocks[1].findtext("p") == "Use gloves."
+    assert [p.text for p in blocks[2]] == ["First.", "Second."]
+
+
+def test_links_and_images_with_disallowed_schemes_are_removed(tmp_path):
+    text = ("# Notes\n\nSee [the **site**](javascript:evil) or [docs](https://example.com/).\n\n"
+            "![Evil](javascript:evil)\n\nInline ![x](vbscript:run) here.\n")
+    ctx = MarkupDocumentImporter().import_document(_write(tmp_path, "notes.md", text), {})
+
+    topic = next(iter(ctx.topics.values()))
+    assert [x.get("href") for x in topic.iter("xref")] == ["https://example.com/"]
+    assert "See the site or docs." in "".join(topic.find(".//p").itertext())
+    assert topic.find(".//p/b").text == "site"
+    assert topic.find(".//fig") is None and topic.find(".//image") is None
+    assert "Inline  here." in ["".join(p.itertext()) for p in topic.iter("p")]
+    assert sum("disallowed URL" in e.message for e in ctx.report.entries) == 3
@@ -0,0 +1,105 @@
+import io
+import zipfile
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.ole_objects import object_type, render_sheet_svg, restore_word_objects
+
+NS = ('xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" '
+      'xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" '
+      'xmlns:v="urn:schemas-microsoft-com:vml" xmlns:o="urn:schemas-microsoft-com:office:office"')
+SVG = "{http://www.w3.org/2000/svg}"
+
+
+def _object(prog_id, rel, preview=None, alt=""):
+    image = f'<v:imagedata r:id="{preview}"/>' if preview else ""
+    return (f'<w:r><w:object><v:shape alt="{alt}">{image}</v:shape>'
+            f'<o:OLEObject Type="Embed" ProgID="{prog_id}" r:id="{rel}"/></w:object></w:r>')
+
+
+def _workbook():
+    sml = 'xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"'
+    buffer = io.BytesIO()
+    with zipfile.ZipFile(buffer, "w") as zf:
+        zf.writestr("xl/workbook.xml", f'<workbook {sml} xmlns:r="http://schemas.openxmlformats.org/officeDocument/'
+                                       '2006/relationships"><sheets><sheet name="Costs" r:id="rId1"/></sheets></workbook>')
+        zf.writestr("xl/_rels/workbook.xml.rels",
+                    '<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">'
+                    '<Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>')
+        zf.writestr("xl/sharedStrings.xml", f"<sst {sml}><si><t>Part</t></si><si><t>Cost</t></si>"
+                                            "<si><t>Impeller</t></si></sst>")
+        zf.writestr("xl/worksheets/sheet1.xml",
+                    f'<worksheet {sml}><sheetData><row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c>'
+                    '</row><row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>42</v></c></row></sheetData></worksheet>')
+    return buffer.getvalue()
+
+
+def _context(body, images):
+    topic = ET.fromstring(f"<concept id='c'><title>C</title><conbody>{body}</conbody></concept>")
+    return DitaContext(ditamap_root=ET.fromstring("<map><topicref href='topics/c.dita'/></map>"),
+                       topics={"c.dita": topic}, images=images, metadata={})
+
+
+def test_embedded_workbook_becomes_object_with_its_preview(tmp_path, docx_factory):
+    body = ('<w:p><w:r><w:t>Budget below.</w:t></w:r></w:p>'
+            '<w:p>' + _object("Excel.Sheet.12", "rId2", "rId1", alt="Budget") + '</w:p>'
+            '<w:p>' + _object("Equation.3", "rId3", "rId4") + '</w:p>')
+    path = docx_factory(tmp_path / "manual.docx", body, namespaces=NS,
+                        rels={"rId1": "media/image1.emf", "rId2": "embeddings/Microsoft_Excel_Worksheet.xlsx",
+                              "rId3": "embeddings/oleObject1.bin", "rId4": "media/image2.wmf"},
+                        parts={"word/media/image1.emf": b"EMF", "word/embeddings/oleObject1.bin": b"EQ",
+                               "word/embeddings/Microsoft_Excel_Worksheet.xlsx": b"XLSX",
+                               "word/media/image2.wmf": b"WMF"})
+    ctx = _context("<p>Budget below.</p><p><image href='../media/image1.emf'/></p>", {"image1.emf": b"EMF"})
+
+    assert restore_word_objects(path, ctx) == 1
+
+    fig = ctx.topics["c.dita"].find("conbody/fig")
+    assert fig.get("outputclass") == "excel" and fig.getprevious().text == "Budget below."
+    assert fig.find("image").get("href") == "../media/image1.emf" and fig.findtext("image/alt") == "Budget"
+    obj = fig.find("object")
+    assert obj.get("data") == "../media/excel_1.xlsx" and obj.findtext("desc") == "Budget"
+    assert obj.get("type") == "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+    assert ctx.videos == {"excel_1.xlsx": b"XLSX"} and set(ctx.images) == {"image1.emf"}
+    assert ctx.topics["c.dita"].find(".//image[@href='../media/image2.wmf']") is None
+    [entry] = [e for e in ctx.report.entries if e.category == "ole_objects"]
+    assert entry.detail == {"attached": 1, "excel": 1}
+
+
+def test_modes_per_type_and_macro_files(tmp_path, docx_factory):
+    body = ('<w:p><w:r><w:t>Wiring diagram:</w:t></w:r>' + _object("Visio.Drawing.11", "rId2", "rId1") + '</w:p>'
+            '<w:p><w:r><w:t>Macros:</w:t></w:r>' + _object("Excel.SheetMacroEnabled.12", "rId3", "rId4") + '</w:p>')
+    path = docx_factory(tmp_path / "manual.docx", body, namespaces=NS,
+                        rels={"rId1": "media/image1.wmf", "rId2": "embeddings/oleObject1.bin",
+                              "rId3": "embeddings/Book.xlsm", "rId4": "media/image2.emf"},
+                        parts={"word/media/image1.wmf": b"WMF", "word/embeddings/oleObject1.bin": b"VSD",
+                               "word/embeddings/Book.xlsm": b"XLSM", "word/media/image2.emf": b"EMF"})
+    ctx = _context("<p>Wiring diagram:</p><p>Macros:</p>", {})
+
+    assert restore_word_objects(path, ctx, {"types": {"visio": "link", "excel": "object"}}) == 2
+
+    visio, excel = ctx.topics["c.dita"].findall("conbody/fig")
+    assert visio.find("image").get("href") == "../media/visio_1_preview.wmf"
+    xref = visio.find("p/xref")
+    assert xref.get("href") == "../media/visio_1.vsd" and xref.get("format") == "vsd"
+    assert xref.text == "Open the Visio diagram"
+    assert excel.find("object") is None and excel.find("image") is not None
+    assert ctx.videos == {"visio_1.vsd": b"VSD"}
+    assert any("macro-enabled" in e.message for e in ctx.report.entries if e.category == "ole_objects")
+
+
+def test_workbook_without_preview_is_drawn_from_its_first_sheet(tmp_path, docx_factory):
+    workbook = _workbook()
+    svg = ET.fromstring(render_sheet_svg(workbook))
+    assert [t.text for t in svg.iter(f"{SVG}text")] == ["Part", "Cost", "Impeller", "42"]
+    assert object_type("", ".vsdx") == "visio" and object_type("Package", ".bin") == "other"
+
+    path = docx_factory(tmp_path / "manual.docx", '<w:p><w:r><w:t>Costs</w:t></w:r>'
+                        + _object("Excel.Sheet.12", "rId1") + '</w:p>', namespaces=NS,
+                        rels={"rId1": "embeddings/Book.xlsx"}, parts={"word/embeddings/Book.xlsx": workbook})
+    ctx = _context("<p>Costs</p>", {})
+    assert restore_word_objects(path, ctx, {"types": {"excel": "image"}}) == 1
+    fig = ctx.topics["c.dita"].find("conbody/fig")
+    assert fig.find("image").get("href") == "../media/excel_1_preview.svg" and fig.find("object") is None
+    assert ctx.images["excel_1_preview.svg"].startswith(b"<?xml") and ctx.videos == {}
@@ -0,0 +1,84 @@
+import json
+
+import pytest
+
+import orlando_toolkit.options as options_module
+from orlando_toolkit.config import ConfigManager
+from orlando_toolkit.options import (
+    ConversionOptions,
+    OutputProfile,
+    build_options,
+    with_output_profile,
+    with_stage,
+    resolve_style_map,
+    with_style_map,
+    with_style_map_file,
+    with_title,
+    with_topic_depth,
+)
+
+
+def test_options_compose_in_order_into_metadata():
+    options = build_options(
+        with_title("Guide"),
+        with_stage("bidi", detect_direction=False),
+        with_style_map({"Chapter": 1}),
+        with_stage("bidi", default_direction="rtl"),
+        with_style_map({"Chapter": 2, "Section": "3"}),
+        with_topic_depth(2),
+    )
+    assert options.to_metadata() == {
+        "manual_title": "Guide",
+        "topic_depth": 2,
+        "conversion_options": {"bidi": {"detect_direction": False, "default_direction": "rtl"}},
+        "style_map": {"Chapter": 2, "Section": 3},
+    }
+    with pytest.raises(ValueError):
+        with_topic_depth(0)
+
+
+def test_profiles_extend_and_later_options_win(monkeypatch):
+    base = OutputProfile.from_mapping("base", {"conversion_options": {"ids": {"stable": True, "strategy": "hash"}}})
+    web = OutputProfile.from_mapping("web", {"extends": "base", "metadata": {"topic_depth": 3},
+                                            "conversion_options": {"ids": {"strategy": "path"}}})
+    monkeypatch.setattr(options_module, "get_output_profile", lambda name: {"base": base}[name])
+    metadata = build_options(with_output_profile(web), with_topic_depth(2)).to_metadata()
+    assert metadata["conversion_options"] == {"ids": {"stable": True, "strategy": "path"}}
+    assert metadata["topic_depth"] == 2
+    assert metadata["output_profiles"] == ["base", "web"]
+
+    loop = OutputProfile.from_mapping("loop", {"extends": ["loop"]})
+    with pytest.raises(ValueError, match="extends itself"):
+        build_options(with_output_profile(loop))
+
+
+def test_legacy_metadata_and_job_files_still_load(tmp_path):
+    legacy = {"manual_title": "Old", "conversion_options": {"symbols": {"enabled": False}}}
+    assert build_options(legacy).to_metadata() == legacy
+    assert ConversionOptions.from_metadata(legacy).conversion_options == {"symbols": {"enabled": False}}
+
+    job = tmp_path / "job.json"
+    job.write_text(json.dumps({"metadata": {"manual_code": "OM-1"}, "style_map": {"Titre 1": 1},
+                               "manual_title": "Job"}), encoding="utf-8")
+    loaded = ConversionOptions.load(job).to_metadata()
+    assert loaded == {"manual_title": "Job", "manual_code": "OM-1", "style_map": {"Titre 1": 1}}
+
+
+def test_style_map_files_load_from_profiles_and_jobs(tmp_path, monkeypatch):
+    corporate = tmp_path / "corporate.yml"
+    corporate.write_text('styles:\n  "Corp Chapter": 1\n  "Corp Button": uicontrol\n'
+                         '  "Corp Warning": {element: note, type: warning}\n', encoding="utf-8")
+    profile = OutputProfile.from_mapping("corp", {"style_map_file": str(corporate), "style_map": {"Corp Chapter": 2}})
+    metadata = build_options(with_output_profile(profile)).to_metadata()
+    assert metadata["style_map"] == {"Corp Chapter": 2, "Corp Button": "uicontrol",
+                                     "Corp Warning": {"element": "note", "type": "warning"}}
+    monkeypatch.setattr(ConfigManager, "get_style_map", lambda self: {"Heading 1": 1})
+    assert resolve_style_map(metadata) == {"Heading 1": 1, "Corp Chapter": 2}
+
+    job = tmp_path / "job.json"
+    job.write_text(json.dumps({"style_map_file": "corporate.yml", "style_map": {"Corp Button": "wintitle"}}),
+                   encoding="utf-8")
+    assert ConversionOptions.load(job).style_map["Corp Button"] == "wintitle"
+    assert build_options(with_style_map_file(corporate)).style_map["Corp Chapter"] == 1
+    with pytest.raises(ValueError, match="unsupported element"):
+        with_style_map({"Odd": "table"})
@@ -0,0 +1,78 @@
+import pytest
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.outline import check_outline, outline_rows, read_outline, write_outline
+from orlando_toolkit.core.services.structure_editing_service import StructureEditingService
+from orlando_toolkit.core.services.undo_service import UndoService
+from orlando_toolkit.ui.controllers.structure_controller import StructureController
+
+
+def _ref(name, title, children="", level=2):
+    return (f"<topicref href='topics/{name}.dita' data-level='{level}'><topicmeta><navtitle>{title}</navtitle>"
+            f"</topicmeta>{children}</topicref>")
+
+
+def _context():
+    titles = {"install": "Installation", "mount": "Mount the pump", "wire": "Wire it", "faq": "FAQ"}
+    root = ET.fromstring("<map>" + _ref("install", "Installation", _ref("mount", "Mount the pump")
+                                                                  + _ref("wire", "Wire it"), level=1)
+                         + "<topichead><topicmeta><navtitle>Help</navtitle></topicmeta>" + _ref("faq", "FAQ")
+                         + "</topichead><reltable/></map>")
+    topics = {f"{n}.dita": ET.fromstring(f"<concept id='{n}'><title>{t}</title><conbody><p>One two three.</p>"
+                                         f"</conbody></concept>") for n, t in titles.items()}
+    return DitaContext(ditamap_root=root, topics=topics, metadata={})
+
+
+def _tree(node):
+    return [(el.findtext("topicmeta/navtitle"), _tree(el)) for el in node if el.tag in ("topicref", "topichead")]
+
+
+@pytest.mark.parametrize("suffix", [".xlsx", ".csv"])
+def test_outline_round_trips_and_applies_titles_and_order(tmp_path, suffix):
+    ctx = _context()
+    rows = outline_rows(ctx)
+    assert [(r.number, r.level, r.title, r.kind, r.filename, r.words) for r in rows] == [
+        ("1", 1, "Installation", "topic", "install.dita", 3), ("1.1", 2, "Mount the pump", "topic", "mount.dita", 3),
+        ("1.2", 2, "Wire it", "topic", "wire.dita", 3), ("2", 1, "Help", "section", "", 0),
+        ("2.1", 2, "FAQ", "topic", "faq.dita", 3)]
+    path = write_outline(rows, tmp_path / f"outline{suffix}")
+    read = read_outline(path)
+    assert [(r.number, r.level, r.title, r.filename, r.line) for r in read] == [
+        (r.number, r.level, r.title, r.filename, n) for n, r in enumerate(rows, start=2)]
+
+    faq, install, mount, wire, help_ = read[4], read[0], read[1], read[2], read[3]
+    faq.level, wire.title, help_.title = 1, "Wire the pump", "Support"
+    ctrl = StructureController(ctx, StructureEditingService(), UndoService(), None)
+    ctrl.start_history()
+    result = ctrl.handle_apply_outline([faq, install, mount, wire, help_])
+    assert result.success and result.details == {"renamed": 2, "moved": 5}
+    assert _tree(ctx.ditamap_root) == [("FAQ", []), ("Installation", [("Mount the pump", []),
+                                                                      ("Wire the pump", [])]), ("Support", [])]
+    assert ctx.ditamap_root[-1].tag == "reltable"
+    assert ctx.topics["wire.dita"].findtext("title") == "Wire the pump"
+    assert ctx.ditamap_root[0].get("data-level") == "1" and ctx.ditamap_root[0].get("data-style") == "Heading 1"
+    assert ctrl.undo_label() == "Apply outline"
+    assert ctrl.undo() and _tree(ctrl.context.ditamap_root)[0][0] == "Installation"
+
+
+def test_a_sheet_that_does_not_match_the_map_is_refused(tmp_path):
+    bad = tmp_path / "bad.csv"
+    bad.write_text("Level;Name\n1;Installation\n", encoding="utf-8")
+    with pytest.raises(ValueError, match="no Number or Title column"):
+        read_outline(bad)
+
+    sheet = tmp_path / "outline.csv"
+    sheet.write_text("Number;Level;Title;Topic file\n1;1;Installation;install.dita\n1.1;3;Mount;mount.dita\n"
+                     "1.2;2;;wire.dita\n9;1;Extra;\n2.1;1;FAQ;install.dita\n", encoding="cp1252")
+    ctx = _context()
+    before = ET.tostring(ctx.ditamap_root)
+    _pairs, problems = check_outline(ctx, read_outline(sheet))
+    assert problems == [
+        "Row 3: level 3 must be between 1 and 2", "Row 4: the title is empty", "Row 5: no entry 9 in the map",
+        "Row 6: install.dita is listed twice (row 2)",
+        "2 entries are missing from the sheet: 2, 2.1. Rows cannot be deleted; export the outline again if the "
+        "structure changed"]
+    result = StructureEditingService().apply_outline(ctx, read_outline(sheet))
+    assert not result.success and len(result.details["problems"]) == 5
+    assert ET.tostring(ctx.ditamap_root) == before
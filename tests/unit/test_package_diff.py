@@ -0,0 +1,70 @@
+import csv
+import io
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.package_diff import compare_images, compare_package_contexts, compare_packages
+
+
+def _context(map_xml, topics, images=None) -> DitaContext:
+    return DitaContext(
+        ditamap_root=ET.fromstring(map_xml),
+        topics={name: ET.fromstring(f"<concept id='{name[:-5]}'><title>{title}</title><conbody>{body}</conbody></concept>")
+                for name, (title, body) in topics.items()},
+        images=dict(images or {}),
+    )
+
+
+_TOPICS = {"a.dita": ("Install", "<p>Unpack.</p>"), "b.dita": ("Wiring", "<p>Connect.</p>"),
+           "c.dita": ("Safety", "<p>Careful.</p>")}
+
+
+def test_structure_reports_moves_and_reorders():
+    old = _context("<map><topichead navtitle='Setup'><topicref href='topics/a.dita'/>"
+                   "<topicref href='topics/b.dita'/></topichead><topicref href='topics/c.dita'/></map>", _TOPICS)
+    new = _context("<map><topicref href='topics/c.dita'/><topichead navtitle='Setup'>"
+                   "<topicref href='topics/b.dita'/></topichead><topicref href='topics/a.dita'/></map>", _TOPICS)
+    diff = compare_package_contexts(old, new)
+
+    changes = {(c.status, c.path) for c in diff.structure}
+    assert ("moved", "Install") in changes
+    assert ("reordered", "Safety") in changes
+    assert ("reordered", "Setup") in changes
+    assert not diff.content.topics
+
+
+def test_images_detect_renames_and_changes():
+    changes = compare_images({"a.png": b"one", "b.png": b"two", "c.png": b"three"},
+                             {"a.png": b"ONE", "b2.png": b"two", "d.png": b"four"})
+    assert [(c.status, c.name, c.old_name) for c in changes] == [
+        ("changed", "a.png", ""), ("renamed", "b2.png", "b.png"), ("added", "d.png", ""), ("removed", "c.png", ""),
+    ]
+
+
+def test_delivery_note_csv_and_html():
+    old = _context("<map><topicref href='topics/a.dita'/></map>", {"a.dita": ("Install", "<p>Unpack.</p>")},
+                   {"fig.png": b"1"})
+    new = _context("<map><topicref href='topics/a.dita'/><topicref href='topics/c.dita'/></map>",
+                   {"a.dita": ("Install", "<p>Unpack it.</p>"), "c.dita": ("Safety", "<p>Careful.</p>")},
+                   {"fig.png": b"2"})
+    diff = compare_package_contexts(old, new, old_source="v1.zip", new_source="v2.zip")
+
+    rows = list(csv.reader(io.StringIO(diff.to_csv())))
+    assert rows[0] == ["area", "status", "item", "detail"]
+    assert ["structure", "added", "Safety", ""] in rows
+    assert ["topic", "changed", "Install", "1 block(s)"] in rows
+    assert ["image", "changed", "fig.png", ""] in rows
+    page = diff.to_html()
+    assert "<h1>Delivery note</h1>" in page and "<h2>Images</h2>" in page and "fig.png" in page
+
+
+def test_compare_packages_imports_both_archives():
+    class _Service:
+        def convert(self, path, metadata):
+            body = "<p>One</p>" if path == "v1.zip" else "<p>Two</p>"
+            return _context("<map><topicref href='topics/a.dita'/></map>", {"a.dita": ("Intro", body)})
+
+    diff = compare_packages("v1.zip", "v2.zip", service=_Service())
+    assert diff.content.count("changed") == 1 and diff.new_source == "v2.zip"
+    assert diff.structure == [] and diff.images == []
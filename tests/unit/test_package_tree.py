@@ -0,0 +1,66 @@
+import pytest
+
+from orlando_toolkit.core.package_tree import MARKER, PackageTreeError, write_package_tree
+
+_MAP = ('<?xml version="1.0" encoding="UTF-8"?><!DOCTYPE map PUBLIC "-//OASIS//DTD DITA Map//EN" "map.dtd">'
+        '<map><title>Manual</title><mapref href="keys.ditamap"/><topicref href="topics/{name}"/></map>')
+_TOPIC = ('<?xml version="1.0" encoding="UTF-8"?><concept id="a"><title>Install</title>'
+          '<conbody><p outputclass="x" id="p1">{text}</p><image href="../media/fig.png"/></conbody></concept>')
+
+
+def _package(root, name="install.dita", text="Unpack.", image=b"png"):
+    data = root / "DATA"
+    (data / "topics").mkdir(parents=True)
+    (data / "media").mkdir()
+    (data / "manual.ditamap").write_text(_MAP.format(name=name), encoding="utf-8")
+    (data / "keys.ditamap").write_text("<map/>", encoding="utf-8")
+    (data / "topics" / name).write_text(_TOPIC.format(text=text), encoding="utf-8")
+    (data / "media" / "fig.png").write_bytes(image)
+    return root
+
+
+def test_tree_layout_rewrites_map_references_and_indents_topics(tmp_path):
+    result = write_package_tree(_package(tmp_path / "pkg"), tmp_path / "out")
+
+    out = tmp_path / "out"
+    assert sorted(result.written) == ["maps/keys.ditamap", "maps/manual.ditamap", "media/fig.png",
+                                      "topics/install.dita"]
+    manual = (out / "maps" / "manual.ditamap").read_text(encoding="utf-8")
+    assert 'href="../topics/install.dita"' in manual and 'href="keys.ditamap"' in manual
+    assert "<!DOCTYPE map" in manual
+    topic = (out / "topics" / "install.dita").read_text(encoding="utf-8")
+    assert '\n  <title>Install</title>\n' in topic
+    assert '<p id="p1" outputclass="x">Unpack.</p>' in topic
+    assert (out / MARKER).exists()
+
+
+def test_tree_keeps_mixed_content_and_code_blocks_as_written(tmp_path):
+    text = 'Press <b>Start</b> <i>then</i> wait.</p><codeblock>a\n  <ph>b</ph>\n</codeblock><p>x'
+    write_package_tree(_package(tmp_path / "pkg", text=text), tmp_path / "out")
+
+    topic = (tmp_path / "out" / "topics" / "install.dita").read_text(encoding="utf-8")
+    assert "<p id=\"p1\" outputclass=\"x\">Press <b>Start</b> <i>then</i> wait.</p>" in topic
+    assert "<codeblock>a\n  <ph>b</ph>\n</codeblock>" in topic
+    assert '\n  <title>Install</title>\n' in topic
+
+
+def test_tree_update_keeps_unchanged_files_and_prunes_orphans(tmp_path):
+    out = tmp_path / "out"
+    write_package_tree(_package(tmp_path / "v1"), out)
+    (out / ".git").mkdir()
+    result = write_package_tree(_package(tmp_path / "v2", name="setup.dita"), out)
+
+    assert "media/fig.png" in result.unchanged
+    assert result.removed == ["topics/install.dita"]
+    assert (out / "topics" / "setup.dita").exists() and (out / ".git").is_dir()
+
+
+def test_tree_refuses_foreign_folder_unless_forced(tmp_path):
+    out = tmp_path / "out"
+    out.mkdir()
+    (out / "notes.txt").write_text("keep", encoding="utf-8")
+    with pytest.raises(PackageTreeError):
+        write_package_tree(_package(tmp_path / "pkg"), out)
+
+    write_package_tree(tmp_path / "pkg", out, force=True)
+    assert (out / "notes.txt").exists() and (out / "topics" / "install.dita").exists()
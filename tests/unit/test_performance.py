@@ -0,0 +1,48 @@
+import json
+import time
+
+from orlando_toolkit.cli import EXIT_OK, main
+from orlando_toolkit.core.models import ConversionReport
+from orlando_toolkit.core.performance import PerformanceRecorder, current_recorder, phase
+
+
+def test_nested_phases_count_their_own_time_and_nothing_is_recorded_without_a_recorder():
+    with phase("parse"):
+        assert current_recorder() is None
+    recorder = PerformanceRecorder(memory=False)
+    with recorder.active():
+        with phase("packaging"):
+            time.sleep(0.02)
+            with phase("images"):
+                time.sleep(0.05)
+        with phase("images"):
+            pass
+    assert current_recorder() is None
+    assert recorder.calls == {"packaging": 1, "images": 2}
+    assert recorder.seconds["images"] >= 0.05
+    assert 0.02 <= recorder.seconds["packaging"] < 0.05
+    assert sum(recorder.seconds.values()) <= recorder.total_seconds
+
+    report = ConversionReport()
+    report.record_timing("typography", 0.5)
+    data = recorder.to_dict(report, source="/tmp/manual.docx", topics=3)
+    assert list(data["phases"]) == ["images", "packaging"]
+    assert data["source"] == "manual.docx" and data["stages"] == {"typography": 0.5}
+    assert data["peak_memory_bytes"] is None
+    assert "slowest stages: typography 0.50 s" in recorder.summary(report)
+
+
+def test_convert_perf_prints_phases_and_writes_json(tmp_path, capsys):
+    source = tmp_path / "guide.md"
+    source.write_text("# Guide\n\n## Start\n\nText.\n\n## Next\n\nMore text.\n", encoding="utf-8")
+    target = tmp_path / "guide.zip"
+    assert main(["convert", str(source), "--no-plugins", "--out", str(target), "--perf", "--perf-json"]) == EXIT_OK
+    out = capsys.readouterr().out
+    assert "Performance:" in out and "packaging" in out
+
+    data = json.loads((tmp_path / "guide.perf.json").read_text(encoding="utf-8"))
+    assert data["format"] == "orlando-performance" and data["source"] == "guide.md"
+    assert {"parse", "analysis", "topics", "packaging"} <= set(data["phases"])
+    assert all(p["peak_memory_bytes"] > 0 for p in data["phases"].values())
+    assert data["peak_memory_bytes"] >= max(p["peak_memory_bytes"] for p in data["phases"].values())
+    assert data["stages"] and data["topics"] >= 2
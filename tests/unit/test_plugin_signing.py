@@ -0,0 +1,112 @@
+import base64
+import sys
+from types import SimpleNamespace
+
+import pytest
+
+pytest.importorskip("cryptography")
+
+from orlando_toolkit.core.plugins import signing
+from orlando_toolkit.core.plugins.exceptions import PluginLoadError
+from orlando_toolkit.core.plugins.loader import PluginLoader
+from orlando_toolkit.core.plugins.signing import (
+    SignaturePolicy,
+    public_key_for,
+    sign_plugin,
+    verify_plugin,
+)
+
+_SEED = bytes(range(32))
+
+
+def _plugin(tmp_path):
+    plugin_dir = tmp_path / "acme-plugin"
+    (plugin_dir / "services").mkdir(parents=True)
+    (plugin_dir / "plugin.json").write_text('{"name": "acme-plugin"}', encoding="utf-8")
+    (plugin_dir / "services" / "handler.py").write_text("X = 1\n", encoding="utf-8")
+    return plugin_dir
+
+
+def _policy(mode="refuse", **extra):
+    key = base64.b64encode(public_key_for(_SEED)).decode()
+    return SignaturePolicy.from_mapping({"mode": mode, "trusted_keys": {"acme": key}, **extra})
+
+
+def test_signed_plugin_verifies_and_tampering_is_detected(tmp_path):
+    plugin_dir = _plugin(tmp_path)
+    sign_plugin(plugin_dir, _SEED, "acme")
+    assert verify_plugin(plugin_dir, _policy()).status == "valid"
+
+    (plugin_dir / "services" / "handler.py").write_text("X = 2\n", encoding="utf-8")
+    assert verify_plugin(plugin_dir, _policy()).status == "invalid"
+
+
+def test_bytecode_next_to_the_signed_source_is_refused(tmp_path):
+    plugin_dir = _plugin(tmp_path)
+    sign_plugin(plugin_dir, _SEED, "acme")
+    (plugin_dir / "services" / "__pycache__").mkdir()
+    (plugin_dir / "services" / "__pycache__" / "handler.cpython-311.pyc").write_bytes(b"\x00")
+    result = verify_plugin(plugin_dir, _policy())
+    assert result.status == "invalid" and "services/__pycache__" in result.message
+
+    (plugin_dir / "services" / "__pycache__" / "handler.cpython-311.pyc").unlink()
+    (plugin_dir / "services" / "__pycache__").rmdir()
+    (plugin_dir / "handler.pyc").write_bytes(b"\x00")
+    assert verify_plugin(plugin_dir, _policy()).status == "invalid"
+
+
+def test_nothing_verifies_without_cryptography(tmp_path, monkeypatch):
+    plugin_dir = _plugin(tmp_path)
+    sign_plugin(plugin_dir, _SEED, "acme")
+    monkeypatch.setattr(signing, "_ed25519", lambda: None)
+    assert verify_plugin(plugin_dir, _policy()).status == "unavailable"
+
+
+def test_unsigned_and_untrusted_plugins(tmp_path):
+    plugin_dir = _plugin(tmp_path)
+    assert verify_plugin(plugin_dir, _policy()).status == "unsigned"
+    sign_plugin(plugin_dir, bytes(32), "someone-else")
+    assert verify_plugin(plugin_dir, _policy()).status == "untrusted"
+    assert SignaturePolicy.from_mapping({}).mode == "off"
+    assert SignaturePolicy.from_mapping({"mode": "strict"}).mode == "refuse"
+
+
+def test_loader_refuses_or_warns_per_policy(tmp_path):
+    plugin_dir = _plugin(tmp_path)
+    loader = PluginLoader.__new__(PluginLoader)
+    loader._logger = SimpleNamespace(info=lambda *a: None, warning=lambda *a: None)
+    info = SimpleNamespace(plugin_dir=plugin_dir, plugin_id="acme-plugin", signature=None)
+
+    loader._signature_policy = _policy("refuse")
+    with pytest.raises(PluginLoadError, match="unsigned"):
+        loader._check_signature(info)
+
+    loader._signature_policy = _policy("warn")
+    loader._check_signature(info)
+    assert info.signature.status == "unsigned"
+
+
+def test_verified_plugins_are_imported_without_writing_bytecode(tmp_path, monkeypatch):
+    plugin_dir = _plugin(tmp_path)
+    package = plugin_dir / "acme_signed"
+    package.mkdir()
+    (package / "__init__.py").write_text("", encoding="utf-8")
+    (package / "entry.py").write_text("class Plugin:\n    pass\n\n\ndef later():\n"
+                                      "    from acme_signed import helper\n    return helper.VALUE\n", encoding="utf-8")
+    (package / "helper.py").write_text("VALUE = 42\n", encoding="utf-8")
+    sign_plugin(plugin_dir, _SEED, "acme")
+    monkeypatch.setattr(sys, "dont_write_bytecode", False)
+    monkeypatch.setattr(sys, "path_hooks", list(sys.path_hooks))
+    for name in ("acme_signed", "acme_signed.entry", "acme_signed.helper"):
+        monkeypatch.delitem(sys.modules, name, raising=False)
+    loader = PluginLoader.__new__(PluginLoader)
+    loader._logger = SimpleNamespace(info=lambda *a: None, warning=lambda *a: None, debug=lambda *a: None)
+    info = SimpleNamespace(plugin_dir=plugin_dir, plugin_id="acme-plugin", signature=None,
+                           metadata=SimpleNamespace(entry_point="acme_signed.entry.Plugin"))
+    loader._signature_policy = _policy("refuse")
+    loader._check_signature(info)
+    module = loader._import_plugin_module(info)
+    assert module.later() == 42  # a submodule imported after loading
+    assert not sys.dont_write_bytecode  # the rest of the process still caches bytecode
+    assert not list(plugin_dir.rglob("__pycache__"))
+    assert verify_plugin(plugin_dir, _policy()).status == "valid"
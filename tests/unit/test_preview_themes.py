@@ -0,0 +1,38 @@
+from orlando_toolkit.core.preview.themes import available_themes, get_theme, preview_scale, theme_name, \
+    themed_document
+
+CONFIG = {
+    "preview": {"theme": "dark", "scale": "auto"},
+    "themes": {
+        "light": {"css": "body { color: #222; }"},
+        "dark": {"label": "Night", "dark": True, "css": "body { background: #1e1f22; }"},
+        "corporate": {"extends": "light", "css_file": "corporate.css"},
+        "loop": {"extends": "loop"},
+    },
+}
+
+
+def test_themes_extend_and_read_user_stylesheets(tmp_path, monkeypatch):
+    (tmp_path / "corporate.css").write_text("h2 { color: #003366; }", encoding="utf-8")
+    monkeypatch.setattr("orlando_toolkit.config.manager._get_user_config_dir", lambda: tmp_path)
+
+    themes = available_themes(CONFIG)
+    assert themes["corporate"].css == "body { color: #222; }\nh2 { color: #003366; }"
+    assert themes["dark"].label == "Night" and themes["dark"].dark and not themes["corporate"].dark
+    assert themes["loop"].css == ""
+    assert get_theme("missing", CONFIG).name == "light"
+
+    # A profile's metadata wins over the configured theme
+    assert theme_name({}, CONFIG) == "dark" and theme_name({"preview_theme": "corporate"}, CONFIG) == "corporate"
+    assert theme_name(None, {}) == "light"
+
+    page = themed_document('<div class="topic"><h2>Pump</h2></div>', themes["dark"])
+    assert page.startswith("<html><head>") and "background: #1e1f22" in page
+    assert '<body class="theme-dark dark"><div class="topic">' in page
+
+
+def test_scale_follows_screen_density_unless_fixed():
+    assert preview_scale(192, CONFIG) == 2.0 and preview_scale(96, CONFIG) == 1.0
+    assert preview_scale(72, CONFIG) == 1.0 and preview_scale(None, CONFIG) == 1.0
+    assert preview_scale(192, {"preview": {"scale": 1.25}}) == 1.25
+    assert preview_scale(144, {"preview": {"scale": "large"}}) == 1.5
@@ -0,0 +1,73 @@
+import json
+
+import pytest
+
+from orlando_toolkit.cli import EXIT_OK, EXIT_USAGE, main
+from orlando_toolkit.config import ConfigManager
+from orlando_toolkit.core import profiles
+from orlando_toolkit.core.profiles import (
+    available_profiles,
+    export_profile,
+    import_profile,
+    profile_from_metadata,
+    save_profile,
+)
+from orlando_toolkit.options import build_options, get_output_profile, with_output_profile, with_title
+
+
+def test_settings_saved_from_a_conversion_apply_by_name(tmp_path, monkeypatch):
+    monkeypatch.setattr(profiles, "profiles_dir", lambda: tmp_path / "profiles")
+    monkeypatch.setattr(ConfigManager, "get_profiles_config", lambda self: {"stable": {"description": "Stable"}})
+    metadata = {"manual_title": "Operator Manual", "revision_date": "2024-05-01", "manual_code": "OM-12",
+                "topic_depth": 2, "language": "fr-FR", "image_naming": {"pattern": "{code}-{n}"},
+                "style_map": {"Titre 1": 1}, "conversion_options": {"ids": {"stable": True}}}
+
+    data = profile_from_metadata(metadata, description="Field manuals")
+    assert set(data["metadata"]) == {"topic_depth", "language", "image_naming"}
+    path = save_profile("field", data)
+    assert path.parent == tmp_path / "profiles" and sorted(available_profiles()) == ["field", "stable"]
+    with pytest.raises(FileExistsError):
+        save_profile("field", data, overwrite=False)
+
+    applied = build_options(with_output_profile("field"), with_title("Quick Guide")).to_metadata()
+    assert applied["topic_depth"] == 2 and applied["style_map"] == {"Titre 1": 1}
+    assert applied["manual_title"] == "Quick Guide" and "manual_code" not in applied
+    assert applied["output_profiles"] == ["field"]
+    assert get_output_profile(str(path)).description == "Field manuals"
+    with pytest.raises(ValueError, match="unknown key"):
+        save_profile("odd", {"depth": 3})
+    with pytest.raises(ValueError, match="Invalid profile name"):
+        save_profile("../escape", data)
+
+
+def test_exported_profiles_are_self_contained_and_import_elsewhere(tmp_path, monkeypatch, capsys):
+    styles = tmp_path / "corporate.yml"
+    styles.write_text('styles:\n  "Corp Chapter": 1\n', encoding="utf-8")
+    monkeypatch.setattr(profiles, "profiles_dir", lambda: tmp_path / "mine")
+    monkeypatch.setattr(ConfigManager, "get_profiles_config", lambda self: {
+        "base": {"metadata": {"topic_depth": 3}, "style_map_file": str(styles)},
+        "corp": {"extends": "base", "description": "Corporate", "style_map": {"Corp Note": "note"},
+                 "templates": {"names": ["Corp.dotx"]}}})
+
+    shared = export_profile("corp", tmp_path / "corp.json")
+    data = json.loads(shared.read_text(encoding="utf-8"))
+    assert "extends" not in data and "style_map_file" not in data
+    assert data["metadata"] == {"topic_depth": 3}
+    assert data["style_map"] == {"Corp Chapter": 1, "Corp Note": "note"}
+    assert data["templates"] == {"names": ["Corp.dotx"]}
+
+    # A colleague's machine: no profiles.yml entries
+    monkeypatch.setattr(profiles, "profiles_dir", lambda: tmp_path / "theirs")
+    monkeypatch.setattr(ConfigManager, "get_profiles_config", lambda self: {})
+    assert import_profile(shared) == "corp"
+    with pytest.raises(FileExistsError):
+        import_profile(shared)
+    assert build_options(with_output_profile("corp")).style_map["Corp Chapter"] == 1
+    assert main(["profile", "import", str(shared), "--name", "corp-fr"]) == EXIT_OK
+    assert main(["profile", "list"]) == EXIT_OK
+    assert "corp-fr" in capsys.readouterr().out
+    broken = tmp_path / "broken.yml"
+    broken.write_text("extends: missing\n", encoding="utf-8")
+    assert main(["profile", "import", str(broken)]) == EXIT_USAGE
+    assert main(["profile", "delete", "corp-fr"]) == EXIT_OK
+    assert sorted(available_profiles()) == ["corp"]
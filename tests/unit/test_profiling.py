@@ -0,0 +1,65 @@
+import pytest
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.processing import run_processing_stages
+from orlando_toolkit.core.processing.conditional import ConditionalContentStage
+from orlando_toolkit.core.profiling import (
+    format_selection,
+    parse_selection,
+    profiling_configurations,
+    profiling_values,
+    write_profile_ditavals,
+)
+
+
+def _props(path):
+    return [(p.get("att"), p.get("val"), p.get("action")) for p in ET.parse(str(path)).getroot().iter("prop")]
+
+
+def test_styles_and_highlights_profile_content_and_configurations_get_ditavals(tmp_path):
+    topic = ET.fromstring(
+        "<concept id='t'><conbody><p data-style='Pro Only'>Turbo mode</p>"
+        "<p>Reset <ph data-style='Service Note' data-highlight='yellow'>with the key</ph></p>"
+        "<p data-style='Body'>All</p></conbody></concept>")
+    ctx = DitaContext(ditamap_root=ET.fromstring("<map><topicref href='topics/t.dita' platform='linux'/></map>"),
+                      topics={"t.dita": topic})
+    ctx.metadata["conversion_options"] = {
+        "conditional": {"styles": {"Pro Only": {"attribute": "product", "value": "pro"},
+                                   "Service Note": {"attribute": "audience", "value": "service"}},
+                        "highlights": {"yellow": {"attribute": "product", "value": "basic pro"}}},
+        "profiling": {"configurations": {"basic": {"product": ["basic"]}, "draft": {"product": ["pro"]}}},
+    }
+    run_processing_stages(ctx, stages=[ConditionalContentStage()])
+    paras = topic.findall(".//p")
+    assert paras[0].get("product") == "pro" and paras[0].get("data-style") == "Pro Only"
+    assert dict(paras[1].find("ph").attrib) == {"data-style": "Service Note", "product": "basic pro",
+                                                "audience": "service"}
+    assert paras[2].get("product") is None
+    assert profiling_values(ctx) == {"audience": ["service"], "platform": ["linux"], "product": ["basic", "pro"]}
+
+    # The Metadata tab edits: one configuration added, one from the configuration file removed
+    ctx.metadata["profiling"] = {"configurations": {"service": {"product": "basic pro", "audience": ["service"]},
+                                                    "draft": None}}
+    paths = write_profile_ditavals(ctx, tmp_path)
+    assert [p.name for p in paths] == ["basic.ditaval", "service.ditaval"]
+    assert _props(paths[0]) == [("product", "basic", "include"), ("product", "pro", "exclude")]
+    assert _props(paths[1]) == [("product", "basic", "include"), ("product", "pro", "include"),
+                                ("audience", "service", "include")]
+
+
+def test_selection_text_round_trips_and_rejects_unknown_attributes():
+    assert parse_selection("product=basic, pro; audience=user\nproduct=lite") == {
+        "product": ["basic", "pro", "lite"], "audience": ["user"]}
+    assert format_selection({"product": ["basic", "pro"], "audience": ["user"]}) == "product=basic,pro; audience=user"
+    assert parse_selection(" ") == {}
+    with pytest.raises(ValueError):
+        parse_selection("edition=gold")
+    with pytest.raises(ValueError):
+        parse_selection("product")
+
+    configurations = profiling_configurations({"profiling": {"configurations": {
+        "Field Service / EU": {"product": ["pro"], "colour": ["red"]}}}})
+    assert [(c.name, c.file_name, c.to_mapping()) for c in configurations] == [
+        ("Field Service / EU", "Field_Service_EU.ditaval", {"product": ["pro"]})]
+    assert write_profile_ditavals(DitaContext(), "/nonexistent") == []
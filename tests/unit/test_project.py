@@ -0,0 +1,68 @@
+import json
+import zipfile
+
+import pytest
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.models.edit_journal import EditJournal
+from orlando_toolkit.core.project import ProjectError, load_project, save_project
+
+
+def _context(source):
+    ctx = DitaContext(
+        ditamap_root=ET.fromstring('<map><title>Guide</title><topicref href="topics/a.dita"/></map>'),
+        topics={"a.dita": ET.fromstring('<concept id="a"><title>A</title><conbody><p data-dir="rtl">x</p></conbody></concept>')},
+        images={"fig 1.png": b"\x89PNG-bytes"},
+        metadata={"manual_title": "Guide", "topic_depth": 2, "source_file": str(source),
+                  "conversion_options": {"ids": {"stable": True}}, "output_profiles": ["stable"],
+                  "not_json": object()},
+    )
+    ctx.save_original_structure()
+    ctx.report.warning("structure", "Heading inside table", topic="a.dita", code="OTK401")
+    return ctx
+
+
+def test_project_round_trips_the_working_session(tmp_path):
+    source = tmp_path / "guide.docx"
+    source.write_bytes(b"source v1")
+    ctx = _context(source)
+    journal = EditJournal()
+    journal.record_edit("rename", {"topic_ref": "topics/a.dita", "new_title": "Intro"})
+
+    path = save_project(ctx, tmp_path / "work" / "guide", journal=journal)
+    assert path.name == "guide.otkproj"
+    project = load_project(path)
+
+    restored = project.context
+    assert ET.tostring(restored.topics["a.dita"]) == ET.tostring(ctx.topics["a.dita"])
+    assert restored.images == {"fig 1.png": b"\x89PNG-bytes"}
+    assert restored.ditamap_root.findtext("title") == "Guide"
+    assert restored.metadata["conversion_options"] == {"ids": {"stable": True}}
+    assert "not_json" not in restored.metadata
+    assert project.profiles == ["stable"]
+    assert restored.report.entries[0].code == "OTK401"
+    assert list(restored.metadata["original_structure"]["topics"]) == ["a.dita"]
+    assert project.journal.serialize()[0]["details"]["new_title"] == "Intro"
+
+    assert project.source_status == "unchanged"
+    source.write_bytes(b"source v2")
+    assert load_project(path).source_status == "modified"
+    source.unlink()
+    assert load_project(path).source_status == "missing"
+
+
+def test_foreign_and_newer_files_are_refused(tmp_path):
+    other = tmp_path / "package.otkproj"
+    with zipfile.ZipFile(other, "w") as z:
+        z.writestr("DATA/guide.ditamap", "<map/>")
+    with pytest.raises(ProjectError, match="not an Orlando Toolkit project") as info:
+        load_project(other)
+    assert info.value.code == "OTK103"
+
+    newer = tmp_path / "newer.otkproj"
+    with zipfile.ZipFile(newer, "w") as z:
+        z.writestr("project.json", json.dumps({"format": "orlando-project", "version": 99}))
+    with pytest.raises(ProjectError, match="newer version") as info:
+        load_project(newer)
+    assert "Update" in info.value.hint
@@ -0,0 +1,66 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.prolog_metadata import PrologMetadata, apply_prolog_metadata, entry_metadata
+from orlando_toolkit.core.services.structure_editing_service import StructureEditingService
+
+
+def _context(metadata=None):
+    root = ET.fromstring("<map><title>Pump</title>"
+                         "<topicref href='topics/intro.dita'><topicmeta><navtitle>Intro</navtitle></topicmeta>"
+                         "</topicref>"
+                         "<topichead><topicmeta><navtitle>Service</navtitle></topicmeta>"
+                         "<topicref href='topics/repair.dita'/></topichead></map>")
+    context = DitaContext(ditamap_root=root, metadata=dict(metadata or {}))
+    for name in ("intro", "repair"):
+        context.topics[f"{name}.dita"] = ET.fromstring(
+            f"<concept id='{name}'><title>{name}</title><prolog><metadata>"
+            f"<othermeta name='docNumber' content='T-1'/></metadata></prolog><conbody/></concept>")
+    return context
+
+
+def test_values_are_checked_before_they_are_stored():
+    values = PrologMetadata(created="2024-02-30", revised="2023-01-01", permissions="public", version="2",
+                            othermeta=[("plant", "Lyon"), ("plant", "Nantes"), ("", "x")])
+    assert values.errors() == [
+        "Created must be a date such as 2024-05-31", "Permissions must be one of internal, classified, all, entitled",
+        "Enter the product the version belongs to", "Metadata row 'plant' is entered twice",
+        "Every metadata row needs a name"]
+    assert PrologMetadata(created="2024-05", revised="2024-04-30").errors() == ["Revised cannot be before Created"]
+    assert PrologMetadata(created="2024", revised="2024-06-01", permissions="internal").errors() == []
+
+
+def test_manual_and_branch_metadata_reach_the_map_and_topic_prologs():
+    context = _context({"prolog": {"author": "Tech Pubs", "created": "2024-01-15", "audience": "user",
+                                   "product": "Pump", "version": "3.1",
+                                   "othermeta": [{"name": "docNumber", "content": "DOC-9"}]}})
+    result = StructureEditingService().set_branch_metadata(
+        context, [], [[1]], {"audience": "field technician", "permissions": "internal",
+                             "othermeta": [["plant", "Lyon"]]})
+    assert result.success, result.message
+    assert entry_metadata(context.ditamap_root[2]).to_mapping() == {
+        "audience": "field technician", "permissions": "internal", "othermeta": [{"name": "plant", "content": "Lyon"}]}
+
+    assert apply_prolog_metadata(context) == 2
+    topicmeta = context.ditamap_root.find("topicmeta")
+    assert context.ditamap_root.index(topicmeta) == 1
+    assert PrologMetadata.from_element(topicmeta).author == "Tech Pubs"
+    intro = context.topics["intro.dita"].find("prolog")
+    assert [c.tag for c in intro] == ["author", "critdates", "metadata"]
+    assert intro.find("critdates/created").get("date") == "2024-01-15"
+    assert [(m.get("name"), m.get("content")) for m in intro.iter("othermeta")] == [("docNumber", "DOC-9")]
+    assert intro.find("metadata/prodinfo/vrmlist/vrm").get("version") == "3.1"
+    repair = context.topics["repair.dita"].find("prolog")
+    assert repair.find("permissions").get("view") == "internal"
+    assert [(a.get("type"), a.get("othertype")) for a in repair.iter("audience")] == [("other", "field technician")]
+    assert [m.get("name") for m in repair.iter("othermeta")] == ["docNumber", "plant"]
+
+
+def test_invalid_branch_metadata_is_refused_and_clearing_removes_it():
+    context = _context()
+    service = StructureEditingService()
+    result = service.set_branch_metadata(context, [], [[1]], {"created": "soon"})
+    assert not result.success and result.details["reason"] == "invalid"
+    assert service.set_branch_metadata(context, [], [[1]], {"author": "Ops"}).success
+    assert service.set_branch_metadata(context, [], [[1]], {}).success
+    assert [c.tag for c in context.ditamap_root[2].find("topicmeta")] == ["navtitle"]
@@ -0,0 +1,78 @@
+import os
+import sys
+import zipfile
+
+import pytest
+
+from orlando_toolkit.core.external_tools import ToolExecutionError, ToolPolicy
+from orlando_toolkit.core.services.publishing_service import PublishingError, PublishingService, PublishingSettings
+
+pytestmark = pytest.mark.skipif(os.name != "posix", reason="fake DITA-OT is a POSIX script")
+
+_FAKE_DITA = """#!{python}
+import pathlib, sys
+args = dict(a[2:].split("=", 1) for a in sys.argv[1:])
+if args["format"] == "broken":
+    print("Error: transtype not supported", file=sys.stderr)
+    sys.exit(2)
+print("[pipeline] reading", args["input"])
+out = pathlib.Path(args["output"])
+out.mkdir(parents=True)
+(out / "index.txt").write_text(args["format"] + " " + args.get("nav-toc", "-"))
+print("BUILD SUCCESSFUL")
+"""
+
+
+def _dita_ot(folder):
+    script = folder / "bin" / "dita"
+    script.parent.mkdir(parents=True)
+    script.write_text(_FAKE_DITA.format(python=sys.executable), encoding="utf-8")
+    script.chmod(0o755)
+    return folder
+
+
+def _archive(path):
+    with zipfile.ZipFile(path, "w") as zf:
+        zf.writestr("DATA/OM-12.ditamap", "<map/>")
+        zf.writestr("DATA/topics/t.dita", "<topic id='t'/>")
+    return path
+
+
+def test_archive_is_published_next_to_itself_with_a_streamed_log(tmp_path, monkeypatch):
+    monkeypatch.delenv("DITA_HOME", raising=False)
+    install = tmp_path / "installs"
+    home = _dita_ot(install / "dita-ot-4.2.3")
+    publisher = PublishingService(PublishingSettings(install_dir=str(install), parameters={"nav-toc": "full"}),
+                                  ToolPolicy())
+    assert publisher.locate() == home
+
+    archive = _archive(tmp_path / "OM-12.zip")
+    (tmp_path / "OM-12_html5").mkdir()
+    (tmp_path / "OM-12_html5" / "stale.html").write_text("old")
+    lines = []
+    outputs = publisher.publish(archive, on_output=lines.append)
+    assert outputs == {"pdf2": tmp_path / "OM-12_pdf2", "html5": tmp_path / "OM-12_html5"}
+    assert (tmp_path / "OM-12_pdf2" / "index.txt").read_text() == "pdf2 full"
+    assert not (tmp_path / "OM-12_html5" / "stale.html").exists()
+    assert lines[:3] == ["Publishing pdf2…", "[pipeline] reading package/DATA/OM-12.ditamap", "BUILD SUCCESSFUL"]
+
+    with pytest.raises(ToolExecutionError, match="transtype not supported"):
+        publisher.publish(archive, ["broken"])
+
+
+def test_missing_or_refused_dita_ot_is_reported(tmp_path, monkeypatch):
+    monkeypatch.delenv("DITA_HOME", raising=False)
+    archive = _archive(tmp_path / "OM-12.zip")
+    publisher = PublishingService(PublishingSettings(install_dir=str(tmp_path / "none")), ToolPolicy())
+    assert publisher.locate() is None
+    with pytest.raises(PublishingError) as info:
+        publisher.publish(archive)
+    assert info.value.code == "OTK503" and "dita_ot_home" in info.value.hint
+
+    # DITA_HOME is honoured, but a closed tool policy still needs `dita` listed
+    monkeypatch.setenv("DITA_HOME", str(_dita_ot(tmp_path / "dita-ot")))
+    closed = PublishingService(PublishingSettings(install_dir=str(tmp_path / "none")),
+                               ToolPolicy(allow_unlisted=False))
+    assert closed.locate() == tmp_path / "dita-ot"
+    with pytest.raises(ToolExecutionError, match="not allowed"):
+        closed.publish(archive, ["html5"])
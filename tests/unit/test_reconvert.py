@@ -0,0 +1,110 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.reconvert import reconversion_metadata, reconvert, record_source_outline
+from orlando_toolkit.core.services.structure_editing_service import StructureEditingService
+from orlando_toolkit.core.services.undo_service import UndoService
+from orlando_toolkit.ui.controllers.structure_controller import StructureController
+
+
+def _conversion(chapters, **metadata):
+    """A converted context: *chapters* is a list of (file, title, text, children)."""
+    root = ET.Element("map")
+    topics = {}
+
+    def _add(parent, entries):
+        for name, title, text, children in entries:
+            topics[name] = ET.fromstring(f"<concept id='{name[:-5]}_{len(topics)}'><title>{title}</title>"
+                                         f"<conbody><p id='p{len(topics)}'>{text}</p></conbody></concept>")
+            ref = ET.SubElement(parent, "topicref", href=f"topics/{name}")
+            ET.SubElement(ET.SubElement(ref, "topicmeta"), "navtitle").text = title
+            _add(ref, children)
+
+    _add(root, chapters)
+    ctx = DitaContext(ditamap_root=root, topics=topics, metadata=dict(metadata))
+    record_source_outline(ctx)
+    return ctx
+
+
+def _outline(ctx):
+    def _walk(node):
+        return [((ref.get("href") or "").split("/")[-1], ref.findtext("topicmeta/navtitle"), _walk(ref))
+                for ref in node.findall("topicref")]
+    return _walk(ctx.ditamap_root)
+
+
+def _body(ctx, name):
+    return ctx.topics[name].findtext("conbody/p")
+
+
+def test_reconvert_updates_changed_topics_and_keeps_edits():
+    edited = _conversion([
+        ("t1.dita", "Intro", "Hello", []),
+        ("t2.dita", "Install", "Step one", [("t3.dita", "Tools", "Hammer", [])]),
+        ("t4.dita", "Old", "Obsolete", []),
+        ("t5.dita", "Use", "Run it", []),
+    ], topic_depth=3, manual_title="Pump")
+    # Editor: rename Install, move Use before Install
+    edited.topics["t2.dita"].find("title").text = "Installation"
+    edited.ditamap_root.find("topicref[2]/topicmeta/navtitle").text = "Installation"
+    use = edited.ditamap_root.find("topicref[4]")
+    edited.ditamap_root.insert(1, use)
+    edited.metadata["merged_depth"] = 3
+
+    fresh = _conversion([
+        ("topic_a.dita", "Intro", "Hello", []),
+        ("topic_b.dita", "Install", "Step one, then two", [("topic_c.dita", "Tools", "Hammer", []),
+                                                           ("topic_d.dita", "Safety", "Gloves", [])]),
+        ("topic_e.dita", "Use", "Run it", []),
+        ("topic_f.dita", "Maintenance", "Oil", []),
+    ])
+    result = reconvert(edited, fresh)
+
+    assert (result.updated, result.added, result.removed, result.unchanged) == (
+        ["t2.dita"], ["topic_d.dita", "topic_f.dita"], ["t4.dita"], 3)
+    # New topics follow the topic preceding them in the new document, wherever the editor moved it
+    assert _outline(edited) == [
+        ("t1.dita", "Intro", []),
+        ("t5.dita", "Use", []),
+        ("topic_f.dita", "Maintenance", []),
+        ("t2.dita", "Installation", [("t3.dita", "Tools", []), ("topic_d.dita", "Safety", [])]),
+    ]
+    assert _body(edited, "t2.dita") == "Step one, then two"
+    assert edited.topics["t2.dita"].findtext("title") == "Installation"
+    assert "t4.dita" not in edited.topics and _body(edited, "topic_d.dita") == "Gloves"
+    # Depth setting kept; merge flags reset so the depth applies to the new topics at packaging
+    assert edited.metadata["topic_depth"] == 3 and "merged_depth" not in edited.metadata
+    assert [e["file"] for e in edited.metadata["source_outline"]] == [
+        "t1.dita", "t2.dita", "t3.dita", "topic_d.dita", "t5.dita", "topic_f.dita"]
+
+    # A second update with the same document changes nothing
+    assert not reconvert(edited, fresh).changed
+    assert "source_outline" not in reconversion_metadata(edited)
+
+
+def test_reconvert_skips_merged_topics_and_ambiguous_headings_and_undoes():
+    edited = _conversion([
+        ("t1.dita", "Setup", "A", [("t2.dita", "Notes", "Same", []), ("t3.dita", "Notes", "Same", [])]),
+        ("t4.dita", "Detail", "Deep", []),
+    ])
+    # t4 was merged away in the editor
+    edited.ditamap_root.remove(edited.ditamap_root.find("topicref[2]"))
+    del edited.topics["t4.dita"]
+    fresh = _conversion([
+        ("n1.dita", "Setup", "A", [("n2.dita", "Notes", "Same", []), ("n3.dita", "Notes", "Same", []),
+                                   ("n5.dita", "Notes", "Same", [])]),
+        ("n4.dita", "Detail", "Deeper", []),
+    ])
+    ctrl = StructureController(edited, StructureEditingService(), UndoService(), None)
+    ctrl.start_history()
+    res = ctrl.handle_reconvert(fresh)
+    outcome = res.details["result"]
+    assert outcome.skipped == ["t4.dita", "t2.dita", "t3.dita"] and not outcome.changed
+    assert not res.success and set(ctrl.context.topics) == {"t1.dita", "t2.dita", "t3.dita"}
+
+    fresh.topics["n1.dita"].find("conbody/p").text = "B"
+    res = ctrl.handle_reconvert(fresh)
+    assert res.success and res.message == "Topics: 1 updated, 0 added, 0 removed, 0 unchanged, 3 skipped"
+    assert _body(ctrl.context, "t1.dita") == "B"
+    assert ctrl.undo_label() == "Update from source"
+    assert ctrl.undo() and _body(ctrl.context, "t1.dita") == "A"
@@ -0,0 +1,70 @@
+import hashlib
+import zipfile
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.reproducible import normalize_attributes, package_date, stabilize_ids
+from orlando_toolkit.core.services.conversion_service import ConversionService
+from orlando_toolkit.core.utils import generate_dita_id, topic_body
+
+PNG = b"\x89PNG" + bytes(range(256)) * 8
+
+
+def _package(source, target, options):
+    service = ConversionService()
+    metadata = {"manual_title": "Manual", "conversion_options": {"reproducible": options}}
+    context = service.convert(source, metadata)
+    # What topic merging leaves behind: a random id and a link to it
+    first = next(iter(context.topics.values()))
+    generated = generate_dita_id()
+    ET.SubElement(topic_body(first), "p", id=generated).text = "Merged paragraph"
+    ET.SubElement(topic_body(first), "p").append(ET.Element("xref", href=f"#{first.get('id')}/{generated}"))
+    service.write_package(service.prepare_package(context), target)
+    return target.read_bytes()
+
+
+def test_same_source_gives_the_same_archive(tmp_path):
+    (tmp_path / "img").mkdir()
+    (tmp_path / "img" / "wiring.png").write_bytes(PNG)
+    source = tmp_path / "manual.md"
+    source.write_text("# Manual\n\n## Wiring\n\n![Wiring](img/wiring.png)\n\n## Panel\n\nText.\n", encoding="utf-8")
+    options = {"enabled": True, "date": "2001-02-03"}
+    first = _package(source, tmp_path / "a.zip", options)
+    assert first == _package(source, tmp_path / "b.zip", options)
+    assert _package(source, tmp_path / "c.zip", {"enabled": False}) != first
+
+    with zipfile.ZipFile(tmp_path / "a.zip") as zf:
+        names = zf.namelist()
+        ditamap = ET.fromstring(zf.read(next(n for n in names if n.endswith(".ditamap"))))
+    digest = hashlib.sha1(PNG).hexdigest()[:8]
+    assert any(n.startswith("DATA/media/") and digest in n for n in names)
+    assert ditamap.find(".//critdates/created").get("date") == "2001-02-03"
+
+
+def test_generated_ids_become_content_hashes_and_references_follow(monkeypatch):
+    generated = generate_dita_id()
+    topic = ET.fromstring(f"<concept id='t'><title>T</title><conbody><p id='{generated}'>Same text</p>"
+                          f"<p id='keep'><xref href='#t/{generated}'/></p></conbody></concept>")
+    other = ET.fromstring(f"<concept id='o'><title>O</title><conbody><p><xref href='t.dita#t/{generated}'/>"
+                          "</p></conbody></concept>")
+    ctx = DitaContext(ditamap_root=ET.fromstring("<map/>"), topics={"t.dita": topic, "o.dita": other})
+    assert stabilize_ids(ctx) == 1
+    new = topic.find("conbody/p").get("id")
+    assert new.startswith("id-") and new != generated and topic.find(".//p[@id='keep']") is not None
+    assert topic.find(".//xref").get("href") == f"#t/{new}"
+    assert other.find(".//xref").get("href") == f"t.dita#t/{new}"
+
+    again = ET.fromstring(f"<concept id='t'><title>T</title><conbody><p id='{generate_dita_id()}'>Same text</p>"
+                          "</conbody></concept>")
+    stabilize_ids(DitaContext(ditamap_root=None, topics={"t.dita": again}))
+    assert again.find("conbody/p").get("id") == new
+
+    el = ET.fromstring("<p outputclass='x' id='a' audience='b'/>")
+    normalize_attributes(el)
+    assert list(el.attrib) == ["audience", "id", "outputclass"]
+
+    monkeypatch.setenv("SOURCE_DATE_EPOCH", "1700000000")
+    assert package_date({}) == "2023-11-14" and package_date({"date": "2020-05-06"}) == "2020-05-06"
+    monkeypatch.delenv("SOURCE_DATE_EPOCH")
+    assert package_date({"date": "soon"}) == "1980-01-01"
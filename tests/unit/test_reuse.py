@@ -0,0 +1,109 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.reuse import apply_notice_warehouse, apply_reuse, consolidate_notices, find_notice_groups, \
+    find_reuse_candidates
+
+_WARNING = ("<note type='warning' id='{n}'>"
+            "<p data-style='Warn'>Disconnect the  pump from the mains before opening it.</p></note>")
+_STEPS = ("<steps><step><cmd>Remove the four screws of the front cover.</cmd></step>"
+          "<step><cmd>Lift the cover and put it aside on a clean surface.</cmd></step></steps>")
+
+
+def _context():
+    topics = {}
+    for n in range(1, 4):
+        topics[f"c{n}.dita"] = ET.fromstring(
+            f"<concept id='c{n}'><title>C{n}</title><conbody>{_WARNING.format(n=f'w{n}')}"
+            f"<p>Topic {n} has its own text with enough words in it.</p></conbody></concept>")
+        topics[f"t{n}.dita"] = ET.fromstring(
+            f"<task id='t{n}'><title>T{n}</title><taskbody>{_STEPS}</taskbody></task>")
+    root = ET.fromstring("<map>" + "".join(f"<topicref href='topics/{name}'/>" for name in topics) + "</map>")
+    return DitaContext(ditamap_root=root, topics=topics, metadata={})
+
+
+def test_repeated_blocks_are_proposed_once_outermost_first():
+    ctx = _context()
+    candidates = find_reuse_candidates(ctx)
+    assert sorted(c.kind for c in candidates) == ["note", "steps"]
+    note = next(c for c in candidates if c.kind == "note")
+    assert note.count == 3 and note.topics == ["c1.dita", "c2.dita", "c3.dita"]
+    assert note.text == "Disconnect the pump from the mains before opening it."
+
+    assert find_reuse_candidates(ctx, {"min_occurrences": 4}) == []
+    # A block holding a link target stays where it is; the target itself can still be shared
+    ctx.topics["c1.dita"].find("conbody/note/p").set("id", "disc")
+    ET.SubElement(ctx.topics["c2.dita"].find("conbody"), "xref", href="c1.dita#c1/disc")
+    assert sorted(c.kind for c in find_reuse_candidates(ctx)) == ["p", "steps"]
+
+
+def test_apply_reuse_moves_blocks_to_warehouse_topics():
+    ctx = _context()
+    assert apply_reuse(ctx, find_reuse_candidates(ctx)) == 6
+    warehouse = ctx.topics["reuse.dita"].find("conbody/note")
+    assert warehouse.get("id") == "reuse_1" and warehouse.find("p").get("data-style") is None
+    note = ctx.topics["c2.dita"].find("conbody/note")
+    assert note.get("conref") == "reuse.dita#reuse/reuse_1" and note.get("id") == "w2" and len(note) == 0
+
+    steps = ctx.topics["reuse_steps.dita"].findall("taskbody/steps/step")
+    assert [s.get("id") for s in steps] == ["reuse_1", "reuse_2"]
+    step = ctx.topics["t3.dita"].find("taskbody/steps/step")
+    assert step.get("conref") == "reuse_steps.dita#reuse_steps/reuse_1"
+    assert step.get("conrefend") == "reuse_steps.dita#reuse_steps/reuse_2"
+
+    refs = [t for t in ctx.ditamap_root if t.get("processing-role") == "resource-only"]
+    assert sorted(t.get("href") for t in refs) == ["topics/reuse.dita", "topics/reuse_steps.dita"]
+    # Copies already replaced are not proposed again
+    assert find_reuse_candidates(ctx) == []
+
+
+def _notices():
+    bodies = [
+        "<note type='warning' id='keep'><p>Wear safety glasses when you open the pump.</p></note>"
+        "<note type='tip'><p>Use the short screwdriver.</p></note>",
+        "<note type='warning'><p>Wear safety glasses when you open the pump.</p></note>"
+        "<hazardstatement type='danger'><messagepanel><typeofhazard>High voltage.</typeofhazard>"
+        "</messagepanel></hazardstatement>",
+        "<note type='warning'><p>Always wear safety glasses when you open the pump.</p></note>"
+        "<note type='caution'><p>Wear safety glasses when you open the pump.</p></note>",
+    ]
+    topics = {f"c{n}.dita": ET.fromstring(f"<concept id='c{n}'><title>C{n}</title><conbody>{body}</conbody>"
+                                          "</concept>") for n, body in enumerate(bodies, 1)}
+    root = ET.fromstring("<map>" + "".join(f"<topicref href='topics/{name}'/>" for name in topics) + "</map>")
+    return DitaContext(ditamap_root=root, topics=topics, metadata={})
+
+
+def test_safety_notices_are_grouped_by_wording_and_single_sourced():
+    ctx = _notices()
+    groups = find_notice_groups(ctx)
+    assert [(g.kind, g.type, g.count) for g in groups] == [
+        ("note", "warning", 3), ("hazardstatement", "danger", 1), ("note", "caution", 1)]
+    warning = groups[0]
+    assert warning.text == "Wear safety glasses when you open the pump."
+    [variant] = warning.variants
+    assert variant.topic == "c3.dita" and 0.85 <= variant.similarity < 1.0
+
+    variant.accepted = False
+    assert apply_notice_warehouse(ctx, groups) == 4
+    warehouse = ctx.topics["safety_notices.dita"]
+    assert warehouse.findtext("title") == "Safety notices"
+    assert [el.get("id") for el in warehouse.find("conbody")] == ["warning_1", "danger_1", "caution_1"]
+    first = ctx.topics["c1.dita"].find("conbody/note")
+    assert first.get("conref") == "safety_notices.dita#safety_notices/warning_1" and first.get("id") == "keep"
+    assert first.get("type") == "warning" and len(first) == 0
+    assert ctx.topics["c1.dita"].find("conbody/note[@type='tip']/p") is not None
+    assert ctx.topics["c3.dita"].findtext("conbody/note[@type='warning']/p").startswith("Always")
+    assert ctx.topics["c2.dita"].find("conbody/hazardstatement").get("conref").endswith("/danger_1")
+    assert ctx.ditamap_root.find("topicref[@href='topics/safety_notices.dita']").get("processing-role") == \
+        "resource-only"
+
+
+def test_notices_are_consolidated_during_conversion_only_when_enabled():
+    ctx = _notices()
+    assert consolidate_notices(ctx, {}) == 0 and "safety_notices.dita" not in ctx.topics
+    # Stricter similarity keeps the reworded warning apart; cautions are not collected
+    assert consolidate_notices(ctx, {"enabled": True, "types": ["warning"], "similarity": 0.95}) == 4
+    third = ctx.topics["c3.dita"].find("conbody")
+    assert third[0].get("conref").endswith("/warning_2") and third[1].get("conref") is None
+    entry = next(e for e in ctx.report.entries if e.category == "safety_notices")
+    assert entry.detail == {"notices": 4, "shared": 3, "reworded": 0}
@@ -0,0 +1,64 @@
+import zipfile
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.preview import xml_compiler
+from orlando_toolkit.core.review_bundle import build_review_bundle
+
+
+def _context():
+    topics = {
+        "intro.dita": "<concept id='intro'><title>Introduction</title><conbody><p>See <xref href='wiring.dita#wiring/"
+                      "earth'>earthing</xref>, <xref href='#intro/p1'>above</xref> and "
+                      "<xref href='https://x.org' scope='external'>x</xref>.</p>"
+                      "<image href='../media/pump.png'/></conbody></concept>",
+        "wiring.dita": "<task id='wiring'><title>Wiring</title><taskbody/></task>",
+        "more.dita": "<concept id='more'><title>More &amp; less</title><conbody/></concept>",
+    }
+    root = ET.fromstring(
+        "<map><topicref href='topics/intro.dita'><topicmeta><navtitle>Introduction</navtitle></topicmeta>"
+        "<topicref href='topics/wiring.dita'/></topicref>"
+        "<topichead><topicmeta><navtitle>Appendix</navtitle></topicmeta><topicgroup>"
+        "<topicref href='topics/more.dita'/><topicref href='topics/intro.dita'/></topicgroup></topichead>"
+        "<topicref href='topics/glossary.dita' processing-role='resource-only'/></map>")
+    ctx = DitaContext(ditamap_root=root, topics={k: ET.fromstring(v) for k, v in topics.items()},
+                      metadata={"manual_title": "Pump manual", "manual_code": "PM-1"})
+    ctx.images = {"pump.png": b"PNG", "spare.png": b"GIF"}
+    return ctx
+
+
+def test_bundle_pages_point_at_each_other_and_the_bundled_images(tmp_path, monkeypatch):
+    monkeypatch.setattr(xml_compiler, "safe_xslt", lambda _xslt: lambda src: ET.tostring(src, encoding="unicode"))
+    bundle = build_review_bundle(_context())
+    assert [(p.topic, p.name, p.title) for p in bundle.pages] == [
+        ("intro.dita", "topics/intro.html", "Introduction"), ("wiring.dita", "topics/wiring.html", "Wiring"),
+        ("more.dita", "topics/more.html", "More & less")]
+    intro = ET.fromstring(bundle.pages[0].body)
+    assert [x.get("href") for x in intro.iter("xref")] == ["wiring.html#earth", "#intro/p1", "https://x.org"]
+    assert intro.find(".//image").get("href") == "../images/pump.png"
+    assert bundle.images == {"pump.png": b"PNG"} and not bundle.warnings
+
+    with zipfile.ZipFile(bundle.write_zip(tmp_path / "review.zip")) as archive:
+        assert sorted(archive.namelist()) == ["images/pump.png", "index.html", "review.css", "topics/intro.html",
+                                              "topics/more.html", "topics/wiring.html"]
+        index = archive.read("index.html").decode("utf-8")
+        wiring = archive.read("topics/wiring.html").decode("utf-8")
+    assert "<title>Pump manual</title>" in index and "Reference: PM-1" in index
+    assert ('<ul><li><a href="topics/intro.html">Introduction</a><ul><li><a href="topics/wiring.html">Wiring</a>'
+            '</li></ul></li><li><span class="head">Appendix</span><ul><li><a href="topics/more.html">'
+            'More &amp; less</a></li><li><a href="topics/intro.html">Introduction</a></li></ul></li></ul>' in index)
+    assert '<li class="current"><a href="../topics/wiring.html">Wiring</a>' in wiring
+    assert '<link rel="stylesheet" href="../review.css">' in wiring
+    assert "<title>Wiring – Pump manual</title>" in wiring
+
+
+def test_topics_the_compiler_cannot_render_are_shown_as_xml(monkeypatch):
+    def _broken(_xslt):
+        raise RuntimeError("no XSLT")
+
+    monkeypatch.setattr(xml_compiler, "safe_xslt", _broken)
+    bundle = build_review_bundle(_context(), title="Draft")
+    assert bundle.title == "Draft" and len(bundle.warnings) == 3
+    assert bundle.pages[1].body.startswith("<h2>Wiring</h2><pre>&lt;task id=&quot;wiring&quot;&gt;&lt;title&gt;")
+    assert bundle.pages[2].body.startswith("<h2>More &amp; less</h2>") and bundle.pages[2].body.endswith("</pre>")
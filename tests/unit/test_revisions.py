@@ -0,0 +1,47 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.revisions import DITAVAL_NAME, mark_revisions, write_revision_ditaval
+
+
+def _context(paragraphs, extra_topic: bool = False) -> DitaContext:
+    refs = "<topicref href='topics/a.dita'/>" + ("<topicref href='topics/b.dita'/>" if extra_topic else "")
+    body = "".join(f"<p>{text}</p>" for text in paragraphs)
+    topics = {"a.dita": ET.fromstring(f"<concept id='a'><title>Install</title><conbody>{body}</conbody></concept>")}
+    if extra_topic:
+        topics["b.dita"] = ET.fromstring("<concept id='b'><title>Upgrade</title><conbody><p>New</p></conbody></concept>")
+    ctx = DitaContext(ditamap_root=ET.fromstring(f"<map>{refs}</map>"), topics=topics)
+    ctx.metadata["revision_number"] = "12"
+    return ctx
+
+
+def _save(ctx: DitaContext, folder) -> None:
+    (folder / "topics").mkdir()
+    (folder / "manual.ditamap").write_bytes(ET.tostring(ctx.ditamap_root))
+    for name, topic in ctx.topics.items():
+        (folder / "topics" / name).write_bytes(ET.tostring(topic))
+
+
+def test_changed_and_new_content_is_marked_with_rev(tmp_path):
+    _save(_context(["Unpack the box.", "Plug in the cable.", "Old remark."]), tmp_path)
+    ctx = _context(["Unpack the box.", "Plug in the power cable.", "Switch it on."], extra_topic=True)
+    result = mark_revisions(ctx, options={"enabled": True, "previous": str(tmp_path)})
+
+    paras = ctx.topics["a.dita"].findall("conbody/p")
+    assert [p.get("rev") for p in paras] == [None, "12", "12"]
+    assert ctx.topics["a.dita"].get("rev") is None and ctx.topics["b.dita"].get("rev") == "12"
+    assert (result.new_topics, result.changed_topics, result.changed_blocks) == (1, 1, 2)
+
+    out = tmp_path / "out"
+    out.mkdir()
+    ditaval = ET.parse(str(write_revision_ditaval(ctx, out))).getroot()
+    assert ditaval.find("revprop").get("val") == "12" and ditaval.find("revprop").get("action") == "flag"
+    assert (out / DITAVAL_NAME).exists()
+
+
+def test_revision_marking_is_opt_in_and_needs_a_previous_conversion(tmp_path):
+    ctx = _context(["Text"])
+    assert mark_revisions(ctx, options={}) is None
+    assert mark_revisions(ctx, options={"enabled": True, "previous": str(tmp_path / "missing")}) is None
+    assert ctx.report.count("warning", "revisions") == 1
+    assert write_revision_ditaval(ctx, tmp_path) is None
@@ -0,0 +1,100 @@
+import zipfile
+
+import pytest
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.s1000d import S1000DError, S1000DSettings, export_s1000d
+
+_ON = S1000DSettings(enabled=True, model_ident_code="PUMP", system_code="29", enterprise_code="K0378")
+
+
+def _context():
+    topics = {
+        "intro.dita": "<concept id='intro'><title>Introduction</title><shortdesc>About the pump.</shortdesc>"
+                      "<conbody><p>Read <xref href='wiring.dita'>the wiring</xref> and "
+                      "<xref href='https://x.org' scope='external'>x</xref>.</p>"
+                      "<section><title>Parts</title><ul><li>Motor</li><li><b>Seal</b></li></ul></section>"
+                      "<p>After the section.</p>"
+                      "<note type='caution'><p>Hot</p></note>"
+                      "<fig><title>Pump</title><image href='../media/pump.png' placement='break'/></fig>"
+                      "</conbody></concept>",
+        "wiring.dita": "<task id='wiring'><title>Wiring</title><taskbody><steps><step><cmd>Open the "
+                       "<uicontrol>cover</uicontrol></cmd><info><p>Use gloves.</p></info></step></steps>"
+                       "<result><simpletable><sthead><stentry>Wire</stentry><stentry>Colour</stentry></sthead>"
+                       "<strow><stentry>L1</stentry><stentry>Brown</stentry></strow></simpletable></result>"
+                       "</taskbody></task>",
+    }
+    root = ET.fromstring(
+        "<map><topicref href='topics/intro.dita'><topicref href='topics/wiring.dita'/></topicref>"
+        "<topichead><topicmeta><navtitle>Appendix</navtitle></topicmeta>"
+        "<topicref href='topics/wiring.dita'/></topichead></map>")
+    ctx = DitaContext(ditamap_root=root, topics={k: ET.fromstring(v) for k, v in topics.items()},
+                      metadata={"manual_title": "Pump Manual", "language": "fr-FR", "revision_date": "2024-05-01",
+                                "revision_number": "3"})
+    ctx.images = {"pump.png": b"PNG", "spare.png": b"GIF"}
+    return ctx
+
+
+def test_export_is_refused_until_enabled():
+    with pytest.raises(S1000DError) as err:
+        export_s1000d(_context(), S1000DSettings())
+    assert err.value.code == "OTK505"
+    with pytest.raises(S1000DError, match="system_code"):
+        export_s1000d(_context(), S1000DSettings(enabled=True, system_code="2"))
+
+
+def test_topics_become_descriptive_data_modules():
+    export = export_s1000d(_context(), _ON)
+
+    assert [m.topic for m in export.modules] == ["intro.dita", "wiring.dita"]
+    intro, wiring = export.modules
+    assert intro.file_name == "DMC-PUMP-A-29-00-0001-00A-040A-D_003-00_FR-FR.XML"
+    assert dict(intro.root.find("identAndStatusSection/dmAddress/dmAddressItems/issueDate").attrib) == {
+        "year": "2024", "month": "05", "day": "01"}
+
+    level = intro.root.find("content/description/levelledPara")
+    assert level.findtext("title") == "Introduction"
+    assert [c.tag for c in level] == ["title", "caution", "para", "para", "levelledPara", "levelledPara"]
+    link = level[3].find("dmRef/dmRefIdent/dmCode")
+    assert link.get("assyCode") == "0002" and level[3].text == "Read the wiring "
+    assert "x (https://x.org)" in "".join(level[3].itertext())
+    parts = level[4]
+    assert parts.findtext("title") == "Parts"
+    assert [li.findtext("para") or li.findtext("para/emphasis") for li in parts.iter("listItem")] == ["Motor", "Seal"]
+    tail = level[5]
+    assert tail.find("title") is None and tail.findtext("para") == "After the section."
+    assert tail.find("figure/graphic").get("infoEntityIdent") == "ICN-K0378-00001-001-01"
+
+    steps = wiring.root.find("content/description/levelledPara/para/sequentialList")
+    assert steps.find("listItem/para/emphasis").get("emphasisType") == "em01"
+    table = wiring.root.find(".//table/tgroup")
+    assert table.get("cols") == "2" and table.findtext("thead/row/entry/para") == "Wire"
+    assert "tasks written as descriptive data modules (steps as sequential lists)" in export.changes
+    assert "warnings and cautions moved to the start of their levelled paragraph" in export.changes
+
+
+def test_dmrl_and_publication_module_follow_the_map(tmp_path):
+    export = export_s1000d(_context(), _ON)
+
+    entries = export.dmrl.root.findall("content/dmrl/dmrlEntry")
+    assert [e.findtext("dmRef/dmRefAddressItems/dmTitle/techName") for e in entries] == ["Introduction", "Wiring"]
+    assert export.dmrl.code["infoCode"] == "00R"
+    content = export.pm.find("content")
+    assert [e.findtext("pmEntryTitle") for e in content.findall("pmEntry")] == ["Introduction", "Appendix"]
+    assert content.find("pmEntry/pmEntry") is None
+    assert len(content.findall("pmEntry/dmRef")) == 3
+
+    with zipfile.ZipFile(export.write_zip(tmp_path / "out.zip")) as zf:
+        names = sorted(zf.namelist())
+    assert names == ["DMC-PUMP-A-29-00-0000-00A-00RA-D_003-00_FR-FR.XML",
+                     "DMC-PUMP-A-29-00-0001-00A-040A-D_003-00_FR-FR.XML",
+                     "DMC-PUMP-A-29-00-0002-00A-040A-D_003-00_FR-FR.XML",
+                     "ICN-K0378-00001-001-01.png",
+                     "PMC-PUMP-K0378-00001-00_003-00_FR-FR.XML"]
+
+
+def test_settings_from_mapping_normalises_codes():
+    settings = S1000DSettings.from_mapping({"enabled": True, "model_ident_code": "pump ", "system_code": 29})
+    assert (settings.model_ident_code, settings.system_code, settings.info_code) == ("PUMP", "29", "040")
+    assert settings.invalid_codes() == []
@@ -0,0 +1,165 @@
+import http.client
+import io
+import json
+import threading
+import time
+import urllib.error
+import urllib.request
+import zipfile
+from contextlib import contextmanager
+from types import SimpleNamespace
+
+from orlando_toolkit.core.audit import AuditLog, JsonLinesSink, set_audit_log
+from orlando_toolkit.core.cancellation import OperationCancelledError
+from orlando_toolkit.server import JobManager, ServerSettings, create_server
+
+
+class _HeldToolkit:
+    """Toolkit whose conversions wait until released."""
+
+    def __init__(self):
+        self.release = threading.Event()
+        self.service = SimpleNamespace(can_handle_file=lambda name: True)
+
+    def supported_extensions(self):
+        return [".md"]
+
+    def convert(self, source, options, progress=None, cancel_token=None):
+        self.release.wait(30)
+        raise OperationCancelledError()
+
+
+@contextmanager
+def _service(tmp_path, toolkit=None, **overrides):
+    settings = ServerSettings(port=0, token="secret", work_dir=str(tmp_path / "jobs"), **overrides)
+    manager = JobManager(settings, toolkit=toolkit) if toolkit else JobManager(settings, plugins=False)
+    server = create_server(settings, manager=manager)
+    threading.Thread(target=server.serve_forever, daemon=True).start()
+    try:
+        yield "http://%s:%d" % server.server_address[:2]
+    finally:
+        server.shutdown()
+        server.manager.shutdown()
+        server.server_close()
+
+
+def _call(url, method="GET", data=None, token="secret", headers=None):
+    request = urllib.request.Request(url, data=data, method=method, headers=dict(headers or {}))
+    if token:
+        request.add_header("Authorization", f"Bearer {token}")
+    try:
+        with urllib.request.urlopen(request, timeout=30) as response:
+            return response.status, response.read()
+    except urllib.error.HTTPError as exc:
+        return exc.code, exc.read()
+
+
+def _raw_post(base, headers, body=b""):
+    connection = http.client.HTTPConnection(base.split("//", 1)[1], timeout=30)
+    try:
+        connection.putrequest("POST", "/jobs?filename=notes.md")
+        for name, value in {"Authorization": "Bearer secret", **headers}.items():
+            connection.putheader(name, value)
+        connection.endheaders(body)
+        return connection.getresponse().status
+    finally:
+        connection.close()
+
+
+def _wait(base, job_id):
+    for _ in range(300):
+        status, body = _call(f"{base}/jobs/{job_id}")
+        job = json.loads(body)
+        if job["status"] not in ("queued", "running"):
+            return job
+        time.sleep(0.05)
+    raise AssertionError("job did not finish")
+
+
+def test_submit_poll_and_download_a_package(tmp_path):
+    with _service(tmp_path) as service:
+        boundary = "otk-boundary"
+        body = (f"--{boundary}\r\nContent-Disposition: form-data; name=\"file\"; filename=\"manual.md\"\r\n"
+                "Content-Type: text/markdown\r\n\r\n# Manual\n\n## Wiring\n\nConnect the cable.\n\r\n"
+                f"--{boundary}--\r\n").encode("utf-8")
+        status, answer = _call(f"{service}/jobs", "POST", body,
+                               headers={"Content-Type": f"multipart/form-data; boundary={boundary}"})
+        assert status == 202
+        submitted = json.loads(answer)
+        assert submitted["filename"] == "manual.md" and submitted["status"] in ("queued", "running", "done")
+
+        job = _wait(service, submitted["id"])
+        assert job["status"] == "done" and job["messages"] and job["summary"]
+        assert job["links"]["package"] == f"/jobs/{job['id']}/package"
+
+        status, package = _call(f"{service}{job['links']['package']}")
+        assert status == 200
+        with zipfile.ZipFile(io.BytesIO(package)) as zf:
+            assert any(name.endswith(".ditamap") for name in zf.namelist())
+        status, report = _call(f"{service}/jobs/{job['id']}/report")
+        assert status == 200 and "entries" in json.loads(report)
+
+        status, listing = _call(f"{service}/jobs")
+        assert [j["id"] for j in json.loads(listing)["jobs"]] == [job["id"]]
+        assert _call(f"{service}/jobs/{job['id']}", "DELETE")[0] == 200
+        assert _call(f"{service}/jobs/{job['id']}")[0] == 404
+
+
+def test_requests_are_checked(tmp_path):
+    with _service(tmp_path) as service:
+        assert _call(f"{service}/health", token=None)[0] == 401
+        assert _call(f"{service}/health", token="wrong")[0] == 401
+        assert [_call(f"{service}/health", token=t)[0] for t in ("secre", "secret2", "sécret")] == [401] * 3
+        assert json.loads(_call(f"{service}/health")[1])["status"] == "ok"
+
+        status, body = _call(f"{service}/jobs?filename=tool.exe", "POST", b"MZ")
+        assert status == 400 and "error" in json.loads(body)
+        assert _call(f"{service}/jobs?filename=empty.md", "POST", b"")[0] == 400
+        assert _call(f"{service}/jobs/{'0' * 32}")[0] == 404
+        assert _call(f"{service}/unknown")[0] == 404
+
+        status, body = _call(f"{service}/jobs?filename=notes.md", "POST", b"# Notes\n\nText.\n")
+        assert status == 202 and _wait(service, json.loads(body)["id"])["status"] == "done"
+
+
+def test_upload_lengths_are_validated_before_reading(tmp_path):
+    with _service(tmp_path) as service:
+        assert _raw_post(service, {}) == 411
+        assert _raw_post(service, {"Content-Length": "-1"}, b"# Notes\n") == 400
+        assert _raw_post(service, {"Content-Length": "ten"}, b"# Notes\n") == 400
+        assert _raw_post(service, {"Content-Length": str(200 * 1024 * 1024)}) == 413
+        assert _raw_post(service, {"Content-Length": "8"}, b"# Notes\n") == 202
+
+
+def test_uploads_beyond_max_queued_are_refused_until_jobs_finish(tmp_path):
+    toolkit = _HeldToolkit()
+    with _service(tmp_path, toolkit, workers=1, max_queued=2) as service:
+        first, second = (_call(f"{service}/jobs?filename=n{i}.md", "POST", b"# Notes\n") for i in range(2))
+        assert first[0] == second[0] == 202
+        status, body = _call(f"{service}/jobs?filename=n3.md", "POST", b"# Notes\n")
+        assert status == 503 and "try again later" in json.loads(body)["error"]
+        assert len(list((tmp_path / "jobs").iterdir())) == 2
+
+        toolkit.release.set()
+        for _status, body in (first, second):
+            assert _wait(service, json.loads(body)["id"])["status"] == "cancelled"
+        assert _call(f"{service}/jobs?filename=n3.md", "POST", b"# Notes\n")[0] == 202
+
+
+def test_jobs_are_audited_under_the_requesting_user(tmp_path):
+    path = tmp_path / "audit.jsonl"
+    set_audit_log(AuditLog([JsonLinesSink(path)], actor="svc-otk"))
+    try:
+        with _service(tmp_path, user_header="X-Remote-User") as service:
+            for headers in ({"X-Remote-User": "alice"}, {}):
+                status, body = _call(f"{service}/jobs?filename=notes.md", "POST", b"# Notes\n\nText.\n",
+                                     headers=headers)
+                assert _wait(service, json.loads(body)["id"])["status"] == "done"
+    finally:
+        set_audit_log(None)
+
+    records = [json.loads(line) for line in path.read_text(encoding="utf-8").splitlines()]
+    actors = {r["action"]: [] for r in records}
+    for record in records:
+        actors[record["action"]].append(record["actor"])
+    assert actors["convert"] == actors["publish"] == ["alice", "client:127.0.0.1"]
@@ -0,0 +1,95 @@
+import types
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.services.preview_service import PreviewService
+from orlando_toolkit.ui.tabs.structure.preview_coordinator import PreviewCoordinator
+
+W = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+STYLES = (f'<w:styles xmlns:w="{W}">'
+          '<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/></w:style>'
+          '<w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/></w:style>'
+          '<w:style w:type="paragraph" w:styleId="Titre"><w:name w:val="Titre Annexe"/></w:style></w:styles>')
+
+
+def _p(text, style=None, bold=False):
+    props = f'<w:pPr><w:pStyle w:val="{style}"/></w:pPr>' if style else ""
+    run_props = "<w:rPr><w:b/></w:rPr>" if bold else ""
+    return f"<w:p>{props}<w:r>{run_props}<w:t>{text}</w:t></w:r></w:p>"
+
+
+BODY = (_p("1 Introduction", "Heading1") + _p("Read this ", bold=True) + _p("Scope", "Heading2")
+        + _p("Scope text.") + _p("Limits", "Heading2") + _p("Merged limits.")
+        + "<w:tbl><w:tr><w:tc><w:p><w:r><w:t>Cell</w:t></w:r></w:p></w:tc></w:tr></w:tbl>"
+        + _p("Spare parts", "Titre") + _p("Parts list."))
+PARTS = {"word/styles.xml": STYLES}
+
+
+def _context(source):
+    # "Limits" was merged into "Scope" (depth limit); the annex heading uses a mapped custom style
+    ditamap = ET.fromstring("<map><topicref href='topics/intro.dita'><topicref href='topics/scope.dita'/>"
+                            "</topicref><topicref href='topics/parts.dita'/></map>")
+    titles = {"intro": "Introduction", "scope": "Scope", "parts": "Spare Parts"}
+    topics = {f"{k}.dita": ET.fromstring(f"<concept id='{k}'><title>{v}</title></concept>") for k, v in titles.items()}
+    return DitaContext(ditamap_root=ditamap, topics=topics,
+                       metadata={"source_file": str(source), "style_map": {"Titre Annexe": 1}})
+
+
+def test_source_section_of_each_topic_runs_to_the_next_topic_heading(tmp_path, docx_factory):
+    context = _context(docx_factory(tmp_path / "manual.docx", BODY, parts=PARTS))
+    service = PreviewService()
+    refs = {ref.get("href").split("/")[-1]: ref for ref in context.ditamap_root.iter("topicref")}
+
+    intro = service.render_source_preview_for_node(context, refs["intro.dita"])
+    assert intro.success and "<h1>1 Introduction</h1>" in intro.content and "<b>Read this </b>" in intro.content
+    assert "Scope" not in intro.content
+    scope = service.render_source_preview_for_node(context, refs["scope.dita"]).content
+    assert "Merged limits." in scope and "<td>" in scope and "Parts list." not in scope
+    assert "<h1>Spare parts</h1>" in service.render_source_preview_for_node(context, refs["parts.dita"]).content
+
+    context.metadata["source_file"] = str(tmp_path / "moved.docx")
+    missing = service.render_source_preview_for_node(context, refs["intro.dita"])
+    assert not missing.success and "not found" in missing.message
+    del context.metadata["source_file"]
+    assert "No source document" in service.render_source_preview_for_node(context, refs["intro.dita"]).message
+
+
+class _SplitPanel:
+    def __init__(self, split):
+        self.split, self.content, self.source = split, None, None
+
+    def get_mode(self):
+        return "html"
+
+    def is_split(self):
+        return self.split
+
+    def set_source_content(self, text):
+        self.source = text
+
+    def set_content(self, text):
+        self.content = text
+
+    def set_loading(self, _loading):
+        pass
+
+    def set_title(self, _title):
+        pass
+
+    def show_error(self, message):
+        self.content = message
+
+
+def test_coordinator_fills_the_source_pane_only_in_side_by_side_mode():
+    ctrl = types.SimpleNamespace(
+        render_html_preview_for_node=lambda node: types.SimpleNamespace(success=True, content="<html>topic</html>"),
+        render_source_preview_for_node=lambda node: types.SimpleNamespace(
+            success=False, content=None, message="No heading of the source document matches this topic."))
+    node = ET.Element("topichead")
+    for split in (True, False):
+        panel = _SplitPanel(split)
+        PreviewCoordinator(controller_getter=lambda: ctrl, panel=panel, schedule_ui=lambda *a: None,
+                           run_in_thread=lambda work, done: done(work())).render_for_node(node)
+        assert panel.content == "<html>topic</html>"
+        assert (panel.source is not None and "No heading" in panel.source) is split
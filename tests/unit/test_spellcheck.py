@@ -0,0 +1,80 @@
+import csv
+import io
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.preview.xml_compiler import _highlight_misspellings
+from orlando_toolkit.core.spellcheck import (
+    IGNORE_KEY,
+    SpellSettings,
+    check_spelling,
+    ignore_words,
+    issues_to_csv,
+    misspelled_spans,
+)
+
+
+class _Checker:
+    def __init__(self, known, languages=("en",)):
+        self.known = {w.lower() for w in known}
+        self.languages = languages
+        self.calls = []
+
+    def check(self, words, language):
+        self.calls.append(language)
+        if language.split("-")[0] not in self.languages:
+            raise LookupError(f"No Hunspell dictionary for {language}")
+        return [w for w in words if w.lower() not in self.known]
+
+
+_KNOWN = ["install", "the", "pump", "press", "button", "then", "remove", "cover"]
+
+
+def _context(metadata=None) -> DitaContext:
+    topics = {
+        "a.dita": ET.fromstring("<concept id='a' xml:lang='en-US'><title>Install the pump</title><conbody>"
+                                "<p>Press teh buton, then teh cover.</p><codeblock>frobnicate</codeblock>"
+                                "</conbody></concept>"),
+        "b.dita": ET.fromstring("<concept id='b' xml:lang='de-DE'><title>Pumpe</title><conbody><p>Deckel</p>"
+                                "</conbody></concept>"),
+    }
+    refs = "".join(f"<topicref href='topics/{n}'/>" for n in topics)
+    return DitaContext(ditamap_root=ET.fromstring(f"<map>{refs}</map>"), topics=topics,
+                       metadata=dict(metadata or {}))
+
+
+def test_check_spelling_lists_words_per_topic_and_reports_missing_dictionaries():
+    errors = []
+    issues = check_spelling(_context(), _Checker(_KNOWN), settings=SpellSettings(), errors=errors)
+
+    assert [(i.topic, i.word, i.count, i.language) for i in issues] == [
+        ("a.dita", "teh", 2, "en-us"), ("a.dita", "buton", 1, "en-us"),
+    ]
+    assert issues[0].title == "Install the pump" and "Press teh buton" in issues[0].snippets[0]
+    assert errors == ["No Hunspell dictionary for de-de"]
+    rows = list(csv.reader(io.StringIO(issues_to_csv(issues))))
+    assert rows[0][:3] == ["topic", "title", "word"] and rows[1][2] == "teh"
+
+
+def test_ignore_list_lives_in_metadata_and_is_respected():
+    metadata = {}
+    assert ignore_words(metadata, ["teh", "Teh", " "]) == ["teh"]
+    assert metadata[IGNORE_KEY] == ["teh"]
+
+    issues = check_spelling(_context(metadata), _Checker(_KNOWN), settings=SpellSettings())
+    assert [i.word for i in issues] == ["buton"]
+
+
+def test_misspelled_spans_skip_markup():
+    text = '<p outputclass="teh">Press <b>teh</b> buton &amp; go</p>'
+    spans = misspelled_spans(text, _Checker(_KNOWN + ["go"]), "en", allowed=["buton"])
+    assert [text[start:end] for start, end in spans] == ["teh"]
+
+
+def test_preview_underlines_words_outside_code():
+    tree = ET.fromstring("<conbody><p>Press teh <b>buton</b> now teh</p><codeblock>teh</codeblock></conbody>")
+    assert _highlight_misspellings(tree, ["teh", "buton"]) == 3
+    assert [s.text for s in tree.findall(".//span")] == ["teh", "buton", "teh"]
+    assert tree.find("codeblock").text == "teh"
+    assert "".join(tree.find("p").itertext()) == "Press teh buton now teh"
@@ -0,0 +1,49 @@
+import copy
+
+from orlando_toolkit.core.concurrency import PipelineSettings
+from orlando_toolkit.core.services.conversion_service import ConversionService
+from orlando_toolkit.core.spool import SpooledBlobs, copy_blobs, rename_blobs, write_blob
+
+
+def test_spooled_blobs_behave_like_a_dict_backed_by_files(tmp_path):
+    blobs = SpooledBlobs([("a.png", b"one"), ("b.png", b"two")], directory=tmp_path / "spool")
+    assert list(blobs) == ["a.png", "b.png"] and blobs["a.png"] == b"one" and blobs.size_of("b.png") == 3
+    assert blobs.path_of("a.png").parent.parent == tmp_path / "spool"
+
+    snapshot = copy_blobs(blobs)
+    deep = copy.deepcopy(blobs)
+    blobs["a.png"] = b"changed"
+    del blobs["b.png"]
+    assert snapshot["a.png"] == b"one" and deep["b.png"] == b"two" and dict(blobs) == {"a.png": b"changed"}
+
+    assert rename_blobs(snapshot, {"a.png": "z.png"}) is snapshot
+    assert list(snapshot.items()) == [("z.png", b"one"), ("b.png", b"two")]
+    write_blob(snapshot, "z.png", tmp_path / "out.png")
+    assert (tmp_path / "out.png").read_bytes() == b"one"
+
+    folder = blobs.directory
+    blobs.close()
+    assert not folder.exists() and len(blobs) == 0
+    assert PipelineSettings.from_mapping({"low_memory": {"enabled": "auto", "threshold_mb": 0}}).low_memory_for(
+        tmp_path / "out.png")
+
+
+def test_low_memory_conversion_packages_the_same_archive(tmp_path):
+    (tmp_path / "img").mkdir()
+    (tmp_path / "img" / "wiring.png").write_bytes(b"\x89PNG" + bytes(range(256)) * 40)
+    (tmp_path / "img" / "panel.png").write_bytes(b"\x89PNG" + b"panel" * 100)
+    source = tmp_path / "manual.md"
+    source.write_text("# Manual\n\n## Wiring\n\n![Wiring](img/wiring.png)\n\n"
+                      "## Panel\n\n![Panel](img/panel.png)\n", encoding="utf-8")
+    service = ConversionService()
+    archives = []
+    for mode in (False, True):
+        metadata = {"manual_title": "Manual", "revision_date": "2024-01-01",
+                    "pipeline": {"low_memory": {"enabled": mode, "spool_dir": str(tmp_path / "spool")}}}
+        context = service.prepare_package(service.convert(source, metadata))
+        assert isinstance(context.images, SpooledBlobs) is mode
+        assert any(e.category == "low_memory" for e in context.report.entries) is mode
+        target = tmp_path / f"out_{mode}.zip"
+        service.write_package(context, target)
+        archives.append(target.read_bytes())
+    assert archives[0] == archives[1]
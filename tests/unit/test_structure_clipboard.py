@@ -0,0 +1,70 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.services.structure_editing_service import StructureEditingService
+from orlando_toolkit.core.structure_clipboard import read_clip, write_clip
+
+
+def _manual(title, section, topics, images=None):
+    """A map with one section holding *topics*, a list of (file, title, body xml)."""
+    root = ET.Element("map")
+    head = ET.SubElement(root, "topichead", {"data-level": "1"})
+    ET.SubElement(ET.SubElement(head, "topicmeta"), "navtitle").text = section
+    context = DitaContext(ditamap_root=root, metadata={"manual_title": title})
+    for name, heading, body in topics:
+        context.topics[name] = ET.fromstring(f"<concept id='{name[:-5]}'><title>{heading}</title>"
+                                             f"<conbody>{body}</conbody></concept>")
+        ref = ET.SubElement(head, "topicref", {"href": f"topics/{name}", "data-level": "2"})
+        ET.SubElement(ET.SubElement(ref, "topicmeta"), "navtitle").text = heading
+    context.images = dict(images or {})
+    return context
+
+
+def _outline(node):
+    return [(el.tag, el.findtext("topicmeta/navtitle"), el.get("href"), el.get("data-level"), _outline(el))
+            for el in node if el.tag in ("topicref", "topichead")]
+
+
+def test_copied_branch_is_pasted_into_another_manual_with_renamed_files_and_ids(tmp_path):
+    service = StructureEditingService()
+    source = _manual("Pump Manual", "Safety", [
+        ("topic_1.dita", "General safety", "<p id='p1' data-bookmarks='Start'>Wear gloves "
+                                           "<xref href='#Start'>here</xref></p><image href='../media/image1.png'/>"),
+        ("topic_2.dita", "Warnings", "<p conref='topic_1.dita#topic_1/p1'/><p><xref href='topic_9.dita'/></p>"
+                                     "<image href='../media/logo.png'/>"),
+    ], images={"image1.png": b"gloves", "logo.png": b"logo", "unused.png": b"x"})
+    target = _manual("Fan Manual", "Maintenance", [
+        ("topic_1.dita", "Cleaning", "<p data-bookmarks='Start'>Clean</p><image href='../media/image1.png'/>"),
+    ], images={"image1.png": b"fan", "logo.png": b"logo"})
+
+    clip = service.copy_selection(source, [], [[0]])
+    assert sorted(clip.topics) == ["topic_1.dita", "topic_2.dita"] and sorted(clip.images) == ["image1.png", "logo.png"]
+    path = write_clip(clip, tmp_path / "clip.zip")
+    clip = read_clip(path)
+    assert clip.summary() == "1 entry, 2 topic(s), 2 media file(s) from Pump Manual"
+
+    result = service.paste_clip(target, clip, [0], "inside")
+    assert result.success, result.message
+    assert result.details == {"topics": 2, "renamed": 2, "ids": 1, "bookmarks": 1, "outside": 1}
+    assert _outline(target.ditamap_root) == [
+        ("topichead", "Maintenance", None, "1", [
+            ("topicref", "Cleaning", "topics/topic_1.dita", "2", []),
+            ("topichead", "Safety", None, "2", [
+                ("topicref", "General safety", "topics/topic_1_2.dita", "3", []),
+                ("topicref", "Warnings", "topics/topic_2.dita", "3", [])])])]
+    assert target.images == {"image1.png": b"fan", "logo.png": b"logo", "image1_2.png": b"gloves"}
+    safety, warnings = target.topics["topic_1_2.dita"], target.topics["topic_2.dita"]
+    assert safety.get("id") == "topic_1_2" and target.topics["topic_1.dita"].get("id") == "topic_1"
+    assert safety.find("conbody/p").get("data-bookmarks") == "pasted_Start"
+    assert safety.find("conbody/p/xref").get("href") == "#pasted_Start"
+    assert safety.find("conbody/image").get("href") == "../media/image1_2.png"
+    assert warnings.find("conbody/p").get("conref") == "topic_1_2.dita#topic_1_2/p1"
+    assert warnings.find("conbody/image").get("href") == "../media/logo.png"
+    # The source manual is unchanged
+    assert source.topics["topic_1.dita"].get("id") == "topic_1" and len(source.ditamap_root[0]) == 3
+
+
+def test_paste_needs_a_clip():
+    target = _manual("Fan Manual", "Maintenance", [])
+    result = StructureEditingService().paste_clip(target, read_clip("missing.zip"), [0], "after")
+    assert not result.success and result.details["reason"] == "empty_clipboard"
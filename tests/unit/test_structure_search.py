@@ -0,0 +1,52 @@
+import pytest
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.search import matching_branches, search_structure
+from orlando_toolkit.core.services.structure_editing_service import StructureEditingService
+from orlando_toolkit.core.services.undo_service import UndoService
+from orlando_toolkit.ui.controllers.structure_controller import StructureController
+
+
+def _context():
+    topics = {
+        "pump.dita": "<concept id='pump'><title>Pump</title><conbody><p>Check the valve V-12.</p></conbody></concept>",
+        "valve.dita": "<concept id='valve'><title>Valve Maintenance</title><conbody><p>Grease.</p></conbody></concept>",
+        "motor.dita": "<concept id='motor'><title>Motor</title><conbody><p>Check V-7 wiring.</p></conbody></concept>",
+    }
+    root = ET.fromstring(
+        "<map><topichead><topicmeta><navtitle>Hydraulics</navtitle></topicmeta>"
+        "<topicref href='topics/pump.dita'><topicref href='topics/valve.dita'/></topicref></topichead>"
+        "<topicref href='topics/motor.dita'/></map>")
+    return DitaContext(ditamap_root=root, topics={k: ET.fromstring(v) for k, v in topics.items()}, metadata={})
+
+
+def _names(nodes):
+    return [(n.get("href") or "section").rsplit("/", 1)[-1] for n in nodes]
+
+
+def test_search_titles_body_text_and_regex():
+    ctx = _context()
+    assert _names(search_structure(ctx, "VALVE")) == ["valve.dita"]
+    assert _names(search_structure(ctx, "valve", body_text=True)) == ["pump.dita", "valve.dita"]
+    assert _names(search_structure(ctx, "hydraul")) == ["section"]
+    assert _names(search_structure(ctx, r"V-\d+", regex=True, body_text=True)) == ["pump.dita", "motor.dita"]
+    # Without regex the pattern is a literal substring
+    assert search_structure(ctx, r"V-\d+", body_text=True) == []
+    with pytest.raises(ValueError):
+        search_structure(ctx, "(unclosed", regex=True)
+
+    valve = search_structure(ctx, "valve")
+    assert sorted(_names(matching_branches(valve))) == ["pump.dita", "section", "valve.dita"]
+
+
+def test_controller_search_options_and_error():
+    ctx = _context()
+    ctrl = StructureController(ctx, StructureEditingService(), UndoService(), None)
+    assert _names(ctrl.handle_search("check")) == []
+    ctrl.set_search_options(body_text=True)
+    assert _names(ctrl.handle_search("check")) == ["pump.dita", "motor.dita"] and ctrl.search_index == 0
+
+    ctrl.set_search_options(regex=True)
+    assert ctrl.handle_search("[") == [] and ctrl.search_error.startswith("Invalid pattern")
+    assert _names(ctrl.handle_search("^mo")) == ["motor.dita"] and ctrl.search_error == ""
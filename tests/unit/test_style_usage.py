@@ -0,0 +1,54 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.processing import run_processing_stages, strip_stage_hints
+from orlando_toolkit.core.processing.preformatted import PreformattedStage
+from orlando_toolkit.core.processing.styles import StyleMapStage
+from orlando_toolkit.core.style_usage import compute_style_usage, record_style_usage, write_style_usage_csv
+
+
+def _context():
+    topic = ET.fromstring(
+        "<concept id='c'><title data-style='Corp Chapter'>C</title><conbody>"
+        "<p data-style='Corp Warning'>Disconnect power.</p>"
+        "<p>Press <ph data-style='Corp Button'>Start</ph> or <ph data-style='Corp Key'>F1</ph>.</p>"
+        "<p data-style='Corp Body'>Text</p><p data-style='Corp Body'>More</p>"
+        "<p data-style='HTML Preformatted'>make</p><p data-style='Normal'>Plain</p></conbody></concept>")
+    root = ET.fromstring("<map><topicref href='topics/c.dita' data-style='Corp Chapter' data-level='1'/></map>")
+    ctx = DitaContext(ditamap_root=root, topics={"c.dita": topic})
+    ctx.metadata["style_map"] = {"Corp Chapter": 1, "Corp Warning": {"element": "note", "type": "warning"},
+                                 "Corp Button": "uicontrol"}
+    run_processing_stages(ctx, stages=[StyleMapStage(), PreformattedStage()])
+    return ctx
+
+
+def test_every_source_style_is_listed_with_what_it_became():
+    ctx = _context()
+    usage = {(u.kind, u.style): (u.count, u.elements, u.status) for u in compute_style_usage(ctx)}
+    assert usage == {
+        ("heading", "Corp Chapter"): (1, ["concept"], "mapped"),
+        ("paragraph", "Corp Body"): (2, ["p"], "unmapped"),
+        ("paragraph", "Corp Warning"): (1, ["note"], "mapped"),
+        ("paragraph", "HTML Preformatted"): (1, ["pre"], "converted"),
+        ("paragraph", "Normal"): (1, ["p"], "built-in"),
+        ("character", "Corp Button"): (1, ["uicontrol"], "mapped"),
+        ("character", "Corp Key"): (1, ["ph"], "unmapped"),
+    }
+    assert [u.kind for u in compute_style_usage(ctx)][:2] == ["heading", "paragraph"]
+
+    strip_stage_hints(ctx)
+    assert not any(el.get("data-paragraph-style") or el.get("data-character-style")
+                   for el in ctx.topics["c.dita"].iter())
+
+
+def test_usage_is_reported_and_exported_as_csv(tmp_path):
+    ctx = _context()
+    usages = record_style_usage(ctx)
+    entry = next(e for e in ctx.report.entries if e.category == "style_usage")
+    assert entry.message == "7 source style(s) in use, 2 unmapped: Corp Body, Corp Key"
+    assert entry.detail["unmapped"] == 2 and entry.detail["styles"][0]["style"] == "Corp Chapter"
+
+    path = write_style_usage_csv(usages, tmp_path / "styles.csv")
+    lines = path.read_text(encoding="utf-8-sig").splitlines()
+    assert lines[0] == "style,kind,count,elements,status"
+    assert "Corp Body,paragraph,2,p,unmapped" in lines and "Corp Warning,paragraph,1,note,mapped" in lines
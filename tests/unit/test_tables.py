@@ -0,0 +1,107 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.importers.asciidoc import AsciiDocParser
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.tables import build_cals, read_word_tables, restore_word_tables
+
+W = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+
+
+def _tc(text, props="", nested=""):
+    paragraphs = "".join(f"<w:p><w:r><w:t>{t}</w:t></w:r></w:p>" for t in text.split("/") if t)
+    return f"<w:tc><w:tcPr>{props}</w:tcPr>{paragraphs}{nested}</w:tc>"
+
+
+def _tr(cells, header=False):
+    props = "<w:trPr><w:tblHeader/></w:trPr>" if header else ""
+    return f"<w:tr>{props}{''.join(cells)}</w:tr>"
+
+
+def _equipment_spec():
+    # Model and Accessories merged over both header rows, Dimensions over L/W/H, P-100 over two
+    # rows, and the P-100 accessories as a nested table
+    accessories = ('<w:tbl><w:tblGrid><w:gridCol w:w="1000"/><w:gridCol w:w="1000"/></w:tblGrid>'
+                   + _tr([_tc("Hose"), _tc("2 m")]) + _tr([_tc("Filter"), _tc("F-7")]) + "</w:tbl>")
+    restart, cont = '<w:vMerge w:val="restart"/>', "<w:vMerge/>"
+    rows = [
+        _tr([_tc("Model", restart), _tc("Dimensions (mm)", '<w:gridSpan w:val="3"/>'),
+             _tc("Accessories", restart)], header=True),
+        _tr([_tc("", cont), _tc("L"), _tc("W"), _tc("H"), _tc("", cont)], header=True),
+        _tr([_tc("P-100", restart), _tc("420"), _tc("310"), _tc("550"), _tc("", "", accessories)]),
+        _tr([_tc("", cont), _tc("430"), _tc("310"), _tc("560"), _tc("Kit/(630 only)", "")]),
+    ]
+    grid = "".join(f'<w:gridCol w:w="{w}"/>' for w in (2000, 1000, 1000, 1000, 2000))
+    return ('<w:p><w:r><w:t>Specifications</w:t></w:r></w:p>'
+            f'<w:tbl><w:tblGrid>{grid}</w:tblGrid>{"".join(rows)}</w:tbl>')
+
+
+def _entries(table):
+    return [[(e.get("colname") or f"{e.get('namest')}-{e.get('nameend')}", e.get("morerows"),
+              " ".join(" ".join(e.itertext()).split())) for e in row]
+            for row in table.iter("row")]
+
+
+def test_word_spans_and_nested_tables_become_cals_spans(tmp_path, docx_factory):
+    path = docx_factory(tmp_path / "spec.docx", _equipment_spec())
+    (model,) = read_word_tables(path)
+    assert (model.rows, model.cols) == (4, 5) and model.spanned and model.nested
+    model_cell = next(c for c in model.cells if c.text == "P-100")
+    assert (model_cell.row, model_cell.rowspan) == (2, 2)
+
+    table = build_cals(model)
+    tgroup = table.find("tgroup")
+    # The nested table splits the Accessories column in two and P-100's rows in two
+    assert tgroup.get("cols") == "6"
+    assert [c.get("colwidth") for c in tgroup.findall("colspec")] == ["2*", "1*", "1*", "1*", "1*", "1*"]
+    assert len(tgroup.findall("thead/row")) == 2
+    assert _entries(table) == [
+        [("c1", "1", "Model"), ("c2-c4", None, "Dimensions (mm)"), ("c5-c6", "1", "Accessories")],
+        [("c2", None, "L"), ("c3", None, "W"), ("c4", None, "H")],
+        [("c1", "2", "P-100"), ("c2", "1", "420"), ("c3", "1", "310"), ("c4", "1", "550"),
+         ("c5", None, "Hose"), ("c6", None, "2 m")],
+        [("c5", None, "Filter"), ("c6", None, "F-7")],
+        [("c2", None, "430"), ("c3", None, "310"), ("c4", None, "560"), ("c5-c6", None, "Kit (630 only)")],
+    ]
+
+
+def test_converted_table_is_rebuilt_keeping_its_content(tmp_path, docx_factory):
+    path = docx_factory(tmp_path / "spec.docx", _equipment_spec())
+    # What a converter without span support emits: one entry per grid cell, nested table kept
+    nested = ("<table><tgroup cols='2'><tbody><row><entry>Hose</entry><entry>2 m</entry></row>"
+              "<row><entry>Filter</entry><entry>F-7</entry></row></tbody></tgroup></table>")
+    flat = ("<table id='spec' frame='all'><title>Pumps</title><tgroup cols='5'><tbody>"
+            "<row><entry>Model</entry><entry>Dimensions (mm)</entry><entry/><entry/><entry>Accessories</entry></row>"
+            "<row><entry/><entry>L</entry><entry>W</entry><entry>H</entry><entry/></row>"
+            f"<row><entry><b>P-100</b></entry><entry>420</entry><entry>310</entry><entry>550</entry>"
+            f"<entry>{nested}</entry></row>"
+            "<row><entry/><entry>430</entry><entry>310</entry><entry>560</entry>"
+            "<entry><p>Kit</p><p>(630 only)</p></entry></row></tbody></tgroup></table>")
+    topic = ET.fromstring(f"<concept id='c'><title>Specifications</title><conbody>{flat}</conbody></concept>")
+    ctx = DitaContext(ditamap_root=ET.fromstring("<map><topicref href='topics/c.dita'/></map>"),
+                      topics={"c.dita": topic})
+    assert restore_word_tables(path, ctx) == 1
+    table = topic.find("conbody/table")
+    assert table.get("id") == "spec" and table.get("frame") == "all" and table.find("title").text == "Pumps"
+    assert len(table.findall("tgroup")) == 1 and not table.findall(".//entry/table")
+    p100 = table.find("tgroup/tbody/row/entry")
+    assert p100.get("morerows") == "2" and p100.find("b").text == "P-100"
+    kit = table.findall("tgroup/tbody/row")[-1][-1]
+    assert [p.text for p in kit.findall("p")] == ["Kit", "(630 only)"]
+    assert any(e.category == "tables" and "1 table(s)" in e.message for e in ctx.report.entries)
+
+    assert restore_word_tables(path, ctx, {"enabled": False}) == 0
+
+
+def test_asciidoc_cell_spans_give_a_cals_table():
+    text = ("= Pumps\n\n== Specifications\n\n[cols=\"4\",options=\"header\"]\n|===\n"
+            "|Model 3+|Dimensions (mm)\n.2+|P-100 |420 |310 |550\n|430 |310 |560\n|===\n\n"
+            "|===\n|Part |Code\n|===\n")
+    blocks = AsciiDocParser().parse(text).sections[0].blocks
+    table, simple = blocks
+    assert table.tag == "table" and simple.tag == "simpletable"
+    assert _entries(table) == [
+        [("c1", None, "Model"), ("c2-c4", None, "Dimensions (mm)")],
+        [("c1", "1", "P-100"), ("c2", None, "420"), ("c3", None, "310"), ("c4", None, "550")],
+        [("c2", None, "430"), ("c3", None, "310"), ("c4", None, "560")],
+    ]
+    assert table.find("tgroup/thead/row/entry").text == "Model"
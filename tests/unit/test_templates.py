@@ -0,0 +1,75 @@
+from orlando_toolkit.core.models import ConversionReport
+from orlando_toolkit.core.templates import (
+    apply_template_profile,
+    detect_template,
+    match_template,
+    record_template_match,
+)
+
+W = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+R = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+
+
+def _parts(template="Operator Manual.dotx", styles=("OM Chapter", "OM Task")):
+    style_xml = "".join(f'<w:style w:customStyle="1" w:styleId="s{i}"><w:name w:val="{n}"/></w:style>'
+                        for i, n in enumerate(styles))
+    return {
+        "word/styles.xml": f'<w:styles xmlns:w="{W}"><w:style w:styleId="Normal">'
+                           f'<w:name w:val="Normal"/></w:style>{style_xml}</w:styles>',
+        "word/settings.xml": f'<w:settings xmlns:w="{W}" xmlns:r="{R}"><w:attachedTemplate r:id="rId1"/></w:settings>',
+        "word/_rels/settings.xml.rels": (
+            '<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">'
+            f'<Relationship Id="rId1" Type="t" Target="file:///C:/Templates/{template}" TargetMode="External"/>'
+            '</Relationships>'),
+    }
+
+
+PROFILES = {
+    "operator": {"templates": {"names": ["operator manual.dotx"]}, "style_map": {"OM Chapter": 1},
+                 "metadata": {"topic_depth": 2}},
+    "tasks": {"templates": {"styles": ["OM Task"]}},
+    "plain": {"description": "no templates"},
+}
+
+
+def test_detects_attached_template_and_styles_fingerprint(tmp_path, docx_factory):
+    info = detect_template(docx_factory(tmp_path / "a.docx", parts=_parts()))
+    assert info.names == ["Operator Manual.dotx"]
+    assert info.custom_styles == ("OM Chapter", "OM Task")
+    same_styles = detect_template(docx_factory(tmp_path / "b.docx", parts=_parts(template="Other.dotx")))
+    assert same_styles.fingerprint == info.fingerprint
+    other_styles = detect_template(docx_factory(tmp_path / "c.docx", parts=_parts(styles=("X",))))
+    assert other_styles.fingerprint != info.fingerprint
+    assert not detect_template(tmp_path / "missing.txt").known
+
+
+def test_best_profile_is_applied_and_explicit_settings_win(tmp_path, docx_factory):
+    source = docx_factory(tmp_path / "a.docx", parts=_parts())
+    metadata, match = apply_template_profile(source, {"topic_depth": 4}, PROFILES)
+    assert match.profile == "operator" and match.scores == {"operator": 2, "tasks": 1}
+    assert metadata["style_map"] == {"OM Chapter": 1}
+    assert metadata["topic_depth"] == 4
+    assert metadata["output_profiles"] == ["operator"]
+
+    report = ConversionReport()
+    record_template_match(report, match)
+    assert report.entries[0].detail["profile"] == "operator"
+
+    untouched, skipped = apply_template_profile(source, {"template_profile": "none"}, PROFILES)
+    assert untouched == {} and skipped is None
+
+
+def test_ties_are_ambiguous_and_honour_a_choice(tmp_path, docx_factory):
+    source = docx_factory(tmp_path / "a.docx", parts=_parts(template="Unknown.dotx"))
+    tied = dict(PROFILES, chapters={"templates": {"styles": ["OM Chapter"]}})
+    match = match_template(detect_template(source), tied)
+    assert match.ambiguous and match.candidates == ("chapters", "tasks")
+
+    report = ConversionReport()
+    metadata, match = apply_template_profile(source, {}, tied)
+    record_template_match(report, match)
+    assert "output_profiles" not in metadata
+    assert report.entries[0].severity == "warning"
+
+    metadata, match = apply_template_profile(source, {"template_profile": "tasks"}, tied)
+    assert metadata["output_profiles"] == ["tasks"] and match.profile == "tasks"
@@ -0,0 +1,76 @@
+import pytest
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.services.structure_editing_service import StructureEditingService
+from orlando_toolkit.core.services.undo_service import UndoService
+from orlando_toolkit.core.terminology import TermRule, TermSettings, check_terminology, read_term_list, replace_term
+from orlando_toolkit.ui.controllers.structure_controller import StructureController
+
+TBX = ("<martif type='TBX' xml:lang='en'><text><body>"
+       "<termEntry><descrip type='definition'>Powered fixed-wing vehicle</descrip>"
+       "<langSet xml:lang='en'><tig><term>airplane</term>"
+       "<termNote type='administrativeStatus'>deprecatedTerm-admn-sts</termNote></tig>"
+       "<tig><term>aircraft</term><termNote type='administrativeStatus'>preferredTerm-admn-sts</termNote></tig>"
+       "</langSet><langSet xml:lang='fr'><tig><term>aéroplane</term>"
+       "<termNote type='administrativeStatus'>deprecatedTerm-admn-sts</termNote></tig>"
+       "<tig><term>avion</term></tig></langSet></termEntry>"
+       "<termEntry><langSet xml:lang='en'><tig><term>log in</term></tig>"
+       "<tig><term>login</term><termNote type='normativeAuthorization'>supersededTerm</termNote></tig>"
+       "</langSet></termEntry></body></text></martif>")
+
+
+def _context():
+    topics = {
+        "a.dita": "<concept id='a'><title>Airplane checks</title><conbody><p>Park the airplane. "
+                  "<b>AIRPLANE</b> doors stay shut; airplanes are counted apart.</p>"
+                  "<p>Use the login screen to log  in.</p></conbody></concept>",
+        "b.dita": "<concept id='b'><title>Hangar</title><conbody><p>Tow the airplane inside.</p>"
+                  "</conbody></concept>",
+    }
+    root = ET.fromstring("<map><topicref href='topics/b.dita'><topicmeta><navtitle>Hangar</navtitle></topicmeta>"
+                         "</topicref><topicref href='topics/a.dita'><topicmeta><navtitle>Airplane checks</navtitle>"
+                         "</topicmeta></topicref></map>")
+    return DitaContext(ditamap_root=root, topics={k: ET.fromstring(v) for k, v in topics.items()}, metadata={})
+
+
+def test_term_lists_are_read_from_csv_and_tbx(tmp_path):
+    (tmp_path / "terms.csv").write_text("banned,preferred,note\nAirplane,aircraft,Style guide 4.2\n"
+                                        "# comment\nwhilst,while\n", encoding="utf-8")
+    (tmp_path / "terms.tbx").write_text(TBX, encoding="utf-8")
+    rules = read_term_list(tmp_path / "terms.csv")
+    assert [(r.banned, r.preferred, r.note) for r in rules] == [("Airplane", "aircraft", "Style guide 4.2"),
+                                                               ("whilst", "while", "")]
+    tbx = read_term_list(tmp_path / "terms.tbx", language="en")
+    assert [(r.banned, r.preferred, r.note) for r in tbx] == [
+        ("airplane", "aircraft", "Powered fixed-wing vehicle"), ("login", "log in", "")]
+    assert [r.banned for r in read_term_list(tmp_path / "terms.tbx")] == ["airplane", "aéroplane", "login"]
+    with pytest.raises(ValueError):
+        read_term_list(tmp_path / "terms.docx")
+
+    settings = TermSettings.from_mapping({"terms": {"airplane": "aeroplane"}, "language": "en",
+                                          "term_files": [str(tmp_path / "terms.tbx"), str(tmp_path / "gone.csv")]})
+    assert [(r.banned, r.preferred) for r in settings.rules()] == [("airplane", "aeroplane"), ("login", "log in")]
+
+
+def test_violations_per_topic_and_replacement_keeps_case():
+    ctx = _context()
+    rules = [TermRule("airplane", "aircraft", "Style guide"), TermRule("log in", ""), TermRule("whilst", "while")]
+    violations = check_terminology(ctx, rules)
+    assert [(v.topic, v.rule.banned, v.count) for v in violations] == [
+        ("b.dita", "airplane", 1), ("a.dita", "airplane", 3), ("a.dita", "log in", 1)]
+    assert violations[0].snippets == ["Tow the airplane inside."]
+    with pytest.raises(ValueError):
+        replace_term(ctx, violations[2].rule)
+
+    ctrl = StructureController(ctx, StructureEditingService(), UndoService(), None)
+    ctrl.start_history()
+    result = ctrl.handle_term_replace(rules[0], ["a.dita"])
+    assert result.success and result.details["replaced"] == {"a.dita": 3}
+    topic = ctrl.context.topics["a.dita"]
+    assert topic.findtext("title") == "Aircraft checks"
+    assert "".join(topic.find("conbody/p").itertext()) == ("Park the aircraft. AIRCRAFT doors stay shut; "
+                                                           "airplanes are counted apart.")
+    assert ctrl.context.ditamap_root.findtext("topicref[2]/topicmeta/navtitle") == "Aircraft checks"
+    assert [v.topic for v in ctrl.get_term_violations(rules[:1])] == ["b.dita"]
+    assert ctrl.undo_label() == "Replace “airplane” with “aircraft”"
@@ -0,0 +1,78 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.text_boxes import read_word_text_boxes, restore_word_text_boxes
+
+W = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+NS = ('xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" '
+      'xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing" '
+      'xmlns:wps="http://schemas.microsoft.com/office/word/2010/wordprocessingShape" '
+      'xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" '
+      'xmlns:adec="http://schemas.microsoft.com/office/drawing/2017/decorative" '
+      'xmlns:mc="http://schemas.openxmlformats.org/markup-compatibility/2006" '
+      'xmlns:v="urn:schemas-microsoft-com:vml"')
+
+
+def _box(paragraphs, title="", decorative=False):
+    ext = ('<a:extLst><a:ext><adec:decorative val="1"/></a:ext></a:extLst>' if decorative else "")
+    content = f"<w:txbxContent>{paragraphs}</w:txbxContent>"
+    return ('<w:r><mc:AlternateContent><mc:Choice Requires="wps"><w:drawing><wp:anchor>'
+            f'<wp:docPr id="1" name="Text Box 1" title="{title}">{ext}</wp:docPr><a:graphic><a:graphicData>'
+            f'<wps:wsp><wps:txbx>{content}</wps:txbx></wps:wsp></a:graphicData></a:graphic></wp:anchor>'
+            f'</w:drawing></mc:Choice><mc:Fallback><w:pict><v:shape><v:textbox>{content}</v:textbox>'
+            '</v:shape></w:pict></mc:Fallback></mc:AlternateContent></w:r>')
+
+
+CALLOUT = ('<w:p><w:pPr><w:pStyle w:val="CorpTip"/></w:pPr><w:r><w:rPr><w:b/></w:rPr><w:t>Tip:</w:t></w:r>'
+           '<w:r><w:t xml:space="preserve"> keep the cover closed.</w:t></w:r></w:p>')
+BODY = (f'<w:p><w:r><w:t>Open the panel.</w:t></w:r>{_box(CALLOUT, title="Tip")}</w:p>'
+        '<w:p><w:r><w:t>Remove the filter.</w:t></w:r></w:p>'
+        f'<w:p>{_box("<w:p><w:r><w:t>Ornament</w:t></w:r></w:p>", decorative=True)}</w:p>'
+        '<w:p><w:pPr><w:framePr w:wrap="around"/></w:pPr><w:r><w:t>Model X200</w:t></w:r></w:p>'
+        '<w:p><w:pPr><w:framePr w:wrap="around"/></w:pPr><w:r><w:t>Issue 3</w:t></w:r></w:p>'
+        '<w:p><w:r><w:t>Clean the housing.</w:t></w:r></w:p>')
+STYLES = f'<w:styles xmlns:w="{W}"><w:style w:styleId="CorpTip"><w:name w:val="Corp Tip"/></w:style></w:styles>'
+PARTS = {"word/styles.xml": STYLES}
+
+
+def _context():
+    topic = ET.fromstring("<concept id='c'><title>C</title><conbody><p>Open the panel.</p><p>Remove the filter.</p>"
+                          "<p>Model X200</p><p>Issue 3</p><p>Clean the housing.</p></conbody></concept>")
+    return DitaContext(ditamap_root=ET.fromstring("<map><topicref href='topics/c.dita'/></map>"),
+                       topics={"c.dita": topic})
+
+
+def test_text_boxes_become_notes_and_frames_are_wrapped(tmp_path, docx_factory):
+    source = docx_factory(tmp_path / "manual.docx", BODY, namespaces=NS, parts=PARTS)
+    boxes = read_word_text_boxes(source)
+    assert [(b.kind, b.anchor, b.decorative) for b in boxes] == [
+        ("text_box", "Open the panel.", False), ("text_box", "Remove the filter.", True),
+        ("frame", "Remove the filter.", False)]
+    assert boxes[0].paragraphs == [("Corp Tip", [("Tip:", "b"), (" keep the cover closed.", "")])]
+
+    ctx = _context()
+    assert restore_word_text_boxes(source, ctx, {"note_type": "tip"}) == 2
+    body = ctx.topics["c.dita"].find("conbody")
+    assert [el.tag for el in body] == ["p", "note", "p", "note", "p"]
+    callout, frame = body.findall("note")
+    assert (callout.get("outputclass"), callout.get("type")) == ("text-box", "tip")
+    p = callout.find("p")
+    assert p.get("data-style") == "Corp Tip" and p.findtext("b") == "Tip:"
+    assert p.find("b").tail == " keep the cover closed."
+    assert frame.get("outputclass") == "frame" and [e.text for e in frame] == ["Model X200", "Issue 3"]
+    entry = next(e for e in ctx.report.entries if e.category == "text_boxes")
+    assert entry.detail == {"text_boxes": 1, "frames": 1, "wrapped": 1, "decorative": 1}
+
+
+def test_figures_placeholders_and_decorative_boxes_on_request(tmp_path, docx_factory):
+    source = docx_factory(tmp_path / "manual.docx", BODY, namespaces=NS, parts=PARTS)
+    ctx = _context()
+    body = ctx.topics["c.dita"].find("conbody")
+    body.insert(2, ET.fromstring("<p><ph data-text-box='2'/></p>"))
+    assert restore_word_text_boxes(source, ctx, {"element": "fig", "ignore_decorative": False}) == 3
+    figs = body.findall("fig")
+    assert [f.findtext("title") for f in figs] == ["Tip", None, None]
+    assert body.index(figs[1]) == 3 and figs[1].findtext("p") == "Ornament"
+    assert body.find(".//ph") is None
+
+    assert restore_word_text_boxes(source, _context(), {"enabled": False}) == 0
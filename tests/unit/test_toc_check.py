@@ -0,0 +1,60 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.toc_check import TocEntry, check_toc, compare_toc, read_word_toc
+
+W = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+
+
+def _entry(style, title, page, anchor):
+    return (f'<w:p><w:pPr><w:pStyle w:val="{style}"/></w:pPr><w:hyperlink w:anchor="{anchor}">'
+            f'<w:r><w:t>{title}</w:t></w:r><w:r><w:tab/></w:r>'
+            f'<w:r><w:fldChar w:fldCharType="begin"/></w:r><w:r><w:instrText> PAGEREF {anchor} \\h </w:instrText></w:r>'
+            f'<w:r><w:fldChar w:fldCharType="separate"/></w:r><w:r><w:t>{page}</w:t></w:r>'
+            f'<w:r><w:fldChar w:fldCharType="end"/></w:r></w:hyperlink></w:p>')
+
+
+BODY = (
+    '<w:p><w:r><w:fldChar w:fldCharType="begin"/></w:r><w:r><w:instrText> TOC \\o </w:instrText></w:r>'
+    '<w:r><w:instrText>"1-2" \\h \\z \\u </w:instrText></w:r><w:r><w:fldChar w:fldCharType="separate"/></w:r></w:p>'
+    + _entry("TOC1", "1 Introduction", 3, "_Toc1")
+    + _entry("TOC2", "1.1 Scope", 3, "_Toc2")
+    + _entry("TOC2", "Safety notes", 4, "_Toc3")
+    + _entry("TOC1", "Maintenance", 7, "_Toc4")
+    + '<w:p><w:r><w:fldChar w:fldCharType="end"/></w:r></w:p>'
+    '<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Introduction</w:t></w:r></w:p>'
+)
+
+
+def _context():
+    ditamap = ET.fromstring(
+        "<map><topicref href='topics/intro.dita'>"
+        "<topicref href='topics/scope.dita'/><topicref href='topics/details.dita'><topicref href='topics/deep.dita'/>"
+        "</topicref></topicref><topicref href='topics/maint.dita'><topicmeta><navtitle>MAINTENANCE</navtitle>"
+        "</topicmeta></topicref></map>")
+    titles = {"intro": "Introduction", "scope": "Scope", "details": "Details", "deep": "Deep"}
+    topics = {f"{k}.dita": ET.fromstring(f"<concept id='{k}'><title>{v}</title></concept>") for k, v in titles.items()}
+    return DitaContext(ditamap_root=ditamap, topics=topics)
+
+
+def test_reads_toc_entries_levels_and_switches(tmp_path, docx_factory):
+    entries, levels = read_word_toc(docx_factory(tmp_path / "doc.docx", BODY))
+    assert levels == (1, 2)
+    assert [(e.level, e.title, e.anchor) for e in entries] == [
+        (1, "1 Introduction", "_Toc1"), (2, "1.1 Scope", "_Toc2"), (2, "Safety notes", "_Toc3"), (1, "Maintenance", "_Toc4"),
+    ]
+    assert read_word_toc(tmp_path / "nothing.txt") == ([], (1, 9))
+
+
+def test_differences_are_reported_within_the_toc_levels(tmp_path, docx_factory):
+    ctx = _context()
+    result = check_toc(docx_factory(tmp_path / "doc.docx", BODY), ctx)
+    assert [e.title for e in result.missing_in_map] == ["Safety notes"]
+    assert [e.title for e in result.missing_in_toc] == ["Details"]  # "Deep" is below the TOC's levels
+    assert result.level_mismatches == []
+    warnings = [e.message for e in ctx.report.entries if e.severity == "warning"]
+    assert len(warnings) == 2 and "Safety notes" in warnings[0]
+
+    mismatch = compare_toc([TocEntry(1, "Scope")], [TocEntry(2, "Scope")])
+    assert mismatch.level_mismatches[0][1] == 2
+    assert check_toc(tmp_path / "doc.docx", ctx, {"enabled": False}) is None
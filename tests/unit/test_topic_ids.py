@@ -0,0 +1,136 @@
+import pytest
+from lxml import etree as ET
+
+from orlando_toolkit.core.id_strategies import ID_STRATEGY_SERVICE
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.package_utils import deduplicate_element_ids, update_topic_references_and_names
+from orlando_toolkit.core.plugins.registry import ServiceRegistry
+
+
+def _topic(topic_id: str, title: str, body: str = "") -> ET._Element:
+    return ET.fromstring(f"<concept id='{topic_id}'><title>{title}</title><conbody>{body}</conbody></concept>")
+
+
+def _context() -> DitaContext:
+    ditamap = ET.fromstring(
+        "<map>"
+        "<topichead><topicmeta><navtitle>Install</navtitle></topicmeta>"
+        "<topicref href='topics/a.dita'/></topichead>"
+        "<topichead><topicmeta><navtitle>Upgrade</navtitle></topicmeta>"
+        "<topicref href='topics/b.dita'/></topichead>"
+        "<topicref href='topics/c.dita'/>"
+        "</map>"
+    )
+    topics = {
+        "a.dita": _topic("ta", "Overview", "<section id='s1'><title>Steps</title></section>"),
+        "b.dita": _topic("tb", "Overview", "<p id='p1'>See <xref href='#tb/p1'/></p>"),
+        "c.dita": _topic(
+            "tc", "Links",
+            "<p><xref href='a.dita#ta/s1'/> <xref href='b.dita'/> <ph conref='b.dita#tb/p1'/>"
+            " <xref href='https://example.com/a.dita' scope='external'/></p>",
+        ),
+    }
+    return DitaContext(ditamap_root=ditamap, topics=topics)
+
+
+def _assert_links_resolve(ctx: DitaContext) -> None:
+    for filename, topic in ctx.topics.items():
+        for el in topic.iter():
+            for attr in ("href", "conref"):
+                value = el.get(attr)
+                if not value or el.get("scope") == "external":
+                    continue
+                path, _, fragment = value.partition("#")
+                target = ctx.topics[path or filename]
+                if fragment:
+                    topic_id, _, element_id = fragment.partition("/")
+                    assert topic_id == target.get("id")
+                    assert not element_id or any(e.get("id") == element_id for e in target.iter())
+
+
+@pytest.mark.parametrize("strategy", ["suffix", "path", "hash"])
+def test_duplicate_headings_get_unique_names_and_links_follow(strategy):
+    ctx = update_topic_references_and_names(_context(), strategy=strategy, include_section_number=False)
+    names = sorted(ctx.topics)
+    assert len(set(names)) == 3 and "topic_overview.dita" in names
+    hrefs = [t.get("href") for t in ctx.ditamap_root.iter("topicref")]
+    assert sorted(h.split("/")[-1] for h in hrefs) == names
+    _assert_links_resolve(ctx)
+    external = [e for e in ctx.topics["topic_links.dita"].iter("xref") if e.get("scope") == "external"]
+    assert external[0].get("href") == "https://example.com/a.dita"
+
+
+def test_strategy_shapes_the_second_name():
+    def second(strategy):
+        ctx = update_topic_references_and_names(_context(), strategy=strategy, include_section_number=False)
+        return [n for n in ctx.topics if n not in ("topic_overview.dita", "topic_links.dita")][0]
+
+    assert second("suffix") == "topic_overview-2.dita"
+    assert second("path") == "topic_upgrade_overview.dita"
+    assert second("hash").startswith("topic_overview-") and second("hash") == second("hash")
+
+
+def test_duplicate_element_ids_keep_first_occurrence():
+    topic = _topic("t", "T", "<p id='x'/><section id='s'><p id='x'/></section><p id='x'/>")
+    assert deduplicate_element_ids(topic, "path") == 2
+    assert [e.get("id") for e in topic.iter("p")] == ["x", "s_x", "x-2"]
+
+
+def test_stable_names_survive_inserted_sections():
+    first = update_topic_references_and_names(_context(), stable=True)
+    second_ctx = _context()
+    second_ctx.ditamap_root.insert(0, ET.fromstring("<topicref href='topics/new.dita'/>"))
+    second_ctx.topics["new.dita"] = _topic("tn", "Preface")
+    second = update_topic_references_and_names(second_ctx, stable=True)
+    assert set(first.topics) < set(second.topics)
+    assert all(name.startswith("topic_") and name.count("-") == 1 for name in second.topics)
+
+
+def test_previous_map_keeps_names_of_moved_topics(tmp_path):
+    first = update_topic_references_and_names(_context())
+    (tmp_path / "topics").mkdir()
+    (tmp_path / "manual.ditamap").write_bytes(ET.tostring(first.ditamap_root))
+    for name, topic in first.topics.items():
+        (tmp_path / "topics" / name).write_bytes(ET.tostring(topic))
+
+    moved = _context()
+    links = moved.ditamap_root[2]
+    moved.ditamap_root.remove(links)
+    moved.ditamap_root.insert(0, links)  # "Links" moves to the front; its content is unchanged
+    moved.metadata["conversion_options"] = {"ids": {"previous_map": str(tmp_path)}}
+    again = update_topic_references_and_names(moved)
+    assert set(again.topics) == set(first.topics)
+    _assert_links_resolve(again)
+
+
+def test_naming_strategies_name_topics_and_links_follow():
+    ctx = _context()
+    ctx.metadata.update(manual_code="OM 12", conversion_options={"ids": {"naming": "sequential",
+                                                                          "pattern": "TP-{manual_code}-{seq:04}"}})
+    update_topic_references_and_names(ctx)
+    assert sorted(ctx.topics) == ["TP-OM_12-0001.dita", "TP-OM_12-0002.dita", "TP-OM_12-0003.dita"]
+    assert ctx.topics["TP-OM_12-0003.dita"].findtext("title") == "Links"
+    _assert_links_resolve(ctx)
+
+    def guids():
+        return sorted(update_topic_references_and_names(_context(), naming="guid", stable=True).topics)
+
+    assert guids() == guids() and all(name.startswith("GUID-") for name in guids())
+    hashed = update_topic_references_and_names(_context(), naming="hash", strategy="suffix")
+    assert len(hashed.topics) == 3 and all(name.startswith("topic_") for name in hashed.topics)
+
+
+def test_plugin_strategy_is_used_and_unknown_names_fall_back_to_slugs():
+    class Numbered:
+        def topic_name(self, info):
+            return f"{info.seq}/{info.slug}"
+
+    registry = ServiceRegistry()
+    registry.register_service(ID_STRATEGY_SERVICE, Numbered(), "ccms")
+    ctx = update_topic_references_and_names(_context(), naming="ccms", registry=registry)
+    assert sorted(ctx.topics) == ["topic_1_overview.dita", "topic_2_overview.dita", "topic_3_links.dita"]
+
+    ctx = update_topic_references_and_names(_context(), naming="nope", include_section_number=False,
+                                            registry=registry)
+    assert "topic_overview.dita" in ctx.topics
+    assert [e.detail["naming"] for e in ctx.report.entries if e.category == "ids"] == ["nope"]
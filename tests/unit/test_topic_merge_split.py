@@ -0,0 +1,63 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.merge import split_points
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.services.structure_editing_service import StructureEditingService
+
+
+def _context():
+    topics = {
+        "a.dita": "<concept id='a'><title>A</title><conbody><p id='pa'>Intro</p>"
+                  "<xref href='b.dita#b/pb'/><xref href='c.dita'/></conbody></concept>",
+        "b.dita": "<concept id='b'><title>Setup</title><conbody><p id='pb'>Setup text</p>"
+                  "<p outputclass='merged-title' id='mt'><b><u>WIRING</u></b></p><p id='pw'>Wiring text</p>"
+                  "<p><xref href='#b/pb'/></p>"
+                  "<section id='s1'><title>Checks</title><p id='pc'>Check text</p></section></conbody></concept>",
+        "c.dita": "<concept id='c'><title>Cleanup</title><conbody><p id='pd'>Done</p></conbody></concept>",
+    }
+    root = ET.fromstring("<map><topicref href='topics/a.dita' data-level='1'>"
+                         "<topicref href='topics/b.dita' data-level='2'>"
+                         "<topicref href='topics/c.dita' data-level='3'/></topicref></topicref></map>")
+    return DitaContext(ditamap_root=root, topics={k: ET.fromstring(v) for k, v in topics.items()})
+
+
+def _hrefs(node):
+    return [t.get("href").rsplit("/", 1)[-1] for t in node.findall("topicref")]
+
+
+def test_merge_into_parent_keeps_children_and_links():
+    ctx, service = _context(), StructureEditingService()
+    assert service.restructure_options(ctx, "topics/b.dita")["previous"] is False
+    assert not service.merge_topic_with_neighbor(ctx, "topics/b.dita", "previous").success
+    assert "b.dita" in ctx.topics
+
+    res = service.merge_topic_with_neighbor(ctx, "topics/b.dita", "parent")
+    assert res.success and "b.dita" not in ctx.topics
+    a_ref = ctx.ditamap_root.find("topicref")
+    assert _hrefs(a_ref) == ["c.dita"] and a_ref.find("topicref").get("data-level") == "2"
+    body = ctx.topics["a.dita"].find("conbody")
+    copied = next(p for p in body.findall("p") if p.text == "Setup text")
+    assert body.find("xref").get("href") == f"a.dita#a/{copied.get('id')}"
+    # Same-topic links written in the merged topic follow the copies
+    assert body.find("p/xref").get("href") == f"#a/{copied.get('id')}"
+
+
+def test_split_at_heading_creates_sibling_taking_the_children():
+    ctx, service = _context(), StructureEditingService()
+    assert split_points(ctx.topics["b.dita"]) == ["Wiring", "Checks"]
+    assert not service.split_topic(ctx, "topics/b.dita", 5).success
+
+    res = service.split_topic(ctx, "topics/b.dita", 0)
+    assert res.success
+    new = res.details["created"]
+    a_ref = ctx.ditamap_root.find("topicref")
+    assert _hrefs(a_ref) == ["b.dita", new]
+    assert _hrefs(a_ref.findall("topicref")[0]) == [] and _hrefs(a_ref.findall("topicref")[1]) == ["c.dita"]
+    assert a_ref.findall("topicref")[1].get("data-level") == "2"
+
+    topic = ctx.topics[new]
+    assert topic.findtext("title") == "Wiring" and topic.find("conbody/p").get("id") == "pw"
+    assert [p.get("id") for p in ctx.topics["b.dita"].findall("conbody/p")] == ["pb"]
+    # The moved same-topic link now points back at the split topic
+    assert topic.find(".//xref").get("href") == "b.dita#b/pb"
+    assert split_points(topic) == ["Checks"]
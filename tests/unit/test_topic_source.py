@@ -0,0 +1,59 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.services.conversion_service import ConversionService
+from orlando_toolkit.core.services.structure_editing_service import StructureEditingService
+from orlando_toolkit.core.services.undo_service import UndoService
+from orlando_toolkit.core.topic_source import highlight_spans, pretty_print
+from orlando_toolkit.ui.controllers.structure_controller import StructureController
+
+
+def _controller():
+    topics = {"wiring.dita": ET.fromstring("<concept id='wiring'><title>Wiring</title>"
+                                           "<conbody><p>Old text</p></conbody></concept>")}
+    root = ET.fromstring("<map><topicref href='topics/wiring.dita' type='concept'>"
+                         "<topicmeta><navtitle>Wiring</navtitle></topicmeta></topicref></map>")
+    ctx = DitaContext(ditamap_root=root, topics=topics, metadata={"manual_title": "Manual"})
+    ctrl = StructureController(ctx, StructureEditingService(), UndoService(), None)
+    ctrl.start_history()
+    return ctrl
+
+
+def test_saved_xml_edits_are_undoable_and_reach_the_package():
+    ctrl = _controller()
+    source = ctrl.get_topic_source("topics/wiring.dita")
+    assert "\n  <conbody>" in source
+    edited = source.replace("Wiring</title>", "Wiring diagram</title>").replace("Old text", "New <b>bold</b> text")
+
+    assert ctrl.check_topic_source("topics/wiring.dita", edited).success
+    result = ctrl.handle_edit_topic_source("topics/wiring.dita", edited)
+    assert result.success and [e["label"] for e in ctrl.get_journal()] == ["Edit XML of Wiring"]
+    assert ctrl.context.ditamap_root.findtext(".//navtitle") == "Wiring diagram"
+    package = ConversionService().prepare_package(ctrl.context)
+    topic = next(iter(package.topics.values()))
+    paragraph = topic.find("conbody/p")
+    assert "".join(paragraph.itertext()) == "New bold text" and paragraph.findtext("b") == "bold"
+
+    assert ctrl.undo()
+    assert ctrl.context.topics["wiring.dita"].findtext("conbody/p") == "Old text"
+    assert ctrl.context.ditamap_root.findtext(".//navtitle") == "Wiring"
+
+
+def test_malformed_or_invalid_xml_is_refused_unless_forced():
+    ctrl = _controller()
+    broken = ctrl.handle_edit_topic_source("topics/wiring.dita", "<concept id='wiring'>\n<title>W</concept>")
+    assert not broken.success and broken.details["reason"] == "parse_error" and broken.details["line"] == 2
+    invalid = "<task><title>Wiring</title><taskbody/></task>"
+    refused = ctrl.handle_edit_topic_source("topics/wiring.dita", invalid)
+    assert not refused.success and refused.details["reason"] == "invalid"
+    assert ctrl.context.topics["wiring.dita"].tag == "concept" and not ctrl.can_undo()
+
+    assert ctrl.handle_edit_topic_source("topics/wiring.dita", invalid, force=True).success
+    assert ctrl.context.ditamap_root.find("topicref").get("type") == "task"
+
+    pretty = pretty_print("<concept id='c'><title>T</title><conbody><p>A <b>b</b><i>c</i></p></conbody></concept>")
+    assert "\n  <conbody>\n    <p>A <b>b</b><i>c</i></p>\n  </conbody>" in pretty
+    text = pretty.replace("<title>", "<!-- n --><title>")
+    kinds = {text[start:end]: kind for kind, start, end in highlight_spans(text)}
+    assert kinds["<concept"] == "tag" and kinds["id"] == "attribute" and kinds['"c"'] == "value"
+    assert kinds["<!-- n -->"] == "comment" and kinds["</conbody"] == "tag"
@@ -0,0 +1,73 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.topic_templates import TopicTemplates, apply_topic_templates
+
+DEFAULT = ("<prolog><author>{author}</author><copyright><copyryear year='{year}'/>"
+           "<copyrholder>{copyright_holder}</copyrholder></copyright>"
+           "<critdates><created date='{date}'/><revised modified='{revision_date}'/></critdates>"
+           "<metadata><othermeta name='manualCode' content='{manual_code}'/>"
+           "<othermeta name='topic' content='{topic_type}:{topic_id}'/></metadata></prolog>")
+
+
+def _context(metadata):
+    topics = {
+        "intro.dita": "<concept id='intro'><title>Intro</title><shortdesc>About.</shortdesc><conbody/></concept>",
+        "fill.dita": "<task id='fill'><title>Fill the tank</title><prolog><author>J. Doe</author><metadata>"
+                     "<keywords><indexterm>tank</indexterm></keywords><othermeta name='cms' content='keep'/>"
+                     "</metadata></prolog><taskbody/></task>",
+    }
+    return DitaContext(ditamap_root=ET.fromstring("<map/>"), topics={k: ET.fromstring(v) for k, v in topics.items()},
+                       metadata=metadata)
+
+
+def test_templates_fill_the_prolog_of_each_topic_type():
+    settings = TopicTemplates.from_mapping({"enabled": True, "templates": {
+        "default": DEFAULT,
+        "task": "<prolog><author>{author}</author><metadata><othermeta name='cms' content='{manual_code}'/>"
+                "<othermeta name='title' content='{topic_title}'/></metadata></prolog>"}})
+    ctx = _context({"book_author": "A. Writer", "manual_code": "PM-1", "revision_date": "2024-03-01",
+                    "book_publisher": "Orlando Inc."})
+    assert apply_topic_templates(ctx, settings) == 2
+
+    intro = ctx.topics["intro.dita"]
+    assert [child.tag for child in intro] == ["title", "shortdesc", "prolog", "conbody"]
+    prolog = intro.find("prolog")
+    assert [child.tag for child in prolog] == ["author", "copyright", "critdates", "metadata"]
+    assert prolog.findtext("author") == "A. Writer" and prolog.find("copyright/copyryear").get("year") == "2024"
+    assert prolog.findtext("copyright/copyrholder") == "Orlando Inc."
+    assert prolog.find("critdates/revised").get("modified") == "2024-03-01"
+    assert [(m.get("name"), m.get("content")) for m in prolog.iter("othermeta")] == [
+        ("manualCode", "PM-1"), ("topic", "concept:intro")]
+
+    task = ctx.topics["fill.dita"].find("prolog")
+    assert [a.text for a in task.iter("author")] == ["J. Doe"]          # existing elements are kept
+    assert [child.tag for child in task.find("metadata")] == ["keywords", "othermeta", "othermeta"]
+    assert [(m.get("name"), m.get("content")) for m in task.iter("othermeta")] == [
+        ("cms", "keep"), ("title", "Fill the tank")]
+
+    ctx = _context({"manual_code": "PM-1"})
+    assert apply_topic_templates(ctx, settings) == 2
+    prolog = ctx.topics["intro.dita"].find("prolog")
+    assert [child.tag for child in prolog] == ["critdates", "metadata"] and prolog.find("critdates/revised") is None
+    warning = next(e for e in ctx.report.entries if e.category == "topic_templates" and e.severity == "warning")
+    assert warning.detail["placeholders"] == ["author", "copyright_holder", "revision_date"]
+
+
+def test_template_files_overwrite_and_invalid_templates(tmp_path, monkeypatch):
+    import orlando_toolkit.config.manager as manager
+
+    monkeypatch.setattr(manager, "_get_user_config_dir", lambda: tmp_path)
+    (tmp_path / "templates").mkdir()
+    (tmp_path / "templates" / "task.xml").write_text(
+        "<prolog><metadata><othermeta name='cms' content='{manual_code}'/></metadata></prolog>", encoding="utf-8")
+    settings = TopicTemplates.from_mapping({"enabled": True, "overwrite": True, "templates": {
+        "task": "templates/task.xml", "concept": "<topicmeta/>", "reference": "missing.xml", "default": "<prolog"}})
+    assert list(settings.templates) == ["task"]
+    ctx = _context({"manual_code": "PM-2"})
+    assert apply_topic_templates(ctx, settings) == 1
+    assert ctx.topics["intro.dita"].find("prolog") is None
+    assert [m.get("content") for m in ctx.topics["fill.dita"].iter("othermeta")] == ["PM-2"]
+
+    assert apply_topic_templates(ctx, TopicTemplates(enabled=False, templates=settings.templates)) == 0
+    assert TopicTemplates.from_mapping({"enabled": True, "templates": ["task.xml"]}).templates == {}
@@ -0,0 +1,111 @@
+import types
+import zipfile
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.bookmap import to_bookmap
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.preview.xml_compiler import _highlight_revisions
+from orlando_toolkit.core.track_changes import record_tracked_changes, resolve_tracked_changes
+from orlando_toolkit.ui.tabs.structure.preview_coordinator import PreviewCoordinator
+
+W = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+
+BODY = (
+    '<w:p><w:r><w:t xml:space="preserve">Torque to </w:t></w:r>'
+    '<w:del w:id="1" w:author="A" w:date="2026-03-02T10:00:00Z"><w:r><w:delText>12</w:delText></w:r></w:del>'
+    '<w:ins w:id="2" w:author="A" w:date="2026-03-04T09:00:00Z"><w:r><w:t>15</w:t></w:r></w:ins>'
+    '<w:r><w:rPr><w:b/><w:rPrChange w:id="3" w:author="A"><w:rPr><w:i/></w:rPr></w:rPrChange></w:rPr>'
+    '<w:t xml:space="preserve"> Nm.</w:t></w:r></w:p>'
+    '<w:p><w:r><w:t>Unchanged.</w:t></w:r></w:p>'
+    '<w:p><w:pPr><w:rPr><w:ins w:id="4" w:author="A"/></w:rPr></w:pPr>'
+    '<w:ins w:id="5" w:author="A" w:date="2026-03-05T00:00:00Z"><w:r><w:t>Added</w:t></w:r></w:ins></w:p>'
+    '<w:p><w:r><w:t>Last.</w:t></w:r></w:p>'
+)
+STYLES = {"word/styles.xml": f'<w:styles xmlns:w="{W}"/>'}
+
+
+def _paragraphs(path):
+    with zipfile.ZipFile(path) as zf:
+        root = ET.fromstring(zf.read("word/document.xml"))
+    return ["".join(t.text or "" for t in p.iter(f"{{{W}}}t")) for p in root.iter(f"{{{W}}}p")], root
+
+
+def test_accept_and_reject_resolve_every_revision(tmp_path, docx_factory):
+    source = docx_factory(tmp_path / "spec.docx", BODY, parts=STYLES)
+    accepted = resolve_tracked_changes(source, {"mode": "accept"}, tmp_path / "a")
+    assert accepted.path != source and (accepted.insertions, accepted.deletions, accepted.formatting) == (2, 1, 1)
+    texts, root = _paragraphs(accepted.path)
+    assert texts == ["Torque to 15 Nm.", "Unchanged.", "Added", "Last."]
+    assert root.find(f".//{{{W}}}b") is not None and root.find(f".//{{{W}}}rPrChange") is None
+    with zipfile.ZipFile(accepted.path) as zf:
+        assert "word/styles.xml" in zf.namelist()
+
+    rejected = resolve_tracked_changes(source, {"mode": "reject"}, tmp_path / "r")
+    texts, root = _paragraphs(rejected.path)
+    assert texts == ["Torque to 12 Nm.", "Unchanged.", "Last."]
+    assert root.find(f".//{{{W}}}i") is not None and root.find(f".//{{{W}}}b") is None
+    assert root.find(f".//{{{W}}}delText") is None
+
+    plain = docx_factory(tmp_path / "plain.docx", "<w:p/>")
+    assert resolve_tracked_changes(plain, {}, tmp_path / "p").path == plain
+    assert resolve_tracked_changes(source, {"enabled": False}, tmp_path / "d").path == source
+
+
+def test_rev_mode_marks_changed_blocks(tmp_path, docx_factory):
+    source = docx_factory(tmp_path / "spec.docx", BODY, parts=STYLES)
+    tracked = resolve_tracked_changes(source, {"mode": "rev"}, tmp_path / "out")
+    topic = ET.fromstring("<concept id='c'><title>C</title><conbody><p>Torque to 15 <b>Nm.</b></p>"
+                          "<p>Unchanged.</p><ul><li><p>Added</p></li></ul><p>Last.</p></conbody></concept>")
+    ctx = DitaContext(ditamap_root=ET.fromstring("<map><topicref href='topics/c.dita'/></map>"),
+                      topics={"c.dita": topic})
+    assert record_tracked_changes(ctx, tracked, {"mode": "rev"}) == 2
+    assert [p.get("rev") for p in topic.iter("p")] == ["2026-03-04", None, "2026-03-05", None]
+    assert topic.find(".//li").get("rev") is None
+
+    ctx.metadata["revision_number"] = "7"
+    assert record_tracked_changes(ctx, tracked, {"rev": "B"}) == 2 and topic.find(".//p").get("rev") == "B"
+    assert any(e.category == "track_changes" and "1 deletion(s)" in e.message for e in ctx.report.entries)
+
+
+def test_rev_mode_keeps_a_change_history_for_the_bookmap(tmp_path, docx_factory):
+    source = docx_factory(tmp_path / "spec.docx", BODY, parts=STYLES)
+    tracked = resolve_tracked_changes(source, {"mode": "rev"}, tmp_path / "out")
+    topic = ET.fromstring("<concept id='c'><title>C</title><conbody><p>Torque to 15 <b>Nm.</b></p></conbody></concept>")
+    ctx = DitaContext(ditamap_root=ET.fromstring("<map><title>Spec</title><topicref href='topics/c.dita'/></map>"),
+                      topics={"c.dita": topic})
+    record_tracked_changes(ctx, tracked, {"mode": "rev"})
+    assert [(e["date"], e["insertions"], e["deletions"], e["rev"]) for e in ctx.metadata["change_history"]] == [
+        ("2026-03-02", 0, 1, "2026-03-02"), ("2026-03-04", 1, 0, "2026-03-04"), ("2026-03-05", 1, 0, "2026-03-05")]
+
+    book = to_bookmap(ctx.ditamap_root, dict(ctx.metadata, manual_code="SPEC-1"))
+    bookmeta = book.find("bookmeta")
+    assert [el.tag for el in bookmeta] == ["bookid", "bookchangehistory"]
+    edited = bookmeta.findall("bookchangehistory/edited")
+    assert len(edited) == 3 and edited[0].findtext("person") == "A"
+    assert edited[0].findtext("summary") == "0 insertion(s), 1 deletion(s)"
+    assert [el.text for el in edited[1].find("completed")] == ["2026", "03", "04"]
+
+    quiet = DitaContext(ditamap_root=ET.fromstring("<map/>"), topics={"c.dita": topic})
+    record_tracked_changes(quiet, tracked, {"mode": "rev", "history": False})
+    assert "change_history" not in quiet.metadata
+
+
+def test_preview_highlights_revised_elements_on_request():
+    topic = ET.fromstring("<concept id='c' rev='2'><title>C</title><conbody><p rev='2'>New <ph rev='2'>x</ph></p>"
+                          "<ul><li rev='2'>Item</li></ul></conbody></concept>")
+    assert _highlight_revisions(topic) == 4
+    body = topic.find("conbody")
+    assert body[0].tag == "div" and body[0].get("title") == "rev 2"
+    assert body[0].find("div/p/span/ph") is not None and body[0].find("ul/li/div").text == "Item"
+
+    calls = []
+    ctrl = types.SimpleNamespace(render_html_preview_for_node=lambda node, **kw: calls.append(kw) or
+                                 types.SimpleNamespace(success=True, content="<html/>"))
+    for revisions in (True, False):
+        panel = types.SimpleNamespace(get_mode=lambda: "html", highlights_revisions=lambda r=revisions: r,
+                                      set_loading=lambda _v: None, set_title=lambda _t: None,
+                                      set_content=lambda _c: None, show_error=lambda _m: None)
+        PreviewCoordinator(controller_getter=lambda: ctrl, panel=panel, schedule_ui=lambda *a: None,
+                           run_in_thread=lambda work, done: done(work())).render_for_node(ET.Element("topichead"))
+    assert calls == [{"highlight_revisions": True}, {}]
@@ -0,0 +1,71 @@
+import ast
+import string
+from pathlib import Path
+
+import pytest
+import yaml
+
+import orlando_toolkit
+from orlando_toolkit.core.plugins.ui_registry import UIRegistry
+from orlando_toolkit.ui.i18n import available_languages, language_name, set_language, tr
+
+PACKAGE = Path(orlando_toolkit.__file__).parent
+
+
+@pytest.fixture(autouse=True)
+def _english():
+    set_language("en")
+    yield
+    set_language("en")
+
+
+def _tr_literals():
+    for path in PACKAGE.rglob("*.py"):
+        for node in ast.walk(ast.parse(path.read_text(encoding="utf-8"))):
+            if (isinstance(node, ast.Call) and node.args and isinstance(node.args[0], ast.Constant)
+                    and (getattr(node.func, "id", None) == "tr" or getattr(node.func, "attr", None) == "tr")):
+                yield path.relative_to(PACKAGE), node.args[0].value
+
+
+def _placeholders(text):
+    return {field for _literal, field, _spec, _conv in string.Formatter().parse(text) if field}
+
+
+def test_strings_follow_the_selected_language_and_fall_back_to_english():
+    assert available_languages()[:1] == ["en"] and "fr" in available_languages()
+    assert language_name("fr") == "Français"
+    assert tr("Archive written to\n{path}", path="a.zip") == "Archive written to\na.zip"
+
+    assert set_language("fr_FR.UTF-8") == "fr"
+    assert tr("Save Project") == "Enregistrer le projet"
+    assert tr("Archive written to\n{path}", path="a.zip") == "Archive écrite dans\na.zip"
+    assert tr("Not in any catalog {n}", n=2) == "Not in any catalog 2"
+    assert set_language("xx") == "en" and tr("Save Project") == "Save Project"
+
+
+def test_plugin_translations_come_and_go_with_the_plugin(tmp_path):
+    (tmp_path / "de.yml").write_text("name: Deutsch\nstrings:\n  'Import Fixtures…': 'Vorrichtungen importieren…'\n"
+                                     "  'Save Project': 'Projekt speichern'\n", encoding="utf-8")
+    registry = UIRegistry()
+    assert registry.load_translation_files("fixtures", tmp_path) == ["de"]
+    registry.register_translations("fixtures", "fr", {"Import Fixtures…": "Importer les gabarits…",
+                                                      "Save Project": "Sauver"})
+    assert "de" in available_languages() and language_name("de") == "Deutsch"
+
+    set_language("de")
+    assert tr("Import Fixtures…") == "Vorrichtungen importieren…"
+    set_language("fr")
+    assert tr("Import Fixtures…") == "Importer les gabarits…"
+    assert tr("Save Project") == "Enregistrer le projet"  # the app's catalog wins
+
+    registry.cleanup_plugin_components("fixtures")
+    assert tr("Import Fixtures…") == "Import Fixtures…"
+    assert "de" not in available_languages()
+
+
+def test_every_interface_string_has_a_french_translation():
+    catalog = yaml.safe_load((PACKAGE / "ui" / "locales" / "fr.yml").read_text(encoding="utf-8"))["strings"]
+    missing = sorted({f"{path}: {text!r}" for path, text in _tr_literals() if text not in catalog})
+    assert not missing, "\n".join(missing)
+    for english, french in catalog.items():
+        assert _placeholders(english) == _placeholders(french), english
@@ -0,0 +1,49 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.services.structure_editing_service import StructureEditingService
+from orlando_toolkit.core.services.undo_service import UndoService
+from orlando_toolkit.ui.controllers.structure_controller import StructureController
+
+
+def _controller():
+    topics = {f"{n}.dita": ET.fromstring(f"<concept id='{n}'><title>{n.upper()}</title><conbody/></concept>")
+              for n in ("a", "b", "c")}
+    root = ET.fromstring("<map>" + "".join(f"<topicref href='topics/{n}'/>" for n in topics) + "</map>")
+    ctx = DitaContext(ditamap_root=root, topics=topics, metadata={})
+    ctrl = StructureController(ctx, StructureEditingService(), UndoService(), None)
+    ctrl.start_history()
+    return ctrl
+
+
+def _hrefs(ctrl):
+    return [t.get("href").rsplit("/", 1)[-1] for t in ctrl.context.ditamap_root.findall("topicref")]
+
+
+def test_each_undo_reverts_exactly_one_operation():
+    ctrl = _controller()
+    ctrl.selected_items = ["topics/a.dita"]
+    assert ctrl.handle_move_operation("down").success
+    assert ctrl.handle_move_operation("down").success
+    assert _hrefs(ctrl) == ["b.dita", "c.dita", "a.dita"]
+    assert [e["label"] for e in ctrl.get_journal()] == ["Move topic", "Move topic"]
+
+    assert ctrl.undo() and _hrefs(ctrl) == ["b.dita", "a.dita", "c.dita"]
+    assert ctrl.undo() and _hrefs(ctrl) == ["a.dita", "b.dita", "c.dita"]
+    assert not ctrl.can_undo() and ctrl.redo_label() == "Move topic"
+    assert ctrl.redo() and _hrefs(ctrl) == ["b.dita", "a.dita", "c.dita"]
+    assert [e["undone"] for e in ctrl.get_journal()] == [False, True]
+
+
+def test_style_toggles_are_journaled_and_restored():
+    ctrl = _controller()
+    ctrl.handle_style_visibility_toggle("Heading1", True)
+    ctrl.handle_filter_toggle("Heading2", False)
+    ctrl.handle_style_visibility_toggle("Heading1", True)  # no change, no entry
+    assert ctrl.undo_label() == "Exclude style Heading2"
+    assert len(ctrl.get_journal()) == 2
+
+    assert ctrl.undo() and ctrl.filter_exclusions == {} and ctrl.style_visibility == {"Heading1": True}
+    assert ctrl.undo() and ctrl.style_visibility == {}
+    assert ctrl.redo() and ctrl.redo() and ctrl.filter_exclusions == {"Heading2": True}
+    assert _hrefs(ctrl) == ["a.dita", "b.dita", "c.dita"]
@@ -0,0 +1,49 @@
+import json
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.errors import ToolkitError
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.usage_stats import UsageStats
+
+
+def _context(topics=3):
+    ctx = DitaContext(
+        topics={f"t{i}.dita": ET.fromstring(f"<topic id='t{i}'><title>T</title></topic>") for i in range(topics)},
+        metadata={"manual_title": "Secret Project"},
+        plugin_data={"_source_plugin": "docx"},
+    )
+    ctx.report.warning("structure", "Heading inside table in Secret Project", topic="t0.dita")
+    ctx.report.record_timing("bidi", 0.5)
+    return ctx
+
+
+def test_counters_aggregate_without_identifying_data(tmp_path):
+    stats = UsageStats(tmp_path / "usage.json", enabled=True)
+    stats.record_conversion(_context(), source_size=2048)
+    stats.record_conversion(_context(topics=60), source_size=5 * 1024 * 1024)
+    stats.record_failure(ToolkitError("archive too large", code="OTK201"))
+
+    data = stats.snapshot()
+    assert data["conversions"] == 2
+    assert data["plugins"] == {"docx": 2}
+    assert data["source_size"] == {"<100KB": 1, "1-10MB": 1}
+    assert data["topics"] == {"1-10": 1, "51-200": 1}
+    assert data["stages"]["bidi"] == {"count": 2, "total_seconds": 1.0, "max_seconds": 0.5}
+    assert data["report"] == {"warning:structure": 2}
+    assert data["failures"] == {"OTK201": 1}
+    raw = (tmp_path / "usage.json").read_text(encoding="utf-8")
+    assert "Secret" not in raw and "t0.dita" not in raw
+
+    exported = stats.export(tmp_path / "export.json")
+    assert json.loads(exported.read_text(encoding="utf-8"))["conversions"] == 2
+    stats.reset()
+    assert stats.snapshot()["conversions"] == 0
+
+
+def test_nothing_is_recorded_unless_enabled(tmp_path):
+    stats = UsageStats(tmp_path / "usage.json")
+    stats.record_conversion(_context(), source_size=10)
+    stats.record_failure(RuntimeError("boom"))
+    assert not (tmp_path / "usage.json").exists()
+    assert UsageStats.from_mapping({"enabled": True, "path": str(tmp_path / "x.json")}).enabled
@@ -0,0 +1,71 @@
+import pytest
+from lxml import etree as ET
+
+from orlando_toolkit.core.errors import describe_error
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.services import ConversionService
+from orlando_toolkit.core.services.publishing_service import PublishingService
+from orlando_toolkit.core.services.validation_service import (
+    PackageValidationError,
+    ValidationService,
+    ValidationSettings,
+)
+
+
+@pytest.fixture(autouse=True)
+def _no_dita_ot(monkeypatch):
+    monkeypatch.setattr(PublishingService, "locate", lambda self: None)
+
+
+def _context(**extra_topics):
+    topics = {
+        "good.dita": "<concept id='good'><title>Good</title><conbody><p id='p1'>Fine.</p></conbody></concept>",
+        **extra_topics,
+    }
+    hrefs = "".join(f"<topicref href='topics/{n}'/>" for n in topics)
+    root = ET.fromstring(f"<map><title>Manual</title>{hrefs}<topicref href='topics/gone.dita' data-level='1'/></map>")
+    return DitaContext(ditamap_root=root, topics={k: ET.fromstring(v) for k, v in topics.items()}, metadata={})
+
+
+def test_builtin_checks_report_issues_per_topic():
+    ctx = _context(**{
+        "bad.dita": "<concept id='1bad'><conbody><p id='x'/><p id='x'/></conbody><body/></concept>",
+        "hint.dita": "<task id='t'><title>T</title><taskbody data-style='Heading 1'/></task>",
+    })
+    report = ValidationService(ValidationSettings()).validate(ctx)
+    assert report.grammar == "built-in checks" and not report.ok
+    by_topic = {}
+    for issue in report.issues:
+        by_topic.setdefault(issue.topic, []).append(issue.message)
+    assert "good.dita" not in by_topic
+    assert by_topic["bad.dita"] == [
+        "<concept> id '1bad' is not a valid XML name",
+        "<concept> must start with <title>",
+        "<body> is not allowed in <concept> (expected <conbody>)",
+        "Duplicate id 'x'",
+    ]
+    assert by_topic["hint.dita"] == ["Attribute data-style on <taskbody> is not valid DITA"]
+    assert by_topic[None] == ["Attribute data-level on <topicref> is not valid DITA",
+                              "topicref points to a missing topic: topics/gone.dita"]
+
+    # Helper attributes dropped at packaging are ignored when validating edited content
+    stripped = ValidationService(ValidationSettings()).validate(ctx, strip_hints=True)
+    assert not any("data-level" in issue.message for issue in stripped.issues)
+    assert ctx.ditamap_root.find("topicref[@href='topics/gone.dita']").get("data-level") == "1"
+
+
+def test_packaging_blocked_only_when_configured(tmp_path, monkeypatch):
+    ctx = _context()
+    out = tmp_path / "out.zip"
+    monkeypatch.setattr(ValidationSettings, "from_config", classmethod(lambda cls: cls(block_on_errors=True)))
+    with pytest.raises(PackageValidationError) as exc_info:
+        ConversionService().write_package(ctx, out)
+    assert not out.exists()
+    assert [issue.message for issue in exc_info.value.issues] == [
+        "Attribute data-level on <topicref> is not valid DITA",
+        "topicref points to a missing topic: topics/gone.dita",
+    ]
+    assert describe_error(exc_info.value).code == "OTK420"
+
+    monkeypatch.setattr(ValidationSettings, "from_config", classmethod(lambda cls: cls()))
+    ValidationService().check_package(ctx)
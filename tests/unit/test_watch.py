@@ -0,0 +1,94 @@
+import json
+import zipfile
+
+from orlando_toolkit.api import ConversionError, Toolkit
+from orlando_toolkit.watch import EmailSettings, FolderWatcher, WatchFolder, WatchSettings, summary_text
+
+
+class _Toolkit:
+    """Markdown toolkit whose conversions of ``broken*`` files fail."""
+
+    def __init__(self):
+        self.real = Toolkit(plugins=False)
+        self.service = self.real.service
+
+    def convert(self, source, *options, **kwargs):
+        if source.name.startswith("broken"):
+            raise ConversionError(f"Could not convert {source.name}: boom", ValueError("boom"))
+        return self.real.convert(source, *options, **kwargs)
+
+
+class _Clock:
+    def __init__(self):
+        self.now = 4_000_000_000.0
+
+    def __call__(self):
+        return self.now
+
+
+def _watcher(tmp_path, clock, mails, **overrides):
+    folder = WatchFolder(str(tmp_path / "drop"), output=str(tmp_path / "packages"))
+    settings = WatchSettings(folders=(folder,), settle_seconds=5, retries=1, retry_delay_seconds=60,
+                             report_path=str(tmp_path / "watch.jsonl"), **overrides)
+    return FolderWatcher(settings, toolkit=_Toolkit(), send_mail=mails.append, clock=clock)
+
+
+def test_new_documents_are_converted_and_filed(tmp_path):
+    drop = tmp_path / "drop"
+    drop.mkdir()
+    (drop / "manual.md").write_text("# Manual\n\n## Wiring\n\nConnect the cable.\n", encoding="utf-8")
+    (drop / "~$manual.md").write_text("lock", encoding="utf-8")
+    (drop / "notes.xyz").write_text("not a document", encoding="utf-8")
+    clock, mails = _Clock(), []
+    watcher = _watcher(tmp_path, clock, mails)
+
+    clock.now = (drop / "manual.md").stat().st_mtime + 1
+    assert watcher.scan() == []  # still settling
+
+    clock.now += 10
+    [result] = watcher.scan()
+    assert result.outcome == "converted" and result.package == tmp_path / "packages" / "manual.zip"
+    with zipfile.ZipFile(result.package) as zf:
+        assert any(name.endswith(".ditamap") for name in zf.namelist())
+    assert (drop / "processed" / "manual.md").is_file() and not (drop / "manual.md").exists()
+    assert (drop / "~$manual.md").exists() and (drop / "notes.xyz").exists()
+    assert watcher.scan() == []
+
+    record = json.loads((tmp_path / "watch.jsonl").read_text(encoding="utf-8").splitlines()[0])
+    assert record["outcome"] == "converted" and record["source"].endswith("manual.md")
+    assert mails == []  # no recipients configured
+
+
+def test_failures_are_retried_then_quarantined(tmp_path):
+    drop = tmp_path / "drop"
+    drop.mkdir()
+    (drop / "broken.md").write_text("# Broken\n", encoding="utf-8")
+    clock, mails = _Clock(), []
+    watcher = _watcher(tmp_path, clock, mails, email=EmailSettings(to=("docs@example.com",)))
+    clock.now = (drop / "broken.md").stat().st_mtime + 10
+
+    [first] = watcher.scan()
+    assert first.outcome == "retrying" and first.attempts == 1
+    assert watcher.scan() == []  # waits for the retry delay
+    clock.now += 61
+    [second] = watcher.scan()
+    assert second.outcome == "quarantined" and second.attempts == 2
+
+    quarantined = drop / "quarantine" / "broken.md"
+    assert quarantined.is_file() and not (drop / "broken.md").exists()
+    note = json.loads((drop / "quarantine" / "broken.md.error.json").read_text(encoding="utf-8"))
+    assert note["attempts"] == 2 and "boom" in note["error"]["message"]
+
+    assert [m["Subject"] for m in mails] == ["Orlando Toolkit: 0 converted, 0 failed",
+                                             "Orlando Toolkit: 0 converted, 1 failed"]
+    assert "FAILED broken.md (attempt 2)" in mails[1].get_content()
+    assert "1 quarantined" in summary_text([second])
+
+
+def test_settings_from_mapping():
+    settings = WatchSettings.from_mapping({"folders": ["/drop", {"path": "/share", "profile": "stable",
+                                                                 "output": "/out"}],
+                                           "retries": 3, "email": {"to": "a@example.com", "from": "otk@x"}})
+    assert settings.folders[0] == WatchFolder("/drop")
+    assert settings.folders[1] == WatchFolder("/share", "stable", "/out")
+    assert settings.retries == 3 and settings.email.to == ("a@example.com",) and settings.email.sender == "otk@x"
@@ -0,0 +1,110 @@
+import zipfile
+from datetime import datetime
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.word_fields import format_word_date, record_word_fields, resolve_word_fields
+
+W = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+
+
+def _field(instruction, cached, rpr=""):
+    return (f'<w:r><w:fldChar w:fldCharType="begin"/></w:r><w:r><w:instrText>{instruction}</w:instrText></w:r>'
+            f'<w:r><w:fldChar w:fldCharType="separate"/></w:r><w:r>{rpr}<w:t>{cached}</w:t></w:r>'
+            '<w:r><w:fldChar w:fldCharType="end"/></w:r>')
+
+
+SEQ_ARABIC = _field("SEQ Figure \\* ARABIC", "7")
+SEQ_ROMAN = _field("SEQ Figure \\* ROMAN", "9")
+CREATED = ('<w:fldSimple w:instr=" CREATEDATE \\@ &quot;d MMMM yyyy&quot; ">'
+           '<w:r><w:t>1 May 2020</w:t></w:r></w:fldSimple>')
+BODY = (
+    '<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Installation</w:t></w:r></w:p>'
+    f'<w:p><w:r><w:t xml:space="preserve">Figure </w:t></w:r>{SEQ_ARABIC}'
+    '<w:r><w:t xml:space="preserve">: Front panel</w:t></w:r></w:p>'
+    f'<w:p><w:r><w:t xml:space="preserve">Figure </w:t></w:r>{SEQ_ROMAN}</w:p>'
+    f'<w:p><w:r><w:t xml:space="preserve">Chapter: </w:t></w:r>{_field("STYLEREF &quot;Heading 1&quot;", "Old")}</w:p>'
+    f'<w:p><w:r><w:t xml:space="preserve">Issued </w:t></w:r>{CREATED}'
+    f'<w:r><w:t xml:space="preserve">, page </w:t></w:r>{_field("PAGE", "3")}</w:p>'
+    '<w:p><w:r><w:t xml:space="preserve">Connect the </w:t></w:r>'
+    '<w:sdt><w:sdtPr><w:tag w:val="Product"/></w:sdtPr><w:sdtContent><w:r><w:t>X200</w:t></w:r></w:sdtContent></w:sdt>'
+    '<w:r><w:t xml:space="preserve"> cable.</w:t></w:r>'
+    '<w:sdt><w:sdtPr><w:showingPlcHdr/></w:sdtPr><w:sdtContent><w:r><w:t>Click here to enter text.</w:t></w:r>'
+    '</w:sdtContent></w:sdt></w:p>'
+    '<w:sdt><w:sdtPr><w:tag w:val="Warning"/></w:sdtPr><w:sdtContent>'
+    '<w:p><w:r><w:t>Disconnect power first.</w:t></w:r></w:p></w:sdtContent></w:sdt>'
+)
+STYLES = (f'<w:styles xmlns:w="{W}"><w:style w:styleId="Heading1"><w:name w:val="heading 1"/></w:style>'
+          '</w:styles>')
+CORE = ('<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" '
+        'xmlns:dcterms="http://purl.org/dc/terms/"><dcterms:created>2026-03-04T08:00:00Z</dcterms:created>'
+        '</cp:coreProperties>')
+PARTS = {"word/styles.xml": STYLES, "docProps/core.xml": CORE}
+CONTROLS = {"map": {"Product": "keyword", "Warning": {"element": "note", "type": "warning"}}}
+
+
+def _paragraphs(path):
+    with zipfile.ZipFile(path) as zf:
+        root = ET.fromstring(zf.read("word/document.xml"))
+    return ["".join(t.text or "" for t in p.iter(f"{{{W}}}t")) for p in root.iter(f"{{{W}}}p")], root
+
+
+def test_fields_become_literal_text_and_controls_are_unwrapped(tmp_path, docx_factory):
+    source = docx_factory(tmp_path / "spec.docx", BODY, parts=PARTS)
+    fields = resolve_word_fields(source, {}, CONTROLS, tmp_path / "out")
+    assert fields.path != source
+    assert fields.evaluated == {"CREATEDATE": 1, "SEQ": 2, "STYLEREF": 1} and fields.removed == 1
+    assert (fields.unwrapped, fields.placeholders) == (2, 1)
+    texts, root = _paragraphs(fields.path)
+    assert texts == ["Installation", "Figure 1: Front panel", "Figure II", "Chapter: Installation",
+                     "Issued 4 March 2026, page ", "Connect the X200 cable.", "Disconnect power first."]
+    assert root.find(f".//{{{W}}}fldChar") is None and root.find(f".//{{{W}}}sdt") is None
+    assert fields.captions == [("Figure 1: Front panel", "Figure"), ("Figure II", "Figure")]
+
+    assert format_word_date(datetime(2026, 3, 4, 15, 5), "dddd, MMM d 'at' h:mm am/pm") == \
+        "Wednesday, Mar 4 at 3:05 pm"
+    kept = resolve_word_fields(source, {"page_fields": "keep"}, {"enabled": False}, tmp_path / "keep")
+    assert "Issued 4 March 2026, page 3" in _paragraphs(kept.path)[0]
+    assert resolve_word_fields(source, {"enabled": False}, {"enabled": False}, tmp_path / "off").path == source
+
+
+def test_tagged_controls_map_to_configured_elements(tmp_path, docx_factory):
+    source = docx_factory(tmp_path / "spec.docx", BODY, parts=PARTS)
+    fields = resolve_word_fields(source, {}, CONTROLS, tmp_path / "out")
+    topic = ET.fromstring("<concept id='c'><title>Installation</title><conbody>"
+                          "<p>Figure 1: Front panel</p><p>Connect the X200 cable.</p><p>Disconnect power first.</p>"
+                          "</conbody></concept>")
+    root = ET.fromstring("<map><topicref href='topics/c.dita'/></map>")
+    ctx = DitaContext(ditamap_root=root, topics={"c.dita": topic})
+
+    assert record_word_fields(ctx, fields, CONTROLS) == 2
+    caption, first = topic.findall("conbody/p")
+    assert caption.get("data-caption") == "Figure" and first.get("data-caption") is None
+    assert first.text == "Connect the " and first.findtext("keyword") == "X200"
+    assert first.find("keyword").tail == " cable."
+    note = topic.find("conbody/note")
+    assert note.get("type") == "warning" and note.findtext("p") == "Disconnect power first."
+    entry = next(e for e in ctx.report.entries if e.category == "word_fields")
+    assert entry.detail["mapped"] == 2 and entry.detail["removed"] == 1
+
+
+def test_mergeformat_switches_keep_the_value_and_the_result_formatting(tmp_path, docx_factory):
+    chapter = _field("STYLEREF &quot;Heading 1&quot; \\* MERGEFORMAT", "Old")
+    figure = _field("SEQ Figure \\* MERGEFORMAT", "4", rpr="<w:rPr><w:b/></w:rPr>")
+    table = _field("SEQ Table \\* MERGEFORMAT \\* roman", "1")
+    issued = _field("CREATEDATE \\@ &quot;yyyy&quot; \\* MERGEFORMAT", "2020") + _field("PAGE \\* CHARFORMAT", "3")
+    body = (
+        '<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Safety</w:t></w:r></w:p>'
+        f'<w:p><w:r><w:t xml:space="preserve">Chapter: </w:t></w:r>{chapter}</w:p>'
+        f'<w:p><w:r><w:t xml:space="preserve">Figure </w:t></w:r>{figure}</w:p>'
+        f'<w:p><w:r><w:t xml:space="preserve">Table </w:t></w:r>{table}</w:p>'
+        f'<w:p><w:r><w:t xml:space="preserve">Issued </w:t></w:r>{issued}</w:p>'
+    )
+    source = docx_factory(tmp_path / "spec.docx", body, parts=PARTS)
+    fields = resolve_word_fields(source, {}, {"enabled": False}, tmp_path / "out")
+    assert fields.evaluated == {"CREATEDATE": 1, "SEQ": 2, "STYLEREF": 1} and fields.removed == 1
+    texts, root = _paragraphs(fields.path)
+    assert texts == ["Safety", "Chapter: Safety", "Figure 1", "Table i", "Issued 2026"]
+    number = next(r for r in root.iter(f"{{{W}}}r") if r.findtext(f"{{{W}}}t") == "1")
+    assert number.find(f"{{{W}}}rPr/{{{W}}}b") is not None
@@ -0,0 +1,96 @@
+from lxml import etree as ET
+
+from orlando_toolkit.core.image_naming import ImageNaming
+from orlando_toolkit.core.integrity import check_integrity
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.package_utils import update_image_references_and_names
+from orlando_toolkit.core.word_media import media_poster, restore_word_media
+
+NS = ('xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" '
+      'xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing" '
+      'xmlns:wp15="http://schemas.microsoft.com/office/word/2012/wordprocessingDrawing" '
+      'xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" '
+      'xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture" '
+      'xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" '
+      'xmlns:v="urn:schemas-microsoft-com:vml" xmlns:o="urn:schemas-microsoft-com:office:office"')
+
+
+def _picture(media, poster, title):
+    return (f'<w:r><w:drawing><wp:inline><wp:docPr id="1" name="Video" title="{title}"/><a:graphic><a:graphicData>'
+            f'<pic:pic><pic:nvPicPr><pic:cNvPr id="1" name="Video"/><pic:nvPr>{media}</pic:nvPr></pic:nvPicPr>'
+            f'<pic:blipFill><a:blip r:embed="{poster}"/></pic:blipFill></pic:pic>'
+            '</a:graphicData></a:graphic></wp:inline></w:drawing></w:r>')
+
+
+def _context(body):
+    topic = ET.fromstring(f"<concept id='c'><title>C</title><conbody>{body}</conbody></concept>")
+    return DitaContext(ditamap_root=ET.fromstring("<map><topicref href='topics/c.dita'/></map>"),
+                       topics={"c.dita": topic}, images={"image1.png": b"POSTER"}, metadata={})
+
+
+def test_clips_become_objects_with_posters(tmp_path, monkeypatch, docx_factory):
+    body = ('<w:p><w:r><w:t>Watch the clip.</w:t></w:r></w:p>'
+            '<w:p>' + _picture('<a:videoFile r:embed="rId2"/>', "rId1", "Changing the filter") + '</w:p>'
+            '<w:p><w:r><w:t>Listen to the pump.</w:t></w:r>'
+            + _picture('<a:audioFile r:link="rId3"/>', "rId4", "") + '</w:p>'
+            '<w:p>' + _picture('<a:videoFile r:link="rId5"/><a:extLst><a:ext><wp15:webVideoPr embeddedHtml='
+                               '"&lt;iframe src=&quot;//video.example.org/embed/42&quot;&gt;&lt;/iframe&gt;"/>'
+                               '</a:ext></a:extLst>', "rId4", "Tour") + '</w:p>')
+    rels = {"rId1": "media/image1.png", "rId2": "media/media1.mp4", "rId3": "file:///C:/Training/pump.mp3",
+            "rId4": "media/image2.jpeg", "rId5": "https://video.example.org/watch/42"}
+    path = docx_factory(tmp_path / "manual.docx", body, namespaces=NS, rels=rels,
+                        parts={"word/media/image1.png": b"POSTER", "word/media/media1.mp4": b"MP4",
+                               "word/media/image2.jpeg": b"J"})
+    (tmp_path / "pump.mp3").write_bytes(b"MP3")
+    ctx = _context("<p>Watch the clip.</p><fig><image href='../media/image1.png'/></fig><p>Listen to the pump.</p>")
+
+    assert restore_word_media(path, ctx) == 3
+    body = ctx.topics["c.dita"].find("conbody")
+    video = body.find("fig/object")
+    assert (video.get("data"), video.get("type"), video.get("outputclass")) == (
+        "../media/video_1.mp4", "video/mp4", "video")
+    assert video.findtext("desc") == "Changing the filter" and body.find("fig/image") is None
+    assert video.find("param").attrib == {"name": "poster", "value": "../media/image1.png", "valuetype": "ref"}
+    audio, online = body.findall("object")
+    assert [el.tag for el in body] == ["p", "fig", "p", "object", "object"]
+    assert (audio.get("data"), audio.get("type"), media_poster(ctx, "audio_2.mp3")) == (
+        "../media/audio_2.mp3", "audio/mpeg", "audio_2_poster.jpeg")
+    assert (online.get("data"), online.get("type")) == ("https://video.example.org/embed/42", "text/html")
+    assert ctx.videos == {"video_1.mp4": b"MP4", "audio_2.mp3": b"MP3"}
+    assert ctx.images == {"image1.png": b"POSTER", "audio_2_poster.jpeg": b"J"}
+    assert online.find("param").get("value") == "../media/audio_2_poster.jpeg"     # same picture, one copy
+    entry = next(e for e in ctx.report.entries if e.category == "media")
+    assert entry.detail == {"videos": 2, "audio": 1, "online": 1}
+    assert not [i for i in check_integrity(ctx) if i.kind == "unused_image"]
+
+    monkeypatch.setattr(ImageNaming, "from_config", classmethod(lambda cls: cls()))
+    ctx.metadata["image_naming"] = {"pattern": "fig_{name}{ext}"}
+    update_image_references_and_names(ctx)
+    assert media_poster(ctx, "video_1.mp4") == "fig_image1.png" and "fig_image1.png" in ctx.images
+
+
+def test_ole_clips_and_missing_links(tmp_path, docx_factory):
+    body = ('<w:p><w:r><w:t>Safety briefing.</w:t></w:r>'
+            '<w:r><w:object><v:shape alt="Briefing"><v:imagedata r:id="rId1"/></v:shape>'
+            '<o:OLEObject ProgID="Package" r:id="rId2"/></w:object></w:r></w:p>'
+            '<w:p><w:r><w:t>Old clip.</w:t></w:r>'
+            + _picture('<a:videoFile r:link="rId3"/>', "rId1", "") + '</w:p>'
+            '<w:p><w:r><w:object><o:OLEObject ProgID="Excel.Sheet.12" r:id="rId4"/></w:object></w:r></w:p>')
+    rels = {"rId1": "media/image1.emf", "rId2": "embeddings/briefing.wav", "rId3": "file:///C:/Clips/old.avi",
+            "rId4": "embeddings/sheet.xlsx"}
+    path = docx_factory(tmp_path / "manual.docx", body, namespaces=NS, rels=rels,
+                        parts={"word/media/image1.emf": b"EMF", "word/embeddings/briefing.wav": b"WAV",
+                               "word/embeddings/sheet.xlsx": b"X"})
+    ctx = _context("<p>Safety briefing.</p><p>Old clip.</p>")
+    assert restore_word_media(path, ctx, {"posters": False}) == 2
+    ole, linked = ctx.topics["c.dita"].find("conbody").findall("object")
+    assert (ole.get("data"), ole.get("outputclass"), ole.findtext("desc")) == ("../media/audio_1.wav", "audio",
+                                                                             "Briefing")
+    assert ole.find("param") is None and list(ctx.images) == ["image1.png"]
+    assert (linked.get("data"), linked.get("type")) == ("file:///C:/Clips/old.avi", "video/x-msvideo")
+    assert ctx.videos == {"audio_1.wav": b"WAV"}
+    warning = next(e for e in ctx.report.entries if e.category == "media" and e.severity == "warning")
+    assert warning.detail["numbers"] == [2]
+
+    ctx = _context("<p>Safety briefing.</p>")
+    assert restore_word_media(path, ctx, {"enabled": False}) == 0 and ctx.topics["c.dita"].find(".//object") is None
@@ -0,0 +1,149 @@
+import zipfile
+
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.word_numbering import (
+    read_word_lists,
+    record_word_headings,
+    resolve_word_headings,
+    restore_word_lists,
+)
+
+W = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+STYLES = (f'<w:styles xmlns:w="{W}"><w:style w:type="paragraph" w:styleId="Normal"><w:name w:val="Normal"/>'
+          '</w:style><w:style w:type="paragraph" w:styleId="Chapter"><w:name w:val="Chapter"/>'
+          '<w:pPr><w:outlineLvl w:val="0"/></w:pPr></w:style></w:styles>')
+NUMBERING = (f'<w:numbering xmlns:w="{W}">'
+             '<w:abstractNum w:abstractNumId="0"><w:lvl w:ilvl="0"><w:start w:val="1"/><w:numFmt w:val="decimal"/>'
+             '<w:lvlText w:val="%1."/></w:lvl><w:lvl w:ilvl="1"><w:numFmt w:val="decimal"/>'
+             '<w:lvlText w:val="%1.%2"/></w:lvl></w:abstractNum>'
+             '<w:abstractNum w:abstractNumId="1"><w:lvl w:ilvl="0"><w:numFmt w:val="decimal"/>'
+             '<w:lvlText w:val="%1."/></w:lvl><w:lvl w:ilvl="1"><w:numFmt w:val="bullet"/><w:lvlText w:val="o"/>'
+             '</w:lvl></w:abstractNum>'
+             '<w:abstractNum w:abstractNumId="2"><w:lvl w:ilvl="0"><w:numFmt w:val="lowerLetter"/>'
+             '<w:lvlText w:val="%1)"/></w:lvl></w:abstractNum>'
+             '<w:num w:numId="1"><w:abstractNumId w:val="0"/></w:num>'
+             '<w:num w:numId="2"><w:abstractNumId w:val="1"/></w:num>'
+             '<w:num w:numId="3"><w:abstractNumId w:val="1"/><w:lvlOverride w:ilvl="0"><w:startOverride w:val="1"/>'
+             '</w:lvlOverride></w:num>'
+             '<w:num w:numId="4"><w:abstractNumId w:val="2"/></w:num></w:numbering>')
+# Level 1 of abstract 5 never restarts; level 1 of abstract 6 restarts with each level 0 item
+RESTARTS = (f'<w:numbering xmlns:w="{W}">'
+            '<w:abstractNum w:abstractNumId="5"><w:lvl w:ilvl="0"><w:start w:val="5"/><w:numFmt w:val="decimal"/>'
+            '<w:lvlText w:val="%1."/></w:lvl><w:lvl w:ilvl="1"><w:numFmt w:val="decimal"/>'
+            '<w:lvlText w:val="%2)"/><w:lvlRestart w:val="0"/></w:lvl></w:abstractNum>'
+            '<w:abstractNum w:abstractNumId="6"><w:lvl w:ilvl="0"><w:numFmt w:val="decimal"/>'
+            '<w:lvlText w:val="%1."/></w:lvl><w:lvl w:ilvl="1"><w:numFmt w:val="lowerLetter"/>'
+            '<w:lvlText w:val="%2)"/></w:lvl></w:abstractNum>'
+            '<w:num w:numId="10"><w:abstractNumId w:val="5"/></w:num>'
+            '<w:num w:numId="11"><w:abstractNumId w:val="6"/></w:num>'
+            '<w:num w:numId="12"><w:abstractNumId w:val="6"/><w:lvlOverride w:ilvl="0">'
+            '<w:startOverride w:val="1"/></w:lvlOverride></w:num></w:numbering>')
+
+
+def _p(text, num=None, ilvl=0, style=None, outline=None):
+    ppr = f'<w:pStyle w:val="{style}"/>' if style else ""
+    if num is not None:
+        ppr += f'<w:numPr><w:ilvl w:val="{ilvl}"/><w:numId w:val="{num}"/></w:numPr>'
+    if outline is not None:
+        ppr += f'<w:outlineLvl w:val="{outline}"/>'
+    return f'<w:p><w:pPr>{ppr}</w:pPr><w:r><w:t>{text}</w:t></w:r></w:p>'
+
+
+PARTS = {"word/styles.xml": STYLES, "word/numbering.xml": NUMBERING}
+
+
+def test_outline_numbering_and_levels_become_heading_styles(tmp_path, docx_factory):
+    long_text = " ".join(["word"] * 20)
+    source = docx_factory(tmp_path / "manual.docx",
+                          _p("Introduction", num=1) + _p("Scope of work", num=1, ilvl=1) + _p(long_text, num=1, ilvl=1)
+                          + _p("Wiring details", outline=2) + _p("Annex", style="Chapter")
+                          + _p("Open the cover", num=2), parts=PARTS)
+    headings = resolve_word_headings(source, {}, tmp_path / "work", style_map={"Chapter": 1})
+    assert headings.promoted == [(1, "Introduction", "numbering"), (2, "Scope of work", "numbering"),
+                                 (3, "Wiring details", "outline_level")]
+    assert headings.path != source and headings.added_styles == ["Heading1", "Heading2", "Heading3"]
+
+    with zipfile.ZipFile(headings.path) as zf:
+        document = ET.fromstring(zf.read("word/document.xml"))
+        styles = ET.fromstring(zf.read("word/styles.xml"))
+    values = [p.find(f"{{{W}}}pPr/{{{W}}}pStyle") for p in document.iter(f"{{{W}}}p")]
+    assert [v.get(f"{{{W}}}val") if v is not None else None for v in values] == [
+        "Heading1", "Heading2", None, "Heading3", "Chapter", None]
+    names = [n.get(f"{{{W}}}val") for n in styles.iter(f"{{{W}}}name")]
+    assert names[-3:] == ["heading 1", "heading 2", "heading 3"]
+
+    ctx = DitaContext(ditamap_root=ET.fromstring("<map/>"), topics={})
+    assert record_word_headings(ctx, headings) == 3
+    entry = next(e for e in ctx.report.entries if e.category == "headings")
+    assert entry.detail["numbering"] == 2 and entry.detail["outline_levels"] == 1
+
+    untouched = resolve_word_headings(source, {"numbering": False, "outline_levels": False}, tmp_path / "off")
+    assert untouched.path == source and not untouched
+
+
+def test_lists_are_rebuilt_with_nesting_restarts_and_formats(tmp_path, docx_factory):
+    source = docx_factory(tmp_path / "manual.docx",
+                          _p("Open the cover", num=2) + _p("Check the seal", num=2, ilvl=1)
+                          + _p("Check the fuse", num=2, ilvl=1) + _p("Close the cover", num=2) + _p("Wait a minute.")
+                          + _p("Start the pump", num=2)
+                          + _p("Prime the line", num=3) + _p("Option one", num=4), parts=PARTS)
+    topic = ET.fromstring("<task id='t'><title>T</title><taskbody><context>"
+                          "<ol><li>Open the cover</li><li>Check the seal</li><li>Check the fuse</li>"
+                          "<li>Close the cover</li></ol><p>Wait a minute.</p>"
+                          "<ol id='second'><li>Start the pump</li><li>Prime the line</li><li>Option one</li></ol>"
+                          "</context></taskbody></task>")
+    ctx = DitaContext(ditamap_root=ET.fromstring("<map><topicref href='topics/t.dita'/></map>"),
+                      topics={"t.dita": topic})
+    assert restore_word_lists(source, ctx) == 2
+
+    context = topic.find("taskbody/context")
+    assert [(el.tag, el.get("outputclass")) for el in context] == [
+        ("ol", None), ("p", None), ("ol", "start-3"), ("ol", None), ("ol", "lower-alpha")]
+    first = context[0]
+    assert [li.text for li in first] == ["Open the cover", "Close the cover"]
+    nested = first[0].find("ul")
+    assert nested.get("outputclass") == "bullet-circle" and [li.text for li in nested] == [
+        "Check the seal", "Check the fuse"]
+    assert context[2].get("id") == "second" and [li.text for li in context[3]] == ["Prime the line"]
+    entry = next(e for e in ctx.report.entries if e.category == "lists")
+    assert entry.detail == {"lists": 2, "restarts": 1, "custom_bullets": 1, "mixed": 1}
+
+    assert restore_word_lists(source, ctx, {"enabled": False}) == 0
+
+
+def test_numbering_continues_restarts_and_starts_over(tmp_path, docx_factory):
+    source = docx_factory(tmp_path / "manual.docx",
+                          _p("Drain", num=10) + _p("Open the tap", num=10, ilvl=1) + _p("Flush", num=10)
+                          + _p("Close the tap", num=10, ilvl=1) + _p("Interruption.")
+                          + _p("Fill", num=11) + _p("Check the level", num=11, ilvl=1) + _p("Seal", num=11)
+                          + _p("Check the cap", num=11, ilvl=1) + _p("Start", num=12) + _p("Listen", num=12)
+                          + _p("Break.") + _p("Stop", num=12), parts={**PARTS, "word/numbering.xml": RESTARTS})
+    assert [[(i.text, i.level, i.number, i.restart) for i in lst.items] for lst in read_word_lists(source)] == [
+        [("Drain", 0, 5, False), ("Open the tap", 1, 1, False), ("Flush", 0, 6, False),
+         ("Close the tap", 1, 2, False)],
+        [("Fill", 0, 1, False), ("Check the level", 1, 1, False), ("Seal", 0, 2, False),
+         ("Check the cap", 1, 1, False), ("Start", 0, 1, True), ("Listen", 0, 2, False)],
+        [("Stop", 0, 3, False)]]
+
+    topic = ET.fromstring("<concept id='c'><title>C</title><conbody>"
+                          "<ol><li>Drain</li><li>Open the tap</li><li>Flush</li><li>Close the tap</li></ol>"
+                          "<p>Interruption.</p><ol><li>Fill</li><li>Check the level</li><li>Seal</li>"
+                          "<li>Check the cap</li><li>Start</li><li>Listen</li></ol>"
+                          "<p>Break.</p><ol><li>Stop</li></ol></conbody></concept>")
+    ctx = DitaContext(ditamap_root=ET.fromstring("<map><topicref href='topics/c.dita'/></map>"),
+                      topics={"c.dita": topic})
+    assert restore_word_lists(source, ctx) == 3
+
+    body = topic.find("conbody")
+    assert [(el.tag, el.get("outputclass")) for el in body] == [
+        ("ol", "start-5"), ("p", None), ("ol", None), ("ol", None), ("p", None), ("ol", "start-3")]
+    drain, flush = body[0]
+    assert drain.find("ol").get("outputclass") is None and flush.find("ol").get("outputclass") == "start-2"
+    fill, seal = body[2]
+    assert [(li.text, li.find("ol").get("outputclass")) for li in (fill, seal)] == [
+        ("Fill", "lower-alpha"), ("Seal", "lower-alpha")]
+    assert [li.text for li in body[3]] == ["Start", "Listen"]
+    entry = next(e for e in ctx.report.entries if e.category == "lists")
+    assert entry.detail == {"lists": 3, "restarts": 3, "custom_bullets": 0, "mixed": 0}
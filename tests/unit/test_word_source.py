@@ -0,0 +1,88 @@
+import zipfile
+from unittest.mock import Mock
+
+import pytest
+from lxml import etree as ET
+
+from orlando_toolkit.core.active_content import ActiveContentPolicy, inspect_container
+from orlando_toolkit.core.cancellation import CancellationToken, OperationCancelledError
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.services import conversion_service
+from orlando_toolkit.core.services.conversion_service import ConversionService
+from orlando_toolkit.core.track_changes import resolve_tracked_changes
+from orlando_toolkit.core.word_fields import resolve_word_fields
+from orlando_toolkit.core.word_source import DOCUMENT, WordSource, w
+
+BODY = (
+    '<w:p><w:r><w:t xml:space="preserve">Torque to </w:t></w:r>'
+    '<w:del w:id="1" w:author="A"><w:r><w:delText>12</w:delText></w:r></w:del>'
+    '<w:ins w:id="2" w:author="A"><w:r><w:t>15</w:t></w:r></w:ins></w:p>'
+    '<w:p><w:r><w:t xml:space="preserve">Figure </w:t></w:r>'
+    '<w:r><w:fldChar w:fldCharType="begin"/></w:r><w:r><w:instrText>SEQ Figure \\* ARABIC</w:instrText></w:r>'
+    '<w:r><w:fldChar w:fldCharType="separate"/></w:r><w:r><w:t>7</w:t></w:r>'
+    '<w:r><w:fldChar w:fldCharType="end"/></w:r></w:p>'
+)
+RELS = {"rId1": "media/panel.png", "rId2": "https://example.com/"}
+PARTS = {"word/media/panel.png": b"png"}
+
+
+def _texts(path):
+    with zipfile.ZipFile(path) as zf:
+        root = ET.fromstring(zf.read("word/document.xml"))
+    return ["".join(t.text or "" for t in p.iter(w("t"))) for p in root.iter(w("p"))]
+
+
+def test_parts_are_parsed_once_and_shared(tmp_path, docx_factory):
+    docx = WordSource.of(docx_factory(tmp_path / "manual.docx", BODY, rels=RELS, parts=PARTS))
+    assert WordSource.of(docx) is docx and WordSource.of(tmp_path / "manual.docx") is not docx
+    assert docx.part(DOCUMENT) is docx.document and docx.body.getparent() is docx.document
+    assert ["".join(t.text for t in p.iter(w("t"))) for p in docx.paragraphs] == ["Torque to 15", "Figure 7"]
+    assert docx.relationships(DOCUMENT) == {"rId1": ("word/media/panel.png", False),
+                                            "rId2": ("https://example.com/", True)}
+    assert docx.read("word/media/panel.png") == b"png"
+    assert docx.part("word/comments.xml") is None and "word/comments.xml" not in docx
+    with pytest.raises(KeyError):
+        docx.read("word/comments.xml")
+
+    (tmp_path / "notes.txt").write_text("plain", encoding="utf-8")
+    assert WordSource.of(tmp_path / "notes.txt") is None
+
+
+def test_passes_edit_the_shared_source_and_it_is_written_once(tmp_path, docx_factory):
+    source = docx_factory(tmp_path / "manual.docx", BODY, rels=RELS, parts=PARTS)
+    docx = WordSource(source)
+    tracked = resolve_tracked_changes(docx, {})
+    fields = resolve_word_fields(docx, {}, {})
+    assert (tracked.insertions, tracked.deletions) == (1, 1) and fields.evaluated.get("SEQ") == 1
+    assert docx.changed and docx.path == source and not (tmp_path / "out").exists()
+
+    saved = docx.save(tmp_path / "out")
+    assert saved == tmp_path / "out" / "manual.docx" and docx.path == saved and not docx.changed
+    assert _texts(saved) == ["Torque to 15", "Figure 1"]
+    assert _texts(source) == ["Torque to 15", "Figure 7"]
+    with zipfile.ZipFile(saved) as zf:
+        assert zf.read("word/media/panel.png") == b"png"
+    assert docx.save(tmp_path / "again") == saved and not (tmp_path / "again").exists()
+
+
+def test_conversion_checks_for_cancellation_between_restore_passes(tmp_path, monkeypatch, docx_factory):
+    source = docx_factory(tmp_path / "manual.docx", BODY, rels=RELS, parts=PARTS)
+    token = CancellationToken()
+    seen = []
+
+    class Handler:
+        def convert_to_dita(self, file_path, metadata, progress_callback=None):
+            return DitaContext()
+
+    def first(path, context, options):
+        seen.append(path)
+        token.cancel()
+
+    monkeypatch.setattr(conversion_service, "_RESTORE_PASSES",
+                        ((first, "tables"), (lambda *args: seen.append("second"), "lists")))
+    registry = Mock()
+    registry.find_handler_for_file.return_value = Handler()
+    with pytest.raises(OperationCancelledError):
+        ConversionService(registry)._convert_with_plugin(source, source, inspect_container(source),
+                                                         ActiveContentPolicy.from_config(), {}, None, token, None)
+    assert len(seen) == 1 and isinstance(seen[0], WordSource) and seen[0].path == source
@@ -0,0 +1,156 @@
+import copy
+
+import pytest
+from lxml import etree as ET
+
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.xliff import XLIFF_NS, export_xliff, import_xliff, split_sentences
+
+X = f"{{{XLIFF_NS}}}"
+
+
+def _context():
+    topic = ET.fromstring(
+        "<concept id='c'><title>Pump <b>care</b></title><conbody>"
+        "<p>Open the valve, e.g. the red one. Then press <uicontrol>Start</uicontrol>. "
+        "<codeph>x=1</codeph> is set.<image href='../media/a.png'/></p>"
+        "<ul><li>Item <p>Nested para.</p> tail</li></ul><codeblock>make all</codeblock></conbody></concept>")
+    root = ET.fromstring("<map><title>M</title><topicref href='topics/c.dita'>"
+                         "<topicmeta><navtitle>Pump</navtitle></topicmeta></topicref></map>")
+    return DitaContext(ditamap_root=root, topics={"c.dita": topic},
+                       metadata={"manual_title": "Pump manual", "language": "en-us"})
+
+
+def _units(path):
+    return {(f.get("original"), u.get("name")): u for f in ET.parse(str(path)).getroot().iter(X + "file")
+            for u in f.iter(X + "unit")}
+
+
+def _translate(path, out, upper=lambda text: text.upper()):
+    tree = ET.parse(str(path))
+    for seg in tree.getroot().iter(X + "segment"):
+        target = copy.deepcopy(seg.find(X + "source"))
+        target.tag = X + "target"
+        for el in target.iter():
+            el.text = upper(el.text) if el.text else el.text
+            if el is not target and el.tail:
+                el.tail = upper(el.tail)
+        seg.append(target)
+    with open(out, "wb") as handle:
+        handle.write(ET.tostring(tree.getroot()))
+    return out
+
+
+def test_export_segments_blocks_and_protects_inline_markup(tmp_path):
+    ctx = _context()
+    assert export_xliff(ctx, tmp_path / "pump.xlf", target_language="de_de") == 6
+    root = ET.parse(str(tmp_path / "pump.xlf")).getroot()
+    assert (root.get("version"), root.get("srcLang"), root.get("trgLang")) == ("2.1", "en-US", "de-DE")
+
+    units = _units(tmp_path / "pump.xlf")
+    assert sorted(units) == [("c.dita", "/concept/conbody/p"), ("c.dita", "/concept/conbody/ul/li"),
+                             ("c.dita", "/concept/conbody/ul/li/p"), ("c.dita", "/concept/title"),
+                             ("map", "/map/topicref/topicmeta/navtitle"), ("map", "metadata/manual_title")]
+    para = units[("c.dita", "/concept/conbody/p")]
+    sources = [s.find(X + "source") for s in para.iter(X + "segment")]
+    assert [s.text for s in sources] == ["Open the valve, e.g. the red one.", "Then press ", None]
+    assert [(c.tag[len(X):], c.get("id"), c.text) for c in sources[1]] == [("pc", "1", "Start")]
+    assert [(c.tag[len(X):], c.get("id")) for c in sources[2]] == [("ph", "2"), ("ph", "3")]
+    assert len(list(para.iter(X + "ignorable"))) == 2
+    data = {d.get("id"): d.text for d in para.iter(X + "data")}
+    assert data["d3"] == "<codeph>…</codeph>" and data["d4"] == '<image href="../media/a.png">'
+    nested = units[("c.dita", "/concept/conbody/ul/li")].find(f"{X}segment/{X}source")
+    assert nested.find(X + "ph").get("canDelete") == "no"
+
+
+def test_import_merges_translation_into_a_language_copy(tmp_path):
+    ctx = _context()
+    export_xliff(ctx, tmp_path / "pump.xlf", target_language="de-DE")
+    result = import_xliff(ctx, _translate(tmp_path / "pump.xlf", tmp_path / "de.xlf"))
+    topic = result.context.topics["c.dita"]
+    assert ET.tostring(topic.find("conbody/p"), encoding="unicode") == (
+        "<p>OPEN THE VALVE, E.G. THE RED ONE. THEN PRESS <uicontrol>START</uicontrol>. "
+        "<codeph>x=1</codeph> IS SET.<image href=\"../media/a.png\" /></p>")
+    assert "".join(topic.find("conbody/ul/li").itertext()) == "ITEM NESTED PARA. TAIL"
+    assert topic.findtext("conbody/codeblock") == "make all"
+    assert result.context.metadata["manual_title"] == "PUMP MANUAL" and result.language == "de-DE"
+    assert topic.get("{http://www.w3.org/XML/1998/namespace}lang") == "de-DE"
+    assert ctx.topics["c.dita"].findtext("title") == "Pump "  # the open document is untouched
+
+    # Edited after the export, one segment left untranslated, a nested block dropped by the translator
+    ctx.topics["c.dita"].find("title").text = "Pump maintenance "
+    tree = ET.parse(str(tmp_path / "de.xlf"))
+    for unit in tree.getroot().iter(X + "unit"):
+        if unit.get("name") == "/concept/conbody/ul/li":
+            target = unit.find(f"{X}segment/{X}target")
+            target.remove(target.find(X + "ph"))
+        if unit.get("name") == "/concept/conbody/p":
+            segment = unit.find(X + "segment")
+            segment.remove(segment.find(X + "target"))
+    with open(tmp_path / "partial.xlf", "wb") as handle:
+        handle.write(ET.tostring(tree.getroot()))
+    result = import_xliff(ctx, tmp_path / "partial.xlf")
+    topic = result.context.topics["c.dita"]
+    assert topic.find("title").text == "Pump maintenance " and result.skipped == ["c.dita#u1"]
+    assert topic.find("conbody/p").text == "Open the valve, e.g. the red one. THEN PRESS "
+    assert [c.tag for c in topic.find("conbody/ul/li")] == ["p"] and result.missing_codes == 1
+    assert result.untranslated == 1
+    assert [e.severity for e in result.context.report.entries] == ["info", "warning", "warning", "warning"]
+
+    assert split_sentences("See Fig. 3 now. No. 5 is next! Done") == ["See Fig. 3 now. ", "No. 5 is next! ", "Done"]
+
+
+def test_translator_can_move_inline_codes_but_not_change_them(tmp_path):
+    topic = ET.fromstring("<concept id='c'><title>Panel</title><conbody><p>Press <b>Start <i>now</i></b> or "
+                          "<i>Stop</i> <ph translate='no'>ACME</ph>.</p></conbody></concept>")
+    ctx = DitaContext(ditamap_root=ET.fromstring("<map><topicref href='topics/c.dita'/></map>"),
+                      topics={"c.dita": topic}, metadata={"language": "en-US"})
+    export_xliff(ctx, tmp_path / "panel.xlf", target_language="de-DE", segment=False)
+
+    tree = ET.parse(str(tmp_path / "panel.xlf"))
+    unit = next(u for u in tree.getroot().iter(X + "unit") if u.get("name") == "/concept/conbody/p")
+    source = unit.find(f"{X}segment/{X}source")
+    assert [(c.tag[len(X):], c.get("id")) for c in source.iter() if c is not source] == [
+        ("pc", "1"), ("pc", "2"), ("pc", "3"), ("ph", "4")]
+    assert source[2].text is None and "ACME" not in "".join(source.itertext())
+    data = {d.get("id"): d.text for d in unit.iter(X + "data")}
+    assert data[source[2].get("dataRef")] == '<ph translate="no">…</ph>'
+
+    # Codes reordered, an unknown code and a duplicate one added
+    unit.find(X + "segment").append(ET.fromstring(
+        f'<target xmlns="{XLIFF_NS}"><pc id="3">Halt</pc> oder <pc id="1">Start <pc id="2">jetzt</pc></pc> '
+        'drücken <ph id="4"/><pc id="9">x</pc><ph id="1"/>.</target>'))
+    tree.write(str(tmp_path / "de.xlf"))
+    result = import_xliff(ctx, tmp_path / "de.xlf")
+    assert ET.tostring(result.context.topics["c.dita"].find("conbody/p"), encoding="unicode") == (
+        '<p><i>Halt</i> oder <b>Start <i>jetzt</i></b> drücken <ph translate="no">ACME</ph>.</p>')
+    assert result.missing_codes == 0 and result.translated == 1 and not result.skipped
+
+
+def test_import_reports_units_matching_no_block_and_keeps_missing_units_untranslated(tmp_path):
+    ctx = _context()
+    export_xliff(ctx, tmp_path / "pump.xlf", target_language="de-DE")
+    tree = ET.parse(str(_translate(tmp_path / "pump.xlf", tmp_path / "de.xlf")))
+    topic_file = next(f for f in tree.getroot().iter(X + "file") if f.get("original") == "c.dita")
+    units = {u.get("name"): u for u in topic_file.iter(X + "unit")}
+    topic_file.remove(units["/concept/title"])
+    units["/concept/conbody/ul/li/p"].set("name", "/concept/conbody/codeblock")
+    gone = ET.SubElement(tree.getroot(), X + "file", id="f9", original="gone.dita")
+    gone.append(copy.deepcopy(units["/concept/conbody/p"]))
+    gone[0].set("id", "u1")
+    tree.write(str(tmp_path / "mismatched.xlf"))
+
+    result = import_xliff(ctx, tmp_path / "mismatched.xlf")
+    topic = result.context.topics["c.dita"]
+    assert result.unknown == ["c.dita#u4", "gone.dita#u1"] and result.units == 6
+    assert ET.tostring(topic.find("title"), encoding="unicode") == "<title>Pump <b>care</b></title>"
+    assert "".join(topic.find("conbody/ul/li").itertext()) == "ITEM Nested para. TAIL"
+    assert topic.findtext("conbody/codeblock") == "make all"
+    assert not result.skipped and result.missing_codes == 0
+    assert [(e.severity, e.message) for e in result.context.report.entries][1:] == [
+        ("warning", "2 unit(s) match no block of the document")]
+
+    (tmp_path / "old.xlf").write_text("<xliff xmlns='urn:oasis:names:tc:xliff:document:1.2' version='1.2'/>",
+                                      encoding="utf-8")
+    with pytest.raises(ValueError):
+        import_xliff(ctx, tmp_path / "old.xlf")
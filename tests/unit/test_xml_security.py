@@ -0,0 +1,48 @@
+import pytest
+
+from orlando_toolkit.core.xml_security import ParseAudit, XmlSecurityError, XmlSecurityPolicy, parse_bytes
+
+_XXE = b"""<?xml version="1.0"?>
+<!DOCTYPE concept [<!ENTITY secret SYSTEM "file:///etc/passwd">]>
+<concept id="t"><title>Secret</title></concept>"""
+
+_LAUGHS = b"""<?xml version="1.0"?>
+<!DOCTYPE lolz [
+  <!ENTITY lol "lollollollollollollollollollol">
+  <!ENTITY lol1 "&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;">
+  <!ENTITY lol2 "&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;">
+  <!ENTITY lol3 "&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;">
+  <!ENTITY lol4 "&lol3;&lol3;&lol3;&lol3;&lol3;&lol3;&lol3;&lol3;&lol3;&lol3;">
+]>
+<lolz>&lol4;</lolz>"""
+
+
+def test_external_entity_is_refused_and_audited():
+    audit = []
+    root = parse_bytes(_XXE, source="t.dita", policy=XmlSecurityPolicy(), audit=audit)
+    assert root.findtext("title") == "Secret"
+    [record] = audit
+    assert isinstance(record, ParseAudit)
+    assert record.refused == ["secret"] and record.external_entities == {"secret": "file:///etc/passwd"}
+
+
+def test_entity_expansion_limit_refuses_billion_laughs():
+    with pytest.raises(XmlSecurityError, match="expands beyond"):
+        parse_bytes(_LAUGHS, policy=XmlSecurityPolicy(max_entity_expansion=10_000))
+
+
+def test_recursive_entities_and_declaration_count_are_refused():
+    recursive = b'<!DOCTYPE x [<!ENTITY a "&b;"><!ENTITY b "&a;">]><x/>'
+    with pytest.raises(XmlSecurityError, match="recursive"):
+        parse_bytes(recursive, policy=XmlSecurityPolicy())
+    with pytest.raises(XmlSecurityError, match="exceed"):
+        parse_bytes(b'<!DOCTYPE x [<!ENTITY a "1"><!ENTITY b "2">]><x/>', policy=XmlSecurityPolicy(max_entities=1))
+
+
+def test_opt_in_is_explicit_and_plain_dita_is_not_audited():
+    policy = XmlSecurityPolicy.from_mapping({"allow_external_entities": True})
+    assert policy.allow_external_entities and not policy.allow_network
+    audit = []
+    doc = b'<?xml version="1.0"?><!DOCTYPE concept PUBLIC "-//OASIS//DTD DITA Concept//EN" "concept.dtd"><concept id="c"/>'
+    assert parse_bytes(doc, policy=XmlSecurityPolicy(), audit=audit).get("id") == "c"
+    assert audit == []
@@ -0,0 +1,77 @@
+import pytest
+from lxml import etree as ET
+
+from orlando_toolkit.core import external_tools
+from orlando_toolkit.core.external_tools import ToolExecutionError, ToolWorkspace
+from orlando_toolkit.core.models import DitaContext
+from orlando_toolkit.core.services.conversion_service import ConversionService
+from orlando_toolkit.core.xslt import XsltError, apply_stylesheets, inline_stylesheets
+
+_XSL2 = ("<xsl:stylesheet version='2.0' xmlns:xsl='http://www.w3.org/1999/XSL/Transform'>"
+         "<xsl:param name='brand'/></xsl:stylesheet>")
+
+
+class _FakeSaxon:
+    """Stands in for the saxon tool: stamps the root with the ``brand`` parameter."""
+
+    calls = []
+    fail_on = None
+
+    def workspace(self):
+        return ToolWorkspace()
+
+    def run(self, tool, args, *, workspace, check=False, **kwargs):
+        type(self).calls.append((tool, list(args)))
+        root = ET.fromstring((workspace.path / "input.xml").read_bytes())
+        if self.fail_on and root.get("id") == self.fail_on:
+            raise ToolExecutionError("XTDE0640: recursion")
+        root.set("outputclass", dict(a.split("=", 1) for a in args if "=" in a and ":" not in a)["brand"])
+        (workspace.path / "output.xml").write_bytes(ET.tostring(root))
+
+
+def _context(options):
+    topics = {"a.dita": ET.fromstring("<concept id='a'><title>A</title><conbody><p>a</p></conbody></concept>"),
+              "b.dita": ET.fromstring("<concept id='b'><title>B</title><conbody><p>b</p></conbody></concept>")}
+    root = ET.fromstring("<map><topicref href='topics/a.dita'/><topicref href='topics/b.dita'/></map>")
+    return DitaContext(ditamap_root=root, topics=topics, metadata={"conversion_options": {"xslt": options}})
+
+
+def _fake_saxon(monkeypatch, fail_on=None):
+    _FakeSaxon.calls, _FakeSaxon.fail_on = [], fail_on
+    monkeypatch.setattr(external_tools, "ToolExecutor", _FakeSaxon)
+
+
+def test_profile_stylesheets_run_on_topics_and_map_at_packaging(tmp_path, monkeypatch):
+    _fake_saxon(monkeypatch)
+    sheet = tmp_path / "brand.xsl"
+    sheet.write_text(_XSL2, encoding="utf-8")
+    ctx = _context({"stylesheets": [{"path": str(sheet), "map": True, "parameters": {"brand": "acme"}}]})
+
+    package = ConversionService().prepare_package(ctx)
+    assert [t.get("outputclass") for t in package.topics.values()] == ["acme", "acme"]
+    assert package.ditamap_root.get("outputclass") == "acme"
+    tool, args = _FakeSaxon.calls[0]
+    assert tool == "saxon" and args == ["-s:input.xml", "-xsl:stylesheet.xsl", "-o:output.xml", "brand=acme"]
+    entry = next(e for e in package.report.entries if e.category == "xslt")
+    assert entry.message == "Applied 1 stylesheet(s) to 3 file(s)"
+
+    inlined = inline_stylesheets({"xslt": {"stylesheets": [str(sheet)]}})
+    assert inlined["xslt"]["stylesheets"] == [{"name": "brand.xsl", "source": _XSL2}]
+
+
+def test_failures_are_reported_per_file_or_stop_packaging(monkeypatch):
+    _fake_saxon(monkeypatch, fail_on="b")
+    options = {"stylesheets": [{"source": _XSL2, "name": "corp.xsl", "parameters": {"brand": "x"}}]}
+    ctx = _context(options)
+    assert apply_stylesheets(ctx, options) == 1
+    assert ctx.topics["a.dita"].get("outputclass") == "x" and ctx.topics["b.dita"].get("outputclass") is None
+    error = next(e for e in ctx.report.entries if e.category == "xslt" and e.severity == "error")
+    assert error.topic == "b.dita" and error.detail["stylesheet"] == "corp.xsl"
+
+    broken = _context({"stylesheets": ["missing.xsl", {"source": "<xsl:stylesheet"}]})
+    assert apply_stylesheets(broken, broken.metadata["conversion_options"]["xslt"]) == 0
+    assert len([e for e in broken.report.entries if e.category == "xslt"]) == 2
+
+    with pytest.raises(XsltError) as info:
+        apply_stylesheets(_context(options), {**options, "on_error": "fail"})
+    assert info.value.code == "OTK430" and info.value.location.topic == "b.dita"